@@ -0,0 +1,144 @@
+package admin
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"famli/internal/apierror"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+// =============================================================================
+// ATIVIDADE DO PAINEL
+// =============================================================================
+// Os eventos relevantes da auditoria (cadastros, exclusões, emergências e
+// feedbacks) são gravados em audit_log pelo ActivitySink; a atividade junta
+// esses registros com alguns eventos de analytics. Assim a lista sobrevive a
+// reinícios e mostra o que aconteceu em todas as réplicas.
+
+// activityRangeLayout é o formato de from/to na query
+const activityRangeLayout = "2006-01-02"
+
+// ActivitySink grava em audit_log os eventos de auditoria da atividade
+// Só tentativas bem-sucedidas entram (ex: exclusão "success", não "initiated").
+func ActivitySink(store storage.SystemStore) security.AuditSink {
+	actions := make(map[string]bool, len(storage.ActivityAuditActions))
+	for _, action := range storage.ActivityAuditActions {
+		actions[action] = true
+	}
+
+	return func(event security.AuditEvent) {
+		if !actions[string(event.Type)] || event.Result != "success" {
+			return
+		}
+		entry := &storage.AuditEntry{
+			UserID:       event.UserID,
+			Action:       string(event.Type),
+			ResourceType: event.Resource,
+			IPAddress:    event.ClientIP,
+			Details:      event.Details,
+			CreatedAt:    event.Timestamp,
+		}
+		if err := store.RecordAudit(entry); err != nil {
+			log.Printf("[Admin] Erro ao gravar atividade %s: %v", event.Type, err)
+		}
+	}
+}
+
+// Activity retorna a atividade relevante do sistema, paginada
+//
+// Endpoint: GET /api/admin/activity
+//
+// Query params:
+//   - type: tipos separados por vírgula (signup, deletion, emergency,
+//     feedback, export, guide); vazio = todos
+//   - from, to: período (AAAA-MM-DD, inclusivo); vazio = sem limite
+//   - cursor, limit: paginação (next_cursor da página anterior; max 50)
+func (h *Handler) Activity(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	params := &storage.ActivityParams{}
+	for _, value := range strings.Split(query.Get("type"), ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		activityType := storage.ActivityType(value)
+		if !storage.IsValidActivityType(activityType) {
+			apierror.Write(w, r, http.StatusBadRequest, "admin.invalid_activity_type")
+			return
+		}
+		params.Types = append(params.Types, activityType)
+	}
+
+	if value := query.Get("from"); value != "" {
+		day, err := time.Parse(activityRangeLayout, value)
+		if err != nil {
+			apierror.Write(w, r, http.StatusBadRequest, "admin.invalid_range")
+			return
+		}
+		params.From = &day
+	}
+	if value := query.Get("to"); value != "" {
+		day, err := time.Parse(activityRangeLayout, value)
+		if err != nil {
+			apierror.Write(w, r, http.StatusBadRequest, "admin.invalid_range")
+			return
+		}
+		end := day.AddDate(0, 0, 1)
+		params.To = &end
+	}
+	if params.From != nil && params.To != nil && !params.From.Before(*params.To) {
+		apierror.Write(w, r, http.StatusBadRequest, "admin.invalid_range")
+		return
+	}
+
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	params.PaginationParams = storage.PaginationParams{Cursor: query.Get("cursor"), Limit: limit}
+
+	result, err := h.store.ListActivity(params)
+	if err != nil {
+		apierror.WriteStorage(w, r, err, apierror.StorageKeys{Invalid: "admin.invalid_cursor", Internal: "admin.action_failed"})
+		return
+	}
+
+	// Identificar o usuário (contas excluídas ficam só com o ID)
+	users := map[string]map[string]interface{}{}
+	activities := make([]map[string]interface{}, 0, len(result.Items))
+	for _, entry := range result.Items {
+		activity := map[string]interface{}{
+			"id":         entry.ID,
+			"type":       entry.Type,
+			"source":     entry.Source,
+			"created_at": entry.CreatedAt.Format(time.RFC3339),
+		}
+		if len(entry.Details) > 0 {
+			activity["details"] = entry.Details
+		}
+		if entry.UserID != "" {
+			activity["user_id"] = entry.UserID
+			user, seen := users[entry.UserID]
+			if !seen {
+				if found, ok := h.store.GetUserByID(entry.UserID); ok {
+					user = map[string]interface{}{"name": found.Name, "email": maskEmail(found.Email)}
+				}
+				users[entry.UserID] = user
+			}
+			if user != nil {
+				activity["user"] = user
+			}
+		}
+		activities = append(activities, activity)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"activities":  activities,
+		"total":       result.Total,
+		"has_more":    result.HasMore,
+		"next_cursor": result.NextCursor,
+	})
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+
+package admin
+
+// diskSpace não é suportado nesta plataforma
+func diskSpace(path string) (free, total uint64, err error) {
+	return 0, 0, errDiskUnsupported
+}
@@ -0,0 +1,14 @@
+//go:build linux || darwin
+
+package admin
+
+import "syscall"
+
+// diskSpace retorna o espaço livre (para usuários comuns) e o total, em bytes
+func diskSpace(path string) (free, total uint64, err error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), stat.Blocks * uint64(stat.Bsize), nil
+}
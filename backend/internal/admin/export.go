@@ -0,0 +1,276 @@
+package admin
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+// =============================================================================
+// EXPORTAÇÃO DE MÉTRICAS
+// =============================================================================
+// Números agregados do painel para planilhas, em CSV (padrão) ou JSON:
+// - GET /api/admin/export/analytics-daily - eventos e usuários ativos por dia (analyst)
+// - GET /api/admin/export/user-growth     - cadastros por dia e total de contas (analyst)
+// - GET /api/admin/export/feedbacks       - feedbacks recebidos (support)
+//
+// Query params: from, to (AAAA-MM-DD, inclusivo; padrão: últimos 30 dias,
+// máximo 366) e format (csv ou json). As linhas são escritas conforme são
+// lidas, sem montar o arquivo em memória. Emails saem mascarados e células
+// que começam com =, +, - ou @ ganham um apóstrofo (fórmulas em planilhas).
+
+// Limites do período exportado
+const (
+	defaultExportDays = 30
+	maxExportDays     = 366
+)
+
+// exportFlushRows é a cada quantas linhas o arquivo parcial é enviado
+const exportFlushRows = 100
+
+// ExportAnalyticsDaily exporta os eventos e usuários ativos por dia
+//
+// Endpoint: GET /api/admin/export/analytics-daily
+func (h *Handler) ExportAnalyticsDaily(w http.ResponseWriter, r *http.Request) {
+	from, to, format, ok := parseExportParams(w, r)
+	if !ok {
+		return
+	}
+	stats, err := h.store.ListDailyStats(from, to)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "admin.export_error")
+		return
+	}
+
+	stream := h.startExport(w, r, "analytics-daily", format, from, to, []string{"date", "events", "users"})
+	for _, day := range stats {
+		if err := stream.Row(day.Day.Format(activityRangeLayout), day.Events, day.Users); err != nil {
+			break
+		}
+	}
+	stream.Close()
+}
+
+// ExportUserGrowth exporta os cadastros por dia e o total acumulado de contas
+//
+// Endpoint: GET /api/admin/export/user-growth
+func (h *Handler) ExportUserGrowth(w http.ResponseWriter, r *http.Request) {
+	from, to, format, ok := parseExportParams(w, r)
+	if !ok {
+		return
+	}
+	growth, err := h.store.GetUserGrowth(from, to)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "admin.export_error")
+		return
+	}
+
+	stream := h.startExport(w, r, "user-growth", format, from, to, []string{"date", "new_users", "total_users"})
+	for _, day := range growth {
+		if err := stream.Row(day.Day.Format(activityRangeLayout), day.NewUsers, day.TotalUsers); err != nil {
+			break
+		}
+	}
+	stream.Close()
+}
+
+// ExportFeedbacks exporta os feedbacks recebidos no período, mais antigos primeiro
+//
+// Endpoint: GET /api/admin/export/feedbacks
+func (h *Handler) ExportFeedbacks(w http.ResponseWriter, r *http.Request) {
+	from, to, format, ok := parseExportParams(w, r)
+	if !ok {
+		return
+	}
+
+	columns := []string{"id", "created_at", "type", "status", "user_id", "user_email", "page", "message", "admin_note", "updated_at"}
+	stream := h.startExport(w, r, "feedbacks", format, from, to, columns)
+	err := h.store.EachFeedback(from, to, func(f *storage.Feedback) error {
+		email := ""
+		if f.UserEmail != "" {
+			email = maskEmail(f.UserEmail)
+		}
+		return stream.Row(f.ID, f.CreatedAt.UTC().Format(time.RFC3339), string(f.Type), f.Status, f.UserID,
+			email, f.Page, f.Message, f.AdminNote, f.UpdatedAt.UTC().Format(time.RFC3339))
+	})
+	if err != nil {
+		// O cabeçalho já foi enviado: o arquivo sai truncado
+		log.Printf("[Admin] Exportação de feedbacks interrompida: %v", err)
+	}
+	stream.Close()
+}
+
+// parseExportParams lê from/to e format da query; em caso de erro, já responde
+func parseExportParams(w http.ResponseWriter, r *http.Request) (time.Time, time.Time, string, bool) {
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		apierror.Write(w, r, http.StatusBadRequest, "admin.invalid_export_format")
+		return time.Time{}, time.Time{}, "", false
+	}
+
+	now := time.Now().UTC()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+	from := to.AddDate(0, 0, -defaultExportDays)
+	if value := query.Get("to"); value != "" {
+		day, err := time.Parse(activityRangeLayout, value)
+		if err != nil {
+			apierror.Write(w, r, http.StatusBadRequest, "admin.invalid_range")
+			return time.Time{}, time.Time{}, "", false
+		}
+		to = day.AddDate(0, 0, 1)
+		from = to.AddDate(0, 0, -defaultExportDays)
+	}
+	if value := query.Get("from"); value != "" {
+		day, err := time.Parse(activityRangeLayout, value)
+		if err != nil {
+			apierror.Write(w, r, http.StatusBadRequest, "admin.invalid_range")
+			return time.Time{}, time.Time{}, "", false
+		}
+		from = day
+	}
+
+	if !from.Before(to) || to.Sub(from) > maxExportDays*24*time.Hour {
+		apierror.Write(w, r, http.StatusBadRequest, "admin.invalid_range")
+		return time.Time{}, time.Time{}, "", false
+	}
+	return from, to, format, true
+}
+
+// startExport registra a exportação e envia os headers do arquivo
+func (h *Handler) startExport(w http.ResponseWriter, r *http.Request, name, format string, from, to time.Time, columns []string) *exportStream {
+	h.auditLogger.Log(security.AuditEvent{
+		Type:     security.EventAdminExport,
+		Severity: security.SeverityInfo,
+		UserID:   auth.GetUserID(r),
+		ClientIP: security.GetClientIP(r),
+		Resource: "admin/export/" + name,
+		Action:   "export",
+		Result:   "success",
+		Details: map[string]interface{}{
+			"format": format,
+			"from":   from.Format(activityRangeLayout),
+			"to":     to.AddDate(0, 0, -1).Format(activityRangeLayout),
+		},
+	})
+
+	filename := fmt.Sprintf("famli-%s-%s-%s.%s", name, from.Format(activityRangeLayout),
+		to.AddDate(0, 0, -1).Format(activityRangeLayout), format)
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	stream := &exportStream{w: w, columns: columns, format: format}
+	stream.flusher, _ = w.(http.Flusher)
+	if format == "csv" {
+		stream.csv = csv.NewWriter(w)
+		stream.csv.Write(columns)
+	} else {
+		w.Write([]byte("["))
+	}
+	return stream
+}
+
+// exportStream escreve as linhas do arquivo conforme chegam
+type exportStream struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	columns []string
+	format  string
+	csv     *csv.Writer
+	rows    int
+}
+
+// Row escreve uma linha (valores na ordem das colunas)
+func (s *exportStream) Row(values ...interface{}) error {
+	if s.format == "csv" {
+		record := make([]string, len(values))
+		for i, value := range values {
+			record[i] = csvCell(value)
+		}
+		if err := s.csv.Write(record); err != nil {
+			return err
+		}
+	} else {
+		var line strings.Builder
+		if s.rows > 0 {
+			line.WriteString(",")
+		}
+		line.WriteString("\n{")
+		for i, value := range values {
+			key, _ := json.Marshal(s.columns[i])
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			if i > 0 {
+				line.WriteString(",")
+			}
+			line.Write(key)
+			line.WriteString(":")
+			line.Write(encoded)
+		}
+		line.WriteString("}")
+		if _, err := s.w.Write([]byte(line.String())); err != nil {
+			return err
+		}
+	}
+
+	s.rows++
+	if s.rows%exportFlushRows == 0 {
+		s.flush()
+	}
+	return nil
+}
+
+// Close termina o arquivo
+func (s *exportStream) Close() {
+	if s.format == "json" {
+		s.w.Write([]byte("\n]\n"))
+	}
+	s.flush()
+}
+
+// flush envia o que já foi escrito
+func (s *exportStream) flush() {
+	if s.csv != nil {
+		s.csv.Flush()
+	}
+	if s.flusher != nil {
+		s.flusher.Flush()
+	}
+}
+
+// csvCell converte um valor em célula, neutralizando fórmulas de planilha
+func csvCell(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+			return "'" + v
+		}
+		return v
+	case int:
+		return strconv.Itoa(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
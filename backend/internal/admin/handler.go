@@ -6,12 +6,20 @@
 //
 // Funcionalidades:
 // - Dashboard com estatísticas gerais
-// - Health check do sistema
-// - Listagem de usuários (sem dados sensíveis)
+// - Health check do sistema e das dependências externas
+// - Busca de usuários (sem dados sensíveis)
+// - Gestão de contas (desativar, reativar, remover, redefinir senha)
+// - Gestão de papéis administrativos
+// - Atividade relevante (auditoria + analytics, ver activity.go)
+// - Exportação de métricas em CSV/JSON (ver export.go)
 // - Métricas de uso
+// - Uso de armazenamento e cotas por usuário
+// - Uso do assistente (perguntas, tokens e recusas)
+// - Página de status pública e notas de incidente (ver status.go)
 //
 // Segurança:
-// - Requer autenticação admin (email em lista permitida)
+// - Requer papel administrativo (support, analyst ou superadmin),
+//   verificado por auth.RequireRole nas rotas
 // - Não expõe dados sensíveis dos usuários
 // - Rate limiting aplicado
 // =============================================================================
@@ -21,126 +29,57 @@ package admin
 import (
 	"encoding/json"
 	"net/http"
-	"os"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
+	"famli/internal/apierror"
 	"famli/internal/auth"
 	"famli/internal/i18n"
+	"famli/internal/quota"
 	"famli/internal/security"
 	"famli/internal/storage"
+
+	"github.com/go-chi/chi/v5"
 )
 
 // =============================================================================
-// CONFIGURAÇÃO
+// HANDLER
 // =============================================================================
 
-// getAdminEmails retorna a lista de emails de administradores
-// Lê dinamicamente a variável de ambiente a cada chamada
-// Formato: ADMIN_EMAILS=admin1@email.com,admin2@email.com
-func getAdminEmails() []string {
-	emails := os.Getenv("ADMIN_EMAILS")
-	if emails == "" {
-		return []string{}
-	}
-
-	result := []string{}
-	for _, email := range strings.Split(emails, ",") {
-		email = strings.TrimSpace(email)
-		if email != "" {
-			result = append(result, strings.ToLower(email))
-		}
-	}
-	return result
+// PasswordResetSender envia o email de redefinição de senha (implementado por auth.Handler)
+type PasswordResetSender interface {
+	SendPasswordReset(user *storage.User, r *http.Request) error
 }
 
-// =============================================================================
-// HANDLER
-// =============================================================================
-
 // Handler gerencia endpoints administrativos
 type Handler struct {
 	store       storage.Store
 	storageType string // Tipo de storage: "PostgreSQL" ou "Memory"
+	environment string // Ambiente (ENV), exibido no dashboard
 	startTime   time.Time
 	auditLogger *security.AuditLogger
+	resetSender PasswordResetSender
+	quotaLimits quota.Limits // Limites por usuário, exibidos no uso de armazenamento
+	health      HealthConfig // Dependências verificadas no health check
+	status      statusCache  // Última resposta da página de status (ver status.go)
 }
 
 // NewHandler cria uma nova instância do handler admin
-func NewHandler(store storage.Store, storageType string) *Handler {
+func NewHandler(store storage.Store, storageType, environment string, resetSender PasswordResetSender, quotaLimits quota.Limits, health HealthConfig) *Handler {
 	return &Handler{
 		store:       store,
 		storageType: storageType,
+		environment: environment,
 		startTime:   time.Now(),
 		auditLogger: security.GetAuditLogger(),
+		resetSender: resetSender,
+		quotaLimits: quotaLimits,
+		health:      health,
 	}
 }
 
-// =============================================================================
-// MIDDLEWARE DE ADMIN
-// =============================================================================
-
-// AdminOnly é um middleware que verifica se o usuário é admin
-func (h *Handler) AdminOnly(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Obter userID do contexto usando a função correta do pacote auth
-		userID := auth.GetUserID(r)
-		if userID == "" {
-			writeError(w, http.StatusUnauthorized, i18n.Tr(r, "admin.not_authenticated"))
-			return
-		}
-
-		// Buscar usuário
-		user, ok := h.store.GetUserByID(userID)
-		if !ok {
-			writeError(w, http.StatusUnauthorized, i18n.Tr(r, "admin.user_not_found"))
-			return
-		}
-
-		// Verificar se é admin
-		if !isAdmin(user.Email) {
-			// Logar apenas tentativas não autorizadas (importante para segurança)
-			h.auditLogger.LogSecurity(security.EventUnauthorizedAccess, security.GetClientIP(r), map[string]interface{}{
-				"email":    user.Email,
-				"resource": "admin",
-			})
-			writeError(w, http.StatusForbidden, i18n.Tr(r, "admin.access_denied"))
-			return
-		}
-
-		// Não logar acessos normais ao admin - seria muito verboso
-		next.ServeHTTP(w, r)
-	})
-}
-
-// isAdmin verifica se o email está na lista de admins
-// Lê a variável de ambiente ADMIN_EMAILS dinamicamente
-func isAdmin(email string) bool {
-	email = strings.ToLower(email)
-	adminEmails := getAdminEmails()
-	env := os.Getenv("ENV")
-
-	// Verificar se está na lista
-	for _, adminEmail := range adminEmails {
-		if email == adminEmail {
-			return true
-		}
-	}
-
-	// Em desenvolvimento, se não houver admins configurados, permitir qualquer usuário autenticado
-	if len(adminEmails) == 0 && env != "production" {
-		return true
-	}
-
-	// Em produção, falhar fechado se não houver ADMIN_EMAILS configurado
-	if len(adminEmails) == 0 && env == "production" {
-		return false
-	}
-
-	return false
-}
-
 // =============================================================================
 // ENDPOINTS
 // =============================================================================
@@ -164,14 +103,11 @@ func (h *Handler) Dashboard(w http.ResponseWriter, r *http.Request) {
 		avgItemsPerUser = float64(stats.TotalItems) / float64(stats.TotalUsers)
 	}
 
-	// Obter configurações para debug
-	adminEmails := getAdminEmails()
-	env := os.Getenv("ENV")
-
 	dashboard := map[string]interface{}{
 		"overview": map[string]interface{}{
 			"total_users":        stats.TotalUsers,
 			"total_items":        stats.TotalItems,
+			"archived_items":     stats.ArchivedItems,
 			"total_guardians":    stats.TotalGuardians,
 			"avg_items_per_user": avgItemsPerUser,
 		},
@@ -179,8 +115,8 @@ func (h *Handler) Dashboard(w http.ResponseWriter, r *http.Request) {
 		"items_by_category": stats.ItemsByCategory,
 		"recent_signups":    stats.RecentSignups,
 		"config": map[string]interface{}{
-			"admin_emails": adminEmails,
-			"environment":  env,
+			"admins":      h.listAdmins(),
+			"environment": h.environment,
 		},
 		"generated_at": time.Now().UTC().Format(time.RFC3339),
 	}
@@ -188,23 +124,48 @@ func (h *Handler) Dashboard(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, dashboard)
 }
 
+// listAdmins retorna os usuários com papel administrativo (email mascarado)
+func (h *Handler) listAdmins() []map[string]interface{} {
+	admins := []map[string]interface{}{}
+	for _, role := range storage.AdminRoles {
+		result, err := h.store.SearchUsers(&storage.UserSearchParams{
+			Role:             role,
+			PaginationParams: storage.PaginationParams{Limit: storage.MaxPageSize},
+		})
+		if err != nil {
+			continue
+		}
+		for _, user := range result.Items {
+			admins = append(admins, map[string]interface{}{
+				"email": maskEmail(user.Email),
+				"role":  user.Role,
+			})
+		}
+	}
+	return admins
+}
+
 // Health retorna o status de saúde do sistema
 //
 // Endpoint: GET /api/admin/health
 //
 // Resposta:
-//   - status: "healthy" ou "degraded"
+//   - status: "healthy", "degraded" (alguma dependência com problema) ou
+//     "unhealthy" (banco fora do ar)
 //   - uptime: tempo de atividade
 //   - memory: uso de memória
 //   - goroutines: número de goroutines
+//   - dependencies: estado e latência de cada dependência (ver health.go)
+//   - queues: filas em memória (profundidade e contadores)
 func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
 	var memStats runtime.MemStats
 	runtime.ReadMemStats(&memStats)
 
 	uptime := time.Since(h.startTime)
+	dependencies := h.checkDependencies(r.Context())
 
 	health := map[string]interface{}{
-		"status": "healthy",
+		"status": overallStatus(dependencies),
 		"uptime": map[string]interface{}{
 			"seconds": int64(uptime.Seconds()),
 			"human":   formatDuration(uptime),
@@ -222,75 +183,395 @@ func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
 		},
 		"storage": map[string]interface{}{
 			"type":   h.storageType,
-			"status": "ok",
+			"status": dependencies["database"].Status,
 		},
-		"timestamp": time.Now().UTC().Format(time.RFC3339),
+		"dependencies": dependencies,
+		"queues":       h.queueStats(),
+		"timestamp":    time.Now().UTC().Format(time.RFC3339),
 	}
 
 	writeJSON(w, http.StatusOK, health)
 }
 
-// Users retorna lista de usuários (sem dados sensíveis)
+// Users busca usuários (sem dados sensíveis)
 //
 // Endpoint: GET /api/admin/users
 //
 // Query params:
-//   - page: página (default: 1)
-//   - limit: itens por página (default: 20, max: 100)
+//   - q: trecho do email ou nome
+//   - status: active, disabled ou locked (default: todos)
+//   - role: support, analyst ou superadmin (default: todos)
+//   - cursor: ID do último usuário da página anterior
+//   - limit: itens por página (default: 20, max: 50)
 func (h *Handler) Users(w http.ResponseWriter, r *http.Request) {
-	users := h.store.ListUsers()
+	query := r.URL.Query()
+
+	status := query.Get("status")
+	switch status {
+	case "", storage.UserStatusActive, storage.UserStatusDisabled, storage.UserStatusLocked:
+	default:
+		apierror.Write(w, r, http.StatusBadRequest, "admin.invalid_status")
+		return
+	}
+
+	var role storage.Role
+	if value := query.Get("role"); value != "" {
+		parsed, ok := storage.ParseRole(value)
+		if !ok {
+			apierror.Write(w, r, http.StatusBadRequest, "admin.invalid_role")
+			return
+		}
+		role = parsed
+	}
+
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	result, err := h.store.SearchUsers(&storage.UserSearchParams{
+		Query:  strings.TrimSpace(query.Get("q")),
+		Status: status,
+		Role:   role,
+		PaginationParams: storage.PaginationParams{
+			Cursor: query.Get("cursor"),
+			Limit:  limit,
+		},
+	})
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "admin.action_failed")
+		return
+	}
 
 	// Mapear para formato seguro (sem senhas)
-	safeUsers := make([]map[string]interface{}, 0, len(users))
-	for _, user := range users {
-		itemCount := len(h.store.ListBoxItems(user.ID))
-		guardianCount := len(h.store.ListGuardians(user.ID))
-
-		safeUsers = append(safeUsers, map[string]interface{}{
-			"id":              user.ID,
-			"email":           maskEmail(user.Email),
-			"name":            user.Name,
-			"created_at":      user.CreatedAt.Format(time.RFC3339),
-			"items_count":     itemCount,
-			"guardians_count": guardianCount,
-			"is_admin":        isAdmin(user.Email),
-		})
+	now := time.Now()
+	safeUsers := make([]map[string]interface{}, 0, len(result.Items))
+	for _, user := range result.Items {
+		itemCount, _ := h.store.CountBoxItems(user.ID)
+		guardianCount, _ := h.store.CountGuardians(user.ID)
+
+		safeUser := map[string]interface{}{
+			"id":                    user.ID,
+			"email":                 maskEmail(user.Email),
+			"name":                  user.Name,
+			"created_at":            user.CreatedAt.Format(time.RFC3339),
+			"items_count":           itemCount,
+			"guardians_count":       guardianCount,
+			"is_admin":              user.Role.IsAdmin(),
+			"role":                  user.Role,
+			"failed_login_attempts": user.FailedLoginAttempts,
+			"is_locked":             user.IsLocked(now),
+			"is_disabled":           user.IsDisabled(),
+		}
+		if user.IsLocked(now) {
+			safeUser["locked_until"] = user.LockedUntil.Format(time.RFC3339)
+		}
+		if user.IsDisabled() {
+			safeUser["disabled_at"] = user.DisabledAt.Format(time.RFC3339)
+		}
+		safeUsers = append(safeUsers, safeUser)
 	}
 
 	response := map[string]interface{}{
-		"users": safeUsers,
-		"total": len(safeUsers),
+		"users":       safeUsers,
+		"total":       result.Total,
+		"has_more":    result.HasMore,
+		"next_cursor": result.NextCursor,
 	}
 
 	writeJSON(w, http.StatusOK, response)
 }
 
-// Activity retorna atividade recente do sistema
+// DisableUser desativa uma conta (sessões ativas são encerradas)
+//
+// Endpoint: POST /api/admin/users/{id}/disable
+func (h *Handler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	h.setUserDisabled(w, r, true)
+}
+
+// EnableUser reativa uma conta desativada
+//
+// Endpoint: POST /api/admin/users/{id}/enable
+func (h *Handler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	h.setUserDisabled(w, r, false)
+}
+
+// setUserDisabled aplica a desativação/reativação e registra na auditoria
+func (h *Handler) setUserDisabled(w http.ResponseWriter, r *http.Request, disabled bool) {
+	user, ok := h.targetUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.store.SetUserDisabled(user.ID, disabled); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "admin.action_failed")
+		return
+	}
+
+	eventType := security.EventAccountEnabled
+	if disabled {
+		eventType = security.EventAccountDisabled
+	}
+	h.logAdminAction(r, eventType, user.ID)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":          user.ID,
+		"is_disabled": disabled,
+	})
+}
+
+// DeleteUser remove a conta e todos os dados do usuário (LGPD: esquecimento)
+//
+// Endpoint: DELETE /api/admin/users/{id}
+func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.targetUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.store.DeleteUser(user.ID); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "admin.action_failed")
+		return
+	}
+
+	h.logAdminAction(r, security.EventAccountDeletion, user.ID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ResetUserPassword envia ao usuário um email de redefinição de senha
 //
-// Endpoint: GET /api/admin/activity
-func (h *Handler) Activity(w http.ResponseWriter, r *http.Request) {
-	// Obter eventos de auditoria recentes
-	events := h.auditLogger.GetRecentEvents(50)
-
-	// Converter para formato de resposta
-	activities := make([]map[string]interface{}, 0, len(events))
-	for _, event := range events {
-		activities = append(activities, map[string]interface{}{
-			"id":        event.ID,
-			"type":      string(event.Type),
-			"severity":  string(event.Severity),
-			"timestamp": event.Timestamp.Format(time.RFC3339),
-			"client_ip": maskIP(event.ClientIP),
-			"result":    event.Result,
+// Endpoint: POST /api/admin/users/{id}/reset-password
+//
+// O admin nunca define nem vê a senha; o usuário escolhe a nova pelo link.
+func (h *Handler) ResetUserPassword(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.targetUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.resetSender.SendPasswordReset(user, r); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "admin.action_failed")
+		return
+	}
+
+	h.logAdminAction(r, security.EventPasswordReset, user.ID)
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"message": i18n.Tr(r, "admin.reset_sent"),
+	})
+}
+
+// rolePayload é o corpo de PUT /api/admin/users/{id}/role
+type rolePayload struct {
+	Role string `json:"role"`
+}
+
+// GrantRole concede (ou troca) o papel administrativo de um usuário
+//
+// Endpoint: PUT /api/admin/users/{id}/role
+//
+// Body: {"role": "support" | "analyst" | "superadmin"}
+func (h *Handler) GrantRole(w http.ResponseWriter, r *http.Request) {
+	var payload rolePayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "admin.invalid_role")
+		return
+	}
+	role, ok := storage.ParseRole(payload.Role)
+	if !ok {
+		apierror.Write(w, r, http.StatusBadRequest, "admin.invalid_role")
+		return
+	}
+
+	user, ok := h.targetUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.store.GrantRole(user.ID, role); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "admin.action_failed")
+		return
+	}
+
+	h.auditLogger.LogAuth(security.EventRoleGranted, user.ID, security.GetClientIP(r), r.UserAgent(), "success", map[string]interface{}{
+		"admin_id":      auth.GetUserID(r),
+		"role":          string(role),
+		"previous_role": string(user.Role),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":   user.ID,
+		"role": role,
+	})
+}
+
+// RevokeRole remove o papel administrativo de um usuário
+//
+// Endpoint: DELETE /api/admin/users/{id}/role
+func (h *Handler) RevokeRole(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.targetUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.store.RevokeRole(user.ID); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "admin.action_failed")
+		return
+	}
+
+	h.auditLogger.LogAuth(security.EventRoleRevoked, user.ID, security.GetClientIP(r), r.UserAgent(), "success", map[string]interface{}{
+		"admin_id":      auth.GetUserID(r),
+		"previous_role": string(user.Role),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// targetUser carrega o usuário alvo de uma ação administrativa
+// Impede que o admin execute ações destrutivas na própria conta e respeita a
+// hierarquia: contas com papel administrativo só pelo superadmin (o suporte
+// age apenas sobre usuários comuns).
+func (h *Handler) targetUser(w http.ResponseWriter, r *http.Request) (*storage.User, bool) {
+	userID := chi.URLParam(r, "id")
+	if userID == auth.GetUserID(r) {
+		apierror.Write(w, r, http.StatusBadRequest, "admin.self_action")
+		return nil, false
+	}
+
+	user, ok := h.store.GetUserByID(userID)
+	if !ok {
+		apierror.Write(w, r, http.StatusNotFound, "admin.user_not_found")
+		return nil, false
+	}
+	if user.Role.IsAdmin() && auth.GetUserRole(r) != storage.RoleSuperadmin {
+		apierror.Write(w, r, http.StatusForbidden, "admin.target_forbidden")
+		return nil, false
+	}
+	return user, true
+}
+
+// logAdminAction registra uma ação administrativa sobre a conta de um usuário
+func (h *Handler) logAdminAction(r *http.Request, eventType security.AuditEventType, targetUserID string) {
+	h.auditLogger.LogAuth(eventType, targetUserID, security.GetClientIP(r), r.UserAgent(), "success", map[string]interface{}{
+		"admin_id": auth.GetUserID(r),
+	})
+}
+
+// Usage retorna os usuários que mais consomem armazenamento
+//
+// Endpoint: GET /api/admin/usage
+//
+// Query params:
+//   - limit: quantidade de usuários (default: 20, max: 100)
+//
+// Ordenado por bytes de texto e depois por quantidade de itens. Cada usuário
+// traz o percentual da cota usado (0 quando o limite está desabilitado).
+func (h *Handler) Usage(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	usages, err := h.store.ListTopUsage(limit)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "admin.usage_error")
+		return
+	}
+
+	consumers := make([]map[string]interface{}, 0, len(usages))
+	for _, usage := range usages {
+		consumers = append(consumers, map[string]interface{}{
+			"user_id":       usage.UserID,
+			"email":         maskEmail(usage.Email),
+			"name":          usage.Name,
+			"item_count":    usage.ItemCount,
+			"content_bytes": usage.ContentBytes,
+			"items_percent": percentOf(int64(usage.ItemCount), int64(h.quotaLimits.MaxItems)),
+			"bytes_percent": percentOf(usage.ContentBytes, h.quotaLimits.MaxContentBytes),
 		})
 	}
 
 	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"activities": activities,
-		"total":      len(activities),
+		"top_consumers": consumers,
+		"limits": map[string]interface{}{
+			"max_items":         h.quotaLimits.MaxItems,
+			"max_content_bytes": h.quotaLimits.MaxContentBytes,
+		},
+		"generated_at": time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// AssistantUsage retorna as métricas de uso do assistente
+//
+// Endpoint: GET /api/admin/assistant
+//
+// Query params:
+//   - days: período em dias, incluindo hoje (default: 30, max: 90)
+//   - limit: quantidade de maiores consumidores (default: 10, max: 50)
+//
+// Inclui perguntas respondidas, tokens estimados, recusas pelo orçamento
+// diário (429) e a série por dia (UTC).
+func (h *Handler) AssistantUsage(w http.ResponseWriter, r *http.Request) {
+	days, _ := strconv.Atoi(r.URL.Query().Get("days"))
+	if days <= 0 {
+		days = 30
+	}
+	if days > 90 {
+		days = 90
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 50 {
+		limit = 50
+	}
+
+	since := time.Now().UTC().AddDate(0, 0, -(days - 1)).Format("2006-01-02")
+	stats, err := h.store.GetAssistantUsageStats(since, limit)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "admin.assistant_error")
+		return
+	}
+
+	consumers := make([]map[string]interface{}, 0, len(stats.TopUsers))
+	for _, usage := range stats.TopUsers {
+		consumer := map[string]interface{}{
+			"user_id":  usage.UserID,
+			"requests": usage.Requests,
+			"tokens":   usage.Tokens,
+			"rejected": usage.Rejected,
+		}
+		if user, ok := h.store.GetUserByID(usage.UserID); ok {
+			consumer["email"] = maskEmail(user.Email)
+			consumer["name"] = user.Name
+		}
+		consumers = append(consumers, consumer)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"since":         since,
+		"requests":      stats.Requests,
+		"tokens":        stats.Tokens,
+		"rejected":      stats.Rejected,
+		"active_users":  stats.ActiveUsers,
+		"daily":         stats.Daily,
+		"top_consumers": consumers,
+		"limits": map[string]interface{}{
+			"daily_tokens": h.quotaLimits.AssistantDailyTokens,
+		},
+		"generated_at": time.Now().UTC().Format(time.RFC3339),
 	})
 }
 
+// percentOf calcula o percentual usado de um limite (0 = sem limite)
+func percentOf(used, limit int64) float64 {
+	if limit <= 0 {
+		return 0
+	}
+	return float64(used) * 100 / float64(limit)
+}
+
 // =============================================================================
 // HEALTH CHECK PÚBLICO (sem autenticação)
 // =============================================================================
@@ -318,11 +599,6 @@ func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
 	}
 }
 
-// writeError escreve erro JSON
-func writeError(w http.ResponseWriter, status int, message string) {
-	writeJSON(w, status, map[string]string{"error": message})
-}
-
 // maskEmail mascara parte do email para privacidade
 func maskEmail(email string) string {
 	parts := strings.Split(email, "@")
@@ -340,15 +616,6 @@ func maskEmail(email string) string {
 	return name[:2] + "***@" + domain
 }
 
-// maskIP mascara parte do IP para privacidade
-func maskIP(ip string) string {
-	parts := strings.Split(ip, ".")
-	if len(parts) != 4 {
-		return ip // IPv6 ou formato desconhecido
-	}
-	return parts[0] + "." + parts[1] + ".***. ***"
-}
-
 // formatDuration formata duração em formato legível
 func formatDuration(d time.Duration) string {
 	days := int(d.Hours() / 24)
@@ -0,0 +1,208 @@
+// =============================================================================
+// FAMLI - Dependências do Health Check
+// =============================================================================
+// GET /api/admin/health verifica, em paralelo, cada dependência externa e
+// informa o estado e a latência de cada uma:
+//
+// - database: conexão com o PostgreSQL e migrações pendentes
+// - email: credenciais do provedor de email
+// - whatsapp: API do Twilio (conta e credenciais)
+// - disk: espaço livre no volume onde o Famli grava arquivos
+// - attachment_scan: antivírus dos anexos
+//
+// As filas em memória (ex: eventos de analytics aguardando gravação)
+// aparecem em "queues", com profundidade e contadores.
+//
+// Estados: ok, disabled (não configurado), unreachable (com error),
+// pending_migrations e low_space. Com o banco fora do ar o sistema fica
+// "unhealthy"; qualquer outro problema deixa o sistema "degraded".
+// =============================================================================
+
+package admin
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"famli/internal/analytics"
+	"famli/internal/scan"
+)
+
+// healthCheckTimeout é o tempo máximo de cada verificação
+const healthCheckTimeout = 3 * time.Second
+
+// Estados de uma dependência
+const (
+	DependencyOK                = "ok"
+	DependencyDisabled          = "disabled"
+	DependencyUnreachable       = "unreachable"
+	DependencyPendingMigrations = "pending_migrations"
+	DependencyLowSpace          = "low_space"
+)
+
+// errDiskUnsupported indica que a plataforma não informa o espaço em disco
+var errDiskUnsupported = errors.New("espaço em disco indisponível nesta plataforma")
+
+// Pinger é uma dependência que sabe se verificar (ex: email, WhatsApp)
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// HealthConfig são as dependências verificadas pelo health check
+type HealthConfig struct {
+	Scanner       scan.Scanner // Antivírus dos anexos
+	Email         Pinger       // Provedor de email (nil = sem credenciais)
+	WhatsApp      Pinger       // API do Twilio (nil = integração desligada)
+	DiskPath      string       // Volume onde o Famli grava arquivos (vazio = não verifica)
+	DiskMinFreeMB int64        // Espaço livre mínimo antes de "low_space"
+
+	AnalyticsBuffer *analytics.Buffer // Fila de eventos (profundidade em queues.analytics)
+}
+
+// DependencyStatus é o resultado da verificação de uma dependência
+type DependencyStatus struct {
+	Status    string                 `json:"status"`
+	LatencyMs float64                `json:"latency_ms,omitempty"`
+	Error     string                 `json:"error,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+// healthy indica se a dependência não degrada o sistema
+func (d DependencyStatus) healthy() bool {
+	return d.Status == DependencyOK || d.Status == DependencyDisabled
+}
+
+// checkDependencies verifica todas as dependências em paralelo
+func (h *Handler) checkDependencies(ctx context.Context) map[string]DependencyStatus {
+	checks := map[string]func(context.Context) DependencyStatus{
+		"database":        h.checkDatabase,
+		"email":           pingCheck(h.health.Email),
+		"whatsapp":        pingCheck(h.health.WhatsApp),
+		"disk":            h.checkDisk,
+		"attachment_scan": h.checkScanner,
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]DependencyStatus, len(checks))
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(context.Context) DependencyStatus) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			result := check(checkCtx)
+			if result.Status != DependencyDisabled {
+				result.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+			}
+
+			mu.Lock()
+			results[name] = result
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+	return results
+}
+
+// overallStatus resume as dependências em healthy, degraded ou unhealthy
+func overallStatus(deps map[string]DependencyStatus) string {
+	if deps["database"].Status == DependencyUnreachable {
+		return "unhealthy"
+	}
+	for _, dep := range deps {
+		if !dep.healthy() {
+			return "degraded"
+		}
+	}
+	return "healthy"
+}
+
+// checkDatabase testa a conexão e as migrações
+func (h *Handler) checkDatabase(ctx context.Context) DependencyStatus {
+	result := DependencyStatus{
+		Status:  DependencyOK,
+		Details: map[string]interface{}{"type": h.storageType},
+	}
+
+	migrations, err := h.store.CheckHealth(ctx)
+	if err != nil {
+		result.Status = DependencyUnreachable
+		result.Error = err.Error()
+		return result
+	}
+	if migrations != nil {
+		result.Details["migrations"] = migrations
+		if len(migrations.Pending) > 0 {
+			result.Status = DependencyPendingMigrations
+		}
+	}
+	return result
+}
+
+// checkDisk confere o espaço livre no volume configurado
+func (h *Handler) checkDisk(ctx context.Context) DependencyStatus {
+	if h.health.DiskPath == "" {
+		return DependencyStatus{Status: DependencyDisabled}
+	}
+
+	free, total, err := diskSpace(h.health.DiskPath)
+	if errors.Is(err, errDiskUnsupported) {
+		return DependencyStatus{Status: DependencyDisabled, Error: err.Error()}
+	}
+	if err != nil {
+		return DependencyStatus{Status: DependencyUnreachable, Error: err.Error()}
+	}
+
+	const mb = 1024 * 1024
+	result := DependencyStatus{
+		Status: DependencyOK,
+		Details: map[string]interface{}{
+			"path":        h.health.DiskPath,
+			"free_mb":     free / mb,
+			"total_mb":    total / mb,
+			"min_free_mb": h.health.DiskMinFreeMB,
+		},
+	}
+	if int64(free/mb) < h.health.DiskMinFreeMB {
+		result.Status = DependencyLowSpace
+	}
+	return result
+}
+
+// checkScanner verifica se o antivírus dos anexos está respondendo
+func (h *Handler) checkScanner(ctx context.Context) DependencyStatus {
+	if h.health.Scanner == nil || h.health.Scanner.Name() == (scan.Disabled{}).Name() {
+		return DependencyStatus{Status: DependencyDisabled, Details: map[string]interface{}{"engine": "disabled"}}
+	}
+
+	result := pingCheck(h.health.Scanner)(ctx)
+	result.Details = map[string]interface{}{"engine": h.health.Scanner.Name()}
+	return result
+}
+
+// queueStats retorna o estado das filas em memória
+func (h *Handler) queueStats() map[string]interface{} {
+	queues := map[string]interface{}{}
+	if h.health.AnalyticsBuffer != nil {
+		queues["analytics"] = h.health.AnalyticsBuffer.Stats()
+	}
+	return queues
+}
+
+// pingCheck verifica uma dependência pelo Ping (nil = desligada)
+func pingCheck(pinger Pinger) func(context.Context) DependencyStatus {
+	return func(ctx context.Context) DependencyStatus {
+		if pinger == nil {
+			return DependencyStatus{Status: DependencyDisabled}
+		}
+		if err := pinger.Ping(ctx); err != nil {
+			return DependencyStatus{Status: DependencyUnreachable, Error: err.Error()}
+		}
+		return DependencyStatus{Status: DependencyOK}
+	}
+}
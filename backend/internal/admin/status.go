@@ -0,0 +1,518 @@
+// =============================================================================
+// FAMLI - Página de Status
+// =============================================================================
+// GET /api/status (público) informa se a Famli está com problemas:
+//
+// - status: operational, maintenance, degraded ou outage
+// - components: banco de dados, email e WhatsApp (sem erros nem latência;
+//   dependências desligadas não aparecem)
+// - incidents: notas abertas e resolvidas nos últimos 7 dias, publicadas
+//   pelos admins em /api/admin/status/incidents
+// - uptime: disponibilidade diária de cada componente nos últimos 90 dias
+//
+// O monitor verifica as dependências a cada 5 minutos e soma o resultado
+// em status_uptime (um registro por componente e dia, em UTC). Cada réplica
+// soma as suas verificações; o percentual não muda com o número de réplicas.
+//
+// A resposta pública fica em cache por 1 minuto (também no navegador e no
+// CDN); publicar ou alterar um incidente invalida o cache.
+// =============================================================================
+
+package admin
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/security"
+	"famli/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	// statusCheckInterval é o intervalo entre as verificações do monitor
+	statusCheckInterval = 5 * time.Minute
+
+	// statusCacheTTL é a validade da resposta pública
+	statusCacheTTL = time.Minute
+
+	// statusUptimeDays é a janela da disponibilidade exibida
+	statusUptimeDays = 90
+
+	// statusRecentIncidents é por quanto tempo um incidente resolvido aparece
+	statusRecentIncidents = 7 * 24 * time.Hour
+
+	// adminStatusIncidents é a janela dos incidentes resolvidos no painel
+	adminStatusIncidents = 30 * 24 * time.Hour
+)
+
+// statusComponents são as dependências exibidas na página de status
+var statusComponents = []string{"database", "email", "whatsapp"}
+
+// Estados da página de status, do melhor para o pior
+const (
+	StatusOperational = "operational"
+	StatusMaintenance = "maintenance"
+	StatusDegraded    = "degraded"
+	StatusOutage      = "outage"
+)
+
+// statusRank ordena os estados para escolher o pior
+var statusRank = map[string]int{
+	StatusOperational: 0,
+	StatusMaintenance: 1,
+	StatusDegraded:    2,
+	StatusOutage:      3,
+}
+
+// worseStatus retorna o pior entre dois estados
+func worseStatus(a, b string) string {
+	if statusRank[b] > statusRank[a] {
+		return b
+	}
+	return a
+}
+
+// incidentStatus é o estado que um incidente aberto impõe
+func incidentStatus(severity storage.IncidentSeverity) string {
+	switch severity {
+	case storage.IncidentMajor:
+		return StatusOutage
+	case storage.IncidentMaintenance:
+		return StatusMaintenance
+	default:
+		return StatusDegraded
+	}
+}
+
+// dependencyStatus traduz o estado do health check para a página de status
+func dependencyStatus(dep DependencyStatus) string {
+	switch {
+	case dep.healthy():
+		return StatusOperational
+	case dep.Status == DependencyUnreachable:
+		return StatusOutage
+	default:
+		return StatusDegraded
+	}
+}
+
+// statusCache guarda a última resposta pública
+type statusCache struct {
+	refresh   sync.Mutex // Uma montagem por vez (requisições simultâneas esperam o cache)
+	mu        sync.Mutex
+	page      *StatusPage
+	checks    map[string]DependencyStatus // Última verificação do monitor
+	checkedAt time.Time
+}
+
+// invalidate descarta a resposta em cache (a verificação continua valendo)
+func (c *statusCache) invalidate() {
+	c.mu.Lock()
+	c.page = nil
+	c.mu.Unlock()
+}
+
+// StatusComponent é um componente da página de status
+type StatusComponent struct {
+	Name          string  `json:"name"`
+	Status        string  `json:"status"`
+	UptimePercent float64 `json:"uptime_percent"` // Últimos 90 dias (100 sem verificações)
+}
+
+// StatusUptime é a disponibilidade de um componente em um dia
+type StatusUptime struct {
+	Day           string  `json:"day"` // AAAA-MM-DD (UTC)
+	UptimePercent float64 `json:"uptime_percent"`
+}
+
+// StatusPage é a resposta de GET /api/status
+type StatusPage struct {
+	Status     string                     `json:"status"`
+	Components []StatusComponent          `json:"components"`
+	Incidents  []*storage.StatusIncident  `json:"incidents"`
+	Uptime     map[string][]*StatusUptime `json:"uptime"`
+	CheckedAt  time.Time                  `json:"checked_at"`
+}
+
+// =============================================================================
+// MONITOR
+// =============================================================================
+
+// StartStatusMonitor inicia o monitor da página de status (encerra quando
+// ctx é cancelado)
+func (h *Handler) StartStatusMonitor(ctx context.Context) {
+	go func() {
+		h.recordStatusChecks(ctx)
+
+		ticker := time.NewTicker(statusCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				h.recordStatusChecks(ctx)
+			}
+		}
+	}()
+}
+
+// recordStatusChecks verifica as dependências e soma o resultado ao dia
+func (h *Handler) recordStatusChecks(ctx context.Context) {
+	checks := h.refreshStatusChecks(ctx)
+	today := time.Now().UTC()
+	for _, name := range statusComponents {
+		dep := checks[name]
+		if dep.Status == DependencyDisabled {
+			continue
+		}
+		if err := h.store.RecordStatusCheck(name, today, dep.healthy()); err != nil {
+			log.Printf("[Status] Erro ao registrar verificação de %s: %v", name, err)
+		}
+	}
+}
+
+// refreshStatusChecks verifica as dependências da página de status
+func (h *Handler) refreshStatusChecks(ctx context.Context) map[string]DependencyStatus {
+	all := h.checkDependencies(ctx)
+	checks := make(map[string]DependencyStatus, len(statusComponents))
+	for _, name := range statusComponents {
+		checks[name] = all[name]
+	}
+
+	h.status.mu.Lock()
+	h.status.checks = checks
+	h.status.checkedAt = time.Now().UTC()
+	h.status.page = nil
+	h.status.mu.Unlock()
+	return checks
+}
+
+// cachedPage retorna a resposta em cache, se ainda válida
+func (c *statusCache) cachedPage() (*StatusPage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.page != nil && time.Since(c.page.CheckedAt) < statusCacheTTL {
+		return c.page, true
+	}
+	return nil, false
+}
+
+// statusPage monta (ou reaproveita) a resposta pública
+func (h *Handler) statusPage(ctx context.Context) (*StatusPage, error) {
+	if page, ok := h.status.cachedPage(); ok {
+		return page, nil
+	}
+	h.status.refresh.Lock()
+	defer h.status.refresh.Unlock()
+	if page, ok := h.status.cachedPage(); ok {
+		return page, nil
+	}
+
+	h.status.mu.Lock()
+	checks, checkedAt := h.status.checks, h.status.checkedAt
+	h.status.mu.Unlock()
+
+	// Verificação antiga (ou monitor ainda não rodou): verifica agora, sem
+	// depender da conexão de quem pediu (o resultado vai para o cache)
+	if checks == nil || time.Since(checkedAt) >= statusCacheTTL {
+		checks = h.refreshStatusChecks(context.WithoutCancel(ctx))
+		checkedAt = time.Now().UTC()
+	}
+
+	now := time.Now()
+	incidents, err := h.store.ListStatusIncidents(now.Add(-statusRecentIncidents))
+	if err != nil {
+		return nil, err
+	}
+	days, err := h.store.ListStatusUptime(now.AddDate(0, 0, -statusUptimeDays+1))
+	if err != nil {
+		return nil, err
+	}
+
+	page := buildStatusPage(checks, incidents, days)
+	page.CheckedAt = checkedAt
+
+	h.status.mu.Lock()
+	h.status.page = page
+	h.status.mu.Unlock()
+	return page, nil
+}
+
+// buildStatusPage junta verificações, incidentes abertos e disponibilidade
+func buildStatusPage(checks map[string]DependencyStatus, incidents []*storage.StatusIncident, days []*storage.StatusUptimeDay) *StatusPage {
+	page := &StatusPage{
+		Status:     StatusOperational,
+		Components: make([]StatusComponent, 0, len(statusComponents)),
+		Incidents:  incidents,
+		Uptime:     make(map[string][]*StatusUptime),
+	}
+
+	totals := make(map[string][2]int) // componente -> verificações, falhas
+	for _, day := range days {
+		total := totals[day.Component]
+		totals[day.Component] = [2]int{total[0] + day.Checks, total[1] + day.Failures}
+		page.Uptime[day.Component] = append(page.Uptime[day.Component], &StatusUptime{
+			Day:           day.Day.UTC().Format("2006-01-02"),
+			UptimePercent: uptimePercent(day.Checks, day.Failures),
+		})
+	}
+
+	for _, name := range statusComponents {
+		dep, checked := checks[name]
+		if !checked || dep.Status == DependencyDisabled {
+			continue
+		}
+		component := StatusComponent{
+			Name:          name,
+			Status:        dependencyStatus(dep),
+			UptimePercent: uptimePercent(totals[name][0], totals[name][1]),
+		}
+		for _, incident := range incidents {
+			if incident.State != storage.IncidentResolved && containsString(incident.Components, name) {
+				component.Status = worseStatus(component.Status, incidentStatus(incident.Severity))
+			}
+		}
+		page.Components = append(page.Components, component)
+		page.Status = worseStatus(page.Status, component.Status)
+	}
+
+	// Incidente aberto sem componente afeta o sistema como um todo
+	for _, incident := range incidents {
+		if incident.State != storage.IncidentResolved {
+			page.Status = worseStatus(page.Status, incidentStatus(incident.Severity))
+		}
+	}
+	return page
+}
+
+// uptimePercent calcula o percentual de verificações sem falha
+func uptimePercent(checks, failures int) float64 {
+	if checks == 0 {
+		return 100
+	}
+	return float64(int(float64(checks-failures)*10000/float64(checks))) / 100
+}
+
+// containsString indica se value está na lista
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// =============================================================================
+// ENDPOINTS
+// =============================================================================
+
+// PublicStatus retorna o estado do sistema para a página de status
+//
+// Endpoint: GET /api/status (público)
+func (h *Handler) PublicStatus(w http.ResponseWriter, r *http.Request) {
+	page, err := h.statusPage(r.Context())
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "status.load_error")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	writeJSON(w, http.StatusOK, page)
+}
+
+// AdminStatus retorna a página de status com as verificações completas e
+// os incidentes dos últimos 30 dias
+//
+// Endpoint: GET /api/admin/status
+func (h *Handler) AdminStatus(w http.ResponseWriter, r *http.Request) {
+	page, err := h.statusPage(r.Context())
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "status.load_error")
+		return
+	}
+	incidents, err := h.store.ListStatusIncidents(time.Now().Add(-adminStatusIncidents))
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "status.load_error")
+		return
+	}
+
+	h.status.mu.Lock()
+	checks := h.status.checks
+	h.status.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":       page.Status,
+		"components":   page.Components,
+		"dependencies": checks,
+		"incidents":    incidents,
+		"uptime":       page.Uptime,
+		"checked_at":   page.CheckedAt,
+	})
+}
+
+// incidentPayload é o corpo de POST/PATCH /api/admin/status/incidents
+// No PATCH, campos ausentes não mudam.
+type incidentPayload struct {
+	Title      *string                   `json:"title"`
+	Message    *string                   `json:"message"`
+	Severity   *storage.IncidentSeverity `json:"severity"`
+	State      *storage.IncidentState    `json:"state"`
+	Components *[]string                 `json:"components"`
+}
+
+// apply aplica o payload ao incidente e valida o resultado
+func (p *incidentPayload) apply(incident *storage.StatusIncident) bool {
+	if p.Title != nil {
+		incident.Title = strings.TrimSpace(*p.Title)
+	}
+	if p.Message != nil {
+		incident.Message = strings.TrimSpace(*p.Message)
+	}
+	if p.Severity != nil {
+		incident.Severity = *p.Severity
+	}
+	if p.State != nil {
+		incident.State = *p.State
+	}
+	if p.Components != nil {
+		components := make([]string, 0, len(*p.Components))
+		for _, name := range *p.Components {
+			if !containsString(statusComponents, name) {
+				return false
+			}
+			if !containsString(components, name) {
+				components = append(components, name)
+			}
+		}
+		sort.Strings(components)
+		incident.Components = components
+	}
+
+	if incident.State == storage.IncidentResolved {
+		if incident.ResolvedAt == nil {
+			now := time.Now()
+			incident.ResolvedAt = &now
+		}
+	} else {
+		incident.ResolvedAt = nil
+	}
+
+	switch {
+	case incident.Title == "" || len([]rune(incident.Title)) > 200 || len([]rune(incident.Message)) > 5000:
+		return false
+	case incident.Severity != storage.IncidentMinor && incident.Severity != storage.IncidentMajor &&
+		incident.Severity != storage.IncidentMaintenance:
+		return false
+	case incident.State != storage.IncidentInvestigating && incident.State != storage.IncidentIdentified &&
+		incident.State != storage.IncidentMonitoring && incident.State != storage.IncidentResolved:
+		return false
+	}
+	return true
+}
+
+// CreateIncident publica uma nota de incidente
+//
+// Endpoint: POST /api/admin/status/incidents
+//
+// Body: {"title": "...", "message": "...", "severity": "minor|major|maintenance",
+// "state": "investigating" (padrão), "components": ["email"]}
+func (h *Handler) CreateIncident(w http.ResponseWriter, r *http.Request) {
+	var payload incidentPayload
+	if err := security.DecodeJSONStrict(r.Body, &payload); err != nil {
+		apierror.WriteDecodeError(w, r, err, "status.invalid_incident")
+		return
+	}
+
+	incident := &storage.StatusIncident{
+		State:     storage.IncidentInvestigating,
+		CreatedBy: auth.GetUserID(r),
+	}
+	if !payload.apply(incident) {
+		apierror.Write(w, r, http.StatusBadRequest, "status.invalid_incident")
+		return
+	}
+	if err := h.store.CreateStatusIncident(incident); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "status.save_error")
+		return
+	}
+
+	h.status.invalidate()
+	h.logIncidentChange(r, incident, "create")
+	writeJSON(w, http.StatusCreated, incident)
+}
+
+// UpdateIncident atualiza uma nota de incidente (ex: resolvido)
+//
+// Endpoint: PATCH /api/admin/status/incidents/{id}
+func (h *Handler) UpdateIncident(w http.ResponseWriter, r *http.Request) {
+	incident, err := h.store.GetStatusIncident(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, r, http.StatusNotFound, "status.incident_not_found")
+		return
+	}
+
+	var payload incidentPayload
+	if err := security.DecodeJSONStrict(r.Body, &payload); err != nil {
+		apierror.WriteDecodeError(w, r, err, "status.invalid_incident")
+		return
+	}
+	if !payload.apply(incident) {
+		apierror.Write(w, r, http.StatusBadRequest, "status.invalid_incident")
+		return
+	}
+	if err := h.store.UpdateStatusIncident(incident); err != nil {
+		apierror.WriteStorage(w, r, err, apierror.StorageKeys{NotFound: "status.incident_not_found", Internal: "status.save_error"})
+		return
+	}
+
+	h.status.invalidate()
+	h.logIncidentChange(r, incident, "update")
+	writeJSON(w, http.StatusOK, incident)
+}
+
+// DeleteIncident remove uma nota de incidente (ex: publicada por engano)
+//
+// Endpoint: DELETE /api/admin/status/incidents/{id}
+func (h *Handler) DeleteIncident(w http.ResponseWriter, r *http.Request) {
+	incident, err := h.store.GetStatusIncident(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, r, http.StatusNotFound, "status.incident_not_found")
+		return
+	}
+	if err := h.store.DeleteStatusIncident(incident.ID); err != nil {
+		apierror.WriteStorage(w, r, err, apierror.StorageKeys{NotFound: "status.incident_not_found", Internal: "status.save_error"})
+		return
+	}
+
+	h.status.invalidate()
+	h.logIncidentChange(r, incident, "delete")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// logIncidentChange registra a alteração de um incidente na auditoria
+func (h *Handler) logIncidentChange(r *http.Request, incident *storage.StatusIncident, action string) {
+	h.auditLogger.Log(security.AuditEvent{
+		Type:     security.EventStatusIncidentChanged,
+		Severity: security.SeverityInfo,
+		UserID:   auth.GetUserID(r),
+		ClientIP: security.GetClientIP(r),
+		Resource: "incident:" + incident.ID,
+		Action:   action,
+		Result:   "success",
+		Details: map[string]interface{}{
+			"severity": string(incident.Severity),
+			"state":    string(incident.State),
+		},
+	})
+}
@@ -0,0 +1,185 @@
+// =============================================================================
+// FAMLI - Gravação em Lote dos Eventos (write-behind)
+// =============================================================================
+// POST /api/analytics/track e /public não gravam o evento na hora: ele entra
+// numa fila em memória, gravada em lote (um INSERT por lote) quando:
+// - a fila atinge o tamanho do lote (ANALYTICS_BATCH_SIZE)
+// - passa o intervalo de gravação (ANALYTICS_FLUSH_INTERVAL_SECONDS)
+// - o servidor encerra (Server.Stop grava o que restou)
+//
+// Analytics tolera perdas: com a fila cheia (ANALYTICS_QUEUE_LIMIT) o evento
+// é descartado, e um lote que falha ao gravar não é repetido. Um crash do
+// processo perde os eventos ainda na fila. Os contadores aparecem em
+// GET /api/admin/health (queues.analytics).
+// =============================================================================
+
+package analytics
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"famli/internal/storage"
+)
+
+// Valores usados quando a configuração não informa
+const (
+	defaultBatchSize     = 100
+	defaultFlushInterval = 5 * time.Second
+	defaultQueueLimit    = 10000
+)
+
+// BufferConfig configura a fila de eventos
+type BufferConfig struct {
+	BatchSize     int           // Eventos por INSERT (e tamanho que dispara a gravação)
+	FlushInterval time.Duration // Intervalo máximo entre gravações
+	QueueLimit    int           // Eventos na fila antes de descartar os novos
+}
+
+// BufferStats são os contadores da fila
+type BufferStats struct {
+	Depth   int   `json:"depth"`   // Eventos aguardando gravação
+	Written int64 `json:"written"` // Eventos gravados desde o início (sem duplicatas)
+	Dropped int64 `json:"dropped"` // Descartados com a fila cheia ou encerrada
+	Failed  int64 `json:"failed"`  // Perdidos em lotes que falharam ao gravar
+}
+
+// Buffer acumula eventos e os grava em lote
+type Buffer struct {
+	store  storage.AnalyticsStore
+	config BufferConfig
+
+	mu      sync.Mutex
+	pending []*storage.AnalyticsEvent
+	closed  bool
+
+	flushMu sync.Mutex    // Uma gravação por vez
+	full    chan struct{} // Sinaliza que a fila atingiu o tamanho do lote
+
+	written atomic.Int64
+	dropped atomic.Int64
+	failed  atomic.Int64
+}
+
+// NewBuffer cria a fila (o worker começa em Start)
+func NewBuffer(store storage.AnalyticsStore, config BufferConfig) *Buffer {
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultBatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaultFlushInterval
+	}
+	if config.QueueLimit <= 0 {
+		config.QueueLimit = defaultQueueLimit
+	}
+	return &Buffer{
+		store:  store,
+		config: config,
+		full:   make(chan struct{}, 1),
+	}
+}
+
+// Add coloca o evento na fila sem bloquear
+// Retorna false se o evento foi descartado (fila cheia ou encerrada).
+func (b *Buffer) Add(event *storage.AnalyticsEvent) bool {
+	b.mu.Lock()
+	if b.closed || len(b.pending) >= b.config.QueueLimit {
+		b.mu.Unlock()
+		b.dropped.Add(1)
+		return false
+	}
+	b.pending = append(b.pending, event)
+	reachedBatch := len(b.pending) >= b.config.BatchSize
+	b.mu.Unlock()
+
+	if reachedBatch {
+		select {
+		case b.full <- struct{}{}:
+		default: // Gravação já sinalizada
+		}
+	}
+	return true
+}
+
+// Start inicia o worker de gravação (encerra quando ctx é cancelado;
+// a gravação final fica com Stop)
+func (b *Buffer) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(b.config.FlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				b.Flush()
+			case <-b.full:
+				b.Flush()
+			}
+		}
+	}()
+}
+
+// Stop recusa novos eventos e grava os que restaram na fila
+// Respeita o prazo de ctx: o que não couber no prazo é descartado.
+func (b *Buffer) Stop(ctx context.Context) {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.Flush()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Printf("[Analytics] Encerramento sem gravar todos os eventos: %v", ctx.Err())
+	}
+}
+
+// Flush grava a fila inteira, em lotes de BatchSize
+func (b *Buffer) Flush() {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	b.mu.Lock()
+	events := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+
+	for start := 0; start < len(events); start += b.config.BatchSize {
+		end := start + b.config.BatchSize
+		if end > len(events) {
+			end = len(events)
+		}
+		batch := events[start:end]
+
+		tracked, err := b.store.TrackEvents(batch)
+		if err != nil {
+			b.failed.Add(int64(len(batch)))
+			log.Printf("[Analytics] Erro ao gravar lote de %d eventos (descartado): %v", len(batch), err)
+			continue
+		}
+		b.written.Add(int64(tracked))
+	}
+}
+
+// Stats retorna os contadores da fila
+func (b *Buffer) Stats() BufferStats {
+	b.mu.Lock()
+	depth := len(b.pending)
+	b.mu.Unlock()
+
+	return BufferStats{
+		Depth:   depth,
+		Written: b.written.Load(),
+		Dropped: b.dropped.Load(),
+		Failed:  b.failed.Load(),
+	}
+}
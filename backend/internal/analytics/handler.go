@@ -5,9 +5,29 @@
 //
 // Endpoints:
 // - POST /api/analytics/track - Rastreia um evento
+// - POST /api/analytics/batch - Rastreia até 100 eventos de uma vez
+// - POST /api/analytics/public - Evento de visitante sem login (landing page)
 // - GET /api/admin/analytics/summary - Resumo de analytics (admin)
 // - GET /api/admin/analytics/events - Eventos recentes (admin)
 // - GET /api/admin/analytics/daily - Estatísticas diárias (admin)
+// - GET /api/admin/analytics/funnel - Funil de ativação (admin)
+// - GET /api/admin/analytics/cohorts - Coortes semanais de retenção (admin)
+// - GET /api/admin/analytics/completeness - Média diária das notas de preparo (admin)
+//
+// Os eventos são enriquecidos no servidor com o país (headers do CDN) e a
+// classe do dispositivo (User-Agent). O client_event_id opcional evita
+// duplicatas quando o cliente reenvia um lote. Acima do limite diário por
+// usuário apenas uma amostra dos eventos é gravada (sampler.go).
+//
+// Eventos avulsos (track e public) são gravados em lote, em segundo plano
+// (buffer.go); o batch grava na hora para informar duplicatas.
+//
+// Visitantes sem login são identificados pelo cookie famli_anon
+// (auth/anonymous.go); no cadastro os eventos passam para o usuário.
+//
+// Funil e coortes aceitam from/to (YYYY-MM-DD, UTC, "to" inclusivo) e
+// consideram os usuários cadastrados no período. As notas de preparo aceitam
+// o mesmo período, contado pelos dias em que a nota foi registrada.
 //
 // Eventos rastreados:
 // - page_view: Visualização de página
@@ -31,6 +51,7 @@ import (
 	"strings"
 	"time"
 
+	"famli/internal/apierror"
 	"famli/internal/auth"
 	"famli/internal/i18n"
 	"famli/internal/security"
@@ -39,28 +60,49 @@ import (
 	"github.com/google/uuid"
 )
 
-// writeError escreve resposta de erro JSON internacionalizada
-func writeError(w http.ResponseWriter, status int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(map[string]string{"error": message})
-}
-
 // Handler gerencia operações de analytics
 type Handler struct {
-	store storage.Store
+	store   storage.AnalyticsStore
+	sampler *Sampler
+	buffer  *Buffer // Fila de gravação em lote (nil = grava na hora)
 }
 
 // NewHandler cria uma nova instância do handler
-func NewHandler(store storage.Store) *Handler {
-	return &Handler{store: store}
+//
+// Parâmetros:
+//   - store: armazenamento de dados
+//   - sampler: amostragem dos eventos de usuários muito ativos (nil = grava todos)
+//   - buffer: fila de gravação em lote dos eventos avulsos (nil = grava na hora)
+func NewHandler(store storage.AnalyticsStore, sampler *Sampler, buffer *Buffer) *Handler {
+	return &Handler{store: store, sampler: sampler, buffer: buffer}
 }
 
 // TrackRequest representa o payload para rastrear um evento
 type TrackRequest struct {
-	EventType string            `json:"event_type"` // Tipo do evento
-	Page      string            `json:"page"`       // Página atual
-	Details   map[string]string `json:"details"`    // Detalhes adicionais
+	EventType     string            `json:"event_type"`      // Tipo do evento
+	Page          string            `json:"page"`            // Página atual
+	Details       map[string]string `json:"details"`         // Detalhes adicionais
+	ClientEventID string            `json:"client_event_id"` // ID único gerado pelo cliente (opcional)
+	OccurredAt    *time.Time        `json:"occurred_at"`     // Quando aconteceu (opcional, eventos em fila)
+}
+
+// BatchRequest representa o payload de um lote de eventos
+type BatchRequest struct {
+	Events []TrackRequest `json:"events"`
+}
+
+// validEvents são os tipos de evento aceitos
+var validEvents = map[string]bool{
+	"page_view":       true,
+	"login":           true,
+	"register":        true,
+	"create_item":     true,
+	"edit_item":       true,
+	"delete_item":     true,
+	"create_guardian": true,
+	"complete_guide":  true,
+	"export_data":     true,
+	"send_feedback":   true,
 }
 
 // Track rastreia um evento de analytics
@@ -71,48 +113,165 @@ func (h *Handler) Track(w http.ResponseWriter, r *http.Request) {
 
 	var req TrackRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "analytics.invalid_data"))
+		apierror.Write(w, r, http.StatusBadRequest, "analytics.invalid_data")
 		return
 	}
 
-	// Validar tipo do evento
-	validEvents := map[string]bool{
-		"page_view":       true,
-		"login":           true,
-		"register":        true,
-		"create_item":     true,
-		"edit_item":       true,
-		"delete_item":     true,
-		"create_guardian": true,
-		"complete_guide":  true,
-		"export_data":     true,
-		"send_feedback":   true,
-	}
-
-	if !validEvents[req.EventType] {
+	event := h.buildEvent(r, userID, &req, time.Now())
+	if event == nil {
 		// Ignorar eventos desconhecidos silenciosamente
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]string{"status": "ignored"})
 		return
 	}
 
-	// Criar evento
-	event := &storage.AnalyticsEvent{
-		ID:        uuid.New().String(),
-		UserID:    userID,
-		EventType: storage.AnalyticsEventType(req.EventType),
-		Page:      req.Page,
-		Details:   sanitizeAnalyticsDetails(req.Details),
-		CreatedAt: time.Now(),
+	// Gravar em lote (silenciosamente ignora erros - tracking não deve bloquear UX)
+	if h.sampler.Keep(userID) {
+		h.enqueue(event)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "tracked"})
+}
+
+// anonymousEvents são os tipos aceitos de visitantes sem login
+var anonymousEvents = map[string]bool{
+	"page_view": true,
+}
+
+// TrackAnonymous rastreia um evento de visitante sem login
+// POST /api/analytics/public
+//
+// Público e com rate limit próprio por IP. Aceita apenas page_view
+// (detalhes como utm_source vão em details). Robôs são ignorados.
+func (h *Handler) TrackAnonymous(w http.ResponseWriter, r *http.Request) {
+	var req TrackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "analytics.invalid_data")
+		return
+	}
+
+	event := h.buildEvent(r, "", &req, time.Now())
+	if event == nil || !anonymousEvents[req.EventType] || event.DeviceClass == security.DeviceBot {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"status": "ignored"})
+		return
 	}
 
-	// Salvar no banco (silenciosamente ignora erros - tracking não deve bloquear UX)
-	_ = h.store.TrackEvent(event)
+	event.AnonymousID = auth.EnsureAnonymousID(w, r)
+	event.ClientEventID = "" // Deduplicação só para usuários autenticados
+
+	if h.sampler.Keep(event.AnonymousID) {
+		h.enqueue(event)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"status": "tracked"})
 }
 
+// enqueue coloca o evento na fila de gravação em lote
+// Sem fila, grava na hora. Erros e descartes são ignorados.
+func (h *Handler) enqueue(event *storage.AnalyticsEvent) {
+	if h.buffer == nil {
+		_ = h.store.TrackEvent(event)
+		return
+	}
+	h.buffer.Add(event)
+}
+
+// Batch rastreia um lote de eventos (ex: fila do cliente enviada ao voltar online)
+// POST /api/analytics/batch
+//
+// Eventos de tipo desconhecido ou inválidos são ignorados sem recusar o lote.
+// A resposta informa quantos foram gravados, duplicados, descartados pela
+// amostragem e ignorados.
+func (h *Handler) Batch(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	var req BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Events) == 0 {
+		apierror.Write(w, r, http.StatusBadRequest, "analytics.invalid_data")
+		return
+	}
+	if len(req.Events) > maxBatchEvents {
+		apierror.Writef(w, r, http.StatusBadRequest, "analytics.batch_too_large", i18n.Vars{"max": maxBatchEvents})
+		return
+	}
+
+	now := time.Now()
+	events := make([]*storage.AnalyticsEvent, 0, len(req.Events))
+	ignored, sampled := 0, 0
+	for i := range req.Events {
+		event := h.buildEvent(r, userID, &req.Events[i], now)
+		switch {
+		case event == nil:
+			ignored++
+		case !h.sampler.Keep(userID):
+			sampled++
+		default:
+			events = append(events, event)
+		}
+	}
+
+	tracked := 0
+	if len(events) > 0 {
+		var err error
+		if tracked, err = h.store.TrackEvents(events); err != nil {
+			apierror.Write(w, r, http.StatusInternalServerError, "analytics.track_error")
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":     "tracked",
+		"tracked":    tracked,
+		"duplicates": len(events) - tracked,
+		"sampled":    sampled,
+		"ignored":    ignored,
+	})
+}
+
+// Limites dos eventos enviados pelo cliente
+const (
+	maxBatchEvents         = 100
+	maxClientEventIDLen    = 64
+	maxEventAge            = 7 * 24 * time.Hour // Eventos em fila mais antigos usam a hora do envio
+	maxEventClockSkew      = 5 * time.Minute
+	maxAnalyticsPageLength = 100
+)
+
+// buildEvent valida o payload e monta o evento enriquecido
+// Retorna nil para tipos desconhecidos ou client_event_id inválido.
+func (h *Handler) buildEvent(r *http.Request, userID string, req *TrackRequest, now time.Time) *storage.AnalyticsEvent {
+	if !validEvents[req.EventType] {
+		return nil
+	}
+
+	clientEventID := strings.TrimSpace(req.ClientEventID)
+	if len(clientEventID) > maxClientEventIDLen {
+		return nil
+	}
+
+	// Hora informada pelo cliente, se plausível
+	createdAt := now
+	if req.OccurredAt != nil && req.OccurredAt.After(now.Add(-maxEventAge)) && req.OccurredAt.Before(now.Add(maxEventClockSkew)) {
+		createdAt = *req.OccurredAt
+	}
+
+	return &storage.AnalyticsEvent{
+		ID:            uuid.New().String(),
+		UserID:        userID,
+		EventType:     storage.AnalyticsEventType(req.EventType),
+		Page:          security.SanitizeText(req.Page, maxAnalyticsPageLength),
+		Details:       sanitizeAnalyticsDetails(req.Details),
+		ClientEventID: clientEventID,
+		Country:       security.GetClientCountry(r),
+		DeviceClass:   security.DeviceClass(r.UserAgent()),
+		CreatedAt:     createdAt,
+	}
+}
+
 const (
 	maxAnalyticsDetailsEntries    = 20
 	maxAnalyticsDetailKeyLength   = 64
@@ -179,7 +338,7 @@ func (h *Handler) GetRecentEvents(w http.ResponseWriter, r *http.Request) {
 
 	events, err := h.store.GetRecentEvents(limit)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, i18n.Tr(r, "analytics.track_error"))
+		apierror.Write(w, r, http.StatusInternalServerError, "analytics.track_error")
 		return
 	}
 
@@ -202,7 +361,7 @@ func (h *Handler) GetDailyStats(w http.ResponseWriter, r *http.Request) {
 
 	stats, err := h.store.GetDailyStats(days)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, i18n.Tr(r, "analytics.track_error"))
+		apierror.Write(w, r, http.StatusInternalServerError, "analytics.track_error")
 		return
 	}
 
@@ -213,3 +372,116 @@ func (h *Handler) GetDailyStats(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(stats)
 }
+
+// Períodos padrão e máximo do funil, das coortes e das notas de preparo
+const (
+	defaultFunnelDays       = 30
+	defaultCohortDays       = 8 * 7
+	defaultCompletenessDays = 30
+	maxRangeDays            = 366
+)
+
+// GetFunnel retorna o funil de ativação (admin only)
+// GET /api/admin/analytics/funnel?from=2024-01-01&to=2024-01-31
+//
+// Etapas: registered → first_item → guardian → shared. Cada etapa conta
+// apenas quem também passou pelas anteriores.
+func (h *Handler) GetFunnel(w http.ResponseWriter, r *http.Request) {
+	from, to, ok := parseRange(r, defaultFunnelDays)
+	if !ok {
+		apierror.Write(w, r, http.StatusBadRequest, "analytics.invalid_range")
+		return
+	}
+
+	funnel, err := h.store.GetFunnel(from, to)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "analytics.query_error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(funnel)
+}
+
+// GetCohorts retorna as coortes semanais de retenção (admin only)
+// GET /api/admin/analytics/cohorts?from=2024-01-01&to=2024-03-31
+//
+// Os usuários são agrupados pela semana de cadastro (segunda-feira, UTC);
+// retained[n] conta quem teve atividade n semanas depois.
+func (h *Handler) GetCohorts(w http.ResponseWriter, r *http.Request) {
+	from, to, ok := parseRange(r, defaultCohortDays)
+	if !ok {
+		apierror.Write(w, r, http.StatusBadRequest, "analytics.invalid_range")
+		return
+	}
+
+	cohorts, err := h.store.GetRetentionCohorts(from, to)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "analytics.query_error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"from":    from,
+		"to":      to,
+		"cohorts": cohorts,
+	})
+}
+
+// GetCompleteness retorna a média diária das notas de preparo (admin only)
+// GET /api/admin/analytics/completeness?from=2024-01-01&to=2024-01-31
+//
+// Cada usuário conta uma vez por dia em que consultou a nota
+// (GET /api/box/completeness), com a última nota do dia.
+func (h *Handler) GetCompleteness(w http.ResponseWriter, r *http.Request) {
+	from, to, ok := parseRange(r, defaultCompletenessDays)
+	if !ok {
+		apierror.Write(w, r, http.StatusBadRequest, "analytics.invalid_range")
+		return
+	}
+
+	days, err := h.store.GetCompletenessTrend(from, to)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "analytics.query_error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"from": from,
+		"to":   to,
+		"days": days,
+	})
+}
+
+// parseRange lê from/to (YYYY-MM-DD) da query e retorna o intervalo [from, to)
+//
+// Sem parâmetros usa os últimos defaultDays dias até hoje. Intervalos
+// invertidos ou maiores que maxRangeDays são recusados.
+func parseRange(r *http.Request, defaultDays int) (time.Time, time.Time, bool) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	to := today.AddDate(0, 0, 1)
+	from := to.AddDate(0, 0, -defaultDays)
+
+	if value := r.URL.Query().Get("to"); value != "" {
+		day, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return time.Time{}, time.Time{}, false
+		}
+		to = day.AddDate(0, 0, 1)
+		from = to.AddDate(0, 0, -defaultDays)
+	}
+	if value := r.URL.Query().Get("from"); value != "" {
+		day, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return time.Time{}, time.Time{}, false
+		}
+		from = day
+	}
+
+	if !from.Before(to) || to.Sub(from) > maxRangeDays*24*time.Hour {
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
@@ -0,0 +1,65 @@
+// =============================================================================
+// FAMLI - Rollups Diários de Analytics
+// =============================================================================
+// O resumo do painel admin (GET /api/admin/analytics/summary) não lê mais a
+// semana inteira de analytics_events: os dias fechados são consolidados em
+// analytics_daily e o resumo soma esses rollups com os eventos de hoje.
+//
+// O worker roda ao iniciar e a cada hora. Dias já consolidados são pulados,
+// exceto os últimos três, recalculados a cada execução para incluir eventos
+// que chegam atrasados. Enquanto a semana não estiver consolidada, o resumo
+// é calculado direto dos eventos.
+// =============================================================================
+
+package analytics
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"famli/internal/storage"
+)
+
+// rollupInterval é o intervalo entre verificações de dias a consolidar
+const rollupInterval = time.Hour
+
+// Rollup consolida os dias fechados de analytics
+type Rollup struct {
+	store storage.AnalyticsStore
+}
+
+// NewRollup cria o worker de rollups
+func NewRollup(store storage.AnalyticsStore) *Rollup {
+	return &Rollup{store: store}
+}
+
+// Start inicia o worker (encerra quando ctx é cancelado)
+func (r *Rollup) Start(ctx context.Context) {
+	go func() {
+		r.run()
+
+		ticker := time.NewTicker(rollupInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.run()
+			}
+		}
+	}()
+}
+
+// run consolida os dias pendentes
+func (r *Rollup) run() {
+	days, err := r.store.RollupAnalytics()
+	if err != nil {
+		log.Printf("[Analytics] Erro ao consolidar dias: %v", err)
+		return
+	}
+	if days > 0 {
+		log.Printf("[Analytics] %d dia(s) consolidado(s) em analytics_daily", days)
+	}
+}
@@ -0,0 +1,62 @@
+// =============================================================================
+// FAMLI - Amostragem de Eventos
+// =============================================================================
+// Usuários muito ativos (ou clientes com bug) podem gerar milhares de eventos
+// por dia. Até o limite diário todos os eventos do usuário são gravados; acima
+// dele apenas uma amostra (percentual configurável) é gravada.
+//
+// A contagem é em memória e por instância, reiniciada a cada dia (UTC).
+// Com várias instâncias o limite efetivo é proporcional ao número delas.
+// =============================================================================
+
+package analytics
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Sampler decide quais eventos de cada usuário são gravados
+type Sampler struct {
+	dailyLimit int // 0 = grava todos os eventos
+	percent    int // Percentual gravado acima do limite (0-100)
+
+	mu     sync.Mutex
+	day    string
+	counts map[string]int // userID -> eventos no dia
+}
+
+// NewSampler cria o amostrador
+//
+// Parâmetros:
+//   - dailyLimit: eventos por usuário/dia gravados integralmente (0 = sem amostragem)
+//   - percent: percentual gravado acima do limite
+func NewSampler(dailyLimit, percent int) *Sampler {
+	return &Sampler{
+		dailyLimit: dailyLimit,
+		percent:    percent,
+		counts:     make(map[string]int),
+	}
+}
+
+// Keep indica se o próximo evento do usuário deve ser gravado
+func (s *Sampler) Keep(userID string) bool {
+	if s == nil || s.dailyLimit <= 0 {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if today := time.Now().UTC().Format("2006-01-02"); today != s.day {
+		s.day = today
+		s.counts = make(map[string]int)
+	}
+
+	s.counts[userID]++
+	if s.counts[userID] <= s.dailyLimit {
+		return true
+	}
+	return rand.Intn(100) < s.percent
+}
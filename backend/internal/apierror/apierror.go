@@ -0,0 +1,148 @@
+// =============================================================================
+// FAMLI - Erros da API
+// =============================================================================
+// Formato único das respostas de erro, para que os clientes decidam pelo
+// código (estável) e exibam a mensagem (traduzida):
+//
+//	{
+//	  "code": "BOX_NOT_FOUND",
+//	  "message": "Item não encontrado.",
+//	  "error": "Item não encontrado.",
+//	  "details": {"max": 100},
+//	  "request_id": "famli/abc123-000042"
+//	}
+//
+// - code: derivado da chave de tradução ("box.not_found" → BOX_NOT_FOUND)
+//   ou explícito (SESSION_EXPIRED, QUOTA_EXCEEDED, PREMIUM_REQUIRED)
+// - message: texto no idioma da requisição
+// - error: igual a message, mantido para clientes antigos (obsoleto)
+// - details: dados extras do erro (opcional)
+// - request_id: o mesmo ID dos logs (X-Request-Id), para suporte
+// =============================================================================
+
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"famli/internal/i18n"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+// Response é o corpo das respostas de erro
+type Response struct {
+	Code      string                 `json:"code"`
+	Message   string                 `json:"message"`
+	Error     string                 `json:"error"` // Obsoleto: use message
+	Details   map[string]interface{} `json:"details,omitempty"`
+	RequestID string                 `json:"request_id,omitempty"`
+}
+
+// Code converte uma chave de tradução no código do erro
+// Ex: "box.not_found" → "BOX_NOT_FOUND".
+func Code(key string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+// Write responde com o erro da chave de tradução key
+func Write(w http.ResponseWriter, r *http.Request, status int, key string) {
+	WriteMessage(w, r, status, Code(key), i18n.Tr(r, key), nil)
+}
+
+// Writef responde com uma mensagem interpolada; vars também vão em details
+func Writef(w http.ResponseWriter, r *http.Request, status int, key string, vars i18n.Vars) {
+	WriteMessage(w, r, status, Code(key), i18n.Trf(r, key, vars), vars)
+}
+
+// WriteCode responde com um código explícito e a mensagem da chave key
+// Para códigos que não seguem a chave (ex: SESSION_EXPIRED, usado pelo frontend).
+func WriteCode(w http.ResponseWriter, r *http.Request, status int, code, key string) {
+	WriteMessage(w, r, status, code, i18n.Tr(r, key), nil)
+}
+
+// WriteMessage responde com código, mensagem já traduzida e detalhes
+func WriteMessage(w http.ResponseWriter, r *http.Request, status int, code, message string, details map[string]interface{}) {
+	security.SetJSONHeaders(w)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Response{
+		Code:      code,
+		Message:   message,
+		Error:     message,
+		Details:   details,
+		RequestID: middleware.GetReqID(r.Context()),
+	})
+}
+
+// WriteDecodeError responde a um erro ao ler o corpo da requisição
+//
+//	*http.MaxBytesError            → 413 request.body_too_large
+//	*security.UnknownFieldError    → 400 request.unknown_field (details.field)
+//	outros                         → 400 key
+func WriteDecodeError(w http.ResponseWriter, r *http.Request, err error, key string) {
+	var tooLarge *http.MaxBytesError
+	var unknown *security.UnknownFieldError
+	switch {
+	case errors.As(err, &tooLarge):
+		Write(w, r, http.StatusRequestEntityTooLarge, "request.body_too_large")
+	case errors.As(err, &unknown):
+		Writef(w, r, http.StatusBadRequest, "request.unknown_field", i18n.Vars{"field": unknown.Field})
+	default:
+		Write(w, r, http.StatusBadRequest, key)
+	}
+}
+
+// =============================================================================
+// ERROS DO STORAGE
+// =============================================================================
+
+// Status mapeia um erro do storage para o status HTTP
+//
+//	storage.ErrNotFound      → 404
+//	storage.ErrAlreadyExists → 409
+//	storage.ErrInvalidData   → 400
+//	outros                   → 500
+func Status(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, storage.ErrAlreadyExists):
+		return http.StatusConflict
+	case errors.Is(err, storage.ErrInvalidData):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// StorageKeys são as chaves de tradução para cada tipo de erro do storage
+// Tipos sem chave respondem 500 com Internal.
+type StorageKeys struct {
+	NotFound string // 404
+	Conflict string // 409
+	Invalid  string // 400
+	Internal string // 500
+}
+
+// WriteStorage responde a um erro do storage com o status de Status(err)
+func WriteStorage(w http.ResponseWriter, r *http.Request, err error, keys StorageKeys) {
+	status := Status(err)
+	key := keys.Internal
+	switch status {
+	case http.StatusNotFound:
+		key = keys.NotFound
+	case http.StatusConflict:
+		key = keys.Conflict
+	case http.StatusBadRequest:
+		key = keys.Invalid
+	}
+	if key == "" {
+		status, key = http.StatusInternalServerError, keys.Internal
+	}
+	Write(w, r, status, key)
+}
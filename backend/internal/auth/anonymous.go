@@ -0,0 +1,81 @@
+// =============================================================================
+// FAMLI - Visitante Anônimo
+// =============================================================================
+// Antes do cadastro o visitante é identificado por um ID aleatório no cookie
+// famli_anon (sem dados pessoais), usado pelos eventos públicos de analytics.
+//
+// No cadastro ou login social os eventos anônimos do visitante passam para
+// o usuário, completando o funil de aquisição (landing → cadastro → ...).
+// =============================================================================
+
+package auth
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"famli/internal/ids"
+	"famli/internal/storage"
+)
+
+const (
+	// AnonymousCookie é o cookie com o ID do visitante anônimo
+	AnonymousCookie = "famli_anon"
+
+	// anonymousCookieMaxAge é a validade do cookie (1 ano)
+	anonymousCookieMaxAge = 365 * 24 * 60 * 60
+
+	// maxAnonymousIDLength limita o ID aceito do cookie
+	maxAnonymousIDLength = 50
+)
+
+// AnonymousID retorna o ID do visitante do cookie (vazio se ausente ou inválido)
+func AnonymousID(r *http.Request) string {
+	cookie, err := r.Cookie(AnonymousCookie)
+	if err != nil {
+		return ""
+	}
+	value := cookie.Value
+	if len(value) > maxAnonymousIDLength || !strings.HasPrefix(value, ids.Anonymous+"_") {
+		return ""
+	}
+	for _, c := range value[len(ids.Anonymous)+1:] {
+		if !(c >= '0' && c <= '9') && !(c >= 'A' && c <= 'Z') {
+			return ""
+		}
+	}
+	return value
+}
+
+// EnsureAnonymousID retorna o ID do visitante, criando o cookie se necessário
+func EnsureAnonymousID(w http.ResponseWriter, r *http.Request) string {
+	if id := AnonymousID(r); id != "" {
+		return id
+	}
+
+	id := ids.New(ids.Anonymous)
+	http.SetCookie(w, &http.Cookie{
+		Name:     AnonymousCookie,
+		Value:    id,
+		Path:     "/",
+		MaxAge:   anonymousCookieMaxAge,
+		HttpOnly: true,
+		Secure:   isSecureContext(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	return id
+}
+
+// LinkAnonymousActivity atribui ao usuário os eventos anônimos deste navegador
+//
+// Falhas são apenas registradas: não devem impedir o cadastro/login.
+func LinkAnonymousActivity(store storage.Store, r *http.Request, userID string) {
+	anonymousID := AnonymousID(r)
+	if anonymousID == "" {
+		return
+	}
+	if _, err := store.LinkAnonymousEvents(anonymousID, userID); err != nil {
+		log.Printf("[ANALYTICS] Erro ao vincular eventos anônimos: %v", err)
+	}
+}
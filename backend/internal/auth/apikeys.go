@@ -0,0 +1,277 @@
+// =============================================================================
+// FAMLI - Chaves Pessoais de API
+// =============================================================================
+// Para quem quer levar os próprios dados para scripts, sem o frontend:
+//
+//	curl -H "Authorization: Bearer famli_..." https://famli.me/api/box/items
+//
+// Endpoints (apenas com a sessão do usuário; uma chave não gerencia chaves):
+// - GET    /api/keys          - chaves da conta (sem a chave em si)
+// - POST   /api/keys          - cria ({name, scopes}); a chave aparece só aqui
+// - DELETE /api/keys/{keyID}  - revoga
+//
+// As chaves são somente leitura (GET/HEAD) e valem apenas nas rotas listadas
+// para as permissões escolhidas (apiKeyRoutes): items:read (itens e os
+// recados dos guardiões, que trazem o conteúdo dos itens) e guardians:read
+// (lista, alertas e regras de acesso). Só o hash SHA-256 é guardado. Cada chave tem o
+// próprio limite de requisições (security.APIKeyRateLimit) e o último uso
+// fica registrado.
+// =============================================================================
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+
+	"famli/internal/apierror"
+	"famli/internal/i18n"
+	"famli/internal/ids"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+const (
+	apiKeyIDKey contextKey = "api_key_id"
+
+	// maxAPIKeys limita as chaves de uma conta
+	maxAPIKeys = 10
+
+	// maxAPIKeyNameLength limita o nome da chave (em caracteres)
+	maxAPIKeyNameLength = 60
+
+	// apiKeyPrefixLength é o início da chave mostrado na listagem
+	apiKeyPrefixLength = 12
+
+	// apiKeyTouchInterval evita gravar o último uso a cada requisição
+	apiKeyTouchInterval = time.Minute
+)
+
+// apiKeyRoutes são as rotas liberadas por cada permissão, comparadas segmento
+// a segmento ({param} aceita qualquer valor). Rotas novas não entram sozinhas.
+var apiKeyRoutes = map[storage.APIKeyScope][]string{
+	storage.APIKeyItemsRead: {
+		"/api/box/items",
+		"/api/box/items/{itemID}",
+		"/api/box/items/{itemID}/comments",
+		"/api/box/items/{itemID}/views",
+		"/api/guardians/{guardianID}/messages", // Conteúdo dos itens do guardião
+	},
+	storage.APIKeyGuardiansRead: {
+		"/api/guardians",
+		"/api/guardians/alerts",
+		"/api/guardians/{guardianID}/access-policy",
+	},
+}
+
+// apiKeyAllows indica se as permissões da chave cobrem o caminho
+func apiKeyAllows(key *storage.APIKey, path string) bool {
+	for _, scope := range key.Scopes {
+		for _, route := range apiKeyRoutes[scope] {
+			if matchRoute(route, path) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchRoute compara o caminho com a rota, segmento a segmento
+func matchRoute(route, path string) bool {
+	routeParts := strings.Split(route, "/")
+	pathParts := strings.Split(path, "/")
+	if len(routeParts) != len(pathParts) {
+		return false
+	}
+	for i, part := range routeParts {
+		if strings.HasPrefix(part, "{") {
+			if pathParts[i] == "" {
+				return false
+			}
+			continue
+		}
+		if part != pathParts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// APIKeyMiddleware autentica as requisições com chave pessoal de API
+// Deve vir antes do JWTMiddleware, que deixa passar as requisições já
+// autenticadas por chave. Sem "Authorization: Bearer famli_...", nada muda.
+func APIKeyMiddleware(store storage.Store, limiter *security.RateLimiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			raw, bearer := sessionToken(r)
+			if !bearer || !strings.HasPrefix(raw, security.APIKeyPrefix) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key, err := store.GetAPIKeyByHash(security.HashToken(raw))
+			if err != nil {
+				apierror.Write(w, r, http.StatusUnauthorized, "auth.api_key_invalid")
+				return
+			}
+
+			allowed, retryAfter := limiter.Allow(key.ID)
+			if !allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+				apierror.Write(w, r, http.StatusTooManyRequests, "security.rate_limited")
+				return
+			}
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				apierror.Write(w, r, http.StatusForbidden, "auth.api_key_read_only")
+				return
+			}
+			if !apiKeyAllows(key, r.URL.Path) {
+				apierror.Write(w, r, http.StatusForbidden, "auth.api_key_scope")
+				return
+			}
+
+			now := time.Now()
+			if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > apiKeyTouchInterval {
+				_ = store.TouchAPIKey(key.ID, now)
+			}
+
+			ctx := context.WithValue(r.Context(), userIDKey, key.UserID)
+			ctx = context.WithValue(ctx, userRoleKey, storage.RoleNone)
+			ctx = context.WithValue(ctx, apiKeyIDKey, key.ID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetAPIKeyID extrai a chave de API da requisição do contexto
+// Vazio para sessões (cookie ou token de app).
+func GetAPIKeyID(r *http.Request) string {
+	if keyID, ok := r.Context().Value(apiKeyIDKey).(string); ok {
+		return keyID
+	}
+	return ""
+}
+
+// createAPIKeyRequest é o payload da criação de chave
+type createAPIKeyRequest struct {
+	Name   string                `json:"name"`
+	Scopes []storage.APIKeyScope `json:"scopes"`
+}
+
+// createAPIKeyResponse é a chave criada, com o valor que não será mostrado de novo
+type createAPIKeyResponse struct {
+	*storage.APIKey
+	Key string `json:"key"`
+}
+
+// ListAPIKeys lista as chaves de API do usuário
+//
+// Endpoint: GET /api/keys
+func (h *Handler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.store.ListAPIKeys(GetUserID(r))
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "auth.api_keys_error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys":  keys,
+		"total": len(keys),
+	})
+}
+
+// CreateAPIKey cria uma chave de API somente leitura
+// A chave volta apenas nesta resposta; depois, só o prefixo.
+//
+// Endpoint: POST /api/keys
+func (h *Handler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID := GetUserID(r)
+
+	var req createAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "auth.invalid_data")
+		return
+	}
+	name := security.SanitizeText(req.Name, 0)
+	if name == "" || utf8.RuneCountInString(name) > maxAPIKeyNameLength {
+		apierror.Write(w, r, http.StatusBadRequest, "auth.api_key_name_invalid")
+		return
+	}
+	scopes := make([]storage.APIKeyScope, 0, len(req.Scopes))
+	seen := make(map[storage.APIKeyScope]bool, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		if _, ok := apiKeyRoutes[scope]; !ok {
+			apierror.Write(w, r, http.StatusBadRequest, "auth.api_key_scopes_invalid")
+			return
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		apierror.Write(w, r, http.StatusBadRequest, "auth.api_key_scopes_invalid")
+		return
+	}
+
+	existing, err := h.store.ListAPIKeys(userID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "auth.api_keys_error")
+		return
+	}
+	if len(existing) >= maxAPIKeys {
+		apierror.Write(w, r, http.StatusConflict, "auth.api_key_limit")
+		return
+	}
+
+	raw := security.GenerateAPIKey()
+	key := &storage.APIKey{
+		ID:        ids.New(ids.APIKey),
+		UserID:    userID,
+		Name:      name,
+		Prefix:    raw[:apiKeyPrefixLength],
+		KeyHash:   security.HashToken(raw),
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+	}
+	if err := h.store.CreateAPIKey(key); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "auth.api_keys_error")
+		return
+	}
+
+	h.auditLogger.LogAuth(security.EventAPIKeyCreated, userID, security.GetClientIP(r), r.UserAgent(), "success", map[string]interface{}{
+		"key_id": key.ID,
+		"scopes": key.Scopes,
+	})
+
+	writeJSON(w, http.StatusCreated, createAPIKeyResponse{APIKey: key, Key: raw})
+}
+
+// DeleteAPIKey revoga uma chave de API
+// A próxima requisição com a chave recebe 401.
+//
+// Endpoint: DELETE /api/keys/{keyID}
+func (h *Handler) DeleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID := GetUserID(r)
+	keyID := chi.URLParam(r, "keyID")
+
+	if err := h.store.DeleteAPIKey(userID, keyID); err != nil {
+		apierror.WriteStorage(w, r, err, apierror.StorageKeys{NotFound: "auth.api_key_not_found", Internal: "auth.api_keys_error"})
+		return
+	}
+
+	h.auditLogger.LogAuth(security.EventAPIKeyRevoked, userID, security.GetClientIP(r), r.UserAgent(), "success", map[string]interface{}{
+		"key_id": keyID,
+	})
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"message": i18n.Tr(r, "auth.api_key_revoked"),
+	})
+}
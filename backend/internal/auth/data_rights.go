@@ -0,0 +1,337 @@
+// =============================================================================
+// FAMLI - Direitos do Titular (LGPD Art. 18)
+// =============================================================================
+// Completa os direitos já atendidos em handler.go (exportação e exclusão):
+//
+//   - GET /api/auth/data-map: inventário dos dados pessoais guardados, onde
+//     ficam, se são criptografados e por quanto tempo são mantidos
+//   - PATCH /api/auth/profile: correção do nome e do email da conta
+//
+// O inventário é estático (muda com o código, não com o usuário) e deve ser
+// atualizado junto com as migrações que criam dados pessoais.
+// =============================================================================
+
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+
+	"famli/internal/apierror"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+// Políticas de retenção do inventário
+const (
+	retentionAccount    = "account"          // Até a exclusão (pelo usuário ou da conta)
+	retentionDays       = "days"             // Removido Days dias após o registro
+	retentionInactivity = "inactivity_days"  // Removido Days dias após o último uso
+	retentionExpiry     = "until_expiration" // Removido ao vencer
+	retentionManual     = "manual"           // Até a equipe remover
+)
+
+// dataRetention descreve por quanto tempo uma categoria é mantida
+type dataRetention struct {
+	Policy string `json:"policy"`
+	Days   int    `json:"days,omitempty"`
+
+	// KeptAfterDeletion indica registros mantidos após a exclusão da conta
+	// (auditoria exigida por lei), até o fim do prazo
+	KeptAfterDeletion bool `json:"kept_after_deletion,omitempty"`
+	// DaysAfterDeletion é o prazo desses registros depois da exclusão
+	DaysAfterDeletion int `json:"days_after_deletion,omitempty"`
+}
+
+// dataCategory é uma categoria de dados pessoais do inventário
+type dataCategory struct {
+	ID        string        `json:"id"`
+	Location  string        `json:"location"` // Tabela no PostgreSQL
+	Fields    []string      `json:"fields"`
+	Encrypted []string      `json:"encrypted,omitempty"` // Campos criptografados (AES-256-GCM)
+	Retention dataRetention `json:"retention"`
+	Correct   string        `json:"correct,omitempty"` // Endpoint para corrigir
+	Delete    string        `json:"delete,omitempty"`  // Endpoint para excluir
+}
+
+// dataCategories monta o inventário com os prazos de retenção vigentes
+func dataCategories(days func(storage.RetentionClass) int) []dataCategory {
+	logs := func(class storage.RetentionClass) dataRetention {
+		return dataRetention{Policy: retentionDays, Days: days(class)}
+	}
+	account := dataRetention{Policy: retentionAccount}
+	// Auditoria: prazo próprio para contas ativas e outro depois da exclusão
+	audit := dataRetention{
+		Policy:            retentionDays,
+		Days:              days(storage.RetentionAudit),
+		KeptAfterDeletion: true,
+		DaysAfterDeletion: days(storage.RetentionDeletedAccountAudit),
+	}
+
+	return []dataCategory{
+		{
+			ID:        "account",
+			Location:  "users",
+			Fields:    []string{"email", "name", "password_hash", "provider", "avatar_url", "locale", "role", "plan", "terms_version", "privacy_version", "terms_accepted_at", "created_at"},
+			Retention: account,
+			Correct:   "PATCH /api/auth/profile",
+			Delete:    "DELETE /api/auth/account",
+		},
+		{
+			ID:        "settings",
+			Location:  "settings",
+			Fields:    []string{"emergency_protocol_enabled", "notifications_enabled", "notification_opt_outs", "digest_frequency", "theme"},
+			Retention: account,
+			Correct:   "PUT /api/settings",
+		},
+		{
+			ID:        "items",
+			Location:  "box_items",
+			Fields:    []string{"title", "content", "recipient", "category", "type", "checklist", "schedule", "document", "pet", "financial_account", "online_account", "created_at", "updated_at"},
+			Encrypted: []string{"title", "content", "recipient", "checklist", "schedule", "document", "pet", "financial_account", "online_account"},
+			Retention: account,
+			Correct:   "PUT /api/box/items/{itemID}",
+			Delete:    "DELETE /api/box/items/{itemID}",
+		},
+		{
+			ID:        "guardians",
+			Location:  "guardians",
+			Fields:    []string{"name", "email", "phone", "relationship", "notes", "access_pin_hash", "notify_channel"},
+			Encrypted: []string{"name", "email", "phone", "notes"},
+			Retention: account,
+			Correct:   "PUT /api/guardians/{guardianID}",
+			Delete:    "DELETE /api/guardians/{guardianID}",
+		},
+		{
+			ID:        "emergency_card",
+			Location:  "emergency_cards",
+			Fields:    []string{"name", "blood_type", "contacts", "medications", "include_schedule", "include_legal", "last_accessed_at"},
+			Encrypted: []string{"name", "blood_type", "contacts", "medications", "include_schedule", "include_legal"},
+			Retention: account,
+			Correct:   "PUT /api/emergency-card",
+			Delete:    "DELETE /api/emergency-card",
+		},
+		{
+			ID:        "share_links",
+			Location:  "share_links",
+			Fields:    []string{"name", "type", "categories", "passphrase_hint", "pin_hash", "expires_at", "max_uses", "usage_count", "last_used_at"},
+			Encrypted: []string{"passphrase_hint"},
+			Retention: dataRetention{Policy: retentionExpiry},
+			Correct:   "PATCH /api/share/links/{id}",
+			Delete:    "DELETE /api/share/links/{id}",
+		},
+		{
+			ID:        "share_link_accesses",
+			Location:  "share_link_accesses",
+			Fields:    []string{"ip_address", "user_agent", "accessed_at"},
+			Retention: logs(storage.RetentionShareAccesses),
+		},
+		{
+			ID:        "guardian_alerts",
+			Location:  "guardian_alerts",
+			Fields:    []string{"message", "status", "attempt_log", "created_at"},
+			Encrypted: []string{"message"},
+			Retention: logs(storage.RetentionGuardianAlerts),
+		},
+		{
+			ID:        "device_sessions",
+			Location:  "device_sessions",
+			Fields:    []string{"name", "user_agent", "ip_address", "created_at", "last_seen_at"},
+			Retention: dataRetention{Policy: retentionInactivity, Days: int(storage.DeviceSessionTTL.Hours() / 24)},
+			Correct:   "PUT /api/auth/devices/{deviceID}",
+			Delete:    "DELETE /api/auth/devices/{deviceID}",
+		},
+		{
+			ID:        "api_keys",
+			Location:  "api_keys",
+			Fields:    []string{"name", "prefix", "key_hash", "scopes", "created_at", "last_used_at"},
+			Retention: account,
+			Delete:    "DELETE /api/keys/{keyID}",
+		},
+		{
+			ID:        "login_history",
+			Location:  "login_history",
+			Fields:    []string{"ip_address", "country", "device_hash", "created_at"},
+			Retention: logs(storage.RetentionLoginHistory),
+		},
+		{
+			ID:        "notifications",
+			Location:  "notifications, push_subscriptions",
+			Fields:    []string{"title", "body", "url", "read_at", "endpoint", "p256dh", "auth", "user_agent"},
+			Encrypted: []string{"auth"},
+			Retention: account,
+			Delete:    "DELETE /api/notifications/subscribe",
+		},
+		{
+			ID:        "feedback",
+			Location:  "feedbacks, feedback_replies",
+			Fields:    []string{"user_email", "type", "message", "page", "admin_note", "created_at"},
+			Retention: dataRetention{Policy: retentionManual, KeptAfterDeletion: true},
+		},
+		{
+			ID:        "support_access",
+			Location:  "support_access",
+			Fields:    []string{"granted_at", "expires_at"},
+			Retention: dataRetention{Policy: retentionExpiry},
+			Delete:    "DELETE /api/settings/support-access",
+		},
+		{
+			ID:        "analytics",
+			Location:  "analytics_events, analytics_client_events",
+			Fields:    []string{"event_type", "page", "details", "country", "created_at"},
+			Retention: logs(storage.RetentionAnalytics),
+		},
+		{
+			ID:        "audit_log",
+			Location:  "audit_log",
+			Fields:    []string{"action", "resource_type", "resource_id", "ip_address", "details", "created_at"},
+			Retention: audit,
+		},
+	}
+}
+
+// DataMap retorna o inventário dos dados pessoais guardados
+//
+// Endpoint: GET /api/auth/data-map
+//
+// Formato legível por máquina: cada categoria informa a tabela, os campos, os
+// campos criptografados, a retenção e os endpoints para corrigir ou excluir.
+func (h *Handler) DataMap(w http.ResponseWriter, r *http.Request) {
+	h.auditLogger.LogDataAccess(GetUserID(r), security.GetClientIP(r), "data_map", "read", "success")
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"categories": dataCategories(h.retentionDays),
+		"rights": map[string]string{
+			"access":        "GET /api/auth/data-map",
+			"portability":   "GET /api/auth/export",
+			"rectification": "PATCH /api/auth/profile",
+			"deletion":      "DELETE /api/auth/account",
+		},
+		"generated_at": time.Now().UTC(),
+	})
+}
+
+// profilePayload é o payload de correção do perfil (campos ausentes não mudam)
+type profilePayload struct {
+	Name     *string `json:"name"`
+	Email    *string `json:"email"`
+	Password string  `json:"password"` // Senha atual, obrigatória para trocar o email
+}
+
+// UpdateProfile corrige o nome e/ou o email da conta (LGPD: Correção)
+//
+// Endpoint: PATCH /api/auth/profile
+//
+// Trocar o email exige a senha atual (exceto contas só com login social).
+func (h *Handler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	clientIP := security.GetClientIP(r)
+	userID := GetUserID(r)
+
+	var payload profilePayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "auth.invalid_data")
+		return
+	}
+
+	user, ok := h.store.GetUserByID(userID)
+	if !ok {
+		apierror.Write(w, r, http.StatusNotFound, "auth.user_not_found")
+		return
+	}
+
+	// Cópias: no storage em memória, user é alterado pela própria atualização
+	previousName, previousEmail := user.Name, user.Email
+	name, email := previousName, previousEmail
+	var changed []string
+	if payload.Name != nil {
+		name = security.SanitizeName(*payload.Name)
+		if name == "" {
+			apierror.Write(w, r, http.StatusBadRequest, "auth.profile_name_required")
+			return
+		}
+		if name != previousName {
+			changed = append(changed, "name")
+		}
+	}
+	if payload.Email != nil {
+		validated, err := security.ValidateEmail(*payload.Email)
+		if err != nil {
+			apierror.Write(w, r, http.StatusBadRequest, "auth.email_invalid")
+			return
+		}
+		email = validated
+		if !strings.EqualFold(email, previousEmail) {
+			changed = append(changed, "email")
+			if !h.confirmPassword(w, r, user, payload.Password, clientIP) {
+				return
+			}
+		} else if email != previousEmail {
+			changed = append(changed, "email") // Só maiúsculas/minúsculas
+		}
+	}
+
+	if len(changed) > 0 {
+		if err := h.store.UpdateUserProfile(userID, name, email); err != nil {
+			if errors.Is(err, storage.ErrAlreadyExists) {
+				apierror.Write(w, r, http.StatusConflict, "auth.profile_email_taken")
+				return
+			}
+			apierror.Write(w, r, http.StatusInternalServerError, "auth.profile_update_error")
+			return
+		}
+
+		details := map[string]interface{}{"fields": changed}
+		if email != previousEmail {
+			details["previous_email"] = maskEmail(previousEmail)
+			details["email"] = maskEmail(email)
+		}
+		h.auditLogger.LogAuth(security.EventDataRectification, userID, clientIP, r.UserAgent(), "success", details)
+
+		// O email também vai no token da sessão do navegador
+		if _, bearer := sessionToken(r); !bearer && email != previousEmail {
+			if err := issueSessionCookie(w, r, h.jwtSecret, userID, GetUserRole(r), GetSessionID(r), jwt.MapClaims{"email": email}); err != nil {
+				apierror.Write(w, r, http.StatusInternalServerError, "auth.session_error")
+				return
+			}
+		}
+	}
+
+	user, ok = h.store.GetUserByID(userID)
+	if !ok {
+		apierror.Write(w, r, http.StatusNotFound, "auth.user_not_found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"user":    userResponse(user),
+		"changed": append([]string{}, changed...),
+	})
+}
+
+// confirmPassword confere a senha atual antes de uma alteração sensível
+// Contas sem senha (só login social) passam. Retorna false se já respondeu.
+func (h *Handler) confirmPassword(w http.ResponseWriter, r *http.Request, user *storage.User, password, clientIP string) bool {
+	if user.Password == "" {
+		return true
+	}
+	if allowed, _ := h.loginLimiter.Allow(clientIP); !allowed {
+		h.auditLogger.LogAuth(security.EventRateLimitExceeded, user.ID, clientIP, r.UserAgent(), "rate_limited", nil)
+		apierror.Write(w, r, http.StatusTooManyRequests, "auth.rate_limit")
+		return false
+	}
+	if password == "" {
+		apierror.Write(w, r, http.StatusBadRequest, "auth.profile_password_required")
+		return false
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		h.auditLogger.LogAuth(security.EventDataRectification, user.ID, clientIP, r.UserAgent(), "invalid_password", nil)
+		apierror.Write(w, r, http.StatusUnauthorized, "auth.password_incorrect")
+		return false
+	}
+	return true
+}
@@ -19,14 +19,13 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 
+	"famli/internal/apierror"
 	"famli/internal/email"
 	"famli/internal/i18n"
 	"famli/internal/security"
@@ -56,6 +55,41 @@ type Handler struct {
 
 	// emailService envia emails
 	emailService *email.Service
+
+	// lockoutThreshold é o número de falhas consecutivas que bloqueia a conta
+	lockoutThreshold int
+
+	// lockoutDuration é o tempo de bloqueio da conta
+	lockoutDuration time.Duration
+
+	// adminEmails promovem o primeiro superadmin (roles.go)
+	adminEmails []string
+
+	// tokenClients são os clientes que podem obter tokens Bearer (token.go)
+	tokenClients map[string]bool
+
+	// appURL é o endereço do frontend usado nos links dos emails
+	appURL string
+
+	// termsVersion e privacyVersion são as versões vigentes, registradas
+	// quando o cadastro aceita os termos (o restante fica em internal/legal)
+	termsVersion   string
+	privacyVersion string
+
+	// retentionDays informa os prazos de retenção no inventário (data_rights.go)
+	retentionDays func(storage.RetentionClass) int
+}
+
+// Config é a configuração de login e sessão
+type Config struct {
+	LockoutThreshold int                              // Falhas consecutivas até bloquear a conta
+	LockoutDuration  time.Duration                    // Tempo de bloqueio da conta
+	AdminEmails      []string                         // Superadmin inicial (ADMIN_EMAILS)
+	TokenClients     []string                         // Clientes com token Bearer (API_TOKEN_CLIENTS)
+	AppURL           string                           // Endereço do frontend nos links dos emails (APP_BASE_URL)
+	TermsVersion     string                           // Versão vigente dos Termos de Uso (LEGAL_TERMS_VERSION)
+	PrivacyVersion   string                           // Versão vigente da Política de Privacidade (LEGAL_PRIVACY_VERSION)
+	RetentionDays    func(storage.RetentionClass) int // Prazo de cada classe de dados (internal/retention)
 }
 
 // NewHandler cria uma nova instância do handler de autenticação
@@ -63,17 +97,28 @@ type Handler struct {
 // Parâmetros:
 //   - store: armazenamento de dados
 //   - secret: segredo JWT (mínimo 32 caracteres em produção)
+//   - config: bloqueio de conta, superadmin inicial e clientes de token
+//   - mailer: serviço de email (boas-vindas, alertas, recuperação de senha)
 //
 // Retorna:
 //   - *Handler: handler configurado
-func NewHandler(store storage.Store, secret string) *Handler {
+func NewHandler(store storage.Store, secret string, config *Config, mailer *email.Service) *Handler {
 	return &Handler{
 		store:           store,
 		jwtSecret:       secret,
 		loginLimiter:    security.NewRateLimiter(security.LoginRateLimit),
 		registerLimiter: security.NewRateLimiter(security.RegisterRateLimit),
 		auditLogger:     security.GetAuditLogger(),
-		emailService:    email.NewService(),
+		emailService:    mailer,
+
+		lockoutThreshold: config.LockoutThreshold,
+		lockoutDuration:  config.LockoutDuration,
+		adminEmails:      config.AdminEmails,
+		tokenClients:     tokenClientSet(config.TokenClients),
+		appURL:           config.AppURL,
+		termsVersion:     config.TermsVersion,
+		privacyVersion:   config.PrivacyVersion,
+		retentionDays:    config.RetentionDays,
 	}
 }
 
@@ -86,6 +131,10 @@ type registerPayload struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 	Name     string `json:"name"`
+
+	// AcceptTerms registra o aceite das versões vigentes dos termos e da
+	// política (sem ele, as rotas autenticadas pedem o aceite com 428)
+	AcceptTerms bool `json:"accept_terms"`
 }
 
 // loginPayload é o payload de login
@@ -118,28 +167,28 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 			"endpoint": "register",
 		})
 		w.Header().Set("Retry-After", itoa(int(retryAfter.Seconds())))
-		writeError(w, http.StatusTooManyRequests, i18n.Tr(r, "auth.rate_limit"))
+		apierror.Write(w, r, http.StatusTooManyRequests, "auth.rate_limit")
 		return
 	}
 
 	// Decodificar payload
 	var payload registerPayload
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "auth.invalid_data"))
+		apierror.Write(w, r, http.StatusBadRequest, "auth.invalid_data")
 		return
 	}
 
 	// Validar e sanitizar email
 	email, err := security.ValidateEmail(payload.Email)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "auth.email_invalid"))
+		apierror.Write(w, r, http.StatusBadRequest, "auth.email_invalid")
 		return
 	}
 
 	// Validar força da senha
 	strength, err := security.ValidatePassword(payload.Password)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "auth.password_weak"))
+		apierror.Write(w, r, http.StatusBadRequest, "auth.password_weak")
 		return
 	}
 
@@ -152,7 +201,7 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 		h.auditLogger.LogSecurity(security.EventSuspiciousActivity, clientIP, map[string]interface{}{
 			"error": "bcrypt failed",
 		})
-		writeError(w, http.StatusInternalServerError, i18n.Tr(r, "auth.prepare_error"))
+		apierror.Write(w, r, http.StatusInternalServerError, "auth.prepare_error")
 		return
 	}
 
@@ -163,16 +212,33 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 			// Não revelar se o email existe (proteção contra enumeração)
 			// Usar mesma mensagem de sucesso após delay
 			time.Sleep(100 * time.Millisecond) // Timing attack protection
-			writeError(w, http.StatusBadRequest, i18n.Tr(r, "auth.email_exists"))
+			apierror.Write(w, r, http.StatusBadRequest, "auth.email_exists")
 			return
 		}
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "auth.create_error"))
+		apierror.Write(w, r, http.StatusBadRequest, "auth.create_error")
 		return
 	}
 
-	// Criar sessão (inclui email no token para contexto)
-	if err := h.setSession(w, user.ID, user.Email, r); err != nil {
-		writeError(w, http.StatusInternalServerError, i18n.Tr(r, "auth.session_error"))
+	// Idioma inicial da conta (depois alterado em /api/settings)
+	locale := i18n.GetLocale(r)
+	if err := h.store.UpdateUserLocale(user.ID, locale); err == nil {
+		user.Locale = locale
+	}
+
+	// Aceite dos termos marcado no formulário de cadastro
+	if payload.AcceptTerms {
+		h.acceptTerms(r, user)
+	}
+
+	// Superadmin inicial (ADMIN_EMAILS, enquanto não houver nenhum)
+	BootstrapSuperadmin(h.store, user, h.adminEmails)
+
+	// Eventos de analytics anteriores ao cadastro (landing page)
+	LinkAnonymousActivity(h.store, r, user.ID)
+
+	// Criar sessão (inclui email e papel no token)
+	if err := h.setSession(w, user, r); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "auth.session_error")
 		return
 	}
 
@@ -184,23 +250,29 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 
 	// Enviar email de boas-vindas (em background, não bloqueia)
 	if h.emailService != nil && h.emailService.IsConfigured() {
-		locale := i18n.GetLocale(r)
 		go h.emailService.SendWelcome(user.Email, user.Name, locale)
 	}
 
-	// Verificar se é admin para retornar na resposta
-	isAdmin := checkIsAdmin(user.Email)
-
 	writeJSON(w, http.StatusCreated, map[string]interface{}{
-		"user": map[string]interface{}{
-			"id":       user.ID,
-			"email":    user.Email,
-			"name":     user.Name,
-			"is_admin": isAdmin,
-		},
+		"user": userResponse(user),
 	})
 }
 
+// acceptTerms registra o aceite das versões vigentes no cadastro
+// Falhas não impedem o cadastro: o aceite é pedido de novo no primeiro acesso.
+func (h *Handler) acceptTerms(r *http.Request, user *storage.User) {
+	now := time.Now().UTC()
+	details := map[string]interface{}{"terms_version": h.termsVersion, "privacy_version": h.privacyVersion}
+	if err := h.store.AcceptLegalTerms(user.ID, h.termsVersion, h.privacyVersion, now); err != nil {
+		h.auditLogger.LogAuth(security.EventTermsAccepted, user.ID, security.GetClientIP(r), r.UserAgent(), "error", details)
+		return
+	}
+	user.TermsVersion = h.termsVersion
+	user.PrivacyVersion = h.privacyVersion
+	user.TermsAcceptedAt = &now
+	h.auditLogger.LogAuth(security.EventTermsAccepted, user.ID, security.GetClientIP(r), r.UserAgent(), "success", details)
+}
+
 // =============================================================================
 // LOGIN
 // =============================================================================
@@ -211,6 +283,8 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 //
 // Segurança:
 // - Rate limiting por IP com bloqueio progressivo
+// - Bloqueio temporário da conta após falhas consecutivas
+// - Alerta por email em login de novo dispositivo/país
 // - Proteção contra timing attacks
 // - Proteção contra enumeração de usuários
 // - Auditoria de tentativas
@@ -218,23 +292,55 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	clientIP := security.GetClientIP(r)
 
 	// Verificar rate limit
-	allowed, retryAfter := h.loginLimiter.Allow(clientIP)
-	if !allowed {
-		h.auditLogger.LogSecurity(security.EventRateLimitExceeded, clientIP, map[string]interface{}{
-			"endpoint": "login",
-		})
-		w.Header().Set("Retry-After", itoa(int(retryAfter.Seconds())))
-		writeError(w, http.StatusTooManyRequests, i18n.Tr(r, "auth.rate_limit"))
+	if !h.allowLogin(w, r, clientIP) {
 		return
 	}
 
 	// Decodificar payload
 	var payload loginPayload
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "auth.invalid_data"))
+		apierror.Write(w, r, http.StatusBadRequest, "auth.invalid_data")
+		return
+	}
+
+	user, ok := h.authenticate(w, r, payload, clientIP)
+	if !ok {
+		return
+	}
+
+	// Criar sessão (inclui email e papel no token)
+	if err := h.setSession(w, user, r); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "auth.session_error")
 		return
 	}
 
+	// Registrar evento
+	h.auditLogger.LogAuth(security.EventLoginSuccess, user.ID, clientIP, r.UserAgent(), "success", nil)
+	h.checkLoginContext(user, clientIP, r)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"user": userResponse(user),
+	})
+}
+
+// allowLogin aplica o rate limit de login por IP
+// Responde 429 e retorna false se o limite foi excedido.
+func (h *Handler) allowLogin(w http.ResponseWriter, r *http.Request, clientIP string) bool {
+	allowed, retryAfter := h.loginLimiter.Allow(clientIP)
+	if !allowed {
+		h.auditLogger.LogSecurity(security.EventRateLimitExceeded, clientIP, map[string]interface{}{
+			"endpoint": "login",
+		})
+		w.Header().Set("Retry-After", itoa(int(retryAfter.Seconds())))
+		apierror.Write(w, r, http.StatusTooManyRequests, "auth.rate_limit")
+		return false
+	}
+	return true
+}
+
+// authenticate confere email e senha (login por cookie e por token)
+// Responde com o erro e retorna false se as credenciais forem recusadas.
+func (h *Handler) authenticate(w http.ResponseWriter, r *http.Request, payload loginPayload, clientIP string) (*storage.User, bool) {
 	// Normalizar email
 	email, _ := security.ValidateEmail(payload.Email)
 	if email == "" {
@@ -255,6 +361,18 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	// Verificar senha
 	err := bcrypt.CompareHashAndPassword([]byte(passwordToCheck), []byte(payload.Password))
 
+	// Conta bloqueada por falhas consecutivas (mesmo com a senha correta)
+	// A resposta é a mesma de credenciais inválidas: um status próprio
+	// revelaria que o email tem conta. O bloqueio fica só na auditoria.
+	if ok && user.IsLocked(time.Now()) {
+		h.loginLimiter.RecordFailure(clientIP)
+		h.auditLogger.LogAuth(security.EventLoginFailed, user.ID, clientIP, r.UserAgent(), "blocked", map[string]interface{}{
+			"reason": "account_locked",
+		})
+		apierror.Write(w, r, http.StatusUnauthorized, "auth.invalid_credentials")
+		return nil, false
+	}
+
 	// Se usuário não existe ou senha incorreta
 	if !ok || err != nil {
 		// Registrar falha
@@ -262,41 +380,104 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		h.auditLogger.LogAuth(security.EventLoginFailed, "", clientIP, r.UserAgent(), "failure", map[string]interface{}{
 			"email": maskEmail(email),
 		})
+		if ok {
+			h.recordAccountFailure(user, clientIP, r)
+		}
 
 		// Mensagem genérica (não revela se email existe)
-		writeError(w, http.StatusUnauthorized, i18n.Tr(r, "auth.invalid_credentials"))
-		return
+		apierror.Write(w, r, http.StatusUnauthorized, "auth.invalid_credentials")
+		return nil, false
+	}
+
+	// Conta desativada pelo admin (só revelado com a senha correta)
+	if user.IsDisabled() {
+		h.auditLogger.LogAuth(security.EventLoginFailed, user.ID, clientIP, r.UserAgent(), "blocked", map[string]interface{}{
+			"reason": "account_disabled",
+		})
+		apierror.Write(w, r, http.StatusForbidden, "auth.account_disabled")
+		return nil, false
 	}
 
 	// Login bem-sucedido
 	h.loginLimiter.RecordSuccess(clientIP)
+	if user.FailedLoginAttempts > 0 || user.LockedUntil != nil {
+		_ = h.store.ResetFailedLogins(user.ID) // Ignora erro, não é crítico
+	}
 
-	// Atualizar locale do usuário baseado no Accept-Language
-	locale := i18n.GetLocale(r)
-	if locale != "" && locale != user.Locale {
-		_ = h.store.UpdateUserLocale(user.ID, locale) // Ignora erro, não é crítico
+	// Idioma inicial vem do Accept-Language; depois vale o escolhido em /api/settings
+	if user.Locale == "" {
+		_ = h.store.UpdateUserLocale(user.ID, i18n.GetLocale(r)) // Ignora erro, não é crítico
+	}
+
+	// Superadmin inicial (ADMIN_EMAILS, enquanto não houver nenhum)
+	BootstrapSuperadmin(h.store, user, h.adminEmails)
+
+	return user, true
+}
+
+// userResponse é o usuário retornado no registro e no login
+func userResponse(user *storage.User) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         user.ID,
+		"email":      user.Email,
+		"name":       user.Name,
+		"is_admin":   user.Role.IsAdmin(),
+		"role":       user.Role,
+		"is_premium": user.IsPremium(),
 	}
+}
 
-	// Criar sessão (inclui email no token para contexto)
-	if err := h.setSession(w, user.ID, user.Email, r); err != nil {
-		writeError(w, http.StatusInternalServerError, i18n.Tr(r, "auth.session_error"))
+// recordAccountFailure registra a falha na conta e bloqueia ao atingir o limite
+func (h *Handler) recordAccountFailure(user *storage.User, clientIP string, r *http.Request) {
+	updated, err := h.store.RecordFailedLogin(user.ID, h.lockoutThreshold, h.lockoutDuration)
+	if err != nil {
 		return
 	}
 
-	// Registrar evento
-	h.auditLogger.LogAuth(security.EventLoginSuccess, user.ID, clientIP, r.UserAgent(), "success", nil)
+	// Registrar apenas a transição para bloqueado (não cada falha adicional)
+	if updated.FailedLoginAttempts == h.lockoutThreshold {
+		h.auditLogger.LogAuth(security.EventAccountLocked, user.ID, clientIP, r.UserAgent(), "blocked", map[string]interface{}{
+			"attempts":         updated.FailedLoginAttempts,
+			"duration_minutes": int(h.lockoutDuration.Minutes()),
+		})
+	}
+}
 
-	// Verificar se é admin para retornar na resposta
-	isAdmin := checkIsAdmin(user.Email)
+// checkLoginContext registra o login e alerta o usuário se o dispositivo ou país for novo
+func (h *Handler) checkLoginContext(user *storage.User, clientIP string, r *http.Request) {
+	userAgent := r.UserAgent()
+	record := &storage.LoginRecord{
+		UserID:     user.ID,
+		IPAddress:  clientIP,
+		Country:    security.GetClientCountry(r),
+		DeviceHash: security.DeviceHash(userAgent),
+	}
 
-	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"user": map[string]interface{}{
-			"id":       user.ID,
-			"email":    user.Email,
-			"name":     user.Name,
-			"is_admin": isAdmin,
-		},
+	check, err := h.store.RecordLogin(record)
+	if err != nil || check.FirstLogin || (!check.NewDevice && !check.NewCountry) {
+		return
+	}
+
+	h.auditLogger.LogAuth(security.EventLoginNewDevice, user.ID, clientIP, userAgent, "success", map[string]interface{}{
+		"new_device":  check.NewDevice,
+		"new_country": check.NewCountry,
+		"country":     record.Country,
 	})
+
+	locale := i18n.UserLocale(user.Locale, r)
+	resetPath := "/esqueci-senha"
+	if i18n.Resolve(locale, "pt-BR", "en") == "en" {
+		resetPath = "/forgot-password"
+	}
+
+	alert := email.LoginAlert{
+		Device:    security.DescribeDevice(userAgent),
+		Country:   record.Country,
+		IP:        maskIP(clientIP),
+		Time:      time.Now(),
+		ResetLink: h.appURL + resetPath,
+	}
+	go h.emailService.SendLoginAlert(user.Email, user.Name, locale, alert)
 }
 
 // =============================================================================
@@ -312,57 +493,36 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 //   - email: email do usuário
 //   - name: nome do usuário
 //   - created_at: data de criação
-//   - is_admin: se o usuário é administrador
+//   - is_admin: se o usuário tem acesso ao painel admin
+//   - role: papel administrativo (support, analyst, superadmin)
+//   - support_session: true quando é o suporte vendo a conta (exibir banner)
 func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
 	userID := GetUserID(r)
 	if userID == "" {
-		writeError(w, http.StatusUnauthorized, i18n.Tr(r, "auth.session_expired"))
+		apierror.Write(w, r, http.StatusUnauthorized, "auth.session_expired")
 		return
 	}
 
 	user, ok := h.store.GetUserByID(userID)
 	if !ok {
 		h.auditLogger.LogAuth(security.EventTokenInvalid, userID, security.GetClientIP(r), r.UserAgent(), "failure", nil)
-		writeError(w, http.StatusUnauthorized, i18n.Tr(r, "auth.session_invalid"))
+		apierror.Write(w, r, http.StatusUnauthorized, "auth.session_invalid")
 		return
 	}
 
-	// Verificar se é admin
-	isAdmin := checkIsAdmin(user.Email)
-
 	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"user": map[string]interface{}{
 			"id":         user.ID,
 			"email":      user.Email,
 			"name":       user.Name,
 			"created_at": user.CreatedAt,
-			"is_admin":   isAdmin,
+			"is_admin":   user.Role.IsAdmin(),
+			"role":       user.Role,
 		},
+		"support_session": GetImpersonatorID(r) != "",
 	})
 }
 
-// checkIsAdmin verifica se o email está na lista de administradores
-// Lê a variável de ambiente ADMIN_EMAILS dinamicamente
-func checkIsAdmin(email string) bool {
-	adminEmails := os.Getenv("ADMIN_EMAILS")
-	if adminEmails == "" {
-		// Em desenvolvimento sem admins configurados, todos são admin
-		if os.Getenv("ENV") != "production" {
-			return true
-		}
-		return false
-	}
-
-	email = strings.ToLower(email)
-	for _, admin := range strings.Split(adminEmails, ",") {
-		admin = strings.TrimSpace(strings.ToLower(admin))
-		if email == admin {
-			return true
-		}
-	}
-	return false
-}
-
 // Logout encerra a sessão do usuário
 //
 // Endpoint: POST /api/auth/logout
@@ -370,6 +530,11 @@ func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
 	userID := GetUserID(r)
 	clientIP := security.GetClientIP(r)
 
+	// Encerrar a sessão do dispositivo (tokens antigos não têm sessão)
+	if sessionID := GetSessionID(r); sessionID != "" {
+		_ = h.store.DeleteDeviceSession(userID, sessionID)
+	}
+
 	// Limpar cookie de sessão
 	http.SetCookie(w, &http.Cookie{
 		Name:     "famli_session",
@@ -419,7 +584,7 @@ func (h *Handler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
 	userID := GetUserID(r)
 
 	if userID == "" {
-		writeError(w, http.StatusUnauthorized, i18n.Tr(r, "auth.session_invalid"))
+		apierror.Write(w, r, http.StatusUnauthorized, "auth.session_invalid")
 		return
 	}
 
@@ -427,14 +592,14 @@ func (h *Handler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
 	allowed, _ := h.loginLimiter.Allow(clientIP)
 	if !allowed {
 		h.auditLogger.LogAuth(security.EventRateLimitExceeded, userID, clientIP, r.UserAgent(), "rate_limited", nil)
-		writeError(w, http.StatusTooManyRequests, i18n.Tr(r, "auth.rate_limit"))
+		apierror.Write(w, r, http.StatusTooManyRequests, "auth.rate_limit")
 		return
 	}
 
 	// Parse payload
 	var payload deleteAccountPayload
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "auth.invalid_data"))
+		apierror.Write(w, r, http.StatusBadRequest, "auth.invalid_data")
 		return
 	}
 
@@ -452,7 +617,7 @@ func (h *Handler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	if !validConfirmation {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "auth.delete_confirm"))
+		apierror.Write(w, r, http.StatusBadRequest, "auth.delete_confirm")
 		return
 	}
 
@@ -460,14 +625,14 @@ func (h *Handler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
 	user, found := h.store.GetUserByID(userID)
 	if !found {
 		h.auditLogger.LogAuth(security.EventAccountDeletion, userID, clientIP, r.UserAgent(), "user_not_found", nil)
-		writeError(w, http.StatusNotFound, i18n.Tr(r, "auth.user_not_found"))
+		apierror.Write(w, r, http.StatusNotFound, "auth.user_not_found")
 		return
 	}
 
 	// Debug: verificar se a senha foi recuperada corretamente
 	if user.Password == "" {
 		h.auditLogger.LogAuth(security.EventAccountDeletion, userID, clientIP, r.UserAgent(), "empty_password_hash", nil)
-		writeError(w, http.StatusInternalServerError, i18n.Tr(r, "auth.internal_error"))
+		apierror.Write(w, r, http.StatusInternalServerError, "auth.internal_error")
 		return
 	}
 
@@ -477,7 +642,7 @@ func (h *Handler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
 			"password_hash_len":  len(user.Password),
 			"input_password_len": len(payload.Password),
 		})
-		writeError(w, http.StatusUnauthorized, i18n.Tr(r, "auth.password_incorrect"))
+		apierror.Write(w, r, http.StatusUnauthorized, "auth.password_incorrect")
 		return
 	}
 
@@ -491,7 +656,7 @@ func (h *Handler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
 		h.auditLogger.LogAuth(security.EventAccountDeletion, userID, clientIP, r.UserAgent(), "error", map[string]interface{}{
 			"error": err.Error(),
 		})
-		writeError(w, http.StatusInternalServerError, i18n.Tr(r, "auth.delete_error"))
+		apierror.Write(w, r, http.StatusInternalServerError, "auth.delete_error")
 		return
 	}
 
@@ -525,7 +690,7 @@ func (h *Handler) ExportData(w http.ResponseWriter, r *http.Request) {
 	userID := GetUserID(r)
 
 	if userID == "" {
-		writeError(w, http.StatusUnauthorized, i18n.Tr(r, "auth.session_invalid"))
+		apierror.Write(w, r, http.StatusUnauthorized, "auth.session_invalid")
 		return
 	}
 
@@ -533,7 +698,7 @@ func (h *Handler) ExportData(w http.ResponseWriter, r *http.Request) {
 	data, err := h.store.ExportUserData(userID)
 	if err != nil {
 		h.auditLogger.LogAuth(security.EventDataExport, userID, clientIP, r.UserAgent(), "error", nil)
-		writeError(w, http.StatusInternalServerError, i18n.Tr(r, "auth.export_error"))
+		apierror.Write(w, r, http.StatusInternalServerError, "auth.export_error")
 		return
 	}
 
@@ -550,41 +715,9 @@ func (h *Handler) ExportData(w http.ResponseWriter, r *http.Request) {
 // SESSÃO JWT
 // =============================================================================
 
-// setSession cria um token JWT e define o cookie de sessão
-// Inclui o email no token para facilitar identificação em feedbacks e logs
-func (h *Handler) setSession(w http.ResponseWriter, userID, email string, r *http.Request) error {
-	now := time.Now()
-	sessionDuration := 7 * 24 * time.Hour
-
-	// Claims do token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub":   userID,                          // Subject (ID do usuário)
-		"email": email,                           // Email do usuário (para contexto)
-		"exp":   now.Add(sessionDuration).Unix(), // Expira em 7 dias
-		"iat":   now.Unix(),                      // Issued at
-		"nbf":   now.Unix(),                      // Not before
-		"jti":   generateJTI(),                   // JWT ID único
-	})
-
-	// Assinar token
-	signed, err := token.SignedString([]byte(h.jwtSecret))
-	if err != nil {
-		return err
-	}
-
-	// Definir cookie seguro
-	http.SetCookie(w, &http.Cookie{
-		Name:     "famli_session",
-		Value:    signed,
-		Path:     "/",
-		HttpOnly: true,                 // Não acessível via JavaScript (previne XSS)
-		Secure:   isSecureContext(r),   // HTTPS only em produção
-		SameSite: http.SameSiteLaxMode, // Proteção contra CSRF
-		Expires:  now.Add(7 * 24 * time.Hour),
-		MaxAge:   7 * 24 * 60 * 60,
-	})
-
-	return nil
+// setSession registra a sessão do dispositivo e define o cookie de sessão
+func (h *Handler) setSession(w http.ResponseWriter, user *storage.User, r *http.Request) error {
+	return StartSession(h.store, h.jwtSecret, w, r, user)
 }
 
 // =============================================================================
@@ -600,11 +733,6 @@ func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
 	}
 }
 
-// writeError escreve resposta de erro
-func writeError(w http.ResponseWriter, status int, message string) {
-	writeJSON(w, status, map[string]string{"error": message})
-}
-
 // isSecureContext verifica se a requisição veio via HTTPS
 func isSecureContext(r *http.Request) bool {
 	// Verificar TLS direto
@@ -618,23 +746,6 @@ func isSecureContext(r *http.Request) bool {
 	return false
 }
 
-// generateJTI gera um ID único para o token JWT
-func generateJTI() string {
-	now := time.Now()
-	return now.Format("20060102150405") + randomChars(8)
-}
-
-// randomChars gera caracteres aleatórios
-func randomChars(n int) string {
-	const chars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, n)
-	for i := range b {
-		b[i] = chars[time.Now().UnixNano()%int64(len(chars))]
-		time.Sleep(time.Nanosecond) // Variação
-	}
-	return string(b)
-}
-
 // maskEmail mascara parte do email para logs
 func maskEmail(email string) string {
 	if len(email) < 5 {
@@ -695,13 +806,13 @@ func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
 	// Rate limiting
 	allowed, _ := h.registerLimiter.Allow(clientIP)
 	if !allowed {
-		writeError(w, http.StatusTooManyRequests, i18n.Tr(r, "auth.rate_limit"))
+		apierror.Write(w, r, http.StatusTooManyRequests, "auth.rate_limit")
 		return
 	}
 
 	var payload forgotPasswordPayload
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "auth.invalid_data"))
+		apierror.Write(w, r, http.StatusBadRequest, "auth.invalid_data")
 		return
 	}
 
@@ -729,10 +840,16 @@ func (h *Handler) processPasswordReset(emailAddr string, r *http.Request) {
 		return // Email não existe, não fazer nada
 	}
 
+	_ = h.SendPasswordReset(user, r)
+}
+
+// SendPasswordReset gera um token de redefinição e envia o link por email
+// Usado pelo fluxo "esqueci minha senha" e pelo painel admin
+func (h *Handler) SendPasswordReset(user *storage.User, r *http.Request) error {
 	// Gerar token seguro
 	tokenBytes := make([]byte, 32)
 	if _, err := rand.Read(tokenBytes); err != nil {
-		return
+		return err
 	}
 	rawToken := hex.EncodeToString(tokenBytes)
 
@@ -750,25 +867,23 @@ func (h *Handler) processPasswordReset(emailAddr string, r *http.Request) {
 	}
 
 	if err := h.store.CreatePasswordResetToken(resetToken); err != nil {
-		return
+		return err
 	}
 
 	// Construir URL de reset (usa rota do idioma do usuário)
-	baseURL := getBaseURLFromRequest(r)
-	locale := user.Locale
-	if locale == "" {
-		locale = i18n.GetLocale(r) // Fallback para o idioma da requisição
-	}
+	// O endereço vem da configuração, nunca do Host da requisição, que pode
+	// ser forjado para desviar o token
+	locale := i18n.UserLocale(user.Locale, r)
 
 	// Usa rota localizada
 	resetPath := "/redefinir-senha"
-	if strings.HasPrefix(locale, "en") {
+	if i18n.Resolve(locale, "pt-BR", "en") == "en" {
 		resetPath = "/reset-password"
 	}
-	resetLink := baseURL + resetPath + "?token=" + rawToken
+	resetLink := h.appURL + resetPath + "?token=" + rawToken
 
 	// Enviar email no idioma do usuário
-	h.emailService.SendPasswordReset(user.Email, user.Name, resetLink, locale)
+	return h.emailService.SendPasswordReset(user.Email, user.Name, resetLink, locale)
 }
 
 // ResetPassword redefine a senha usando o token
@@ -784,19 +899,19 @@ func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 
 	var payload resetPasswordPayload
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "auth.invalid_data"))
+		apierror.Write(w, r, http.StatusBadRequest, "auth.invalid_data")
 		return
 	}
 
 	if payload.Token == "" || payload.NewPassword == "" {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "auth.invalid_data"))
+		apierror.Write(w, r, http.StatusBadRequest, "auth.invalid_data")
 		return
 	}
 
 	// Validar força da senha
 	_, err := security.ValidatePassword(payload.NewPassword)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "auth.password_weak"))
+		apierror.Write(w, r, http.StatusBadRequest, "auth.password_weak")
 		return
 	}
 
@@ -810,33 +925,36 @@ func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 		h.auditLogger.LogSecurity(security.EventSuspiciousActivity, clientIP, map[string]interface{}{
 			"event": "invalid_reset_token",
 		})
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "password.reset_invalid"))
+		apierror.Write(w, r, http.StatusBadRequest, "password.reset_invalid")
 		return
 	}
 
 	// Buscar usuário
 	user, ok := h.store.GetUserByID(resetToken.UserID)
 	if !ok {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "password.reset_invalid"))
+		apierror.Write(w, r, http.StatusBadRequest, "password.reset_invalid")
 		return
 	}
 
 	// Hash da nova senha
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(payload.NewPassword), bcrypt.DefaultCost)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, i18n.Tr(r, "password.reset_error"))
+		apierror.Write(w, r, http.StatusInternalServerError, "password.reset_error")
 		return
 	}
 
 	// Atualizar senha (precisamos adicionar este método ao store)
 	if err := h.updateUserPassword(user.ID, string(hashedPassword)); err != nil {
-		writeError(w, http.StatusInternalServerError, i18n.Tr(r, "password.reset_error"))
+		apierror.Write(w, r, http.StatusInternalServerError, "password.reset_error")
 		return
 	}
 
 	// Marcar token como usado
 	h.store.MarkPasswordResetTokenUsed(resetToken.ID)
 
+	// Nova senha desbloqueia a conta
+	_ = h.store.ResetFailedLogins(user.ID)
+
 	// Log
 	h.auditLogger.LogAuth(security.EventPasswordChange, user.ID, clientIP, r.UserAgent(), "success", nil)
 
@@ -850,11 +968,10 @@ func (h *Handler) updateUserPassword(userID, hashedPassword string) error {
 	return h.store.UpdateUserPassword(userID, hashedPassword)
 }
 
-// getBaseURLFromRequest extrai a URL base da requisição
-func getBaseURLFromRequest(r *http.Request) string {
-	scheme := "https"
-	if r.TLS == nil && r.Header.Get("X-Forwarded-Proto") != "https" {
-		scheme = "http"
+// maskIP mascara o último octeto do IP para exibição (ex: 192.168.1.xxx)
+func maskIP(ip string) string {
+	if idx := strings.LastIndexAny(ip, ".:"); idx > 0 {
+		return ip[:idx+1] + "xxx"
 	}
-	return scheme + "://" + r.Host
+	return "xxx"
 }
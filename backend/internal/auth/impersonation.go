@@ -0,0 +1,228 @@
+// =============================================================================
+// FAMLI - Sessão do Suporte (impersonação com consentimento)
+// =============================================================================
+// Para reproduzir um problema, o suporte pode ver a conta como o usuário, mas
+// só com a autorização dele (24h, em /api/settings/support-access):
+//
+// 1. POST /api/admin/users/{id}/impersonate (papel support ou superadmin)
+//    troca o cookie de sessão do admin por um JWT do usuário com a claim
+//    "imp" (ID do admin). O cookie do admin fica guardado à parte.
+// 2. Com "imp", o ActiveUserMiddleware aceita apenas leituras (GET/HEAD),
+//    exceto as de impersonationDeniedRoutes, retira o papel administrativo
+//    e registra cada requisição na auditoria.
+//    Revogar a autorização encerra a sessão na requisição seguinte.
+// 3. POST /api/auth/impersonation/end devolve a sessão do admin.
+//
+// A sessão do suporte vale até 1h (nunca além da autorização) e não é renovada.
+// Contas com papel administrativo não podem ser vistas assim.
+// =============================================================================
+
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+
+	"famli/internal/apierror"
+	"famli/internal/i18n"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+const (
+	// impersonationDuration é a validade máxima de uma sessão do suporte
+	impersonationDuration = time.Hour
+
+	// adminSessionCookie guarda a sessão do admin durante a sessão do suporte
+	adminSessionCookie = "famli_admin_session"
+)
+
+// impersonationDeniedRoutes são leituras que o suporte não faz, mesmo com a
+// autorização: cobrança, exportação dos dados, chaves de API e dispositivos
+var impersonationDeniedRoutes = []string{
+	"/api/billing/portal",
+	"/api/billing/checkout",
+	"/api/auth/export",
+	"/api/keys",
+	"/api/auth/devices",
+}
+
+// canImpersonate indica se o papel pode abrir sessões do suporte
+func canImpersonate(role storage.Role) bool {
+	return role == storage.RoleSupport || role == storage.RoleSuperadmin
+}
+
+// StartImpersonation abre uma sessão somente leitura como o usuário
+//
+// Endpoint: POST /api/admin/users/{id}/impersonate
+//
+// Exige a autorização do usuário ainda válida. O cookie de sessão passa a
+// ser o do usuário até POST /api/auth/impersonation/end.
+func (h *Handler) StartImpersonation(w http.ResponseWriter, r *http.Request) {
+	adminID := GetUserID(r)
+	userID := chi.URLParam(r, "id")
+	if userID == adminID {
+		apierror.Write(w, r, http.StatusBadRequest, "admin.self_action")
+		return
+	}
+
+	user, ok := h.store.GetUserByID(userID)
+	if !ok {
+		apierror.Write(w, r, http.StatusNotFound, "admin.user_not_found")
+		return
+	}
+	if user.Role.IsAdmin() {
+		apierror.Write(w, r, http.StatusForbidden, "admin.impersonation_not_allowed")
+		return
+	}
+
+	now := time.Now()
+	access, err := h.store.GetSupportAccess(user.ID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		apierror.Write(w, r, http.StatusInternalServerError, "admin.action_failed")
+		return
+	}
+	if !access.ActiveAt(now) {
+		apierror.Write(w, r, http.StatusForbidden, "admin.support_access_required")
+		return
+	}
+
+	expiresAt := now.Add(impersonationDuration)
+	if access.ExpiresAt.Before(expiresAt) {
+		expiresAt = access.ExpiresAt
+	}
+	token, _, err := signSessionToken(h.jwtSecret, user.ID, storage.RoleNone, "", jwt.MapClaims{
+		"email": user.Email,
+		"jti":   security.GenerateJTI(),
+		"imp":   adminID,          // Admin que está vendo a conta (banner no frontend)
+		"exp":   expiresAt.Unix(), // Substitui a validade padrão da sessão
+	})
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "auth.session_error")
+		return
+	}
+
+	// Guardar a sessão do admin para devolvê-la no fim
+	if cookie, err := r.Cookie("famli_session"); err == nil {
+		setSessionCookie(w, r, adminSessionCookie, cookie.Value, now.Add(sessionDuration))
+	}
+	setSessionCookie(w, r, "famli_session", token, expiresAt)
+
+	h.auditLogger.LogAuth(security.EventImpersonationStarted, user.ID, security.GetClientIP(r), r.UserAgent(), "success", map[string]interface{}{
+		"admin_id":   adminID,
+		"expires_at": expiresAt.UTC(),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"user": map[string]interface{}{
+			"id":   user.ID,
+			"name": user.Name,
+		},
+		"read_only":  true,
+		"expires_at": expiresAt.UTC(),
+	})
+}
+
+// EndImpersonation encerra a sessão do suporte e devolve a sessão do admin
+//
+// Endpoint: POST /api/auth/impersonation/end
+//
+// Fica fora do ActiveUserMiddleware: precisa funcionar mesmo depois que o
+// usuário revogou a autorização.
+func (h *Handler) EndImpersonation(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie("famli_session")
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "auth.not_impersonating")
+		return
+	}
+	token, err := jwt.Parse(cookie.Value, func(token *jwt.Token) (interface{}, error) {
+		return []byte(h.jwtSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}), jwt.WithoutClaimsValidation())
+	claims, ok := jwt.MapClaims(nil), false
+	if err == nil {
+		claims, ok = token.Claims.(jwt.MapClaims)
+	}
+	adminID, _ := claims["imp"].(string)
+	if !ok || adminID == "" {
+		apierror.Write(w, r, http.StatusBadRequest, "auth.not_impersonating")
+		return
+	}
+	userID, _ := claims["sub"].(string)
+
+	// Devolver a sessão do admin (sem ela, o admin entra de novo)
+	if saved, err := r.Cookie(adminSessionCookie); err == nil && saved.Value != "" {
+		setSessionCookie(w, r, "famli_session", saved.Value, time.Now().Add(sessionDuration))
+	} else {
+		clearSessionCookie(w, r)
+	}
+	setSessionCookie(w, r, adminSessionCookie, "", time.Unix(0, 0))
+
+	h.auditLogger.LogAuth(security.EventImpersonationEnded, userID, security.GetClientIP(r), r.UserAgent(), "success", map[string]interface{}{
+		"admin_id": adminID,
+	})
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": i18n.Tr(r, "auth.impersonation_ended")})
+}
+
+// checkImpersonation confere a sessão do suporte a cada requisição
+// A autorização do usuário precisa valer, o admin precisa manter o papel de
+// suporte e apenas leituras fora de impersonationDeniedRoutes são aceitas.
+// Retorna false se já respondeu.
+func checkImpersonation(store storage.Store, w http.ResponseWriter, r *http.Request, user *storage.User, adminID string) bool {
+	access, err := store.GetSupportAccess(user.ID)
+	if err != nil || !access.ActiveAt(time.Now()) {
+		apierror.WriteCode(w, r, http.StatusUnauthorized, "SUPPORT_ACCESS_REVOKED", "auth.support_access_revoked")
+		return false
+	}
+	admin, ok := store.GetUserByID(adminID)
+	if !ok || admin.IsDisabled() || !canImpersonate(admin.Role) {
+		apierror.WriteCode(w, r, http.StatusUnauthorized, "SUPPORT_ACCESS_REVOKED", "auth.support_access_revoked")
+		return false
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		apierror.Write(w, r, http.StatusForbidden, "auth.impersonation_read_only")
+		return false
+	}
+	for _, route := range impersonationDeniedRoutes {
+		if matchRoute(route, r.URL.Path) {
+			apierror.Write(w, r, http.StatusForbidden, "auth.impersonation_denied")
+			return false
+		}
+	}
+
+	security.GetAuditLogger().Log(security.AuditEvent{
+		Type:      security.EventImpersonatedRequest,
+		Severity:  security.SeverityInfo,
+		UserID:    user.ID,
+		ClientIP:  security.GetClientIP(r),
+		UserAgent: r.UserAgent(),
+		Resource:  r.URL.Path,
+		Action:    r.Method,
+		Result:    "success",
+		Details:   map[string]interface{}{"admin_id": adminID},
+	})
+	return true
+}
+
+// setSessionCookie define um cookie de sessão (HttpOnly) até expiresAt
+// expiresAt no passado remove o cookie.
+func setSessionCookie(w http.ResponseWriter, r *http.Request, name, value string, expiresAt time.Time) {
+	maxAge := int(time.Until(expiresAt).Seconds())
+	if maxAge <= 0 {
+		maxAge = -1
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   isSecureContextMiddleware(r),
+		SameSite: http.SameSiteLaxMode,
+		Expires:  expiresAt,
+		MaxAge:   maxAge,
+	})
+}
@@ -4,19 +4,31 @@
 // Middleware para autenticação JWT com renovação automática de sessão.
 //
 // Funcionalidades:
-// - Valida token JWT no cookie
+// - Valida token JWT no cookie ou no header Authorization (Bearer)
 // - Renova automaticamente sessões próximas de expirar
-// - Adiciona user_id e user_email ao contexto
+// - Adiciona user_id, user_email, role e sessão do dispositivo ao contexto
+// - Encerra sessões de contas removidas ou desativadas pelo admin
+// - Encerra sessões de dispositivos removidos pelo usuário (sessions.go)
+// - Aceita as chaves pessoais de API já conferidas em apikeys.go
+// - Limita as sessões do suporte a leituras enquanto o usuário autorizar
+//   (impersonation.go)
 // =============================================================================
 
 package auth
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
+	"famli/internal/apierror"
+	"famli/internal/i18n"
+	"famli/internal/security"
+	"famli/internal/storage"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
@@ -25,6 +37,11 @@ type contextKey string
 const (
 	userIDKey    contextKey = "userID"
 	userEmailKey contextKey = "user_email"
+	userRoleKey  contextKey = "user_role"
+	sessionIDKey contextKey = "session_id"
+	clientIDKey  contextKey = "client_id"
+
+	impersonatorKey contextKey = "impersonator"
 )
 
 // Constantes de tempo para renovação de sessão
@@ -34,53 +51,69 @@ const (
 	sessionCheckThreshold = 6 * time.Hour      // Log se faltam menos de 6h
 )
 
-// JWTMiddleware valida o token JWT no cookie e renova automaticamente
+// JWTMiddleware valida o token JWT (cookie ou header) e renova automaticamente
+// O header "Authorization: Bearer" (POST /api/auth/token) tem precedência
+// sobre o cookie. Tokens do header precisam de um cliente habilitado em
+// API_TOKEN_CLIENTS e não são renovados (o app pede um novo token).
 // Logs são minimizados para evitar custos - apenas erros importantes são logados
-func JWTMiddleware(secret string) func(http.Handler) http.Handler {
+func JWTMiddleware(secret string, tokenClients []string) func(http.Handler) http.Handler {
+	clients := tokenClientSet(tokenClients)
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			cookie, err := r.Cookie("famli_session")
-			if err != nil {
+			// Já autenticada por chave de API (APIKeyMiddleware)
+			if GetAPIKeyID(r) != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			raw, bearer := sessionToken(r)
+			if raw == "" {
 				// Não logar - é normal não ter cookie em algumas situações
-				http.Error(w, `{"error":"Sessão não encontrada","code":"SESSION_NOT_FOUND"}`, http.StatusUnauthorized)
+				apierror.WriteCode(w, r, http.StatusUnauthorized, "SESSION_NOT_FOUND", "auth.session_not_found")
 				return
 			}
 
-			token, err := jwt.Parse(cookie.Value, func(token *jwt.Token) (interface{}, error) {
+			// Sessão inválida: limpa o cookie (não logar - pode ser token expirado normal)
+			reject := func(code, key string) {
+				if !bearer {
+					clearSessionCookie(w, r)
+				}
+				apierror.WriteCode(w, r, http.StatusUnauthorized, code, key)
+			}
+
+			token, err := jwt.Parse(raw, func(token *jwt.Token) (interface{}, error) {
 				return []byte(secret), nil
 			}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}))
 
-			if err != nil {
-				// Limpar cookie inválido (não logar - pode ser token expirado normal)
-				clearSessionCookie(w, r)
-				http.Error(w, `{"error":"Sessão inválida","code":"SESSION_INVALID"}`, http.StatusUnauthorized)
-				return
-			}
-
-			if !token.Valid {
-				clearSessionCookie(w, r)
-				http.Error(w, `{"error":"Sessão inválida","code":"SESSION_INVALID"}`, http.StatusUnauthorized)
+			if err != nil || !token.Valid {
+				reject("SESSION_INVALID", "auth.session_invalid")
 				return
 			}
 
 			claims, ok := token.Claims.(jwt.MapClaims)
 			if !ok {
-				clearSessionCookie(w, r)
-				http.Error(w, `{"error":"Sessão inválida","code":"SESSION_INVALID"}`, http.StatusUnauthorized)
+				reject("SESSION_INVALID", "auth.session_invalid")
 				return
 			}
 
 			sub, ok := claims["sub"].(string)
 			if !ok || sub == "" {
-				clearSessionCookie(w, r)
-				http.Error(w, `{"error":"Sessão inválida","code":"SESSION_INVALID"}`, http.StatusUnauthorized)
+				reject("SESSION_INVALID", "auth.session_invalid")
 				return
 			}
 
 			expFloat, ok := claims["exp"].(float64)
 			if !ok {
-				clearSessionCookie(w, r)
-				http.Error(w, `{"error":"Sessão inválida","code":"SESSION_INVALID"}`, http.StatusUnauthorized)
+				reject("SESSION_INVALID", "auth.session_invalid")
+				return
+			}
+
+			// Token no header só para clientes habilitados (desabilitar o cliente
+			// invalida os tokens já emitidos)
+			clientID, _ := claims["cid"].(string)
+			if bearer && !clients[clientID] {
+				reject("SESSION_INVALID", "auth.session_invalid")
 				return
 			}
 
@@ -89,8 +122,7 @@ func JWTMiddleware(secret string) func(http.Handler) http.Handler {
 
 			// Verificar se expirou
 			if expTime.Before(now) {
-				clearSessionCookie(w, r)
-				http.Error(w, `{"error":"Sessão expirada","code":"SESSION_EXPIRED"}`, http.StatusUnauthorized)
+				reject("SESSION_EXPIRED", "auth.session_expired")
 				return
 			}
 
@@ -98,51 +130,119 @@ func JWTMiddleware(secret string) func(http.Handler) http.Handler {
 			timeRemaining := expTime.Sub(now)
 
 			// Renovar automaticamente se faltam menos de 24h (sem logar - operação normal)
-			if timeRemaining < renewalThreshold {
-				renewSession(w, r, sub, secret)
-			}
-
-			// Extrair email se presente
+			// Extrair email e papel se presentes
 			email, _ := claims["email"].(string)
+			role, _ := claims["role"].(string)
+			sessionID, _ := claims["sid"].(string)
+			impersonator, _ := claims["imp"].(string) // Admin na sessão do suporte
+
+			// Sessões do suporte não são renovadas (valem no máximo 1h)
+			if !bearer && impersonator == "" && timeRemaining < renewalThreshold {
+				renewSession(w, r, sub, email, storage.Role(role), sessionID, secret)
+			}
 
 			// Adicionar ao contexto
 			ctx := context.WithValue(r.Context(), userIDKey, sub)
 			ctx = context.WithValue(ctx, userEmailKey, email)
+			ctx = context.WithValue(ctx, userRoleKey, storage.Role(role))
+			ctx = context.WithValue(ctx, sessionIDKey, sessionID)
+			ctx = context.WithValue(ctx, clientIDKey, clientID)
+			ctx = context.WithValue(ctx, impersonatorKey, impersonator)
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
-// renewSession renova o token JWT e o cookie de sessão
-func renewSession(w http.ResponseWriter, r *http.Request, userID string, secret string) {
-	now := time.Now()
-
-	// Novo token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": userID,
-		"exp": now.Add(sessionDuration).Unix(),
-		"iat": now.Unix(),
-		"nbf": now.Unix(),
-	})
+// sessionToken extrai o JWT do header Authorization ou do cookie de sessão
+//
+// Retorna:
+//   - string: token (vazio se ausente)
+//   - bool: true se veio do header (Bearer)
+func sessionToken(r *http.Request) (string, bool) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		if scheme, token, ok := strings.Cut(header, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token), true
+		}
+	}
+	if cookie, err := r.Cookie("famli_session"); err == nil {
+		return cookie.Value, false
+	}
+	return "", false
+}
 
-	signed, err := token.SignedString([]byte(secret))
-	if err != nil {
-		log.Printf("[AUTH] Erro ao renovar sessão: %v", err)
-		return
+// ActiveUserMiddleware rejeita sessões de contas removidas ou desativadas
+// Deve ser usado após JWTMiddleware. O JWT continua válido até expirar, então
+// a desativação só tem efeito imediato se o status for verificado a cada requisição.
+func ActiveUserMiddleware(store storage.Store) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := store.GetUserByID(GetUserID(r))
+			if !ok {
+				clearSessionCookie(w, r)
+				apierror.WriteCode(w, r, http.StatusUnauthorized, "SESSION_INVALID", "auth.session_invalid")
+				return
+			}
+
+			if user.IsDisabled() {
+				clearSessionCookie(w, r)
+				apierror.WriteCode(w, r, http.StatusForbidden, "ACCOUNT_DISABLED", "auth.account_disabled")
+				return
+			}
+
+			// Sessão do dispositivo encerrada pelo usuário (ou expirada)
+			if sessionID := GetSessionID(r); sessionID != "" {
+				session, err := store.GetDeviceSession(sessionID)
+				if errors.Is(err, storage.ErrNotFound) || (err == nil && session.UserID != user.ID) {
+					clearSessionCookie(w, r)
+					apierror.WriteCode(w, r, http.StatusUnauthorized, "SESSION_REVOKED", "auth.session_revoked")
+					return
+				}
+				if err == nil && time.Since(session.LastSeenAt) > sessionTouchInterval {
+					_ = store.TouchDeviceSession(sessionID, security.GetClientIP(r))
+				}
+			}
+
+			// Sessão do suporte: somente leitura, enquanto o usuário autorizar,
+			// e sem o papel administrativo de quem está sendo visto. Chaves de
+			// API também nunca têm papel administrativo.
+			role := user.Role
+			if GetAPIKeyID(r) != "" {
+				role = storage.RoleNone
+			}
+			if adminID := GetImpersonatorID(r); adminID != "" {
+				if !checkImpersonation(store, w, r, user, adminID) {
+					return
+				}
+				role = storage.RoleNone
+			}
+
+			// Papel do token desatualizado (concedido/revogado após o login):
+			// vale o papel atual, para que a revogação tenha efeito imediato
+			if role != GetUserRole(r) {
+				r = r.WithContext(context.WithValue(r.Context(), userRoleKey, role))
+			}
+
+			// Mensagens no idioma salvo do usuário (antes do Accept-Language)
+			if user.Locale != "" {
+				r = r.WithContext(i18n.WithLocale(r.Context(), user.Locale))
+			}
+
+			next.ServeHTTP(w, r)
+		})
 	}
+}
 
-	// Definir novo cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "famli_session",
-		Value:    signed,
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   isSecureContextMiddleware(r),
-		SameSite: http.SameSiteLaxMode,
-		Expires:  now.Add(sessionDuration),
-		MaxAge:   int(sessionDuration.Seconds()),
-	})
+// renewSession renova o token JWT e o cookie de sessão
+// Mantém email, papel e sessão do dispositivo do token anterior.
+func renewSession(w http.ResponseWriter, r *http.Request, userID, email string, role storage.Role, sessionID, secret string) {
+	extra := jwt.MapClaims{}
+	if email != "" {
+		extra["email"] = email
+	}
+	if err := issueSessionCookie(w, r, secret, userID, role, sessionID, extra); err != nil {
+		log.Printf("[AUTH] Erro ao renovar sessão: %v", err)
+	}
 	// Não logar renovação - é uma operação normal e frequente
 }
 
@@ -175,16 +275,45 @@ func isSecureContextMiddleware(r *http.Request) bool {
 
 // GetUserID extrai o ID do usuário do contexto
 func GetUserID(r *http.Request) string {
-	value := r.Context().Value(userIDKey)
-	if value == nil {
-		return ""
-	}
-	if userID, ok := value.(string); ok {
+	return UserIDFromContext(r.Context())
+}
+
+// UserIDFromContext extrai o ID do usuário de um context.Context
+// Útil para código fora dos handlers (ex: features.Enabled)
+func UserIDFromContext(ctx context.Context) string {
+	if userID, ok := ctx.Value(userIDKey).(string); ok {
 		return userID
 	}
 	return ""
 }
 
+// GetSessionID extrai o ID da sessão do dispositivo do contexto
+// Vazio para tokens emitidos antes das sessões por dispositivo.
+func GetSessionID(r *http.Request) string {
+	if sessionID, ok := r.Context().Value(sessionIDKey).(string); ok {
+		return sessionID
+	}
+	return ""
+}
+
+// GetClientID extrai o cliente do token Bearer do contexto
+// Vazio para sessões por cookie.
+func GetClientID(r *http.Request) string {
+	if clientID, ok := r.Context().Value(clientIDKey).(string); ok {
+		return clientID
+	}
+	return ""
+}
+
+// GetImpersonatorID extrai o admin da sessão do suporte do contexto
+// Vazio fora das sessões do suporte.
+func GetImpersonatorID(r *http.Request) string {
+	if adminID, ok := r.Context().Value(impersonatorKey).(string); ok {
+		return adminID
+	}
+	return ""
+}
+
 // GetUserEmail extrai o email do usuário do contexto
 func GetUserEmail(r *http.Request) string {
 	value := r.Context().Value(userEmailKey)
@@ -196,3 +325,11 @@ func GetUserEmail(r *http.Request) string {
 	}
 	return ""
 }
+
+// GetUserRole extrai o papel administrativo do usuário do contexto
+func GetUserRole(r *http.Request) storage.Role {
+	if role, ok := r.Context().Value(userRoleKey).(storage.Role); ok {
+		return role
+	}
+	return storage.RoleNone
+}
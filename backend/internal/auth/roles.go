@@ -0,0 +1,94 @@
+// =============================================================================
+// FAMLI - Papéis Administrativos
+// =============================================================================
+// Controle de acesso ao painel admin baseado no papel do usuário.
+//
+// Papéis:
+// - support: atendimento (busca de contas, desativação, feedbacks)
+// - analyst: métricas e analytics (somente leitura)
+// - superadmin: acesso total, incluindo remoção de contas e gestão de papéis
+//
+// O papel é gravado no JWT no login e conferido com o banco a cada
+// requisição pelo ActiveUserMiddleware (revogação com efeito imediato).
+//
+// Primeiro acesso:
+// - ADMIN_EMAILS=admin@email.com,... promove esses usuários a superadmin
+//   no login, apenas enquanto não existir nenhum superadmin.
+// - Depois disso, papéis são gerenciados pelo painel admin.
+// =============================================================================
+
+package auth
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"famli/internal/apierror"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+// RequireRole permite acesso apenas a usuários com um dos papéis informados
+// Superadmin sempre tem acesso. Deve ser usado após JWTMiddleware e ActiveUserMiddleware.
+func RequireRole(roles ...storage.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role := GetUserRole(r)
+			if role == storage.RoleSuperadmin {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			for _, allowed := range roles {
+				if role == allowed {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			// Logar apenas tentativas não autorizadas (importante para segurança)
+			security.GetAuditLogger().LogSecurity(security.EventUnauthorizedAccess, security.GetClientIP(r), map[string]interface{}{
+				"user_id":  GetUserID(r),
+				"role":     string(role),
+				"resource": r.URL.Path,
+			})
+			apierror.Write(w, r, http.StatusForbidden, "admin.access_denied")
+		})
+	}
+}
+
+// BootstrapSuperadmin promove o usuário a superadmin se o email estiver em
+// adminEmails (ADMIN_EMAILS) e ainda não existir nenhum superadmin.
+// Atualiza user.Role.
+func BootstrapSuperadmin(store storage.Store, user *storage.User, adminEmails []string) {
+	if user.Role == storage.RoleSuperadmin || !inBootstrapEmails(user.Email, adminEmails) {
+		return
+	}
+
+	existing, err := store.SearchUsers(&storage.UserSearchParams{
+		Role:             storage.RoleSuperadmin,
+		PaginationParams: storage.PaginationParams{Limit: 1},
+	})
+	if err != nil || existing.Total > 0 {
+		return
+	}
+
+	if err := store.GrantRole(user.ID, storage.RoleSuperadmin); err != nil {
+		log.Printf("[AUTH] Erro ao promover superadmin inicial: %v", err)
+		return
+	}
+	user.Role = storage.RoleSuperadmin
+	log.Printf("[AUTH] Superadmin inicial definido: %s", maskEmail(user.Email))
+}
+
+// inBootstrapEmails verifica se o email está na lista de ADMIN_EMAILS
+func inBootstrapEmails(email string, adminEmails []string) bool {
+	email = strings.ToLower(strings.TrimSpace(email))
+	for _, admin := range adminEmails {
+		if admin = strings.TrimSpace(strings.ToLower(admin)); admin != "" && admin == email {
+			return true
+		}
+	}
+	return false
+}
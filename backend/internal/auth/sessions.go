@@ -0,0 +1,239 @@
+// =============================================================================
+// FAMLI - Sessões por Dispositivo
+// =============================================================================
+// Cada login (email/senha, OAuth ou token de app) cria uma sessão de dispositivo com
+// User-Agent, IP, criação e último acesso. O ID da sessão vai no JWT
+// (claim "sid") e é conferido a cada requisição: remover a sessão encerra o
+// login naquele dispositivo, mesmo com o JWT ainda dentro da validade.
+//
+// Tokens emitidos antes das sessões (sem "sid") continuam válidos até expirar.
+//
+// Endpoints (usuário autenticado):
+// - GET    /api/auth/devices             - sessões ativas (a atual com current=true)
+// - PUT    /api/auth/devices/{deviceID}  - renomeia ({name})
+// - DELETE /api/auth/devices/{deviceID}  - encerra a sessão do dispositivo
+// =============================================================================
+
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+
+	"famli/internal/apierror"
+	"famli/internal/i18n"
+	"famli/internal/ids"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+const (
+	// sessionTouchInterval evita gravar o último acesso a cada requisição
+	sessionTouchInterval = 5 * time.Minute
+
+	// maxDeviceNameLength limita o nome do dispositivo (em caracteres)
+	maxDeviceNameLength = 60
+
+	// maxUserAgentLength limita o User-Agent guardado
+	maxUserAgentLength = 500
+)
+
+// deviceResponse é uma sessão na listagem de dispositivos
+type deviceResponse struct {
+	*storage.DeviceSession
+	Current bool `json:"current"` // Sessão desta requisição
+}
+
+// StartSession registra a sessão do dispositivo e define o cookie de sessão
+// Usado pelo login com email/senha e pelo login social.
+//
+// Inclui o email no token para facilitar identificação em feedbacks e logs,
+// e o papel administrativo (se houver) para o controle de acesso ao admin.
+func StartSession(store storage.Store, secret string, w http.ResponseWriter, r *http.Request, user *storage.User) error {
+	session, err := createDeviceSession(store, r, user, "")
+	if err != nil {
+		return err
+	}
+
+	claims := jwt.MapClaims{
+		"email": user.Email,             // Email do usuário (para contexto)
+		"jti":   security.GenerateJTI(), // JWT ID único
+	}
+	return issueSessionCookie(w, r, secret, user.ID, user.Role, session.ID, claims)
+}
+
+// createDeviceSession registra a sessão do dispositivo da requisição
+// name vazio usa a descrição do User-Agent.
+func createDeviceSession(store storage.Store, r *http.Request, user *storage.User, name string) (*storage.DeviceSession, error) {
+	now := time.Now()
+
+	userAgent := r.UserAgent()
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	if name == "" {
+		name = security.DescribeDevice(userAgent)
+	}
+	session := &storage.DeviceSession{
+		ID:         ids.New(ids.Session),
+		UserID:     user.ID,
+		Name:       name,
+		UserAgent:  userAgent,
+		IPAddress:  security.GetClientIP(r),
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+	if err := store.CreateDeviceSession(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// signSessionToken assina o JWT da sessão
+// extra recebe claims adicionais (email, jti, cid).
+func signSessionToken(secret, userID string, role storage.Role, sessionID string, extra jwt.MapClaims) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(sessionDuration)
+
+	claims := jwt.MapClaims{
+		"sub": userID,           // Subject (ID do usuário)
+		"exp": expiresAt.Unix(), // Expira em 7 dias
+		"iat": now.Unix(),       // Issued at
+		"nbf": now.Unix(),       // Not before
+	}
+	for key, value := range extra {
+		claims[key] = value
+	}
+	if sessionID != "" {
+		claims["sid"] = sessionID // Sessão do dispositivo
+	}
+	if role.IsAdmin() {
+		claims["role"] = string(role) // Papel administrativo
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+// issueSessionCookie assina o JWT da sessão e define o cookie
+func issueSessionCookie(w http.ResponseWriter, r *http.Request, secret, userID string, role storage.Role, sessionID string, extra jwt.MapClaims) error {
+	signed, expiresAt, err := signSessionToken(secret, userID, role, sessionID, extra)
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     "famli_session",
+		Value:    signed,
+		Path:     "/",
+		HttpOnly: true,                         // Não acessível via JavaScript (previne XSS)
+		Secure:   isSecureContextMiddleware(r), // HTTPS only em produção
+		SameSite: http.SameSiteLaxMode,         // Proteção contra CSRF
+		Expires:  expiresAt,
+		MaxAge:   int(sessionDuration.Seconds()),
+	})
+	return nil
+}
+
+// ListDevices lista as sessões ativas do usuário
+//
+// Endpoint: GET /api/auth/devices
+func (h *Handler) ListDevices(w http.ResponseWriter, r *http.Request) {
+	userID := GetUserID(r)
+
+	sessions, err := h.store.ListDeviceSessions(userID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "auth.devices_error")
+		return
+	}
+
+	currentID := GetSessionID(r)
+	devices := make([]deviceResponse, 0, len(sessions))
+	for _, session := range sessions {
+		session.IPAddress = maskIP(session.IPAddress)
+		devices = append(devices, deviceResponse{DeviceSession: session, Current: session.ID == currentID})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"devices": devices,
+		"total":   len(devices),
+	})
+}
+
+// RenameDevice altera o nome de um dispositivo
+//
+// Endpoint: PUT /api/auth/devices/{deviceID}
+func (h *Handler) RenameDevice(w http.ResponseWriter, r *http.Request) {
+	userID := GetUserID(r)
+	deviceID := chi.URLParam(r, "deviceID")
+
+	var payload struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "auth.invalid_data")
+		return
+	}
+	name := security.SanitizeText(payload.Name, 0)
+	if name == "" || utf8.RuneCountInString(name) > maxDeviceNameLength {
+		apierror.Write(w, r, http.StatusBadRequest, "auth.device_name_invalid")
+		return
+	}
+
+	if err := h.store.RenameDeviceSession(userID, deviceID, name); err != nil {
+		h.writeDeviceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"id": deviceID, "name": name})
+}
+
+// RevokeDevice encerra a sessão de um dispositivo
+// O próximo acesso com o token daquele dispositivo recebe 401 (SESSION_REVOKED).
+// Encerrar a sessão atual equivale ao logout.
+//
+// Endpoint: DELETE /api/auth/devices/{deviceID}
+func (h *Handler) RevokeDevice(w http.ResponseWriter, r *http.Request) {
+	userID := GetUserID(r)
+	deviceID := chi.URLParam(r, "deviceID")
+
+	// Nome do dispositivo para a auditoria
+	var deviceName string
+	if session, err := h.store.GetDeviceSession(deviceID); err == nil && session.UserID == userID {
+		deviceName = session.Name
+	}
+
+	if err := h.store.DeleteDeviceSession(userID, deviceID); err != nil {
+		h.writeDeviceError(w, r, err)
+		return
+	}
+
+	current := deviceID == GetSessionID(r)
+	h.auditLogger.LogAuth(security.EventSessionRevoked, userID, security.GetClientIP(r), r.UserAgent(), "success", map[string]interface{}{
+		"session_id": deviceID,
+		"device":     deviceName,
+		"current":    current,
+	})
+
+	if current {
+		clearSessionCookie(w, r)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"message": i18n.Tr(r, "auth.device_revoked"),
+		"current": current,
+	})
+}
+
+// writeDeviceError converte erros do storage em respostas
+func (h *Handler) writeDeviceError(w http.ResponseWriter, r *http.Request, err error) {
+	apierror.WriteStorage(w, r, err, apierror.StorageKeys{NotFound: "auth.device_not_found", Internal: "auth.devices_error"})
+}
@@ -0,0 +1,118 @@
+// =============================================================================
+// FAMLI - Tokens para Apps e Clientes de API
+// =============================================================================
+// Apps móveis não usam cookies: fazem login em POST /api/auth/token e enviam
+// o JWT recebido no header:
+//
+//	Authorization: Bearer <token>
+//
+// O token é o mesmo JWT da sessão por cookie (mesmas claims, mesma validade)
+// mais a claim "cid" com o cliente. Cada login cria uma sessão de dispositivo
+// (sessions.go), então o logout e o encerramento pela lista de dispositivos
+// também invalidam o token.
+//
+// Apenas clientes listados em API_TOKEN_CLIENTS (validados pelo pacote
+// config) podem obter e usar tokens.
+// Remover um cliente da lista invalida os tokens já emitidos para ele.
+// Sem a variável, o endpoint responde 403 e só o cookie é aceito.
+// =============================================================================
+
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"famli/internal/apierror"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+// tokenPayload é o payload de POST /api/auth/token
+type tokenPayload struct {
+	loginPayload
+	ClientID string `json:"client_id"`
+}
+
+// tokenClientSet indexa os clientes habilitados (API_TOKEN_CLIENTS)
+func tokenClientSet(clientIDs []string) map[string]bool {
+	clients := make(map[string]bool, len(clientIDs))
+	for _, clientID := range clientIDs {
+		clients[strings.ToLower(strings.TrimSpace(clientID))] = true
+	}
+	return clients
+}
+
+// IssueToken autentica com email e senha e retorna um token Bearer
+//
+// Endpoint: POST /api/auth/token
+//
+// Mesmas proteções do login (rate limit, bloqueio da conta, auditoria),
+// sem cookie.
+func (h *Handler) IssueToken(w http.ResponseWriter, r *http.Request) {
+	clientIP := security.GetClientIP(r)
+
+	// Verificar rate limit
+	if !h.allowLogin(w, r, clientIP) {
+		return
+	}
+
+	var payload tokenPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "auth.invalid_data")
+		return
+	}
+
+	// Cliente habilitado (antes de conferir a senha)
+	clientID := strings.ToLower(strings.TrimSpace(payload.ClientID))
+	if !h.tokenClients[clientID] {
+		h.auditLogger.LogSecurity(security.EventUnauthorizedAccess, clientIP, map[string]interface{}{
+			"endpoint":  "auth_token",
+			"client_id": security.SanitizeText(payload.ClientID, 40),
+		})
+		apierror.Write(w, r, http.StatusForbidden, "auth.token_client_invalid")
+		return
+	}
+
+	user, ok := h.authenticate(w, r, payload.loginPayload, clientIP)
+	if !ok {
+		return
+	}
+
+	token, err := h.issueBearerToken(r, user, clientID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "auth.session_error")
+		return
+	}
+
+	h.auditLogger.LogAuth(security.EventLoginSuccess, user.ID, clientIP, r.UserAgent(), "success", map[string]interface{}{
+		"transport": "token",
+		"client_id": clientID,
+	})
+	h.checkLoginContext(user, clientIP, r)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   int(sessionDuration.Seconds()),
+		"user":         userResponse(user),
+	})
+}
+
+// issueBearerToken registra a sessão do dispositivo e assina o token do cliente
+func (h *Handler) issueBearerToken(r *http.Request, user *storage.User, clientID string) (string, error) {
+	session, err := createDeviceSession(h.store, r, user, clientID)
+	if err != nil {
+		return "", err
+	}
+
+	token, _, err := signSessionToken(h.jwtSecret, user.ID, user.Role, session.ID, jwt.MapClaims{
+		"email": user.Email,
+		"jti":   security.GenerateJTI(),
+		"cid":   clientID, // Cliente que recebeu o token
+	})
+	return token, err
+}
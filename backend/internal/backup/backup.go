@@ -0,0 +1,356 @@
+// =============================================================================
+// FAMLI - Backup e Restauração
+// =============================================================================
+// Caminho de recuperação de desastres independente de dumps do PostgreSQL:
+//
+// - POST /api/admin/backup gera um export completo (contas, itens,
+//   guardiões e links), comprimido e cifrado com BACKUP_ENCRYPTION_KEY,
+//   gravado no disco (BACKUP_DIR) ou enviado ao S3 (BACKUP_S3_*)
+// - famli -restore arquivo [-dry-run] verifica o arquivo inteiro e recria as
+//   contas que ainda não existem, com os IDs originais
+//
+// O conteúdo (antes de cifrar) é JSON Lines comprimido com gzip:
+//
+//	{"kind":"header","version":1,"created_at":"..."}
+//	{"kind":"user","user":{...}}            (um por conta, ver storage.UserBackup)
+//	{"kind":"footer","stats":{...}}         (contagens, conferidas na leitura)
+//
+// Fora do backup: famílias, categorias, configurações, progresso do Guia,
+// históricos e eventos. Itens de famílias voltam para a caixa pessoal.
+// =============================================================================
+
+package backup
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"famli/internal/storage"
+)
+
+// formatVersion é a versão do conteúdo do backup
+const formatVersion = 1
+
+// fileExtension identifica os arquivos de backup
+const fileExtension = ".famlibak"
+
+var (
+	// ErrDisabled indica que BACKUP_ENCRYPTION_KEY não foi configurada
+	ErrDisabled = errors.New("backup não configurado")
+
+	// ErrRunning indica que já há um backup em andamento
+	ErrRunning = errors.New("backup em andamento")
+)
+
+// Config é a configuração dos backups
+type Config struct {
+	EncryptionKey string    // Vazio = backups desabilitados
+	Dir           string    // Destino local (sem S3)
+	S3            *S3Config // nil = disco local
+}
+
+// Stats são as contagens de um backup
+type Stats struct {
+	Users      int `json:"users"`
+	Items      int `json:"items"`
+	Guardians  int `json:"guardians"`
+	ShareLinks int `json:"share_links"`
+}
+
+// add soma os registros de uma conta
+func (s *Stats) add(data *storage.UserBackup) {
+	s.Users++
+	s.Items += len(data.Items)
+	s.Guardians += len(data.Guardians)
+	s.ShareLinks += len(data.ShareLinks)
+}
+
+// Result descreve um backup gerado
+type Result struct {
+	Location  string    `json:"location"` // Caminho local ou s3://bucket/chave
+	Bytes     int64     `json:"bytes"`
+	Stats     Stats     `json:"stats"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// record é uma linha do conteúdo do backup
+type record struct {
+	Kind      string              `json:"kind"` // header, user ou footer
+	Version   int                 `json:"version,omitempty"`
+	CreatedAt *time.Time          `json:"created_at,omitempty"`
+	User      *storage.UserBackup `json:"user,omitempty"`
+	Stats     *Stats              `json:"stats,omitempty"`
+}
+
+// Service gera os backups (um de cada vez)
+type Service struct {
+	store  storage.Store
+	config *Config
+	mu     sync.Mutex
+}
+
+// NewService cria o serviço de backup
+func NewService(store storage.Store, config *Config) *Service {
+	return &Service{store: store, config: config}
+}
+
+// Enabled indica se os backups estão configurados
+func (s *Service) Enabled() bool {
+	return s.config.EncryptionKey != ""
+}
+
+// Run gera um backup completo no destino configurado
+//
+// Retorna ErrRunning se outro backup ainda estiver sendo gerado.
+func (s *Service) Run(ctx context.Context) (*Result, error) {
+	if !s.Enabled() {
+		return nil, ErrDisabled
+	}
+	if !s.mu.TryLock() {
+		return nil, ErrRunning
+	}
+	defer s.mu.Unlock()
+
+	createdAt := time.Now().UTC()
+	name := "famli-backup-" + createdAt.Format("20060102T150405Z") + fileExtension
+
+	// No S3 o arquivo é montado em disco temporário (o PUT precisa do tamanho)
+	dir := s.config.Dir
+	if s.config.S3 != nil {
+		dir = os.TempDir()
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("erro ao criar diretório de backup: %w", err)
+	}
+
+	file, err := os.CreateTemp(dir, name+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar arquivo de backup: %w", err)
+	}
+	tmpPath := file.Name()
+	defer os.Remove(tmpPath) // Sem efeito depois do rename
+
+	stats, err := Write(file, s.store, s.config.EncryptionKey, createdAt)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(tmpPath)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Bytes: info.Size(), Stats: *stats, CreatedAt: createdAt}
+
+	if s.config.S3 != nil {
+		upload, err := os.Open(tmpPath)
+		if err != nil {
+			return nil, err
+		}
+		defer upload.Close()
+		result.Location, err = newS3Uploader(s.config.S3).Put(ctx, name, upload, info.Size())
+		if err != nil {
+			return nil, fmt.Errorf("erro ao enviar backup ao S3: %w", err)
+		}
+		return result, nil
+	}
+
+	finalPath := filepath.Join(dir, name)
+	if err := os.Rename(tmpPath, finalPath); err != nil {
+		return nil, err
+	}
+	result.Location = finalPath
+	return result, nil
+}
+
+// Write grava um backup completo e cifrado em w
+func Write(w io.Writer, store storage.Store, secret string, createdAt time.Time) (*Stats, error) {
+	encrypted, err := newEncryptWriter(w, secret)
+	if err != nil {
+		return nil, err
+	}
+	compressed := gzip.NewWriter(encrypted)
+	encoder := json.NewEncoder(compressed)
+
+	if err := encoder.Encode(&record{Kind: "header", Version: formatVersion, CreatedAt: &createdAt}); err != nil {
+		return nil, err
+	}
+
+	stats := &Stats{}
+	params := storage.UserSearchParams{PaginationParams: storage.PaginationParams{Limit: storage.MaxPageSize}}
+	for {
+		page, err := store.SearchUsers(&params)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao listar contas: %w", err)
+		}
+		for _, user := range page.Items {
+			data, err := store.BackupUserData(user.ID)
+			if errors.Is(err, storage.ErrNotFound) {
+				continue // Conta removida durante o backup
+			}
+			if err != nil {
+				return nil, fmt.Errorf("erro ao exportar a conta %s: %w", user.ID, err)
+			}
+			if err := encoder.Encode(&record{Kind: "user", User: data}); err != nil {
+				return nil, err
+			}
+			stats.add(data)
+		}
+		if !page.HasMore {
+			break
+		}
+		params.Cursor = page.NextCursor
+	}
+
+	if err := encoder.Encode(&record{Kind: "footer", Stats: stats}); err != nil {
+		return nil, err
+	}
+	if err := compressed.Close(); err != nil {
+		return nil, err
+	}
+	if err := encrypted.Close(); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// Read decifra o backup e chama fn para cada conta, na ordem do arquivo
+//
+// Confere o cabeçalho, a versão e as contagens do rodapé. fn pode ser nil
+// (apenas verificação). Se fn retornar erro, a leitura para.
+func Read(r io.Reader, secret string, fn func(*storage.UserBackup) error) (*Stats, time.Time, error) {
+	var createdAt time.Time
+
+	decrypted, err := newDecryptReader(bufio.NewReader(r), secret)
+	if err != nil {
+		return nil, createdAt, err
+	}
+	decompressed, err := gzip.NewReader(decrypted)
+	if err != nil {
+		return nil, createdAt, ErrCorrupted
+	}
+	decoder := json.NewDecoder(decompressed)
+
+	var header record
+	if err := decoder.Decode(&header); err != nil || header.Kind != "header" || header.CreatedAt == nil {
+		return nil, createdAt, ErrCorrupted
+	}
+	if header.Version != formatVersion {
+		return nil, createdAt, fmt.Errorf("versão de backup não suportada: %d", header.Version)
+	}
+	createdAt = *header.CreatedAt
+
+	stats := &Stats{}
+	for {
+		var rec record
+		if err := decoder.Decode(&rec); err != nil {
+			if errors.Is(err, ErrCorrupted) {
+				return nil, createdAt, err
+			}
+			return nil, createdAt, ErrCorrupted // Sem rodapé: arquivo incompleto
+		}
+
+		switch rec.Kind {
+		case "user":
+			if rec.User == nil || rec.User.User == nil || rec.User.User.ID == "" {
+				return nil, createdAt, ErrCorrupted
+			}
+			stats.add(rec.User)
+			if fn != nil {
+				if err := fn(rec.User); err != nil {
+					return nil, createdAt, err
+				}
+			}
+		case "footer":
+			if rec.Stats == nil || *rec.Stats != *stats {
+				return nil, createdAt, ErrCorrupted
+			}
+			// Nada depois do rodapé (e o stream cifrado precisa terminar no bloco final)
+			if _, err := io.Copy(io.Discard, decompressed); err != nil {
+				return nil, createdAt, ErrCorrupted
+			}
+			if decoder.More() {
+				return nil, createdAt, ErrCorrupted
+			}
+			return stats, createdAt, nil
+		default:
+			return nil, createdAt, ErrCorrupted
+		}
+	}
+}
+
+// RestoreReport resume uma restauração
+type RestoreReport struct {
+	CreatedAt time.Time // Quando o backup foi gerado
+	Stats     Stats     // Conteúdo do arquivo
+	Restored  int       // Contas recriadas
+	Skipped   int       // Contas que já existiam (ID ou email)
+	DryRun    bool
+}
+
+// Restore verifica o arquivo inteiro e, fora do dry-run, recria as contas
+//
+// Nada é gravado se o arquivo estiver corrompido. Contas que já existem são
+// puladas, então a restauração pode ser repetida depois de uma falha.
+func Restore(store storage.Store, path, secret string, dryRun bool) (*RestoreReport, error) {
+	open := func() (*os.File, error) {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao abrir backup: %w", err)
+		}
+		return file, nil
+	}
+
+	// 1ª leitura: integridade (blocos, rodapé e contagens)
+	file, err := open()
+	if err != nil {
+		return nil, err
+	}
+	stats, createdAt, err := Read(file, secret, nil)
+	file.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RestoreReport{CreatedAt: createdAt, Stats: *stats, DryRun: dryRun}
+	if dryRun {
+		return report, nil
+	}
+
+	// 2ª leitura: gravação
+	file, err = open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	_, _, err = Read(file, secret, func(data *storage.UserBackup) error {
+		err := store.RestoreUserData(data)
+		switch {
+		case err == nil:
+			report.Restored++
+		case errors.Is(err, storage.ErrAlreadyExists):
+			report.Skipped++
+		default:
+			return fmt.Errorf("erro ao restaurar a conta %s: %w", data.User.ID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+	return report, nil
+}
@@ -0,0 +1,73 @@
+// =============================================================================
+// FAMLI - Backup: Endpoint Administrativo
+// =============================================================================
+// POST /api/admin/backup gera o backup de forma síncrona e responde com a
+// localização do arquivo. Apenas superadmin (verificado nas rotas).
+// =============================================================================
+
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/security"
+)
+
+// Handler expõe o backup para administradores
+type Handler struct {
+	service     *Service
+	auditLogger *security.AuditLogger
+}
+
+// NewHandler cria o handler de backup
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service:     service,
+		auditLogger: security.GetAuditLogger(),
+	}
+}
+
+// Create gera um backup completo
+//
+// Endpoint: POST /api/admin/backup
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	adminID := auth.GetUserID(r)
+	ip := security.GetClientIP(r)
+
+	result, err := h.service.Run(r.Context())
+	switch {
+	case errors.Is(err, ErrDisabled):
+		apierror.Write(w, r, http.StatusServiceUnavailable, "admin.backup_disabled")
+		return
+	case errors.Is(err, ErrRunning):
+		apierror.Write(w, r, http.StatusConflict, "admin.backup_running")
+		return
+	case err != nil:
+		log.Printf("[BACKUP] Erro ao gerar backup: %v", err)
+		h.auditLogger.LogDataAccess(adminID, ip, "admin/backup", "create", "failure")
+		apierror.Write(w, r, http.StatusInternalServerError, "admin.backup_error")
+		return
+	}
+
+	log.Printf("[BACKUP] Backup gerado em %s (%d contas, %d bytes)", result.Location, result.Stats.Users, result.Bytes)
+	h.auditLogger.LogDataAccess(adminID, ip, "admin/backup", "create", "success")
+	writeJSON(w, http.StatusCreated, result)
+}
+
+// =============================================================================
+// HELPERS
+// =============================================================================
+
+// writeJSON escreve resposta JSON
+func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
+	security.SetJSONHeaders(w)
+	w.WriteHeader(status)
+	if payload != nil {
+		json.NewEncoder(w).Encode(payload)
+	}
+}
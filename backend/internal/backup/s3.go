@@ -0,0 +1,138 @@
+// =============================================================================
+// FAMLI - Backup: Envio para o S3
+// =============================================================================
+// Cliente mínimo para um único PUT de objeto (AWS S3 ou compatível, como
+// MinIO ou Cloudflare R2), assinado com AWS Signature Version 4. O corpo não
+// entra na assinatura (UNSIGNED-PAYLOAD): a integridade do backup é
+// garantida pelo AES-GCM do próprio arquivo.
+// =============================================================================
+
+package backup
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// S3Config é o destino dos backups no S3
+type S3Config struct {
+	Bucket          string
+	Region          string
+	Endpoint        string // Vazio = AWS (https://s3.{region}.amazonaws.com)
+	Prefix          string // "Pasta" dos objetos, ex: "famli/backups"
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// s3Uploader envia objetos para o bucket configurado
+type s3Uploader struct {
+	config *S3Config
+	client *http.Client
+	now    func() time.Time
+}
+
+func newS3Uploader(config *S3Config) *s3Uploader {
+	return &s3Uploader{
+		config: config,
+		client: &http.Client{Timeout: 30 * time.Minute},
+		now:    time.Now,
+	}
+}
+
+// objectURL monta a URL do objeto (path-style, aceito pela AWS e pelos compatíveis)
+func (u *s3Uploader) objectURL(key string) (*url.URL, error) {
+	endpoint := u.config.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + u.config.Region + ".amazonaws.com"
+	}
+	base, err := url.Parse(strings.TrimRight(endpoint, "/"))
+	if err != nil {
+		return nil, err
+	}
+	objectKey := key
+	if prefix := strings.Trim(u.config.Prefix, "/"); prefix != "" {
+		objectKey = prefix + "/" + key
+	}
+	base.Path += "/" + u.config.Bucket + "/" + objectKey
+	return base, nil
+}
+
+// Put envia o corpo (de tamanho conhecido) e retorna a localização s3://
+func (u *s3Uploader) Put(ctx context.Context, key string, body io.Reader, size int64) (string, error) {
+	target, err := u.objectURL(key)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target.String(), body)
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	u.sign(req)
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("S3 respondeu %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+
+	return "s3://" + u.config.Bucket + "/" + strings.TrimPrefix(target.Path, "/"+u.config.Bucket+"/"), nil
+}
+
+// sign adiciona a assinatura AWS SigV4 à requisição
+func (u *s3Uploader) sign(req *http.Request) {
+	now := u.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := "UNSIGNED-PAYLOAD"
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "content-type;host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "content-type:" + req.Header.Get("Content-Type") + "\n" +
+		"host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		"",
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + u.config.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+u.config.SecretAccessKey), day)
+	key = hmacSHA256(key, u.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+u.config.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,224 @@
+// =============================================================================
+// FAMLI - Backup: Criptografia em Fluxo
+// =============================================================================
+// O arquivo de backup é cifrado em blocos, sem precisar caber na memória:
+//
+//	"FAMLIBK1" | salt (16) | prefixo do nonce (7) | blocos...
+//	bloco: final (1) | tamanho (4, big-endian) | AES-256-GCM(até 64 KiB)
+//
+// A chave vem de BACKUP_ENCRYPTION_KEY com Argon2id e o salt do arquivo.
+// O nonce de cada bloco é prefixo | contador (4) | final (1): blocos
+// trocados de ordem, removidos do fim ou adulterados falham na leitura.
+// =============================================================================
+
+package backup
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Formato do arquivo
+const (
+	fileMagic       = "FAMLIBK1"
+	saltSize        = 16
+	noncePrefixSize = 7
+	chunkSize       = 64 * 1024
+)
+
+// Parâmetros Argon2id (os mesmos da criptografia dos dados no banco)
+const (
+	argon2Time    = 3
+	argon2Memory  = 64 * 1024
+	argon2Threads = 4
+	argon2KeyLen  = 32
+)
+
+// ErrCorrupted indica um arquivo de backup adulterado, truncado ou cifrado
+// com outra chave
+var ErrCorrupted = errors.New("backup corrompido ou chave incorreta")
+
+// newAEAD deriva a chave do backup e monta o AES-256-GCM
+func newAEAD(secret string, salt []byte) (cipher.AEAD, error) {
+	key := argon2.IDKey([]byte(secret), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// chunkNonce monta o nonce do bloco a partir do contador e do marcador de fim
+func chunkNonce(prefix []byte, counter uint32, final bool) []byte {
+	nonce := make([]byte, 0, noncePrefixSize+5)
+	nonce = append(nonce, prefix...)
+	nonce = binary.BigEndian.AppendUint32(nonce, counter)
+	if final {
+		return append(nonce, 1)
+	}
+	return append(nonce, 0)
+}
+
+// =============================================================================
+// ESCRITA
+// =============================================================================
+
+// encryptWriter cifra o que recebe em blocos de até chunkSize
+// Close grava o bloco final (obrigatório para o arquivo ser válido).
+type encryptWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint32
+	buf     []byte
+	closed  bool
+}
+
+// newEncryptWriter grava o cabeçalho e retorna o writer cifrado
+func newEncryptWriter(w io.Writer, secret string) (*encryptWriter, error) {
+	header := make([]byte, saltSize+noncePrefixSize)
+	if _, err := io.ReadFull(rand.Reader, header); err != nil {
+		return nil, fmt.Errorf("erro ao gerar salt: %w", err)
+	}
+	aead, err := newAEAD(secret, header[:saltSize])
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(w, fileMagic); err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{
+		w:      w,
+		aead:   aead,
+		prefix: header[saltSize:],
+		buf:    make([]byte, 0, chunkSize),
+	}, nil
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := copy(e.buf[len(e.buf):chunkSize], p)
+		e.buf = e.buf[:len(e.buf)+n]
+		p = p[n:]
+		written += n
+		// Só grava blocos cheios quando há mais dados: o último bloco é o final
+		if len(e.buf) == chunkSize && len(p) > 0 {
+			if err := e.flush(false); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// Close grava o que sobrou como bloco final
+func (e *encryptWriter) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	return e.flush(true)
+}
+
+func (e *encryptWriter) flush(final bool) error {
+	sealed := e.aead.Seal(nil, chunkNonce(e.prefix, e.counter, final), e.buf, nil)
+	frame := make([]byte, 5, 5+len(sealed))
+	if final {
+		frame[0] = 1
+	}
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(sealed)))
+	if _, err := e.w.Write(append(frame, sealed...)); err != nil {
+		return err
+	}
+	e.counter++
+	e.buf = e.buf[:0]
+	return nil
+}
+
+// =============================================================================
+// LEITURA
+// =============================================================================
+
+// decryptReader decifra e autentica os blocos na ordem
+// Retorna ErrCorrupted se o arquivo terminar antes do bloco final ou se
+// houver dados depois dele.
+type decryptReader struct {
+	r       io.Reader
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint32
+	buf     []byte
+	done    bool
+}
+
+// newDecryptReader confere o cabeçalho e retorna o reader decifrado
+func newDecryptReader(r io.Reader, secret string) (*decryptReader, error) {
+	header := make([]byte, len(fileMagic)+saltSize+noncePrefixSize)
+	if _, err := io.ReadFull(r, header); err != nil || string(header[:len(fileMagic)]) != fileMagic {
+		return nil, ErrCorrupted
+	}
+	salt := header[len(fileMagic) : len(fileMagic)+saltSize]
+	aead, err := newAEAD(secret, salt)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptReader{r: r, aead: aead, prefix: header[len(fileMagic)+saltSize:]}, nil
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.buf) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
+// next lê e decifra o próximo bloco
+func (d *decryptReader) next() error {
+	var frame [5]byte
+	if _, err := io.ReadFull(d.r, frame[:]); err != nil {
+		return ErrCorrupted
+	}
+	final := frame[0] == 1
+	size := binary.BigEndian.Uint32(frame[1:])
+	if frame[0] > 1 || size > chunkSize+uint32(d.aead.Overhead()) {
+		return ErrCorrupted
+	}
+
+	sealed := make([]byte, size)
+	if _, err := io.ReadFull(d.r, sealed); err != nil {
+		return ErrCorrupted
+	}
+	plain, err := d.aead.Open(nil, chunkNonce(d.prefix, d.counter, final), sealed, nil)
+	if err != nil {
+		return ErrCorrupted
+	}
+	d.counter++
+	d.buf = plain
+
+	if final {
+		d.done = true
+		var extra [1]byte
+		if _, err := io.ReadFull(d.r, extra[:]); err != io.EOF {
+			return ErrCorrupted
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+// =============================================================================
+// FAMLI - Recursos Premium
+// =============================================================================
+// Middlewares que limitam o plano gratuito:
+// - RequirePremium: recurso exclusivo do premium (ex: vincular WhatsApp)
+// - LimitGuardians: cadastro de guardiões além do limite gratuito
+//   (CheckGuardianLimit para cadastros em lote)
+//
+// Bloqueios respondem 402 com o código PREMIUM_REQUIRED para o frontend
+// oferecer a assinatura. Com a cobrança desabilitada nada é bloqueado.
+//
+// Os middlewares rodam depois de auth.JWTMiddleware (usam o usuário logado).
+// =============================================================================
+
+package billing
+
+import (
+	"net/http"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/i18n"
+)
+
+// RequirePremium permite a rota apenas para assinantes premium
+func (h *Handler) RequirePremium(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !h.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		user, found := h.store.GetUserByID(auth.GetUserID(r))
+		if !found {
+			apierror.Write(w, r, http.StatusUnauthorized, "auth.user_not_found")
+			return
+		}
+		if !user.IsPremium() {
+			writePremiumRequired(w, r, i18n.Tr(r, "billing.premium_required"), nil)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// LimitGuardians bloqueia novos guardiões acima do limite do plano gratuito
+func (h *Handler) LimitGuardians(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.CheckGuardianLimit(w, r, 1) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// CheckGuardianLimit verifica se o usuário pode cadastrar mais adding
+// guardiões (ex: a importação de contatos); se não puder, responde 402 e
+// retorna false
+func (h *Handler) CheckGuardianLimit(w http.ResponseWriter, r *http.Request, adding int) bool {
+	if !h.Enabled() || h.config.FreeMaxGuardians <= 0 {
+		return true
+	}
+
+	userID := auth.GetUserID(r)
+	user, found := h.store.GetUserByID(userID)
+	if !found {
+		apierror.Write(w, r, http.StatusUnauthorized, "auth.user_not_found")
+		return false
+	}
+	if user.IsPremium() {
+		return true
+	}
+
+	count, err := h.store.CountGuardians(userID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "billing.status_error")
+		return false
+	}
+	if count+adding > h.config.FreeMaxGuardians {
+		writePremiumRequired(w, r, i18n.Trn(r, "billing.guardian_limit", h.config.FreeMaxGuardians, nil),
+			map[string]interface{}{"limit": h.config.FreeMaxGuardians})
+		return false
+	}
+	return true
+}
+
+// writePremiumRequired responde 402 indicando que o recurso exige o premium
+func writePremiumRequired(w http.ResponseWriter, r *http.Request, message string, details map[string]interface{}) {
+	apierror.WriteMessage(w, r, http.StatusPaymentRequired, "PREMIUM_REQUIRED", message, details)
+}
@@ -0,0 +1,286 @@
+// =============================================================================
+// FAMLI - Assinaturas (Stripe)
+// =============================================================================
+// Planos:
+// - free: plano padrão, com limites (guardiões) e sem recursos premium
+// - premium: assinatura mensal paga pelo Stripe Checkout
+//
+// Endpoints (usuário autenticado):
+// - GET  /api/billing          - plano atual e status da assinatura
+// - POST /api/billing/checkout - link do Stripe Checkout para assinar
+// - GET  /api/billing/portal   - link do portal do cliente (cartão, faturas, cancelamento)
+//
+// Endpoint público (chamado pelo Stripe):
+// - POST /api/billing/webhook  - ciclo de vida da assinatura (webhook.go)
+//
+// Recursos premium são protegidos pelos middlewares de gate.go.
+//
+// Sem STRIPE_SECRET_KEY a cobrança fica desabilitada: os endpoints de
+// assinatura respondem 404 e nenhum recurso é bloqueado (instalações próprias).
+// =============================================================================
+
+package billing
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+// maxWebhookBody limita o corpo dos eventos do Stripe
+const maxWebhookBody = 256 * 1024
+
+// Config é a configuração das assinaturas
+type Config struct {
+	StripeSecretKey     string // Vazio = cobrança desabilitada
+	StripeWebhookSecret string // Segredo de assinatura dos webhooks
+	PriceID             string // Preço mensal do plano premium
+	AppURL              string // Base das URLs de retorno (sem "/" no final)
+	FreeMaxGuardians    int    // Guardiões no plano gratuito (0 = sem limite)
+}
+
+// Handler gerencia as assinaturas
+type Handler struct {
+	store       storage.Store
+	config      *Config
+	stripe      *StripeClient // nil com a cobrança desabilitada
+	auditLogger *security.AuditLogger
+}
+
+// NewHandler cria uma nova instância do handler de assinaturas
+func NewHandler(store storage.Store, config *Config) *Handler {
+	h := &Handler{
+		store:       store,
+		config:      config,
+		auditLogger: security.GetAuditLogger(),
+	}
+	if config.StripeSecretKey != "" {
+		h.stripe = NewStripeClient(config.StripeSecretKey)
+	}
+	return h
+}
+
+// Enabled indica se a cobrança está configurada
+func (h *Handler) Enabled() bool {
+	return h.stripe != nil
+}
+
+// =============================================================================
+// ENDPOINTS
+// =============================================================================
+
+// Status retorna o plano do usuário e a assinatura
+//
+// Endpoint: GET /api/billing
+func (h *Handler) Status(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	user, found := h.store.GetUserByID(userID)
+	if !found {
+		apierror.Write(w, r, http.StatusNotFound, "auth.user_not_found")
+		return
+	}
+
+	response := map[string]interface{}{
+		"enabled":            h.Enabled(),
+		"plan":               planOf(user),
+		"free_max_guardians": h.config.FreeMaxGuardians,
+	}
+
+	sub, err := h.store.GetSubscription(userID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		apierror.Write(w, r, http.StatusInternalServerError, "billing.status_error")
+		return
+	}
+	if sub != nil && sub.SubscriptionID != "" {
+		response["subscription"] = sub
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// Checkout cria o link do Stripe Checkout para assinar o premium
+//
+// Endpoint: POST /api/billing/checkout
+//
+// O cliente do Stripe é criado na primeira vez e reaproveitado depois.
+// O plano muda apenas quando o webhook confirmar o pagamento.
+func (h *Handler) Checkout(w http.ResponseWriter, r *http.Request) {
+	if !h.Enabled() {
+		apierror.Write(w, r, http.StatusNotFound, "billing.disabled")
+		return
+	}
+
+	userID := auth.GetUserID(r)
+	user, found := h.store.GetUserByID(userID)
+	if !found {
+		apierror.Write(w, r, http.StatusNotFound, "auth.user_not_found")
+		return
+	}
+	if user.IsPremium() {
+		apierror.Write(w, r, http.StatusConflict, "billing.already_premium")
+		return
+	}
+
+	sub, err := h.store.GetSubscription(userID)
+	if errors.Is(err, storage.ErrNotFound) {
+		customerID, createErr := h.stripe.CreateCustomer(user.Email, user.ID)
+		if createErr != nil {
+			log.Printf("[BILLING] Erro ao criar cliente: %v", createErr)
+			apierror.Write(w, r, http.StatusBadGateway, "billing.checkout_error")
+			return
+		}
+		sub = &storage.Subscription{UserID: userID, CustomerID: customerID, Plan: storage.PlanFree}
+		err = h.store.SaveSubscription(sub)
+	}
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "billing.checkout_error")
+		return
+	}
+
+	checkoutURL, err := h.stripe.CreateCheckoutSession(sub.CustomerID, h.config.PriceID, userID,
+		h.config.AppURL+"/perfil?billing=success",
+		h.config.AppURL+"/perfil?billing=canceled")
+	if err != nil {
+		log.Printf("[BILLING] Erro ao criar checkout: %v", err)
+		apierror.Write(w, r, http.StatusBadGateway, "billing.checkout_error")
+		return
+	}
+
+	h.auditLogger.LogDataAccess(userID, security.GetClientIP(r), "billing/checkout", "create", "success")
+	writeJSON(w, http.StatusOK, map[string]string{"url": checkoutURL})
+}
+
+// Portal cria o link do portal do cliente no Stripe
+//
+// Endpoint: GET /api/billing/portal
+//
+// Disponível para quem já iniciou uma assinatura (404 caso contrário).
+func (h *Handler) Portal(w http.ResponseWriter, r *http.Request) {
+	if !h.Enabled() {
+		apierror.Write(w, r, http.StatusNotFound, "billing.disabled")
+		return
+	}
+
+	userID := auth.GetUserID(r)
+	sub, err := h.store.GetSubscription(userID)
+	if errors.Is(err, storage.ErrNotFound) || (err == nil && sub.SubscriptionID == "") {
+		apierror.Write(w, r, http.StatusNotFound, "billing.no_subscription")
+		return
+	}
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "billing.portal_error")
+		return
+	}
+
+	portalURL, err := h.stripe.CreatePortalSession(sub.CustomerID, h.config.AppURL+"/perfil")
+	if err != nil {
+		log.Printf("[BILLING] Erro ao criar portal: %v", err)
+		apierror.Write(w, r, http.StatusBadGateway, "billing.portal_error")
+		return
+	}
+
+	h.auditLogger.LogDataAccess(userID, security.GetClientIP(r), "billing/portal", "create", "success")
+	writeJSON(w, http.StatusOK, map[string]string{"url": portalURL})
+}
+
+// Webhook recebe os eventos de assinatura do Stripe
+//
+// Endpoint: POST /api/billing/webhook
+//
+// Responde 400 para assinatura inválida e 500 para falhas ao gravar
+// (o Stripe tenta de novo); eventos sem efeito respondem 200.
+func (h *Handler) Webhook(w http.ResponseWriter, r *http.Request) {
+	if !h.Enabled() {
+		apierror.Write(w, r, http.StatusNotFound, "billing.disabled")
+		return
+	}
+
+	clientIP := security.GetClientIP(r)
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "billing.invalid_event")
+		return
+	}
+
+	if err := verifySignature(payload, r.Header.Get("Stripe-Signature"), h.config.StripeWebhookSecret, time.Now()); err != nil {
+		h.auditLogger.LogSecurity(security.EventTokenInvalid, clientIP, map[string]interface{}{
+			"endpoint": "billing_webhook",
+		})
+		apierror.Write(w, r, http.StatusBadRequest, "billing.invalid_event")
+		return
+	}
+
+	var event stripeEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "billing.invalid_event")
+		return
+	}
+
+	var sub *storage.Subscription
+	switch event.Type {
+	case "checkout.session.completed":
+		var session checkoutSession
+		if err = json.Unmarshal(event.Data.Object, &session); err == nil {
+			sub, err = h.applyCheckout(&session)
+		}
+	case "customer.subscription.created", "customer.subscription.updated", "customer.subscription.deleted":
+		var object stripeSubscription
+		if err = json.Unmarshal(event.Data.Object, &object); err == nil {
+			sub, err = h.applySubscription(event.Type, &object)
+		}
+	}
+	if err != nil {
+		log.Printf("[BILLING] Erro ao processar evento %s (%s): %v", event.ID, event.Type, err)
+		apierror.Write(w, r, http.StatusInternalServerError, "billing.invalid_event")
+		return
+	}
+
+	if sub != nil {
+		h.auditLogger.Log(security.AuditEvent{
+			Type:     security.EventSubscriptionChanged,
+			Severity: security.SeverityInfo,
+			UserID:   sub.UserID,
+			ClientIP: clientIP,
+			Resource: "billing:" + sub.UserID,
+			Action:   event.Type,
+			Result:   "success",
+			Details: map[string]interface{}{
+				"event_id": event.ID,
+				"status":   sub.Status,
+				"plan":     string(sub.Plan),
+			},
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]bool{"received": true})
+}
+
+// =============================================================================
+// HELPERS
+// =============================================================================
+
+// planOf retorna o plano do usuário (contas antigas sem plano são gratuitas)
+func planOf(user *storage.User) storage.Plan {
+	if user.Plan == "" {
+		return storage.PlanFree
+	}
+	return user.Plan
+}
+
+// writeJSON escreve resposta JSON
+func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
+	security.SetJSONHeaders(w)
+	w.WriteHeader(status)
+	if payload != nil {
+		json.NewEncoder(w).Encode(payload)
+	}
+}
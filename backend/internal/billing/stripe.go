@@ -0,0 +1,151 @@
+// =============================================================================
+// FAMLI - Cliente Stripe
+// =============================================================================
+// Chamadas à API REST do Stripe usadas pelas assinaturas:
+// - Clientes (um por usuário, criado no primeiro checkout)
+// - Stripe Checkout (página de pagamento hospedada pelo Stripe)
+// - Portal do cliente (trocar cartão, ver faturas, cancelar)
+//
+// A API recebe formulários (application/x-www-form-urlencoded) e responde
+// JSON. A chave secreta vai no header Authorization (Bearer).
+//
+// Documentação:
+// - https://docs.stripe.com/api/checkout/sessions/create
+// - https://docs.stripe.com/api/customer_portal/sessions/create
+// =============================================================================
+
+package billing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// stripeAPIURL é a URL base da API do Stripe
+const stripeAPIURL = "https://api.stripe.com/v1"
+
+// StripeClient é o cliente da API do Stripe
+type StripeClient struct {
+	// secretKey é a chave secreta da conta (sk_live_... ou sk_test_...)
+	secretKey string
+
+	// httpClient é o cliente HTTP para fazer requisições
+	httpClient *http.Client
+}
+
+// NewStripeClient cria uma nova instância do cliente Stripe
+func NewStripeClient(secretKey string) *StripeClient {
+	return &StripeClient{
+		secretKey:  secretKey,
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// CreateCustomer cria o cliente do usuário no Stripe
+//
+// Retorna:
+//   - string: ID do cliente (cus_...)
+func (c *StripeClient) CreateCustomer(email, userID string) (string, error) {
+	data := url.Values{}
+	data.Set("email", email)
+	data.Set("metadata[user_id]", userID)
+
+	var customer struct {
+		ID string `json:"id"`
+	}
+	if err := c.post("/customers", data, &customer); err != nil {
+		return "", err
+	}
+	return customer.ID, nil
+}
+
+// CreateCheckoutSession cria a página de pagamento da assinatura
+//
+// O userID vai em client_reference_id e nos metadados da assinatura para
+// os webhooks identificarem o usuário.
+//
+// Retorna:
+//   - string: URL do Stripe Checkout
+func (c *StripeClient) CreateCheckoutSession(customerID, priceID, userID, successURL, cancelURL string) (string, error) {
+	data := url.Values{}
+	data.Set("mode", "subscription")
+	data.Set("customer", customerID)
+	data.Set("client_reference_id", userID)
+	data.Set("line_items[0][price]", priceID)
+	data.Set("line_items[0][quantity]", "1")
+	data.Set("subscription_data[metadata][user_id]", userID)
+	data.Set("success_url", successURL)
+	data.Set("cancel_url", cancelURL)
+
+	var session struct {
+		URL string `json:"url"`
+	}
+	if err := c.post("/checkout/sessions", data, &session); err != nil {
+		return "", err
+	}
+	return session.URL, nil
+}
+
+// CreatePortalSession cria o link do portal do cliente
+//
+// Retorna:
+//   - string: URL do portal (válida por poucos minutos)
+func (c *StripeClient) CreatePortalSession(customerID, returnURL string) (string, error) {
+	data := url.Values{}
+	data.Set("customer", customerID)
+	data.Set("return_url", returnURL)
+
+	var session struct {
+		URL string `json:"url"`
+	}
+	if err := c.post("/billing_portal/sessions", data, &session); err != nil {
+		return "", err
+	}
+	return session.URL, nil
+}
+
+// post envia um formulário para a API e decodifica a resposta em out
+func (c *StripeClient) post(path string, data url.Values, out interface{}) error {
+	req, err := http.NewRequest("POST", stripeAPIURL+path, strings.NewReader(data.Encode()))
+	if err != nil {
+		return fmt.Errorf("erro ao criar requisição: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.secretKey)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("erro ao chamar o Stripe: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("erro ao ler resposta do Stripe: %w", err)
+	}
+
+	if resp.StatusCode >= 400 {
+		// A mensagem do Stripe não contém dados do cartão
+		var apiErr struct {
+			Error struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.Unmarshal(body, &apiErr)
+		log.Printf("[Stripe] Erro na API: path=%s status=%d type=%s", path, resp.StatusCode, apiErr.Error.Type)
+		return fmt.Errorf("erro da API Stripe: status %d: %s", resp.StatusCode, apiErr.Error.Message)
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("resposta inválida do Stripe: %w", err)
+	}
+	return nil
+}
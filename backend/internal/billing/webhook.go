@@ -0,0 +1,179 @@
+// =============================================================================
+// FAMLI - Webhooks do Stripe
+// =============================================================================
+// O Stripe avisa mudanças nas assinaturas em POST /api/billing/webhook.
+// Cada evento traz o estado completo do objeto, então reprocessar um evento
+// (retentativas do Stripe) não causa efeito diferente. O Stripe não garante a
+// ordem de entrega: eventos de uma assinatura que não é a gravada são
+// ignorados, exceto customer.subscription.created (assinatura nova).
+//
+// Eventos tratados:
+// - checkout.session.completed: vincula a assinatura criada ao usuário
+// - customer.subscription.created/updated/deleted: atualiza status e plano
+//
+// Os demais eventos são confirmados (200) e ignorados.
+//
+// Autenticidade: header Stripe-Signature com HMAC-SHA256 de "timestamp.corpo"
+// usando STRIPE_WEBHOOK_SECRET. Eventos com mais de 5 minutos são recusados
+// (proteção contra replay).
+// =============================================================================
+
+package billing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"famli/internal/storage"
+)
+
+// signatureTolerance é a idade máxima aceita de um evento assinado
+const signatureTolerance = 5 * time.Minute
+
+// errInvalidSignature indica header Stripe-Signature ausente, inválido ou antigo
+var errInvalidSignature = errors.New("assinatura do Stripe inválida")
+
+// stripeEvent é o envelope dos eventos do Stripe
+type stripeEvent struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+// checkoutSession é o objeto de checkout.session.completed
+type checkoutSession struct {
+	ClientReferenceID string `json:"client_reference_id"`
+	Customer          string `json:"customer"`
+	Subscription      string `json:"subscription"`
+}
+
+// stripeSubscription é o objeto dos eventos customer.subscription.*
+type stripeSubscription struct {
+	ID                string `json:"id"`
+	Customer          string `json:"customer"`
+	Status            string `json:"status"`
+	CurrentPeriodEnd  int64  `json:"current_period_end"`
+	CancelAtPeriodEnd bool   `json:"cancel_at_period_end"`
+}
+
+// verifySignature confere o header Stripe-Signature
+//
+// Formato: t=<unix>,v1=<hex>[,v1=<hex>...]
+// Várias assinaturas v1 aparecem durante a rotação do segredo.
+func verifySignature(payload []byte, header, secret string, now time.Time) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return errInvalidSignature
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > signatureTolerance || age < -signatureTolerance {
+		return errInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	expected := mac.Sum(nil)
+
+	for _, signature := range signatures {
+		decoded, err := hex.DecodeString(signature)
+		if err == nil && hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+	return errInvalidSignature
+}
+
+// planForStatus retorna o plano concedido por um status de assinatura
+//
+// past_due mantém o premium enquanto o Stripe tenta cobrar de novo;
+// unpaid, canceled e incomplete voltam para o plano gratuito.
+func planForStatus(status string) storage.Plan {
+	switch status {
+	case "active", "trialing", "past_due":
+		return storage.PlanPremium
+	default:
+		return storage.PlanFree
+	}
+}
+
+// applyCheckout vincula a assinatura do checkout concluído ao usuário
+//
+// Retorna a assinatura gravada ou nil se o evento não se aplica.
+func (h *Handler) applyCheckout(session *checkoutSession) (*storage.Subscription, error) {
+	sub, err := h.store.GetSubscriptionByCustomer(session.Customer)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// O cliente é criado para um usuário; uma referência diferente é ignorada
+	if session.ClientReferenceID != "" && session.ClientReferenceID != sub.UserID {
+		return nil, nil
+	}
+	if session.Subscription == "" || sub.SubscriptionID == session.Subscription {
+		return nil, nil
+	}
+
+	sub.SubscriptionID = session.Subscription
+	return sub, h.store.SaveSubscription(sub)
+}
+
+// applySubscription atualiza status, período e plano a partir do Stripe
+//
+// Retorna a assinatura gravada ou nil se o evento não se aplica.
+func (h *Handler) applySubscription(eventType string, object *stripeSubscription) (*storage.Subscription, error) {
+	sub, err := h.store.GetSubscriptionByCustomer(object.Customer)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Eventos de uma assinatura antiga (ex: um updated atrasado da que foi
+	// substituída) não alteram a atual; só a criação troca a assinatura
+	if sub.SubscriptionID != "" && sub.SubscriptionID != object.ID && eventType != "customer.subscription.created" {
+		return nil, nil
+	}
+
+	sub.SubscriptionID = object.ID
+	sub.Status = object.Status
+	sub.CancelAtPeriodEnd = object.CancelAtPeriodEnd
+	sub.CurrentPeriodEnd = nil
+	if object.CurrentPeriodEnd > 0 {
+		periodEnd := time.Unix(object.CurrentPeriodEnd, 0).UTC()
+		sub.CurrentPeriodEnd = &periodEnd
+	}
+
+	sub.Plan = planForStatus(object.Status)
+	if eventType == "customer.subscription.deleted" {
+		sub.Plan = storage.PlanFree
+	}
+
+	return sub, h.store.SaveSubscription(sub)
+}
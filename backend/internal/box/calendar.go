@@ -0,0 +1,295 @@
+// =============================================================================
+// FAMLI - Calendário de Datas Importantes (ICS)
+// =============================================================================
+// Exporta vencimentos e renovações dos itens como um calendário ICS
+// (RFC 5545), para assinatura no Google Calendar, Apple Calendar, etc.
+//
+// Endpoints:
+// - GET    /api/box/calendar            - estado do link (autenticado)
+// - POST   /api/box/calendar            - gera ou troca o link (autenticado)
+// - DELETE /api/box/calendar            - revoga o link (autenticado)
+// - GET    /api/box/calendar.ics?token= - calendário (público, somente leitura)
+//
+// Segurança:
+// - O link é um token aleatório de 256 bits; apenas o hash fica no banco
+// - Gerar um novo link invalida o anterior
+// - O calendário traz apenas título e datas (nunca o conteúdo dos itens)
+// =============================================================================
+
+package box
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/i18n"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+const (
+	// calendarRefresh é o intervalo de atualização sugerido aos clientes
+	calendarRefresh = "PT6H"
+
+	// icsLineLimit é o tamanho máximo de linha do ICS (em bytes, sem CRLF)
+	icsLineLimit = 75
+)
+
+// CalendarStatus retorna se o usuário tem um link de calendário ativo
+// O link em si só é exibido quando gerado.
+//
+// Endpoint: GET /api/box/calendar
+func (h *Handler) CalendarStatus(w http.ResponseWriter, r *http.Request) {
+	feed, err := h.store.GetCalendarFeed(auth.GetUserID(r))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			writeJSON(w, http.StatusOK, map[string]interface{}{"enabled": false})
+			return
+		}
+		apierror.Write(w, r, http.StatusInternalServerError, "box.list_error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"enabled":          true,
+		"created_at":       feed.CreatedAt,
+		"last_accessed_at": feed.LastAccessedAt,
+	})
+}
+
+// CreateCalendarFeed gera um novo link de calendário (substitui o anterior)
+//
+// Endpoint: POST /api/box/calendar
+func (h *Handler) CreateCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+	clientIP := security.GetClientIP(r)
+
+	token, err := generateAccessToken()
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "calendar.save_error")
+		return
+	}
+
+	feed := &storage.CalendarFeed{
+		UserID:    userID,
+		TokenHash: hashAccessToken(token),
+		CreatedAt: time.Now(),
+	}
+	if err := h.store.SaveCalendarFeed(feed); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "calendar.save_error")
+		return
+	}
+
+	h.auditLogger.LogDataAccess(userID, clientIP, "box/calendar", "create", "success")
+
+	feedURL := getBaseURL(r) + "/api/box/calendar.ics?token=" + token
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"enabled":    true,
+		"url":        feedURL,
+		"webcal_url": "webcal://" + strings.SplitN(feedURL, "://", 2)[1],
+		"created_at": feed.CreatedAt,
+	})
+}
+
+// RevokeCalendarFeed desativa o link de calendário
+//
+// Endpoint: DELETE /api/box/calendar
+func (h *Handler) RevokeCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	if err := h.store.DeleteCalendarFeed(userID); err != nil {
+		apierror.WriteStorage(w, r, err, apierror.StorageKeys{NotFound: "calendar.not_found", Internal: "calendar.save_error"})
+		return
+	}
+
+	h.auditLogger.LogDataAccess(userID, security.GetClientIP(r), "box/calendar", "delete", "success")
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": i18n.Tr(r, "calendar.revoked")})
+}
+
+// CalendarFeed retorna o calendário ICS do dono do token
+//
+// Endpoint: GET /api/box/calendar.ics?token=...
+//
+// Segurança:
+// - Público (clientes de calendário não enviam cookies), protegido pelo token
+// - Rate limit na rota contra enumeração de tokens
+func (h *Handler) CalendarFeed(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if len(token) != 64 {
+		apierror.Write(w, r, http.StatusNotFound, "calendar.not_found")
+		return
+	}
+
+	feed, err := h.store.GetCalendarFeedByToken(hashAccessToken(token))
+	if err != nil {
+		apierror.Write(w, r, http.StatusNotFound, "calendar.not_found")
+		return
+	}
+
+	items, err := h.store.ListDatedBoxItems(feed.UserID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "box.list_error")
+		return
+	}
+
+	locale := "pt-BR"
+	if user, ok := h.store.GetUserByID(feed.UserID); ok && user.Locale != "" {
+		locale = user.Locale
+	}
+
+	h.store.TouchCalendarFeed(feed.UserID, time.Now())
+	h.auditLogger.LogDataAccess(feed.UserID, security.GetClientIP(r), "box/calendar.ics", "read", "success")
+
+	security.SetDownloadHeaders(w, "famli.ics", "text/calendar; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(buildCalendar(items, locale)))
+}
+
+// =============================================================================
+// GERAÇÃO DO ICS
+// =============================================================================
+
+// calendarEvent é um evento de dia inteiro do calendário
+type calendarEvent struct {
+	uid     string
+	date    time.Time
+	summary string
+	stamp   time.Time
+	alarm   string // Antecedência do lembrete (duração ICS)
+}
+
+// buildCalendar monta o calendário com um evento por data de cada item
+func buildCalendar(items []*storage.BoxItem, locale string) string {
+	events := make([]calendarEvent, 0, len(items))
+	for _, item := range items {
+		if item.DueDate != nil {
+			events = append(events, calendarEvent{
+				uid:     item.ID + "-due@famli",
+				date:    *item.DueDate,
+				summary: i18n.Format(locale, "calendar.due", i18n.Vars{"title": item.Title}),
+				stamp:   item.UpdatedAt,
+				alarm:   "-P1D",
+			})
+		}
+		if item.RenewalDate != nil {
+			events = append(events, calendarEvent{
+				uid:     item.ID + "-renewal@famli",
+				date:    *item.RenewalDate,
+				summary: i18n.Format(locale, "calendar.renewal", i18n.Vars{"title": item.Title}),
+				stamp:   item.UpdatedAt,
+				alarm:   "-P7D", // Renovações pedem mais antecedência
+			})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].date.Equal(events[j].date) {
+			return events[i].uid < events[j].uid
+		}
+		return events[i].date.Before(events[j].date)
+	})
+
+	var b strings.Builder
+	writeICSLine(&b, "BEGIN:VCALENDAR")
+	writeICSLine(&b, "VERSION:2.0")
+	writeICSLine(&b, "PRODID:-//Famli//Calendar//"+strings.ToUpper(strings.SplitN(locale, "-", 2)[0]))
+	writeICSLine(&b, "CALSCALE:GREGORIAN")
+	writeICSLine(&b, "METHOD:PUBLISH")
+	writeICSLine(&b, "X-WR-CALNAME:"+escapeICSText(i18n.T(locale, "calendar.name")))
+	writeICSLine(&b, "X-PUBLISHED-TTL:"+calendarRefresh)
+	writeICSLine(&b, "REFRESH-INTERVAL;VALUE=DURATION:"+calendarRefresh)
+
+	description := escapeICSText(i18n.T(locale, "calendar.description"))
+	for _, event := range events {
+		summary := escapeICSText(event.summary)
+		writeICSLine(&b, "BEGIN:VEVENT")
+		writeICSLine(&b, "UID:"+event.uid)
+		writeICSLine(&b, "DTSTAMP:"+event.stamp.UTC().Format("20060102T150405Z"))
+		writeICSLine(&b, "DTSTART;VALUE=DATE:"+event.date.Format("20060102"))
+		writeICSLine(&b, "DTEND;VALUE=DATE:"+event.date.AddDate(0, 0, 1).Format("20060102"))
+		writeICSLine(&b, "SUMMARY:"+summary)
+		writeICSLine(&b, "DESCRIPTION:"+description)
+		writeICSLine(&b, "TRANSP:TRANSPARENT")
+		writeICSLine(&b, "BEGIN:VALARM")
+		writeICSLine(&b, "ACTION:DISPLAY")
+		writeICSLine(&b, "DESCRIPTION:"+summary)
+		writeICSLine(&b, "TRIGGER:"+event.alarm)
+		writeICSLine(&b, "END:VALARM")
+		writeICSLine(&b, "END:VEVENT")
+	}
+
+	writeICSLine(&b, "END:VCALENDAR")
+	return b.String()
+}
+
+// writeICSLine escreve uma linha terminada em CRLF, dobrada em 75 bytes
+// (continuações começam com espaço, sem quebrar caracteres UTF-8)
+func writeICSLine(b *strings.Builder, line string) {
+	limit := icsLineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = icsLineLimit - 1 // O espaço inicial conta no limite
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+// isRuneStart indica se o byte inicia um caractere UTF-8
+func isRuneStart(c byte) bool {
+	return c&0xC0 != 0x80
+}
+
+// escapeICSText escapa texto conforme a RFC 5545 (seção 3.3.11)
+func escapeICSText(text string) string {
+	replacer := strings.NewReplacer(
+		"\\", "\\\\",
+		";", "\\;",
+		",", "\\,",
+		"\r\n", "\\n",
+		"\n", "\\n",
+		"\r", "\\n",
+	)
+	return replacer.Replace(text)
+}
+
+// =============================================================================
+// TOKENS PESSOAIS (CALENDÁRIO E CAPTURA)
+// =============================================================================
+
+// generateAccessToken gera um token aleatório (64 caracteres hex)
+func generateAccessToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+// hashAccessToken retorna o hash salvo no banco
+func hashAccessToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// getBaseURL retorna a URL base da aplicação
+func getBaseURL(r *http.Request) string {
+	scheme := "https"
+	if r.TLS == nil && r.Header.Get("X-Forwarded-Proto") != "https" {
+		scheme = "http"
+	}
+	return scheme + "://" + r.Host
+}
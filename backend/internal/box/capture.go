@@ -0,0 +1,416 @@
+// =============================================================================
+// FAMLI - Captura pelo Navegador
+// =============================================================================
+// Guarda a página aberta no navegador (portal do seguro, página de uma conta)
+// direto na Caixa Famli, com um bookmarklet ou uma extensão. O item guarda a
+// nota, o trecho selecionado e o endereço; a página de origem fica em
+// item_sources e volta no GET do item (campo source).
+//
+// Endpoints:
+// - GET    /api/box/capture - estado do token (autenticado)
+// - POST   /api/box/capture - gera ou troca o token e o bookmarklet (autenticado)
+// - DELETE /api/box/capture - revoga o token (autenticado)
+// - POST   /api/capture     - captura (público, protegido pelo token)
+//
+// A captura aceita JSON (extensões, token no header X-Capture-Token) ou
+// formulário (o bookmarklet, que abre uma página de confirmação em outra aba).
+//
+// Segurança:
+// - O token é aleatório de 256 bits; apenas o hash fica no banco
+// - Gerar um novo token invalida o anterior (e os bookmarklets antigos)
+// - O endereço capturado nunca é acessado pelo servidor
+// - Rate limit por IP na rota pública
+// =============================================================================
+
+package box
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/i18n"
+	"famli/internal/security"
+	"famli/internal/storage"
+	"famli/internal/webhooks"
+)
+
+const (
+	// captureTokenHeader é o header com o token nas capturas por JSON
+	captureTokenHeader = "X-Capture-Token"
+
+	// maxCaptureSelection limita o trecho selecionado guardado no item (em runes)
+	maxCaptureSelection = 5000
+
+	// Origem registrada em item_sources
+	captureViaBookmarklet = "bookmarklet"
+	captureViaExtension   = "extension"
+)
+
+// captureRequest é o corpo de POST /api/capture
+type captureRequest struct {
+	Token     string `json:"token,omitempty"` // Alternativa ao header X-Capture-Token
+	URL       string `json:"url"`
+	Title     string `json:"title,omitempty"` // Título da página
+	Selection string `json:"selection,omitempty"`
+	Note      string `json:"note,omitempty"`
+	Category  string `json:"category,omitempty"`
+}
+
+// CaptureStatus retorna se o usuário tem um token de captura ativo
+// O token em si só é exibido quando gerado.
+//
+// Endpoint: GET /api/box/capture
+func (h *Handler) CaptureStatus(w http.ResponseWriter, r *http.Request) {
+	token, err := h.store.GetCaptureToken(auth.GetUserID(r))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			writeJSON(w, http.StatusOK, map[string]interface{}{"enabled": false})
+			return
+		}
+		apierror.Write(w, r, http.StatusInternalServerError, "capture.save_error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"enabled":      true,
+		"created_at":   token.CreatedAt,
+		"last_used_at": token.LastUsedAt,
+	})
+}
+
+// CreateCaptureToken gera um novo token de captura (substitui o anterior)
+//
+// Endpoint: POST /api/box/capture
+//
+// Resposta: o token, o endereço da captura e o bookmarklet pronto para
+// arrastar para a barra de favoritos.
+func (h *Handler) CreateCaptureToken(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	token, err := generateAccessToken()
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "capture.save_error")
+		return
+	}
+
+	captureToken := &storage.CaptureToken{
+		UserID:    userID,
+		TokenHash: hashAccessToken(token),
+		CreatedAt: time.Now(),
+	}
+	if err := h.store.SaveCaptureToken(captureToken); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "capture.save_error")
+		return
+	}
+
+	h.auditLogger.LogDataAccess(userID, security.GetClientIP(r), "box/capture", "create", "success")
+
+	captureURL := getBaseURL(r) + "/api/capture"
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"enabled":     true,
+		"token":       token,
+		"capture_url": captureURL,
+		"bookmarklet": buildBookmarklet(captureURL, token, i18n.Tr(r, "capture.note_prompt")),
+		"created_at":  captureToken.CreatedAt,
+	})
+}
+
+// RevokeCaptureToken desativa o token de captura
+//
+// Endpoint: DELETE /api/box/capture
+func (h *Handler) RevokeCaptureToken(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	if err := h.store.DeleteCaptureToken(userID); err != nil {
+		apierror.WriteStorage(w, r, err, apierror.StorageKeys{NotFound: "capture.not_found", Internal: "capture.save_error"})
+		return
+	}
+
+	h.auditLogger.LogDataAccess(userID, security.GetClientIP(r), "box/capture", "delete", "success")
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": i18n.Tr(r, "capture.revoked")})
+}
+
+// Capture guarda a página enviada pelo bookmarklet ou pela extensão
+//
+// Endpoint: POST /api/capture
+// Body (JSON ou formulário): {"url", "title", "selection", "note", "category"}
+//
+// Segurança:
+//   - Público (o bookmarklet roda na página capturada, sem cookies da Famli),
+//     protegido pelo token pessoal
+//   - Token inválido responde 401, sem dizer se existiu
+func (h *Handler) Capture(w http.ResponseWriter, r *http.Request) {
+	clientIP := security.GetClientIP(r)
+
+	req, isForm, err := parseCaptureRequest(r)
+	if err != nil {
+		h.writeCaptureError(w, r, isForm, http.StatusBadRequest, "capture.invalid_request")
+		return
+	}
+
+	if len(req.Token) != 64 {
+		h.writeCaptureError(w, r, isForm, http.StatusUnauthorized, "capture.invalid_token")
+		return
+	}
+	captureToken, err := h.store.GetCaptureTokenByHash(hashAccessToken(req.Token))
+	if err != nil {
+		h.auditLogger.LogSecurity(security.EventTokenInvalid, clientIP, map[string]interface{}{
+			"endpoint": "capture",
+		})
+		h.writeCaptureError(w, r, isForm, http.StatusUnauthorized, "capture.invalid_token")
+		return
+	}
+	userID := captureToken.UserID
+
+	// Respostas no idioma do dono do token (a rota não passa pela autenticação)
+	if user, ok := h.store.GetUserByID(userID); ok {
+		r = r.WithContext(i18n.WithLocale(r.Context(), i18n.UserLocale(user.Locale, r)))
+	}
+
+	pageURL, ok := normalizeCaptureURL(req.URL)
+	if !ok {
+		h.writeCaptureError(w, r, isForm, http.StatusBadRequest, "capture.invalid_url")
+		return
+	}
+
+	pageTitle := security.SanitizeTitle(req.Title)
+	payload := itemPayload{
+		Type:     storage.ItemTypeInfo,
+		Title:    req.Title,
+		Content:  captureContent(req.Note, pageURL, req.Selection),
+		Category: req.Category,
+	}
+	if strings.TrimSpace(payload.Title) == "" {
+		payload.Title = pageURL.Host
+	}
+	if errKey := payload.validate(); errKey != "" {
+		h.writeCaptureError(w, r, isForm, http.StatusBadRequest, errKey)
+		return
+	}
+
+	category, ok := h.resolveCategory(r, userID, payload.Category, "")
+	if !ok {
+		h.writeCaptureError(w, r, isForm, http.StatusBadRequest, "box.invalid_category")
+		return
+	}
+
+	item := &storage.BoxItem{
+		Type:     payload.Type,
+		Title:    payload.Title,
+		Content:  payload.Content,
+		Category: category,
+	}
+	if err := h.quota.CheckCreate(userID, item); err != nil {
+		if isForm {
+			h.writeCaptureError(w, r, isForm, http.StatusRequestEntityTooLarge, "capture.quota_exceeded")
+			return
+		}
+		h.writeQuotaError(w, r, err)
+		return
+	}
+
+	created, err := h.store.CreateBoxItem(userID, item)
+	if err != nil {
+		h.auditLogger.LogDataAccess(userID, clientIP, "capture", "create", "failure")
+		h.writeCaptureError(w, r, isForm, http.StatusInternalServerError, "box.save_error")
+		return
+	}
+
+	source := &storage.ItemSource{
+		ItemID:     created.ID,
+		URL:        pageURL.String(),
+		PageTitle:  pageTitle,
+		Via:        captureViaExtension,
+		CapturedAt: created.CreatedAt,
+	}
+	if isForm {
+		source.Via = captureViaBookmarklet
+	}
+	if err := h.store.SaveItemSource(source); err != nil {
+		// O item já foi guardado; apenas a origem fica de fora
+		h.auditLogger.LogDataAccess(userID, clientIP, "capture/source", "create", "failure")
+	} else {
+		created.Source = source
+	}
+
+	h.store.TouchCaptureToken(userID, time.Now())
+	h.auditLogger.LogDataAccess(userID, clientIP, "box/items/"+created.ID, "capture", "success")
+
+	// Webhooks recebem apenas metadados (nunca título, conteúdo ou endereço)
+	webhooks.Emit(userID, storage.WebhookItemCreated, map[string]interface{}{
+		"item_id":      created.ID,
+		"type":         created.Type,
+		"category":     created.Category,
+		"is_important": created.IsImportant,
+		"created_at":   created.CreatedAt,
+	})
+
+	if isForm {
+		writeCapturePage(w, r, http.StatusCreated, i18n.Tr(r, "capture.saved"))
+		return
+	}
+	setItemETag(w, created)
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// attachItemSource preenche a página de origem de um item capturado
+func (h *Handler) attachItemSource(item *storage.BoxItem) error {
+	source, err := h.store.GetItemSource(item.ID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	item.Source = source
+	return nil
+}
+
+// writeCaptureError responde o erro em JSON ou, para o bookmarklet, na página
+func (h *Handler) writeCaptureError(w http.ResponseWriter, r *http.Request, isForm bool, status int, key string) {
+	if isForm {
+		writeCapturePage(w, r, status, i18n.Tr(r, key))
+		return
+	}
+	apierror.Write(w, r, status, key)
+}
+
+// =============================================================================
+// REQUISIÇÃO E CONTEÚDO
+// =============================================================================
+
+// parseCaptureRequest lê a captura em JSON ou formulário
+//
+// Retorna também se veio por formulário (o bookmarklet), para responder
+// com a página de confirmação em vez de JSON.
+func parseCaptureRequest(r *http.Request) (*captureRequest, bool, error) {
+	var req captureRequest
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(r.Header.Get("Content-Type"), ";")[0]))
+
+	isForm := mediaType == "application/x-www-form-urlencoded"
+	if isForm {
+		if err := r.ParseForm(); err != nil {
+			return nil, true, err
+		}
+		req = captureRequest{
+			Token:     r.PostForm.Get("token"),
+			URL:       r.PostForm.Get("url"),
+			Title:     r.PostForm.Get("title"),
+			Selection: r.PostForm.Get("selection"),
+			Note:      r.PostForm.Get("note"),
+			Category:  r.PostForm.Get("category"),
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, false, err
+	}
+
+	if token := r.Header.Get(captureTokenHeader); token != "" {
+		req.Token = token
+	}
+	req.Token = strings.TrimSpace(req.Token)
+	return &req, isForm, nil
+}
+
+// normalizeCaptureURL valida o endereço capturado (http ou https, com host)
+//
+// Endereços internos são aceitos: o servidor nunca acessa a página.
+func normalizeCaptureURL(raw string) (*url.URL, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || len(raw) > security.MaxURLLength {
+		return nil, false
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, false
+	}
+	u.User = nil // Nunca guardar credenciais embutidas no endereço
+	u.Fragment = ""
+	return u, true
+}
+
+// captureContent monta o conteúdo do item: nota, endereço e o trecho
+// selecionado como citação (Markdown)
+func captureContent(note string, pageURL *url.URL, selection string) string {
+	parts := make([]string, 0, 3)
+	if note = strings.TrimSpace(note); note != "" {
+		parts = append(parts, note)
+	}
+	parts = append(parts, pageURL.String())
+
+	selection = strings.TrimSpace(strings.ReplaceAll(selection, "\r\n", "\n"))
+	if runes := []rune(selection); len(runes) > maxCaptureSelection {
+		selection = string(runes[:maxCaptureSelection]) + "…"
+	}
+	if selection != "" {
+		lines := strings.Split(selection, "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("> "+line, " ")
+		}
+		parts = append(parts, strings.Join(lines, "\n"))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// =============================================================================
+// BOOKMARKLET E PÁGINA DE CONFIRMAÇÃO
+// =============================================================================
+
+// buildBookmarklet monta o bookmarklet que envia a página aberta
+//
+// O bookmarklet pergunta a nota (Cancelar desiste) e envia um formulário
+// para uma nova aba: funciona em qualquer site, sem CORS nem cookies.
+func buildBookmarklet(captureURL, token, notePrompt string) string {
+	quote := func(value string) string {
+		encoded, _ := json.Marshal(value)
+		return string(encoded)
+	}
+	script := `(function(){var n=prompt(` + quote(notePrompt) + `,'');if(n===null)return;` +
+		`var f=document.createElement('form');f.method='POST';f.action=` + quote(captureURL) + `;` +
+		`f.target='_blank';f.acceptCharset='UTF-8';` +
+		`var d={token:` + quote(token) + `,url:location.href,title:document.title,selection:String(window.getSelection()),note:n};` +
+		`for(var k in d){var i=document.createElement('input');i.type='hidden';i.name=k;i.value=d[k];f.appendChild(i);}` +
+		`document.body.appendChild(f);f.submit();f.remove();})();`
+	return "javascript:" + url.PathEscape(script)
+}
+
+// captureTemplate é a página exibida ao bookmarklet depois da captura
+var captureTemplate = template.Must(template.New("capture").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex, nofollow">
+<title>Famli</title>
+<style>
+  body { font-family: -apple-system, "Segoe UI", Roboto, Arial, sans-serif; margin: 2rem; color: #1f2937; text-align: center; }
+  p { font-size: 1.1rem; }
+  a { color: #2563eb; }
+</style>
+</head>
+<body>
+<p>{{.Message}}</p>
+<p><a href="{{.BoxURL}}">{{.OpenBox}}</a></p>
+</body>
+</html>
+`))
+
+// writeCapturePage responde com a página de confirmação (ou erro) da captura
+func writeCapturePage(w http.ResponseWriter, r *http.Request, status int, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	captureTemplate.Execute(w, map[string]string{
+		"Lang":    i18n.GetLocale(r),
+		"Message": message,
+		"BoxURL":  getBaseURL(r) + "/",
+		"OpenBox": i18n.Tr(r, "capture.open_box"),
+	})
+}
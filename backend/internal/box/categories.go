@@ -0,0 +1,361 @@
+// =============================================================================
+// FAMLI - Categorias da Caixa Famli
+// =============================================================================
+// Cada usuário define as próprias categorias (nome, cor, ícone e ordem).
+// Os itens guardam o nome da categoria: renomear atualiza os itens e
+// excluir deixa os itens sem categoria.
+//
+// Endpoints (usuário autenticado):
+// - GET    /api/box/categories                - lista (cria as padrão se não houver)
+// - POST   /api/box/categories                - cria categoria
+// - PUT    /api/box/categories/order          - reordena ({ids: [...]})
+// - PUT    /api/box/categories/{categoryID}   - altera nome, cor e ícone
+// - DELETE /api/box/categories/{categoryID}   - remove categoria
+// =============================================================================
+
+package box
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/i18n"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+const (
+	// maxCategoriesPerUser limita as categorias de cada usuário
+	maxCategoriesPerUser = 30
+
+	// maxCategoryNameLength limita o nome (em caracteres)
+	maxCategoryNameLength = 40
+
+	// maxCategoryIconLength limita o ícone (emojis compostos têm vários caracteres)
+	maxCategoryIconLength = 8
+
+	// defaultCategoryColor é usada quando a cor não é informada
+	defaultCategoryColor = "#9ca3af"
+)
+
+// categoryColorPattern valida cores no formato #RRGGBB
+var categoryColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// defaultCategories são criadas na primeira listagem (nome traduzido)
+var defaultCategories = []struct {
+	key   string
+	color string
+	icon  string
+}{
+	{"category.default.health", "#ef4444", "🏥"},
+	{"category.default.finances", "#22c55e", "💰"},
+	{"category.default.family", "#f59e0b", "👨‍👩‍👧"},
+	{"category.default.docs", "#3b82f6", "📄"},
+	{"category.default.memories", "#ec4899", "💝"},
+	{"category.default.pets", "#a16207", "🐾"},
+	{"category.default.other", defaultCategoryColor, "📁"},
+}
+
+// legacyCategoryAliases aceita os valores sem acento enviados por clientes antigos
+var legacyCategoryAliases = map[string]string{
+	"saude":    "saúde",
+	"financas": "finanças",
+	"familia":  "família",
+	"memorias": "memórias",
+	"outro":    "outros",
+}
+
+// categoryPayload representa o payload de criação/atualização de categoria
+type categoryPayload struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+	Icon  string `json:"icon"`
+}
+
+// validate valida e sanitiza o payload
+//
+// Retorna:
+//   - string: chave de tradução do erro (vazia se válido)
+func (p *categoryPayload) validate() string {
+	p.Name = security.SanitizeText(p.Name, 0)
+	if p.Name == "" {
+		return "category.name_required"
+	}
+	if utf8.RuneCountInString(p.Name) > maxCategoryNameLength {
+		return "category.name_too_long"
+	}
+
+	p.Color = strings.TrimSpace(p.Color)
+	if p.Color == "" {
+		p.Color = defaultCategoryColor
+	}
+	if !categoryColorPattern.MatchString(p.Color) {
+		return "category.invalid_color"
+	}
+	p.Color = strings.ToLower(p.Color)
+
+	p.Icon = security.SanitizeText(p.Icon, 0)
+	if utf8.RuneCountInString(p.Icon) > maxCategoryIconLength || strings.ContainsAny(p.Icon, "&;") {
+		return "category.invalid_icon"
+	}
+
+	return ""
+}
+
+// ListCategories retorna as categorias do usuário
+//
+// Endpoint: GET /api/box/categories
+func (h *Handler) ListCategories(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	categories, err := h.userCategories(r, userID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "category.list_error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"categories": categories,
+		"total":      len(categories),
+	})
+}
+
+// CreateCategory cria uma categoria no fim da lista
+//
+// Endpoint: POST /api/box/categories
+func (h *Handler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	var payload categoryPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "box.invalid_content")
+		return
+	}
+	if errKey := payload.validate(); errKey != "" {
+		apierror.Write(w, r, http.StatusBadRequest, errKey)
+		return
+	}
+
+	categories, err := h.userCategories(r, userID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "category.save_error")
+		return
+	}
+	if len(categories) >= maxCategoriesPerUser {
+		apierror.Write(w, r, http.StatusBadRequest, "category.limit_reached")
+		return
+	}
+
+	now := time.Now()
+	category := &storage.Category{
+		ID:        "cat_" + uuid.New().String(),
+		UserID:    userID,
+		Name:      payload.Name,
+		Color:     payload.Color,
+		Icon:      payload.Icon,
+		Position:  len(categories),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := h.store.CreateCategory(category); err != nil {
+		h.writeCategoryError(w, r, err)
+		return
+	}
+
+	h.auditLogger.LogDataAccess(userID, security.GetClientIP(r), "box/categories/"+category.ID, "create", "success")
+
+	writeJSON(w, http.StatusCreated, category)
+}
+
+// UpdateCategory altera nome, cor e ícone
+// Renomear atualiza a categoria dos itens do usuário.
+//
+// Endpoint: PUT /api/box/categories/{categoryID}
+func (h *Handler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+	categoryID := chi.URLParam(r, "categoryID")
+
+	var payload categoryPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "box.invalid_content")
+		return
+	}
+	if errKey := payload.validate(); errKey != "" {
+		apierror.Write(w, r, http.StatusBadRequest, errKey)
+		return
+	}
+
+	categories, err := h.store.ListCategories(userID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "category.save_error")
+		return
+	}
+	category := findCategory(categories, categoryID)
+	if category == nil {
+		apierror.Write(w, r, http.StatusNotFound, "category.not_found")
+		return
+	}
+
+	category.Name = payload.Name
+	category.Color = payload.Color
+	category.Icon = payload.Icon
+	category.UpdatedAt = time.Now()
+	if err := h.store.UpdateCategory(category); err != nil {
+		h.writeCategoryError(w, r, err)
+		return
+	}
+
+	h.auditLogger.LogDataAccess(userID, security.GetClientIP(r), "box/categories/"+categoryID, "update", "success")
+
+	writeJSON(w, http.StatusOK, category)
+}
+
+// DeleteCategory remove uma categoria (os itens ficam sem categoria)
+//
+// Endpoint: DELETE /api/box/categories/{categoryID}
+func (h *Handler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+	categoryID := chi.URLParam(r, "categoryID")
+
+	if err := h.store.DeleteCategory(userID, categoryID); err != nil {
+		h.writeCategoryError(w, r, err)
+		return
+	}
+
+	h.auditLogger.LogDataAccess(userID, security.GetClientIP(r), "box/categories/"+categoryID, "delete", "success")
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": i18n.Tr(r, "category.deleted")})
+}
+
+// ReorderCategories define a ordem das categorias
+// A lista deve conter exatamente as categorias do usuário.
+//
+// Endpoint: PUT /api/box/categories/order
+func (h *Handler) ReorderCategories(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	var payload struct {
+		IDs []string `json:"ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "category.invalid_order")
+		return
+	}
+
+	categories, err := h.store.ListCategories(userID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "category.save_error")
+		return
+	}
+	if len(payload.IDs) != len(categories) {
+		apierror.Write(w, r, http.StatusBadRequest, "category.invalid_order")
+		return
+	}
+	seen := make(map[string]struct{}, len(payload.IDs))
+	for _, id := range payload.IDs {
+		if _, dup := seen[id]; dup || findCategory(categories, id) == nil {
+			apierror.Write(w, r, http.StatusBadRequest, "category.invalid_order")
+			return
+		}
+		seen[id] = struct{}{}
+	}
+
+	if err := h.store.ReorderCategories(userID, payload.IDs); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "category.save_error")
+		return
+	}
+
+	h.ListCategories(w, r)
+}
+
+// =============================================================================
+// FUNÇÕES AUXILIARES
+// =============================================================================
+
+// userCategories lista as categorias, criando as padrão se o usuário não tiver
+func (h *Handler) userCategories(r *http.Request, userID string) ([]*storage.Category, error) {
+	categories, err := h.store.ListCategories(userID)
+	if err != nil || len(categories) > 0 {
+		return categories, err
+	}
+
+	now := time.Now()
+	for position, def := range defaultCategories {
+		// Requisições simultâneas podem criar as mesmas (ErrAlreadyExists é ignorado)
+		_ = h.store.CreateCategory(&storage.Category{
+			ID:        "cat_" + uuid.New().String(),
+			UserID:    userID,
+			Name:      i18n.Tr(r, def.key),
+			Color:     def.color,
+			Icon:      def.icon,
+			Position:  position,
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+	}
+	return h.store.ListCategories(userID)
+}
+
+// resolveCategory valida a categoria do item contra as categorias do usuário
+// Retorna o nome cadastrado (sem diferenciar maiúsculas). A categoria atual
+// do item é mantida mesmo que não seja do usuário (item da família editado
+// por outro membro).
+func (h *Handler) resolveCategory(r *http.Request, userID, name, current string) (string, bool) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", true
+	}
+	if name == current {
+		return current, true
+	}
+
+	categories, err := h.userCategories(r, userID)
+	if err != nil {
+		return "", false
+	}
+
+	candidates := []string{name}
+	if alias, ok := legacyCategoryAliases[strings.ToLower(name)]; ok {
+		candidates = append(candidates, alias)
+	}
+	for _, candidate := range candidates {
+		for _, category := range categories {
+			if strings.EqualFold(category.Name, candidate) {
+				return category.Name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// findCategory busca a categoria pelo ID
+func findCategory(categories []*storage.Category, categoryID string) *storage.Category {
+	for _, category := range categories {
+		if category.ID == categoryID {
+			return category
+		}
+	}
+	return nil
+}
+
+// writeCategoryError converte erros do storage em respostas
+func (h *Handler) writeCategoryError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		apierror.Write(w, r, http.StatusNotFound, "category.not_found")
+	case errors.Is(err, storage.ErrAlreadyExists):
+		apierror.Write(w, r, http.StatusConflict, "category.already_exists")
+	default:
+		apierror.Write(w, r, http.StatusInternalServerError, "category.save_error")
+	}
+}
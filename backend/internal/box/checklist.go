@@ -0,0 +1,143 @@
+// =============================================================================
+// FAMLI - Caixa Famli: Lista Marcável
+// =============================================================================
+// Itens como "rotina que não pode parar" são, na prática, listas. O campo
+// checklist do item guarda as linhas na ordem do usuário:
+//
+//	"checklist": [{"text": "Dar o remédio da pressão"}, {"text": "Regar", "done": true}]
+//
+// O PUT/PATCH do item troca a lista inteira (linhas sem id ganham um novo);
+// para só marcar uma linha, sem If-Match:
+//
+// - PUT    /api/box/items/{itemID}/checklist/{entryID}/done - marca a linha
+// - DELETE /api/box/items/{itemID}/checklist/{entryID}/done - desmarca
+//
+// Guardiões veem a lista (somente leitura) nos links e no portal. Itens
+// selados não têm lista: o texto das linhas ficaria fora da cifra.
+// =============================================================================
+
+package box
+
+import (
+	"errors"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+
+	"famli/internal/apierror"
+	"famli/internal/ids"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+const (
+	// maxChecklistEntries limita as linhas da lista de cada item
+	maxChecklistEntries = 100
+
+	// maxChecklistTextLength limita o texto de cada linha (em caracteres)
+	maxChecklistTextLength = 200
+)
+
+// normalizeChecklist sanitiza as linhas e descarta as vazias
+// nil continua nil (mantém a lista atual); [] limpa a lista.
+// Retorna false se houver linhas demais ou longas demais.
+func normalizeChecklist(entries []storage.ChecklistEntry) ([]storage.ChecklistEntry, bool) {
+	if entries == nil {
+		return nil, true
+	}
+
+	result := make([]storage.ChecklistEntry, 0, len(entries))
+	for _, entry := range entries {
+		text := security.SanitizeText(entry.Text, 0)
+		if text == "" {
+			continue
+		}
+		if utf8.RuneCountInString(text) > maxChecklistTextLength {
+			return nil, false
+		}
+		result = append(result, storage.ChecklistEntry{
+			ID:   sanitizeID(entry.ID),
+			Text: text,
+			Done: entry.Done,
+		})
+	}
+	if len(result) > maxChecklistEntries {
+		return nil, false
+	}
+	return result, true
+}
+
+// mergeChecklist completa a lista enviada com a salva: linhas novas (ou com
+// id repetido) ganham um id e linhas que continuam marcadas mantêm a data
+func mergeChecklist(entries, current []storage.ChecklistEntry) []storage.ChecklistEntry {
+	if entries == nil {
+		return current
+	}
+
+	existing := make(map[string]storage.ChecklistEntry, len(current))
+	for _, entry := range current {
+		existing[entry.ID] = entry
+	}
+	now := time.Now().UTC()
+	seen := make(map[string]struct{}, len(entries))
+	for i := range entries {
+		entry := &entries[i]
+		if _, dup := seen[entry.ID]; dup || entry.ID == "" {
+			entry.ID = ids.New(ids.Checklist)
+		}
+		seen[entry.ID] = struct{}{}
+
+		if !entry.Done {
+			continue
+		}
+		if previous, ok := existing[entry.ID]; ok && previous.Done {
+			entry.DoneAt = previous.DoneAt
+		} else {
+			entry.DoneAt = &now
+		}
+	}
+	return entries
+}
+
+// CheckEntry marca uma linha da lista do item
+//
+// Endpoint: PUT /api/box/items/{itemID}/checklist/{entryID}/done
+func (h *Handler) CheckEntry(w http.ResponseWriter, r *http.Request) {
+	h.setEntryDone(w, r, true)
+}
+
+// UncheckEntry desmarca uma linha da lista do item
+//
+// Endpoint: DELETE /api/box/items/{itemID}/checklist/{entryID}/done
+func (h *Handler) UncheckEntry(w http.ResponseWriter, r *http.Request) {
+	h.setEntryDone(w, r, false)
+}
+
+// setEntryDone grava o estado da linha para quem pode editar o item
+// Responde o item completo, com o novo ETag.
+func (h *Handler) setEntryDone(w http.ResponseWriter, r *http.Request, done bool) {
+	itemID := sanitizeID(chi.URLParam(r, "itemID"))
+	entryID := sanitizeID(chi.URLParam(r, "entryID"))
+
+	existing, _, ok := h.findEditableItem(w, r, itemID)
+	if !ok {
+		return
+	}
+
+	updated, err := h.store.SetChecklistEntryDone(existing.UserID, itemID, entryID, done)
+	if errors.Is(err, storage.ErrNotFound) {
+		apierror.Write(w, r, http.StatusNotFound, "box.checklist_entry_not_found")
+		return
+	}
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "box.save_error")
+		return
+	}
+
+	h.auditLogger.LogDataAccess(existing.UserID, security.GetClientIP(r), "box/items/"+itemID+"/checklist/"+entryID, "update", "success")
+
+	setItemETag(w, updated)
+	writeJSON(w, http.StatusOK, updated)
+}
@@ -0,0 +1,102 @@
+// =============================================================================
+// FAMLI - Caixa Famli: Comentários dos Guardiões
+// =============================================================================
+// Guardiões podem deixar perguntas nos itens compartilhados com eles (pelo
+// link de acesso, ver share/comments.go). Quem criou o item lê os
+// comentários na visualização do item e pode apagá-los depois de responder.
+//
+// Apenas quem criou o item vê os comentários.
+// =============================================================================
+
+package box
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/i18n"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+// Comments lista os comentários dos guardiões no item, mais antigos primeiro
+//
+// Endpoint: GET /api/box/items/{itemID}/comments
+func (h *Handler) Comments(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+	itemID := sanitizeID(chi.URLParam(r, "itemID"))
+
+	if _, ok := h.findOwnItem(w, r, itemID); !ok {
+		return
+	}
+
+	comments, err := h.store.ListItemComments(itemID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "box.comments_error")
+		return
+	}
+	h.fillCommentAuthors(userID, comments)
+
+	h.auditLogger.LogDataAccess(userID, security.GetClientIP(r), "box/items/"+itemID+"/comments", "read", "success")
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"item_id":  itemID,
+		"comments": comments,
+	})
+}
+
+// DeleteComment apaga um comentário do item
+//
+// Endpoint: DELETE /api/box/items/{itemID}/comments/{commentID}
+func (h *Handler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+	itemID := sanitizeID(chi.URLParam(r, "itemID"))
+	commentID := sanitizeID(chi.URLParam(r, "commentID"))
+
+	if _, ok := h.findOwnItem(w, r, itemID); !ok {
+		return
+	}
+
+	if err := h.store.DeleteItemComment(itemID, commentID); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			apierror.Write(w, r, http.StatusNotFound, "box.comment_not_found")
+			return
+		}
+		apierror.Write(w, r, http.StatusInternalServerError, "box.comments_error")
+		return
+	}
+
+	h.auditLogger.LogDataAccess(userID, security.GetClientIP(r), "box/items/"+itemID+"/comments/"+commentID, "delete", "success")
+	writeJSON(w, http.StatusOK, map[string]string{"message": i18n.Tr(r, "box.comment_deleted")})
+}
+
+// findOwnItem carrega um item criado pelo usuário; os demais recebem 404
+func (h *Handler) findOwnItem(w http.ResponseWriter, r *http.Request, itemID string) (*storage.BoxItem, bool) {
+	item, _, ok := h.findViewableItem(w, r, itemID)
+	if !ok {
+		return nil, false
+	}
+	if item.UserID != auth.GetUserID(r) {
+		apierror.Write(w, r, http.StatusNotFound, "box.not_found")
+		return nil, false
+	}
+	return item, true
+}
+
+// fillCommentAuthors preenche o nome do guardião de cada comentário
+func (h *Handler) fillCommentAuthors(userID string, comments []*storage.ItemComment) {
+	guardians, err := h.store.GetGuardians(userID)
+	if err != nil {
+		return
+	}
+	names := make(map[string]string, len(guardians))
+	for _, g := range guardians {
+		names[g.ID] = g.Name
+	}
+	for _, comment := range comments {
+		comment.GuardianName = names[comment.GuardianID]
+	}
+}
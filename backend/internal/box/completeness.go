@@ -0,0 +1,176 @@
+// =============================================================================
+// FAMLI - Caixa Famli: Nota de Preparo
+// =============================================================================
+// Mostra o quanto a caixa já ajudaria a família numa emergência, por área:
+// - Contatos de emergência (no cartão de emergência)
+// - Onde estão os documentos (itens "location" e "document")
+// - Instruções de acesso (itens "access")
+// - Mensagens de memória (itens "memory" e "sealed")
+// - Pessoas de confiança com acesso ativo
+// - Inventário financeiro (itens "financial_account")
+//
+// Cada área vale o mesmo (100 / número de áreas; as primeiras levam o resto
+// da divisão, para a caixa completa somar 100) e pontua proporcionalmente
+// até a meta. As lacunas trazem uma dica e itens sugeridos (templates).
+//
+// A nota do dia fica registrada (completeness_scores) para o painel admin
+// acompanhar a evolução (GET /api/admin/analytics/completeness).
+// =============================================================================
+
+package box
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/i18n"
+	"famli/internal/storage"
+)
+
+// completenessArea define uma área da nota e como contá-la
+type completenessArea struct {
+	ID        string
+	Target    int                // Quantidade para a área ficar completa
+	ItemTypes []storage.ItemType // Tipos de item que contam (vazio = contagem própria)
+	Templates int                // Itens sugeridos nas traduções (completeness.<id>.template.<n>.*)
+}
+
+// completenessAreas são as áreas avaliadas, na ordem da resposta
+var completenessAreas = []completenessArea{
+	{ID: "emergency_contacts", Target: 2},
+	{ID: "document_locations", Target: 3, ItemTypes: []storage.ItemType{storage.ItemTypeLocation, storage.ItemTypeDocument}, Templates: 2},
+	{ID: "access_instructions", Target: 2, ItemTypes: []storage.ItemType{storage.ItemTypeAccess}, Templates: 2},
+	{ID: "memorial_messages", Target: 1, ItemTypes: []storage.ItemType{storage.ItemTypeMemory, storage.ItemTypeSealed}, Templates: 1},
+	{ID: "active_guardians", Target: 2},
+	{ID: "financial_accounts", Target: 2, ItemTypes: []storage.ItemType{storage.ItemTypeFinancialAccount}, Templates: 1},
+}
+
+// CompletenessArea é o resultado de uma área
+type CompletenessArea struct {
+	ID        string                      `json:"id"`
+	Title     string                      `json:"title"`
+	Count     int                         `json:"count"`
+	Target    int                         `json:"target"`
+	Score     int                         `json:"score"`
+	MaxScore  int                         `json:"max_score"`
+	Complete  bool                        `json:"complete"`
+	Tip       string                      `json:"tip,omitempty"`       // Como completar (só nas lacunas)
+	Templates []storage.GuideItemTemplate `json:"templates,omitempty"` // Itens sugeridos (só nas lacunas)
+}
+
+// CompletenessReport é a nota de preparo da caixa
+type CompletenessReport struct {
+	Score int                 `json:"score"` // 0 a 100
+	Areas []*CompletenessArea `json:"areas"`
+	Gaps  []string            `json:"gaps"` // Áreas incompletas, na ordem
+}
+
+// Completeness calcula a nota de preparo do usuário
+//
+// Endpoint: GET /api/box/completeness
+func (h *Handler) Completeness(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	counts, err := h.completenessCounts(userID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "box.completeness_error")
+		return
+	}
+
+	report := buildCompleteness(i18n.GetLocale(r), counts)
+	if err := h.store.RecordCompletenessScore(userID, time.Now().UTC(), report.Score); err != nil {
+		log.Printf("[Box] Erro ao registrar a nota de preparo de %s: %v", userID, err)
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+// completenessCounts conta o que o usuário já tem em cada área
+func (h *Handler) completenessCounts(userID string) (map[string]int, error) {
+	items, err := h.store.GetBoxItems(userID)
+	if err != nil {
+		return nil, err
+	}
+	byType := make(map[storage.ItemType]int)
+	for _, item := range items {
+		byType[item.Type]++
+	}
+
+	counts := make(map[string]int, len(completenessAreas))
+	for _, area := range completenessAreas {
+		for _, itemType := range area.ItemTypes {
+			counts[area.ID] += byType[itemType]
+		}
+	}
+
+	if card, err := h.store.GetEmergencyCard(userID); err == nil {
+		counts["emergency_contacts"] = len(card.Contacts)
+	}
+
+	guardians, err := h.store.GetGuardians(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range guardians {
+		if g.AccessDisabledAt == nil && g.DeletionRequestedAt == nil {
+			counts["active_guardians"]++
+		}
+	}
+	return counts, nil
+}
+
+// buildCompleteness monta a nota a partir das contagens, traduzida para o idioma
+func buildCompleteness(locale string, counts map[string]int) *CompletenessReport {
+	report := &CompletenessReport{
+		Areas: make([]*CompletenessArea, 0, len(completenessAreas)),
+		Gaps:  make([]string, 0),
+	}
+	for i, def := range completenessAreas {
+		maxScore := 100 / len(completenessAreas)
+		if i < 100%len(completenessAreas) {
+			maxScore++
+		}
+		count := counts[def.ID]
+		area := &CompletenessArea{
+			ID:       def.ID,
+			Title:    i18n.T(locale, "completeness."+def.ID+".title"),
+			Count:    count,
+			Target:   def.Target,
+			MaxScore: maxScore,
+			Complete: count >= def.Target,
+		}
+		if area.Complete {
+			area.Score = maxScore
+		} else {
+			area.Score = maxScore * count / def.Target
+			area.Tip = i18n.T(locale, "completeness."+def.ID+".tip")
+			area.Templates = completenessTemplates(locale, def)
+			report.Gaps = append(report.Gaps, def.ID)
+		}
+		report.Score += area.Score
+		report.Areas = append(report.Areas, area)
+	}
+	return report
+}
+
+// completenessTemplates traduz os itens sugeridos de uma área
+// O tipo do item sugerido é o primeiro tipo que conta na área.
+func completenessTemplates(locale string, def completenessArea) []storage.GuideItemTemplate {
+	if def.Templates == 0 || len(def.ItemTypes) == 0 {
+		return nil
+	}
+	templates := make([]storage.GuideItemTemplate, 0, def.Templates)
+	for n := 1; n <= def.Templates; n++ {
+		key := "completeness." + def.ID + ".template." + strconv.Itoa(n)
+		templates = append(templates, storage.GuideItemTemplate{
+			Type:    def.ItemTypes[0],
+			Title:   i18n.T(locale, key+".title"),
+			Content: i18n.T(locale, key+".content"),
+		})
+	}
+	return templates
+}
@@ -0,0 +1,319 @@
+// =============================================================================
+// FAMLI - Caixa Famli: Documentos com Validade
+// =============================================================================
+// Itens do tipo "document" guardam os dados estruturados do documento:
+//
+//	"document": {"kind": "passport", "number": "FX123456", "expires_on": "2030-05-01"}
+//
+// O número nunca é guardado inteiro: apenas os 4 últimos caracteres
+// ("****3456"). O PUT/PATCH do item troca o documento inteiro; omitir mantém
+// o atual e {} (ou null no PATCH) o remove.
+//
+// Os documentos jurídicos (will, power_of_attorney, living_will e
+// guardianship) aceitam também os dados de "legal" (ver legal.go).
+//
+// Endpoint:
+// - GET /api/box/expiring?days=N - documentos vencidos ou que vencem em N dias
+//
+// A listagem de itens traz o selo expiry_status ("expired", "expiring" ou
+// "valid") e o worker ExpiryReminders avisa o dono antes do vencimento
+// (60, 30 e 7 dias), pela central de notificações.
+// =============================================================================
+
+package box
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/notifications"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+const (
+	// maxDocumentNumberLength limita o número do documento enviado
+	maxDocumentNumberLength = 40
+
+	// expiringSoonDays é a antecedência do selo "expiring"
+	expiringSoonDays = 30
+
+	// defaultExpiringDays e maxExpiringDays limitam GET /api/box/expiring
+	defaultExpiringDays = 60
+	maxExpiringDays     = 365
+
+	// reminderInterval é o intervalo entre verificações de vencimentos
+	reminderInterval = time.Hour
+)
+
+// reminderDays são as antecedências dos lembretes, da menor para a maior
+var reminderDays = []int{7, 30, 60}
+
+// documentKinds são os tipos de documento aceitos
+var documentKinds = map[storage.DocumentKind]bool{
+	storage.DocumentPassport:      true,
+	storage.DocumentIDCard:        true,
+	storage.DocumentDriverLicense: true,
+	storage.DocumentInsurance:     true,
+	storage.DocumentVehicle:       true,
+	storage.DocumentOther:         true,
+
+	storage.DocumentWill:             true,
+	storage.DocumentPowerOfAttorney:  true,
+	storage.DocumentLivingWill:       true,
+	storage.DocumentGuardianshipPlan: true,
+}
+
+// documentPayload é o documento enviado no item (validade em AAAA-MM-DD)
+type documentPayload struct {
+	Kind      storage.DocumentKind `json:"kind"`
+	Number    string               `json:"number"`
+	ExpiresOn string               `json:"expires_on"`
+	Legal     *legalPayload        `json:"legal"` // Apenas nos documentos jurídicos
+}
+
+// isEmpty indica um documento vazio ({}), que remove o documento do item
+func (p *documentPayload) isEmpty() bool {
+	return p != nil && p.Kind == "" && strings.TrimSpace(p.Number) == "" && strings.TrimSpace(p.ExpiresOn) == "" && p.Legal.isEmpty()
+}
+
+// normalizeDocument valida o documento e mascara o número
+// nil mantém o atual; {} remove (documento vazio). Retorna false se algum
+// campo for inválido.
+func normalizeDocument(payload *documentPayload) (*storage.DocumentInfo, bool) {
+	if payload == nil {
+		return nil, true
+	}
+	if payload.isEmpty() {
+		return &storage.DocumentInfo{}, true
+	}
+
+	kind := payload.Kind
+	if kind == "" {
+		kind = storage.DocumentOther
+	}
+	if !documentKinds[kind] {
+		return nil, false
+	}
+
+	number := strings.TrimSpace(payload.Number)
+	if len(number) > maxDocumentNumberLength {
+		return nil, false
+	}
+	expiresOn, ok := parseItemDate(payload.ExpiresOn)
+	if !ok {
+		return nil, false
+	}
+	legal, ok := normalizeLegal(kind, payload.Legal)
+	if !ok {
+		return nil, false
+	}
+	return &storage.DocumentInfo{Kind: kind, Number: maskDocumentNumber(number), ExpiresOn: expiresOn, Legal: legal}, true
+}
+
+// maskDocumentNumber mantém apenas os 4 últimos caracteres do número
+// Mascarar um número já mascarado não muda nada ("****3456").
+func maskDocumentNumber(number string) string {
+	var visible []rune
+	for _, r := range number {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			visible = append(visible, unicode.ToUpper(r))
+		}
+	}
+	if len(visible) == 0 {
+		return ""
+	}
+	if len(visible) > 4 {
+		visible = visible[len(visible)-4:]
+	}
+	return "****" + string(visible)
+}
+
+// mergeDocument decide o documento gravado no item
+// Apenas itens "document" têm documento (false se enviado em outro tipo).
+func mergeDocument(itemType storage.ItemType, document, current *storage.DocumentInfo) (*storage.DocumentInfo, bool) {
+	if document == nil {
+		document = current
+	} else if document.Kind == "" {
+		return nil, true // {} remove
+	} else if itemType != storage.ItemTypeDocument {
+		return nil, false
+	}
+	if itemType != storage.ItemTypeDocument {
+		return nil, true // Deixar de ser documento remove os dados salvos
+	}
+	return document, true
+}
+
+// expiryStatus calcula o selo de validade no dia de now (UTC)
+func expiryStatus(expiresOn *time.Time, now time.Time) storage.ExpiryStatus {
+	if expiresOn == nil {
+		return ""
+	}
+	days := daysUntil(*expiresOn, now)
+	switch {
+	case days < 0:
+		return storage.ExpiryExpired
+	case days <= expiringSoonDays:
+		return storage.ExpirySoon
+	default:
+		return storage.ExpiryValid
+	}
+}
+
+// daysUntil conta os dias (UTC) de now até date (negativo se já passou)
+func daysUntil(date, now time.Time) int {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	return int(day.Sub(today).Hours() / 24)
+}
+
+// expiringEntry é um documento na lista de vencimentos
+type expiringEntry struct {
+	ItemID      string               `json:"item_id"`
+	Title       string               `json:"title"`
+	Kind        storage.DocumentKind `json:"kind"`
+	Number      string               `json:"number,omitempty"`
+	ExpiresOn   time.Time            `json:"expires_on"`
+	DaysLeft    int                  `json:"days_left"` // Negativo se já venceu
+	Status      storage.ExpiryStatus `json:"status"`
+	HouseholdID string               `json:"household_id,omitempty"`
+}
+
+// Expiring lista os documentos vencidos ou que vencem nos próximos dias
+//
+// Endpoint: GET /api/box/expiring
+//
+// Query params:
+// - days: antecedência, de 1 a 365 (padrão 60)
+// - scope, household_id e os filtros da listagem de itens
+func (h *Handler) Expiring(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	days := defaultExpiringDays
+	if value := r.URL.Query().Get("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxExpiringDays {
+			apierror.Write(w, r, http.StatusBadRequest, "box.invalid_filter")
+			return
+		}
+		days = parsed
+	}
+
+	filter, _, ok := h.itemFilter(w, r, userID)
+	if !ok {
+		return
+	}
+	now := time.Now().UTC()
+	items, err := h.store.ListExpiringDocuments(filter, now.AddDate(0, 0, days))
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "box.list_error")
+		return
+	}
+
+	entries := make([]expiringEntry, 0, len(items))
+	for _, item := range items {
+		entries = append(entries, expiringEntry{
+			ItemID:      item.ID,
+			Title:       item.Title,
+			Kind:        item.Document.Kind,
+			Number:      item.Document.Number,
+			ExpiresOn:   *item.Document.ExpiresOn,
+			DaysLeft:    daysUntil(*item.Document.ExpiresOn, now),
+			Status:      expiryStatus(item.Document.ExpiresOn, now),
+			HouseholdID: item.HouseholdID,
+		})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].ExpiresOn.Equal(entries[j].ExpiresOn) {
+			return entries[i].ExpiresOn.Before(entries[j].ExpiresOn)
+		}
+		return entries[i].Title < entries[j].Title
+	})
+
+	h.auditLogger.LogDataAccess(userID, security.GetClientIP(r), "box/expiring", "list", "success")
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"days":  days,
+		"items": entries,
+	})
+}
+
+// =============================================================================
+// LEMBRETES DE VENCIMENTO
+// =============================================================================
+
+// ExpiryReminders avisa os donos dos documentos perto do vencimento
+// Cada documento recebe um único aviso por antecedência (60, 30 e 7 dias);
+// um documento cadastrado a 5 dias do vencimento recebe só o de 7 dias. O
+// aviso não traz o nome do documento (a central nunca mostra dados dos itens).
+type ExpiryReminders struct {
+	store storage.Store
+}
+
+// NewExpiryReminders cria o worker de lembretes de vencimento
+func NewExpiryReminders(store storage.Store) *ExpiryReminders {
+	return &ExpiryReminders{store: store}
+}
+
+// Start inicia o worker (encerra quando ctx é cancelado)
+func (e *ExpiryReminders) Start(ctx context.Context) {
+	go func() {
+		e.runDue(time.Now())
+
+		ticker := time.NewTicker(reminderInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				e.runDue(now)
+			}
+		}
+	}()
+}
+
+// runDue envia os lembretes que já chegaram
+func (e *ExpiryReminders) runDue(now time.Time) {
+	maxDays := reminderDays[len(reminderDays)-1]
+	expiries, err := e.store.ListDocumentExpiries(now.UTC().AddDate(0, 0, maxDays))
+	if err != nil {
+		log.Printf("[Box] Erro ao listar vencimentos: %v", err)
+		return
+	}
+
+	for _, expiry := range expiries {
+		left := daysUntil(expiry.ExpiresOn, now.UTC())
+		if left < 0 {
+			continue // Já venceu: o selo "expired" fica na listagem
+		}
+		stage := reminderStage(left)
+		claimed, err := e.store.ClaimExpiryReminder(expiry.ItemID, expiry.ExpiresOn, stage, now)
+		if err != nil {
+			log.Printf("[Box] Erro ao registrar lembrete de %s: %v", expiry.ItemID, err)
+			continue
+		}
+		if claimed {
+			notifications.Notify(expiry.UserID, storage.NotificationReminders, "notify.document_expiring")
+		}
+	}
+}
+
+// reminderStage é a menor antecedência que já alcançou o documento
+func reminderStage(daysLeft int) int {
+	for _, days := range reminderDays {
+		if daysLeft <= days {
+			return days
+		}
+	}
+	return reminderDays[len(reminderDays)-1]
+}
@@ -0,0 +1,79 @@
+// =============================================================================
+// FAMLI - Caixa Famli: Concorrência Otimista (ETag / If-Match)
+// =============================================================================
+// Cada item tem uma versão (1, 2, 3...) que aumenta a cada atualização. O GET
+// responde com ETag: "<versão>" e a listagem traz o campo version. O PUT e o
+// PATCH exigem If-Match com a versão lida:
+//
+// - Sem If-Match: 428 (o cliente precisa dizer sobre qual versão editou)
+// - Versão antiga: 412 com o item atual em details.item, para o cliente
+//   mesclar as alterações e tentar de novo
+// - If-Match: * grava sem conferir (sobrescrita consciente)
+// =============================================================================
+
+package box
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"famli/internal/apierror"
+	"famli/internal/i18n"
+	"famli/internal/storage"
+)
+
+// itemETag monta o ETag de uma versão do item
+func itemETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// setItemETag envia o ETag do item na resposta
+func setItemETag(w http.ResponseWriter, item *storage.BoxItem) {
+	if item.Version > 0 {
+		w.Header().Set("ETag", itemETag(item.Version))
+	}
+}
+
+// expectedVersion lê a versão esperada do header If-Match
+//
+// Retorna:
+//   - int: versão esperada (0 para If-Match: *, sem conferir)
+//   - bool: false se o header estiver ausente ou inválido (responde 428)
+func expectedVersion(w http.ResponseWriter, r *http.Request) (int, bool) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "*" {
+		return 0, true
+	}
+
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(value, "W/"), `"`))
+	if value == "" || err != nil || version < 1 {
+		apierror.Write(w, r, http.StatusPreconditionRequired, "box.version_required")
+		return 0, false
+	}
+	return version, true
+}
+
+// checkItemVersion confere o If-Match contra a versão atual do item
+// Responde 428 ou 412 e retorna false se a gravação não puder seguir.
+func checkItemVersion(w http.ResponseWriter, r *http.Request, existing *storage.BoxItem) (int, bool) {
+	version, ok := expectedVersion(w, r)
+	if !ok {
+		return 0, false
+	}
+	if version != 0 && version != existing.Version {
+		writeVersionConflict(w, r, existing)
+		return 0, false
+	}
+	return version, true
+}
+
+// writeVersionConflict responde 412 com o item atual
+func writeVersionConflict(w http.ResponseWriter, r *http.Request, current *storage.BoxItem) {
+	setItemETag(w, current)
+	apierror.WriteMessage(w, r, http.StatusPreconditionFailed, apierror.Code("box.version_conflict"),
+		i18n.Tr(r, "box.version_conflict"), map[string]interface{}{
+			"version": current.Version,
+			"item":    current,
+		})
+}
@@ -0,0 +1,192 @@
+// =============================================================================
+// FAMLI - Tags e Filtros da Caixa Famli
+// =============================================================================
+// Além da categoria, cada item pode ter tags livres. A listagem aceita filtros
+// combinados e uma ordenação:
+//
+// GET /api/box/items?type=info,note&category=saúde&tag=banco&tag=2024
+//     &important=true&shared=false&updated_since=2024-01-01&sort=due
+//     &include_archived=true
+//
+// - type: um ou mais tipos (separados por vírgula ou repetidos)
+// - category: nome da categoria (sem diferenciar maiúsculas)
+// - tag: uma ou mais tags; o item precisa ter todas
+// - important, shared: true ou false
+// - include_archived: true inclui os itens arquivados (padrão: false)
+// - updated_since: AAAA-MM-DD ou RFC 3339
+// - sort: newest (padrão; created é sinônimo), oldest, updated, due, manual
+//   (ordem definida em PUT /api/box/items/order) ou title. Itens fixados
+//   vêm sempre primeiro.
+// =============================================================================
+
+package box
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"famli/internal/apierror"
+	"famli/internal/household"
+	"famli/internal/storage"
+)
+
+const (
+	// maxItemTags limita as tags de cada item
+	maxItemTags = 10
+
+	// maxTagLength limita cada tag (em caracteres)
+	maxTagLength = 30
+)
+
+// tagPattern aceita letras, números, espaço, "_" e "-" (começando por letra ou número)
+var tagPattern = regexp.MustCompile(`^[\p{L}\p{N}][\p{L}\p{N} _-]*$`)
+
+// normalizeTags padroniza as tags (minúsculas, sem "#", sem repetição)
+//
+// Retorna:
+//   - []string: tags normalizadas
+//   - bool: false se alguma tag for inválida ou houver tags demais
+func normalizeTags(tags []string) ([]string, bool) {
+	if len(tags) == 0 {
+		return nil, true
+	}
+
+	result := make([]string, 0, len(tags))
+	seen := make(map[string]struct{}, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(tag), "#")))
+		if tag == "" {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > maxTagLength || !tagPattern.MatchString(tag) {
+			return nil, false
+		}
+		if _, dup := seen[tag]; dup {
+			continue
+		}
+		seen[tag] = struct{}{}
+		result = append(result, tag)
+	}
+	if len(result) > maxItemTags {
+		return nil, false
+	}
+	return result, true
+}
+
+// itemFilter monta o filtro da listagem a partir da query (escopo e filtros)
+// Responde com erro e retorna false se algum parâmetro for inválido.
+func (h *Handler) itemFilter(w http.ResponseWriter, r *http.Request, userID string) (*storage.BoxItemFilter, map[string]*household.Membership, bool) {
+	memberships, err := household.Memberships(h.store, userID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "box.list_error")
+		return nil, nil, false
+	}
+
+	filter := &storage.BoxItemFilter{UserID: userID, IncludePersonal: true, HouseholdIDs: household.IDs(memberships)}
+	if householdID := r.URL.Query().Get("household_id"); householdID != "" {
+		if _, ok := memberships[householdID]; !ok {
+			apierror.Write(w, r, http.StatusNotFound, "household.not_found")
+			return nil, nil, false
+		}
+		filter.IncludePersonal = false
+		filter.HouseholdIDs = []string{householdID}
+	} else if r.URL.Query().Get("scope") == string(storage.ItemScopePersonal) {
+		filter.HouseholdIDs = nil
+	}
+	if errKey := parseItemFilters(r, filter); errKey != "" {
+		apierror.Write(w, r, http.StatusBadRequest, errKey)
+		return nil, nil, false
+	}
+	return filter, memberships, true
+}
+
+// parseItemFilters aplica os filtros e a ordenação da query ao filtro da listagem
+//
+// Retorna:
+//   - string: chave de tradução do erro (vazia se válido)
+func parseItemFilters(r *http.Request, filter *storage.BoxItemFilter) string {
+	query := r.URL.Query()
+
+	for _, value := range queryList(query, "type") {
+		itemType := storage.ItemType(value)
+		if !isValidItemType(itemType) {
+			return "box.invalid_filter"
+		}
+		filter.Types = append(filter.Types, itemType)
+	}
+
+	filter.Category = strings.TrimSpace(query.Get("category"))
+
+	if tags := queryList(query, "tag"); len(tags) > 0 {
+		normalized, ok := normalizeTags(tags)
+		if !ok {
+			return "box.invalid_filter"
+		}
+		filter.Tags = normalized
+	}
+
+	var ok bool
+	if filter.Important, ok = parseBoolParam(query.Get("important")); !ok {
+		return "box.invalid_filter"
+	}
+	if filter.Shared, ok = parseBoolParam(query.Get("shared")); !ok {
+		return "box.invalid_filter"
+	}
+	includeArchived, ok := parseBoolParam(query.Get("include_archived"))
+	if !ok {
+		return "box.invalid_filter"
+	}
+	filter.IncludeArchived = includeArchived != nil && *includeArchived
+
+	if since := strings.TrimSpace(query.Get("updated_since")); since != "" {
+		parsed, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			if parsed, err = time.Parse("2006-01-02", since); err != nil {
+				return "box.invalid_filter"
+			}
+		}
+		filter.UpdatedSince = &parsed
+	}
+
+	switch sort := storage.BoxItemSort(query.Get("sort")); sort {
+	case "", "created", storage.BoxItemSortNewest:
+		filter.Sort = storage.BoxItemSortNewest
+	case storage.BoxItemSortOldest, storage.BoxItemSortUpdated, storage.BoxItemSortDue,
+		storage.BoxItemSortManual, storage.BoxItemSortTitle:
+		filter.Sort = sort
+	default:
+		return "box.invalid_filter"
+	}
+
+	return ""
+}
+
+// queryList junta parâmetros repetidos e separados por vírgula
+func queryList(query url.Values, key string) []string {
+	var result []string
+	for _, value := range query[key] {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				result = append(result, part)
+			}
+		}
+	}
+	return result
+}
+
+// parseBoolParam converte "true"/"false" (vazio = sem filtro)
+func parseBoolParam(value string) (*bool, bool) {
+	if value == "" {
+		return nil, true
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, false
+	}
+	return &parsed, true
+}
@@ -0,0 +1,102 @@
+// =============================================================================
+// FAMLI - Caixa Famli: Inventário Financeiro
+// =============================================================================
+// Itens do tipo "financial_account" listam as contas da pessoa, para a
+// família saber onde procurar:
+//
+//	"financial_account": {"institution": "Banco do Brasil", "account_type": "checking",
+//	  "number": "****1234", "statements_location": "Email pessoal, pasta Banco",
+//	  "credentials_location": "Gerenciador de senhas, com a Ana"}
+//
+// O número nunca é guardado inteiro: o servidor mantém apenas os 4 últimos
+// caracteres, mesmo que o número completo seja enviado. Os textos são
+// criptografados no banco. O PUT/PATCH do item troca a conta inteira; omitir
+// mantém a atual e {} (ou null no PATCH) a remove.
+//
+// As contas contam na área "financial_accounts" da nota de preparo
+// (ver completeness.go).
+// =============================================================================
+
+package box
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+// maxFinancialTextLength limita a instituição e os locais de extratos e acessos
+const maxFinancialTextLength = 200
+
+// financialAccountTypes são os tipos de conta aceitos
+var financialAccountTypes = map[storage.FinancialAccountType]bool{
+	storage.FinancialChecking:   true,
+	storage.FinancialSavings:    true,
+	storage.FinancialInvestment: true,
+	storage.FinancialRetirement: true,
+	storage.FinancialCreditCard: true,
+	storage.FinancialLoan:       true,
+	storage.FinancialOther:      true,
+}
+
+// isEmptyFinancialAccount indica uma conta vazia ({}), que remove a conta do item
+func isEmptyFinancialAccount(account *storage.FinancialAccount) bool {
+	return account != nil && strings.TrimSpace(account.Institution) == "" && account.AccountType == "" &&
+		strings.TrimSpace(account.Number) == "" && strings.TrimSpace(account.StatementsLocation) == "" &&
+		strings.TrimSpace(account.CredentialsLocation) == ""
+}
+
+// normalizeFinancialAccount sanitiza a conta e mascara o número
+// nil continua nil (mantém a conta atual); {} remove. Retorna false se algum
+// campo for inválido ou se faltar a instituição.
+func normalizeFinancialAccount(account *storage.FinancialAccount) (*storage.FinancialAccount, bool) {
+	if account == nil || isEmptyFinancialAccount(account) {
+		return account, true
+	}
+
+	result := &storage.FinancialAccount{
+		Institution:         security.SanitizeText(account.Institution, 0),
+		AccountType:         account.AccountType,
+		StatementsLocation:  security.SanitizeText(account.StatementsLocation, 0),
+		CredentialsLocation: security.SanitizeText(account.CredentialsLocation, 0),
+	}
+	if result.Institution == "" {
+		return nil, false
+	}
+	if result.AccountType == "" {
+		result.AccountType = storage.FinancialOther
+	}
+	if !financialAccountTypes[result.AccountType] {
+		return nil, false
+	}
+	for _, text := range []string{result.Institution, result.StatementsLocation, result.CredentialsLocation} {
+		if utf8.RuneCountInString(text) > maxFinancialTextLength {
+			return nil, false
+		}
+	}
+
+	number := strings.TrimSpace(account.Number)
+	if len(number) > maxDocumentNumberLength {
+		return nil, false
+	}
+	result.Number = maskDocumentNumber(number)
+	return result, true
+}
+
+// mergeFinancialAccount decide a conta gravada no item
+// Apenas itens "financial_account" têm conta (false se enviada em outro tipo).
+func mergeFinancialAccount(itemType storage.ItemType, account, current *storage.FinancialAccount) (*storage.FinancialAccount, bool) {
+	if account == nil {
+		account = current
+	} else if isEmptyFinancialAccount(account) {
+		return nil, true
+	} else if itemType != storage.ItemTypeFinancialAccount {
+		return nil, false
+	}
+	if itemType != storage.ItemTypeFinancialAccount {
+		return nil, true // Deixar de ser conta remove os dados salvos
+	}
+	return account, true
+}
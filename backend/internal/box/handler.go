@@ -9,23 +9,31 @@
 // - Limite de tamanho de conteúdo
 // - Auditoria de acesso a dados
 // - Isolamento por usuário (A01)
+// - Itens da família: membros ativos veem; apenas "edit"/"manage" alteram
 // =============================================================================
 
 package box
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
+	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 
+	"famli/internal/apierror"
 	"famli/internal/auth"
+	"famli/internal/household"
 	"famli/internal/i18n"
+	"famli/internal/ids"
+	"famli/internal/quota"
 	"famli/internal/security"
 	"famli/internal/storage"
+	"famli/internal/webhooks"
 )
 
 // =============================================================================
@@ -39,19 +47,24 @@ type Handler struct {
 
 	// auditLogger registra eventos de acesso
 	auditLogger *security.AuditLogger
+
+	// quota verifica os limites de armazenamento (nil = sem limites)
+	quota *quota.Checker
 }
 
 // NewHandler cria uma nova instância do handler
 //
 // Parâmetros:
 //   - store: armazenamento de dados
+//   - quotaChecker: limites por usuário (nil = sem limites)
 //
 // Retorna:
 //   - *Handler: handler configurado
-func NewHandler(store storage.Store) *Handler {
+func NewHandler(store storage.Store, quotaChecker *quota.Checker) *Handler {
 	return &Handler{
 		store:       store,
 		auditLogger: security.GetAuditLogger(),
+		quota:       quotaChecker,
 	}
 }
 
@@ -69,46 +82,168 @@ type itemPayload struct {
 	IsImportant bool             `json:"is_important"`
 	IsShared    bool             `json:"is_shared"` // Compartilhado com guardiões
 	GuardianIDs []string         `json:"guardian_ids,omitempty"`
+	DueDate     string           `json:"due_date,omitempty"`     // AAAA-MM-DD
+	RenewalDate string           `json:"renewal_date,omitempty"` // AAAA-MM-DD
+	HouseholdID *string          `json:"household_id,omitempty"` // nil mantém; "" volta para a caixa pessoal
+	Tags        []string         `json:"tags,omitempty"`
+	Archived    *bool            `json:"archived,omitempty"` // nil mantém; ignorado na criação
+
+	// Checklist é a lista marcável (ver checklist.go); nil mantém a atual
+	Checklist []storage.ChecklistEntry `json:"checklist,omitempty"`
+
+	// Schedule é a agenda de uma rotina (ver routines.go); nil mantém a atual
+	// e {} remove
+	Schedule *storage.RoutineSchedule `json:"schedule,omitempty"`
+
+	// Document são os dados de um documento (ver documents.go); nil mantém
+	// os atuais e {} remove
+	Document *documentPayload `json:"document,omitempty"`
+
+	// Pet é o perfil de um pet (ver pets.go); nil mantém o atual e {} remove
+	Pet *storage.PetProfile `json:"pet,omitempty"`
+
+	// FinancialAccount é a conta do inventário financeiro (ver financial.go);
+	// nil mantém a atual e {} remove
+	FinancialAccount *storage.FinancialAccount `json:"financial_account,omitempty"`
+
+	// OnlineAccount é a conta do legado digital (ver online_accounts.go);
+	// nil mantém a atual e {} remove
+	OnlineAccount *storage.OnlineAccount `json:"online_account,omitempty"`
+
+	// RecipientGuardianID endereça o item a uma pessoa de confiança
+	// nil mantém; "" volta a ser apenas o texto de recipient
+	RecipientGuardianID *string `json:"recipient_guardian_id,omitempty"`
+
+	// Sealed são os parâmetros de um item selado (type "sealed", ver sealed.go)
+	Sealed *storage.SealedParams `json:"sealed,omitempty"`
+
+	// Datas convertidas por validate
+	dueDate     *time.Time
+	renewalDate *time.Time
+	document    *storage.DocumentInfo
 }
 
 // validate valida e sanitiza o payload
 //
 // Retorna:
-//   - string: mensagem de erro (vazia se válido)
-func (p *itemPayload) validate(r *http.Request) string {
-	// Sanitizar título
-	p.Title = security.SanitizeTitle(p.Title)
-	if p.Title == "" {
-		return i18n.Tr(r, "box.title_required")
-	}
+//   - string: chave de tradução do erro (vazia se válido)
+func (p *itemPayload) validate() string {
+	return p.validateFields(nil)
+}
+
+// validateFields valida e sanitiza apenas os campos em fields (nomes JSON)
+// nil valida todos (PUT). No PATCH os demais campos já vêm do item salvo e
+// não podem ser sanitizados de novo (o escape de HTML seria duplicado).
+func (p *itemPayload) validateFields(fields map[string]bool) string {
+	has := func(name string) bool { return fields == nil || fields[name] }
+
+	if has("title") {
+		// Sanitizar título
+		p.Title = security.SanitizeTitle(p.Title)
+		if p.Title == "" {
+			return "box.title_required"
+		}
 
-	// Verificar tamanho do título
-	if len(p.Title) > security.MaxTitleLength {
-		return i18n.Tr(r, "box.title_too_long")
+		// Verificar tamanho do título
+		if len(p.Title) > security.MaxTitleLength {
+			return "box.title_too_long"
+		}
 	}
 
-	// Sanitizar conteúdo
-	p.Content = security.SanitizeContent(p.Content)
+	// Itens selados trazem o texto cifrado no navegador, que não é sanitizado
+	if p.Type == storage.ItemTypeSealed {
+		if (has("type") || has("content") || has("sealed")) && !validSealed(p.Content, p.Sealed) {
+			return "box.sealed_invalid"
+		}
+	} else {
+		p.Sealed = nil
+
+		if has("content") {
+			// Sanitizar conteúdo
+			p.Content = security.SanitizeContent(p.Content)
 
-	// Verificar tamanho do conteúdo
-	if len(p.Content) > security.MaxContentLength {
-		return i18n.Tr(r, "box.content_too_long")
+			// Verificar tamanho do conteúdo
+			if len(p.Content) > security.MaxContentLength {
+				return "box.content_too_long"
+			}
+		}
 	}
 
-	// Sanitizar categoria
-	p.Category = sanitizeCategory(p.Category)
+	// Sanitizar categoria (validada contra as categorias do usuário no handler)
+	if has("category") {
+		p.Category = security.SanitizeText(p.Category, 0)
+	}
 
 	// Sanitizar destinatário
-	p.Recipient = security.SanitizeName(p.Recipient)
+	if has("recipient") {
+		p.Recipient = security.SanitizeName(p.Recipient)
+	}
 
 	// Validar tipo
 	if !isValidItemType(p.Type) {
 		p.Type = storage.ItemTypeInfo
 	}
 
-	// Verificar por tentativas de injection
-	if security.ContainsSQLInjection(p.Title) || security.ContainsSQLInjection(p.Content) {
-		return i18n.Tr(r, "box.invalid_detected")
+	// Datas importantes (opcionais)
+	var ok bool
+	if has("due_date") {
+		if p.dueDate, ok = parseItemDate(p.DueDate); !ok {
+			return "box.invalid_date"
+		}
+	}
+	if has("renewal_date") {
+		if p.renewalDate, ok = parseItemDate(p.RenewalDate); !ok {
+			return "box.invalid_date"
+		}
+	}
+
+	// Tags livres (opcionais)
+	if has("tags") {
+		if p.Tags, ok = normalizeTags(p.Tags); !ok {
+			return "box.invalid_tag"
+		}
+	}
+
+	// Lista marcável (opcional)
+	if has("checklist") {
+		if p.Checklist, ok = normalizeChecklist(p.Checklist); !ok {
+			return "box.invalid_checklist"
+		}
+	}
+
+	// Dados do documento (opcional)
+	if has("document") {
+		if p.document, ok = normalizeDocument(p.Document); !ok {
+			return "box.invalid_document"
+		}
+	}
+
+	// Agenda da rotina (opcional)
+	if has("schedule") {
+		if p.Schedule, ok = normalizeSchedule(p.Schedule); !ok {
+			return "box.invalid_schedule"
+		}
+	}
+
+	// Perfil do pet (opcional)
+	if has("pet") {
+		if p.Pet, ok = normalizePet(p.Pet); !ok {
+			return "box.invalid_pet"
+		}
+	}
+
+	// Conta financeira (opcional)
+	if has("financial_account") {
+		if p.FinancialAccount, ok = normalizeFinancialAccount(p.FinancialAccount); !ok {
+			return "box.invalid_financial_account"
+		}
+	}
+
+	// Conta online (opcional)
+	if has("online_account") {
+		if p.OnlineAccount, ok = normalizeOnlineAccount(p.OnlineAccount); !ok {
+			return "box.invalid_online_account"
+		}
 	}
 
 	if !p.IsShared {
@@ -140,17 +275,27 @@ func (p *itemPayload) validate(r *http.Request) string {
 // ENDPOINTS
 // =============================================================================
 
-// List retorna todos os itens da Caixa Famli do usuário
+// List retorna os itens da Caixa Famli do usuário e das suas famílias
 //
 // Endpoint: GET /api/box/items
 //
+// Query params:
+// - scope=personal: apenas a caixa pessoal
+// - household_id=X: apenas a caixa da família X
+// - type, category, tag, important, shared, updated_since, sort: ver filters.go
+//
 // Segurança:
 // - Requer autenticação JWT
-// - Retorna apenas itens do usuário autenticado (A01)
+// - Retorna apenas itens do usuário e das famílias em que é membro ativo (A01)
 func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 	userID := auth.GetUserID(r)
 	clientIP := security.GetClientIP(r)
 
+	filter, memberships, ok := h.itemFilter(w, r, userID)
+	if !ok {
+		return
+	}
+
 	// Parâmetros de paginação
 	cursor := r.URL.Query().Get("cursor")
 	limitStr := r.URL.Query().Get("limit")
@@ -168,16 +313,21 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 		Limit:  limit,
 	}
 
-	result, err := h.store.ListBoxItemsPaginated(userID, params)
+	result, err := h.store.ListAccessibleBoxItemsPaginated(filter, params)
+	if errors.Is(err, storage.ErrInvalidData) {
+		apierror.Write(w, r, http.StatusBadRequest, "box.invalid_cursor")
+		return
+	}
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, i18n.Tr(r, "box.list_error"))
+		apierror.Write(w, r, http.StatusInternalServerError, "box.list_error")
 		return
 	}
+	h.annotateItems(userID, result.Items, memberships)
 
-	// Contar total (opcional, apenas na primeira página)
+	// Contar total (apenas na primeira página; páginas seguintes usam Count)
 	var total int
 	if cursor == "" {
-		total, _ = h.store.CountBoxItems(userID)
+		total, _ = h.store.CountAccessibleBoxItems(filter)
 	}
 
 	// Registrar acesso (auditoria)
@@ -191,6 +341,37 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// Count retorna o total de itens com os mesmos filtros da listagem
+// Útil para exibir o total sem recontar a cada página.
+//
+// Endpoints:
+// - GET  /api/box/items/count  - {"total": N}
+// - HEAD /api/box/items        - total no header X-Total-Count
+func (h *Handler) Count(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	filter, _, ok := h.itemFilter(w, r, userID)
+	if !ok {
+		return
+	}
+
+	total, err := h.store.CountAccessibleBoxItems(filter)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "box.list_error")
+		return
+	}
+
+	h.auditLogger.LogDataAccess(userID, security.GetClientIP(r), "box/items", "count", "success")
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	if r.Method == http.MethodHead {
+		security.SetJSONHeaders(w)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"total": total})
+}
+
 // Create adiciona um novo item à Caixa Famli
 //
 // Endpoint: POST /api/box/items
@@ -204,32 +385,102 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	userID := auth.GetUserID(r)
 	clientIP := security.GetClientIP(r)
 
-	// Limitar tamanho do body (previne DoS)
-	r.Body = http.MaxBytesReader(w, r.Body, 100*1024) // 100KB max
-
 	// Decodificar payload
 	var payload itemPayload
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "box.invalid_content"))
+		apierror.Write(w, r, http.StatusBadRequest, "box.invalid_content")
 		return
 	}
 
 	// Validar e sanitizar
-	if errMsg := payload.validate(r); errMsg != "" {
-		writeError(w, http.StatusBadRequest, errMsg)
+	if errKey := payload.validate(); errKey != "" {
+		apierror.Write(w, r, http.StatusBadRequest, errKey)
+		return
+	}
+
+	category, ok := h.resolveCategory(r, userID, payload.Category, "")
+	if !ok {
+		apierror.Write(w, r, http.StatusBadRequest, "box.invalid_category")
+		return
+	}
+
+	// Itens da família exigem permissão de edição
+	householdID := ""
+	if payload.HouseholdID != nil && *payload.HouseholdID != "" {
+		memberships, err := household.Memberships(h.store, userID)
+		if err != nil {
+			apierror.Write(w, r, http.StatusInternalServerError, "box.save_error")
+			return
+		}
+		if !household.CanAddTo(*payload.HouseholdID, memberships) {
+			apierror.Write(w, r, http.StatusForbidden, "household.forbidden")
+			return
+		}
+		householdID = *payload.HouseholdID
+	}
+
+	recipient, recipientGuardianID, ok := h.resolveRecipient(userID, payload.Recipient, payload.RecipientGuardianID, "")
+	if !ok {
+		apierror.Write(w, r, http.StatusBadRequest, "box.invalid_recipient")
+		return
+	}
+
+	schedule, ok := h.mergeSchedule(payload.Type, userID, payload.Schedule, nil)
+	if !ok {
+		apierror.Write(w, r, http.StatusBadRequest, "box.invalid_schedule")
+		return
+	}
+
+	document, ok := mergeDocument(payload.Type, payload.document, nil)
+	if !ok {
+		apierror.Write(w, r, http.StatusBadRequest, "box.invalid_document")
+		return
+	}
+
+	pet, ok := mergePet(payload.Type, payload.Pet, nil)
+	if !ok {
+		apierror.Write(w, r, http.StatusBadRequest, "box.invalid_pet")
+		return
+	}
+
+	account, ok := mergeFinancialAccount(payload.Type, payload.FinancialAccount, nil)
+	if !ok {
+		apierror.Write(w, r, http.StatusBadRequest, "box.invalid_financial_account")
+		return
+	}
+
+	online, ok := mergeOnlineAccount(payload.Type, payload.OnlineAccount, nil)
+	if !ok {
+		apierror.Write(w, r, http.StatusBadRequest, "box.invalid_online_account")
 		return
 	}
 
 	// Criar item
 	item := &storage.BoxItem{
-		Type:        payload.Type,
-		Title:       payload.Title,
-		Content:     payload.Content,
-		Category:    payload.Category,
-		Recipient:   payload.Recipient,
-		IsImportant: payload.IsImportant,
-		IsShared:    payload.IsShared,
-		GuardianIDs: payload.GuardianIDs,
+		Type:                payload.Type,
+		Title:               payload.Title,
+		Content:             payload.Content,
+		Category:            category,
+		Recipient:           recipient,
+		RecipientGuardianID: recipientGuardianID,
+		IsImportant:         payload.IsImportant,
+		IsShared:            payload.IsShared,
+		GuardianIDs:         payload.GuardianIDs,
+		DueDate:             payload.dueDate,
+		RenewalDate:         payload.renewalDate,
+		HouseholdID:         householdID,
+		Tags:                payload.Tags,
+		Checklist:           mergeChecklist(payload.Checklist, nil),
+		Schedule:            schedule,
+		Document:            document,
+		Pet:                 pet,
+		FinancialAccount:    account,
+		OnlineAccount:       online,
+		Sealed:              payload.Sealed,
+	}
+	if item.IsSealed() && len(item.Checklist) > 0 {
+		apierror.Write(w, r, http.StatusBadRequest, "box.invalid_checklist")
+		return
 	}
 
 	idempotencyKey := getIdempotencyKey(r)
@@ -239,16 +490,16 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 
 	var itemID string
 	if idempotencyKey != "" {
-		itemID = fmt.Sprintf("itm_%d", time.Now().UnixNano())
+		itemID = ids.New(ids.Item)
 		existingID, inserted, err := h.store.RegisterIdempotencyKey(userID, idempotencyKey, "box_item", itemID)
 		if err != nil {
-			writeError(w, http.StatusInternalServerError, i18n.Tr(r, "box.save_error"))
+			apierror.Write(w, r, http.StatusInternalServerError, "box.save_error")
 			return
 		}
 		if !inserted {
 			existing, err := h.store.GetBoxItem(userID, existingID)
 			if err != nil {
-				writeError(w, http.StatusConflict, i18n.Tr(r, "box.save_error"))
+				apierror.Write(w, r, http.StatusConflict, "box.save_error")
 				return
 			}
 			w.Header().Set("Idempotency-Replayed", "true")
@@ -257,6 +508,15 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Cota do usuário (depois do replay: repetir a requisição não cria nada)
+	if err := h.quota.CheckCreate(userID, item); err != nil {
+		if idempotencyKey != "" {
+			_ = h.store.DeleteIdempotencyKey(userID, idempotencyKey, "box_item")
+		}
+		h.writeQuotaError(w, r, err)
+		return
+	}
+
 	var created *storage.BoxItem
 	var err error
 	if idempotencyKey != "" {
@@ -269,86 +529,322 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		if idempotencyKey != "" {
 			_ = h.store.DeleteIdempotencyKey(userID, idempotencyKey, "box_item")
 		}
-		writeError(w, http.StatusInternalServerError, i18n.Tr(r, "box.save_error"))
+		apierror.Write(w, r, http.StatusInternalServerError, "box.save_error")
 		return
 	}
 
 	// Registrar criação (auditoria)
 	h.auditLogger.LogDataAccess(userID, clientIP, "box/items/"+created.ID, "create", "success")
 
+	// Webhooks recebem apenas metadados (nunca título ou conteúdo)
+	webhooks.Emit(userID, storage.WebhookItemCreated, map[string]interface{}{
+		"item_id":      created.ID,
+		"type":         created.Type,
+		"category":     created.Category,
+		"is_important": created.IsImportant,
+		"created_at":   created.CreatedAt,
+	})
+
+	if security.WantsRenderedHTML(r) && !created.IsSealed() {
+		created.ContentHTML = security.RenderMarkdown(created.Content)
+	}
+
+	setItemETag(w, created)
 	writeJSON(w, http.StatusCreated, created)
 }
 
+// Get retorna um item com os guardiões, itens vinculados e a página de origem
+//
+// Endpoint: GET /api/box/items/{itemID}
+//
+// Segurança:
+// - Requer autenticação JWT
+// - Verifica se o item é do usuário ou de uma família em que é membro (A01)
+// - Itens vinculados que o usuário não pode ver ficam de fora
+// - Auditoria de leitura
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+	itemID := sanitizeID(chi.URLParam(r, "itemID"))
+
+	item, memberships, ok := h.findViewableItem(w, r, itemID)
+	if !ok {
+		return
+	}
+
+	relations, err := h.itemRelations(userID, item, memberships)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "box.relation_error")
+		return
+	}
+	item.Relations = relations
+
+	if err := h.attachItemSource(item); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "box.list_error")
+		return
+	}
+
+	h.auditLogger.LogDataAccess(userID, security.GetClientIP(r), "box/items/"+itemID, "read", "success")
+
+	if security.WantsRenderedHTML(r) && !item.IsSealed() {
+		item.ContentHTML = security.RenderMarkdown(item.Content)
+	}
+
+	setItemETag(w, item)
+	writeJSON(w, http.StatusOK, item)
+}
+
 // Update modifica um item existente
 //
 // Endpoint: PUT /api/box/items/{itemID}
 //
 // Segurança:
 // - Requer autenticação JWT
-// - Verifica propriedade do item (A01)
+// - Verifica propriedade do item ou permissão de edição na família (A01)
+// - Apenas quem criou pode mover o item entre a caixa pessoal e a família
 // - Validação e sanitização de inputs
 // - Auditoria de atualização
+//
+// Concorrência: exige If-Match com a versão lida (ver etag.go).
 func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
-	userID := auth.GetUserID(r)
-	clientIP := security.GetClientIP(r)
 	itemID := chi.URLParam(r, "itemID")
 
 	// Sanitizar itemID (previne path traversal)
 	itemID = sanitizeID(itemID)
 
-	// Limitar tamanho do body
-	r.Body = http.MaxBytesReader(w, r.Body, 100*1024)
-
 	// Decodificar payload
 	var payload itemPayload
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "box.invalid_content"))
+		apierror.Write(w, r, http.StatusBadRequest, "box.invalid_content")
 		return
 	}
 
 	// Validar e sanitizar
-	if errMsg := payload.validate(r); errMsg != "" {
-		writeError(w, http.StatusBadRequest, errMsg)
+	if errKey := payload.validate(); errKey != "" {
+		apierror.Write(w, r, http.StatusBadRequest, errKey)
 		return
 	}
 
+	existing, memberships, ok := h.findEditableItem(w, r, itemID)
+	if !ok {
+		return
+	}
+	version, ok := checkItemVersion(w, r, existing)
+	if !ok {
+		return
+	}
+
+	h.saveUpdate(w, r, existing, memberships, &payload, version)
+}
+
+// saveUpdate grava a atualização já validada de um item (PUT e PATCH)
+// Resolve categoria, família e destinatário, aplica as regras de quem pode
+// alterar o quê e confere a cota de quem criou o item.
+func (h *Handler) saveUpdate(w http.ResponseWriter, r *http.Request, existing *storage.BoxItem, memberships map[string]*household.Membership, payload *itemPayload, version int) {
+	userID := auth.GetUserID(r)
+	itemID := existing.ID
+
+	category, ok := h.resolveCategory(r, userID, payload.Category, existing.Category)
+	if !ok {
+		apierror.Write(w, r, http.StatusBadRequest, "box.invalid_category")
+		return
+	}
+
+	householdID := existing.HouseholdID
+	if payload.HouseholdID != nil && *payload.HouseholdID != existing.HouseholdID {
+		target := *payload.HouseholdID
+		if existing.UserID != userID || (target != "" && !household.CanAddTo(target, memberships)) {
+			apierror.Write(w, r, http.StatusForbidden, "household.forbidden")
+			return
+		}
+		householdID = target
+	}
+
+	// Só quem criou o item conhece a frase secreta: selar, reabrir ou trocar
+	// o texto cifrado é decisão dessa pessoa
+	if existing.UserID != userID && (existing.IsSealed() || payload.Type == storage.ItemTypeSealed) {
+		apierror.Write(w, r, http.StatusForbidden, "box.sealed_forbidden")
+		return
+	}
+
+	// Compartilhamento com guardiões é decisão de quem criou o item
+	// (assim como endereçar o item a um deles)
+	if existing.UserID != userID {
+		payload.IsShared = existing.IsShared
+		payload.GuardianIDs = existing.GuardianIDs
+		payload.RecipientGuardianID = nil
+	}
+
+	recipient, recipientGuardianID, ok := h.resolveRecipient(existing.UserID, payload.Recipient, payload.RecipientGuardianID, existing.RecipientGuardianID)
+	if !ok {
+		apierror.Write(w, r, http.StatusBadRequest, "box.invalid_recipient")
+		return
+	}
+
+	checklist := mergeChecklist(payload.Checklist, existing.Checklist)
+	if payload.Type == storage.ItemTypeSealed && len(checklist) > 0 {
+		apierror.Write(w, r, http.StatusBadRequest, "box.invalid_checklist")
+		return
+	}
+
+	// O responsável pela rotina é uma pessoa de confiança de quem criou o item
+	// (quem edita pela família mantém o responsável atual)
+	if existing.UserID != userID && payload.Schedule != nil && !isEmptySchedule(payload.Schedule) {
+		payload.Schedule.ResponsibleGuardianID = ""
+		if existing.Schedule != nil {
+			payload.Schedule.ResponsibleGuardianID = existing.Schedule.ResponsibleGuardianID
+		}
+	}
+	schedule, ok := h.mergeSchedule(payload.Type, existing.UserID, payload.Schedule, existing.Schedule)
+	if !ok {
+		apierror.Write(w, r, http.StatusBadRequest, "box.invalid_schedule")
+		return
+	}
+
+	document, ok := mergeDocument(payload.Type, payload.document, existing.Document)
+	if !ok {
+		apierror.Write(w, r, http.StatusBadRequest, "box.invalid_document")
+		return
+	}
+
+	pet, ok := mergePet(payload.Type, payload.Pet, existing.Pet)
+	if !ok {
+		apierror.Write(w, r, http.StatusBadRequest, "box.invalid_pet")
+		return
+	}
+
+	account, ok := mergeFinancialAccount(payload.Type, payload.FinancialAccount, existing.FinancialAccount)
+	if !ok {
+		apierror.Write(w, r, http.StatusBadRequest, "box.invalid_financial_account")
+		return
+	}
+
+	online, ok := mergeOnlineAccount(payload.Type, payload.OnlineAccount, existing.OnlineAccount)
+	if !ok {
+		apierror.Write(w, r, http.StatusBadRequest, "box.invalid_online_account")
+		return
+	}
+
+	// Arquivar guarda quando foi; arquivar de novo mantém a data
+	archivedAt := existing.ArchivedAt
+	if payload.Archived != nil {
+		switch {
+		case !*payload.Archived:
+			archivedAt = nil
+		case archivedAt == nil:
+			now := time.Now().UTC()
+			archivedAt = &now
+		}
+	}
+
 	// Atualizar item
 	updates := &storage.BoxItem{
-		Type:        payload.Type,
-		Title:       payload.Title,
-		Content:     payload.Content,
-		Category:    payload.Category,
-		Recipient:   payload.Recipient,
-		IsImportant: payload.IsImportant,
-		IsShared:    payload.IsShared,
-		GuardianIDs: payload.GuardianIDs,
+		Type:                payload.Type,
+		Title:               payload.Title,
+		Content:             payload.Content,
+		Category:            category,
+		Recipient:           recipient,
+		RecipientGuardianID: recipientGuardianID,
+		IsImportant:         payload.IsImportant,
+		IsShared:            payload.IsShared,
+		GuardianIDs:         payload.GuardianIDs,
+		DueDate:             payload.dueDate,
+		RenewalDate:         payload.renewalDate,
+		HouseholdID:         householdID,
+		Tags:                payload.Tags,
+		Checklist:           checklist,
+		Schedule:            schedule,
+		Document:            document,
+		Pet:                 pet,
+		FinancialAccount:    account,
+		OnlineAccount:       online,
+		ArchivedAt:          archivedAt,
+		Sealed:              payload.Sealed,
+		Version:             version,
+	}
+
+	// O aumento de tamanho conta na cota de quem criou o item
+	if err := h.quota.CheckUpdate(existing.UserID, existing, updates); err != nil {
+		h.writeQuotaError(w, r, err)
+		return
 	}
 
-	updated, err := h.store.UpdateBoxItem(userID, itemID, updates)
+	updated, err := h.store.UpdateBoxItem(existing.UserID, itemID, updates)
+	if errors.Is(err, storage.ErrVersionConflict) {
+		// Outra gravação entre a leitura acima e o UPDATE
+		if current, getErr := h.store.GetBoxItem(existing.UserID, itemID); getErr == nil {
+			writeVersionConflict(w, r, current)
+			return
+		}
+	}
 	if err != nil {
-		// Não revelar se o item existe mas pertence a outro usuário
-		h.auditLogger.LogSecurity(security.EventUnauthorizedAccess, clientIP, map[string]interface{}{
-			"user_id":  userID,
-			"item_id":  itemID,
-			"resource": "box/items",
-		})
-		writeError(w, http.StatusNotFound, i18n.Tr(r, "box.not_found"))
+		apierror.Write(w, r, http.StatusNotFound, "box.not_found")
 		return
 	}
 
 	// Registrar atualização (auditoria)
-	h.auditLogger.LogDataAccess(userID, clientIP, "box/items/"+itemID, "update", "success")
+	h.auditLogger.LogDataAccess(userID, security.GetClientIP(r), "box/items/"+itemID, "update", "success")
+
+	if security.WantsRenderedHTML(r) && !updated.IsSealed() {
+		updated.ContentHTML = security.RenderMarkdown(updated.Content)
+	}
 
+	setItemETag(w, updated)
 	writeJSON(w, http.StatusOK, updated)
 }
 
+// Usage retorna o uso de armazenamento do usuário e os limites do plano
+//
+// Endpoint: GET /api/box/usage
+//
+// Limites com valor 0 não são aplicados.
+func (h *Handler) Usage(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	usage, err := h.store.GetUserUsage(userID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "box.usage_error")
+		return
+	}
+
+	limits := h.quota.Limits()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"item_count":        usage.ItemCount,
+		"archived_count":    usage.ArchivedCount,
+		"content_bytes":     usage.ContentBytes,
+		"max_items":         limits.MaxItems,
+		"max_content_bytes": limits.MaxContentBytes,
+	})
+}
+
+// writeQuotaError responde 413 com a cota excedida
+// Erros do storage ao calcular o uso viram 500.
+func (h *Handler) writeQuotaError(w http.ResponseWriter, r *http.Request, err error) {
+	var exceeded *quota.ExceededError
+	if !errors.As(err, &exceeded) {
+		apierror.Write(w, r, http.StatusInternalServerError, "box.save_error")
+		return
+	}
+
+	h.auditLogger.LogDataAccess(auth.GetUserID(r), security.GetClientIP(r), "box/items", "quota_"+string(exceeded.Resource), "denied")
+
+	message := i18n.Trn(r, "box.quota_items", int(exceeded.Limit), nil)
+	if exceeded.Resource == quota.ResourceContent {
+		message = i18n.Trf(r, "box.quota_content", i18n.Vars{"mb": formatMB(exceeded.Limit)})
+	}
+	apierror.WriteMessage(w, r, http.StatusRequestEntityTooLarge, "QUOTA_EXCEEDED", message, map[string]interface{}{
+		"resource": exceeded.Resource,
+		"limit":    exceeded.Limit,
+		"used":     exceeded.Used,
+	})
+}
+
 // Delete remove um item da Caixa Famli
 //
 // Endpoint: DELETE /api/box/items/{itemID}
 //
 // Segurança:
 // - Requer autenticação JWT
-// - Verifica propriedade do item (A01)
+// - Verifica propriedade do item ou permissão de edição na família (A01)
 // - Auditoria de deleção
 func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	userID := auth.GetUserID(r)
@@ -358,14 +854,14 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	// Sanitizar itemID
 	itemID = sanitizeID(itemID)
 
+	existing, _, ok := h.findEditableItem(w, r, itemID)
+	if !ok {
+		return
+	}
+
 	// Deletar item
-	if err := h.store.DeleteBoxItem(userID, itemID); err != nil {
-		h.auditLogger.LogSecurity(security.EventUnauthorizedAccess, clientIP, map[string]interface{}{
-			"user_id":  userID,
-			"item_id":  itemID,
-			"resource": "box/items",
-		})
-		writeError(w, http.StatusNotFound, i18n.Tr(r, "box.not_found"))
+	if err := h.store.DeleteBoxItem(existing.UserID, itemID); err != nil {
+		apierror.Write(w, r, http.StatusNotFound, "box.not_found")
 		return
 	}
 
@@ -375,6 +871,113 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]string{"message": i18n.Tr(r, "box.deleted")})
 }
 
+// findEditableItem carrega o item que o usuário pode alterar
+// Itens inexistentes, de outros usuários ou de famílias sem permissão de
+// edição recebem 404 (não revela se o item existe).
+func (h *Handler) findEditableItem(w http.ResponseWriter, r *http.Request, itemID string) (*storage.BoxItem, map[string]*household.Membership, bool) {
+	userID := auth.GetUserID(r)
+
+	memberships, err := household.Memberships(h.store, userID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "box.save_error")
+		return nil, nil, false
+	}
+
+	item, err := h.store.GetBoxItemByID(itemID)
+	if err != nil || !household.CanEdit(userID, item, memberships) {
+		h.auditLogger.LogSecurity(security.EventUnauthorizedAccess, security.GetClientIP(r), map[string]interface{}{
+			"user_id":  userID,
+			"item_id":  itemID,
+			"resource": "box/items",
+		})
+		apierror.Write(w, r, http.StatusNotFound, "box.not_found")
+		return nil, nil, false
+	}
+	return item, memberships, true
+}
+
+// resolveRecipient valida o destinatário do item
+// O guardião precisa ser de quem criou o item (ownerID); sem texto, o
+// destinatário exibido passa a ser o nome dele. guardianID nil mantém o atual.
+//
+// Retorna o texto do destinatário, o ID do guardião e false se o guardião
+// não for encontrado.
+func (h *Handler) resolveRecipient(ownerID, text string, guardianID *string, current string) (string, string, bool) {
+	if guardianID == nil {
+		return text, current, true
+	}
+	id := sanitizeID(*guardianID)
+	if id == "" {
+		return text, "", true
+	}
+
+	guardians, err := h.store.GetGuardians(ownerID)
+	if err != nil {
+		return "", "", false
+	}
+	for _, g := range guardians {
+		if g.ID == id {
+			if text == "" {
+				text = g.Name
+			}
+			return text, id, true
+		}
+	}
+	return "", "", false
+}
+
+// findViewableItem carrega o item que o usuário pode ver
+// Como em findEditableItem, itens sem acesso recebem 404.
+func (h *Handler) findViewableItem(w http.ResponseWriter, r *http.Request, itemID string) (*storage.BoxItem, map[string]*household.Membership, bool) {
+	userID := auth.GetUserID(r)
+
+	memberships, err := household.Memberships(h.store, userID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "box.list_error")
+		return nil, nil, false
+	}
+
+	item, err := h.store.GetBoxItemByID(itemID)
+	if err != nil || !household.CanView(userID, item, memberships) {
+		h.auditLogger.LogSecurity(security.EventUnauthorizedAccess, security.GetClientIP(r), map[string]interface{}{
+			"user_id":  userID,
+			"item_id":  itemID,
+			"resource": "box/items",
+		})
+		apierror.Write(w, r, http.StatusNotFound, "box.not_found")
+		return nil, nil, false
+	}
+	return item, memberships, true
+}
+
+// annotateItems preenche escopo, permissão, selo de validade e nomes de
+// família/criador na listagem
+func (h *Handler) annotateItems(userID string, items []*storage.BoxItemSummary, memberships map[string]*household.Membership) {
+	ownerNames := make(map[string]string)
+	now := time.Now().UTC()
+	for _, item := range items {
+		item.CanEdit = household.CanEditSummary(userID, item, memberships)
+		item.ExpiryStatus = expiryStatus(item.ExpiresOn, now)
+		if item.HouseholdID == "" {
+			item.Scope = storage.ItemScopePersonal
+			continue
+		}
+
+		item.Scope = storage.ItemScopeHousehold
+		if membership, ok := memberships[item.HouseholdID]; ok {
+			item.HouseholdName = membership.Household.Name
+		}
+		name, cached := ownerNames[item.OwnerID]
+		if !cached {
+			if owner, found := h.store.GetUserByID(item.OwnerID); found {
+				name = owner.Name
+			}
+			ownerNames[item.OwnerID] = name
+		}
+		item.OwnerName = name
+	}
+}
+
 // =============================================================================
 // ASSISTENTE
 // =============================================================================
@@ -387,36 +990,61 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 // - Requer autenticação JWT
 // - Sanitização de input
 // - Limite de tamanho
+// - Perguntas por hora por usuário e por IP (rate limit nas rotas)
+// - Orçamento diário de tokens por usuário (429 com Retry-After)
 func (h *Handler) Assistant(w http.ResponseWriter, r *http.Request) {
-	// Limitar tamanho do body
-	r.Body = http.MaxBytesReader(w, r.Body, 10*1024) // 10KB max
-
 	var payload struct {
 		Input string `json:"input"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "assistant.empty_input"))
+		apierror.Write(w, r, http.StatusBadRequest, "assistant.empty_input")
 		return
 	}
 
 	// Sanitizar e validar input
 	input := security.SanitizeText(payload.Input, 1000)
 	if strings.TrimSpace(input) == "" {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "assistant.empty_input"))
+		apierror.Write(w, r, http.StatusBadRequest, "assistant.empty_input")
 		return
 	}
 
-	// Verificar por conteúdo malicioso
-	if security.ContainsSQLInjection(input) {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "box.invalid_query"))
+	userID := auth.GetUserID(r)
+	now := time.Now()
+	retryAfter, err := h.quota.CheckAssistant(userID, now)
+	if err != nil {
+		h.writeAssistantQuotaError(w, r, err, retryAfter)
 		return
 	}
 
 	reply := buildAssistantReply(r, input)
+	if err := h.quota.RecordAssistant(userID, quota.EstimateTokens(input)+quota.EstimateTokens(reply), now); err != nil {
+		log.Printf("[Assistant] Erro ao registrar uso: %v", err)
+	}
 	writeJSON(w, http.StatusOK, map[string]string{"reply": reply})
 }
 
+// writeAssistantQuotaError responde 429 quando o orçamento diário acabou
+// Erros do storage ao calcular o uso viram 500.
+func (h *Handler) writeAssistantQuotaError(w http.ResponseWriter, r *http.Request, err error, retryAfter time.Duration) {
+	var exceeded *quota.ExceededError
+	if !errors.As(err, &exceeded) {
+		apierror.Write(w, r, http.StatusInternalServerError, "assistant.error")
+		return
+	}
+
+	h.auditLogger.LogDataAccess(auth.GetUserID(r), security.GetClientIP(r), "assistant", "quota_"+string(exceeded.Resource), "denied")
+
+	seconds := int(retryAfter.Seconds()) + 1
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	apierror.WriteMessage(w, r, http.StatusTooManyRequests, "QUOTA_EXCEEDED", i18n.Tr(r, "assistant.budget_exceeded"), map[string]interface{}{
+		"resource":    exceeded.Resource,
+		"limit":       exceeded.Limit,
+		"used":        exceeded.Used,
+		"retry_after": seconds,
+	})
+}
+
 // buildAssistantReply gera resposta do assistente baseada na pergunta
 func buildAssistantReply(r *http.Request, input string) string {
 	normalized := strings.ToLower(input)
@@ -468,11 +1096,6 @@ func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
 	}
 }
 
-// writeError escreve resposta de erro JSON
-func writeError(w http.ResponseWriter, status int, message string) {
-	writeJSON(w, status, map[string]string{"error": message})
-}
-
 func getIdempotencyKey(r *http.Request) string {
 	key := strings.TrimSpace(r.Header.Get("Idempotency-Key"))
 	if key == "" {
@@ -485,6 +1108,11 @@ func getIdempotencyKey(r *http.Request) string {
 }
 
 // parseInt converte string para int
+// formatMB formata bytes em MB para mensagens
+func formatMB(bytes int64) string {
+	return strconv.FormatFloat(float64(bytes)/(1024*1024), 'f', -1, 64)
+}
+
 func parseInt(s string) (int, error) {
 	if s == "" {
 		return 0, nil
@@ -518,34 +1146,27 @@ func sanitizeID(id string) string {
 	return result
 }
 
-// sanitizeCategory sanitiza e normaliza categoria
-func sanitizeCategory(category string) string {
-	category = strings.TrimSpace(strings.ToLower(category))
-
-	// Categorias válidas
-	validCategories := map[string]string{
-		"saude":      "saúde",
-		"saúde":      "saúde",
-		"financas":   "finanças",
-		"finanças":   "finanças",
-		"familia":    "família",
-		"família":    "família",
-		"documentos": "documentos",
-		"memorias":   "memórias",
-		"memórias":   "memórias",
-		"outros":     "outros",
+// parseItemDate converte uma data AAAA-MM-DD (ou RFC 3339) para o dia em UTC
+// Vazio significa sem data. Retorna false para datas inválidas.
+func parseItemDate(value string) (*time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, true
 	}
 
-	if normalized, ok := validCategories[category]; ok {
-		return normalized
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		full, errFull := time.Parse(time.RFC3339, value)
+		if errFull != nil {
+			return nil, false
+		}
+		parsed = time.Date(full.Year(), full.Month(), full.Day(), 0, 0, 0, 0, time.UTC)
 	}
 
-	// Se não for uma categoria válida, retornar "outros"
-	if category != "" {
-		return "outros"
+	if parsed.Year() < 1900 || parsed.Year() > 2200 {
+		return nil, false
 	}
-
-	return ""
+	return &parsed, true
 }
 
 // isValidItemType verifica se o tipo de item é válido
@@ -557,6 +1178,12 @@ func isValidItemType(t storage.ItemType) bool {
 		storage.ItemTypeAccess:   true,
 		storage.ItemTypeRoutine:  true,
 		storage.ItemTypeLocation: true,
+		storage.ItemTypeSealed:   true,
+		storage.ItemTypeDocument: true,
+		storage.ItemTypePet:      true,
+
+		storage.ItemTypeFinancialAccount: true,
+		storage.ItemTypeOnlineAccount:    true,
 	}
 	return validTypes[t]
 }
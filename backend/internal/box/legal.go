@@ -0,0 +1,234 @@
+// =============================================================================
+// FAMLI - Caixa Famli: Documentos Jurídicos
+// =============================================================================
+// Testamento, procuração, diretivas antecipadas de vontade e indicação de
+// tutor(a) dos filhos são itens "document" com um kind jurídico e os dados de
+// "legal" (textos criptografados no banco):
+//
+//	"document": {"kind": "will", "legal": {"stored_at": "Cartório do 2º Ofício",
+//	  "lawyer_name": "Dra. Ana", "lawyer_contact": "+5511999990000",
+//	  "executor_name": "Carlos", "executor_contact": "carlos@exemplo.com",
+//	  "signed_on": "2024-03-10"}}
+//
+// Endpoint:
+// - GET /api/box/legal-checklist - o que falta em cada documento jurídico,
+//   com orientações no idioma do usuário
+//
+// Com include_legal, o cartão de emergência mostra onde está cada documento
+// e quem procurar (ver emergency/handler.go).
+// =============================================================================
+
+package box
+
+import (
+	"net/http"
+	"strings"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/i18n"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+// maxLegalFieldLength limita cada texto dos dados jurídicos
+const maxLegalFieldLength = 200
+
+// Campos cobrados no checklist
+const (
+	legalFieldStoredAt      = "stored_at"
+	legalFieldLawyerContact = "lawyer_contact"
+	legalFieldExecutor      = "executor_name"
+)
+
+// Estados de cada documento no checklist
+const (
+	legalStatusMissing    = "missing"    // Nenhum item do tipo
+	legalStatusIncomplete = "incomplete" // Falta algum campo
+	legalStatusComplete   = "complete"
+)
+
+// legalRequirement define o que o checklist cobra de cada documento
+type legalRequirement struct {
+	Kind        storage.DocumentKind
+	Recommended bool     // Conta na nota (a indicação de tutor só vale para quem tem filhos menores)
+	Fields      []string // Campos obrigatórios
+}
+
+// legalRequirements são os documentos do checklist, na ordem da resposta
+var legalRequirements = []legalRequirement{
+	{Kind: storage.DocumentWill, Recommended: true, Fields: []string{legalFieldStoredAt, legalFieldLawyerContact, legalFieldExecutor}},
+	{Kind: storage.DocumentPowerOfAttorney, Recommended: true, Fields: []string{legalFieldStoredAt, legalFieldLawyerContact, legalFieldExecutor}},
+	{Kind: storage.DocumentLivingWill, Recommended: true, Fields: []string{legalFieldStoredAt, legalFieldExecutor}},
+	{Kind: storage.DocumentGuardianshipPlan, Fields: []string{legalFieldStoredAt, legalFieldExecutor}},
+}
+
+// legalPayload são os dados jurídicos enviados no documento (assinatura em AAAA-MM-DD)
+type legalPayload struct {
+	StoredAt        string `json:"stored_at"`
+	LawyerName      string `json:"lawyer_name"`
+	LawyerContact   string `json:"lawyer_contact"`
+	ExecutorName    string `json:"executor_name"`
+	ExecutorContact string `json:"executor_contact"`
+	SignedOn        string `json:"signed_on"`
+}
+
+// isEmpty indica dados jurídicos ausentes ou vazios
+func (p *legalPayload) isEmpty() bool {
+	if p == nil {
+		return true
+	}
+	for _, value := range []string{p.StoredAt, p.LawyerName, p.LawyerContact, p.ExecutorName, p.ExecutorContact, p.SignedOn} {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeLegal valida e sanitiza os dados jurídicos do documento
+// Retorna false se enviados num documento que não é jurídico ou se algum
+// campo for inválido.
+func normalizeLegal(kind storage.DocumentKind, payload *legalPayload) (*storage.LegalDetails, bool) {
+	if payload.isEmpty() {
+		return nil, true
+	}
+	if !kind.IsLegal() {
+		return nil, false
+	}
+
+	for _, value := range []string{payload.StoredAt, payload.LawyerName, payload.LawyerContact, payload.ExecutorName, payload.ExecutorContact} {
+		if len([]rune(strings.TrimSpace(value))) > maxLegalFieldLength {
+			return nil, false
+		}
+	}
+	signedOn, ok := parseItemDate(payload.SignedOn)
+	if !ok {
+		return nil, false
+	}
+
+	return &storage.LegalDetails{
+		StoredAt:        security.SanitizeText(payload.StoredAt, 0),
+		LawyerName:      security.SanitizeText(payload.LawyerName, 0),
+		LawyerContact:   security.SanitizeText(payload.LawyerContact, 0),
+		ExecutorName:    security.SanitizeText(payload.ExecutorName, 0),
+		ExecutorContact: security.SanitizeText(payload.ExecutorContact, 0),
+		SignedOn:        signedOn,
+	}, true
+}
+
+// missingLegalFields lista os campos obrigatórios ainda vazios do documento
+func missingLegalFields(req legalRequirement, document *storage.DocumentInfo) []string {
+	legal := document.Legal
+	if legal == nil {
+		legal = &storage.LegalDetails{}
+	}
+	values := map[string]string{
+		legalFieldStoredAt:      legal.StoredAt,
+		legalFieldLawyerContact: legal.LawyerContact,
+		legalFieldExecutor:      legal.ExecutorName,
+	}
+
+	missing := make([]string, 0)
+	for _, field := range req.Fields {
+		if values[field] == "" {
+			missing = append(missing, field)
+		}
+	}
+	return missing
+}
+
+// LegalChecklistEntry é a situação de um documento jurídico
+type LegalChecklistEntry struct {
+	Kind          storage.DocumentKind `json:"kind"`
+	Title         string               `json:"title"`
+	Guidance      string               `json:"guidance"`       // O que é e por que ter
+	ExecutorLabel string               `json:"executor_label"` // Como chamar o responsável neste documento
+	Recommended   bool                 `json:"recommended"`
+	Status        string               `json:"status"` // missing, incomplete ou complete
+	ItemID        string               `json:"item_id,omitempty"`
+	ItemTitle     string               `json:"item_title,omitempty"`
+	MissingFields []string             `json:"missing_fields"`
+}
+
+// LegalChecklist é o checklist dos documentos jurídicos
+type LegalChecklist struct {
+	Score    int                    `json:"score"`    // 0 a 100, só os recomendados
+	Complete int                    `json:"complete"` // Documentos completos (todos)
+	Total    int                    `json:"total"`
+	Items    []*LegalChecklistEntry `json:"items"`
+}
+
+// LegalChecklist mostra o que falta em cada documento jurídico
+// Com mais de um item do mesmo tipo, vale o mais completo. Itens arquivados
+// não contam.
+//
+// Endpoint: GET /api/box/legal-checklist
+func (h *Handler) LegalChecklist(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	items, err := h.store.GetBoxItems(userID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "box.list_error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, buildLegalChecklist(i18n.GetLocale(r), items))
+}
+
+// buildLegalChecklist monta o checklist a partir dos itens do usuário
+func buildLegalChecklist(locale string, items []*storage.BoxItem) *LegalChecklist {
+	checklist := &LegalChecklist{
+		Total: len(legalRequirements),
+		Items: make([]*LegalChecklistEntry, 0, len(legalRequirements)),
+	}
+	recommended, recommendedDone := 0, 0
+
+	for _, req := range legalRequirements {
+		key := "legal_document." + string(req.Kind)
+		entry := &LegalChecklistEntry{
+			Kind:          req.Kind,
+			Title:         i18n.T(locale, key+".title"),
+			Guidance:      i18n.T(locale, key+".guidance"),
+			ExecutorLabel: i18n.T(locale, key+".executor"),
+			Recommended:   req.Recommended,
+			Status:        legalStatusMissing,
+			MissingFields: append([]string(nil), req.Fields...),
+		}
+
+		var best *storage.BoxItem
+		for _, item := range items {
+			if item.Type != storage.ItemTypeDocument || item.Document == nil || item.Document.Kind != req.Kind || item.ArchivedAt != nil {
+				continue
+			}
+			missing := missingLegalFields(req, item.Document)
+			if best == nil || len(missing) < len(entry.MissingFields) ||
+				(len(missing) == len(entry.MissingFields) && item.UpdatedAt.After(best.UpdatedAt)) {
+				best = item
+				entry.MissingFields = missing
+			}
+		}
+		if best != nil {
+			entry.ItemID = best.ID
+			entry.ItemTitle = best.Title
+			entry.Status = legalStatusIncomplete
+			if len(entry.MissingFields) == 0 {
+				entry.Status = legalStatusComplete
+				checklist.Complete++
+			}
+		}
+
+		if req.Recommended {
+			recommended++
+			if entry.Status == legalStatusComplete {
+				recommendedDone++
+			}
+		}
+		checklist.Items = append(checklist.Items, entry)
+	}
+
+	if recommended > 0 {
+		checklist.Score = 100 * recommendedDone / recommended
+	}
+	return checklist
+}
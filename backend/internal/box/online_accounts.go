@@ -0,0 +1,78 @@
+// =============================================================================
+// FAMLI - Caixa Famli: Legado Digital
+// =============================================================================
+// Itens do tipo "online_account" dizem à família o que fazer com cada conta
+// online depois da partida:
+//
+//	"online_account": {"service": "Facebook", "username_hint": "maria.s... no Gmail pessoal",
+//	  "recovery_email_hint": "o email antigo do Hotmail", "action": "memorialize"}
+//
+// Ações: memorialize (transformar em memorial), delete (encerrar e apagar) e
+// transfer (passar para alguém da família). Apenas dicas: nunca a senha. Os
+// textos são criptografados no banco. O PUT/PATCH do item troca a conta
+// inteira; omitir mantém a atual e {} (ou null no PATCH) a remove.
+//
+// O card "digital_legacy" do Guia sugere contas comuns (Google, Facebook,
+// app do banco) e os links memorial mostram as contas agrupadas pela ação
+// (digital_legacy, ver share/handler.go).
+// =============================================================================
+
+package box
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+// maxOnlineAccountTextLength limita o serviço e as dicas de acesso
+const maxOnlineAccountTextLength = 200
+
+// isEmptyOnlineAccount indica uma conta vazia ({}), que remove a conta do item
+func isEmptyOnlineAccount(account *storage.OnlineAccount) bool {
+	return account != nil && strings.TrimSpace(account.Service) == "" && strings.TrimSpace(account.UsernameHint) == "" &&
+		strings.TrimSpace(account.RecoveryEmailHint) == "" && account.Action == ""
+}
+
+// normalizeOnlineAccount sanitiza a conta online
+// nil continua nil (mantém a conta atual); {} remove. Retorna false se algum
+// campo for inválido ou se faltar o serviço ou a ação.
+func normalizeOnlineAccount(account *storage.OnlineAccount) (*storage.OnlineAccount, bool) {
+	if account == nil || isEmptyOnlineAccount(account) {
+		return account, true
+	}
+
+	result := &storage.OnlineAccount{
+		Service:           security.SanitizeText(account.Service, 0),
+		UsernameHint:      security.SanitizeText(account.UsernameHint, 0),
+		RecoveryEmailHint: security.SanitizeText(account.RecoveryEmailHint, 0),
+		Action:            account.Action,
+	}
+	if result.Service == "" || !result.Action.IsValid() {
+		return nil, false
+	}
+	for _, text := range []string{result.Service, result.UsernameHint, result.RecoveryEmailHint} {
+		if utf8.RuneCountInString(text) > maxOnlineAccountTextLength {
+			return nil, false
+		}
+	}
+	return result, true
+}
+
+// mergeOnlineAccount decide a conta online gravada no item
+// Apenas itens "online_account" têm conta (false se enviada em outro tipo).
+func mergeOnlineAccount(itemType storage.ItemType, account, current *storage.OnlineAccount) (*storage.OnlineAccount, bool) {
+	if account == nil {
+		account = current
+	} else if isEmptyOnlineAccount(account) {
+		return nil, true
+	} else if itemType != storage.ItemTypeOnlineAccount {
+		return nil, false
+	}
+	if itemType != storage.ItemTypeOnlineAccount {
+		return nil, true // Deixar de ser conta online remove os dados salvos
+	}
+	return account, true
+}
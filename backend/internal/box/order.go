@@ -0,0 +1,112 @@
+// =============================================================================
+// FAMLI - Caixa Famli: Itens Fixados e Ordem Manual
+// =============================================================================
+// Cada pessoa pode fixar no topo os itens mais importantes (ex: contatos de
+// emergência) e arrastar os itens para a ordem que preferir:
+//
+// - PUT    /api/box/items/{itemID}/pin - fixa o item
+// - DELETE /api/box/items/{itemID}/pin - solta o item
+// - PUT    /api/box/items/order        - {"ids": [...]} define a ordem manual
+//
+// A ordem é de quem lista: membros de uma família fixam e ordenam os itens
+// compartilhados sem mudar a listagem dos outros. Itens fixados vêm primeiro
+// em todas as ordenações; a ordem manual vale com sort=manual.
+// =============================================================================
+
+package box
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/household"
+	"famli/internal/i18n"
+	"famli/internal/security"
+)
+
+// maxReorderItems limita os itens de uma reordenação
+const maxReorderItems = 1000
+
+// Pin fixa o item no topo da listagem do usuário
+//
+// Endpoint: PUT /api/box/items/{itemID}/pin
+func (h *Handler) Pin(w http.ResponseWriter, r *http.Request) {
+	h.setPinned(w, r, true)
+}
+
+// Unpin solta o item fixado
+//
+// Endpoint: DELETE /api/box/items/{itemID}/pin
+func (h *Handler) Unpin(w http.ResponseWriter, r *http.Request) {
+	h.setPinned(w, r, false)
+}
+
+// setPinned grava o item fixado de quem pode vê-lo
+func (h *Handler) setPinned(w http.ResponseWriter, r *http.Request, pinned bool) {
+	userID := auth.GetUserID(r)
+	itemID := sanitizeID(chi.URLParam(r, "itemID"))
+
+	if _, _, ok := h.findViewableItem(w, r, itemID); !ok {
+		return
+	}
+	if err := h.store.SetBoxItemPinned(userID, itemID, pinned); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "box.save_error")
+		return
+	}
+
+	h.auditLogger.LogDataAccess(userID, security.GetClientIP(r), "box/items/"+itemID+"/pin", "update", "success")
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"item_id": itemID,
+		"pinned":  pinned,
+	})
+}
+
+// Reorder define a ordem manual dos itens (sort=manual)
+// A lista pode ter só parte dos itens (ex: os da tela); os outros mantêm a
+// posição. Todos precisam ser itens que o usuário vê, sem repetição.
+//
+// Endpoint: PUT /api/box/items/order
+func (h *Handler) Reorder(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	var payload struct {
+		IDs []string `json:"ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || len(payload.IDs) == 0 || len(payload.IDs) > maxReorderItems {
+		apierror.Write(w, r, http.StatusBadRequest, "box.invalid_order")
+		return
+	}
+
+	memberships, err := household.Memberships(h.store, userID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "box.save_error")
+		return
+	}
+	seen := make(map[string]struct{}, len(payload.IDs))
+	for i, id := range payload.IDs {
+		id = sanitizeID(id)
+		if _, dup := seen[id]; dup || id == "" {
+			apierror.Write(w, r, http.StatusBadRequest, "box.invalid_order")
+			return
+		}
+		item, err := h.store.GetBoxItemByID(id)
+		if err != nil || !household.CanView(userID, item, memberships) {
+			apierror.Write(w, r, http.StatusBadRequest, "box.invalid_order")
+			return
+		}
+		seen[id] = struct{}{}
+		payload.IDs[i] = id
+	}
+
+	if err := h.store.ReorderBoxItems(userID, payload.IDs); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "box.save_error")
+		return
+	}
+
+	h.auditLogger.LogDataAccess(userID, security.GetClientIP(r), "box/items/order", "update", "success")
+	writeJSON(w, http.StatusOK, map[string]string{"message": i18n.Tr(r, "box.order_saved")})
+}
@@ -0,0 +1,211 @@
+// =============================================================================
+// FAMLI - Caixa Famli: Atualização Parcial (PATCH)
+// =============================================================================
+// PATCH /api/box/items/{itemID} aceita um JSON Merge Patch (RFC 7396): só os
+// campos enviados mudam, null limpa o campo e os demais ficam como estão.
+//
+//	{"title": "Conta do banco"}          → muda apenas o título
+//	{"due_date": null}                   → remove a data de vencimento
+//	{"household_id": null}               → volta para a caixa pessoal
+//	{"archived": true}                   → arquiva o item
+//	{"checklist": null}                  → remove a lista marcável
+//	{"schedule": null}                   → remove a agenda da rotina
+//	{"document": null}                   → remove os dados do documento
+//	{"pet": null}                        → remove o perfil do pet
+//	{"financial_account": null}          → remove a conta financeira
+//	{"online_account": null}             → remove a conta online
+//
+// Apenas os campos enviados são validados e sanitizados; o restante já está
+// salvo (sanitizado ou, em itens selados, cifrado) e é gravado sem alteração.
+// As regras de permissão, cota e versão (If-Match) são as mesmas do PUT.
+// =============================================================================
+
+package box
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"famli/internal/apierror"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+// Patch atualiza apenas os campos enviados de um item
+//
+// Endpoint: PATCH /api/box/items/{itemID}
+//
+// Segurança: as mesmas do PUT (ver Update).
+func (h *Handler) Patch(w http.ResponseWriter, r *http.Request) {
+	itemID := sanitizeID(chi.URLParam(r, "itemID"))
+
+	fields, patch, err := decodeItemPatch(r.Body)
+	if err != nil {
+		apierror.WriteDecodeError(w, r, err, "box.invalid_content")
+		return
+	}
+
+	existing, memberships, ok := h.findEditableItem(w, r, itemID)
+	if !ok {
+		return
+	}
+	version, ok := checkItemVersion(w, r, existing)
+	if !ok {
+		return
+	}
+
+	payload := payloadFromItem(existing)
+	payload.merge(patch, fields)
+
+	if errKey := payload.validateFields(fields); errKey != "" {
+		apierror.Write(w, r, http.StatusBadRequest, errKey)
+		return
+	}
+
+	// Selar ou reabrir um item troca o conteúdo inteiro: o texto cifrado (ou
+	// o texto claro) precisa vir no patch
+	if existing.IsSealed() != (payload.Type == storage.ItemTypeSealed) && !fields["content"] {
+		apierror.Write(w, r, http.StatusBadRequest, "box.sealed_invalid")
+		return
+	}
+
+	h.saveUpdate(w, r, existing, memberships, payload, version)
+}
+
+// decodeItemPatch lê o merge patch
+//
+// Retorna os campos presentes (nomes JSON) e os valores decodificados (null
+// vira o valor zero). Campos desconhecidos são recusados: num patch, um nome
+// errado não pode ser ignorado em silêncio.
+func decodeItemPatch(body io.Reader) (map[string]bool, *itemPayload, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, nil, err
+	}
+	if raw == nil {
+		return nil, nil, errors.New("patch deve ser um objeto JSON")
+	}
+	var patch itemPayload
+	if err := security.DecodeJSONStrict(bytes.NewReader(data), &patch); err != nil {
+		return nil, nil, err
+	}
+
+	fields := make(map[string]bool, len(raw))
+	for name := range raw {
+		fields[name] = true
+	}
+	return fields, &patch, nil
+}
+
+// payloadFromItem monta o payload com os valores atuais do item
+// HouseholdID e RecipientGuardianID ficam nil (mantém os atuais).
+func payloadFromItem(item *storage.BoxItem) *itemPayload {
+	return &itemPayload{
+		Type:        item.Type,
+		Title:       item.Title,
+		Content:     item.Content,
+		Category:    item.Category,
+		Recipient:   item.Recipient,
+		IsImportant: item.IsImportant,
+		IsShared:    item.IsShared,
+		GuardianIDs: item.GuardianIDs,
+		Tags:        item.Tags,
+		Sealed:      item.Sealed,
+		dueDate:     item.DueDate,
+		renewalDate: item.RenewalDate,
+	}
+}
+
+// merge copia para p os campos presentes no patch
+func (p *itemPayload) merge(patch *itemPayload, fields map[string]bool) {
+	for name := range fields {
+		switch name {
+		case "type":
+			p.Type = patch.Type
+		case "title":
+			p.Title = patch.Title
+		case "content":
+			p.Content = patch.Content
+		case "category":
+			p.Category = patch.Category
+		case "recipient":
+			p.Recipient = patch.Recipient
+		case "is_important":
+			p.IsImportant = patch.IsImportant
+		case "is_shared":
+			p.IsShared = patch.IsShared
+		case "guardian_ids":
+			p.GuardianIDs = patch.GuardianIDs
+		case "due_date":
+			p.DueDate = patch.DueDate
+		case "renewal_date":
+			p.RenewalDate = patch.RenewalDate
+		case "tags":
+			p.Tags = patch.Tags
+		case "archived":
+			p.Archived = patch.Archived
+		case "checklist":
+			// null limpa a lista (nil manteria a atual)
+			p.Checklist = patch.Checklist
+			if p.Checklist == nil {
+				p.Checklist = []storage.ChecklistEntry{}
+			}
+		case "schedule":
+			// null remove a agenda (nil manteria a atual)
+			p.Schedule = patch.Schedule
+			if p.Schedule == nil {
+				p.Schedule = &storage.RoutineSchedule{}
+			}
+		case "document":
+			// null remove o documento (nil manteria o atual)
+			p.Document = patch.Document
+			if p.Document == nil {
+				p.Document = &documentPayload{}
+			}
+		case "pet":
+			// null remove o perfil (nil manteria o atual)
+			p.Pet = patch.Pet
+			if p.Pet == nil {
+				p.Pet = &storage.PetProfile{}
+			}
+		case "financial_account":
+			// null remove a conta (nil manteria a atual)
+			p.FinancialAccount = patch.FinancialAccount
+			if p.FinancialAccount == nil {
+				p.FinancialAccount = &storage.FinancialAccount{}
+			}
+		case "online_account":
+			// null remove a conta (nil manteria a atual)
+			p.OnlineAccount = patch.OnlineAccount
+			if p.OnlineAccount == nil {
+				p.OnlineAccount = &storage.OnlineAccount{}
+			}
+		case "sealed":
+			p.Sealed = patch.Sealed
+		case "household_id":
+			p.HouseholdID = emptyIfNil(patch.HouseholdID)
+		case "recipient_guardian_id":
+			p.RecipientGuardianID = emptyIfNil(patch.RecipientGuardianID)
+		}
+	}
+}
+
+// emptyIfNil troca null por "" (no patch, null limpa o vínculo em vez de
+// manter o atual)
+func emptyIfNil(value *string) *string {
+	if value == nil {
+		empty := ""
+		return &empty
+	}
+	return value
+}
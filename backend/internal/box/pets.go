@@ -0,0 +1,125 @@
+// =============================================================================
+// FAMLI - Caixa Famli: Perfil dos Pets
+// =============================================================================
+// Itens do tipo "pet" guardam o perfil do animal, para quem cuidar dele numa
+// emergência:
+//
+//	"pet": {"name": "Thor", "species": "dog", "vet_name": "Clínica Patas",
+//	        "vet_phone": "(11) 3333-4444", "feeding_times": ["08:00", "18:00"],
+//	        "food": "Ração sênior, 1 xícara", "medication": "Apoquel 5,4mg pela manhã"}
+//
+// Os textos são criptografados no banco. O PUT/PATCH do item troca o perfil
+// inteiro; omitir mantém o atual e {} (ou null no PATCH) o remove.
+//
+// Guardiões veem os pets numa seção própria (pets) nos links e no portal; no
+// WhatsApp, a categoria "pets" (🐾) guarda o que for enviado sobre eles.
+// =============================================================================
+
+package box
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+const (
+	// maxPetTextLength limita o nome, o veterinário, a ração e o remédio
+	maxPetTextLength = 200
+
+	// maxFeedingTimes limita os horários de alimentação
+	maxFeedingTimes = 8
+)
+
+// petSpecies são as espécies aceitas
+var petSpecies = map[storage.PetSpecies]bool{
+	storage.PetDog:     true,
+	storage.PetCat:     true,
+	storage.PetBird:    true,
+	storage.PetFish:    true,
+	storage.PetRodent:  true,
+	storage.PetReptile: true,
+	storage.PetOther:   true,
+}
+
+// isEmptyPet indica um perfil vazio ({}), que remove o perfil do item
+func isEmptyPet(pet *storage.PetProfile) bool {
+	return pet != nil && strings.TrimSpace(pet.Name) == "" && pet.Species == "" && strings.TrimSpace(pet.VetName) == "" &&
+		strings.TrimSpace(pet.VetPhone) == "" && len(pet.FeedingTimes) == 0 && strings.TrimSpace(pet.Food) == "" &&
+		strings.TrimSpace(pet.Medication) == ""
+}
+
+// normalizePet sanitiza o perfil e ordena os horários de alimentação
+// nil continua nil (mantém o perfil atual); {} remove. Retorna false se
+// algum campo for inválido ou se faltar o nome.
+func normalizePet(pet *storage.PetProfile) (*storage.PetProfile, bool) {
+	if pet == nil || isEmptyPet(pet) {
+		return pet, true
+	}
+
+	result := &storage.PetProfile{
+		Name:       security.SanitizeName(pet.Name),
+		Species:    pet.Species,
+		VetName:    security.SanitizeText(pet.VetName, 0),
+		Food:       security.SanitizeText(pet.Food, 0),
+		Medication: security.SanitizeText(pet.Medication, 0),
+	}
+	if result.Name == "" {
+		return nil, false
+	}
+	if result.Species == "" {
+		result.Species = storage.PetOther
+	}
+	if !petSpecies[result.Species] {
+		return nil, false
+	}
+	for _, text := range []string{result.VetName, result.Food, result.Medication} {
+		if utf8.RuneCountInString(text) > maxPetTextLength {
+			return nil, false
+		}
+	}
+
+	phone, err := security.ValidatePhone(strings.TrimSpace(pet.VetPhone))
+	if err != nil {
+		return nil, false
+	}
+	result.VetPhone = phone
+
+	seen := make(map[string]bool, len(pet.FeedingTimes))
+	for _, value := range pet.FeedingTimes {
+		parsed, err := time.Parse("15:04", strings.TrimSpace(value))
+		if err != nil {
+			return nil, false
+		}
+		value = parsed.Format("15:04")
+		if !seen[value] {
+			seen[value] = true
+			result.FeedingTimes = append(result.FeedingTimes, value)
+		}
+	}
+	if len(result.FeedingTimes) > maxFeedingTimes {
+		return nil, false
+	}
+	sort.Strings(result.FeedingTimes)
+	return result, true
+}
+
+// mergePet decide o perfil gravado no item
+// Apenas itens "pet" têm perfil (false se enviado em outro tipo).
+func mergePet(itemType storage.ItemType, pet, current *storage.PetProfile) (*storage.PetProfile, bool) {
+	if pet == nil {
+		pet = current
+	} else if isEmptyPet(pet) {
+		return nil, true
+	} else if itemType != storage.ItemTypePet {
+		return nil, false
+	}
+	if itemType != storage.ItemTypePet {
+		return nil, true // Deixar de ser pet remove o perfil salvo
+	}
+	return pet, true
+}
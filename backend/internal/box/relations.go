@@ -0,0 +1,221 @@
+// =============================================================================
+// FAMLI - Caixa Famli: Vínculos entre Itens
+// =============================================================================
+// Um item pode ser ligado a:
+// - Uma pessoa de confiança ("intended_for"): a quem aquela informação se
+//   destina, ex: "onde estão meus documentos" → filha
+// - Outro item ("see_also"): "veja também", vale nos dois itens
+//
+// Regras de acesso:
+// - Vincular e desvincular exige permissão de edição no item
+// - Os guardiões são de quem criou o item: apenas essa pessoa gerencia
+//   "intended_for" (como o compartilhamento com guardiões)
+// - "see_also" só aponta para itens que o usuário pode ver; na exibição,
+//   itens vinculados sem acesso ficam de fora
+// =============================================================================
+
+package box
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/household"
+	"famli/internal/ids"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+// relationPayload é o payload de criação de vínculo
+type relationPayload struct {
+	Kind     storage.RelationKind `json:"kind"`
+	TargetID string               `json:"target_id"`
+}
+
+// CreateRelation vincula o item a um guardião ou a outro item
+//
+// Endpoint: POST /api/box/items/{itemID}/relations
+//
+// Retorna os vínculos atualizados do item.
+func (h *Handler) CreateRelation(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+	itemID := sanitizeID(chi.URLParam(r, "itemID"))
+
+	var payload relationPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "box.relation_invalid")
+		return
+	}
+	payload.TargetID = sanitizeID(payload.TargetID)
+	if !payload.Kind.IsValid() || payload.TargetID == "" || payload.TargetID == itemID {
+		apierror.Write(w, r, http.StatusBadRequest, "box.relation_invalid")
+		return
+	}
+
+	item, memberships, ok := h.findEditableItem(w, r, itemID)
+	if !ok {
+		return
+	}
+
+	switch payload.Kind {
+	case storage.RelationIntendedFor:
+		if item.UserID != userID {
+			apierror.Write(w, r, http.StatusForbidden, "box.relation_forbidden")
+			return
+		}
+		if !h.ownsGuardian(userID, payload.TargetID) {
+			apierror.Write(w, r, http.StatusNotFound, "box.relation_target_not_found")
+			return
+		}
+	case storage.RelationSeeAlso:
+		target, err := h.store.GetBoxItemByID(payload.TargetID)
+		if err != nil || !household.CanView(userID, target, memberships) {
+			apierror.Write(w, r, http.StatusNotFound, "box.relation_target_not_found")
+			return
+		}
+	}
+
+	rel := &storage.ItemRelation{
+		ID:        ids.New(ids.Relation),
+		ItemID:    itemID,
+		Kind:      payload.Kind,
+		TargetID:  payload.TargetID,
+		CreatedBy: userID,
+	}
+	if err := h.store.CreateItemRelation(rel); err != nil {
+		apierror.WriteStorage(w, r, err, apierror.StorageKeys{Conflict: "box.relation_exists", Internal: "box.save_error"})
+		return
+	}
+
+	h.auditLogger.LogDataAccess(userID, security.GetClientIP(r), "box/items/"+itemID+"/relations", "create", "success")
+	h.writeRelations(w, r, http.StatusCreated, item, memberships)
+}
+
+// DeleteRelation remove um vínculo do item
+//
+// Endpoint: DELETE /api/box/items/{itemID}/relations/{relationID}
+//
+// Um "veja também" pode ser removido a partir de qualquer um dos dois itens.
+// Retorna os vínculos atualizados do item.
+func (h *Handler) DeleteRelation(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+	itemID := sanitizeID(chi.URLParam(r, "itemID"))
+	relationID := sanitizeID(chi.URLParam(r, "relationID"))
+
+	item, memberships, ok := h.findEditableItem(w, r, itemID)
+	if !ok {
+		return
+	}
+
+	relations, err := h.store.ListItemRelations(itemID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "box.relation_error")
+		return
+	}
+	var rel *storage.ItemRelation
+	for _, candidate := range relations {
+		if candidate.ID == relationID {
+			rel = candidate
+			break
+		}
+	}
+	if rel == nil {
+		apierror.Write(w, r, http.StatusNotFound, "box.relation_not_found")
+		return
+	}
+	if rel.Kind == storage.RelationIntendedFor && item.UserID != userID {
+		apierror.Write(w, r, http.StatusForbidden, "box.relation_forbidden")
+		return
+	}
+
+	if err := h.store.DeleteItemRelation(itemID, relationID); err != nil {
+		apierror.WriteStorage(w, r, err, apierror.StorageKeys{NotFound: "box.relation_not_found", Internal: "box.save_error"})
+		return
+	}
+
+	h.auditLogger.LogDataAccess(userID, security.GetClientIP(r), "box/items/"+itemID+"/relations", "delete", "success")
+	h.writeRelations(w, r, http.StatusOK, item, memberships)
+}
+
+// writeRelations responde com os vínculos atuais do item
+func (h *Handler) writeRelations(w http.ResponseWriter, r *http.Request, status int, item *storage.BoxItem, memberships map[string]*household.Membership) {
+	relations, err := h.itemRelations(auth.GetUserID(r), item, memberships)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "box.relation_error")
+		return
+	}
+	writeJSON(w, status, relations)
+}
+
+// itemRelations monta os guardiões e itens vinculados, prontos para exibir
+// Guardiões removidos e itens que o usuário não pode ver ficam de fora.
+func (h *Handler) itemRelations(userID string, item *storage.BoxItem, memberships map[string]*household.Membership) (*storage.ItemRelations, error) {
+	relations, err := h.store.ListItemRelations(item.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &storage.ItemRelations{
+		IntendedFor: make([]*storage.RelatedGuardian, 0),
+		SeeAlso:     make([]*storage.RelatedItem, 0),
+	}
+	var guardians map[string]*storage.Guardian // Guardiões de quem criou o item (carregados sob demanda)
+
+	for _, rel := range relations {
+		switch rel.Kind {
+		case storage.RelationIntendedFor:
+			if guardians == nil {
+				list, err := h.store.GetGuardians(item.UserID)
+				if err != nil {
+					return nil, err
+				}
+				guardians = make(map[string]*storage.Guardian, len(list))
+				for _, g := range list {
+					guardians[g.ID] = g
+				}
+			}
+			g, ok := guardians[rel.TargetID]
+			if !ok {
+				continue
+			}
+			result.IntendedFor = append(result.IntendedFor, &storage.RelatedGuardian{
+				RelationID:   rel.ID,
+				ID:           g.ID,
+				Name:         g.Name,
+				Relationship: g.Relationship,
+			})
+
+		case storage.RelationSeeAlso:
+			other, err := h.store.GetBoxItemByID(rel.Counterpart(item.ID))
+			if err != nil || !household.CanView(userID, other, memberships) {
+				continue
+			}
+			result.SeeAlso = append(result.SeeAlso, &storage.RelatedItem{
+				RelationID: rel.ID,
+				ID:         other.ID,
+				Title:      other.Title,
+				Type:       other.Type,
+				Category:   other.Category,
+			})
+		}
+	}
+	return result, nil
+}
+
+// ownsGuardian indica se o guardião pertence ao usuário
+func (h *Handler) ownsGuardian(userID, guardianID string) bool {
+	guardians, err := h.store.GetGuardians(userID)
+	if err != nil {
+		return false
+	}
+	for _, g := range guardians {
+		if g.ID == guardianID {
+			return true
+		}
+	}
+	return false
+}
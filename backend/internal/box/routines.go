@@ -0,0 +1,273 @@
+// =============================================================================
+// FAMLI - Caixa Famli: Agenda das Rotinas
+// =============================================================================
+// Rotinas (type "routine") podem ter uma agenda estruturada em vez de só
+// texto livre:
+//
+//	"schedule": {"medication": "Losartana", "dose": "50mg", "times": ["08:00", "20:00"],
+//	             "weekdays": [1, 3, 5], "responsible_guardian_id": "grd_..."}
+//
+// Os horários são do relógio de quem cuida (sem fuso). O PUT/PATCH do item
+// troca a agenda inteira; omitir mantém a atual e {} (ou null no PATCH) a
+// remove.
+//
+// Endpoint:
+// - GET /api/box/routines?date=AAAA-MM-DD&days=N - doses de hoje e dos próximos dias
+//
+// Guardiões veem a agenda nos links e no portal; o cartão de emergência a
+// inclui quando o usuário escolhe (include_schedule).
+// =============================================================================
+
+package box
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+const (
+	// maxScheduleTimes limita os horários de cada rotina
+	maxScheduleTimes = 12
+
+	// maxScheduleTextLength limita o remédio e a dose (em caracteres)
+	maxScheduleTextLength = 100
+
+	// maxRoutineDays limita o período da listagem de doses
+	maxRoutineDays = 7
+)
+
+// routineEntry é uma dose (ou tarefa) da agenda num dia e horário
+type routineEntry struct {
+	ItemID          string `json:"item_id"`
+	Title           string `json:"title"`
+	Medication      string `json:"medication,omitempty"`
+	Dose            string `json:"dose,omitempty"`
+	Date            string `json:"date"` // AAAA-MM-DD
+	Time            string `json:"time"` // HH:MM
+	ResponsibleID   string `json:"responsible_guardian_id,omitempty"`
+	ResponsibleName string `json:"responsible_name,omitempty"`
+}
+
+// isEmptySchedule indica uma agenda vazia ({}), que remove a agenda do item
+func isEmptySchedule(schedule *storage.RoutineSchedule) bool {
+	return schedule != nil && schedule.Medication == "" && schedule.Dose == "" &&
+		len(schedule.Times) == 0 && len(schedule.Weekdays) == 0 && schedule.ResponsibleGuardianID == ""
+}
+
+// normalizeSchedule sanitiza a agenda, ordena os horários e os dias
+// nil continua nil (mantém a agenda atual); {} remove.
+// Retorna false se algum campo for inválido.
+func normalizeSchedule(schedule *storage.RoutineSchedule) (*storage.RoutineSchedule, bool) {
+	if schedule == nil {
+		return nil, true
+	}
+
+	result := &storage.RoutineSchedule{
+		Medication:            security.SanitizeText(schedule.Medication, 0),
+		Dose:                  security.SanitizeText(schedule.Dose, 0),
+		ResponsibleGuardianID: sanitizeID(schedule.ResponsibleGuardianID),
+	}
+	if utf8.RuneCountInString(result.Medication) > maxScheduleTextLength ||
+		utf8.RuneCountInString(result.Dose) > maxScheduleTextLength {
+		return nil, false
+	}
+
+	seen := make(map[string]bool, len(schedule.Times))
+	for _, value := range schedule.Times {
+		parsed, err := time.Parse("15:04", strings.TrimSpace(value))
+		if err != nil {
+			return nil, false
+		}
+		value = parsed.Format("15:04")
+		if !seen[value] {
+			seen[value] = true
+			result.Times = append(result.Times, value)
+		}
+	}
+	if len(result.Times) > maxScheduleTimes {
+		return nil, false
+	}
+	sort.Strings(result.Times)
+
+	days := make(map[int]bool, len(schedule.Weekdays))
+	for _, day := range schedule.Weekdays {
+		if day < 0 || day > 6 {
+			return nil, false
+		}
+		days[day] = true
+	}
+	if len(days) < 7 { // Todos os dias = sem restrição
+		for day := range days {
+			result.Weekdays = append(result.Weekdays, day)
+		}
+		sort.Ints(result.Weekdays)
+	}
+
+	if isEmptySchedule(result) {
+		return result, true
+	}
+	// Uma agenda precisa de ao menos um horário
+	if len(result.Times) == 0 {
+		return nil, false
+	}
+	return result, true
+}
+
+// mergeSchedule decide a agenda gravada no item
+// Apenas rotinas têm agenda; o responsável precisa ser uma pessoa de
+// confiança de quem criou o item (false caso contrário).
+func (h *Handler) mergeSchedule(itemType storage.ItemType, ownerID string, schedule, current *storage.RoutineSchedule) (*storage.RoutineSchedule, bool) {
+	if schedule == nil {
+		schedule = current
+	} else if isEmptySchedule(schedule) {
+		return nil, true
+	} else if itemType != storage.ItemTypeRoutine {
+		return nil, false
+	}
+	if schedule == nil || itemType != storage.ItemTypeRoutine {
+		return nil, true // Deixar de ser rotina remove a agenda salva
+	}
+
+	if id := schedule.ResponsibleGuardianID; id != "" && (current == nil || id != current.ResponsibleGuardianID) {
+		if _, ok := h.guardianNames(ownerID)[id]; !ok {
+			return nil, false
+		}
+	}
+	return schedule, true
+}
+
+// guardianNames retorna o nome das pessoas de confiança do usuário por ID
+func (h *Handler) guardianNames(userID string) map[string]string {
+	names := make(map[string]string)
+	guardians, err := h.store.GetGuardians(userID)
+	if err != nil {
+		return names
+	}
+	for _, g := range guardians {
+		names[g.ID] = g.Name
+	}
+	return names
+}
+
+// withResponsibleName retorna uma cópia da agenda com o nome do responsável
+// (vazio se o guardião foi removido)
+func withResponsibleName(schedule *storage.RoutineSchedule, names map[string]string) *storage.RoutineSchedule {
+	if schedule == nil {
+		return nil
+	}
+	result := *schedule
+	result.ResponsibleName = names[schedule.ResponsibleGuardianID]
+	return &result
+}
+
+// Routines lista as doses das rotinas de hoje (ou dos próximos dias)
+//
+// Endpoint: GET /api/box/routines
+//
+// Query params:
+// - date: primeiro dia (AAAA-MM-DD; padrão: hoje em UTC, o cliente envia o dia local)
+// - days: quantidade de dias, de 1 a 7 (padrão 1)
+// - scope, household_id e os filtros da listagem de itens
+func (h *Handler) Routines(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	start := time.Now().UTC().Truncate(24 * time.Hour)
+	if value := r.URL.Query().Get("date"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			apierror.Write(w, r, http.StatusBadRequest, "box.invalid_filter")
+			return
+		}
+		start = parsed
+	}
+	days := 1
+	if value := r.URL.Query().Get("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxRoutineDays {
+			apierror.Write(w, r, http.StatusBadRequest, "box.invalid_filter")
+			return
+		}
+		days = parsed
+	}
+
+	filter, _, ok := h.itemFilter(w, r, userID)
+	if !ok {
+		return
+	}
+	items, err := h.store.ListScheduledRoutines(filter)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "box.list_error")
+		return
+	}
+
+	entries := buildRoutineEntries(items, start, days, h.ownerGuardianNames(items))
+
+	h.auditLogger.LogDataAccess(userID, security.GetClientIP(r), "box/routines", "list", "success")
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"from":    start.Format("2006-01-02"),
+		"days":    days,
+		"entries": entries,
+	})
+}
+
+// ownerGuardianNames junta o nome dos guardiões de quem criou cada item
+func (h *Handler) ownerGuardianNames(items []*storage.BoxItem) map[string]string {
+	names := make(map[string]string)
+	loaded := make(map[string]bool)
+	for _, item := range items {
+		if loaded[item.UserID] {
+			continue
+		}
+		loaded[item.UserID] = true
+		for id, name := range h.guardianNames(item.UserID) {
+			names[id] = name
+		}
+	}
+	return names
+}
+
+// buildRoutineEntries expande as agendas em doses, em ordem de dia e horário
+func buildRoutineEntries(items []*storage.BoxItem, start time.Time, days int, names map[string]string) []routineEntry {
+	entries := make([]routineEntry, 0)
+	for day := 0; day < days; day++ {
+		date := start.AddDate(0, 0, day)
+		for _, item := range items {
+			schedule := item.Schedule
+			if schedule == nil || !schedule.RunsOn(date.Weekday()) {
+				continue
+			}
+			for _, at := range schedule.Times {
+				entries = append(entries, routineEntry{
+					ItemID:          item.ID,
+					Title:           item.Title,
+					Medication:      schedule.Medication,
+					Dose:            schedule.Dose,
+					Date:            date.Format("2006-01-02"),
+					Time:            at,
+					ResponsibleID:   schedule.ResponsibleGuardianID,
+					ResponsibleName: names[schedule.ResponsibleGuardianID],
+				})
+			}
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Date != entries[j].Date {
+			return entries[i].Date < entries[j].Date
+		}
+		if entries[i].Time != entries[j].Time {
+			return entries[i].Time < entries[j].Time
+		}
+		return entries[i].Title < entries[j].Title
+	})
+	return entries
+}
@@ -0,0 +1,80 @@
+// =============================================================================
+// FAMLI - Caixa Famli: Itens Selados
+// =============================================================================
+// Itens "sealed" são cifrados no navegador com uma chave derivada de uma
+// frase secreta. O servidor guarda apenas o texto cifrado (em content, em
+// base64) e os parâmetros para derivar a chave e decifrar; a frase secreta
+// nunca chega ao backend.
+//
+// Por isso o conteúdo de itens selados:
+// - Não passa pela sanitização (é validado como base64)
+// - Nunca é renderizado (?render=html), buscado ou resumido no WhatsApp
+//
+// O título continua em texto claro para a listagem.
+// =============================================================================
+
+package box
+
+import (
+	"encoding/base64"
+
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+// Algoritmos aceitos nos itens selados (os mesmos da Web Crypto API ou de
+// bibliotecas amplamente disponíveis no navegador)
+const (
+	sealedKDFPBKDF2   = "pbkdf2-sha256"
+	sealedKDFArgon2id = "argon2id"
+	sealedCipher      = "aes-256-gcm"
+)
+
+// Limites dos parâmetros de derivação (evitam chaves fracas ou custo absurdo
+// para quem vai decifrar)
+const (
+	minPBKDF2Iterations = 100000
+	maxPBKDF2Iterations = 10000000
+	maxArgon2Passes     = 10
+	minArgon2MemoryKiB  = 19 * 1024
+	maxArgon2MemoryKiB  = 1024 * 1024
+	maxArgon2Threads    = 16
+
+	minSaltBytes = 16
+	maxSaltBytes = 64
+	gcmNonceSize = 12
+	gcmTagSize   = 16
+)
+
+// validSealed confere o texto cifrado e os parâmetros de um item selado
+func validSealed(content string, params *storage.SealedParams) bool {
+	if params == nil {
+		return false
+	}
+
+	ciphertext, err := base64.StdEncoding.Strict().DecodeString(content)
+	if err != nil || len(ciphertext) <= gcmTagSize || len(ciphertext) > security.MaxContentLength+gcmTagSize {
+		return false
+	}
+
+	salt, err := base64.StdEncoding.Strict().DecodeString(params.Salt)
+	if err != nil || len(salt) < minSaltBytes || len(salt) > maxSaltBytes {
+		return false
+	}
+	nonce, err := base64.StdEncoding.Strict().DecodeString(params.Nonce)
+	if err != nil || len(nonce) != gcmNonceSize || params.Cipher != sealedCipher {
+		return false
+	}
+
+	switch params.KDF {
+	case sealedKDFPBKDF2:
+		params.MemoryKiB = 0
+		params.Parallelism = 0
+		return params.Iterations >= minPBKDF2Iterations && params.Iterations <= maxPBKDF2Iterations
+	case sealedKDFArgon2id:
+		return params.Iterations >= 1 && params.Iterations <= maxArgon2Passes &&
+			params.MemoryKiB >= minArgon2MemoryKiB && params.MemoryKiB <= maxArgon2MemoryKiB &&
+			params.Parallelism >= 1 && params.Parallelism <= maxArgon2Threads
+	}
+	return false
+}
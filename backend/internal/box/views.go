@@ -0,0 +1,67 @@
+// =============================================================================
+// FAMLI - Caixa Famli: Recibos de Leitura
+// =============================================================================
+// Quando um item aparece num link compartilhado ou para um guardião (link
+// de acesso ou portal), a visualização é registrada por origem. Assim quem
+// deixou uma mensagem sabe se ela já foi entregue e lida.
+//
+// Apenas quem criou o item vê os recibos.
+// =============================================================================
+
+package box
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+// Views lista quem já viu o item e quando
+//
+// Endpoint: GET /api/box/items/{itemID}/views
+func (h *Handler) Views(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+	itemID := sanitizeID(chi.URLParam(r, "itemID"))
+
+	if _, ok := h.findOwnItem(w, r, itemID); !ok {
+		return
+	}
+
+	views, err := h.store.ListItemViews(itemID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "box.views_error")
+		return
+	}
+	h.fillViewerNames(userID, views)
+
+	h.auditLogger.LogDataAccess(userID, security.GetClientIP(r), "box/items/"+itemID+"/views", "read", "success")
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"item_id": itemID,
+		"read":    len(views) > 0,
+		"views":   views,
+	})
+}
+
+// fillViewerNames preenche o nome do guardião ou do link de cada recibo
+// Origens removidas ficam sem nome.
+func (h *Handler) fillViewerNames(userID string, views []*storage.ItemView) {
+	names := make(map[string]string)
+	if guardians, err := h.store.GetGuardians(userID); err == nil {
+		for _, g := range guardians {
+			names[string(storage.ItemViewGuardian)+"|"+g.ID] = g.Name
+		}
+	}
+	if links, err := h.store.GetShareLinksByUser(userID); err == nil {
+		for _, link := range links {
+			names[string(storage.ItemViewShareLink)+"|"+link.ID] = link.Name
+		}
+	}
+	for _, view := range views {
+		view.ViewerName = names[string(view.Source)+"|"+view.SourceID]
+	}
+}
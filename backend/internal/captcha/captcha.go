@@ -0,0 +1,86 @@
+// =============================================================================
+// FAMLI - Verificação de CAPTCHA
+// =============================================================================
+// Desafio anti-robô pedido nas rotas públicas de compartilhamento quando um
+// IP acumula tokens inválidos (ver share/botguard.go). O navegador resolve o
+// desafio do provedor e envia o token; o servidor confere o token na API
+// "siteverify" do provedor.
+//
+// Drivers (CAPTCHA_PROVIDER):
+// - turnstile: Cloudflare Turnstile
+// - hcaptcha: hCaptcha
+// - vazio: desligado (o abuso é barrado só com 429)
+// =============================================================================
+
+package captcha
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrMissingToken indica que o cliente não enviou o token do desafio
+	ErrMissingToken = errors.New("captcha: token ausente")
+
+	// ErrInvalidToken indica que o provedor recusou o token
+	ErrInvalidToken = errors.New("captcha: token inválido")
+)
+
+// Verifier confere os tokens dos desafios resolvidos no navegador
+type Verifier interface {
+	// Name identifica o provedor (ex: "turnstile", "hcaptcha", "disabled")
+	Name() string
+
+	// Enabled indica se há um provedor configurado
+	Enabled() bool
+
+	// SiteKey é a chave pública usada pelo widget no navegador
+	SiteKey() string
+
+	// Verify confere o token. Retorna ErrMissingToken, ErrInvalidToken ou o
+	// erro de comunicação com o provedor.
+	Verify(ctx context.Context, token, remoteIP string) error
+}
+
+// Config escolhe e configura o provedor
+type Config struct {
+	Provider  string        // "turnstile", "hcaptcha" ou vazio (desligado)
+	SiteKey   string        // Chave pública do widget
+	SecretKey string        // Chave secreta da API siteverify
+	VerifyURL string        // Endereço da API siteverify (vazio = o do provedor)
+	Timeout   time.Duration // Tempo máximo da verificação
+}
+
+// New cria o Verifier da configuração
+// Sem provedor ou sem chave secreta, retorna um Verifier desligado.
+func New(config Config) Verifier {
+	if config.SecretKey == "" {
+		return Disabled{}
+	}
+	switch config.Provider {
+	case ProviderTurnstile, ProviderHCaptcha:
+		return NewSiteVerify(config)
+	}
+	return Disabled{}
+}
+
+// =============================================================================
+// DESLIGADO
+// =============================================================================
+
+// Disabled não pede desafio algum
+type Disabled struct{}
+
+// Name implementa Verifier
+func (Disabled) Name() string { return "disabled" }
+
+// Enabled implementa Verifier
+func (Disabled) Enabled() bool { return false }
+
+// SiteKey implementa Verifier
+func (Disabled) SiteKey() string { return "" }
+
+// Verify implementa Verifier
+func (Disabled) Verify(ctx context.Context, token, remoteIP string) error { return nil }
@@ -0,0 +1,117 @@
+// =============================================================================
+// FAMLI - Driver siteverify (Turnstile e hCaptcha)
+// =============================================================================
+// Os dois provedores têm a mesma API de verificação:
+//
+//	POST <siteverify> (form) secret=...&response=<token>&remoteip=<ip>
+//	← {"success": true|false, "error-codes": [...]}
+//
+// Referências:
+// - https://developers.cloudflare.com/turnstile/get-started/server-side-validation/
+// - https://docs.hcaptcha.com/#verify-the-user-response-server-side
+// =============================================================================
+
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	ProviderTurnstile = "turnstile"
+	ProviderHCaptcha  = "hcaptcha"
+
+	turnstileVerifyURL = "https://challenges.cloudflare.com/turnstile/v0/siteverify"
+	hcaptchaVerifyURL  = "https://api.hcaptcha.com/siteverify"
+
+	// defaultVerifyTimeout vale quando nenhum tempo é configurado
+	defaultVerifyTimeout = 10 * time.Second
+)
+
+// SiteVerify confere tokens na API siteverify do provedor
+type SiteVerify struct {
+	provider  string
+	siteKey   string
+	secretKey string
+	verifyURL string
+	client    *http.Client
+}
+
+// siteVerifyResponse é a resposta da API siteverify
+type siteVerifyResponse struct {
+	Success    bool     `json:"success"`
+	ErrorCodes []string `json:"error-codes"`
+}
+
+// NewSiteVerify cria o driver do provedor da configuração
+func NewSiteVerify(config Config) *SiteVerify {
+	verifyURL := config.VerifyURL
+	if verifyURL == "" {
+		verifyURL = turnstileVerifyURL
+		if config.Provider == ProviderHCaptcha {
+			verifyURL = hcaptchaVerifyURL
+		}
+	}
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultVerifyTimeout
+	}
+	return &SiteVerify{
+		provider:  config.Provider,
+		siteKey:   config.SiteKey,
+		secretKey: config.SecretKey,
+		verifyURL: verifyURL,
+		client:    &http.Client{Timeout: timeout},
+	}
+}
+
+// Name implementa Verifier
+func (s *SiteVerify) Name() string { return s.provider }
+
+// Enabled implementa Verifier
+func (s *SiteVerify) Enabled() bool { return true }
+
+// SiteKey implementa Verifier
+func (s *SiteVerify) SiteKey() string { return s.siteKey }
+
+// Verify implementa Verifier
+func (s *SiteVerify) Verify(ctx context.Context, token, remoteIP string) error {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return ErrMissingToken
+	}
+
+	form := url.Values{"secret": {s.secretKey}, "response": {token}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.verifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("captcha: erro ao verificar no %s: %w", s.provider, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("captcha: %s respondeu %d", s.provider, resp.StatusCode)
+	}
+
+	var result siteVerifyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("captcha: resposta inválida do %s: %w", s.provider, err)
+	}
+	if !result.Success {
+		return fmt.Errorf("%w (%s)", ErrInvalidToken, strings.Join(result.ErrorCodes, ", "))
+	}
+	return nil
+}
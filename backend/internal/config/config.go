@@ -0,0 +1,686 @@
+// =============================================================================
+// FAMLI - Configuração
+// =============================================================================
+// Lê todas as variáveis de ambiente uma única vez, na inicialização, e valida
+// os valores. Os pacotes recebem a configuração pelos construtores (Config de
+// cada pacote montado no main.go) e não leem os.Getenv durante as requisições.
+//
+// Fora de desenvolvimento, valores ausentes ou inválidos interrompem a
+// inicialização com um relatório de todos os problemas. Em desenvolvimento,
+// os problemas viram avisos e os valores padrão são usados.
+//
+// A lista completa de variáveis está em env.example.
+// =============================================================================
+
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Valores padrão usados apenas em desenvolvimento
+const (
+	devJWTSecret     = "famli-dev-secret-change-in-production"
+	devEncryptionKey = "famli-dev-encryption-key-32chars!"
+
+	// minSecretLength é o tamanho mínimo de JWT_SECRET em produção
+	minSecretLength = 32
+
+	// maxLegalVersionLength é o tamanho das colunas de versão dos termos
+	maxLegalVersionLength = 50
+)
+
+// clientIDPattern define o formato dos clientes de API_TOKEN_CLIENTS
+var clientIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{1,39}$`)
+
+// Config reúne as configurações do servidor
+type Config struct {
+	Env       string // ENV: development, staging, production
+	Port      string // PORT
+	StaticDir string // STATIC_DIR: frontend buildado
+	AppURL    string // APP_BASE_URL (ou APP_URL): endereço público do frontend
+
+	// ExtraAllowedOrigins são origens aceitas pelo CORS/CSRF além da do
+	// AppURL (EXTRA_ALLOWED_ORIGINS, ex: https://www.famli.me)
+	ExtraAllowedOrigins []string
+
+	JWTSecret      string // JWT_SECRET
+	EncryptionKey  string // ENCRYPTION_KEY (fallback: JWT_SECRET)
+	EncryptionSalt string // ENCRYPTION_SALT (opcional, base64)
+
+	DatabaseURL string // DATABASE_URL (vazio = armazenamento em memória)
+	RedisURL    string // REDIS_URL (vazio = rate limit em memória)
+	GeoIPDBPath string // GEOIP_DB_PATH: base MaxMind City (vazio = sem geolocalização)
+
+	LogRetentionDays        int // LOG_RETENTION_DAYS
+	LogCleanupIntervalHours int // LOG_CLEANUP_INTERVAL_HOURS (0 = sem limpeza periódica)
+
+	Auth      Auth
+	Share     Share
+	Quota     Quota
+	Assistant Assistant
+	Analytics Analytics
+	Billing   Billing
+	Email     Email
+	WhatsApp  WhatsApp
+	Inbound   Inbound
+	OAuth     OAuth
+	Push      Push
+	Backup    Backup
+	Scan      Scan
+	Captcha   Captcha
+	Legal     Legal
+	Health    Health
+}
+
+// Auth são as configurações de login e sessão
+type Auth struct {
+	LockoutThreshold int           // ACCOUNT_LOCKOUT_THRESHOLD
+	LockoutDuration  time.Duration // ACCOUNT_LOCKOUT_MINUTES
+	AdminEmails      []string      // ADMIN_EMAILS: superadmin inicial
+	TokenClients     []string      // API_TOKEN_CLIENTS: clientes com token Bearer
+}
+
+// Share são os limites dos links compartilhados (aplicados em produção)
+type Share struct {
+	DefaultExpiresDays int // SHARE_LINK_DEFAULT_EXPIRES_DAYS
+	MaxExpiresDays     int // SHARE_LINK_MAX_EXPIRES_DAYS
+	DefaultMaxUses     int // SHARE_LINK_DEFAULT_MAX_USES
+	MaxUses            int // SHARE_LINK_MAX_USES
+
+	// URLPrefix fixa o início das URLs dos links, antes do token
+	// (SHARE_URL_PREFIX; vazio = APP_BASE_URL + /compartilhado ou /shared,
+	// conforme o idioma do dono)
+	URLPrefix string
+
+	// GuardianDeletionGraceDays é o prazo entre o pedido de remoção feito
+	// pelo próprio guardião e a exclusão do cadastro
+	// (GUARDIAN_DELETION_GRACE_DAYS)
+	GuardianDeletionGraceDays int
+
+	// PublicRateLimit são as requisições por minuto e por IP nas rotas
+	// públicas dos links (SHARE_PUBLIC_RATE_LIMIT)
+	PublicRateLimit int
+
+	// AbuseThreshold são os tokens inválidos (404) por IP em 10 minutos antes
+	// de pedir CAPTCHA, ou de bloquear sem CAPTCHA (SHARE_ABUSE_THRESHOLD)
+	AbuseThreshold int
+}
+
+// Quota são os limites de armazenamento por usuário (0 = sem limite)
+type Quota struct {
+	MaxItems     int // QUOTA_MAX_ITEMS
+	MaxContentMB int // QUOTA_MAX_CONTENT_MB
+}
+
+// Assistant são os limites de uso do assistente (POST /api/assistant)
+type Assistant struct {
+	UserHourlyRequests int // ASSISTANT_USER_HOURLY_REQUESTS: perguntas por usuário por hora
+	IPHourlyRequests   int // ASSISTANT_IP_HOURLY_REQUESTS: perguntas por IP por hora
+	DailyTokens        int // ASSISTANT_DAILY_TOKENS: tokens por usuário por dia (0 = sem limite)
+}
+
+// Analytics é a amostragem dos eventos de usuários muito ativos
+type Analytics struct {
+	DailyEventLimit int // ANALYTICS_DAILY_EVENT_LIMIT: eventos/dia gravados integralmente (0 = sem amostragem)
+	SamplePercent   int // ANALYTICS_SAMPLE_PERCENT: % gravada acima do limite diário
+
+	// Gravação em lote dos eventos avulsos (analytics/buffer.go)
+	BatchSize     int           // ANALYTICS_BATCH_SIZE: eventos por INSERT
+	FlushInterval time.Duration // ANALYTICS_FLUSH_INTERVAL_SECONDS: intervalo máximo entre gravações
+	QueueLimit    int           // ANALYTICS_QUEUE_LIMIT: eventos na fila antes de descartar
+}
+
+// Billing é a configuração das assinaturas pagas (Stripe)
+type Billing struct {
+	StripeSecretKey     string // STRIPE_SECRET_KEY (vazio = cobrança desabilitada)
+	StripeWebhookSecret string // STRIPE_WEBHOOK_SECRET
+	StripePriceID       string // STRIPE_PRICE_ID: preço mensal do plano premium
+	FreeMaxGuardians    int    // FREE_PLAN_MAX_GUARDIANS (0 = sem limite)
+}
+
+// Email é a configuração do envio de emails
+type Email struct {
+	Provider         string // EMAIL_PROVIDER
+	From             string // EMAIL_FROM
+	FromName         string // EMAIL_FROM_NAME
+	MailtrapAPIToken string // MAILTRAP_API_TOKEN
+	MailtrapSandbox  bool   // MAILTRAP_SANDBOX
+	MailtrapInboxID  string // MAILTRAP_INBOX_ID (obrigatório com sandbox)
+}
+
+// WhatsApp é a configuração da integração Twilio
+type WhatsApp struct {
+	AccountSid     string // TWILIO_ACCOUNT_SID (vazio = desabilitado)
+	AuthToken      string // TWILIO_AUTH_TOKEN
+	PhoneNumber    string // TWILIO_PHONE_NUMBER
+	SMSNumber      string // TWILIO_SMS_NUMBER
+	WebhookBaseURL string // WEBHOOK_BASE_URL
+	DigestHourUTC  int    // WHATSAPP_DIGEST_HOUR: hora (UTC) de envio dos resumos
+
+	// SessionTimeout é o prazo de uma conversa sem mensagens
+	// (WHATSAPP_SESSION_TIMEOUT_MINUTES); a pergunta pendente vira rascunho
+	SessionTimeout time.Duration
+}
+
+// Inbound é o gateway de emails recebidos (Mailgun)
+type Inbound struct {
+	Address           string // INBOUND_EMAIL_ADDRESS: endereço que recebe os itens
+	MailgunSigningKey string // MAILGUN_WEBHOOK_SIGNING_KEY (vazio = desabilitado)
+}
+
+// OAuth é a configuração do login social
+type OAuth struct {
+	GoogleClientID  string // GOOGLE_CLIENT_ID
+	AppleClientID   string // APPLE_CLIENT_ID
+	AppleTeamID     string // APPLE_TEAM_ID
+	AppleKeyID      string // APPLE_KEY_ID
+	ApplePrivateKey string // APPLE_PRIVATE_KEY
+}
+
+// Push é a configuração das notificações Web Push
+type Push struct {
+	VAPIDPublicKey  string // VAPID_PUBLIC_KEY
+	VAPIDPrivateKey string // VAPID_PRIVATE_KEY
+	Subject         string // VAPID_SUBJECT
+}
+
+// Backup é a configuração dos backups administrativos (POST /api/admin/backup)
+type Backup struct {
+	EncryptionKey     string // BACKUP_ENCRYPTION_KEY (vazio = backups desabilitados)
+	Dir               string // BACKUP_DIR: destino local (sem S3)
+	S3Bucket          string // BACKUP_S3_BUCKET (vazio = disco local)
+	S3Region          string // BACKUP_S3_REGION
+	S3Endpoint        string // BACKUP_S3_ENDPOINT: S3 compatível (vazio = AWS)
+	S3Prefix          string // BACKUP_S3_PREFIX
+	S3AccessKeyID     string // BACKUP_S3_ACCESS_KEY_ID
+	S3SecretAccessKey string // BACKUP_S3_SECRET_ACCESS_KEY
+}
+
+// Scan é a verificação de vírus dos anexos (ClamAV)
+type Scan struct {
+	ClamAVAddress string        // CLAMAV_ADDRESS: clamd (tcp://host:3310 ou unix:///caminho)
+	Timeout       time.Duration // CLAMAV_TIMEOUT_SECONDS
+	Disabled      bool          // ATTACHMENT_SCAN_DISABLED: apenas em desenvolvimento
+}
+
+// Captcha é o desafio anti-robô das rotas públicas dos links
+type Captcha struct {
+	Provider  string // CAPTCHA_PROVIDER: turnstile, hcaptcha ou vazio (desligado)
+	SiteKey   string // CAPTCHA_SITE_KEY: chave pública do widget
+	SecretKey string // CAPTCHA_SECRET_KEY: chave da API siteverify
+	VerifyURL string // CAPTCHA_VERIFY_URL: outra API siteverify (testes)
+}
+
+// Legal são as versões vigentes dos documentos legais
+// Mudar uma versão pede um novo aceite a todos os usuários.
+type Legal struct {
+	TermsVersion   string // LEGAL_TERMS_VERSION: Termos de Uso
+	PrivacyVersion string // LEGAL_PRIVACY_VERSION: Política de Privacidade
+}
+
+// Health configura as verificações de GET /api/admin/health
+type Health struct {
+	DiskPath      string // HEALTH_DISK_PATH: volume onde o Famli grava arquivos
+	DiskMinFreeMB int    // HEALTH_DISK_MIN_FREE_MB: abaixo disso o disco fica "low_space"
+}
+
+// IsDevelopment indica o ambiente de desenvolvimento (padrão)
+func (c *Config) IsDevelopment() bool {
+	return c.Env == "development"
+}
+
+// IsProduction indica o ambiente de produção
+func (c *Config) IsProduction() bool {
+	return c.Env == "production"
+}
+
+// WhatsAppEnabled indica se a integração com o Twilio está configurada
+func (c *Config) WhatsAppEnabled() bool {
+	return c.WhatsApp.AccountSid != ""
+}
+
+// InboundEmailEnabled indica se o gateway de email está configurado
+func (c *Config) InboundEmailEnabled() bool {
+	return c.Inbound.MailgunSigningKey != ""
+}
+
+// BillingEnabled indica se as assinaturas pagas estão configuradas
+func (c *Config) BillingEnabled() bool {
+	return c.Billing.StripeSecretKey != ""
+}
+
+// ValidationError lista os problemas encontrados na configuração
+type ValidationError struct {
+	Problems []string
+}
+
+// Error formata o relatório (um problema por linha)
+func (e *ValidationError) Error() string {
+	return "configuração inválida:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Load lê e valida a configuração a partir das variáveis de ambiente
+//
+// Retorna:
+//   - *Config: sempre preenchido (valores inválidos ficam com o padrão)
+//   - error: *ValidationError se houver problemas
+func Load() (*Config, error) {
+	return LoadFrom(os.Getenv)
+}
+
+// LoadFrom é o Load com outra fonte de variáveis (ex: testes de integração)
+func LoadFrom(getenv func(string) string) (*Config, error) {
+	r := &reader{getenv: getenv}
+
+	cfg := &Config{
+		Env:       strings.ToLower(r.str("ENV", "development")),
+		Port:      r.str("PORT", "8080"),
+		StaticDir: r.str("STATIC_DIR", filepath.Join("..", "frontend", "dist")),
+		AppURL:    strings.TrimRight(r.str("APP_BASE_URL", r.str("APP_URL", "http://localhost:5173")), "/"),
+
+		ExtraAllowedOrigins: r.list("EXTRA_ALLOWED_ORIGINS"),
+
+		JWTSecret:      r.str("JWT_SECRET", ""),
+		EncryptionKey:  r.str("ENCRYPTION_KEY", ""),
+		EncryptionSalt: r.str("ENCRYPTION_SALT", ""),
+
+		DatabaseURL: r.str("DATABASE_URL", ""),
+		RedisURL:    r.str("REDIS_URL", ""),
+		GeoIPDBPath: r.str("GEOIP_DB_PATH", ""),
+
+		LogRetentionDays:        r.int("LOG_RETENTION_DAYS", 30, 1),
+		LogCleanupIntervalHours: r.int("LOG_CLEANUP_INTERVAL_HOURS", 24, 0),
+
+		Auth: Auth{
+			LockoutThreshold: r.int("ACCOUNT_LOCKOUT_THRESHOLD", 5, 1),
+			LockoutDuration:  time.Duration(r.int("ACCOUNT_LOCKOUT_MINUTES", 15, 1)) * time.Minute,
+			AdminEmails:      r.list("ADMIN_EMAILS"),
+			TokenClients:     r.list("API_TOKEN_CLIENTS"),
+		},
+		Share: Share{
+			DefaultExpiresDays: r.int("SHARE_LINK_DEFAULT_EXPIRES_DAYS", 30, 0),
+			MaxExpiresDays:     r.int("SHARE_LINK_MAX_EXPIRES_DAYS", 365, 0),
+			DefaultMaxUses:     r.int("SHARE_LINK_DEFAULT_MAX_USES", 50, 0),
+			MaxUses:            r.int("SHARE_LINK_MAX_USES", 200, 0),
+			URLPrefix:          strings.TrimRight(r.str("SHARE_URL_PREFIX", ""), "/"),
+
+			GuardianDeletionGraceDays: r.int("GUARDIAN_DELETION_GRACE_DAYS", 30, 1),
+			PublicRateLimit:           r.int("SHARE_PUBLIC_RATE_LIMIT", 20, 1),
+			AbuseThreshold:            r.int("SHARE_ABUSE_THRESHOLD", 10, 1),
+		},
+		Quota: Quota{
+			MaxItems:     r.int("QUOTA_MAX_ITEMS", 1000, 0),
+			MaxContentMB: r.int("QUOTA_MAX_CONTENT_MB", 25, 0),
+		},
+		Assistant: Assistant{
+			UserHourlyRequests: r.int("ASSISTANT_USER_HOURLY_REQUESTS", 30, 1),
+			IPHourlyRequests:   r.int("ASSISTANT_IP_HOURLY_REQUESTS", 100, 1),
+			DailyTokens:        r.int("ASSISTANT_DAILY_TOKENS", 20000, 0),
+		},
+		Analytics: Analytics{
+			DailyEventLimit: r.int("ANALYTICS_DAILY_EVENT_LIMIT", 500, 0),
+			SamplePercent:   r.int("ANALYTICS_SAMPLE_PERCENT", 10, 0),
+			BatchSize:       r.int("ANALYTICS_BATCH_SIZE", 100, 1),
+			FlushInterval:   time.Duration(r.int("ANALYTICS_FLUSH_INTERVAL_SECONDS", 5, 1)) * time.Second,
+			QueueLimit:      r.int("ANALYTICS_QUEUE_LIMIT", 10000, 1),
+		},
+		Billing: Billing{
+			StripeSecretKey:     r.str("STRIPE_SECRET_KEY", ""),
+			StripeWebhookSecret: r.str("STRIPE_WEBHOOK_SECRET", ""),
+			StripePriceID:       r.str("STRIPE_PRICE_ID", ""),
+			FreeMaxGuardians:    r.int("FREE_PLAN_MAX_GUARDIANS", 3, 0),
+		},
+		Email: Email{
+			Provider:         strings.ToLower(r.str("EMAIL_PROVIDER", "mailtrap")),
+			From:             r.str("EMAIL_FROM", "noreply@famli.me"),
+			FromName:         r.str("EMAIL_FROM_NAME", "Famli"),
+			MailtrapAPIToken: r.str("MAILTRAP_API_TOKEN", ""),
+			MailtrapSandbox:  r.bool("MAILTRAP_SANDBOX"),
+			MailtrapInboxID:  r.str("MAILTRAP_INBOX_ID", ""),
+		},
+		WhatsApp: WhatsApp{
+			AccountSid:     r.str("TWILIO_ACCOUNT_SID", ""),
+			AuthToken:      r.str("TWILIO_AUTH_TOKEN", ""),
+			PhoneNumber:    r.str("TWILIO_PHONE_NUMBER", ""),
+			SMSNumber:      r.str("TWILIO_SMS_NUMBER", ""),
+			WebhookBaseURL: r.str("WEBHOOK_BASE_URL", "http://localhost:8080"),
+			DigestHourUTC:  r.int("WHATSAPP_DIGEST_HOUR", 12, 0),
+			SessionTimeout: time.Duration(r.int("WHATSAPP_SESSION_TIMEOUT_MINUTES", 30, 1)) * time.Minute,
+		},
+		Inbound: Inbound{
+			Address:           strings.ToLower(r.str("INBOUND_EMAIL_ADDRESS", "save@famli.net")),
+			MailgunSigningKey: r.str("MAILGUN_WEBHOOK_SIGNING_KEY", ""),
+		},
+		OAuth: OAuth{
+			GoogleClientID:  r.str("GOOGLE_CLIENT_ID", ""),
+			AppleClientID:   r.str("APPLE_CLIENT_ID", ""),
+			AppleTeamID:     r.str("APPLE_TEAM_ID", ""),
+			AppleKeyID:      r.str("APPLE_KEY_ID", ""),
+			ApplePrivateKey: r.str("APPLE_PRIVATE_KEY", ""),
+		},
+		Push: Push{
+			VAPIDPublicKey:  r.str("VAPID_PUBLIC_KEY", ""),
+			VAPIDPrivateKey: r.str("VAPID_PRIVATE_KEY", ""),
+			Subject:         r.str("VAPID_SUBJECT", ""),
+		},
+		Backup: Backup{
+			EncryptionKey:     r.str("BACKUP_ENCRYPTION_KEY", ""),
+			Dir:               r.str("BACKUP_DIR", "./backups"),
+			S3Bucket:          r.str("BACKUP_S3_BUCKET", ""),
+			S3Region:          r.str("BACKUP_S3_REGION", ""),
+			S3Endpoint:        r.str("BACKUP_S3_ENDPOINT", ""),
+			S3Prefix:          r.str("BACKUP_S3_PREFIX", ""),
+			S3AccessKeyID:     r.str("BACKUP_S3_ACCESS_KEY_ID", ""),
+			S3SecretAccessKey: r.str("BACKUP_S3_SECRET_ACCESS_KEY", ""),
+		},
+		Scan: Scan{
+			ClamAVAddress: r.str("CLAMAV_ADDRESS", ""),
+			Timeout:       time.Duration(r.int("CLAMAV_TIMEOUT_SECONDS", 30, 1)) * time.Second,
+			Disabled:      r.bool("ATTACHMENT_SCAN_DISABLED"),
+		},
+		Captcha: Captcha{
+			Provider:  strings.ToLower(r.str("CAPTCHA_PROVIDER", "")),
+			SiteKey:   r.str("CAPTCHA_SITE_KEY", ""),
+			SecretKey: r.str("CAPTCHA_SECRET_KEY", ""),
+			VerifyURL: r.str("CAPTCHA_VERIFY_URL", ""),
+		},
+		Legal: Legal{
+			TermsVersion:   r.str("LEGAL_TERMS_VERSION", "2025-12-22"),
+			PrivacyVersion: r.str("LEGAL_PRIVACY_VERSION", "2025-12-22"),
+		},
+		Health: Health{
+			DiskPath:      r.str("HEALTH_DISK_PATH", "."),
+			DiskMinFreeMB: r.int("HEALTH_DISK_MIN_FREE_MB", 1024, 0),
+		},
+	}
+
+	cfg.validate(r)
+	cfg.applyDefaults()
+
+	if len(r.problems) > 0 {
+		return cfg, &ValidationError{Problems: r.problems}
+	}
+	return cfg, nil
+}
+
+// validate confere valores obrigatórios e combinações inválidas
+func (c *Config) validate(r *reader) {
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		r.problem("PORT deve ser um número entre 1 e 65535 (recebido %q)", c.Port)
+	}
+
+	// Segredos: obrigatórios fora de desenvolvimento
+	if !c.IsDevelopment() {
+		switch {
+		case c.JWTSecret == "":
+			r.problem("JWT_SECRET é obrigatório fora de desenvolvimento")
+		case len(c.JWTSecret) < minSecretLength:
+			r.problem("JWT_SECRET deve ter pelo menos %d caracteres em produção", minSecretLength)
+		case c.JWTSecret == devJWTSecret:
+			r.problem("JWT_SECRET não pode usar o valor padrão de desenvolvimento")
+		}
+	}
+
+	for _, admin := range c.Auth.AdminEmails {
+		if !strings.Contains(admin, "@") {
+			r.problem("ADMIN_EMAILS contém um email inválido: %q", admin)
+		}
+	}
+	for _, client := range c.Auth.TokenClients {
+		if !clientIDPattern.MatchString(client) {
+			r.problem("API_TOKEN_CLIENTS contém um cliente inválido: %q (use letras minúsculas, números, '.', '_' ou '-')", client)
+		}
+	}
+
+	if c.Share.MaxExpiresDays > 0 && c.Share.DefaultExpiresDays > c.Share.MaxExpiresDays {
+		r.problem("SHARE_LINK_DEFAULT_EXPIRES_DAYS (%d) maior que SHARE_LINK_MAX_EXPIRES_DAYS (%d)", c.Share.DefaultExpiresDays, c.Share.MaxExpiresDays)
+	}
+	if c.Share.MaxUses > 0 && c.Share.DefaultMaxUses > c.Share.MaxUses {
+		r.problem("SHARE_LINK_DEFAULT_MAX_USES (%d) maior que SHARE_LINK_MAX_USES (%d)", c.Share.DefaultMaxUses, c.Share.MaxUses)
+	}
+
+	if c.Analytics.SamplePercent > 100 {
+		r.problem("ANALYTICS_SAMPLE_PERCENT deve estar entre 0 e 100 (recebido %d)", c.Analytics.SamplePercent)
+	}
+
+	if c.Email.Provider != "mailtrap" {
+		r.problem("EMAIL_PROVIDER não suportado: %q (use mailtrap)", c.Email.Provider)
+	}
+	if c.Email.MailtrapSandbox && c.Email.MailtrapInboxID == "" {
+		r.problem("MAILTRAP_INBOX_ID é obrigatório com MAILTRAP_SANDBOX=true")
+	}
+
+	// Integrações: se uma variável do grupo foi definida, todas são necessárias
+	if c.WhatsAppEnabled() {
+		r.requireAll("TWILIO_ACCOUNT_SID", map[string]string{
+			"TWILIO_AUTH_TOKEN":   c.WhatsApp.AuthToken,
+			"TWILIO_PHONE_NUMBER": c.WhatsApp.PhoneNumber,
+		})
+	}
+	if u, err := url.Parse(c.WhatsApp.WebhookBaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+		r.problem("WEBHOOK_BASE_URL deve ser uma URL absoluta (recebido %q)", c.WhatsApp.WebhookBaseURL)
+	}
+	if c.WhatsApp.DigestHourUTC > 23 {
+		r.problem("WHATSAPP_DIGEST_HOUR deve estar entre 0 e 23 (recebido %d)", c.WhatsApp.DigestHourUTC)
+	}
+	if c.InboundEmailEnabled() && !strings.Contains(c.Inbound.Address, "@") {
+		r.problem("INBOUND_EMAIL_ADDRESS deve ser um email (recebido %q)", c.Inbound.Address)
+	}
+	if c.BillingEnabled() {
+		r.requireAll("STRIPE_SECRET_KEY", map[string]string{
+			"STRIPE_WEBHOOK_SECRET": c.Billing.StripeWebhookSecret,
+			"STRIPE_PRICE_ID":       c.Billing.StripePriceID,
+		})
+	}
+	if u, err := url.Parse(c.AppURL); err != nil || u.Scheme == "" || u.Host == "" {
+		r.problem("APP_BASE_URL deve ser uma URL absoluta (recebido %q)", c.AppURL)
+	}
+	for _, origin := range c.ExtraAllowedOrigins {
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" || strings.TrimRight(u.Path, "/") != "" {
+			r.problem("EXTRA_ALLOWED_ORIGINS contém uma origem inválida: %q (use esquema://domínio[:porta])", origin)
+		}
+	}
+	if c.Share.URLPrefix != "" {
+		if u, err := url.Parse(c.Share.URLPrefix); err != nil || u.Scheme == "" || u.Host == "" {
+			r.problem("SHARE_URL_PREFIX deve ser uma URL absoluta (recebido %q)", c.Share.URLPrefix)
+		}
+	}
+	if c.OAuth.AppleClientID != "" {
+		r.requireAll("APPLE_CLIENT_ID", map[string]string{
+			"APPLE_TEAM_ID":     c.OAuth.AppleTeamID,
+			"APPLE_KEY_ID":      c.OAuth.AppleKeyID,
+			"APPLE_PRIVATE_KEY": c.OAuth.ApplePrivateKey,
+		})
+	}
+	if (c.Push.VAPIDPublicKey == "") != (c.Push.VAPIDPrivateKey == "") {
+		r.problem("VAPID_PUBLIC_KEY e VAPID_PRIVATE_KEY devem ser definidas juntas")
+	}
+
+	if c.Backup.EncryptionKey != "" && len(c.Backup.EncryptionKey) < minSecretLength {
+		r.problem("BACKUP_ENCRYPTION_KEY deve ter pelo menos %d caracteres", minSecretLength)
+	}
+	if c.Backup.S3Bucket != "" {
+		r.requireAll("BACKUP_S3_BUCKET", map[string]string{
+			"BACKUP_S3_REGION":            c.Backup.S3Region,
+			"BACKUP_S3_ACCESS_KEY_ID":     c.Backup.S3AccessKeyID,
+			"BACKUP_S3_SECRET_ACCESS_KEY": c.Backup.S3SecretAccessKey,
+		})
+	}
+	if c.Scan.Disabled && !c.IsDevelopment() {
+		r.problem("ATTACHMENT_SCAN_DISABLED só é permitido em desenvolvimento")
+	}
+	if addr := c.Scan.ClamAVAddress; addr != "" && !strings.HasPrefix(addr, "unix://") {
+		if _, _, err := net.SplitHostPort(strings.TrimPrefix(addr, "tcp://")); err != nil {
+			r.problem("CLAMAV_ADDRESS deve ser tcp://host:porta, host:porta ou unix:///caminho (recebido %q)", addr)
+		}
+	}
+	switch c.Captcha.Provider {
+	case "":
+	case "turnstile", "hcaptcha":
+		r.requireAll("CAPTCHA_PROVIDER", map[string]string{
+			"CAPTCHA_SITE_KEY":   c.Captcha.SiteKey,
+			"CAPTCHA_SECRET_KEY": c.Captcha.SecretKey,
+		})
+	default:
+		r.problem("CAPTCHA_PROVIDER não suportado: %q (use turnstile ou hcaptcha)", c.Captcha.Provider)
+	}
+	if c.Captcha.VerifyURL != "" {
+		if u, err := url.Parse(c.Captcha.VerifyURL); err != nil || u.Scheme == "" || u.Host == "" {
+			r.problem("CAPTCHA_VERIFY_URL deve ser uma URL absoluta (recebido %q)", c.Captcha.VerifyURL)
+		}
+	}
+	if len(c.Legal.TermsVersion) > maxLegalVersionLength {
+		r.problem("LEGAL_TERMS_VERSION deve ter no máximo %d caracteres", maxLegalVersionLength)
+	}
+	if len(c.Legal.PrivacyVersion) > maxLegalVersionLength {
+		r.problem("LEGAL_PRIVACY_VERSION deve ter no máximo %d caracteres", maxLegalVersionLength)
+	}
+	if c.Backup.S3Endpoint != "" {
+		if u, err := url.Parse(c.Backup.S3Endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+			r.problem("BACKUP_S3_ENDPOINT deve ser uma URL absoluta (recebido %q)", c.Backup.S3Endpoint)
+		}
+	}
+}
+
+// applyDefaults preenche os valores derivados de outros
+func (c *Config) applyDefaults() {
+	if c.JWTSecret == "" {
+		c.JWTSecret = devJWTSecret
+	}
+	if c.EncryptionKey == "" {
+		// Fallback para compatibilidade com dados já criptografados
+		c.EncryptionKey = c.JWTSecret
+	}
+	if c.EncryptionKey == devJWTSecret {
+		c.EncryptionKey = devEncryptionKey
+	}
+}
+
+// AllowedOrigins lista as origens aceitas pelo CORS e pela proteção CSRF:
+// a do AppURL, as de EXTRA_ALLOWED_ORIGINS e, em desenvolvimento, o Vite e
+// o próprio servidor em localhost
+func (c *Config) AllowedOrigins() []string {
+	var origins []string
+	if c.IsDevelopment() {
+		origins = append(origins, "http://localhost:5173", "http://localhost:8080")
+	}
+	if u, err := url.Parse(c.AppURL); err == nil && u.Host != "" {
+		origins = append(origins, u.Scheme+"://"+u.Host)
+	}
+	for _, origin := range c.ExtraAllowedOrigins {
+		origins = append(origins, strings.TrimRight(origin, "/"))
+	}
+	return origins
+}
+
+// Warnings lista configurações válidas mas não recomendadas em produção
+func (c *Config) Warnings() []string {
+	if c.IsDevelopment() {
+		return nil
+	}
+
+	var warnings []string
+	if c.DatabaseURL == "" {
+		warnings = append(warnings, "DATABASE_URL não definido: dados em memória serão perdidos ao reiniciar")
+	}
+	if c.Email.MailtrapAPIToken == "" {
+		warnings = append(warnings, "MAILTRAP_API_TOKEN não definido: emails não serão entregues")
+	}
+	if c.Push.VAPIDPublicKey == "" {
+		warnings = append(warnings, "VAPID_PUBLIC_KEY não definido: notificações push desabilitadas")
+	}
+	if c.Scan.ClamAVAddress == "" {
+		warnings = append(warnings, "CLAMAV_ADDRESS não definido: anexos não passarão por verificação de vírus")
+	}
+	if u, err := url.Parse(c.AppURL); err == nil && (u.Hostname() == "localhost" || u.Hostname() == "127.0.0.1") {
+		warnings = append(warnings, "APP_BASE_URL aponta para localhost: links em emails, compartilhamentos e WhatsApp não funcionarão fora desta máquina")
+	}
+	return warnings
+}
+
+// =============================================================================
+// LEITURA DAS VARIÁVEIS
+// =============================================================================
+
+// reader lê variáveis de ambiente e acumula os problemas encontrados
+type reader struct {
+	getenv   func(string) string
+	problems []string
+}
+
+// problem registra um problema de configuração
+func (r *reader) problem(format string, args ...interface{}) {
+	r.problems = append(r.problems, fmt.Sprintf(format, args...))
+}
+
+// str lê uma variável de texto (com fallback)
+func (r *reader) str(key, fallback string) string {
+	if value := strings.TrimSpace(r.getenv(key)); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// int lê uma variável inteira com valor mínimo
+// Valores inválidos são registrados e substituídos pelo fallback.
+func (r *reader) int(key string, fallback, min int) int {
+	raw := strings.TrimSpace(r.getenv(key))
+	if raw == "" {
+		return fallback
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < min {
+		r.problem("%s deve ser um número inteiro >= %d (recebido %q)", key, min, raw)
+		return fallback
+	}
+	return value
+}
+
+// bool lê uma variável booleana ("true"/"1"; vazio = false)
+func (r *reader) bool(key string) bool {
+	raw := strings.TrimSpace(r.getenv(key))
+	if raw == "" {
+		return false
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		r.problem("%s deve ser true ou false (recebido %q)", key, raw)
+		return false
+	}
+	return value
+}
+
+// list lê uma lista separada por vírgulas (minúsculas, sem itens vazios)
+func (r *reader) list(key string) []string {
+	var result []string
+	for _, value := range strings.Split(r.getenv(key), ",") {
+		if value = strings.ToLower(strings.TrimSpace(value)); value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// requireAll registra as variáveis vazias exigidas por outra
+func (r *reader) requireAll(trigger string, required map[string]string) {
+	var missing []string
+	for key, value := range required {
+		if value == "" {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing) // Relatório estável entre execuções
+		r.problem("%s definido sem %s", trigger, strings.Join(missing, ", "))
+	}
+}
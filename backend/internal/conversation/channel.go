@@ -0,0 +1,149 @@
+// =============================================================================
+// FAMLI - Motor de Conversas
+// =============================================================================
+// Este pacote contém os fluxos de conversa (guardar item, escolher categoria,
+// confirmar, comandos) independentes do canal de mensagens.
+//
+// Cada canal (WhatsApp, Telegram, SMS, chat web) só precisa:
+// 1. Converter a mensagem recebida para Message
+// 2. Implementar Channel (envio de texto e download de mídia)
+// 3. Chamar Engine.Handle e entregar a resposta ao usuário
+//
+// Novos comandos e fluxos são implementados uma única vez, aqui.
+// =============================================================================
+
+package conversation
+
+import "time"
+
+// =============================================================================
+// CANAL
+// =============================================================================
+
+// Channel é o transporte de mensagens usado pelo motor de conversas
+type Channel interface {
+	// Name é o nome do canal exibido ao usuário (ex: "WhatsApp")
+	Name() string
+
+	// Send envia uma mensagem de texto para um endereço do canal
+	Send(to, body string) error
+
+	// FetchMedia baixa uma mídia recebida, retornando o conteúdo e o tipo MIME
+	FetchMedia(mediaURL string) ([]byte, string, error)
+}
+
+// InteractiveChannel é implementado por canais com botões de resposta
+// rápida e listas de opções (ex: WhatsApp). Nos demais canais, ou se o
+// envio falhar, o motor usa o texto numerado de Prompt.Text.
+type InteractiveChannel interface {
+	Channel
+
+	// SendPrompt envia uma pergunta com opções clicáveis
+	SendPrompt(to string, prompt Prompt) error
+}
+
+// AddressResolver é implementado por canais que guardam os próprios vínculos
+// entre endereço e conta (ex: email, ver internal/inbound). Nos demais, o
+// endereço é um telefone vinculado (storage.PhoneLink).
+type AddressResolver interface {
+	// UserForAddress retorna o usuário vinculado ao endereço ("" se nenhum)
+	UserForAddress(address string) string
+}
+
+// =============================================================================
+// PERGUNTAS COM OPÇÕES
+// =============================================================================
+
+// PromptStyle define como as opções de uma pergunta são exibidas
+type PromptStyle string
+
+const (
+	// PromptButtons exibe as opções como botões de resposta rápida (até 3)
+	PromptButtons PromptStyle = "buttons"
+
+	// PromptList exibe as opções em uma lista aberta por um botão (até 10)
+	PromptList PromptStyle = "list"
+)
+
+// Option é uma opção de resposta; ao ser escolhida, o canal devolve o ID
+// em Message.OptionID
+type Option struct {
+	// ID é o valor recebido de volta (ex: "2" para a segunda categoria)
+	ID string
+
+	// Title é o texto do botão ou item da lista
+	Title string
+}
+
+// Prompt é uma pergunta com opções clicáveis
+type Prompt struct {
+	// Style é o formato das opções (botões ou lista)
+	Style PromptStyle
+
+	// Body é o texto da pergunta exibido junto das opções
+	Body string
+
+	// Button é o rótulo do botão que abre a lista (apenas PromptList)
+	Button string
+
+	// Options são as opções oferecidas
+	Options []Option
+
+	// Text é a mesma pergunta com as opções numeradas, para canais sem suporte
+	Text string
+
+	// Locale é o idioma da pergunta (usado por canais que registram modelos)
+	Locale string
+}
+
+// =============================================================================
+// MENSAGEM RECEBIDA
+// =============================================================================
+
+// MessageKind define os tipos de mensagem que o motor sabe processar
+type MessageKind string
+
+const (
+	// KindText representa uma mensagem de texto simples
+	KindText MessageKind = "text"
+
+	// KindImage representa uma imagem enviada pelo usuário
+	KindImage MessageKind = "image"
+
+	// KindAudio representa um áudio/mensagem de voz
+	KindAudio MessageKind = "audio"
+
+	// KindDocument representa um documento (PDF, etc.)
+	KindDocument MessageKind = "document"
+
+	// KindLocation representa uma localização compartilhada
+	KindLocation MessageKind = "location"
+)
+
+// Message é uma mensagem recebida, já normalizada pelo canal
+type Message struct {
+	// Address identifica o remetente no canal (telefone, chat ID, etc.)
+	Address string
+
+	// Kind é o tipo da mensagem
+	Kind MessageKind
+
+	// Text é o conteúdo textual (ou legenda da mídia)
+	Text string
+
+	// OptionID é o ID da opção escolhida em um botão ou lista (ver Prompt)
+	OptionID string
+
+	// MediaURL é a URL da mídia anexada (se houver)
+	MediaURL string
+
+	// MediaType é o tipo MIME da mídia
+	MediaType string
+
+	// Latitude e Longitude são preenchidas em mensagens de localização
+	Latitude  string
+	Longitude string
+
+	// ReceivedAt é quando a mensagem foi recebida
+	ReceivedAt time.Time
+}
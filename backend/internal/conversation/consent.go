@@ -0,0 +1,54 @@
+// =============================================================================
+// FAMLI - Motor de Conversas: Consentimento para Mensagens
+// =============================================================================
+// Pela política do WhatsApp Business, quem pede para parar deixa de receber
+// mensagens nossas. "STOP" (ou "descadastrar", "baja", "unsubscribe") grava
+// o pedido nas configurações do usuário; avisos e resumos deixam de sair
+// (ver whatsapp.Service.NotifyUser). "START" (ou "reativar", "reactivar",
+// "alta", "subscribe") volta a permitir.
+//
+// As respostas às mensagens que o próprio usuário envia continuam
+// funcionando: o pedido vale apenas para o que sai por iniciativa do Famli.
+// =============================================================================
+
+package conversation
+
+import (
+	"log"
+	"time"
+)
+
+// optOutWords pedem para não receber mais mensagens
+var optOutWords = map[string]bool{
+	"stop": true, "stopall": true, "unsubscribe": true, "descadastrar": true, "baja": true,
+}
+
+// optInWords voltam a permitir as mensagens
+var optInWords = map[string]bool{
+	"start": true, "unstop": true, "subscribe": true, "reativar": true, "reactivar": true, "alta": true,
+}
+
+// handleConsentCommand registra o pedido de parar (ou voltar a receber) mensagens
+func (e *Engine) handleConsentCommand(session *Session, optOut bool) (string, error) {
+	// Parar também encerra o que estava em andamento
+	if optOut {
+		session.PendingItem = nil
+		session.State = StateIdle
+		e.saveSession(session)
+	}
+
+	if session.UserID == "" {
+		return e.t(session, "bot.consent_unlinked", nil), nil
+	}
+
+	if err := e.store.UpdateMessagingConsent(session.UserID, optOut, time.Now().UTC()); err != nil {
+		log.Printf("[Conversa] Erro ao registrar consentimento: %v", err)
+		return e.t(session, "bot.try_again", nil), nil
+	}
+	log.Printf("[Conversa] Usuário %s: opt-out=%t (canal=%s)", session.UserID, optOut, e.channel.Name())
+
+	if optOut {
+		return e.t(session, "bot.opted_out", nil), nil
+	}
+	return e.t(session, "bot.opted_in", nil), nil
+}
@@ -0,0 +1,78 @@
+// =============================================================================
+// FAMLI - Motor de Conversas: Resumo da Caixa
+// =============================================================================
+// "resumo" liga o resumo semanal (ver internal/digest); "resumo diário" muda
+// para diário e "parar resumo" desliga. Aceita as mesmas palavras em inglês
+// ("digest", "daily digest", "stop digest") e espanhol ("resumen",
+// "cancelar resumen").
+// =============================================================================
+
+package conversation
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"famli/internal/storage"
+)
+
+// digestWords identificam o comando de resumo
+var digestWords = map[string]bool{
+	"resumo": true, "resumen": true, "digest": true,
+}
+
+// digestFrequencyWords são os argumentos aceitos junto do comando
+var digestFrequencyWords = map[string]storage.DigestFrequency{
+	"parar": storage.DigestOff, "sair": storage.DigestOff, "cancelar": storage.DigestOff,
+	"desligar": storage.DigestOff, "stop": storage.DigestOff, "off": storage.DigestOff,
+	"unsubscribe": storage.DigestOff, "detener": storage.DigestOff,
+	"diario": storage.DigestDaily, "daily": storage.DigestDaily,
+	"semanal": storage.DigestWeekly, "weekly": storage.DigestWeekly,
+}
+
+// isDigestCommand reconhece "resumo" com até um argumento, em qualquer ordem
+func isDigestCommand(text string) bool {
+	words := strings.Fields(text)
+	if len(words) == 0 || len(words) > 2 {
+		return false
+	}
+	for _, word := range words {
+		if digestWords[word] {
+			return true
+		}
+	}
+	return false
+}
+
+// digestFrequency extrai a frequência pedida (semanal quando não informada)
+func digestFrequency(text string) storage.DigestFrequency {
+	for _, word := range strings.Fields(fold(strings.TrimPrefix(strings.TrimSpace(text), "/"))) {
+		if f, ok := digestFrequencyWords[word]; ok {
+			return f
+		}
+	}
+	return storage.DigestWeekly
+}
+
+// handleDigestCommand liga, muda ou desliga o resumo do usuário vinculado
+func (e *Engine) handleDigestCommand(session *Session, text string) (string, error) {
+	if session.UserID == "" {
+		return e.t(session, "bot.digest_unlinked", nil), nil
+	}
+
+	frequency := digestFrequency(text)
+	if err := e.store.UpdateDigestFrequency(session.UserID, frequency, time.Now()); err != nil {
+		log.Printf("[Conversa] Erro ao atualizar resumo: %v", err)
+		return e.t(session, "bot.try_again", nil), nil
+	}
+
+	switch frequency {
+	case storage.DigestOff:
+		return e.t(session, "bot.digest_off", nil), nil
+	case storage.DigestDaily:
+		return e.t(session, "bot.digest_daily_on", nil), nil
+	default:
+		return e.t(session, "bot.digest_weekly_on", nil), nil
+	}
+}
@@ -0,0 +1,731 @@
+// =============================================================================
+// FAMLI - Motor de Conversas: Fluxos
+// =============================================================================
+// Interpreta o que o usuário enviou e toma a ação apropriada.
+//
+// Fluxo principal:
+// 1. O canal converte a mensagem recebida para Message
+// 2. Identificamos o usuário pela sessão ou endereço
+// 3. Processamos baseado no tipo de mensagem e estado atual
+// 4. Salvamos na Caixa Famli se necessário
+// 5. Retornamos a resposta para o canal entregar
+// =============================================================================
+
+package conversation
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"famli/internal/i18n"
+	"famli/internal/quota"
+	"famli/internal/storage"
+)
+
+// =============================================================================
+// MOTOR
+// =============================================================================
+
+// Engine executa os fluxos de conversa de um canal
+type Engine struct {
+	// store é o armazenamento de dados do Famli
+	store storage.Store
+
+	// channel é o transporte usado para enviar mensagens e baixar mídias
+	channel Channel
+
+	// quota verifica os limites de armazenamento (nil = sem limites)
+	quota *quota.Checker
+
+	// site é o endereço do frontend citado nas respostas, sem o esquema
+	// (ex: famli.me), preenchido em {site} nas mensagens
+	site string
+
+	// sessions armazena as sessões ativas dos usuários
+	// Chave: endereço no canal (ex: +5511999999999)
+	sessions map[string]*Session
+
+	// linkCodes guarda os códigos de vinculação pendentes (ver link.go)
+	// Chave: código de 6 dígitos
+	linkCodes map[string]*linkCode
+
+	// sessionTimeout é o prazo de uma sessão sem mensagens (ver timeout.go)
+	sessionTimeout time.Duration
+
+	// mu protege o acesso concorrente aos maps
+	mu sync.RWMutex
+}
+
+// NewEngine cria um motor de conversas para um canal
+// appURL é o endereço público do frontend (APP_BASE_URL).
+func NewEngine(store storage.Store, channel Channel, quotaChecker *quota.Checker, appURL string) *Engine {
+	return &Engine{
+		store:          store,
+		channel:        channel,
+		quota:          quotaChecker,
+		site:           siteName(appURL),
+		sessions:       make(map[string]*Session),
+		linkCodes:      make(map[string]*linkCode),
+		sessionTimeout: DefaultSessionTimeout,
+	}
+}
+
+// Channel retorna o canal usado pelo motor
+func (e *Engine) Channel() Channel {
+	return e.channel
+}
+
+// =============================================================================
+// PROCESSAMENTO DE MENSAGENS
+// =============================================================================
+
+// Handle é o ponto de entrada para processar uma mensagem recebida
+//
+// Retorna a resposta a ser entregue ao usuário. Canais com resposta
+// síncrona (ex: TwiML) devolvem o texto no próprio webhook; os demais
+// usam Channel.Send. A resposta é vazia quando já foi enviada pelo canal
+// (perguntas com botões ou lista, ver ask).
+func (e *Engine) Handle(msg *Message) (string, error) {
+	log.Printf("[Conversa] Mensagem recebida: canal=%s, tipo=%s", e.channel.Name(), msg.Kind)
+
+	// Obter ou criar sessão do usuário
+	session := e.getOrCreateSession(msg.Address)
+	session.LastMessageAt = time.Now()
+
+	// Idioma das respostas (o usuário pode ter trocado no perfil)
+	session.Locale = e.localeFor(session.UserID, session.Address)
+
+	// Toque em botão ou item de lista: vale o ID da opção, não o rótulo
+	if msg.OptionID != "" {
+		msg.Text = msg.OptionID
+	}
+
+	// Verificar se é um comando especial
+	if cmd := e.parseCommand(msg.Text); cmd != "" {
+		return e.handleCommand(session, cmd, msg)
+	}
+
+	// Processar baseado no tipo de mensagem
+	switch msg.Kind {
+	case KindText:
+		return e.processTextMessage(session, msg)
+
+	case KindImage:
+		return e.processImageMessage(session, msg)
+
+	case KindAudio:
+		return e.processAudioMessage(session, msg)
+
+	case KindDocument:
+		return e.processDocumentMessage(session, msg)
+
+	case KindLocation:
+		return e.processLocationMessage(session, msg)
+
+	default:
+		return e.getHelpMessage(session), nil
+	}
+}
+
+// =============================================================================
+// PROCESSAMENTO POR TIPO
+// =============================================================================
+
+// processTextMessage processa mensagens de texto
+// Pode ser uma nota, memória ou informação a ser guardada
+func (e *Engine) processTextMessage(session *Session, msg *Message) (string, error) {
+	text := strings.TrimSpace(msg.Text)
+
+	// Se não está vinculado, pedir para vincular
+	if session.UserID == "" {
+		return e.handleUnlinkedUser(session, text)
+	}
+
+	// Verificar estado da sessão
+	switch session.State {
+	case StateAwaitingCategory:
+		return e.handleCategorySelection(session, text)
+
+	case StateAwaitingConfirmation:
+		return e.handleConfirmation(session, text)
+
+	case StateAwaitingResultChoice:
+		return e.handleResultChoice(session, text)
+
+	default:
+		// Estado idle - interpretar como novo item
+		return e.startNewItem(session, text, "text")
+	}
+}
+
+// processImageMessage processa imagens enviadas
+// Salva como uma memória visual ou documento
+func (e *Engine) processImageMessage(session *Session, msg *Message) (string, error) {
+	if session.UserID == "" {
+		return e.t(session, "bot.unlinked_image", nil), nil
+	}
+
+	// Criar item com a imagem
+	caption := msg.Text
+	if caption == "" {
+		caption = e.t(session, "bot.image_caption", i18n.Vars{"channel": e.channel.Name()})
+	}
+
+	// Iniciar processo de salvamento
+	session.PendingItem = &PendingItem{
+		Content:   caption,
+		Type:      "memory",
+		MediaURL:  msg.MediaURL,
+		MediaType: msg.MediaType,
+		Title:     e.generateTitle(session, caption),
+	}
+	session.State = StateAwaitingCategory
+	e.saveSession(session)
+
+	return e.askCategory(session, "bot.image_received", i18n.Vars{"caption": truncate(caption, 100)})
+}
+
+// processAudioMessage processa mensagens de voz
+// No futuro, pode transcrever o áudio automaticamente
+func (e *Engine) processAudioMessage(session *Session, msg *Message) (string, error) {
+	if session.UserID == "" {
+		return e.t(session, "bot.unlinked_audio", nil), nil
+	}
+
+	// Por enquanto, salvar como nota de áudio
+	// TODO: Implementar transcrição com Whisper/similar
+	session.PendingItem = &PendingItem{
+		Content:   e.t(session, "bot.audio_content", i18n.Vars{"channel": e.channel.Name()}),
+		Type:      "note",
+		MediaURL:  msg.MediaURL,
+		MediaType: "audio",
+		Title:     e.t(session, "bot.audio_title", i18n.Vars{"date": e.formatDate(session, time.Now())}),
+	}
+	session.State = StateAwaitingCategory
+	e.saveSession(session)
+
+	return e.askCategory(session, "bot.audio_received", nil)
+}
+
+// processDocumentMessage processa documentos (PDFs, etc.)
+func (e *Engine) processDocumentMessage(session *Session, msg *Message) (string, error) {
+	if session.UserID == "" {
+		return e.t(session, "bot.unlinked_document", nil), nil
+	}
+
+	caption := msg.Text
+	if caption == "" {
+		caption = e.t(session, "bot.document_caption", i18n.Vars{"channel": e.channel.Name()})
+	}
+
+	session.PendingItem = &PendingItem{
+		Content:   caption,
+		Type:      "info",
+		MediaURL:  msg.MediaURL,
+		MediaType: "document",
+		Title:     e.generateTitle(session, caption),
+	}
+	session.State = StateAwaitingCategory
+	e.saveSession(session)
+
+	return e.askCategory(session, "bot.document_received", nil)
+}
+
+// processLocationMessage processa localizações compartilhadas
+func (e *Engine) processLocationMessage(session *Session, msg *Message) (string, error) {
+	if session.UserID == "" {
+		return e.t(session, "bot.unlinked_location", nil), nil
+	}
+
+	// Criar conteúdo com coordenadas
+	coords := i18n.Vars{"lat": msg.Latitude, "lng": msg.Longitude}
+	title := e.t(session, "bot.location_title", nil)
+
+	session.PendingItem = &PendingItem{
+		Content:  e.t(session, "bot.location_content", coords),
+		Type:     "location",
+		Title:    title,
+		Category: i18n.T(session.Locale, "category.default.family"),
+	}
+	session.State = StateAwaitingConfirmation
+	e.saveSession(session)
+
+	return e.askConfirmation(session, "bot.location_received", i18n.Vars{
+		"lat":   msg.Latitude,
+		"lng":   msg.Longitude,
+		"title": title,
+	})
+}
+
+// =============================================================================
+// FLUXO DE CRIAÇÃO DE ITEM
+// =============================================================================
+
+// startNewItem inicia o processo de criar um novo item na Caixa Famli
+func (e *Engine) startNewItem(session *Session, content string, contentType string) (string, error) {
+	// Detectar automaticamente o tipo de item baseado no conteúdo
+	itemType := detectItemType(content)
+	title := e.generateTitle(session, content)
+
+	session.PendingItem = &PendingItem{
+		Content: content,
+		Type:    itemType,
+		Title:   title,
+	}
+	session.State = StateAwaitingCategory
+	e.saveSession(session)
+
+	return e.askCategory(session, "bot.new_item", i18n.Vars{"content": truncate(content, 200)})
+}
+
+// handleCategorySelection processa a seleção de categoria pelo usuário
+func (e *Engine) handleCategorySelection(session *Session, input string) (string, error) {
+	option := matchCategory(input)
+	category := i18n.T(session.Locale, option.key)
+
+	if session.PendingItem == nil {
+		session.State = StateIdle
+		e.saveSession(session)
+		return e.t(session, "bot.session_lost", nil), nil
+	}
+
+	session.PendingItem.Category = category
+	if itemType, ok := categoryItemTypes[option.key]; ok {
+		session.PendingItem.Type = string(itemType)
+	}
+	session.State = StateAwaitingConfirmation
+	e.saveSession(session)
+
+	return e.askConfirmation(session, "bot.confirm_item", i18n.Vars{
+		"title":    session.PendingItem.Title,
+		"category": category,
+		"content":  truncate(session.PendingItem.Content, 150),
+	})
+}
+
+// handleConfirmation processa a confirmação ou alteração do item
+func (e *Engine) handleConfirmation(session *Session, input string) (string, error) {
+	answer := fold(input)
+
+	if session.PendingItem == nil {
+		session.State = StateIdle
+		e.saveSession(session)
+		return e.t(session, "bot.session_lost", nil), nil
+	}
+
+	switch answer {
+	case "sim", "s", "si", "yes", "y", "confirmar", "confirm", "ok":
+		// Salvar o item na Caixa Famli
+		return e.saveItemToBox(session)
+
+	case "nao", "n", "no", "cancelar", "cancel":
+		session.PendingItem = nil
+		session.State = StateIdle
+		e.saveSession(session)
+		return e.t(session, "bot.item_cancelled", nil), nil
+
+	default:
+		// Usuário digitou um novo título
+		session.PendingItem.Title = input
+		return e.askConfirmation(session, "bot.title_updated", i18n.Vars{
+			"title":    session.PendingItem.Title,
+			"category": session.PendingItem.Category,
+		})
+	}
+}
+
+// saveItemToBox salva o item pendente na Caixa Famli
+func (e *Engine) saveItemToBox(session *Session) (string, error) {
+	if session.PendingItem == nil || session.UserID == "" {
+		return e.t(session, "bot.try_again", nil), nil
+	}
+
+	// Criar o item no storage
+	item := &storage.BoxItem{
+		Type:        storage.ItemType(session.PendingItem.Type),
+		Title:       session.PendingItem.Title,
+		Content:     session.PendingItem.Content,
+		Category:    session.PendingItem.Category,
+		IsImportant: false,
+	}
+
+	// Se tem mídia, adicionar à descrição
+	if session.PendingItem.MediaURL != "" {
+		item.Content = e.t(session, "bot.media_note", i18n.Vars{
+			"content": item.Content,
+			"url":     session.PendingItem.MediaURL,
+		})
+	}
+
+	// Cota do usuário (o item continua pendente para nova tentativa)
+	if err := e.quota.CheckCreate(session.UserID, item); err != nil {
+		var exceeded *quota.ExceededError
+		if errors.As(err, &exceeded) {
+			return e.t(session, "bot.quota_exceeded", nil), nil
+		}
+		log.Printf("[Conversa] Erro ao verificar cota: %v", err)
+		return e.t(session, "bot.save_error", nil), nil
+	}
+
+	// Salvar no store
+	created, err := e.store.CreateBoxItem(session.UserID, item)
+	if err != nil {
+		log.Printf("[Conversa] Erro ao salvar item: %v", err)
+		return e.t(session, "bot.save_error", nil), nil
+	}
+
+	// Limpar sessão
+	session.PendingItem = nil
+	session.State = StateIdle
+	e.saveSession(session)
+
+	return e.t(session, "bot.saved", i18n.Vars{
+		"title":    created.Title,
+		"category": created.Category,
+	}), nil
+}
+
+// =============================================================================
+// COMANDOS
+// =============================================================================
+
+// parseCommand verifica se a mensagem é um comando conhecido
+// Os comandos são aceitos em português, inglês e espanhol, com ou sem acento.
+func (e *Engine) parseCommand(text string) Command {
+	textLower := fold(text)
+
+	// Comandos podem começar com / ou não
+	textLower = strings.TrimPrefix(textLower, "/")
+
+	// Resumo: "resumo", "resumo diário", "parar resumo" (até duas palavras)
+	if isDigestCommand(textLower) {
+		return CommandDigest
+	}
+
+	// Consentimento: "STOP" / "START" (ver consent.go)
+	if optOutWords[textLower] {
+		return CommandOptOut
+	}
+	if optInWords[textLower] {
+		return CommandOptIn
+	}
+
+	// Rascunho de um item abandonado: "continuar" (ver timeout.go)
+	if continueWords[textLower] {
+		return CommandContinue
+	}
+
+	// Busca: "buscar banco" (comando seguido do que procurar)
+	if word, _, _ := strings.Cut(textLower, " "); searchCommands[word] {
+		return CommandSearch
+	}
+
+	switch textLower {
+	case "ajuda", "help", "ayuda", "?", "oi", "ola", "hola", "hi", "hello", "menu":
+		return CommandHelp
+	case "guardar", "salvar", "save":
+		return CommandSave
+	case "listar", "ver", "list", "lista":
+		return CommandList
+	case "cancelar", "cancel", "parar", "sair", "salir":
+		return CommandCancel
+	case "status", "conta", "estado", "cuenta", "account":
+		return CommandStatus
+	case "vincular", "conectar", "link", "login", "connect":
+		return CommandLink
+	default:
+		return ""
+	}
+}
+
+// searchCommands são as palavras que iniciam uma busca
+var searchCommands = map[string]bool{
+	"buscar": true, "busca": true, "procurar": true, "search": true, "find": true,
+}
+
+// commandArgument retorna o texto depois do comando ("buscar banco" → "banco")
+func commandArgument(text string) string {
+	_, arg, _ := strings.Cut(strings.TrimSpace(text), " ")
+	return strings.TrimSpace(arg)
+}
+
+// handleCommand processa comandos especiais
+func (e *Engine) handleCommand(session *Session, cmd Command, msg *Message) (string, error) {
+	switch cmd {
+	case CommandHelp:
+		return e.getHelpMessage(session), nil
+
+	case CommandSave:
+		return e.t(session, "bot.save_mode", nil), nil
+
+	case CommandList:
+		return e.handleListCommand(session)
+
+	case CommandCancel:
+		session.PendingItem = nil
+		session.State = StateIdle
+		e.saveSession(session)
+		e.discardDraft(session)
+		return e.t(session, "bot.operation_cancelled", nil), nil
+
+	case CommandStatus:
+		return e.handleStatusCommand(session)
+
+	case CommandLink:
+		return e.handleLinkCommand(session)
+
+	case CommandSearch:
+		return e.handleSearchCommand(session, commandArgument(msg.Text))
+
+	case CommandDigest:
+		return e.handleDigestCommand(session, msg.Text)
+
+	case CommandContinue:
+		return e.handleContinueCommand(session)
+
+	case CommandOptOut:
+		return e.handleConsentCommand(session, true)
+
+	case CommandOptIn:
+		return e.handleConsentCommand(session, false)
+
+	default:
+		return e.getHelpMessage(session), nil
+	}
+}
+
+// handleListCommand lista os últimos itens salvos pelo usuário
+func (e *Engine) handleListCommand(session *Session) (string, error) {
+	if session.UserID == "" {
+		return e.t(session, "bot.list_unlinked", nil), nil
+	}
+
+	items, err := e.store.GetBoxItems(session.UserID)
+	if err != nil || len(items) == 0 {
+		return e.t(session, "bot.list_empty", nil), nil
+	}
+
+	// Mostrar os últimos 5 itens
+	response := e.t(session, "bot.list_header", nil) + "\n\n"
+	limit := 5
+	if len(items) < limit {
+		limit = len(items)
+	}
+
+	for i := 0; i < limit; i++ {
+		item := items[i]
+		emoji := getCategoryEmoji(item.Category)
+		response += fmt.Sprintf("%s *%s*\n   _%s_\n\n", emoji, item.Title, truncate(e.itemText(session, item), 50))
+	}
+
+	response += i18n.Plural(session.Locale, "bot.list_footer", len(items), i18n.Vars{"site": e.site})
+	return response, nil
+}
+
+// handleStatusCommand mostra o status da conta
+func (e *Engine) handleStatusCommand(session *Session) (string, error) {
+	if session.UserID == "" {
+		return e.t(session, "bot.status_unlinked", i18n.Vars{"channel": e.channel.Name()}), nil
+	}
+
+	// Contar itens do usuário
+	items, _ := e.store.GetBoxItems(session.UserID)
+	itemCount := len(items)
+
+	return e.t(session, "bot.status_linked", i18n.Vars{
+		"count": itemCount,
+		"date":  e.formatDate(session, session.LastMessageAt),
+	}), nil
+}
+
+// handleUnlinkedUser trata mensagens de usuários não vinculados
+func (e *Engine) handleUnlinkedUser(session *Session, text string) (string, error) {
+	return e.t(session, "bot.unlinked", i18n.Vars{
+		"content": truncate(text, 100),
+		"channel": e.channel.Name(),
+	}), nil
+}
+
+// =============================================================================
+// MENSAGENS PADRÃO
+// =============================================================================
+
+// getHelpMessage retorna a mensagem de ajuda
+func (e *Engine) getHelpMessage(session *Session) string {
+	return e.t(session, "bot.help", i18n.Vars{"channel": e.channel.Name()})
+}
+
+// =============================================================================
+// PERGUNTAS COM OPÇÕES
+// =============================================================================
+
+// askCategory pergunta a categoria do item pendente (lista de categorias)
+// A mensagem key deve terminar com o placeholder {menu}.
+func (e *Engine) askCategory(session *Session, key string, vars i18n.Vars) (string, error) {
+	options := make([]Option, len(categoryOptions))
+	for i, opt := range categoryOptions {
+		options[i] = Option{ID: strconv.Itoa(i + 1), Title: capitalize(i18n.T(session.Locale, opt.key))}
+	}
+
+	return e.ask(session, Prompt{
+		Style:   PromptList,
+		Body:    e.t(session, key, withVar(vars, "menu", "")),
+		Button:  e.t(session, "bot.category_button", nil),
+		Options: options,
+		Text:    e.t(session, key, withVar(vars, "menu", e.categoryMenu(session))),
+	})
+}
+
+// askConfirmation pede para salvar ou cancelar o item pendente (botões sim/não)
+// A mensagem key deve terminar com o placeholder {actions}.
+func (e *Engine) askConfirmation(session *Session, key string, vars i18n.Vars) (string, error) {
+	return e.ask(session, Prompt{
+		Style: PromptButtons,
+		Body:  e.t(session, key, withVar(vars, "actions", e.t(session, "bot.confirm_actions_buttons", nil))),
+		Options: []Option{
+			{ID: "yes", Title: e.t(session, "bot.button_yes", nil)},
+			{ID: "no", Title: e.t(session, "bot.button_no", nil)},
+		},
+		Text: e.t(session, key, withVar(vars, "actions", e.t(session, "bot.confirm_actions", nil))),
+	})
+}
+
+// ask envia a pergunta com opções clicáveis, se o canal suportar
+// Caso contrário (ou se o envio falhar), responde com o texto numerado.
+func (e *Engine) ask(session *Session, prompt Prompt) (string, error) {
+	prompt.Body = strings.TrimSpace(prompt.Body)
+	prompt.Locale = session.Locale
+
+	if interactive, ok := e.channel.(InteractiveChannel); ok {
+		err := interactive.SendPrompt(session.Address, prompt)
+		if err == nil {
+			return "", nil
+		}
+		log.Printf("[Conversa] Opções indisponíveis, respondendo com texto: %v", err)
+	}
+	return prompt.Text, nil
+}
+
+// withVar retorna uma cópia de vars com mais um valor
+func withVar(vars i18n.Vars, key string, value interface{}) i18n.Vars {
+	out := i18n.Vars{key: value}
+	for k, v := range vars {
+		out[k] = v
+	}
+	return out
+}
+
+// =============================================================================
+// GERENCIAMENTO DE SESSÕES
+// =============================================================================
+
+// getOrCreateSession obtém ou cria uma sessão para o endereço
+func (e *Engine) getOrCreateSession(address string) *Session {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if session, ok := e.sessions[address]; ok {
+		return session
+	}
+
+	// Criar nova sessão
+	session := &Session{
+		Address:   address,
+		State:     StateIdle,
+		CreatedAt: time.Now(),
+	}
+
+	// Verificar se o endereço já está vinculado a um usuário
+	session.UserID = e.userForAddress(address)
+
+	e.sessions[address] = session
+	return session
+}
+
+// saveSession salva a sessão atualizada
+func (e *Engine) saveSession(session *Session) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.sessions[session.Address] = session
+}
+
+// =============================================================================
+// FUNÇÕES AUXILIARES
+// =============================================================================
+
+// truncate trunca uma string para o tamanho máximo (em caracteres)
+// Conta runas para não cortar acentos e emojis ao meio.
+func truncate(s string, maxLen int) string {
+	runes := []rune(s)
+	if len(runes) <= maxLen {
+		return s
+	}
+	return string(runes[:maxLen-3]) + "..."
+}
+
+// generateTitle gera o título do item; sem texto, usa "Item sem título"
+func (e *Engine) generateTitle(session *Session, content string) string {
+	if title := generateTitleFromContent(content, 50); title != "" {
+		return title
+	}
+	return e.t(session, "bot.untitled", nil)
+}
+
+// formatDate formata data e hora no padrão do idioma da sessão
+func (e *Engine) formatDate(session *Session, t time.Time) string {
+	return t.Format(e.t(session, "bot.date_format", nil))
+}
+
+// generateTitleFromContent gera um título a partir do conteúdo
+// Retorna "" se o conteúdo não tiver texto.
+func generateTitleFromContent(content string, maxLen int) string {
+	// Pegar primeira linha ou primeiras palavras
+	lines := strings.Split(content, "\n")
+	title := strings.TrimSpace(lines[0])
+
+	// Limitar tamanho
+	if len(title) > maxLen {
+		// Tentar cortar em uma palavra
+		words := strings.Fields(title)
+		title = ""
+		for _, word := range words {
+			if len(title)+len(word)+1 > maxLen {
+				break
+			}
+			if title != "" {
+				title += " "
+			}
+			title += word
+		}
+	}
+
+	return title
+}
+
+// detectItemType detecta o tipo de item baseado no conteúdo
+func detectItemType(content string) string {
+	contentLower := strings.ToLower(content)
+
+	// Palavras-chave para cada tipo
+	keywords := map[string][]string{
+		"memory": {"lembro", "memória", "saudade", "querido", "amor", "filho", "neto", "família", "remember", "memory", "family", "recuerdo", "familia"},
+		"info":   {"importante", "conta", "banco", "senha", "cpf", "documento", "cartão", "important", "bank", "account", "cuenta", "tarjeta"},
+		"access": {"login", "senha", "acesso", "usuário", "email", "password", "contraseña", "usuario"},
+		"note":   {"nota", "lembrete", "anotar", "não esquecer", "note", "reminder", "recordatorio"},
+	}
+
+	for itemType, words := range keywords {
+		for _, word := range words {
+			if strings.Contains(contentLower, word) {
+				return itemType
+			}
+		}
+	}
+
+	return "note" // Padrão
+}
@@ -0,0 +1,128 @@
+// =============================================================================
+// FAMLI - Motor de Conversas: Vinculação de Números
+// =============================================================================
+// "vincular" gera um código de 6 dígitos para o número que enviou a
+// mensagem. Digitado no perfil (POST /api/whatsapp/links), o código prova
+// que a pessoa logada tem aquele número em mãos: RedeemLinkCode devolve o
+// número e o código deixa de valer.
+//
+// Os códigos valem por linkCodeTTL e ficam na memória, como as sessões. Os
+// vínculos confirmados ficam no storage (storage.PhoneLink): uma conta pode
+// ter vários números, e cada número pertence a uma única conta.
+// =============================================================================
+
+package conversation
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"time"
+
+	"famli/internal/i18n"
+)
+
+// linkCodeTTL é a validade de um código de vinculação
+const linkCodeTTL = 10 * time.Minute
+
+// linkCode é um código de vinculação pendente
+type linkCode struct {
+	address   string
+	expiresAt time.Time
+}
+
+// handleLinkCommand gera o código para vincular o número a uma conta Famli
+func (e *Engine) handleLinkCommand(session *Session) (string, error) {
+	if session.UserID != "" {
+		return e.t(session, "bot.already_linked", i18n.Vars{"channel": e.channel.Name()}), nil
+	}
+
+	code, err := e.newLinkCode(session.Address)
+	if err != nil {
+		return e.t(session, "bot.try_again", nil), nil
+	}
+
+	return e.t(session, "bot.link_instructions", i18n.Vars{
+		"channel": e.channel.Name(),
+		"code":    code,
+	}), nil
+}
+
+// newLinkCode gera um código aleatório para o endereço
+// Um novo pedido do mesmo endereço invalida o código anterior.
+func (e *Engine) newLinkCode(address string) (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for code, pending := range e.linkCodes {
+		if pending.address == address {
+			delete(e.linkCodes, code)
+		}
+	}
+
+	for {
+		n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+		if err != nil {
+			return "", err
+		}
+		code := fmt.Sprintf("%06d", n.Int64())
+		if _, taken := e.linkCodes[code]; taken {
+			continue
+		}
+		e.linkCodes[code] = &linkCode{address: address, expiresAt: time.Now().Add(linkCodeTTL)}
+		return code, nil
+	}
+}
+
+// RedeemLinkCode troca o código pelo endereço que o pediu
+// O código só vale uma vez; retorna false se não existir ou tiver vencido.
+func (e *Engine) RedeemLinkCode(code string) (string, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	pending, ok := e.linkCodes[code]
+	if !ok {
+		return "", false
+	}
+	delete(e.linkCodes, code)
+	if time.Now().After(pending.expiresAt) {
+		return "", false
+	}
+	return pending.address, true
+}
+
+// expireLinkCodes remove os códigos vencidos (chamado com e.mu travado)
+func (e *Engine) expireLinkCodes(now time.Time) {
+	for code, pending := range e.linkCodes {
+		if now.After(pending.expiresAt) {
+			delete(e.linkCodes, code)
+		}
+	}
+}
+
+// userForAddress retorna o usuário vinculado ao endereço ("" se nenhum)
+func (e *Engine) userForAddress(address string) string {
+	if resolver, ok := e.channel.(AddressResolver); ok {
+		return resolver.UserForAddress(address)
+	}
+	if link, err := e.store.GetPhoneLinkByPhone(address); err == nil {
+		return link.UserID
+	}
+	return ""
+}
+
+// LinkUser atualiza o usuário da sessão ativa do endereço depois de
+// vincular ou desvincular o número (userID vazio); o vínculo fica no storage
+func (e *Engine) LinkUser(address, userID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if session, ok := e.sessions[address]; ok {
+		session.UserID = userID
+		if userID == "" {
+			session.PendingItem = nil
+			session.SearchResults = nil
+			session.State = StateIdle
+		}
+	}
+}
@@ -0,0 +1,224 @@
+// =============================================================================
+// FAMLI - Motor de Conversas: Idioma
+// =============================================================================
+// As respostas do assistente usam as traduções de i18n (chaves bot.*).
+//
+// Idioma da conversa:
+// 1. Usuário vinculado: idioma salvo na conta (user.Locale)
+// 2. Não vinculado: deduzido do código do país do telefone (+1 → en, +34 → es)
+// 3. pt-BR
+//
+// Categorias e comandos são reconhecidos em qualquer idioma suportado
+// ("saúde", "health", "salud" ou "2"); o item é salvo com o nome da
+// categoria no idioma da conversa, como as categorias padrão da Caixa.
+// =============================================================================
+
+package conversation
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"famli/internal/i18n"
+	"famli/internal/storage"
+)
+
+// countryCodeLocales mapeia códigos de país (DDI) para o idioma da conversa
+var countryCodeLocales = map[string]string{
+	// Português
+	"55": "pt-BR", "351": "pt-BR", "244": "pt-BR", "258": "pt-BR",
+
+	// Espanhol
+	"34": "es", "52": "es", "53": "es", "54": "es", "56": "es", "57": "es",
+	"51": "es", "58": "es", "502": "es", "503": "es", "504": "es", "505": "es",
+	"506": "es", "507": "es", "591": "es", "593": "es", "595": "es", "598": "es",
+
+	// Inglês
+	"1": "en", "44": "en", "61": "en", "64": "en", "353": "en", "27": "en",
+}
+
+// countryCodeLengths são os tamanhos de DDI testados, do mais específico
+var countryCodeLengths = []int{3, 2, 1}
+
+// LocaleFromPhone deduz o idioma pelo código do país de um número E.164
+// Números sem "+" ou de países não mapeados usam pt-BR.
+func LocaleFromPhone(phone string) string {
+	digits := strings.TrimPrefix(strings.TrimSpace(phone), "+")
+	if digits == phone || digits == "" {
+		return i18n.DefaultLocale
+	}
+	for _, n := range countryCodeLengths {
+		if len(digits) <= n {
+			continue
+		}
+		if locale, ok := countryCodeLocales[digits[:n]]; ok {
+			return locale
+		}
+	}
+	return i18n.DefaultLocale
+}
+
+// Locale retorna o idioma usado nas respostas para um endereço do canal
+func (e *Engine) Locale(address string) string {
+	return e.localeFor(e.userForAddress(address), address)
+}
+
+// localeFor usa o idioma salvo do usuário vinculado ou o do telefone
+func (e *Engine) localeFor(userID, address string) string {
+	if userID != "" {
+		if user, ok := e.store.GetUserByID(userID); ok {
+			if locale := i18n.Normalize(user.Locale); locale != "" {
+				return locale
+			}
+		}
+	}
+	return LocaleFromPhone(address)
+}
+
+// t traduz uma mensagem do assistente no idioma da sessão
+// {site} é sempre preenchido com o endereço do frontend.
+func (e *Engine) t(session *Session, key string, vars i18n.Vars) string {
+	return i18n.Format(session.Locale, key, withVar(vars, "site", e.site))
+}
+
+// siteName retira o esquema e a "/" final do endereço do frontend
+// (https://famli.me → famli.me), como é escrito nas mensagens
+func siteName(appURL string) string {
+	site := strings.TrimPrefix(strings.TrimPrefix(appURL, "https://"), "http://")
+	return strings.TrimRight(site, "/")
+}
+
+// =============================================================================
+// CATEGORIAS
+// =============================================================================
+
+// categoryOption é uma categoria oferecida no menu da conversa
+type categoryOption struct {
+	key   string
+	emoji string
+}
+
+// categoryOptions são as categorias do menu, na ordem dos números (1 a 6)
+var categoryOptions = []categoryOption{
+	{"category.default.family", "👨‍👩‍👧‍👦"},
+	{"category.default.health", "🏥"},
+	{"category.default.finances", "💰"},
+	{"category.default.docs", "📄"},
+	{"category.default.memories", "💝"},
+	{"category.default.pets", "🐾"},
+}
+
+// categoryItemTypes são as categorias que definem o tipo do item salvo
+// (o perfil do pet é completado depois, no app)
+var categoryItemTypes = map[string]storage.ItemType{
+	"category.default.pets": storage.ItemTypePet,
+}
+
+// otherCategory é usada quando a resposta não corresponde a nenhuma opção
+var otherCategory = categoryOption{"category.default.other", "📌"}
+
+// menuNumbers são os emojis numéricos do menu de categorias
+var menuNumbers = []string{"1️⃣", "2️⃣", "3️⃣", "4️⃣", "5️⃣", "6️⃣"}
+
+// categoryAliases são apelidos aceitos além dos nomes traduzidos
+var categoryAliases = map[string]string{
+	"dinheiro": "category.default.finances",
+	"money":    "category.default.finances",
+	"dinero":   "category.default.finances",
+	"docs":     "category.default.docs",
+	"doc":      "category.default.docs",
+	"fotos":    "category.default.memories",
+	"photos":   "category.default.memories",
+	"animais":  "category.default.pets",
+	"animals":  "category.default.pets",
+	"animales": "category.default.pets",
+}
+
+// minCategoryPrefix é o tamanho mínimo de abreviações ("fam", "sau", "fin")
+const minCategoryPrefix = 3
+
+// accentFolder remove acentos para comparar respostas digitadas sem eles
+var accentFolder = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "ê", "e", "è", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ô", "o", "õ", "o", "ò", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// fold normaliza a entrada do usuário (minúsculas, sem acentos e espaços)
+func fold(s string) string {
+	return accentFolder.Replace(strings.ToLower(strings.TrimSpace(s)))
+}
+
+// categoryMenu monta o menu numerado de categorias no idioma da sessão
+func (e *Engine) categoryMenu(session *Session) string {
+	lines := make([]string, 0, len(categoryOptions)+2)
+	for i, opt := range categoryOptions {
+		lines = append(lines, menuNumbers[i]+" "+capitalize(i18n.T(session.Locale, opt.key)))
+	}
+	lines = append(lines, "", e.t(session, "bot.category_hint", nil))
+	return strings.Join(lines, "\n")
+}
+
+// matchCategory encontra a opção correspondente à resposta do usuário
+// Aceita o número do menu, o nome em qualquer idioma (com ou sem acento),
+// abreviações e apelidos; o item é salvo com o nome no idioma da sessão.
+func matchCategory(input string) categoryOption {
+	answer := fold(input)
+	if answer == "" {
+		return otherCategory
+	}
+
+	if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(categoryOptions) {
+		return categoryOptions[n-1]
+	}
+
+	all := append(append([]categoryOption{}, categoryOptions...), otherCategory)
+	if key, ok := categoryAliases[answer]; ok {
+		for _, opt := range all {
+			if opt.key == key {
+				return opt
+			}
+		}
+	}
+
+	locales := supportedLocales()
+	for _, opt := range all {
+		for _, locale := range locales {
+			name := fold(i18n.T(locale, opt.key))
+			if answer == name || (len(answer) >= minCategoryPrefix && strings.HasPrefix(name, answer)) {
+				return opt
+			}
+		}
+	}
+
+	return otherCategory
+}
+
+// getCategoryEmoji retorna o emoji para uma categoria (em qualquer idioma)
+func getCategoryEmoji(category string) string {
+	return matchCategory(category).emoji
+}
+
+// supportedLocales lista os idiomas com traduções, em ordem estável
+func supportedLocales() []string {
+	locales := make([]string, 0, len(i18n.Translations))
+	for locale := range i18n.Translations {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// capitalize deixa a primeira letra maiúscula ("família" → "Família")
+func capitalize(s string) string {
+	for i := range s {
+		if i > 0 {
+			return strings.ToUpper(s[:i]) + s[i:]
+		}
+	}
+	return strings.ToUpper(s)
+}
@@ -0,0 +1,119 @@
+// =============================================================================
+// FAMLI - Motor de Conversas: Sessões e Comandos
+// =============================================================================
+
+package conversation
+
+import "time"
+
+// =============================================================================
+// SESSÃO DO USUÁRIO
+// =============================================================================
+
+// State é o estado atual de uma conversa
+type State string
+
+const (
+	// StateIdle aguarda uma nova mensagem para guardar
+	StateIdle State = "idle"
+
+	// StateAwaitingCategory aguarda a escolha da categoria do item pendente
+	StateAwaitingCategory State = "awaiting_category"
+
+	// StateAwaitingConfirmation aguarda confirmação (ou novo título) do item pendente
+	StateAwaitingConfirmation State = "awaiting_confirmation"
+
+	// StateAwaitingResultChoice aguarda o número de um resultado da busca
+	StateAwaitingResultChoice State = "awaiting_result_choice"
+)
+
+// Session armazena o estado da conversa com um usuário
+// Permite manter contexto entre mensagens
+type Session struct {
+	// Address é o endereço do usuário no canal (chave)
+	Address string `json:"address"`
+
+	// UserID é o ID do usuário no Famli (se vinculado)
+	UserID string `json:"user_id,omitempty"`
+
+	// Locale é o idioma das respostas (ver locale.go)
+	Locale string `json:"locale"`
+
+	// State é o estado atual da conversa
+	State State `json:"state"`
+
+	// PendingItem armazena dados temporários de um item sendo criado
+	PendingItem *PendingItem `json:"pending_item,omitempty"`
+
+	// SearchResults são os IDs dos itens da última busca, na ordem exibida
+	SearchResults []string `json:"search_results,omitempty"`
+
+	// LastMessageAt é quando a última mensagem foi recebida
+	LastMessageAt time.Time `json:"last_message_at"`
+
+	// CreatedAt é quando a sessão foi criada
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PendingItem armazena dados de um item que está sendo criado pela conversa
+type PendingItem struct {
+	// Content é o conteúdo principal (texto, legenda da imagem, etc.)
+	Content string `json:"content"`
+
+	// Type é o tipo do item (info, memory, note, etc.)
+	Type string `json:"type"`
+
+	// Title é o título do item (pode ser gerado automaticamente)
+	Title string `json:"title"`
+
+	// Category é a categoria (saúde, finanças, família, etc.)
+	Category string `json:"category"`
+
+	// MediaURL é a URL da mídia se houver
+	MediaURL string `json:"media_url,omitempty"`
+
+	// MediaType é o tipo da mídia
+	MediaType string `json:"media_type,omitempty"`
+}
+
+// =============================================================================
+// COMANDOS RECONHECIDOS
+// =============================================================================
+
+// Command representa um comando que o usuário pode enviar
+type Command string
+
+const (
+	// CommandHelp mostra a ajuda
+	CommandHelp Command = "ajuda"
+
+	// CommandSave inicia o processo de salvar algo
+	CommandSave Command = "guardar"
+
+	// CommandList lista os últimos itens salvos
+	CommandList Command = "listar"
+
+	// CommandCancel cancela a operação atual
+	CommandCancel Command = "cancelar"
+
+	// CommandStatus mostra o status da conta
+	CommandStatus Command = "status"
+
+	// CommandLink vincula o endereço a uma conta Famli
+	CommandLink Command = "vincular"
+
+	// CommandSearch busca nos itens da Caixa ("buscar banco")
+	CommandSearch Command = "buscar"
+
+	// CommandDigest liga, muda ou desliga o resumo da Caixa ("parar resumo")
+	CommandDigest Command = "resumo"
+
+	// CommandContinue retoma o item abandonado no meio ("continuar")
+	CommandContinue Command = "continuar"
+
+	// CommandOptOut para as mensagens enviadas pelo Famli ("STOP")
+	CommandOptOut Command = "stop"
+
+	// CommandOptIn volta a permitir as mensagens ("START")
+	CommandOptIn Command = "start"
+)
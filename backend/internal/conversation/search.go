@@ -0,0 +1,283 @@
+// =============================================================================
+// FAMLI - Motor de Conversas: Busca na Caixa
+// =============================================================================
+// "buscar banco" procura nos itens que o usuário pode ver (título, conteúdo
+// e categoria) e responde com os melhores resultados, numerados. Responder
+// com o número mostra o conteúdo completo do item.
+//
+// Acesso (as mesmas regras da Caixa Famli):
+// - Itens pessoais do usuário e das famílias em que é membro ativo
+// - Itens compartilhados com ele como guardião NÃO entram: dependem das
+//   regras do portal do guardião (PIN, tipo de acesso)
+// - O acesso é conferido de novo ao abrir um resultado
+// =============================================================================
+
+package conversation
+
+import (
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"famli/internal/household"
+	"famli/internal/i18n"
+	"famli/internal/storage"
+)
+
+const (
+	// maxSearchResults é quantos resultados são mostrados
+	maxSearchResults = 5
+
+	// maxSearchSnippet é o tamanho do trecho de conteúdo de cada resultado
+	maxSearchSnippet = 60
+
+	// maxResultContent limita o conteúdo completo (uma mensagem do WhatsApp
+	// tem até 1600 caracteres; sobra espaço para título e rodapé)
+	maxResultContent = 1200
+
+	// maxResultOptionTitle é o tamanho dos itens da lista interativa
+	maxResultOptionTitle = 24
+)
+
+// Pesos de cada campo no ranking da busca
+const (
+	searchWeightTitle    = 3
+	searchWeightCategory = 2
+	searchWeightContent  = 1
+)
+
+// searchResult é um item encontrado e sua relevância
+type searchResult struct {
+	item  *storage.BoxItem
+	score int
+}
+
+// handleSearchCommand busca na Caixa e mostra os resultados numerados
+func (e *Engine) handleSearchCommand(session *Session, query string) (string, error) {
+	if session.UserID == "" {
+		return e.t(session, "bot.list_unlinked", nil), nil
+	}
+
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return e.t(session, "bot.search_usage", nil), nil
+	}
+
+	items, err := e.searchableItems(session.UserID)
+	if err != nil {
+		log.Printf("[Conversa] Erro ao buscar itens: %v", err)
+		return e.t(session, "bot.try_again", nil), nil
+	}
+
+	results := rankItems(items, terms)
+	if len(results) == 0 {
+		return e.t(session, "bot.search_empty", i18n.Vars{"query": query}), nil
+	}
+
+	total := len(results)
+	if len(results) > maxSearchResults {
+		results = results[:maxSearchResults]
+	}
+
+	// Guardar os IDs na sessão para "responda com o número"
+	session.SearchResults = make([]string, len(results))
+	lines := make([]string, len(results))
+	options := make([]Option, len(results))
+	for i, r := range results {
+		n := strconv.Itoa(i + 1)
+		session.SearchResults[i] = r.item.ID
+		lines[i] = n + ". " + getCategoryEmoji(r.item.Category) + " *" + r.item.Title + "*" + sharedMarker(r.item) +
+			"\n   _" + truncate(singleLine(e.itemText(session, r.item)), maxSearchSnippet) + "_"
+		options[i] = Option{ID: n, Title: truncate(n+". "+r.item.Title, maxResultOptionTitle)}
+	}
+	session.State = StateAwaitingResultChoice
+	e.saveSession(session)
+
+	header := i18n.Plural(session.Locale, "bot.search_results", total, i18n.Vars{"query": query})
+	list := strings.Join(lines, "\n\n")
+
+	return e.ask(session, Prompt{
+		Style:   PromptList,
+		Body:    header + "\n\n" + list,
+		Button:  e.t(session, "bot.search_button", nil),
+		Options: options,
+		Text:    header + "\n\n" + list + "\n\n" + e.t(session, "bot.search_hint", nil),
+	})
+}
+
+// handleResultChoice mostra o conteúdo completo do resultado escolhido
+// Se a resposta não for o número de um resultado, segue como mensagem nova.
+func (e *Engine) handleResultChoice(session *Session, input string) (string, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(input))
+	if err == nil && (n < 1 || n > len(session.SearchResults)) {
+		return e.t(session, "bot.search_hint", nil), nil
+	}
+	if err != nil {
+		session.SearchResults = nil
+		session.State = StateIdle
+		e.saveSession(session)
+		return e.startNewItem(session, input, "text")
+	}
+
+	item, err := e.store.GetBoxItemByID(session.SearchResults[n-1])
+	if err != nil || !e.canView(session.UserID, item) {
+		return e.t(session, "bot.search_not_found", nil), nil
+	}
+
+	content := strings.TrimSpace(e.itemText(session, item))
+	if utf8.RuneCountInString(content) > maxResultContent {
+		content = truncate(content, maxResultContent) + "\n\n" + e.t(session, "bot.search_truncated", nil)
+	}
+
+	category := item.Category
+	if category == "" {
+		category = i18n.T(session.Locale, otherCategory.key)
+	}
+	visibility := e.t(session, "bot.search_private", nil)
+	if item.IsShared {
+		visibility = e.t(session, "bot.search_shared", nil)
+	}
+
+	return e.t(session, "bot.search_item", i18n.Vars{
+		"title":      item.Title,
+		"category":   category,
+		"visibility": visibility,
+		"content":    content,
+	}), nil
+}
+
+// itemText retorna o conteúdo do item para exibir no chat
+// Itens selados só podem ser abertos no app, com a frase secreta.
+func (e *Engine) itemText(session *Session, item *storage.BoxItem) string {
+	if item.IsSealed() {
+		return e.t(session, "bot.sealed_content", nil)
+	}
+	return item.Content
+}
+
+// searchableItems retorna os itens que o usuário pode ver na Caixa
+// (pessoais e das famílias em que é membro ativo)
+func (e *Engine) searchableItems(userID string) ([]*storage.BoxItem, error) {
+	memberships, err := household.Memberships(e.store, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	own, err := e.store.GetBoxItems(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*storage.BoxItem, 0, len(own))
+	for _, item := range own {
+		if household.CanView(userID, item, memberships) {
+			items = append(items, item)
+		}
+	}
+	if len(memberships) == 0 {
+		return items, nil
+	}
+
+	// Itens das famílias criados por outros membros
+	filter := &storage.BoxItemFilter{UserID: userID, HouseholdIDs: household.IDs(memberships)}
+	params := &storage.PaginationParams{Limit: storage.MaxPageSize}
+	for {
+		page, err := e.store.ListAccessibleBoxItemsPaginated(filter, params)
+		if err != nil {
+			return nil, err
+		}
+		for _, summary := range page.Items {
+			if summary.OwnerID == userID {
+				continue // Já incluído acima
+			}
+			if item, err := e.store.GetBoxItemByID(summary.ID); err == nil {
+				items = append(items, item)
+			}
+		}
+		if !page.HasMore {
+			return items, nil
+		}
+		params.Cursor = page.NextCursor
+	}
+}
+
+// canView confere se o usuário ainda pode ver o item (pode ter saído da família)
+func (e *Engine) canView(userID string, item *storage.BoxItem) bool {
+	if item.HouseholdID == "" {
+		return item.UserID == userID
+	}
+	memberships, err := household.Memberships(e.store, userID)
+	if err != nil {
+		return false
+	}
+	return household.CanView(userID, item, memberships)
+}
+
+// searchTerms separa a busca em termos normalizados (sem acentos)
+func searchTerms(query string) []string {
+	var terms []string
+	for _, term := range strings.Fields(fold(query)) {
+		term = strings.Trim(term, ".,;:!?\"'()")
+		if utf8.RuneCountInString(term) >= 2 {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// rankItems mantém os itens que contêm todos os termos, do mais relevante
+// (termo no título vale mais que na categoria, que vale mais que no conteúdo)
+func rankItems(items []*storage.BoxItem, terms []string) []searchResult {
+	var results []searchResult
+	for _, item := range items {
+		title, category, content := fold(item.Title), fold(item.Category), fold(item.Content)
+		if item.IsSealed() {
+			content = "" // Texto cifrado não é buscável
+		}
+
+		score := 0
+		for _, term := range terms {
+			termScore := 0
+			if strings.Contains(title, term) {
+				termScore += searchWeightTitle
+			}
+			if strings.Contains(category, term) {
+				termScore += searchWeightCategory
+			}
+			if strings.Contains(content, term) {
+				termScore += searchWeightContent
+			}
+			if termScore == 0 {
+				score = 0
+				break
+			}
+			score += termScore
+		}
+		if score > 0 {
+			results = append(results, searchResult{item: item, score: score})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return results[i].item.UpdatedAt.After(results[j].item.UpdatedAt)
+	})
+	return results
+}
+
+// sharedMarker indica nos resultados os itens visíveis para guardiões
+func sharedMarker(item *storage.BoxItem) string {
+	if item.IsShared {
+		return " 👥"
+	}
+	return ""
+}
+
+// singleLine junta as linhas do conteúdo para o trecho do resultado
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,145 @@
+// =============================================================================
+// FAMLI - Motor de Conversas: Sessões Paradas
+// =============================================================================
+// Quem some no meio de uma pergunta (categoria, confirmação ou escolha de um
+// resultado da busca) não deixa a sessão presa para sempre: sem mensagens
+// pelo prazo da sessão (SetSessionTimeout, WHATSAPP_SESSION_TIMEOUT_MINUTES),
+// ExpireSessions encerra a sessão e a próxima mensagem começa do zero.
+//
+// O item que estava sendo criado vira rascunho (storage.ConversationDraft) e
+// o usuário recebe um lembrete: "continuar" retoma a pergunta de onde parou
+// e "cancelar" descarta. O lembrete respeita o STOP (ver consent.go).
+// =============================================================================
+
+package conversation
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"famli/internal/i18n"
+	"famli/internal/storage"
+)
+
+// DefaultSessionTimeout é o prazo padrão de uma sessão sem mensagens
+const DefaultSessionTimeout = 30 * time.Minute
+
+// continueWords retomam o rascunho salvo
+var continueWords = map[string]bool{
+	"continuar": true, "continue": true, "retomar": true, "seguir": true, "resume": true,
+}
+
+// SetSessionTimeout define o prazo de uma sessão sem mensagens
+func (e *Engine) SetSessionTimeout(timeout time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.sessionTimeout = timeout
+}
+
+// ExpireSessions encerra as sessões sem mensagens há mais que o prazo
+// Itens pendentes viram rascunho e o usuário é avisado.
+func (e *Engine) ExpireSessions(now time.Time) {
+	var expired []*Session
+
+	e.mu.Lock()
+	for address, session := range e.sessions {
+		if now.Sub(session.LastMessageAt) < e.sessionTimeout {
+			continue
+		}
+		delete(e.sessions, address)
+		if session.State != StateIdle {
+			expired = append(expired, session)
+		}
+	}
+	e.expireLinkCodes(now)
+	e.mu.Unlock()
+
+	for _, session := range expired {
+		e.saveDraft(session, now)
+	}
+}
+
+// saveDraft guarda o item pendente da sessão encerrada e avisa o usuário
+func (e *Engine) saveDraft(session *Session, now time.Time) {
+	if session.PendingItem == nil || session.UserID == "" {
+		return // Escolha de resultado da busca: nada a guardar
+	}
+
+	pending := session.PendingItem
+	draft := &storage.ConversationDraft{
+		UserID:    session.UserID,
+		State:     string(session.State),
+		Type:      pending.Type,
+		Title:     pending.Title,
+		Content:   pending.Content,
+		Category:  pending.Category,
+		MediaURL:  pending.MediaURL,
+		MediaType: pending.MediaType,
+		CreatedAt: now,
+	}
+	if err := e.store.SaveConversationDraft(draft); err != nil {
+		log.Printf("[Conversa] Erro ao salvar rascunho de %s: %v", session.UserID, err)
+		return
+	}
+
+	if e.store.GetSettings(session.UserID).MessagingOptOut {
+		return
+	}
+	message := e.t(session, "bot.draft_saved", i18n.Vars{"title": truncate(pending.Title, 60)})
+	if err := e.channel.Send(session.Address, message); err != nil {
+		log.Printf("[Conversa] Lembrete do rascunho não enviado: %v", err)
+	}
+}
+
+// handleContinueCommand retoma a pergunta do rascunho salvo
+func (e *Engine) handleContinueCommand(session *Session) (string, error) {
+	if session.UserID == "" {
+		return e.t(session, "bot.draft_none", nil), nil
+	}
+
+	draft, err := e.store.GetConversationDraft(session.UserID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return e.t(session, "bot.draft_none", nil), nil
+	}
+	if err != nil {
+		log.Printf("[Conversa] Erro ao buscar rascunho: %v", err)
+		return e.t(session, "bot.try_again", nil), nil
+	}
+	if err := e.store.DeleteConversationDraft(session.UserID); err != nil {
+		log.Printf("[Conversa] Erro ao descartar rascunho: %v", err)
+	}
+
+	session.PendingItem = &PendingItem{
+		Content:   draft.Content,
+		Type:      draft.Type,
+		Title:     draft.Title,
+		Category:  draft.Category,
+		MediaURL:  draft.MediaURL,
+		MediaType: draft.MediaType,
+	}
+
+	if State(draft.State) == StateAwaitingConfirmation && draft.Category != "" {
+		session.State = StateAwaitingConfirmation
+		e.saveSession(session)
+		return e.askConfirmation(session, "bot.confirm_item", i18n.Vars{
+			"title":    draft.Title,
+			"category": draft.Category,
+			"content":  truncate(draft.Content, 150),
+		})
+	}
+
+	session.State = StateAwaitingCategory
+	e.saveSession(session)
+	return e.askCategory(session, "bot.new_item", i18n.Vars{"content": truncate(draft.Content, 200)})
+}
+
+// discardDraft descarta o rascunho do usuário ("cancelar")
+func (e *Engine) discardDraft(session *Session) {
+	if session.UserID == "" {
+		return
+	}
+	if err := e.store.DeleteConversationDraft(session.UserID); err != nil {
+		log.Printf("[Conversa] Erro ao descartar rascunho: %v", err)
+	}
+}
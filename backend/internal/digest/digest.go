@@ -0,0 +1,242 @@
+// =============================================================================
+// FAMLI - Resumo da Caixa pelo WhatsApp
+// =============================================================================
+// Usuários que ligam o resumo (nas configurações ou com "resumo" no
+// WhatsApp) recebem, diária ou semanalmente:
+// - Itens novos na Caixa desde o último resumo
+// - Vencimentos e renovações próximos
+// - Passos do Guia Famli ainda pendentes
+//
+// Agendamento:
+// - O scheduler verifica a cada hora quem tem resumo vencido
+// - Os resumos saem na hora configurada (WHATSAPP_DIGEST_HOUR, em UTC)
+// - ClaimDigest marca o envio antes de mandar: com várias réplicas, cada
+//   resumo sai uma única vez
+// - Resumos sem novidades não são enviados
+//
+// Para parar, basta responder "parar resumo" (ver conversation.CommandDigest).
+// Quem respondeu "STOP" não recebe nenhum resumo (ver conversation.CommandOptOut).
+// =============================================================================
+
+package digest
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"famli/internal/guide"
+	"famli/internal/i18n"
+	"famli/internal/storage"
+)
+
+const (
+	// checkInterval é o intervalo entre verificações de resumos vencidos
+	checkInterval = time.Hour
+
+	// maxListed limita quantos itens de cada seção aparecem no resumo
+	maxListed = 5
+)
+
+// SendFunc envia o texto do resumo ao usuário (ex: whatsapp.Service.NotifyUser)
+type SendFunc func(userID, text string) error
+
+// Scheduler envia os resumos periódicos
+type Scheduler struct {
+	store   storage.Store
+	send    SendFunc
+	hourUTC int
+}
+
+// NewScheduler cria o scheduler de resumos
+func NewScheduler(store storage.Store, send SendFunc, hourUTC int) *Scheduler {
+	return &Scheduler{store: store, send: send, hourUTC: hourUTC}
+}
+
+// Start inicia o worker de resumos (encerra quando ctx é cancelado)
+func (s *Scheduler) Start(ctx context.Context) {
+	go func() {
+		s.runDue(time.Now())
+
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.runDue(now)
+			}
+		}
+	}()
+}
+
+// runDue envia os resumos cujo horário já chegou
+func (s *Scheduler) runDue(now time.Time) {
+	subs, err := s.store.ListDigestSubscriptions()
+	if err != nil {
+		log.Printf("[Digest] Erro ao listar inscrições: %v", err)
+		return
+	}
+
+	for _, sub := range subs {
+		period := sub.Frequency.Period()
+		if period == 0 || now.Before(nextDigestAt(sub.LastSentAt, period, s.hourUTC)) {
+			continue
+		}
+
+		claimed, err := s.store.ClaimDigest(sub.UserID, sub.LastSentAt, now)
+		if err != nil {
+			log.Printf("[Digest] Erro ao registrar resumo de %s: %v", sub.UserID, err)
+			continue
+		}
+		if !claimed {
+			continue // Outra réplica já enviou
+		}
+
+		text, ok := s.build(sub, now)
+		if !ok {
+			continue
+		}
+		if err := s.send(sub.UserID, text); err != nil {
+			log.Printf("[Digest] Resumo não enviado para %s: %v", sub.UserID, err)
+		}
+	}
+}
+
+// nextDigestAt retorna o próximo horário de envio depois do último resumo
+// O horário é fixo (hourUTC); com a folga de um dia, mudar a hora da
+// inscrição não empurra os envios seguintes.
+func nextDigestAt(last time.Time, period time.Duration, hourUTC int) time.Time {
+	from := last.Add(period - 24*time.Hour).UTC()
+	slot := time.Date(from.Year(), from.Month(), from.Day(), hourUTC, 0, 0, 0, time.UTC)
+	if !slot.After(from) {
+		slot = slot.AddDate(0, 0, 1)
+	}
+	return slot
+}
+
+// =============================================================================
+// CONTEÚDO
+// =============================================================================
+
+// build monta o resumo no idioma do usuário
+// Retorna false quando não há nada a contar.
+func (s *Scheduler) build(sub *storage.DigestSubscription, now time.Time) (string, bool) {
+	locale := i18n.DefaultLocale
+	if user, ok := s.store.GetUserByID(sub.UserID); ok {
+		locale = i18n.UserLocale(user.Locale, nil)
+	}
+
+	var sections []string
+	if section := s.addedSection(sub.UserID, sub.LastSentAt, locale); section != "" {
+		sections = append(sections, section)
+	}
+	if section := s.dueSection(sub.UserID, now, sub.Frequency.Period(), locale); section != "" {
+		sections = append(sections, section)
+	}
+	if section := guideSection(s.store, sub.UserID, locale); section != "" {
+		sections = append(sections, section)
+	}
+	if len(sections) == 0 {
+		return "", false
+	}
+
+	parts := append([]string{i18n.T(locale, "digest.title."+string(sub.Frequency))}, sections...)
+	parts = append(parts, i18n.T(locale, "digest.footer"))
+	return strings.Join(parts, "\n\n"), true
+}
+
+// addedSection lista os itens criados desde o último resumo
+func (s *Scheduler) addedSection(userID string, since time.Time, locale string) string {
+	items, err := s.store.GetBoxItems(userID)
+	if err != nil {
+		log.Printf("[Digest] Erro ao buscar itens de %s: %v", userID, err)
+		return ""
+	}
+
+	var added []*storage.BoxItem
+	for _, item := range items {
+		if item.CreatedAt.After(since) {
+			added = append(added, item)
+		}
+	}
+	if len(added) == 0 {
+		return ""
+	}
+	sort.Slice(added, func(i, j int) bool { return added[i].CreatedAt.After(added[j].CreatedAt) })
+
+	lines := []string{i18n.Plural(locale, "digest.items_added", len(added), nil)}
+	for i, item := range added {
+		if i == maxListed {
+			break
+		}
+		lines = append(lines, "• "+item.Title)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// dueSection lista vencimentos e renovações até o próximo resumo
+func (s *Scheduler) dueSection(userID string, now time.Time, lookahead time.Duration, locale string) string {
+	items, err := s.store.ListDatedBoxItems(userID)
+	if err != nil {
+		log.Printf("[Digest] Erro ao buscar datas de %s: %v", userID, err)
+		return ""
+	}
+
+	type dueEntry struct {
+		key   string
+		title string
+		date  time.Time
+	}
+	until := now.Add(lookahead)
+	inWindow := func(t *time.Time) bool {
+		return t != nil && !t.Before(now) && !t.After(until)
+	}
+
+	var entries []dueEntry
+	for _, item := range items {
+		if inWindow(item.DueDate) {
+			entries = append(entries, dueEntry{"digest.due_item", item.Title, *item.DueDate})
+		}
+		if inWindow(item.RenewalDate) {
+			entries = append(entries, dueEntry{"digest.renewal_item", item.Title, *item.RenewalDate})
+		}
+	}
+	if len(entries) == 0 {
+		return ""
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].date.Before(entries[j].date) })
+
+	dateFormat := i18n.T(locale, "digest.date_format")
+	lines := []string{i18n.T(locale, "digest.due_header")}
+	for i, entry := range entries {
+		if i == maxListed {
+			break
+		}
+		lines = append(lines, i18n.Format(locale, entry.key, i18n.Vars{
+			"title": entry.title,
+			"date":  entry.date.Format(dateFormat),
+		}))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// guideSection lembra os passos do Guia Famli ainda pendentes
+func guideSection(store storage.Store, userID, locale string) string {
+	pending := guide.PendingCards(store, userID, locale)
+	if len(pending) == 0 {
+		return ""
+	}
+
+	lines := []string{i18n.Plural(locale, "digest.guide_header", len(pending), nil)}
+	for i, card := range pending {
+		if i == maxListed {
+			break
+		}
+		lines = append(lines, card.Icon+" "+card.Title)
+	}
+	return strings.Join(lines, "\n")
+}
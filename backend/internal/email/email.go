@@ -10,7 +10,7 @@
 // - AWS SES (futuro)
 // - SMTP genérico (futuro)
 //
-// Variáveis de ambiente:
+// Configuração (lida pelo pacote config e passada em NewService):
 // - EMAIL_PROVIDER: "mailtrap" (padrão), "sendgrid", "ses", "smtp"
 // - MAILTRAP_API_TOKEN: Token da API do Mailtrap
 // - MAILTRAP_SANDBOX: "true" para usar sandbox (testes), "false" para produção
@@ -23,6 +23,7 @@ package email
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
@@ -30,9 +31,10 @@ import (
 	"html/template"
 	"io"
 	"net/http"
-	"os"
 	"strings"
 	"time"
+
+	"famli/internal/i18n"
 )
 
 // =============================================================================
@@ -43,6 +45,9 @@ import (
 type Provider interface {
 	Send(email *Email) error
 	Name() string
+
+	// Ping confere as credenciais sem enviar nada (usado no health check)
+	Ping(ctx context.Context) error
 }
 
 // Email representa um email a ser enviado
@@ -52,14 +57,27 @@ type Email struct {
 	Subject  string            // Assunto
 	HTML     string            // Corpo HTML
 	Text     string            // Corpo texto (fallback)
+	ReplyTo  string            // Endereço das respostas (vazio = remetente)
 	Metadata map[string]string // Metadados opcionais
 }
 
+// Config é a configuração do serviço de email
+type Config struct {
+	Provider         string // "mailtrap" (padrão)
+	From             string // Email remetente
+	FromName         string // Nome do remetente
+	MailtrapAPIToken string // Token da API do Mailtrap
+	MailtrapSandbox  bool   // Usar o sandbox (testes)
+	MailtrapInboxID  string // Inbox do sandbox
+	AppURL           string // Endereço público do frontend (links dos emails)
+}
+
 // Service gerencia o envio de emails
 type Service struct {
 	provider Provider
 	from     string
 	fromName string
+	appURL   string
 }
 
 // =============================================================================
@@ -67,39 +85,35 @@ type Service struct {
 // =============================================================================
 
 // NewService cria uma nova instância do serviço de email
-func NewService() *Service {
-	providerName := os.Getenv("EMAIL_PROVIDER")
-	if providerName == "" {
-		providerName = "mailtrap"
-	}
-
-	from := os.Getenv("EMAIL_FROM")
+func NewService(config *Config) *Service {
+	from := config.From
 	if from == "" {
 		from = "noreply@famli.me"
 	}
 
-	fromName := os.Getenv("EMAIL_FROM_NAME")
+	fromName := config.FromName
 	if fromName == "" {
 		fromName = "Famli"
 	}
 
 	var provider Provider
-	switch providerName {
-	case "mailtrap":
-		provider = NewMailtrapProvider()
+	switch config.Provider {
+	case "", "mailtrap":
+		provider = NewMailtrapProvider(config)
 	// Adicionar outros provedores no futuro:
 	// case "sendgrid":
 	//     provider = NewSendGridProvider()
 	// case "ses":
 	//     provider = NewSESProvider()
 	default:
-		provider = NewMailtrapProvider()
+		provider = NewMailtrapProvider(config)
 	}
 
 	return &Service{
 		provider: provider,
 		from:     from,
 		fromName: fromName,
+		appURL:   strings.TrimRight(config.AppURL, "/"),
 	}
 }
 
@@ -124,6 +138,20 @@ func (s *Service) Send(email *Email) error {
 	return s.provider.Send(email)
 }
 
+// Ping confere se o provedor aceita as credenciais configuradas
+func (s *Service) Ping(ctx context.Context) error {
+	if s.provider == nil {
+		return fmt.Errorf("email provider not configured")
+	}
+	return s.provider.Ping(ctx)
+}
+
+// englishTemplate indica se o email usa o template em inglês
+// Templates existem em pt-BR e en; outros idiomas seguem o fallback do i18n.
+func englishTemplate(locale string) bool {
+	return i18n.Resolve(locale, "pt-BR", "en") == "en"
+}
+
 // =============================================================================
 // TEMPLATES DE EMAIL
 // =============================================================================
@@ -139,7 +167,7 @@ func (s *Service) SendPasswordReset(to, toName, resetLink, locale string) error
 		<path d="M34 52C34 52 52.5 38.5 52.5 26C52.5 20 48 15 42 15C37.5 15 34 18 34 18C34 18 30.5 15 26 15C20 15 15.5 20 15.5 26C15.5 38.5 34 52 34 52Z" fill="#f4a285"/>
 	</svg>`
 
-	if strings.HasPrefix(locale, "en") {
+	if englishTemplate(locale) {
 		// Template em Inglês
 		subject = "🔐 Reset your password - Famli"
 		html = fmt.Sprintf(`
@@ -309,7 +337,7 @@ func (s *Service) SendWelcome(to, toName, locale string) error {
 		<path d="M34 52C34 52 52.5 38.5 52.5 26C52.5 20 48 15 42 15C37.5 15 34 18 34 18C34 18 30.5 15 26 15C20 15 15.5 20 15.5 26C15.5 38.5 34 52 34 52Z" fill="#f4a285"/>
 	</svg>`
 
-	if strings.HasPrefix(locale, "en") {
+	if englishTemplate(locale) {
 		subject = "🏠 Welcome to Famli!"
 		html = fmt.Sprintf(`
 <!DOCTYPE html>
@@ -340,7 +368,7 @@ func (s *Service) SendWelcome(to, toName, locale string) error {
                 </p>
                 
                 <div style="text-align: center; margin: 32px 0;">
-                    <a href="https://famli.me/my-box" style="display: inline-block; background: #e07b39; color: white; padding: 16px 36px; text-decoration: none; border-radius: 12px; font-weight: 700; font-size: 17px;">
+                    <a href="%s/my-box" style="display: inline-block; background: #e07b39; color: white; padding: 16px 36px; text-decoration: none; border-radius: 12px; font-weight: 700; font-size: 17px;">
                         Access My Box
                     </a>
                 </div>
@@ -354,8 +382,8 @@ func (s *Service) SendWelcome(to, toName, locale string) error {
     </table>
 </body>
 </html>
-`, logo, getNameGreeting(toName))
-		text = fmt.Sprintf("Hello%s! Your Famli account was created successfully. Access: https://famli.me/my-box", getNameGreeting(toName))
+`, logo, getNameGreeting(toName), s.appURL)
+		text = fmt.Sprintf("Hello%s! Your Famli account was created successfully. Access: %s/my-box", getNameGreeting(toName), s.appURL)
 	} else {
 		subject = "🏠 Bem-vindo ao Famli!"
 		html = fmt.Sprintf(`
@@ -387,7 +415,7 @@ func (s *Service) SendWelcome(to, toName, locale string) error {
                 </p>
                 
                 <div style="text-align: center; margin: 32px 0;">
-                    <a href="https://famli.me/minha-caixa" style="display: inline-block; background: #e07b39; color: white; padding: 16px 36px; text-decoration: none; border-radius: 12px; font-weight: 700; font-size: 17px;">
+                    <a href="%s/minha-caixa" style="display: inline-block; background: #e07b39; color: white; padding: 16px 36px; text-decoration: none; border-radius: 12px; font-weight: 700; font-size: 17px;">
                         Acessar Minha Caixa
                     </a>
                 </div>
@@ -401,9 +429,306 @@ func (s *Service) SendWelcome(to, toName, locale string) error {
     </table>
 </body>
 </html>
-`, logo, getNameGreeting(toName))
-		text = fmt.Sprintf("Olá%s! Sua conta Famli foi criada com sucesso. Acesse: https://famli.me/minha-caixa", getNameGreeting(toName))
+`, logo, getNameGreeting(toName), s.appURL)
+		text = fmt.Sprintf("Olá%s! Sua conta Famli foi criada com sucesso. Acesse: %s/minha-caixa", getNameGreeting(toName), s.appURL)
+	}
+
+	return s.Send(&Email{
+		To:      to,
+		ToName:  toName,
+		Subject: subject,
+		HTML:    html,
+		Text:    text,
+	})
+}
+
+// LoginAlert contém os dados de um login suspeito para o email de alerta
+type LoginAlert struct {
+	Device    string    // Descrição do dispositivo (ex: "Chrome · Windows")
+	Country   string    // Código do país (ex: "BR"), vazio se desconhecido
+	IP        string    // IP mascarado
+	Time      time.Time // Momento do login
+	ResetLink string    // Link para redefinir a senha caso não tenha sido o usuário
+}
+
+// SendLoginAlert avisa o usuário sobre um login de novo dispositivo ou país
+// locale: idioma do usuário ("pt-BR", "en", etc.)
+func (s *Service) SendLoginAlert(to, toName, locale string, alert LoginAlert) error {
+	var subject, title, intro, notYou, button, labelDevice, labelCountry, labelIP, labelTime, signature string
+
+	country := alert.Country
+	if englishTemplate(locale) {
+		subject = "🔔 New sign-in to your Famli account"
+		title = "Hello%s!"
+		intro = "We noticed a new sign-in to your Famli account from a device or location you haven't used before."
+		notYou = "If this was you, no action is needed. If you don't recognize this sign-in, reset your password right away."
+		button = "Reset My Password"
+		labelDevice, labelCountry, labelIP, labelTime = "Device", "Country", "IP", "When"
+		signature = "The Famli Team"
+		if country == "" {
+			country = "Unknown"
+		}
+	} else {
+		subject = "🔔 Novo acesso à sua conta Famli"
+		title = "Olá%s!"
+		intro = "Identificamos um novo acesso à sua conta Famli a partir de um dispositivo ou local que você ainda não tinha usado."
+		notYou = "Se foi você, não precisa fazer nada. Se você não reconhece este acesso, redefina sua senha imediatamente."
+		button = "Redefinir Minha Senha"
+		labelDevice, labelCountry, labelIP, labelTime = "Dispositivo", "País", "IP", "Quando"
+		signature = "Equipe Famli"
+		if country == "" {
+			country = "Desconhecido"
+		}
+	}
+
+	when := alert.Time.UTC().Format("02/01/2006 15:04 UTC")
+	greeting := fmt.Sprintf(title, getNameGreeting(toName))
+
+	html := fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <link href="https://fonts.googleapis.com/css2?family=Nunito:wght@400;600;700&display=swap" rel="stylesheet">
+</head>
+<body style="margin: 0; padding: 0; font-family: 'Nunito', -apple-system, BlinkMacSystemFont, sans-serif; background-color: #faf8f5;">
+    <table width="100%%" cellpadding="0" cellspacing="0" style="max-width: 600px; margin: 0 auto; padding: 24px;">
+        <tr>
+            <td style="background: white; padding: 40px; border-radius: 20px; box-shadow: 0 4px 24px rgba(44, 42, 38, 0.08);">
+                <h2 style="color: #2c2a26; margin: 0 0 20px; font-size: 24px; font-weight: 600;">%s</h2>
+
+                <p style="color: #5c584f; font-size: 17px; line-height: 1.6;">%s</p>
+
+                <table cellpadding="6" cellspacing="0" style="color: #5c584f; font-size: 15px; margin: 16px 0;">
+                    <tr><td><strong>%s</strong></td><td>%s</td></tr>
+                    <tr><td><strong>%s</strong></td><td>%s</td></tr>
+                    <tr><td><strong>%s</strong></td><td>%s</td></tr>
+                    <tr><td><strong>%s</strong></td><td>%s</td></tr>
+                </table>
+
+                <p style="color: #5c584f; font-size: 17px; line-height: 1.6;">%s</p>
+
+                <div style="text-align: center; margin: 32px 0;">
+                    <a href="%s" style="display: inline-block; background: #e07b39; color: white; padding: 16px 36px; text-decoration: none; border-radius: 12px; font-weight: 700; font-size: 17px;">
+                        %s
+                    </a>
+                </div>
+
+                <p style="color: #6b665c; font-size: 15px;">
+                    <strong style="color: #2d5a47;">%s</strong>
+                </p>
+            </td>
+        </tr>
+    </table>
+</body>
+</html>
+`, template.HTMLEscapeString(greeting), intro,
+		labelDevice, template.HTMLEscapeString(alert.Device),
+		labelCountry, template.HTMLEscapeString(country),
+		labelIP, template.HTMLEscapeString(alert.IP),
+		labelTime, when,
+		notYou, alert.ResetLink, button, signature)
+
+	text := fmt.Sprintf("%s\n\n%s\n\n%s: %s\n%s: %s\n%s: %s\n%s: %s\n\n%s\n%s\n\n--\n%s\n",
+		greeting, intro,
+		labelDevice, alert.Device, labelCountry, country, labelIP, alert.IP, labelTime, when,
+		notYou, alert.ResetLink, signature)
+
+	return s.Send(&Email{
+		To:      to,
+		ToName:  toName,
+		Subject: subject,
+		HTML:    html,
+		Text:    text,
+	})
+}
+
+// SendNotice envia um aviso em texto simples
+// Usado para pessoas de confiança (quando preferem email ou WhatsApp/SMS
+// falham) e pela central de notificações.
+// message: texto já pronto do aviso (pode conter quebras de linha)
+func (s *Service) SendNotice(to, toName, message, locale string) error {
+	subject := "💚 Aviso do Famli"
+	if englishTemplate(locale) {
+		subject = "💚 A notice from Famli"
+	}
+	return s.Send(noticeEmail(to, toName, subject, message, locale))
+}
+
+// SendReply responde a um email recebido pelo gateway (internal/inbound)
+// replyTo é o endereço do gateway, para que a resposta continue a conversa.
+func (s *Service) SendReply(to, replyTo, subject, message, locale string) error {
+	reply := noticeEmail(to, "", subject, message, locale)
+	reply.ReplyTo = replyTo
+	return s.Send(reply)
+}
+
+// noticeEmail monta um email de texto simples com a assinatura da equipe
+func noticeEmail(to, toName, subject, message, locale string) *Email {
+	signature := "Equipe Famli"
+	if englishTemplate(locale) {
+		signature = "The Famli Team"
+	}
+
+	// Remover a marcação do WhatsApp (*negrito* e _itálico_) para o email
+	plain := strings.NewReplacer("*", "", "_", "").Replace(message)
+	body := strings.ReplaceAll(template.HTMLEscapeString(plain), "\n", "<br>")
+
+	html := fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+</head>
+<body style="margin: 0; padding: 0; font-family: 'Nunito', -apple-system, BlinkMacSystemFont, sans-serif; background-color: #faf8f5;">
+    <table width="100%%" cellpadding="0" cellspacing="0" style="max-width: 600px; margin: 0 auto; padding: 24px;">
+        <tr>
+            <td style="background: white; padding: 40px; border-radius: 20px; box-shadow: 0 4px 24px rgba(44, 42, 38, 0.08);">
+                <p style="color: #5c584f; font-size: 17px; line-height: 1.6;">%s</p>
+                <p style="color: #6b665c; font-size: 15px;">
+                    <strong style="color: #2d5a47;">%s</strong>
+                </p>
+            </td>
+        </tr>
+    </table>
+</body>
+</html>
+`, body, signature)
+
+	return &Email{
+		To:      to,
+		ToName:  toName,
+		Subject: subject,
+		HTML:    html,
+		Text:    plain + "\n\n--\n" + signature + "\n",
 	}
+}
+
+// FeedbackAlert contém os dados de um feedback para o aviso à equipe
+type FeedbackAlert struct {
+	Type      string // suggestion, problem, praise, question
+	Message   string // Texto do feedback ou da nova mensagem
+	UserEmail string // Email mascarado de quem enviou
+	Page      string // Página onde o usuário estava
+	IsReply   bool   // Nova mensagem do usuário em uma conversa existente
+	AdminLink string // Link do painel administrativo
+}
+
+// SendFeedbackAlert avisa a equipe sobre um feedback novo ou uma nova mensagem
+// Sempre em português (equipe interna).
+func (s *Service) SendFeedbackAlert(to string, alert FeedbackAlert) error {
+	subject := fmt.Sprintf("📝 Novo feedback (%s)", alert.Type)
+	intro := "Um usuário enviou um novo feedback."
+	if alert.IsReply {
+		subject = fmt.Sprintf("💬 Nova mensagem em feedback (%s)", alert.Type)
+		intro = "Um usuário respondeu a uma conversa de feedback."
+	}
+
+	html := fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+</head>
+<body style="margin: 0; padding: 0; font-family: 'Nunito', -apple-system, BlinkMacSystemFont, sans-serif; background-color: #faf8f5;">
+    <table width="100%%" cellpadding="0" cellspacing="0" style="max-width: 600px; margin: 0 auto; padding: 24px;">
+        <tr>
+            <td style="background: white; padding: 40px; border-radius: 20px; box-shadow: 0 4px 24px rgba(44, 42, 38, 0.08);">
+                <p style="color: #5c584f; font-size: 17px; line-height: 1.6;">%s</p>
+
+                <table cellpadding="6" cellspacing="0" style="color: #5c584f; font-size: 15px; margin: 16px 0;">
+                    <tr><td><strong>Tipo</strong></td><td>%s</td></tr>
+                    <tr><td><strong>Usuário</strong></td><td>%s</td></tr>
+                    <tr><td><strong>Página</strong></td><td>%s</td></tr>
+                </table>
+
+                <blockquote style="color: #2c2a26; font-size: 16px; line-height: 1.6; border-left: 4px solid #e07b39; margin: 16px 0; padding: 8px 16px;">%s</blockquote>
+
+                <div style="text-align: center; margin: 32px 0;">
+                    <a href="%s" style="display: inline-block; background: #e07b39; color: white; padding: 16px 36px; text-decoration: none; border-radius: 12px; font-weight: 700; font-size: 17px;">
+                        Abrir no painel
+                    </a>
+                </div>
+            </td>
+        </tr>
+    </table>
+</body>
+</html>
+`, intro, template.HTMLEscapeString(alert.Type), template.HTMLEscapeString(alert.UserEmail),
+		template.HTMLEscapeString(alert.Page),
+		strings.ReplaceAll(template.HTMLEscapeString(alert.Message), "\n", "<br>"),
+		alert.AdminLink)
+
+	text := fmt.Sprintf("%s\n\nTipo: %s\nUsuário: %s\nPágina: %s\n\n%s\n\n%s\n",
+		intro, alert.Type, alert.UserEmail, alert.Page, alert.Message, alert.AdminLink)
+
+	return s.Send(&Email{
+		To:      to,
+		Subject: subject,
+		HTML:    html,
+		Text:    text,
+	})
+}
+
+// SendFeedbackReply envia ao usuário a resposta da equipe a um feedback
+// original: mensagem do feedback; reply: resposta da equipe
+func (s *Service) SendFeedbackReply(to, toName, locale, original, reply string) error {
+	subject := "💬 Resposta ao seu feedback no Famli"
+	title := "Olá%s!"
+	intro := "A equipe Famli respondeu ao seu feedback:"
+	youWrote := "Você escreveu:"
+	outro := "Você pode ver a conversa e responder pelo Famli, em Perfil."
+	signature := "Equipe Famli"
+	if englishTemplate(locale) {
+		subject = "💬 Reply to your Famli feedback"
+		title = "Hello%s!"
+		intro = "The Famli team replied to your feedback:"
+		youWrote = "You wrote:"
+		outro = "You can see the conversation and reply in Famli, under Profile."
+		signature = "The Famli Team"
+	}
+	greeting := fmt.Sprintf(title, getNameGreeting(toName))
+
+	html := fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+</head>
+<body style="margin: 0; padding: 0; font-family: 'Nunito', -apple-system, BlinkMacSystemFont, sans-serif; background-color: #faf8f5;">
+    <table width="100%%" cellpadding="0" cellspacing="0" style="max-width: 600px; margin: 0 auto; padding: 24px;">
+        <tr>
+            <td style="background: white; padding: 40px; border-radius: 20px; box-shadow: 0 4px 24px rgba(44, 42, 38, 0.08);">
+                <h2 style="color: #2c2a26; margin: 0 0 20px; font-size: 24px; font-weight: 600;">%s</h2>
+
+                <p style="color: #5c584f; font-size: 17px; line-height: 1.6;">%s</p>
+                <blockquote style="color: #2c2a26; font-size: 16px; line-height: 1.6; border-left: 4px solid #2d5a47; margin: 16px 0; padding: 8px 16px;">%s</blockquote>
+
+                <p style="color: #6b665c; font-size: 15px; line-height: 1.6;">%s</p>
+                <blockquote style="color: #6b665c; font-size: 15px; line-height: 1.6; border-left: 4px solid #e8e4dc; margin: 16px 0; padding: 8px 16px;">%s</blockquote>
+
+                <p style="color: #5c584f; font-size: 17px; line-height: 1.6;">%s</p>
+
+                <p style="color: #6b665c; font-size: 15px;">
+                    <strong style="color: #2d5a47;">%s</strong>
+                </p>
+            </td>
+        </tr>
+    </table>
+</body>
+</html>
+`, template.HTMLEscapeString(greeting), intro,
+		strings.ReplaceAll(template.HTMLEscapeString(reply), "\n", "<br>"),
+		youWrote,
+		strings.ReplaceAll(template.HTMLEscapeString(original), "\n", "<br>"),
+		outro, signature)
+
+	text := fmt.Sprintf("%s\n\n%s\n\n%s\n\n%s\n%s\n\n%s\n\n--\n%s\n",
+		greeting, intro, reply, youWrote, original, outro, signature)
 
 	return s.Send(&Email{
 		To:      to,
@@ -422,6 +747,7 @@ func (s *Service) SendWelcome(to, toName, locale string) error {
 const (
 	MailtrapSandboxBaseURL = "https://sandbox.api.mailtrap.io/api/send"
 	MailtrapProductionURL  = "https://send.api.mailtrap.io/api/send"
+	MailtrapAccountsURL    = "https://mailtrap.io/api/accounts" // Usada só para conferir o token
 )
 
 // MailtrapProvider implementa o envio via Mailtrap
@@ -436,11 +762,10 @@ type MailtrapProvider struct {
 }
 
 // NewMailtrapProvider cria um novo provider Mailtrap
-func NewMailtrapProvider() *MailtrapProvider {
+func NewMailtrapProvider(config *Config) *MailtrapProvider {
 	// Verifica se deve usar sandbox (default: false para produção)
-	sandbox := os.Getenv("MAILTRAP_SANDBOX")
-	isSandbox := sandbox == "true" || sandbox == "1"
-	inboxID := os.Getenv("MAILTRAP_INBOX_ID") // Necessário apenas para sandbox
+	isSandbox := config.MailtrapSandbox
+	inboxID := config.MailtrapInboxID // Necessário apenas para sandbox
 
 	apiURL := MailtrapProductionURL
 	if isSandbox {
@@ -453,11 +778,11 @@ func NewMailtrapProvider() *MailtrapProvider {
 	}
 
 	return &MailtrapProvider{
-		apiToken:  os.Getenv("MAILTRAP_API_TOKEN"),
+		apiToken:  config.MailtrapAPIToken,
 		apiURL:    apiURL,
 		inboxID:   inboxID,
-		from:      os.Getenv("EMAIL_FROM"),
-		fromName:  os.Getenv("EMAIL_FROM_NAME"),
+		from:      config.From,
+		fromName:  config.FromName,
 		isSandbox: isSandbox,
 		client: &http.Client{
 			Timeout: 30 * time.Second,
@@ -478,6 +803,33 @@ func (p *MailtrapProvider) IsSandbox() bool {
 	return p.isSandbox
 }
 
+// Ping confere o token listando as contas do Mailtrap (não envia email)
+func (p *MailtrapProvider) Ping(ctx context.Context) error {
+	if p.apiToken == "" {
+		return fmt.Errorf("MAILTRAP_API_TOKEN not configured")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, MailtrapAccountsURL, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.apiToken)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error reaching mailtrap: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("mailtrap rejected the API token (status %d)", resp.StatusCode)
+	case resp.StatusCode >= 400:
+		return fmt.Errorf("mailtrap error (status %d)", resp.StatusCode)
+	}
+	return nil
+}
+
 // Send envia um email via Mailtrap
 func (p *MailtrapProvider) Send(email *Email) error {
 	if p.apiToken == "" {
@@ -490,7 +842,12 @@ func (p *MailtrapProvider) Send(email *Email) error {
 	}
 
 	// Gerar Message-ID único para evitar filtros de spam
-	messageID := fmt.Sprintf("<%d.%s@famli.me>", time.Now().UnixNano(), generateRandomID(12))
+	// (no domínio do remetente)
+	domain := "famli.me"
+	if at := strings.LastIndex(p.from, "@"); at >= 0 && at < len(p.from)-1 {
+		domain = p.from[at+1:]
+	}
+	messageID := fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), generateRandomID(12), domain)
 
 	// Payload da API Mailtrap
 	payload := map[string]interface{}{
@@ -511,6 +868,9 @@ func (p *MailtrapProvider) Send(email *Email) error {
 			"Message-ID": messageID,
 		},
 	}
+	if email.ReplyTo != "" {
+		payload["reply_to"] = map[string]string{"email": email.ReplyTo}
+	}
 
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
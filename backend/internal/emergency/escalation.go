@@ -0,0 +1,424 @@
+// =============================================================================
+// FAMLI - Escalonamento de Emergência
+// =============================================================================
+// O dono define a ordem em que as pessoas de confiança são chamadas quando a
+// emergência é acionada, e o prazo de cada uma para confirmar que recebeu o
+// aviso. A caminhada pela lista fica no worker dos avisos
+// (whatsapp/escalation.go).
+//
+// Endpoints (usuário autenticado):
+// - GET    /api/emergency-escalation        - lista e último escalonamento
+// - PUT    /api/emergency-escalation        - define a lista
+// - DELETE /api/emergency-escalation        - remove a lista (volta o aviso a todos)
+// - POST   /api/emergency-escalation/cancel - encerra o escalonamento em andamento
+//
+// Endpoints públicos (token no link do aviso, um por pessoa chamada):
+// - GET  /api/emergency-ack/{token} - situação do alerta
+// - POST /api/emergency-ack/{token} - confirma o recebimento
+//
+// Navegadores (Accept: text/html) recebem uma página HTML autônoma com o
+// botão de confirmação: o link chega por WhatsApp, SMS ou email e abrir o
+// link não confirma nada (prévias de links não disparam a confirmação).
+// =============================================================================
+
+package emergency
+
+import (
+	"errors"
+	"html"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/i18n"
+	"famli/internal/notifications"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+const (
+	// maxChainLength limita as pessoas da lista de escalonamento
+	maxChainLength = 10
+
+	// defaultAckTimeout é o prazo para confirmar quando o dono não informa
+	defaultAckTimeout = 30
+
+	// minAckTimeout e maxAckTimeout limitam o prazo (em minutos)
+	minAckTimeout = 5
+	maxAckTimeout = 24 * 60
+)
+
+// chainPayload é o corpo de PUT /api/emergency-escalation
+type chainPayload struct {
+	GuardianIDs       []string `json:"guardian_ids"`        // Em ordem de chamada
+	AckTimeoutMinutes int      `json:"ack_timeout_minutes"` // 0 = 30 minutos
+}
+
+// chainGuardian é uma pessoa da lista na resposta
+type chainGuardian struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// escalationResponse é a resposta de GET /api/emergency-escalation
+type escalationResponse struct {
+	Chain     *storage.EscalationChain     `json:"chain"`            // null sem lista
+	Guardians []chainGuardian              `json:"guardians"`        // Pessoas da lista, em ordem
+	Latest    *storage.EmergencyEscalation `json:"latest,omitempty"` // Último escalonamento (só no GET)
+}
+
+// GetEscalation retorna a lista de escalonamento e o último escalonamento
+//
+// Endpoint: GET /api/emergency-escalation
+func (h *Handler) GetEscalation(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+	guardians, err := h.store.GetGuardians(userID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "emergency_escalation.load_error")
+		return
+	}
+	names := guardianNames(guardians)
+
+	response := escalationResponse{Guardians: []chainGuardian{}}
+	chain, err := h.store.GetEscalationChain(userID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		apierror.Write(w, r, http.StatusInternalServerError, "emergency_escalation.load_error")
+		return
+	}
+	if chain != nil {
+		// Guardiões removidos depois de salvar a lista saem da resposta
+		ids := make([]string, 0, len(chain.GuardianIDs))
+		for _, id := range chain.GuardianIDs {
+			if name, ok := names[id]; ok {
+				ids = append(ids, id)
+				response.Guardians = append(response.Guardians, chainGuardian{ID: id, Name: name})
+			}
+		}
+		chain.GuardianIDs = ids
+		response.Chain = chain
+	}
+
+	latest, err := h.store.GetLatestEscalation(userID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		apierror.Write(w, r, http.StatusInternalServerError, "emergency_escalation.load_error")
+		return
+	}
+	if latest != nil {
+		for i := range latest.Steps {
+			latest.Steps[i].GuardianName = names[latest.Steps[i].GuardianID]
+		}
+		response.Latest = latest
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// SaveEscalation define a lista de escalonamento
+//
+// Endpoint: PUT /api/emergency-escalation
+//
+// Body: {"guardian_ids": ["grd_...", "grd_..."], "ack_timeout_minutes": 30}
+//
+// Um escalonamento em andamento continua com a lista de quando começou.
+func (h *Handler) SaveEscalation(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	var payload chainPayload
+	if err := security.DecodeJSONStrict(r.Body, &payload); err != nil {
+		apierror.WriteDecodeError(w, r, err, "emergency_escalation.invalid")
+		return
+	}
+
+	if payload.AckTimeoutMinutes == 0 {
+		payload.AckTimeoutMinutes = defaultAckTimeout
+	}
+	if payload.AckTimeoutMinutes < minAckTimeout || payload.AckTimeoutMinutes > maxAckTimeout {
+		apierror.Write(w, r, http.StatusBadRequest, "emergency_escalation.invalid_timeout")
+		return
+	}
+
+	guardians, err := h.store.GetGuardians(userID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "emergency_escalation.save_error")
+		return
+	}
+	names := guardianNames(guardians)
+	if len(payload.GuardianIDs) == 0 || len(payload.GuardianIDs) > maxChainLength {
+		apierror.Write(w, r, http.StatusBadRequest, "emergency_escalation.invalid_guardians")
+		return
+	}
+	seen := make(map[string]bool, len(payload.GuardianIDs))
+	for _, id := range payload.GuardianIDs {
+		if _, ok := names[id]; !ok || seen[id] {
+			apierror.Write(w, r, http.StatusBadRequest, "emergency_escalation.invalid_guardians")
+			return
+		}
+		seen[id] = true
+	}
+
+	chain := &storage.EscalationChain{
+		UserID:            userID,
+		GuardianIDs:       payload.GuardianIDs,
+		AckTimeoutMinutes: payload.AckTimeoutMinutes,
+	}
+	if err := h.store.SaveEscalationChain(chain); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "emergency_escalation.save_error")
+		return
+	}
+
+	h.logEscalationChange(r, "update", map[string]interface{}{
+		"guardians":           len(chain.GuardianIDs),
+		"ack_timeout_minutes": chain.AckTimeoutMinutes,
+	})
+
+	response := escalationResponse{Chain: chain, Guardians: make([]chainGuardian, 0, len(chain.GuardianIDs))}
+	for _, id := range chain.GuardianIDs {
+		response.Guardians = append(response.Guardians, chainGuardian{ID: id, Name: names[id]})
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// DeleteEscalation remove a lista: a emergência volta a avisar todos os
+// guardiões de uma vez
+//
+// Endpoint: DELETE /api/emergency-escalation
+func (h *Handler) DeleteEscalation(w http.ResponseWriter, r *http.Request) {
+	if err := h.store.DeleteEscalationChain(auth.GetUserID(r)); err != nil {
+		apierror.WriteStorage(w, r, err, apierror.StorageKeys{NotFound: "emergency_escalation.not_found", Internal: "emergency_escalation.save_error"})
+		return
+	}
+
+	h.logEscalationChange(r, "delete", nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CancelEscalation encerra o escalonamento em andamento (ex: alarme falso);
+// ninguém mais da lista é chamado
+//
+// Endpoint: POST /api/emergency-escalation/cancel
+func (h *Handler) CancelEscalation(w http.ResponseWriter, r *http.Request) {
+	escalation, err := h.store.GetLatestEscalation(auth.GetUserID(r))
+	if err != nil || escalation.Status != storage.EscalationActive {
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			apierror.Write(w, r, http.StatusInternalServerError, "emergency_escalation.save_error")
+			return
+		}
+		apierror.Write(w, r, http.StatusNotFound, "emergency_escalation.not_active")
+		return
+	}
+
+	escalation.Status = storage.EscalationCancelled
+	escalation.NextStepAt = nil
+	if err := h.store.UpdateEscalation(escalation, storage.EscalationActive); err != nil {
+		if errors.Is(err, storage.ErrVersionConflict) {
+			// Alguém confirmou (ou a lista acabou) nesse meio-tempo
+			apierror.Write(w, r, http.StatusNotFound, "emergency_escalation.not_active")
+			return
+		}
+		apierror.Write(w, r, http.StatusInternalServerError, "emergency_escalation.save_error")
+		return
+	}
+
+	h.logEscalationChange(r, "cancel", map[string]interface{}{"escalation_id": escalation.ID})
+	writeJSON(w, http.StatusOK, escalation)
+}
+
+// guardianNames mapeia o ID de cada guardião para o nome
+func guardianNames(guardians []*storage.Guardian) map[string]string {
+	names := make(map[string]string, len(guardians))
+	for _, guardian := range guardians {
+		names[guardian.ID] = guardian.Name
+	}
+	return names
+}
+
+// logEscalationChange registra a alteração na auditoria
+func (h *Handler) logEscalationChange(r *http.Request, action string, details map[string]interface{}) {
+	h.auditLogger.Log(security.AuditEvent{
+		Type:     security.EventEscalationChanged,
+		Severity: security.SeverityInfo,
+		UserID:   auth.GetUserID(r),
+		ClientIP: security.GetClientIP(r),
+		Resource: "emergency_escalation",
+		Action:   action,
+		Result:   "success",
+		Details:  details,
+	})
+}
+
+// =============================================================================
+// CONFIRMAÇÃO (link do aviso)
+// =============================================================================
+
+// ackTokenLength é o tamanho do token do link de confirmação (hex)
+const ackTokenLength = security.ShareTokenLength
+
+// ackResponse é a situação do alerta para quem recebeu o aviso
+type ackResponse struct {
+	Status             storage.EscalationStatus `json:"status"`
+	OwnerName          string                   `json:"owner_name"`
+	GuardianName       string                   `json:"guardian_name"`                  // Quem recebeu este link
+	AcknowledgedByName string                   `json:"acknowledged_by_name,omitempty"` // Quem confirmou
+	AcknowledgedAt     *time.Time               `json:"acknowledged_at,omitempty"`
+}
+
+// AckStatus mostra a situação do alerta (sem confirmar)
+//
+// Endpoint: GET /api/emergency-ack/{token}
+func (h *Handler) AckStatus(w http.ResponseWriter, r *http.Request) {
+	escalation, step, ok := h.findByAckToken(w, r)
+	if !ok {
+		return
+	}
+	h.writeAck(w, r, escalation, step)
+}
+
+// Acknowledge confirma o recebimento do aviso: ninguém mais da lista é chamado
+//
+// Endpoint: POST /api/emergency-ack/{token}
+//
+// Idempotente: confirmar de novo (ou depois de outra pessoa) só mostra a
+// situação. Depois do fim da lista a confirmação ainda vale.
+func (h *Handler) Acknowledge(w http.ResponseWriter, r *http.Request) {
+	escalation, step, ok := h.findByAckToken(w, r)
+	if !ok {
+		return
+	}
+
+	if from := escalation.Status; from == storage.EscalationActive || from == storage.EscalationExhausted {
+		now := time.Now()
+		escalation.Status = storage.EscalationAcknowledged
+		escalation.AcknowledgedBy = step.GuardianID
+		escalation.AcknowledgedAt = &now
+		escalation.NextStepAt = nil
+
+		err := h.store.UpdateEscalation(escalation, from)
+		switch {
+		case err == nil:
+			h.auditLogger.Log(security.AuditEvent{
+				Type:     security.EventEmergencyAcknowledged,
+				Severity: security.SeverityInfo,
+				UserID:   escalation.UserID,
+				ClientIP: security.GetClientIP(r),
+				Resource: "guardian:" + step.GuardianID,
+				Action:   "acknowledge",
+				Result:   "success",
+				Details:  map[string]interface{}{"escalation_id": escalation.ID},
+			})
+			notifications.Notify(escalation.UserID, storage.NotificationEmergency, "notify.emergency_acknowledged")
+		case errors.Is(err, storage.ErrVersionConflict):
+			// Outra confirmação (ou o dono) chegou antes: mostrar o estado atual
+			if escalation, step, ok = h.findByAckToken(w, r); !ok {
+				return
+			}
+		default:
+			apierror.Write(w, r, http.StatusInternalServerError, "emergency_escalation.save_error")
+			return
+		}
+	}
+
+	h.writeAck(w, r, escalation, step)
+}
+
+// findByAckToken busca o escalonamento e a pessoa do token da URL
+// Tokens inválidos respondem sempre o mesmo 404.
+func (h *Handler) findByAckToken(w http.ResponseWriter, r *http.Request) (*storage.EmergencyEscalation, *storage.EscalationStep, bool) {
+	token := chi.URLParam(r, "token")
+	if len(token) != ackTokenLength {
+		apierror.Write(w, r, http.StatusNotFound, "emergency_escalation.ack_not_found")
+		return nil, nil, false
+	}
+
+	tokenHash := security.HashToken(token)
+	escalation, err := h.store.GetEscalationByAckToken(tokenHash)
+	if err != nil {
+		apierror.Write(w, r, http.StatusNotFound, "emergency_escalation.ack_not_found")
+		return nil, nil, false
+	}
+
+	// O link não deve ser indexado nem guardado por proxies
+	w.Header().Set("X-Robots-Tag", "noindex, nofollow")
+	w.Header().Set("Cache-Control", "no-store")
+	return escalation, escalation.StepByAckToken(tokenHash), true
+}
+
+// writeAck responde a situação do alerta (página HTML para navegadores)
+func (h *Handler) writeAck(w http.ResponseWriter, r *http.Request, escalation *storage.EmergencyEscalation, step *storage.EscalationStep) {
+	response := ackResponse{Status: escalation.Status, AcknowledgedAt: escalation.AcknowledgedAt}
+	if owner, ok := h.store.GetUserByID(escalation.UserID); ok {
+		response.OwnerName = owner.Name
+	}
+	if guardians, err := h.store.GetGuardians(escalation.UserID); err == nil {
+		names := guardianNames(guardians)
+		response.GuardianName = names[step.GuardianID]
+		response.AcknowledgedByName = names[escalation.AcknowledgedBy]
+	}
+
+	if !strings.Contains(r.Header.Get("Accept"), "text/html") {
+		writeJSON(w, http.StatusOK, response)
+		return
+	}
+
+	// Os nomes são salvos escapados: voltar ao texto puro para o template
+	// não escapar duas vezes
+	data := ackPageData{
+		Lang:  i18n.GetLocale(r),
+		Title: i18n.Tr(r, "emergency_escalation.page_title"),
+		Intro: i18n.Trf(r, "emergency_escalation.page_intro", i18n.Vars{"owner": html.UnescapeString(response.OwnerName)}),
+	}
+	switch escalation.Status {
+	case storage.EscalationAcknowledged:
+		data.Result = i18n.Trf(r, "emergency_escalation.page_acknowledged", i18n.Vars{"guardian": html.UnescapeString(response.AcknowledgedByName)})
+	case storage.EscalationCancelled:
+		data.Result = i18n.Tr(r, "emergency_escalation.page_cancelled")
+	default:
+		data.Button = i18n.Tr(r, "emergency_escalation.page_confirm")
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	ackTemplate.Execute(w, data)
+}
+
+// ackPageData são os textos da página de confirmação
+type ackPageData struct {
+	Lang   string
+	Title  string
+	Intro  string
+	Button string // Vazio quando não há o que confirmar
+	Result string
+}
+
+// ackTemplate é a página de confirmação (sem JavaScript: o botão envia um
+// formulário para o mesmo endereço)
+var ackTemplate = template.Must(template.New("emergency-ack").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex, nofollow">
+<title>{{.Title}}</title>
+<style>
+  body { font-family: -apple-system, "Segoe UI", Roboto, Arial, sans-serif; margin: 0; padding: 2rem 1rem; color: #1f2937; background: #f9fafb; }
+  main { max-width: 28rem; margin: 0 auto; padding: 1.5rem; background: #fff; border: 1px solid #e5e7eb; border-radius: .75rem; }
+  h1 { margin: 0 0 1rem; font-size: 1.25rem; color: #b91c1c; }
+  p { line-height: 1.5; }
+  button { width: 100%; padding: .875rem; font-size: 1rem; font-weight: 600; color: #fff; background: #b91c1c; border: 0; border-radius: .5rem; cursor: pointer; }
+  .result { font-weight: 600; }
+</style>
+</head>
+<body>
+<main>
+  <h1>{{.Title}}</h1>
+  <p>{{.Intro}}</p>
+  {{if .Button}}<form method="post"><button type="submit">{{.Button}}</button></form>{{end}}
+  {{if .Result}}<p class="result">{{.Result}}</p>{{end}}
+</main>
+</body>
+</html>
+`))
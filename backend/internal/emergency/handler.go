@@ -0,0 +1,472 @@
+// =============================================================================
+// FAMLI - Cartão de Emergência
+// =============================================================================
+// Um link público e leve com os dados mínimos para socorristas (nome, tipo
+// sanguíneo, contatos de emergência e medicamentos críticos), sem o fluxo do
+// guardião (PIN, portal). O usuário escolhe o que entra: campos vazios não
+// aparecem. O link cabe num QR code ou num cartão de carteira impresso.
+// Com include_schedule, o cartão mostra também a agenda das rotinas pessoais
+// (remédio, dose e horários), para quem cuida saber o que dar e quando.
+// Com include_legal, mostra onde estão os documentos jurídicos (testamento,
+// procuração...) e quem procurar, sem o número dos documentos.
+//
+// Endpoints (usuário autenticado):
+// - GET    /api/emergency-card        - cartão atual (sem o link)
+// - PUT    /api/emergency-card        - salva os campos (o primeiro gera o link)
+// - POST   /api/emergency-card/rotate - troca o link (o anterior deixa de valer)
+// - DELETE /api/emergency-card        - remove o cartão e o link
+//
+// Endpoints públicos (token no link):
+// - GET /api/emergency-card/{token}       - dados do cartão (JSON)
+// - GET /api/emergency-card/{token}/print - cartão de carteira para imprimir (HTML)
+//
+// Segurança:
+// - O token é aleatório (256 bits); apenas o hash fica no banco
+// - Os campos são criptografados no banco
+// - Rate limit e CAPTCHA das rotas públicas dos links contra varredura
+// =============================================================================
+
+package emergency
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/i18n"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+const (
+	// maxContacts limita os contatos de emergência do cartão
+	maxContacts = 5
+
+	// maxMedications limita os medicamentos do cartão
+	maxMedications = 10
+
+	// maxMedicationLength limita cada medicamento (nome e dose)
+	maxMedicationLength = 100
+)
+
+// bloodTypes são os tipos sanguíneos aceitos
+var bloodTypes = map[string]bool{
+	"A+": true, "A-": true, "B+": true, "B-": true,
+	"AB+": true, "AB-": true, "O+": true, "O-": true,
+}
+
+// Handler gerencia o cartão de emergência
+type Handler struct {
+	store       storage.Store
+	auditLogger *security.AuditLogger
+}
+
+// NewHandler cria uma nova instância do handler do cartão de emergência
+func NewHandler(store storage.Store) *Handler {
+	return &Handler{
+		store:       store,
+		auditLogger: security.GetAuditLogger(),
+	}
+}
+
+// cardPayload é o corpo de PUT /api/emergency-card
+type cardPayload struct {
+	Name        string                     `json:"name"`
+	BloodType   string                     `json:"blood_type"`
+	Contacts    []storage.EmergencyContact `json:"contacts"`
+	Medications []string                   `json:"medications"`
+
+	// IncludeSchedule mostra a agenda das rotinas pessoais no cartão
+	IncludeSchedule bool `json:"include_schedule"`
+
+	// IncludeLegal mostra onde estão os documentos jurídicos no cartão
+	IncludeLegal bool `json:"include_legal"`
+}
+
+// publicCard é o que o link público mostra
+type publicCard struct {
+	Name        string                     `json:"name,omitempty"`
+	BloodType   string                     `json:"blood_type,omitempty"`
+	Contacts    []storage.EmergencyContact `json:"contacts,omitempty"`
+	Medications []string                   `json:"medications,omitempty"`
+	Schedule    []scheduleEntry            `json:"schedule,omitempty"`
+	Legal       []legalEntry               `json:"legal,omitempty"`
+	UpdatedAt   time.Time                  `json:"updated_at"`
+}
+
+// scheduleEntry é uma rotina agendada mostrada no cartão
+type scheduleEntry struct {
+	Title      string   `json:"title"`
+	Medication string   `json:"medication,omitempty"`
+	Dose       string   `json:"dose,omitempty"`
+	Times      []string `json:"times"`
+	Weekdays   []int    `json:"weekdays,omitempty"` // 0=domingo; vazio=todos os dias
+}
+
+// legalEntry é um documento jurídico mostrado no cartão
+type legalEntry struct {
+	Kind            storage.DocumentKind `json:"kind"`
+	Title           string               `json:"title"`
+	StoredAt        string               `json:"stored_at,omitempty"`
+	LawyerName      string               `json:"lawyer_name,omitempty"`
+	LawyerContact   string               `json:"lawyer_contact,omitempty"`
+	ExecutorName    string               `json:"executor_name,omitempty"`
+	ExecutorContact string               `json:"executor_contact,omitempty"`
+}
+
+// Get retorna o cartão do usuário
+// O link em si só é exibido quando gerado.
+//
+// Endpoint: GET /api/emergency-card
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	card, err := h.store.GetEmergencyCard(auth.GetUserID(r))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			writeJSON(w, http.StatusOK, map[string]interface{}{"enabled": false})
+			return
+		}
+		apierror.Write(w, r, http.StatusInternalServerError, "emergency_card.load_error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, cardResponse(card, ""))
+}
+
+// Save salva os campos do cartão
+// Salvar o primeiro cartão gera o link (201, com url e print_url).
+//
+// Endpoint: PUT /api/emergency-card
+func (h *Handler) Save(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	var payload cardPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "emergency_card.invalid_data")
+		return
+	}
+	if errKey := payload.normalize(); errKey != "" {
+		apierror.Write(w, r, http.StatusBadRequest, errKey)
+		return
+	}
+
+	now := time.Now()
+	card, err := h.store.GetEmergencyCard(userID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		apierror.Write(w, r, http.StatusInternalServerError, "emergency_card.save_error")
+		return
+	}
+
+	status := http.StatusOK
+	token := ""
+	if card == nil {
+		token, err = generateToken()
+		if err != nil {
+			apierror.Write(w, r, http.StatusInternalServerError, "emergency_card.save_error")
+			return
+		}
+		card = &storage.EmergencyCard{UserID: userID, TokenHash: hashToken(token), CreatedAt: now}
+		status = http.StatusCreated
+	}
+
+	card.Name = payload.Name
+	card.BloodType = payload.BloodType
+	card.Contacts = payload.Contacts
+	card.Medications = payload.Medications
+	card.IncludeSchedule = payload.IncludeSchedule
+	card.IncludeLegal = payload.IncludeLegal
+	card.UpdatedAt = now
+	if err := h.store.SaveEmergencyCard(card); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "emergency_card.save_error")
+		return
+	}
+
+	h.auditLogger.LogDataAccess(userID, security.GetClientIP(r), "emergency-card", "update", "success")
+
+	if token != "" {
+		writeJSON(w, status, cardResponse(card, getBaseURL(r)+"/api/emergency-card/"+token))
+		return
+	}
+	writeJSON(w, status, cardResponse(card, ""))
+}
+
+// Rotate troca o link do cartão (o anterior deixa de funcionar)
+//
+// Endpoint: POST /api/emergency-card/rotate
+func (h *Handler) Rotate(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	card, err := h.store.GetEmergencyCard(userID)
+	if err != nil {
+		apierror.WriteStorage(w, r, err, apierror.StorageKeys{NotFound: "emergency_card.not_found", Internal: "emergency_card.save_error"})
+		return
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "emergency_card.save_error")
+		return
+	}
+	card.TokenHash = hashToken(token)
+	card.LastAccessedAt = nil
+	if err := h.store.SaveEmergencyCard(card); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "emergency_card.save_error")
+		return
+	}
+
+	h.auditLogger.LogDataAccess(userID, security.GetClientIP(r), "emergency-card", "rotate", "success")
+
+	writeJSON(w, http.StatusOK, cardResponse(card, getBaseURL(r)+"/api/emergency-card/"+token))
+}
+
+// Delete remove o cartão e desativa o link
+//
+// Endpoint: DELETE /api/emergency-card
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	if err := h.store.DeleteEmergencyCard(userID); err != nil {
+		apierror.WriteStorage(w, r, err, apierror.StorageKeys{NotFound: "emergency_card.not_found", Internal: "emergency_card.save_error"})
+		return
+	}
+
+	h.auditLogger.LogDataAccess(userID, security.GetClientIP(r), "emergency-card", "delete", "success")
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": i18n.Tr(r, "emergency_card.deleted")})
+}
+
+// Public retorna os dados do cartão do token
+//
+// Endpoint: GET /api/emergency-card/{token}
+func (h *Handler) Public(w http.ResponseWriter, r *http.Request) {
+	card, ok := h.findByToken(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, publicCard{
+		Name:        card.Name,
+		BloodType:   card.BloodType,
+		Contacts:    card.Contacts,
+		Medications: card.Medications,
+		Schedule:    h.schedule(card),
+		Legal:       h.legal(card),
+		UpdatedAt:   card.UpdatedAt,
+	})
+}
+
+// schedule lista as rotinas pessoais agendadas do dono, quando o cartão as
+// inclui (itens da família e arquivados ficam de fora), por horário
+func (h *Handler) schedule(card *storage.EmergencyCard) []scheduleEntry {
+	if !card.IncludeSchedule {
+		return nil
+	}
+	items, err := h.store.ListScheduledRoutines(&storage.BoxItemFilter{UserID: card.UserID, IncludePersonal: true})
+	if err != nil {
+		return nil
+	}
+
+	entries := make([]scheduleEntry, 0, len(items))
+	for _, item := range items {
+		if len(item.Schedule.Times) == 0 {
+			continue
+		}
+		entries = append(entries, scheduleEntry{
+			Title:      item.Title,
+			Medication: item.Schedule.Medication,
+			Dose:       item.Schedule.Dose,
+			Times:      item.Schedule.Times,
+			Weekdays:   item.Schedule.Weekdays,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Times[0] != entries[j].Times[0] {
+			return entries[i].Times[0] < entries[j].Times[0]
+		}
+		return entries[i].Title < entries[j].Title
+	})
+	return entries
+}
+
+// legal lista os documentos jurídicos do dono, quando o cartão os inclui
+// (itens arquivados ficam de fora), na ordem do checklist
+func (h *Handler) legal(card *storage.EmergencyCard) []legalEntry {
+	if !card.IncludeLegal {
+		return nil
+	}
+	items, err := h.store.GetBoxItems(card.UserID)
+	if err != nil {
+		return nil
+	}
+
+	entries := make([]legalEntry, 0)
+	for _, kind := range storage.LegalDocumentKinds {
+		for _, item := range items {
+			if item.Type != storage.ItemTypeDocument || item.Document == nil || item.Document.Kind != kind || item.ArchivedAt != nil {
+				continue
+			}
+			entry := legalEntry{Kind: kind, Title: item.Title}
+			if legal := item.Document.Legal; legal != nil {
+				entry.StoredAt = legal.StoredAt
+				entry.LawyerName = legal.LawyerName
+				entry.LawyerContact = legal.LawyerContact
+				entry.ExecutorName = legal.ExecutorName
+				entry.ExecutorContact = legal.ExecutorContact
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// findByToken busca o cartão do token da URL e registra o acesso
+// Tokens inválidos ou removidos respondem sempre o mesmo 404.
+func (h *Handler) findByToken(w http.ResponseWriter, r *http.Request) (*storage.EmergencyCard, bool) {
+	token := chi.URLParam(r, "token")
+	if len(token) != 64 {
+		apierror.Write(w, r, http.StatusNotFound, "emergency_card.not_found")
+		return nil, false
+	}
+
+	card, err := h.store.GetEmergencyCardByToken(hashToken(token))
+	if err != nil {
+		apierror.Write(w, r, http.StatusNotFound, "emergency_card.not_found")
+		return nil, false
+	}
+
+	h.store.TouchEmergencyCard(card.UserID, time.Now())
+	h.auditLogger.LogDataAccess(card.UserID, security.GetClientIP(r), "emergency-card/public", "read", "success")
+
+	// O link não deve ser indexado nem guardado por proxies
+	w.Header().Set("X-Robots-Tag", "noindex, nofollow")
+	return card, true
+}
+
+// normalize sanitiza os campos e retorna a chave do erro de validação
+func (p *cardPayload) normalize() string {
+	p.Name = security.SanitizeName(p.Name)
+
+	p.BloodType = strings.ToUpper(strings.ReplaceAll(p.BloodType, " ", ""))
+	if p.BloodType != "" && !bloodTypes[p.BloodType] {
+		return "emergency_card.invalid_blood_type"
+	}
+
+	if len(p.Contacts) > maxContacts {
+		return "emergency_card.too_many_contacts"
+	}
+	contacts := make([]storage.EmergencyContact, 0, len(p.Contacts))
+	for _, contact := range p.Contacts {
+		name := security.SanitizeName(contact.Name)
+		phone, err := security.ValidatePhone(strings.TrimSpace(contact.Phone))
+		if name == "" || phone == "" || err != nil {
+			return "emergency_card.invalid_contact"
+		}
+		contacts = append(contacts, storage.EmergencyContact{
+			Name:         name,
+			Relationship: security.SanitizeText(contact.Relationship, security.MaxNameLength),
+			Phone:        phone,
+		})
+	}
+	p.Contacts = contacts
+
+	if len(p.Medications) > maxMedications {
+		return "emergency_card.too_many_medications"
+	}
+	medications := make([]string, 0, len(p.Medications))
+	for _, medication := range p.Medications {
+		if len([]rune(strings.TrimSpace(medication))) > maxMedicationLength {
+			return "emergency_card.invalid_medication"
+		}
+		if medication = security.SanitizeText(medication, 0); medication != "" {
+			medications = append(medications, medication)
+		}
+	}
+	p.Medications = medications
+
+	if p.Name == "" && p.BloodType == "" && len(p.Contacts) == 0 && len(p.Medications) == 0 && !p.IncludeSchedule && !p.IncludeLegal {
+		return "emergency_card.empty"
+	}
+	return ""
+}
+
+// cardResponse monta a resposta das rotas do dono
+// url e print_url só aparecem quando o link acaba de ser gerado.
+func cardResponse(card *storage.EmergencyCard, url string) map[string]interface{} {
+	response := map[string]interface{}{
+		"enabled":          true,
+		"name":             card.Name,
+		"blood_type":       card.BloodType,
+		"contacts":         nonNilContacts(card.Contacts),
+		"medications":      nonNilStrings(card.Medications),
+		"include_schedule": card.IncludeSchedule,
+		"include_legal":    card.IncludeLegal,
+		"created_at":       card.CreatedAt,
+		"updated_at":       card.UpdatedAt,
+		"last_accessed_at": card.LastAccessedAt,
+	}
+	if url != "" {
+		response["url"] = url
+		response["print_url"] = url + "/print"
+	}
+	return response
+}
+
+func nonNilContacts(contacts []storage.EmergencyContact) []storage.EmergencyContact {
+	if contacts == nil {
+		return []storage.EmergencyContact{}
+	}
+	return contacts
+}
+
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// =============================================================================
+// TOKEN DO CARTÃO
+// =============================================================================
+
+// generateToken gera um token aleatório (64 caracteres hex)
+func generateToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+// hashToken retorna o hash salvo no banco
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// getBaseURL retorna a URL base da aplicação
+func getBaseURL(r *http.Request) string {
+	scheme := "https"
+	if r.TLS == nil && r.Header.Get("X-Forwarded-Proto") != "https" {
+		scheme = "http"
+	}
+	return scheme + "://" + r.Host
+}
+
+// writeJSON escreve resposta JSON
+func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
+	security.SetJSONHeaders(w)
+	w.WriteHeader(status)
+	if payload != nil {
+		json.NewEncoder(w).Encode(payload)
+	}
+}
@@ -0,0 +1,172 @@
+// =============================================================================
+// FAMLI - Cartão de Emergência: versão para imprimir
+// =============================================================================
+// Página HTML autônoma (sem JavaScript nem recursos externos) no tamanho de
+// um cartão de carteira (85,6 x 54 mm). Os rótulos seguem o idioma de quem
+// abre o link, que pode não ser o do dono.
+// =============================================================================
+
+package emergency
+
+import (
+	"html"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"famli/internal/i18n"
+	"famli/internal/storage"
+)
+
+// printTemplate é o cartão de carteira
+var printTemplate = template.Must(template.New("emergency-card").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex, nofollow">
+<title>{{.Labels.Title}}</title>
+<style>
+  body { font-family: -apple-system, "Segoe UI", Roboto, Arial, sans-serif; margin: 1rem; color: #1f2937; }
+  .card { width: 85.6mm; min-height: 54mm; box-sizing: border-box; padding: 3mm 4mm; border: 1px solid #b91c1c; border-radius: 3mm; font-size: 8pt; line-height: 1.3; }
+  .card h1 { margin: 0 0 1.5mm; font-size: 10pt; color: #b91c1c; text-transform: uppercase; letter-spacing: .04em; }
+  .name { font-size: 11pt; font-weight: 700; }
+  .blood { float: right; font-size: 14pt; font-weight: 700; color: #b91c1c; }
+  .label { margin-top: 1.5mm; font-weight: 700; font-size: 7pt; text-transform: uppercase; color: #6b7280; }
+  ul { margin: 0; padding-left: 3.5mm; }
+  .footer { margin-top: 2mm; font-size: 6pt; color: #6b7280; }
+  @media print { body { margin: 0; } }
+</style>
+</head>
+<body>
+<div class="card">
+  <h1>{{.Labels.Title}}</h1>
+  {{if .BloodType}}<div class="blood" title="{{.Labels.BloodType}}">{{.BloodType}}</div>{{end}}
+  {{if .Name}}<div class="name">{{.Name}}</div>{{end}}
+  {{if .Contacts}}<div class="label">{{.Labels.Contacts}}</div>
+  <ul>{{range .Contacts}}<li>{{.Name}}{{if .Relationship}} ({{.Relationship}}){{end}}: <a href="tel:{{.Phone}}">{{.Phone}}</a></li>{{end}}</ul>{{end}}
+  {{if .Medications}}<div class="label">{{.Labels.Medications}}</div>
+  <ul>{{range .Medications}}<li>{{.}}</li>{{end}}</ul>{{end}}
+  {{if .Schedule}}<div class="label">{{.Labels.Schedule}}</div>
+  <ul>{{range .Schedule}}<li>{{if .Medication}}{{.Medication}}{{else}}{{.Title}}{{end}}{{if .Dose}} {{.Dose}}{{end}}: {{.Times}}</li>{{end}}</ul>{{end}}
+  {{if .Legal}}<div class="label">{{.Labels.Legal}}</div>
+  <ul>{{range .Legal}}<li>{{.Kind}}{{if .StoredAt}}: {{.StoredAt}}{{end}}{{if .Lawyer}}; {{$.Labels.Lawyer}} {{.Lawyer}}{{end}}{{if .Executor}}; {{.ExecutorLabel}} {{.Executor}}{{end}}</li>{{end}}</ul>{{end}}
+  <div class="footer">{{.Labels.Footer}}</div>
+</div>
+</body>
+</html>
+`))
+
+// printLabels são os textos fixos do cartão
+type printLabels struct {
+	Title       string
+	BloodType   string
+	Contacts    string
+	Medications string
+	Schedule    string
+	Legal       string
+	Lawyer      string
+	Footer      string
+}
+
+// printData são os dados do cartão impresso (texto puro; o template escapa)
+type printData struct {
+	Lang        string
+	Labels      printLabels
+	Name        string
+	BloodType   string
+	Contacts    []storage.EmergencyContact
+	Medications []string
+	Schedule    []printScheduleEntry
+	Legal       []printLegalEntry
+}
+
+// printScheduleEntry é uma rotina agendada no cartão impresso
+type printScheduleEntry struct {
+	Title      string
+	Medication string
+	Dose       string
+	Times      string // "08:00, 20:00"
+}
+
+// printLegalEntry é um documento jurídico no cartão impresso
+type printLegalEntry struct {
+	Kind          string // Nome do documento no idioma de quem abre
+	StoredAt      string
+	Lawyer        string // "Nome (contato)"
+	ExecutorLabel string // Como chamar o responsável neste documento
+	Executor      string // "Nome (contato)"
+}
+
+// Print retorna o cartão de carteira para imprimir
+//
+// Endpoint: GET /api/emergency-card/{token}/print
+func (h *Handler) Print(w http.ResponseWriter, r *http.Request) {
+	card, ok := h.findByToken(w, r)
+	if !ok {
+		return
+	}
+
+	// Os campos são salvos escapados (security.SanitizeText): voltar ao
+	// texto puro para o template não escapar duas vezes
+	data := printData{
+		Lang: i18n.GetLocale(r),
+		Labels: printLabels{
+			Title:       i18n.Tr(r, "emergency_card.print_title"),
+			BloodType:   i18n.Tr(r, "emergency_card.print_blood_type"),
+			Contacts:    i18n.Tr(r, "emergency_card.print_contacts"),
+			Medications: i18n.Tr(r, "emergency_card.print_medications"),
+			Schedule:    i18n.Tr(r, "emergency_card.print_schedule"),
+			Legal:       i18n.Tr(r, "emergency_card.print_legal"),
+			Lawyer:      i18n.Tr(r, "emergency_card.print_lawyer"),
+			Footer:      i18n.Tr(r, "emergency_card.print_footer"),
+		},
+		Name:      html.UnescapeString(card.Name),
+		BloodType: card.BloodType,
+	}
+	for _, contact := range card.Contacts {
+		data.Contacts = append(data.Contacts, storage.EmergencyContact{
+			Name:         html.UnescapeString(contact.Name),
+			Relationship: html.UnescapeString(contact.Relationship),
+			Phone:        contact.Phone,
+		})
+	}
+	for _, medication := range card.Medications {
+		data.Medications = append(data.Medications, html.UnescapeString(medication))
+	}
+	for _, entry := range h.schedule(card) {
+		data.Schedule = append(data.Schedule, printScheduleEntry{
+			Title:      html.UnescapeString(entry.Title),
+			Medication: html.UnescapeString(entry.Medication),
+			Dose:       html.UnescapeString(entry.Dose),
+			Times:      strings.Join(entry.Times, ", "),
+		})
+	}
+	for _, entry := range h.legal(card) {
+		key := "legal_document." + string(entry.Kind)
+		data.Legal = append(data.Legal, printLegalEntry{
+			Kind:          i18n.Tr(r, key+".title"),
+			StoredAt:      html.UnescapeString(entry.StoredAt),
+			Lawyer:        withContact(entry.LawyerName, entry.LawyerContact),
+			ExecutorLabel: i18n.Tr(r, key+".executor"),
+			Executor:      withContact(entry.ExecutorName, entry.ExecutorContact),
+		})
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	printTemplate.Execute(w, data)
+}
+
+// withContact junta nome e contato ("Nome (contato)"), em texto puro
+func withContact(name, contact string) string {
+	name, contact = html.UnescapeString(name), html.UnescapeString(contact)
+	switch {
+	case name == "":
+		return contact
+	case contact == "":
+		return name
+	default:
+		return name + " (" + contact + ")"
+	}
+}
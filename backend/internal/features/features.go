@@ -0,0 +1,270 @@
+// =============================================================================
+// FAMLI - Feature Flags
+// =============================================================================
+// Liga/desliga funcionalidades em tempo de execução, sem deploy.
+//
+// Cada flag é avaliada por usuário:
+// - enabled: ligada para todos
+// - users: lista de IDs de usuários com acesso antecipado
+// - percentage: rollout gradual (0-100), estável por usuário
+//
+// Armazenamento: tabela system_config, chave "feature:<nome>", valor JSON.
+// Flags sem registro no banco usam o padrão definido em Known.
+//
+// Uso nos handlers:
+//
+//	if features.Enabled(r.Context(), features.WhatsApp) { ... }
+//
+// Ou como middleware (responde 404 se desligada):
+//
+//	r.With(features.Require(features.ShareLinks)).Post(...)
+// =============================================================================
+
+package features
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/storage"
+)
+
+// Nomes das flags conhecidas
+const (
+	WhatsApp   = "whatsapp"    // Vincular WhatsApp à conta
+	ShareLinks = "share_links" // Links de compartilhamento e acesso do guardião
+	OAuth      = "oauth"       // Login social (Google, Apple)
+	Webhooks   = "webhooks"    // Webhooks de saída configurados pelo usuário
+)
+
+// Definition descreve uma flag conhecida e seu valor padrão
+type Definition struct {
+	Description string
+	Default     bool
+}
+
+// Known lista as flags disponíveis (apenas estas podem ser alteradas pelo admin)
+var Known = map[string]Definition{
+	WhatsApp:   {Description: "Vincular WhatsApp à conta", Default: true},
+	ShareLinks: {Description: "Links de compartilhamento e acesso do guardião", Default: true},
+	OAuth:      {Description: "Login social (Google, Apple)", Default: true},
+	Webhooks:   {Description: "Webhooks de saída configurados pelo usuário", Default: true},
+}
+
+// configPrefix é o prefixo das chaves de flags em system_config
+const configPrefix = "feature:"
+
+// cacheTTL é por quanto tempo as flags ficam em cache antes de recarregar
+// Alterações feitas nesta instância invalidam o cache na hora; outras réplicas
+// enxergam a mudança em até cacheTTL.
+const cacheTTL = 30 * time.Second
+
+// Flag é o estado persistido de uma feature flag
+type Flag struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Enabled     bool       `json:"enabled"`              // Ligada para todos
+	Percentage  int        `json:"percentage"`           // Rollout gradual (0-100)
+	Users       []string   `json:"users,omitempty"`      // IDs com acesso antecipado
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"` // Última alteração (nil = padrão)
+	UpdatedBy   string     `json:"updated_by,omitempty"` // ID do admin que alterou
+}
+
+// IsOnFor avalia a flag para um usuário (userID vazio = visitante anônimo)
+func (f *Flag) IsOnFor(userID string) bool {
+	if f.Enabled {
+		return true
+	}
+	if userID == "" {
+		return false
+	}
+	for _, id := range f.Users {
+		if id == userID {
+			return true
+		}
+	}
+	return f.Percentage > 0 && bucket(f.Name, userID) < f.Percentage
+}
+
+// bucket distribui usuários em 0-99 de forma estável (por flag)
+func bucket(name, userID string) int {
+	h := fnv.New32a()
+	h.Write([]byte(name + ":" + userID))
+	return int(h.Sum32() % 100)
+}
+
+// =============================================================================
+// MANAGER
+// =============================================================================
+
+// Manager carrega, avalia e altera flags
+type Manager struct {
+	store storage.Store
+
+	mu       sync.RWMutex
+	flags    map[string]*Flag
+	loadedAt time.Time
+}
+
+// NewManager cria um manager de flags sobre o store
+func NewManager(store storage.Store) *Manager {
+	return &Manager{store: store}
+}
+
+// EnabledFor avalia uma flag para um usuário
+func (m *Manager) EnabledFor(name, userID string) bool {
+	flag, ok := m.Get(name)
+	if !ok {
+		return false
+	}
+	return flag.IsOnFor(userID)
+}
+
+// Get retorna o estado atual de uma flag conhecida
+func (m *Manager) Get(name string) (*Flag, bool) {
+	definition, known := Known[name]
+	if !known {
+		return nil, false
+	}
+
+	m.refresh()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if flag, ok := m.flags[name]; ok {
+		return flag, true
+	}
+	return &Flag{Name: name, Description: definition.Description, Enabled: definition.Default}, true
+}
+
+// List retorna todas as flags conhecidas, ordenadas por nome
+func (m *Manager) List() []*Flag {
+	names := make([]string, 0, len(Known))
+	for name := range Known {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	flags := make([]*Flag, 0, len(names))
+	for _, name := range names {
+		flag, _ := m.Get(name)
+		flags = append(flags, flag)
+	}
+	return flags
+}
+
+// Set persiste o novo estado de uma flag conhecida
+func (m *Manager) Set(flag *Flag) error {
+	definition, known := Known[flag.Name]
+	if !known {
+		return storage.ErrNotFound
+	}
+	if flag.Percentage < 0 || flag.Percentage > 100 {
+		return storage.ErrInvalidData
+	}
+
+	now := time.Now()
+	flag.Description = definition.Description
+	flag.UpdatedAt = &now
+
+	data, err := json.Marshal(flag)
+	if err != nil {
+		return err
+	}
+	if err := m.store.SetSystemConfig(configPrefix+flag.Name, string(data)); err != nil {
+		return err
+	}
+
+	// Invalidar cache para a mudança valer imediatamente nesta instância
+	m.mu.Lock()
+	m.loadedAt = time.Time{}
+	m.mu.Unlock()
+	return nil
+}
+
+// refresh recarrega as flags do banco quando o cache expira
+func (m *Manager) refresh() {
+	m.mu.RLock()
+	fresh := time.Since(m.loadedAt) < cacheTTL
+	m.mu.RUnlock()
+	if fresh {
+		return
+	}
+
+	values, err := m.store.ListSystemConfig(configPrefix)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.loadedAt = time.Now()
+	if err != nil {
+		// Mantém o último estado conhecido (ou os padrões) se o banco falhar
+		log.Printf("[Features] Erro ao carregar flags: %v", err)
+		return
+	}
+
+	flags := make(map[string]*Flag, len(values))
+	for key, value := range values {
+		var flag Flag
+		if err := json.Unmarshal([]byte(value), &flag); err != nil {
+			log.Printf("[Features] Flag inválida %s: %v", key, err)
+			continue
+		}
+		flag.Name = key[len(configPrefix):]
+		flags[flag.Name] = &flag
+	}
+	m.flags = flags
+}
+
+// =============================================================================
+// INSTÂNCIA GLOBAL E HELPERS
+// =============================================================================
+
+var (
+	defaultManager   *Manager
+	defaultManagerMu sync.RWMutex
+)
+
+// Init define o manager global usado por Enabled e Require
+// Deve ser chamado na inicialização, antes de registrar as rotas.
+func Init(store storage.Store) *Manager {
+	manager := NewManager(store)
+	defaultManagerMu.Lock()
+	defaultManager = manager
+	defaultManagerMu.Unlock()
+	return manager
+}
+
+// Enabled avalia uma flag para o usuário autenticado do contexto
+// Sem Init, usa o valor padrão da flag.
+func Enabled(ctx context.Context, name string) bool {
+	defaultManagerMu.RLock()
+	manager := defaultManager
+	defaultManagerMu.RUnlock()
+
+	if manager == nil {
+		return Known[name].Default
+	}
+	return manager.EnabledFor(name, auth.UserIDFromContext(ctx))
+}
+
+// Require é um middleware que responde 404 quando a flag está desligada
+// Em rotas públicas, a flag é avaliada como visitante anônimo (só "enabled").
+func Require(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !Enabled(r.Context(), name) {
+				apierror.Write(w, r, http.StatusNotFound, "features.disabled")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
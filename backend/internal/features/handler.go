@@ -0,0 +1,130 @@
+// =============================================================================
+// FAMLI - Handler de Feature Flags
+// =============================================================================
+// Endpoints:
+// - GET /api/features              - flags avaliadas para o usuário logado
+// - GET /api/admin/features        - estado de todas as flags (admin)
+// - PUT /api/admin/features/{name} - altera uma flag (superadmin)
+// =============================================================================
+
+package features
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/security"
+	"github.com/go-chi/chi/v5"
+)
+
+// Handler expõe as feature flags via HTTP
+type Handler struct {
+	manager     *Manager
+	auditLogger *security.AuditLogger
+}
+
+// NewHandler cria uma nova instância do handler de flags
+func NewHandler(manager *Manager) *Handler {
+	return &Handler{
+		manager:     manager,
+		auditLogger: security.GetAuditLogger(),
+	}
+}
+
+// flagPayload é o corpo de PUT /api/admin/features/{name}
+type flagPayload struct {
+	Enabled    bool     `json:"enabled"`
+	Percentage int      `json:"percentage"`
+	Users      []string `json:"users"`
+}
+
+// Current retorna as flags avaliadas para o usuário autenticado
+//
+// Endpoint: GET /api/features
+//
+// Resposta: {"features": {"whatsapp": true, "share_links": false, ...}}
+func (h *Handler) Current(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	result := make(map[string]bool, len(Known))
+	for name := range Known {
+		result[name] = h.manager.EnabledFor(name, userID)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"features": result})
+}
+
+// List retorna o estado de todas as flags conhecidas
+//
+// Endpoint: GET /api/admin/features
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"flags": h.manager.List(),
+	})
+}
+
+// Update altera uma flag em tempo de execução
+//
+// Endpoint: PUT /api/admin/features/{name}
+//
+// Body: {"enabled": false, "percentage": 10, "users": ["usr_123"]}
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	previous, ok := h.manager.Get(name)
+	if !ok {
+		apierror.Write(w, r, http.StatusNotFound, "features.not_found")
+		return
+	}
+
+	var payload flagPayload
+	if err := security.DecodeJSONStrict(r.Body, &payload); err != nil {
+		apierror.WriteDecodeError(w, r, err, "features.invalid_data")
+		return
+	}
+
+	flag := &Flag{
+		Name:       name,
+		Enabled:    payload.Enabled,
+		Percentage: payload.Percentage,
+		Users:      payload.Users,
+		UpdatedBy:  auth.GetUserID(r),
+	}
+	if err := h.manager.Set(flag); err != nil {
+		apierror.WriteStorage(w, r, err, apierror.StorageKeys{Invalid: "features.invalid_data", Internal: "features.update_error"})
+		return
+	}
+
+	h.auditLogger.Log(security.AuditEvent{
+		Type:     security.EventFeatureFlagChanged,
+		Severity: security.SeverityInfo,
+		UserID:   auth.GetUserID(r),
+		ClientIP: security.GetClientIP(r),
+		Resource: "feature:" + name,
+		Action:   "update",
+		Result:   "success",
+		Details: map[string]interface{}{
+			"enabled":             flag.Enabled,
+			"percentage":          flag.Percentage,
+			"users":               len(flag.Users),
+			"previous_enabled":    previous.Enabled,
+			"previous_percentage": previous.Percentage,
+		},
+	})
+
+	writeJSON(w, http.StatusOK, flag)
+}
+
+// =============================================================================
+// FUNÇÕES AUXILIARES
+// =============================================================================
+
+// writeJSON escreve resposta JSON
+func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
+	security.SetJSONHeaders(w)
+	w.WriteHeader(status)
+	if payload != nil {
+		json.NewEncoder(w).Encode(payload)
+	}
+}
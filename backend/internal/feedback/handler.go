@@ -5,8 +5,15 @@
 //
 // Endpoints:
 // - POST /api/feedback - Envia um feedback
+// - GET /api/feedback/mine - Feedbacks do usuário com as respostas
+// - POST /api/feedback/:id/replies - Usuário responde na conversa
 // - GET /api/admin/feedbacks - Lista feedbacks (admin only)
+// - GET /api/admin/feedbacks/:id - Feedback com a conversa (admin)
 // - PATCH /api/admin/feedbacks/:id - Atualiza status do feedback (admin)
+// - POST /api/admin/feedbacks/:id/replies - Equipe responde (enviado por email)
+//
+// A equipe (support e superadmin) recebe email a cada feedback novo e a cada
+// nova mensagem do usuário na conversa.
 //
 // Tipos de feedback:
 // - suggestion: Sugestão de melhoria
@@ -19,13 +26,17 @@ package feedback
 
 import (
 	"encoding/json"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"famli/internal/apierror"
 	"famli/internal/auth"
+	"famli/internal/email"
 	"famli/internal/i18n"
+	"famli/internal/ids"
 	"famli/internal/security"
 	"famli/internal/storage"
 
@@ -33,21 +44,32 @@ import (
 	"github.com/google/uuid"
 )
 
-// writeError escreve resposta de erro JSON internacionalizada
-func writeError(w http.ResponseWriter, status int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(map[string]string{"error": message})
-}
-
 // Handler gerencia operações de feedback
 type Handler struct {
-	store storage.Store
+	store       storage.Store
+	mailer      *email.Service
+	appURL      string // Endereço do frontend (link do painel nos avisos)
+	auditLogger *security.AuditLogger
 }
 
 // NewHandler cria uma nova instância do handler
-func NewHandler(store storage.Store) *Handler {
-	return &Handler{store: store}
+//
+// Parâmetros:
+//   - store: armazenamento de dados
+//   - mailer: envio dos avisos à equipe e das respostas ao usuário
+//   - appURL: endereço público do frontend (APP_BASE_URL)
+func NewHandler(store storage.Store, mailer *email.Service, appURL string) *Handler {
+	return &Handler{
+		store:       store,
+		mailer:      mailer,
+		appURL:      appURL,
+		auditLogger: security.GetAuditLogger(),
+	}
+}
+
+// ReplyRequest representa o payload de uma mensagem na conversa
+type ReplyRequest struct {
+	Message string `json:"message"`
 }
 
 // CreateFeedbackRequest representa o payload para criar feedback
@@ -72,20 +94,20 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 
 	var req CreateFeedbackRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "feedback.invalid_data"))
+		apierror.Write(w, r, http.StatusBadRequest, "feedback.invalid_data")
 		return
 	}
 
 	// Sanitizar e validar mensagem
 	req.Message = strings.TrimSpace(req.Message)
 	if req.Message == "" {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "feedback.invalid_data"))
+		apierror.Write(w, r, http.StatusBadRequest, "feedback.invalid_data")
 		return
 	}
 
 	// Limitar tamanho da mensagem (2KB para economizar banco)
 	if len(req.Message) > security.MaxFeedbackLength {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "feedback.message_too_long"))
+		apierror.Write(w, r, http.StatusBadRequest, "feedback.message_too_long")
 		return
 	}
 
@@ -100,7 +122,7 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		req.Type = "suggestion"
 	}
 	if !validTypes[req.Type] {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "feedback.type_required"))
+		apierror.Write(w, r, http.StatusBadRequest, "feedback.type_required")
 		return
 	}
 
@@ -119,10 +141,25 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 
 	// Salvar feedback
 	if err := h.store.CreateFeedback(feedback); err != nil {
-		writeError(w, http.StatusInternalServerError, i18n.Tr(r, "feedback.save_error"))
+		apierror.Write(w, r, http.StatusInternalServerError, "feedback.save_error")
 		return
 	}
 
+	// Atividade do painel admin (sem o texto do feedback)
+	h.auditLogger.Log(security.AuditEvent{
+		Type:     security.EventFeedbackCreated,
+		Severity: security.SeverityInfo,
+		UserID:   userID,
+		ClientIP: security.GetClientIP(r),
+		Resource: "feedback",
+		Action:   "create",
+		Result:   "success",
+		Details:  map[string]interface{}{"feedback_id": feedback.ID, "type": feedback.Type},
+	})
+
+	// Avisar a equipe (em background, não bloqueia)
+	go h.notifyTeam(feedback, feedback.Message, false)
+
 	// Responder com sucesso
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -144,7 +181,7 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 
 	feedbacks, err := h.store.ListFeedbacks(status, limit)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, i18n.Tr(r, "feedback.save_error"))
+		apierror.Write(w, r, http.StatusInternalServerError, "feedback.save_error")
 		return
 	}
 
@@ -161,13 +198,13 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	if id == "" {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "feedback.not_found"))
+		apierror.Write(w, r, http.StatusBadRequest, "feedback.not_found")
 		return
 	}
 
 	var req UpdateFeedbackRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "feedback.invalid_data"))
+		apierror.Write(w, r, http.StatusBadRequest, "feedback.invalid_data")
 		return
 	}
 
@@ -178,12 +215,12 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 		"resolved": true,
 	}
 	if !validStatuses[req.Status] {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "feedback.invalid_data"))
+		apierror.Write(w, r, http.StatusBadRequest, "feedback.invalid_data")
 		return
 	}
 
 	if err := h.store.UpdateFeedbackStatus(id, req.Status, req.AdminNote); err != nil {
-		writeError(w, http.StatusInternalServerError, i18n.Tr(r, "feedback.update_error"))
+		apierror.Write(w, r, http.StatusInternalServerError, "feedback.update_error")
 		return
 	}
 
@@ -193,6 +230,93 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// Mine lista os feedbacks do usuário com as respostas da equipe
+// GET /api/feedback/mine
+func (h *Handler) Mine(w http.ResponseWriter, r *http.Request) {
+	feedbacks, err := h.store.ListFeedbacksByUser(auth.GetUserID(r), 50)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "feedback.list_error")
+		return
+	}
+
+	// Dados internos ficam só no painel
+	for _, f := range feedbacks {
+		f.AdminNote = ""
+		f.UserAgent = ""
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"feedbacks": feedbacks,
+	})
+}
+
+// UserReply adiciona uma mensagem do usuário na conversa do próprio feedback
+// POST /api/feedback/:id/replies
+//
+// O feedback volta para "pending" para aparecer na fila da equipe.
+func (h *Handler) UserReply(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	feedback, err := h.store.GetFeedback(chi.URLParam(r, "id"))
+	if err != nil || feedback.UserID != userID {
+		apierror.Write(w, r, http.StatusNotFound, "feedback.not_found")
+		return
+	}
+
+	reply, ok := h.addReply(w, r, feedback, userID, false)
+	if !ok {
+		return
+	}
+
+	if feedback.Status != "pending" {
+		_ = h.store.UpdateFeedbackStatus(feedback.ID, "pending", feedback.AdminNote)
+	}
+	go h.notifyTeam(feedback, reply.Message, true)
+
+	writeReply(w, r, reply)
+}
+
+// Get retorna um feedback com a conversa (admin only)
+// GET /api/admin/feedbacks/:id
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	feedback, err := h.store.GetFeedback(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, r, http.StatusNotFound, "feedback.not_found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(feedback)
+}
+
+// AdminReply responde um feedback e envia a resposta por email ao usuário
+// POST /api/admin/feedbacks/:id/replies
+//
+// Feedbacks pendentes passam para "reviewed".
+func (h *Handler) AdminReply(w http.ResponseWriter, r *http.Request) {
+	adminID := auth.GetUserID(r)
+
+	feedback, err := h.store.GetFeedback(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, r, http.StatusNotFound, "feedback.not_found")
+		return
+	}
+
+	reply, ok := h.addReply(w, r, feedback, adminID, true)
+	if !ok {
+		return
+	}
+
+	if feedback.Status == "pending" {
+		_ = h.store.UpdateFeedbackStatus(feedback.ID, "reviewed", feedback.AdminNote)
+	}
+	h.auditLogger.LogDataAccess(adminID, security.GetClientIP(r), "feedback/"+feedback.ID, "reply", "success")
+	go h.emailReply(feedback, reply.Message)
+
+	writeReply(w, r, reply)
+}
+
 // GetStats retorna estatísticas de feedback (para admin dashboard)
 func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
 	total, pending := h.store.GetFeedbackStats()
@@ -203,3 +327,121 @@ func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
 		"pending": pending,
 	})
 }
+
+// =============================================================================
+// HELPERS
+// =============================================================================
+
+// addReply valida a mensagem do payload e grava na conversa
+func (h *Handler) addReply(w http.ResponseWriter, r *http.Request, feedback *storage.Feedback, authorID string, fromAdmin bool) (*storage.FeedbackReply, bool) {
+	var req ReplyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "feedback.invalid_data")
+		return nil, false
+	}
+
+	message := strings.TrimSpace(req.Message)
+	if message == "" {
+		apierror.Write(w, r, http.StatusBadRequest, "feedback.invalid_data")
+		return nil, false
+	}
+	if len(message) > security.MaxFeedbackLength {
+		apierror.Write(w, r, http.StatusBadRequest, "feedback.message_too_long")
+		return nil, false
+	}
+
+	reply := &storage.FeedbackReply{
+		ID:         ids.New(ids.FeedbackReply),
+		FeedbackID: feedback.ID,
+		AuthorID:   authorID,
+		FromAdmin:  fromAdmin,
+		Message:    message,
+		CreatedAt:  time.Now(),
+	}
+	if err := h.store.AddFeedbackReply(reply); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "feedback.save_error")
+		return nil, false
+	}
+	return reply, true
+}
+
+// writeReply responde com a mensagem criada
+func writeReply(w http.ResponseWriter, r *http.Request, reply *storage.FeedbackReply) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": i18n.Tr(r, "feedback.reply_success"),
+		"reply":   reply,
+	})
+}
+
+// notifyTeam envia o aviso de feedback para support e superadmin
+func (h *Handler) notifyTeam(feedback *storage.Feedback, message string, isReply bool) {
+	if h.mailer == nil || !h.mailer.IsConfigured() {
+		return
+	}
+
+	alert := email.FeedbackAlert{
+		Type:      string(feedback.Type),
+		Message:   message,
+		UserEmail: maskEmail(feedback.UserEmail),
+		Page:      feedback.Page,
+		IsReply:   isReply,
+		AdminLink: h.appURL + "/administracao",
+	}
+
+	for _, role := range []storage.Role{storage.RoleSupport, storage.RoleSuperadmin} {
+		result, err := h.store.SearchUsers(&storage.UserSearchParams{
+			Role:             role,
+			PaginationParams: storage.PaginationParams{Limit: storage.MaxPageSize},
+		})
+		if err != nil {
+			log.Printf("[FEEDBACK] Erro ao listar equipe: %v", err)
+			return
+		}
+		for _, admin := range result.Items {
+			if admin.IsDisabled() {
+				continue
+			}
+			if err := h.mailer.SendFeedbackAlert(admin.Email, alert); err != nil {
+				log.Printf("[FEEDBACK] Erro ao avisar %s: %v", maskEmail(admin.Email), err)
+			}
+		}
+	}
+}
+
+// emailReply envia a resposta da equipe ao autor do feedback
+func (h *Handler) emailReply(feedback *storage.Feedback, message string) {
+	if h.mailer == nil || !h.mailer.IsConfigured() {
+		return
+	}
+
+	to, name, locale := feedback.UserEmail, "", ""
+	if user, found := h.store.GetUserByID(feedback.UserID); found {
+		to, name, locale = user.Email, user.Name, user.Locale
+	}
+	if to == "" {
+		return
+	}
+
+	if err := h.mailer.SendFeedbackReply(to, name, locale, feedback.Message, message); err != nil {
+		log.Printf("[FEEDBACK] Erro ao enviar resposta do feedback %s: %v", feedback.ID, err)
+	}
+}
+
+// maskEmail mascara parte do email para privacidade
+func maskEmail(email string) string {
+	parts := strings.Split(email, "@")
+	if len(parts) != 2 || parts[0] == "" {
+		return "***"
+	}
+
+	name := parts[0]
+	domain := parts[1]
+
+	if len(name) <= 2 {
+		return name[:1] + "***@" + domain
+	}
+
+	return name[:2] + "***@" + domain
+}
@@ -0,0 +1,161 @@
+// =============================================================================
+// FAMLI - Importação de Guardiões: Google Contatos
+// =============================================================================
+// O frontend pede ao Google um access token com o escopo
+// contacts.readonly (Google Identity Services, token client) e o envia para
+// POST /api/guardians/import. O backend confere que o token foi emitido para
+// o GOOGLE_CLIENT_ID da Famli e lê os contatos pela People API. O token não
+// é guardado.
+// =============================================================================
+
+package guardian
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// googleContactsScope é o escopo exigido do access token
+	googleContactsScope = "https://www.googleapis.com/auth/contacts.readonly"
+
+	googleTokenInfoURL  = "https://oauth2.googleapis.com/tokeninfo"
+	googleConnectionURL = "https://people.googleapis.com/v1/people/me/connections"
+)
+
+var (
+	// errGoogleToken indica token inválido, vencido, de outro app ou sem o escopo
+	errGoogleToken = errors.New("token do Google inválido")
+)
+
+// googleClient lê os contatos do Google do usuário
+type googleClient struct {
+	clientID   string
+	httpClient *http.Client
+}
+
+// newGoogleClient cria o cliente da People API
+func newGoogleClient(clientID string) *googleClient {
+	return &googleClient{
+		clientID:   clientID,
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// Enabled indica se o GOOGLE_CLIENT_ID está configurado
+func (g *googleClient) Enabled() bool {
+	return g.clientID != ""
+}
+
+// Contacts retorna até limit contatos com email ou telefone
+func (g *googleClient) Contacts(accessToken string, limit int) ([]importContact, error) {
+	if err := g.checkToken(accessToken); err != nil {
+		return nil, err
+	}
+
+	var contacts []importContact
+	pageToken := ""
+	for {
+		query := url.Values{
+			"personFields": {"names,emailAddresses,phoneNumbers"},
+			"pageSize":     {"1000"},
+			"sortOrder":    {"FIRST_NAME_ASCENDING"},
+		}
+		if pageToken != "" {
+			query.Set("pageToken", pageToken)
+		}
+
+		var page struct {
+			Connections []struct {
+				Names []struct {
+					DisplayName string `json:"displayName"`
+				} `json:"names"`
+				EmailAddresses []struct {
+					Value string `json:"value"`
+				} `json:"emailAddresses"`
+				PhoneNumbers []struct {
+					Value string `json:"value"`
+				} `json:"phoneNumbers"`
+			} `json:"connections"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		if err := g.get(googleConnectionURL+"?"+query.Encode(), accessToken, &page); err != nil {
+			return nil, err
+		}
+
+		for _, person := range page.Connections {
+			var contact importContact
+			if len(person.Names) > 0 {
+				contact.Name = person.Names[0].DisplayName
+			}
+			if len(person.EmailAddresses) > 0 {
+				contact.Email = person.EmailAddresses[0].Value
+			}
+			if len(person.PhoneNumbers) > 0 {
+				contact.Phone = person.PhoneNumbers[0].Value
+			}
+			if contact.Email == "" && contact.Phone == "" {
+				continue
+			}
+			contacts = append(contacts, contact)
+			if len(contacts) >= limit {
+				return contacts, nil
+			}
+		}
+
+		if page.NextPageToken == "" {
+			return contacts, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+// checkToken confere o app e o escopo do access token
+func (g *googleClient) checkToken(accessToken string) error {
+	var info struct {
+		Audience string `json:"aud"`
+		Scope    string `json:"scope"`
+	}
+	if err := g.get(googleTokenInfoURL+"?access_token="+url.QueryEscape(accessToken), "", &info); err != nil {
+		return err
+	}
+	if info.Audience != g.clientID {
+		return errGoogleToken
+	}
+	for _, scope := range strings.Fields(info.Scope) {
+		if scope == googleContactsScope {
+			return nil
+		}
+	}
+	return errGoogleToken
+}
+
+// get faz um GET na API do Google e decodifica a resposta
+func (g *googleClient) get(endpoint, accessToken string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return errGoogleToken
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("google: status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -1,8 +1,10 @@
 package guardian
 
 import (
+	"bytes"
 	"encoding/json"
-	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"strings"
 	"time"
@@ -10,18 +12,33 @@ import (
 	"github.com/go-chi/chi/v5"
 	"golang.org/x/crypto/bcrypt"
 
+	"famli/internal/apierror"
 	"famli/internal/auth"
+	"famli/internal/email"
 	"famli/internal/i18n"
+	"famli/internal/ids"
+	"famli/internal/notifications"
 	"famli/internal/security"
 	"famli/internal/storage"
+	"famli/internal/webhooks"
 )
 
 type Handler struct {
-	store storage.Store
+	store       storage.Store
+	mailer      *email.Service // Envia o novo link ao guardião (opcional)
+	sendMessage MessageSender  // Recados do dono aos guardiões
+	appURL      string
+	auditLogger *security.AuditLogger
 }
 
-func NewHandler(store storage.Store) *Handler {
-	return &Handler{store: store}
+func NewHandler(store storage.Store, mailer *email.Service, sendMessage MessageSender, appURL string) *Handler {
+	return &Handler{
+		store:       store,
+		mailer:      mailer,
+		sendMessage: sendMessage,
+		appURL:      strings.TrimRight(appURL, "/"),
+		auditLogger: security.GetAuditLogger(),
+	}
 }
 
 type guardianPayload struct {
@@ -31,6 +48,20 @@ type guardianPayload struct {
 	Relationship string `json:"relationship,omitempty"`
 	Notes        string `json:"notes,omitempty"`
 	AccessPIN    string `json:"access_pin,omitempty"` // PIN de proteção para acesso
+
+	// NotifyChannel é o canal preferido para avisos: "", "whatsapp", "sms" ou "email"
+	NotifyChannel string `json:"notify_channel,omitempty"`
+}
+
+// rotateTokenPayload é o corpo (opcional) de POST /api/guardians/{id}/rotate-token
+type rotateTokenPayload struct {
+	SendLink bool `json:"send_link"` // Enviar o novo link por email ao guardião
+}
+
+// rotateTokenResponse é o guardião com o novo token
+type rotateTokenResponse struct {
+	*storage.Guardian
+	LinkSent bool `json:"link_sent"` // O email com o novo link foi enviado
 }
 
 // List retorna todas as pessoas de confiança
@@ -49,7 +80,7 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 
 	var payload guardianPayload
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "guardian.invalid_data"))
+		apierror.Write(w, r, http.StatusBadRequest, "guardian.invalid_data")
 		return
 	}
 
@@ -58,34 +89,41 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	payload.Notes = security.SanitizeText(payload.Notes, security.MaxNotesLength)
 
 	if payload.Name == "" {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "guardian.name_required"))
+		apierror.Write(w, r, http.StatusBadRequest, "guardian.name_required")
 		return
 	}
 	if payload.AccessPIN == "" {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "guardian.pin_required"))
+		apierror.Write(w, r, http.StatusBadRequest, "guardian.pin_required")
 		return
 	}
 
 	// Limitar tamanho das notas para economizar banco
 	payload.Notes = strings.TrimSpace(payload.Notes)
 	if len(payload.Notes) > security.MaxNotesLength {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "guardian.notes_too_long"))
+		apierror.Write(w, r, http.StatusBadRequest, "guardian.notes_too_long")
+		return
+	}
+
+	notifyChannel, errKey := parseNotifyChannel(&payload)
+	if errKey != "" {
+		apierror.Write(w, r, http.StatusBadRequest, errKey)
 		return
 	}
 
 	guardian := &storage.Guardian{
-		Name:         payload.Name,
-		Email:        payload.Email,
-		Phone:        payload.Phone,
-		Relationship: payload.Relationship,
-		Notes:        payload.Notes,
-		Role:         "viewer",
+		Name:          payload.Name,
+		Email:         payload.Email,
+		Phone:         payload.Phone,
+		Relationship:  payload.Relationship,
+		Notes:         payload.Notes,
+		Role:          "viewer",
+		NotifyChannel: notifyChannel,
 	}
 
 	// Hash do PIN se fornecido
 	if payload.AccessPIN != "" {
 		if len(payload.AccessPIN) < 4 {
-			writeError(w, http.StatusBadRequest, i18n.Tr(r, "guardian.pin_too_short"))
+			apierror.Write(w, r, http.StatusBadRequest, "guardian.pin_too_short")
 			return
 		}
 		hash, err := bcrypt.GenerateFromPassword([]byte(payload.AccessPIN), bcrypt.DefaultCost)
@@ -97,10 +135,10 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	idempotencyKey := getIdempotencyKey(r)
 	var guardianID string
 	if idempotencyKey != "" {
-		guardianID = fmt.Sprintf("grd_%d", time.Now().UnixNano())
+		guardianID = ids.New(ids.Guardian)
 		existingID, inserted, err := h.store.RegisterIdempotencyKey(userID, idempotencyKey, "guardian", guardianID)
 		if err != nil {
-			writeError(w, http.StatusInternalServerError, i18n.Tr(r, "guardian.add_error"))
+			apierror.Write(w, r, http.StatusInternalServerError, "guardian.add_error")
 			return
 		}
 		if !inserted {
@@ -112,7 +150,7 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 					return
 				}
 			}
-			writeError(w, http.StatusConflict, i18n.Tr(r, "guardian.add_error"))
+			apierror.Write(w, r, http.StatusConflict, "guardian.add_error")
 			return
 		}
 	}
@@ -128,10 +166,17 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		if idempotencyKey != "" {
 			_ = h.store.DeleteIdempotencyKey(userID, idempotencyKey, "guardian")
 		}
-		writeError(w, http.StatusInternalServerError, i18n.Tr(r, "guardian.add_error"))
+		apierror.Write(w, r, http.StatusInternalServerError, "guardian.add_error")
 		return
 	}
 
+	webhooks.Emit(userID, storage.WebhookGuardianAdded, map[string]interface{}{
+		"guardian_id":  created.ID,
+		"relationship": created.Relationship,
+		"role":         created.Role,
+		"created_at":   created.CreatedAt,
+	})
+
 	writeJSON(w, http.StatusCreated, created)
 }
 
@@ -142,7 +187,7 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 
 	var payload guardianPayload
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "guardian.invalid_data"))
+		apierror.Write(w, r, http.StatusBadRequest, "guardian.invalid_data")
 		return
 	}
 
@@ -150,30 +195,110 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 	payload.Relationship = security.SanitizeText(payload.Relationship, security.MaxNameLength)
 	payload.Notes = security.SanitizeText(payload.Notes, security.MaxNotesLength)
 
+	h.save(w, r, userID, guardianID, &payload)
+}
+
+// Patch atualiza apenas os campos enviados (JSON Merge Patch, RFC 7396)
+// Campos ausentes mantêm o valor atual, null limpa o campo e campos
+// desconhecidos são recusados. Só os campos enviados são sanitizados: os
+// demais já estão salvos assim. O PIN só muda se access_pin vier preenchido.
+//
+// Endpoint: PATCH /api/guardians/{guardianID}
+func (h *Handler) Patch(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+	guardianID := chi.URLParam(r, "guardianID")
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		apierror.WriteDecodeError(w, r, err, "guardian.invalid_data")
+		return
+	}
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(data, &fields) != nil || fields == nil {
+		apierror.Write(w, r, http.StatusBadRequest, "guardian.invalid_data")
+		return
+	}
+	var patch guardianPayload
+	if err := security.DecodeJSONStrict(bytes.NewReader(data), &patch); err != nil {
+		apierror.WriteDecodeError(w, r, err, "guardian.invalid_data")
+		return
+	}
+
+	var existing *storage.Guardian
+	for _, g := range h.store.ListGuardians(userID) {
+		if g.ID == guardianID {
+			existing = g
+			break
+		}
+	}
+	if existing == nil {
+		apierror.Write(w, r, http.StatusNotFound, "guardian.not_found")
+		return
+	}
+
+	payload := guardianPayload{
+		Name:          existing.Name,
+		Email:         existing.Email,
+		Phone:         existing.Phone,
+		Relationship:  existing.Relationship,
+		Notes:         existing.Notes,
+		NotifyChannel: string(existing.NotifyChannel),
+	}
+	for name := range fields {
+		switch name {
+		case "name":
+			payload.Name = security.SanitizeName(patch.Name)
+		case "email":
+			payload.Email = patch.Email
+		case "phone":
+			payload.Phone = patch.Phone
+		case "relationship":
+			payload.Relationship = security.SanitizeText(patch.Relationship, security.MaxNameLength)
+		case "notes":
+			payload.Notes = security.SanitizeText(patch.Notes, security.MaxNotesLength)
+		case "access_pin":
+			payload.AccessPIN = patch.AccessPIN
+		case "notify_channel":
+			payload.NotifyChannel = patch.NotifyChannel
+		}
+	}
+
+	h.save(w, r, userID, guardianID, &payload)
+}
+
+// save valida o payload já sanitizado e grava a pessoa de confiança
+func (h *Handler) save(w http.ResponseWriter, r *http.Request, userID, guardianID string, payload *guardianPayload) {
 	if payload.Name == "" {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "guardian.name_required"))
+		apierror.Write(w, r, http.StatusBadRequest, "guardian.name_required")
 		return
 	}
 
 	// Limitar tamanho das notas para economizar banco
 	payload.Notes = strings.TrimSpace(payload.Notes)
 	if len(payload.Notes) > security.MaxNotesLength {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "guardian.notes_too_long"))
+		apierror.Write(w, r, http.StatusBadRequest, "guardian.notes_too_long")
+		return
+	}
+
+	notifyChannel, errKey := parseNotifyChannel(payload)
+	if errKey != "" {
+		apierror.Write(w, r, http.StatusBadRequest, errKey)
 		return
 	}
 
 	updates := &storage.Guardian{
-		Name:         payload.Name,
-		Email:        payload.Email,
-		Phone:        payload.Phone,
-		Relationship: payload.Relationship,
-		Notes:        payload.Notes,
+		Name:          payload.Name,
+		Email:         payload.Email,
+		Phone:         payload.Phone,
+		Relationship:  payload.Relationship,
+		Notes:         payload.Notes,
+		NotifyChannel: notifyChannel,
 	}
 
 	// Hash do PIN se fornecido
 	if payload.AccessPIN != "" {
 		if len(payload.AccessPIN) < 4 {
-			writeError(w, http.StatusBadRequest, i18n.Tr(r, "guardian.pin_too_short"))
+			apierror.Write(w, r, http.StatusBadRequest, "guardian.pin_too_short")
 			return
 		}
 		hash, err := bcrypt.GenerateFromPassword([]byte(payload.AccessPIN), bcrypt.DefaultCost)
@@ -184,7 +309,7 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 
 	updated, err := h.store.UpdateGuardian(userID, guardianID, updates)
 	if err != nil {
-		writeError(w, http.StatusNotFound, i18n.Tr(r, "guardian.not_found"))
+		apierror.Write(w, r, http.StatusNotFound, "guardian.not_found")
 		return
 	}
 
@@ -197,13 +322,238 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	guardianID := chi.URLParam(r, "guardianID")
 
 	if err := h.store.DeleteGuardian(userID, guardianID); err != nil {
-		writeError(w, http.StatusNotFound, i18n.Tr(r, "guardian.not_found"))
+		apierror.Write(w, r, http.StatusNotFound, "guardian.not_found")
 		return
 	}
 
 	writeJSON(w, http.StatusOK, map[string]string{"message": i18n.Tr(r, "guardian.deleted")})
 }
 
+// RotateToken gera um novo link de acesso para a pessoa de confiança
+// O link antigo para de funcionar na hora (o PIN continua o mesmo) e o dono
+// recebe um aviso para enviar o novo link.
+//
+// Endpoint: POST /api/guardians/{guardianID}/rotate-token
+//
+// Body (opcional): {"send_link": true} envia o novo link por email ao
+// guardião (exige email cadastrado). O PIN nunca vai no email.
+func (h *Handler) RotateToken(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	var payload rotateTokenPayload
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		apierror.WriteDecodeError(w, r, err, "guardian.invalid_data")
+		return
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := security.DecodeJSONStrict(bytes.NewReader(data), &payload); err != nil {
+			apierror.WriteDecodeError(w, r, err, "guardian.invalid_data")
+			return
+		}
+	}
+
+	guardian := h.findGuardian(userID, chi.URLParam(r, "guardianID"))
+	if guardian == nil {
+		apierror.Write(w, r, http.StatusNotFound, "guardian.not_found")
+		return
+	}
+	if payload.SendLink && strings.TrimSpace(guardian.Email) == "" {
+		apierror.Write(w, r, http.StatusBadRequest, "guardian.email_required_for_link")
+		return
+	}
+
+	token, err := h.store.RotateGuardianAccessToken(guardian.ID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "guardian.rotate_token_error")
+		return
+	}
+	guardian.AccessToken = token
+
+	linkSent := false
+	if payload.SendLink {
+		linkSent = h.sendAccessLink(userID, guardian)
+	}
+
+	h.auditLogger.Log(security.AuditEvent{
+		Type:     security.EventGuardianTokenRotated,
+		Severity: security.SeverityInfo,
+		UserID:   userID,
+		ClientIP: security.GetClientIP(r),
+		Resource: "guardian:" + guardian.ID,
+		Action:   "rotate_token",
+		Result:   "success",
+		Details:  map[string]interface{}{"link_sent": linkSent},
+	})
+	notifications.Notify(userID, storage.NotificationGuardianAccess, "notify.guardian_token_rotated")
+
+	writeJSON(w, http.StatusOK, rotateTokenResponse{Guardian: guardian, LinkSent: linkSent})
+}
+
+// DisableAccess desativa o link e o portal da pessoa de confiança, mantendo
+// o cadastro. O link passa a responder 410 até o acesso ser reativado.
+//
+// Endpoint: POST /api/guardians/{guardianID}/disable-access
+func (h *Handler) DisableAccess(w http.ResponseWriter, r *http.Request) {
+	now := time.Now().UTC()
+	h.setAccessDisabled(w, r, &now)
+}
+
+// EnableAccess reativa o acesso da pessoa de confiança (mesmo link e PIN)
+//
+// Endpoint: POST /api/guardians/{guardianID}/enable-access
+func (h *Handler) EnableAccess(w http.ResponseWriter, r *http.Request) {
+	h.setAccessDisabled(w, r, nil)
+}
+
+// setAccessDisabled grava o estado do acesso e registra na auditoria
+func (h *Handler) setAccessDisabled(w http.ResponseWriter, r *http.Request, at *time.Time) {
+	userID := auth.GetUserID(r)
+
+	guardian := h.findGuardian(userID, chi.URLParam(r, "guardianID"))
+	if guardian == nil {
+		apierror.Write(w, r, http.StatusNotFound, "guardian.not_found")
+		return
+	}
+
+	if err := h.store.SetGuardianAccessDisabled(guardian.ID, at); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "guardian.access_update_error")
+		return
+	}
+	guardian.AccessDisabledAt = at
+
+	action := "enable_access"
+	if at != nil {
+		action = "disable_access"
+	}
+	h.auditLogger.Log(security.AuditEvent{
+		Type:     security.EventGuardianAccessDisabled,
+		Severity: security.SeverityInfo,
+		UserID:   userID,
+		ClientIP: security.GetClientIP(r),
+		Resource: "guardian:" + guardian.ID,
+		Action:   action,
+		Result:   "success",
+	})
+
+	writeJSON(w, http.StatusOK, guardian)
+}
+
+// findGuardian busca a pessoa de confiança entre as do usuário
+func (h *Handler) findGuardian(userID, guardianID string) *storage.Guardian {
+	for _, g := range h.store.ListGuardians(userID) {
+		if g.ID == guardianID {
+			return g
+		}
+	}
+	return nil
+}
+
+// sendAccessLink envia o link de acesso por email ao guardião, no idioma do dono
+func (h *Handler) sendAccessLink(userID string, guardian *storage.Guardian) bool {
+	if h.mailer == nil || !h.mailer.IsConfigured() {
+		return false
+	}
+
+	locale, ownerName := i18n.DefaultLocale, ""
+	if owner, ok := h.store.GetUserByID(userID); ok {
+		locale = i18n.UserLocale(owner.Locale, nil)
+		ownerName = owner.Name
+	}
+	message := i18n.Format(locale, "guardian.new_link_message", i18n.Vars{
+		"name":  guardian.Name,
+		"owner": ownerName,
+		"link":  h.appURL + "/g/" + guardian.AccessToken,
+	})
+
+	if err := h.mailer.SendNotice(guardian.Email, guardian.Name, message, locale); err != nil {
+		log.Printf("[GUARDIAN] Erro ao enviar o novo link ao guardião %s: %v", guardian.ID, err)
+		return false
+	}
+	return true
+}
+
+// Messages retorna os itens endereçados à pessoa de confiança
+// (BoxItem.RecipientGuardianID), mais recentes primeiro
+//
+// Endpoint: GET /api/guardians/{guardianID}/messages
+func (h *Handler) Messages(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+	guardianID := chi.URLParam(r, "guardianID")
+
+	var guardian *storage.Guardian
+	for _, g := range h.store.ListGuardians(userID) {
+		if g.ID == guardianID {
+			guardian = g
+			break
+		}
+	}
+	if guardian == nil {
+		apierror.Write(w, r, http.StatusNotFound, "guardian.not_found")
+		return
+	}
+
+	items, err := h.store.ListItemsForRecipient(userID, guardianID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "guardian.messages_error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"guardian": map[string]string{
+			"id":           guardian.ID,
+			"name":         guardian.Name,
+			"relationship": guardian.Relationship,
+		},
+		"items": items,
+	})
+}
+
+// maxAlertsListed limita a listagem de avisos aos guardiões
+const maxAlertsListed = 50
+
+// Alerts lista os avisos mais recentes às pessoas de confiança e o resultado
+// das entregas (canal, tentativas, erro e próxima retentativa)
+//
+// Endpoint: GET /api/guardians/alerts
+func (h *Handler) Alerts(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	alerts, err := h.store.ListGuardianAlerts(userID, maxAlertsListed)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "guardian.alerts_error")
+		return
+	}
+
+	names := make(map[string]string)
+	for _, g := range h.store.ListGuardians(userID) {
+		names[g.ID] = g.Name
+	}
+	for _, alert := range alerts {
+		alert.GuardianName = names[alert.GuardianID]
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"alerts": alerts,
+	})
+}
+
+// parseNotifyChannel valida o canal de notificação escolhido
+// Retorna a chave i18n do erro (vazia se válido).
+func parseNotifyChannel(payload *guardianPayload) (storage.NotifyChannel, string) {
+	channel, ok := storage.ParseNotifyChannel(strings.TrimSpace(payload.NotifyChannel))
+	if !ok {
+		return "", "guardian.invalid_notify_channel"
+	}
+	if (channel == storage.NotifyWhatsApp || channel == storage.NotifySMS) && strings.TrimSpace(payload.Phone) == "" {
+		return "", "guardian.phone_required_for_channel"
+	}
+	if channel == storage.NotifyEmail && strings.TrimSpace(payload.Email) == "" {
+		return "", "guardian.email_required_for_channel"
+	}
+	return channel, ""
+}
+
 func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -212,10 +562,6 @@ func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
 	}
 }
 
-func writeError(w http.ResponseWriter, status int, message string) {
-	writeJSON(w, status, map[string]string{"error": message})
-}
-
 func getIdempotencyKey(r *http.Request) string {
 	key := strings.TrimSpace(r.Header.Get("Idempotency-Key"))
 	if key == "" {
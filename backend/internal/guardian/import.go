@@ -0,0 +1,375 @@
+// =============================================================================
+// FAMLI - Importação de Guardiões
+// =============================================================================
+// Cadastra pessoas de confiança a partir dos contatos do usuário, em duas
+// etapas:
+//
+// 1. POST /api/guardians/import: lê um arquivo vCard (.vcf, multipart no
+//    campo "file") ou os contatos do Google (JSON com o access token) e
+//    devolve a prévia, sem salvar nada. Contatos com o mesmo email ou
+//    telefone de um guardião já cadastrado vêm marcados em duplicate_of.
+// 2. POST /api/guardians/import/confirm: recebe os contatos escolhidos
+//    (com parentesco) e o PIN de acesso, confere de novo as duplicatas e
+//    cria os guardiões.
+//
+// A prévia não fica salva no servidor: a confirmação passa pelas mesmas
+// validações do cadastro manual.
+// =============================================================================
+
+package guardian
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/security"
+	"famli/internal/storage"
+	"famli/internal/webhooks"
+)
+
+const (
+	// maxImportContacts limita os contatos de uma importação
+	maxImportContacts = 500
+
+	// maxVCardSize é o tamanho máximo do arquivo .vcf (fotos incluídas)
+	maxVCardSize = 5 << 20
+
+	// Motivos de um contato ignorado na confirmação
+	skipDuplicate = "duplicate"
+	skipInvalid   = "invalid"
+)
+
+// ImportConfig é a configuração da importação de contatos
+type ImportConfig struct {
+	GoogleClientID string // GOOGLE_CLIENT_ID (vazio = importação do Google desabilitada)
+
+	// CheckLimit confere o limite de guardiões do plano antes de criar
+	// adding guardiões; responde o erro e retorna false se estourar
+	// (nil = sem limite)
+	CheckLimit func(w http.ResponseWriter, r *http.Request, adding int) bool
+}
+
+// ImportHandler expõe a importação de guardiões
+type ImportHandler struct {
+	store       storage.Store
+	google      *googleClient
+	checkLimit  func(w http.ResponseWriter, r *http.Request, adding int) bool
+	auditLogger *security.AuditLogger
+}
+
+// NewImportHandler cria o handler da importação de guardiões
+func NewImportHandler(store storage.Store, config *ImportConfig) *ImportHandler {
+	return &ImportHandler{
+		store:       store,
+		google:      newGoogleClient(config.GoogleClientID),
+		checkLimit:  config.CheckLimit,
+		auditLogger: security.GetAuditLogger(),
+	}
+}
+
+// importContact é um contato lido do vCard ou do Google
+type importContact struct {
+	Name         string `json:"name"`
+	Email        string `json:"email,omitempty"`
+	Phone        string `json:"phone,omitempty"`
+	Relationship string `json:"relationship,omitempty"` // Apenas na confirmação
+
+	// DuplicateOf é o guardião já cadastrado com o mesmo email ou telefone
+	DuplicateOf string `json:"duplicate_of,omitempty"`
+	Reason      string `json:"reason,omitempty"` // Motivo de ter sido ignorado (confirmação)
+}
+
+// googleImportRequest é o corpo JSON de POST /api/guardians/import
+type googleImportRequest struct {
+	Provider    string `json:"provider"` // "google"
+	AccessToken string `json:"access_token"`
+}
+
+// confirmImportRequest é o corpo de POST /api/guardians/import/confirm
+type confirmImportRequest struct {
+	Contacts  []importContact `json:"contacts"`
+	AccessPIN string          `json:"access_pin"` // PIN de acesso de todos os guardiões importados
+}
+
+// =============================================================================
+// PRÉVIA
+// =============================================================================
+
+// Preview lê os contatos e devolve a prévia da importação
+//
+// Endpoint: POST /api/guardians/import
+// Body: multipart com o arquivo .vcf em "file", ou
+// {"provider": "google", "access_token": "ya29..."}
+func (h *ImportHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	var contacts []importContact
+	source := "vcard"
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		data, ok := readVCardFile(w, r)
+		if !ok {
+			return
+		}
+		contacts = parseVCards(data)
+	} else {
+		var req googleImportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Provider != "google" {
+			apierror.Write(w, r, http.StatusBadRequest, "guardian.import_invalid_source")
+			return
+		}
+		if !h.google.Enabled() {
+			apierror.Write(w, r, http.StatusServiceUnavailable, "oauth.google_not_configured")
+			return
+		}
+		if strings.TrimSpace(req.AccessToken) == "" {
+			apierror.Write(w, r, http.StatusBadRequest, "oauth.token_required")
+			return
+		}
+
+		var err error
+		source = "google"
+		contacts, err = h.google.Contacts(strings.TrimSpace(req.AccessToken), maxImportContacts+1)
+		if errors.Is(err, errGoogleToken) {
+			apierror.Write(w, r, http.StatusUnauthorized, "oauth.invalid_token")
+			return
+		}
+		if err != nil {
+			log.Printf("[Guardians] Erro ao ler contatos do Google: %v", err)
+			apierror.Write(w, r, http.StatusBadGateway, "guardian.import_google_error")
+			return
+		}
+	}
+
+	truncated := len(contacts) > maxImportContacts
+	if truncated {
+		contacts = contacts[:maxImportContacts]
+	}
+
+	existing := newDuplicateIndex(h.store.ListGuardians(userID))
+	seen := newDuplicateIndex(nil)
+	preview := make([]importContact, 0, len(contacts))
+	duplicates, skipped := 0, 0
+	for _, contact := range contacts {
+		contact, ok := normalizeImportContact(contact)
+		if !ok || seen.find(contact) != "" {
+			skipped++
+			continue
+		}
+		seen.add(contact, "-")
+		if contact.DuplicateOf = existing.find(contact); contact.DuplicateOf != "" {
+			duplicates++
+		}
+		preview = append(preview, contact)
+	}
+
+	h.auditLogger.LogDataAccess(userID, security.GetClientIP(r), "guardians/import", "preview", "success")
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"source":     source,
+		"contacts":   preview,
+		"new":        len(preview) - duplicates,
+		"duplicates": duplicates,
+		"skipped":    skipped,
+		"truncated":  truncated,
+	})
+}
+
+// readVCardFile lê o arquivo .vcf do multipart (campo "file")
+func readVCardFile(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	if err := r.ParseMultipartForm(maxVCardSize); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "guardian.import_invalid_file")
+		return nil, false
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "guardian.import_invalid_file")
+		return nil, false
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxVCardSize+1))
+	if err != nil || len(data) > maxVCardSize || !strings.Contains(strings.ToUpper(string(data)), "BEGIN:VCARD") {
+		apierror.Write(w, r, http.StatusBadRequest, "guardian.import_invalid_file")
+		return nil, false
+	}
+	return data, true
+}
+
+// =============================================================================
+// CONFIRMAÇÃO
+// =============================================================================
+
+// Confirm cria os guardiões escolhidos na prévia
+//
+// Endpoint: POST /api/guardians/import/confirm
+// Body: {"contacts": [{"name", "email", "phone", "relationship"}], "access_pin": "1234"}
+//
+// Contatos duplicados (de guardiões existentes ou repetidos na lista) e
+// sem email nem telefone voltam em skipped, com o motivo.
+func (h *ImportHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	var req confirmImportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "guardian.invalid_data")
+		return
+	}
+	if len(req.Contacts) == 0 {
+		apierror.Write(w, r, http.StatusBadRequest, "guardian.import_empty")
+		return
+	}
+	if len(req.Contacts) > maxImportContacts {
+		apierror.Write(w, r, http.StatusBadRequest, "guardian.import_too_many")
+		return
+	}
+	if req.AccessPIN == "" {
+		apierror.Write(w, r, http.StatusBadRequest, "guardian.pin_required")
+		return
+	}
+	if len(req.AccessPIN) < 4 {
+		apierror.Write(w, r, http.StatusBadRequest, "guardian.pin_too_short")
+		return
+	}
+
+	existing := newDuplicateIndex(h.store.ListGuardians(userID))
+	seen := newDuplicateIndex(nil)
+	toCreate := make([]importContact, 0, len(req.Contacts))
+	skipped := make([]importContact, 0)
+	for _, contact := range req.Contacts {
+		normalized, ok := normalizeImportContact(contact)
+		if !ok {
+			contact.Reason = skipInvalid
+			skipped = append(skipped, contact)
+			continue
+		}
+		normalized.DuplicateOf = existing.find(normalized)
+		if normalized.DuplicateOf != "" || seen.find(normalized) != "" {
+			normalized.Reason = skipDuplicate
+			skipped = append(skipped, normalized)
+			continue
+		}
+		seen.add(normalized, "-")
+		toCreate = append(toCreate, normalized)
+	}
+
+	if len(toCreate) > 0 && h.checkLimit != nil && !h.checkLimit(w, r, len(toCreate)) {
+		return
+	}
+
+	pinHash, err := bcrypt.GenerateFromPassword([]byte(req.AccessPIN), bcrypt.DefaultCost)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "guardian.add_error")
+		return
+	}
+
+	created := make([]*storage.Guardian, 0, len(toCreate))
+	for _, contact := range toCreate {
+		guardian, err := h.store.CreateGuardian(userID, &storage.Guardian{
+			Name:         contact.Name,
+			Email:        contact.Email,
+			Phone:        contact.Phone,
+			Relationship: contact.Relationship,
+			Role:         "viewer",
+			AccessPIN:    string(pinHash),
+		})
+		if err != nil {
+			log.Printf("[Guardians] Erro na importação (%d de %d criados): %v", len(created), len(toCreate), err)
+			apierror.Write(w, r, http.StatusInternalServerError, "guardian.add_error")
+			return
+		}
+		created = append(created, guardian)
+
+		webhooks.Emit(userID, storage.WebhookGuardianAdded, map[string]interface{}{
+			"guardian_id":  guardian.ID,
+			"relationship": guardian.Relationship,
+			"role":         guardian.Role,
+			"created_at":   guardian.CreatedAt,
+		})
+	}
+
+	h.auditLogger.LogDataAccess(userID, security.GetClientIP(r), "guardians/import", "create", "success")
+
+	status := http.StatusOK
+	if len(created) > 0 {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, map[string]interface{}{
+		"created": created,
+		"skipped": skipped,
+	})
+}
+
+// =============================================================================
+// NORMALIZAÇÃO E DUPLICATAS
+// =============================================================================
+
+// normalizeImportContact sanitiza o contato como no cadastro manual
+// Email e telefone inválidos são descartados; sem nenhum dos dois (ou sem
+// nome) o contato não pode ser importado.
+func normalizeImportContact(contact importContact) (importContact, bool) {
+	normalized := importContact{
+		Name:         security.SanitizeName(contact.Name),
+		Relationship: security.SanitizeText(contact.Relationship, security.MaxNameLength),
+	}
+	if email, err := security.ValidateEmail(contact.Email); err == nil {
+		normalized.Email = email
+	}
+	if phone, err := security.ValidatePhone(contact.Phone); err == nil {
+		normalized.Phone = phone
+	}
+	if normalized.Name == "" || (normalized.Email == "" && normalized.Phone == "") {
+		return normalized, false
+	}
+	return normalized, true
+}
+
+// duplicateIndex encontra guardiões pelo email ou telefone normalizados
+type duplicateIndex struct {
+	emails map[string]string // email -> guardianID
+	phones map[string]string // telefone -> guardianID
+}
+
+// newDuplicateIndex indexa os guardiões já cadastrados
+func newDuplicateIndex(guardians []*storage.Guardian) *duplicateIndex {
+	index := &duplicateIndex{emails: map[string]string{}, phones: map[string]string{}}
+	for _, g := range guardians {
+		contact := importContact{}
+		if email, err := security.ValidateEmail(g.Email); err == nil {
+			contact.Email = email
+		}
+		if phone, err := security.ValidatePhone(g.Phone); err == nil {
+			contact.Phone = phone
+		}
+		index.add(contact, g.ID)
+	}
+	return index
+}
+
+// add registra o email e o telefone do contato
+func (d *duplicateIndex) add(contact importContact, guardianID string) {
+	if contact.Email != "" {
+		d.emails[contact.Email] = guardianID
+	}
+	if contact.Phone != "" {
+		d.phones[contact.Phone] = guardianID
+	}
+}
+
+// find retorna o guardião com o mesmo email ou telefone ("" se nenhum)
+func (d *duplicateIndex) find(contact importContact) string {
+	if id := d.emails[contact.Email]; contact.Email != "" && id != "" {
+		return id
+	}
+	if id := d.phones[contact.Phone]; contact.Phone != "" && id != "" {
+		return id
+	}
+	return ""
+}
@@ -0,0 +1,179 @@
+package guardian
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+// =============================================================================
+// RECADOS ÀS PESSOAS DE CONFIANÇA
+// =============================================================================
+// O dono escreve um recado ("mudei a chave de lugar") para todos ou alguns
+// guardiões. Cada guardião recebe um aviso em guardian_alerts, com as mesmas
+// tentativas e retentativas dos alertas de emergência; o dono acompanha as
+// entregas em GET /api/guardians/alerts.
+
+// maxMessageLength limita o recado (em caracteres, antes dos placeholders)
+const maxMessageLength = 1000
+
+// MessageSender registra o recado a um guardião e tenta a entrega em background
+// (whatsapp.Service.MessageGuardian)
+type MessageSender func(userID string, guardian *storage.Guardian, message string, channels []storage.NotifyChannel) (*storage.GuardianAlert, error)
+
+// notifyPayload é o corpo de POST /api/guardians/notify
+type notifyPayload struct {
+	Message     string   `json:"message"`
+	GuardianIDs []string `json:"guardian_ids,omitempty"` // Vazio = todos com acesso ativo
+	Channels    []string `json:"channels,omitempty"`     // Ordem de preferência; vazio = a de cada guardião
+}
+
+// Notify envia um recado do dono às pessoas de confiança
+//
+// Endpoint: POST /api/guardians/notify
+//
+// Body: {"message": "Oi, {name}! Mudei a chave de lugar.", "guardian_ids": [...], "channels": ["whatsapp", "email"]}
+//
+// O texto aceita {name} e {relationship} (do guardião) e {owner} (nome do
+// dono). Guardiões sem contato para os canais escolhidos ficam com o aviso
+// "failed". Limitado por usuário (security.GuardianMessageRateLimit).
+func (h *Handler) Notify(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	var payload notifyPayload
+	if err := security.DecodeJSONStrict(r.Body, &payload); err != nil {
+		apierror.WriteDecodeError(w, r, err, "guardian.invalid_data")
+		return
+	}
+
+	message := strings.TrimSpace(payload.Message)
+	if message == "" {
+		apierror.Write(w, r, http.StatusBadRequest, "guardian.message_required")
+		return
+	}
+	if utf8.RuneCountInString(message) > maxMessageLength {
+		apierror.Write(w, r, http.StatusBadRequest, "guardian.message_too_long")
+		return
+	}
+
+	channels, ok := parseMessageChannels(payload.Channels)
+	if !ok {
+		apierror.Write(w, r, http.StatusBadRequest, "guardian.invalid_notify_channel")
+		return
+	}
+
+	recipients, status, errKey := h.messageRecipients(userID, payload.GuardianIDs)
+	if errKey != "" {
+		apierror.Write(w, r, status, errKey)
+		return
+	}
+
+	ownerName := ""
+	if owner, ok := h.store.GetUserByID(userID); ok {
+		ownerName = owner.Name
+	}
+
+	alerts := make([]*storage.GuardianAlert, 0, len(recipients))
+	for _, guardian := range recipients {
+		text := strings.NewReplacer(
+			"{name}", guardian.Name,
+			"{relationship}", guardian.Relationship,
+			"{owner}", ownerName,
+		).Replace(message)
+
+		alert, err := h.sendMessage(userID, guardian, text, channels)
+		if err != nil {
+			log.Printf("[GUARDIAN] Erro ao registrar recado ao guardião %s: %v", guardian.ID, err)
+			continue
+		}
+		alert.GuardianName = guardian.Name
+		alerts = append(alerts, alert)
+	}
+	if len(alerts) == 0 {
+		apierror.Write(w, r, http.StatusInternalServerError, "guardian.notify_error")
+		return
+	}
+
+	h.auditLogger.Log(security.AuditEvent{
+		Type:     security.EventGuardianMessageSent,
+		Severity: security.SeverityInfo,
+		UserID:   userID,
+		ClientIP: security.GetClientIP(r),
+		Resource: "guardians",
+		Action:   "notify",
+		Result:   "success",
+		Details: map[string]interface{}{
+			"recipients": len(alerts),
+			"channels":   channels,
+		},
+	})
+
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{
+		"alerts": alerts,
+	})
+}
+
+// messageRecipients resolve os guardiões escolhidos (vazio = todos com acesso
+// ativo). Retorna o status e a chave i18n do erro (vazia se válido).
+func (h *Handler) messageRecipients(userID string, guardianIDs []string) ([]*storage.Guardian, int, string) {
+	guardians := h.store.ListGuardians(userID)
+
+	if len(guardianIDs) == 0 {
+		recipients := make([]*storage.Guardian, 0, len(guardians))
+		for _, g := range guardians {
+			if g.AccessDisabledAt == nil {
+				recipients = append(recipients, g)
+			}
+		}
+		if len(recipients) == 0 {
+			return nil, http.StatusBadRequest, "guardian.no_recipients"
+		}
+		return recipients, 0, ""
+	}
+
+	byID := make(map[string]*storage.Guardian, len(guardians))
+	for _, g := range guardians {
+		byID[g.ID] = g
+	}
+	recipients := make([]*storage.Guardian, 0, len(guardianIDs))
+	seen := make(map[string]bool, len(guardianIDs))
+	for _, id := range guardianIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		g, ok := byID[id]
+		if !ok {
+			return nil, http.StatusNotFound, "guardian.not_found"
+		}
+		if g.AccessDisabledAt != nil {
+			return nil, http.StatusBadRequest, "guardian.recipient_access_disabled"
+		}
+		recipients = append(recipients, g)
+	}
+	return recipients, 0, ""
+}
+
+// parseMessageChannels valida os canais escolhidos para o recado, sem repetir
+func parseMessageChannels(values []string) ([]storage.NotifyChannel, bool) {
+	var channels []storage.NotifyChannel
+	seen := make(map[storage.NotifyChannel]bool, len(values))
+	for _, value := range values {
+		channel, ok := storage.ParseNotifyChannel(strings.TrimSpace(value))
+		if !ok || channel == storage.NotifyAuto {
+			return nil, false
+		}
+		if !seen[channel] {
+			seen[channel] = true
+			channels = append(channels, channel)
+		}
+	}
+	return channels, true
+}
@@ -0,0 +1,230 @@
+// =============================================================================
+// FAMLI - Regras de Acesso das Pessoas de Confiança
+// =============================================================================
+// O dono limita quando cada pessoa de confiança pode abrir o que foi
+// compartilhado:
+//
+// - GET    /api/guardians/{guardianID}/access-policy - regras e estado atual
+// - PUT    /api/guardians/{guardianID}/access-policy - define as regras
+// - DELETE /api/guardians/{guardianID}/access-policy - remove as regras
+//
+// A conferência acontece no link e no portal (ver share/access_policy.go).
+// =============================================================================
+
+package guardian
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+const (
+	// maxAccessWindows é o número máximo de janelas de horário
+	maxAccessWindows = 14
+
+	// maxPolicyAccesses é o maior limite de acessos aceito
+	maxPolicyAccesses = 10000
+)
+
+// accessPolicyPayload é o corpo de PUT /api/guardians/{guardianID}/access-policy
+type accessPolicyPayload struct {
+	ExpiresAt   *time.Time             `json:"expires_at"`   // null = sem prazo
+	Windows     []storage.AccessWindow `json:"windows"`      // vazio = qualquer horário
+	Timezone    string                 `json:"timezone"`     // vazio = America/Sao_Paulo
+	MaxAccesses int                    `json:"max_accesses"` // 0 = sem limite
+	ResetCount  bool                   `json:"reset_count"`  // Zera os acessos já contados
+}
+
+// accessPolicyResponse são as regras com o estado de agora
+type accessPolicyResponse struct {
+	*storage.GuardianAccessPolicy
+	Status            string `json:"status"`                       // active, expired, outside_window ou limit_reached
+	RemainingAccesses *int   `json:"remaining_accesses,omitempty"` // Com max_accesses
+}
+
+// newAccessPolicyResponse calcula o estado das regras
+func newAccessPolicyResponse(policy *storage.GuardianAccessPolicy) accessPolicyResponse {
+	response := accessPolicyResponse{GuardianAccessPolicy: policy, Status: "active"}
+	if block := policy.Check(time.Now()); block != storage.AccessPolicyAllowed {
+		response.Status = string(block)
+	}
+	if policy.MaxAccesses > 0 {
+		remaining := policy.MaxAccesses - policy.AccessCount
+		if remaining < 0 {
+			remaining = 0
+		}
+		response.RemainingAccesses = &remaining
+	}
+	return response
+}
+
+// GetAccessPolicy retorna as regras de acesso da pessoa de confiança
+//
+// Endpoint: GET /api/guardians/{guardianID}/access-policy
+//
+// 404 (guardian.policy_not_found) se não houver regras.
+func (h *Handler) GetAccessPolicy(w http.ResponseWriter, r *http.Request) {
+	guardian := h.findGuardian(auth.GetUserID(r), chi.URLParam(r, "guardianID"))
+	if guardian == nil {
+		apierror.Write(w, r, http.StatusNotFound, "guardian.not_found")
+		return
+	}
+
+	policy, err := h.store.GetGuardianAccessPolicy(guardian.ID)
+	if err != nil {
+		apierror.WriteStorage(w, r, err, apierror.StorageKeys{NotFound: "guardian.policy_not_found", Internal: "guardian.policy_error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, newAccessPolicyResponse(policy))
+}
+
+// PutAccessPolicy define as regras de acesso da pessoa de confiança
+//
+// Endpoint: PUT /api/guardians/{guardianID}/access-policy
+//
+// Body: {"expires_at": "2027-01-01T00:00:00Z", "timezone": "America/Sao_Paulo",
+// "windows": [{"weekdays": [1,2,3,4,5], "start": "08:00", "end": "18:00"}],
+// "max_accesses": 20, "reset_count": false}
+//
+// Os acessos já contados continuam valendo (a não ser com reset_count).
+func (h *Handler) PutAccessPolicy(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+	guardian := h.findGuardian(userID, chi.URLParam(r, "guardianID"))
+	if guardian == nil {
+		apierror.Write(w, r, http.StatusNotFound, "guardian.not_found")
+		return
+	}
+
+	var payload accessPolicyPayload
+	if err := security.DecodeJSONStrict(r.Body, &payload); err != nil {
+		apierror.WriteDecodeError(w, r, err, "guardian.policy_invalid")
+		return
+	}
+	policy, key := buildAccessPolicy(guardian.ID, &payload)
+	if policy == nil {
+		apierror.Write(w, r, http.StatusBadRequest, key)
+		return
+	}
+
+	previous, err := h.store.GetGuardianAccessPolicy(guardian.ID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		apierror.Write(w, r, http.StatusInternalServerError, "guardian.policy_error")
+		return
+	}
+	if previous != nil && !payload.ResetCount {
+		policy.AccessCount = previous.AccessCount
+		policy.BlockedNotifiedAt = previous.BlockedNotifiedAt
+	}
+
+	if err := h.store.SaveGuardianAccessPolicy(policy); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "guardian.policy_error")
+		return
+	}
+
+	h.logPolicyChange(r, guardian, "update", map[string]interface{}{
+		"expires_at":   policy.ExpiresAt,
+		"windows":      len(policy.Windows),
+		"max_accesses": policy.MaxAccesses,
+		"reset_count":  payload.ResetCount,
+	})
+	writeJSON(w, http.StatusOK, newAccessPolicyResponse(policy))
+}
+
+// DeleteAccessPolicy remove as regras (acesso sem prazo, horário ou limite)
+//
+// Endpoint: DELETE /api/guardians/{guardianID}/access-policy
+func (h *Handler) DeleteAccessPolicy(w http.ResponseWriter, r *http.Request) {
+	guardian := h.findGuardian(auth.GetUserID(r), chi.URLParam(r, "guardianID"))
+	if guardian == nil {
+		apierror.Write(w, r, http.StatusNotFound, "guardian.not_found")
+		return
+	}
+
+	if err := h.store.DeleteGuardianAccessPolicy(guardian.ID); err != nil {
+		apierror.WriteStorage(w, r, err, apierror.StorageKeys{NotFound: "guardian.policy_not_found", Internal: "guardian.policy_error"})
+		return
+	}
+
+	h.logPolicyChange(r, guardian, "delete", nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// buildAccessPolicy valida o payload (nil + chave do erro se inválido)
+func buildAccessPolicy(guardianID string, payload *accessPolicyPayload) (*storage.GuardianAccessPolicy, string) {
+	policy := &storage.GuardianAccessPolicy{
+		GuardianID:  guardianID,
+		Timezone:    strings.TrimSpace(payload.Timezone),
+		MaxAccesses: payload.MaxAccesses,
+		Windows:     make([]storage.AccessWindow, 0, len(payload.Windows)),
+	}
+	if policy.Timezone == "" {
+		policy.Timezone = storage.DefaultAccessTimezone
+	}
+	if _, err := time.LoadLocation(policy.Timezone); err != nil {
+		return nil, "guardian.policy_invalid_timezone"
+	}
+
+	if payload.ExpiresAt != nil {
+		if !payload.ExpiresAt.After(time.Now()) {
+			return nil, "guardian.policy_invalid_expiry"
+		}
+		expiresAt := payload.ExpiresAt.UTC()
+		policy.ExpiresAt = &expiresAt
+	}
+
+	if payload.MaxAccesses < 0 || payload.MaxAccesses > maxPolicyAccesses {
+		return nil, "guardian.policy_invalid_limit"
+	}
+
+	if len(payload.Windows) > maxAccessWindows {
+		return nil, "guardian.policy_invalid_window"
+	}
+	for _, window := range payload.Windows {
+		start, okStart := storage.ParseClock(window.Start)
+		end, okEnd := storage.ParseClock(window.End)
+		if !okStart || !okEnd || start == end || len(window.Weekdays) == 0 {
+			return nil, "guardian.policy_invalid_window"
+		}
+		weekdays := make([]time.Weekday, 0, len(window.Weekdays))
+		seen := make(map[time.Weekday]bool, len(window.Weekdays))
+		for _, day := range window.Weekdays {
+			if day < time.Sunday || day > time.Saturday {
+				return nil, "guardian.policy_invalid_window"
+			}
+			if !seen[day] {
+				seen[day] = true
+				weekdays = append(weekdays, day)
+			}
+		}
+		window.Weekdays = weekdays
+		policy.Windows = append(policy.Windows, window)
+	}
+
+	if policy.ExpiresAt == nil && len(policy.Windows) == 0 && policy.MaxAccesses == 0 {
+		return nil, "guardian.policy_empty"
+	}
+	return policy, ""
+}
+
+// logPolicyChange registra a alteração das regras na auditoria
+func (h *Handler) logPolicyChange(r *http.Request, guardian *storage.Guardian, action string, details map[string]interface{}) {
+	h.auditLogger.Log(security.AuditEvent{
+		Type:     security.EventGuardianPolicyChanged,
+		Severity: security.SeverityInfo,
+		UserID:   auth.GetUserID(r),
+		ClientIP: security.GetClientIP(r),
+		Resource: "guardian:" + guardian.ID,
+		Action:   action,
+		Result:   "success",
+		Details:  details,
+	})
+}
@@ -0,0 +1,209 @@
+// =============================================================================
+// FAMLI - Importação de Guardiões: vCard
+// =============================================================================
+// Leitura dos arquivos .vcf exportados pelo celular, Google Contatos,
+// iCloud e Outlook (vCard 2.1, 3.0 e 4.0). Só interessam nome, email e
+// telefone: fotos, endereços e demais campos são ignorados.
+// =============================================================================
+
+package guardian
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"mime/quotedprintable"
+	"strings"
+)
+
+// vcardProperty é uma linha do vCard já desdobrada
+type vcardProperty struct {
+	name   string            // Em maiúsculas, sem o grupo (ex: "TEL")
+	params map[string]string // Em maiúsculas (ex: TYPE=CELL,PREF)
+	value  string
+}
+
+// parseVCards lê os contatos de um arquivo vCard
+//
+// Sem FN, o nome vem do campo N; cartões sem email nem telefone são
+// descartados.
+func parseVCards(data []byte) []importContact {
+	var contacts []importContact
+	var current *vcardContact
+	for _, line := range unfoldVCard(data) {
+		prop, ok := parseVCardLine(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case prop.name == "BEGIN" && strings.EqualFold(prop.value, "VCARD"):
+			current = &vcardContact{}
+		case prop.name == "END" && strings.EqualFold(prop.value, "VCARD"):
+			if current != nil {
+				if contact, ok := current.contact(); ok {
+					contacts = append(contacts, contact)
+				}
+			}
+			current = nil
+		case current != nil:
+			current.add(prop)
+		}
+	}
+	return contacts
+}
+
+// vcardContact acumula as propriedades de um cartão
+type vcardContact struct {
+	fullName   string
+	structured string // N: sobrenome;nome;...
+	emails     []string
+	phones     []string
+}
+
+// add registra uma propriedade do cartão (preferidas primeiro)
+func (c *vcardContact) add(prop vcardProperty) {
+	value := strings.TrimSpace(decodeVCardValue(prop))
+	if value == "" {
+		return
+	}
+	preferred := strings.Contains(prop.params["TYPE"], "PREF") || prop.params["PREF"] != ""
+
+	switch prop.name {
+	case "FN":
+		c.fullName = unescapeVCard(value)
+	case "N":
+		c.structured = value
+	case "EMAIL":
+		c.emails = addPreferred(c.emails, unescapeVCard(value), preferred)
+	case "TEL":
+		c.phones = addPreferred(c.phones, strings.TrimPrefix(unescapeVCard(value), "tel:"), preferred)
+	}
+}
+
+// contact monta o contato (ok = false se não há como falar com a pessoa)
+func (c *vcardContact) contact() (importContact, bool) {
+	contact := importContact{Name: c.fullName}
+	if contact.Name == "" && c.structured != "" {
+		// N: sobrenome;nome;nomes do meio;prefixo;sufixo
+		parts := splitVCardValue(c.structured)
+		for len(parts) < 3 {
+			parts = append(parts, "")
+		}
+		contact.Name = strings.Join(strings.Fields(parts[1]+" "+parts[2]+" "+parts[0]), " ")
+	}
+	if len(c.emails) > 0 {
+		contact.Email = c.emails[0]
+	}
+	if len(c.phones) > 0 {
+		contact.Phone = c.phones[0]
+	}
+	if contact.Email == "" && contact.Phone == "" {
+		return contact, false
+	}
+	return contact, true
+}
+
+// addPreferred adiciona o valor à lista, no início se for o preferido
+func addPreferred(values []string, value string, preferred bool) []string {
+	if preferred {
+		return append([]string{value}, values...)
+	}
+	return append(values, value)
+}
+
+// unfoldVCard junta as linhas dobradas (continuação começa com espaço ou
+// tab; no quoted-printable do vCard 2.1, a linha termina com "=")
+func unfoldVCard(data []byte) []string {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // BOM
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+
+	var lines []string
+	softBreak := false
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		switch {
+		case len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")):
+			lines[len(lines)-1] += line[1:]
+		case softBreak && len(lines) > 0:
+			lines[len(lines)-1] += line
+		default:
+			lines = append(lines, line)
+		}
+		last := lines[len(lines)-1]
+		softBreak = strings.HasSuffix(last, "=") && strings.Contains(strings.ToUpper(last), "QUOTED-PRINTABLE")
+		if softBreak {
+			lines[len(lines)-1] = strings.TrimSuffix(last, "=")
+		}
+	}
+	return lines
+}
+
+// parseVCardLine separa grupo, nome, parâmetros e valor
+// Ex: "item1.EMAIL;TYPE=INTERNET,pref:maria@example.com"
+func parseVCardLine(line string) (vcardProperty, bool) {
+	colon := strings.Index(line, ":")
+	if colon <= 0 {
+		return vcardProperty{}, false
+	}
+	head := strings.Split(line[:colon], ";")
+	name := strings.ToUpper(head[0])
+	if dot := strings.LastIndex(name, "."); dot >= 0 {
+		name = name[dot+1:]
+	}
+
+	params := make(map[string]string, len(head)-1)
+	for _, param := range head[1:] {
+		key, value, found := strings.Cut(param, "=")
+		if !found {
+			// vCard 2.1: "TEL;CELL;PREF:..." (tipos sem TYPE=)
+			key, value = "TYPE", param
+		}
+		key = strings.ToUpper(strings.TrimSpace(key))
+		value = strings.ToUpper(strings.Trim(strings.TrimSpace(value), `"`))
+		if params[key] != "" {
+			value = params[key] + "," + value
+		}
+		params[key] = value
+	}
+	return vcardProperty{name: name, params: params, value: line[colon+1:]}, true
+}
+
+// decodeVCardValue decodifica o quoted-printable do vCard 2.1
+func decodeVCardValue(prop vcardProperty) string {
+	if prop.params["ENCODING"] != "QUOTED-PRINTABLE" && !strings.Contains(prop.params["TYPE"], "QUOTED-PRINTABLE") {
+		return prop.value
+	}
+	decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(prop.value)))
+	if err != nil {
+		return prop.value
+	}
+	return string(decoded)
+}
+
+// splitVCardValue separa os componentes de um valor estruturado (";"),
+// respeitando "\;"
+func splitVCardValue(value string) []string {
+	var parts []string
+	var current strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) {
+			current.WriteByte(value[i])
+			current.WriteByte(value[i+1])
+			i++
+			continue
+		}
+		if value[i] == ';' {
+			parts = append(parts, unescapeVCard(current.String()))
+			current.Reset()
+			continue
+		}
+		current.WriteByte(value[i])
+	}
+	return append(parts, unescapeVCard(current.String()))
+}
+
+// unescapeVCard desfaz os escapes de texto do vCard (\, \; \n)
+func unescapeVCard(value string) string {
+	return strings.NewReplacer(`\,`, ",", `\;`, ";", `\n`, " ", `\N`, " ", `\\`, `\`).Replace(value)
+}
@@ -0,0 +1,177 @@
+// =============================================================================
+// FAMLI - Guia Famli: Administração dos Cards
+// =============================================================================
+// Endpoints (superadmin):
+// - GET    /api/admin/guide/cards      - todos os cards, com textos de todos os idiomas
+// - POST   /api/admin/guide/cards      - cria um card
+// - PUT    /api/admin/guide/cards/{id} - substitui um card
+// - DELETE /api/admin/guide/cards/{id} - remove (cards padrão voltam ao original)
+//
+// Para esconder um card sem perder o conteúdo, use "active": false.
+// =============================================================================
+
+package guide
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/i18n"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+// cardPayload é o corpo de criação/edição de um card
+type cardPayload struct {
+	ID        string              `json:"id"` // Apenas na criação
+	Icon      string              `json:"icon"`
+	Order     int                 `json:"order"`
+	ItemType  string              `json:"item_type"`
+	Active    *bool               `json:"active"` // nil = ativo
+	Texts     map[string]CardText `json:"texts"`
+	Templates []ItemTemplate      `json:"templates"`
+}
+
+// toCard converte o payload em card (idiomas normalizados)
+func (p *cardPayload) toCard(id, adminID string) *Card {
+	now := time.Now()
+	card := &Card{
+		ID:        id,
+		Icon:      p.Icon,
+		Order:     p.Order,
+		ItemType:  p.ItemType,
+		Active:    p.Active == nil || *p.Active,
+		Texts:     normalizeLocales(p.Texts),
+		Templates: make([]ItemTemplate, len(p.Templates)),
+		UpdatedAt: &now,
+		UpdatedBy: adminID,
+	}
+	for i, tpl := range p.Templates {
+		tpl.Texts = normalizeLocales(tpl.Texts)
+		card.Templates[i] = tpl
+	}
+	return card
+}
+
+// normalizeLocales aceita "en-US", "es-AR"... como en, es
+// Idiomas desconhecidos ficam como vieram (e são recusados na validação).
+func normalizeLocales[T any](texts map[string]T) map[string]T {
+	result := make(map[string]T, len(texts))
+	for locale, text := range texts {
+		if normalized := i18n.Normalize(locale); normalized != "" {
+			locale = normalized
+		}
+		result[locale] = text
+	}
+	return result
+}
+
+// AdminListCards retorna todos os cards, inclusive os inativos
+//
+// Endpoint: GET /api/admin/guide/cards
+func (h *Handler) AdminListCards(w http.ResponseWriter, r *http.Request) {
+	cards, err := loadCards(h.store)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "guide.load_error")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"cards": cards})
+}
+
+// AdminCreateCard cria um card
+//
+// Endpoint: POST /api/admin/guide/cards
+//
+// Body: {"id": "pets", "icon": "🐾", "order": 7, "texts": {"pt-BR": {"title": "..."}}}
+func (h *Handler) AdminCreateCard(w http.ResponseWriter, r *http.Request) {
+	var payload cardPayload
+	if err := security.DecodeJSONStrict(r.Body, &payload); err != nil {
+		apierror.WriteDecodeError(w, r, err, "guide.invalid_data")
+		return
+	}
+
+	if _, err := findCard(h.store, payload.ID); err == nil {
+		apierror.Write(w, r, http.StatusConflict, "guide.card_exists")
+		return
+	} else if !errors.Is(err, storage.ErrNotFound) {
+		apierror.Write(w, r, http.StatusInternalServerError, "guide.load_error")
+		return
+	}
+
+	h.saveCard(w, r, payload.toCard(payload.ID, auth.GetUserID(r)), "create", http.StatusCreated)
+}
+
+// AdminUpdateCard substitui o conteúdo de um card
+//
+// Endpoint: PUT /api/admin/guide/cards/{cardID}
+func (h *Handler) AdminUpdateCard(w http.ResponseWriter, r *http.Request) {
+	cardID := chi.URLParam(r, "cardID")
+	if _, err := findCard(h.store, cardID); err != nil {
+		apierror.WriteStorage(w, r, err, apierror.StorageKeys{NotFound: "guide.card_not_found", Internal: "guide.load_error"})
+		return
+	}
+
+	var payload cardPayload
+	if err := security.DecodeJSONStrict(r.Body, &payload); err != nil {
+		apierror.WriteDecodeError(w, r, err, "guide.invalid_data")
+		return
+	}
+
+	h.saveCard(w, r, payload.toCard(cardID, auth.GetUserID(r)), "update", http.StatusOK)
+}
+
+// AdminDeleteCard remove um card criado pelo admin
+// Cards padrão voltam ao conteúdo original (e são retornados).
+//
+// Endpoint: DELETE /api/admin/guide/cards/{cardID}
+func (h *Handler) AdminDeleteCard(w http.ResponseWriter, r *http.Request) {
+	cardID := chi.URLParam(r, "cardID")
+	err := h.store.DeleteSystemConfig(configPrefix + cardID)
+
+	original, builtIn := defaultCard(cardID)
+	if err != nil && !(builtIn && errors.Is(err, storage.ErrNotFound)) {
+		apierror.WriteStorage(w, r, err, apierror.StorageKeys{NotFound: "guide.card_not_found", Internal: "guide.save_error"})
+		return
+	}
+
+	h.logChange(r, cardID, "delete")
+	if builtIn {
+		writeJSON(w, http.StatusOK, original)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// saveCard valida, persiste e audita um card
+func (h *Handler) saveCard(w http.ResponseWriter, r *http.Request, card *Card, action string, status int) {
+	if key := validateCard(card); key != "" {
+		apierror.Write(w, r, http.StatusBadRequest, key)
+		return
+	}
+	if err := saveCard(h.store, card); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "guide.save_error")
+		return
+	}
+
+	_, card.BuiltIn = defaultCard(card.ID)
+	h.logChange(r, card.ID, action)
+	writeJSON(w, status, card)
+}
+
+// logChange registra a alteração de conteúdo na auditoria
+func (h *Handler) logChange(r *http.Request, cardID, action string) {
+	security.GetAuditLogger().Log(security.AuditEvent{
+		Type:     security.EventGuideCardChanged,
+		Severity: security.SeverityInfo,
+		UserID:   auth.GetUserID(r),
+		ClientIP: security.GetClientIP(r),
+		Resource: "guide_card:" + cardID,
+		Action:   action,
+		Result:   "success",
+	})
+}
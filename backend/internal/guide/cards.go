@@ -0,0 +1,322 @@
+// =============================================================================
+// FAMLI - Guia Famli: Catálogo de Cards
+// =============================================================================
+// Os cards do Guia são editáveis pelo admin, sem deploy:
+// - Título e descrição por idioma (pt-BR, en, es)
+// - Ordem, ícone e ativação
+// - Itens sugeridos (templates) para começar cada passo
+//
+// Armazenamento: tabela system_config, chave "guide_card:<id>", valor JSON
+// (como as feature flags). Os cards padrão (defaultCards) valem enquanto não
+// houver registro no banco; os textos deles (e dos itens sugeridos) vêm das
+// traduções (guide.card.*).
+// Remover um card padrão volta ao conteúdo original.
+// =============================================================================
+
+package guide
+
+import (
+	"encoding/json"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"famli/internal/i18n"
+	"famli/internal/storage"
+)
+
+// configPrefix é o prefixo das chaves de cards em system_config
+const configPrefix = "guide_card:"
+
+// Limites do conteúdo editável
+const (
+	maxTitleLength       = 120
+	maxDescriptionLength = 500
+	maxTemplateContent   = 2000
+	maxTemplates         = 5
+)
+
+// cardIDPattern restringe os IDs a slugs ("welcome", "pets-2")
+var cardIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,39}$`)
+
+// CardText são os textos de um card em um idioma
+type CardText struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// TemplateText são os textos de um item sugerido em um idioma
+type TemplateText struct {
+	Title   string `json:"title"`
+	Content string `json:"content,omitempty"`
+}
+
+// ItemTemplate é um item sugerido por um card
+type ItemTemplate struct {
+	Type     storage.ItemType        `json:"type"`
+	Category string                  `json:"category,omitempty"`
+	Texts    map[string]TemplateText `json:"texts"` // idioma -> textos
+
+	// OnlineAccount pré-preenche itens "online_account" (sem serviço, vale o
+	// título traduzido)
+	OnlineAccount *storage.OnlineAccount `json:"online_account,omitempty"`
+}
+
+// Card é a definição editável de um card do Guia
+type Card struct {
+	ID        string              `json:"id"`
+	Icon      string              `json:"icon"`
+	Order     int                 `json:"order"`
+	ItemType  string              `json:"item_type,omitempty"` // tipo de item ou "guardian"
+	Active    bool                `json:"active"`
+	Texts     map[string]CardText `json:"texts"` // idioma -> textos
+	Templates []ItemTemplate      `json:"templates,omitempty"`
+	BuiltIn   bool                `json:"built_in"`             // Card padrão (remover restaura o original)
+	UpdatedAt *time.Time          `json:"updated_at,omitempty"` // Última alteração (nil = padrão)
+	UpdatedBy string              `json:"updated_by,omitempty"` // ID do admin que alterou
+}
+
+// defaultCards são os cards originais (textos nas traduções guide.card.<id>.*
+// e guide.card.<id>.template.<n>.* para os itens sugeridos)
+var defaultCards = []Card{
+	{ID: "welcome", Icon: "👋", Order: 1, ItemType: "info"},
+	{ID: "people", Icon: "👥", Order: 2, ItemType: "guardian"},
+	{ID: "locations", Icon: "📍", Order: 3, ItemType: "location"},
+	{ID: "routines", Icon: "🔄", Order: 4, ItemType: "routine"},
+	{ID: "access", Icon: "🔑", Order: 5, ItemType: "access"},
+	{ID: "memories", Icon: "💝", Order: 6, ItemType: "memory"},
+	{ID: "pets", Icon: "🐾", Order: 7, ItemType: "pet", Templates: []ItemTemplate{{Type: storage.ItemTypePet}}},
+	{ID: "digital_legacy", Icon: "🌐", Order: 8, ItemType: "online_account", Templates: []ItemTemplate{
+		{Type: storage.ItemTypeOnlineAccount, OnlineAccount: &storage.OnlineAccount{Action: storage.OnlineAccountTransfer}},
+		{Type: storage.ItemTypeOnlineAccount, OnlineAccount: &storage.OnlineAccount{Action: storage.OnlineAccountMemorialize}},
+		{Type: storage.ItemTypeOnlineAccount, OnlineAccount: &storage.OnlineAccount{Action: storage.OnlineAccountDelete}},
+	}},
+}
+
+// defaultCard monta um card padrão com os textos de todos os idiomas
+func defaultCard(id string) (*Card, bool) {
+	for _, base := range defaultCards {
+		if base.ID != id {
+			continue
+		}
+		card := base
+		card.Active = true
+		card.BuiltIn = true
+		card.Texts = make(map[string]CardText, len(i18n.Translations))
+		for locale := range i18n.Translations {
+			card.Texts[locale] = CardText{
+				Title:       i18n.T(locale, "guide.card."+id+".title"),
+				Description: i18n.T(locale, "guide.card."+id+".description"),
+			}
+		}
+		card.Templates = make([]ItemTemplate, len(base.Templates))
+		for n, tpl := range base.Templates {
+			key := "guide.card." + id + ".template." + strconv.Itoa(n+1)
+			tpl.Texts = make(map[string]TemplateText, len(i18n.Translations))
+			for locale := range i18n.Translations {
+				tpl.Texts[locale] = TemplateText{
+					Title:   i18n.T(locale, key+".title"),
+					Content: i18n.T(locale, key+".content"),
+				}
+			}
+			card.Templates[n] = tpl
+		}
+		return &card, true
+	}
+	return nil, false
+}
+
+// loadCards retorna todos os cards (padrão e do banco), em ordem
+func loadCards(store storage.Store) ([]*Card, error) {
+	values, err := store.ListSystemConfig(configPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	cards := make(map[string]*Card, len(defaultCards)+len(values))
+	for _, base := range defaultCards {
+		cards[base.ID], _ = defaultCard(base.ID)
+	}
+	for key, value := range values {
+		var card Card
+		if err := json.Unmarshal([]byte(value), &card); err != nil {
+			log.Printf("[Guide] Card inválido %s: %v", key, err)
+			continue
+		}
+		card.ID = strings.TrimPrefix(key, configPrefix)
+		_, card.BuiltIn = defaultCard(card.ID)
+		cards[card.ID] = &card
+	}
+
+	result := make([]*Card, 0, len(cards))
+	for _, card := range cards {
+		result = append(result, card)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Order != result[j].Order {
+			return result[i].Order < result[j].Order
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+// findCard retorna um card pelo ID
+func findCard(store storage.Store, id string) (*Card, error) {
+	cards, err := loadCards(store)
+	if err != nil {
+		return nil, err
+	}
+	for _, card := range cards {
+		if card.ID == id {
+			return card, nil
+		}
+	}
+	return nil, storage.ErrNotFound
+}
+
+// saveCard persiste um card (criação ou edição)
+func saveCard(store storage.Store, card *Card) error {
+	data, err := json.Marshal(card)
+	if err != nil {
+		return err
+	}
+	return store.SetSystemConfig(configPrefix+card.ID, string(data))
+}
+
+// activeCards retorna os cards ativos traduzidos para um idioma
+func activeCards(store storage.Store, locale string) ([]storage.GuideCard, error) {
+	cards, err := loadCards(store)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]storage.GuideCard, 0, len(cards))
+	for _, card := range cards {
+		if card.Active {
+			result = append(result, card.localize(locale))
+		}
+	}
+	return result, nil
+}
+
+// localize traduz o card (sem o idioma, usa pt-BR)
+func (c *Card) localize(locale string) storage.GuideCard {
+	text := pickText(c.Texts, locale)
+	card := storage.GuideCard{
+		ID:          c.ID,
+		Title:       text.Title,
+		Description: text.Description,
+		Icon:        c.Icon,
+		Order:       c.Order,
+		ItemType:    c.ItemType,
+	}
+	for _, tpl := range c.Templates {
+		tplText := pickText(tpl.Texts, locale)
+		template := storage.GuideItemTemplate{
+			Type:     tpl.Type,
+			Category: tpl.Category,
+			Title:    tplText.Title,
+			Content:  tplText.Content,
+		}
+		if tpl.OnlineAccount != nil {
+			account := *tpl.OnlineAccount
+			if account.Service == "" {
+				account.Service = tplText.Title
+			}
+			template.OnlineAccount = &account
+		}
+		card.Templates = append(card.Templates, template)
+	}
+	return card
+}
+
+// pickText escolhe os textos do idioma, de pt-BR ou de qualquer idioma
+func pickText[T any](texts map[string]T, locale string) T {
+	if text, ok := texts[i18n.Normalize(locale)]; ok {
+		return text
+	}
+	if text, ok := texts[i18n.DefaultLocale]; ok {
+		return text
+	}
+	for _, candidate := range sortedKeys(texts) {
+		return texts[candidate]
+	}
+	var zero T
+	return zero
+}
+
+// sortedKeys lista as chaves em ordem (escolha estável do idioma)
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// =============================================================================
+// VALIDAÇÃO
+// =============================================================================
+
+// cardItemTypes são os tipos aceitos em Card.ItemType
+var cardItemTypes = map[string]bool{
+	"guardian":                       true,
+	string(storage.ItemTypeInfo):     true,
+	string(storage.ItemTypeMemory):   true,
+	string(storage.ItemTypeNote):     true,
+	string(storage.ItemTypeAccess):   true,
+	string(storage.ItemTypeRoutine):  true,
+	string(storage.ItemTypeLocation): true,
+	string(storage.ItemTypePet):      true,
+
+	string(storage.ItemTypeOnlineAccount): true,
+}
+
+// validateCard confere um card antes de salvar
+// Retorna a chave de tradução do erro ("" quando válido).
+func validateCard(card *Card) string {
+	if !cardIDPattern.MatchString(card.ID) {
+		return "guide.card_invalid_id"
+	}
+	if card.Order < 0 || (card.ItemType != "" && !cardItemTypes[card.ItemType]) {
+		return "guide.invalid_data"
+	}
+
+	if _, ok := card.Texts[i18n.DefaultLocale]; !ok {
+		return "guide.card_missing_text"
+	}
+	for locale, text := range card.Texts {
+		if !i18n.IsSupported(locale) || strings.TrimSpace(text.Title) == "" ||
+			len([]rune(text.Title)) > maxTitleLength || len([]rune(text.Description)) > maxDescriptionLength {
+			return "guide.card_invalid_text"
+		}
+	}
+
+	if len(card.Templates) > maxTemplates {
+		return "guide.card_invalid_template"
+	}
+	for _, tpl := range card.Templates {
+		if !cardItemTypes[string(tpl.Type)] || tpl.Type == "guardian" {
+			return "guide.card_invalid_template"
+		}
+		if _, ok := tpl.Texts[i18n.DefaultLocale]; !ok {
+			return "guide.card_invalid_template"
+		}
+		if account := tpl.OnlineAccount; account != nil && (tpl.Type != storage.ItemTypeOnlineAccount ||
+			!account.Action.IsValid() || len([]rune(account.Service)) > maxTitleLength) {
+			return "guide.card_invalid_template"
+		}
+		for locale, text := range tpl.Texts {
+			if !i18n.IsSupported(locale) || strings.TrimSpace(text.Title) == "" ||
+				len([]rune(text.Title)) > maxTitleLength || len([]rune(text.Content)) > maxTemplateContent {
+				return "guide.card_invalid_template"
+			}
+		}
+	}
+	return ""
+}
@@ -2,47 +2,35 @@ package guide
 
 import (
 	"encoding/json"
+	"log"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 
+	"famli/internal/apierror"
 	"famli/internal/auth"
 	"famli/internal/i18n"
 	"famli/internal/storage"
 )
 
-// CardConfig define a configuração de um card do guia (sem textos)
-type CardConfig struct {
-	ID       string
-	Icon     string
-	Order    int
-	ItemType string
-}
-
-// cardConfigs são as configurações dos cards (sem textos para i18n)
-var cardConfigs = []CardConfig{
-	{ID: "welcome", Icon: "👋", Order: 1, ItemType: "info"},
-	{ID: "people", Icon: "👥", Order: 2, ItemType: "guardian"},
-	{ID: "locations", Icon: "📍", Order: 3, ItemType: "location"},
-	{ID: "routines", Icon: "🔄", Order: 4, ItemType: "routine"},
-	{ID: "access", Icon: "🔑", Order: 5, ItemType: "access"},
-	{ID: "memories", Icon: "💝", Order: 6, ItemType: "memory"},
-}
+// PendingCards retorna os cards que o usuário ainda não concluiu nem pulou
+// (usado fora das requisições, como no resumo pelo WhatsApp)
+func PendingCards(store storage.Store, userID, locale string) []storage.GuideCard {
+	cards, err := activeCards(store, locale)
+	if err != nil {
+		log.Printf("[Guide] Erro ao carregar cards: %v", err)
+		return nil
+	}
+	progress := store.GetGuideProgress(userID)
 
-// getLocalizedCards retorna os cards do guia traduzidos para o locale do request
-func getLocalizedCards(r *http.Request) []storage.GuideCard {
-	cards := make([]storage.GuideCard, len(cardConfigs))
-	for idx, cfg := range cardConfigs {
-		cards[idx] = storage.GuideCard{
-			ID:          cfg.ID,
-			Title:       i18n.Tr(r, "guide.card."+cfg.ID+".title"),
-			Description: i18n.Tr(r, "guide.card."+cfg.ID+".description"),
-			Icon:        cfg.Icon,
-			Order:       cfg.Order,
-			ItemType:    cfg.ItemType,
+	var pending []storage.GuideCard
+	for _, card := range cards {
+		if p, ok := progress[card.ID]; ok && (p.Status == "completed" || p.Status == "skipped") {
+			continue
 		}
+		pending = append(pending, card)
 	}
-	return cards
+	return pending
 }
 
 type Handler struct {
@@ -53,10 +41,15 @@ func NewHandler(store storage.Store) *Handler {
 	return &Handler{store: store}
 }
 
-// ListCards retorna os cards do Guia Famli (traduzidos)
+// ListCards retorna os cards ativos do Guia Famli (traduzidos)
 func (h *Handler) ListCards(w http.ResponseWriter, r *http.Request) {
+	cards, err := activeCards(h.store, i18n.GetLocale(r))
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "guide.load_error")
+		return
+	}
 	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"cards": getLocalizedCards(r),
+		"cards": cards,
 	})
 }
 
@@ -64,7 +57,11 @@ func (h *Handler) ListCards(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) GetProgress(w http.ResponseWriter, r *http.Request) {
 	userID := auth.GetUserID(r)
 	progress := h.store.GetGuideProgress(userID)
-	cards := getLocalizedCards(r)
+	cards, err := activeCards(h.store, i18n.GetLocale(r))
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "guide.load_error")
+		return
+	}
 
 	// Montar resposta com status de cada card
 	cardsProgress := make([]map[string]interface{}, len(cards))
@@ -94,7 +91,7 @@ func (h *Handler) MarkCardProgress(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "guide.invalid_data"))
+		apierror.Write(w, r, http.StatusBadRequest, "guide.invalid_data")
 		return
 	}
 
@@ -106,13 +103,13 @@ func (h *Handler) MarkCardProgress(w http.ResponseWriter, r *http.Request) {
 		"skipped":   true,
 	}
 	if !validStatuses[payload.Status] {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "guide.invalid_status"))
+		apierror.Write(w, r, http.StatusBadRequest, "guide.invalid_status")
 		return
 	}
 
 	progress, err := h.store.UpdateGuideProgress(userID, cardID, payload.Status)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, i18n.Tr(r, "guide.progress_error"))
+		apierror.Write(w, r, http.StatusInternalServerError, "guide.progress_error")
 		return
 	}
 
@@ -126,7 +123,3 @@ func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
 		json.NewEncoder(w).Encode(payload)
 	}
 }
-
-func writeError(w http.ResponseWriter, status int, message string) {
-	writeJSON(w, status, map[string]string{"error": message})
-}
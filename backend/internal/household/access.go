@@ -0,0 +1,79 @@
+// =============================================================================
+// FAMLI - Acesso às Caixas da Família
+// =============================================================================
+// Regras de acesso aos itens usadas pela Caixa Famli:
+// - Item pessoal: apenas quem criou
+// - Item da família: membros ativos veem; "edit" e "manage" alteram
+// =============================================================================
+
+package household
+
+import (
+	"famli/internal/storage"
+)
+
+// Membership é a participação ativa do usuário em uma família
+type Membership struct {
+	Household *storage.Household
+	Member    *storage.HouseholdMember
+}
+
+// Memberships retorna as famílias em que o usuário é membro ativo (por ID)
+// Convites pendentes não dão acesso aos itens.
+func Memberships(store storage.Store, userID string) (map[string]*Membership, error) {
+	households, err := store.ListHouseholdsForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*Membership, len(households))
+	for _, h := range households {
+		member, err := store.GetHouseholdMember(h.ID, userID)
+		if err != nil || !member.IsActive() {
+			continue
+		}
+		result[h.ID] = &Membership{Household: h, Member: member}
+	}
+	return result, nil
+}
+
+// IDs retorna os IDs das famílias (para o filtro da listagem)
+func IDs(memberships map[string]*Membership) []string {
+	ids := make([]string, 0, len(memberships))
+	for id := range memberships {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// CanView indica se o usuário pode ver o item
+func CanView(userID string, item *storage.BoxItem, memberships map[string]*Membership) bool {
+	if item.HouseholdID == "" {
+		return item.UserID == userID
+	}
+	_, ok := memberships[item.HouseholdID]
+	return ok
+}
+
+// CanEdit indica se o usuário pode alterar ou remover o item
+func CanEdit(userID string, item *storage.BoxItem, memberships map[string]*Membership) bool {
+	return canEdit(userID, item.UserID, item.HouseholdID, memberships)
+}
+
+// CanEditSummary é CanEdit para os itens da listagem
+func CanEditSummary(userID string, item *storage.BoxItemSummary, memberships map[string]*Membership) bool {
+	return canEdit(userID, item.OwnerID, item.HouseholdID, memberships)
+}
+
+// CanAddTo indica se o usuário pode criar ou mover itens para a família
+func CanAddTo(householdID string, memberships map[string]*Membership) bool {
+	membership, ok := memberships[householdID]
+	return ok && membership.Member.CanEdit()
+}
+
+func canEdit(userID, ownerID, householdID string, memberships map[string]*Membership) bool {
+	if householdID == "" {
+		return ownerID == userID
+	}
+	return CanAddTo(householdID, memberships)
+}
@@ -0,0 +1,520 @@
+// =============================================================================
+// FAMLI - Handler de Famílias (caixas compartilhadas)
+// =============================================================================
+// Uma família tem uma caixa compartilhada entre os membros. Os itens da
+// família aparecem junto com os pessoais em GET /api/box/items.
+//
+// Endpoints (usuário autenticado):
+// - GET    /api/households                         - minhas famílias e convites
+// - POST   /api/households                         - cria família (quem cria é o dono)
+// - GET    /api/households/{id}                    - detalhes e membros
+// - PUT    /api/households/{id}                    - renomeia (manage)
+// - DELETE /api/households/{id}                    - exclui (apenas o dono)
+// - POST   /api/households/{id}/members            - convida um usuário Famli (manage)
+// - PUT    /api/households/{id}/members/{userID}   - altera permissão (manage)
+// - DELETE /api/households/{id}/members/{userID}   - remove membro, sai ou recusa convite
+// - POST   /api/households/{id}/accept             - aceita o convite
+//
+// Permissões: view (vê itens), edit (cria/edita itens), manage (edit + membros).
+// =============================================================================
+
+package household
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/i18n"
+	"famli/internal/notifications"
+	"famli/internal/security"
+	"famli/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxHouseholdsPerUser limita de quantas famílias cada usuário participa
+	maxHouseholdsPerUser = 5
+
+	// maxMembersPerHousehold limita os membros (incluindo convites pendentes)
+	maxMembersPerHousehold = 10
+
+	// maxNameLength limita o nome da família
+	maxNameLength = 100
+)
+
+// Handler gerencia as famílias do usuário
+type Handler struct {
+	store       storage.Store
+	auditLogger *security.AuditLogger
+}
+
+// NewHandler cria uma nova instância do handler de famílias
+func NewHandler(store storage.Store) *Handler {
+	return &Handler{
+		store:       store,
+		auditLogger: security.GetAuditLogger(),
+	}
+}
+
+// householdPayload é o corpo de POST e PUT /api/households
+type householdPayload struct {
+	Name string `json:"name"`
+}
+
+// memberPayload é o corpo do convite e da alteração de permissão
+type memberPayload struct {
+	Email      string                      `json:"email"`
+	Permission storage.HouseholdPermission `json:"permission"`
+}
+
+// householdView é a família como o usuário a vê (com a própria participação)
+type householdView struct {
+	*storage.Household
+	Permission storage.HouseholdPermission   `json:"permission"`
+	Status     storage.HouseholdMemberStatus `json:"status"`
+	IsOwner    bool                          `json:"is_owner"`
+}
+
+// List retorna as famílias do usuário, incluindo convites pendentes
+//
+// Endpoint: GET /api/households
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	households, err := h.store.ListHouseholdsForUser(userID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "household.list_error")
+		return
+	}
+
+	views := make([]*householdView, 0, len(households))
+	for _, household := range households {
+		member, err := h.store.GetHouseholdMember(household.ID, userID)
+		if err != nil {
+			continue
+		}
+		views = append(views, newHouseholdView(household, member))
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"households":  views,
+		"permissions": []storage.HouseholdPermission{storage.HouseholdView, storage.HouseholdEdit, storage.HouseholdManage},
+	})
+}
+
+// Create cria uma família tendo o usuário como dono
+//
+// Endpoint: POST /api/households
+//
+// Body: {"name": "Casa da Ana e do João"}
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	var payload householdPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "household.invalid_data")
+		return
+	}
+	name, ok := sanitizeName(payload.Name)
+	if !ok {
+		apierror.Write(w, r, http.StatusBadRequest, "household.name_required")
+		return
+	}
+
+	existing, err := h.store.ListHouseholdsForUser(userID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "household.save_error")
+		return
+	}
+	if len(existing) >= maxHouseholdsPerUser {
+		apierror.Write(w, r, http.StatusBadRequest, "household.limit_reached")
+		return
+	}
+
+	now := time.Now()
+	household := &storage.Household{
+		ID:        "hh_" + uuid.New().String(),
+		Name:      name,
+		OwnerID:   userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	owner := &storage.HouseholdMember{
+		HouseholdID: household.ID,
+		UserID:      userID,
+		Permission:  storage.HouseholdManage,
+		Status:      storage.HouseholdMemberActive,
+		JoinedAt:    &now,
+		CreatedAt:   now,
+	}
+	if err := h.store.CreateHousehold(household, owner); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "household.save_error")
+		return
+	}
+
+	h.logChange(r, household.ID, "create", nil)
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"household": newHouseholdView(household, owner),
+	})
+}
+
+// Get retorna a família e seus membros (apenas membros ativos)
+//
+// Endpoint: GET /api/households/{id}
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	household, member, ok := h.findHousehold(w, r, false)
+	if !ok {
+		return
+	}
+
+	members, err := h.store.ListHouseholdMembers(household.ID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "household.list_error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"household": newHouseholdView(household, member),
+		"members":   members,
+	})
+}
+
+// Update renomeia a família
+//
+// Endpoint: PUT /api/households/{id}
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	household, member, ok := h.findHousehold(w, r, true)
+	if !ok {
+		return
+	}
+
+	var payload householdPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "household.invalid_data")
+		return
+	}
+	name, valid := sanitizeName(payload.Name)
+	if !valid {
+		apierror.Write(w, r, http.StatusBadRequest, "household.name_required")
+		return
+	}
+
+	household.Name = name
+	household.UpdatedAt = time.Now()
+	if err := h.store.UpdateHousehold(household); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "household.save_error")
+		return
+	}
+
+	h.logChange(r, household.ID, "update", nil)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"household": newHouseholdView(household, member),
+	})
+}
+
+// Delete exclui a família; os itens voltam para a caixa pessoal de quem os criou
+//
+// Endpoint: DELETE /api/households/{id}
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	household, _, ok := h.findHousehold(w, r, false)
+	if !ok {
+		return
+	}
+	if household.OwnerID != auth.GetUserID(r) {
+		apierror.Write(w, r, http.StatusForbidden, "household.owner_only")
+		return
+	}
+
+	if err := h.store.DeleteHousehold(household.ID); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "household.save_error")
+		return
+	}
+
+	h.logChange(r, household.ID, "delete", nil)
+	writeJSON(w, http.StatusOK, map[string]string{"message": i18n.Tr(r, "household.deleted")})
+}
+
+// Invite convida outro usuário Famli pelo email
+//
+// Endpoint: POST /api/households/{id}/members
+//
+// Body: {"email": "joao@email.com", "permission": "edit"}
+// O convidado vê o convite em GET /api/households e aceita em /accept.
+func (h *Handler) Invite(w http.ResponseWriter, r *http.Request) {
+	household, _, ok := h.findHousehold(w, r, true)
+	if !ok {
+		return
+	}
+	userID := auth.GetUserID(r)
+
+	var payload memberPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "household.invalid_data")
+		return
+	}
+	if payload.Permission == "" {
+		payload.Permission = storage.HouseholdView
+	}
+	if !storage.IsValidHouseholdPermission(payload.Permission) {
+		apierror.Write(w, r, http.StatusBadRequest, "household.invalid_permission")
+		return
+	}
+
+	invitee, found := h.store.GetUserByEmail(strings.ToLower(strings.TrimSpace(payload.Email)))
+	if !found {
+		apierror.Write(w, r, http.StatusNotFound, "household.user_not_found")
+		return
+	}
+	if invitee.ID == userID {
+		apierror.Write(w, r, http.StatusBadRequest, "household.already_member")
+		return
+	}
+
+	members, err := h.store.ListHouseholdMembers(household.ID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "household.save_error")
+		return
+	}
+	if len(members) >= maxMembersPerHousehold {
+		apierror.Write(w, r, http.StatusBadRequest, "household.members_limit")
+		return
+	}
+
+	member := &storage.HouseholdMember{
+		HouseholdID: household.ID,
+		UserID:      invitee.ID,
+		Permission:  payload.Permission,
+		Status:      storage.HouseholdMemberPending,
+		InvitedBy:   userID,
+		CreatedAt:   time.Now(),
+	}
+	if err := h.store.AddHouseholdMember(member); err != nil {
+		apierror.WriteStorage(w, r, err, apierror.StorageKeys{Conflict: "household.already_member", Internal: "household.save_error"})
+		return
+	}
+
+	h.logChange(r, household.ID, "invite", map[string]interface{}{
+		"member_id":  invitee.ID,
+		"permission": member.Permission,
+	})
+	notifications.Notify(invitee.ID, storage.NotificationHousehold, "notify.household_invite")
+
+	member.Name = invitee.Name
+	member.Email = invitee.Email
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"member": member})
+}
+
+// UpdateMember altera a permissão de um membro
+//
+// Endpoint: PUT /api/households/{id}/members/{userID}
+//
+// Body: {"permission": "view"}
+func (h *Handler) UpdateMember(w http.ResponseWriter, r *http.Request) {
+	household, _, ok := h.findHousehold(w, r, true)
+	if !ok {
+		return
+	}
+
+	target, ok := h.findMember(w, r, household)
+	if !ok {
+		return
+	}
+	if target.UserID == household.OwnerID {
+		apierror.Write(w, r, http.StatusForbidden, "household.owner_locked")
+		return
+	}
+
+	var payload memberPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "household.invalid_data")
+		return
+	}
+	if !storage.IsValidHouseholdPermission(payload.Permission) {
+		apierror.Write(w, r, http.StatusBadRequest, "household.invalid_permission")
+		return
+	}
+
+	target.Permission = payload.Permission
+	if err := h.store.UpdateHouseholdMember(target); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "household.save_error")
+		return
+	}
+
+	h.logChange(r, household.ID, "update_member", map[string]interface{}{
+		"member_id":  target.UserID,
+		"permission": target.Permission,
+	})
+	writeJSON(w, http.StatusOK, map[string]interface{}{"member": target})
+}
+
+// RemoveMember remove um membro (manage), ou o próprio usuário sai/recusa o convite
+// Os itens que o membro criou na família voltam para a caixa pessoal dele.
+//
+// Endpoint: DELETE /api/households/{id}/members/{userID}
+func (h *Handler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+	targetID := chi.URLParam(r, "userID")
+
+	// Quem sai de si mesmo pode ainda estar com o convite pendente (recusa)
+	var household *storage.Household
+	var member *storage.HouseholdMember
+	var ok bool
+	if targetID == userID {
+		household, member, ok = h.findMembership(w, r)
+	} else {
+		household, member, ok = h.findHousehold(w, r, true)
+	}
+	if !ok {
+		return
+	}
+	if targetID == household.OwnerID {
+		apierror.Write(w, r, http.StatusForbidden, "household.owner_locked")
+		return
+	}
+
+	if err := h.store.RemoveHouseholdMember(household.ID, targetID); err != nil {
+		apierror.WriteStorage(w, r, err, apierror.StorageKeys{NotFound: "household.member_not_found", Internal: "household.save_error"})
+		return
+	}
+
+	action := "remove_member"
+	if targetID == userID {
+		action = "leave"
+		if !member.IsActive() {
+			action = "decline"
+		}
+	}
+	h.logChange(r, household.ID, action, map[string]interface{}{"member_id": targetID})
+	writeJSON(w, http.StatusOK, map[string]string{"message": i18n.Tr(r, "household.member_removed")})
+}
+
+// Accept aceita o convite para a família
+//
+// Endpoint: POST /api/households/{id}/accept
+func (h *Handler) Accept(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+	householdID := chi.URLParam(r, "id")
+
+	household, err := h.store.GetHousehold(householdID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusNotFound, "household.not_found")
+		return
+	}
+	member, err := h.store.GetHouseholdMember(householdID, userID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusNotFound, "household.not_found")
+		return
+	}
+
+	if !member.IsActive() {
+		now := time.Now()
+		member.Status = storage.HouseholdMemberActive
+		member.JoinedAt = &now
+		if err := h.store.UpdateHouseholdMember(member); err != nil {
+			apierror.Write(w, r, http.StatusInternalServerError, "household.save_error")
+			return
+		}
+		h.logChange(r, householdID, "accept", nil)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"household": newHouseholdView(household, member),
+	})
+}
+
+// =============================================================================
+// FUNÇÕES AUXILIARES
+// =============================================================================
+
+// findHousehold carrega a família da URL e a participação ativa do usuário
+func (h *Handler) findHousehold(w http.ResponseWriter, r *http.Request, requireManage bool) (*storage.Household, *storage.HouseholdMember, bool) {
+	household, member, ok := h.findMembership(w, r)
+	if !ok {
+		return nil, nil, false
+	}
+	if !member.IsActive() {
+		apierror.Write(w, r, http.StatusNotFound, "household.not_found")
+		return nil, nil, false
+	}
+	if requireManage && !member.CanManage() {
+		apierror.Write(w, r, http.StatusForbidden, "household.forbidden")
+		return nil, nil, false
+	}
+	return household, member, true
+}
+
+// findMembership carrega a família da URL e a participação do usuário,
+// ativa ou pendente
+func (h *Handler) findMembership(w http.ResponseWriter, r *http.Request) (*storage.Household, *storage.HouseholdMember, bool) {
+	householdID := chi.URLParam(r, "id")
+
+	member, err := h.store.GetHouseholdMember(householdID, auth.GetUserID(r))
+	if err != nil {
+		apierror.Write(w, r, http.StatusNotFound, "household.not_found")
+		return nil, nil, false
+	}
+	household, err := h.store.GetHousehold(householdID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusNotFound, "household.not_found")
+		return nil, nil, false
+	}
+	return household, member, true
+}
+
+// findMember carrega o membro indicado na URL
+func (h *Handler) findMember(w http.ResponseWriter, r *http.Request, household *storage.Household) (*storage.HouseholdMember, bool) {
+	member, err := h.store.GetHouseholdMember(household.ID, chi.URLParam(r, "userID"))
+	if err != nil {
+		apierror.Write(w, r, http.StatusNotFound, "household.member_not_found")
+		return nil, false
+	}
+	return member, true
+}
+
+// newHouseholdView combina a família com a participação do usuário
+func newHouseholdView(household *storage.Household, member *storage.HouseholdMember) *householdView {
+	return &householdView{
+		Household:  household,
+		Permission: member.Permission,
+		Status:     member.Status,
+		IsOwner:    household.OwnerID == member.UserID,
+	}
+}
+
+// sanitizeName limpa o nome da família
+func sanitizeName(name string) (string, bool) {
+	name = security.SanitizeName(name)
+	if name == "" || len(name) > maxNameLength {
+		return "", false
+	}
+	return name, true
+}
+
+// logChange registra a alteração no audit log
+func (h *Handler) logChange(r *http.Request, householdID, action string, details map[string]interface{}) {
+	h.auditLogger.Log(security.AuditEvent{
+		Type:     security.EventHouseholdChanged,
+		Severity: security.SeverityInfo,
+		UserID:   auth.GetUserID(r),
+		ClientIP: security.GetClientIP(r),
+		Resource: "household:" + householdID,
+		Action:   action,
+		Result:   "success",
+		Details:  details,
+	})
+}
+
+// writeJSON escreve resposta JSON
+func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
+	security.SetJSONHeaders(w)
+	w.WriteHeader(status)
+	if payload != nil {
+		json.NewEncoder(w).Encode(payload)
+	}
+}
@@ -4,7 +4,7 @@
 // Este pacote gerencia as meta tags localizadas para compartilhamento social
 // e SEO. As meta tags são injetadas no HTML antes de servir ao cliente.
 //
-// Idiomas suportados: pt-BR (padrão), en
+// Idiomas suportados: pt-BR (padrão), en, es
 // =============================================================================
 
 package i18n
@@ -23,6 +23,7 @@ type MetaTags struct {
 	OGDesc      string
 	Language    string
 	Locale      string
+	HTMLLang    string
 }
 
 // Traduções das meta tags por idioma
@@ -35,6 +36,7 @@ var metaTagsTranslations = map[string]MetaTags{
 		OGDesc:      "Transmita o que importa para as pessoas certas, quando for a hora. Organize com cuidado, no seu tempo.",
 		Language:    "Portuguese",
 		Locale:      "pt_BR",
+		HTMLLang:    "pt-BR",
 	},
 	"en": {
 		Title:       "Famli - Organize memories and guidance for your loved ones",
@@ -44,6 +46,17 @@ var metaTagsTranslations = map[string]MetaTags{
 		OGDesc:      "Pass on what matters to the right people, when the time comes. Organize with care, at your own pace.",
 		Language:    "English",
 		Locale:      "en_US",
+		HTMLLang:    "en-US",
+	},
+	"es": {
+		Title:       "Famli - Organiza recuerdos y orientaciones para quienes amas",
+		Description: "Transmite lo que importa a las personas correctas, cuando llegue el momento. Organiza recuerdos, documentos y orientaciones con cuidado, a tu ritmo y con más control.",
+		Keywords:    "recuerdos familiares, documentos importantes, organización familiar, legado, orientaciones familiares, planificación familiar, seguridad de datos",
+		OGTitle:     "Famli - Organiza recuerdos y orientaciones para quienes amas",
+		OGDesc:      "Transmite lo que importa a las personas correctas, cuando llegue el momento. Organiza con cuidado, a tu ritmo.",
+		Language:    "Spanish",
+		Locale:      "es_ES",
+		HTMLLang:    "es",
 	},
 }
 
@@ -69,32 +82,9 @@ var originalTexts = struct {
 }
 
 // GetPreferredLanguage detecta o idioma preferido do usuário pelo header Accept-Language
+// Formato: pt-BR,pt;q=0.9,en-US;q=0.8,en;q=0.7 (vale o primeiro idioma suportado)
 func GetPreferredLanguage(r *http.Request) string {
-	acceptLang := r.Header.Get("Accept-Language")
-	if acceptLang == "" {
-		return "pt-BR"
-	}
-
-	// Parsear o header Accept-Language
-	// Formato: pt-BR,pt;q=0.9,en-US;q=0.8,en;q=0.7
-	langs := strings.Split(acceptLang, ",")
-	for _, lang := range langs {
-		// Remover qualidade (q=)
-		parts := strings.Split(strings.TrimSpace(lang), ";")
-		langCode := strings.TrimSpace(parts[0])
-
-		// Verificar se é inglês (prioridade para o primeiro idioma)
-		if strings.HasPrefix(strings.ToLower(langCode), "en") {
-			return "en"
-		}
-
-		// Verificar se é português
-		if strings.HasPrefix(strings.ToLower(langCode), "pt") {
-			return "pt-BR"
-		}
-	}
-
-	return "pt-BR"
+	return acceptLanguage(r)
 }
 
 // GetMetaTags retorna as meta tags para o idioma especificado
@@ -119,8 +109,8 @@ func InjectMetaTags(html string, lang string) string {
 
 	// HTML lang
 	result = strings.Replace(result,
-		`<html lang="pt-BR">`,
-		`<html lang="en-US">`,
+		`<html lang="`+originalTexts.HTMLLang+`">`,
+		`<html lang="`+meta.HTMLLang+`">`,
 		1)
 
 	// Title
@@ -143,8 +133,8 @@ func InjectMetaTags(html string, lang string) string {
 
 	// Language
 	result = strings.Replace(result,
-		`<meta name="language" content="Portuguese" />`,
-		`<meta name="language" content="English" />`,
+		`<meta name="language" content="`+originalTexts.Language+`" />`,
+		`<meta name="language" content="`+meta.Language+`" />`,
 		1)
 
 	// Open Graph title
@@ -161,8 +151,8 @@ func InjectMetaTags(html string, lang string) string {
 
 	// Open Graph locale
 	result = strings.Replace(result,
-		`<meta property="og:locale" content="pt_BR" />`,
-		`<meta property="og:locale" content="en_US" />`,
+		`<meta property="og:locale" content="`+originalTexts.Locale+`" />`,
+		`<meta property="og:locale" content="`+meta.Locale+`" />`,
 		1)
 
 	// Open Graph image alt
@@ -0,0 +1,59 @@
+// =============================================================================
+// FAMLI - Idioma por requisição
+// =============================================================================
+// O idioma é resolvido uma vez por requisição e guardado no contexto:
+//
+//  1. Idioma salvo do usuário autenticado (auth.ActiveUserMiddleware)
+//  2. Parâmetro ?lang= (ex: links de email e páginas públicas)
+//  3. Header Accept-Language
+//  4. pt-BR
+//
+// Jobs em background (emails, WhatsApp, notificações) não têm requisição:
+// usam UserLocale com o idioma salvo do destinatário.
+// =============================================================================
+
+package i18n
+
+import (
+	"context"
+	"net/http"
+)
+
+// Middleware resolve o idioma da requisição (?lang= ou Accept-Language)
+// O idioma salvo do usuário é aplicado depois da autenticação, com WithLocale.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), localeKey, requestLocale(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// FromContext retorna o idioma guardado no contexto (pt-BR se não houver)
+func FromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeKey).(string); ok {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// UserLocale retorna o idioma para falar com um usuário
+//
+// Usa o idioma salvo (user.Locale); sem ele, o da requisição (r pode ser nil
+// em jobs em background, caso em que vale pt-BR).
+func UserLocale(saved string, r *http.Request) string {
+	if locale := Normalize(saved); locale != "" {
+		return locale
+	}
+	if r != nil {
+		return GetLocale(r)
+	}
+	return DefaultLocale
+}
+
+// requestLocale lê ?lang= e, sem ele, o Accept-Language
+func requestLocale(r *http.Request) string {
+	if locale := Normalize(r.URL.Query().Get("lang")); locale != "" {
+		return locale
+	}
+	return acceptLanguage(r)
+}
@@ -0,0 +1,78 @@
+// =============================================================================
+// FAMLI - Identificadores
+// =============================================================================
+// Gera IDs com prefixo do tipo de registro e um ULID:
+//
+//	itm_01HV3K9Q7M8X2C4D5E6F7G8H9J
+//
+// O ULID tem 48 bits de tempo (milissegundos) e 80 bits aleatórios
+// (crypto/rand), em base32 de Crockford (26 caracteres, apenas letras e
+// números). Não colide entre requisições simultâneas nem entre instâncias,
+// ao contrário dos IDs antigos baseados em time.Now().UnixNano().
+//
+// Compatibilidade: IDs antigos ("itm_1700000000000000000", "usr_3") continuam
+// válidos. O sufixo é opaco: nenhum código deve interpretá-lo ou ordenar por
+// ele (a listagem da caixa ordena por created_at).
+// =============================================================================
+
+package ids
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"time"
+)
+
+// Prefixos dos registros
+const (
+	User          = "usr"  // Usuários
+	Item          = "itm"  // Itens da caixa
+	Guardian      = "grd"  // Guardiões
+	Relation      = "rel"  // Vínculos entre itens e guardiões
+	Checklist     = "chk"  // Linhas da lista de um item
+	LoginAttempt  = "lgn"  // Tentativas de login
+	Session       = "ses"  // Sessões por dispositivo
+	FeedbackReply = "fbr"  // Respostas de feedback
+	GuardianAlert = "gal"  // Avisos aos guardiões
+	RetentionRun  = "ret"  // Execuções da retenção de dados
+	ItemComment   = "cmt"  // Comentários dos guardiões nos itens
+	PhoneLink     = "phl"  // Números de WhatsApp vinculados
+	InboundSender = "ibs"  // Remetentes do gateway de email
+	Incident      = "inc"  // Incidentes da página de status
+	Escalation    = "esc"  // Escalonamentos de emergência
+	Memorial      = "mem"  // Pedidos de abertura do memorial
+	APIKey        = "key"  // Chaves pessoais de API
+	Anonymous     = "anon" // Visitantes anônimos (analytics antes do cadastro)
+)
+
+// crockford é o alfabeto base32 do ULID (sem I, L, O e U)
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ulidLength é o tamanho do ULID codificado
+const ulidLength = 26
+
+// New gera um ID com o prefixo informado ("itm" → "itm_01HV...")
+func New(prefix string) string {
+	return prefix + "_" + newULID(time.Now())
+}
+
+// newULID codifica o tempo e 80 bits aleatórios
+func newULID(t time.Time) string {
+	var data [16]byte
+	binary.BigEndian.PutUint64(data[:8], uint64(t.UnixMilli())<<16)
+	if _, err := rand.Read(data[6:]); err != nil {
+		// crypto/rand não falha em sistemas suportados
+		panic("ids: crypto/rand indisponível: " + err.Error())
+	}
+
+	// 128 bits em 26 caracteres de 5 bits (os 2 bits extras ficam no primeiro)
+	hi := binary.BigEndian.Uint64(data[:8])
+	lo := binary.BigEndian.Uint64(data[8:])
+	var out [ulidLength]byte
+	for i := ulidLength - 1; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
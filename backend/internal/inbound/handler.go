@@ -0,0 +1,167 @@
+// =============================================================================
+// FAMLI - Gateway de Email: Endpoints
+// =============================================================================
+// Endpoints:
+// - POST   /api/inbound/email                 - Webhook do Mailgun (público, assinado)
+// - GET    /api/inbound/senders               - Lista os remetentes verificados
+// - POST   /api/inbound/senders               - Verifica um remetente (código)
+// - DELETE /api/inbound/senders/{senderID}    - Remove um remetente
+// =============================================================================
+
+package inbound
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+// Handler expõe o gateway de email
+type Handler struct {
+	service     *Service
+	auditLogger *security.AuditLogger
+}
+
+// NewHandler cria o handler do gateway de email
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service:     service,
+		auditLogger: security.GetAuditLogger(),
+	}
+}
+
+// writeJSON escreve uma resposta JSON
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+// =============================================================================
+// WEBHOOK
+// =============================================================================
+
+// Email recebe um email encaminhado pelo Mailgun
+//
+// Endpoint: POST /api/inbound/email
+//
+// Emails rejeitados (destinatário errado, remetente sem SPF/DKIM) respondem
+// 200 para o Mailgun não reenviar; só assinatura inválida responde 400.
+func (h *Handler) Email(w http.ResponseWriter, r *http.Request) {
+	if !h.service.Enabled() {
+		apierror.Write(w, r, http.StatusNotFound, "inbound.disabled")
+		return
+	}
+
+	msg, err := parseWebhook(r, h.service.config.SigningKey, h.service.config.Address, time.Now())
+	switch {
+	case errors.Is(err, errInvalidSignature):
+		h.auditLogger.LogSecurity(security.EventTokenInvalid, security.GetClientIP(r), map[string]interface{}{
+			"endpoint": "inbound_email",
+		})
+		apierror.Write(w, r, http.StatusBadRequest, "inbound.invalid_request")
+		return
+	case err != nil:
+		log.Printf("[Inbound] Email descartado: %v", err)
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ignored"})
+		return
+	}
+
+	if strings.TrimSpace(msg.Message()) == "" {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ignored"})
+		return
+	}
+
+	if err := h.service.Process(msg); err != nil {
+		log.Printf("[Inbound] Erro ao processar email: %v", err)
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// =============================================================================
+// REMETENTES VERIFICADOS
+// =============================================================================
+
+// SenderRequest é o corpo de POST /api/inbound/senders
+type SenderRequest struct {
+	Code string `json:"code"`
+}
+
+// ListSenders lista os remetentes verificados e o endereço do gateway
+//
+// Endpoint: GET /api/inbound/senders
+func (h *Handler) ListSenders(w http.ResponseWriter, r *http.Request) {
+	senders, err := h.service.Senders(auth.GetUserID(r))
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "inbound.sender_error")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"address":     h.service.config.Address,
+		"enabled":     h.service.Enabled(),
+		"senders":     senders,
+		"max_senders": maxSenders,
+	})
+}
+
+// CreateSender verifica um remetente com o código recebido por email
+//
+// Endpoint: POST /api/inbound/senders
+// Body: {"code": "123456"}
+func (h *Handler) CreateSender(w http.ResponseWriter, r *http.Request) {
+	if !h.service.Enabled() {
+		apierror.Write(w, r, http.StatusNotFound, "inbound.disabled")
+		return
+	}
+
+	var req SenderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "inbound.invalid_request")
+		return
+	}
+	if strings.TrimSpace(req.Code) == "" {
+		apierror.Write(w, r, http.StatusBadRequest, "inbound.code_required")
+		return
+	}
+
+	sender, err := h.service.LinkSender(auth.GetUserID(r), req.Code)
+	switch {
+	case errors.Is(err, errInvalidCode):
+		apierror.Write(w, r, http.StatusBadRequest, "inbound.invalid_code")
+	case errors.Is(err, errLinkedHere):
+		apierror.Write(w, r, http.StatusConflict, "inbound.sender_already_linked")
+	case errors.Is(err, storage.ErrAlreadyExists):
+		apierror.Write(w, r, http.StatusConflict, "inbound.sender_linked_elsewhere")
+	case errors.Is(err, errTooManySenders):
+		apierror.Write(w, r, http.StatusBadRequest, "inbound.too_many_senders")
+	case err != nil:
+		apierror.Write(w, r, http.StatusInternalServerError, "inbound.sender_error")
+	default:
+		writeJSON(w, http.StatusCreated, sender)
+	}
+}
+
+// DeleteSender remove um remetente verificado
+//
+// Endpoint: DELETE /api/inbound/senders/{senderID}
+func (h *Handler) DeleteSender(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.UnlinkSender(auth.GetUserID(r), chi.URLParam(r, "senderID")); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			apierror.Write(w, r, http.StatusNotFound, "inbound.sender_not_found")
+			return
+		}
+		apierror.Write(w, r, http.StatusInternalServerError, "inbound.sender_error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,202 @@
+// =============================================================================
+// FAMLI - Gateway de Email: Webhook do Mailgun
+// =============================================================================
+// O Mailgun recebe os emails enviados para INBOUND_EMAIL_ADDRESS e os
+// encaminha (rota "forward") como multipart/form-data para
+// POST /api/inbound/email.
+//
+// Campos usados:
+//   - timestamp, token, signature: assinatura HMAC-SHA256 com a
+//     MAILGUN_WEBHOOK_SIGNING_KEY
+//   - sender: remetente do envelope (SMTP MAIL FROM)
+//   - from: header From ("Maria <maria@example.com>")
+//   - recipient: destinatários (separados por vírgula)
+//   - subject, body-plain, stripped-text (resposta sem citação e assinatura)
+//   - message-headers: headers em JSON, com o resultado do SPF e do DKIM
+//
+// Anexos são ignorados: só o texto vira item.
+// =============================================================================
+
+package inbound
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// signatureTolerance é a idade máxima aceita de um webhook assinado
+	signatureTolerance = 5 * time.Minute
+
+	// maxFormMemory é o quanto do multipart fica em memória (o resto vai a disco)
+	maxFormMemory = 1 << 20
+
+	// maxTextLength limita o texto de um email (em caracteres)
+	maxTextLength = 10000
+)
+
+var (
+	// errInvalidSignature indica assinatura ausente, inválida ou antiga
+	errInvalidSignature = errors.New("assinatura do Mailgun inválida")
+
+	// errUnauthenticated indica remetente sem SPF nem DKIM válidos
+	errUnauthenticated = errors.New("remetente não autenticado (SPF/DKIM)")
+
+	// errWrongRecipient indica email que não foi enviado ao gateway
+	errWrongRecipient = errors.New("destinatário não é o gateway")
+)
+
+// replyPrefixes são os prefixos de assunto de respostas e encaminhamentos
+var replyPrefixes = []string{"re:", "res:", "fwd:", "fw:", "enc:", "rv:"}
+
+// IncomingEmail é um email recebido pelo gateway, já validado
+type IncomingEmail struct {
+	From    string // Email do remetente, em minúsculas
+	Subject string // Assunto sem "Re:"/"Fwd:"
+	Reply   bool   // O assunto indica resposta ou encaminhamento
+	Text    string // Corpo sem citações e assinatura
+}
+
+// Message monta o texto entregue ao motor de conversas
+// Respostas usam só o corpo ("2", "sim"); emails novos juntam assunto e corpo.
+func (m *IncomingEmail) Message() string {
+	switch {
+	case m.Text == "":
+		return m.Subject
+	case m.Reply || m.Subject == "":
+		return m.Text
+	default:
+		return m.Subject + "\n" + m.Text
+	}
+}
+
+// verifySignature confere a assinatura do webhook
+// HMAC-SHA256(chave, timestamp + token), em hexadecimal.
+func verifySignature(timestamp, token, signature, key string, now time.Time) error {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || token == "" || signature == "" {
+		return errInvalidSignature
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > signatureTolerance || age < -signatureTolerance {
+		return errInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(timestamp + token))
+	decoded, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(decoded, mac.Sum(nil)) {
+		return errInvalidSignature
+	}
+	return nil
+}
+
+// parseWebhook valida e converte o POST do Mailgun
+// address é o endereço do gateway (INBOUND_EMAIL_ADDRESS).
+func parseWebhook(r *http.Request, signingKey, address string, now time.Time) (*IncomingEmail, error) {
+	if err := r.ParseMultipartForm(maxFormMemory); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return nil, err
+	}
+	if r.MultipartForm != nil {
+		defer r.MultipartForm.RemoveAll()
+	}
+
+	if err := verifySignature(r.FormValue("timestamp"), r.FormValue("token"), r.FormValue("signature"), signingKey, now); err != nil {
+		return nil, err
+	}
+
+	if !sentTo(r.FormValue("recipient"), address) {
+		return nil, errWrongRecipient
+	}
+
+	from, err := mail.ParseAddress(r.FormValue("from"))
+	if err != nil {
+		return nil, err
+	}
+	sender := strings.ToLower(from.Address)
+	if !authenticated(r.FormValue("message-headers"), sender, strings.ToLower(strings.TrimSpace(r.FormValue("sender")))) {
+		return nil, errUnauthenticated
+	}
+
+	text := r.FormValue("stripped-text")
+	if strings.TrimSpace(text) == "" {
+		text = r.FormValue("body-plain")
+	}
+	subject, reply := cleanSubject(r.FormValue("subject"))
+
+	return &IncomingEmail{
+		From:    sender,
+		Subject: subject,
+		Reply:   reply,
+		Text:    truncateText(strings.TrimSpace(text), maxTextLength),
+	}, nil
+}
+
+// sentTo indica se o endereço do gateway está entre os destinatários
+func sentTo(recipients, address string) bool {
+	for _, recipient := range strings.Split(recipients, ",") {
+		if strings.EqualFold(strings.TrimSpace(recipient), address) {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticated exige DKIM válido ou SPF válido com o envelope igual ao From
+// Sem isso, qualquer um poderia escrever em nome de um remetente verificado.
+func authenticated(rawHeaders, from, envelope string) bool {
+	var headers [][]string
+	if err := json.Unmarshal([]byte(rawHeaders), &headers); err != nil {
+		return false
+	}
+
+	var spf, dkim string
+	for _, header := range headers {
+		if len(header) != 2 {
+			continue
+		}
+		switch strings.ToLower(header[0]) {
+		case "x-mailgun-spf":
+			spf = strings.TrimSpace(header[1])
+		case "x-mailgun-dkim-check-result":
+			dkim = strings.TrimSpace(header[1])
+		}
+	}
+	return strings.EqualFold(dkim, "pass") || (strings.EqualFold(spf, "pass") && envelope == from)
+}
+
+// cleanSubject remove os prefixos de resposta ("Re: Fwd: ...")
+func cleanSubject(subject string) (string, bool) {
+	subject = strings.TrimSpace(subject)
+	reply := false
+	for {
+		lower := strings.ToLower(subject)
+		trimmed := false
+		for _, prefix := range replyPrefixes {
+			if strings.HasPrefix(lower, prefix) {
+				subject = strings.TrimSpace(subject[len(prefix):])
+				reply, trimmed = true, true
+				break
+			}
+		}
+		if !trimmed {
+			return subject, reply
+		}
+	}
+}
+
+// truncateText corta o texto em max caracteres sem quebrar UTF-8
+func truncateText(text string, max int) string {
+	if utf8.RuneCountInString(text) <= max {
+		return text
+	}
+	return string([]rune(text)[:max])
+}
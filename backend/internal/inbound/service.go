@@ -0,0 +1,235 @@
+// =============================================================================
+// FAMLI - Gateway de Email
+// =============================================================================
+// Quem prefere email ao WhatsApp escreve para INBOUND_EMAIL_ADDRESS (ex:
+// save@famli.net). O email vira um item pendente na Caixa e a conversa
+// segue por respostas ao email: categoria, confirmação e comandos são os
+// mesmos do WhatsApp (internal/conversation).
+//
+// Só remetentes verificados guardam itens. Para verificar, a pessoa envia
+// "vincular" para o gateway, recebe o código por email e o digita no perfil
+// (POST /api/inbound/senders). Emails sem SPF nem DKIM válidos são
+// descartados (ver mailgun.go).
+//
+// As conversas por email são mais lentas: a sessão dura sessionTimeout, e a
+// pergunta pendente vira rascunho depois disso (ver conversation/timeout.go).
+// =============================================================================
+
+package inbound
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"famli/internal/conversation"
+	"famli/internal/email"
+	"famli/internal/i18n"
+	"famli/internal/ids"
+	"famli/internal/quota"
+	"famli/internal/storage"
+)
+
+const (
+	// sessionTimeout é o prazo de uma conversa por email sem respostas
+	sessionTimeout = 24 * time.Hour
+
+	// sessionSweepInterval é a frequência com que as conversas paradas são encerradas
+	sessionSweepInterval = 10 * time.Minute
+
+	// maxSenders limita os remetentes verificados de uma conta
+	maxSenders = 5
+)
+
+var (
+	// errNoMedia indica que o canal de email não baixa mídias
+	errNoMedia = errors.New("anexos não são suportados pelo gateway de email")
+
+	// errInvalidCode indica código inexistente, vencido ou já usado
+	errInvalidCode = errors.New("código de vinculação inválido")
+
+	// errLinkedHere indica que o email já está vinculado a esta conta
+	errLinkedHere = errors.New("email já vinculado a esta conta")
+
+	// errTooManySenders indica que a conta atingiu maxSenders
+	errTooManySenders = errors.New("limite de remetentes atingido")
+)
+
+// Config é a configuração do gateway de email
+type Config struct {
+	Address    string // INBOUND_EMAIL_ADDRESS: endereço que recebe os itens
+	SigningKey string // MAILGUN_WEBHOOK_SIGNING_KEY (vazio = desabilitado)
+	AppURL     string // Endereço público do frontend (citado nas respostas)
+}
+
+// Enabled indica se o gateway está configurado
+func (c *Config) Enabled() bool {
+	return c != nil && c.SigningKey != "" && c.Address != ""
+}
+
+// =============================================================================
+// SERVIÇO
+// =============================================================================
+
+// Service adapta os emails recebidos para o motor de conversas
+type Service struct {
+	store  storage.Store
+	engine *conversation.Engine
+	config *Config
+}
+
+// NewService cria o serviço do gateway de email
+func NewService(store storage.Store, config *Config, mailer *email.Service, quotaChecker *quota.Checker) *Service {
+	ch := &channel{store: store, mailer: mailer, address: config.Address}
+	engine := conversation.NewEngine(store, ch, quotaChecker, config.AppURL)
+	engine.SetSessionTimeout(sessionTimeout)
+	ch.locale = engine.Locale
+
+	return &Service{store: store, engine: engine, config: config}
+}
+
+// Enabled indica se o gateway está configurado
+func (s *Service) Enabled() bool {
+	return s.config.Enabled()
+}
+
+// Process entrega o email ao motor de conversas e responde por email
+func (s *Service) Process(msg *IncomingEmail) error {
+	reply, err := s.engine.Handle(&conversation.Message{
+		Address:    msg.From,
+		Kind:       conversation.KindText,
+		Text:       msg.Message(),
+		ReceivedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if reply == "" {
+		return nil
+	}
+	return s.engine.Channel().Send(msg.From, reply)
+}
+
+// StartSessionExpiry inicia o worker que encerra as conversas paradas
+// (perguntas sem resposta viram rascunho); encerra quando ctx é cancelado
+func (s *Service) StartSessionExpiry(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(sessionSweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.engine.ExpireSessions(now)
+			}
+		}
+	}()
+}
+
+// =============================================================================
+// REMETENTES VERIFICADOS
+// =============================================================================
+
+// Senders lista os remetentes verificados do usuário
+func (s *Service) Senders(userID string) ([]*storage.InboundSender, error) {
+	return s.store.ListInboundSenders(userID)
+}
+
+// LinkSender confirma o código recebido por email e autoriza o remetente
+func (s *Service) LinkSender(userID, code string) (*storage.InboundSender, error) {
+	address, ok := s.engine.RedeemLinkCode(strings.TrimSpace(code))
+	if !ok {
+		return nil, errInvalidCode
+	}
+
+	if existing, err := s.store.GetInboundSenderByEmail(address); err == nil {
+		if existing.UserID == userID {
+			return existing, errLinkedHere
+		}
+		return nil, storage.ErrAlreadyExists
+	}
+
+	senders, err := s.store.ListInboundSenders(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(senders) >= maxSenders {
+		return nil, errTooManySenders
+	}
+
+	now := time.Now().UTC()
+	sender := &storage.InboundSender{
+		ID:         ids.New(ids.InboundSender),
+		UserID:     userID,
+		Email:      address,
+		VerifiedAt: now,
+		CreatedAt:  now,
+	}
+	if err := s.store.CreateInboundSender(sender); err != nil {
+		return nil, err
+	}
+	s.engine.LinkUser(address, userID)
+
+	log.Printf("[Inbound] Remetente vinculado ao usuário %s", userID)
+	return sender, nil
+}
+
+// UnlinkSender remove um remetente verificado do usuário
+func (s *Service) UnlinkSender(userID, senderID string) error {
+	senders, err := s.store.ListInboundSenders(userID)
+	if err != nil {
+		return err
+	}
+	for _, sender := range senders {
+		if sender.ID != senderID {
+			continue
+		}
+		if err := s.store.DeleteInboundSender(userID, senderID); err != nil {
+			return err
+		}
+		s.engine.LinkUser(sender.Email, "")
+		log.Printf("[Inbound] Remetente desvinculado do usuário %s", userID)
+		return nil
+	}
+	return storage.ErrNotFound
+}
+
+// =============================================================================
+// CANAL
+// =============================================================================
+
+// channel implementa conversation.Channel e conversation.AddressResolver
+// sobre o serviço de email; o endereço no canal é o email do remetente
+type channel struct {
+	store   storage.Store
+	mailer  *email.Service
+	address string                      // Endereço do gateway (Reply-To)
+	locale  func(address string) string // Idioma das respostas (Engine.Locale)
+}
+
+// Name retorna o nome do canal exibido ao usuário
+func (c *channel) Name() string {
+	return "Email"
+}
+
+// Send responde por email, com Reply-To no gateway
+func (c *channel) Send(to, body string) error {
+	locale := c.locale(to)
+	return c.mailer.SendReply(to, c.address, i18n.T(locale, "inbound.reply_subject"), body, locale)
+}
+
+// FetchMedia não é suportado: anexos são ignorados
+func (c *channel) FetchMedia(mediaURL string) ([]byte, string, error) {
+	return nil, "", errNoMedia
+}
+
+// UserForAddress retorna o usuário do remetente verificado ("" se nenhum)
+func (c *channel) UserForAddress(address string) string {
+	if sender, err := c.store.GetInboundSenderByEmail(address); err == nil {
+		return sender.UserID
+	}
+	return ""
+}
@@ -0,0 +1,196 @@
+// =============================================================================
+// FAMLI - Versões dos Termos de Uso e da Política de Privacidade
+// =============================================================================
+// Cada usuário guarda a versão dos termos e da política que aceitou (e
+// quando). Ao publicar uma nova versão (LEGAL_TERMS_VERSION ou
+// LEGAL_PRIVACY_VERSION), as rotas autenticadas passam a responder 428
+// (LEGAL_ACCEPTANCE_REQUIRED) até o usuário aceitar de novo.
+//
+// Endpoints:
+// - GET  /api/legal/current - versões vigentes (público)
+// - GET  /api/legal/status  - versões aceitas pelo usuário e se falta aceitar
+// - POST /api/legal/accept  - aceita as versões vigentes
+//
+// O cadastro com "accept_terms": true já registra o aceite. Todo aceite vai
+// para o log de auditoria (TERMS_ACCEPTED).
+// =============================================================================
+
+package legal
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/i18n"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+// Versions são as versões vigentes dos documentos
+type Versions struct {
+	Terms   string `json:"terms_version"`
+	Privacy string `json:"privacy_version"`
+}
+
+// AcceptedBy indica se o usuário aceitou as versões vigentes
+func (v Versions) AcceptedBy(user *storage.User) bool {
+	return user.TermsVersion == v.Terms && user.PrivacyVersion == v.Privacy
+}
+
+// details são as versões no formato de apierror.Response.Details
+func (v Versions) details() map[string]interface{} {
+	return map[string]interface{}{
+		"terms_version":   v.Terms,
+		"privacy_version": v.Privacy,
+	}
+}
+
+// Handler expõe as versões e registra os aceites
+type Handler struct {
+	store       storage.Store
+	current     Versions
+	auditLogger *security.AuditLogger
+}
+
+// NewHandler cria o handler com as versões vigentes
+func NewHandler(store storage.Store, current Versions) *Handler {
+	return &Handler{
+		store:       store,
+		current:     current,
+		auditLogger: security.GetAuditLogger(),
+	}
+}
+
+// acceptPayload é o corpo de POST /api/legal/accept
+// As versões precisam ser as vigentes: o usuário aceita o que leu.
+type acceptPayload struct {
+	TermsVersion   string `json:"terms_version"`
+	PrivacyVersion string `json:"privacy_version"`
+}
+
+// StatusResponse é a situação do usuário em relação às versões vigentes
+type StatusResponse struct {
+	Current         Versions   `json:"current"`
+	TermsVersion    string     `json:"terms_version,omitempty"`   // Aceita pelo usuário
+	PrivacyVersion  string     `json:"privacy_version,omitempty"` // Aceita pelo usuário
+	TermsAcceptedAt *time.Time `json:"terms_accepted_at,omitempty"`
+	Pending         bool       `json:"pending"` // Falta aceitar as versões vigentes
+}
+
+// Current retorna as versões vigentes
+//
+// Endpoint: GET /api/legal/current
+func (h *Handler) Current(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.current)
+}
+
+// Status retorna as versões aceitas pelo usuário
+//
+// Endpoint: GET /api/legal/status
+func (h *Handler) Status(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.store.GetUserByID(auth.GetUserID(r))
+	if !ok {
+		apierror.Write(w, r, http.StatusUnauthorized, "auth.session_invalid")
+		return
+	}
+	writeJSON(w, http.StatusOK, h.status(user))
+}
+
+// Accept registra o aceite das versões vigentes
+//
+// Endpoint: POST /api/legal/accept
+//
+// Body: {"terms_version": "2025-12-22", "privacy_version": "2025-12-22"}
+//
+// Versões diferentes das vigentes (o usuário leu uma versão antiga) → 409.
+func (h *Handler) Accept(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	var payload acceptPayload
+	if err := security.DecodeJSONStrict(r.Body, &payload); err != nil {
+		apierror.WriteDecodeError(w, r, err, "legal.invalid_data")
+		return
+	}
+	if strings.TrimSpace(payload.TermsVersion) == "" || strings.TrimSpace(payload.PrivacyVersion) == "" {
+		apierror.Write(w, r, http.StatusBadRequest, "legal.invalid_data")
+		return
+	}
+	if payload.TermsVersion != h.current.Terms || payload.PrivacyVersion != h.current.Privacy {
+		apierror.WriteMessage(w, r, http.StatusConflict, apierror.Code("legal.version_outdated"),
+			i18n.Tr(r, "legal.version_outdated"), h.current.details())
+		return
+	}
+
+	if err := h.record(r, userID); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "legal.accept_error")
+		return
+	}
+
+	user, ok := h.store.GetUserByID(userID)
+	if !ok {
+		apierror.Write(w, r, http.StatusUnauthorized, "auth.session_invalid")
+		return
+	}
+	writeJSON(w, http.StatusOK, h.status(user))
+}
+
+// RequireAcceptance responde 428 enquanto o usuário não aceitar as versões
+// vigentes. Deve ser usado após auth.ActiveUserMiddleware. Rotas com os
+// prefixos em exempt (ex: /api/auth/, /api/legal/) continuam liberadas, para
+// o usuário poder ver a conta, aceitar, exportar os dados ou sair.
+func (h *Handler) RequireAcceptance(exempt ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, prefix := range exempt {
+				if strings.HasPrefix(r.URL.Path, prefix) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			user, ok := h.store.GetUserByID(auth.GetUserID(r))
+			if ok && !h.current.AcceptedBy(user) {
+				apierror.WriteMessage(w, r, http.StatusPreconditionRequired, "LEGAL_ACCEPTANCE_REQUIRED",
+					i18n.Tr(r, "legal.acceptance_required"), h.current.details())
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// record grava o aceite das versões e registra na auditoria
+func (h *Handler) record(r *http.Request, userID string) error {
+	err := h.store.AcceptLegalTerms(userID, h.current.Terms, h.current.Privacy, time.Now().UTC())
+
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	h.auditLogger.LogAuth(security.EventTermsAccepted, userID, security.GetClientIP(r), r.UserAgent(), result, h.current.details())
+	return err
+}
+
+// status monta a situação do usuário
+func (h *Handler) status(user *storage.User) StatusResponse {
+	return StatusResponse{
+		Current:         h.current,
+		TermsVersion:    user.TermsVersion,
+		PrivacyVersion:  user.PrivacyVersion,
+		TermsAcceptedAt: user.TermsAcceptedAt,
+		Pending:         !h.current.AcceptedBy(user),
+	}
+}
+
+// writeJSON escreve uma resposta JSON
+func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if payload != nil {
+		json.NewEncoder(w).Encode(payload)
+	}
+}
@@ -0,0 +1,197 @@
+// =============================================================================
+// FAMLI - Canais de Entrega de Notificações
+// =============================================================================
+// Canais externos usados pela central de notificações:
+// - push: Web Push para os navegadores inscritos (registrado automaticamente
+//   quando há chaves VAPID)
+// - email: aviso por email (NewEmailChannel)
+// - whatsapp: mensagem para o número vinculado (NewMessageChannel)
+// =============================================================================
+
+package notifications
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"famli/internal/email"
+	"famli/internal/storage"
+)
+
+// Nomes dos canais externos
+const (
+	ChannelPush     = "push"
+	ChannelEmail    = "email"
+	ChannelWhatsApp = "whatsapp"
+)
+
+var (
+	// errNoDevices indica que o usuário não tem dispositivos inscritos
+	errNoDevices = errors.New("nenhum dispositivo inscrito")
+
+	// errSubscriptionGone indica inscrição expirada (removida)
+	errSubscriptionGone = errors.New("inscrição expirada")
+)
+
+// Channel entrega um aviso ao usuário por um meio externo
+type Channel interface {
+	// Name é o nome do canal (ChannelPush, ChannelEmail, ...)
+	Name() string
+
+	// Deliver entrega o aviso; erro indica que o usuário não foi alcançado
+	Deliver(user *storage.User, notification *storage.Notification) error
+}
+
+// =============================================================================
+// WEB PUSH
+// =============================================================================
+
+// pushPayload é o conteúdo entregue ao service worker do navegador
+type pushPayload struct {
+	ID       string                       `json:"id"`
+	Title    string                       `json:"title"`
+	Body     string                       `json:"body"`
+	URL      string                       `json:"url,omitempty"` // Página aberta ao clicar
+	Category storage.NotificationCategory `json:"category"`
+	Tag      string                       `json:"tag,omitempty"` // Agrupa notificações repetidas
+}
+
+// pushChannel entrega avisos para todos os navegadores inscritos do usuário
+type pushChannel struct {
+	service *Service
+}
+
+func (c *pushChannel) Name() string { return ChannelPush }
+
+// Deliver envia para todos os dispositivos; basta um receber para contar como entregue
+func (c *pushChannel) Deliver(user *storage.User, notification *storage.Notification) error {
+	subs, err := c.service.store.ListPushSubscriptions(user.ID)
+	if err != nil {
+		return err
+	}
+	if len(subs) == 0 {
+		return errNoDevices
+	}
+
+	var lastErr error
+	delivered := false
+	for _, sub := range subs {
+		if err := c.service.Send(sub, notification); err != nil {
+			lastErr = err
+			continue
+		}
+		delivered = true
+	}
+	if !delivered {
+		return lastErr
+	}
+	return nil
+}
+
+// Send cifra e envia o aviso para uma inscrição Web Push
+// Inscrições expiradas são removidas.
+func (s *Service) Send(sub *storage.PushSubscription, notification *storage.Notification) error {
+	if s.keys == nil {
+		return fmt.Errorf("web push não configurado")
+	}
+
+	payload, err := json.Marshal(&pushPayload{
+		ID:       notification.ID,
+		Title:    notification.Title,
+		Body:     notification.Body,
+		URL:      notification.URL,
+		Category: notification.Category,
+		Tag:      string(notification.Category),
+	})
+	if err != nil {
+		return err
+	}
+	body, err := encrypt(payload, sub.P256dh, sub.Auth)
+	if err != nil {
+		return err
+	}
+	authorization, err := s.keys.authorization(sub.Endpoint, s.subject)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", fmt.Sprintf("%d", int(messageTTL.Seconds())))
+	if notification.Category == storage.NotificationEmergency {
+		req.Header.Set("Urgency", "high")
+	} else {
+		req.Header.Set("Urgency", "normal")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return s.store.TouchPushSubscription(sub.ID, time.Now())
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		// Inscrição cancelada ou expirada no navegador
+		s.store.DeletePushSubscription(sub.UserID, sub.Endpoint)
+		return errSubscriptionGone
+	default:
+		return fmt.Errorf("serviço de push respondeu %d", resp.StatusCode)
+	}
+}
+
+// =============================================================================
+// EMAIL
+// =============================================================================
+
+// emailChannel entrega avisos no email da conta
+type emailChannel struct {
+	mailer *email.Service
+}
+
+// NewEmailChannel cria o canal de email sobre o serviço de email
+func NewEmailChannel(mailer *email.Service) Channel {
+	return &emailChannel{mailer: mailer}
+}
+
+func (c *emailChannel) Name() string { return ChannelEmail }
+
+func (c *emailChannel) Deliver(user *storage.User, notification *storage.Notification) error {
+	if c.mailer == nil || !c.mailer.IsConfigured() {
+		return fmt.Errorf("email não configurado")
+	}
+	return c.mailer.SendNotice(user.Email, user.Name, notification.Title+"\n\n"+notification.Body, user.Locale)
+}
+
+// =============================================================================
+// MENSAGENS DE TEXTO (WhatsApp)
+// =============================================================================
+
+// messageChannel entrega avisos como texto por uma função de envio
+type messageChannel struct {
+	name string
+	send func(userID, text string) error
+}
+
+// NewMessageChannel cria um canal de texto (ex: WhatsApp) a partir da função de envio
+// send recebe o ID do usuário e deve falhar se ele não tiver o canal vinculado.
+func NewMessageChannel(name string, send func(userID, text string) error) Channel {
+	return &messageChannel{name: name, send: send}
+}
+
+func (c *messageChannel) Name() string { return c.name }
+
+func (c *messageChannel) Deliver(user *storage.User, notification *storage.Notification) error {
+	return c.send(user.ID, "*"+notification.Title+"*\n\n"+notification.Body)
+}
@@ -0,0 +1,245 @@
+// =============================================================================
+// FAMLI - Handler de Notificações
+// =============================================================================
+// Endpoints (usuário autenticado):
+// - GET    /api/notifications               - central de avisos (paginada) e não lidos
+// - POST   /api/notifications/{id}/read     - marca um aviso como lido
+// - POST   /api/notifications/read-all      - marca todos como lidos
+// - GET    /api/notifications/vapid-key     - chave pública VAPID e categorias
+// - GET    /api/notifications/subscriptions - dispositivos inscritos
+// - POST   /api/notifications/subscribe     - inscreve este navegador
+// - DELETE /api/notifications/subscribe     - cancela a inscrição deste navegador
+//
+// As preferências por categoria ficam em PUT /api/settings
+// (notification_opt_outs).
+// =============================================================================
+
+package notifications
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/i18n"
+	"famli/internal/security"
+	"famli/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// maxSubscriptionsPerUser limita os dispositivos por usuário
+// Ao passar do limite, a inscrição mais antiga é removida.
+const maxSubscriptionsPerUser = 10
+
+// Handler expõe a central de notificações e as inscrições Web Push
+type Handler struct {
+	store   storage.Store
+	service *Service
+}
+
+// NewHandler cria uma nova instância do handler de notificações
+func NewHandler(store storage.Store, service *Service) *Handler {
+	return &Handler{store: store, service: service}
+}
+
+// subscribePayload segue o formato de PushSubscription.toJSON() do navegador
+type subscribePayload struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// List retorna os avisos do usuário e o total de não lidos
+//
+// Endpoint: GET /api/notifications?limit=20&cursor=ntf_...&unread=true
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+	query := r.URL.Query()
+
+	params := &storage.PaginationParams{Cursor: query.Get("cursor")}
+	if limit, err := strconv.Atoi(query.Get("limit")); err == nil {
+		params.Limit = limit
+	}
+	unreadOnly := query.Get("unread") == "true"
+
+	page, err := h.store.ListNotifications(userID, unreadOnly, params)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "notifications.list_error")
+		return
+	}
+	unread, err := h.store.CountUnreadNotifications(userID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "notifications.list_error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"notifications": page.Items,
+		"next_cursor":   page.NextCursor,
+		"has_more":      page.HasMore,
+		"unread_count":  unread,
+	})
+}
+
+// MarkRead marca um aviso como lido
+//
+// Endpoint: POST /api/notifications/{id}/read
+func (h *Handler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	if err := h.store.MarkNotificationRead(userID, chi.URLParam(r, "id")); err != nil {
+		apierror.WriteStorage(w, r, err, apierror.StorageKeys{NotFound: "notifications.not_found", Internal: "notifications.save_error"})
+		return
+	}
+	h.writeUnreadCount(w, r, userID)
+}
+
+// MarkAllRead marca todos os avisos do usuário como lidos
+//
+// Endpoint: POST /api/notifications/read-all
+func (h *Handler) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	if err := h.store.MarkAllNotificationsRead(userID); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "notifications.save_error")
+		return
+	}
+	h.writeUnreadCount(w, r, userID)
+}
+
+// VAPIDKey retorna a chave pública usada pelo navegador no subscribe
+//
+// Endpoint: GET /api/notifications/vapid-key
+func (h *Handler) VAPIDKey(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"enabled":    h.service.Enabled(),
+		"public_key": h.service.PublicKey(),
+		"categories": storage.NotificationCategories,
+	})
+}
+
+// ListSubscriptions retorna os dispositivos inscritos do usuário
+//
+// Endpoint: GET /api/notifications/subscriptions
+func (h *Handler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.store.ListPushSubscriptions(auth.GetUserID(r))
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "notifications.list_error")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"subscriptions": subs})
+}
+
+// Subscribe inscreve o navegador atual para receber notificações
+//
+// Endpoint: POST /api/notifications/subscribe
+//
+// Body: {"endpoint": "https://fcm.googleapis.com/...", "keys": {"p256dh": "...", "auth": "..."}}
+func (h *Handler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	if !h.service.Enabled() {
+		apierror.Write(w, r, http.StatusServiceUnavailable, "notifications.unavailable")
+		return
+	}
+
+	var payload subscribePayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "notifications.invalid_subscription")
+		return
+	}
+
+	payload.Endpoint = strings.TrimSpace(payload.Endpoint)
+	p256dh := strings.TrimRight(payload.Keys.P256dh, "=")
+	authSecret := strings.TrimRight(payload.Keys.Auth, "=")
+	if !h.service.ValidateEndpoint(payload.Endpoint) || !validSubscriptionKeys(p256dh, authSecret) {
+		apierror.Write(w, r, http.StatusBadRequest, "notifications.invalid_subscription")
+		return
+	}
+
+	userID := auth.GetUserID(r)
+	userAgent := r.UserAgent()
+	if len(userAgent) > 300 {
+		userAgent = userAgent[:300]
+	}
+
+	sub := &storage.PushSubscription{
+		ID:        "psh_" + uuid.New().String(),
+		UserID:    userID,
+		Endpoint:  payload.Endpoint,
+		P256dh:    p256dh,
+		Auth:      authSecret,
+		UserAgent: userAgent,
+		CreatedAt: time.Now(),
+	}
+	if err := h.store.SavePushSubscription(sub); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "notifications.save_error")
+		return
+	}
+	h.pruneSubscriptions(userID)
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"subscription": sub})
+}
+
+// Unsubscribe cancela a inscrição do navegador atual
+//
+// Endpoint: DELETE /api/notifications/subscribe
+//
+// Body: {"endpoint": "https://..."}
+func (h *Handler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	var payload subscribePayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || payload.Endpoint == "" {
+		apierror.Write(w, r, http.StatusBadRequest, "notifications.invalid_subscription")
+		return
+	}
+
+	err := h.store.DeletePushSubscription(auth.GetUserID(r), strings.TrimSpace(payload.Endpoint))
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		apierror.Write(w, r, http.StatusInternalServerError, "notifications.save_error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": i18n.Tr(r, "notifications.unsubscribed")})
+}
+
+// =============================================================================
+// FUNÇÕES AUXILIARES
+// =============================================================================
+
+// writeUnreadCount responde com o total atualizado de não lidos
+func (h *Handler) writeUnreadCount(w http.ResponseWriter, r *http.Request, userID string) {
+	unread, err := h.store.CountUnreadNotifications(userID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "notifications.list_error")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"unread_count": unread})
+}
+
+// pruneSubscriptions remove as inscrições mais antigas acima do limite
+func (h *Handler) pruneSubscriptions(userID string) {
+	subs, err := h.store.ListPushSubscriptions(userID)
+	if err != nil || len(subs) <= maxSubscriptionsPerUser {
+		return
+	}
+	// Lista vem ordenada da mais recente para a mais antiga
+	for _, sub := range subs[maxSubscriptionsPerUser:] {
+		h.store.DeletePushSubscription(userID, sub.Endpoint)
+	}
+}
+
+// writeJSON escreve resposta JSON
+func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
+	security.SetJSONHeaders(w)
+	w.WriteHeader(status)
+	if payload != nil {
+		json.NewEncoder(w).Encode(payload)
+	}
+}
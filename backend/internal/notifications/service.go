@@ -0,0 +1,271 @@
+// =============================================================================
+// FAMLI - Central de Notificações
+// =============================================================================
+// Ponto único para avisar o usuário. Cada aviso:
+// 1. Fica salvo na central de notificações (GET /api/notifications)
+// 2. É entregue nos canais externos da categoria (push, email, WhatsApp),
+//    se o usuário não tiver desligado a categoria em Configurações
+//
+// Categorias e canais externos:
+// - reminders: lembretes para manter a caixa em dia (push, email)
+// - guardian_access: alguém acessou informações compartilhadas (push)
+// - emergency: eventos do protocolo de emergência (push, email, WhatsApp)
+// - household: convites para famílias (push, email)
+//
+// O envio é assíncrono e "melhor esforço": falhas de canal são registradas em
+// log e o aviso continua disponível na central. O conteúdo nunca inclui dados
+// dos itens da caixa.
+//
+// Uso nos handlers:
+//
+//	notifications.Notify(userID, storage.NotificationGuardianAccess, "notify.shared_access")
+// =============================================================================
+
+package notifications
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"famli/internal/i18n"
+	"famli/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// requestTimeout limita o tempo de cada envio ao serviço de push
+	requestTimeout = 10 * time.Second
+
+	// messageTTL é por quanto tempo o serviço de push guarda a mensagem
+	// se o dispositivo estiver offline
+	messageTTL = 24 * time.Hour
+)
+
+// categoryChannels define em quais canais externos cada categoria é entregue
+var categoryChannels = map[storage.NotificationCategory][]string{
+	storage.NotificationReminders:      {ChannelPush, ChannelEmail},
+	storage.NotificationGuardianAccess: {ChannelPush},
+	storage.NotificationEmergency:      {ChannelPush, ChannelEmail, ChannelWhatsApp},
+	storage.NotificationHousehold:      {ChannelPush, ChannelEmail},
+}
+
+// pushServiceHosts são os serviços de push aceitos como endpoint de inscrição
+// (evita que o servidor seja usado para enviar requisições a hosts arbitrários)
+var pushServiceHosts = []string{
+	"fcm.googleapis.com",        // Chrome, Edge, Opera
+	"android.googleapis.com",    // Chrome (endpoints antigos)
+	"push.services.mozilla.com", // Firefox
+	"push.apple.com",            // Safari
+	"notify.windows.com",        // Edge legado
+}
+
+// Config configura o envio de Web Push
+type Config struct {
+	VAPIDPublicKey  string // Opcional: validada contra a privada
+	VAPIDPrivateKey string // base64url; vazio em desenvolvimento gera um par temporário
+	Subject         string // Contato do remetente (mailto: ou https:)
+	AllowAnyHost    bool   // Aceita qualquer endpoint (apenas desenvolvimento)
+}
+
+// Service cria os avisos e os entrega nos canais externos
+type Service struct {
+	store   storage.Store
+	keys    *VAPIDKeys
+	subject string
+	anyHost bool
+	client  *http.Client
+
+	mu       sync.RWMutex
+	channels map[string]Channel
+}
+
+// NewService cria o serviço de notificações
+// Sem chave VAPID, gera um par temporário se allowEphemeral (desenvolvimento)
+// ou desabilita o Web Push. Os demais canais são adicionados com AddChannel.
+func NewService(store storage.Store, cfg *Config, allowEphemeral bool) *Service {
+	s := &Service{
+		store:   store,
+		subject: cfg.Subject,
+		anyHost: cfg.AllowAnyHost,
+		client: &http.Client{
+			Timeout: requestTimeout,
+			// Não seguir redirecionamentos (o endpoint já foi validado)
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		channels: make(map[string]Channel),
+	}
+	if s.subject == "" {
+		s.subject = "mailto:contato@famli.net"
+	}
+
+	switch {
+	case cfg.VAPIDPrivateKey != "":
+		keys, err := ParseVAPIDKeys(cfg.VAPIDPrivateKey)
+		if err != nil {
+			log.Printf("[Notifications] VAPID_PRIVATE_KEY inválida, Web Push desabilitado: %v", err)
+			return s
+		}
+		if cfg.VAPIDPublicKey != "" && cfg.VAPIDPublicKey != keys.PublicKey {
+			log.Printf("[Notifications] VAPID_PUBLIC_KEY não corresponde à chave privada, Web Push desabilitado")
+			return s
+		}
+		s.keys = keys
+	case allowEphemeral:
+		keys, _, err := GenerateVAPIDKeys()
+		if err != nil {
+			log.Printf("[Notifications] Erro ao gerar chaves VAPID: %v", err)
+			return s
+		}
+		log.Printf("[Notifications] Usando chaves VAPID temporárias (inscrições não sobrevivem a reinícios)")
+		s.keys = keys
+	}
+
+	if s.keys != nil {
+		s.AddChannel(&pushChannel{service: s})
+	}
+	return s
+}
+
+// AddChannel registra um canal externo de entrega
+func (s *Service) AddChannel(channel Channel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.channels[channel.Name()] = channel
+}
+
+// channel retorna o canal registrado com o nome (nil se não houver)
+func (s *Service) channel(name string) Channel {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.channels[name]
+}
+
+// Enabled indica se o Web Push está configurado
+func (s *Service) Enabled() bool {
+	return s.keys != nil
+}
+
+// PublicKey retorna a chave pública VAPID (usada pelo navegador no subscribe)
+func (s *Service) PublicKey() string {
+	if s.keys == nil {
+		return ""
+	}
+	return s.keys.PublicKey
+}
+
+// ValidateEndpoint verifica se o endpoint pertence a um serviço de push conhecido
+func (s *Service) ValidateEndpoint(endpoint string) bool {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Host == "" || parsed.User != nil || len(endpoint) > 1000 {
+		return false
+	}
+	if s.anyHost {
+		return parsed.Scheme == "https" || parsed.Scheme == "http"
+	}
+	if parsed.Scheme != "https" {
+		return false
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	for _, allowed := range pushServiceHosts {
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// Notify cria o aviso da categoria e o entrega nos canais externos
+// Os textos vêm de i18n: "<key>.title" e "<key>.body", no idioma do usuário.
+// Não bloqueia: a gravação e a entrega acontecem em background.
+func (s *Service) Notify(userID string, category storage.NotificationCategory, key string) {
+	if userID == "" {
+		return
+	}
+	go s.deliver(userID, category, key)
+}
+
+// deliver grava o aviso na central e tenta os canais externos da categoria
+func (s *Service) deliver(userID string, category storage.NotificationCategory, key string) {
+	user, ok := s.store.GetUserByID(userID)
+	if !ok {
+		return
+	}
+
+	locale := i18n.UserLocale(user.Locale, nil)
+
+	notification := &storage.Notification{
+		ID:        "ntf_" + uuid.New().String(),
+		UserID:    userID,
+		Category:  category,
+		Title:     i18n.T(locale, key+".title"),
+		Body:      i18n.T(locale, key+".body"),
+		URL:       "/", // Relativo à origem do app
+		Channels:  []string{},
+		CreatedAt: time.Now(),
+	}
+	if err := s.store.CreateNotification(notification); err != nil {
+		log.Printf("[Notifications] Erro ao salvar aviso: %v", err)
+		return
+	}
+
+	if !s.store.GetSettings(userID).AllowsNotification(category) {
+		return
+	}
+
+	delivered := make([]string, 0)
+	for _, name := range categoryChannels[category] {
+		channel := s.channel(name)
+		if channel == nil {
+			continue
+		}
+		if err := channel.Deliver(user, notification); err != nil {
+			log.Printf("[Notifications] Aviso %s não entregue por %s: %v", notification.ID, name, err)
+			continue
+		}
+		delivered = append(delivered, name)
+	}
+
+	if len(delivered) > 0 {
+		if err := s.store.UpdateNotificationChannels(notification.ID, delivered); err != nil {
+			log.Printf("[Notifications] Erro ao registrar canais do aviso: %v", err)
+		}
+	}
+}
+
+// =============================================================================
+// INSTÂNCIA GLOBAL
+// =============================================================================
+
+var (
+	defaultService   *Service
+	defaultServiceMu sync.RWMutex
+)
+
+// Init define o serviço global usado por Notify
+func Init(store storage.Store, cfg *Config, allowEphemeral bool) *Service {
+	service := NewService(store, cfg, allowEphemeral)
+	defaultServiceMu.Lock()
+	defaultService = service
+	defaultServiceMu.Unlock()
+	return service
+}
+
+// Notify cria o aviso pelo serviço global (não faz nada sem Init)
+func Notify(userID string, category storage.NotificationCategory, key string) {
+	defaultServiceMu.RLock()
+	service := defaultService
+	defaultServiceMu.RUnlock()
+
+	if service == nil {
+		return
+	}
+	service.Notify(userID, category, key)
+}
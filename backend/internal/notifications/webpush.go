@@ -0,0 +1,211 @@
+// =============================================================================
+// FAMLI - Protocolo Web Push
+// =============================================================================
+// Implementa o necessário para enviar notificações a navegadores:
+// - VAPID (RFC 8292): identifica o servidor para o serviço de push com um JWT
+//   ES256 assinado pela chave privada VAPID
+// - Criptografia do payload (RFC 8291, "aes128gcm"): apenas o navegador
+//   inscrito consegue ler o conteúdo da notificação
+//
+// Chaves VAPID no mesmo formato da biblioteca web-push (base64url):
+// - pública: ponto P-256 não comprimido (65 bytes)
+// - privada: escalar P-256 (32 bytes)
+// =============================================================================
+
+package notifications
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/url"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// recordSize é o tamanho de registro declarado no cabeçalho aes128gcm
+	recordSize = 4096
+
+	// vapidTokenTTL é a validade do JWT enviado ao serviço de push (máximo: 24h)
+	vapidTokenTTL = 12 * time.Hour
+)
+
+// ErrInvalidKeys indica chaves VAPID ou da inscrição inválidas
+var ErrInvalidKeys = errors.New("invalid web push keys")
+
+// b64 é a codificação usada pelas chaves Web Push (base64url sem padding)
+var b64 = base64.RawURLEncoding
+
+// VAPIDKeys é o par de chaves que identifica este servidor
+type VAPIDKeys struct {
+	PublicKey  string // base64url, enviada ao navegador no subscribe
+	privateKey *ecdsa.PrivateKey
+}
+
+// GenerateVAPIDKeys gera um novo par de chaves VAPID
+func GenerateVAPIDKeys() (*VAPIDKeys, string, error) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, "", err
+	}
+	privateKey := b64.EncodeToString(key.Bytes())
+	keys, err := ParseVAPIDKeys(privateKey)
+	return keys, privateKey, err
+}
+
+// ParseVAPIDKeys carrega o par de chaves a partir da chave privada (base64url)
+// A chave pública é derivada da privada.
+func ParseVAPIDKeys(privateKey string) (*VAPIDKeys, error) {
+	raw, err := b64.DecodeString(privateKey)
+	if err != nil {
+		return nil, ErrInvalidKeys
+	}
+	key, err := ecdh.P256().NewPrivateKey(raw)
+	if err != nil {
+		return nil, ErrInvalidKeys
+	}
+
+	public := key.PublicKey().Bytes() // 0x04 || X || Y
+	return &VAPIDKeys{
+		PublicKey: b64.EncodeToString(public),
+		privateKey: &ecdsa.PrivateKey{
+			PublicKey: ecdsa.PublicKey{
+				Curve: elliptic.P256(),
+				X:     new(big.Int).SetBytes(public[1:33]),
+				Y:     new(big.Int).SetBytes(public[33:]),
+			},
+			D: new(big.Int).SetBytes(raw),
+		},
+	}, nil
+}
+
+// authorization monta o header Authorization VAPID para o endpoint
+func (k *VAPIDKeys) authorization(endpoint, subject string) (string, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": parsed.Scheme + "://" + parsed.Host,
+		"exp": time.Now().Add(vapidTokenTTL).Unix(),
+		"sub": subject,
+	})
+	signed, err := token.SignedString(k.privateKey)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("vapid t=%s, k=%s", signed, k.PublicKey), nil
+}
+
+// encrypt cifra o payload para a inscrição (RFC 8291, registro único)
+//
+// Formato do corpo: salt(16) || rs(4) || idlen(1) || chave pública efêmera(65) || cifrado
+func encrypt(payload []byte, p256dh, authSecret string) ([]byte, error) {
+	uaPublicRaw, err := b64.DecodeString(p256dh)
+	if err != nil {
+		return nil, ErrInvalidKeys
+	}
+	auth, err := b64.DecodeString(authSecret)
+	if err != nil || len(auth) == 0 {
+		return nil, ErrInvalidKeys
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(uaPublicRaw)
+	if err != nil {
+		return nil, ErrInvalidKeys
+	}
+
+	// Chave efêmera do servidor (uma por mensagem)
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := asPrivate.PublicKey().Bytes()
+
+	sharedSecret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	// IKM = HKDF(auth, ecdh_secret, "WebPush: info" || 0x00 || ua_public || as_public)
+	keyInfo := append([]byte("WebPush: info\x00"), uaPublicRaw...)
+	keyInfo = append(keyInfo, asPublic...)
+	ikm, err := expand(hkdf.Extract(sha256.New, sharedSecret, auth), keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	// CEK e nonce derivados do salt aleatório
+	prk := hkdf.Extract(sha256.New, ikm, salt)
+	cek, err := expand(prk, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := expand(prk, []byte("Content-Encoding: nonce\x00"), 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// Registro único: payload + delimitador de último registro (0x02)
+	plaintext := append(append([]byte{}, payload...), 0x02)
+	if len(plaintext)+gcm.Overhead() > recordSize {
+		return nil, errors.New("payload grande demais para web push")
+	}
+
+	header := make([]byte, 0, 21+len(asPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, recordSize)
+	header = append(header, byte(len(asPublic)))
+	header = append(header, asPublic...)
+
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+// expand lê length bytes do HKDF-Expand
+func expand(prk, info []byte, length int) ([]byte, error) {
+	out := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, prk, info), out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// validSubscriptionKeys verifica as chaves enviadas pelo navegador no subscribe
+func validSubscriptionKeys(p256dh, authSecret string) bool {
+	raw, err := b64.DecodeString(p256dh)
+	if err != nil {
+		return false
+	}
+	if _, err := ecdh.P256().NewPublicKey(raw); err != nil {
+		return false
+	}
+	auth, err := b64.DecodeString(authSecret)
+	return err == nil && len(auth) == 16
+}
@@ -32,12 +32,11 @@ import (
 	"io"
 	"math/big"
 	"net/http"
-	"os"
-	"strings"
-	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 
+	"famli/internal/apierror"
+	"famli/internal/auth"
 	"famli/internal/i18n"
 	"famli/internal/security"
 	"famli/internal/storage"
@@ -56,6 +55,7 @@ type Handler struct {
 	appleTeamID     string
 	appleKeyID      string
 	applePrivateKey string
+	adminEmails     []string
 	auditLogger     *security.AuditLogger
 }
 
@@ -66,6 +66,7 @@ type Config struct {
 	AppleTeamID     string
 	AppleKeyID      string
 	ApplePrivateKey string
+	AdminEmails     []string // Superadmin inicial (ADMIN_EMAILS)
 }
 
 // NewHandler cria uma nova instância do handler OAuth
@@ -78,6 +79,7 @@ func NewHandler(store storage.Store, jwtSecret string, config *Config) *Handler
 		appleTeamID:     config.AppleTeamID,
 		appleKeyID:      config.AppleKeyID,
 		applePrivateKey: config.ApplePrivateKey,
+		adminEmails:     config.AdminEmails,
 		auditLogger:     security.GetAuditLogger(),
 	}
 }
@@ -118,19 +120,19 @@ func (h *Handler) Google(w http.ResponseWriter, r *http.Request) {
 
 	// Verificar se Google está configurado
 	if h.googleClientID == "" {
-		writeError(w, http.StatusServiceUnavailable, i18n.Tr(r, "oauth.google_not_configured"))
+		apierror.Write(w, r, http.StatusServiceUnavailable, "oauth.google_not_configured")
 		return
 	}
 
 	// Decodificar payload
 	var payload oauthPayload
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "auth.invalid_data"))
+		apierror.Write(w, r, http.StatusBadRequest, "auth.invalid_data")
 		return
 	}
 
 	if payload.Token == "" {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "oauth.token_required"))
+		apierror.Write(w, r, http.StatusBadRequest, "oauth.token_required")
 		return
 	}
 
@@ -141,13 +143,13 @@ func (h *Handler) Google(w http.ResponseWriter, r *http.Request) {
 			"provider": "google",
 			"error":    err.Error(),
 		})
-		writeError(w, http.StatusUnauthorized, i18n.Tr(r, "oauth.invalid_token"))
+		apierror.Write(w, r, http.StatusUnauthorized, "oauth.invalid_token")
 		return
 	}
 
 	// Verificar email
 	if !userInfo.EmailVerified {
-		writeError(w, http.StatusUnauthorized, i18n.Tr(r, "oauth.email_not_verified"))
+		apierror.Write(w, r, http.StatusUnauthorized, "oauth.email_not_verified")
 		return
 	}
 
@@ -164,13 +166,34 @@ func (h *Handler) Google(w http.ResponseWriter, r *http.Request) {
 			"provider": "google",
 			"error":    err.Error(),
 		})
-		writeError(w, http.StatusInternalServerError, i18n.Tr(r, "auth.create_error"))
+		apierror.Write(w, r, http.StatusInternalServerError, "auth.create_error")
 		return
 	}
 
+	// Status e papel atuais da conta
+	if current, ok := h.store.GetUserByID(user.ID); ok {
+		// Conta desativada pelo admin
+		if current.IsDisabled() {
+			h.auditLogger.LogAuth(security.EventLoginFailed, user.ID, clientIP, r.UserAgent(), "blocked", map[string]interface{}{
+				"provider": "google",
+				"reason":   "account_disabled",
+			})
+			apierror.Write(w, r, http.StatusForbidden, "auth.account_disabled")
+			return
+		}
+		user.Role = current.Role
+
+		// Idioma inicial da conta (depois alterado em /api/settings)
+		if current.Locale == "" {
+			_ = h.store.UpdateUserLocale(user.ID, i18n.GetLocale(r))
+		}
+	}
+	auth.BootstrapSuperadmin(h.store, user, h.adminEmails)
+	auth.LinkAnonymousActivity(h.store, r, user.ID)
+
 	// Criar sessão JWT
-	if err := h.setSession(w, user.ID, user.Email, r); err != nil {
-		writeError(w, http.StatusInternalServerError, i18n.Tr(r, "auth.session_error"))
+	if err := h.setSession(w, user, r); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "auth.session_error")
 		return
 	}
 
@@ -179,9 +202,6 @@ func (h *Handler) Google(w http.ResponseWriter, r *http.Request) {
 		"provider": "google",
 	})
 
-	// Verificar se é admin
-	isAdmin := checkIsAdmin(user.Email)
-
 	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"user": map[string]interface{}{
 			"id":         user.ID,
@@ -189,7 +209,8 @@ func (h *Handler) Google(w http.ResponseWriter, r *http.Request) {
 			"name":       user.Name,
 			"avatar_url": user.AvatarURL,
 			"provider":   user.Provider,
-			"is_admin":   isAdmin,
+			"is_admin":   user.Role.IsAdmin(),
+			"role":       user.Role,
 		},
 	})
 }
@@ -254,19 +275,19 @@ func (h *Handler) Apple(w http.ResponseWriter, r *http.Request) {
 
 	// Verificar se Apple está configurado
 	if h.appleClientID == "" {
-		writeError(w, http.StatusServiceUnavailable, i18n.Tr(r, "oauth.apple_not_configured"))
+		apierror.Write(w, r, http.StatusServiceUnavailable, "oauth.apple_not_configured")
 		return
 	}
 
 	// Decodificar payload
 	var payload oauthPayload
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "auth.invalid_data"))
+		apierror.Write(w, r, http.StatusBadRequest, "auth.invalid_data")
 		return
 	}
 
 	if payload.Token == "" {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "oauth.token_required"))
+		apierror.Write(w, r, http.StatusBadRequest, "oauth.token_required")
 		return
 	}
 
@@ -277,7 +298,7 @@ func (h *Handler) Apple(w http.ResponseWriter, r *http.Request) {
 			"provider": "apple",
 			"error":    err.Error(),
 		})
-		writeError(w, http.StatusUnauthorized, i18n.Tr(r, "oauth.invalid_token"))
+		apierror.Write(w, r, http.StatusUnauthorized, "oauth.invalid_token")
 		return
 	}
 
@@ -286,7 +307,7 @@ func (h *Handler) Apple(w http.ResponseWriter, r *http.Request) {
 	sub, _ := claims["sub"].(string)
 
 	if sub == "" {
-		writeError(w, http.StatusUnauthorized, i18n.Tr(r, "oauth.invalid_token"))
+		apierror.Write(w, r, http.StatusUnauthorized, "oauth.invalid_token")
 		return
 	}
 
@@ -307,13 +328,34 @@ func (h *Handler) Apple(w http.ResponseWriter, r *http.Request) {
 			"provider": "apple",
 			"error":    err.Error(),
 		})
-		writeError(w, http.StatusInternalServerError, i18n.Tr(r, "auth.create_error"))
+		apierror.Write(w, r, http.StatusInternalServerError, "auth.create_error")
 		return
 	}
 
+	// Status e papel atuais da conta
+	if current, ok := h.store.GetUserByID(user.ID); ok {
+		// Conta desativada pelo admin
+		if current.IsDisabled() {
+			h.auditLogger.LogAuth(security.EventLoginFailed, user.ID, clientIP, r.UserAgent(), "blocked", map[string]interface{}{
+				"provider": "apple",
+				"reason":   "account_disabled",
+			})
+			apierror.Write(w, r, http.StatusForbidden, "auth.account_disabled")
+			return
+		}
+		user.Role = current.Role
+
+		// Idioma inicial da conta (depois alterado em /api/settings)
+		if current.Locale == "" {
+			_ = h.store.UpdateUserLocale(user.ID, i18n.GetLocale(r))
+		}
+	}
+	auth.BootstrapSuperadmin(h.store, user, h.adminEmails)
+	auth.LinkAnonymousActivity(h.store, r, user.ID)
+
 	// Criar sessão JWT
-	if err := h.setSession(w, user.ID, user.Email, r); err != nil {
-		writeError(w, http.StatusInternalServerError, i18n.Tr(r, "auth.session_error"))
+	if err := h.setSession(w, user, r); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "auth.session_error")
 		return
 	}
 
@@ -322,9 +364,6 @@ func (h *Handler) Apple(w http.ResponseWriter, r *http.Request) {
 		"provider": "apple",
 	})
 
-	// Verificar se é admin
-	isAdmin := checkIsAdmin(user.Email)
-
 	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"user": map[string]interface{}{
 			"id":         user.ID,
@@ -332,7 +371,8 @@ func (h *Handler) Apple(w http.ResponseWriter, r *http.Request) {
 			"name":       user.Name,
 			"avatar_url": user.AvatarURL,
 			"provider":   user.Provider,
-			"is_admin":   isAdmin,
+			"is_admin":   user.Role.IsAdmin(),
+			"role":       user.Role,
 		},
 	})
 }
@@ -480,76 +520,9 @@ func (h *Handler) Status(w http.ResponseWriter, r *http.Request) {
 // FUNÇÕES AUXILIARES
 // =============================================================================
 
-// setSession cria um token JWT e define o cookie de sessão
-func (h *Handler) setSession(w http.ResponseWriter, userID, email string, r *http.Request) error {
-	now := time.Now()
-	sessionDuration := 7 * 24 * time.Hour
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub":   userID,
-		"email": email,
-		"exp":   now.Add(sessionDuration).Unix(),
-		"iat":   now.Unix(),
-		"nbf":   now.Unix(),
-		"jti":   fmt.Sprintf("%d%s", now.UnixNano(), randomChars(4)),
-	})
-
-	signed, err := token.SignedString([]byte(h.jwtSecret))
-	if err != nil {
-		return err
-	}
-
-	http.SetCookie(w, &http.Cookie{
-		Name:     "famli_session",
-		Value:    signed,
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   isSecureContext(r),
-		SameSite: http.SameSiteLaxMode,
-		Expires:  now.Add(7 * 24 * time.Hour),
-		MaxAge:   7 * 24 * 60 * 60,
-	})
-
-	return nil
-}
-
-func isSecureContext(r *http.Request) bool {
-	if r.TLS != nil {
-		return true
-	}
-	if r.Header.Get("X-Forwarded-Proto") == "https" {
-		return true
-	}
-	return false
-}
-
-func randomChars(n int) string {
-	const chars = "abcdefghijklmnopqrstuvwxyz0123456789"
-	b := make([]byte, n)
-	for i := range b {
-		b[i] = chars[time.Now().UnixNano()%int64(len(chars))]
-		time.Sleep(time.Nanosecond)
-	}
-	return string(b)
-}
-
-func checkIsAdmin(email string) bool {
-	adminEmails := os.Getenv("ADMIN_EMAILS")
-	if adminEmails == "" {
-		if os.Getenv("ENV") != "production" {
-			return true
-		}
-		return false
-	}
-
-	email = strings.ToLower(email)
-	for _, admin := range strings.Split(adminEmails, ",") {
-		admin = strings.TrimSpace(strings.ToLower(admin))
-		if email == admin {
-			return true
-		}
-	}
-	return false
+// setSession registra a sessão do dispositivo e define o cookie de sessão
+func (h *Handler) setSession(w http.ResponseWriter, user *storage.User, r *http.Request) error {
+	return auth.StartSession(h.store, h.jwtSecret, w, r, user)
 }
 
 func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
@@ -559,7 +532,3 @@ func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
 		json.NewEncoder(w).Encode(payload)
 	}
 }
-
-func writeError(w http.ResponseWriter, status int, message string) {
-	writeJSON(w, status, map[string]string{"error": message})
-}
@@ -0,0 +1,169 @@
+// =============================================================================
+// FAMLI - Onboarding: Checklist Calculado
+// =============================================================================
+// GET /api/onboarding/status responde o que o usuário já fez de verdade
+// (não o progresso marcado no Guia), para o anel de progresso e a próxima
+// ação sugerida no frontend.
+//
+// Passos, na ordem em que são sugeridos:
+// 1. first_item: pelo menos um item criado na Caixa (inclusive nas famílias)
+// 2. guardian: pelo menos uma pessoa de confiança
+// 3. emergency_contact: uma pessoa de confiança com telefone que não seja só
+//    do modo memorial (quem avisar rápido numa emergência)
+// 4. shared_item: pelo menos um item pessoal compartilhado com as pessoas de confiança
+// 5. whatsapp: número vinculado (apenas com o WhatsApp habilitado para o usuário)
+// =============================================================================
+
+package onboarding
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/features"
+	"famli/internal/i18n"
+	"famli/internal/storage"
+)
+
+// Passos do checklist
+const (
+	StepFirstItem        = "first_item"
+	StepGuardian         = "guardian"
+	StepEmergencyContact = "emergency_contact"
+	StepSharedItem       = "shared_item"
+	StepWhatsApp         = "whatsapp"
+)
+
+// Step é um passo do checklist com o texto no idioma do usuário
+type Step struct {
+	ID          string `json:"id"`
+	Done        bool   `json:"done"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Action      string `json:"action"` // Texto do botão da próxima ação
+}
+
+// LinkChecker informa se o usuário vinculou o WhatsApp
+type LinkChecker func(userID string) bool
+
+// Handler calcula o checklist de onboarding
+type Handler struct {
+	store          storage.Store
+	whatsappLinked LinkChecker // nil = WhatsApp desabilitado
+}
+
+// NewHandler cria o handler de onboarding
+// whatsappLinked pode ser nil quando o WhatsApp não está configurado.
+func NewHandler(store storage.Store, whatsappLinked LinkChecker) *Handler {
+	return &Handler{store: store, whatsappLinked: whatsappLinked}
+}
+
+// Status retorna os passos concluídos e a próxima ação sugerida
+//
+// Endpoint: GET /api/onboarding/status
+func (h *Handler) Status(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	done, err := h.completion(r, userID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "onboarding.status_error")
+		return
+	}
+
+	steps := make([]Step, 0, len(done))
+	completed := 0
+	var next *Step
+	for _, id := range []string{StepFirstItem, StepGuardian, StepEmergencyContact, StepSharedItem, StepWhatsApp} {
+		isDone, ok := done[id]
+		if !ok {
+			continue // Passo não se aplica (ex: WhatsApp desabilitado)
+		}
+		steps = append(steps, Step{
+			ID:          id,
+			Done:        isDone,
+			Title:       i18n.Tr(r, "onboarding.step."+id+".title"),
+			Description: i18n.Tr(r, "onboarding.step."+id+".description"),
+			Action:      i18n.Tr(r, "onboarding.step."+id+".action"),
+		})
+		if isDone {
+			completed++
+		} else if next == nil {
+			next = &steps[len(steps)-1]
+		}
+	}
+
+	percent := 0
+	if len(steps) > 0 {
+		percent = completed * 100 / len(steps)
+	}
+
+	var nextStep interface{}
+	if next != nil {
+		nextStep = *next
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"steps":     steps,
+		"completed": completed,
+		"total":     len(steps),
+		"percent":   percent,
+		"next_step": nextStep,
+	})
+}
+
+// completion calcula cada passo a partir dos dados do usuário
+func (h *Handler) completion(r *http.Request, userID string) (map[string]bool, error) {
+	usage, err := h.store.GetUserUsage(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	shared := true
+	sharedCount, err := h.store.CountAccessibleBoxItems(&storage.BoxItemFilter{
+		UserID:          userID,
+		IncludePersonal: true,
+		Shared:          &shared,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	guardians, err := h.store.GetGuardians(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	done := map[string]bool{
+		StepFirstItem:        usage.ItemCount > 0,
+		StepGuardian:         len(guardians) > 0,
+		StepEmergencyContact: hasEmergencyContact(guardians),
+		StepSharedItem:       sharedCount > 0,
+	}
+	if h.whatsappLinked != nil && features.Enabled(r.Context(), features.WhatsApp) {
+		done[StepWhatsApp] = h.whatsappLinked(userID)
+	}
+	return done, nil
+}
+
+// hasEmergencyContact indica se há quem avisar rápido numa emergência
+func hasEmergencyContact(guardians []*storage.Guardian) bool {
+	for _, g := range guardians {
+		if g.Phone != "" && g.AccessType != storage.GuardianAccessMemorial {
+			return true
+		}
+	}
+	return false
+}
+
+// =============================================================================
+// FUNÇÕES AUXILIARES
+// =============================================================================
+
+func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if payload != nil {
+		json.NewEncoder(w).Encode(payload)
+	}
+}
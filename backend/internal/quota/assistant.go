@@ -0,0 +1,70 @@
+// =============================================================================
+// FAMLI - Cotas: Orçamento diário do assistente
+// =============================================================================
+// Cada pergunta ao assistente consome tokens (pergunta + resposta). O uso
+// fica no storage por dia UTC, então o orçamento vale entre réplicas e
+// sobrevive a deploys; ele volta a zero à meia-noite UTC.
+//
+// Enquanto o assistente não usa um modelo de linguagem, os tokens são
+// estimados pelo tamanho do texto (EstimateTokens).
+// =============================================================================
+
+package quota
+
+import (
+	"time"
+	"unicode/utf8"
+)
+
+// runesPerToken é a média usada para estimar tokens a partir de texto
+const runesPerToken = 4
+
+// dayFormat é o formato dos dias de uso no storage
+const dayFormat = "2006-01-02"
+
+// EstimateTokens estima os tokens de um texto (arredonda para cima)
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + runesPerToken - 1) / runesPerToken
+}
+
+// CheckAssistant verifica se o usuário ainda tem orçamento no dia
+//
+// Retorna:
+//   - *ExceededError se os tokens do dia acabaram (a recusa é registrada)
+//   - time.Duration até o orçamento renovar (meia-noite UTC), para Retry-After
+//   - erro do storage ao buscar o uso
+func (c *Checker) CheckAssistant(userID string, now time.Time) (time.Duration, error) {
+	if c == nil || c.limits.AssistantDailyTokens <= 0 {
+		return 0, nil
+	}
+
+	day := now.UTC().Format(dayFormat)
+	usage, err := c.store.GetAssistantUsage(userID, day)
+	if err != nil {
+		return 0, err
+	}
+	if usage.Tokens < c.limits.AssistantDailyTokens {
+		return 0, nil
+	}
+
+	c.store.RecordAssistantUsage(userID, day, 0, true)
+	return untilNextDay(now), &ExceededError{
+		Resource: ResourceTokens,
+		Limit:    int64(c.limits.AssistantDailyTokens),
+		Used:     int64(usage.Tokens),
+	}
+}
+
+// RecordAssistant soma os tokens de uma pergunta respondida ao dia
+func (c *Checker) RecordAssistant(userID string, tokens int, now time.Time) error {
+	if c == nil {
+		return nil
+	}
+	return c.store.RecordAssistantUsage(userID, now.UTC().Format(dayFormat), tokens, false)
+}
+
+// untilNextDay é o tempo até a meia-noite UTC seguinte
+func untilNextDay(now time.Time) time.Duration {
+	today := now.UTC().Truncate(24 * time.Hour)
+	return today.Add(24 * time.Hour).Sub(now)
+}
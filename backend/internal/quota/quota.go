@@ -0,0 +1,124 @@
+// =============================================================================
+// FAMLI - Cotas de Armazenamento
+// =============================================================================
+// Limites por usuário do plano gratuito:
+// - Quantidade de itens criados (inclusive nos itens da família)
+// - Bytes de texto dos itens (título + conteúdo + destinatário)
+// - Tokens do assistente por dia (ver assistant.go)
+//
+// O uso é calculado pelo storage (GetUserUsage). A cota é de quem criou o
+// item: um membro da família que edita o item de outra pessoa consome a cota
+// do criador.
+//
+// O Famli ainda não guarda arquivos; quando houver anexos, o tamanho deles
+// entra aqui como um novo recurso.
+//
+// Configuração (pacote config):
+// - QUOTA_MAX_ITEMS: itens por usuário (padrão: 1000, 0 = sem limite)
+// - QUOTA_MAX_CONTENT_MB: MB de texto por usuário (padrão: 25, 0 = sem limite)
+// - ASSISTANT_DAILY_TOKENS: tokens do assistente por dia (padrão: 20000, 0 = sem limite)
+// =============================================================================
+
+package quota
+
+import (
+	"fmt"
+
+	"famli/internal/storage"
+)
+
+// Resource identifica o recurso limitado
+type Resource string
+
+const (
+	ResourceItems   Resource = "items"            // Quantidade de itens
+	ResourceContent Resource = "content_bytes"    // Bytes de texto
+	ResourceTokens  Resource = "assistant_tokens" // Tokens do assistente no dia
+)
+
+// Limits são os limites por usuário (0 = sem limite)
+type Limits struct {
+	MaxItems             int
+	MaxContentBytes      int64
+	AssistantDailyTokens int
+}
+
+// ExceededError indica que a operação ultrapassaria a cota
+type ExceededError struct {
+	Resource Resource
+	Limit    int64 // Limite configurado
+	Used     int64 // Uso atual (antes da operação)
+}
+
+// Error descreve a cota excedida (para logs)
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("cota excedida: %s (uso %d, limite %d)", e.Resource, e.Used, e.Limit)
+}
+
+// Checker confere as cotas antes de gravar itens
+// Um Checker nil não aplica limites.
+type Checker struct {
+	store  storage.UsageStore
+	limits Limits
+}
+
+// NewChecker cria o verificador de cotas
+func NewChecker(store storage.UsageStore, limits Limits) *Checker {
+	return &Checker{store: store, limits: limits}
+}
+
+// Limits retorna os limites configurados
+func (c *Checker) Limits() Limits {
+	if c == nil {
+		return Limits{}
+	}
+	return c.limits
+}
+
+// CheckCreate verifica se o usuário pode criar o item
+//
+// Retorna:
+//   - *ExceededError se a cota de itens ou de bytes for ultrapassada
+//   - erro do storage ao calcular o uso
+func (c *Checker) CheckCreate(userID string, item *storage.BoxItem) error {
+	if c == nil || (c.limits.MaxItems <= 0 && c.limits.MaxContentBytes <= 0) {
+		return nil
+	}
+
+	usage, err := c.store.GetUserUsage(userID)
+	if err != nil {
+		return err
+	}
+
+	if c.limits.MaxItems > 0 && usage.ItemCount >= c.limits.MaxItems {
+		return &ExceededError{Resource: ResourceItems, Limit: int64(c.limits.MaxItems), Used: int64(usage.ItemCount)}
+	}
+	return c.checkBytes(usage, item.ContentBytes())
+}
+
+// CheckUpdate verifica se o dono do item pode gravar a nova versão
+// Só o aumento de tamanho conta: reduzir um item é sempre permitido.
+func (c *Checker) CheckUpdate(ownerID string, existing, updates *storage.BoxItem) error {
+	if c == nil || c.limits.MaxContentBytes <= 0 {
+		return nil
+	}
+
+	delta := updates.ContentBytes() - existing.ContentBytes()
+	if delta <= 0 {
+		return nil
+	}
+
+	usage, err := c.store.GetUserUsage(ownerID)
+	if err != nil {
+		return err
+	}
+	return c.checkBytes(usage, delta)
+}
+
+// checkBytes verifica se cabem mais bytes na cota
+func (c *Checker) checkBytes(usage *storage.UserUsage, added int64) error {
+	if c.limits.MaxContentBytes > 0 && usage.ContentBytes+added > c.limits.MaxContentBytes {
+		return &ExceededError{Resource: ResourceContent, Limit: c.limits.MaxContentBytes, Used: usage.ContentBytes}
+	}
+	return nil
+}
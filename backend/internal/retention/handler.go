@@ -0,0 +1,157 @@
+// =============================================================================
+// FAMLI - Handler de Retenção de Dados
+// =============================================================================
+// Endpoints:
+// - GET  /api/admin/retention         - prazos e execuções recentes (admin)
+// - PUT  /api/admin/retention/{class} - altera um prazo (superadmin)
+// - POST /api/admin/retention/run     - executa a retenção agora (superadmin)
+// =============================================================================
+
+package retention
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/security"
+	"famli/internal/storage"
+	"github.com/go-chi/chi/v5"
+)
+
+// recentRuns é quantas execuções a listagem retorna
+const recentRuns = 50
+
+// Handler expõe os prazos de retenção via HTTP
+type Handler struct {
+	manager     *Manager
+	job         *Job
+	store       storage.SystemStore
+	auditLogger *security.AuditLogger
+}
+
+// NewHandler cria uma nova instância do handler de retenção
+func NewHandler(manager *Manager, job *Job, store storage.SystemStore) *Handler {
+	return &Handler{
+		manager:     manager,
+		job:         job,
+		store:       store,
+		auditLogger: security.GetAuditLogger(),
+	}
+}
+
+// policyPayload é o corpo de PUT /api/admin/retention/{class}
+type policyPayload struct {
+	Days int `json:"days"`
+}
+
+// List retorna os prazos de todas as classes e as execuções recentes
+//
+// Endpoint: GET /api/admin/retention
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	policies, err := h.manager.List()
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "retention.list_error")
+		return
+	}
+	runs, err := h.store.ListRetentionRuns(recentRuns)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "retention.list_error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"policies": policies,
+		"runs":     runs,
+		"limits":   map[string]int{"min_days": MinDays, "max_days": MaxDays},
+	})
+}
+
+// Update altera o prazo de uma classe
+// Vale a partir da próxima execução do job.
+//
+// Endpoint: PUT /api/admin/retention/{class}
+//
+// Body: {"days": 365}
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	class := storage.RetentionClass(chi.URLParam(r, "class"))
+	previous, ok, err := h.manager.Get(class)
+	if !ok {
+		apierror.Write(w, r, http.StatusNotFound, "retention.not_found")
+		return
+	}
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "retention.update_error")
+		return
+	}
+
+	var payload policyPayload
+	if err := security.DecodeJSONStrict(r.Body, &payload); err != nil {
+		apierror.WriteDecodeError(w, r, err, "retention.invalid_data")
+		return
+	}
+
+	policy, err := h.manager.Set(class, payload.Days, auth.GetUserID(r))
+	if err != nil {
+		apierror.WriteStorage(w, r, err, apierror.StorageKeys{Invalid: "retention.invalid_data", Internal: "retention.update_error"})
+		return
+	}
+
+	h.auditLogger.Log(security.AuditEvent{
+		Type:     security.EventRetentionChanged,
+		Severity: security.SeverityInfo,
+		UserID:   auth.GetUserID(r),
+		ClientIP: security.GetClientIP(r),
+		Resource: "retention:" + string(class),
+		Action:   "update",
+		Result:   "success",
+		Details: map[string]interface{}{
+			"days":          policy.Days,
+			"previous_days": previous.Days,
+		},
+	})
+
+	writeJSON(w, http.StatusOK, policy)
+}
+
+// Run aplica os prazos agora, sem esperar o job
+//
+// Endpoint: POST /api/admin/retention/run
+func (h *Handler) Run(w http.ResponseWriter, r *http.Request) {
+	runs := h.job.Run()
+	if runs == nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "retention.run_error")
+		return
+	}
+
+	purged := map[storage.RetentionClass]int64{}
+	for _, run := range runs {
+		purged[run.Class] = run.Purged
+	}
+	h.auditLogger.Log(security.AuditEvent{
+		Type:     security.EventRetentionPurged,
+		Severity: security.SeverityInfo,
+		UserID:   auth.GetUserID(r),
+		ClientIP: security.GetClientIP(r),
+		Resource: "retention",
+		Action:   "run",
+		Result:   "success",
+		Details:  map[string]interface{}{"purged": purged},
+	})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"runs": runs})
+}
+
+// =============================================================================
+// FUNÇÕES AUXILIARES
+// =============================================================================
+
+// writeJSON escreve resposta JSON
+func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
+	security.SetJSONHeaders(w)
+	w.WriteHeader(status)
+	if payload != nil {
+		json.NewEncoder(w).Encode(payload)
+	}
+}
@@ -0,0 +1,79 @@
+package retention
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"famli/internal/storage"
+)
+
+// Job aplica os prazos de retenção de cada classe
+// Roda ao iniciar e a cada LOG_CLEANUP_INTERVAL_HOURS (0 = só ao iniciar).
+type Job struct {
+	manager  *Manager
+	store    storage.SystemStore
+	interval time.Duration
+
+	mu sync.Mutex // Uma execução por vez (job e execução manual)
+}
+
+// NewJob cria o job de retenção
+func NewJob(manager *Manager, store storage.SystemStore, interval time.Duration) *Job {
+	return &Job{manager: manager, store: store, interval: interval}
+}
+
+// Start inicia o job (encerra quando ctx é cancelado)
+func (j *Job) Start(ctx context.Context) {
+	go func() {
+		j.Run()
+		if j.interval <= 0 {
+			return
+		}
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				j.Run()
+			}
+		}
+	}()
+}
+
+// Run aplica os prazos de todas as classes e registra cada execução
+// Uma classe com erro não impede as demais.
+func (j *Job) Run() []*storage.RetentionRun {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	policies, err := j.manager.List()
+	if err != nil {
+		log.Printf("[Retention] Erro ao carregar prazos: %v", err)
+		return nil
+	}
+
+	runs := make([]*storage.RetentionRun, 0, len(policies))
+	for _, policy := range policies {
+		run := &storage.RetentionRun{Class: policy.Class, Days: policy.Days, StartedAt: time.Now()}
+		purged, err := j.store.PurgeRetention(policy.Class, policy.Days)
+		run.Purged = purged
+		run.DurationMS = time.Since(run.StartedAt).Milliseconds()
+		if err != nil {
+			run.Error = err.Error()
+			log.Printf("[Retention] Erro ao aplicar %s (%d dias): %v", policy.Class, policy.Days, err)
+		} else if purged > 0 {
+			log.Printf("[Retention] %s: %d registro(s) removido(s) (>%d dias)", policy.Class, purged, policy.Days)
+		}
+
+		if err := j.store.CreateRetentionRun(run); err != nil {
+			log.Printf("[Retention] Erro ao registrar execução de %s: %v", policy.Class, err)
+		}
+		runs = append(runs, run)
+	}
+	return runs
+}
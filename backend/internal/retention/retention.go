@@ -0,0 +1,198 @@
+// =============================================================================
+// FAMLI - Retenção de Dados
+// =============================================================================
+// Cada classe de dados tem o próprio prazo de retenção (analytics 90 dias,
+// auditoria 1 ano, auditoria de contas excluídas 5 anos...). O job remove o
+// que passou do prazo e registra cada execução em retention_runs.
+//
+// Armazenamento: tabela system_config, chave "retention:<classe>", valor JSON.
+// Classes sem registro no banco usam o padrão definido em Known; as sem
+// padrão próprio usam LOG_RETENTION_DAYS.
+//
+// Administração:
+// - GET  /api/admin/retention         - prazos e execuções recentes (admin)
+// - PUT  /api/admin/retention/{class} - altera um prazo (superadmin)
+// - POST /api/admin/retention/run     - executa a retenção agora (superadmin)
+// =============================================================================
+
+package retention
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"famli/internal/storage"
+)
+
+// Limites dos prazos (em dias)
+const (
+	MinDays = 7
+	MaxDays = 3650
+)
+
+// Definition descreve uma classe de dados e seu prazo padrão
+type Definition struct {
+	Description string
+	Default     int // Dias (0 = LOG_RETENTION_DAYS)
+}
+
+// Known lista as classes com retenção (apenas estas podem ser alteradas pelo admin)
+var Known = map[storage.RetentionClass]Definition{
+	storage.RetentionAnalytics:           {Description: "Eventos de analytics, rollups diários e notas de preparo", Default: 90},
+	storage.RetentionAudit:               {Description: "Auditoria de contas ativas", Default: 365},
+	storage.RetentionDeletedAccountAudit: {Description: "Auditoria de contas excluídas", Default: 5 * 365},
+	storage.RetentionShareAccesses:       {Description: "Acessos aos links compartilhados", Default: 180},
+	storage.RetentionLoginHistory:        {Description: "Histórico de logins (detecção de novos dispositivos)", Default: storage.LoginHistoryRetentionDays},
+	storage.RetentionWebhookDeliveries:   {Description: "Entregas de webhooks finalizadas"},
+	storage.RetentionGuardianAlerts:      {Description: "Avisos aos guardiões finalizados"},
+}
+
+// order é a ordem das classes na listagem e na execução
+var order = []storage.RetentionClass{
+	storage.RetentionAnalytics,
+	storage.RetentionAudit,
+	storage.RetentionDeletedAccountAudit,
+	storage.RetentionShareAccesses,
+	storage.RetentionLoginHistory,
+	storage.RetentionWebhookDeliveries,
+	storage.RetentionGuardianAlerts,
+}
+
+// configPrefix é o prefixo das chaves de prazos em system_config
+const configPrefix = "retention:"
+
+// Policy é o prazo de retenção de uma classe
+type Policy struct {
+	Class       storage.RetentionClass `json:"class"`
+	Description string                 `json:"description,omitempty"`
+	Days        int                    `json:"days"`
+	DefaultDays int                    `json:"default_days"`
+	UpdatedAt   *time.Time             `json:"updated_at,omitempty"` // Última alteração (nil = padrão)
+	UpdatedBy   string                 `json:"updated_by,omitempty"` // ID do admin que alterou
+}
+
+// storedPolicy é o valor JSON guardado em system_config
+type storedPolicy struct {
+	Days      int        `json:"days"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	UpdatedBy string     `json:"updated_by,omitempty"`
+}
+
+// =============================================================================
+// MANAGER
+// =============================================================================
+
+// Manager lê e altera os prazos de retenção
+// Sem cache: os prazos são lidos só pelo job, pelo painel admin e pelo
+// inventário de dados do usuário.
+type Manager struct {
+	store            storage.Store
+	logRetentionDays int
+}
+
+// NewManager cria um manager de prazos (logRetentionDays = LOG_RETENTION_DAYS)
+func NewManager(store storage.Store, logRetentionDays int) *Manager {
+	return &Manager{store: store, logRetentionDays: clamp(logRetentionDays)}
+}
+
+// List retorna os prazos de todas as classes conhecidas
+func (m *Manager) List() ([]*Policy, error) {
+	values, err := m.store.ListSystemConfig(configPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	policies := make([]*Policy, 0, len(order))
+	for _, class := range order {
+		policies = append(policies, m.policy(class, values[configPrefix+string(class)]))
+	}
+	return policies, nil
+}
+
+// Get retorna o prazo atual de uma classe conhecida
+func (m *Manager) Get(class storage.RetentionClass) (*Policy, bool, error) {
+	if _, known := Known[class]; !known {
+		return nil, false, nil
+	}
+	values, err := m.store.ListSystemConfig(configPrefix + string(class))
+	if err != nil {
+		return nil, true, err
+	}
+	return m.policy(class, values[configPrefix+string(class)]), true, nil
+}
+
+// Days retorna o prazo de uma classe (o padrão se o banco falhar)
+func (m *Manager) Days(class storage.RetentionClass) int {
+	policy, known, err := m.Get(class)
+	if !known {
+		return 0
+	}
+	if err != nil {
+		log.Printf("[Retention] Erro ao carregar prazo de %s: %v", class, err)
+		return m.defaultDays(class)
+	}
+	return policy.Days
+}
+
+// Set persiste o novo prazo de uma classe conhecida
+func (m *Manager) Set(class storage.RetentionClass, days int, updatedBy string) (*Policy, error) {
+	if _, known := Known[class]; !known {
+		return nil, storage.ErrNotFound
+	}
+	if days < MinDays || days > MaxDays {
+		return nil, storage.ErrInvalidData
+	}
+
+	now := time.Now()
+	data, err := json.Marshal(storedPolicy{Days: days, UpdatedAt: &now, UpdatedBy: updatedBy})
+	if err != nil {
+		return nil, err
+	}
+	if err := m.store.SetSystemConfig(configPrefix+string(class), string(data)); err != nil {
+		return nil, err
+	}
+	return m.policy(class, string(data)), nil
+}
+
+// policy monta o prazo da classe a partir do valor em system_config
+func (m *Manager) policy(class storage.RetentionClass, value string) *Policy {
+	policy := &Policy{
+		Class:       class,
+		Description: Known[class].Description,
+		DefaultDays: m.defaultDays(class),
+	}
+	policy.Days = policy.DefaultDays
+
+	if value == "" {
+		return policy
+	}
+	var stored storedPolicy
+	if err := json.Unmarshal([]byte(value), &stored); err != nil {
+		log.Printf("[Retention] Prazo inválido para %s: %v", class, err)
+		return policy
+	}
+	policy.Days = clamp(stored.Days)
+	policy.UpdatedAt = stored.UpdatedAt
+	policy.UpdatedBy = stored.UpdatedBy
+	return policy
+}
+
+// defaultDays retorna o prazo padrão da classe
+func (m *Manager) defaultDays(class storage.RetentionClass) int {
+	if days := Known[class].Default; days > 0 {
+		return days
+	}
+	return m.logRetentionDays
+}
+
+// clamp mantém um prazo dentro dos limites
+func clamp(days int) int {
+	if days < MinDays {
+		return MinDays
+	}
+	if days > MaxDays {
+		return MaxDays
+	}
+	return days
+}
@@ -0,0 +1,175 @@
+// =============================================================================
+// FAMLI - Driver ClamAV (clamd)
+// =============================================================================
+// Fala o protocolo do clamd diretamente, sem dependências:
+//
+//	zINSTREAM\0 + blocos [tamanho uint32 big-endian][dados] + bloco vazio
+//	← "stream: OK\0" | "stream: <assinatura> FOUND\0" | "<erro> ERROR\0"
+//
+// O arquivo nunca é gravado em disco: o conteúdo vai direto pela conexão.
+// Referência: https://docs.clamav.net/manual/Usage/Scanning.html#clamd
+// =============================================================================
+
+package scan
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	// clamavChunkSize é o tamanho dos blocos enviados no INSTREAM
+	clamavChunkSize = 64 * 1024
+
+	// defaultClamAVTimeout vale quando nenhum tempo é configurado
+	defaultClamAVTimeout = 30 * time.Second
+)
+
+// ClamAV verifica arquivos num clamd
+type ClamAV struct {
+	network string // "tcp" ou "unix"
+	address string
+	timeout time.Duration
+}
+
+// NewClamAV cria o driver para o clamd em address
+// (tcp://host:3310, host:3310 ou unix:///var/run/clamav/clamd.ctl)
+func NewClamAV(address string, timeout time.Duration) *ClamAV {
+	if timeout <= 0 {
+		timeout = defaultClamAVTimeout
+	}
+	network, addr := "tcp", address
+	switch {
+	case strings.HasPrefix(address, "unix://"):
+		network, addr = "unix", strings.TrimPrefix(address, "unix://")
+	case strings.HasPrefix(address, "tcp://"):
+		addr = strings.TrimPrefix(address, "tcp://")
+	}
+	return &ClamAV{network: network, address: addr, timeout: timeout}
+}
+
+// Name implementa Scanner
+func (c *ClamAV) Name() string { return "clamav" }
+
+// Scan envia o conteúdo ao clamd (INSTREAM)
+func (c *ClamAV) Scan(ctx context.Context, content io.Reader) Result {
+	result := Result{Engine: c.Name()}
+	signature, err := c.instream(ctx, content)
+	result.ScannedAt = time.Now().UTC()
+
+	switch {
+	case err != nil:
+		result.Status = StatusFailed
+		result.Error = err.Error()
+	case signature != "":
+		result.Status = StatusInfected
+		result.Signature = signature
+	default:
+		result.Status = StatusClean
+	}
+	return result
+}
+
+// Ping verifica se o clamd responde PONG
+func (c *ClamAV) Ping(ctx context.Context) error {
+	reply, err := c.command(ctx, "PING", nil)
+	if err != nil {
+		return err
+	}
+	if reply != "PONG" {
+		return fmt.Errorf("clamav: resposta inesperada ao PING: %q", reply)
+	}
+	return nil
+}
+
+// instream verifica o conteúdo e retorna a assinatura encontrada ("" = limpo)
+func (c *ClamAV) instream(ctx context.Context, content io.Reader) (string, error) {
+	reply, err := c.command(ctx, "INSTREAM", content)
+	if err != nil {
+		return "", err
+	}
+
+	// "stream: OK", "stream: Eicar-Signature FOUND" ou "... ERROR"
+	reply = strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case reply == "OK":
+		return "", nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return strings.TrimSuffix(reply, " FOUND"), nil
+	default:
+		return "", fmt.Errorf("clamav: %s", reply)
+	}
+}
+
+// command envia um comando (com o conteúdo em blocos, se houver) e lê a
+// resposta terminada em \0
+func (c *ClamAV) command(ctx context.Context, name string, content io.Reader) (string, error) {
+	dialer := net.Dialer{Timeout: c.timeout}
+	conn, err := dialer.DialContext(ctx, c.network, c.address)
+	if err != nil {
+		return "", fmt.Errorf("clamav: conexão: %w", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(c.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	w := bufio.NewWriterSize(conn, clamavChunkSize+4)
+	if _, err := w.WriteString("z" + name + "\x00"); err != nil {
+		return "", fmt.Errorf("clamav: envio: %w", err)
+	}
+	if content != nil {
+		if err := writeChunks(w, content); err != nil {
+			return "", err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return "", fmt.Errorf("clamav: envio: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && !(errors.Is(err, io.EOF) && reply != "") {
+		return "", fmt.Errorf("clamav: resposta: %w", err)
+	}
+	return strings.TrimRight(reply, "\x00\n"), nil
+}
+
+// writeChunks envia o conteúdo em blocos [tamanho][dados], terminando com
+// um bloco de tamanho zero
+func writeChunks(w io.Writer, content io.Reader) error {
+	buf := make([]byte, clamavChunkSize)
+	size := make([]byte, 4)
+	for {
+		n, err := content.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, werr := w.Write(size); werr != nil {
+				return fmt.Errorf("clamav: envio: %w", werr)
+			}
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return fmt.Errorf("clamav: envio: %w", werr)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("clamav: leitura do arquivo: %w", err)
+		}
+	}
+	binary.BigEndian.PutUint32(size, 0)
+	if _, err := w.Write(size); err != nil {
+		return fmt.Errorf("clamav: envio: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+// =============================================================================
+// FAMLI - Verificação de Vírus dos Anexos
+// =============================================================================
+// Todo arquivo enviado por um usuário passa por um Scanner antes de ficar
+// disponível. O resultado decide o estado do arquivo:
+//
+// - clean: liberado
+// - infected: em quarentena (nunca é servido; só administradores veem o
+//   resultado, com a assinatura encontrada)
+// - failed: em quarentena até uma nova verificação (o antivírus não
+//   respondeu; na dúvida, o arquivo não é liberado)
+// - skipped: verificação desligada (ATTACHMENT_SCAN_DISABLED, apenas em
+//   desenvolvimento) ou sem antivírus configurado
+//
+// Drivers:
+// - ClamAV (clamd via TCP ou socket unix, comando INSTREAM): CLAMAV_ADDRESS
+// - Disabled: não verifica nada
+// =============================================================================
+
+package scan
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Status é o resultado da verificação de um arquivo
+type Status string
+
+const (
+	StatusClean    Status = "clean"    // Nenhuma ameaça encontrada
+	StatusInfected Status = "infected" // Ameaça encontrada (quarentena)
+	StatusFailed   Status = "failed"   // Erro na verificação (quarentena)
+	StatusSkipped  Status = "skipped"  // Verificação desligada
+)
+
+// Result é o resultado da verificação de um arquivo
+type Result struct {
+	Status    Status    `json:"status"`
+	Signature string    `json:"signature,omitempty"` // Ameaça encontrada (ex: "Win.Test.EICAR_HDB-1")
+	Engine    string    `json:"engine"`              // Driver que verificou (ex: "clamav")
+	Error     string    `json:"error,omitempty"`     // Motivo da falha (status failed)
+	ScannedAt time.Time `json:"scanned_at"`
+}
+
+// Quarantined indica se o arquivo deve ficar em quarentena
+func (r Result) Quarantined() bool {
+	return r.Status == StatusInfected || r.Status == StatusFailed
+}
+
+// Scanner verifica arquivos enviados
+type Scanner interface {
+	// Name identifica o driver (ex: "clamav", "disabled")
+	Name() string
+
+	// Scan verifica o conteúdo. Falhas de comunicação não retornam erro:
+	// viram um Result com StatusFailed, para o arquivo ir para a quarentena.
+	Scan(ctx context.Context, content io.Reader) Result
+
+	// Ping verifica se o antivírus está respondendo
+	Ping(ctx context.Context) error
+}
+
+// Config escolhe e configura o driver
+type Config struct {
+	Disabled      bool          // Desliga a verificação (desenvolvimento)
+	ClamAVAddress string        // clamd: tcp://host:3310, host:3310 ou unix:///caminho
+	Timeout       time.Duration // Tempo máximo de cada verificação
+}
+
+// New cria o Scanner da configuração
+// Sem endereço do ClamAV (ou com Disabled), retorna um Scanner desligado.
+func New(config Config) Scanner {
+	if config.Disabled || config.ClamAVAddress == "" {
+		return Disabled{}
+	}
+	return NewClamAV(config.ClamAVAddress, config.Timeout)
+}
+
+// =============================================================================
+// DESLIGADO
+// =============================================================================
+
+// Disabled não verifica os arquivos (resultado skipped)
+type Disabled struct{}
+
+// Name implementa Scanner
+func (Disabled) Name() string { return "disabled" }
+
+// Scan implementa Scanner
+func (Disabled) Scan(ctx context.Context, content io.Reader) Result {
+	return Result{Status: StatusSkipped, Engine: "disabled", ScannedAt: time.Now().UTC()}
+}
+
+// Ping implementa Scanner
+func (Disabled) Ping(ctx context.Context) error { return nil }
@@ -36,13 +36,29 @@ type AuditEventType string
 
 const (
 	// Autenticação
-	EventLoginSuccess   AuditEventType = "LOGIN_SUCCESS"
-	EventLoginFailed    AuditEventType = "LOGIN_FAILED"
-	EventLogout         AuditEventType = "LOGOUT"
-	EventRegister       AuditEventType = "REGISTER"
-	EventPasswordChange AuditEventType = "PASSWORD_CHANGE"
-	EventPasswordReset  AuditEventType = "PASSWORD_RESET"
-	EventSessionExpired AuditEventType = "SESSION_EXPIRED"
+	EventLoginSuccess    AuditEventType = "LOGIN_SUCCESS"
+	EventLoginFailed     AuditEventType = "LOGIN_FAILED"
+	EventLogout          AuditEventType = "LOGOUT"
+	EventRegister        AuditEventType = "REGISTER"
+	EventPasswordChange  AuditEventType = "PASSWORD_CHANGE"
+	EventPasswordReset   AuditEventType = "PASSWORD_RESET"
+	EventSessionExpired  AuditEventType = "SESSION_EXPIRED"
+	EventAccountLocked   AuditEventType = "ACCOUNT_LOCKED"
+	EventLoginNewDevice  AuditEventType = "LOGIN_NEW_DEVICE"
+	EventAccountDisabled AuditEventType = "ACCOUNT_DISABLED" // Desativada pelo admin
+	EventAccountEnabled  AuditEventType = "ACCOUNT_ENABLED"  // Reativada pelo admin
+	EventRoleGranted     AuditEventType = "ROLE_GRANTED"     // Papel administrativo concedido
+	EventRoleRevoked     AuditEventType = "ROLE_REVOKED"     // Papel administrativo removido
+	EventSessionRevoked  AuditEventType = "SESSION_REVOKED"  // Sessão de um dispositivo encerrada
+	EventAPIKeyCreated   AuditEventType = "API_KEY_CREATED"  // Chave pessoal de API criada
+	EventAPIKeyRevoked   AuditEventType = "API_KEY_REVOKED"  // Chave pessoal de API revogada
+
+	// Acesso do suporte (sessão somente leitura com consentimento do usuário)
+	EventSupportAccessGranted AuditEventType = "SUPPORT_ACCESS_GRANTED" // Autorizado pelo usuário (24h)
+	EventSupportAccessRevoked AuditEventType = "SUPPORT_ACCESS_REVOKED" // Revogado pelo usuário
+	EventImpersonationStarted AuditEventType = "IMPERSONATION_STARTED"  // Admin assumiu a sessão do usuário
+	EventImpersonationEnded   AuditEventType = "IMPERSONATION_ENDED"    // Admin voltou à própria sessão
+	EventImpersonatedRequest  AuditEventType = "IMPERSONATED_REQUEST"   // Requisição feita pelo admin como o usuário
 
 	// Acesso a dados
 	EventDataAccess      AuditEventType = "DATA_ACCESS"
@@ -57,6 +73,43 @@ const (
 	EventSuspiciousActivity AuditEventType = "SUSPICIOUS_ACTIVITY"
 	EventTokenInvalid       AuditEventType = "TOKEN_INVALID"
 
+	// Administração
+	EventFeatureFlagChanged    AuditEventType = "FEATURE_FLAG_CHANGED"
+	EventGuideCardChanged      AuditEventType = "GUIDE_CARD_CHANGED"
+	EventRetentionChanged      AuditEventType = "RETENTION_POLICY_CHANGED"
+	EventRetentionPurged       AuditEventType = "RETENTION_PURGED"        // Execução manual da retenção
+	EventAdminExport           AuditEventType = "ADMIN_EXPORT"            // Métricas exportadas pelo painel (CSV/JSON)
+	EventStatusIncidentChanged AuditEventType = "STATUS_INCIDENT_CHANGED" // Nota da página de status publicada/alterada
+
+	// Integrações
+	EventWebhookChanged AuditEventType = "WEBHOOK_CHANGED"
+
+	// Famílias (caixas compartilhadas)
+	EventHouseholdChanged AuditEventType = "HOUSEHOLD_CHANGED"
+
+	// Assinaturas (Stripe)
+	EventSubscriptionChanged AuditEventType = "SUBSCRIPTION_CHANGED"
+
+	// Portal do guardião
+	EventGuardianAccountLinked   AuditEventType = "GUARDIAN_ACCOUNT_LINKED"
+	EventGuardianAccountUnlinked AuditEventType = "GUARDIAN_ACCOUNT_UNLINKED"
+	EventGuardianTokenRotated    AuditEventType = "GUARDIAN_TOKEN_ROTATED"         // Novo link gerado pelo dono
+	EventGuardianAccessDisabled  AuditEventType = "GUARDIAN_ACCESS_DISABLED"       // Acesso desativado (ou reativado) pelo dono
+	EventGuardianPolicyChanged   AuditEventType = "GUARDIAN_ACCESS_POLICY_CHANGED" // Regras de acesso (prazo, horário, limite) alteradas pelo dono
+	EventGuardianAccessBlocked   AuditEventType = "GUARDIAN_ACCESS_BLOCKED"        // Acesso barrado pelas regras do dono
+	EventGuardianMessageSent     AuditEventType = "GUARDIAN_MESSAGE_SENT"          // Recado do dono às pessoas de confiança
+	EventEscalationChanged       AuditEventType = "EMERGENCY_ESCALATION_CHANGED"   // Lista de escalonamento alterada (ou escalonamento encerrado) pelo dono
+	EventEmergencyAcknowledged   AuditEventType = "EMERGENCY_ACKNOWLEDGED"         // Pessoa da lista confirmou o aviso de emergência
+	EventMemorialConfigChanged   AuditEventType = "MEMORIAL_CONFIG_CHANGED"        // Quem libera o memorial alterado (ou pedido cancelado) pelo dono
+	EventMemorialActivation      AuditEventType = "MEMORIAL_ACTIVATION"            // Pedido, confirmação ou liberação do memorial
+
+	// PIN dos links de acesso (força bruta)
+	EventPINFailed             AuditEventType = "PIN_FAILED"
+	EventAccessLinkDeactivated AuditEventType = "ACCESS_LINK_DEACTIVATED"
+
+	// Envio avulso de um item por email (envio e acesso único)
+	EventItemSent AuditEventType = "ITEM_SENT"
+
 	// WhatsApp
 	EventWhatsAppLink            AuditEventType = "WHATSAPP_LINK"
 	EventWhatsAppUnlink          AuditEventType = "WHATSAPP_UNLINK"
@@ -64,8 +117,17 @@ const (
 	EventWhatsAppWebhookReceived AuditEventType = "WHATSAPP_WEBHOOK_RECEIVED"
 
 	// LGPD - Direitos do Titular
-	EventAccountDeletion AuditEventType = "ACCOUNT_DELETION" // Direito ao esquecimento
-	EventDataExport      AuditEventType = "DATA_EXPORT"      // Direito à portabilidade
+	EventAccountDeletion   AuditEventType = "ACCOUNT_DELETION"   // Direito ao esquecimento
+	EventDataExport        AuditEventType = "DATA_EXPORT"        // Direito à portabilidade
+	EventDataRectification AuditEventType = "DATA_RECTIFICATION" // Direito à correção (nome, email)
+	EventTermsAccepted     AuditEventType = "TERMS_ACCEPTED"     // Aceite dos termos e da política (com as versões)
+
+	// Remoção pedida pelo próprio guardião (apagado após a carência)
+	EventGuardianDataDeletion AuditEventType = "GUARDIAN_DATA_DELETION"
+
+	// Atividade do painel admin (gravados também em audit_log)
+	EventEmergencyActivated AuditEventType = "EMERGENCY_ACTIVATED" // Primeiro acesso a um link de emergência
+	EventFeedbackCreated    AuditEventType = "FEEDBACK_CREATED"
 )
 
 // AuditSeverity define a severidade do evento
@@ -117,6 +179,9 @@ type AuditEvent struct {
 	// Detalhes adicionais (não incluir dados sensíveis!)
 	Details map[string]interface{} `json:"details,omitempty"`
 
+	// Localização aproximada do IP (com GeoIP configurado)
+	Location *GeoLocation `json:"location,omitempty"`
+
 	// Request ID para correlação
 	RequestID string `json:"request_id,omitempty"`
 }
@@ -144,8 +209,15 @@ type AuditLogger struct {
 
 	// lastReset é quando os contadores foram resetados
 	lastReset time.Time
+
+	// sink grava os eventos fora da memória (ex: audit_log)
+	sink AuditSink
 }
 
+// AuditSink recebe cada evento registrado e escolhe o que gravar
+// Chamado de forma síncrona, depois do log e fora do lock.
+type AuditSink func(event AuditEvent)
+
 // NewAuditLogger cria um novo logger de auditoria
 func NewAuditLogger() *AuditLogger {
 	al := &AuditLogger{
@@ -176,8 +248,12 @@ func NewAuditLogger() *AuditLogger {
 // Parâmetros:
 //   - event: evento a ser registrado
 func (al *AuditLogger) Log(event AuditEvent) {
+	// Localização do IP (fora do lock; o IP é mascarado só na saída)
+	if event.Location == nil && event.ClientIP != "" {
+		event.Location = LookupLocation(event.ClientIP, "pt-BR")
+	}
+
 	al.mu.Lock()
-	defer al.mu.Unlock()
 
 	// Adicionar timestamp se não definido
 	if event.Timestamp.IsZero() {
@@ -207,12 +283,30 @@ func (al *AuditLogger) Log(event AuditEvent) {
 
 	// Log para saída padrão (em produção, enviar para sistema centralizado)
 	al.logToOutput(event)
+
+	sink := al.sink
+	al.mu.Unlock()
+
+	// Gravação persistente (fora do lock: pode ir ao banco)
+	if sink != nil {
+		sink(event)
+	}
+}
+
+// SetSink define onde os eventos são gravados além do log (nil = só o log)
+func (al *AuditLogger) SetSink(sink AuditSink) {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	al.sink = sink
 }
 
 // LogAuth registra evento de autenticação
 func (al *AuditLogger) LogAuth(eventType AuditEventType, userID, clientIP, userAgent, result string, details map[string]interface{}) {
 	severity := SeverityInfo
-	if eventType == EventLoginFailed || eventType == EventUnauthorizedAccess {
+	if eventType == EventLoginFailed || eventType == EventUnauthorizedAccess ||
+		eventType == EventAccountLocked || eventType == EventLoginNewDevice ||
+		eventType == EventAccountDisabled || eventType == EventRoleGranted ||
+		eventType == EventSessionRevoked || eventType == EventImpersonationStarted {
 		severity = SeverityWarning
 	}
 
@@ -516,17 +610,7 @@ func maskIP(value string) string {
 // generateEventID gera um ID único para o evento
 func generateEventID() string {
 	now := time.Now()
-	return now.Format("20060102150405") + "-" + randomString(6)
-}
-
-// randomString gera string aleatória
-func randomString(n int) string {
-	const letters = "abcdefghijklmnopqrstuvwxyz0123456789"
-	b := make([]byte, n)
-	for i := range b {
-		b[i] = letters[time.Now().UnixNano()%int64(len(letters))]
-	}
-	return string(b)
+	return now.Format("20060102150405") + "-" + mustRandomString(6, AlphabetLowerAlphanumeric)
 }
 
 // =============================================================================
@@ -0,0 +1,165 @@
+// =============================================================================
+// FAMLI - Limites do Corpo das Requisições
+// =============================================================================
+// Protege contra corpos enormes e JSON aninhado demais (OWASP A04), antes de
+// qualquer handler:
+//
+// - Cada rota tem um limite de bytes (padrão DefaultBodyLimit); acima dele a
+//   resposta é 413 REQUEST_BODY_TOO_LARGE
+// - O corpo é lido por inteiro aqui; JSON com mais de MaxDepth níveis de
+//   objetos/listas responde 400 REQUEST_JSON_TOO_DEEP
+//
+// Os limites por rota usam o padrão do chi ("PUT /api/box/items/{itemID}"),
+// resolvido pela função route passada ao middleware.
+//
+// DecodeJSONStrict decodifica recusando campos desconhecidos, para os
+// endpoints em que um campo com nome errado não deve ser ignorado em silêncio.
+// =============================================================================
+
+package security
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Limites padrão
+const (
+	DefaultBodyLimit = 64 * 1024 // 64KB
+	DefaultJSONDepth = 20
+)
+
+// BodyLimits configura o BodyLimitMiddleware
+type BodyLimits struct {
+	// Default é o limite de bytes das rotas sem limite próprio
+	Default int64
+
+	// Routes são os limites por rota: "MÉTODO padrão" → bytes
+	Routes map[string]int64
+
+	// MaxDepth é o aninhamento máximo de objetos e listas JSON
+	MaxDepth int
+}
+
+// Limit retorna o limite de bytes da rota
+func (l BodyLimits) Limit(method, pattern string) int64 {
+	if limit, ok := l.Routes[method+" "+pattern]; ok {
+		return limit
+	}
+	if l.Default > 0 {
+		return l.Default
+	}
+	return DefaultBodyLimit
+}
+
+// BodyLimitMiddleware aplica o limite de bytes e de aninhamento JSON
+// route retorna o padrão da rota da requisição ("" se não houver).
+func BodyLimitMiddleware(limits BodyLimits, route func(r *http.Request) string) func(http.Handler) http.Handler {
+	maxDepth := limits.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultJSONDepth
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			limit := limits.Limit(r.Method, route(r))
+			if r.ContentLength > limit {
+				writeError(w, r, http.StatusRequestEntityTooLarge, "request.body_too_large")
+				return
+			}
+
+			data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					writeError(w, r, http.StatusRequestEntityTooLarge, "request.body_too_large")
+				} else {
+					writeError(w, r, http.StatusBadRequest, "request.invalid_body")
+				}
+				return
+			}
+			if jsonDepth(data) > maxDepth {
+				writeError(w, r, http.StatusBadRequest, "request.json_too_deep")
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(data))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// jsonDepth retorna o maior aninhamento de objetos/listas no corpo
+// Não valida o JSON (isso fica com o handler); colchetes dentro de strings
+// não contam.
+func jsonDepth(data []byte) int {
+	depth, deepest := 0, 0
+	inString, escaped := false, false
+	for _, c := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > deepest {
+				deepest = depth
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+	return deepest
+}
+
+// =============================================================================
+// DECODIFICAÇÃO ESTRITA
+// =============================================================================
+
+// UnknownFieldError indica um campo JSON que o endpoint não aceita
+type UnknownFieldError struct {
+	Field string
+}
+
+func (e *UnknownFieldError) Error() string {
+	return fmt.Sprintf("campo desconhecido: %s", e.Field)
+}
+
+// DecodeJSONStrict decodifica um único valor JSON em v
+// Campos desconhecidos retornam *UnknownFieldError; dados após o valor
+// também são recusados.
+func DecodeJSONStrict(body io.Reader, v interface{}) error {
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		// encoding/json não exporta o erro de campo desconhecido
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			return &UnknownFieldError{Field: strings.Trim(field, `"`)}
+		}
+		return err
+	}
+	if decoder.More() {
+		return errors.New("dados após o JSON")
+	}
+	return nil
+}
@@ -26,6 +26,13 @@ func CSRFMiddleware(allowedOrigins []string, allowMissing bool) func(http.Handle
 				return
 			}
 
+			// Token Bearer: o navegador nao envia o header sozinho, entao
+			// nao ha credencial para outro site abusar (apps moveis)
+			if hasBearerToken(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			origin := normalizeOrigin(r.Header.Get("Origin"))
 			if origin == "" {
 				origin = normalizeOrigin(refererOrigin(r.Header.Get("Referer")))
@@ -36,12 +43,12 @@ func CSRFMiddleware(allowedOrigins []string, allowMissing bool) func(http.Handle
 					next.ServeHTTP(w, r)
 					return
 				}
-				http.Error(w, `{"error":"CSRF check failed"}`, http.StatusForbidden)
+				writeError(w, r, http.StatusForbidden, "security.csrf_failed")
 				return
 			}
 
 			if origin == "null" {
-				http.Error(w, `{"error":"CSRF check failed"}`, http.StatusForbidden)
+				writeError(w, r, http.StatusForbidden, "security.csrf_failed")
 				return
 			}
 
@@ -51,11 +58,18 @@ func CSRFMiddleware(allowedOrigins []string, allowMissing bool) func(http.Handle
 				return
 			}
 
-			http.Error(w, `{"error":"CSRF check failed"}`, http.StatusForbidden)
+			writeError(w, r, http.StatusForbidden, "security.csrf_failed")
 		})
 	}
 }
 
+// hasBearerToken indica se a requisicao se autentica pelo header Authorization
+// O JWTMiddleware da precedencia ao header sobre o cookie.
+func hasBearerToken(r *http.Request) bool {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	return ok && strings.EqualFold(scheme, "Bearer") && strings.TrimSpace(token) != ""
+}
+
 func isUnsafeMethod(method string) bool {
 	switch method {
 	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
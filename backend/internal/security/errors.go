@@ -0,0 +1,39 @@
+// =============================================================================
+// FAMLI - Respostas de Erro dos Middlewares
+// =============================================================================
+// Os middlewares deste pacote (rate limit, CSRF) rodam antes dos handlers e
+// não podem importar internal/apierror (que depende do storage, que depende
+// deste pacote). O servidor instala o formato padrão da API com
+// SetErrorWriter(apierror.Write); sem ele, a resposta é {"error": "..."}.
+// =============================================================================
+
+package security
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+
+	"famli/internal/i18n"
+)
+
+// ErrorWriter escreve uma resposta de erro a partir da chave de tradução
+type ErrorWriter func(w http.ResponseWriter, r *http.Request, status int, key string)
+
+var errorWriter atomic.Value // ErrorWriter
+
+// SetErrorWriter define como os middlewares escrevem erros
+func SetErrorWriter(fn ErrorWriter) {
+	errorWriter.Store(fn)
+}
+
+// writeError responde com o erro da chave key
+func writeError(w http.ResponseWriter, r *http.Request, status int, key string) {
+	if fn, ok := errorWriter.Load().(ErrorWriter); ok && fn != nil {
+		fn(w, r, status, key)
+		return
+	}
+	SetJSONHeaders(w)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": i18n.Tr(r, key)})
+}
@@ -0,0 +1,141 @@
+// =============================================================================
+// FAMLI - Contexto de Login (país e dispositivo)
+// =============================================================================
+// Funções para identificar de onde e de qual dispositivo vem uma requisição,
+// usadas na detecção de logins suspeitos (novo dispositivo / novo país) e
+// no enriquecimento dos eventos de analytics (país e classe do dispositivo).
+//
+// País: obtido dos headers de geolocalização adicionados pelo CDN/proxy
+// (Cloudflare, CloudFront, Vercel, Fastly ou um proxy próprio).
+// Dispositivo: hash do User-Agent sem números de versão, para que
+// atualizações do navegador não sejam tratadas como um novo dispositivo.
+// =============================================================================
+
+package security
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// countryHeaders são os headers de país conhecidos, em ordem de prioridade
+var countryHeaders = []string{
+	"CF-IPCountry",               // Cloudflare
+	"CloudFront-Viewer-Country",  // AWS CloudFront
+	"X-Vercel-IP-Country",        // Vercel
+	"Fastly-Client-Country-Code", // Fastly (via VCL)
+	"X-Country-Code",             // Proxy próprio
+}
+
+// GetClientCountry retorna o código ISO do país do cliente (ex: "BR")
+// Retorna string vazia se o país não puder ser determinado
+func GetClientCountry(r *http.Request) string {
+	for _, header := range countryHeaders {
+		code := strings.ToUpper(strings.TrimSpace(r.Header.Get(header)))
+		// "XX" e "T1" são usados pelo Cloudflare para desconhecido/Tor
+		if len(code) == 2 && code != "XX" && code != "T1" && isASCIILetters(code) {
+			return code
+		}
+	}
+	return ""
+}
+
+// DeviceHash retorna um identificador estável do dispositivo a partir do User-Agent
+// Retorna string vazia se o User-Agent estiver ausente
+func DeviceHash(userAgent string) string {
+	normalized := normalizeUserAgent(userAgent)
+	if normalized == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// DescribeDevice retorna uma descrição amigável do dispositivo (ex: "Chrome · Windows")
+func DescribeDevice(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+
+	browser := "Navegador"
+	switch {
+	case strings.Contains(ua, "edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "opr/") || strings.Contains(ua, "opera"):
+		browser = "Opera"
+	case strings.Contains(ua, "firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "chrome/") || strings.Contains(ua, "crios/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "safari/"):
+		browser = "Safari"
+	}
+
+	system := ""
+	switch {
+	case strings.Contains(ua, "android"):
+		system = "Android"
+	case strings.Contains(ua, "iphone") || strings.Contains(ua, "ipad"):
+		system = "iOS"
+	case strings.Contains(ua, "windows"):
+		system = "Windows"
+	case strings.Contains(ua, "mac os"):
+		system = "macOS"
+	case strings.Contains(ua, "linux"):
+		system = "Linux"
+	}
+
+	if system == "" {
+		return browser
+	}
+	return browser + " · " + system
+}
+
+// Classes de dispositivo retornadas por DeviceClass
+const (
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceDesktop = "desktop"
+	DeviceBot     = "bot"
+)
+
+// DeviceClass classifica o dispositivo pelo User-Agent (mobile, tablet, desktop ou bot)
+// Retorna string vazia se o User-Agent estiver ausente
+func DeviceClass(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	switch {
+	case strings.TrimSpace(ua) == "":
+		return ""
+	case strings.Contains(ua, "bot") || strings.Contains(ua, "spider") || strings.Contains(ua, "crawl"):
+		return DeviceBot
+	case strings.Contains(ua, "ipad") || strings.Contains(ua, "tablet") ||
+		(strings.Contains(ua, "android") && !strings.Contains(ua, "mobile")):
+		return DeviceTablet
+	case strings.Contains(ua, "mobi") || strings.Contains(ua, "iphone") || strings.Contains(ua, "android"):
+		return DeviceMobile
+	default:
+		return DeviceDesktop
+	}
+}
+
+// normalizeUserAgent remove números de versão e espaços extras do User-Agent
+func normalizeUserAgent(userAgent string) string {
+	var b strings.Builder
+	for _, c := range strings.ToLower(userAgent) {
+		if (c >= '0' && c <= '9') || c == '.' || c == '_' {
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// isASCIILetters verifica se a string contém apenas letras A-Z
+func isASCIILetters(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 'A' || s[i] > 'Z' {
+			return false
+		}
+	}
+	return true
+}
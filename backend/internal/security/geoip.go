@@ -0,0 +1,140 @@
+// =============================================================================
+// FAMLI - Geolocalização por IP (GeoIP)
+// =============================================================================
+// Converte o IP de quem acessa em país e cidade usando uma base MaxMind
+// (GeoLite2-City ou GeoIP2-City) indicada em GEOIP_DB_PATH. Usado nos acessos
+// a links compartilhados ("acessado de São Paulo, Brasil") e na auditoria.
+//
+// Sem base configurada as buscas retornam nil e nada é registrado.
+// IPs privados e de loopback não são consultados.
+// =============================================================================
+
+package security
+
+import (
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+// GeoLocation é a localização aproximada de um IP
+type GeoLocation struct {
+	Country     string `json:"country"`                // Código ISO (ex: "BR")
+	CountryName string `json:"country_name,omitempty"` // No idioma da consulta (ex: "Brasil")
+	City        string `json:"city,omitempty"`         // No idioma da consulta (ex: "São Paulo")
+}
+
+// String formata a localização para exibição ("São Paulo, Brasil")
+func (l *GeoLocation) String() string {
+	if l == nil {
+		return ""
+	}
+	country := l.CountryName
+	if country == "" {
+		country = l.Country
+	}
+	if l.City == "" {
+		return country
+	}
+	return l.City + ", " + country
+}
+
+// GeoIP consulta a base MaxMind carregada em memória
+type GeoIP struct {
+	db *mmdb
+}
+
+// OpenGeoIP carrega a base MaxMind do arquivo
+func OpenGeoIP(path string) (*GeoIP, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	db, err := parseMMDB(buf)
+	if err != nil {
+		return nil, err
+	}
+	return &GeoIP{db: db}, nil
+}
+
+// Lookup retorna a localização do IP com nomes no idioma do locale
+// (pt-BR ou en). Retorna nil se o IP não estiver na base.
+func (g *GeoIP) Lookup(ip, locale string) *GeoLocation {
+	if g == nil {
+		return nil
+	}
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil || parsed.IsPrivate() || parsed.IsLoopback() || parsed.IsUnspecified() {
+		return nil
+	}
+
+	value, err := g.db.lookup(parsed)
+	record, ok := value.(map[string]interface{})
+	if err != nil || !ok {
+		return nil
+	}
+
+	country, _ := record["country"].(map[string]interface{})
+	if country == nil {
+		country, _ = record["registered_country"].(map[string]interface{})
+	}
+	code, _ := country["iso_code"].(string)
+	if code == "" {
+		return nil
+	}
+
+	city, _ := record["city"].(map[string]interface{})
+	lang := geoLanguage(locale)
+	return &GeoLocation{
+		Country:     code,
+		CountryName: geoName(country, lang),
+		City:        geoName(city, lang),
+	}
+}
+
+// geoLanguage converte o locale da aplicação no idioma dos nomes da base
+// (pt-BR é o padrão da aplicação, inclusive para locale vazio)
+func geoLanguage(locale string) string {
+	switch {
+	case strings.HasPrefix(strings.ToLower(locale), "en"):
+		return "en"
+	case strings.HasPrefix(strings.ToLower(locale), "es"):
+		return "es"
+	}
+	return "pt-BR"
+}
+
+// geoName retorna o nome no idioma pedido, com fallback para inglês
+func geoName(entry map[string]interface{}, lang string) string {
+	names, _ := entry["names"].(map[string]interface{})
+	if name, ok := names[lang].(string); ok && name != "" {
+		return name
+	}
+	name, _ := names["en"].(string)
+	return name
+}
+
+// =============================================================================
+// INSTÂNCIA GLOBAL
+// =============================================================================
+
+var (
+	globalGeoIP   *GeoIP
+	globalGeoIPMu sync.RWMutex
+)
+
+// SetGeoIP define a base usada por LookupLocation (nil desabilita)
+func SetGeoIP(g *GeoIP) {
+	globalGeoIPMu.Lock()
+	defer globalGeoIPMu.Unlock()
+	globalGeoIP = g
+}
+
+// LookupLocation consulta a base global (nil sem base ou IP desconhecido)
+func LookupLocation(ip, locale string) *GeoLocation {
+	globalGeoIPMu.RLock()
+	g := globalGeoIP
+	globalGeoIPMu.RUnlock()
+	return g.Lookup(ip, locale)
+}
@@ -0,0 +1,283 @@
+// =============================================================================
+// FAMLI - Markdown do conteúdo dos itens
+// =============================================================================
+// O conteúdo dos itens aceita um subconjunto de Markdown (negrito, itálico,
+// listas, citações, títulos, código e links). O texto é guardado como
+// Markdown e o HTML é gerado no servidor apenas quando pedido (?render=html).
+//
+// Política de saída (no estilo do bluemonday):
+// - Todo texto é escapado; HTML digitado pelo usuário nunca é repassado
+// - Apenas p, br, strong, em, code, pre, ul, ol, li, blockquote, h1-h6, hr e a
+// - Links apenas http, https e mailto, sempre com rel="nofollow noopener"
+//
+// OWASP A03:2021 – Injection (XSS)
+// =============================================================================
+
+package security
+
+import (
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// allowedLinkSchemes são os únicos esquemas aceitos em links
+var allowedLinkSchemes = map[string]bool{
+	"http":   true,
+	"https":  true,
+	"mailto": true,
+}
+
+const (
+	maxQuoteDepth  = 3 // Citações aninhadas além disso viram texto
+	maxInlineDepth = 4 // Negrito/itálico/links aninhados além disso viram texto
+)
+
+var (
+	headingPattern     = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	hrPattern          = regexp.MustCompile(`^ {0,3}((-[ ]*){3,}|(\*[ ]*){3,}|(_[ ]*){3,})$`)
+	bulletPattern      = regexp.MustCompile(`^ {0,3}[-*+]\s+(.*)$`)
+	orderedPattern     = regexp.MustCompile(`^ {0,3}\d{1,9}[.)]\s+(.*)$`)
+	quotePattern       = regexp.MustCompile(`^ {0,3}> ?(.*)$`)
+	fencePattern       = regexp.MustCompile("^ {0,3}(```|~~~)")
+	dangerousBlockHTML = regexp.MustCompile(`(?is)<(script|style|iframe|object|embed|template)\b.*?(</\s*\w+\s*>|$)`)
+	htmlTagPattern     = regexp.MustCompile(`(?s)<!--.*?-->|</?[a-zA-Z][a-zA-Z0-9-]*(\s[^<>]*)?/?>`)
+)
+
+// WantsRenderedHTML indica se o cliente pediu o conteúdo em HTML (?render=html)
+func WantsRenderedHTML(r *http.Request) bool {
+	return r.URL.Query().Get("render") == "html"
+}
+
+// stripHTMLTags remove tags HTML do Markdown, mantendo o texto
+//
+// Blocos perigosos (script, style, iframe...) são removidos com o conteúdo.
+// Sinais soltos como "a < b" ou "<https://...>" não são tags e ficam.
+func stripHTMLTags(s string) string {
+	s = dangerousBlockHTML.ReplaceAllString(s, "")
+	return htmlTagPattern.ReplaceAllString(s, "")
+}
+
+// RenderMarkdown converte o conteúdo de um item em HTML seguro
+//
+// Conteúdo salvo antes do suporte a Markdown está escapado (&gt;, &amp;);
+// por isso o texto é desescapado antes, e escapado de novo na saída.
+func RenderMarkdown(content string) string {
+	content = html.UnescapeString(content)
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = strings.ReplaceAll(content, "\r", "\n")
+	return renderBlocks(strings.Split(content, "\n"), 0)
+}
+
+// renderBlocks renderiza parágrafos, títulos, listas, citações e código
+func renderBlocks(lines []string, depth int) string {
+	var b strings.Builder
+
+	for i := 0; i < len(lines); {
+		line := lines[i]
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			i++
+
+		case fencePattern.MatchString(line):
+			fence := fencePattern.FindStringSubmatch(line)[1]
+			i++
+			var code []string
+			for i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+				code = append(code, lines[i])
+				i++
+			}
+			i++ // Fechamento (ou fim do texto)
+			b.WriteString("<pre><code>")
+			b.WriteString(html.EscapeString(strings.Join(code, "\n")))
+			b.WriteString("</code></pre>\n")
+
+		case headingPattern.MatchString(line):
+			m := headingPattern.FindStringSubmatch(line)
+			tag := "h" + string(rune('0'+len(m[1])))
+			b.WriteString("<" + tag + ">" + renderInline(m[2], 0) + "</" + tag + ">\n")
+			i++
+
+		case hrPattern.MatchString(line):
+			b.WriteString("<hr>\n")
+			i++
+
+		case quotePattern.MatchString(line) && depth < maxQuoteDepth:
+			var quoted []string
+			for i < len(lines) && quotePattern.MatchString(lines[i]) {
+				quoted = append(quoted, quotePattern.FindStringSubmatch(lines[i])[1])
+				i++
+			}
+			b.WriteString("<blockquote>\n")
+			b.WriteString(renderBlocks(quoted, depth+1))
+			b.WriteString("</blockquote>\n")
+
+		case bulletPattern.MatchString(line):
+			i = renderList(&b, lines, i, "ul", bulletPattern)
+
+		case orderedPattern.MatchString(line):
+			i = renderList(&b, lines, i, "ol", orderedPattern)
+
+		default:
+			var para []string
+			for i < len(lines) && strings.TrimSpace(lines[i]) != "" && (len(para) == 0 || !startsBlock(lines[i])) {
+				para = append(para, renderInline(strings.TrimSpace(lines[i]), 0))
+				i++
+			}
+			b.WriteString("<p>" + strings.Join(para, "<br>\n") + "</p>\n")
+		}
+	}
+
+	return b.String()
+}
+
+// renderList renderiza itens consecutivos de uma lista e retorna a próxima linha
+func renderList(b *strings.Builder, lines []string, i int, tag string, pattern *regexp.Regexp) int {
+	b.WriteString("<" + tag + ">\n")
+	for i < len(lines) && pattern.MatchString(lines[i]) {
+		item := pattern.FindStringSubmatch(lines[i])[1]
+		b.WriteString("<li>" + renderInline(strings.TrimSpace(item), 0) + "</li>\n")
+		i++
+	}
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// startsBlock indica se a linha interrompe um parágrafo
+func startsBlock(line string) bool {
+	return fencePattern.MatchString(line) || headingPattern.MatchString(line) ||
+		hrPattern.MatchString(line) || quotePattern.MatchString(line) ||
+		bulletPattern.MatchString(line) || orderedPattern.MatchString(line)
+}
+
+// renderInline renderiza código, links, negrito e itálico dentro de uma linha
+func renderInline(s string, depth int) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case c == '\\' && i+1 < len(s) && strings.IndexByte("\\`*_[]()#+-.!>", s[i+1]) >= 0:
+			i++
+			b.WriteString(html.EscapeString(s[i : i+1]))
+			continue
+
+		case c == '`':
+			if end := strings.IndexByte(s[i+1:], '`'); end >= 0 {
+				b.WriteString("<code>" + html.EscapeString(s[i+1:i+1+end]) + "</code>")
+				i += end + 1
+				continue
+			}
+
+		case c == '[' && depth < maxInlineDepth:
+			if text, href, n, ok := parseLink(s[i:]); ok {
+				if safe, ok := safeLinkURL(href); ok {
+					b.WriteString(`<a href="` + html.EscapeString(safe) + `" rel="nofollow noopener noreferrer" target="_blank">`)
+					b.WriteString(renderInline(text, depth+1))
+					b.WriteString("</a>")
+				} else {
+					b.WriteString(renderInline(text, depth+1))
+				}
+				i += n - 1
+				continue
+			}
+
+		case (c == '*' || c == '_') && depth < maxInlineDepth:
+			if inner, n, strong, ok := parseEmphasis(s, i); ok {
+				tag := "em"
+				if strong {
+					tag = "strong"
+				}
+				b.WriteString("<" + tag + ">" + renderInline(inner, depth+1) + "</" + tag + ">")
+				i += n - 1
+				continue
+			}
+		}
+
+		b.WriteString(html.EscapeString(s[i : i+1]))
+	}
+
+	return b.String()
+}
+
+// parseLink reconhece [texto](url) no início de s
+func parseLink(s string) (text, href string, n int, ok bool) {
+	closeText := strings.IndexByte(s, ']')
+	if closeText < 1 || closeText+1 >= len(s) || s[closeText+1] != '(' {
+		return "", "", 0, false
+	}
+	// Parênteses balanceados na URL: [x](https://pt.wikipedia.org/wiki/A_(b))
+	closeURL, open := -1, 0
+	for j := closeText + 2; j < len(s) && closeURL < 0; j++ {
+		switch s[j] {
+		case '(':
+			open++
+		case ')':
+			if open == 0 {
+				closeURL = j - closeText - 2
+			}
+			open--
+		}
+	}
+	if closeURL < 0 {
+		return "", "", 0, false
+	}
+	text = s[1:closeText]
+	href = strings.TrimSpace(s[closeText+2 : closeText+2+closeURL])
+	return text, href, closeText + 2 + closeURL + 1, true
+}
+
+// safeLinkURL aceita apenas URLs absolutas com esquema permitido
+func safeLinkURL(raw string) (string, bool) {
+	if raw == "" || strings.ContainsAny(raw, " \t\n\"'<>`") {
+		return "", false
+	}
+	u, err := url.Parse(raw)
+	if err != nil || !allowedLinkSchemes[strings.ToLower(u.Scheme)] {
+		return "", false
+	}
+	if u.Scheme != "mailto" && u.Host == "" {
+		return "", false
+	}
+	return u.String(), true
+}
+
+// parseEmphasis reconhece **negrito**, __negrito__, *itálico* e _itálico_
+//
+// O "_" só vale nas bordas de palavra, para não quebrar nomes_com_sublinhado.
+func parseEmphasis(s string, i int) (inner string, n int, strong bool, ok bool) {
+	c := s[i]
+	delim := s[i : i+1]
+	if i+1 < len(s) && s[i+1] == c {
+		delim = s[i : i+2]
+		strong = true
+	}
+
+	if c == '_' && i > 0 && isWordByte(s[i-1]) {
+		return "", 0, false, false
+	}
+
+	start := i + len(delim)
+	if start >= len(s) || s[start] == ' ' {
+		return "", 0, false, false
+	}
+	end := strings.Index(s[start:], delim)
+	if end <= 0 || s[start+end-1] == ' ' {
+		return "", 0, false, false
+	}
+	after := start + end + len(delim)
+	if c == '_' && after < len(s) && isWordByte(s[after]) {
+		return "", 0, false, false
+	}
+
+	return s[start : start+end], after - i, strong, true
+}
+
+// isWordByte indica letras, dígitos ou bytes de caracteres acentuados (UTF-8)
+func isWordByte(c byte) bool {
+	return c >= 0x80 || c == '_' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,323 @@
+// =============================================================================
+// FAMLI - Leitor MaxMind DB
+// =============================================================================
+// Leitura mínima do formato MaxMind DB (.mmdb), usado pelas bases GeoLite2 e
+// GeoIP2. Implementa apenas o necessário para buscar um IP: metadados, árvore
+// de busca (registros de 24, 28 ou 32 bits) e o decodificador da seção de
+// dados. O arquivo inteiro é carregado em memória.
+//
+// Especificação: https://maxmind.github.io/MaxMind-DB/
+// =============================================================================
+
+package security
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"net"
+)
+
+// mmdbMetadataMarker precede a seção de metadados no fim do arquivo
+var mmdbMetadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// errInvalidMMDB indica arquivo corrompido ou em formato desconhecido
+var errInvalidMMDB = errors.New("arquivo MaxMind DB inválido")
+
+// mmdbDataSeparator são os 16 bytes zerados entre a árvore e os dados
+const mmdbDataSeparator = 16
+
+// mmdbMaxDepth limita o aninhamento de mapas/arrays/ponteiros na decodificação
+const mmdbMaxDepth = 32
+
+// Tipos da seção de dados
+const (
+	mmdbExtended = 0
+	mmdbPointer  = 1
+	mmdbString   = 2
+	mmdbDouble   = 3
+	mmdbBytes    = 4
+	mmdbUint16   = 5
+	mmdbUint32   = 6
+	mmdbMap      = 7
+	mmdbInt32    = 8
+	mmdbUint64   = 9
+	mmdbUint128  = 10
+	mmdbArray    = 11
+	mmdbBool     = 14
+	mmdbFloat    = 15
+)
+
+// mmdb é uma base MaxMind DB carregada em memória
+type mmdb struct {
+	buf        []byte
+	data       mmdbDecoder // Seção de dados
+	nodeCount  uint
+	recordSize uint
+	ipVersion  uint
+	ipv4Start  uint // Nó de ::/96, onde começam os IPv4 em bases IPv6
+}
+
+// parseMMDB lê os metadados e prepara a base para buscas
+func parseMMDB(buf []byte) (*mmdb, error) {
+	markerAt := bytes.LastIndex(buf, mmdbMetadataMarker)
+	if markerAt < 0 {
+		return nil, errInvalidMMDB
+	}
+
+	meta, _, err := mmdbDecoder(buf[markerAt+len(mmdbMetadataMarker):]).decode(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	fields, ok := meta.(map[string]interface{})
+	if !ok {
+		return nil, errInvalidMMDB
+	}
+
+	db := &mmdb{
+		buf:        buf,
+		nodeCount:  mmdbUint(fields["node_count"]),
+		recordSize: mmdbUint(fields["record_size"]),
+		ipVersion:  mmdbUint(fields["ip_version"]),
+	}
+	if db.recordSize != 24 && db.recordSize != 28 && db.recordSize != 32 {
+		return nil, errInvalidMMDB
+	}
+	if db.nodeCount == 0 || (db.ipVersion != 4 && db.ipVersion != 6) {
+		return nil, errInvalidMMDB
+	}
+
+	treeSize := db.nodeCount * db.recordSize / 4 // dois registros por nó
+	if treeSize+mmdbDataSeparator > uint(markerAt) {
+		return nil, errInvalidMMDB
+	}
+	db.data = mmdbDecoder(buf[treeSize+mmdbDataSeparator : markerAt])
+
+	if db.ipVersion == 6 {
+		node := uint(0)
+		for i := 0; i < 96 && node < db.nodeCount; i++ {
+			node = db.readNode(node, 0)
+		}
+		db.ipv4Start = node
+	}
+	return db, nil
+}
+
+// lookup retorna o registro do IP (nil se a base não tiver o IP)
+func (db *mmdb) lookup(ip net.IP) (interface{}, error) {
+	bits, node := 128, uint(0)
+	if v4 := ip.To4(); v4 != nil {
+		ip, bits, node = v4, 32, db.ipv4Start
+	} else if db.ipVersion == 4 {
+		return nil, nil
+	}
+
+	for i := 0; i < bits && node < db.nodeCount; i++ {
+		bit := uint(ip[i>>3]>>(7-uint(i&7))) & 1
+		node = db.readNode(node, bit)
+	}
+
+	switch {
+	case node == db.nodeCount: // IP sem registro
+		return nil, nil
+	case node < db.nodeCount:
+		return nil, errInvalidMMDB
+	}
+
+	value, _, err := db.data.decode(node-db.nodeCount-mmdbDataSeparator, 0)
+	return value, err
+}
+
+// readNode retorna o registro esquerdo (bit 0) ou direito (bit 1) de um nó
+func (db *mmdb) readNode(node, bit uint) uint {
+	switch db.recordSize {
+	case 24:
+		b := db.buf[node*6+bit*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		b := db.buf[node*7:]
+		if bit == 0 {
+			return uint(b[3]&0xF0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0F)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		return uint(binary.BigEndian.Uint32(db.buf[node*8+bit*4:]))
+	}
+}
+
+// =============================================================================
+// SEÇÃO DE DADOS
+// =============================================================================
+
+// mmdbDecoder decodifica valores de uma seção (ponteiros são relativos a ela)
+type mmdbDecoder []byte
+
+// decode lê o valor em offset e retorna o offset seguinte
+func (d mmdbDecoder) decode(offset uint, depth int) (interface{}, uint, error) {
+	if depth > mmdbMaxDepth || offset >= uint(len(d)) {
+		return nil, 0, errInvalidMMDB
+	}
+
+	ctrl := d[offset]
+	offset++
+	kind := uint(ctrl >> 5)
+
+	if kind == mmdbPointer {
+		target, next, err := d.pointer(ctrl, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		value, _, err := d.decode(target, depth+1)
+		return value, next, err
+	}
+
+	if kind == mmdbExtended {
+		if offset >= uint(len(d)) {
+			return nil, 0, errInvalidMMDB
+		}
+		kind = 7 + uint(d[offset])
+		offset++
+	}
+
+	size, offset, err := d.size(ctrl, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch kind {
+	case mmdbMap:
+		result := make(map[string]interface{}, size)
+		for i := uint(0); i < size; i++ {
+			var key, value interface{}
+			if key, offset, err = d.decode(offset, depth+1); err != nil {
+				return nil, 0, err
+			}
+			name, ok := key.(string)
+			if !ok {
+				return nil, 0, errInvalidMMDB
+			}
+			if value, offset, err = d.decode(offset, depth+1); err != nil {
+				return nil, 0, err
+			}
+			result[name] = value
+		}
+		return result, offset, nil
+	case mmdbArray:
+		result := make([]interface{}, 0, size)
+		for i := uint(0); i < size; i++ {
+			var value interface{}
+			if value, offset, err = d.decode(offset, depth+1); err != nil {
+				return nil, 0, err
+			}
+			result = append(result, value)
+		}
+		return result, offset, nil
+	case mmdbBool:
+		return size != 0, offset, nil
+	}
+
+	// Tipos com conteúdo de tamanho fixo em bytes
+	if offset+size > uint(len(d)) {
+		return nil, 0, errInvalidMMDB
+	}
+	raw := d[offset : offset+size]
+	next := offset + size
+
+	switch kind {
+	case mmdbString:
+		return string(raw), next, nil
+	case mmdbBytes:
+		return append([]byte(nil), raw...), next, nil
+	case mmdbDouble:
+		if size != 8 {
+			return nil, 0, errInvalidMMDB
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(raw)), next, nil
+	case mmdbFloat:
+		if size != 4 {
+			return nil, 0, errInvalidMMDB
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(raw))), next, nil
+	case mmdbUint16, mmdbUint32, mmdbUint64, mmdbUint128:
+		if size > 16 {
+			return nil, 0, errInvalidMMDB
+		}
+		var value uint64 // uint128 fica com os 64 bits menos significativos
+		for _, b := range raw {
+			value = value<<8 | uint64(b)
+		}
+		return value, next, nil
+	case mmdbInt32:
+		if size > 4 {
+			return nil, 0, errInvalidMMDB
+		}
+		var value uint32
+		for _, b := range raw {
+			value = value<<8 | uint32(b)
+		}
+		return int64(int32(value)), next, nil
+	default:
+		return nil, 0, errInvalidMMDB
+	}
+}
+
+// size lê o tamanho do valor (5 bits do controle + até 3 bytes extras)
+func (d mmdbDecoder) size(ctrl byte, offset uint) (uint, uint, error) {
+	size := uint(ctrl & 0x1f)
+	extra := uint(0)
+	switch size {
+	case 29, 30, 31:
+		extra = size - 28
+	default:
+		return size, offset, nil
+	}
+	if offset+extra > uint(len(d)) {
+		return 0, 0, errInvalidMMDB
+	}
+
+	var value uint
+	for _, b := range d[offset : offset+extra] {
+		value = value<<8 | uint(b)
+	}
+	switch size {
+	case 29:
+		value += 29
+	case 30:
+		value += 285
+	default:
+		value += 65821
+	}
+	return value, offset + extra, nil
+}
+
+// pointer lê o destino de um ponteiro e o offset após ele
+func (d mmdbDecoder) pointer(ctrl byte, offset uint) (uint, uint, error) {
+	n := uint((ctrl>>3)&0x3) + 1
+	if offset+n > uint(len(d)) {
+		return 0, 0, errInvalidMMDB
+	}
+	b := d[offset : offset+n]
+	high := uint(ctrl & 0x7)
+
+	var target uint
+	switch n {
+	case 1:
+		target = high<<8 | uint(b[0])
+	case 2:
+		target = (high<<16 | uint(b[0])<<8 | uint(b[1])) + 2048
+	case 3:
+		target = (high<<24 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])) + 526336
+	default:
+		target = uint(binary.BigEndian.Uint32(b))
+	}
+	return target, offset + n, nil
+}
+
+// mmdbUint converte um inteiro decodificado (0 se ausente)
+func mmdbUint(value interface{}) uint {
+	if v, ok := value.(uint64); ok {
+		return uint(v)
+	}
+	return 0
+}
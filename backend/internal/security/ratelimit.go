@@ -13,6 +13,10 @@
 // - Rate limit por usuário (login)
 // - Sliding window algorithm
 // - Bloqueio progressivo após falhas
+//
+// Backends:
+// - Memória (padrão): estado local ao processo
+// - Redis (REDIS_URL): estado compartilhado entre réplicas (ratelimit_redis.go)
 // =============================================================================
 
 package security
@@ -30,6 +34,9 @@ import (
 
 // RateLimitConfig define configuração de rate limiting
 type RateLimitConfig struct {
+	// Name identifica o limiter (namespace das chaves no backend)
+	Name string
+
 	// Requests é o número máximo de requisições permitidas
 	Requests int
 
@@ -44,6 +51,7 @@ type RateLimitConfig struct {
 var (
 	// DefaultRateLimit para endpoints gerais
 	DefaultRateLimit = RateLimitConfig{
+		Name:          "default",
 		Requests:      100,
 		Window:        time.Minute,
 		BlockDuration: time.Minute * 5,
@@ -51,6 +59,7 @@ var (
 
 	// LoginRateLimit para tentativas de login (mais restritivo)
 	LoginRateLimit = RateLimitConfig{
+		Name:          "login",
 		Requests:      5,
 		Window:        time.Minute,
 		BlockDuration: time.Minute * 15,
@@ -58,6 +67,7 @@ var (
 
 	// RegisterRateLimit para criação de contas
 	RegisterRateLimit = RateLimitConfig{
+		Name:          "register",
 		Requests:      3,
 		Window:        time.Hour,
 		BlockDuration: time.Hour,
@@ -65,6 +75,7 @@ var (
 
 	// APIRateLimit para chamadas de API
 	APIRateLimit = RateLimitConfig{
+		Name:          "api",
 		Requests:      60,
 		Window:        time.Minute,
 		BlockDuration: time.Minute * 5,
@@ -72,27 +83,254 @@ var (
 
 	// WebhookRateLimit para webhooks externos (mais permissivo)
 	WebhookRateLimit = RateLimitConfig{
+		Name:          "webhook",
 		Requests:      200,
 		Window:        time.Minute,
 		BlockDuration: time.Minute,
 	}
+
+	// ShareAccessRateLimit para acesso público a links compartilhados
+	// Mais restritivo que a API geral (tokens e PINs são alvo de força bruta)
+	ShareAccessRateLimit = RateLimitConfig{
+		Name:          "share",
+		Requests:      30,
+		Window:        time.Minute,
+		BlockDuration: time.Minute * 10,
+	}
+
+	// PublicShareRateLimit para as páginas públicas dos links
+	// (/api/shared e /api/guardian-access): alvo de robôs quando indexadas
+	PublicShareRateLimit = RateLimitConfig{
+		Name:          "share_public",
+		Requests:      20,
+		Window:        time.Minute,
+		BlockDuration: time.Minute * 10,
+	}
+
+	// SharePreviewRateLimit para as páginas dos links com prévia (meta tags
+	// com o nome do dono); acima do limite a página sai com a prévia genérica
+	SharePreviewRateLimit = RateLimitConfig{
+		Name:          "share_preview",
+		Requests:      30,
+		Window:        time.Minute,
+		BlockDuration: time.Minute * 10,
+	}
+
+	// ShareMissRateLimit conta os tokens inválidos (404) por IP; quem passa
+	// do limite recebe um CAPTCHA (ou 429, sem CAPTCHA configurado)
+	ShareMissRateLimit = RateLimitConfig{
+		Name:          "share_miss",
+		Requests:      10,
+		Window:        time.Minute * 10,
+		BlockDuration: time.Minute * 30,
+	}
+
+	// AnonymousAnalyticsRateLimit para eventos de visitantes sem login
+	// Endpoint público: limite baixo por IP para não inflar a tabela de eventos
+	AnonymousAnalyticsRateLimit = RateLimitConfig{
+		Name:          "analytics_public",
+		Requests:      20,
+		Window:        time.Minute,
+		BlockDuration: time.Minute * 10,
+	}
+
+	// GuardianMessageRateLimit para recados do dono às pessoas de confiança,
+	// por usuário (cada recado vira WhatsApp, SMS ou email para vários guardiões)
+	GuardianMessageRateLimit = RateLimitConfig{
+		Name:          "guardian_message",
+		Requests:      5,
+		Window:        time.Hour,
+		BlockDuration: time.Hour,
+	}
+
+	// APIKeyRateLimit para as requisições com chave pessoal de API, por chave
+	APIKeyRateLimit = RateLimitConfig{
+		Name:          "api_key",
+		Requests:      30,
+		Window:        time.Minute,
+		BlockDuration: 5 * time.Minute,
+	}
+
+	// ItemSendRateLimit para os envios avulsos de itens por email, por usuário
+	ItemSendRateLimit = RateLimitConfig{
+		Name:          "item_send",
+		Requests:      10,
+		Window:        time.Hour,
+		BlockDuration: time.Hour,
+	}
+
+	// GuardianCommentRateLimit para comentários de guardiões nos itens, por IP
+	// (cada comentário notifica o dono; o link é público, protegido só pelo PIN)
+	GuardianCommentRateLimit = RateLimitConfig{
+		Name:          "guardian_comment",
+		Requests:      10,
+		Window:        time.Hour,
+		BlockDuration: time.Hour,
+	}
+
+	// WhatsAppLinkRateLimit para vincular números ao WhatsApp, por usuário
+	// (o código de vinculação tem 6 dígitos: limita tentativas de adivinhar)
+	WhatsAppLinkRateLimit = RateLimitConfig{
+		Name:          "whatsapp_link",
+		Requests:      10,
+		Window:        15 * time.Minute,
+		BlockDuration: 15 * time.Minute,
+	}
+
+	// InboundSenderRateLimit para verificar remetentes do gateway de email,
+	// por usuário (código de 6 dígitos, como o do WhatsApp)
+	InboundSenderRateLimit = RateLimitConfig{
+		Name:          "inbound_sender",
+		Requests:      10,
+		Window:        15 * time.Minute,
+		BlockDuration: 15 * time.Minute,
+	}
+
+	// CaptureRateLimit para a captura pelo navegador (rota pública), por IP
+	CaptureRateLimit = RateLimitConfig{
+		Name:          "capture",
+		Requests:      30,
+		Window:        time.Minute,
+		BlockDuration: 10 * time.Minute,
+	}
+
+	// AssistantUserRateLimit para perguntas ao assistente, por usuário
+	// Cada resposta tem custo; Requests é ajustado por ASSISTANT_USER_HOURLY_REQUESTS
+	AssistantUserRateLimit = RateLimitConfig{
+		Name:          "assistant_user",
+		Requests:      30,
+		Window:        time.Hour,
+		BlockDuration: time.Minute * 15,
+	}
+
+	// AssistantIPRateLimit para perguntas ao assistente, por IP
+	// Contém várias contas no mesmo IP; ajustado por ASSISTANT_IP_HOURLY_REQUESTS
+	AssistantIPRateLimit = RateLimitConfig{
+		Name:          "assistant_ip",
+		Requests:      100,
+		Window:        time.Hour,
+		BlockDuration: time.Minute * 15,
+	}
 )
 
 // =============================================================================
 // RATE LIMITER
 // =============================================================================
 
-// RateLimiter implementa rate limiting com sliding window
+// RateLimitBackend define onde o estado de rate limiting é armazenado
+// A implementação em memória é o padrão; a implementação Redis permite
+// compartilhar limites entre réplicas e sobreviver a deploys.
+type RateLimitBackend interface {
+	// Allow registra uma requisição e informa se ela deve ser permitida
+	Allow(key string, config RateLimitConfig) (bool, time.Duration)
+
+	// RecordFailure registra uma tentativa falha (bloqueio progressivo)
+	RecordFailure(key string)
+
+	// RecordSuccess reseta o contador de falhas
+	RecordSuccess(key string)
+
+	// Status retorna requisições restantes, tempo até reset e bloqueio
+	Status(key string, config RateLimitConfig) (remaining int, resetIn time.Duration, blocked bool)
+}
+
+// RateLimiter aplica uma configuração de limites sobre um backend
 type RateLimiter struct {
 	// config é a configuração do limiter
 	config RateLimitConfig
 
+	// backend armazena o estado (memória ou Redis)
+	backend RateLimitBackend
+}
+
+// NewRateLimiter cria um novo rate limiter
+// Usa o backend configurado via ConfigureRateLimitBackend (memória por padrão)
+//
+// Parâmetros:
+//   - config: configuração de limites
+//
+// Retorna:
+//   - *RateLimiter: limiter configurado
+func NewRateLimiter(config RateLimitConfig) *RateLimiter {
+	return &RateLimiter{
+		config:  config,
+		backend: newRateLimitBackend(config),
+	}
+}
+
+// Allow verifica se uma requisição deve ser permitida
+//
+// Parâmetros:
+//   - identifier: identificador do cliente (IP, userID, etc.)
+//
+// Retorna:
+//   - bool: true se permitido, false se bloqueado
+//   - time.Duration: tempo restante de bloqueio (se bloqueado)
+func (rl *RateLimiter) Allow(identifier string) (bool, time.Duration) {
+	return rl.backend.Allow(rl.key(identifier), rl.config)
+}
+
+// RecordFailure registra uma tentativa falha (ex: login incorreto)
+// Aumenta progressivamente o tempo de bloqueio
+//
+// Parâmetros:
+//   - identifier: identificador do cliente
+func (rl *RateLimiter) RecordFailure(identifier string) {
+	rl.backend.RecordFailure(rl.key(identifier))
+}
+
+// RecordSuccess registra uma tentativa bem-sucedida
+// Reseta o contador de falhas
+//
+// Parâmetros:
+//   - identifier: identificador do cliente
+func (rl *RateLimiter) RecordSuccess(identifier string) {
+	rl.backend.RecordSuccess(rl.key(identifier))
+}
+
+// GetStatus retorna o status atual de rate limit para um cliente
+func (rl *RateLimiter) GetStatus(identifier string) (remaining int, resetIn time.Duration, blocked bool) {
+	return rl.backend.Status(rl.key(identifier), rl.config)
+}
+
+// key monta a chave do cliente com o namespace do limiter
+// Evita que limiters diferentes compartilhem contadores no mesmo backend
+func (rl *RateLimiter) key(identifier string) string {
+	return rl.config.Name + ":" + identifier
+}
+
+// progressiveBlockDuration calcula o bloqueio baseado em falhas consecutivas
+// 3 falhas: 1 min, 5 falhas: 5 min, 10 falhas: 30 min, 15+: 1 hora
+func progressiveBlockDuration(failedAttempts int) time.Duration {
+	switch {
+	case failedAttempts >= 15:
+		return time.Hour
+	case failedAttempts >= 10:
+		return time.Minute * 30
+	case failedAttempts >= 5:
+		return time.Minute * 5
+	case failedAttempts >= 3:
+		return time.Minute
+	}
+	return 0
+}
+
+// =============================================================================
+// BACKEND EM MEMÓRIA (PADRÃO)
+// =============================================================================
+
+// memoryBackend implementa rate limiting com sliding window em memória
+// Limites são perdidos em deploys e não são compartilhados entre réplicas
+type memoryBackend struct {
 	// clients armazena estado por identificador (IP, userID, etc.)
 	clients map[string]*clientState
 
 	// mu protege acesso concorrente
 	mu sync.RWMutex
 
+	// retention define por quanto tempo entradas inativas são mantidas
+	retention time.Duration
+
 	// cleanupInterval define intervalo de limpeza de entradas antigas
 	cleanupInterval time.Duration
 }
@@ -115,48 +353,35 @@ type clientState struct {
 	lastRequest time.Time
 }
 
-// NewRateLimiter cria um novo rate limiter
-//
-// Parâmetros:
-//   - config: configuração de limites
-//
-// Retorna:
-//   - *RateLimiter: limiter configurado
-func NewRateLimiter(config RateLimitConfig) *RateLimiter {
-	rl := &RateLimiter{
-		config:          config,
+// newMemoryBackend cria um backend em memória com limpeza periódica
+func newMemoryBackend(retention time.Duration) *memoryBackend {
+	mb := &memoryBackend{
 		clients:         make(map[string]*clientState),
+		retention:       retention,
 		cleanupInterval: time.Minute * 5,
 	}
 
 	// Iniciar goroutine de limpeza
-	go rl.cleanup()
+	go mb.cleanup()
 
-	return rl
+	return mb
 }
 
-// Allow verifica se uma requisição deve ser permitida
-//
-// Parâmetros:
-//   - identifier: identificador do cliente (IP, userID, etc.)
-//
-// Retorna:
-//   - bool: true se permitido, false se bloqueado
-//   - time.Duration: tempo restante de bloqueio (se bloqueado)
-func (rl *RateLimiter) Allow(identifier string) (bool, time.Duration) {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
+// Allow implementa RateLimitBackend
+func (mb *memoryBackend) Allow(key string, config RateLimitConfig) (bool, time.Duration) {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
 
 	now := time.Now()
 
 	// Obter ou criar estado do cliente
-	state, exists := rl.clients[identifier]
+	state, exists := mb.clients[key]
 	if !exists {
 		state = &clientState{
 			windowStart: now,
 			lastRequest: now,
 		}
-		rl.clients[identifier] = state
+		mb.clients[key] = state
 	}
 
 	// Verificar se está bloqueado
@@ -165,17 +390,17 @@ func (rl *RateLimiter) Allow(identifier string) (bool, time.Duration) {
 	}
 
 	// Verificar se a janela expirou
-	if now.Sub(state.windowStart) > rl.config.Window {
+	if now.Sub(state.windowStart) > config.Window {
 		// Resetar janela
 		state.requests = 0
 		state.windowStart = now
 	}
 
 	// Verificar limite
-	if state.requests >= rl.config.Requests {
+	if state.requests >= config.Requests {
 		// Bloquear cliente
-		state.blockedUntil = now.Add(rl.config.BlockDuration)
-		return false, rl.config.BlockDuration
+		state.blockedUntil = now.Add(config.BlockDuration)
+		return false, config.BlockDuration
 	}
 
 	// Permitir requisição
@@ -184,66 +409,44 @@ func (rl *RateLimiter) Allow(identifier string) (bool, time.Duration) {
 	return true, 0
 }
 
-// RecordFailure registra uma tentativa falha (ex: login incorreto)
-// Aumenta progressivamente o tempo de bloqueio
-//
-// Parâmetros:
-//   - identifier: identificador do cliente
-func (rl *RateLimiter) RecordFailure(identifier string) {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
+// RecordFailure implementa RateLimitBackend
+func (mb *memoryBackend) RecordFailure(key string) {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
 
-	state, exists := rl.clients[identifier]
+	state, exists := mb.clients[key]
 	if !exists {
 		state = &clientState{
 			windowStart: time.Now(),
 		}
-		rl.clients[identifier] = state
+		mb.clients[key] = state
 	}
 
 	state.failedAttempts++
 
-	// Bloqueio progressivo baseado em falhas
-	// 3 falhas: 1 min, 5 falhas: 5 min, 10 falhas: 30 min, 15+: 1 hora
-	var blockDuration time.Duration
-	switch {
-	case state.failedAttempts >= 15:
-		blockDuration = time.Hour
-	case state.failedAttempts >= 10:
-		blockDuration = time.Minute * 30
-	case state.failedAttempts >= 5:
-		blockDuration = time.Minute * 5
-	case state.failedAttempts >= 3:
-		blockDuration = time.Minute
-	}
-
-	if blockDuration > 0 {
+	if blockDuration := progressiveBlockDuration(state.failedAttempts); blockDuration > 0 {
 		state.blockedUntil = time.Now().Add(blockDuration)
 	}
 }
 
-// RecordSuccess registra uma tentativa bem-sucedida
-// Reseta o contador de falhas
-//
-// Parâmetros:
-//   - identifier: identificador do cliente
-func (rl *RateLimiter) RecordSuccess(identifier string) {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
+// RecordSuccess implementa RateLimitBackend
+func (mb *memoryBackend) RecordSuccess(key string) {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
 
-	if state, exists := rl.clients[identifier]; exists {
+	if state, exists := mb.clients[key]; exists {
 		state.failedAttempts = 0
 	}
 }
 
-// GetStatus retorna o status atual de rate limit para um cliente
-func (rl *RateLimiter) GetStatus(identifier string) (remaining int, resetIn time.Duration, blocked bool) {
-	rl.mu.RLock()
-	defer rl.mu.RUnlock()
+// Status implementa RateLimitBackend
+func (mb *memoryBackend) Status(key string, config RateLimitConfig) (remaining int, resetIn time.Duration, blocked bool) {
+	mb.mu.RLock()
+	defer mb.mu.RUnlock()
 
-	state, exists := rl.clients[identifier]
+	state, exists := mb.clients[key]
 	if !exists {
-		return rl.config.Requests, rl.config.Window, false
+		return config.Requests, config.Window, false
 	}
 
 	now := time.Now()
@@ -255,35 +458,35 @@ func (rl *RateLimiter) GetStatus(identifier string) (remaining int, resetIn time
 
 	// Verificar janela
 	elapsed := now.Sub(state.windowStart)
-	if elapsed > rl.config.Window {
-		return rl.config.Requests, rl.config.Window, false
+	if elapsed > config.Window {
+		return config.Requests, config.Window, false
 	}
 
-	remaining = rl.config.Requests - state.requests
+	remaining = config.Requests - state.requests
 	if remaining < 0 {
 		remaining = 0
 	}
 
-	return remaining, rl.config.Window - elapsed, false
+	return remaining, config.Window - elapsed, false
 }
 
 // cleanup remove entradas antigas periodicamente
-func (rl *RateLimiter) cleanup() {
-	ticker := time.NewTicker(rl.cleanupInterval)
+func (mb *memoryBackend) cleanup() {
+	ticker := time.NewTicker(mb.cleanupInterval)
 	defer ticker.Stop()
 
 	for range ticker.C {
-		rl.mu.Lock()
+		mb.mu.Lock()
 		now := time.Now()
-		cutoff := now.Add(-rl.config.Window * 2)
+		cutoff := now.Add(-mb.retention)
 
-		for id, state := range rl.clients {
+		for id, state := range mb.clients {
 			// Remover se última requisição foi há muito tempo e não está bloqueado
 			if state.lastRequest.Before(cutoff) && now.After(state.blockedUntil) {
-				delete(rl.clients, id)
+				delete(mb.clients, id)
 			}
 		}
-		rl.mu.Unlock()
+		mb.mu.Unlock()
 	}
 }
 
@@ -313,7 +516,7 @@ func (rl *RateLimiter) Middleware(getIdentifier func(*http.Request) string) func
 
 			if !allowed {
 				w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
-				http.Error(w, `{"error":"Muitas requisições. Tente novamente em alguns minutos."}`, http.StatusTooManyRequests)
+				writeError(w, r, http.StatusTooManyRequests, "security.rate_limited")
 				return
 			}
 
@@ -0,0 +1,204 @@
+// =============================================================================
+// FAMLI - Rate Limiter Distribuído (Redis)
+// =============================================================================
+// Backend de rate limiting que guarda contadores no Redis, para que os
+// limites sejam compartilhados entre réplicas e sobrevivam a deploys.
+//
+// Variáveis de ambiente:
+// - REDIS_URL: URL de conexão (ex: redis://:senha@host:6379/0)
+//   Se não definida, o backend em memória é usado.
+//
+// Chaves utilizadas (TTL automático):
+// - famli:rl:<limiter>:<id>:count  - requisições na janela atual
+// - famli:rl:<limiter>:<id>:block  - bloqueio ativo
+// - famli:rl:<limiter>:<id>:fail   - falhas consecutivas
+//
+// Em caso de indisponibilidade do Redis, o limiter recorre a um backend
+// em memória local (fail-open controlado) em vez de derrubar a requisição.
+// =============================================================================
+
+package security
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisKeyPrefix é o prefixo de todas as chaves de rate limit
+const redisKeyPrefix = "famli:rl:"
+
+// redisOpTimeout limita o tempo de cada operação no Redis
+const redisOpTimeout = 200 * time.Millisecond
+
+// failureRetention é por quanto tempo falhas consecutivas são lembradas
+const failureRetention = time.Hour * 24
+
+var (
+	// rateLimitRedis é o cliente compartilhado (nil = backend em memória)
+	rateLimitRedis *redis.Client
+
+	// rateLimitMu protege a configuração global do backend
+	rateLimitMu sync.RWMutex
+)
+
+// allowScript incrementa o contador da janela e aplica bloqueio atomicamente
+// KEYS[1]=count, KEYS[2]=block; ARGV[1]=limite, ARGV[2]=janela(ms), ARGV[3]=bloqueio(ms)
+// Retorna {permitido(0/1), retryAfter(ms)}
+var allowScript = redis.NewScript(`
+local blockTTL = redis.call("PTTL", KEYS[2])
+if blockTTL > 0 then
+	return {0, blockTTL}
+end
+local count = redis.call("INCR", KEYS[1])
+if count == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+if count > tonumber(ARGV[1]) then
+	redis.call("SET", KEYS[2], "1", "PX", ARGV[3])
+	return {0, tonumber(ARGV[3])}
+end
+return {1, 0}
+`)
+
+// ConfigureRateLimitBackend define o backend usado pelos próximos limiters
+// Deve ser chamado na inicialização, antes de criar handlers.
+// Com redisURL vazio, mantém o backend em memória.
+func ConfigureRateLimitBackend(redisURL string) error {
+	if redisURL == "" {
+		return nil
+	}
+
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return fmt.Errorf("REDIS_URL inválida: %w", err)
+	}
+
+	client := redis.NewClient(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return fmt.Errorf("erro ao conectar ao Redis: %w", err)
+	}
+
+	rateLimitMu.Lock()
+	rateLimitRedis = client
+	rateLimitMu.Unlock()
+	return nil
+}
+
+// RateLimitBackendName retorna o nome do backend ativo (para logs/health)
+func RateLimitBackendName() string {
+	rateLimitMu.RLock()
+	defer rateLimitMu.RUnlock()
+	if rateLimitRedis != nil {
+		return "redis"
+	}
+	return "memory"
+}
+
+// newRateLimitBackend cria o backend para um limiter conforme a configuração global
+func newRateLimitBackend(config RateLimitConfig) RateLimitBackend {
+	fallback := newMemoryBackend(config.Window * 2)
+
+	rateLimitMu.RLock()
+	client := rateLimitRedis
+	rateLimitMu.RUnlock()
+
+	if client == nil {
+		return fallback
+	}
+	return &redisBackend{client: client, fallback: fallback}
+}
+
+// =============================================================================
+// BACKEND REDIS
+// =============================================================================
+
+// redisBackend implementa RateLimitBackend usando Redis (fixed window)
+type redisBackend struct {
+	client   *redis.Client
+	fallback *memoryBackend
+}
+
+// Allow implementa RateLimitBackend
+func (rb *redisBackend) Allow(key string, config RateLimitConfig) (bool, time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+
+	result, err := allowScript.Run(ctx, rb.client,
+		[]string{redisKeyPrefix + key + ":count", redisKeyPrefix + key + ":block"},
+		config.Requests, config.Window.Milliseconds(), config.BlockDuration.Milliseconds(),
+	).Int64Slice()
+	if err != nil || len(result) != 2 {
+		log.Printf("[RateLimit] Redis indisponível, usando memória: %v", err)
+		return rb.fallback.Allow(key, config)
+	}
+
+	return result[0] == 1, time.Duration(result[1]) * time.Millisecond
+}
+
+// RecordFailure implementa RateLimitBackend
+func (rb *redisBackend) RecordFailure(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+
+	failKey := redisKeyPrefix + key + ":fail"
+	failures, err := rb.client.Incr(ctx, failKey).Result()
+	if err != nil {
+		log.Printf("[RateLimit] Redis indisponível, usando memória: %v", err)
+		rb.fallback.RecordFailure(key)
+		return
+	}
+	rb.client.Expire(ctx, failKey, failureRetention)
+
+	if blockDuration := progressiveBlockDuration(int(failures)); blockDuration > 0 {
+		rb.client.Set(ctx, redisKeyPrefix+key+":block", "1", blockDuration)
+	}
+}
+
+// RecordSuccess implementa RateLimitBackend
+func (rb *redisBackend) RecordSuccess(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+
+	if err := rb.client.Del(ctx, redisKeyPrefix+key+":fail").Err(); err != nil {
+		rb.fallback.RecordSuccess(key)
+	}
+}
+
+// Status implementa RateLimitBackend
+func (rb *redisBackend) Status(key string, config RateLimitConfig) (remaining int, resetIn time.Duration, blocked bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+
+	pipe := rb.client.Pipeline()
+	blockTTL := pipe.PTTL(ctx, redisKeyPrefix+key+":block")
+	count := pipe.Get(ctx, redisKeyPrefix+key+":count")
+	countTTL := pipe.PTTL(ctx, redisKeyPrefix+key+":count")
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return rb.fallback.Status(key, config)
+	}
+
+	if ttl := blockTTL.Val(); ttl > 0 {
+		return 0, ttl, true
+	}
+
+	used, _ := count.Int()
+	remaining = config.Requests - used
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	resetIn = countTTL.Val()
+	if resetIn <= 0 {
+		resetIn = config.Window
+	}
+
+	return remaining, resetIn, false
+}
@@ -0,0 +1,129 @@
+// =============================================================================
+// FAMLI - Geração de Tokens
+// =============================================================================
+// Todos os valores secretos ou que não podem ser adivinhados (JTI dos JWT,
+// tokens de acesso de guardiões, tokens de links compartilhados, chaves de
+// API) são gerados
+// aqui, sempre com crypto/rand.
+//
+// OWASP A02:2021 – Cryptographic Failures
+// - Nunca usar time.Now() ou math/rand como fonte de aleatoriedade
+// - Sorteio sem viés (rejeita bytes acima do maior múltiplo do alfabeto)
+// =============================================================================
+
+package security
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+// Alfabetos para RandomString
+const (
+	// AlphabetAlphanumeric tem letras maiúsculas, minúsculas e números (URL-safe)
+	AlphabetAlphanumeric = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+	// AlphabetLowerAlphanumeric tem letras minúsculas e números
+	AlphabetLowerAlphanumeric = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+	// AlphabetHex tem dígitos hexadecimais minúsculos
+	AlphabetHex = "0123456789abcdef"
+)
+
+// Tamanhos dos tokens (em caracteres)
+const (
+	// JTILength gera ~131 bits (alfanumérico)
+	JTILength = 22
+
+	// AccessTokenLength gera ~119 bits (alfanumérico), o formato dos links de guardião
+	AccessTokenLength = 20
+
+	// ShareTokenLength gera 128 bits (hex), o formato dos links compartilhados
+	ShareTokenLength = 32
+
+	// APIKeyLength gera ~238 bits (alfanumérico), depois do prefixo das chaves de API
+	APIKeyLength = 40
+)
+
+// APIKeyPrefix inicia as chaves pessoais de API (distingue a chave de um JWT)
+const APIKeyPrefix = "famli_"
+
+// ErrInvalidAlphabet indica alfabeto vazio ou com mais de 256 símbolos
+var ErrInvalidAlphabet = errors.New("alfabeto inválido para geração de token")
+
+// RandomString gera uma string aleatória com o tamanho e o alfabeto informados
+//
+// Parâmetros:
+//   - length: número de caracteres
+//   - alphabet: símbolos permitidos (1 a 256 bytes ASCII)
+//
+// Retorna:
+//   - string: valor gerado com crypto/rand
+//   - error: se o alfabeto for inválido ou crypto/rand falhar
+func RandomString(length int, alphabet string) (string, error) {
+	if len(alphabet) == 0 || len(alphabet) > 256 {
+		return "", ErrInvalidAlphabet
+	}
+	if length <= 0 {
+		return "", nil
+	}
+
+	// Bytes >= limit são descartados para que todos os símbolos tenham a mesma chance
+	limit := 256 - 256%len(alphabet)
+	result := make([]byte, 0, length)
+	buf := make([]byte, length+length/4+1)
+	for len(result) < length {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if int(b) >= limit {
+				continue
+			}
+			result = append(result, alphabet[int(b)%len(alphabet)])
+			if len(result) == length {
+				break
+			}
+		}
+	}
+	return string(result), nil
+}
+
+// GenerateJTI gera o ID único (claim "jti") de um JWT
+func GenerateJTI() string {
+	return mustRandomString(JTILength, AlphabetAlphanumeric)
+}
+
+// GenerateAccessToken gera o token do link de acesso de um guardião
+func GenerateAccessToken() string {
+	return mustRandomString(AccessTokenLength, AlphabetAlphanumeric)
+}
+
+// GenerateShareToken gera o token de um link compartilhado
+func GenerateShareToken() string {
+	return mustRandomString(ShareTokenLength, AlphabetHex)
+}
+
+// GenerateAPIKey gera uma chave pessoal de API ("famli_...")
+func GenerateAPIKey() string {
+	return APIKeyPrefix + mustRandomString(APIKeyLength, AlphabetAlphanumeric)
+}
+
+// HashToken retorna o hash (SHA-256, hex) guardado no banco no lugar do token
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// mustRandomString gera o token ou interrompe a requisição
+// Um token previsível é pior que um erro: crypto/rand só falha se o sistema
+// operacional não tiver fonte de entropia.
+func mustRandomString(length int, alphabet string) string {
+	token, err := RandomString(length, alphabet)
+	if err != nil {
+		panic("security: falha ao gerar token: " + err.Error())
+	}
+	return token
+}
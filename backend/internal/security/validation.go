@@ -63,6 +63,7 @@ const (
 	MaxContentLength  = 10000 // Conteúdo de item (10KB - ~2500 palavras)
 	MaxFeedbackLength = 2000  // Feedback do usuário (2KB)
 	MaxNotesLength    = 255   // Notas de guardião (curtas)
+	MaxCommentLength  = 1000  // Comentário de guardião em item
 	MaxPhoneLength    = 20    // Telefone internacional
 	MaxURLLength      = 2048  // URL
 )
@@ -308,9 +309,21 @@ func SanitizeTitle(title string) string {
 	return SanitizeText(title, MaxTitleLength)
 }
 
-// SanitizeContent sanitiza conteúdo de itens
+// SanitizeContent sanitiza conteúdo de itens (Markdown)
+//
+// Diferente de SanitizeText, não escapa HTML: "> citação", "**negrito**" e
+// "a & b" são guardados como digitados. Tags HTML são removidas e o escape
+// acontece na saída (RenderMarkdown ou o próprio frontend).
 func SanitizeContent(content string) string {
-	return SanitizeText(content, MaxContentLength)
+	content = removeControlChars(content)
+	content = stripHTMLTags(content)
+	content = strings.TrimSpace(content)
+
+	if len(content) > MaxContentLength {
+		content = truncateString(content, MaxContentLength)
+	}
+
+	return content
 }
 
 // =============================================================================
@@ -443,28 +456,3 @@ func truncateString(s string, maxLen int) string {
 
 	return truncated
 }
-
-// ContainsSQLInjection verifica padrões comuns de SQL injection
-// NOTA: Isto é uma camada adicional, não substitui prepared statements
-func ContainsSQLInjection(input string) bool {
-	patterns := []string{
-		"(?i)(union\\s+select)",
-		"(?i)(select\\s+.*\\s+from)",
-		"(?i)(insert\\s+into)",
-		"(?i)(delete\\s+from)",
-		"(?i)(drop\\s+table)",
-		"(?i)(update\\s+.*\\s+set)",
-		"(?i)('\\s*or\\s+')",
-		"(?i)(--)",
-		"(?i)(/\\*.*\\*/)",
-	}
-
-	for _, pattern := range patterns {
-		matched, _ := regexp.MatchString(pattern, input)
-		if matched {
-			return true
-		}
-	}
-
-	return false
-}
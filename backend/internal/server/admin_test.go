@@ -0,0 +1,152 @@
+package server_test
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"famli/internal/storage"
+	"famli/internal/testutil"
+)
+
+func TestAdminActivityFeed(t *testing.T) {
+	h := testutil.New(t, map[string]string{"ADMIN_EMAILS": "admin@example.com"})
+	admin := h.Register("admin@example.com", "Admin")
+	maria := h.Register("maria@example.com", "Maria")
+	joao := h.Register("joao@example.com", "João")
+
+	maria.Post("/api/feedback", map[string]string{"type": "praise", "message": "Muito bom"}).Expect(http.StatusCreated)
+	admin.Delete("/api/admin/users/" + joao.User.ID).Expect(http.StatusNoContent)
+	h.Store.TrackEvent(&storage.AnalyticsEvent{ID: "exp", UserID: maria.User.ID, EventType: storage.EventExportData, CreatedAt: time.Now().AddDate(0, 0, -3)})
+	h.Store.TrackEvent(&storage.AnalyticsEvent{ID: "view", UserID: maria.User.ID, EventType: storage.EventPageView, CreatedAt: time.Now()})
+
+	type activity struct {
+		ID     string            `json:"id"`
+		Type   string            `json:"type"`
+		Source string            `json:"source"`
+		UserID string            `json:"user_id"`
+		User   map[string]string `json:"user"`
+	}
+	type feed struct {
+		Activities []activity `json:"activities"`
+		Total      int        `json:"total"`
+		HasMore    bool       `json:"has_more"`
+		NextCursor string     `json:"next_cursor"`
+	}
+
+	// 3 cadastros, 1 feedback, 1 exclusão e 1 exportação (page_view fica de fora)
+	var all feed
+	admin.Get("/api/admin/activity").Expect(http.StatusOK).JSON(&all)
+	counts := map[string]int{}
+	for _, a := range all.Activities {
+		counts[a.Type]++
+	}
+	if all.Total != 6 || counts["signup"] != 3 || counts["feedback"] != 1 || counts["deletion"] != 1 || counts["export"] != 1 {
+		t.Fatalf("atividade: %+v", all)
+	}
+	if last := all.Activities[len(all.Activities)-1]; last.Type != "export" || last.Source != "analytics" {
+		t.Fatalf("ordem (mais recentes primeiro): %+v", all.Activities)
+	}
+	for _, a := range all.Activities {
+		if a.Type == "deletion" && (a.UserID != joao.User.ID || a.User != nil) {
+			t.Fatalf("conta excluída: %+v", a)
+		}
+		if a.Type == "feedback" && a.User["name"] != "Maria" {
+			t.Fatalf("autor do feedback: %+v", a)
+		}
+	}
+
+	// Filtro por tipo e paginação
+	var page feed
+	admin.Get("/api/admin/activity?type=signup&limit=2").Expect(http.StatusOK).JSON(&page)
+	if page.Total != 3 || len(page.Activities) != 2 || !page.HasMore || page.NextCursor == "" {
+		t.Fatalf("primeira página: %+v", page)
+	}
+	var next feed
+	admin.Get("/api/admin/activity?type=signup&limit=2&cursor=" + page.NextCursor).Expect(http.StatusOK).JSON(&next)
+	if len(next.Activities) != 1 || next.HasMore || next.Activities[0].Type != "signup" ||
+		next.Activities[0].ID == page.Activities[0].ID || next.Activities[0].ID == page.Activities[1].ID {
+		t.Fatalf("segunda página: %+v", next)
+	}
+
+	// Período (to inclusivo)
+	today := time.Now().Format("2006-01-02")
+	var ranged feed
+	admin.Get("/api/admin/activity?from=" + today + "&to=" + today).Expect(http.StatusOK).JSON(&ranged)
+	if ranged.Total != 5 {
+		t.Fatalf("atividade de hoje: %+v", ranged)
+	}
+
+	admin.Get("/api/admin/activity?type=login").ExpectError(http.StatusBadRequest, "ADMIN_INVALID_ACTIVITY_TYPE")
+	admin.Get("/api/admin/activity?from=ontem").ExpectError(http.StatusBadRequest, "ADMIN_INVALID_RANGE")
+	admin.Get("/api/admin/activity?cursor=invalido").ExpectError(http.StatusBadRequest, "ADMIN_INVALID_CURSOR")
+	maria.Get("/api/admin/activity").Expect(http.StatusForbidden)
+}
+
+func TestAdminMetricsExport(t *testing.T) {
+	h := testutil.New(t, map[string]string{"ADMIN_EMAILS": "admin@example.com"})
+	admin := h.Register("admin@example.com", "Admin")
+	maria := h.Register("maria@example.com", "Maria")
+
+	maria.Post("/api/feedback", map[string]string{"type": "praise", "message": "=1+1"}).Expect(http.StatusCreated)
+	today := time.Now().UTC().Format("2006-01-02")
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Format("2006-01-02")
+
+	// Feedbacks em CSV: arquivo para download, email mascarado, fórmula neutralizada
+	res := admin.Get("/api/admin/export/feedbacks?from=" + yesterday + "&to=" + today).Expect(http.StatusOK)
+	if got := res.Header.Get("Content-Disposition"); got != `attachment; filename="famli-feedbacks-`+yesterday+"-"+today+`.csv"` {
+		t.Fatalf("Content-Disposition inesperado: %q", got)
+	}
+	rows, err := csv.NewReader(strings.NewReader(string(res.Body))).ReadAll()
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("CSV inválido (%v): %s", err, res.Body)
+	}
+	if rows[0][0] != "id" || rows[1][5] != "ma***@example.com" || rows[1][7] != "'=1+1" {
+		t.Fatalf("linha inesperada: %q", rows)
+	}
+
+	// Crescimento em JSON: um registro por dia, com o total acumulado
+	var growth []struct {
+		Date       string `json:"date"`
+		NewUsers   int    `json:"new_users"`
+		TotalUsers int    `json:"total_users"`
+	}
+	admin.Get("/api/admin/export/user-growth?format=json&from=" + yesterday + "&to=" + today).Expect(http.StatusOK).JSON(&growth)
+	if len(growth) != 2 || growth[0].Date != yesterday || growth[1].NewUsers != 2 || growth[1].TotalUsers != 2 {
+		t.Fatalf("crescimento inesperado: %+v", growth)
+	}
+
+	// Série diária de analytics: padrão de 30 dias, inclusive os vazios
+	res = admin.Get("/api/admin/export/analytics-daily").Expect(http.StatusOK)
+	if rows, err := csv.NewReader(strings.NewReader(string(res.Body))).ReadAll(); err != nil || len(rows) != 31 || rows[30][0] != today {
+		t.Fatalf("série diária inesperada (%v): %s", err, res.Body)
+	}
+
+	admin.Get("/api/admin/export/feedbacks?format=xlsx").ExpectError(http.StatusBadRequest, "ADMIN_INVALID_EXPORT_FORMAT")
+	admin.Get("/api/admin/export/feedbacks?from="+today+"&to="+yesterday).ExpectError(http.StatusBadRequest, "ADMIN_INVALID_RANGE")
+	maria.Get("/api/admin/export/user-growth").Expect(http.StatusForbidden)
+}
+
+func TestAdminRoleHierarchy(t *testing.T) {
+	h := testutil.New(t, map[string]string{"ADMIN_EMAILS": "admin@example.com"})
+	superadmin := h.Register("admin@example.com", "Admin")
+	support := h.Register("suporte@example.com", "Suporte")
+	analyst := h.Register("analista@example.com", "Analista")
+	maria := h.Register("maria@example.com", "Maria")
+	h.Store.GrantRole(support.User.ID, storage.RoleSupport)
+	h.Store.GrantRole(analyst.User.ID, storage.RoleAnalyst)
+
+	// O suporte não age sobre contas administrativas
+	for _, target := range []string{superadmin.User.ID, analyst.User.ID} {
+		support.Post("/api/admin/users/"+target+"/disable", nil).ExpectError(http.StatusForbidden, "ADMIN_TARGET_FORBIDDEN")
+		support.Post("/api/admin/users/"+target+"/reset-password", nil).ExpectError(http.StatusForbidden, "ADMIN_TARGET_FORBIDDEN")
+	}
+	superadmin.Get("/api/auth/me").Expect(http.StatusOK)
+
+	// Usuários comuns continuam com o suporte; contas administrativas, com o superadmin
+	support.Post("/api/admin/users/"+maria.User.ID+"/disable", nil).Expect(http.StatusOK)
+	support.Post("/api/admin/users/"+maria.User.ID+"/enable", nil).Expect(http.StatusOK)
+	superadmin.Post("/api/admin/users/"+support.User.ID+"/disable", nil).Expect(http.StatusOK)
+}
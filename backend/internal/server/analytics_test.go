@@ -0,0 +1,82 @@
+package server_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"famli/internal/analytics"
+	"famli/internal/storage"
+	"famli/internal/testutil"
+)
+
+func TestAnalyticsBufferBatchesEvents(t *testing.T) {
+	store := storage.NewMemoryStore()
+	buffer := analytics.NewBuffer(store, analytics.BufferConfig{BatchSize: 2, FlushInterval: time.Hour, QueueLimit: 3})
+
+	for i := 0; i < 4; i++ {
+		buffer.Add(&storage.AnalyticsEvent{ID: string(rune('a' + i)), EventType: "page_view", CreatedAt: time.Now()})
+	}
+	// Fila limitada a 3: o quarto evento é descartado, nada foi gravado ainda
+	if stats := buffer.Stats(); stats.Depth != 3 || stats.Dropped != 1 || stats.Written != 0 {
+		t.Fatalf("antes da gravação: %+v", stats)
+	}
+	if events, _ := store.GetRecentEvents(10); len(events) != 0 {
+		t.Fatalf("eventos gravados antes do flush: %d", len(events))
+	}
+
+	buffer.Flush()
+	if stats := buffer.Stats(); stats.Depth != 0 || stats.Written != 3 {
+		t.Fatalf("depois da gravação: %+v", stats)
+	}
+
+	// O lote cheio dispara a gravação pelo worker
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	buffer.Start(ctx)
+	buffer.Add(&storage.AnalyticsEvent{ID: "e", EventType: "page_view", CreatedAt: time.Now()})
+	buffer.Add(&storage.AnalyticsEvent{ID: "f", EventType: "page_view", CreatedAt: time.Now()})
+	deadline := time.Now().Add(2 * time.Second)
+	for buffer.Stats().Written != 5 {
+		if time.Now().After(deadline) {
+			t.Fatalf("lote cheio não foi gravado: %+v", buffer.Stats())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Encerramento grava o que restou e recusa novos eventos
+	buffer.Add(&storage.AnalyticsEvent{ID: "g", EventType: "page_view", CreatedAt: time.Now()})
+	buffer.Stop(context.Background())
+	if buffer.Add(&storage.AnalyticsEvent{ID: "h", EventType: "page_view", CreatedAt: time.Now()}) {
+		t.Fatal("evento aceito depois do encerramento")
+	}
+	if stats := buffer.Stats(); stats.Written != 6 || stats.Depth != 0 || stats.Dropped != 2 {
+		t.Fatalf("depois do encerramento: %+v", stats)
+	}
+	if events, _ := store.GetRecentEvents(10); len(events) != 6 {
+		t.Fatalf("eventos gravados: %d", len(events))
+	}
+}
+
+func TestTrackedEventsShowInHealthQueue(t *testing.T) {
+	h := testutil.New(t, map[string]string{
+		"ADMIN_EMAILS":                     "admin@example.com",
+		"ANALYTICS_BATCH_SIZE":             "50",
+		"ANALYTICS_FLUSH_INTERVAL_SECONDS": "3600",
+	})
+	admin := h.Register("admin@example.com", "Admin")
+
+	admin.Post("/api/analytics/track", map[string]string{"event_type": "page_view", "page": "/caixa"}).Expect(http.StatusOK)
+	admin.Post("/api/analytics/track", map[string]string{"event_type": "create_item"}).Expect(http.StatusOK)
+
+	var health struct {
+		Queues struct {
+			Analytics analytics.BufferStats `json:"analytics"`
+		} `json:"queues"`
+	}
+	admin.Get("/api/admin/health").Expect(http.StatusOK).JSON(&health)
+	if health.Queues.Analytics.Depth != 2 {
+		t.Fatalf("fila de analytics: %+v", health.Queues.Analytics)
+	}
+}
@@ -0,0 +1,386 @@
+package server_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"famli/internal/security"
+	"famli/internal/testutil"
+)
+
+func TestRegisterAndMe(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+
+	me := maria.Get("/api/auth/me").Expect(http.StatusOK).Map()
+	user, _ := me["user"].(map[string]interface{})
+	if user["email"] != "maria@example.com" || user["name"] != "Maria" {
+		t.Fatalf("/auth/me inesperado: %v", me)
+	}
+}
+
+func TestProtectedRoutesRequireSession(t *testing.T) {
+	h := testutil.New(t, nil)
+	visitor := h.NewClient()
+
+	visitor.Get("/api/auth/me").Expect(http.StatusUnauthorized)
+	visitor.Get("/api/box/items").Expect(http.StatusUnauthorized)
+	visitor.Get("/api/guardians").Expect(http.StatusUnauthorized)
+}
+
+func TestRegisterDuplicateEmail(t *testing.T) {
+	h := testutil.New(t, nil)
+	h.Register("joao@example.com", "João")
+
+	h.NewClient().Post("/api/auth/register", map[string]string{
+		"email":    "joao@example.com",
+		"password": testutil.DefaultPassword,
+		"name":     "Outro João",
+	}).Expect(http.StatusBadRequest)
+}
+
+func TestLoginLogout(t *testing.T) {
+	h := testutil.New(t, nil)
+	h.Register("ana@example.com", "Ana")
+
+	c := h.NewClient()
+	c.Login("ana@example.com", "senha-errada").Expect(http.StatusUnauthorized)
+	c.Get("/api/auth/me").Expect(http.StatusUnauthorized)
+
+	c.Login("ana@example.com", testutil.DefaultPassword).Expect(http.StatusOK)
+	c.Get("/api/auth/me").Expect(http.StatusOK)
+
+	c.Post("/api/auth/logout", nil).Expect(http.StatusOK)
+	c.Get("/api/auth/me").Expect(http.StatusUnauthorized)
+}
+
+func TestAccountLockoutDoesNotRevealAccount(t *testing.T) {
+	h := testutil.New(t, map[string]string{"ACCOUNT_LOCKOUT_THRESHOLD": "3"})
+	h.Register("ana@example.com", "Ana")
+
+	// Cada tentativa de um IP novo, para não esbarrar no limite por IP
+	for i := 0; i < 3; i++ {
+		h.NewClient().Login("ana@example.com", "senha-errada").
+			ExpectError(http.StatusUnauthorized, "AUTH_INVALID_CREDENTIALS")
+		h.NewClient().Login("ninguem@example.com", "senha-errada").
+			ExpectError(http.StatusUnauthorized, "AUTH_INVALID_CREDENTIALS")
+	}
+
+	// Bloqueada, a conta responde igual a um email sem conta (mesmo com a senha certa)
+	locked := h.NewClient().Login("ana@example.com", testutil.DefaultPassword)
+	locked.ExpectError(http.StatusUnauthorized, "AUTH_INVALID_CREDENTIALS")
+	if locked.Header.Get("Retry-After") != "" {
+		t.Fatal("o bloqueio não deveria aparecer na resposta")
+	}
+	h.NewClient().Login("ninguem@example.com", testutil.DefaultPassword).
+		ExpectError(http.StatusUnauthorized, "AUTH_INVALID_CREDENTIALS")
+}
+
+func TestAdminRoutesRequireRole(t *testing.T) {
+	h := testutil.New(t, nil)
+	user := h.Register("usuario@example.com", "Usuário")
+
+	user.Get("/api/admin/dashboard").Expect(http.StatusForbidden)
+	h.NewClient().Get("/api/admin/dashboard").Expect(http.StatusUnauthorized)
+}
+
+func TestCSRFRejectsForeignOrigin(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+
+	maria.SetHeader("Origin", "https://evil.example")
+	maria.Post("/api/box/items", map[string]string{"title": "Banco", "type": "info"}).Expect(http.StatusForbidden)
+}
+
+func TestAllowedOriginsFromConfig(t *testing.T) {
+	h := testutil.New(t, map[string]string{
+		"APP_BASE_URL":          "https://familia.example.org",
+		"EXTRA_ALLOWED_ORIGINS": "https://www.familia.example.org",
+	})
+	maria := h.Register("maria@example.com", "Maria")
+
+	for _, origin := range []string{"https://familia.example.org", "https://www.familia.example.org"} {
+		resp := maria.WithHeader("Origin", origin).
+			Post("/api/box/items", map[string]string{"title": "Banco", "type": "info"}).
+			Expect(http.StatusCreated)
+		if got := resp.Header.Get("Access-Control-Allow-Origin"); got != origin {
+			t.Fatalf("CORS para %s: Access-Control-Allow-Origin %q", origin, got)
+		}
+	}
+	maria.WithHeader("Origin", "https://famli.me").
+		Post("/api/box/items", map[string]string{"title": "Banco", "type": "info"}).
+		Expect(http.StatusForbidden)
+}
+
+func TestLegalTermsVersioning(t *testing.T) {
+	h := testutil.New(t, map[string]string{
+		"LEGAL_TERMS_VERSION":   "2026-01",
+		"LEGAL_PRIVACY_VERSION": "2026-02",
+	})
+
+	current := h.NewClient().Get("/api/legal/current").Expect(http.StatusOK)
+	if current.String("terms_version") != "2026-01" || current.String("privacy_version") != "2026-02" {
+		t.Fatalf("versões vigentes inesperadas: %s", current.Body)
+	}
+
+	// Cadastro com aceite: tudo liberado
+	maria := h.Register("maria@example.com", "Maria")
+	maria.Get("/api/box/items").Expect(http.StatusOK)
+	if maria.User.TermsVersion != "2026-01" || maria.User.TermsAcceptedAt == nil {
+		t.Fatalf("aceite do cadastro não registrado: %+v", maria.User)
+	}
+
+	// Cadastro sem aceite: 428 até aceitar, mas a conta continua acessível
+	joao := h.NewClient()
+	joao.Post("/api/auth/register", map[string]string{
+		"email":    "joao@example.com",
+		"password": testutil.DefaultPassword,
+		"name":     "João",
+	}).Expect(http.StatusCreated)
+	blocked := joao.Get("/api/box/items").ExpectError(http.StatusPreconditionRequired, "LEGAL_ACCEPTANCE_REQUIRED")
+	if details, _ := blocked.Map()["details"].(map[string]interface{}); details["terms_version"] != "2026-01" {
+		t.Fatalf("428 sem as versões vigentes: %s", blocked.Body)
+	}
+	joao.Get("/api/auth/me").Expect(http.StatusOK)
+	if joao.Get("/api/legal/status").Expect(http.StatusOK).Map()["pending"] != true {
+		t.Fatal("aceite pendente não indicado")
+	}
+
+	joao.Post("/api/legal/accept", map[string]string{"terms_version": "2025-12", "privacy_version": "2026-02"}).
+		ExpectError(http.StatusConflict, "LEGAL_VERSION_OUTDATED")
+	accepted := joao.Post("/api/legal/accept", map[string]string{"terms_version": "2026-01", "privacy_version": "2026-02"}).
+		Expect(http.StatusOK).Map()
+	if accepted["pending"] != false || accepted["terms_accepted_at"] == nil {
+		t.Fatalf("aceite não registrado: %v", accepted)
+	}
+	joao.Get("/api/box/items").Expect(http.StatusOK)
+
+	// Nova versão publicada depois do aceite: pede de novo
+	if err := h.Store.AcceptLegalTerms(maria.User.ID, "2025-12", "2026-02", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	maria.Get("/api/guardians").ExpectError(http.StatusPreconditionRequired, "LEGAL_ACCEPTANCE_REQUIRED")
+}
+
+func TestSupportImpersonation(t *testing.T) {
+	h := testutil.New(t, map[string]string{"ADMIN_EMAILS": "admin@example.com"})
+	maria := h.Register("maria@example.com", "Maria")
+	maria.Post("/api/box/items", map[string]string{"title": "Banco", "type": "info"}).Expect(http.StatusCreated)
+	admin := h.Register("admin@example.com", "Admin")
+	impersonate := "/api/admin/users/" + maria.User.ID + "/impersonate"
+
+	// Sem a autorização do usuário, nada feito
+	admin.Post(impersonate, nil).ExpectError(http.StatusForbidden, "ADMIN_SUPPORT_ACCESS_REQUIRED")
+	admin.Post("/api/admin/users/"+admin.User.ID+"/impersonate", nil).Expect(http.StatusBadRequest)
+
+	access := maria.Post("/api/settings/support-access", nil).Expect(http.StatusOK).Map()
+	if access["active"] != true || access["expires_at"] == nil {
+		t.Fatalf("autorização não registrada: %v", access)
+	}
+	if maria.Get("/api/settings/support-access").Expect(http.StatusOK).Map()["active"] != true {
+		t.Fatal("autorização não aparece nas configurações")
+	}
+
+	if admin.Post(impersonate, nil).Expect(http.StatusOK).Map()["read_only"] != true {
+		t.Fatal("sessão do suporte deveria ser somente leitura")
+	}
+	me := admin.Get("/api/auth/me").Expect(http.StatusOK).Map()
+	user, _ := me["user"].(map[string]interface{})
+	if user["email"] != "maria@example.com" || me["support_session"] != true {
+		t.Fatalf("/auth/me na sessão do suporte: %v", me)
+	}
+	if maria.Get("/api/auth/me").Expect(http.StatusOK).Map()["support_session"] != false {
+		t.Fatal("sessão do próprio usuário marcada como do suporte")
+	}
+
+	// Somente leitura e sem o painel admin
+	admin.Get("/api/box/items").Expect(http.StatusOK)
+	admin.Post("/api/box/items", map[string]string{"title": "Intruso", "type": "info"}).
+		ExpectError(http.StatusForbidden, "AUTH_IMPERSONATION_READ_ONLY")
+	admin.Get("/api/admin/dashboard").Expect(http.StatusForbidden)
+
+	// Leituras sensíveis ficam fora da sessão do suporte
+	for _, path := range []string{"/api/billing/portal", "/api/auth/export", "/api/keys", "/api/auth/devices"} {
+		admin.Get(path).ExpectError(http.StatusForbidden, "AUTH_IMPERSONATION_DENIED")
+	}
+	admin.Post("/api/billing/checkout", map[string]string{"plan": "family"}).
+		ExpectError(http.StatusForbidden, "AUTH_IMPERSONATION_READ_ONLY")
+
+	// Revogar encerra a sessão na requisição seguinte
+	maria.Delete("/api/settings/support-access").Expect(http.StatusOK)
+	admin.Get("/api/box/items").ExpectError(http.StatusUnauthorized, "SUPPORT_ACCESS_REVOKED")
+
+	// Voltar à sessão do admin
+	admin.Post("/api/auth/impersonation/end", nil).Expect(http.StatusOK)
+	me = admin.Get("/api/auth/me").Expect(http.StatusOK).Map()
+	user, _ = me["user"].(map[string]interface{})
+	if user["email"] != "admin@example.com" || me["support_session"] != false {
+		t.Fatalf("sessão do admin não restaurada: %v", me)
+	}
+	admin.Get("/api/admin/dashboard").Expect(http.StatusOK)
+	admin.Post("/api/auth/impersonation/end", nil).ExpectError(http.StatusBadRequest, "AUTH_NOT_IMPERSONATING")
+	admin.Post(impersonate, nil).ExpectError(http.StatusForbidden, "ADMIN_SUPPORT_ACCESS_REQUIRED")
+}
+
+func TestDataMapAndProfileCorrection(t *testing.T) {
+	h := testutil.New(t, map[string]string{"LOG_RETENTION_DAYS": "45"})
+	maria := h.Register("maria@example.com", "Maria")
+	h.Register("joao@example.com", "João")
+
+	var dataMap struct {
+		Categories []struct {
+			ID        string   `json:"id"`
+			Location  string   `json:"location"`
+			Fields    []string `json:"fields"`
+			Retention struct {
+				Policy            string `json:"policy"`
+				Days              int    `json:"days"`
+				DaysAfterDeletion int    `json:"days_after_deletion"`
+			} `json:"retention"`
+			Correct string `json:"correct"`
+		} `json:"categories"`
+		Rights map[string]string `json:"rights"`
+	}
+	maria.Get("/api/auth/data-map").Expect(http.StatusOK).JSON(&dataMap)
+	categories := map[string]int{}
+	for i, category := range dataMap.Categories {
+		categories[category.ID] = i
+	}
+	account, ok := categories["account"]
+	if !ok || dataMap.Categories[account].Correct != "PATCH /api/auth/profile" {
+		t.Fatalf("categoria account: %+v", dataMap.Categories)
+	}
+	audit, ok := categories["audit_log"]
+	if !ok || dataMap.Categories[audit].Retention.Days != 365 || dataMap.Categories[audit].Retention.DaysAfterDeletion != 1825 {
+		t.Fatalf("retenção da auditoria: %+v", dataMap.Categories)
+	}
+	// Classes sem prazo próprio seguem LOG_RETENTION_DAYS
+	alerts, ok := categories["guardian_alerts"]
+	if !ok || dataMap.Categories[alerts].Retention.Days != 45 {
+		t.Fatalf("retenção dos avisos: %+v", dataMap.Categories)
+	}
+	if dataMap.Rights["rectification"] != "PATCH /api/auth/profile" || dataMap.Rights["deletion"] == "" {
+		t.Fatalf("direitos: %v", dataMap.Rights)
+	}
+
+	// Nome sem senha; email exige a senha atual
+	profile := maria.Patch("/api/auth/profile", map[string]string{"name": "Maria Souza"}).Expect(http.StatusOK).Map()
+	if user, _ := profile["user"].(map[string]interface{}); user["name"] != "Maria Souza" {
+		t.Fatalf("nome não corrigido: %v", profile)
+	}
+	maria.Patch("/api/auth/profile", map[string]string{"name": "  "}).ExpectError(http.StatusBadRequest, "AUTH_PROFILE_NAME_REQUIRED")
+	maria.Patch("/api/auth/profile", map[string]string{"email": "maria.souza@example.com"}).
+		ExpectError(http.StatusBadRequest, "AUTH_PROFILE_PASSWORD_REQUIRED")
+	maria.Patch("/api/auth/profile", map[string]string{"email": "maria.souza@example.com", "password": "errada"}).
+		ExpectError(http.StatusUnauthorized, "AUTH_PASSWORD_INCORRECT")
+	maria.Patch("/api/auth/profile", map[string]string{"email": "JOAO@example.com", "password": testutil.DefaultPassword}).
+		ExpectError(http.StatusConflict, "AUTH_PROFILE_EMAIL_TAKEN")
+	maria.Patch("/api/auth/profile", map[string]string{"email": "invalido"}).ExpectError(http.StatusBadRequest, "AUTH_EMAIL_INVALID")
+
+	maria.Patch("/api/auth/profile", map[string]string{"email": "maria.souza@example.com", "password": testutil.DefaultPassword}).
+		Expect(http.StatusOK)
+	me := maria.Get("/api/auth/me").Expect(http.StatusOK).Map()
+	if user, _ := me["user"].(map[string]interface{}); user["email"] != "maria.souza@example.com" {
+		t.Fatalf("email não corrigido: %v", me)
+	}
+
+	// O login passa a ser pelo novo email
+	h.NewClient().Login("maria@example.com", testutil.DefaultPassword).Expect(http.StatusUnauthorized)
+	h.NewClient().Login("maria.souza@example.com", testutil.DefaultPassword).Expect(http.StatusOK)
+}
+
+func TestAPIKeys(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+	joao := h.Register("joao@example.com", "João")
+	maria.Post("/api/box/items", map[string]string{"title": "Banco", "type": "info"}).Expect(http.StatusCreated)
+	guardianID := maria.Post("/api/guardians", map[string]string{"name": "Ana", "access_pin": "4321"}).
+		Expect(http.StatusCreated).String("id")
+
+	maria.Post("/api/keys", map[string]interface{}{"name": "", "scopes": []string{"items:read"}}).
+		ExpectError(http.StatusBadRequest, "AUTH_API_KEY_NAME_INVALID")
+	maria.Post("/api/keys", map[string]interface{}{"name": "Script", "scopes": []string{"items:write"}}).
+		ExpectError(http.StatusBadRequest, "AUTH_API_KEY_SCOPES_INVALID")
+	maria.Post("/api/keys", map[string]interface{}{"name": "Script", "scopes": []string{}}).
+		ExpectError(http.StatusBadRequest, "AUTH_API_KEY_SCOPES_INVALID")
+
+	var created struct {
+		ID     string   `json:"id"`
+		Key    string   `json:"key"`
+		Prefix string   `json:"prefix"`
+		Scopes []string `json:"scopes"`
+	}
+	maria.Post("/api/keys", map[string]interface{}{"name": "Planilha", "scopes": []string{"items:read", "items:read"}}).
+		Expect(http.StatusCreated).JSON(&created)
+	if !strings.HasPrefix(created.Key, "famli_") || !strings.HasPrefix(created.Key, created.Prefix) || len(created.Scopes) != 1 {
+		t.Fatalf("chave inesperada: %+v", created)
+	}
+
+	// A chave fica guardada só como hash
+	stored, err := h.Store.GetAPIKeyByHash(security.HashToken(created.Key))
+	if err != nil || stored.KeyHash == created.Key {
+		t.Fatalf("chave não guardada pelo hash: %v %+v", err, stored)
+	}
+
+	script := h.NewClient().WithHeader("Authorization", "Bearer "+created.Key)
+	var items struct {
+		Items []struct {
+			Title string `json:"title"`
+		} `json:"items"`
+	}
+	script.Get("/api/box/items").Expect(http.StatusOK).JSON(&items)
+	if len(items.Items) != 1 || items.Items[0].Title != "Banco" {
+		t.Fatalf("a chave deveria ler os itens da dona: %+v", items)
+	}
+
+	// Somente leitura e só nas rotas das permissões
+	script.Post("/api/box/items", map[string]string{"title": "Intruso", "type": "info"}).
+		ExpectError(http.StatusForbidden, "AUTH_API_KEY_READ_ONLY")
+	script.Get("/api/guardians").ExpectError(http.StatusForbidden, "AUTH_API_KEY_SCOPE")
+	script.Get("/api/auth/me").ExpectError(http.StatusForbidden, "AUTH_API_KEY_SCOPE")
+	script.Get("/api/keys").ExpectError(http.StatusForbidden, "AUTH_API_KEY_SCOPE")
+	script.Get("/api/guardians/" + guardianID + "/messages").Expect(http.StatusOK)
+	h.NewClient().WithHeader("Authorization", "Bearer famli_invalida").Get("/api/box/items").
+		ExpectError(http.StatusUnauthorized, "AUTH_API_KEY_INVALID")
+
+	// Listagem sem a chave, com o último uso
+	var list struct {
+		Keys []map[string]interface{} `json:"keys"`
+	}
+	maria.Get("/api/keys").Expect(http.StatusOK).JSON(&list)
+	if len(list.Keys) != 1 || list.Keys[0]["last_used_at"] == nil || list.Keys[0]["key"] != nil || list.Keys[0]["key_hash"] != nil {
+		t.Fatalf("listagem inesperada: %+v", list)
+	}
+	joao.Get("/api/keys").Expect(http.StatusOK).JSON(&list)
+	if len(list.Keys) != 0 {
+		t.Fatalf("João vê chaves de outra conta: %+v", list)
+	}
+	joao.Delete("/api/keys/"+created.ID).ExpectError(http.StatusNotFound, "AUTH_API_KEY_NOT_FOUND")
+
+	// guardians:read não lê os recados dos guardiões, que trazem o conteúdo
+	// dos itens (a rota fica abaixo de /api/guardians, mas fora da lista)
+	var onlyGuardians struct {
+		Key string `json:"key"`
+	}
+	maria.Post("/api/keys", map[string]interface{}{"name": "Só guardiões", "scopes": []string{"guardians:read"}}).
+		Expect(http.StatusCreated).JSON(&onlyGuardians)
+	guardianScript := h.NewClient().WithHeader("Authorization", "Bearer "+onlyGuardians.Key)
+	guardianScript.Get("/api/guardians/"+guardianID+"/access-policy").ExpectError(http.StatusNotFound, "GUARDIAN_POLICY_NOT_FOUND")
+	guardianScript.Get("/api/guardians/"+guardianID+"/messages").ExpectError(http.StatusForbidden, "AUTH_API_KEY_SCOPE")
+
+	// Limite por chave
+	maria.Post("/api/keys", map[string]interface{}{"name": "Guardiões", "scopes": []string{"guardians:read"}}).
+		Expect(http.StatusCreated).JSON(&created)
+	guardians := h.NewClient().WithHeader("Authorization", "Bearer "+created.Key)
+	for i := 0; i < security.APIKeyRateLimit.Requests; i++ {
+		guardians.Get("/api/guardians").Expect(http.StatusOK)
+	}
+	guardians.Get("/api/guardians").ExpectError(http.StatusTooManyRequests, "SECURITY_RATE_LIMITED")
+	script.Get("/api/box/items").Expect(http.StatusOK)
+
+	// Revogada, a chave deixa de funcionar
+	maria.Delete("/api/keys/" + created.ID).Expect(http.StatusOK)
+	guardians.Get("/api/guardians").ExpectError(http.StatusUnauthorized, "AUTH_API_KEY_INVALID")
+}
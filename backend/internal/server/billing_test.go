@@ -0,0 +1,91 @@
+package server_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"famli/internal/storage"
+	"famli/internal/testutil"
+)
+
+const testWebhookSecret = "whsec_teste"
+
+// stripeWebhook envia um evento customer.subscription.* assinado como o Stripe
+func stripeWebhook(h *testutil.Harness, eventType, subscriptionID, status string) *testutil.Response {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"id":   "evt_" + subscriptionID + "_" + status,
+		"type": eventType,
+		"data": map[string]interface{}{
+			"object": map[string]interface{}{
+				"id":       subscriptionID,
+				"customer": "cus_maria",
+				"status":   status,
+			},
+		},
+	})
+
+	timestamp := fmt.Sprint(time.Now().Unix())
+	mac := hmac.New(sha256.New, []byte(testWebhookSecret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	signature := "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+
+	return h.NewClient().WithHeader("Stripe-Signature", signature).
+		Post("/api/billing/webhook", json.RawMessage(payload))
+}
+
+func TestBillingWebhookIgnoresStaleSubscription(t *testing.T) {
+	h := testutil.New(t, map[string]string{
+		"STRIPE_SECRET_KEY":     "sk_test_teste",
+		"STRIPE_WEBHOOK_SECRET": testWebhookSecret,
+		"STRIPE_PRICE_ID":       "price_teste",
+	})
+	maria := h.Register("maria@example.com", "Maria")
+
+	// Maria trocou a assinatura A pela B
+	if err := h.Store.SaveSubscription(&storage.Subscription{
+		UserID:         maria.User.ID,
+		CustomerID:     "cus_maria",
+		SubscriptionID: "sub_B",
+		Status:         "active",
+		Plan:           storage.PlanPremium,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	status := func() (string, string) {
+		var billing struct {
+			Plan         string `json:"plan"`
+			Subscription struct {
+				Status string `json:"status"`
+			} `json:"subscription"`
+		}
+		maria.Get("/api/billing").Expect(http.StatusOK).JSON(&billing)
+		return billing.Plan, billing.Subscription.Status
+	}
+
+	// Eventos atrasados da assinatura A não rebaixam a B
+	stripeWebhook(h, "customer.subscription.updated", "sub_A", "canceled").Expect(http.StatusOK)
+	stripeWebhook(h, "customer.subscription.deleted", "sub_A", "canceled").Expect(http.StatusOK)
+	if plan, subStatus := status(); plan != "premium" || subStatus != "active" {
+		t.Fatalf("evento da assinatura antiga alterou a atual: %s %s", plan, subStatus)
+	}
+
+	// Eventos da assinatura atual continuam valendo
+	stripeWebhook(h, "customer.subscription.updated", "sub_B", "past_due").Expect(http.StatusOK)
+	if plan, subStatus := status(); plan != "premium" || subStatus != "past_due" {
+		t.Fatalf("evento da assinatura atual ignorado: %s %s", plan, subStatus)
+	}
+
+	// Uma assinatura criada substitui a gravada
+	stripeWebhook(h, "customer.subscription.created", "sub_C", "active").Expect(http.StatusOK)
+	stripeWebhook(h, "customer.subscription.updated", "sub_B", "canceled").Expect(http.StatusOK)
+	if plan, subStatus := status(); plan != "premium" || subStatus != "active" {
+		t.Fatalf("a assinatura nova deveria valer: %s %s", plan, subStatus)
+	}
+}
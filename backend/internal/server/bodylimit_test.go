@@ -0,0 +1,60 @@
+package server_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"famli/internal/testutil"
+)
+
+func TestBodyLimits(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+
+	big := strings.Repeat("a", 80*1024)
+
+	// Limite padrão (64KB) nas demais rotas; itens da caixa aceitam até 100KB
+	maria.Put("/api/settings", map[string]string{"theme": big}).
+		ExpectError(http.StatusRequestEntityTooLarge, "REQUEST_BODY_TOO_LARGE")
+	maria.Post("/api/box/items", map[string]string{"type": "note", "title": "Grande", "content": big}).
+		Expect(http.StatusCreated)
+	maria.Post("/api/box/items", map[string]string{"type": "note", "title": "Enorme", "content": big + big}).
+		ExpectError(http.StatusRequestEntityTooLarge, "REQUEST_BODY_TOO_LARGE")
+
+	// JSON aninhado demais é recusado antes do handler
+	var nested interface{} = "fundo"
+	for i := 0; i < 30; i++ {
+		nested = []interface{}{nested}
+	}
+	maria.Post("/api/box/items", map[string]interface{}{"type": "note", "title": "Aninhado", "tags": nested}).
+		ExpectError(http.StatusBadRequest, "REQUEST_JSON_TOO_DEEP")
+
+	// Colchetes dentro de textos não contam
+	maria.Post("/api/box/items", map[string]string{"type": "note", "title": "Colchetes", "content": strings.Repeat("[{", 50)}).
+		Expect(http.StatusCreated)
+}
+
+func TestStrictJSONDecoding(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+
+	created := maria.Post("/api/box/items", map[string]string{"type": "note", "title": "Receita"}).
+		Expect(http.StatusCreated)
+
+	// No PATCH um campo com nome errado não é ignorado em silêncio
+	var body struct {
+		Details struct {
+			Field string `json:"field"`
+		} `json:"details"`
+	}
+	maria.WithHeader("If-Match", "*").Patch("/api/box/items/"+created.String("id"), map[string]interface{}{"titel": "Bolo"}).
+		ExpectError(http.StatusBadRequest, "REQUEST_UNKNOWN_FIELD").JSON(&body)
+	if body.Details.Field != "titel" {
+		t.Fatalf("campo desconhecido não informado: %+v", body)
+	}
+
+	// PUT continua tolerante (clientes antigos enviam campos extras)
+	maria.WithHeader("If-Match", "*").Put("/api/box/items/"+created.String("id"), map[string]interface{}{"type": "note", "title": "Bolo", "id": "x"}).
+		Expect(http.StatusOK)
+}
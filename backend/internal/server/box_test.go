@@ -0,0 +1,1138 @@
+package server_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"famli/internal/storage"
+	"famli/internal/testutil"
+)
+
+func TestBoxItemCRUD(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+
+	created := maria.Post("/api/box/items", map[string]interface{}{
+		"type":     "info",
+		"title":    "Conta no banco",
+		"content":  "Agência 1234, conta 5678-9",
+		"category": "finanças",
+	}).Expect(http.StatusCreated)
+	itemID := created.String("id")
+	if itemID == "" {
+		t.Fatalf("item criado sem id: %s", created.Body)
+	}
+
+	resp := maria.Get("/api/box/items/" + itemID).Expect(http.StatusOK)
+	got := resp.Map()
+	if got["title"] != "Conta no banco" || got["content"] != "Agência 1234, conta 5678-9" {
+		t.Fatalf("item inesperado: %v", got)
+	}
+
+	maria.WithHeader("If-Match", resp.Header.Get("ETag")).Put("/api/box/items/"+itemID, map[string]interface{}{
+		"type":    "info",
+		"title":   "Conta no banco (atualizada)",
+		"content": "Agência 4321",
+	}).Expect(http.StatusOK)
+
+	var list struct {
+		Items []struct {
+			ID    string `json:"id"`
+			Title string `json:"title"`
+		} `json:"items"`
+		Total int `json:"total"`
+	}
+	maria.Get("/api/box/items").Expect(http.StatusOK).JSON(&list)
+	if list.Total != 1 || len(list.Items) != 1 || list.Items[0].Title != "Conta no banco (atualizada)" {
+		t.Fatalf("listagem inesperada: %+v", list)
+	}
+
+	maria.Delete("/api/box/items/" + itemID).Expect(http.StatusOK)
+	maria.Get("/api/box/items/" + itemID).Expect(http.StatusNotFound)
+}
+
+func TestBoxItemValidation(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+
+	maria.Post("/api/box/items", map[string]interface{}{"type": "info"}).Expect(http.StatusBadRequest)
+	maria.Post("/api/box/items", map[string]interface{}{"type": "info", "title": "X", "due_date": "31/12/2030"}).Expect(http.StatusBadRequest)
+
+	// Tipo desconhecido vira "info"
+	created := maria.Post("/api/box/items", map[string]interface{}{"type": "desconhecido", "title": "X"}).Expect(http.StatusCreated)
+	if created.String("type") != "info" {
+		t.Fatalf("tipo inesperado: %s", created.Body)
+	}
+}
+
+func TestBoxItemsAreIsolatedBetweenUsers(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+	joao := h.Register("joao@example.com", "João")
+
+	itemID := maria.Post("/api/box/items", map[string]interface{}{
+		"type":  "note",
+		"title": "Particular",
+	}).Expect(http.StatusCreated).String("id")
+
+	joao.Get("/api/box/items/" + itemID).Expect(http.StatusNotFound)
+	joao.Put("/api/box/items/"+itemID, map[string]interface{}{"type": "note", "title": "Invadido"}).Expect(http.StatusNotFound)
+	joao.Delete("/api/box/items/" + itemID).Expect(http.StatusNotFound)
+
+	var list struct {
+		Total int `json:"total"`
+	}
+	joao.Get("/api/box/items").Expect(http.StatusOK).JSON(&list)
+	if list.Total != 0 {
+		t.Fatalf("João vê %d itens de outra conta", list.Total)
+	}
+	maria.Get("/api/box/items/" + itemID).Expect(http.StatusOK)
+}
+
+func TestBoxItemOptimisticConcurrency(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+
+	created := maria.Post("/api/box/items", map[string]interface{}{"type": "note", "title": "Receita"}).
+		Expect(http.StatusCreated)
+	itemID := created.String("id")
+	etag := created.Header.Get("ETag")
+	if etag != `"1"` {
+		t.Fatalf("ETag inesperado: %q", etag)
+	}
+	path := "/api/box/items/" + itemID
+
+	// Sem If-Match o PUT é recusado
+	maria.Put(path, map[string]interface{}{"type": "note", "title": "Sem versão"}).
+		ExpectError(http.StatusPreconditionRequired, "BOX_VERSION_REQUIRED")
+
+	// Primeira aba grava; a segunda, com a mesma versão, recebe 412 com o item atual
+	first := maria.WithHeader("If-Match", etag).Put(path, map[string]interface{}{"type": "note", "title": "Aba 1"}).
+		Expect(http.StatusOK)
+	if first.Header.Get("ETag") != `"2"` {
+		t.Fatalf("ETag após atualização: %q", first.Header.Get("ETag"))
+	}
+
+	var conflict struct {
+		Code    string `json:"code"`
+		Details struct {
+			Version int `json:"version"`
+			Item    struct {
+				Title string `json:"title"`
+			} `json:"item"`
+		} `json:"details"`
+	}
+	second := maria.WithHeader("If-Match", etag).Put(path, map[string]interface{}{"type": "note", "title": "Aba 2"}).
+		ExpectError(http.StatusPreconditionFailed, "BOX_VERSION_CONFLICT")
+	second.JSON(&conflict)
+	if conflict.Details.Version != 2 || conflict.Details.Item.Title != "Aba 1" {
+		t.Fatalf("412 sem a versão atual: %s", second.Body)
+	}
+
+	// Com a versão atual (ou If-Match: *) a gravação passa
+	maria.WithHeader("If-Match", second.Header.Get("ETag")).Put(path, map[string]interface{}{"type": "note", "title": "Aba 2"}).
+		Expect(http.StatusOK)
+	maria.WithHeader("If-Match", "*").Put(path, map[string]interface{}{"type": "note", "title": "Forçado"}).
+		Expect(http.StatusOK)
+
+	// A listagem traz a versão de cada item
+	var list struct {
+		Items []struct {
+			Version int `json:"version"`
+		} `json:"items"`
+	}
+	maria.Get("/api/box/items").Expect(http.StatusOK).JSON(&list)
+	if len(list.Items) != 1 || list.Items[0].Version != 4 {
+		t.Fatalf("versão na listagem: %+v", list)
+	}
+}
+
+func TestBoxItemPatch(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+
+	created := maria.Post("/api/box/items", map[string]interface{}{
+		"type":     "info",
+		"title":    "Conta & banco",
+		"content":  "Agência 1234 & conta 5678",
+		"tags":     []string{"banco"},
+		"due_date": "2030-01-10",
+	}).Expect(http.StatusCreated)
+	path := "/api/box/items/" + created.String("id")
+
+	// PATCH também exige If-Match
+	maria.Patch(path, map[string]interface{}{"is_important": true}).
+		ExpectError(http.StatusPreconditionRequired, "BOX_VERSION_REQUIRED")
+
+	var item struct {
+		Title       string   `json:"title"`
+		Content     string   `json:"content"`
+		IsImportant bool     `json:"is_important"`
+		Tags        []string `json:"tags"`
+		DueDate     *string  `json:"due_date"`
+		Version     int      `json:"version"`
+	}
+	maria.WithHeader("If-Match", created.Header.Get("ETag")).
+		Patch(path, map[string]interface{}{"is_important": true}).
+		Expect(http.StatusOK).JSON(&item)
+
+	// Campos ausentes ficam como estavam (sem escapar o título de novo)
+	if !item.IsImportant || item.Title != created.String("title") || item.Content != "Agência 1234 & conta 5678" ||
+		len(item.Tags) != 1 || item.DueDate == nil || item.Version != 2 {
+		t.Fatalf("PATCH alterou campos não enviados: %+v", item)
+	}
+
+	// null limpa o campo
+	item.DueDate = nil
+	maria.WithHeader("If-Match", "*").Patch(path, map[string]interface{}{"due_date": nil}).
+		Expect(http.StatusOK).JSON(&item)
+	if item.DueDate != nil || item.Title != created.String("title") {
+		t.Fatalf("due_date não removida: %+v", item)
+	}
+
+	// Apenas os campos enviados são validados
+	maria.WithHeader("If-Match", "*").Patch(path, map[string]interface{}{"title": ""}).
+		ExpectError(http.StatusBadRequest, "BOX_TITLE_REQUIRED")
+	maria.WithHeader("If-Match", "*").Patch(path, []string{"title"}).
+		ExpectError(http.StatusBadRequest, "BOX_INVALID_CONTENT")
+
+	// Reabrir um item selado (ou selar) exige o conteúdo no patch
+	maria.WithHeader("If-Match", "*").Patch(path, map[string]interface{}{"type": "sealed"}).
+		ExpectError(http.StatusBadRequest, "BOX_SEALED_INVALID")
+
+	joao := h.Register("joao@example.com", "João")
+	joao.WithHeader("If-Match", "*").Patch(path, map[string]interface{}{"title": "Invadido"}).
+		ExpectError(http.StatusNotFound, "BOX_NOT_FOUND")
+}
+
+func TestBoxItemPinAndManualOrder(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+	joao := h.Register("joao@example.com", "João")
+
+	ids := map[string]string{}
+	for _, title := range []string{"Banco", "Contatos de emergência", "alergias", "Documentos"} {
+		ids[title] = maria.Post("/api/box/items", map[string]interface{}{"type": "info", "title": title}).
+			Expect(http.StatusCreated).String("id")
+	}
+
+	titles := func(query string) []string {
+		var list struct {
+			Items []struct {
+				Title  string `json:"title"`
+				Pinned bool   `json:"pinned"`
+			} `json:"items"`
+			NextCursor string `json:"next_cursor"`
+		}
+		var result []string
+		cursor := ""
+		for {
+			maria.Get("/api/box/items?limit=2&" + query + "&cursor=" + cursor).Expect(http.StatusOK).JSON(&list)
+			for _, item := range list.Items {
+				result = append(result, item.Title)
+			}
+			if list.NextCursor == "" {
+				return result
+			}
+			cursor = list.NextCursor
+		}
+	}
+	expectOrder := func(query string, want ...string) {
+		t.Helper()
+		got := titles(query)
+		if len(got) != len(want) {
+			t.Fatalf("%s: ordem inesperada %v", query, got)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("%s: ordem inesperada %v (esperado %v)", query, got, want)
+			}
+		}
+	}
+
+	// Fixado vem primeiro em todas as ordenações
+	pin := maria.Put("/api/box/items/"+ids["Contatos de emergência"]+"/pin", nil).Expect(http.StatusOK)
+	if pin.Map()["pinned"] != true {
+		t.Fatalf("pin inesperado: %s", pin.Body)
+	}
+	expectOrder("sort=newest", "Contatos de emergência", "Documentos", "alergias", "Banco")
+	expectOrder("sort=created", "Contatos de emergência", "Documentos", "alergias", "Banco")
+	expectOrder("sort=oldest", "Contatos de emergência", "Banco", "alergias", "Documentos")
+	expectOrder("sort=title", "Contatos de emergência", "alergias", "Banco", "Documentos")
+
+	// Ordem manual: itens nunca reordenados vêm antes dos reordenados
+	maria.Put("/api/box/items/order", map[string]interface{}{
+		"ids": []string{ids["Banco"], ids["alergias"], ids["Contatos de emergência"]},
+	}).Expect(http.StatusOK)
+	expectOrder("sort=manual", "Contatos de emergência", "Documentos", "Banco", "alergias")
+
+	maria.Delete("/api/box/items/" + ids["Contatos de emergência"] + "/pin").Expect(http.StatusOK)
+	expectOrder("sort=manual", "Documentos", "Banco", "alergias", "Contatos de emergência")
+
+	// A ordem é de cada pessoa e só com itens que ela vê
+	maria.Put("/api/box/items/order", map[string]interface{}{"ids": []string{ids["Banco"], ids["Banco"]}}).
+		ExpectError(http.StatusBadRequest, "BOX_INVALID_ORDER")
+	maria.Put("/api/box/items/order", map[string]interface{}{"ids": []string{}}).
+		ExpectError(http.StatusBadRequest, "BOX_INVALID_ORDER")
+	joao.Put("/api/box/items/order", map[string]interface{}{"ids": []string{ids["Banco"]}}).
+		ExpectError(http.StatusBadRequest, "BOX_INVALID_ORDER")
+	joao.Put("/api/box/items/"+ids["Banco"]+"/pin", nil).Expect(http.StatusNotFound)
+
+	// Cursor de outra ordenação é recusado
+	var page struct {
+		NextCursor string `json:"next_cursor"`
+	}
+	maria.Get("/api/box/items?limit=1&sort=manual").Expect(http.StatusOK).JSON(&page)
+	maria.Get("/api/box/items?limit=1&sort=title&cursor="+page.NextCursor).
+		ExpectError(http.StatusBadRequest, "BOX_INVALID_CURSOR")
+}
+
+func TestBoxItemArchive(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+
+	oldID := maria.Post("/api/box/items", map[string]interface{}{
+		"type":      "info",
+		"title":     "Plano de saúde antigo",
+		"is_shared": true,
+	}).Expect(http.StatusCreated).String("id")
+	maria.Post("/api/box/items", map[string]interface{}{
+		"type":      "info",
+		"title":     "Plano de saúde atual",
+		"is_shared": true,
+	}).Expect(http.StatusCreated)
+	_, token := createShareLink(t, maria, map[string]interface{}{"name": "Família", "type": "normal"})
+
+	archived := maria.WithHeader("If-Match", "*").Patch("/api/box/items/"+oldID, map[string]interface{}{"archived": true}).
+		Expect(http.StatusOK)
+	if archived.String("archived_at") == "" {
+		t.Fatalf("item sem archived_at: %s", archived.Body)
+	}
+
+	var list struct {
+		Items []struct {
+			ID         string `json:"id"`
+			ArchivedAt string `json:"archived_at"`
+		} `json:"items"`
+		Total int `json:"total"`
+	}
+	maria.Get("/api/box/items").Expect(http.StatusOK).JSON(&list)
+	if list.Total != 1 || len(list.Items) != 1 || list.Items[0].ID == oldID {
+		t.Fatalf("arquivado na listagem padrão: %+v", list)
+	}
+	maria.Get("/api/box/items?include_archived=true").Expect(http.StatusOK).JSON(&list)
+	if list.Total != 2 || len(list.Items) != 2 {
+		t.Fatalf("include_archived sem o arquivado: %+v", list)
+	}
+	maria.Get("/api/box/items?include_archived=talvez").ExpectError(http.StatusBadRequest, "BOX_INVALID_FILTER")
+
+	// Links compartilhados não mostram o arquivado
+	var view struct {
+		Items []struct {
+			Title string `json:"title"`
+		} `json:"items"`
+	}
+	h.NewClient().Get("/api/shared/" + token).Expect(http.StatusOK).JSON(&view)
+	if len(view.Items) != 1 || view.Items[0].Title != "Plano de saúde atual" {
+		t.Fatalf("link mostra o item arquivado: %+v", view)
+	}
+
+	// Arquivados contam à parte no uso (e continuam na cota)
+	usage := maria.Get("/api/box/usage").Expect(http.StatusOK).Map()
+	if usage["item_count"] != float64(2) || usage["archived_count"] != float64(1) {
+		t.Fatalf("uso inesperado: %v", usage)
+	}
+
+	// PUT sem "archived" mantém o estado; false desarquiva
+	maria.WithHeader("If-Match", "*").Put("/api/box/items/"+oldID, map[string]interface{}{"type": "info", "title": "Plano antigo"}).
+		Expect(http.StatusOK)
+	if maria.Get("/api/box/items/"+oldID).Expect(http.StatusOK).String("archived_at") == "" {
+		t.Fatal("PUT desarquivou o item")
+	}
+	maria.WithHeader("If-Match", "*").Patch("/api/box/items/"+oldID, map[string]interface{}{"archived": false}).
+		Expect(http.StatusOK)
+	maria.Get("/api/box/items").Expect(http.StatusOK).JSON(&list)
+	if list.Total != 2 {
+		t.Fatalf("desarquivado fora da listagem: %+v", list)
+	}
+}
+
+func TestBoxItemChecklist(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+	joao := h.Register("joao@example.com", "João")
+
+	type checklist []struct {
+		ID     string `json:"id"`
+		Text   string `json:"text"`
+		Done   bool   `json:"done"`
+		DoneAt string `json:"done_at"`
+	}
+	var item struct {
+		ID        string    `json:"id"`
+		Version   int       `json:"version"`
+		Checklist checklist `json:"checklist"`
+	}
+	maria.Post("/api/box/items", map[string]interface{}{
+		"type":      "routine",
+		"title":     "Cuidados com a mãe",
+		"is_shared": true,
+		"checklist": []map[string]interface{}{
+			{"text": "Remédio da pressão às 8h"},
+			{"text": "  "},
+			{"text": "Caminhada", "done": true},
+		},
+	}).Expect(http.StatusCreated).JSON(&item)
+	if len(item.Checklist) != 2 || item.Checklist[0].ID == "" || item.Checklist[0].Done ||
+		!item.Checklist[1].Done || item.Checklist[1].DoneAt == "" {
+		t.Fatalf("lista inesperada: %+v", item.Checklist)
+	}
+	path := "/api/box/items/" + item.ID
+	entryPath := path + "/checklist/" + item.Checklist[0].ID + "/done"
+
+	// Marcar não exige If-Match, mas muda a versão do item
+	checked := maria.Put(entryPath, nil).Expect(http.StatusOK)
+	if checked.Header.Get("ETag") != `"2"` {
+		t.Fatalf("ETag após marcar: %q", checked.Header.Get("ETag"))
+	}
+	checked.JSON(&item)
+	if !item.Checklist[0].Done || item.Checklist[0].DoneAt == "" {
+		t.Fatalf("linha não marcada: %+v", item.Checklist)
+	}
+	item.Checklist = nil
+	maria.Delete(entryPath).Expect(http.StatusOK).JSON(&item)
+	if item.Checklist[0].Done || item.Checklist[0].DoneAt != "" {
+		t.Fatalf("linha não desmarcada: %+v", item.Checklist)
+	}
+	maria.Put(path+"/checklist/chk_inexistente/done", nil).ExpectError(http.StatusNotFound, "BOX_CHECKLIST_ENTRY_NOT_FOUND")
+	joao.Put(entryPath, nil).Expect(http.StatusNotFound)
+
+	// PUT sem checklist mantém a lista; PATCH null remove
+	maria.WithHeader("If-Match", "*").Put(path, map[string]interface{}{"type": "routine", "title": "Cuidados", "is_shared": true}).
+		Expect(http.StatusOK).JSON(&item)
+	if len(item.Checklist) != 2 {
+		t.Fatalf("PUT apagou a lista: %+v", item.Checklist)
+	}
+
+	// O guardião vê a lista
+	token := maria.Post("/api/guardians", map[string]string{"name": "Pedro", "access_pin": "4321"}).
+		Expect(http.StatusCreated).String("access_token")
+	var view struct {
+		Items []struct {
+			Checklist checklist `json:"checklist"`
+		} `json:"items"`
+	}
+	h.NewClient().Post("/api/guardian-access/"+token+"/verify", map[string]string{"pin": "4321"}).
+		Expect(http.StatusOK).JSON(&view)
+	if len(view.Items) != 1 || len(view.Items[0].Checklist) != 2 || view.Items[0].Checklist[1].Text != "Caminhada" {
+		t.Fatalf("guardião sem a lista: %+v", view)
+	}
+
+	item.Checklist = nil
+	maria.WithHeader("If-Match", "*").Patch(path, map[string]interface{}{"checklist": nil}).Expect(http.StatusOK).JSON(&item)
+	if len(item.Checklist) != 0 {
+		t.Fatalf("PATCH null manteve a lista: %+v", item.Checklist)
+	}
+
+	// Limites
+	long := make([]map[string]interface{}, 101)
+	for i := range long {
+		long[i] = map[string]interface{}{"text": "linha"}
+	}
+	maria.WithHeader("If-Match", "*").Patch(path, map[string]interface{}{"checklist": long}).
+		ExpectError(http.StatusBadRequest, "BOX_INVALID_CHECKLIST")
+}
+
+func TestBoxItemRoutineSchedule(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+
+	created := maria.Post("/api/guardians", map[string]string{"name": "Pedro", "access_pin": "4321"}).Expect(http.StatusCreated)
+	pedroID, token := created.String("id"), created.String("access_token")
+
+	type schedule struct {
+		Medication            string   `json:"medication"`
+		Dose                  string   `json:"dose"`
+		Times                 []string `json:"times"`
+		Weekdays              []int    `json:"weekdays"`
+		ResponsibleGuardianID string   `json:"responsible_guardian_id"`
+		ResponsibleName       string   `json:"responsible_name"`
+	}
+	var item struct {
+		ID       string    `json:"id"`
+		Schedule *schedule `json:"schedule"`
+	}
+	maria.Post("/api/box/items", map[string]interface{}{
+		"type":      "routine",
+		"title":     "Remédio da pressão",
+		"is_shared": true,
+		"schedule": map[string]interface{}{
+			"medication":              "Losartana",
+			"dose":                    "50mg",
+			"times":                   []string{"20:00", "08:00", "08:00"},
+			"weekdays":                []int{0, 1, 2, 3, 4, 5, 6},
+			"responsible_guardian_id": pedroID,
+		},
+	}).Expect(http.StatusCreated).JSON(&item)
+	if item.Schedule == nil || len(item.Schedule.Times) != 2 || item.Schedule.Times[0] != "08:00" ||
+		len(item.Schedule.Weekdays) != 0 || item.Schedule.ResponsibleGuardianID != pedroID {
+		t.Fatalf("agenda inesperada: %+v", item.Schedule)
+	}
+	path := "/api/box/items/" + item.ID
+
+	// Uma caminhada só às segundas (12/10/2026) e quartas
+	maria.Post("/api/box/items", map[string]interface{}{
+		"type":     "routine",
+		"title":    "Caminhada",
+		"schedule": map[string]interface{}{"times": []string{"7:30"}, "weekdays": []int{1, 3}},
+	}).Expect(http.StatusCreated)
+
+	// Validação
+	for _, invalid := range []map[string]interface{}{
+		{"medication": "Losartana"},
+		{"times": []string{"25:00"}},
+		{"times": []string{"08:00"}, "weekdays": []int{7}},
+		{"times": []string{"08:00"}, "responsible_guardian_id": "grd_inexistente"},
+	} {
+		maria.Post("/api/box/items", map[string]interface{}{"type": "routine", "title": "Inválida", "schedule": invalid}).
+			ExpectError(http.StatusBadRequest, "BOX_INVALID_SCHEDULE")
+	}
+	maria.Post("/api/box/items", map[string]interface{}{"type": "info", "title": "Nota", "schedule": map[string]interface{}{"times": []string{"08:00"}}}).
+		ExpectError(http.StatusBadRequest, "BOX_INVALID_SCHEDULE")
+
+	// Doses do dia e dos próximos dias
+	var routines struct {
+		From    string `json:"from"`
+		Entries []struct {
+			ItemID          string `json:"item_id"`
+			Title           string `json:"title"`
+			Medication      string `json:"medication"`
+			Date            string `json:"date"`
+			Time            string `json:"time"`
+			ResponsibleName string `json:"responsible_name"`
+		} `json:"entries"`
+	}
+	maria.Get("/api/box/routines?date=2026-10-12").Expect(http.StatusOK).JSON(&routines)
+	if routines.From != "2026-10-12" || len(routines.Entries) != 3 || routines.Entries[0].Time != "07:30" ||
+		routines.Entries[1].Medication != "Losartana" || routines.Entries[1].ResponsibleName != "Pedro" ||
+		routines.Entries[2].Time != "20:00" {
+		t.Fatalf("doses de segunda inesperadas: %+v", routines)
+	}
+	routines.Entries = nil
+	maria.Get("/api/box/routines?date=2026-10-13&days=2").Expect(http.StatusOK).JSON(&routines)
+	if len(routines.Entries) != 5 || routines.Entries[2].Date != "2026-10-14" || routines.Entries[2].Title != "Caminhada" {
+		t.Fatalf("doses de terça e quarta inesperadas: %+v", routines.Entries)
+	}
+	maria.Get("/api/box/routines?days=8").ExpectError(http.StatusBadRequest, "BOX_INVALID_FILTER")
+	maria.Get("/api/box/routines?date=12/10/2026").ExpectError(http.StatusBadRequest, "BOX_INVALID_FILTER")
+	h.Register("joao@example.com", "João").Get("/api/box/routines?date=2026-10-12").Expect(http.StatusOK).JSON(&routines)
+	if len(routines.Entries) != 0 {
+		t.Fatalf("outro usuário vê as doses: %+v", routines.Entries)
+	}
+
+	// O guardião vê a agenda com o nome do responsável
+	var view struct {
+		Items []struct {
+			Schedule *schedule `json:"schedule"`
+		} `json:"items"`
+	}
+	h.NewClient().Post("/api/guardian-access/"+token+"/verify", map[string]string{"pin": "4321"}).
+		Expect(http.StatusOK).JSON(&view)
+	if len(view.Items) != 1 || view.Items[0].Schedule == nil || view.Items[0].Schedule.ResponsibleName != "Pedro" {
+		t.Fatalf("guardião sem a agenda: %+v", view)
+	}
+
+	// O cartão de emergência inclui a agenda quando o usuário escolhe
+	cardURL := maria.Put("/api/emergency-card", map[string]interface{}{"name": "Maria", "include_schedule": true}).
+		Expect(http.StatusCreated).String("url")
+	var card struct {
+		Schedule []struct {
+			Medication string   `json:"medication"`
+			Times      []string `json:"times"`
+		} `json:"schedule"`
+	}
+	public := cardURL[strings.Index(cardURL, "/api/"):]
+	h.NewClient().Get(public).Expect(http.StatusOK).JSON(&card)
+	if len(card.Schedule) != 2 || card.Schedule[1].Medication != "Losartana" || len(card.Schedule[1].Times) != 2 {
+		t.Fatalf("cartão sem a agenda: %+v", card)
+	}
+	if body := h.NewClient().Get(public + "/print").Expect(http.StatusOK).Body; !strings.Contains(string(body), "Losartana 50mg: 08:00, 20:00") {
+		t.Fatalf("cartão impresso sem a agenda: %s", body)
+	}
+
+	// PATCH null remove a agenda; deixar de ser rotina também
+	item.Schedule = nil
+	maria.WithHeader("If-Match", "*").Patch(path, map[string]interface{}{"schedule": nil}).Expect(http.StatusOK).JSON(&item)
+	if item.Schedule != nil {
+		t.Fatalf("PATCH null manteve a agenda: %+v", item.Schedule)
+	}
+}
+
+func TestBoxItemDocumentExpiry(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+
+	day := func(offset int) string { return time.Now().UTC().AddDate(0, 0, offset).Format("2006-01-02") }
+	type document struct {
+		Kind      string `json:"kind"`
+		Number    string `json:"number"`
+		ExpiresOn string `json:"expires_on"`
+	}
+	var passport struct {
+		ID       string    `json:"id"`
+		Document *document `json:"document"`
+	}
+	maria.Post("/api/box/items", map[string]interface{}{
+		"type":     "document",
+		"title":    "Passaporte",
+		"document": map[string]string{"kind": "passport", "number": "fx-123456", "expires_on": day(10)},
+	}).Expect(http.StatusCreated).JSON(&passport)
+	if passport.Document == nil || passport.Document.Kind != "passport" || passport.Document.Number != "****3456" {
+		t.Fatalf("documento inesperado: %+v", passport.Document)
+	}
+	maria.Post("/api/box/items", map[string]interface{}{
+		"type": "document", "title": "Seguro do carro",
+		"document": map[string]string{"kind": "insurance", "expires_on": day(-3)},
+	}).Expect(http.StatusCreated)
+	maria.Post("/api/box/items", map[string]interface{}{
+		"type": "document", "title": "RG",
+		"document": map[string]string{"kind": "id_card", "expires_on": day(400)},
+	}).Expect(http.StatusCreated)
+
+	// Validação
+	maria.Post("/api/box/items", map[string]interface{}{"type": "document", "title": "X", "document": map[string]string{"kind": "diploma"}}).
+		ExpectError(http.StatusBadRequest, "BOX_INVALID_DOCUMENT")
+	maria.Post("/api/box/items", map[string]interface{}{"type": "document", "title": "X", "document": map[string]string{"expires_on": "01/05/2030"}}).
+		ExpectError(http.StatusBadRequest, "BOX_INVALID_DOCUMENT")
+	maria.Post("/api/box/items", map[string]interface{}{"type": "info", "title": "X", "document": map[string]string{"kind": "passport"}}).
+		ExpectError(http.StatusBadRequest, "BOX_INVALID_DOCUMENT")
+
+	// Selos na listagem
+	var list struct {
+		Items []struct {
+			Title        string `json:"title"`
+			ExpiryStatus string `json:"expiry_status"`
+		} `json:"items"`
+	}
+	maria.Get("/api/box/items").Expect(http.StatusOK).JSON(&list)
+	status := make(map[string]string)
+	for _, item := range list.Items {
+		status[item.Title] = item.ExpiryStatus
+	}
+	if status["Passaporte"] != "expiring" || status["Seguro do carro"] != "expired" || status["RG"] != "valid" {
+		t.Fatalf("selos inesperados: %v", status)
+	}
+
+	// Vencidos e vencendo, em ordem de data
+	var expiring struct {
+		Items []struct {
+			Title    string `json:"title"`
+			Number   string `json:"number"`
+			DaysLeft int    `json:"days_left"`
+			Status   string `json:"status"`
+		} `json:"items"`
+	}
+	maria.Get("/api/box/expiring").Expect(http.StatusOK).JSON(&expiring)
+	if len(expiring.Items) != 2 || expiring.Items[0].Title != "Seguro do carro" || expiring.Items[0].DaysLeft != -3 ||
+		expiring.Items[1].Number != "****3456" || expiring.Items[1].DaysLeft != 10 {
+		t.Fatalf("vencimentos inesperados: %+v", expiring.Items)
+	}
+	maria.Get("/api/box/expiring?days=365").Expect(http.StatusOK).JSON(&expiring)
+	if len(expiring.Items) != 2 {
+		t.Fatalf("documento fora do prazo listado: %+v", expiring.Items)
+	}
+	maria.Get("/api/box/expiring?days=366").ExpectError(http.StatusBadRequest, "BOX_INVALID_FILTER")
+	h.Register("joao@example.com", "João").Get("/api/box/expiring").Expect(http.StatusOK).JSON(&expiring)
+	if len(expiring.Items) != 0 {
+		t.Fatalf("outro usuário vê os documentos: %+v", expiring.Items)
+	}
+
+	// Reenviar o número mascarado não muda nada; PATCH null remove
+	path := "/api/box/items/" + passport.ID
+	maria.WithHeader("If-Match", "*").Patch(path, map[string]interface{}{
+		"document": map[string]string{"kind": "passport", "number": "****3456", "expires_on": day(10)},
+	}).Expect(http.StatusOK).JSON(&passport)
+	if passport.Document.Number != "****3456" {
+		t.Fatalf("número mascarado de novo: %+v", passport.Document)
+	}
+	passport.Document = nil
+	maria.WithHeader("If-Match", "*").Patch(path, map[string]interface{}{"document": nil}).Expect(http.StatusOK).JSON(&passport)
+	if passport.Document != nil {
+		t.Fatalf("PATCH null manteve o documento: %+v", passport.Document)
+	}
+}
+
+func TestBoxItemPetProfile(t *testing.T) {
+	h := testutil.New(t, map[string]string{"TWILIO_ACCOUNT_SID": "AC-teste", "TWILIO_AUTH_TOKEN": "token", "TWILIO_PHONE_NUMBER": "whatsapp:+14155238886"})
+	maria := h.Register("maria@example.com", "Maria")
+	token := maria.Post("/api/guardians", map[string]string{"name": "Pedro", "access_pin": "4321"}).
+		Expect(http.StatusCreated).String("access_token")
+
+	type pet struct {
+		Name         string   `json:"name"`
+		Species      string   `json:"species"`
+		VetName      string   `json:"vet_name"`
+		VetPhone     string   `json:"vet_phone"`
+		FeedingTimes []string `json:"feeding_times"`
+		Food         string   `json:"food"`
+		Medication   string   `json:"medication"`
+	}
+	var item struct {
+		ID  string `json:"id"`
+		Pet *pet   `json:"pet"`
+	}
+	maria.Post("/api/box/items", map[string]interface{}{
+		"type": "pet", "title": "Thor", "is_shared": true,
+		"pet": map[string]interface{}{
+			"name": "Thor", "species": "dog", "vet_name": "Clínica Patas", "vet_phone": "(11) 3333-4444",
+			"feeding_times": []string{"18:00", "08:00", "08:00"}, "food": "Ração sênior, 1 xícara", "medication": "Apoquel pela manhã",
+		},
+	}).Expect(http.StatusCreated).JSON(&item)
+	if item.Pet == nil || item.Pet.Species != "dog" || item.Pet.VetPhone != "+551133334444" ||
+		strings.Join(item.Pet.FeedingTimes, ",") != "08:00,18:00" {
+		t.Fatalf("perfil inesperado: %+v", item.Pet)
+	}
+
+	// Validação
+	for _, invalid := range []map[string]interface{}{
+		{"type": "pet", "title": "X", "pet": map[string]interface{}{"name": "Mia", "species": "dragon"}},
+		{"type": "pet", "title": "X", "pet": map[string]interface{}{"species": "cat"}},
+		{"type": "pet", "title": "X", "pet": map[string]interface{}{"name": "Mia", "feeding_times": []string{"8h"}}},
+		{"type": "pet", "title": "X", "pet": map[string]interface{}{"name": "Mia", "vet_phone": "123"}},
+		{"type": "info", "title": "X", "pet": map[string]interface{}{"name": "Mia"}},
+	} {
+		maria.Post("/api/box/items", invalid).ExpectError(http.StatusBadRequest, "BOX_INVALID_PET")
+	}
+
+	// O guardião vê os pets numa seção própria
+	var view struct {
+		Items []struct {
+			Pet *pet `json:"pet"`
+		} `json:"items"`
+		Pets []struct {
+			ItemID  string `json:"item_id"`
+			Name    string `json:"name"`
+			VetName string `json:"vet_name"`
+		} `json:"pets"`
+	}
+	h.NewClient().Post("/api/guardian-access/"+token+"/verify", map[string]string{"pin": "4321"}).
+		Expect(http.StatusOK).JSON(&view)
+	if len(view.Pets) != 1 || view.Pets[0].ItemID != item.ID || view.Pets[0].VetName != "Clínica Patas" ||
+		len(view.Items) != 1 || view.Items[0].Pet == nil {
+		t.Fatalf("guardião sem a seção de pets: %+v", view)
+	}
+
+	// PATCH null remove o perfil; deixar de ser pet também
+	path := "/api/box/items/" + item.ID
+	item.Pet = nil
+	maria.WithHeader("If-Match", "*").Patch(path, map[string]interface{}{"pet": map[string]string{"name": "Thor"}}).
+		Expect(http.StatusOK).JSON(&item)
+	if item.Pet == nil || item.Pet.Species != "other" || item.Pet.VetName != "" {
+		t.Fatalf("PATCH não trocou o perfil: %+v", item.Pet)
+	}
+	item.Pet = nil
+	maria.WithHeader("If-Match", "*").Patch(path, map[string]interface{}{"pet": nil}).Expect(http.StatusOK).JSON(&item)
+	if item.Pet != nil {
+		t.Fatalf("PATCH null manteve o perfil: %+v", item.Pet)
+	}
+
+	// No WhatsApp, a categoria pets (🐾) salva o item como pet
+	const phone = "+5511988887777"
+	maria.Put("/api/settings", map[string]interface{}{"theme": "light", "messaging_opt_out": true}).Expect(http.StatusOK)
+	linkPhone(t, h, maria, phone).Expect(http.StatusOK)
+	if reply := postWebhook(t, h, phone, "A Mia come às 7h e às 19h"); !strings.Contains(reply, "6️⃣ Pets") {
+		t.Fatalf("menu sem a categoria pets: %s", reply)
+	}
+	postWebhook(t, h, phone, "6")
+	postWebhook(t, h, phone, "sim")
+	found := false
+	for _, saved := range h.Store.ListBoxItems(maria.User.ID) {
+		if saved.Category == "pets" {
+			found = saved.Type == storage.ItemTypePet
+		}
+	}
+	if !found {
+		t.Fatalf("item do WhatsApp não salvo como pet: %+v", h.Store.ListBoxItems(maria.User.ID))
+	}
+}
+
+func TestBoxItemFinancialAccount(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+	token := maria.Post("/api/guardians", map[string]string{"name": "Pedro", "access_pin": "4321"}).
+		Expect(http.StatusCreated).String("access_token")
+
+	type account struct {
+		Institution         string `json:"institution"`
+		AccountType         string `json:"account_type"`
+		Number              string `json:"number"`
+		StatementsLocation  string `json:"statements_location"`
+		CredentialsLocation string `json:"credentials_location"`
+	}
+	var item struct {
+		ID      string   `json:"id"`
+		Account *account `json:"financial_account"`
+	}
+
+	// O número completo nunca é guardado: só os 4 últimos caracteres
+	maria.Post("/api/box/items", map[string]interface{}{
+		"type": "financial_account", "title": "Conta do banco", "is_shared": true,
+		"financial_account": map[string]string{
+			"institution": "Banco do Brasil", "account_type": "checking", "number": "12345-6 / 0009876-5",
+			"statements_location": "Email pessoal, pasta Banco", "credentials_location": "Gerenciador de senhas, com a Ana",
+		},
+	}).Expect(http.StatusCreated).JSON(&item)
+	if item.Account == nil || item.Account.Number != "****8765" || item.Account.AccountType != "checking" ||
+		item.Account.StatementsLocation != "Email pessoal, pasta Banco" {
+		t.Fatalf("conta inesperada: %+v", item.Account)
+	}
+	if saved, err := h.Store.GetBoxItem(maria.User.ID, item.ID); err != nil || saved.FinancialAccount.Number != "****8765" {
+		t.Fatalf("número gravado sem máscara: %+v (%v)", saved, err)
+	}
+
+	// Validação
+	for _, invalid := range []map[string]interface{}{
+		{"type": "financial_account", "title": "X", "financial_account": map[string]string{"account_type": "savings"}},
+		{"type": "financial_account", "title": "X", "financial_account": map[string]string{"institution": "Nubank", "account_type": "crypto"}},
+		{"type": "financial_account", "title": "X", "financial_account": map[string]string{"institution": "Nubank", "number": strings.Repeat("1", 41)}},
+		{"type": "note", "title": "X", "financial_account": map[string]string{"institution": "Nubank"}},
+	} {
+		maria.Post("/api/box/items", invalid).ExpectError(http.StatusBadRequest, "BOX_INVALID_FINANCIAL_ACCOUNT")
+	}
+
+	// PATCH com o número mascarado mantém a máscara
+	path := "/api/box/items/" + item.ID
+	item.Account = nil
+	maria.WithHeader("If-Match", "*").Patch(path, map[string]interface{}{
+		"financial_account": map[string]string{"institution": "Nubank", "number": "****8765"},
+	}).Expect(http.StatusOK).JSON(&item)
+	if item.Account == nil || item.Account.Number != "****8765" || item.Account.AccountType != "other" {
+		t.Fatalf("PATCH inesperado: %+v", item.Account)
+	}
+
+	// O guardião vê a conta com o número mascarado
+	var view struct {
+		Items []struct {
+			Account *account `json:"financial_account"`
+		} `json:"items"`
+	}
+	h.NewClient().Post("/api/guardian-access/"+token+"/verify", map[string]string{"pin": "4321"}).
+		Expect(http.StatusOK).JSON(&view)
+	if len(view.Items) != 1 || view.Items[0].Account == nil || view.Items[0].Account.Number != "****8765" {
+		t.Fatalf("guardião sem a conta: %+v", view)
+	}
+
+	// As contas contam na nota de preparo
+	var report struct {
+		Areas []struct {
+			ID    string `json:"id"`
+			Count int    `json:"count"`
+			Score int    `json:"score"`
+		} `json:"areas"`
+	}
+	maria.Get("/api/box/completeness").Expect(http.StatusOK).JSON(&report)
+	last := report.Areas[len(report.Areas)-1]
+	if last.ID != "financial_accounts" || last.Count != 1 || last.Score != 8 {
+		t.Fatalf("área financeira inesperada: %+v", report.Areas)
+	}
+
+	maria.WithHeader("If-Match", "*").Patch(path, map[string]interface{}{"financial_account": nil}).Expect(http.StatusOK)
+	if saved, _ := h.Store.GetBoxItem(maria.User.ID, item.ID); saved.FinancialAccount != nil {
+		t.Fatalf("PATCH null manteve a conta: %+v", saved.FinancialAccount)
+	}
+}
+
+func TestBoxItemOnlineAccount(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+
+	// O card do Guia sugere contas comuns já com a ação
+	var guide struct {
+		Cards []struct {
+			ID        string `json:"id"`
+			Templates []struct {
+				Type          string `json:"type"`
+				OnlineAccount *struct {
+					Service string `json:"service"`
+					Action  string `json:"action"`
+				} `json:"online_account"`
+			} `json:"templates"`
+		} `json:"cards"`
+	}
+	maria.Get("/api/guide/cards").Expect(http.StatusOK).JSON(&guide)
+	found := false
+	for _, card := range guide.Cards {
+		if card.ID == "digital_legacy" {
+			found = len(card.Templates) == 3 && card.Templates[0].Type == "online_account" &&
+				card.Templates[0].OnlineAccount != nil && card.Templates[0].OnlineAccount.Service == "Google" &&
+				card.Templates[1].OnlineAccount.Action == "memorialize"
+		}
+	}
+	if !found {
+		t.Fatalf("card do legado digital ausente: %+v", guide.Cards)
+	}
+
+	for _, account := range []map[string]interface{}{
+		{"service": "Facebook", "username_hint": "maria.s... no Gmail", "action": "memorialize"},
+		{"service": "Google", "recovery_email_hint": "o Hotmail antigo", "action": "transfer"},
+		{"service": "Instagram", "action": "memorialize"},
+		{"service": "App do banco", "action": "delete"},
+	} {
+		maria.Post("/api/box/items", map[string]interface{}{
+			"type": "online_account", "title": account["service"], "content": "Falar com a Ana", "is_shared": true,
+			"online_account": account,
+		}).Expect(http.StatusCreated)
+	}
+
+	// Validação
+	for _, invalid := range []map[string]interface{}{
+		{"type": "online_account", "title": "X", "online_account": map[string]string{"action": "delete"}},
+		{"type": "online_account", "title": "X", "online_account": map[string]string{"service": "X", "action": "sell"}},
+		{"type": "online_account", "title": "X", "online_account": map[string]string{"service": "X"}},
+		{"type": "note", "title": "X", "online_account": map[string]string{"service": "X", "action": "delete"}},
+	} {
+		maria.Post("/api/box/items", invalid).ExpectError(http.StatusBadRequest, "BOX_INVALID_ONLINE_ACCOUNT")
+	}
+
+	// O link memorial agrupa as contas pela ação; os outros links não
+	type legacy struct {
+		DigitalLegacy []struct {
+			Action   string `json:"action"`
+			Accounts []struct {
+				ItemID       string `json:"item_id"`
+				Service      string `json:"service"`
+				UsernameHint string `json:"username_hint"`
+				Content      string `json:"content"`
+			} `json:"accounts"`
+		} `json:"digital_legacy"`
+	}
+	var view legacy
+	_, token := createShareLink(t, maria, map[string]interface{}{"name": "Memorial", "type": "memorial"})
+	h.NewClient().Get("/api/shared/" + token).Expect(http.StatusOK).JSON(&view)
+	if len(view.DigitalLegacy) != 3 {
+		t.Fatalf("grupos inesperados: %+v", view.DigitalLegacy)
+	}
+	memorialize, remove, transfer := view.DigitalLegacy[0], view.DigitalLegacy[1], view.DigitalLegacy[2]
+	if memorialize.Action != "memorialize" || len(memorialize.Accounts) != 2 || remove.Action != "delete" ||
+		len(remove.Accounts) != 1 || transfer.Action != "transfer" || transfer.Accounts[0].Service != "Google" ||
+		transfer.Accounts[0].Content != "Falar com a Ana" {
+		t.Fatalf("legado digital inesperado: %+v", view.DigitalLegacy)
+	}
+
+	view = legacy{}
+	_, token = createShareLink(t, maria, map[string]interface{}{"name": "Vizinho"})
+	h.NewClient().Get("/api/shared/" + token).Expect(http.StatusOK).JSON(&view)
+	if len(view.DigitalLegacy) != 0 {
+		t.Fatalf("legado digital fora do memorial: %+v", view.DigitalLegacy)
+	}
+
+	// No acesso da pessoa escolhida, os grupos aparecem depois da abertura do memorial
+	pedro := maria.Post("/api/guardians", map[string]string{"name": "Pedro", "access_pin": "4321"}).Expect(http.StatusCreated)
+	access := pedro.String("access_token")
+	verify := func() {
+		view = legacy{}
+		h.NewClient().Post("/api/guardian-access/"+access+"/verify", map[string]string{"pin": "4321"}).
+			Expect(http.StatusOK).JSON(&view)
+	}
+	maria.Put("/api/memorial", map[string]interface{}{"guardian_ids": []string{pedro.String("id")}, "delay_hours": 0}).
+		Expect(http.StatusOK)
+	if verify(); len(view.DigitalLegacy) != 0 {
+		t.Fatalf("legado digital antes da abertura: %+v", view.DigitalLegacy)
+	}
+	h.NewClient().Post("/api/guardian-access/"+access+"/memorial", map[string]string{"pin": "4321"}).Expect(http.StatusOK)
+	if verify(); len(view.DigitalLegacy) != 3 {
+		t.Fatalf("legado digital ausente após a abertura: %+v", view.DigitalLegacy)
+	}
+}
+
+func TestBoxLegalChecklist(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+
+	type entry struct {
+		Kind          string   `json:"kind"`
+		Title         string   `json:"title"`
+		Guidance      string   `json:"guidance"`
+		Recommended   bool     `json:"recommended"`
+		Status        string   `json:"status"`
+		ItemID        string   `json:"item_id"`
+		MissingFields []string `json:"missing_fields"`
+	}
+	var checklist struct {
+		Score    int     `json:"score"`
+		Complete int     `json:"complete"`
+		Total    int     `json:"total"`
+		Items    []entry `json:"items"`
+	}
+
+	// Caixa vazia: tudo faltando, com orientações
+	maria.Get("/api/box/legal-checklist").Expect(http.StatusOK).JSON(&checklist)
+	if checklist.Score != 0 || checklist.Total != 4 || len(checklist.Items) != 4 {
+		t.Fatalf("checklist inicial inesperado: %+v", checklist)
+	}
+	if will := checklist.Items[0]; will.Kind != "will" || will.Title != "Testamento" || will.Guidance == "" || will.Status != "missing" ||
+		len(will.MissingFields) != 3 || !will.Recommended {
+		t.Fatalf("testamento inesperado: %+v", will)
+	}
+	if checklist.Items[3].Kind != "guardianship" || checklist.Items[3].Recommended {
+		t.Fatalf("indicação de tutor inesperada: %+v", checklist.Items[3])
+	}
+
+	// Validação: dados jurídicos só em documentos jurídicos
+	maria.Post("/api/box/items", map[string]interface{}{
+		"type": "document", "title": "Passaporte",
+		"document": map[string]interface{}{"kind": "passport", "legal": map[string]string{"stored_at": "Gaveta"}},
+	}).ExpectError(http.StatusBadRequest, "BOX_INVALID_DOCUMENT")
+	maria.Post("/api/box/items", map[string]interface{}{
+		"type": "document", "title": "Testamento",
+		"document": map[string]interface{}{"kind": "will", "legal": map[string]string{"signed_on": "10/03/2024"}},
+	}).ExpectError(http.StatusBadRequest, "BOX_INVALID_DOCUMENT")
+
+	// Testamento completo; procuração sem advogado
+	var will struct {
+		ID       string `json:"id"`
+		Document struct {
+			Legal struct {
+				StoredAt string `json:"stored_at"`
+				SignedOn string `json:"signed_on"`
+			} `json:"legal"`
+		} `json:"document"`
+	}
+	maria.Post("/api/box/items", map[string]interface{}{
+		"type": "document", "title": "Testamento",
+		"document": map[string]interface{}{"kind": "will", "legal": map[string]string{
+			"stored_at": "Cartório do 2º Ofício", "lawyer_name": "Dra. Ana", "lawyer_contact": "(11) 99999-0000",
+			"executor_name": "Carlos", "executor_contact": "carlos@example.com", "signed_on": "2024-03-10",
+		}},
+	}).Expect(http.StatusCreated).JSON(&will)
+	if will.Document.Legal.StoredAt != "Cartório do 2º Ofício" || !strings.HasPrefix(will.Document.Legal.SignedOn, "2024-03-10") {
+		t.Fatalf("dados jurídicos inesperados: %+v", will.Document)
+	}
+	maria.Post("/api/box/items", map[string]interface{}{
+		"type": "document", "title": "Procuração",
+		"document": map[string]interface{}{"kind": "power_of_attorney", "legal": map[string]string{"stored_at": "Cofre", "executor_name": "Pedro"}},
+	}).Expect(http.StatusCreated)
+
+	checklist.Items = nil
+	maria.Get("/api/box/legal-checklist").Expect(http.StatusOK).JSON(&checklist)
+	if checklist.Items[0].Status != "complete" || checklist.Items[0].ItemID != will.ID {
+		t.Fatalf("testamento não completo: %+v", checklist.Items[0])
+	}
+	if poa := checklist.Items[1]; poa.Status != "incomplete" || len(poa.MissingFields) != 1 || poa.MissingFields[0] != "lawyer_contact" {
+		t.Fatalf("procuração inesperada: %+v", poa)
+	}
+	// 1 de 3 recomendados completo
+	if checklist.Score != 33 || checklist.Complete != 1 {
+		t.Fatalf("nota inesperada: %+v", checklist)
+	}
+
+	// O cartão de emergência mostra onde estão os documentos quando o usuário
+	// escolhe; os rótulos seguem o idioma de quem abre
+	cardURL := maria.Put("/api/emergency-card", map[string]interface{}{"include_legal": true}).Expect(http.StatusCreated).String("url")
+	var card struct {
+		Legal []struct {
+			Kind         string `json:"kind"`
+			StoredAt     string `json:"stored_at"`
+			ExecutorName string `json:"executor_name"`
+		} `json:"legal"`
+	}
+	public := cardURL[strings.Index(cardURL, "/api/"):]
+	h.NewClient().Get(public).Expect(http.StatusOK).JSON(&card)
+	if len(card.Legal) != 2 || card.Legal[0].Kind != "will" || card.Legal[0].ExecutorName != "Carlos" || card.Legal[1].StoredAt != "Cofre" {
+		t.Fatalf("cartão sem os documentos jurídicos: %+v", card)
+	}
+	body := string(h.NewClient().WithHeader("Accept-Language", "en").Get(public + "/print").Expect(http.StatusOK).Body)
+	if !strings.Contains(body, "Will: Cartório do 2º Ofício; lawyer: Dra. Ana ((11) 99999-0000); executor: Carlos (carlos@example.com)") {
+		t.Fatalf("cartão impresso sem os documentos jurídicos: %s", body)
+	}
+
+	// Arquivar tira o documento do checklist
+	maria.WithHeader("If-Match", "*").Patch("/api/box/items/"+will.ID, map[string]interface{}{"archived": true}).Expect(http.StatusOK)
+	checklist.Items = nil
+	maria.Get("/api/box/legal-checklist").Expect(http.StatusOK).JSON(&checklist)
+	if checklist.Items[0].Status != "missing" || checklist.Score != 0 {
+		t.Fatalf("item arquivado contou: %+v", checklist)
+	}
+}
+
+func TestBoxCompleteness(t *testing.T) {
+	h := testutil.New(t, map[string]string{"ADMIN_EMAILS": "admin@example.com"})
+	admin := h.Register("admin@example.com", "Admin")
+	maria := h.Register("maria@example.com", "Maria")
+
+	type area struct {
+		ID        string `json:"id"`
+		Count     int    `json:"count"`
+		Score     int    `json:"score"`
+		Complete  bool   `json:"complete"`
+		Tip       string `json:"tip"`
+		Templates []struct {
+			Type  string `json:"type"`
+			Title string `json:"title"`
+		} `json:"templates"`
+	}
+	var report struct {
+		Score int      `json:"score"`
+		Areas []area   `json:"areas"`
+		Gaps  []string `json:"gaps"`
+	}
+	areaByID := func(id string) area {
+		for _, a := range report.Areas {
+			if a.ID == id {
+				return a
+			}
+		}
+		t.Fatalf("área %s ausente: %+v", id, report.Areas)
+		return area{}
+	}
+
+	// Caixa vazia: nota 0, todas as áreas com dica e os itens com sugestões
+	maria.Get("/api/box/completeness").Expect(http.StatusOK).JSON(&report)
+	if report.Score != 0 || len(report.Areas) != 6 || len(report.Gaps) != 6 {
+		t.Fatalf("nota inicial inesperada: %+v", report)
+	}
+	if docs := areaByID("document_locations"); docs.Tip == "" || len(docs.Templates) == 0 || docs.Templates[0].Type != "location" {
+		t.Fatalf("lacuna sem dica ou sugestões: %+v", docs)
+	}
+
+	// Meta parcial pontua proporcionalmente; área completa some das lacunas
+	maria.Post("/api/box/items", map[string]interface{}{"type": "memory", "title": "Para os netos"}).Expect(http.StatusCreated)
+	maria.Post("/api/box/items", map[string]interface{}{"type": "location", "title": "Certidões"}).Expect(http.StatusCreated)
+	maria.Post("/api/guardians", map[string]string{"name": "Pedro", "email": "pedro@example.com", "access_pin": "4321"}).Expect(http.StatusCreated)
+	maria.Put("/api/emergency-card", map[string]interface{}{
+		"contacts": []map[string]string{
+			{"name": "Pedro", "phone": "(11) 99999-8888"},
+			{"name": "Ana", "phone": "(11) 98888-7777"},
+		},
+	}).Expect(http.StatusCreated)
+
+	report.Areas = nil
+	maria.Get("/api/box/completeness").Expect(http.StatusOK).JSON(&report)
+	if memory := areaByID("memorial_messages"); !memory.Complete || memory.Score != 17 || memory.Tip != "" || len(memory.Templates) != 0 {
+		t.Fatalf("área completa inesperada: %+v", memory)
+	}
+	if docs := areaByID("document_locations"); docs.Count != 1 || docs.Score != 5 {
+		t.Fatalf("pontuação parcial inesperada: %+v", docs)
+	}
+	// 17 (contatos) + 5 (1/3 dos documentos) + 0 (acessos) + 17 (mensagem) + 8 (1/2 guardiões) + 0 (contas)
+	if report.Score != 47 || len(report.Gaps) != 4 {
+		t.Fatalf("nota inesperada: %+v", report)
+	}
+
+	// O painel admin vê a média do dia (a última nota do dia vale)
+	admin.Get("/api/box/completeness").Expect(http.StatusOK)
+	var trend struct {
+		Days []struct {
+			Users        int     `json:"users"`
+			AverageScore float64 `json:"average_score"`
+			Complete     int     `json:"complete"`
+		} `json:"days"`
+	}
+	admin.Get("/api/admin/analytics/completeness").Expect(http.StatusOK).JSON(&trend)
+	if len(trend.Days) != 1 || trend.Days[0].Users != 2 || trend.Days[0].AverageScore != 23.5 {
+		t.Fatalf("evolução inesperada: %+v", trend)
+	}
+	maria.Get("/api/admin/analytics/completeness").Expect(http.StatusForbidden)
+}
@@ -0,0 +1,227 @@
+package server_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"famli/internal/security"
+	"famli/internal/storage"
+	"famli/internal/testutil"
+)
+
+func TestEmergencyCard(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+	public := h.NewClient()
+
+	if enabled := maria.Get("/api/emergency-card").Expect(http.StatusOK).Map()["enabled"]; enabled != false {
+		t.Fatalf("cartão ativo sem ter sido criado: %v", enabled)
+	}
+	maria.Put("/api/emergency-card", map[string]interface{}{"blood_type": "C+"}).
+		ExpectError(http.StatusBadRequest, "EMERGENCY_CARD_INVALID_BLOOD_TYPE")
+	maria.Put("/api/emergency-card", map[string]interface{}{
+		"contacts": []map[string]string{{"name": "Pedro", "phone": "123"}},
+	}).ExpectError(http.StatusBadRequest, "EMERGENCY_CARD_INVALID_CONTACT")
+	maria.Put("/api/emergency-card", map[string]interface{}{}).
+		ExpectError(http.StatusBadRequest, "EMERGENCY_CARD_EMPTY")
+
+	// O primeiro cartão gera o link
+	created := maria.Put("/api/emergency-card", map[string]interface{}{
+		"name":        "Maria Souza",
+		"blood_type":  "o-",
+		"contacts":    []map[string]string{{"name": "Pedro", "relationship": "filho", "phone": "(11) 99999-8888"}},
+		"medications": []string{"Losartana 50mg", " "},
+	}).Expect(http.StatusCreated)
+	url := created.String("url")
+	if !strings.Contains(url, "/api/emergency-card/") || created.String("print_url") != url+"/print" {
+		t.Fatalf("link do cartão ausente: %s", created.Body)
+	}
+	path := url[strings.Index(url, "/api/"):]
+
+	var card struct {
+		Name      string `json:"name"`
+		BloodType string `json:"blood_type"`
+		Contacts  []struct {
+			Name  string `json:"name"`
+			Phone string `json:"phone"`
+		} `json:"contacts"`
+		Medications []string `json:"medications"`
+	}
+	resp := public.Get(path).Expect(http.StatusOK)
+	resp.JSON(&card)
+	if card.Name != "Maria Souza" || card.BloodType != "O-" || len(card.Contacts) != 1 ||
+		card.Contacts[0].Phone != "+5511999998888" || len(card.Medications) != 1 {
+		t.Fatalf("cartão público inesperado: %s", resp.Body)
+	}
+	if _, leaked := resp.Map()["last_accessed_at"]; leaked {
+		t.Fatalf("cartão público expõe dados do dono: %s", resp.Body)
+	}
+	if resp.Header.Get("X-Robots-Tag") == "" {
+		t.Fatalf("cartão público sem X-Robots-Tag")
+	}
+
+	printed := public.Get(path + "/print").Expect(http.StatusOK)
+	if !strings.HasPrefix(printed.Header.Get("Content-Type"), "text/html") ||
+		!strings.Contains(string(printed.Body), "Losartana 50mg") || !strings.Contains(string(printed.Body), "5511999998888") {
+		t.Fatalf("cartão impresso inesperado: %s", printed.Body)
+	}
+
+	// Editar mantém o link; o dono vê o último acesso
+	maria.Put("/api/emergency-card", map[string]interface{}{"name": "Maria S. Souza"}).Expect(http.StatusOK)
+	public.Get(path).Expect(http.StatusOK)
+	if maria.Get("/api/emergency-card").Expect(http.StatusOK).Map()["last_accessed_at"] == nil {
+		t.Fatalf("último acesso não registrado")
+	}
+
+	// Trocar o link invalida o anterior
+	rotated := maria.Post("/api/emergency-card/rotate", nil).Expect(http.StatusOK).String("url")
+	public.Get(path).ExpectError(http.StatusNotFound, "EMERGENCY_CARD_NOT_FOUND")
+	newPath := rotated[strings.Index(rotated, "/api/"):]
+	public.Get(newPath).Expect(http.StatusOK)
+
+	maria.Delete("/api/emergency-card").Expect(http.StatusOK)
+	public.Get(newPath).ExpectError(http.StatusNotFound, "EMERGENCY_CARD_NOT_FOUND")
+	maria.Delete("/api/emergency-card").Expect(http.StatusNotFound)
+}
+
+func TestEmergencyEscalation(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+	createGuardian := func(name, email string) string {
+		return maria.Post("/api/guardians", map[string]string{"name": name, "email": email, "access_pin": "4321"}).
+			Expect(http.StatusCreated).String("id")
+	}
+	noContactID := createGuardian("Sem contato", "")
+	pedroID := createGuardian("Pedro", "pedro@example.com")
+	anaID := createGuardian("Ana", "ana@example.com")
+
+	maria.Put("/api/emergency-escalation", map[string]interface{}{"guardian_ids": []string{pedroID, pedroID}}).
+		ExpectError(http.StatusBadRequest, "EMERGENCY_ESCALATION_INVALID_GUARDIANS")
+	maria.Put("/api/emergency-escalation", map[string]interface{}{"guardian_ids": []string{"grd_outro"}}).
+		ExpectError(http.StatusBadRequest, "EMERGENCY_ESCALATION_INVALID_GUARDIANS")
+	maria.Put("/api/emergency-escalation", map[string]interface{}{"guardian_ids": []string{pedroID}, "ack_timeout_minutes": 2}).
+		ExpectError(http.StatusBadRequest, "EMERGENCY_ESCALATION_INVALID_TIMEOUT")
+
+	saved := maria.Put("/api/emergency-escalation", map[string]interface{}{
+		"guardian_ids": []string{noContactID, pedroID, anaID},
+	}).Expect(http.StatusOK).Map()
+	chain := saved["chain"].(map[string]interface{})
+	guardians := saved["guardians"].([]interface{})
+	if chain["ack_timeout_minutes"] != float64(30) || len(guardians) != 3 || guardians[1].(map[string]interface{})["name"] != "Pedro" {
+		t.Fatalf("lista salva: %v", saved)
+	}
+
+	// A emergência chama a lista em ordem: quem não tem contato é pulado na
+	// hora e Pedro fica aguardando a confirmação; Ana ainda não é avisada
+	_, token := createShareLink(t, maria, map[string]interface{}{"type": "emergency"})
+	h.NewClient().Get("/api/shared/" + token).Expect(http.StatusOK)
+
+	type escalation struct {
+		Status      string `json:"status"`
+		CurrentStep int    `json:"current_step"`
+		NextStepAt  string `json:"next_step_at"`
+		Steps       []struct {
+			GuardianID   string `json:"guardian_id"`
+			GuardianName string `json:"guardian_name"`
+			Skipped      bool   `json:"skipped"`
+			NotifiedAt   string `json:"notified_at"`
+		} `json:"steps"`
+	}
+	var state struct {
+		Latest *escalation `json:"latest"`
+	}
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		maria.Get("/api/emergency-escalation").Expect(http.StatusOK).JSON(&state)
+		if state.Latest != nil && state.Latest.CurrentStep == 1 {
+			break
+		}
+	}
+	latest := state.Latest
+	if latest == nil || latest.Status != "active" || latest.CurrentStep != 1 || latest.NextStepAt == "" || len(latest.Steps) != 3 ||
+		!latest.Steps[0].Skipped || latest.Steps[1].GuardianName != "Pedro" || latest.Steps[1].NotifiedAt == "" || latest.Steps[2].NotifiedAt != "" {
+		t.Fatalf("escalonamento inesperado: %+v", latest)
+	}
+
+	alerts, _ := h.Store.ListGuardianAlerts(maria.User.ID, 10)
+	var ackToken string
+	for _, alert := range alerts {
+		if alert.Event != storage.GuardianAlertEscalation || alert.GuardianID == anaID {
+			t.Fatalf("aviso inesperado: %+v", alert)
+		}
+		if alert.GuardianID == pedroID {
+			_, ackToken, _ = strings.Cut(alert.Message, "/api/emergency-ack/")
+			ackToken, _, _ = strings.Cut(ackToken, "\n")
+		}
+	}
+	if len(alerts) != 2 || ackToken == "" {
+		t.Fatalf("avisos da lista: %+v", alerts)
+	}
+
+	// Abrir o link não confirma; o navegador recebe a página com o botão
+	ackPath := "/api/emergency-ack/" + ackToken
+	public := h.NewClient()
+	status := public.Get(ackPath).Expect(http.StatusOK).Map()
+	if status["status"] != "active" || status["owner_name"] != "Maria" || status["guardian_name"] != "Pedro" {
+		t.Fatalf("situação do alerta: %v", status)
+	}
+	page := public.WithHeader("Accept", "text/html").Get(ackPath).Expect(http.StatusOK)
+	if !strings.Contains(string(page.Body), `<form method="post">`) || page.Header.Get("X-Robots-Tag") == "" {
+		t.Fatalf("página de confirmação: %s", page.Body)
+	}
+
+	acked := public.Post(ackPath, nil).Expect(http.StatusOK).Map()
+	if acked["status"] != "acknowledged" || acked["acknowledged_by_name"] != "Pedro" || acked["acknowledged_at"] == nil {
+		t.Fatalf("confirmação: %v", acked)
+	}
+	if again := public.Post(ackPath, nil).Expect(http.StatusOK).Map(); again["acknowledged_at"] != acked["acknowledged_at"] {
+		t.Fatalf("confirmar de novo não deveria mudar nada: %v", again)
+	}
+	state.Latest = nil
+	maria.Get("/api/emergency-escalation").Expect(http.StatusOK).JSON(&state)
+	if state.Latest.Status != "acknowledged" || state.Latest.NextStepAt != "" {
+		t.Fatalf("escalonamento confirmado: %+v", state.Latest)
+	}
+	maria.Post("/api/emergency-escalation/cancel", nil).ExpectError(http.StatusNotFound, "EMERGENCY_ESCALATION_NOT_ACTIVE")
+	public.Post("/api/emergency-ack/"+strings.Repeat("0", 32), nil).ExpectError(http.StatusNotFound, "EMERGENCY_ESCALATION_ACK_NOT_FOUND")
+
+	// O dono é avisado da confirmação
+	found := false
+	for deadline := time.Now().Add(2 * time.Second); !found && time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		var inbox struct {
+			Notifications []struct {
+				Title string `json:"title"`
+			} `json:"notifications"`
+		}
+		maria.Get("/api/notifications").Expect(http.StatusOK).JSON(&inbox)
+		for _, n := range inbox.Notifications {
+			found = found || n.Title == "Alerta de emergência confirmado"
+		}
+	}
+	if !found {
+		t.Fatal("o dono não foi avisado da confirmação")
+	}
+
+	// Alarme falso: o dono encerra; a confirmação depois disso só mostra o estado
+	cancelToken := strings.Repeat("a", 32)
+	notifiedAt := time.Now()
+	next := notifiedAt.Add(30 * time.Minute)
+	h.Store.CreateEscalation(&storage.EmergencyEscalation{
+		ID:                "esc_teste",
+		UserID:            maria.User.ID,
+		Status:            storage.EscalationActive,
+		Steps:             []storage.EscalationStep{{GuardianID: anaID, NotifiedAt: &notifiedAt, AckTokenHash: security.HashToken(cancelToken)}},
+		AckTimeoutMinutes: 30,
+		NextStepAt:        &next,
+	})
+	if cancelled := maria.Post("/api/emergency-escalation/cancel", nil).Expect(http.StatusOK).Map(); cancelled["status"] != "cancelled" {
+		t.Fatalf("escalonamento encerrado: %v", cancelled)
+	}
+	if late := public.Post("/api/emergency-ack/"+cancelToken, nil).Expect(http.StatusOK).Map(); late["status"] != "cancelled" {
+		t.Fatalf("confirmação após encerrar: %v", late)
+	}
+
+	maria.Delete("/api/emergency-escalation").Expect(http.StatusNoContent)
+	maria.Delete("/api/emergency-escalation").ExpectError(http.StatusNotFound, "EMERGENCY_ESCALATION_NOT_FOUND")
+}
@@ -0,0 +1,42 @@
+package server_test
+
+import (
+	"net/http"
+	"testing"
+
+	"famli/internal/testutil"
+)
+
+func TestErrorResponseFormat(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+
+	resp := maria.Get("/api/box/items/inexistente").ExpectError(http.StatusNotFound, "BOX_NOT_FOUND")
+	body := resp.Map()
+	if body["message"] == "" || body["message"] != body["error"] {
+		t.Fatalf("message e error devem ser iguais: %s", resp.Body)
+	}
+	if body["request_id"] == nil || body["request_id"] == "" {
+		t.Fatalf("request_id ausente: %s", resp.Body)
+	}
+
+	// A mensagem segue o idioma da requisição; o código não muda
+	visitor := h.NewClient()
+	pt := visitor.Get("/api/shared/token-invalido").Expect(http.StatusNotFound)
+	visitor.SetHeader("Accept-Language", "en")
+	en := visitor.Get("/api/shared/token-invalido").ExpectError(http.StatusNotFound, pt.String("code"))
+	if en.String("message") == pt.String("message") {
+		t.Fatalf("mensagem não traduzida: %s", en.Body)
+	}
+}
+
+func TestMiddlewareErrorCodes(t *testing.T) {
+	h := testutil.New(t, nil)
+
+	h.NewClient().Get("/api/box/items").ExpectError(http.StatusUnauthorized, "SESSION_NOT_FOUND")
+
+	maria := h.Register("maria@example.com", "Maria")
+	maria.SetHeader("Origin", "https://evil.example")
+	maria.Post("/api/box/items", map[string]string{"title": "Banco", "type": "info"}).
+		ExpectError(http.StatusForbidden, "SECURITY_CSRF_FAILED")
+}
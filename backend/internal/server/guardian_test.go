@@ -0,0 +1,822 @@
+package server_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"famli/internal/storage"
+	"famli/internal/testutil"
+)
+
+func TestGuardianCRUD(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+
+	maria.Post("/api/guardians", map[string]string{"name": "Sem PIN"}).Expect(http.StatusBadRequest)
+
+	created := maria.Post("/api/guardians", map[string]string{
+		"name":         "Pedro",
+		"email":        "pedro@example.com",
+		"relationship": "filho",
+		"access_pin":   "4321",
+	}).Expect(http.StatusCreated)
+	guardianID := created.String("id")
+	if guardianID == "" || created.String("access_token") == "" {
+		t.Fatalf("guardião criado sem id ou token: %s", created.Body)
+	}
+	if _, leaked := created.Map()["access_pin"]; leaked {
+		t.Fatalf("PIN exposto na resposta: %s", created.Body)
+	}
+
+	maria.Put("/api/guardians/"+guardianID, map[string]string{
+		"name":         "Pedro Silva",
+		"relationship": "filho",
+	}).Expect(http.StatusOK)
+
+	var list struct {
+		Guardians []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"guardians"`
+	}
+	maria.Get("/api/guardians").Expect(http.StatusOK).JSON(&list)
+	if len(list.Guardians) != 1 || list.Guardians[0].Name != "Pedro Silva" {
+		t.Fatalf("listagem inesperada: %+v", list)
+	}
+
+	// Outra conta não enxerga nem altera o guardião
+	joao := h.Register("joao@example.com", "João")
+	joao.Get("/api/guardians").Expect(http.StatusOK).JSON(&list)
+	if len(list.Guardians) != 0 {
+		t.Fatalf("João vê guardiões de outra conta: %+v", list)
+	}
+	joao.Delete("/api/guardians/" + guardianID).Expect(http.StatusNotFound)
+
+	maria.Delete("/api/guardians/" + guardianID).Expect(http.StatusOK)
+	maria.Get("/api/guardians").Expect(http.StatusOK).JSON(&list)
+	if len(list.Guardians) != 0 {
+		t.Fatalf("guardião não removido: %+v", list)
+	}
+}
+
+func TestGuardianAccessWithPIN(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+
+	maria.Post("/api/box/items", map[string]interface{}{
+		"type":      "info",
+		"title":     "Plano de saúde",
+		"is_shared": true,
+	}).Expect(http.StatusCreated)
+	maria.Post("/api/box/items", map[string]interface{}{
+		"type":  "note",
+		"title": "Diário",
+	}).Expect(http.StatusCreated)
+
+	token := maria.Post("/api/guardians", map[string]string{
+		"name":       "Pedro",
+		"access_pin": "4321",
+	}).Expect(http.StatusCreated).String("access_token")
+
+	guardian := h.NewClient()
+	preview := guardian.Get("/api/guardian-access/" + token).Expect(http.StatusOK).Map()
+	if preview["requires_pin"] != true {
+		t.Fatalf("acesso sem exigir PIN: %v", preview)
+	}
+
+	guardian.Post("/api/guardian-access/"+token+"/verify", map[string]string{"pin": "0000"}).Expect(http.StatusUnauthorized)
+
+	var view struct {
+		Items []struct {
+			Title string `json:"title"`
+		} `json:"items"`
+	}
+	guardian.Post("/api/guardian-access/"+token+"/verify", map[string]string{"pin": "4321"}).
+		Expect(http.StatusOK).JSON(&view)
+	if len(view.Items) != 1 || view.Items[0].Title != "Plano de saúde" {
+		t.Fatalf("guardião deveria ver apenas o item compartilhado: %+v", view)
+	}
+
+	guardian.Get("/api/guardian-access/token-invalido").Expect(http.StatusNotFound)
+}
+
+func TestGuardianRotateToken(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+
+	created := maria.Post("/api/guardians", map[string]string{
+		"name":       "Pedro",
+		"access_pin": "4321",
+	}).Expect(http.StatusCreated)
+	guardianID, oldToken := created.String("id"), created.String("access_token")
+
+	// Listar não altera o token
+	var list struct {
+		Guardians []struct {
+			AccessToken string `json:"access_token"`
+		} `json:"guardians"`
+	}
+	maria.Get("/api/guardians").Expect(http.StatusOK).JSON(&list)
+	if len(list.Guardians) != 1 || list.Guardians[0].AccessToken != oldToken {
+		t.Fatalf("listagem mudou o token: %+v", list)
+	}
+
+	joao := h.Register("joao@example.com", "João")
+	joao.Post("/api/guardians/"+guardianID+"/rotate-token", nil).ExpectError(http.StatusNotFound, "GUARDIAN_NOT_FOUND")
+
+	newToken := maria.Post("/api/guardians/"+guardianID+"/rotate-token", nil).Expect(http.StatusOK).String("access_token")
+	if newToken == "" || newToken == oldToken {
+		t.Fatalf("token não foi trocado: %q", newToken)
+	}
+
+	// O link antigo para de funcionar; o novo usa o mesmo PIN
+	guardian := h.NewClient()
+	guardian.Get("/api/guardian-access/" + oldToken).Expect(http.StatusNotFound)
+	guardian.Post("/api/guardian-access/"+newToken+"/verify", map[string]string{"pin": "4321"}).Expect(http.StatusOK)
+
+	notified := false
+	for deadline := time.Now().Add(2 * time.Second); !notified && time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		var inbox struct {
+			Notifications []struct {
+				Title string `json:"title"`
+			} `json:"notifications"`
+		}
+		maria.Get("/api/notifications").Expect(http.StatusOK).JSON(&inbox)
+		for _, n := range inbox.Notifications {
+			notified = notified || n.Title == "Novo link de acesso gerado"
+		}
+	}
+	if !notified {
+		t.Fatal("dono não foi avisado do novo link")
+	}
+}
+
+func TestGuardianDisableAccess(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+
+	created := maria.Post("/api/guardians", map[string]string{
+		"name":       "Pedro",
+		"access_pin": "4321",
+	}).Expect(http.StatusCreated)
+	path, token := "/api/guardians/"+created.String("id"), created.String("access_token")
+
+	// Enviar o link exige email cadastrado (o token não muda)
+	maria.Post(path+"/rotate-token", map[string]bool{"send_link": true}).
+		ExpectError(http.StatusBadRequest, "GUARDIAN_EMAIL_REQUIRED_FOR_LINK")
+
+	disabled := maria.Post(path+"/disable-access", nil).Expect(http.StatusOK)
+	if disabled.String("access_disabled_at") == "" || disabled.String("access_token") != token {
+		t.Fatalf("acesso não desativado: %s", disabled.Body)
+	}
+
+	guardian := h.NewClient()
+	guardian.Get("/api/guardian-access/"+token).ExpectError(http.StatusNotFound, "SHARE_LINK_EXPIRED")
+	guardian.Post("/api/guardian-access/"+token+"/verify", map[string]string{"pin": "4321"}).
+		ExpectError(http.StatusNotFound, "SHARE_LINK_EXPIRED")
+
+	// O cadastro continua na lista
+	var list struct {
+		Guardians []struct {
+			AccessDisabledAt *time.Time `json:"access_disabled_at"`
+		} `json:"guardians"`
+	}
+	maria.Get("/api/guardians").Expect(http.StatusOK).JSON(&list)
+	if len(list.Guardians) != 1 || list.Guardians[0].AccessDisabledAt == nil {
+		t.Fatalf("guardião desativado fora da lista: %+v", list)
+	}
+
+	maria.Post(path+"/enable-access", nil).Expect(http.StatusOK)
+	guardian.Post("/api/guardian-access/"+token+"/verify", map[string]string{"pin": "4321"}).Expect(http.StatusOK)
+
+	h.Register("joao@example.com", "João").Post(path+"/disable-access", nil).
+		ExpectError(http.StatusNotFound, "GUARDIAN_NOT_FOUND")
+}
+
+func TestGuardianPatch(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+
+	created := maria.Post("/api/guardians", map[string]string{
+		"name":         "Pedro",
+		"email":        "pedro@example.com",
+		"relationship": "filho",
+		"access_pin":   "4321",
+	}).Expect(http.StatusCreated)
+	path := "/api/guardians/" + created.String("id")
+
+	updated := maria.Patch(path, map[string]string{"notes": "Mora em Curitiba"}).Expect(http.StatusOK)
+	if updated.String("name") != "Pedro" || updated.String("email") != "pedro@example.com" ||
+		updated.String("relationship") != "filho" || updated.String("notes") != "Mora em Curitiba" {
+		t.Fatalf("PATCH alterou campos não enviados: %s", updated.Body)
+	}
+
+	// O canal é validado contra o resultado da mesclagem
+	maria.Patch(path, map[string]string{"notify_channel": "whatsapp"}).
+		ExpectError(http.StatusBadRequest, "GUARDIAN_PHONE_REQUIRED_FOR_CHANNEL")
+	maria.Patch(path, map[string]string{"notify_channel": "whatsapp", "phone": "+5511999999999"}).
+		Expect(http.StatusOK)
+	maria.Patch(path, map[string]interface{}{"email": nil, "notify_channel": "email"}).
+		ExpectError(http.StatusBadRequest, "GUARDIAN_EMAIL_REQUIRED_FOR_CHANNEL")
+	maria.Patch(path, map[string]interface{}{"name": nil}).
+		ExpectError(http.StatusBadRequest, "GUARDIAN_NAME_REQUIRED")
+
+	joao := h.Register("joao@example.com", "João")
+	joao.Patch(path, map[string]string{"name": "Outro"}).ExpectError(http.StatusNotFound, "GUARDIAN_NOT_FOUND")
+}
+
+func TestGuardianOwnDataExportAndDeletion(t *testing.T) {
+	h := testutil.New(t, map[string]string{"GUARDIAN_DELETION_GRACE_DAYS": "15"})
+	maria := h.Register("maria@example.com", "Maria")
+
+	itemID := maria.Post("/api/box/items", map[string]interface{}{
+		"type":      "info",
+		"title":     "Plano de saúde",
+		"is_shared": true,
+	}).Expect(http.StatusCreated).String("id")
+	token := maria.Post("/api/guardians", map[string]string{
+		"name":       "Pedro",
+		"email":      "pedro@example.com",
+		"phone":      "+5511999990000",
+		"access_pin": "4321",
+	}).Expect(http.StatusCreated).String("access_token")
+
+	guardian := h.NewClient()
+	guardian.Post("/api/guardian-access/"+token+"/verify", map[string]string{"pin": "4321"}).Expect(http.StatusOK)
+
+	// Cópia dos dados exige o PIN
+	guardian.Post("/api/guardian-access/"+token+"/my-data", map[string]string{"pin": "0000"}).Expect(http.StatusUnauthorized)
+	var export struct {
+		Guardian struct {
+			Name  string `json:"name"`
+			Email string `json:"email"`
+			Phone string `json:"phone"`
+		} `json:"guardian"`
+		Owner struct {
+			Name string `json:"name"`
+		} `json:"owner"`
+		ItemViews []struct {
+			ItemID    string `json:"item_id"`
+			ViewCount int    `json:"view_count"`
+		} `json:"item_views"`
+	}
+	guardian.Post("/api/guardian-access/"+token+"/my-data", map[string]string{"pin": "4321"}).Expect(http.StatusOK).JSON(&export)
+	if export.Guardian.Email != "pedro@example.com" || export.Guardian.Phone != "+5511999990000" {
+		t.Fatalf("dados do guardião incompletos: %+v", export)
+	}
+	if export.Owner.Name == "Maria" {
+		t.Fatalf("nome do dono deveria vir mascarado: %+v", export.Owner)
+	}
+	if len(export.ItemViews) != 1 || export.ItemViews[0].ItemID != itemID || export.ItemViews[0].ViewCount != 1 {
+		t.Fatalf("recibos de leitura inesperados: %+v", export.ItemViews)
+	}
+
+	// Pedido de remoção: 202 com a data prevista; repetir mantém a data
+	var deletion struct {
+		RequestedAt time.Time `json:"requested_at"`
+		DeletesAt   time.Time `json:"deletes_at"`
+	}
+	guardian.Post("/api/guardian-access/"+token+"/deletion-request", map[string]string{"pin": "4321"}).
+		Expect(http.StatusAccepted).JSON(&deletion)
+	if got := deletion.DeletesAt.Sub(deletion.RequestedAt); got != 15*24*time.Hour {
+		t.Fatalf("carência de 15 dias esperada, recebido %v", got)
+	}
+	var again struct {
+		RequestedAt time.Time `json:"requested_at"`
+	}
+	guardian.Post("/api/guardian-access/"+token+"/deletion-request", map[string]string{"pin": "4321"}).
+		Expect(http.StatusAccepted).JSON(&again)
+	if !again.RequestedAt.Equal(deletion.RequestedAt) {
+		t.Fatalf("novo pedido mudou a data: %v != %v", again.RequestedAt, deletion.RequestedAt)
+	}
+
+	// O dono vê o pedido na lista e recebe um aviso
+	var list struct {
+		Guardians []struct {
+			DeletionRequestedAt *time.Time `json:"deletion_requested_at"`
+		} `json:"guardians"`
+	}
+	maria.Get("/api/guardians").Expect(http.StatusOK).JSON(&list)
+	if len(list.Guardians) != 1 || list.Guardians[0].DeletionRequestedAt == nil {
+		t.Fatalf("pedido de remoção não aparece para o dono: %+v", list)
+	}
+	notified := false
+	for deadline := time.Now().Add(2 * time.Second); !notified && time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		var inbox struct {
+			Notifications []struct {
+				Title string `json:"title"`
+			} `json:"notifications"`
+		}
+		maria.Get("/api/notifications").Expect(http.StatusOK).JSON(&inbox)
+		for _, n := range inbox.Notifications {
+			notified = notified || n.Title == "Pessoa de confiança pediu remoção"
+		}
+	}
+	if !notified {
+		t.Fatal("dono não foi avisado do pedido de remoção")
+	}
+
+	// Antes da carência nada é apagado; depois, o guardião e os recibos somem
+	if purged, err := h.Store.PurgeGuardiansPendingDeletion(deletion.RequestedAt.Add(-time.Minute)); err != nil || purged != 0 {
+		t.Fatalf("remoção antes da carência: %d, %v", purged, err)
+	}
+	if purged, err := h.Store.PurgeGuardiansPendingDeletion(deletion.DeletesAt.AddDate(0, 0, -15)); err != nil || purged != 1 {
+		t.Fatalf("remoção após a carência: %d, %v", purged, err)
+	}
+	maria.Get("/api/guardians").Expect(http.StatusOK).JSON(&list)
+	if len(list.Guardians) != 0 {
+		t.Fatalf("guardião não foi apagado: %+v", list)
+	}
+	if views, _ := h.Store.ListItemViews(itemID); len(views) != 0 {
+		t.Fatalf("recibos do guardião não foram apagados: %+v", views)
+	}
+	guardian.Post("/api/guardian-access/"+token+"/my-data", map[string]string{"pin": "4321"}).Expect(http.StatusNotFound)
+}
+
+func TestGuardianEmergencyAlerts(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+
+	pedroID := maria.Post("/api/guardians", map[string]string{
+		"name":       "Pedro",
+		"email":      "pedro@example.com",
+		"access_pin": "4321",
+	}).Expect(http.StatusCreated).String("id")
+	maria.Post("/api/guardians", map[string]string{"name": "Sem contato", "access_pin": "1234"}).Expect(http.StatusCreated)
+
+	_, token := createShareLink(t, maria, map[string]interface{}{"type": "emergency"})
+	h.NewClient().Get("/api/shared/" + token).Expect(http.StatusOK)
+
+	type alert struct {
+		GuardianID    string     `json:"guardian_id"`
+		GuardianName  string     `json:"guardian_name"`
+		Event         string     `json:"event"`
+		Status        string     `json:"status"`
+		Attempts      int        `json:"attempts"`
+		Error         string     `json:"error"`
+		NextAttemptAt *time.Time `json:"next_attempt_at"`
+		Log           []struct {
+			Channel string `json:"channel"`
+			Success bool   `json:"success"`
+			Error   string `json:"error"`
+		} `json:"log"`
+	}
+	var list struct {
+		Alerts []alert `json:"alerts"`
+	}
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		list.Alerts = nil
+		maria.Get("/api/guardians/alerts").Expect(http.StatusOK).JSON(&list)
+		if len(list.Alerts) == 2 && list.Alerts[0].Attempts > 0 && list.Alerts[1].Attempts > 0 {
+			break
+		}
+	}
+	if len(list.Alerts) != 2 {
+		t.Fatalf("esperava um aviso por guardião: %+v", list.Alerts)
+	}
+
+	for _, a := range list.Alerts {
+		if a.Event != "emergency.activated" || a.Attempts != 1 {
+			t.Fatalf("aviso inesperado: %+v", a)
+		}
+		if a.GuardianID == pedroID {
+			// Sem email configurado no teste: falha e fica para retentativa
+			if a.GuardianName != "Pedro" || a.Status != "pending" || a.NextAttemptAt == nil || a.Error == "" {
+				t.Fatalf("aviso a Pedro deveria aguardar retentativa: %+v", a)
+			}
+			if len(a.Log) != 1 || a.Log[0].Channel != "email" || a.Log[0].Success || a.Log[0].Error == "" {
+				t.Fatalf("tentativa por email não registrada: %+v", a.Log)
+			}
+		} else if a.Status != "failed" || a.NextAttemptAt != nil || len(a.Log) != 0 {
+			t.Fatalf("guardião sem contato não tem como ser avisado: %+v", a)
+		}
+	}
+
+	// Acessos seguintes ao link não avisam de novo; outros usuários não veem os avisos
+	h.NewClient().Get("/api/shared/" + token).Expect(http.StatusOK)
+	joao := h.Register("joao@example.com", "João")
+	var other struct {
+		Alerts []alert `json:"alerts"`
+	}
+	joao.Get("/api/guardians/alerts").Expect(http.StatusOK).JSON(&other)
+	if len(other.Alerts) != 0 {
+		t.Fatalf("avisos de outro usuário: %+v", other.Alerts)
+	}
+	list.Alerts = nil
+	maria.Get("/api/guardians/alerts").Expect(http.StatusOK).JSON(&list)
+	if len(list.Alerts) != 2 {
+		t.Fatalf("segundo acesso não deveria gerar avisos: %+v", list.Alerts)
+	}
+}
+
+func TestGuardianNotify(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+
+	pedroID := maria.Post("/api/guardians", map[string]string{
+		"name":         "Pedro",
+		"email":        "pedro@example.com",
+		"relationship": "filho",
+		"access_pin":   "4321",
+	}).Expect(http.StatusCreated).String("id")
+	maria.Post("/api/guardians", map[string]string{
+		"name":       "Ana",
+		"phone":      "+5511999990000",
+		"access_pin": "1234",
+	}).Expect(http.StatusCreated)
+	carlaID := maria.Post("/api/guardians", map[string]string{"name": "Carla", "email": "carla@example.com", "access_pin": "1234"}).
+		Expect(http.StatusCreated).String("id")
+	maria.Post("/api/guardians/"+carlaID+"/disable-access", nil).Expect(http.StatusOK)
+
+	type alert struct {
+		ID           string   `json:"id"`
+		GuardianID   string   `json:"guardian_id"`
+		GuardianName string   `json:"guardian_name"`
+		Event        string   `json:"event"`
+		Status       string   `json:"status"`
+		Attempts     int      `json:"attempts"`
+		Channels     []string `json:"channels"`
+		Log          []struct {
+			Channel string `json:"channel"`
+		} `json:"log"`
+	}
+	var sent struct {
+		Alerts []alert `json:"alerts"`
+	}
+
+	// Só Pedro, por email: o texto é preenchido com os dados do guardião
+	maria.Post("/api/guardians/notify", map[string]interface{}{
+		"message":      "Oi, {name} ({relationship})! {owner} mudou a chave de lugar.",
+		"guardian_ids": []string{pedroID, pedroID},
+		"channels":     []string{"email"},
+	}).Expect(http.StatusAccepted).JSON(&sent)
+	if len(sent.Alerts) != 1 {
+		t.Fatalf("esperava um aviso: %+v", sent.Alerts)
+	}
+	if a := sent.Alerts[0]; a.GuardianID != pedroID || a.GuardianName != "Pedro" || a.Event != "owner.message" ||
+		a.Status != "pending" || len(a.Channels) != 1 || a.Channels[0] != "email" {
+		t.Fatalf("aviso inesperado: %+v", a)
+	}
+	stored, err := h.Store.ListGuardianAlerts(maria.User.ID, 10)
+	if err != nil || len(stored) != 1 {
+		t.Fatalf("aviso não registrado: %v %+v", err, stored)
+	}
+	if want := "Oi, Pedro (filho)! Maria mudou a chave de lugar."; stored[0].Message != want {
+		t.Fatalf("mensagem = %q, esperava %q", stored[0].Message, want)
+	}
+
+	// Sem guardian_ids: todos com acesso ativo (Carla está desativada)
+	sent.Alerts = nil
+	maria.Post("/api/guardians/notify", map[string]string{"message": "Recado para todos"}).
+		Expect(http.StatusAccepted).JSON(&sent)
+	if len(sent.Alerts) != 2 {
+		t.Fatalf("esperava avisos para Pedro e Ana: %+v", sent.Alerts)
+	}
+	for _, a := range sent.Alerts {
+		if a.GuardianID == carlaID || len(a.Channels) != 0 {
+			t.Fatalf("aviso inesperado: %+v", a)
+		}
+	}
+
+	// Canal sem contato (Pedro não tem telefone): o aviso falha sem tentativas por canal
+	sent.Alerts = nil
+	maria.Post("/api/guardians/notify", map[string]interface{}{
+		"message":      "Só por SMS",
+		"guardian_ids": []string{pedroID},
+		"channels":     []string{"sms"},
+	}).Expect(http.StatusAccepted).JSON(&sent)
+	smsID := sent.Alerts[0].ID
+	var list struct {
+		Alerts []alert `json:"alerts"`
+	}
+	var sms alert
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		list.Alerts = nil
+		maria.Get("/api/guardians/alerts").Expect(http.StatusOK).JSON(&list)
+		for _, a := range list.Alerts {
+			if a.ID == smsID {
+				sms = a
+			}
+		}
+		if sms.Attempts > 0 {
+			break
+		}
+	}
+	if sms.Status != "failed" || len(sms.Log) != 0 {
+		t.Fatalf("aviso sem contato deveria falhar: %+v", sms)
+	}
+
+	maria.Post("/api/guardians/notify", map[string]interface{}{
+		"message":      "Oi",
+		"guardian_ids": []string{carlaID},
+	}).ExpectError(http.StatusBadRequest, "GUARDIAN_RECIPIENT_ACCESS_DISABLED")
+	maria.Post("/api/guardians/notify", map[string]interface{}{
+		"message":  "Oi",
+		"channels": []string{"pombo"},
+	}).ExpectError(http.StatusBadRequest, "GUARDIAN_INVALID_NOTIFY_CHANNEL")
+
+	// Limite por usuário
+	maria.Post("/api/guardians/notify", map[string]string{"message": "De novo"}).
+		ExpectError(http.StatusTooManyRequests, "SECURITY_RATE_LIMITED")
+
+	// Outro usuário não envia aos guardiões de Maria
+	joao := h.Register("joao@example.com", "João")
+	joao.Post("/api/guardians/notify", map[string]string{"message": "  "}).
+		ExpectError(http.StatusBadRequest, "GUARDIAN_MESSAGE_REQUIRED")
+	joao.Post("/api/guardians/notify", map[string]interface{}{
+		"message":      "Oi",
+		"guardian_ids": []string{pedroID},
+	}).ExpectError(http.StatusNotFound, "GUARDIAN_NOT_FOUND")
+	joao.Post("/api/guardians/notify", map[string]string{"message": "Oi"}).
+		ExpectError(http.StatusBadRequest, "GUARDIAN_NO_RECIPIENTS")
+}
+
+func TestGuardianItemComments(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+
+	sharedID := maria.Post("/api/box/items", map[string]interface{}{
+		"type":      "location",
+		"title":     "Documentos do carro",
+		"is_shared": true,
+	}).Expect(http.StatusCreated).String("id")
+	privateID := maria.Post("/api/box/items", map[string]interface{}{
+		"type":  "info",
+		"title": "Senha do cofre",
+	}).Expect(http.StatusCreated).String("id")
+	token := maria.Post("/api/guardians", map[string]string{
+		"name":       "Pedro",
+		"email":      "pedro@example.com",
+		"access_pin": "4321",
+	}).Expect(http.StatusCreated).String("access_token")
+
+	guardian := h.NewClient()
+	path := "/api/guardian-access/" + token + "/items/" + sharedID + "/comments"
+
+	// Exige o PIN, texto e item visível para o guardião
+	guardian.Post(path, map[string]string{"pin": "0000", "body": "Qual gaveta?"}).Expect(http.StatusUnauthorized)
+	guardian.Post(path, map[string]string{"pin": "4321", "body": "   "}).ExpectError(http.StatusBadRequest, "SHARE_COMMENT_REQUIRED")
+	long := make([]byte, 1001)
+	for i := range long {
+		long[i] = 'a'
+	}
+	guardian.Post(path, map[string]string{"pin": "4321", "body": string(long)}).ExpectError(http.StatusBadRequest, "SHARE_COMMENT_TOO_LONG")
+	guardian.Post("/api/guardian-access/"+token+"/items/"+privateID+"/comments", map[string]string{"pin": "4321", "body": "Oi"}).
+		ExpectError(http.StatusNotFound, "SHARE_ITEM_NOT_FOUND")
+
+	// O texto é sanitizado
+	commentID := guardian.Post(path, map[string]string{"pin": "4321", "body": "Qual gaveta? <script>x</script>"}).
+		Expect(http.StatusCreated).String("id")
+
+	// O dono vê o comentário com o nome do guardião
+	var list struct {
+		Comments []struct {
+			ID           string `json:"id"`
+			GuardianName string `json:"guardian_name"`
+			Body         string `json:"body"`
+		} `json:"comments"`
+	}
+	maria.Get("/api/box/items/" + sharedID + "/comments").Expect(http.StatusOK).JSON(&list)
+	if len(list.Comments) != 1 || list.Comments[0].ID != commentID || list.Comments[0].GuardianName != "Pedro" {
+		t.Fatalf("comentários inesperados: %+v", list)
+	}
+	if list.Comments[0].Body != "Qual gaveta? &lt;script&gt;x&lt;/script&gt;" {
+		t.Fatalf("comentário não foi sanitizado: %q", list.Comments[0].Body)
+	}
+
+	// Outros usuários não veem os comentários
+	joao := h.Register("joao@example.com", "João")
+	joao.Get("/api/box/items/"+sharedID+"/comments").ExpectError(http.StatusNotFound, "BOX_NOT_FOUND")
+
+	// O dono é avisado
+	notified := false
+	for deadline := time.Now().Add(2 * time.Second); !notified && time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		var inbox struct {
+			Notifications []struct {
+				Title string `json:"title"`
+			} `json:"notifications"`
+		}
+		maria.Get("/api/notifications").Expect(http.StatusOK).JSON(&inbox)
+		for _, n := range inbox.Notifications {
+			notified = notified || n.Title == "Novo comentário em um item"
+		}
+	}
+	if !notified {
+		t.Fatal("dono não foi avisado do comentário")
+	}
+
+	// O comentário aparece na cópia dos dados do guardião
+	var export struct {
+		Comments []struct {
+			ItemID string `json:"item_id"`
+		} `json:"comments"`
+	}
+	guardian.Post("/api/guardian-access/"+token+"/my-data", map[string]string{"pin": "4321"}).Expect(http.StatusOK).JSON(&export)
+	if len(export.Comments) != 1 || export.Comments[0].ItemID != sharedID {
+		t.Fatalf("comentários ausentes na cópia dos dados: %+v", export)
+	}
+
+	// Apagar
+	maria.Delete("/api/box/items/" + sharedID + "/comments/" + commentID).Expect(http.StatusOK)
+	maria.Delete("/api/box/items/"+sharedID+"/comments/"+commentID).ExpectError(http.StatusNotFound, "BOX_COMMENT_NOT_FOUND")
+	maria.Get("/api/box/items/" + sharedID + "/comments").Expect(http.StatusOK).JSON(&list)
+	if len(list.Comments) != 0 {
+		t.Fatalf("comentário não foi apagado: %+v", list)
+	}
+}
+
+func TestGuardianImport(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+	maria.Post("/api/guardians", map[string]string{
+		"name": "Pedro", "email": "Pedro@Example.com", "access_pin": "4321",
+	}).Expect(http.StatusCreated)
+
+	vcf := strings.Join([]string{
+		"BEGIN:VCARD", "VERSION:3.0", "FN:Pedro Souza", "EMAIL;TYPE=INTERNET:pedro@example.com", "END:VCARD",
+		"BEGIN:VCARD", "VERSION:3.0", "N:Lima;Ana;;;", "item1.EMAIL:ana@example.com",
+		"TEL;TYPE=HOME:(11) 3333-4444", "TEL;TYPE=CELL,pref:(11) 98888-7777", "END:VCARD",
+		"BEGIN:VCARD", "VERSION:2.1", "FN;ENCODING=QUOTED-PRINTABLE;CHARSET=UTF-8:Jo=C3=A3o Lo=",
+		"pes", "TEL;CELL:11 97777-6666", "END:VCARD",
+		"BEGIN:VCARD", "VERSION:4.0", "FN:Sem Contato", "END:VCARD",
+		"BEGIN:VCARD", "VERSION:4.0", "FN:Ana Repetida", "TEL:+55 11 98888-7777", "END:VCARD",
+	}, "\r\n")
+
+	maria.Upload("/api/guardians/import", "file", "contatos.vcf", []byte("não é vcard")).
+		ExpectError(http.StatusBadRequest, "GUARDIAN_IMPORT_INVALID_FILE")
+	maria.Post("/api/guardians/import", map[string]string{"provider": "google", "access_token": "ya29"}).
+		ExpectError(http.StatusServiceUnavailable, "OAUTH_GOOGLE_NOT_CONFIGURED")
+
+	// Prévia: nada é salvo, duplicatas marcadas
+	type contact struct {
+		Name        string `json:"name"`
+		Email       string `json:"email"`
+		Phone       string `json:"phone"`
+		DuplicateOf string `json:"duplicate_of"`
+		Reason      string `json:"reason"`
+	}
+	var preview struct {
+		Contacts   []contact `json:"contacts"`
+		New        int       `json:"new"`
+		Duplicates int       `json:"duplicates"`
+		Skipped    int       `json:"skipped"`
+	}
+	maria.Upload("/api/guardians/import", "file", "contatos.vcf", []byte(vcf)).Expect(http.StatusOK).JSON(&preview)
+	if len(preview.Contacts) != 3 || preview.New != 2 || preview.Duplicates != 1 || preview.Skipped != 1 {
+		t.Fatalf("prévia inesperada: %+v", preview)
+	}
+	if c := preview.Contacts[0]; c.DuplicateOf == "" || c.Email != "pedro@example.com" {
+		t.Fatalf("duplicata por email não detectada: %+v", c)
+	}
+	if c := preview.Contacts[1]; c.Name != "Ana Lima" || c.Phone != "+5511988887777" || c.Email != "ana@example.com" {
+		t.Fatalf("contato do vCard 3.0 inesperado: %+v", c)
+	}
+	if c := preview.Contacts[2]; c.Name != "João Lopes" || c.Phone != "+5511977776666" {
+		t.Fatalf("contato do vCard 2.1 inesperado: %+v", c)
+	}
+	if n := len(h.Store.ListGuardians(maria.User.ID)); n != 1 {
+		t.Fatalf("a prévia criou guardiões: %d", n)
+	}
+
+	// Confirmação: exige PIN e cria apenas os novos
+	chosen := []map[string]string{
+		{"name": "Pedro Souza", "email": "pedro@example.com"},
+		{"name": "Ana Lima", "email": "ana@example.com", "phone": "+5511988887777", "relationship": "irmã"},
+		{"name": "João Lopes", "phone": "+5511977776666"},
+		{"name": "João de Novo", "phone": "(11) 97777-6666"},
+		{"name": "Sem Contato"},
+	}
+	maria.Post("/api/guardians/import/confirm", map[string]interface{}{"contacts": chosen}).
+		ExpectError(http.StatusBadRequest, "GUARDIAN_PIN_REQUIRED")
+	var result struct {
+		Created []struct {
+			Name         string `json:"name"`
+			Relationship string `json:"relationship"`
+		} `json:"created"`
+		Skipped []contact `json:"skipped"`
+	}
+	maria.Post("/api/guardians/import/confirm", map[string]interface{}{"contacts": chosen, "access_pin": "2468"}).
+		Expect(http.StatusCreated).JSON(&result)
+	if len(result.Created) != 2 || result.Created[0].Name != "Ana Lima" || result.Created[0].Relationship != "irmã" {
+		t.Fatalf("guardiões importados inesperados: %+v", result.Created)
+	}
+	reasons := []string{}
+	for _, skipped := range result.Skipped {
+		reasons = append(reasons, skipped.Name+":"+skipped.Reason)
+	}
+	if strings.Join(reasons, ",") != "Pedro Souza:duplicate,João de Novo:duplicate,Sem Contato:invalid" {
+		t.Fatalf("contatos ignorados inesperados: %v", reasons)
+	}
+
+	// Limite do plano gratuito vale para o lote inteiro
+	paid := testutil.New(t, map[string]string{
+		"STRIPE_SECRET_KEY": "sk_test_famli", "STRIPE_PRICE_ID": "price_famli", "STRIPE_WEBHOOK_SECRET": "whsec_famli",
+		"FREE_PLAN_MAX_GUARDIANS": "2",
+	})
+	joao := paid.Register("joao@example.com", "João")
+	joao.Post("/api/guardians/import/confirm", map[string]interface{}{"contacts": chosen[:3], "access_pin": "2468"}).
+		ExpectError(http.StatusPaymentRequired, "PREMIUM_REQUIRED")
+	if n := len(paid.Store.ListGuardians(joao.User.ID)); n != 0 {
+		t.Fatalf("importação acima do limite criou guardiões: %d", n)
+	}
+	joao.Post("/api/guardians/import/confirm", map[string]interface{}{"contacts": chosen[:2], "access_pin": "2468"}).
+		Expect(http.StatusCreated)
+}
+
+func TestGuardianAccessPolicy(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+	maria.Post("/api/box/items", map[string]interface{}{
+		"type":      "info",
+		"title":     "Remédios da mãe",
+		"is_shared": true,
+	}).Expect(http.StatusCreated)
+
+	created := maria.Post("/api/guardians", map[string]string{
+		"name":       "Cuidadora",
+		"access_pin": "4321",
+	}).Expect(http.StatusCreated)
+	guardianID, token := created.String("id"), created.String("access_token")
+	policyPath := "/api/guardians/" + guardianID + "/access-policy"
+	verify := func() *testutil.Response {
+		return h.NewClient().Post("/api/guardian-access/"+token+"/verify", map[string]string{"pin": "4321"})
+	}
+
+	maria.Get(policyPath).ExpectError(http.StatusNotFound, "GUARDIAN_POLICY_NOT_FOUND")
+	maria.Put(policyPath, map[string]interface{}{}).ExpectError(http.StatusBadRequest, "GUARDIAN_POLICY_EMPTY")
+	maria.Put(policyPath, map[string]interface{}{
+		"windows": []map[string]interface{}{{"weekdays": []int{1}, "start": "25:00", "end": "18:00"}},
+	}).ExpectError(http.StatusBadRequest, "GUARDIAN_POLICY_INVALID_WINDOW")
+	maria.Put(policyPath, map[string]interface{}{"expires_at": time.Now().Add(-time.Hour)}).
+		ExpectError(http.StatusBadRequest, "GUARDIAN_POLICY_INVALID_EXPIRY")
+	maria.Put(policyPath, map[string]interface{}{"max_accesses": 1, "timezone": "Lua/Base"}).
+		ExpectError(http.StatusBadRequest, "GUARDIAN_POLICY_INVALID_TIMEZONE")
+
+	// Limite de acessos: cada abertura do conteúdo conta; a prévia não
+	policy := maria.Put(policyPath, map[string]interface{}{"max_accesses": 2}).Expect(http.StatusOK).Map()
+	if policy["status"] != "active" || policy["remaining_accesses"] != float64(2) || policy["timezone"] != "America/Sao_Paulo" {
+		t.Fatalf("regras criadas: %v", policy)
+	}
+	h.NewClient().Get("/api/guardian-access/" + token).Expect(http.StatusOK)
+	verify().Expect(http.StatusOK)
+	verify().Expect(http.StatusOK)
+	verify().ExpectError(http.StatusForbidden, "SHARE_ACCESS_LIMIT_REACHED")
+	h.NewClient().Get("/api/guardian-access/"+token).ExpectError(http.StatusForbidden, "SHARE_ACCESS_LIMIT_REACHED")
+
+	// Os direitos do titular (LGPD) continuam disponíveis
+	h.NewClient().Post("/api/guardian-access/"+token+"/my-data", map[string]string{"pin": "4321"}).Expect(http.StatusOK)
+
+	policy = maria.Get(policyPath).Expect(http.StatusOK).Map()
+	if policy["status"] != "limit_reached" || policy["access_count"] != float64(2) || policy["remaining_accesses"] != float64(0) {
+		t.Fatalf("regras após o limite: %v", policy)
+	}
+
+	// O dono é avisado uma vez, mesmo com vários bloqueios
+	blocked := 0
+	for deadline := time.Now().Add(2 * time.Second); blocked == 0 && time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		var inbox struct {
+			Notifications []struct {
+				Title string `json:"title"`
+			} `json:"notifications"`
+		}
+		maria.Get("/api/notifications").Expect(http.StatusOK).JSON(&inbox)
+		for _, n := range inbox.Notifications {
+			if n.Title == "Acesso barrado pelas suas regras" {
+				blocked++
+			}
+		}
+	}
+	if blocked != 1 {
+		t.Fatalf("avisos de acesso barrado: %d", blocked)
+	}
+
+	// Janela de horário só amanhã
+	tomorrow := time.Now().In(mustLocation(t, "America/Sao_Paulo")).Weekday() + 1
+	maria.Put(policyPath, map[string]interface{}{
+		"reset_count": true,
+		"windows":     []map[string]interface{}{{"weekdays": []time.Weekday{tomorrow % 7}, "start": "08:00", "end": "18:00"}},
+	}).Expect(http.StatusOK)
+	verify().ExpectError(http.StatusForbidden, "SHARE_ACCESS_OUTSIDE_WINDOW")
+
+	// Prazo vencido
+	expired := time.Now().Add(-time.Minute)
+	h.Store.SaveGuardianAccessPolicy(&storage.GuardianAccessPolicy{GuardianID: guardianID, ExpiresAt: &expired})
+	verify().ExpectError(http.StatusForbidden, "SHARE_ACCESS_EXPIRED")
+
+	// Sem regras, o acesso volta ao normal
+	maria.Delete(policyPath).Expect(http.StatusNoContent)
+	verify().Expect(http.StatusOK)
+	h.Register("joao@example.com", "João").Delete(policyPath).ExpectError(http.StatusNotFound, "GUARDIAN_NOT_FOUND")
+}
+
+// mustLocation carrega um fuso horário
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
@@ -0,0 +1,79 @@
+package server_test
+
+import (
+	"net/http"
+	"testing"
+
+	"famli/internal/testutil"
+)
+
+// healthResponse é a parte de GET /api/admin/health usada nos testes
+type healthResponse struct {
+	Status  string `json:"status"`
+	Storage struct {
+		Type   string `json:"type"`
+		Status string `json:"status"`
+	} `json:"storage"`
+	Dependencies map[string]struct {
+		Status    string                 `json:"status"`
+		LatencyMs float64                `json:"latency_ms"`
+		Error     string                 `json:"error"`
+		Details   map[string]interface{} `json:"details"`
+	} `json:"dependencies"`
+}
+
+func TestAdminHealthChecksDependencies(t *testing.T) {
+	h := testutil.New(t, map[string]string{
+		"ADMIN_EMAILS":            "admin@example.com",
+		"HEALTH_DISK_PATH":        t.TempDir(),
+		"HEALTH_DISK_MIN_FREE_MB": "0",
+	})
+	admin := h.Register("admin@example.com", "Admin")
+
+	var health healthResponse
+	admin.Get("/api/admin/health").Expect(http.StatusOK).JSON(&health)
+	if health.Status != "healthy" {
+		t.Fatalf("status = %q, want healthy: %+v", health.Status, health)
+	}
+	for _, name := range []string{"database", "email", "whatsapp", "disk", "attachment_scan"} {
+		if _, ok := health.Dependencies[name]; !ok {
+			t.Fatalf("dependência %s ausente: %+v", name, health.Dependencies)
+		}
+	}
+
+	database := health.Dependencies["database"]
+	if database.Status != "ok" || database.Details["type"] != "Memory" || health.Storage.Status != "ok" {
+		t.Fatalf("database: %+v storage: %+v", database, health.Storage)
+	}
+	// Sem credenciais, email e Twilio ficam desligados (não degradam o sistema)
+	if health.Dependencies["email"].Status != "disabled" || health.Dependencies["whatsapp"].Status != "disabled" {
+		t.Fatalf("email/whatsapp sem credenciais: %+v", health.Dependencies)
+	}
+	disk := health.Dependencies["disk"]
+	if disk.Status != "ok" || disk.Details["total_mb"] == nil {
+		t.Fatalf("disk: %+v", disk)
+	}
+
+	// Pouco espaço livre degrada o sistema, mas as demais dependências seguem ok
+	h = testutil.New(t, map[string]string{
+		"ADMIN_EMAILS":            "admin@example.com",
+		"HEALTH_DISK_PATH":        t.TempDir(),
+		"HEALTH_DISK_MIN_FREE_MB": "1000000000",
+	})
+	admin = h.Register("admin@example.com", "Admin")
+	admin.Get("/api/admin/health").Expect(http.StatusOK).JSON(&health)
+	if health.Status != "degraded" || health.Dependencies["disk"].Status != "low_space" || health.Dependencies["database"].Status != "ok" {
+		t.Fatalf("disco cheio: %+v", health)
+	}
+
+	// Volume inexistente
+	h = testutil.New(t, map[string]string{
+		"ADMIN_EMAILS":     "admin@example.com",
+		"HEALTH_DISK_PATH": "/caminho/que/nao/existe",
+	})
+	admin = h.Register("admin@example.com", "Admin")
+	admin.Get("/api/admin/health").Expect(http.StatusOK).JSON(&health)
+	if health.Dependencies["disk"].Status != "unreachable" || health.Dependencies["disk"].Error == "" {
+		t.Fatalf("volume inexistente: %+v", health.Dependencies["disk"])
+	}
+}
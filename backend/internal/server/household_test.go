@@ -0,0 +1,41 @@
+package server_test
+
+import (
+	"net/http"
+	"testing"
+
+	"famli/internal/testutil"
+)
+
+func TestHouseholdDeclineInvite(t *testing.T) {
+	h := testutil.New(t, nil)
+	ana := h.Register("ana@example.com", "Ana")
+	joao := h.Register("joao@example.com", "João")
+	pedro := h.Register("pedro@example.com", "Pedro")
+
+	created := ana.Post("/api/households", map[string]string{"name": "Casa da Ana"}).Expect(http.StatusCreated)
+	var body struct {
+		Household struct {
+			ID string `json:"id"`
+		} `json:"household"`
+	}
+	created.JSON(&body)
+	householdID := body.Household.ID
+	members := "/api/households/" + householdID + "/members/"
+
+	ana.Post("/api/households/"+householdID+"/members", map[string]string{"email": "joao@example.com"}).Expect(http.StatusCreated)
+	ana.Post("/api/households/"+householdID+"/members", map[string]string{"email": "pedro@example.com"}).Expect(http.StatusCreated)
+
+	// Convite pendente: o convidado não vê a família nem remove outros
+	joao.Get("/api/households/"+householdID).ExpectError(http.StatusNotFound, "HOUSEHOLD_NOT_FOUND")
+	joao.Delete(members+pedro.User.ID).ExpectError(http.StatusNotFound, "HOUSEHOLD_NOT_FOUND")
+
+	// Mas pode recusar
+	joao.Delete(members + joao.User.ID).Expect(http.StatusOK)
+	joao.Post("/api/households/"+householdID+"/accept", nil).ExpectError(http.StatusNotFound, "HOUSEHOLD_NOT_FOUND")
+	joao.Delete(members+joao.User.ID).ExpectError(http.StatusNotFound, "HOUSEHOLD_NOT_FOUND")
+
+	// O outro convite continua valendo
+	pedro.Post("/api/households/"+householdID+"/accept", nil).Expect(http.StatusOK)
+	pedro.Get("/api/households/" + householdID).Expect(http.StatusOK)
+}
@@ -0,0 +1,147 @@
+package server_test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"famli/internal/storage"
+	"famli/internal/testutil"
+)
+
+const inboundSigningKey = "key-teste-mailgun"
+
+// TestInboundEmail cobre o gateway de email: assinatura, remetentes e o fluxo de categoria
+func TestInboundEmail(t *testing.T) {
+	// Sem a chave do Mailgun o gateway fica desligado
+	off := testutil.New(t, nil)
+	if status := postInboundEmail(t, off, inboundEmail{From: "maria@example.com", Text: "oi"}); status != http.StatusNotFound {
+		t.Fatalf("gateway desligado: status %d", status)
+	}
+
+	h := testutil.New(t, map[string]string{"MAILGUN_WEBHOOK_SIGNING_KEY": inboundSigningKey})
+	maria := h.Register("maria@example.com", "Maria")
+	joao := h.Register("joao@example.com", "João")
+
+	sender := &storage.InboundSender{ID: "ibs_teste", UserID: maria.User.ID, Email: "maria@example.com",
+		VerifiedAt: time.Now(), CreatedAt: time.Now()}
+	if err := h.Store.CreateInboundSender(sender); err != nil {
+		t.Fatal(err)
+	}
+	items := func() []*storage.BoxItem {
+		return h.Store.ListBoxItems(maria.User.ID)
+	}
+
+	// Assinatura inválida
+	if status := postInboundEmail(t, h, inboundEmail{From: "maria@example.com", Text: "oi", BadSignature: true}); status != http.StatusBadRequest {
+		t.Fatalf("assinatura inválida: status %d", status)
+	}
+
+	// Remetente forjado (sem DKIM, envelope diferente) e outro destinatário: descartados
+	postInboundEmail(t, h, inboundEmail{From: "Maria <maria@example.com>", Envelope: "spam@example.net", Subject: "Senha", Text: "123", NoDKIM: true})
+	if status := postInboundEmail(t, h, inboundEmail{From: "maria@example.com", Recipient: "outro@famli.net", Subject: "Senha", Text: "123"}); status != http.StatusOK {
+		t.Fatalf("email descartado: status %d", status)
+	}
+	if len(items()) != 0 {
+		t.Fatalf("email descartado virou item: %d", len(items()))
+	}
+
+	// Email novo → categoria → confirmação, por respostas
+	postInboundEmail(t, h, inboundEmail{From: "Maria <Maria@Example.com>", Subject: "Senha do wifi", Text: "famli123"})
+	postInboundEmail(t, h, inboundEmail{From: "maria@example.com", Subject: "Re: Sua Caixa Famli", Text: "1"})
+	postInboundEmail(t, h, inboundEmail{From: "maria@example.com", Subject: "RE: Re: Sua Caixa Famli", Text: "sim"})
+	saved := items()
+	if len(saved) != 1 || saved[0].Content != "Senha do wifi\nfamli123" {
+		t.Fatalf("item do email não salvo: %+v", saved)
+	}
+
+	// Remetente não verificado não guarda nada
+	postInboundEmail(t, h, inboundEmail{From: "joao@example.com", Subject: "Banco", Text: "agência 1234"})
+	if n := len(h.Store.ListBoxItems(joao.User.ID)); n != 0 {
+		t.Fatalf("remetente não verificado guardou %d itens", n)
+	}
+
+	// Remetentes verificados da conta
+	var list struct {
+		Address string `json:"address"`
+		Enabled bool   `json:"enabled"`
+		Senders []struct {
+			ID    string `json:"id"`
+			Email string `json:"email"`
+		} `json:"senders"`
+	}
+	maria.Get("/api/inbound/senders").Expect(http.StatusOK).JSON(&list)
+	if list.Address != "save@famli.net" || !list.Enabled || len(list.Senders) != 1 || list.Senders[0].Email != "maria@example.com" {
+		t.Fatalf("remetentes: %+v", list)
+	}
+	maria.Post("/api/inbound/senders", map[string]string{}).ExpectError(http.StatusBadRequest, "INBOUND_CODE_REQUIRED")
+	maria.Post("/api/inbound/senders", map[string]string{"code": "000000"}).ExpectError(http.StatusBadRequest, "INBOUND_INVALID_CODE")
+
+	joao.Delete("/api/inbound/senders/"+sender.ID).ExpectError(http.StatusNotFound, "INBOUND_SENDER_NOT_FOUND")
+	maria.Delete("/api/inbound/senders/" + sender.ID).Expect(http.StatusNoContent)
+
+	// Depois de remover, os emails não viram itens
+	postInboundEmail(t, h, inboundEmail{From: "maria@example.com", Subject: "Outra senha", Text: "abc"})
+	postInboundEmail(t, h, inboundEmail{From: "maria@example.com", Text: "1"})
+	postInboundEmail(t, h, inboundEmail{From: "maria@example.com", Text: "sim"})
+	if len(items()) != 1 {
+		t.Fatalf("remetente removido continua guardando itens: %d", len(items()))
+	}
+}
+
+// inboundEmail descreve um email encaminhado pelo Mailgun
+type inboundEmail struct {
+	From, Envelope, Recipient, Subject, Text string
+	NoDKIM, BadSignature                     bool
+}
+
+// postInboundEmail simula o webhook do Mailgun e retorna o status
+func postInboundEmail(t *testing.T, h *testutil.Harness, e inboundEmail) int {
+	t.Helper()
+	if e.Envelope == "" {
+		e.Envelope = "bounce@mail.example.com"
+	}
+	if e.Recipient == "" {
+		e.Recipient = "save@famli.net"
+	}
+	dkim := "Pass"
+	if e.NoDKIM {
+		dkim = "Fail"
+	}
+	headers, _ := json.Marshal([][]string{{"X-Mailgun-Spf", "Pass"}, {"X-Mailgun-Dkim-Check-Result", dkim}})
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	token := "token-" + timestamp + e.Subject + e.Text
+	mac := hmac.New(sha256.New, []byte(inboundSigningKey))
+	mac.Write([]byte(timestamp + token))
+	signature := hex.EncodeToString(mac.Sum(nil))
+	if e.BadSignature {
+		signature = "00" + signature[2:]
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for key, value := range map[string]string{
+		"timestamp": timestamp, "token": token, "signature": signature,
+		"sender": e.Envelope, "from": e.From, "recipient": e.Recipient,
+		"subject": e.Subject, "body-plain": e.Text + "\n\n> citação", "stripped-text": e.Text,
+		"message-headers": string(headers),
+	} {
+		form.WriteField(key, value)
+	}
+	form.Close()
+
+	resp, err := http.Post(h.URL("/api/inbound/email"), form.FormDataContentType(), &body)
+	if err != nil {
+		t.Fatalf("webhook: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
@@ -0,0 +1,70 @@
+package server_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"famli/internal/retention"
+	"famli/internal/storage"
+	"famli/internal/testutil"
+)
+
+func TestRetentionPolicies(t *testing.T) {
+	h := testutil.New(t, map[string]string{"ADMIN_EMAILS": "admin@example.com"})
+	admin := h.Register("admin@example.com", "Admin")
+	maria := h.Register("maria@example.com", "Maria")
+
+	var listing struct {
+		Policies []retention.Policy      `json:"policies"`
+		Runs     []*storage.RetentionRun `json:"runs"`
+	}
+	admin.Get("/api/admin/retention").Expect(http.StatusOK).JSON(&listing)
+	days := map[storage.RetentionClass]int{}
+	for _, policy := range listing.Policies {
+		days[policy.Class] = policy.Days
+	}
+	if days[storage.RetentionAnalytics] != 90 || days[storage.RetentionAudit] != 365 ||
+		days[storage.RetentionDeletedAccountAudit] != 1825 || days[storage.RetentionShareAccesses] != 180 {
+		t.Fatalf("prazos padrão: %v", days)
+	}
+	if len(listing.Runs) != 0 {
+		t.Fatalf("execuções antes do job: %v", listing.Runs)
+	}
+
+	// Só o superadmin altera; prazos fora dos limites são recusados
+	maria.Get("/api/admin/retention").Expect(http.StatusForbidden)
+	admin.Put("/api/admin/retention/inexistente", map[string]int{"days": 30}).ExpectError(http.StatusNotFound, "RETENTION_NOT_FOUND")
+	admin.Put("/api/admin/retention/analytics", map[string]int{"days": 3}).ExpectError(http.StatusBadRequest, "RETENTION_INVALID_DATA")
+	policy := admin.Put("/api/admin/retention/analytics", map[string]int{"days": 30}).Expect(http.StatusOK).Map()
+	if policy["days"] != float64(30) || policy["default_days"] != float64(90) || policy["updated_by"] != admin.User.ID {
+		t.Fatalf("prazo alterado: %v", policy)
+	}
+
+	// Eventos além do novo prazo saem na execução; os recentes ficam
+	h.Store.TrackEvent(&storage.AnalyticsEvent{ID: "old", EventType: "page_view", CreatedAt: time.Now().AddDate(0, 0, -45)})
+	h.Store.TrackEvent(&storage.AnalyticsEvent{ID: "new", EventType: "page_view", CreatedAt: time.Now().AddDate(0, 0, -10)})
+
+	var result struct {
+		Runs []*storage.RetentionRun `json:"runs"`
+	}
+	admin.Post("/api/admin/retention/run", nil).Expect(http.StatusOK).JSON(&result)
+	purged := map[storage.RetentionClass]int64{}
+	for _, run := range result.Runs {
+		if run.Error != "" {
+			t.Fatalf("execução com erro: %+v", run)
+		}
+		purged[run.Class] = run.Purged
+	}
+	if len(result.Runs) != len(retention.Known) || purged[storage.RetentionAnalytics] != 1 {
+		t.Fatalf("execuções: %v", purged)
+	}
+	if events, _ := h.Store.GetRecentEvents(10); len(events) != 1 || events[0].ID != "new" {
+		t.Fatalf("eventos depois da retenção: %v", events)
+	}
+
+	admin.Get("/api/admin/retention").Expect(http.StatusOK).JSON(&listing)
+	if len(listing.Runs) != len(retention.Known) {
+		t.Fatalf("execuções registradas: %d", len(listing.Runs))
+	}
+}
@@ -0,0 +1,118 @@
+package server_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"famli/internal/scan"
+	"famli/internal/testutil"
+)
+
+// fakeClamd responde como o clamd: PONG ao PING e, no INSTREAM, FOUND
+// quando o arquivo contém "EICAR"
+func fakeClamd(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				command, _ := r.ReadString(0)
+				if command == "zPING\x00" {
+					conn.Write([]byte("PONG\x00"))
+					return
+				}
+				var content bytes.Buffer
+				size := make([]byte, 4)
+				for {
+					if _, err := io.ReadFull(r, size); err != nil {
+						return
+					}
+					n := binary.BigEndian.Uint32(size)
+					if n == 0 {
+						break
+					}
+					io.CopyN(&content, r, int64(n))
+				}
+				if strings.Contains(content.String(), "EICAR") {
+					conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+				} else {
+					conn.Write([]byte("stream: OK\x00"))
+				}
+			}(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestClamAVScanner(t *testing.T) {
+	scanner := scan.New(scan.Config{ClamAVAddress: "tcp://" + fakeClamd(t), Timeout: time.Second})
+	ctx := context.Background()
+
+	// Arquivo maior que um bloco do INSTREAM
+	clean := scanner.Scan(ctx, strings.NewReader(strings.Repeat("famli ", 20000)))
+	if clean.Status != scan.StatusClean || clean.Quarantined() {
+		t.Fatalf("arquivo limpo: %+v", clean)
+	}
+
+	infected := scanner.Scan(ctx, strings.NewReader("X5O!P%@AP EICAR-STANDARD-ANTIVIRUS-TEST-FILE"))
+	if infected.Status != scan.StatusInfected || infected.Signature != "Eicar-Test-Signature" || !infected.Quarantined() {
+		t.Fatalf("arquivo infectado: %+v", infected)
+	}
+
+	// Antivírus fora do ar: o arquivo vai para a quarentena
+	down := scan.New(scan.Config{ClamAVAddress: "127.0.0.1:1", Timeout: time.Second})
+	if result := down.Scan(ctx, strings.NewReader("famli")); result.Status != scan.StatusFailed || !result.Quarantined() {
+		t.Fatalf("antivírus indisponível: %+v", result)
+	}
+
+	if result := scan.New(scan.Config{Disabled: true, ClamAVAddress: "127.0.0.1:1"}).Scan(ctx, strings.NewReader("EICAR")); result.Status != scan.StatusSkipped {
+		t.Fatalf("verificação desligada: %+v", result)
+	}
+}
+
+func TestAdminHealthShowsAttachmentScan(t *testing.T) {
+	h := testutil.New(t, map[string]string{
+		"ADMIN_EMAILS":            "admin@example.com",
+		"CLAMAV_ADDRESS":          fakeClamd(t),
+		"HEALTH_DISK_MIN_FREE_MB": "0",
+	})
+	admin := h.Register("admin@example.com", "Admin")
+
+	var health healthResponse
+	admin.Get("/api/admin/health").Expect(http.StatusOK).JSON(&health)
+	scanner := health.Dependencies["attachment_scan"]
+	if health.Status != "healthy" || scanner.Details["engine"] != "clamav" || scanner.Status != "ok" {
+		t.Fatalf("health com ClamAV: %+v", health)
+	}
+
+	h = testutil.New(t, map[string]string{
+		"ADMIN_EMAILS":            "admin@example.com",
+		"CLAMAV_ADDRESS":          "127.0.0.1:1",
+		"HEALTH_DISK_MIN_FREE_MB": "0",
+	})
+	admin = h.Register("admin@example.com", "Admin")
+	admin.Get("/api/admin/health").Expect(http.StatusOK).JSON(&health)
+	scanner = health.Dependencies["attachment_scan"]
+	if health.Status != "degraded" || scanner.Status != "unreachable" || scanner.Error == "" {
+		t.Fatalf("health com ClamAV fora do ar: %+v", health)
+	}
+}
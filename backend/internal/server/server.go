@@ -0,0 +1,862 @@
+// =============================================================================
+// FAMLI - Montagem do Servidor
+// =============================================================================
+// Cria os serviços, os handlers e o router da API a partir da configuração
+// e do storage. Usado pelo main.go e pelo harness de testes de integração
+// (internal/testutil), para que os testes passem pelas mesmas rotas e
+// middlewares da produção.
+//
+// O frontend (SPA) e o servidor HTTP continuam no main.go.
+// =============================================================================
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/cors"
+
+	"famli/internal/admin"
+	"famli/internal/analytics"
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/backup"
+	"famli/internal/billing"
+	"famli/internal/box"
+	"famli/internal/captcha"
+	"famli/internal/config"
+	"famli/internal/digest"
+	"famli/internal/email"
+	"famli/internal/emergency"
+	"famli/internal/features"
+	"famli/internal/feedback"
+	"famli/internal/guardian"
+	"famli/internal/guide"
+	"famli/internal/household"
+	"famli/internal/i18n"
+	"famli/internal/inbound"
+	"famli/internal/legal"
+	"famli/internal/notifications"
+	"famli/internal/oauth"
+	"famli/internal/onboarding"
+	"famli/internal/quota"
+	"famli/internal/retention"
+	"famli/internal/scan"
+	"famli/internal/security"
+	"famli/internal/settings"
+	"famli/internal/share"
+	"famli/internal/storage"
+	"famli/internal/web"
+	"famli/internal/webhooks"
+	"famli/internal/whatsapp"
+)
+
+// compressibleTypes são os tipos de conteúdo comprimidos nas respostas
+var compressibleTypes = []string{
+	"application/json",
+	"application/javascript",
+	"application/manifest+json",
+	"image/svg+xml",
+	"text/calendar",
+	"text/css",
+	"text/html",
+	"text/javascript",
+	"text/plain",
+}
+
+// Server é a aplicação montada: o router da API e os workers de background
+type Server struct {
+	// Router com todas as rotas /api (o main.go adiciona o frontend)
+	Router chi.Router
+
+	webhooks  *webhooks.Dispatcher
+	digest    *digest.Scheduler // nil sem o Twilio configurado
+	rollup    *analytics.Rollup
+	events    *analytics.Buffer
+	expiry    *box.ExpiryReminders
+	retention *retention.Job    // Prazos de retenção por classe de dados
+	whatsapp  *whatsapp.Service // Retentativas dos avisos aos guardiões
+	inbound   *inbound.Service  // Conversas paradas do gateway de email
+	status    *admin.Handler    // Monitor da página de status
+
+	share          *share.Handler        // Prévia das páginas dos links (MountFrontend)
+	previewLimiter *security.RateLimiter // Limite das páginas dos links com prévia
+}
+
+// New cria os serviços, os handlers e as rotas da API
+//
+// Parâmetros:
+//   - cfg: configuração já validada
+//   - store: storage (PostgreSQL ou memória)
+//   - storageType: nome do storage, exibido no painel admin
+func New(cfg *config.Config, store storage.Store, storageType string) *Server {
+	env := cfg.Env
+	isDev := cfg.IsDevelopment()
+	jwtSecret := cfg.JWTSecret
+
+	// Configuração do WhatsApp/Twilio
+	whatsappConfig := &whatsapp.Config{
+		TwilioAccountSid:  cfg.WhatsApp.AccountSid,
+		TwilioAuthToken:   cfg.WhatsApp.AuthToken,
+		TwilioPhoneNumber: cfg.WhatsApp.PhoneNumber,
+		TwilioSMSNumber:   cfg.WhatsApp.SMSNumber,
+		WebhookBaseURL:    cfg.WhatsApp.WebhookBaseURL,
+		AppURL:            cfg.AppURL,
+		SessionTimeout:    cfg.WhatsApp.SessionTimeout,
+		Enabled:           cfg.WhatsAppEnabled(),
+	}
+
+	// Configuração do OAuth (Google, Apple)
+	oauthConfig := &oauth.Config{
+		GoogleClientID:  cfg.OAuth.GoogleClientID,
+		AppleClientID:   cfg.OAuth.AppleClientID,
+		AppleTeamID:     cfg.OAuth.AppleTeamID,
+		AppleKeyID:      cfg.OAuth.AppleKeyID,
+		ApplePrivateKey: cfg.OAuth.ApplePrivateKey,
+		AdminEmails:     cfg.Auth.AdminEmails,
+	}
+
+	// Feature flags (system_config) - avaliadas por features.Enabled/Require
+	featureManager := features.Init(store)
+
+	// Eventos relevantes da auditoria vão para audit_log (atividade do painel admin)
+	security.GetAuditLogger().SetSink(admin.ActivitySink(store))
+
+	// Prazos de retenção por classe de dados (system_config); o job começa em Start
+	retentionManager := retention.NewManager(store, cfg.LogRetentionDays)
+	retentionJob := retention.NewJob(retentionManager, store, time.Duration(cfg.LogCleanupIntervalHours)*time.Hour)
+
+	// Webhooks de saída (entrega imediata; o worker de retentativas começa em Start)
+	// Em desenvolvimento, aceita HTTP e localhost para testes
+	webhookDispatcher := webhooks.Init(store, isDev)
+
+	// Central de notificações + Web Push (em desenvolvimento, sem chaves VAPID,
+	// usa um par temporário)
+	notificationService := notifications.Init(store, &notifications.Config{
+		VAPIDPublicKey:  cfg.Push.VAPIDPublicKey,
+		VAPIDPrivateKey: cfg.Push.VAPIDPrivateKey,
+		Subject:         cfg.Push.Subject,
+		AllowAnyHost:    isDev,
+	}, isDev)
+
+	// Serviço de email (compartilhado por autenticação, avisos e WhatsApp)
+	mailer := email.NewService(&email.Config{
+		Provider:         cfg.Email.Provider,
+		From:             cfg.Email.From,
+		FromName:         cfg.Email.FromName,
+		MailtrapAPIToken: cfg.Email.MailtrapAPIToken,
+		MailtrapSandbox:  cfg.Email.MailtrapSandbox,
+		MailtrapInboxID:  cfg.Email.MailtrapInboxID,
+		AppURL:           cfg.AppURL,
+	})
+
+	// Handlers organizados por domínio
+	authHandler := auth.NewHandler(store, jwtSecret, &auth.Config{
+		LockoutThreshold: cfg.Auth.LockoutThreshold,
+		LockoutDuration:  cfg.Auth.LockoutDuration,
+		AdminEmails:      cfg.Auth.AdminEmails,
+		TokenClients:     cfg.Auth.TokenClients,
+		AppURL:           cfg.AppURL,
+		TermsVersion:     cfg.Legal.TermsVersion,
+		PrivacyVersion:   cfg.Legal.PrivacyVersion,
+		RetentionDays:    retentionManager.Days,
+	}, mailer)
+	// Versões dos termos e da política (novo aceite a cada versão publicada)
+	legalHandler := legal.NewHandler(store, legal.Versions{
+		Terms:   cfg.Legal.TermsVersion,
+		Privacy: cfg.Legal.PrivacyVersion,
+	})
+	// Cotas de armazenamento por usuário (0 = sem limite)
+	quotaChecker := quota.NewChecker(store, quota.Limits{
+		MaxItems:             cfg.Quota.MaxItems,
+		MaxContentBytes:      int64(cfg.Quota.MaxContentMB) * 1024 * 1024,
+		AssistantDailyTokens: cfg.Assistant.DailyTokens,
+	})
+	boxHandler := box.NewHandler(store, quotaChecker)
+	guideHandler := guide.NewHandler(store)
+	householdHandler := household.NewHandler(store)
+	emergencyCardHandler := emergency.NewHandler(store)
+	settingsHandler := settings.NewHandler(store)
+	// Antivírus dos anexos (ClamAV; desligado sem CLAMAV_ADDRESS)
+	scanner := scan.New(scan.Config{
+		Disabled:      cfg.Scan.Disabled,
+		ClamAVAddress: cfg.Scan.ClamAVAddress,
+		Timeout:       cfg.Scan.Timeout,
+	})
+	backupHandler := backup.NewHandler(backup.NewService(store, backupConfig(cfg)))
+	feedbackHandler := feedback.NewHandler(store, mailer, cfg.AppURL)
+	// Eventos avulsos gravados em lote, em segundo plano (o worker começa em Start)
+	analyticsBuffer := analytics.NewBuffer(store, analytics.BufferConfig{
+		BatchSize:     cfg.Analytics.BatchSize,
+		FlushInterval: cfg.Analytics.FlushInterval,
+		QueueLimit:    cfg.Analytics.QueueLimit,
+	})
+	analyticsHandler := analytics.NewHandler(store, analytics.NewSampler(cfg.Analytics.DailyEventLimit, cfg.Analytics.SamplePercent), analyticsBuffer)
+	oauthHandler := oauth.NewHandler(store, jwtSecret, oauthConfig)
+	// Serviço do WhatsApp (também avisa os guardiões por WhatsApp, SMS ou email)
+	whatsappService := whatsapp.NewService(store, whatsappConfig, mailer, quotaChecker)
+	guardianHandler := guardian.NewHandler(store, mailer, whatsappService.MessageGuardian, cfg.AppURL)
+	// Gateway de email (Mailgun): mesmos fluxos de conversa do WhatsApp
+	inboundService := inbound.NewService(store, &inbound.Config{
+		Address:    cfg.Inbound.Address,
+		SigningKey: cfg.Inbound.MailgunSigningKey,
+		AppURL:     cfg.AppURL,
+	}, mailer, quotaChecker)
+	shareHandler := share.NewHandler(store, &share.Config{
+		EnforceLimits:      cfg.IsProduction(),
+		DefaultExpiresDays: cfg.Share.DefaultExpiresDays,
+		MaxExpiresDays:     cfg.Share.MaxExpiresDays,
+		DefaultMaxUses:     cfg.Share.DefaultMaxUses,
+		MaxUses:            cfg.Share.MaxUses,
+		AppURL:             cfg.AppURL,
+		URLPrefix:          cfg.Share.URLPrefix,
+
+		GuardianDeletionGraceDays: cfg.Share.GuardianDeletionGraceDays,
+		AlertGuardians:            whatsappService.NotifyEmergency,
+		MessageGuardian:           whatsappService.AlertGuardian,
+		Mailer:                    mailer,
+	})
+	// Tokens inválidos em excesso pedem CAPTCHA (ou 429, sem CAPTCHA_PROVIDER)
+	shareBotGuard := share.NewBotGuard(captcha.New(captcha.Config{
+		Provider:  cfg.Captcha.Provider,
+		SiteKey:   cfg.Captcha.SiteKey,
+		SecretKey: cfg.Captcha.SecretKey,
+		VerifyURL: cfg.Captcha.VerifyURL,
+	}), cfg.Share.AbuseThreshold, jwtSecret)
+	billingHandler := billing.NewHandler(store, &billing.Config{
+		StripeSecretKey:     cfg.Billing.StripeSecretKey,
+		StripeWebhookSecret: cfg.Billing.StripeWebhookSecret,
+		PriceID:             cfg.Billing.StripePriceID,
+		AppURL:              cfg.AppURL,
+		FreeMaxGuardians:    cfg.Billing.FreeMaxGuardians,
+	})
+	// Importação de guardiões (vCard ou Google Contatos), no limite do plano
+	guardianImportHandler := guardian.NewImportHandler(store, &guardian.ImportConfig{
+		GoogleClientID: cfg.OAuth.GoogleClientID,
+		CheckLimit:     billingHandler.CheckGuardianLimit,
+	})
+	featuresHandler := features.NewHandler(featureManager)
+	retentionHandler := retention.NewHandler(retentionManager, retentionJob, store)
+	webhooksHandler := webhooks.NewHandler(store, webhookDispatcher)
+	notificationsHandler := notifications.NewHandler(store, notificationService)
+
+	// Handler do WhatsApp
+	whatsappHandler := whatsapp.NewHandler(whatsappService, whatsappConfig)
+	inboundHandler := inbound.NewHandler(inboundService)
+
+	// Administração (o health check verifica banco, email, Twilio, disco e antivírus)
+	adminHealth := admin.HealthConfig{
+		Scanner:         scanner,
+		DiskPath:        cfg.Health.DiskPath,
+		DiskMinFreeMB:   int64(cfg.Health.DiskMinFreeMB),
+		AnalyticsBuffer: analyticsBuffer,
+	}
+	if cfg.Email.MailtrapAPIToken != "" {
+		adminHealth.Email = mailer
+	}
+	if whatsappConfig.Enabled {
+		adminHealth.WhatsApp = whatsappService
+	}
+	adminHandler := admin.NewHandler(store, storageType, env, authHandler, quotaChecker.Limits(), adminHealth)
+
+	// Checklist de onboarding (o passo do WhatsApp só existe com o Twilio configurado)
+	var whatsappLinked onboarding.LinkChecker
+	if whatsappConfig.Enabled {
+		whatsappLinked = whatsappService.IsLinked
+	}
+	onboardingHandler := onboarding.NewHandler(store, whatsappLinked)
+
+	// Canais externos da central de notificações (push é registrado pelo serviço)
+	notificationService.AddChannel(notifications.NewEmailChannel(mailer))
+	notificationService.AddChannel(notifications.NewMessageChannel(notifications.ChannelWhatsApp, whatsappService.NotifyUser))
+
+	// Resumos da Caixa pelo WhatsApp (apenas com o Twilio configurado)
+	var digestScheduler *digest.Scheduler
+	if whatsappConfig.Enabled {
+		digestScheduler = digest.NewScheduler(store, whatsappService.NotifyUser, cfg.WhatsApp.DigestHourUTC)
+	}
+
+	// Erros dos middlewares de segurança no formato padrão da API
+	security.SetErrorWriter(apierror.Write)
+
+	// Rate limiters
+	apiLimiter := security.NewRateLimiter(security.APIRateLimit)
+	webhookLimiter := security.NewRateLimiter(security.WebhookRateLimit)
+	shareLimiter := security.NewRateLimiter(security.ShareAccessRateLimit)
+	publicShareLimit := security.PublicShareRateLimit
+	publicShareLimit.Requests = cfg.Share.PublicRateLimit
+	publicShareLimiter := security.NewRateLimiter(publicShareLimit)
+	anonymousAnalyticsLimiter := security.NewRateLimiter(security.AnonymousAnalyticsRateLimit)
+	guardianMessageLimiter := security.NewRateLimiter(security.GuardianMessageRateLimit)
+	itemSendLimiter := security.NewRateLimiter(security.ItemSendRateLimit)
+	apiKeyLimiter := security.NewRateLimiter(security.APIKeyRateLimit)
+	guardianCommentLimiter := security.NewRateLimiter(security.GuardianCommentRateLimit)
+	whatsappLinkLimiter := security.NewRateLimiter(security.WhatsAppLinkRateLimit)
+	inboundSenderLimiter := security.NewRateLimiter(security.InboundSenderRateLimit)
+	captureLimiter := security.NewRateLimiter(security.CaptureRateLimit)
+
+	// Assistente: limites por usuário e por IP (o orçamento diário de tokens fica na cota)
+	assistantUserLimit := security.AssistantUserRateLimit
+	assistantUserLimit.Requests = cfg.Assistant.UserHourlyRequests
+	assistantIPLimit := security.AssistantIPRateLimit
+	assistantIPLimit.Requests = cfg.Assistant.IPHourlyRequests
+	assistantUserLimiter := security.NewRateLimiter(assistantUserLimit)
+	assistantIPLimiter := security.NewRateLimiter(assistantIPLimit)
+
+	// Limites de corpo das rotas que fogem do padrão (security.DefaultBodyLimit)
+	bodyLimits := security.BodyLimits{
+		Routes: map[string]int64{
+			"PUT /api/auth/devices/{deviceID}":       1 * 1024,
+			"POST /api/box/items":                    100 * 1024,
+			"POST /api/capture":                      64 * 1024,
+			"PUT /api/box/items/{itemID}":            100 * 1024,
+			"PATCH /api/box/items/{itemID}":          100 * 1024,
+			"POST /api/box/items/{itemID}/relations": 4 * 1024,
+			"POST /api/box/categories":               4 * 1024,
+			"POST /api/guardians/import":             6 * 1024 * 1024, // Arquivo .vcf (fotos incluídas)
+			"POST /api/guardians/import/confirm":     256 * 1024,
+			"PUT /api/box/categories/{categoryID}":   4 * 1024,
+			"PUT /api/box/categories/order":          8 * 1024,
+			"POST /api/assistant":                    10 * 1024,
+			"POST /api/analytics/public":             8 * 1024,
+			"POST /api/analytics/batch":              512 * 1024,
+			"POST /api/billing/webhook":              256 * 1024,
+			"POST /api/inbound/email":                25 * 1024 * 1024, // Anexos chegam no multipart (ignorados)
+		},
+	}
+
+	// =========================================================================
+	// CONFIGURAÇÃO DO ROUTER
+	// =========================================================================
+
+	r := chi.NewRouter()
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// MIDDLEWARES GLOBAIS
+	// ─────────────────────────────────────────────────────────────────────────
+
+	// Request ID para rastreamento
+	r.Use(chimiddleware.RequestID)
+
+	// IP real do cliente (quando atrás de proxy)
+	r.Use(chimiddleware.RealIP)
+
+	// Logger de requisições (com redação de tokens)
+	r.Use(security.RedactingLogger())
+
+	// Recuperar de panics
+	r.Use(chimiddleware.Recoverer)
+
+	// Compressão gzip das respostas de texto (API e frontend); os arquivos
+	// pré-comprimidos no build (.br/.gz) já chegam com Content-Encoding
+	r.Use(chimiddleware.Compress(5, compressibleTypes...))
+
+	// Idioma da requisição (?lang= ou Accept-Language; o idioma salvo do
+	// usuário é aplicado após a autenticação)
+	r.Use(i18n.Middleware)
+
+	// Headers de segurança (OWASP A05)
+	var headersConfig security.SecurityHeadersConfig
+	if isDev {
+		headersConfig = security.DevelopmentSecurityHeadersConfig()
+	} else {
+		headersConfig = security.DefaultSecurityHeadersConfig()
+	}
+	r.Use(security.HeadersMiddleware(headersConfig))
+
+	// CORS - Cross-Origin Resource Sharing (APP_BASE_URL + EXTRA_ALLOWED_ORIGINS)
+	allowedOrigins := cfg.AllowedOrigins()
+
+	r.Use(cors.Handler(cors.Options{
+		AllowedOrigins:   allowedOrigins,
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "Accept-Language", "If-Match"},
+		ExposedHeaders:   []string{"ETag"},
+		AllowCredentials: true,
+		MaxAge:           300,
+	}))
+
+	// =========================================================================
+	// ROTAS DA API
+	// =========================================================================
+
+	r.Route("/api", func(api chi.Router) {
+		// Rate limiting para API (OWASP A04)
+		api.Use(apiLimiter.Middleware(security.GetClientIP))
+
+		// Tamanho do corpo por rota e aninhamento do JSON (413/400)
+		api.Use(security.BodyLimitMiddleware(bodyLimits, func(req *http.Request) string {
+			rctx := chi.NewRouteContext()
+			if !r.Match(rctx, req.Method, req.URL.Path) {
+				return ""
+			}
+			return rctx.RoutePattern()
+		}))
+
+		// ─────────────────────────────────────────────────────────────────────
+		// ROTAS PÚBLICAS (sem autenticação)
+		// ─────────────────────────────────────────────────────────────────────
+
+		// Health check público (para load balancers)
+		api.Get("/health", adminHandler.PublicHealth)
+		// Página de status (componentes, incidentes e disponibilidade; cache de 1 min)
+		api.Get("/status", adminHandler.PublicStatus)
+
+		// Autenticação (rate limit adicional no handler)
+		api.Post("/auth/register", authHandler.Register)
+		api.Post("/auth/login", authHandler.Login)
+		api.Post("/auth/token", authHandler.IssueToken)
+
+		// Fim da sessão do suporte (funciona mesmo com a autorização revogada)
+		api.With(security.CSRFMiddleware(allowedOrigins, isDev)).Post("/auth/impersonation/end", authHandler.EndImpersonation)
+
+		// Recuperação de senha
+		api.Post("/auth/forgot-password", authHandler.ForgotPassword)
+		api.Post("/auth/reset-password", authHandler.ResetPassword)
+
+		// OAuth - Login Social (Google, Apple)
+		api.With(features.Require(features.OAuth)).Post("/auth/oauth/google", oauthHandler.Google)
+		api.With(features.Require(features.OAuth)).Post("/auth/oauth/apple", oauthHandler.Apple)
+		api.Get("/auth/oauth/status", oauthHandler.Status)
+
+		// Webhook do WhatsApp (chamado pelo Twilio)
+		api.Group(func(wh chi.Router) {
+			wh.Use(webhookLimiter.Middleware(security.GetClientIP))
+			wh.Get("/whatsapp/webhook", whatsappHandler.WebhookVerify)
+			wh.Post("/whatsapp/webhook", whatsappHandler.Webhook)
+		})
+
+		// Webhook do gateway de email (chamado pelo Mailgun)
+		api.With(webhookLimiter.Middleware(security.GetClientIP)).Post("/inbound/email", inboundHandler.Email)
+
+		// Webhook do Stripe (assinaturas)
+		api.With(webhookLimiter.Middleware(security.GetClientIP)).Post("/billing/webhook", billingHandler.Webhook)
+
+		// Status da integração WhatsApp
+		api.Get("/whatsapp/status", whatsappHandler.Status)
+
+		// Versões vigentes dos Termos de Uso e da Política de Privacidade
+		api.Get("/legal/current", legalHandler.Current)
+
+		// Analytics de visitantes sem login (landing page)
+		api.With(anonymousAnalyticsLimiter.Middleware(security.GetClientIP)).Post("/analytics/public", analyticsHandler.TrackAnonymous)
+
+		// ─────────────────────────────────────────────────────────────────────
+		// ROTAS PROTEGIDAS (requerem autenticação JWT)
+		// ─────────────────────────────────────────────────────────────────────
+
+		api.Group(func(pr chi.Router) {
+			// Chaves pessoais de API (somente leitura, por permissão e com limite por chave)
+			pr.Use(auth.APIKeyMiddleware(store, apiKeyLimiter))
+			// Middleware de autenticação JWT
+			pr.Use(auth.JWTMiddleware(jwtSecret, cfg.Auth.TokenClients))
+			// Contas removidas ou desativadas perdem a sessão imediatamente
+			pr.Use(auth.ActiveUserMiddleware(store))
+			// CSRF - validar origem para requisições mutantes
+			pr.Use(security.CSRFMiddleware(allowedOrigins, isDev))
+			// Termos ou política com nova versão: 428 até aceitar (conta e aceite liberados)
+			pr.Use(legalHandler.RequireAcceptance("/api/auth/", "/api/legal/"))
+
+			// Autenticação
+			pr.Get("/auth/me", authHandler.Me)
+			pr.Post("/auth/logout", authHandler.Logout)
+			pr.Get("/auth/devices", authHandler.ListDevices)
+			pr.Put("/auth/devices/{deviceID}", authHandler.RenameDevice)
+			pr.Delete("/auth/devices/{deviceID}", authHandler.RevokeDevice)
+
+			// Chaves pessoais de API
+			pr.Get("/keys", authHandler.ListAPIKeys)
+			pr.Post("/keys", authHandler.CreateAPIKey)
+			pr.Delete("/keys/{keyID}", authHandler.DeleteAPIKey)
+
+			// LGPD - Direitos do Titular
+			pr.Delete("/auth/account", authHandler.DeleteAccount) // Direito ao esquecimento
+			pr.Get("/auth/export", authHandler.ExportData)        // Direito à portabilidade
+			pr.Get("/auth/data-map", authHandler.DataMap)         // Direito de acesso (inventário dos dados)
+			pr.Patch("/auth/profile", authHandler.UpdateProfile)  // Direito à correção
+
+			// Termos de Uso e Política de Privacidade
+			pr.Get("/legal/status", legalHandler.Status)
+			pr.Post("/legal/accept", legalHandler.Accept)
+
+			// Caixa Famli
+			pr.Get("/box/items", boxHandler.List)
+			pr.Head("/box/items", boxHandler.Count)
+			pr.Get("/box/items/count", boxHandler.Count)
+			pr.Get("/box/usage", boxHandler.Usage)
+			pr.Get("/box/routines", boxHandler.Routines)
+			pr.Get("/box/expiring", boxHandler.Expiring)
+			pr.Post("/box/items", boxHandler.Create)
+			pr.Put("/box/items/order", boxHandler.Reorder)
+			pr.Get("/box/items/{itemID}", boxHandler.Get)
+			pr.Put("/box/items/{itemID}", boxHandler.Update)
+			pr.Patch("/box/items/{itemID}", boxHandler.Patch)
+			pr.Delete("/box/items/{itemID}", boxHandler.Delete)
+			pr.Post("/box/items/{itemID}/relations", boxHandler.CreateRelation)
+			pr.Delete("/box/items/{itemID}/relations/{relationID}", boxHandler.DeleteRelation)
+			pr.Get("/box/items/{itemID}/views", boxHandler.Views)
+			pr.With(features.Require(features.ShareLinks), itemSendLimiter.Middleware(auth.GetUserID)).Post("/box/items/{itemID}/send", shareHandler.SendItem)
+			pr.Get("/box/items/{itemID}/comments", boxHandler.Comments)
+			pr.Delete("/box/items/{itemID}/comments/{commentID}", boxHandler.DeleteComment)
+			pr.Put("/box/items/{itemID}/pin", boxHandler.Pin)
+			pr.Delete("/box/items/{itemID}/pin", boxHandler.Unpin)
+			pr.Put("/box/items/{itemID}/checklist/{entryID}/done", boxHandler.CheckEntry)
+			pr.Delete("/box/items/{itemID}/checklist/{entryID}/done", boxHandler.UncheckEntry)
+			pr.Get("/box/categories", boxHandler.ListCategories)
+			pr.Post("/box/categories", boxHandler.CreateCategory)
+			pr.Put("/box/categories/order", boxHandler.ReorderCategories)
+			pr.Put("/box/categories/{categoryID}", boxHandler.UpdateCategory)
+			pr.Delete("/box/categories/{categoryID}", boxHandler.DeleteCategory)
+			pr.Get("/box/completeness", boxHandler.Completeness)
+			pr.Get("/box/legal-checklist", boxHandler.LegalChecklist)
+			pr.Get("/box/calendar", boxHandler.CalendarStatus)
+			pr.Post("/box/calendar", boxHandler.CreateCalendarFeed)
+			pr.Delete("/box/calendar", boxHandler.RevokeCalendarFeed)
+			pr.Get("/box/capture", boxHandler.CaptureStatus)
+			pr.Post("/box/capture", boxHandler.CreateCaptureToken)
+			pr.Delete("/box/capture", boxHandler.RevokeCaptureToken)
+
+			// Guardiões
+			pr.Get("/guardians", guardianHandler.List)
+			pr.Get("/guardians/alerts", guardianHandler.Alerts)
+			pr.With(guardianMessageLimiter.Middleware(auth.GetUserID)).Post("/guardians/notify", guardianHandler.Notify)
+			pr.With(billingHandler.LimitGuardians).Post("/guardians", guardianHandler.Create)
+			pr.Post("/guardians/import", guardianImportHandler.Preview)
+			pr.Post("/guardians/import/confirm", guardianImportHandler.Confirm)
+			pr.Put("/guardians/{guardianID}", guardianHandler.Update)
+			pr.Patch("/guardians/{guardianID}", guardianHandler.Patch)
+			pr.Delete("/guardians/{guardianID}", guardianHandler.Delete)
+			pr.Get("/guardians/{guardianID}/messages", guardianHandler.Messages)
+			pr.Post("/guardians/{guardianID}/rotate-token", guardianHandler.RotateToken)
+			pr.Post("/guardians/{guardianID}/disable-access", guardianHandler.DisableAccess)
+			pr.Post("/guardians/{guardianID}/enable-access", guardianHandler.EnableAccess)
+			pr.Get("/guardians/{guardianID}/access-policy", guardianHandler.GetAccessPolicy)
+			pr.Put("/guardians/{guardianID}/access-policy", guardianHandler.PutAccessPolicy)
+			pr.Delete("/guardians/{guardianID}/access-policy", guardianHandler.DeleteAccessPolicy)
+
+			// Famílias (caixas compartilhadas)
+			pr.Route("/households", func(hr chi.Router) {
+				hr.Get("/", householdHandler.List)
+				hr.Post("/", householdHandler.Create)
+				hr.Get("/{id}", householdHandler.Get)
+				hr.Put("/{id}", householdHandler.Update)
+				hr.Delete("/{id}", householdHandler.Delete)
+				hr.Post("/{id}/accept", householdHandler.Accept)
+				hr.Post("/{id}/members", householdHandler.Invite)
+				hr.Put("/{id}/members/{userID}", householdHandler.UpdateMember)
+				hr.Delete("/{id}/members/{userID}", householdHandler.RemoveMember)
+			})
+
+			// Cartão de emergência (dados mínimos para socorristas)
+			pr.Get("/emergency-card", emergencyCardHandler.Get)
+			pr.Put("/emergency-card", emergencyCardHandler.Save)
+			pr.Delete("/emergency-card", emergencyCardHandler.Delete)
+			pr.Post("/emergency-card/rotate", emergencyCardHandler.Rotate)
+			// Escalonamento de emergência (pessoas de confiança chamadas em ordem)
+			pr.Get("/emergency-escalation", emergencyCardHandler.GetEscalation)
+			pr.Put("/emergency-escalation", emergencyCardHandler.SaveEscalation)
+			pr.Delete("/emergency-escalation", emergencyCardHandler.DeleteEscalation)
+			pr.Post("/emergency-escalation/cancel", emergencyCardHandler.CancelEscalation)
+
+			// Guia Famli
+			pr.Get("/guide/cards", guideHandler.ListCards)
+			pr.Get("/guide/progress", guideHandler.GetProgress)
+			pr.Post("/guide/progress/{cardID}", guideHandler.MarkCardProgress)
+
+			// Checklist de onboarding calculado a partir dos dados reais
+			pr.Get("/onboarding/status", onboardingHandler.Status)
+
+			// Configurações
+			pr.Get("/settings", settingsHandler.Get)
+			pr.Put("/settings", settingsHandler.Update)
+			// Autorização do suporte para ver a conta (24h, somente leitura)
+			pr.Get("/settings/support-access", settingsHandler.GetSupportAccess)
+			pr.Post("/settings/support-access", settingsHandler.GrantSupportAccess)
+			pr.Delete("/settings/support-access", settingsHandler.RevokeSupportAccess)
+
+			// Central de notificações e Web Push (preferências por categoria em /settings)
+			pr.Get("/notifications", notificationsHandler.List)
+			pr.Post("/notifications/read-all", notificationsHandler.MarkAllRead)
+			pr.Post("/notifications/{id}/read", notificationsHandler.MarkRead)
+			pr.Get("/notifications/vapid-key", notificationsHandler.VAPIDKey)
+			pr.Get("/notifications/subscriptions", notificationsHandler.ListSubscriptions)
+			pr.Post("/notifications/subscribe", notificationsHandler.Subscribe)
+			pr.Delete("/notifications/subscribe", notificationsHandler.Unsubscribe)
+
+			// Assistente
+			pr.With(
+				assistantIPLimiter.Middleware(security.GetClientIP),
+				assistantUserLimiter.Middleware(auth.GetUserID),
+			).Post("/assistant", boxHandler.Assistant)
+
+			// Feature flags avaliadas para o usuário (frontend esconde o que está desligado)
+			pr.Get("/features", featuresHandler.Current)
+
+			// WhatsApp (vincular/desvincular; uma conta pode ter vários números)
+			whatsappLinkGate := pr.With(
+				features.Require(features.WhatsApp),
+				billingHandler.RequirePremium,
+				whatsappLinkLimiter.Middleware(auth.GetUserID),
+			)
+			whatsappLinkGate.Post("/whatsapp/link", whatsappHandler.Link)
+			whatsappLinkGate.Post("/whatsapp/links", whatsappHandler.CreateLink)
+			pr.Delete("/whatsapp/link", whatsappHandler.Unlink)
+			pr.Get("/whatsapp/links", whatsappHandler.ListLinks)
+			pr.Post("/whatsapp/links/{linkID}/primary", whatsappHandler.SetPrimaryLink)
+			pr.Delete("/whatsapp/links/{linkID}", whatsappHandler.DeleteLink)
+
+			// Gateway de email (remetentes verificados)
+			pr.Get("/inbound/senders", inboundHandler.ListSenders)
+			pr.With(inboundSenderLimiter.Middleware(auth.GetUserID)).Post("/inbound/senders", inboundHandler.CreateSender)
+			pr.Delete("/inbound/senders/{senderID}", inboundHandler.DeleteSender)
+
+			// Assinaturas (Stripe)
+			pr.Get("/billing", billingHandler.Status)
+			pr.Post("/billing/checkout", billingHandler.Checkout)
+			pr.Get("/billing/portal", billingHandler.Portal)
+
+			// Feedback - Usuários podem enviar feedback
+			pr.Post("/feedback", feedbackHandler.Create)
+			pr.Get("/feedback/mine", feedbackHandler.Mine)
+			pr.Post("/feedback/{id}/replies", feedbackHandler.UserReply)
+
+			// Analytics - Rastreamento de eventos
+			pr.Post("/analytics/track", analyticsHandler.Track)
+			pr.Post("/analytics/batch", analyticsHandler.Batch)
+
+			// Share - Gerenciar links de compartilhamento
+			pr.With(features.Require(features.ShareLinks)).Post("/share/links", shareHandler.CreateLink)
+			pr.Get("/share/links", shareHandler.ListLinks)
+			pr.Patch("/share/links/{id}", shareHandler.UpdateLink)
+			pr.Delete("/share/links/{id}", shareHandler.DeleteLink)
+			pr.Get("/share/links/{id}/accesses", shareHandler.ListAccesses)
+
+			// Abertura do memorial (N de M pessoas de confiança confirmam)
+			pr.Get("/memorial", shareHandler.GetMemorial)
+			pr.Put("/memorial", shareHandler.SaveMemorial)
+			pr.Delete("/memorial", shareHandler.DeleteMemorial)
+			pr.Post("/memorial/abort", shareHandler.AbortMemorial)
+
+			// Portal do guardião (conta vinculada com token + PIN do link)
+			pr.With(shareLimiter.Middleware(security.GetClientIP)).Post("/guardian/link", shareHandler.LinkGuardianAccount)
+			pr.Get("/guardian/dashboard", shareHandler.GuardianDashboard)
+			pr.Get("/guardian/boxes/{guardianID}", shareHandler.GuardianBoxContent)
+			pr.Delete("/guardian/boxes/{guardianID}", shareHandler.UnlinkGuardianAccount)
+
+			// Webhooks de saída (integrações)
+			pr.Route("/webhooks", func(wr chi.Router) {
+				wr.Use(features.Require(features.Webhooks))
+				wr.Get("/", webhooksHandler.List)
+				wr.Post("/", webhooksHandler.Create)
+				wr.Put("/{id}", webhooksHandler.Update)
+				wr.Delete("/{id}", webhooksHandler.Delete)
+				wr.Get("/{id}/deliveries", webhooksHandler.Deliveries)
+				wr.Post("/{id}/test", webhooksHandler.Test)
+			})
+		})
+
+		// ─────────────────────────────────────────────────────────────────────
+		// ROTAS PÚBLICAS DE COMPARTILHAMENTO (não requerem autenticação)
+		// ─────────────────────────────────────────────────────────────────────
+
+		api.Route("/shared", func(sr chi.Router) {
+			// Rate limit mais restrito (páginas indexadas são varridas por
+			// robôs) e CAPTCHA para quem acumula tokens inválidos
+			sr.Use(publicShareLimiter.Middleware(security.GetClientIP))
+			sr.Use(shareBotGuard.Middleware)
+			sr.Use(features.Require(features.ShareLinks))
+
+			// Acessar conteúdo compartilhado
+			sr.Get("/{token}", shareHandler.AccessShared)
+			// Prévia sem conteúdo (título e descrição do link)
+			sr.Get("/{token}/preview", shareHandler.Preview)
+			// Verificar PIN e acessar
+			sr.Post("/{token}/verify", shareHandler.VerifyPIN)
+		})
+
+		// Calendário ICS (token no link; clientes de calendário não autenticam)
+		api.With(shareLimiter.Middleware(security.GetClientIP)).Get("/box/calendar.ics", boxHandler.CalendarFeed)
+
+		// Captura pelo navegador (token pessoal; o bookmarklet roda em outros sites)
+		api.With(captureLimiter.Middleware(security.GetClientIP)).Post("/capture", boxHandler.Capture)
+
+		// Cartão de emergência (token no link; socorristas não têm conta)
+		api.Route("/emergency-card/{token}", func(er chi.Router) {
+			er.Use(publicShareLimiter.Middleware(security.GetClientIP))
+			er.Use(shareBotGuard.Middleware)
+			er.Get("/", emergencyCardHandler.Public)
+			er.Get("/print", emergencyCardHandler.Print)
+		})
+
+		// Confirmação dos avisos de escalonamento (token no link do aviso)
+		api.Route("/emergency-ack/{token}", func(er chi.Router) {
+			er.Use(publicShareLimiter.Middleware(security.GetClientIP))
+			er.Use(shareBotGuard.Middleware)
+			er.Get("/", emergencyCardHandler.AckStatus)
+			er.Post("/", emergencyCardHandler.Acknowledge)
+		})
+
+		// ─────────────────────────────────────────────────────────────────────
+		// ROTA DE ACESSO DO GUARDIÃO (nova arquitetura integrada)
+		// ─────────────────────────────────────────────────────────────────────
+		api.Route("/guardian-access", func(sr chi.Router) {
+			sr.Use(publicShareLimiter.Middleware(security.GetClientIP))
+			sr.Use(shareBotGuard.Middleware)
+			sr.Use(features.Require(features.ShareLinks))
+			sr.Get("/{token}", shareHandler.AccessGuardianView)
+			sr.Post("/{token}/verify", shareHandler.VerifyGuardianPIN)
+			sr.Post("/{token}/my-data", shareHandler.GuardianMyData)
+			sr.Post("/{token}/deletion-request", shareHandler.RequestGuardianDeletion)
+			sr.Post("/{token}/memorial", shareHandler.ConfirmMemorial)
+			sr.With(guardianCommentLimiter.Middleware(security.GetClientIP)).Post("/{token}/items/{itemID}/comments", shareHandler.CreateGuardianComment)
+		})
+
+		// ─────────────────────────────────────────────────────────────────────
+		// ROTAS ADMINISTRATIVAS (requerem autenticação JWT + papel administrativo)
+		// ─────────────────────────────────────────────────────────────────────
+		// support: contas e feedbacks | analyst: métricas | superadmin: tudo
+
+		api.Route("/admin", func(ar chi.Router) {
+			// Autenticação JWT obrigatória
+			ar.Use(auth.JWTMiddleware(jwtSecret, cfg.Auth.TokenClients))
+			ar.Use(auth.ActiveUserMiddleware(store))
+			// CSRF - validar origem para requisições mutantes
+			ar.Use(security.CSRFMiddleware(allowedOrigins, isDev))
+			// Qualquer papel administrativo
+			ar.Use(auth.RequireRole(storage.AdminRoles...))
+
+			// Dashboard com métricas
+			ar.Get("/dashboard", adminHandler.Dashboard)
+			// Health check detalhado
+			ar.Get("/health", adminHandler.Health)
+			// Resumo dos feedbacks
+			ar.Get("/feedbacks/stats", feedbackHandler.GetStats)
+			// Feature flags (estado atual)
+			ar.Get("/features", featuresHandler.List)
+			// Retenção de dados (prazos e execuções recentes)
+			ar.Get("/retention", retentionHandler.List)
+			// Página de status (verificações completas e incidentes dos últimos 30 dias)
+			ar.Get("/status", adminHandler.AdminStatus)
+
+			// Atendimento - contas, atividade, feedbacks e incidentes da página de status
+			ar.Group(func(sr chi.Router) {
+				sr.Use(auth.RequireRole(storage.RoleSupport))
+
+				sr.Get("/users", adminHandler.Users)
+				sr.Post("/users/{id}/disable", adminHandler.DisableUser)
+				sr.Post("/users/{id}/enable", adminHandler.EnableUser)
+				sr.Post("/users/{id}/reset-password", adminHandler.ResetUserPassword)
+				sr.Post("/users/{id}/impersonate", authHandler.StartImpersonation)
+				sr.Get("/activity", adminHandler.Activity)
+				sr.Get("/feedbacks", feedbackHandler.List)
+				sr.Get("/feedbacks/{id}", feedbackHandler.Get)
+				sr.Patch("/feedbacks/{id}", feedbackHandler.Update)
+				sr.Post("/feedbacks/{id}/replies", feedbackHandler.AdminReply)
+				sr.Get("/export/feedbacks", adminHandler.ExportFeedbacks)
+				sr.Post("/status/incidents", adminHandler.CreateIncident)
+				sr.Patch("/status/incidents/{id}", adminHandler.UpdateIncident)
+				sr.Delete("/status/incidents/{id}", adminHandler.DeleteIncident)
+			})
+
+			// Analytics - Métricas de uso da aplicação
+			ar.Group(func(an chi.Router) {
+				an.Use(auth.RequireRole(storage.RoleAnalyst))
+
+				an.Get("/analytics/summary", analyticsHandler.GetSummary)
+				an.Get("/analytics/events", analyticsHandler.GetRecentEvents)
+				an.Get("/analytics/daily", analyticsHandler.GetDailyStats)
+				an.Get("/analytics/funnel", analyticsHandler.GetFunnel)
+				an.Get("/analytics/cohorts", analyticsHandler.GetCohorts)
+				an.Get("/analytics/completeness", analyticsHandler.GetCompleteness)
+				an.Get("/usage", adminHandler.Usage)
+				an.Get("/assistant", adminHandler.AssistantUsage)
+				an.Get("/export/analytics-daily", adminHandler.ExportAnalyticsDaily)
+				an.Get("/export/user-growth", adminHandler.ExportUserGrowth)
+			})
+
+			// Superadmin - remoção de contas, papéis, feature flags, retenção, conteúdo do Guia e backups
+			ar.Group(func(su chi.Router) {
+				su.Use(auth.RequireRole(storage.RoleSuperadmin))
+
+				su.Delete("/users/{id}", adminHandler.DeleteUser)
+				su.Put("/users/{id}/role", adminHandler.GrantRole)
+				su.Delete("/users/{id}/role", adminHandler.RevokeRole)
+				su.Put("/features/{name}", featuresHandler.Update)
+				su.Put("/retention/{class}", retentionHandler.Update)
+				su.Post("/retention/run", retentionHandler.Run)
+				su.Post("/backup", backupHandler.Create)
+
+				// Conteúdo do Guia Famli (textos por idioma, ordem, ativação e itens sugeridos)
+				su.Get("/guide/cards", guideHandler.AdminListCards)
+				su.Post("/guide/cards", guideHandler.AdminCreateCard)
+				su.Put("/guide/cards/{cardID}", guideHandler.AdminUpdateCard)
+				su.Delete("/guide/cards/{cardID}", guideHandler.AdminDeleteCard)
+			})
+		})
+	})
+
+	return &Server{Router: r, webhooks: webhookDispatcher, digest: digestScheduler, rollup: analytics.NewRollup(store), events: analyticsBuffer, expiry: box.NewExpiryReminders(store), retention: retentionJob, whatsapp: whatsappService, inbound: inboundService, status: adminHandler,
+		share: shareHandler, previewLimiter: security.NewRateLimiter(security.SharePreviewRateLimit)}
+}
+
+// MountFrontend serve o frontend (SPA) nas rotas fora da API. As páginas dos
+// links compartilhados saem com a prévia do link nas meta tags (Open Graph),
+// sem dados do conteúdo (ver share.PreviewPage).
+func (s *Server) MountFrontend(spa *web.SPA) {
+	page := s.share.PreviewPage(spa, s.previewLimiter)
+	s.Router.Get("/compartilhado/{token}", page)
+	s.Router.Get("/shared/{token}", page)
+	s.Router.Handle("/*", spa)
+}
+
+// Start inicia os workers de background (retentativas de webhooks, resumos
+// pelo WhatsApp, gravação e rollups de analytics, lembretes de vencimento,
+// retentativas dos avisos aos guardiões, conversas paradas do WhatsApp e do
+// gateway de email, retenção de dados);
+// param com o cancelamento do contexto
+func (s *Server) Start(ctx context.Context) {
+	s.webhooks.Start(ctx)
+	s.events.Start(ctx)
+	s.rollup.Start(ctx)
+	s.expiry.Start(ctx)
+	s.retention.Start(ctx)
+	s.status.StartStatusMonitor(ctx)
+	s.whatsapp.StartAlerts(ctx)
+	s.whatsapp.StartSessionExpiry(ctx)
+	s.share.StartMemorialActivations(ctx)
+	if s.inbound.Enabled() {
+		s.inbound.StartSessionExpiry(ctx)
+	}
+	if s.digest != nil {
+		s.digest.Start(ctx)
+	}
+}
+
+// Stop grava o que ficou nas filas em memória (eventos de analytics)
+// Chamar depois de encerrar o servidor HTTP, dentro do prazo de ctx.
+func (s *Server) Stop(ctx context.Context) {
+	s.events.Stop(ctx)
+}
+
+// backupConfig monta a configuração de backup a partir de BACKUP_*
+func backupConfig(cfg *config.Config) *backup.Config {
+	bc := &backup.Config{
+		EncryptionKey: cfg.Backup.EncryptionKey,
+		Dir:           cfg.Backup.Dir,
+	}
+	if cfg.Backup.S3Bucket != "" {
+		bc.S3 = &backup.S3Config{
+			Bucket:          cfg.Backup.S3Bucket,
+			Region:          cfg.Backup.S3Region,
+			Endpoint:        cfg.Backup.S3Endpoint,
+			Prefix:          cfg.Backup.S3Prefix,
+			AccessKeyID:     cfg.Backup.S3AccessKeyID,
+			SecretAccessKey: cfg.Backup.S3SecretAccessKey,
+		}
+	}
+	return bc
+}
@@ -0,0 +1,668 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"famli/internal/storage"
+	"famli/internal/testutil"
+	"famli/internal/web"
+)
+
+// createShareLink cria o link e retorna o token (última parte da URL)
+func createShareLink(t *testing.T, c *testutil.Client, payload map[string]interface{}) (id, token string) {
+	t.Helper()
+	resp := c.Post("/api/share/links", payload).Expect(http.StatusCreated)
+	url := resp.String("url")
+	token = url[strings.LastIndex(url, "/")+1:]
+	if token == "" {
+		t.Fatalf("link sem token: %s", resp.Body)
+	}
+	return resp.String("id"), token
+}
+
+func TestShareLinkAccess(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+
+	maria.Post("/api/box/items", map[string]interface{}{
+		"type":      "info",
+		"title":     "Seguro de vida",
+		"is_shared": true,
+	}).Expect(http.StatusCreated)
+	maria.Post("/api/box/items", map[string]interface{}{
+		"type":  "note",
+		"title": "Particular",
+	}).Expect(http.StatusCreated)
+
+	linkID, token := createShareLink(t, maria, map[string]interface{}{
+		"name": "Para a família",
+		"type": "normal",
+	})
+
+	var view struct {
+		UserName string `json:"user_name"`
+		Items    []struct {
+			Title string `json:"title"`
+		} `json:"items"`
+	}
+	visitor := h.NewClient()
+	visitor.Get("/api/shared/" + token).Expect(http.StatusOK).JSON(&view)
+	if len(view.Items) != 1 || view.Items[0].Title != "Seguro de vida" {
+		t.Fatalf("link deveria mostrar apenas o item compartilhado: %+v", view)
+	}
+
+	visitor.Get("/api/shared/token-invalido").Expect(http.StatusNotFound)
+
+	// Removido, o link deixa de funcionar
+	maria.Delete("/api/share/links/" + linkID).Expect(http.StatusOK)
+	visitor.Get("/api/shared/" + token).Expect(http.StatusNotFound)
+}
+
+func TestShareLinkWithPIN(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+	maria.Post("/api/box/items", map[string]interface{}{
+		"type":      "info",
+		"title":     "Senha do cofre",
+		"is_shared": true,
+	}).Expect(http.StatusCreated)
+
+	_, token := createShareLink(t, maria, map[string]interface{}{
+		"name": "Com PIN",
+		"type": "normal",
+		"pin":  "2468",
+	})
+
+	visitor := h.NewClient()
+	preview := visitor.Get("/api/shared/" + token).Expect(http.StatusOK).Map()
+	if preview["requires_pin"] != true || preview["items"] != nil {
+		t.Fatalf("conteúdo exposto antes do PIN: %v", preview)
+	}
+
+	visitor.Post("/api/shared/"+token+"/verify", map[string]string{"pin": "1111"}).Expect(http.StatusUnauthorized)
+
+	var view struct {
+		Items []struct {
+			Title string `json:"title"`
+		} `json:"items"`
+	}
+	visitor.Post("/api/shared/"+token+"/verify", map[string]string{"pin": "2468"}).Expect(http.StatusOK).JSON(&view)
+	if len(view.Items) != 1 || view.Items[0].Title != "Senha do cofre" {
+		t.Fatalf("conteúdo inesperado após o PIN: %+v", view)
+	}
+}
+
+func TestShareLinksAreScopedToOwner(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+	joao := h.Register("joao@example.com", "João")
+
+	linkID, _ := createShareLink(t, maria, map[string]interface{}{"name": "Família", "type": "normal"})
+
+	var list struct {
+		Links []struct {
+			ID string `json:"id"`
+		} `json:"links"`
+	}
+	joao.Get("/api/share/links").Expect(http.StatusOK).JSON(&list)
+	if len(list.Links) != 0 {
+		t.Fatalf("João vê links de outra conta: %+v", list)
+	}
+	joao.Delete("/api/share/links/" + linkID).Expect(http.StatusNotFound)
+
+	maria.Get("/api/share/links").Expect(http.StatusOK).JSON(&list)
+	if len(list.Links) != 1 || list.Links[0].ID != linkID {
+		t.Fatalf("listagem inesperada: %+v", list)
+	}
+}
+
+func TestShareLinkURLFromConfig(t *testing.T) {
+	h := testutil.New(t, map[string]string{"APP_BASE_URL": "https://familia.example.org/"})
+	maria := h.Register("maria@example.com", "Maria")
+
+	url := maria.Post("/api/share/links", map[string]interface{}{"name": "Família", "type": "normal"}).
+		Expect(http.StatusCreated).String("url")
+	if !strings.HasPrefix(url, "https://familia.example.org/compartilhado/") {
+		t.Fatalf("URL do link não usa APP_BASE_URL: %q", url)
+	}
+
+	// O caminho segue o idioma do dono, também na listagem
+	if err := h.Store.UpdateUserLocale(maria.User.ID, "en"); err != nil {
+		t.Fatal(err)
+	}
+	var list struct {
+		Links []struct {
+			URL string `json:"url"`
+		} `json:"links"`
+	}
+	maria.Get("/api/share/links").Expect(http.StatusOK).JSON(&list)
+	token := url[strings.LastIndex(url, "/")+1:]
+	if len(list.Links) != 1 || list.Links[0].URL != "https://familia.example.org/shared/"+token {
+		t.Fatalf("link de dono em inglês deveria usar /shared: %+v", list.Links)
+	}
+
+	h = testutil.New(t, map[string]string{"SHARE_URL_PREFIX": "https://s.example.org/"})
+	joao := h.Register("joao@example.com", "João")
+	url = joao.Post("/api/share/links", map[string]interface{}{"name": "Família", "type": "normal"}).
+		Expect(http.StatusCreated).String("url")
+	if !strings.HasPrefix(url, "https://s.example.org/") || strings.Contains(url, "compartilhado") {
+		t.Fatalf("URL do link não usa SHARE_URL_PREFIX: %q", url)
+	}
+}
+
+func TestShareLinkInvalidTokensLookAlike(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+
+	_, token := createShareLink(t, maria, map[string]interface{}{
+		"name":     "Uma vez",
+		"type":     "normal",
+		"max_uses": 1,
+	})
+
+	visitor := h.NewClient()
+	visitor.Get("/api/shared/" + token).Expect(http.StatusOK)
+
+	// Esgotado, inexistente e guardião inexistente: mesma resposta
+	used := visitor.Get("/api/shared/"+token).ExpectError(http.StatusNotFound, "SHARE_LINK_EXPIRED").Map()
+	missing := visitor.Get("/api/shared/token-invalido").ExpectError(http.StatusNotFound, "SHARE_LINK_EXPIRED").Map()
+	guardian := visitor.Get("/api/guardian-access/token-invalido").ExpectError(http.StatusNotFound, "SHARE_LINK_EXPIRED").Map()
+	if used["message"] != missing["message"] || missing["message"] != guardian["message"] {
+		t.Fatalf("respostas diferentes para tokens inválidos: %v / %v / %v", used, missing, guardian)
+	}
+}
+
+func TestShareTokenScanIsBlocked(t *testing.T) {
+	h := testutil.New(t, map[string]string{"SHARE_ABUSE_THRESHOLD": "3"})
+	maria := h.Register("maria@example.com", "Maria")
+	_, token := createShareLink(t, maria, map[string]interface{}{"name": "Família", "type": "normal"})
+
+	scanner := h.NewClient()
+	for i := 0; i < 3; i++ {
+		scanner.Get("/api/shared/token-" + strconv.Itoa(i)).Expect(http.StatusNotFound)
+	}
+
+	// Sem CAPTCHA configurado, o IP fica bloqueado (inclusive nos tokens válidos)
+	blocked := scanner.Get("/api/shared/"+token).ExpectError(http.StatusTooManyRequests, "SECURITY_RATE_LIMITED")
+	if blocked.Header.Get("Retry-After") == "" {
+		t.Fatal("429 sem Retry-After")
+	}
+	scanner.Get("/api/guardian-access/token-0").ExpectError(http.StatusTooManyRequests, "SECURITY_RATE_LIMITED")
+
+	// Outros IPs não são afetados
+	h.NewClient().Get("/api/shared/" + token).Expect(http.StatusOK)
+}
+
+func TestShareTokenScanRequiresCaptcha(t *testing.T) {
+	var verified []string
+	siteverify := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		verified = append(verified, r.PostForm.Get("response"))
+		success := r.PostForm.Get("secret") == "segredo" && r.PostForm.Get("response") == "resolvido"
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": success})
+	}))
+	defer siteverify.Close()
+
+	h := testutil.New(t, map[string]string{
+		"SHARE_ABUSE_THRESHOLD": "3",
+		"CAPTCHA_PROVIDER":      "turnstile",
+		"CAPTCHA_SITE_KEY":      "chave-publica",
+		"CAPTCHA_SECRET_KEY":    "segredo",
+		"CAPTCHA_VERIFY_URL":    siteverify.URL,
+	})
+	maria := h.Register("maria@example.com", "Maria")
+	_, token := createShareLink(t, maria, map[string]interface{}{"name": "Família", "type": "normal"})
+
+	visitor := h.NewClient()
+	for i := 0; i < 3; i++ {
+		visitor.Get("/api/shared/token-" + strconv.Itoa(i)).Expect(http.StatusNotFound)
+	}
+
+	challenge := visitor.Get("/api/shared/"+token).ExpectError(http.StatusForbidden, "CAPTCHA_REQUIRED").Map()
+	details, _ := challenge["details"].(map[string]interface{})
+	if details["provider"] != "turnstile" || details["site_key"] != "chave-publica" {
+		t.Fatalf("desafio sem os dados do widget: %v", challenge)
+	}
+	visitor.WithHeader("X-Captcha-Token", "errado").Get("/api/shared/"+token).
+		ExpectError(http.StatusForbidden, "CAPTCHA_REQUIRED")
+
+	// Resolvido, o desafio vale para as próximas requisições (cookie)
+	visitor.WithHeader("X-Captcha-Token", "resolvido").Get("/api/shared/" + token).Expect(http.StatusOK)
+	visitor.Get("/api/shared/" + token).Expect(http.StatusOK)
+	if len(verified) != 2 {
+		t.Fatalf("verificações no provedor: %v", verified)
+	}
+}
+
+func TestShareLinkPreview(t *testing.T) {
+	h := testutil.New(t, nil)
+	spa, err := web.NewSPAFS(fstest.MapFS{"index.html": {Data: []byte(`<!DOCTYPE html><html lang="pt-BR"><head>
+<title>Famli - Organize memórias e orientações para quem você ama</title>
+<meta name="description" content="Transmita o que importa." />
+<meta name="robots" content="index, follow" />
+<meta property="og:url" content="https://famli.me/" />
+<meta property="og:title" content="Famli - Organize memórias" />
+<meta property="og:description" content="Transmita o que importa." />
+<meta name="twitter:title" content="Famli - Organize memórias" />
+</head><body><div id="app"></div></body></html>`)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.App.MountFrontend(spa)
+
+	maria := h.Register("maria@example.com", "Maria Souza")
+	maria.Post("/api/box/items", map[string]interface{}{
+		"type":      "info",
+		"title":     "Seguro de vida",
+		"is_shared": true,
+	}).Expect(http.StatusCreated)
+	_, token := createShareLink(t, maria, map[string]interface{}{"name": "Para o Pedro", "type": "emergency", "pin": "4321"})
+
+	// API: só o primeiro nome do dono, sem contar acesso
+	visitor := h.NewClient()
+	preview := visitor.Get("/api/shared/" + token + "/preview").Expect(http.StatusOK)
+	if got := preview.String("title"); got != "Maria compartilhou algo com você no Famli" {
+		t.Fatalf("título da prévia: %q", got)
+	}
+	visitor.Get("/api/shared/token-invalido/preview").ExpectError(http.StatusNotFound, "SHARE_LINK_EXPIRED")
+
+	// Página: meta tags da prévia, sem cache nem indexação
+	page := visitor.Get("/compartilhado/" + token).Expect(http.StatusOK)
+	body := string(page.Body)
+	for _, want := range []string{
+		`<title>Maria compartilhou algo com você no Famli</title>`,
+		`<meta property="og:title" content="Maria compartilhou algo com você no Famli" />`,
+		`<meta name="twitter:title" content="Maria compartilhou algo com você no Famli" />`,
+		`<meta property="og:description" content="Abra o link para ver com segurança." />`,
+		`<meta name="robots" content="noindex, nofollow" />`,
+		`<div id="app"></div>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("página sem %s:\n%s", want, body)
+		}
+	}
+	for _, leaked := range []string{"Souza", "Pedro", "Seguro", "emergency"} {
+		if strings.Contains(body, leaked) {
+			t.Fatalf("prévia expõe %q:\n%s", leaked, body)
+		}
+	}
+	if page.Header.Get("Cache-Control") != "no-store, no-cache, must-revalidate" || page.Header.Get("X-Robots-Tag") == "" {
+		t.Fatalf("página da prévia com cache ou indexável: %v", page.Header)
+	}
+
+	// Em inglês e para links indisponíveis: prévia genérica, ainda com o SPA
+	page = visitor.WithHeader("Accept-Language", "en").Get("/shared/token-invalido").Expect(http.StatusOK)
+	if !strings.Contains(string(page.Body), `<title>Someone shared something with you on Famli</title>`) {
+		t.Fatalf("prévia genérica:\n%s", page.Body)
+	}
+
+	// Os acessos à prévia não contam como uso do link
+	var links struct {
+		Links []struct {
+			UsageCount int `json:"usage_count"`
+		} `json:"links"`
+	}
+	maria.Get("/api/share/links").Expect(http.StatusOK).JSON(&links)
+	if len(links.Links) != 1 || links.Links[0].UsageCount != 0 {
+		t.Fatalf("prévia contou como acesso: %+v", links.Links)
+	}
+
+	// Demais páginas continuam com o index.html padrão
+	home := visitor.Get("/box").Expect(http.StatusOK)
+	if !strings.Contains(string(home.Body), `<meta name="robots" content="index, follow" />`) {
+		t.Fatalf("página comum alterada:\n%s", home.Body)
+	}
+}
+
+// TestSharedViewSearch cobre a busca (?q=, ?category=) no link e no acesso do guardião
+func TestSharedViewSearch(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+
+	for _, item := range []map[string]interface{}{
+		{"type": "info", "title": "Seguro de vida", "content": "Apólice na gaveta", "category": "finanças"},
+		{"type": "info", "title": "Conta no banco", "content": "Agência 123", "category": "finanças"},
+		{"type": "info", "title": "Plano de saúde", "content": "Carteirinha na bolsa", "category": "saúde"},
+		{"type": "note", "title": "Apólice particular", "category": "finanças", "is_shared": false},
+	} {
+		if _, ok := item["is_shared"]; !ok {
+			item["is_shared"] = true
+		}
+		maria.Post("/api/box/items", item).Expect(http.StatusCreated)
+	}
+
+	type view struct {
+		Items []struct {
+			Title string `json:"title"`
+		} `json:"items"`
+		Search *struct {
+			Query    string `json:"q"`
+			Category string `json:"category"`
+			Total    int    `json:"total"`
+		} `json:"search"`
+	}
+	titles := func(v view) string {
+		names := make([]string, 0, len(v.Items))
+		for _, item := range v.Items {
+			names = append(names, item.Title)
+		}
+		return strings.Join(names, ",")
+	}
+
+	// Link restrito a finanças: a busca não alcança outras categorias nem itens privados
+	_, token := createShareLink(t, maria, map[string]interface{}{
+		"name":       "Finanças",
+		"type":       "normal",
+		"categories": []string{"finanças"},
+	})
+	visitor := h.NewClient()
+	var v view
+	visitor.Get("/api/shared/" + token + "?q=APOLICE").Expect(http.StatusOK).JSON(&v)
+	if titles(v) != "Seguro de vida" || v.Search == nil || v.Search.Query != "APOLICE" || v.Search.Total != 2 {
+		t.Fatalf("busca no link: %+v", v)
+	}
+	visitor.Get("/api/shared/" + token + "?q=carteirinha").Expect(http.StatusOK).JSON(&v)
+	if len(v.Items) != 0 {
+		t.Fatalf("busca fora do escopo do link: %+v", v)
+	}
+	visitor.Get("/api/shared/"+token+"?q="+strings.Repeat("a", 201)).
+		ExpectError(http.StatusBadRequest, "SHARE_INVALID_SEARCH")
+
+	// Sem filtro, a resposta não traz search
+	var all view
+	visitor.Get("/api/shared/" + token).Expect(http.StatusOK).JSON(&all)
+	if len(all.Items) != 2 || all.Search != nil {
+		t.Fatalf("link sem busca: %+v", all)
+	}
+
+	// Acesso do guardião: texto (todas as palavras) e categoria
+	guardianToken := maria.Post("/api/guardians", map[string]string{
+		"name":       "Pedro",
+		"access_pin": "4321",
+	}).Expect(http.StatusCreated).String("access_token")
+	guardian := h.NewClient()
+	guardian.Post("/api/guardian-access/"+guardianToken+"/verify?category=Sa%C3%BAde", map[string]string{"pin": "4321"}).
+		Expect(http.StatusOK).JSON(&v)
+	if titles(v) != "Plano de saúde" || v.Search.Total != 3 {
+		t.Fatalf("busca por categoria: %+v", v)
+	}
+	guardian.Post("/api/guardian-access/"+guardianToken+"/verify?q=conta+agencia", map[string]string{"pin": "4321"}).
+		Expect(http.StatusOK).JSON(&v)
+	if titles(v) != "Conta no banco" {
+		t.Fatalf("busca com várias palavras: %+v", v)
+	}
+	// Busca inválida não consome tentativa de PIN
+	guardian.Post("/api/guardian-access/"+guardianToken+"/verify?category="+strings.Repeat("x", 201), map[string]string{"pin": "0000"}).
+		ExpectError(http.StatusBadRequest, "SHARE_INVALID_SEARCH")
+}
+
+// TestMemorialActivation cobre a abertura do memorial com N de M confirmações
+func TestMemorialActivation(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+	maria.Post("/api/box/items", map[string]interface{}{
+		"type":      "info",
+		"title":     "Carta para a família",
+		"is_shared": true,
+	}).Expect(http.StatusCreated)
+
+	guardianIDs := map[string]string{}
+	tokens := map[string]string{}
+	for _, name := range []string{"Ana", "Bruno", "Carla", "Davi"} {
+		created := maria.Post("/api/guardians", map[string]string{"name": name, "access_pin": "4321"}).Expect(http.StatusCreated)
+		guardianIDs[name], tokens[name] = created.String("id"), created.String("access_token")
+	}
+	confirm := func(name, pin string) *testutil.Response {
+		return h.NewClient().Post("/api/guardian-access/"+tokens[name]+"/memorial", map[string]string{"pin": pin})
+	}
+
+	// Sem configuração, o link memorial abre como antes
+	_, token := createShareLink(t, maria, map[string]interface{}{"name": "Memorial", "type": "memorial"})
+	visitor := h.NewClient()
+	visitor.Get("/api/shared/" + token).Expect(http.StatusOK)
+	confirm("Ana", "4321").ExpectError(http.StatusForbidden, "MEMORIAL_NOT_DESIGNATED")
+
+	designated := []string{guardianIDs["Ana"], guardianIDs["Bruno"], guardianIDs["Carla"]}
+	maria.Put("/api/memorial", map[string]interface{}{"guardian_ids": []string{}}).
+		ExpectError(http.StatusBadRequest, "MEMORIAL_INVALID_GUARDIANS")
+	maria.Put("/api/memorial", map[string]interface{}{"guardian_ids": []string{guardianIDs["Ana"], guardianIDs["Ana"]}}).
+		ExpectError(http.StatusBadRequest, "MEMORIAL_INVALID_GUARDIANS")
+	maria.Put("/api/memorial", map[string]interface{}{"guardian_ids": designated, "required_confirmations": 4}).
+		ExpectError(http.StatusBadRequest, "MEMORIAL_INVALID_CONFIRMATIONS")
+	maria.Put("/api/memorial", map[string]interface{}{"guardian_ids": designated, "delay_hours": 1000}).
+		ExpectError(http.StatusBadRequest, "MEMORIAL_INVALID_DELAY")
+
+	saved := maria.Put("/api/memorial", map[string]interface{}{"guardian_ids": designated, "delay_hours": 24}).Expect(http.StatusOK).Map()
+	config := saved["config"].(map[string]interface{})
+	if config["required_confirmations"] != float64(2) || config["delay_hours"] != float64(24) || saved["locked"] != true {
+		t.Fatalf("configuração salva: %v", saved)
+	}
+
+	// Com a configuração, o link fica fechado até a liberação
+	visitor.Get("/api/shared/"+token).ExpectError(http.StatusForbidden, "SHARE_MEMORIAL_LOCKED")
+	confirm("Davi", "4321").ExpectError(http.StatusForbidden, "MEMORIAL_NOT_DESIGNATED")
+	confirm("Ana", "0000").Expect(http.StatusUnauthorized)
+
+	type memorialStatus struct {
+		Status         string     `json:"status"`
+		Confirmations  int        `json:"confirmations"`
+		Required       int        `json:"required_confirmations"`
+		ConfirmedByYou bool       `json:"confirmed_by_you"`
+		UnlocksAt      *time.Time `json:"unlocks_at"`
+	}
+	var status memorialStatus
+	confirm("Ana", "4321").Expect(http.StatusOK).JSON(&status)
+	if status.Status != "pending" || status.Confirmations != 1 || status.Required != 2 || !status.ConfirmedByYou {
+		t.Fatalf("pedido aberto: %+v", status)
+	}
+	confirm("Ana", "4321").Expect(http.StatusOK).JSON(&status)
+	if status.Confirmations != 1 {
+		t.Fatalf("confirmação repetida contou de novo: %+v", status)
+	}
+
+	// As outras pessoas escolhidas são avisadas do pedido
+	var alerts struct {
+		Alerts []struct {
+			GuardianID string `json:"guardian_id"`
+			Event      string `json:"event"`
+		} `json:"alerts"`
+	}
+	maria.Get("/api/guardians/alerts").Expect(http.StatusOK).JSON(&alerts)
+	if len(alerts.Alerts) != 2 {
+		t.Fatalf("avisos do pedido: %+v", alerts.Alerts)
+	}
+	for _, a := range alerts.Alerts {
+		if a.Event != "memorial.activation" || (a.GuardianID != guardianIDs["Bruno"] && a.GuardianID != guardianIDs["Carla"]) {
+			t.Fatalf("aviso inesperado: %+v", a)
+		}
+	}
+
+	// O dono cancela o pedido
+	aborted := maria.Post("/api/memorial/abort", nil).Expect(http.StatusOK)
+	if aborted.String("status") != "aborted" {
+		t.Fatalf("pedido cancelado: %s", aborted.Body)
+	}
+	maria.Post("/api/memorial/abort", nil).ExpectError(http.StatusNotFound, "MEMORIAL_NOT_ACTIVE")
+
+	// Novo pedido: com 2 confirmações começa a espera
+	confirm("Ana", "4321").Expect(http.StatusOK)
+	status.UnlocksAt = nil
+	confirm("Bruno", "4321").Expect(http.StatusOK).JSON(&status)
+	if status.Status != "waiting" || status.Confirmations != 2 || status.UnlocksAt == nil ||
+		status.UnlocksAt.Sub(time.Now()) < 23*time.Hour {
+		t.Fatalf("pedido confirmado: %+v", status)
+	}
+	visitor.Get("/api/shared/"+token).ExpectError(http.StatusForbidden, "SHARE_MEMORIAL_LOCKED")
+
+	// O acesso das pessoas escolhidas mostra o andamento
+	var view struct {
+		Memorial *memorialStatus `json:"memorial"`
+	}
+	h.NewClient().Post("/api/guardian-access/"+tokens["Carla"]+"/verify", map[string]string{"pin": "4321"}).
+		Expect(http.StatusOK).JSON(&view)
+	if view.Memorial == nil || view.Memorial.Status != "waiting" || view.Memorial.ConfirmedByYou {
+		t.Fatalf("andamento para Carla: %+v", view.Memorial)
+	}
+	view.Memorial = nil
+	h.NewClient().Post("/api/guardian-access/"+tokens["Davi"]+"/verify", map[string]string{"pin": "4321"}).
+		Expect(http.StatusOK).JSON(&view)
+	if view.Memorial != nil {
+		t.Fatalf("andamento exposto a quem não foi escolhido: %+v", view.Memorial)
+	}
+
+	// O dono é avisado do início da espera
+	waiting := false
+	for deadline := time.Now().Add(2 * time.Second); !waiting && time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		var inbox struct {
+			Notifications []struct {
+				Title string `json:"title"`
+			} `json:"notifications"`
+		}
+		maria.Get("/api/notifications").Expect(http.StatusOK).JSON(&inbox)
+		for _, n := range inbox.Notifications {
+			waiting = waiting || n.Title == "Seu memorial será liberado em breve"
+		}
+	}
+	if !waiting {
+		t.Fatal("dono não foi avisado da espera")
+	}
+
+	// Fim da espera: o link abre
+	activation, err := h.Store.GetLatestMemorialActivation(maria.User.ID)
+	if err != nil {
+		t.Fatalf("pedido salvo: %v", err)
+	}
+	unlocksAt := time.Now().Add(-time.Minute)
+	activation.UnlocksAt = &unlocksAt
+	if err := h.Store.UpdateMemorialActivation(activation, storage.MemorialWaiting); err != nil {
+		t.Fatalf("encerrar a espera: %v", err)
+	}
+	visitor.Get("/api/shared/" + token).Expect(http.StatusOK)
+	state := maria.Get("/api/memorial").Expect(http.StatusOK).Map()
+	if state["locked"] != false || len(state["guardians"].([]interface{})) != 3 {
+		t.Fatalf("estado após a espera: %v", state)
+	}
+
+	// Cancelar depois de liberado fecha o link de novo
+	maria.Post("/api/memorial/abort", nil).Expect(http.StatusOK)
+	visitor.Get("/api/shared/"+token).ExpectError(http.StatusForbidden, "SHARE_MEMORIAL_LOCKED")
+
+	// Sem a configuração, o link volta a abrir sempre
+	maria.Delete("/api/memorial").Expect(http.StatusNoContent)
+	maria.Delete("/api/memorial").ExpectError(http.StatusNotFound, "MEMORIAL_NOT_FOUND")
+	visitor.Get("/api/shared/" + token).Expect(http.StatusOK)
+}
+
+// TestItemSend cobre o envio avulso de um item: link de uso único, com e sem PIN
+func TestItemSend(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+	joao := h.Register("joao@example.com", "João")
+
+	// O item não precisa estar compartilhado
+	itemID := maria.Post("/api/box/items", map[string]interface{}{
+		"type":    "info",
+		"title":   "Senha do wifi",
+		"content": "Fica atrás do roteador",
+	}).Expect(http.StatusCreated).String("id")
+	maria.Post("/api/box/items", map[string]interface{}{
+		"type":      "info",
+		"title":     "Outro item",
+		"is_shared": true,
+	}).Expect(http.StatusCreated)
+
+	path := "/api/box/items/" + itemID + "/send"
+	maria.Post(path, map[string]interface{}{"recipient_email": "não é email"}).
+		ExpectError(http.StatusBadRequest, "SHARE_SEND_INVALID_EMAIL")
+	maria.Post(path, map[string]interface{}{"recipient_email": "ana@example.com", "expires_in_hours": 200}).
+		ExpectError(http.StatusBadRequest, "SHARE_SEND_INVALID_EXPIRY")
+	maria.Post("/api/box/items/inexistente/send", map[string]interface{}{"recipient_email": "ana@example.com"}).
+		ExpectError(http.StatusNotFound, "SHARE_ITEM_NOT_FOUND")
+	joao.Post(path, map[string]interface{}{"recipient_email": "ana@example.com"}).
+		ExpectError(http.StatusNotFound, "SHARE_ITEM_NOT_FOUND")
+
+	var sent struct {
+		Link struct {
+			ID        string     `json:"id"`
+			Type      string     `json:"type"`
+			URL       string     `json:"url"`
+			MaxUses   int        `json:"max_uses"`
+			ExpiresAt *time.Time `json:"expires_at"`
+			ItemIDs   []string   `json:"item_ids"`
+		} `json:"link"`
+		EmailSent bool `json:"email_sent"`
+	}
+	maria.Post(path, map[string]interface{}{
+		"recipient_email": "ana@example.com",
+		"recipient_name":  "Ana",
+		"note":            "Para a visita de sábado",
+	}).Expect(http.StatusCreated).JSON(&sent)
+	if sent.Link.Type != "one_time" || sent.Link.MaxUses != 1 || len(sent.Link.ItemIDs) != 1 || sent.Link.ItemIDs[0] != itemID {
+		t.Fatalf("link inesperado: %+v", sent)
+	}
+	if sent.Link.ExpiresAt == nil || sent.Link.ExpiresAt.Sub(time.Now()) < 71*time.Hour || sent.Link.ExpiresAt.Sub(time.Now()) > 72*time.Hour {
+		t.Fatalf("validade padrão deveria ser 72 horas: %v", sent.Link.ExpiresAt)
+	}
+	if sent.EmailSent {
+		t.Fatal("sem provedor configurado, o email não deveria constar como enviado")
+	}
+	token := sent.Link.URL[strings.LastIndex(sent.Link.URL, "/")+1:]
+
+	// O link continua preso ao item enviado
+	maria.Patch("/api/share/links/"+sent.Link.ID, map[string]interface{}{"item_ids": []string{}}).
+		ExpectError(http.StatusBadRequest, "SHARE_INVALID_DATA")
+
+	var view struct {
+		LinkType string `json:"link_type"`
+		Items    []struct {
+			Title   string `json:"title"`
+			Content string `json:"content"`
+		} `json:"items"`
+	}
+	visitor := h.NewClient()
+	visitor.Get("/api/shared/" + token).Expect(http.StatusOK).JSON(&view)
+	if view.LinkType != "one_time" || len(view.Items) != 1 || view.Items[0].Title != "Senha do wifi" || view.Items[0].Content != "Fica atrás do roteador" {
+		t.Fatalf("o link deveria mostrar apenas o item enviado: %+v", view)
+	}
+
+	// Depois do primeiro acesso, o link deixa de funcionar
+	visitor.Get("/api/shared/"+token).ExpectError(http.StatusNotFound, "SHARE_LINK_EXPIRED")
+	h.NewClient().Get("/api/shared/"+token).ExpectError(http.StatusNotFound, "SHARE_LINK_EXPIRED")
+
+	var accesses struct {
+		Accesses []map[string]interface{} `json:"accesses"`
+	}
+	maria.Get("/api/share/links/" + sent.Link.ID + "/accesses").Expect(http.StatusOK).JSON(&accesses)
+	if len(accesses.Accesses) != 1 {
+		t.Fatalf("o dono deveria ver um único acesso: %+v", accesses)
+	}
+
+	// Com PIN, um PIN errado não gasta o acesso
+	maria.Post(path, map[string]interface{}{"recipient_email": "ana@example.com", "pin": "12"}).
+		ExpectError(http.StatusBadRequest, "SHARE_SEND_PIN_TOO_SHORT")
+	maria.Post(path, map[string]interface{}{
+		"recipient_email":  "ana@example.com",
+		"pin":              "2468",
+		"expires_in_hours": 1,
+	}).Expect(http.StatusCreated).JSON(&sent)
+	token = sent.Link.URL[strings.LastIndex(sent.Link.URL, "/")+1:]
+
+	if preview := visitor.Get("/api/shared/" + token).Expect(http.StatusOK).Map(); preview["requires_pin"] != true {
+		t.Fatalf("o link deveria pedir o PIN: %v", preview)
+	}
+	visitor.Post("/api/shared/"+token+"/verify", map[string]string{"pin": "1111"}).Expect(http.StatusUnauthorized)
+	view.Items = nil
+	visitor.Post("/api/shared/"+token+"/verify", map[string]string{"pin": "2468"}).Expect(http.StatusOK).JSON(&view)
+	if len(view.Items) != 1 || view.Items[0].Title != "Senha do wifi" {
+		t.Fatalf("conteúdo inesperado após o PIN: %+v", view)
+	}
+	visitor.Post("/api/shared/"+token+"/verify", map[string]string{"pin": "2468"}).
+		ExpectError(http.StatusNotFound, "SHARE_LINK_EXPIRED")
+}
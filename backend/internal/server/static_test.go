@@ -0,0 +1,125 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"famli/internal/testutil"
+	"famli/internal/web"
+)
+
+func TestAPIResponsesAreCompressed(t *testing.T) {
+	h := testutil.New(t, nil)
+	maria := h.Register("maria@example.com", "Maria")
+
+	resp := maria.WithHeader("Accept-Encoding", "gzip").Get("/api/box/items").Expect(http.StatusOK)
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("resposta da API sem gzip: %v", resp.Header)
+	}
+}
+
+func TestSPACachingAndPrecompression(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("index.html", `<!DOCTYPE html><html lang="pt-BR"><head><title>Famli - Organize memórias e orientações para quem você ama</title></head></html>`)
+	write("assets/index-BXk3a9Qz.js", "console.log('famli')")
+	write("assets/index-BXk3a9Qz.js.br", "brotli")
+	write("version.json", `{"build":"1"}`)
+
+	spa, err := web.NewSPA(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(path string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		spa.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// index.html: revalidado a cada acesso, 304 com o mesmo ETag
+	page := get("/box", nil)
+	etag := page.Header().Get("ETag")
+	if page.Code != http.StatusOK || etag == "" || page.Header().Get("Cache-Control") != "no-cache" {
+		t.Fatalf("index.html: %d %v", page.Code, page.Header())
+	}
+	if rec := get("/", map[string]string{"If-None-Match": etag}); rec.Code != http.StatusNotModified {
+		t.Fatalf("If-None-Match: status %d, esperado 304", rec.Code)
+	}
+
+	// Cada idioma tem sua versão (e seu ETag)
+	english := get("/box", map[string]string{"Accept-Language": "en-US,en;q=0.9"})
+	if !strings.Contains(english.Body.String(), `<html lang="en-US">`) || english.Header().Get("ETag") == etag {
+		t.Fatalf("index.html em inglês: %s %v", english.Body, english.Header())
+	}
+
+	// Assets com hash: imutáveis e pré-comprimidos quando o navegador aceita
+	asset := get("/assets/index-BXk3a9Qz.js", map[string]string{"Accept-Encoding": "gzip, br"})
+	if asset.Header().Get("Content-Encoding") != "br" || asset.Body.String() != "brotli" ||
+		!strings.Contains(asset.Header().Get("Cache-Control"), "immutable") {
+		t.Fatalf("asset pré-comprimido: %v %q", asset.Header(), asset.Body)
+	}
+	plain := get("/assets/index-BXk3a9Qz.js", nil)
+	if plain.Header().Get("Content-Encoding") != "" || plain.Body.String() != "console.log('famli')" {
+		t.Fatalf("asset sem compressão: %v %q", plain.Header(), plain.Body)
+	}
+
+	// version.json nunca fica em cache; arquivos inexistentes caem no index.html
+	if cc := get("/version.json", nil).Header().Get("Cache-Control"); !strings.Contains(cc, "no-store") {
+		t.Fatalf("version.json com cache: %q", cc)
+	}
+	if rec := get("/assets/removido-12345678.js", nil); !strings.Contains(rec.Body.String(), "<html") {
+		t.Fatalf("fallback da SPA: %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestSPAFromEmbeddedFS(t *testing.T) {
+	// Como no embed.FS, os arquivos não têm data de modificação
+	spa, err := web.NewSPAFS(fstest.MapFS{
+		"index.html":                   {Data: []byte(`<!DOCTYPE html><html lang="pt-BR"><head></head></html>`)},
+		"assets/index-BXk3a9Qz.css":    {Data: []byte("body{}")},
+		"assets/index-BXk3a9Qz.css.gz": {Data: []byte("gzip")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(path, encoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Encoding", encoding)
+		rec := httptest.NewRecorder()
+		spa.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := get("/settings", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "<html") {
+		t.Fatalf("index.html embutido: %d %s", rec.Code, rec.Body)
+	}
+	if rec := get("/assets/index-BXk3a9Qz.css", "gzip"); rec.Header().Get("Content-Encoding") != "gzip" || rec.Body.String() != "gzip" {
+		t.Fatalf("asset .gz embutido: %v %q", rec.Header(), rec.Body)
+	}
+	if rec := get("/assets/index-BXk3a9Qz.css", ""); rec.Body.String() != "body{}" {
+		t.Fatalf("asset embutido: %d %q", rec.Code, rec.Body)
+	}
+}
+
+func TestNewSPAWithoutIndex(t *testing.T) {
+	if _, err := web.NewSPAFS(fstest.MapFS{}); err == nil {
+		t.Fatal("NewSPAFS sem index.html deveria falhar")
+	}
+}
@@ -0,0 +1,120 @@
+package server_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"famli/internal/storage"
+	"famli/internal/testutil"
+)
+
+// statusResponse é a resposta de GET /api/status
+type statusResponse struct {
+	Status     string `json:"status"`
+	Components []struct {
+		Name          string  `json:"name"`
+		Status        string  `json:"status"`
+		UptimePercent float64 `json:"uptime_percent"`
+	} `json:"components"`
+	Incidents []struct {
+		ID       string `json:"id"`
+		Title    string `json:"title"`
+		Severity string `json:"severity"`
+		State    string `json:"state"`
+	} `json:"incidents"`
+	Uptime map[string][]struct {
+		Day           string  `json:"day"`
+		UptimePercent float64 `json:"uptime_percent"`
+	} `json:"uptime"`
+}
+
+// TestStatusPage cobre a página de status pública e os incidentes do painel
+func TestStatusPage(t *testing.T) {
+	h := testutil.New(t, map[string]string{"ADMIN_EMAILS": "admin@example.com"})
+	admin := h.Register("admin@example.com", "Admin")
+	maria := h.Register("maria@example.com", "Maria")
+	public := h.NewClient()
+
+	// Disponibilidade: ontem com 1 falha em 4 verificações, hoje sem falhas
+	yesterday := time.Now().UTC().AddDate(0, 0, -1)
+	for i, ok := range []bool{true, true, true, false} {
+		if err := h.Store.RecordStatusCheck("database", yesterday, ok); err != nil {
+			t.Fatalf("verificação %d: %v", i, err)
+		}
+	}
+	h.Store.RecordStatusCheck("database", time.Now().UTC(), true)
+	h.Store.RecordStatusCheck("database", time.Now().UTC().AddDate(0, 0, -120), false) // Fora da janela
+
+	var status statusResponse
+	resp := public.Get("/api/status").Expect(http.StatusOK)
+	resp.JSON(&status)
+	if resp.Header.Get("Cache-Control") != "public, max-age=60" {
+		t.Fatalf("Cache-Control = %q", resp.Header.Get("Cache-Control"))
+	}
+	// Sem credenciais, email e WhatsApp ficam de fora
+	if status.Status != "operational" || len(status.Components) != 1 || status.Components[0].Name != "database" ||
+		status.Components[0].Status != "operational" || status.Components[0].UptimePercent != 80 {
+		t.Fatalf("status inesperado: %s", resp.Body)
+	}
+	if days := status.Uptime["database"]; len(days) != 2 || days[0].UptimePercent != 75 || days[1].UptimePercent != 100 {
+		t.Fatalf("disponibilidade diária inesperada: %+v", days)
+	}
+
+	// Só admins publicam incidentes; validação dos campos
+	maria.Post("/api/admin/status/incidents", map[string]string{"title": "x", "severity": "minor"}).Expect(http.StatusForbidden)
+	admin.Post("/api/admin/status/incidents", map[string]string{"title": "Lentidão", "severity": "critical"}).
+		ExpectError(http.StatusBadRequest, "STATUS_INVALID_INCIDENT")
+	admin.Post("/api/admin/status/incidents", map[string]interface{}{"title": "Lentidão", "severity": "minor", "components": []string{"dns"}}).
+		ExpectError(http.StatusBadRequest, "STATUS_INVALID_INCIDENT")
+
+	created := admin.Post("/api/admin/status/incidents", map[string]interface{}{
+		"title":      "Banco fora do ar",
+		"message":    "Estamos investigando.",
+		"severity":   "major",
+		"components": []string{"database"},
+	}).Expect(http.StatusCreated)
+	incidentID := created.String("id")
+	if created.String("state") != "investigating" {
+		t.Fatalf("incidente criado: %s", created.Body)
+	}
+
+	// O incidente invalida o cache e derruba o componente afetado
+	public.Get("/api/status").Expect(http.StatusOK).JSON(&status)
+	if status.Status != "outage" || status.Components[0].Status != "outage" ||
+		len(status.Incidents) != 1 || status.Incidents[0].Title != "Banco fora do ar" {
+		t.Fatalf("status com incidente: %+v", status)
+	}
+
+	// Resolvido: continua na lista, mas o sistema volta ao normal
+	resolved := admin.Patch("/api/admin/status/incidents/"+incidentID, map[string]string{"state": "resolved"}).Expect(http.StatusOK)
+	if resolved.Map()["resolved_at"] == nil || resolved.String("title") != "Banco fora do ar" {
+		t.Fatalf("incidente resolvido: %s", resolved.Body)
+	}
+	public.Get("/api/status").Expect(http.StatusOK).JSON(&status)
+	if status.Status != "operational" || len(status.Incidents) != 1 || status.Incidents[0].State != "resolved" {
+		t.Fatalf("status após resolver: %+v", status)
+	}
+
+	// Resolvido há mais de 7 dias: some da página pública, fica no painel
+	old := &storage.StatusIncident{Title: "Manutenção antiga", Severity: storage.IncidentMaintenance, State: storage.IncidentResolved}
+	resolvedAt := time.Now().AddDate(0, 0, -10)
+	old.ResolvedAt = &resolvedAt
+	h.Store.CreateStatusIncident(old)
+	admin.Post("/api/admin/status/incidents", map[string]string{"title": "Manutenção programada", "severity": "maintenance"}).
+		Expect(http.StatusCreated)
+	public.Get("/api/status").Expect(http.StatusOK).JSON(&status)
+	if status.Status != "maintenance" || len(status.Incidents) != 2 {
+		t.Fatalf("status em manutenção: %+v", status)
+	}
+	var panel statusResponse
+	admin.Get("/api/admin/status").Expect(http.StatusOK).JSON(&panel)
+	if len(panel.Incidents) != 3 {
+		t.Fatalf("incidentes no painel: %+v", panel.Incidents)
+	}
+
+	admin.Delete("/api/admin/status/incidents/" + incidentID).Expect(http.StatusNoContent)
+	admin.Delete("/api/admin/status/incidents/"+incidentID).ExpectError(http.StatusNotFound, "STATUS_INCIDENT_NOT_FOUND")
+	admin.Patch("/api/admin/status/incidents/"+incidentID, map[string]string{"state": "resolved"}).
+		ExpectError(http.StatusNotFound, "STATUS_INCIDENT_NOT_FOUND")
+}
@@ -0,0 +1,229 @@
+package server_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"famli/internal/storage"
+	"famli/internal/testutil"
+)
+
+// TestWhatsAppOptOut cobre o STOP/START pelo WhatsApp e pelas configurações
+func TestWhatsAppOptOut(t *testing.T) {
+	h := testutil.New(t, map[string]string{"TWILIO_ACCOUNT_SID": "AC-teste", "TWILIO_AUTH_TOKEN": "token", "TWILIO_PHONE_NUMBER": "whatsapp:+14155238886"})
+	maria := h.Register("maria@example.com", "Maria")
+	const phone = "+5511988887777"
+
+	webhook := func(from, body string) string {
+		t.Helper()
+		return postWebhook(t, h, from, body)
+	}
+
+	// Número sem conta: nada a registrar
+	if reply := webhook("+5511900001111", "STOP"); !strings.Contains(reply, "não está vinculado") {
+		t.Fatalf("STOP sem conta: %s", reply)
+	}
+
+	// Desligar pelas configurações (antes de vincular: sem mensagem de confirmação)
+	settings := maria.Put("/api/settings", map[string]interface{}{"theme": "light", "messaging_opt_out": true}).
+		Expect(http.StatusOK).Map()
+	if settings["messaging_opt_out"] != true || settings["messaging_consent_at"] == nil {
+		t.Fatalf("opt-out não salvo: %v", settings)
+	}
+	linkPhone(t, h, maria, phone).Expect(http.StatusOK)
+
+	// Salvar as demais configurações mantém o consentimento
+	maria.Put("/api/settings", map[string]string{"theme": "dark"}).Expect(http.StatusOK)
+	if !h.Store.GetSettings(maria.User.ID).MessagingOptOut {
+		t.Fatal("opt-out perdido ao salvar as configurações")
+	}
+
+	if reply := webhook(phone, "start"); !strings.Contains(reply, "voltou a receber") {
+		t.Fatalf("START: %s", reply)
+	}
+	if h.Store.GetSettings(maria.User.ID).MessagingOptOut {
+		t.Fatal("START não registrado")
+	}
+
+	// STOP registra o pedido e tira o usuário dos resumos
+	maria.Put("/api/settings", map[string]string{"theme": "light", "digest_frequency": "daily"}).Expect(http.StatusOK)
+	if reply := webhook(phone, "STOP"); !strings.Contains(reply, "não vai mais receber") {
+		t.Fatalf("STOP: %s", reply)
+	}
+	if !h.Store.GetSettings(maria.User.ID).MessagingOptOut {
+		t.Fatal("STOP não registrado")
+	}
+	subs, err := h.Store.ListDigestSubscriptions()
+	if err != nil || len(subs) != 0 {
+		t.Fatalf("resumo continua inscrito após STOP: %v %v", subs, err)
+	}
+
+	// "cancelar" continua só cancelando a operação em andamento
+	webhook(phone, "cancelar")
+	if !h.Store.GetSettings(maria.User.ID).MessagingOptOut {
+		t.Fatal("cancelar mudou o consentimento")
+	}
+}
+
+// TestWhatsAppDrafts cobre o rascunho de uma conversa abandonada
+func TestWhatsAppDrafts(t *testing.T) {
+	h := testutil.New(t, map[string]string{"TWILIO_ACCOUNT_SID": "AC-teste", "TWILIO_AUTH_TOKEN": "token", "TWILIO_PHONE_NUMBER": "whatsapp:+14155238886"})
+	maria := h.Register("maria@example.com", "Maria")
+	const phone = "+5511988887777"
+
+	// Sem confirmação de vinculação (nenhuma mensagem sai no teste)
+	maria.Put("/api/settings", map[string]interface{}{"theme": "light", "messaging_opt_out": true}).Expect(http.StatusOK)
+	linkPhone(t, h, maria, phone).Expect(http.StatusOK)
+
+	if reply := postWebhook(t, h, phone, "continuar"); !strings.Contains(reply, "nenhum rascunho") {
+		t.Fatalf("continuar sem rascunho: %s", reply)
+	}
+
+	// A sessão expirou com o item pendente: "cancelar" descarta o rascunho
+	draft := &storage.ConversationDraft{UserID: maria.User.ID, State: "awaiting_category", Type: "note",
+		Title: "Senha do wifi", Content: "Senha do wifi: famli123", CreatedAt: time.Now()}
+	if err := h.Store.SaveConversationDraft(draft); err != nil {
+		t.Fatal(err)
+	}
+	if saved, err := h.Store.GetConversationDraft(maria.User.ID); err != nil || saved.Content != draft.Content {
+		t.Fatalf("rascunho não salvo: %+v %v", saved, err)
+	}
+	postWebhook(t, h, phone, "cancelar")
+	if _, err := h.Store.GetConversationDraft(maria.User.ID); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("rascunho não descartado: %v", err)
+	}
+	if links, err := h.Store.ListPhoneLinks(maria.User.ID); err != nil || len(links) != 1 {
+		t.Fatalf("descartar o rascunho mudou os números vinculados: %v %v", links, err)
+	}
+	if reply := postWebhook(t, h, phone, "continue"); !strings.Contains(reply, "nenhum rascunho") {
+		t.Fatalf("continuar após cancelar: %s", reply)
+	}
+}
+
+// TestWhatsAppPhoneLinks cobre vários números vinculados à mesma conta
+func TestWhatsAppPhoneLinks(t *testing.T) {
+	h := testutil.New(t, map[string]string{"TWILIO_ACCOUNT_SID": "AC-teste", "TWILIO_AUTH_TOKEN": "token", "TWILIO_PHONE_NUMBER": "whatsapp:+14155238886"})
+	maria := h.Register("maria@example.com", "Maria")
+	joao := h.Register("joao@example.com", "João")
+	const mariaPhone, tabletPhone = "+5511988887777", "+5511977776666"
+
+	// Sem confirmação de vinculação (nenhuma mensagem sai no teste)
+	for _, c := range []*testutil.Client{maria, joao} {
+		c.Put("/api/settings", map[string]interface{}{"theme": "light", "messaging_opt_out": true}).Expect(http.StatusOK)
+	}
+
+	// Código inventado não vincula
+	maria.Post("/api/whatsapp/links", map[string]string{"code": "000000"}).
+		ExpectError(http.StatusBadRequest, "WHATSAPP_INVALID_CODE")
+	maria.Post("/api/whatsapp/links", map[string]string{}).
+		ExpectError(http.StatusBadRequest, "WHATSAPP_CODE_REQUIRED")
+
+	// Dois números na mesma conta: o primeiro é o principal
+	linkPhone(t, h, maria, mariaPhone).Expect(http.StatusOK)
+	code := requestLinkCode(t, h, tabletPhone)
+	tablet := maria.Post("/api/whatsapp/links", map[string]string{"code": code, "label": "Tablet da sala"}).
+		Expect(http.StatusCreated).Map()
+	if tablet["phone"] != tabletPhone || tablet["label"] != "Tablet da sala" || tablet["primary"] != false {
+		t.Fatalf("segundo número: %v", tablet)
+	}
+
+	// O código só vale uma vez
+	maria.Post("/api/whatsapp/links", map[string]string{"code": code}).
+		ExpectError(http.StatusBadRequest, "WHATSAPP_INVALID_CODE")
+
+	var list struct {
+		Links []struct {
+			ID      string `json:"id"`
+			Phone   string `json:"phone"`
+			Primary bool   `json:"primary"`
+		} `json:"links"`
+		MaxLinks int `json:"max_links"`
+	}
+	maria.Get("/api/whatsapp/links").Expect(http.StatusOK).JSON(&list)
+	if len(list.Links) != 2 || list.Links[0].Phone != mariaPhone || !list.Links[0].Primary || list.MaxLinks == 0 {
+		t.Fatalf("lista de números: %+v", list)
+	}
+
+	// Os dois números conversam com a mesma conta
+	for _, phone := range []string{mariaPhone, tabletPhone} {
+		if reply := postWebhook(t, h, phone, "vincular"); !strings.Contains(reply, "já está conectado") {
+			t.Fatalf("%s não está vinculado: %s", phone, reply)
+		}
+	}
+
+	// Um número pertence a uma única conta: o código foi pedido antes de o
+	// número ser vinculado (por outra aba ou instância)
+	const sharedPhone = "+5511966665555"
+	redeemAfterLinked := func(c *testutil.Client) *testutil.Response {
+		t.Helper()
+		code := requestLinkCode(t, h, sharedPhone)
+		link := &storage.PhoneLink{ID: "phl_teste", UserID: maria.User.ID, Phone: sharedPhone, VerifiedAt: time.Now(), CreatedAt: time.Now()}
+		if err := h.Store.CreatePhoneLink(link); err != nil {
+			t.Fatal(err)
+		}
+		defer h.Store.DeletePhoneLink(maria.User.ID, link.ID)
+		return c.Post("/api/whatsapp/links", map[string]string{"code": code})
+	}
+	redeemAfterLinked(maria).ExpectError(http.StatusConflict, "WHATSAPP_PHONE_ALREADY_LINKED")
+	redeemAfterLinked(joao).ExpectError(http.StatusConflict, "WHATSAPP_PHONE_LINKED_ELSEWHERE")
+
+	// Trocar o principal e desvincular o principal promove o outro
+	maria.Post("/api/whatsapp/links/"+list.Links[1].ID+"/primary", nil).Expect(http.StatusOK).JSON(&list)
+	if list.Links[0].Phone != tabletPhone || !list.Links[0].Primary || list.Links[1].Primary {
+		t.Fatalf("principal não trocado: %+v", list)
+	}
+	maria.Delete("/api/whatsapp/links/" + list.Links[0].ID).Expect(http.StatusOK).JSON(&list)
+	if len(list.Links) != 1 || list.Links[0].Phone != mariaPhone || !list.Links[0].Primary {
+		t.Fatalf("principal não promovido: %+v", list)
+	}
+
+	// Links de outra conta não existem para quem pede
+	joao.Delete("/api/whatsapp/links/"+list.Links[0].ID).ExpectError(http.StatusNotFound, "WHATSAPP_LINK_NOT_FOUND")
+	joao.Post("/api/whatsapp/links/"+list.Links[0].ID+"/primary", nil).ExpectError(http.StatusNotFound, "WHATSAPP_LINK_NOT_FOUND")
+
+	// O número desvinculado fica livre para outra conta
+	linkPhone(t, h, joao, tabletPhone).Expect(http.StatusOK)
+
+	// DELETE /api/whatsapp/link desvincula todos os números
+	maria.Delete("/api/whatsapp/link").Expect(http.StatusOK)
+	maria.Get("/api/whatsapp/links").Expect(http.StatusOK).JSON(&list)
+	if len(list.Links) != 0 {
+		t.Fatalf("números continuam vinculados: %+v", list)
+	}
+}
+
+// requestLinkCode pede um código de vinculação pelo WhatsApp
+func requestLinkCode(t *testing.T, h *testutil.Harness, phone string) string {
+	t.Helper()
+	reply := postWebhook(t, h, phone, "vincular")
+	code := regexp.MustCompile(`\d{6}`).FindString(reply)
+	if code == "" {
+		t.Fatalf("código de vinculação não recebido: %s", reply)
+	}
+	return code
+}
+
+// linkPhone vincula o número à conta do cliente com um código pedido pelo WhatsApp
+func linkPhone(t *testing.T, h *testutil.Harness, c *testutil.Client, phone string) *testutil.Response {
+	t.Helper()
+	code := requestLinkCode(t, h, phone)
+	return c.Post("/api/whatsapp/link", map[string]string{"code": code, "phone_number": phone})
+}
+
+// postWebhook simula uma mensagem do Twilio e retorna o TwiML da resposta
+func postWebhook(t *testing.T, h *testutil.Harness, from, body string) string {
+	t.Helper()
+	resp, err := http.PostForm(h.URL("/api/whatsapp/webhook"), url.Values{"From": {"whatsapp:" + from}, "Body": {body}})
+	if err != nil {
+		t.Fatalf("webhook: %v", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return string(data)
+}
@@ -3,7 +3,9 @@ package settings
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
+	"famli/internal/apierror"
 	"famli/internal/auth"
 	"famli/internal/i18n"
 	"famli/internal/storage"
@@ -21,12 +23,30 @@ type settingsPayload struct {
 	EmergencyProtocolEnabled bool   `json:"emergency_protocol_enabled"`
 	NotificationsEnabled     bool   `json:"notifications_enabled"`
 	Theme                    string `json:"theme"`
+
+	// Language muda o idioma das mensagens e emails (pt-BR, en, es).
+	// Vazio mantém o idioma atual.
+	Language string `json:"language"`
+
+	// NotificationOptOuts são as categorias de notificação desligadas
+	NotificationOptOuts []storage.NotificationCategory `json:"notification_opt_outs"`
+
+	// DigestFrequency liga o resumo da Caixa pelo WhatsApp (off, daily, weekly).
+	// Vazio mantém a frequência atual.
+	DigestFrequency storage.DigestFrequency `json:"digest_frequency"`
+
+	// MessagingOptOut liga ou desliga as mensagens pelo WhatsApp (o mesmo
+	// que responder "STOP"/"START"). Ausente mantém o consentimento atual.
+	MessagingOptOut *bool `json:"messaging_opt_out"`
 }
 
 // Get retorna as configurações do usuário
 func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
 	userID := auth.GetUserID(r)
 	settings := h.store.GetSettings(userID)
+	if user, ok := h.store.GetUserByID(userID); ok {
+		settings.Language = user.Locale
+	}
 
 	writeJSON(w, http.StatusOK, settings)
 }
@@ -37,21 +57,69 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 
 	var payload settingsPayload
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "settings.invalid_data"))
+		apierror.Write(w, r, http.StatusBadRequest, "settings.invalid_data")
+		return
+	}
+
+	optOuts := make([]storage.NotificationCategory, 0, len(payload.NotificationOptOuts))
+	for _, category := range payload.NotificationOptOuts {
+		if !storage.IsValidNotificationCategory(category) {
+			apierror.Write(w, r, http.StatusBadRequest, "notifications.invalid_category")
+			return
+		}
+		optOuts = append(optOuts, category)
+	}
+
+	if payload.DigestFrequency != "" && !storage.IsValidDigestFrequency(payload.DigestFrequency) {
+		apierror.Write(w, r, http.StatusBadRequest, "settings.invalid_digest")
 		return
 	}
 
+	language := ""
+	if payload.Language != "" {
+		language = i18n.Normalize(payload.Language)
+		if language == "" {
+			apierror.Write(w, r, http.StatusBadRequest, "settings.invalid_language")
+			return
+		}
+		if err := h.store.UpdateUserLocale(userID, language); err != nil {
+			apierror.Write(w, r, http.StatusInternalServerError, "settings.save_error")
+			return
+		}
+	}
+
 	updates := &storage.Settings{
 		EmergencyProtocolEnabled: payload.EmergencyProtocolEnabled,
 		NotificationsEnabled:     payload.NotificationsEnabled,
 		Theme:                    payload.Theme,
+		NotificationOptOuts:      optOuts,
 	}
 
 	if updates.Theme == "" {
 		updates.Theme = "light"
 	}
 
+	// O primeiro resumo sai um período depois de ligar (ou mudar) a frequência
+	if payload.DigestFrequency != "" && payload.DigestFrequency != h.store.GetSettings(userID).DigestFrequency {
+		if err := h.store.UpdateDigestFrequency(userID, payload.DigestFrequency, time.Now()); err != nil {
+			apierror.Write(w, r, http.StatusInternalServerError, "settings.save_error")
+			return
+		}
+	}
+
+	if payload.MessagingOptOut != nil && *payload.MessagingOptOut != h.store.GetSettings(userID).MessagingOptOut {
+		if err := h.store.UpdateMessagingConsent(userID, *payload.MessagingOptOut, time.Now().UTC()); err != nil {
+			apierror.Write(w, r, http.StatusInternalServerError, "settings.save_error")
+			return
+		}
+	}
+
 	updated := h.store.UpdateSettings(userID, updates)
+	if language != "" {
+		updated.Language = language
+	} else if user, ok := h.store.GetUserByID(userID); ok {
+		updated.Language = user.Locale
+	}
 	writeJSON(w, http.StatusOK, updated)
 }
 
@@ -62,7 +130,3 @@ func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
 		json.NewEncoder(w).Encode(payload)
 	}
 }
-
-func writeError(w http.ResponseWriter, status int, message string) {
-	writeJSON(w, status, map[string]string{"error": message})
-}
@@ -0,0 +1,84 @@
+package settings
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+// supportAccessDuration é a validade da autorização dada ao suporte
+const supportAccessDuration = 24 * time.Hour
+
+// supportAccessResponse descreve a autorização atual do suporte
+type supportAccessResponse struct {
+	Active    bool       `json:"active"`
+	GrantedAt *time.Time `json:"granted_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// GetSupportAccess informa se o suporte pode ver a conta
+//
+// Endpoint: GET /api/settings/support-access
+func (h *Handler) GetSupportAccess(w http.ResponseWriter, r *http.Request) {
+	access, err := h.store.GetSupportAccess(auth.GetUserID(r))
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		apierror.Write(w, r, http.StatusInternalServerError, "settings.support_access_error")
+		return
+	}
+	writeJSON(w, http.StatusOK, newSupportAccessResponse(access))
+}
+
+// GrantSupportAccess autoriza o suporte a ver a conta (somente leitura) por 24h
+// Autorizar de novo renova o prazo.
+//
+// Endpoint: POST /api/settings/support-access
+func (h *Handler) GrantSupportAccess(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+	now := time.Now()
+	access := &storage.SupportAccess{
+		UserID:    userID,
+		GrantedAt: now,
+		ExpiresAt: now.Add(supportAccessDuration),
+	}
+	if err := h.store.SaveSupportAccess(access); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "settings.support_access_error")
+		return
+	}
+
+	security.GetAuditLogger().LogAuth(security.EventSupportAccessGranted, userID, security.GetClientIP(r), r.UserAgent(), "success", map[string]interface{}{
+		"expires_at": access.ExpiresAt.UTC(),
+	})
+	writeJSON(w, http.StatusOK, newSupportAccessResponse(access))
+}
+
+// RevokeSupportAccess revoga a autorização do suporte
+// Uma sessão do suporte aberta é encerrada na requisição seguinte.
+//
+// Endpoint: DELETE /api/settings/support-access
+func (h *Handler) RevokeSupportAccess(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+	if err := h.store.DeleteSupportAccess(userID); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "settings.support_access_error")
+		return
+	}
+
+	security.GetAuditLogger().LogAuth(security.EventSupportAccessRevoked, userID, security.GetClientIP(r), r.UserAgent(), "success", nil)
+	writeJSON(w, http.StatusOK, supportAccessResponse{})
+}
+
+// newSupportAccessResponse monta a resposta (autorização vencida = inativa)
+func newSupportAccessResponse(access *storage.SupportAccess) supportAccessResponse {
+	if !access.ActiveAt(time.Now()) {
+		return supportAccessResponse{}
+	}
+	return supportAccessResponse{
+		Active:    true,
+		GrantedAt: &access.GrantedAt,
+		ExpiresAt: &access.ExpiresAt,
+	}
+}
@@ -0,0 +1,105 @@
+// =============================================================================
+// FAMLI - Regras de Acesso dos Guardiões
+// =============================================================================
+// O dono pode limitar o acesso de cada guardião (PUT
+// /api/guardians/{guardianID}/access-policy):
+//
+// - expires_at: depois do prazo o acesso não abre mais
+// - windows: dias da semana e horários permitidos (ex: cuidadora em horário
+//   comercial), no fuso da regra
+// - max_accesses: total de vezes que o conteúdo pode ser aberto
+//
+// As regras valem no link (GET /api/guardian-access/{token}, verify e
+// comentários) e no portal (GET /api/guardian/boxes/{guardianID}); a cópia
+// dos dados e o pedido de remoção (LGPD) continuam disponíveis. Cada
+// abertura do conteúdo conta um acesso, reservado antes de mostrar o
+// conteúdo num UPDATE condicional (aberturas simultâneas não passam do
+// limite). Um acesso barrado responde 403 com o motivo e avisa o dono (no
+// máximo um aviso por dia, por guardião).
+// =============================================================================
+
+package share
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"famli/internal/apierror"
+	"famli/internal/notifications"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+// accessBlockedNotifyInterval é o intervalo mínimo entre avisos ao dono
+const accessBlockedNotifyInterval = 24 * time.Hour
+
+// accessPolicyErrors são as mensagens de cada motivo de bloqueio
+var accessPolicyErrors = map[storage.AccessPolicyBlock]string{
+	storage.AccessPolicyExpired:       "share.access_expired",
+	storage.AccessPolicyOutsideWindow: "share.access_outside_window",
+	storage.AccessPolicyLimitReached:  "share.access_limit_reached",
+}
+
+// enforceAccessPolicy confere as regras de acesso do guardião; se barrado,
+// responde 403, avisa o dono e retorna false
+func (h *Handler) enforceAccessPolicy(w http.ResponseWriter, r *http.Request, guardian *storage.Guardian) bool {
+	policy, err := h.store.GetGuardianAccessPolicy(guardian.ID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return true
+	}
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "share.access_error")
+		return false
+	}
+
+	block := policy.Check(time.Now())
+	if block == storage.AccessPolicyAllowed {
+		return true
+	}
+	h.writeAccessBlocked(w, r, guardian, block)
+	return false
+}
+
+// claimPolicyAccess conta a abertura do conteúdo nas regras do guardião
+// Se outra abertura levou o último acesso, responde 403 e retorna false.
+func (h *Handler) claimPolicyAccess(w http.ResponseWriter, r *http.Request, guardian *storage.Guardian) bool {
+	claimed, err := h.store.ClaimGuardianPolicyAccess(guardian.ID)
+	if err != nil {
+		log.Printf("[Share] Erro ao contar acesso do guardião %s: %v", guardian.ID, err)
+		apierror.Write(w, r, http.StatusInternalServerError, "share.access_error")
+		return false
+	}
+	if !claimed {
+		h.writeAccessBlocked(w, r, guardian, storage.AccessPolicyLimitReached)
+		return false
+	}
+	return true
+}
+
+// writeAccessBlocked registra o acesso barrado, avisa o dono e responde 403
+func (h *Handler) writeAccessBlocked(w http.ResponseWriter, r *http.Request, guardian *storage.Guardian, block storage.AccessPolicyBlock) {
+	if h.auditLogger != nil {
+		h.auditLogger.Log(security.AuditEvent{
+			Type:     security.EventGuardianAccessBlocked,
+			Severity: security.SeverityInfo,
+			UserID:   guardian.UserID,
+			ClientIP: security.GetClientIP(r),
+			Resource: "guardian:" + guardian.ID,
+			Action:   "access_policy",
+			Result:   "blocked",
+			Details:  map[string]interface{}{"reason": string(block)},
+		})
+	}
+
+	notify, err := h.store.MarkGuardianPolicyBlocked(guardian.ID, time.Now().Add(-accessBlockedNotifyInterval))
+	if err != nil {
+		log.Printf("[Share] Erro ao registrar acesso barrado do guardião %s: %v", guardian.ID, err)
+	}
+	if notify {
+		notifications.Notify(guardian.UserID, storage.NotificationGuardianAccess, "notify.guardian_access_blocked")
+	}
+
+	apierror.Write(w, r, http.StatusForbidden, accessPolicyErrors[block])
+}
@@ -0,0 +1,194 @@
+// =============================================================================
+// FAMLI - Proteção contra Robôs nas Rotas Públicas dos Links
+// =============================================================================
+// /api/shared/{token} e /api/guardian-access/{token} são varridas por robôs
+// quando os links acabam indexados. Além do rate limit por IP
+// (security.PublicShareRateLimit):
+//
+// - Tokens inexistentes, expirados ou desativados respondem sempre o mesmo
+//   404 (writeLinkUnavailable), para não revelar quais tokens já existiram
+// - Cada 404 conta para o IP; passando de SHARE_ABUSE_THRESHOLD em 10
+//   minutos, o IP precisa resolver um CAPTCHA (403 CAPTCHA_REQUIRED) ou, sem
+//   CAPTCHA configurado, recebe 429 até o fim do bloqueio
+//
+// O desafio resolvido vale por captchaPassDuration naquele IP (cookie
+// assinado), para o navegador não pedir um novo CAPTCHA a cada requisição.
+// =============================================================================
+
+package share
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"famli/internal/apierror"
+	"famli/internal/captcha"
+	"famli/internal/i18n"
+	"famli/internal/security"
+)
+
+const (
+	// CaptchaHeader traz o token do desafio resolvido no navegador
+	CaptchaHeader = "X-Captcha-Token"
+
+	// captchaPassCookie guarda a liberação depois do desafio resolvido
+	captchaPassCookie = "famli_captcha"
+
+	// captchaPassDuration é a validade da liberação
+	captchaPassDuration = 15 * time.Minute
+)
+
+// BotGuard pede CAPTCHA (ou bloqueia) os IPs que acumulam tokens inválidos
+type BotGuard struct {
+	misses      *security.RateLimiter
+	verifier    captcha.Verifier
+	secret      []byte // Assina o cookie de liberação
+	auditLogger *security.AuditLogger
+}
+
+// NewBotGuard cria a proteção
+//
+// Parâmetros:
+//   - verifier: provedor de CAPTCHA (desligado = 429 em vez do desafio)
+//   - threshold: tokens inválidos por IP em 10 minutos (0 = padrão)
+//   - secret: chave que assina o cookie de liberação (JWT_SECRET)
+func NewBotGuard(verifier captcha.Verifier, threshold int, secret string) *BotGuard {
+	config := security.ShareMissRateLimit
+	if threshold > 0 {
+		config.Requests = threshold
+	}
+	if verifier == nil {
+		verifier = captcha.Disabled{}
+	}
+	return &BotGuard{
+		misses:      security.NewRateLimiter(config),
+		verifier:    verifier,
+		secret:      []byte(secret),
+		auditLogger: security.GetAuditLogger(),
+	}
+}
+
+// Middleware aplica a proteção às rotas públicas dos links
+func (g *BotGuard) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientIP := security.GetClientIP(r)
+
+		if flagged, resetIn := g.flagged(clientIP); flagged && !g.hasPass(r, clientIP) {
+			if !g.verifier.Enabled() {
+				w.Header().Set("Retry-After", strconv.Itoa(int(resetIn.Seconds())))
+				apierror.Write(w, r, http.StatusTooManyRequests, "security.rate_limited")
+				return
+			}
+			err := g.verifier.Verify(r.Context(), r.Header.Get(CaptchaHeader), clientIP)
+			if err != nil {
+				if !errors.Is(err, captcha.ErrMissingToken) && !errors.Is(err, captcha.ErrInvalidToken) {
+					log.Printf("[SHARE] Erro ao verificar CAPTCHA: %v", err)
+				}
+				g.writeChallenge(w, r)
+				return
+			}
+			g.setPass(w, r, clientIP)
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		if recorder.status == http.StatusNotFound {
+			g.recordMiss(r, clientIP)
+		}
+	})
+}
+
+// flagged indica se o IP passou do limite de tokens inválidos
+func (g *BotGuard) flagged(clientIP string) (bool, time.Duration) {
+	remaining, resetIn, blocked := g.misses.GetStatus(clientIP)
+	return blocked || remaining == 0, resetIn
+}
+
+// recordMiss conta um token inválido; o IP que acaba de passar do limite
+// fica no log de auditoria
+func (g *BotGuard) recordMiss(r *http.Request, clientIP string) {
+	wasFlagged, _ := g.flagged(clientIP)
+	g.misses.Allow(clientIP)
+	if flagged, _ := g.flagged(clientIP); flagged && !wasFlagged {
+		g.auditLogger.Log(security.AuditEvent{
+			Type:     security.EventSuspiciousActivity,
+			Severity: security.SeverityWarning,
+			ClientIP: clientIP,
+			Resource: "share:" + r.URL.Path,
+			Action:   "token_scan",
+			Result:   "challenged",
+			Details:  map[string]interface{}{"captcha": g.verifier.Name()},
+		})
+	}
+}
+
+// writeChallenge responde 403 CAPTCHA_REQUIRED com os dados do widget
+func (g *BotGuard) writeChallenge(w http.ResponseWriter, r *http.Request) {
+	apierror.WriteMessage(w, r, http.StatusForbidden, "CAPTCHA_REQUIRED", i18n.Tr(r, "share.captcha_required"), map[string]interface{}{
+		"provider": g.verifier.Name(),
+		"site_key": g.verifier.SiteKey(),
+	})
+}
+
+// =============================================================================
+// COOKIE DE LIBERAÇÃO
+// =============================================================================
+// Valor: <expiração unix>.<HMAC-SHA256(ip|expiração)>, preso ao IP que
+// resolveu o desafio.
+
+// passSignature assina a liberação do IP até expires
+func (g *BotGuard) passSignature(clientIP string, expires int64) string {
+	mac := hmac.New(sha256.New, g.secret)
+	mac.Write([]byte(clientIP + "|" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// hasPass indica se a requisição traz uma liberação válida para o IP
+func (g *BotGuard) hasPass(r *http.Request, clientIP string) bool {
+	cookie, err := r.Cookie(captchaPassCookie)
+	if err != nil {
+		return false
+	}
+	expiresText, signature, found := strings.Cut(cookie.Value, ".")
+	if !found {
+		return false
+	}
+	expires, err := strconv.ParseInt(expiresText, 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(g.passSignature(clientIP, expires)))
+}
+
+// setPass grava o cookie de liberação depois do desafio resolvido
+func (g *BotGuard) setPass(w http.ResponseWriter, r *http.Request, clientIP string) {
+	expires := time.Now().Add(captchaPassDuration).Unix()
+	http.SetCookie(w, &http.Cookie{
+		Name:     captchaPassCookie,
+		Value:    strconv.FormatInt(expires, 10) + "." + g.passSignature(clientIP, expires),
+		Path:     "/api",
+		MaxAge:   int(captchaPassDuration.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// statusRecorder guarda o status da resposta do handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader implementa http.ResponseWriter
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,112 @@
+// =============================================================================
+// FAMLI - Comentários dos Guardiões nos Itens
+// =============================================================================
+// Quem recebe o acesso às vezes precisa perguntar algo ao dono sobre um item
+// ("qual gaveta?"). Pelo próprio link de acesso, o guardião pode deixar um
+// comentário curto em um item compartilhado com ele:
+//
+// - POST /api/guardian-access/{token}/items/{itemID}/comments
+//
+// Exige o PIN do link, como o acesso normal. O texto é sanitizado, o dono é
+// avisado pela central de notificações e lê (ou apaga) o comentário na
+// visualização do item (GET /api/box/items/{itemID}/comments).
+// =============================================================================
+
+package share
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+
+	"famli/internal/apierror"
+	"famli/internal/ids"
+	"famli/internal/notifications"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+// ItemCommentRequest é o corpo do comentário do guardião
+type ItemCommentRequest struct {
+	PIN  string `json:"pin"`
+	Body string `json:"body"`
+}
+
+// CreateGuardianComment salva o comentário do guardião em um item compartilhado
+//
+// Endpoint: POST /api/guardian-access/{token}/items/{itemID}/comments
+//
+// Body: {"pin": "1234", "body": "Em qual gaveta está?"}
+//
+// Só vale para itens que o guardião vê; os demais respondem 404, sem revelar
+// se o item existe. Limitado por IP (security.GuardianCommentRateLimit).
+func (h *Handler) CreateGuardianComment(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+	if token == "" {
+		apierror.Write(w, r, http.StatusBadRequest, "share.invalid_token")
+		return
+	}
+
+	var req ItemCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "share.invalid_data")
+		return
+	}
+
+	guardian, ok := h.authenticateGuardian(w, r, token, req.PIN, true)
+	if !ok {
+		return
+	}
+
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		apierror.Write(w, r, http.StatusBadRequest, "share.comment_required")
+		return
+	}
+	if utf8.RuneCountInString(body) > security.MaxCommentLength {
+		apierror.Write(w, r, http.StatusBadRequest, "share.comment_too_long")
+		return
+	}
+
+	item := h.guardianVisibleItem(guardian, chi.URLParam(r, "itemID"))
+	if item == nil {
+		apierror.Write(w, r, http.StatusNotFound, "share.item_not_found")
+		return
+	}
+
+	comment := &storage.ItemComment{
+		ID:         ids.New(ids.ItemComment),
+		ItemID:     item.ID,
+		UserID:     guardian.UserID,
+		GuardianID: guardian.ID,
+		Body:       security.SanitizeText(body, 0),
+		CreatedAt:  time.Now().UTC(),
+	}
+	if err := h.store.CreateItemComment(comment); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "share.comment_error")
+		return
+	}
+
+	notifications.Notify(guardian.UserID, storage.NotificationGuardianAccess, "notify.item_comment")
+	log.Printf("[SHARE] Guardião %s comentou o item %s", guardian.ID, item.ID)
+
+	comment.GuardianName = guardian.Name
+	writeJSON(w, http.StatusCreated, comment)
+}
+
+// guardianVisibleItem busca um item entre os que o guardião pode ver
+// (compartilhados e liberados para ele); nil se não for um deles
+func (h *Handler) guardianVisibleItem(guardian *storage.Guardian, itemID string) *storage.BoxItem {
+	items := filterItemsByGuardians(h.store.ListSharedItems(guardian.UserID), []string{guardian.ID})
+	for _, item := range items {
+		if item.ID == itemID {
+			return item
+		}
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+// =============================================================================
+// FAMLI - Dados Pessoais do Guardião (LGPD)
+// =============================================================================
+// Guardiões normalmente não têm conta Famli, mas o dono da caixa guarda
+// dados pessoais deles (nome, email, telefone, observações) e o Famli
+// registra quando abriram cada item e o que comentaram. Pelo próprio link
+// de acesso, o guardião pode:
+//
+// - POST /api/guardian-access/{token}/my-data          - cópia dos seus dados
+// - POST /api/guardian-access/{token}/deletion-request - pedir a remoção
+//
+// Ambos exigem o PIN do link ({"pin": "..."}), com o mesmo bloqueio
+// progressivo do acesso normal. O pedido de remoção avisa o dono e marca o
+// cadastro; depois do prazo de carência (GUARDIAN_DELETION_GRACE_DAYS) o
+// guardião, seus recibos de leitura e comentários são apagados pela limpeza
+// periódica.
+// =============================================================================
+
+package share
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"golang.org/x/crypto/bcrypt"
+
+	"famli/internal/apierror"
+	"famli/internal/i18n"
+	"famli/internal/notifications"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+// defaultGuardianDeletionGraceDays vale quando nenhum prazo é configurado
+const defaultGuardianDeletionGraceDays = 30
+
+// GuardianDataExport é a cópia dos dados pessoais do guardião
+type GuardianDataExport struct {
+	ExportedAt time.Time              `json:"exported_at"`
+	Guardian   *GuardianPersonalData  `json:"guardian"`
+	Owner      *OwnerInfo             `json:"owner"`      // Quem cadastrou (mascarado)
+	ItemViews  []*storage.ItemView    `json:"item_views"` // Quando abriu cada item
+	Comments   []*storage.ItemComment `json:"comments"`   // O que comentou nos itens
+}
+
+// GuardianPersonalData são os dados que o dono cadastrou sobre o guardião
+type GuardianPersonalData struct {
+	Name                string     `json:"name"`
+	Email               string     `json:"email,omitempty"`
+	Phone               string     `json:"phone,omitempty"`
+	Relationship        string     `json:"relationship,omitempty"`
+	Notes               string     `json:"notes,omitempty"`
+	AccessType          string     `json:"access_type"`
+	NotifyChannel       string     `json:"notify_channel,omitempty"`
+	HasAccount          bool       `json:"has_account"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+	DeletionRequestedAt *time.Time `json:"deletion_requested_at,omitempty"`
+}
+
+// GuardianDeletionResponse é a resposta do pedido de remoção
+type GuardianDeletionResponse struct {
+	Message     string    `json:"message"`
+	RequestedAt time.Time `json:"requested_at"`
+	DeletesAt   time.Time `json:"deletes_at"` // Data prevista para apagar o cadastro
+}
+
+// GuardianMyData retorna os dados pessoais guardados sobre o guardião
+//
+// Endpoint: POST /api/guardian-access/{token}/my-data
+func (h *Handler) GuardianMyData(w http.ResponseWriter, r *http.Request) {
+	guardian, ok := h.guardianFromPINRequest(w, r)
+	if !ok {
+		return
+	}
+
+	views, err := h.store.ListItemViewsBySource(storage.ItemViewGuardian, guardian.ID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "share.my_data_error")
+		return
+	}
+	comments, err := h.store.ListItemCommentsByGuardian(guardian.ID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "share.my_data_error")
+		return
+	}
+	owner := &OwnerInfo{}
+	if user, found := h.store.GetUserByID(guardian.UserID); found {
+		owner = &OwnerInfo{Name: maskName(user.Name), Email: maskEmail(user.Email)}
+	}
+
+	h.auditLogger.Log(security.AuditEvent{
+		Type:     security.EventDataExport,
+		Severity: security.SeverityInfo,
+		UserID:   guardian.UserID,
+		ClientIP: security.GetClientIP(r),
+		Resource: "guardian:" + guardian.ID,
+		Action:   "guardian_export",
+		Result:   "success",
+	})
+
+	w.Header().Set("Content-Disposition", "attachment; filename=famli-meus-dados.json")
+	writeJSON(w, http.StatusOK, GuardianDataExport{
+		ExportedAt: time.Now().UTC(),
+		Guardian: &GuardianPersonalData{
+			Name:                guardian.Name,
+			Email:               guardian.Email,
+			Phone:               guardian.Phone,
+			Relationship:        guardian.Relationship,
+			Notes:               guardian.Notes,
+			AccessType:          string(guardian.AccessType),
+			NotifyChannel:       string(guardian.NotifyChannel),
+			HasAccount:          guardian.HasAccount,
+			CreatedAt:           guardian.CreatedAt,
+			UpdatedAt:           guardian.UpdatedAt,
+			DeletionRequestedAt: guardian.DeletionRequestedAt,
+		},
+		Owner:     owner,
+		ItemViews: views,
+		Comments:  comments,
+	})
+}
+
+// RequestGuardianDeletion marca os dados do guardião para remoção
+//
+// Endpoint: POST /api/guardian-access/{token}/deletion-request
+//
+// Responde 202: o cadastro só é apagado depois do prazo de carência, para o
+// dono poder se organizar (ex: escolher outra pessoa de confiança). Pedidos
+// repetidos mantêm a data do primeiro e não avisam o dono de novo.
+func (h *Handler) RequestGuardianDeletion(w http.ResponseWriter, r *http.Request) {
+	guardian, ok := h.guardianFromPINRequest(w, r)
+	if !ok {
+		return
+	}
+
+	alreadyRequested := guardian.DeletionRequestedAt != nil
+	marked, err := h.store.RequestGuardianDeletion(guardian.ID, time.Now().UTC())
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "share.deletion_request_error")
+		return
+	}
+
+	if !alreadyRequested {
+		h.auditLogger.Log(security.AuditEvent{
+			Type:     security.EventGuardianDataDeletion,
+			Severity: security.SeverityInfo,
+			UserID:   guardian.UserID,
+			ClientIP: security.GetClientIP(r),
+			Resource: "guardian:" + guardian.ID,
+			Action:   "request_deletion",
+			Result:   "success",
+			Details:  map[string]interface{}{"grace_days": h.guardianDeletionGraceDays()},
+		})
+		notifications.Notify(guardian.UserID, storage.NotificationGuardianAccess, "notify.guardian_deletion_requested")
+		log.Printf("[SHARE] Guardião %s pediu a remoção dos seus dados", guardian.ID)
+	}
+
+	requestedAt := *marked.DeletionRequestedAt
+	writeJSON(w, http.StatusAccepted, GuardianDeletionResponse{
+		Message:     i18n.Tr(r, "share.deletion_requested"),
+		RequestedAt: requestedAt,
+		DeletesAt:   requestedAt.AddDate(0, 0, h.guardianDeletionGraceDays()),
+	})
+}
+
+// guardianFromPINRequest lê o PIN do corpo e autentica o guardião do token
+func (h *Handler) guardianFromPINRequest(w http.ResponseWriter, r *http.Request) (*storage.Guardian, bool) {
+	token := chi.URLParam(r, "token")
+	if token == "" {
+		apierror.Write(w, r, http.StatusBadRequest, "share.invalid_token")
+		return nil, false
+	}
+
+	var req VerifyPINRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "share.invalid_data")
+		return nil, false
+	}
+	return h.authenticateGuardian(w, r, token, req.PIN, false)
+}
+
+// authenticateGuardian busca o guardião pelo token e confere o PIN
+// (com bloqueio progressivo por guardião). Em caso de falha, já responde.
+// Com checkPolicy, as regras de acesso do guardião são conferidas antes do
+// PIN (ver access_policy.go).
+func (h *Handler) authenticateGuardian(w http.ResponseWriter, r *http.Request, token, pin string, checkPolicy bool) (*storage.Guardian, bool) {
+	guardian, err := h.store.GetGuardianByAccessToken(token)
+	if err != nil {
+		writeLinkUnavailable(w, r)
+		return nil, false
+	}
+	if h.rejectDisabledGuardian(w, r, guardian) {
+		return nil, false
+	}
+
+	if guardian.AccessPIN == "" {
+		writeLinkUnavailable(w, r)
+		return nil, false
+	}
+	if checkPolicy && !h.enforceAccessPolicy(w, r, guardian) {
+		return nil, false
+	}
+
+	target := h.guardianPINTarget(guardian)
+	if !h.checkPINLockout(w, r, target) {
+		return nil, false
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(guardian.AccessPIN), []byte(pin)); err != nil {
+		h.handlePINFailure(w, r, target)
+		return nil, false
+	}
+	h.resetPINAttempts(target)
+	return guardian, true
+}
+
+// rejectDisabledGuardian responde o 404 dos links inválidos se o dono
+// desativou o acesso do guardião
+func (h *Handler) rejectDisabledGuardian(w http.ResponseWriter, r *http.Request, guardian *storage.Guardian) bool {
+	if guardian.AccessDisabledAt == nil {
+		return false
+	}
+	writeLinkUnavailable(w, r)
+	return true
+}
+
+// guardianDeletionGraceDays retorna o prazo de carência configurado
+func (h *Handler) guardianDeletionGraceDays() int {
+	if h.deletionGraceDays <= 0 {
+		return defaultGuardianDeletionGraceDays
+	}
+	return h.deletionGraceDays
+}
@@ -6,26 +6,29 @@
 // Endpoints:
 // - POST /api/share/links - Criar link de compartilhamento
 // - GET /api/share/links - Listar links do usuário
+// - PATCH /api/share/links/:id - Alterar nome, categorias e itens do link
 // - DELETE /api/share/links/:id - Remover link
+// - GET /api/share/links/:id/accesses - Acessos ao link (quando e de onde)
 // - GET /api/shared/:token - Acessar conteúdo compartilhado (público)
 // - POST /api/shared/:token/verify - Verificar PIN (se necessário)
+// - POST /api/guardian-access/:token/my-data - Dados do guardião (LGPD)
+// - POST /api/guardian-access/:token/deletion-request - Remoção dos dados do guardião
+// - POST /api/box/items/:itemID/send - Envio avulso de um item por email (ver send.go)
 //
 // Tipos de link:
-// - normal: Acesso a categorias selecionadas
+// - normal: Acesso a categorias ou itens selecionados
 // - emergency: Acesso em caso de emergência
 // - memorial: Acesso completo após falecimento
+// - one_time: Um item enviado por email, para um único acesso (ver send.go)
 // =============================================================================
 
 package share
 
 import (
-	"crypto/rand"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
-	"os"
-	"strconv"
 	"strings"
 	"time"
 
@@ -33,23 +36,91 @@ import (
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 
+	"famli/internal/apierror"
 	"famli/internal/auth"
+	"famli/internal/email"
 	"famli/internal/i18n"
+	"famli/internal/notifications"
 	"famli/internal/security"
 	"famli/internal/storage"
+	"famli/internal/webhooks"
 )
 
 // Handler gerencia operações de compartilhamento
 type Handler struct {
 	store       storage.Store
 	auditLogger *security.AuditLogger
+	policy      shareLinkPolicy
+	appURL      string // Endereço público do frontend
+	urlPrefix   string // Início fixo das URLs dos links (vazio = por idioma)
+
+	deletionGraceDays int // Carência antes de apagar o guardião que pediu a remoção
+
+	alertGuardians  GuardianAlerter   // Avisa os guardiões quando a emergência é acionada (opcional)
+	messageGuardian GuardianMessenger // Avisa as pessoas escolhidas da abertura do memorial (opcional)
+	mailer          *email.Service    // Envia os itens avulsos por email (opcional)
+}
+
+// GuardianAlerter avisa as pessoas de confiança do usuário de que a
+// emergência foi acionada (não deve bloquear a requisição)
+type GuardianAlerter func(userID string)
+
+// GuardianMessenger envia um aviso do sistema a uma pessoa de confiança (a
+// entrega acontece em background)
+type GuardianMessenger func(userID string, guardian *storage.Guardian, event storage.GuardianAlertEvent, message string) error
+
+// Config são os limites dos links compartilhados
+type Config struct {
+	EnforceLimits      bool // Aplicar validade e usos padrão (produção)
+	DefaultExpiresDays int  // Validade padrão (dias)
+	MaxExpiresDays     int  // Validade máxima (dias, 0 = sem limite)
+	DefaultMaxUses     int  // Usos padrão
+	MaxUses            int  // Usos máximos (0 = sem limite)
+
+	// AppURL é o endereço público do frontend; as URLs dos links usam o
+	// caminho no idioma do dono (/compartilhado ou /shared)
+	AppURL string
+
+	// URLPrefix fixa o início das URLs dos links, antes do token, para todos
+	// os idiomas (ex: https://s.famli.me); vazio = AppURL + caminho do idioma
+	URLPrefix string
+
+	// GuardianDeletionGraceDays é o prazo entre o pedido de remoção feito
+	// pelo guardião e a exclusão do cadastro (padrão 30)
+	GuardianDeletionGraceDays int
+
+	// AlertGuardians avisa os guardiões no primeiro acesso a um link de
+	// emergência (nil = apenas a central de notificações do dono)
+	AlertGuardians GuardianAlerter
+
+	// MessageGuardian avisa as pessoas escolhidas a cada passo da abertura
+	// do memorial (nil = apenas a central de notificações do dono)
+	MessageGuardian GuardianMessenger
+
+	// Mailer envia os itens avulsos (POST /api/box/items/{itemID}/send);
+	// nil = o link é criado e o dono repassa a URL
+	Mailer *email.Service
 }
 
 // NewHandler cria uma nova instância do handler
-func NewHandler(store storage.Store) *Handler {
+func NewHandler(store storage.Store, config *Config) *Handler {
 	return &Handler{
 		store:       store,
 		auditLogger: security.GetAuditLogger(),
+		policy: shareLinkPolicy{
+			enforce:            config.EnforceLimits,
+			defaultExpiresDays: config.DefaultExpiresDays,
+			maxExpiresDays:     config.MaxExpiresDays,
+			defaultMaxUses:     config.DefaultMaxUses,
+			maxMaxUses:         config.MaxUses,
+		},
+		appURL:    strings.TrimRight(config.AppURL, "/"),
+		urlPrefix: strings.TrimRight(config.URLPrefix, "/"),
+
+		deletionGraceDays: config.GuardianDeletionGraceDays,
+		alertGuardians:    config.AlertGuardians,
+		messageGuardian:   config.MessageGuardian,
+		mailer:            config.Mailer,
 	}
 }
 
@@ -67,20 +138,53 @@ type CreateLinkRequest struct {
 	PIN         string   `json:"pin,omitempty"`          // PIN opcional
 	ExpiresIn   int      `json:"expires_in,omitempty"`   // Dias até expirar (0 = nunca)
 	MaxUses     int      `json:"max_uses,omitempty"`     // Máximo de usos (0 = ilimitado)
+
+	// MessagesOnly (apenas memorial com guardian_ids) mostra só os itens
+	// endereçados aos guardiões do link
+	MessagesOnly bool `json:"messages_only,omitempty"`
+
+	// ItemIDs restringe o link a itens escolhidos (apenas os compartilhados aparecem)
+	ItemIDs []string `json:"item_ids,omitempty"`
+
+	// PassphraseHint orienta como obter a frase secreta dos itens selados
+	PassphraseHint string `json:"passphrase_hint,omitempty"`
+}
+
+// UpdateLinkRequest representa o payload para alterar um link
+// Campos ausentes (nil) mantêm o valor atual; listas vazias removem o filtro.
+type UpdateLinkRequest struct {
+	Name         *string   `json:"name,omitempty"`
+	Categories   *[]string `json:"categories,omitempty"`
+	ItemIDs      *[]string `json:"item_ids,omitempty"`
+	IsActive     *bool     `json:"is_active,omitempty"`
+	MessagesOnly *bool     `json:"messages_only,omitempty"`
+
+	// PassphraseHint "" remove a dica
+	PassphraseHint *string `json:"passphrase_hint,omitempty"`
 }
 
+// maxLinkItems limita quantos itens podem ser escolhidos em um link
+const maxLinkItems = 100
+
+// maxPassphraseHint limita o tamanho da dica da frase secreta
+const maxPassphraseHint = 500
+
 // ShareLinkResponse representa a resposta com o link criado
 type ShareLinkResponse struct {
-	ID         string     `json:"id"`
-	Name       string     `json:"name"`
-	Type       string     `json:"type"`
-	URL        string     `json:"url"`
-	Categories []string   `json:"categories,omitempty"`
-	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
-	MaxUses    int        `json:"max_uses"`
-	UsageCount int        `json:"usage_count"`
-	IsActive   bool       `json:"is_active"`
-	CreatedAt  time.Time  `json:"created_at"`
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	Type         string     `json:"type"`
+	URL          string     `json:"url"`
+	Categories   []string   `json:"categories,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	MaxUses      int        `json:"max_uses"`
+	UsageCount   int        `json:"usage_count"`
+	IsActive     bool       `json:"is_active"`
+	CreatedAt    time.Time  `json:"created_at"`
+	MessagesOnly bool       `json:"messages_only,omitempty"`
+	ItemIDs      []string   `json:"item_ids,omitempty"`
+
+	PassphraseHint string `json:"passphrase_hint,omitempty"`
 }
 
 // VerifyPINRequest representa o payload para verificar PIN
@@ -100,7 +204,7 @@ func (h *Handler) CreateLink(w http.ResponseWriter, r *http.Request) {
 
 	var req CreateLinkRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "share.invalid_data"))
+		apierror.Write(w, r, http.StatusBadRequest, "share.invalid_data")
 		return
 	}
 
@@ -122,7 +226,7 @@ func (h *Handler) CreateLink(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Gerar token seguro
-	token := generateSecureToken()
+	token := security.GenerateShareToken()
 
 	// Hash do PIN se fornecido
 	var pinHash string
@@ -133,7 +237,7 @@ func (h *Handler) CreateLink(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	policy := getShareLinkPolicy()
+	policy := h.policy
 	expiresIn := req.ExpiresIn
 	maxUses := req.MaxUses
 	if policy.enforce {
@@ -154,47 +258,66 @@ func (h *Handler) CreateLink(w http.ResponseWriter, r *http.Request) {
 		guardianIDs = []string{req.GuardianID}
 	}
 
+	// Apenas mensagens: memorial endereçado a guardiões específicos
+	if req.MessagesOnly && (linkType != storage.ShareLinkMemorial || len(guardianIDs) == 0) {
+		apierror.Write(w, r, http.StatusBadRequest, "share.messages_only_invalid")
+		return
+	}
+
+	itemIDs, ok := h.validateItemIDs(userID, req.ItemIDs)
+	if !ok {
+		apierror.Write(w, r, http.StatusBadRequest, "share.invalid_items")
+		return
+	}
+
 	now := time.Now()
 	link := &storage.ShareLink{
-		ID:          uuid.New().String(),
-		UserID:      userID,
-		GuardianID:  req.GuardianID,
-		GuardianIDs: guardianIDs,
-		Token:       token,
-		Type:        linkType,
-		Name:        req.Name,
-		PIN:         pinHash,
-		Categories:  req.Categories,
-		ExpiresAt:   expiresAt,
-		MaxUses:     maxUses,
-		IsActive:    true,
-		CreatedAt:   now,
-		UpdatedAt:   now,
+		ID:           uuid.New().String(),
+		UserID:       userID,
+		GuardianID:   req.GuardianID,
+		GuardianIDs:  guardianIDs,
+		Token:        token,
+		Type:         linkType,
+		Name:         req.Name,
+		PIN:          pinHash,
+		Categories:   req.Categories,
+		ExpiresAt:    expiresAt,
+		MaxUses:      maxUses,
+		IsActive:     true,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		MessagesOnly: req.MessagesOnly,
+		ItemIDs:      itemIDs,
+
+		PassphraseHint: security.SanitizeText(req.PassphraseHint, maxPassphraseHint),
 	}
 
 	if err := h.store.CreateShareLink(link); err != nil {
-		writeError(w, http.StatusInternalServerError, i18n.Tr(r, "share.create_error"))
+		apierror.Write(w, r, http.StatusInternalServerError, "share.create_error")
 		return
 	}
 
 	// Log de auditoria
 	h.auditLogger.LogDataAccess(userID, clientIP, "share/links/"+link.ID, "create", "success")
 
-	// Construir URL
-	baseURL := getBaseURL(r)
-	shareURL := baseURL + "/compartilhado/" + token
+	// Construir URL (no idioma do dono)
+	shareURL := h.linkURL(token, h.ownerLocale(r, userID))
 
 	writeJSON(w, http.StatusCreated, ShareLinkResponse{
-		ID:         link.ID,
-		Name:       link.Name,
-		Type:       string(link.Type),
-		URL:        shareURL,
-		Categories: link.Categories,
-		ExpiresAt:  link.ExpiresAt,
-		MaxUses:    link.MaxUses,
-		UsageCount: link.UsageCount,
-		IsActive:   link.IsActive,
-		CreatedAt:  link.CreatedAt,
+		ID:           link.ID,
+		Name:         link.Name,
+		Type:         string(link.Type),
+		URL:          shareURL,
+		Categories:   link.Categories,
+		ExpiresAt:    link.ExpiresAt,
+		MaxUses:      link.MaxUses,
+		UsageCount:   link.UsageCount,
+		IsActive:     link.IsActive,
+		CreatedAt:    link.CreatedAt,
+		MessagesOnly: link.MessagesOnly,
+		ItemIDs:      link.ItemIDs,
+
+		PassphraseHint: link.PassphraseHint,
 	})
 }
 
@@ -202,36 +325,19 @@ func (h *Handler) CreateLink(w http.ResponseWriter, r *http.Request) {
 // GET /api/share/links
 func (h *Handler) ListLinks(w http.ResponseWriter, r *http.Request) {
 	userID := auth.GetUserID(r)
-	policy := getShareLinkPolicy()
+	policy := h.policy
 
 	links, err := h.store.GetShareLinksByUser(userID)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, i18n.Tr(r, "share.list_error"))
+		apierror.Write(w, r, http.StatusInternalServerError, "share.list_error")
 		return
 	}
 
 	// Converter para response (sem expor tokens)
-	baseURL := getBaseURL(r)
+	locale := h.ownerLocale(r, userID)
 	var responses []ShareLinkResponse
 	for _, link := range links {
-		expiresAt := link.ExpiresAt
-		maxUses := link.MaxUses
-		if policy.enforce {
-			expiresAt = effectiveShareExpiresAt(link, policy)
-			maxUses = effectiveShareMaxUses(link, policy)
-		}
-		responses = append(responses, ShareLinkResponse{
-			ID:         link.ID,
-			Name:       link.Name,
-			Type:       string(link.Type),
-			URL:        baseURL + "/compartilhado/" + link.Token,
-			Categories: link.Categories,
-			ExpiresAt:  expiresAt,
-			MaxUses:    maxUses,
-			UsageCount: link.UsageCount,
-			IsActive:   link.IsActive,
-			CreatedAt:  link.CreatedAt,
-		})
+		responses = append(responses, linkResponse(link, h.linkURL(link.Token, locale), policy))
 	}
 
 	if responses == nil {
@@ -243,6 +349,137 @@ func (h *Handler) ListLinks(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// UpdateLink altera nome, categorias, itens escolhidos e ativação de um link
+// PATCH /api/share/links/:id
+func (h *Handler) UpdateLink(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+	linkID := chi.URLParam(r, "id")
+
+	var req UpdateLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "share.invalid_data")
+		return
+	}
+
+	links, err := h.store.GetShareLinksByUser(userID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "share.update_error")
+		return
+	}
+	var link *storage.ShareLink
+	for _, candidate := range links {
+		if candidate.ID == linkID {
+			link = candidate
+			break
+		}
+	}
+	if link == nil {
+		apierror.Write(w, r, http.StatusNotFound, "share.not_found")
+		return
+	}
+
+	// Envios avulsos continuam presos ao item enviado
+	if link.Type == storage.ShareLinkOneTime && (req.Categories != nil || req.ItemIDs != nil || req.MessagesOnly != nil) {
+		apierror.Write(w, r, http.StatusBadRequest, "share.invalid_data")
+		return
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" || len(name) > 255 {
+			apierror.Write(w, r, http.StatusBadRequest, "share.invalid_data")
+			return
+		}
+		link.Name = name
+	}
+	if req.Categories != nil {
+		link.Categories = *req.Categories
+	}
+	if req.ItemIDs != nil {
+		itemIDs, ok := h.validateItemIDs(userID, *req.ItemIDs)
+		if !ok {
+			apierror.Write(w, r, http.StatusBadRequest, "share.invalid_items")
+			return
+		}
+		link.ItemIDs = itemIDs
+	}
+	if req.IsActive != nil {
+		link.IsActive = *req.IsActive
+	}
+	if req.MessagesOnly != nil {
+		link.MessagesOnly = *req.MessagesOnly
+	}
+	if req.PassphraseHint != nil {
+		link.PassphraseHint = security.SanitizeText(*req.PassphraseHint, maxPassphraseHint)
+	}
+	if link.MessagesOnly && (link.Type != storage.ShareLinkMemorial || len(link.GuardianIDs) == 0) {
+		apierror.Write(w, r, http.StatusBadRequest, "share.messages_only_invalid")
+		return
+	}
+
+	if err := h.store.UpdateShareLink(link); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "share.update_error")
+		return
+	}
+
+	h.auditLogger.LogDataAccess(userID, security.GetClientIP(r), "share/links/"+link.ID, "update", "success")
+
+	writeJSON(w, http.StatusOK, linkResponse(link, h.linkURL(link.Token, h.ownerLocale(r, userID)), h.policy))
+}
+
+// validateItemIDs confere os itens escolhidos para um link
+// Os itens precisam ser do usuário; repetições são removidas. Itens não
+// compartilhados podem ser escolhidos, mas só aparecem com is_shared.
+func (h *Handler) validateItemIDs(userID string, itemIDs []string) ([]string, bool) {
+	if len(itemIDs) > maxLinkItems {
+		return nil, false
+	}
+
+	seen := make(map[string]bool, len(itemIDs))
+	result := make([]string, 0, len(itemIDs))
+	for _, id := range itemIDs {
+		id = strings.TrimSpace(id)
+		if seen[id] {
+			continue
+		}
+		if _, err := h.store.GetBoxItem(userID, id); err != nil {
+			return nil, false
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+	if len(result) == 0 {
+		return nil, true
+	}
+	return result, true
+}
+
+// linkResponse converte o link para a resposta (sem expor o token)
+func linkResponse(link *storage.ShareLink, linkURL string, policy shareLinkPolicy) ShareLinkResponse {
+	expiresAt := link.ExpiresAt
+	maxUses := link.MaxUses
+	if policy.enforce {
+		expiresAt = effectiveShareExpiresAt(link, policy)
+		maxUses = effectiveShareMaxUses(link, policy)
+	}
+	return ShareLinkResponse{
+		ID:           link.ID,
+		Name:         link.Name,
+		Type:         string(link.Type),
+		URL:          linkURL,
+		Categories:   link.Categories,
+		ExpiresAt:    expiresAt,
+		MaxUses:      maxUses,
+		UsageCount:   link.UsageCount,
+		IsActive:     link.IsActive,
+		CreatedAt:    link.CreatedAt,
+		MessagesOnly: link.MessagesOnly,
+		ItemIDs:      link.ItemIDs,
+
+		PassphraseHint: link.PassphraseHint,
+	}
+}
+
 // DeleteLink remove um link
 // DELETE /api/share/links/:id
 func (h *Handler) DeleteLink(w http.ResponseWriter, r *http.Request) {
@@ -251,7 +488,7 @@ func (h *Handler) DeleteLink(w http.ResponseWriter, r *http.Request) {
 	clientIP := security.GetClientIP(r)
 
 	if err := h.store.DeleteShareLink(userID, linkID); err != nil {
-		writeError(w, http.StatusNotFound, i18n.Tr(r, "share.not_found"))
+		apierror.Write(w, r, http.StatusNotFound, "share.not_found")
 		return
 	}
 
@@ -262,41 +499,98 @@ func (h *Handler) DeleteLink(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// maxAccessesListed limita os acessos retornados por link
+const maxAccessesListed = 100
+
+// ListAccesses lista os acessos recentes a um link do usuário
+// GET /api/share/links/:id/accesses
+//
+// Mostra quando, de onde (GeoIP) e de qual dispositivo; o IP não é exposto.
+func (h *Handler) ListAccesses(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+	linkID := chi.URLParam(r, "id")
+
+	accesses, err := h.store.ListShareLinkAccesses(userID, linkID, maxAccessesListed)
+	if errors.Is(err, storage.ErrNotFound) {
+		apierror.Write(w, r, http.StatusNotFound, "share.not_found")
+		return
+	}
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "share.list_error")
+		return
+	}
+
+	response := make([]map[string]interface{}, 0, len(accesses))
+	for _, access := range accesses {
+		location := &security.GeoLocation{Country: access.Country, CountryName: access.CountryName, City: access.City}
+		response = append(response, map[string]interface{}{
+			"id":          access.ID,
+			"accessed_at": access.AccessedAt,
+			"country":     access.Country,
+			"city":        access.City,
+			"location":    location.String(),
+			"device":      security.DescribeDevice(access.UserAgent),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"accesses": response,
+	})
+}
+
 // =============================================================================
 // ENDPOINTS PÚBLICOS (Acesso via Link)
 // =============================================================================
 
-// AccessShared acessa o conteúdo compartilhado
-// GET /api/shared/:token
-func (h *Handler) AccessShared(w http.ResponseWriter, r *http.Request) {
-	token := chi.URLParam(r, "token")
-	clientIP := security.GetClientIP(r)
-	policy := getShareLinkPolicy()
+// writeLinkUnavailable responde o 404 único dos tokens inválidos: inexistente,
+// expirado, esgotado ou desativado não se distinguem (ver botguard.go)
+func writeLinkUnavailable(w http.ResponseWriter, r *http.Request) {
+	apierror.Write(w, r, http.StatusNotFound, "share.link_expired")
+}
 
-	// Buscar link
+// availableLink busca o link pelo token e confere a validade e o limite de
+// usos (com os limites padrão, em produção)
+func (h *Handler) availableLink(token string) (*storage.ShareLink, bool) {
 	link, err := h.store.GetShareLinkByToken(token)
 	if err != nil {
-		writeError(w, http.StatusNotFound, i18n.Tr(r, "share.link_expired"))
-		return
+		return nil, false
 	}
 
-	// Verificar expiração
 	expiresAt := link.ExpiresAt
-	if policy.enforce {
-		expiresAt = effectiveShareExpiresAt(link, policy)
+	if h.policy.enforce {
+		expiresAt = effectiveShareExpiresAt(link, h.policy)
 	}
 	if expiresAt != nil && expiresAt.Before(time.Now()) {
-		writeError(w, http.StatusGone, i18n.Tr(r, "share.link_expired"))
-		return
+		return nil, false
 	}
 
-	// Verificar limite de uso
 	maxUses := link.MaxUses
-	if policy.enforce {
-		maxUses = effectiveShareMaxUses(link, policy)
+	if h.policy.enforce {
+		maxUses = effectiveShareMaxUses(link, h.policy)
 	}
 	if maxUses > 0 && link.UsageCount >= maxUses {
-		writeError(w, http.StatusGone, i18n.Tr(r, "share.link_expired"))
+		return nil, false
+	}
+	return link, true
+}
+
+// AccessShared acessa o conteúdo compartilhado
+// GET /api/shared/:token
+func (h *Handler) AccessShared(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+	clientIP := security.GetClientIP(r)
+	search, ok := parseItemSearch(w, r)
+	if !ok {
+		return
+	}
+
+	// Buscar link (ativo, dentro da validade e do limite de usos)
+	link, ok := h.availableLink(token)
+	if !ok {
+		writeLinkUnavailable(w, r)
+		return
+	}
+	if h.rejectLockedMemorial(w, r, link) {
 		return
 	}
 
@@ -309,15 +603,26 @@ func (h *Handler) AccessShared(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Envios avulsos: este acesso gasta o link
+	if !h.claimOneTimeLink(link, clientIP) {
+		writeLinkUnavailable(w, r)
+		return
+	}
+
 	// Buscar dados do usuário
-	sharedView, err := h.getSharedContent(link)
+	sharedView, err := h.getSharedContent(link, search)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, i18n.Tr(r, "share.access_error"))
+		apierror.Write(w, r, http.StatusInternalServerError, "share.access_error")
 		return
 	}
 
-	// Registrar acesso
+	if security.WantsRenderedHTML(r) {
+		renderItemsHTML(sharedView.Items)
+	}
+
+	// Registrar acesso e recibos de leitura
 	h.recordAccess(link, clientIP, r.UserAgent())
+	h.markViews(storage.ItemViewShareLink, link.ID, sharedView.Items)
 
 	writeJSON(w, http.StatusOK, sharedView)
 }
@@ -327,54 +632,63 @@ func (h *Handler) AccessShared(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) VerifyPIN(w http.ResponseWriter, r *http.Request) {
 	token := chi.URLParam(r, "token")
 	clientIP := security.GetClientIP(r)
-	policy := getShareLinkPolicy()
+	search, ok := parseItemSearch(w, r)
+	if !ok {
+		return
+	}
 
 	var req VerifyPINRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "share.invalid_data"))
+		apierror.Write(w, r, http.StatusBadRequest, "share.invalid_data")
 		return
 	}
 
 	// Buscar link
-	link, err := h.store.GetShareLinkByToken(token)
-	if err != nil {
-		writeError(w, http.StatusNotFound, i18n.Tr(r, "share.link_expired"))
+	link, ok := h.availableLink(token)
+	if !ok {
+		writeLinkUnavailable(w, r)
 		return
 	}
-
-	expiresAt := link.ExpiresAt
-	if policy.enforce {
-		expiresAt = effectiveShareExpiresAt(link, policy)
+	if h.rejectLockedMemorial(w, r, link) {
+		return
 	}
-	if expiresAt != nil && expiresAt.Before(time.Now()) {
-		writeError(w, http.StatusGone, i18n.Tr(r, "share.link_expired"))
+
+	if link.PIN == "" {
+		apierror.Write(w, r, http.StatusBadRequest, "share.invalid_data")
 		return
 	}
 
-	maxUses := link.MaxUses
-	if policy.enforce {
-		maxUses = effectiveShareMaxUses(link, policy)
+	// Verificar PIN (com bloqueio progressivo por link)
+	target := h.shareLinkPINTarget(link)
+	if !h.checkPINLockout(w, r, target) {
+		return
 	}
-	if maxUses > 0 && link.UsageCount >= maxUses {
-		writeError(w, http.StatusGone, i18n.Tr(r, "share.link_expired"))
+	if err := bcrypt.CompareHashAndPassword([]byte(link.PIN), []byte(req.PIN)); err != nil {
+		h.handlePINFailure(w, r, target)
 		return
 	}
+	h.resetPINAttempts(target)
 
-	// Verificar PIN
-	if err := bcrypt.CompareHashAndPassword([]byte(link.PIN), []byte(req.PIN)); err != nil {
-		writeError(w, http.StatusUnauthorized, i18n.Tr(r, "share.invalid_pin"))
+	// Envios avulsos: este acesso gasta o link
+	if !h.claimOneTimeLink(link, clientIP) {
+		writeLinkUnavailable(w, r)
 		return
 	}
 
 	// Buscar dados
-	sharedView, err := h.getSharedContent(link)
+	sharedView, err := h.getSharedContent(link, search)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, i18n.Tr(r, "share.access_error"))
+		apierror.Write(w, r, http.StatusInternalServerError, "share.access_error")
 		return
 	}
 
-	// Registrar acesso
+	if security.WantsRenderedHTML(r) {
+		renderItemsHTML(sharedView.Items)
+	}
+
+	// Registrar acesso e recibos de leitura
 	h.recordAccess(link, clientIP, r.UserAgent())
+	h.markViews(storage.ItemViewShareLink, link.ID, sharedView.Items)
 
 	writeJSON(w, http.StatusOK, sharedView)
 }
@@ -384,20 +698,34 @@ func (h *Handler) VerifyPIN(w http.ResponseWriter, r *http.Request) {
 // =============================================================================
 
 // getSharedContent retorna o conteúdo baseado no tipo de link
-func (h *Handler) getSharedContent(link *storage.ShareLink) (*storage.SharedView, error) {
+// A busca é aplicada depois do escopo do link (ver search.go).
+func (h *Handler) getSharedContent(link *storage.ShareLink, search itemSearch) (*storage.SharedView, error) {
 	// Buscar usuário
 	user, ok := h.store.GetUserByID(link.UserID)
 	if !ok {
 		return nil, storage.ErrNotFound
 	}
 
-	// Buscar apenas itens compartilhados
-	allItems := h.store.ListSharedItems(link.UserID)
-	allItems = filterItemsByGuardians(allItems, link.GuardianIDs)
+	// Buscar apenas itens compartilhados (o envio avulso mostra o item
+	// escolhido pelo dono, mesmo sem is_shared)
+	var allItems []*storage.BoxItem
+	if link.Type == storage.ShareLinkOneTime {
+		allItems = h.oneTimeItems(link)
+	} else {
+		allItems = h.store.ListSharedItems(link.UserID)
+		allItems = filterItemsByGuardians(allItems, link.GuardianIDs)
+	}
 
-	// Filtrar por categoria se necessário
+	// Filtrar por categoria e pelos itens escolhidos, se necessário
+	// Links "apenas mensagens" mostram só o que foi endereçado aos guardiões do link.
 	var items []*storage.BoxItem
 	for _, item := range allItems {
+		if link.MessagesOnly && !contains(link.GuardianIDs, item.RecipientGuardianID) {
+			continue
+		}
+		if len(link.ItemIDs) > 0 && !contains(link.ItemIDs, item.ID) {
+			continue
+		}
 		if len(link.Categories) == 0 || contains(link.Categories, item.Category) {
 			items = append(items, item)
 		}
@@ -408,9 +736,17 @@ func (h *Handler) getSharedContent(link *storage.ShareLink) (*storage.SharedView
 
 	view := &storage.SharedView{
 		UserName:   maskedUserName,
-		Items:      items,
+		Items:      search.filter(items),
 		LinkType:   link.Type,
 		AccessedAt: time.Now(),
+
+		PassphraseHint: link.PassphraseHint,
+		Search:         search.info(len(items)),
+	}
+	for _, item := range view.Items {
+		if item.Pet != nil {
+			view.Pets = append(view.Pets, &storage.SharedPet{ItemID: item.ID, PetProfile: item.Pet})
+		}
 	}
 
 	// Adicionar guardiões baseado no tipo de link e filtro
@@ -442,6 +778,7 @@ func (h *Handler) getSharedContent(link *storage.ShareLink) (*storage.SharedView
 		}
 		view.UserEmail = maskedUserEmail
 		view.Message = "Este é o memorial de " + maskedUserName + ". As informações aqui foram deixadas para ajudar você."
+		view.DigitalLegacy = digitalLegacy(view.Items)
 	}
 
 	// Mensagem para modo emergência
@@ -452,6 +789,46 @@ func (h *Handler) getSharedContent(link *storage.ShareLink) (*storage.SharedView
 	return view, nil
 }
 
+// renderItemsHTML preenche o conteúdo renderizado (?render=html) dos itens
+// Itens selados nunca são renderizados.
+func renderItemsHTML(items []*storage.BoxItem) {
+	for _, item := range items {
+		if item.IsSealed() {
+			continue // Texto cifrado: só o navegador, com a frase secreta, decifra
+		}
+		item.ContentHTML = security.RenderMarkdown(item.Content)
+	}
+}
+
+// markViews registra a leitura dos itens pela origem e marca cada item como
+// entregue agora ("delivered") ou já lido antes ("read")
+// Falhas não impedem o acesso ao conteúdo.
+func (h *Handler) markViews(source storage.ItemViewSource, sourceID string, items []*storage.BoxItem) {
+	if len(items) == 0 {
+		return
+	}
+	itemIDs := make([]string, 0, len(items))
+	for _, item := range items {
+		itemIDs = append(itemIDs, item.ID)
+	}
+	seenBefore, err := h.store.RecordItemViews(source, sourceID, itemIDs, time.Now())
+	if err != nil {
+		log.Printf("[SHARE] Erro ao registrar leitura dos itens: %v", err)
+		return
+	}
+	for _, item := range items {
+		seen, ok := seenBefore[item.ID]
+		if !ok {
+			continue
+		}
+		if seen {
+			item.ViewStatus = storage.ItemRead
+		} else {
+			item.ViewStatus = storage.ItemDelivered
+		}
+	}
+}
+
 func sanitizeGuardiansForShare(guardians []*storage.Guardian) []*storage.Guardian {
 	if len(guardians) == 0 {
 		return nil
@@ -476,10 +853,12 @@ func sanitizeGuardianForShare(g *storage.Guardian) *storage.Guardian {
 
 // recordAccess registra um acesso ao link
 func (h *Handler) recordAccess(link *storage.ShareLink, ip, userAgent string) {
-	// Incrementar contador
-	h.store.IncrementShareLinkUsage(link.ID)
+	// Incrementar contador (envios avulsos já contaram em claimOneTimeLink)
+	if link.Type != storage.ShareLinkOneTime {
+		h.store.IncrementShareLinkUsage(link.ID)
+	}
 
-	// Registrar detalhes do acesso
+	// Registrar detalhes do acesso (localização nos termos do dono do link)
 	access := &storage.ShareLinkAccess{
 		ID:          uuid.New().String(),
 		ShareLinkID: link.ID,
@@ -487,18 +866,51 @@ func (h *Handler) recordAccess(link *storage.ShareLink, ip, userAgent string) {
 		UserAgent:   userAgent,
 		AccessedAt:  time.Now(),
 	}
+	locale := ""
+	if owner, ok := h.store.GetUserByID(link.UserID); ok {
+		locale = owner.Locale
+	}
+	if location := security.LookupLocation(ip, locale); location != nil {
+		access.Country = location.Country
+		access.CountryName = location.CountryName
+		access.City = location.City
+	}
 	h.store.RecordShareLinkAccess(access)
 
 	// Log de auditoria
 	h.auditLogger.LogDataAccess(link.UserID, ip, "shared/"+link.ID, "access", "success")
-}
 
-// generateSecureToken gera um token seguro para o link
-func generateSecureToken() string {
-	bytes := make([]byte, 32)
-	rand.Read(bytes)
-	hash := sha256.Sum256(bytes)
-	return hex.EncodeToString(hash[:16]) // 32 caracteres hex
+	// Webhooks (sem IP ou User-Agent de quem acessou)
+	data := map[string]interface{}{
+		"link_id":     link.ID,
+		"link_type":   link.Type,
+		"accessed_at": access.AccessedAt,
+	}
+	webhooks.Emit(link.UserID, storage.WebhookShareAccessed, data)
+
+	// Não há ativação explícita do protocolo de emergência: o primeiro acesso
+	// a um link de emergência é o sinal de que ele foi acionado
+	if link.Type == storage.ShareLinkEmergency && link.UsageCount == 0 {
+		h.auditLogger.Log(security.AuditEvent{
+			Type:     security.EventEmergencyActivated,
+			Severity: security.SeverityWarning,
+			UserID:   link.UserID,
+			ClientIP: ip,
+			Resource: "share_link",
+			Action:   "access",
+			Result:   "success",
+			Details:  map[string]interface{}{"link_id": link.ID},
+		})
+		webhooks.Emit(link.UserID, storage.WebhookEmergencyActivated, data)
+		notifications.Notify(link.UserID, storage.NotificationEmergency, "notify.emergency_activated")
+		if h.alertGuardians != nil {
+			if protocol := h.emergencyProtocol(link.UserID); protocol == nil || protocol.NotifyGuardians {
+				h.alertGuardians(link.UserID)
+			}
+		}
+	} else {
+		notifications.Notify(link.UserID, storage.NotificationGuardianAccess, "notify.shared_access")
+	}
 }
 
 // =============================================================================
@@ -507,11 +919,23 @@ func generateSecureToken() string {
 
 // GuardianAccessResponse representa a resposta para acesso do guardião
 type GuardianAccessResponse struct {
-	Guardian   *GuardianInfo     `json:"guardian"`
-	Owner      *OwnerInfo        `json:"owner"`
-	Items      []*SharedItemInfo `json:"items"`
-	AccessType string            `json:"access_type"`
-	AccessedAt time.Time         `json:"accessed_at"`
+	Guardian   *GuardianInfo       `json:"guardian"`
+	Owner      *OwnerInfo          `json:"owner"`
+	Items      []*SharedItemInfo   `json:"items"`
+	AccessType string              `json:"access_type"`
+	AccessedAt time.Time           `json:"accessed_at"`
+	Search     *storage.ItemSearch `json:"search,omitempty"` // Busca aplicada (?q=, ?category=)
+
+	// Memorial é o andamento da abertura do memorial (apenas para as pessoas
+	// escolhidas pelo dono, ver memorial.go)
+	Memorial *MemorialStatus `json:"memorial,omitempty"`
+
+	// Pets são os perfis dos pets entre os itens, para quem for cuidar deles
+	Pets []*storage.SharedPet `json:"pets,omitempty"`
+
+	// DigitalLegacy são as contas online agrupadas pela ação (guardiões de
+	// acesso memorial ou depois da abertura do memorial)
+	DigitalLegacy []*storage.DigitalLegacyGroup `json:"digital_legacy,omitempty"`
 }
 
 // GuardianInfo representa info do guardião
@@ -532,10 +956,55 @@ type SharedItemInfo struct {
 	Type        string    `json:"type"`
 	Title       string    `json:"title"`
 	Content     string    `json:"content"`
+	ContentHTML string    `json:"content_html,omitempty"` // Apenas com ?render=html
 	Category    string    `json:"category,omitempty"`
 	Recipient   string    `json:"recipient,omitempty"`
 	IsImportant bool      `json:"is_important,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
+
+	// ViewStatus indica se o item está sendo entregue agora ("delivered")
+	// ou se este guardião já o leu antes ("read")
+	ViewStatus storage.ItemViewStatus `json:"view_status,omitempty"`
+
+	// Sealed traz os parâmetros para decifrar no navegador um item selado
+	// (Content é o texto cifrado)
+	Sealed *storage.SealedParams `json:"sealed,omitempty"`
+
+	// Checklist é a lista marcável do item (somente leitura para o guardião)
+	Checklist []storage.ChecklistEntry `json:"checklist,omitempty"`
+
+	// Schedule é a agenda da rotina, com o nome do responsável
+	Schedule *storage.RoutineSchedule `json:"schedule,omitempty"`
+
+	// Pet é o perfil do pet (itens "pet")
+	Pet *storage.PetProfile `json:"pet,omitempty"`
+
+	// FinancialAccount é a conta financeira (número já mascarado)
+	FinancialAccount *storage.FinancialAccount `json:"financial_account,omitempty"`
+
+	// OnlineAccount é a conta online e o que fazer com ela
+	OnlineAccount *storage.OnlineAccount `json:"online_account,omitempty"`
+}
+
+// guardianNames retorna o nome das pessoas de confiança do dono por ID,
+// usado para mostrar quem é responsável por cada rotina agendada
+// (só consulta os guardiões se algum item tiver agenda)
+func (h *Handler) guardianNames(ownerID string, items []*storage.BoxItem) map[string]string {
+	names := make(map[string]string)
+	for _, item := range items {
+		if item.Schedule == nil || item.Schedule.ResponsibleGuardianID == "" {
+			continue
+		}
+		guardians, err := h.store.GetGuardians(ownerID)
+		if err != nil {
+			return names
+		}
+		for _, g := range guardians {
+			names[g.ID] = g.Name
+		}
+		return names
+	}
+	return names
 }
 
 // AccessGuardianView permite acessar itens compartilhados via token do guardião
@@ -543,27 +1012,35 @@ type SharedItemInfo struct {
 func (h *Handler) AccessGuardianView(w http.ResponseWriter, r *http.Request) {
 	token := chi.URLParam(r, "token")
 	if token == "" {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "share.invalid_token"))
+		apierror.Write(w, r, http.StatusBadRequest, "share.invalid_token")
 		return
 	}
 
 	// Buscar guardião pelo token
 	guardian, err := h.store.GetGuardianByAccessToken(token)
 	if err != nil {
-		writeError(w, http.StatusNotFound, i18n.Tr(r, "share.link_not_found"))
+		writeLinkUnavailable(w, r)
+		return
+	}
+	if h.rejectDisabledGuardian(w, r, guardian) {
 		return
 	}
 
 	// Buscar dono da caixa
 	owner, found := h.store.GetUserByID(guardian.UserID)
 	if !found {
-		writeError(w, http.StatusNotFound, i18n.Tr(r, "share.link_not_found"))
+		writeLinkUnavailable(w, r)
 		return
 	}
 
-	// Exigir PIN para acesso do guardião
+	// Exigir PIN para acesso do guardião (sem PIN, o link não funciona)
 	if guardian.AccessPIN == "" {
-		writeError(w, http.StatusForbidden, i18n.Tr(r, "share.pin_required"))
+		writeLinkUnavailable(w, r)
+		return
+	}
+
+	// Regras do dono (prazo, horário, limite): o motivo aparece antes do PIN
+	if !h.enforceAccessPolicy(w, r, guardian) {
 		return
 	}
 
@@ -588,59 +1065,68 @@ func (h *Handler) AccessGuardianView(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) VerifyGuardianPIN(w http.ResponseWriter, r *http.Request) {
 	token := chi.URLParam(r, "token")
 	if token == "" {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "share.invalid_token"))
+		apierror.Write(w, r, http.StatusBadRequest, "share.invalid_token")
 		return
 	}
 
 	var req VerifyPINRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, i18n.Tr(r, "share.invalid_data"))
+		apierror.Write(w, r, http.StatusBadRequest, "share.invalid_data")
 		return
 	}
-
-	// Buscar guardião pelo token
-	guardian, err := h.store.GetGuardianByAccessToken(token)
-	if err != nil {
-		writeError(w, http.StatusNotFound, i18n.Tr(r, "share.link_not_found"))
-		return
-	}
-
-	if guardian.AccessPIN == "" {
-		writeError(w, http.StatusForbidden, i18n.Tr(r, "share.pin_required"))
+	search, ok := parseItemSearch(w, r)
+	if !ok {
 		return
 	}
 
-	// Verificar PIN
-	if err := bcrypt.CompareHashAndPassword([]byte(guardian.AccessPIN), []byte(req.PIN)); err != nil {
-		writeError(w, http.StatusUnauthorized, i18n.Tr(r, "share.invalid_pin"))
+	guardian, ok := h.authenticateGuardian(w, r, token, req.PIN, true)
+	if !ok {
 		return
 	}
 
 	// Buscar dono da caixa
 	owner, found := h.store.GetUserByID(guardian.UserID)
 	if !found {
-		writeError(w, http.StatusNotFound, i18n.Tr(r, "share.link_not_found"))
+		writeLinkUnavailable(w, r)
 		return
 	}
 
-	// Retornar conteúdo completo
-	h.returnGuardianContent(w, r, guardian, owner)
+	// Retornar conteúdo completo (ou o resultado da busca)
+	h.returnGuardianContent(w, r, guardian, owner, search)
 }
 
 // returnGuardianContent retorna o conteúdo da caixa para o guardião
-func (h *Handler) returnGuardianContent(w http.ResponseWriter, r *http.Request, guardian *storage.Guardian, owner *storage.User) {
+// A busca é aplicada depois do escopo do guardião (ver search.go).
+func (h *Handler) returnGuardianContent(w http.ResponseWriter, r *http.Request, guardian *storage.Guardian, owner *storage.User, search itemSearch) {
+	if !h.claimPolicyAccess(w, r, guardian) {
+		return
+	}
+
 	maskedOwnerName := maskName(owner.Name)
 	maskedOwnerEmail := maskEmail(owner.Email)
 
+	webhooks.Emit(guardian.UserID, storage.WebhookShareAccessed, map[string]interface{}{
+		"guardian_id": guardian.ID,
+		"link_type":   "guardian",
+		"accessed_at": time.Now(),
+	})
+	notifications.Notify(guardian.UserID, storage.NotificationGuardianAccess, "notify.shared_access")
+
 	// IMPORTANTE: Buscar apenas itens COMPARTILHADOS (is_shared = true)
 	// Itens não compartilhados são privados e não devem ser expostos
 	sharedItems := h.store.ListSharedItems(guardian.UserID)
 	sharedItems = filterItemsByGuardians(sharedItems, []string{guardian.ID})
+	total := len(sharedItems)
+	sharedItems = search.filter(sharedItems)
+	h.markViews(storage.ItemViewGuardian, guardian.ID, sharedItems)
 
 	// Converter para resposta
+	renderHTML := security.WantsRenderedHTML(r)
+	guardianNames := h.guardianNames(guardian.UserID, sharedItems)
 	items := make([]*SharedItemInfo, 0, len(sharedItems))
+	var pets []*storage.SharedPet
 	for _, item := range sharedItems {
-		items = append(items, &SharedItemInfo{
+		info := &SharedItemInfo{
 			ID:          item.ID,
 			Type:        string(item.Type),
 			Title:       item.Title,
@@ -649,7 +1135,26 @@ func (h *Handler) returnGuardianContent(w http.ResponseWriter, r *http.Request,
 			Recipient:   item.Recipient,
 			IsImportant: item.IsImportant,
 			CreatedAt:   item.CreatedAt,
-		})
+			ViewStatus:  item.ViewStatus,
+			Sealed:      item.Sealed,
+			Checklist:   item.Checklist,
+			Pet:         item.Pet,
+
+			FinancialAccount: item.FinancialAccount,
+			OnlineAccount:    item.OnlineAccount,
+		}
+		if item.Pet != nil {
+			pets = append(pets, &storage.SharedPet{ItemID: item.ID, PetProfile: item.Pet})
+		}
+		if item.Schedule != nil {
+			schedule := *item.Schedule
+			schedule.ResponsibleName = guardianNames[schedule.ResponsibleGuardianID]
+			info.Schedule = &schedule
+		}
+		if renderHTML && !item.IsSealed() {
+			info.ContentHTML = security.RenderMarkdown(item.Content)
+		}
+		items = append(items, info)
 	}
 
 	response := &GuardianAccessResponse{
@@ -664,6 +1169,13 @@ func (h *Handler) returnGuardianContent(w http.ResponseWriter, r *http.Request,
 		Items:      items,
 		AccessType: string(guardian.AccessType),
 		AccessedAt: time.Now(),
+		Search:     search.info(total),
+		Memorial:   h.memorialStatusFor(guardian),
+		Pets:       pets,
+	}
+	if guardian.AccessType == storage.GuardianAccessMemorial ||
+		(response.Memorial != nil && response.Memorial.Status == string(storage.MemorialUnlocked)) {
+		response.DigitalLegacy = digitalLegacy(sharedItems)
 	}
 
 	// Log de acesso
@@ -769,29 +1281,6 @@ type shareLinkPolicy struct {
 	maxMaxUses         int
 }
 
-func getShareLinkPolicy() shareLinkPolicy {
-	env := strings.ToLower(strings.TrimSpace(os.Getenv("ENV")))
-	return shareLinkPolicy{
-		enforce:            env == "production",
-		defaultExpiresDays: envInt("SHARE_LINK_DEFAULT_EXPIRES_DAYS", 30),
-		maxExpiresDays:     envInt("SHARE_LINK_MAX_EXPIRES_DAYS", 365),
-		defaultMaxUses:     envInt("SHARE_LINK_DEFAULT_MAX_USES", 50),
-		maxMaxUses:         envInt("SHARE_LINK_MAX_USES", 200),
-	}
-}
-
-func envInt(key string, fallback int) int {
-	raw := strings.TrimSpace(os.Getenv(key))
-	if raw == "" {
-		return fallback
-	}
-	val, err := strconv.Atoi(raw)
-	if err != nil {
-		return fallback
-	}
-	return val
-}
-
 func normalizeExpiresIn(value int, policy shareLinkPolicy) int {
 	if value < 0 {
 		value = 0
@@ -839,13 +1328,31 @@ func effectiveShareMaxUses(link *storage.ShareLink, policy shareLinkPolicy) int
 	return policy.defaultMaxUses
 }
 
-// getBaseURL retorna a URL base da aplicação
-func getBaseURL(r *http.Request) string {
-	scheme := "https"
-	if r.TLS == nil && r.Header.Get("X-Forwarded-Proto") != "https" {
-		scheme = "http"
+// LinkPath retorna o caminho das páginas de link compartilhado no idioma
+// (o frontend aceita os dois: /compartilhado/{token} e /shared/{token})
+func LinkPath(locale string) string {
+	if i18n.Resolve(locale, "pt-BR", "en") == "en" {
+		return "/shared"
 	}
-	return scheme + "://" + r.Host
+	return "/compartilhado"
+}
+
+// linkURL monta a URL pública de um link compartilhado
+func (h *Handler) linkURL(token, locale string) string {
+	if h.urlPrefix != "" {
+		return h.urlPrefix + "/" + token
+	}
+	return h.appURL + LinkPath(locale) + "/" + token
+}
+
+// ownerLocale retorna o idioma do dono dos links (salvo na conta ou, sem
+// ele, o da requisição)
+func (h *Handler) ownerLocale(r *http.Request, userID string) string {
+	var saved string
+	if user, ok := h.store.GetUserByID(userID); ok {
+		saved = user.Locale
+	}
+	return i18n.UserLocale(saved, r)
 }
 
 // contains verifica se um slice contém um valor
@@ -866,8 +1373,3 @@ func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 		json.NewEncoder(w).Encode(data)
 	}
 }
-
-// writeError escreve uma resposta de erro
-func writeError(w http.ResponseWriter, status int, message string) {
-	writeJSON(w, status, map[string]string{"error": message})
-}
@@ -0,0 +1,643 @@
+// =============================================================================
+// FAMLI - Abertura do Memorial
+// =============================================================================
+// Liberar os links memorial não depende de uma só pessoa. O dono escolhe as
+// pessoas de confiança que podem pedir a abertura (M) e quantas precisam
+// confirmar (N):
+//
+// - GET    /api/memorial       - configuração, pessoas escolhidas e último pedido
+// - PUT    /api/memorial       - define quem libera e o prazo de espera
+// - DELETE /api/memorial       - remove (os links memorial voltam a abrir sempre)
+// - POST   /api/memorial/abort - cancela o pedido (ou fecha de novo os links)
+//
+// Cada pessoa escolhida pede ou confirma pelo próprio link de acesso (token +
+// PIN): POST /api/guardian-access/{token}/memorial.
+//
+// Fluxo: pending (juntando confirmações, até 30 dias) → waiting (N
+// confirmações; o dono ainda pode cancelar durante delay_hours) → unlocked.
+// O dono (central de notificações) e as pessoas escolhidas (WhatsApp, SMS ou
+// email) são avisados a cada passo. Com a configuração, os links memorial
+// respondem 403 (share.memorial_locked) até a liberação; sem ela, nada muda.
+// =============================================================================
+
+package share
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/i18n"
+	"famli/internal/ids"
+	"famli/internal/notifications"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+const (
+	// maxMemorialGuardians limita as pessoas que podem liberar o memorial
+	maxMemorialGuardians = 10
+
+	// defaultMemorialDelayHours é a espera quando o dono não informa
+	defaultMemorialDelayHours = 72
+
+	// maxMemorialDelayHours limita a espera (30 dias)
+	maxMemorialDelayHours = 30 * 24
+
+	// memorialRequestTTL é o prazo para juntar as confirmações
+	memorialRequestTTL = 30 * 24 * time.Hour
+
+	// memorialPollInterval é o intervalo do worker de liberação
+	memorialPollInterval = time.Minute
+
+	// memorialBatchSize é o máximo de pedidos processados por rodada
+	memorialBatchSize = 100
+)
+
+// memorialConfigPayload é o corpo de PUT /api/memorial
+type memorialConfigPayload struct {
+	GuardianIDs           []string `json:"guardian_ids"`
+	RequiredConfirmations int      `json:"required_confirmations"` // 0 = 2 (ou 1 com uma pessoa)
+	DelayHours            *int     `json:"delay_hours"`            // null = 72 horas
+}
+
+// memorialGuardian é uma pessoa escolhida na resposta
+type memorialGuardian struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// memorialResponse é a resposta de GET e PUT /api/memorial
+type memorialResponse struct {
+	Config     *storage.MemorialConfig     `json:"config"`               // null sem configuração
+	Guardians  []memorialGuardian          `json:"guardians"`            // Pessoas escolhidas
+	Activation *storage.MemorialActivation `json:"activation,omitempty"` // Último pedido
+	Locked     bool                        `json:"locked"`               // Links memorial fechados agora
+}
+
+// MemorialStatus é o andamento do pedido visto por uma pessoa escolhida
+type MemorialStatus struct {
+	Status                string     `json:"status"` // none, pending, waiting, unlocked, aborted ou expired
+	Confirmations         int        `json:"confirmations"`
+	RequiredConfirmations int        `json:"required_confirmations"`
+	ConfirmedByYou        bool       `json:"confirmed_by_you"`
+	ExpiresAt             *time.Time `json:"expires_at,omitempty"` // Prazo das confirmações (pending)
+	UnlocksAt             *time.Time `json:"unlocks_at,omitempty"` // Fim da espera (waiting)
+}
+
+// =============================================================================
+// DONO
+// =============================================================================
+
+// GetMemorial retorna quem libera o memorial e o último pedido
+//
+// Endpoint: GET /api/memorial
+func (h *Handler) GetMemorial(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+	names := memorialGuardianNames(h.store.ListGuardians(userID))
+
+	response := memorialResponse{Guardians: []memorialGuardian{}}
+	config, err := h.store.GetMemorialConfig(userID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		apierror.Write(w, r, http.StatusInternalServerError, "memorial.load_error")
+		return
+	}
+	if config != nil {
+		// Guardiões removidos depois de salvar a configuração saem da resposta
+		kept := make([]string, 0, len(config.GuardianIDs))
+		for _, id := range config.GuardianIDs {
+			if name, ok := names[id]; ok {
+				kept = append(kept, id)
+				response.Guardians = append(response.Guardians, memorialGuardian{ID: id, Name: name})
+			}
+		}
+		config.GuardianIDs = kept
+		response.Config = config
+	}
+
+	activation, err := h.store.GetLatestMemorialActivation(userID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		apierror.Write(w, r, http.StatusInternalServerError, "memorial.load_error")
+		return
+	}
+	if activation != nil {
+		for i := range activation.Confirmations {
+			activation.Confirmations[i].GuardianName = names[activation.Confirmations[i].GuardianID]
+		}
+		response.Activation = activation
+	}
+	response.Locked = config != nil && (activation == nil || !activation.Unlocked(time.Now()))
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// SaveMemorial define quem libera o memorial
+//
+// Endpoint: PUT /api/memorial
+//
+// Body: {"guardian_ids": ["grd_...", "grd_...", "grd_..."],
+// "required_confirmations": 2, "delay_hours": 72}
+//
+// Um pedido em andamento continua com a configuração de quando começou. Os
+// links memorial ficam fechados até uma liberação.
+func (h *Handler) SaveMemorial(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	var payload memorialConfigPayload
+	if err := security.DecodeJSONStrict(r.Body, &payload); err != nil {
+		apierror.WriteDecodeError(w, r, err, "memorial.invalid")
+		return
+	}
+
+	names := memorialGuardianNames(h.store.ListGuardians(userID))
+	if len(payload.GuardianIDs) == 0 || len(payload.GuardianIDs) > maxMemorialGuardians {
+		apierror.Write(w, r, http.StatusBadRequest, "memorial.invalid_guardians")
+		return
+	}
+	seen := make(map[string]bool, len(payload.GuardianIDs))
+	for _, id := range payload.GuardianIDs {
+		if _, ok := names[id]; !ok || seen[id] {
+			apierror.Write(w, r, http.StatusBadRequest, "memorial.invalid_guardians")
+			return
+		}
+		seen[id] = true
+	}
+
+	required := payload.RequiredConfirmations
+	if required == 0 {
+		required = min(2, len(payload.GuardianIDs))
+	}
+	if required < 1 || required > len(payload.GuardianIDs) {
+		apierror.Write(w, r, http.StatusBadRequest, "memorial.invalid_confirmations")
+		return
+	}
+
+	delay := defaultMemorialDelayHours
+	if payload.DelayHours != nil {
+		delay = *payload.DelayHours
+	}
+	if delay < 0 || delay > maxMemorialDelayHours {
+		apierror.Write(w, r, http.StatusBadRequest, "memorial.invalid_delay")
+		return
+	}
+
+	config := &storage.MemorialConfig{
+		UserID:                userID,
+		GuardianIDs:           payload.GuardianIDs,
+		RequiredConfirmations: required,
+		DelayHours:            delay,
+	}
+	if err := h.store.SaveMemorialConfig(config); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "memorial.save_error")
+		return
+	}
+
+	h.logMemorialChange(r, "update", map[string]interface{}{
+		"guardians":              len(config.GuardianIDs),
+		"required_confirmations": config.RequiredConfirmations,
+		"delay_hours":            config.DelayHours,
+	})
+
+	response := memorialResponse{Config: config, Guardians: make([]memorialGuardian, 0, len(config.GuardianIDs))}
+	for _, id := range config.GuardianIDs {
+		response.Guardians = append(response.Guardians, memorialGuardian{ID: id, Name: names[id]})
+	}
+	activation, err := h.store.GetLatestMemorialActivation(userID)
+	if err == nil {
+		response.Activation = activation
+	}
+	response.Locked = activation == nil || !activation.Unlocked(time.Now())
+	writeJSON(w, http.StatusOK, response)
+}
+
+// DeleteMemorial remove a configuração: os links memorial voltam a abrir
+// sem confirmações. Um pedido em andamento é cancelado.
+//
+// Endpoint: DELETE /api/memorial
+func (h *Handler) DeleteMemorial(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+	if err := h.store.DeleteMemorialConfig(userID); err != nil {
+		apierror.WriteStorage(w, r, err, apierror.StorageKeys{NotFound: "memorial.not_found", Internal: "memorial.save_error"})
+		return
+	}
+
+	if activation, err := h.store.GetLatestMemorialActivation(userID); err == nil &&
+		(activation.Status == storage.MemorialPending || activation.Status == storage.MemorialWaiting) {
+		h.abortMemorial(activation)
+	}
+
+	h.logMemorialChange(r, "delete", nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AbortMemorial cancela o pedido de abertura (ex: pedido por engano). Um
+// memorial já liberado volta a ficar fechado.
+//
+// Endpoint: POST /api/memorial/abort
+//
+// 404 (memorial.not_active) sem pedido pendente, em espera ou liberado.
+func (h *Handler) AbortMemorial(w http.ResponseWriter, r *http.Request) {
+	activation, err := h.store.GetLatestMemorialActivation(auth.GetUserID(r))
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		apierror.Write(w, r, http.StatusInternalServerError, "memorial.save_error")
+		return
+	}
+	if activation == nil || !h.abortMemorial(activation) {
+		apierror.Write(w, r, http.StatusNotFound, "memorial.not_active")
+		return
+	}
+
+	h.logMemorialChange(r, "abort", map[string]interface{}{"activation_id": activation.ID})
+	writeJSON(w, http.StatusOK, activation)
+}
+
+// abortMemorial cancela o pedido e avisa as pessoas escolhidas (false se o
+// pedido já estava encerrado)
+func (h *Handler) abortMemorial(activation *storage.MemorialActivation) bool {
+	from := activation.Status
+	if from != storage.MemorialPending && from != storage.MemorialWaiting && from != storage.MemorialUnlocked {
+		return false
+	}
+
+	now := time.Now()
+	activation.Status = storage.MemorialAborted
+	activation.AbortedAt = &now
+	if err := h.store.UpdateMemorialActivation(activation, from); err != nil {
+		log.Printf("[Share] Erro ao cancelar a abertura do memorial %s: %v", activation.ID, err)
+		return false
+	}
+	h.alertMemorialGuardians(activation, "guardian.memorial_aborted_message", "")
+	return true
+}
+
+// logMemorialChange registra a alteração feita pelo dono na auditoria
+func (h *Handler) logMemorialChange(r *http.Request, action string, details map[string]interface{}) {
+	h.auditLogger.Log(security.AuditEvent{
+		Type:     security.EventMemorialConfigChanged,
+		Severity: security.SeverityInfo,
+		UserID:   auth.GetUserID(r),
+		ClientIP: security.GetClientIP(r),
+		Resource: "memorial",
+		Action:   action,
+		Result:   "success",
+		Details:  details,
+	})
+}
+
+// =============================================================================
+// PESSOAS ESCOLHIDAS
+// =============================================================================
+
+// ConfirmMemorial pede a abertura do memorial ou confirma o pedido em
+// andamento (com o PIN do link de acesso)
+//
+// Endpoint: POST /api/guardian-access/{token}/memorial
+//
+// Body: {"pin": "1234"}
+//
+// Repetir não conta de novo. As regras de acesso do dono (prazo, horário,
+// limite) não barram o pedido. 403 (memorial.not_designated) para quem não
+// foi escolhido.
+func (h *Handler) ConfirmMemorial(w http.ResponseWriter, r *http.Request) {
+	var req VerifyPINRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "share.invalid_data")
+		return
+	}
+
+	guardian, ok := h.authenticateGuardian(w, r, chi.URLParam(r, "token"), req.PIN, false)
+	if !ok {
+		return
+	}
+
+	config, err := h.store.GetMemorialConfig(guardian.UserID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		apierror.Write(w, r, http.StatusInternalServerError, "memorial.save_error")
+		return
+	}
+	if config == nil || !contains(config.GuardianIDs, guardian.ID) {
+		apierror.Write(w, r, http.StatusForbidden, "memorial.not_designated")
+		return
+	}
+
+	activation, err := h.confirmMemorial(r, config, guardian)
+	if err != nil {
+		log.Printf("[Share] Erro na confirmação do memorial pelo guardião %s: %v", guardian.ID, err)
+		apierror.Write(w, r, http.StatusInternalServerError, "memorial.save_error")
+		return
+	}
+	writeJSON(w, http.StatusOK, newMemorialStatus(activation, guardian.ID))
+}
+
+// confirmMemorial abre o pedido (se não houver um em andamento) ou soma a
+// confirmação; com N confirmações, começa a espera
+func (h *Handler) confirmMemorial(r *http.Request, config *storage.MemorialConfig, guardian *storage.Guardian) (*storage.MemorialActivation, error) {
+	activation, err := h.store.GetLatestMemorialActivation(guardian.UserID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return nil, err
+	}
+
+	now := time.Now()
+	if activation == nil || activation.Status == storage.MemorialAborted || activation.Status == storage.MemorialExpired {
+		activation = &storage.MemorialActivation{
+			ID:                    ids.New(ids.Memorial),
+			UserID:                guardian.UserID,
+			Status:                storage.MemorialPending,
+			GuardianIDs:           config.GuardianIDs,
+			RequiredConfirmations: config.RequiredConfirmations,
+			DelayHours:            config.DelayHours,
+			Confirmations:         []storage.MemorialConfirmation{{GuardianID: guardian.ID, ConfirmedAt: now}},
+			ExpiresAt:             now.Add(memorialRequestTTL),
+		}
+		err := h.store.CreateMemorialActivation(activation)
+		switch {
+		case err == nil:
+			h.logMemorialActivation(r, activation, guardian.ID, "request")
+			notifications.Notify(guardian.UserID, storage.NotificationEmergency, "notify.memorial_requested")
+			h.alertMemorialGuardians(activation, "guardian.memorial_requested_message", guardian.ID)
+		case errors.Is(err, storage.ErrAlreadyExists):
+			// Outra pessoa abriu o pedido ao mesmo tempo: confirmar nele
+			if activation, err = h.store.GetLatestMemorialActivation(guardian.UserID); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, err
+		}
+	}
+
+	if activation.Status == storage.MemorialPending && !activation.ConfirmedBy(guardian.ID) {
+		confirmed, err := h.store.AddMemorialConfirmation(activation.ID, storage.MemorialConfirmation{GuardianID: guardian.ID, ConfirmedAt: now})
+		switch {
+		case err == nil:
+			activation = confirmed
+			h.logMemorialActivation(r, activation, guardian.ID, "confirm")
+			notifications.Notify(guardian.UserID, storage.NotificationEmergency, "notify.memorial_confirmed")
+		case errors.Is(err, storage.ErrVersionConflict):
+			// O pedido andou (cancelado, expirado ou já confirmado)
+			return h.store.GetLatestMemorialActivation(guardian.UserID)
+		default:
+			return nil, err
+		}
+	}
+
+	if activation.Status == storage.MemorialPending && len(activation.Confirmations) >= activation.RequiredConfirmations {
+		return h.startMemorialDelay(activation)
+	}
+	return activation, nil
+}
+
+// startMemorialDelay passa o pedido confirmado para a espera (ou libera na
+// hora, sem prazo de espera)
+func (h *Handler) startMemorialDelay(activation *storage.MemorialActivation) (*storage.MemorialActivation, error) {
+	now := time.Now()
+	if activation.DelayHours == 0 {
+		activation.Status = storage.MemorialUnlocked
+		activation.UnlockedAt = &now
+	} else {
+		unlocksAt := now.Add(time.Duration(activation.DelayHours) * time.Hour)
+		activation.Status = storage.MemorialWaiting
+		activation.UnlocksAt = &unlocksAt
+	}
+
+	err := h.store.UpdateMemorialActivation(activation, storage.MemorialPending)
+	if errors.Is(err, storage.ErrVersionConflict) {
+		// Outra confirmação já começou a espera
+		return h.store.GetLatestMemorialActivation(activation.UserID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if activation.Status == storage.MemorialUnlocked {
+		h.announceMemorialUnlocked(activation)
+	} else {
+		notifications.Notify(activation.UserID, storage.NotificationEmergency, "notify.memorial_waiting")
+		h.alertMemorialGuardians(activation, "guardian.memorial_waiting_message", "")
+	}
+	return activation, nil
+}
+
+// memorialStatusFor retorna o andamento para uma pessoa escolhida (nil para
+// as demais, ou sem configuração)
+func (h *Handler) memorialStatusFor(guardian *storage.Guardian) *MemorialStatus {
+	config, err := h.store.GetMemorialConfig(guardian.UserID)
+	if err != nil || !contains(config.GuardianIDs, guardian.ID) {
+		return nil
+	}
+	activation, err := h.store.GetLatestMemorialActivation(guardian.UserID)
+	if err != nil {
+		return &MemorialStatus{Status: "none", RequiredConfirmations: config.RequiredConfirmations}
+	}
+	return newMemorialStatus(activation, guardian.ID)
+}
+
+// newMemorialStatus resume o pedido para a pessoa escolhida
+func newMemorialStatus(activation *storage.MemorialActivation, guardianID string) *MemorialStatus {
+	status := &MemorialStatus{
+		Status:                string(activation.Status),
+		Confirmations:         len(activation.Confirmations),
+		RequiredConfirmations: activation.RequiredConfirmations,
+		ConfirmedByYou:        activation.ConfirmedBy(guardianID),
+		UnlocksAt:             activation.UnlocksAt,
+	}
+	if activation.Unlocked(time.Now()) {
+		status.Status = string(storage.MemorialUnlocked)
+	}
+	if activation.Status == storage.MemorialPending {
+		expiresAt := activation.ExpiresAt
+		status.ExpiresAt = &expiresAt
+	}
+	return status
+}
+
+// logMemorialActivation registra o pedido ou a confirmação na auditoria
+func (h *Handler) logMemorialActivation(r *http.Request, activation *storage.MemorialActivation, guardianID, action string) {
+	h.auditLogger.Log(security.AuditEvent{
+		Type:     security.EventMemorialActivation,
+		Severity: security.SeverityWarning,
+		UserID:   activation.UserID,
+		ClientIP: security.GetClientIP(r),
+		Resource: "memorial:" + activation.ID,
+		Action:   action,
+		Result:   "success",
+		Details: map[string]interface{}{
+			"guardian_id":   guardianID,
+			"confirmations": len(activation.Confirmations),
+			"required":      activation.RequiredConfirmations,
+		},
+	})
+}
+
+// =============================================================================
+// LINKS MEMORIAL
+// =============================================================================
+
+// digitalLegacy agrupa as contas online dos itens pelo que fazer com elas,
+// na ordem de storage.OnlineAccountActions (grupos vazios ficam de fora)
+func digitalLegacy(items []*storage.BoxItem) []*storage.DigitalLegacyGroup {
+	byAction := make(map[storage.OnlineAccountAction][]*storage.SharedOnlineAccount)
+	for _, item := range items {
+		if item.OnlineAccount == nil {
+			continue
+		}
+		byAction[item.OnlineAccount.Action] = append(byAction[item.OnlineAccount.Action], &storage.SharedOnlineAccount{
+			ItemID:        item.ID,
+			Title:         item.Title,
+			Content:       item.Content,
+			OnlineAccount: item.OnlineAccount,
+		})
+	}
+
+	var groups []*storage.DigitalLegacyGroup
+	for _, action := range storage.OnlineAccountActions {
+		if accounts := byAction[action]; len(accounts) > 0 {
+			groups = append(groups, &storage.DigitalLegacyGroup{Action: action, Accounts: accounts})
+		}
+	}
+	return groups
+}
+
+// rejectLockedMemorial responde 403 (share.memorial_locked) se o link é
+// memorial e o dono exige confirmações que ainda não liberaram a abertura
+func (h *Handler) rejectLockedMemorial(w http.ResponseWriter, r *http.Request, link *storage.ShareLink) bool {
+	if link.Type != storage.ShareLinkMemorial {
+		return false
+	}
+
+	_, err := h.store.GetMemorialConfig(link.UserID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return false
+	}
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "share.access_error")
+		return true
+	}
+
+	activation, err := h.store.GetLatestMemorialActivation(link.UserID)
+	if err == nil && activation.Unlocked(time.Now()) {
+		return false
+	}
+	apierror.Write(w, r, http.StatusForbidden, "share.memorial_locked")
+	return true
+}
+
+// =============================================================================
+// WORKER
+// =============================================================================
+
+// StartMemorialActivations inicia o worker que libera os memoriais depois da
+// espera e encerra os pedidos sem confirmações no prazo (encerra quando ctx
+// é cancelado)
+func (h *Handler) StartMemorialActivations(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(memorialPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				h.processDueMemorialActivations()
+			}
+		}
+	}()
+}
+
+// processDueMemorialActivations libera ou encerra os pedidos vencidos
+func (h *Handler) processDueMemorialActivations() {
+	due, err := h.store.ListDueMemorialActivations(time.Now(), memorialBatchSize)
+	if err != nil {
+		log.Printf("[Share] Erro ao listar pedidos de abertura do memorial: %v", err)
+		return
+	}
+
+	for _, activation := range due {
+		from := activation.Status
+		now := time.Now()
+		action := "unlock"
+		if from == storage.MemorialWaiting {
+			activation.Status = storage.MemorialUnlocked
+			activation.UnlockedAt = &now
+		} else {
+			activation.Status = storage.MemorialExpired
+			action = "expire"
+		}
+
+		// Conflito: o dono cancelou enquanto o worker processava
+		if err := h.store.UpdateMemorialActivation(activation, from); err != nil {
+			if !errors.Is(err, storage.ErrVersionConflict) {
+				log.Printf("[Share] Erro ao atualizar a abertura do memorial %s: %v", activation.ID, err)
+			}
+			continue
+		}
+
+		h.auditLogger.Log(security.AuditEvent{
+			Type:     security.EventMemorialActivation,
+			Severity: security.SeverityWarning,
+			UserID:   activation.UserID,
+			Resource: "memorial:" + activation.ID,
+			Action:   action,
+			Result:   "success",
+		})
+		if activation.Status == storage.MemorialUnlocked {
+			h.announceMemorialUnlocked(activation)
+		} else {
+			notifications.Notify(activation.UserID, storage.NotificationEmergency, "notify.memorial_expired")
+			h.alertMemorialGuardians(activation, "guardian.memorial_expired_message", "")
+		}
+	}
+}
+
+// =============================================================================
+// AVISOS
+// =============================================================================
+
+// announceMemorialUnlocked avisa o dono e as pessoas escolhidas da liberação
+func (h *Handler) announceMemorialUnlocked(activation *storage.MemorialActivation) {
+	notifications.Notify(activation.UserID, storage.NotificationEmergency, "notify.memorial_unlocked")
+	h.alertMemorialGuardians(activation, "guardian.memorial_unlocked_message", "")
+}
+
+// alertMemorialGuardians envia a mensagem key às pessoas escolhidas do pedido
+// (menos exceptID e quem teve o acesso desativado), no idioma do dono
+func (h *Handler) alertMemorialGuardians(activation *storage.MemorialActivation, key, exceptID string) {
+	if h.messageGuardian == nil {
+		return
+	}
+	owner, ok := h.store.GetUserByID(activation.UserID)
+	if !ok {
+		return
+	}
+	locale := h.ownerLocale(nil, owner.ID)
+
+	for _, guardian := range h.store.ListGuardians(owner.ID) {
+		if guardian.ID == exceptID || guardian.AccessDisabledAt != nil || !contains(activation.GuardianIDs, guardian.ID) {
+			continue
+		}
+		message := i18n.Format(locale, key, i18n.Vars{
+			"owner":    owner.Name,
+			"link":     h.appURL + "/g/" + guardian.AccessToken,
+			"required": activation.RequiredConfirmations,
+			"hours":    activation.DelayHours,
+		})
+		if err := h.messageGuardian(owner.ID, guardian, storage.GuardianAlertMemorial, message); err != nil {
+			log.Printf("[Share] Erro ao avisar o guardião %s sobre o memorial: %v", guardian.ID, err)
+		}
+	}
+}
+
+// memorialGuardianNames indexa os nomes dos guardiões por ID
+func memorialGuardianNames(guardians []*storage.Guardian) map[string]string {
+	names := make(map[string]string, len(guardians))
+	for _, guardian := range guardians {
+		names[guardian.ID] = guardian.Name
+	}
+	return names
+}
@@ -0,0 +1,178 @@
+// =============================================================================
+// FAMLI - Proteção contra Força Bruta no PIN
+// =============================================================================
+// Os links com PIN (compartilhamento e guardião) são públicos: quem tem o token
+// pode tentar PINs. O rate limit por IP não basta contra tentativas
+// distribuídas, então as falhas são contadas por link (pin_attempts):
+//
+// - 3 falhas: bloqueio de 30s, dobrando a cada nova falha (máx. 1 hora)
+// - 10 falhas: o link é desativado e o dono é avisado
+//
+// Links de compartilhamento são desativados (is_active = false); links de
+// guardião recebem um novo token (o dono reenvia o link atualizado).
+// Um PIN correto zera o contador.
+// =============================================================================
+
+package share
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"famli/internal/apierror"
+	"famli/internal/notifications"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+const (
+	// pinFreeAttempts é o número de falhas antes do primeiro bloqueio
+	pinFreeAttempts = 3
+
+	// pinBaseLockout é o bloqueio após pinFreeAttempts falhas (dobra a cada falha)
+	pinBaseLockout = 30 * time.Second
+
+	// pinMaxLockout limita o bloqueio exponencial
+	pinMaxLockout = time.Hour
+
+	// pinMaxFailures desativa o link e avisa o dono
+	pinMaxFailures = 10
+)
+
+// pinTarget é um link protegido por PIN
+type pinTarget struct {
+	key        string       // Chave em pin_attempts
+	ownerID    string       // Dono do link (recebe o aviso)
+	resource   string       // Recurso para auditoria
+	deactivate func() error // Desativa o link após pinMaxFailures
+}
+
+// shareLinkPINTarget protege um link de compartilhamento
+func (h *Handler) shareLinkPINTarget(link *storage.ShareLink) pinTarget {
+	return pinTarget{
+		key:      "share:" + link.ID,
+		ownerID:  link.UserID,
+		resource: "share_link:" + link.ID,
+		deactivate: func() error {
+			link.IsActive = false
+			return h.store.UpdateShareLink(link)
+		},
+	}
+}
+
+// guardianPINTarget protege o link de um guardião
+func (h *Handler) guardianPINTarget(guardian *storage.Guardian) pinTarget {
+	return pinTarget{
+		key:      "guardian:" + guardian.ID,
+		ownerID:  guardian.UserID,
+		resource: "guardian:" + guardian.ID,
+		deactivate: func() error {
+			_, err := h.store.RotateGuardianAccessToken(guardian.ID)
+			return err
+		},
+	}
+}
+
+// checkPINLockout responde 429 se o link estiver bloqueado
+// Deve ser chamado antes de comparar o PIN.
+func (h *Handler) checkPINLockout(w http.ResponseWriter, r *http.Request, target pinTarget) bool {
+	attempt, err := h.store.GetPINAttempt(target.key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return true
+	}
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "share.access_error")
+		return false
+	}
+
+	retryAfter := time.Until(attempt.LastFailedAt.Add(pinLockoutDuration(attempt.FailedAttempts)))
+	if retryAfter <= 0 {
+		return true
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	apierror.Write(w, r, http.StatusTooManyRequests, "share.pin_locked")
+	return false
+}
+
+// handlePINFailure registra a falha e responde ao cliente
+// Ao atingir pinMaxFailures, desativa o link e avisa o dono.
+func (h *Handler) handlePINFailure(w http.ResponseWriter, r *http.Request, target pinTarget) {
+	clientIP := security.GetClientIP(r)
+
+	failures := 0
+	attempt, err := h.store.RecordPINFailure(target.key)
+	if err != nil {
+		log.Printf("[SHARE] Erro ao registrar falha de PIN: %v", err)
+	} else {
+		failures = attempt.FailedAttempts
+	}
+
+	h.auditLogger.Log(security.AuditEvent{
+		Type:     security.EventPINFailed,
+		Severity: security.SeverityWarning,
+		UserID:   target.ownerID,
+		ClientIP: clientIP,
+		Resource: target.resource,
+		Action:   "verify_pin",
+		Result:   "failure",
+		Details:  map[string]interface{}{"failed_attempts": failures},
+	})
+
+	if failures >= pinMaxFailures {
+		h.deactivatePINTarget(target, clientIP, failures)
+		apierror.Write(w, r, http.StatusGone, "share.deactivated")
+		return
+	}
+
+	apierror.Write(w, r, http.StatusUnauthorized, "share.invalid_pin")
+}
+
+// deactivatePINTarget desativa o link e avisa o dono
+func (h *Handler) deactivatePINTarget(target pinTarget, clientIP string, failures int) {
+	result := "success"
+	if err := target.deactivate(); err != nil {
+		log.Printf("[SHARE] Erro ao desativar link após falhas de PIN: %v", err)
+		result = "failure"
+	} else {
+		// O novo link (ou o link reativado) começa sem falhas
+		h.resetPINAttempts(target)
+	}
+
+	h.auditLogger.Log(security.AuditEvent{
+		Type:     security.EventAccessLinkDeactivated,
+		Severity: security.SeverityCritical,
+		UserID:   target.ownerID,
+		ClientIP: clientIP,
+		Resource: target.resource,
+		Action:   "deactivate",
+		Result:   result,
+		Details:  map[string]interface{}{"failed_attempts": failures},
+	})
+
+	notifications.Notify(target.ownerID, storage.NotificationGuardianAccess, "notify.pin_lockout")
+}
+
+// resetPINAttempts zera o contador após um PIN correto
+func (h *Handler) resetPINAttempts(target pinTarget) {
+	if err := h.store.ResetPINAttempts(target.key); err != nil {
+		log.Printf("[SHARE] Erro ao zerar falhas de PIN: %v", err)
+	}
+}
+
+// pinLockoutDuration calcula o bloqueio após falhas consecutivas
+// 3 falhas: 30s, 4: 1 min, 5: 2 min... até pinMaxLockout
+func pinLockoutDuration(failures int) time.Duration {
+	if failures < pinFreeAttempts {
+		return 0
+	}
+	lockout := pinBaseLockout << uint(failures-pinFreeAttempts)
+	if lockout <= 0 || lockout > pinMaxLockout {
+		return pinMaxLockout
+	}
+	return lockout
+}
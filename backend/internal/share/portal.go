@@ -0,0 +1,308 @@
+// =============================================================================
+// FAMLI - Portal do Guardião
+// =============================================================================
+// Guardiões frequentes podem usar uma conta Famli (cadastro/login normal por
+// email) em vez de abrir cada link. A conta é vinculada a um guardião com o
+// token e o PIN do link de acesso, provando que a pessoa recebeu o convite.
+// Uma mesma conta pode ser guardiã de vários donos.
+//
+// Endpoints (usuário autenticado):
+// - POST   /api/guardian/link                - vincula a conta ({token, pin})
+// - GET    /api/guardian/dashboard           - caixas compartilhadas comigo
+// - GET    /api/guardian/boxes/{guardianID}  - itens compartilhados de um dono
+// - DELETE /api/guardian/boxes/{guardianID}  - desvincula a conta
+//
+// Disponibilidade: guardiões "normal" veem os itens sempre; "emergency" e
+// "memorial" apenas com o protocolo de emergência do dono ativo. Guardiões
+// com o acesso desativado pelo dono somem do painel.
+// =============================================================================
+
+package share
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"golang.org/x/crypto/bcrypt"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/i18n"
+	"famli/internal/notifications"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+// LinkGuardianRequest é o corpo de POST /api/guardian/link
+type LinkGuardianRequest struct {
+	Token string `json:"token"`
+	PIN   string `json:"pin"`
+}
+
+// GuardianBox é uma caixa compartilhada no painel do guardião
+type GuardianBox struct {
+	GuardianID   string           `json:"guardian_id"`
+	Owner        *OwnerInfo       `json:"owner"`
+	Relationship string           `json:"relationship,omitempty"`
+	AccessType   string           `json:"access_type"`
+	Available    bool             `json:"available"`    // Itens podem ser abertos agora
+	SharedItems  int              `json:"shared_items"` // Quantidade de itens compartilhados comigo
+	Emergency    *EmergencyStatus `json:"emergency"`
+}
+
+// EmergencyStatus é o estado do protocolo de emergência do dono
+type EmergencyStatus struct {
+	ProtocolEnabled bool       `json:"protocol_enabled"` // Dono habilitou o protocolo
+	Active          bool       `json:"active"`
+	ActivatedAt     *time.Time `json:"activated_at,omitempty"`
+}
+
+// LinkGuardianAccount vincula a conta autenticada a um guardião
+//
+// Endpoint: POST /api/guardian/link
+//
+// Segurança:
+// - Exige o token e o PIN do link do guardião (rate limit e bloqueio por PIN)
+// - Um guardião só pode estar vinculado a uma conta
+func (h *Handler) LinkGuardianAccount(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+	clientIP := security.GetClientIP(r)
+
+	var req LinkGuardianRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		apierror.Write(w, r, http.StatusBadRequest, "share.invalid_data")
+		return
+	}
+
+	guardian, err := h.store.GetGuardianByAccessToken(req.Token)
+	if err != nil {
+		writeLinkUnavailable(w, r)
+		return
+	}
+	if h.rejectDisabledGuardian(w, r, guardian) {
+		return
+	}
+	if guardian.AccessPIN == "" {
+		writeLinkUnavailable(w, r)
+		return
+	}
+	target := h.guardianPINTarget(guardian)
+	if !h.checkPINLockout(w, r, target) {
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(guardian.AccessPIN), []byte(req.PIN)); err != nil {
+		h.handlePINFailure(w, r, target)
+		return
+	}
+	h.resetPINAttempts(target)
+
+	if guardian.UserID == userID {
+		apierror.Write(w, r, http.StatusBadRequest, "guardian_portal.self_link")
+		return
+	}
+	if guardian.AccountUserID != "" && guardian.AccountUserID != userID {
+		apierror.Write(w, r, http.StatusConflict, "guardian_portal.already_linked")
+		return
+	}
+
+	if guardian.AccountUserID == "" {
+		if err := h.store.LinkGuardianAccount(guardian.ID, userID); err != nil {
+			apierror.Write(w, r, http.StatusInternalServerError, "guardian_portal.link_error")
+			return
+		}
+
+		h.auditLogger.Log(security.AuditEvent{
+			Type:     security.EventGuardianAccountLinked,
+			Severity: security.SeverityInfo,
+			UserID:   userID,
+			ClientIP: clientIP,
+			Resource: "guardian:" + guardian.ID,
+			Action:   "link",
+			Result:   "success",
+			Details:  map[string]interface{}{"owner_id": guardian.UserID},
+		})
+		notifications.Notify(guardian.UserID, storage.NotificationGuardianAccess, "notify.guardian_linked")
+	}
+
+	owner, found := h.store.GetUserByID(guardian.UserID)
+	if !found {
+		writeLinkUnavailable(w, r)
+		return
+	}
+	guardian.AccountUserID = userID
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"box": h.buildGuardianBox(guardian, owner),
+	})
+}
+
+// GuardianDashboard lista as caixas compartilhadas com a conta, de todos os donos
+//
+// Endpoint: GET /api/guardian/dashboard
+//
+// Não retorna conteúdo dos itens (apenas contagem e estado de emergência);
+// o conteúdo é aberto por caixa, com aviso ao dono.
+func (h *Handler) GuardianDashboard(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	guardians, err := h.store.ListGuardiansByAccount(userID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "guardian_portal.list_error")
+		return
+	}
+
+	boxes := make([]*GuardianBox, 0, len(guardians))
+	for _, guardian := range guardians {
+		if guardian.AccessDisabledAt != nil {
+			continue
+		}
+		owner, found := h.store.GetUserByID(guardian.UserID)
+		if !found || owner.DisabledAt != nil {
+			continue
+		}
+		boxes = append(boxes, h.buildGuardianBox(guardian, owner))
+	}
+
+	h.auditLogger.LogDataAccess(userID, security.GetClientIP(r), "guardian/dashboard", "list", "success")
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"boxes": boxes,
+		"total": len(boxes),
+	})
+}
+
+// GuardianBoxContent retorna os itens compartilhados de um dono
+//
+// Endpoint: GET /api/guardian/boxes/{guardianID}
+//
+// Query params: q e category filtram os itens (ver search.go)
+//
+// O dono é avisado do acesso, como no link do guardião.
+func (h *Handler) GuardianBoxContent(w http.ResponseWriter, r *http.Request) {
+	search, ok := parseItemSearch(w, r)
+	if !ok {
+		return
+	}
+	guardian, owner, ok := h.findLinkedGuardian(w, r)
+	if !ok {
+		return
+	}
+
+	if !guardianBoxAvailable(guardian, h.emergencyProtocol(owner.ID)) {
+		apierror.Write(w, r, http.StatusForbidden, "guardian_portal.emergency_only")
+		return
+	}
+	if !h.enforceAccessPolicy(w, r, guardian) {
+		return
+	}
+
+	h.returnGuardianContent(w, r, guardian, owner, search)
+}
+
+// UnlinkGuardianAccount desvincula a conta do guardião
+// O link com token e PIN continua funcionando.
+//
+// Endpoint: DELETE /api/guardian/boxes/{guardianID}
+func (h *Handler) UnlinkGuardianAccount(w http.ResponseWriter, r *http.Request) {
+	guardian, _, ok := h.findLinkedGuardian(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.store.LinkGuardianAccount(guardian.ID, ""); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "guardian_portal.link_error")
+		return
+	}
+
+	h.auditLogger.Log(security.AuditEvent{
+		Type:     security.EventGuardianAccountUnlinked,
+		Severity: security.SeverityInfo,
+		UserID:   auth.GetUserID(r),
+		ClientIP: security.GetClientIP(r),
+		Resource: "guardian:" + guardian.ID,
+		Action:   "unlink",
+		Result:   "success",
+		Details:  map[string]interface{}{"owner_id": guardian.UserID},
+	})
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": i18n.Tr(r, "guardian_portal.unlinked")})
+}
+
+// =============================================================================
+// FUNÇÕES AUXILIARES
+// =============================================================================
+
+// findLinkedGuardian busca o guardião da URL entre os vínculos da conta
+// Guardiões de outras contas recebem 404 (não revela a existência).
+func (h *Handler) findLinkedGuardian(w http.ResponseWriter, r *http.Request) (*storage.Guardian, *storage.User, bool) {
+	guardianID := chi.URLParam(r, "guardianID")
+
+	guardians, err := h.store.ListGuardiansByAccount(auth.GetUserID(r))
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "guardian_portal.list_error")
+		return nil, nil, false
+	}
+	for _, guardian := range guardians {
+		if guardian.ID != guardianID || guardian.AccessDisabledAt != nil {
+			continue
+		}
+		owner, found := h.store.GetUserByID(guardian.UserID)
+		if !found || owner.DisabledAt != nil {
+			break
+		}
+		return guardian, owner, true
+	}
+
+	apierror.Write(w, r, http.StatusNotFound, "guardian_portal.not_found")
+	return nil, nil, false
+}
+
+// buildGuardianBox monta o resumo da caixa para o painel
+func (h *Handler) buildGuardianBox(guardian *storage.Guardian, owner *storage.User) *GuardianBox {
+	protocol := h.emergencyProtocol(owner.ID)
+	settings := h.store.GetSettings(owner.ID)
+
+	accessType := guardian.AccessType
+	if accessType == "" {
+		accessType = storage.GuardianAccessNormal
+	}
+
+	sharedItems := filterItemsByGuardians(h.store.ListSharedItems(owner.ID), []string{guardian.ID})
+
+	box := &GuardianBox{
+		GuardianID:   guardian.ID,
+		Owner:        &OwnerInfo{Name: owner.Name, Email: maskEmail(owner.Email)},
+		Relationship: guardian.Relationship,
+		AccessType:   string(accessType),
+		Available:    guardianBoxAvailable(guardian, protocol),
+		SharedItems:  len(sharedItems),
+		Emergency: &EmergencyStatus{
+			ProtocolEnabled: settings != nil && settings.EmergencyProtocolEnabled,
+		},
+	}
+	if protocol != nil {
+		box.Emergency.Active = protocol.IsActive
+		box.Emergency.ActivatedAt = protocol.ActivatedAt
+	}
+	return box
+}
+
+// emergencyProtocol retorna o protocolo de emergência do dono (nil em erro)
+func (h *Handler) emergencyProtocol(ownerID string) *storage.EmergencyProtocol {
+	protocol, err := h.store.GetEmergencyProtocol(ownerID)
+	if err != nil {
+		return nil
+	}
+	return protocol
+}
+
+// guardianBoxAvailable indica se o guardião pode abrir os itens agora
+func guardianBoxAvailable(guardian *storage.Guardian, protocol *storage.EmergencyProtocol) bool {
+	switch guardian.AccessType {
+	case storage.GuardianAccessEmergency, storage.GuardianAccessMemorial:
+		return protocol != nil && protocol.IsActive
+	default:
+		return true
+	}
+}
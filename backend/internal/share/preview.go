@@ -0,0 +1,91 @@
+package share
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"famli/internal/features"
+	"famli/internal/i18n"
+	"famli/internal/security"
+	"famli/internal/web"
+)
+
+// =============================================================================
+// PRÉVIA DOS LINKS
+// =============================================================================
+// Links enviados pelo WhatsApp ganham uma prévia montada pelas meta tags da
+// página. A prévia diz só "Maria compartilhou algo com você no Famli": nada
+// do conteúdo, do tipo do link ou dos guardiões, e apenas o primeiro nome do
+// dono. Links indisponíveis (ou acima do limite de acessos) ficam com a
+// prévia genérica, sem nome.
+
+// Preview é a prévia pública de um link compartilhado
+type Preview struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	SiteName    string `json:"site_name"`
+}
+
+// Preview retorna a prévia do link, sem dados do conteúdo
+// Não conta como acesso nem como uso do link.
+//
+// Endpoint: GET /api/shared/{token}/preview
+func (h *Handler) Preview(w http.ResponseWriter, r *http.Request) {
+	preview, ok := h.linkPreview(chi.URLParam(r, "token"), i18n.GetLocale(r))
+	if !ok {
+		writeLinkUnavailable(w, r)
+		return
+	}
+	writeJSON(w, http.StatusOK, preview)
+}
+
+// PreviewPage serve a página do link (/compartilhado/{token} e
+// /shared/{token}) com as meta tags da prévia, no lugar do index.html padrão
+func (h *Handler) PreviewPage(spa *web.SPA, limiter *security.RateLimiter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		locale := i18n.GetLocale(r)
+
+		var preview *Preview
+		if allowed, _ := limiter.Allow(security.GetClientIP(r)); allowed && features.Enabled(r.Context(), features.ShareLinks) {
+			preview, _ = h.linkPreview(chi.URLParam(r, "token"), locale)
+		}
+		if preview == nil {
+			preview = genericPreview(locale)
+		}
+
+		meta := web.Meta{Title: preview.Title, Description: preview.Description}
+		if h.appURL != "" {
+			meta.URL = h.appURL + r.URL.Path
+		}
+		spa.ServeMeta(w, r, meta)
+	}
+}
+
+// linkPreview monta a prévia de um link disponível
+func (h *Handler) linkPreview(token, locale string) (*Preview, bool) {
+	link, ok := h.availableLink(token)
+	if !ok {
+		return nil, false
+	}
+	owner, ok := h.store.GetUserByID(link.UserID)
+	if !ok {
+		return nil, false
+	}
+
+	preview := genericPreview(locale)
+	if names := strings.Fields(owner.Name); len(names) > 0 {
+		preview.Title = i18n.Format(locale, "share.preview_title", i18n.Vars{"owner": names[0]})
+	}
+	return preview, true
+}
+
+// genericPreview é a prévia sem o nome do dono
+func genericPreview(locale string) *Preview {
+	return &Preview{
+		Title:       i18n.T(locale, "share.preview_title_generic"),
+		Description: i18n.T(locale, "share.preview_description"),
+		SiteName:    "Famli",
+	}
+}
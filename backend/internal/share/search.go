@@ -0,0 +1,112 @@
+// =============================================================================
+// FAMLI - Busca nos Acessos Compartilhados
+// =============================================================================
+// Um memorial pode ter centenas de itens. Os acessos de quem recebe aceitam
+// busca no servidor pela query string:
+//
+// - q: texto procurado no título, no conteúdo, no destinatário e na
+//   categoria (sem diferenciar maiúsculas e acentos; todas as palavras
+//   precisam aparecer). Itens selados só são buscados pelo título.
+// - category: apenas itens da categoria
+//
+// Vale para GET /api/shared/{token}, POST /api/shared/{token}/verify,
+// POST /api/guardian-access/{token}/verify e GET /api/guardian/boxes/{id}.
+// A busca só restringe o que o link já mostra: o filtro é aplicado depois do
+// escopo do link (guardiões, categorias, itens escolhidos, só mensagens).
+// =============================================================================
+
+package share
+
+import (
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"famli/internal/apierror"
+	"famli/internal/storage"
+)
+
+// maxSearchLength é o tamanho máximo de q e category
+const maxSearchLength = 200
+
+// searchFolder remove acentos para comparar o texto procurado
+var searchFolder = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "ê", "e", "è", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ô", "o", "õ", "o", "ò", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// foldSearch normaliza o texto (minúsculas, sem acentos)
+func foldSearch(s string) string {
+	return searchFolder.Replace(strings.ToLower(s))
+}
+
+// itemSearch é a busca pedida na query string
+type itemSearch struct {
+	query    string
+	category string
+	terms    []string // Palavras de q, normalizadas
+}
+
+// parseItemSearch lê q e category (ok = false já respondeu 400)
+func parseItemSearch(w http.ResponseWriter, r *http.Request) (itemSearch, bool) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	category := strings.TrimSpace(r.URL.Query().Get("category"))
+	if utf8.RuneCountInString(query) > maxSearchLength || utf8.RuneCountInString(category) > maxSearchLength {
+		apierror.Write(w, r, http.StatusBadRequest, "share.invalid_search")
+		return itemSearch{}, false
+	}
+	return itemSearch{query: query, category: category, terms: strings.Fields(foldSearch(query))}, true
+}
+
+// active indica se há algum filtro
+func (s itemSearch) active() bool {
+	return len(s.terms) > 0 || s.category != ""
+}
+
+// filter retorna os itens que atendem à busca, na mesma ordem
+func (s itemSearch) filter(items []*storage.BoxItem) []*storage.BoxItem {
+	if !s.active() {
+		return items
+	}
+	filtered := make([]*storage.BoxItem, 0, len(items))
+	for _, item := range items {
+		if s.matches(item) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// matches indica se o item é da categoria e contém todas as palavras
+func (s itemSearch) matches(item *storage.BoxItem) bool {
+	if s.category != "" && !strings.EqualFold(item.Category, s.category) {
+		return false
+	}
+	if len(s.terms) == 0 {
+		return true
+	}
+
+	text := item.Title + " " + item.Recipient + " " + item.Category
+	if !item.IsSealed() {
+		text += " " + item.Content
+	}
+	text = foldSearch(text)
+	for _, term := range s.terms {
+		if !strings.Contains(text, term) {
+			return false
+		}
+	}
+	return true
+}
+
+// info resume a busca aplicada para a resposta (nil sem filtro)
+func (s itemSearch) info(total int) *storage.ItemSearch {
+	if !s.active() {
+		return nil
+	}
+	return &storage.ItemSearch{Query: s.query, Category: s.category, Total: total}
+}
@@ -0,0 +1,244 @@
+// =============================================================================
+// FAMLI - Envio Avulso de Itens
+// =============================================================================
+// Nem sempre quem precisa de uma informação é um guardião. O dono envia um
+// item por email num link de uso único, sem conta no Famli:
+//
+// - POST /api/box/items/{itemID}/send - cria o link e envia o email
+//
+// O link (tipo one_time) mostra só o item escolhido, mesmo sem is_shared,
+// expira em expires_in_hours (padrão 72, até 168) e deixa de funcionar no
+// primeiro acesso: o uso é contado antes de mostrar o conteúdo, num UPDATE
+// condicional, e um PIN errado não gasta o acesso. O PIN nunca vai no email;
+// o dono combina com quem recebe.
+//
+// O envio e o acesso ficam no log de auditoria (ITEM_SENT); o dono vê o link
+// em GET /api/share/links (com os acessos) e pode removê-lo antes do uso.
+// =============================================================================
+
+package share
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/i18n"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+const (
+	// defaultSendExpiresHours é a validade quando o dono não informa
+	defaultSendExpiresHours = 72
+
+	// maxSendExpiresHours limita a validade do envio (7 dias)
+	maxSendExpiresHours = 7 * 24
+
+	// maxSendNoteLength limita o recado do dono no email
+	maxSendNoteLength = 500
+)
+
+// SendItemRequest é o payload do envio avulso
+type SendItemRequest struct {
+	RecipientEmail string `json:"recipient_email"`
+	RecipientName  string `json:"recipient_name,omitempty"`
+	Note           string `json:"note,omitempty"`             // Recado do dono no email
+	PIN            string `json:"pin,omitempty"`              // Combinado fora do email
+	ExpiresInHours int    `json:"expires_in_hours,omitempty"` // Padrão 72, até 168
+
+	// PassphraseHint orienta como obter a frase secreta de um item selado
+	PassphraseHint string `json:"passphrase_hint,omitempty"`
+}
+
+// SendItemResponse é o link criado e o resultado do email
+type SendItemResponse struct {
+	Link      ShareLinkResponse `json:"link"`
+	EmailSent bool              `json:"email_sent"` // false: o dono pode repassar a URL por conta própria
+}
+
+// SendItem envia um item por email num link de uso único
+//
+// Endpoint: POST /api/box/items/{itemID}/send
+func (h *Handler) SendItem(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+	clientIP := security.GetClientIP(r)
+
+	var req SendItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "share.invalid_data")
+		return
+	}
+
+	recipient, err := security.ValidateEmail(req.RecipientEmail)
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "share.send_invalid_email")
+		return
+	}
+	note := security.SanitizeText(req.Note, 0)
+	if len([]rune(note)) > maxSendNoteLength {
+		apierror.Write(w, r, http.StatusBadRequest, "share.send_note_too_long")
+		return
+	}
+	expiresIn := req.ExpiresInHours
+	if expiresIn == 0 {
+		expiresIn = defaultSendExpiresHours
+	}
+	if expiresIn < 0 || expiresIn > maxSendExpiresHours {
+		apierror.Write(w, r, http.StatusBadRequest, "share.send_invalid_expiry")
+		return
+	}
+
+	var pinHash string
+	if req.PIN != "" {
+		if len(req.PIN) < 4 {
+			apierror.Write(w, r, http.StatusBadRequest, "share.send_pin_too_short")
+			return
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(req.PIN), bcrypt.DefaultCost)
+		if err != nil {
+			apierror.Write(w, r, http.StatusInternalServerError, "share.create_error")
+			return
+		}
+		pinHash = string(hash)
+	}
+
+	item, err := h.store.GetBoxItem(userID, chi.URLParam(r, "itemID"))
+	if err != nil || item.ArchivedAt != nil {
+		apierror.Write(w, r, http.StatusNotFound, "share.item_not_found")
+		return
+	}
+
+	locale := h.ownerLocale(r, userID)
+	name := i18n.Format(locale, "share.send_link_name", i18n.Vars{"title": item.Title, "recipient": recipient})
+	if len(name) > 255 {
+		name = name[:255]
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(time.Duration(expiresIn) * time.Hour)
+	link := &storage.ShareLink{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Token:     security.GenerateShareToken(),
+		Type:      storage.ShareLinkOneTime,
+		Name:      name,
+		PIN:       pinHash,
+		ExpiresAt: &expiresAt,
+		MaxUses:   1,
+		IsActive:  true,
+		CreatedAt: now,
+		UpdatedAt: now,
+		ItemIDs:   []string{item.ID},
+
+		PassphraseHint: security.SanitizeText(req.PassphraseHint, maxPassphraseHint),
+	}
+	if err := h.store.CreateShareLink(link); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "share.create_error")
+		return
+	}
+
+	linkURL := h.linkURL(link.Token, locale)
+	emailSent := h.sendItemEmail(userID, link, recipient, security.SanitizeName(req.RecipientName), note, linkURL, locale)
+
+	h.auditLogger.Log(security.AuditEvent{
+		Type:     security.EventItemSent,
+		Severity: security.SeverityInfo,
+		UserID:   userID,
+		ClientIP: clientIP,
+		Resource: "box_item:" + item.ID,
+		Action:   "send",
+		Result:   "success",
+		Details: map[string]interface{}{
+			"link_id":    link.ID,
+			"recipient":  maskEmail(recipient),
+			"pin":        pinHash != "",
+			"expires_at": expiresAt,
+			"email_sent": emailSent,
+		},
+	})
+
+	writeJSON(w, http.StatusCreated, SendItemResponse{
+		Link:      linkResponse(link, linkURL, h.policy),
+		EmailSent: emailSent,
+	})
+}
+
+// sendItemEmail envia o link ao destinatário, no idioma do dono
+func (h *Handler) sendItemEmail(userID string, link *storage.ShareLink, to, toName, note, linkURL, locale string) bool {
+	if h.mailer == nil || !h.mailer.IsConfigured() {
+		return false
+	}
+
+	ownerName := ""
+	if owner, ok := h.store.GetUserByID(userID); ok {
+		ownerName = owner.Name
+	}
+	key := "share.send_message"
+	if link.PIN != "" {
+		key = "share.send_message_pin"
+	}
+	message := i18n.Format(locale, key, i18n.Vars{
+		"owner":   ownerName,
+		"link":    linkURL,
+		"expires": link.ExpiresAt.UTC().Format("02/01/2006 15:04 UTC"),
+	})
+	if note != "" {
+		message = i18n.Format(locale, "share.send_note", i18n.Vars{"owner": ownerName, "note": note}) + "\n\n" + message
+	}
+	if toName != "" {
+		message = i18n.Format(locale, "share.send_greeting", i18n.Vars{"name": toName}) + "\n\n" + message
+	}
+
+	if err := h.mailer.SendNotice(to, toName, message, locale); err != nil {
+		log.Printf("[SHARE] Erro ao enviar o item do link %s: %v", link.ID, err)
+		return false
+	}
+	return true
+}
+
+// claimOneTimeLink gasta o acesso de um envio avulso antes de mostrar o item
+// Outros tipos de link passam direto (o uso é contado em recordAccess).
+// Retorna false se outro acesso já levou o link.
+func (h *Handler) claimOneTimeLink(link *storage.ShareLink, ip string) bool {
+	if link.Type != storage.ShareLinkOneTime {
+		return true
+	}
+	claimed, err := h.store.ClaimShareLinkUsage(link.ID)
+	if err != nil || !claimed {
+		return false
+	}
+
+	h.auditLogger.Log(security.AuditEvent{
+		Type:     security.EventItemSent,
+		Severity: security.SeverityInfo,
+		UserID:   link.UserID,
+		ClientIP: ip,
+		Resource: "share_link",
+		Action:   "view",
+		Result:   "success",
+		Details:  map[string]interface{}{"link_id": link.ID, "item_ids": link.ItemIDs},
+	})
+	return true
+}
+
+// oneTimeItems busca o item de um envio avulso (compartilhado ou não)
+// Itens arquivados ou removidos depois do envio não aparecem.
+func (h *Handler) oneTimeItems(link *storage.ShareLink) []*storage.BoxItem {
+	var items []*storage.BoxItem
+	for _, id := range link.ItemIDs {
+		item, err := h.store.GetBoxItem(link.UserID, id)
+		if err != nil || item.ArchivedAt != nil {
+			continue
+		}
+		items = append(items, item)
+	}
+	return items
+}
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// =============================================================================
+// ATIVIDADE DO PAINEL ADMIN
+// =============================================================================
+// A atividade junta os eventos relevantes gravados em audit_log (cadastros,
+// exclusões, emergências, feedbacks) com alguns eventos de analytics, do mais
+// recente para o mais antigo. O cursor guarda a data e o ID do último evento
+// da página; para o cliente é opaco (base64).
+
+// auditActivityPrefix distingue os IDs de audit_log (numéricos) dos de analytics
+const auditActivityPrefix = "aud_"
+
+// activityCursor é a posição de uma página na atividade
+type activityCursor struct {
+	CreatedAt time.Time `json:"k"`
+	ID        string    `json:"id"`
+}
+
+// encodeActivityCursor gera o cursor da página seguinte a partir do último evento
+func encodeActivityCursor(entry *ActivityEntry) string {
+	data, _ := json.Marshal(activityCursor{CreatedAt: entry.CreatedAt, ID: entry.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeActivityCursor lê um cursor gerado por encodeActivityCursor
+func decodeActivityCursor(cursor string) (*activityCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidData
+	}
+	var c activityCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return nil, ErrInvalidData
+	}
+	return &c, nil
+}
+
+// activityBefore indica se o evento vem depois da posição do cursor
+// (ordem: created_at DESC, id DESC)
+func activityBefore(entry *ActivityEntry, c *activityCursor) bool {
+	if entry.CreatedAt.Equal(c.CreatedAt) {
+		return entry.ID < c.ID
+	}
+	return entry.CreatedAt.Before(c.CreatedAt)
+}
+
+// activitySources separa os tipos pedidos em ações de audit_log e eventos de
+// analytics (sem tipos = todos)
+func activitySources(types []ActivityType) (map[string]ActivityType, map[AnalyticsEventType]ActivityType) {
+	actions := map[string]ActivityType{}
+	events := map[AnalyticsEventType]ActivityType{}
+	for activity, action := range ActivityAuditActions {
+		if len(types) == 0 || containsActivityType(types, activity) {
+			actions[action] = activity
+		}
+	}
+	for activity, event := range ActivityAnalyticsEvents {
+		if len(types) == 0 || containsActivityType(types, activity) {
+			events[event] = activity
+		}
+	}
+	return actions, events
+}
+
+// containsActivityType indica se o tipo está na lista
+func containsActivityType(types []ActivityType, t ActivityType) bool {
+	for _, candidate := range types {
+		if candidate == t {
+			return true
+		}
+	}
+	return false
+}
+
+// paginateActivity ordena os eventos (mais recentes primeiro), aplica o
+// cursor e corta a página
+func paginateActivity(entries []*ActivityEntry, params *ActivityParams) (*PaginatedResult[*ActivityEntry], error) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].CreatedAt.Equal(entries[j].CreatedAt) {
+			return entries[i].ID > entries[j].ID
+		}
+		return entries[i].CreatedAt.After(entries[j].CreatedAt)
+	})
+	total := len(entries)
+
+	if params.Cursor != "" {
+		cursor, err := decodeActivityCursor(params.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		start := len(entries)
+		for i, entry := range entries {
+			if activityBefore(entry, cursor) {
+				start = i
+				break
+			}
+		}
+		entries = entries[start:]
+	}
+
+	hasMore := len(entries) > params.Limit
+	if hasMore {
+		entries = entries[:params.Limit]
+	}
+	var nextCursor string
+	if hasMore {
+		nextCursor = encodeActivityCursor(entries[len(entries)-1])
+	}
+
+	return &PaginatedResult[*ActivityEntry]{
+		Items:      entries,
+		NextCursor: nextCursor,
+		HasMore:    hasMore,
+		Total:      total,
+	}, nil
+}
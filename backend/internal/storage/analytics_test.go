@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// trackAt grava um evento do usuário no horário indicado
+func trackAt(t *testing.T, store *PostgresStore, userID string, eventType AnalyticsEventType, at time.Time) {
+	t.Helper()
+	_, err := store.TrackEvents([]*AnalyticsEvent{{ID: uuid.New().String(), UserID: userID, EventType: eventType, CreatedAt: at}})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// dailyEvents retorna o total consolidado do dia em analytics_daily
+func dailyEvents(t *testing.T, store *PostgresStore, day time.Time) int {
+	t.Helper()
+	var events int
+	if err := store.db.QueryRow(`SELECT events FROM analytics_daily WHERE day = $1::date`, day.Format("2006-01-02")).Scan(&events); err != nil {
+		t.Fatalf("dia %s não consolidado: %v", day.Format("2006-01-02"), err)
+	}
+	return events
+}
+
+func TestPostgresAnalyticsRollup(t *testing.T) {
+	store := newTestPostgres(t)
+	maria := createTestUser(t, store, "maria@example.com")
+	joao := createTestUser(t, store, "joao@example.com")
+	now, err := store.dbNow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	today := monthStart(now).AddDate(0, 0, now.Day()-1)
+	noon := func(daysAgo int) time.Time { return today.AddDate(0, 0, -daysAgo).Add(12 * time.Hour) }
+
+	// Um evento por dia na semana, mais um de João anteontem e os de hoje
+	for daysAgo := 1; daysAgo <= analyticsRollupDays; daysAgo++ {
+		trackAt(t, store, maria.ID, EventPageView, noon(daysAgo))
+	}
+	trackAt(t, store, joao.ID, EventCreateItem, noon(2))
+	trackAt(t, store, maria.ID, EventLogin, now)
+
+	// Sem rollups, o resumo vem direto dos eventos
+	fromEvents := store.GetAnalyticsSummary()
+	if fromEvents.ActiveThisWeek != 2 || fromEvents.ActiveToday != 1 || fromEvents.EventsToday != 1 ||
+		fromEvents.EventsByType[string(EventPageView)] != analyticsRollupDays || fromEvents.EventsByType[string(EventCreateItem)] != 1 {
+		t.Fatalf("resumo pelos eventos: %+v", fromEvents)
+	}
+
+	rolledUp, err := store.RollupAnalytics()
+	if err != nil || rolledUp != analyticsRollupDays {
+		t.Fatalf("dias consolidados: %d %v", rolledUp, err)
+	}
+	if events := dailyEvents(t, store, noon(2)); events != 2 {
+		t.Fatalf("eventos de anteontem: %d", events)
+	}
+
+	// Com a semana consolidada, o resumo dos rollups é o mesmo
+	fromRollups := store.GetAnalyticsSummary()
+	if !reflect.DeepEqual(fromRollups, fromEvents) {
+		t.Fatalf("resumo pelos rollups difere:\n%+v\n%+v", fromRollups, fromEvents)
+	}
+
+	// Eventos atrasados entram nos últimos dias recalculados; dias mais
+	// antigos continuam como foram consolidados
+	trackAt(t, store, joao.ID, EventPageView, noon(1))
+	trackAt(t, store, joao.ID, EventPageView, noon(analyticsRollupDays))
+	rolledUp, err = store.RollupAnalytics()
+	if err != nil || rolledUp != 0 {
+		t.Fatalf("nenhum dia novo deveria ser consolidado: %d %v", rolledUp, err)
+	}
+	if events := dailyEvents(t, store, noon(1)); events != 2 {
+		t.Fatalf("evento atrasado de ontem fora do rollup: %d", events)
+	}
+	if events := dailyEvents(t, store, noon(analyticsRollupDays)); events != 1 {
+		t.Fatalf("dia fora da janela recalculado: %d", events)
+	}
+	summary := store.GetAnalyticsSummary()
+	if summary.EventsByType[string(EventPageView)] != analyticsRollupDays+1 {
+		t.Fatalf("resumo sem o evento atrasado: %+v", summary)
+	}
+
+	// Eventos que somem (ex.: retenção) também saem do dia recalculado
+	if _, err := store.db.Exec(`DELETE FROM analytics_events WHERE user_id = $1 AND created_at >= $2`, joao.ID, noon(2).Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.RollupAnalytics(); err != nil {
+		t.Fatal(err)
+	}
+	if events := dailyEvents(t, store, noon(2)); events != 1 {
+		t.Fatalf("evento removido de anteontem ainda no rollup: %d", events)
+	}
+	var stale int
+	store.db.QueryRow(`SELECT COUNT(*) FROM analytics_daily_events WHERE event_type = $1`, string(EventCreateItem)).Scan(&stale)
+	if stale != 0 {
+		t.Fatalf("tipo sem eventos ainda no rollup: %d", stale)
+	}
+	store.db.QueryRow(`SELECT COUNT(*) FROM analytics_daily_users WHERE user_id = $1 AND day >= $2::date`, joao.ID, noon(2).Format("2006-01-02")).Scan(&stale)
+	if stale != 0 {
+		t.Fatalf("usuário sem eventos ainda no rollup: %d", stale)
+	}
+}
@@ -0,0 +1,33 @@
+package storage
+
+import "time"
+
+// =============================================================================
+// LISTA MARCÁVEL DOS ITENS
+// =============================================================================
+// Marcar uma linha não passa pelo UpdateBoxItem (não exige If-Match): só o
+// estado da linha muda, mas a versão do item aumenta para que uma edição
+// aberta em outra aba não desfaça a marcação.
+
+// setChecklistDone retorna uma cópia da lista com a linha marcada (ou não)
+// Marcar de novo uma linha já marcada mantém a data da primeira vez.
+func setChecklistDone(checklist []ChecklistEntry, entryID string, done bool, at time.Time) ([]ChecklistEntry, error) {
+	result := make([]ChecklistEntry, len(checklist))
+	copy(result, checklist)
+
+	for i := range result {
+		if result[i].ID != entryID {
+			continue
+		}
+		switch {
+		case !done:
+			result[i].Done = false
+			result[i].DoneAt = nil
+		case !result[i].Done:
+			result[i].Done = true
+			result[i].DoneAt = &at
+		}
+		return result, nil
+	}
+	return nil, ErrNotFound
+}
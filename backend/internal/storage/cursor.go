@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+)
+
+// =============================================================================
+// CURSOR DA LISTAGEM DE ITENS
+// =============================================================================
+// O cursor guarda a posição do último item da página: se estava fixado, a
+// chave de ordenação (created_at, updated_at, due_date, posição manual ou
+// título) e o ID, que desempata itens com a mesma chave. Não depende do
+// formato do ID e continua válido se o item for removido.
+// Para o cliente é opaco (base64): basta repassar o next_cursor.
+
+// itemCursor é a posição de uma página na listagem de itens
+type itemCursor struct {
+	Sort     BoxItemSort `json:"s"`
+	Pinned   bool        `json:"p,omitempty"`
+	Position int         `json:"n,omitempty"` // Ordenação "manual"
+	Title    string      `json:"t,omitempty"` // Ordenação "title" (minúsculas)
+	Key      *time.Time  `json:"k,omitempty"` // nil = item sem vencimento (ordenação "due")
+	ID       string      `json:"id"`
+}
+
+// itemPosition retorna a posição do item na ordenação (o que o cursor guarda)
+func itemPosition(order BoxItemSort, item *BoxItemSummary) itemCursor {
+	order = normalizeItemSort(order)
+	position := itemCursor{Sort: order, Pinned: item.Pinned, ID: item.ID}
+	switch order {
+	case BoxItemSortTitle:
+		position.Title = strings.ToLower(item.Title)
+	case BoxItemSortManual:
+		position.Position = item.Position
+		position.Key = &item.CreatedAt
+	default:
+		position.Key = itemSortKey(order, item.CreatedAt, item.UpdatedAt, item.DueDate)
+	}
+	return position
+}
+
+// encodeItemCursor gera o cursor da página seguinte a partir do último item
+func encodeItemCursor(order BoxItemSort, item *BoxItemSummary) string {
+	data, _ := json.Marshal(itemPosition(order, item))
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeItemCursor lê um cursor gerado por encodeItemCursor
+// Cursores inválidos ou de outra ordenação retornam ErrInvalidData.
+func decodeItemCursor(cursor string, order BoxItemSort) (*itemCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidData
+	}
+	var c itemCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return nil, ErrInvalidData
+	}
+	if c.Sort != normalizeItemSort(order) {
+		return nil, ErrInvalidData
+	}
+	if c.Key == nil && c.Sort != BoxItemSortDue && c.Sort != BoxItemSortTitle {
+		return nil, ErrInvalidData
+	}
+	return &c, nil
+}
+
+// normalizeItemSort aplica a ordenação padrão
+func normalizeItemSort(order BoxItemSort) BoxItemSort {
+	switch order {
+	case BoxItemSortOldest, BoxItemSortUpdated, BoxItemSortDue, BoxItemSortManual, BoxItemSortTitle:
+		return order
+	default:
+		return BoxItemSortNewest
+	}
+}
+
+// itemSortKey retorna a chave de data do item na ordenação
+func itemSortKey(order BoxItemSort, createdAt, updatedAt time.Time, dueDate *time.Time) *time.Time {
+	switch normalizeItemSort(order) {
+	case BoxItemSortUpdated:
+		return &updatedAt
+	case BoxItemSortDue:
+		return dueDate
+	default:
+		return &createdAt
+	}
+}
+
+// itemPositionLess indica se a posição a vem antes de b na ordenação
+// Mesma regra do ORDER BY do PostgresStore: fixados primeiro, depois a
+// chave da ordenação (chave nula no fim) e o ID.
+func itemPositionLess(a, b itemCursor) bool {
+	if a.Pinned != b.Pinned {
+		return a.Pinned
+	}
+
+	order := normalizeItemSort(a.Sort)
+	switch order {
+	case BoxItemSortTitle:
+		if a.Title != b.Title {
+			return a.Title < b.Title
+		}
+		return a.ID < b.ID
+	case BoxItemSortManual:
+		if a.Position != b.Position {
+			return a.Position < b.Position
+		}
+	}
+	return itemKeyLess(order, a.Key, a.ID, b.Key, b.ID)
+}
+
+// itemKeyLess indica se (keyA, idA) vem antes de (keyB, idB) na ordenação
+// Mesma regra do ORDER BY do PostgresStore (chave nula no fim).
+func itemKeyLess(order BoxItemSort, keyA *time.Time, idA string, keyB *time.Time, idB string) bool {
+	order = normalizeItemSort(order)
+	desc := order == BoxItemSortNewest || order == BoxItemSortUpdated || order == BoxItemSortManual
+
+	switch {
+	case keyA == nil && keyB == nil:
+	case keyA == nil:
+		return false
+	case keyB == nil:
+		return true
+	case !keyA.Equal(*keyB):
+		if desc {
+			return keyA.After(*keyB)
+		}
+		return keyA.Before(*keyB)
+	}
+
+	if desc {
+		return idA > idB
+	}
+	return idA < idB
+}
+
+// sortItemSummaries ordena a listagem como o ORDER BY do PostgresStore
+func sortItemSummaries(items []*BoxItemSummary, order BoxItemSort) {
+	sort.Slice(items, func(i, j int) bool {
+		return itemPositionLess(itemPosition(order, items[i]), itemPosition(order, items[j]))
+	})
+}
+
+// paginateItemSummaries aplica o cursor e o limite a itens já ordenados
+func paginateItemSummaries(allItems []*BoxItemSummary, order BoxItemSort, params *PaginationParams) (*PaginatedResult[*BoxItemSummary], error) {
+	params = NormalizePagination(params)
+
+	// Aplicar cursor (primeiro item depois da posição do cursor)
+	startIdx := 0
+	if params.Cursor != "" {
+		cursor, err := decodeItemCursor(params.Cursor, order)
+		if err != nil {
+			return nil, err
+		}
+		startIdx = sort.Search(len(allItems), func(i int) bool {
+			return itemPositionLess(*cursor, itemPosition(order, allItems[i]))
+		})
+	}
+
+	endIdx := startIdx + params.Limit + 1
+	if endIdx > len(allItems) {
+		endIdx = len(allItems)
+	}
+	items := allItems[startIdx:endIdx]
+	hasMore := len(items) > params.Limit
+	if hasMore {
+		items = items[:params.Limit]
+	}
+
+	var nextCursor string
+	if hasMore && len(items) > 0 {
+		nextCursor = encodeItemCursor(order, items[len(items)-1])
+	}
+
+	return &PaginatedResult[*BoxItemSummary]{
+		Items:      items,
+		NextCursor: nextCursor,
+		HasMore:    hasMore,
+	}, nil
+}
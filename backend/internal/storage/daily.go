@@ -0,0 +1,37 @@
+package storage
+
+import "time"
+
+// =============================================================================
+// SÉRIES DIÁRIAS
+// =============================================================================
+// Helpers comuns ao MemoryStore e ao PostgresStore para as exportações do
+// painel admin: um registro por dia do período, inclusive os dias vazios
+// (planilhas esperam a série completa). Os dias são em UTC.
+
+// dayStart retorna o dia (00:00 UTC) de t
+func dayStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// newDailyStats monta a série de [from, to) com os eventos e usuários por dia
+func newDailyStats(from, to time.Time, events, users map[time.Time]int) []*DailyStats {
+	stats := make([]*DailyStats, 0)
+	for day := dayStart(from); day.Before(to); day = day.AddDate(0, 0, 1) {
+		stats = append(stats, &DailyStats{Day: day, Events: events[day], Users: users[day]})
+	}
+	return stats
+}
+
+// newUserGrowth monta a série de [from, to) com os cadastros por dia e o
+// total acumulado (before = contas criadas antes de from)
+func newUserGrowth(from, to time.Time, before int, signups map[time.Time]int) []*UserGrowthDay {
+	growth := make([]*UserGrowthDay, 0)
+	total := before
+	for day := dayStart(from); day.Before(to); day = day.AddDate(0, 0, 1) {
+		total += signups[day]
+		growth = append(growth, &UserGrowthDay{Day: day, NewUsers: signups[day], TotalUsers: total})
+	}
+	return growth
+}
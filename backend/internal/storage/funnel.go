@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"math"
+	"time"
+)
+
+// =============================================================================
+// FUNIL E COORTES
+// =============================================================================
+// Helpers comuns ao MemoryStore e ao PostgresStore. As semanas começam na
+// segunda-feira (UTC), igual ao date_trunc('week', ...) do PostgreSQL.
+
+// weekStart retorna a segunda-feira 00:00 UTC da semana de t
+func weekStart(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	offset := (int(day.Weekday()) + 6) % 7 // segunda = 0
+	return day.AddDate(0, 0, -offset)
+}
+
+// newFunnel monta o funil com os percentuais em relação aos cadastrados
+func newFunnel(from, to time.Time, registered, firstItem, guardian, shared int) *AnalyticsFunnel {
+	funnel := &AnalyticsFunnel{From: from, To: to}
+	for _, step := range []struct {
+		name  string
+		users int
+	}{
+		{FunnelRegistered, registered},
+		{FunnelFirstItem, firstItem},
+		{FunnelGuardian, guardian},
+		{FunnelShared, shared},
+	} {
+		percent := 0.0
+		if registered > 0 {
+			percent = math.Round(float64(step.users)*1000/float64(registered)) / 10
+		}
+		funnel.Steps = append(funnel.Steps, FunnelStep{Step: step.name, Users: step.users, Percent: percent})
+	}
+	return funnel
+}
+
+// newRetentionCohort cria a coorte com uma posição por semana já iniciada
+func newRetentionCohort(week, now time.Time) *RetentionCohort {
+	weeks := int(weekStart(now).Sub(week).Hours()/(24*7)) + 1
+	if weeks < 1 {
+		weeks = 1
+	}
+	if weeks > MaxCohortWeeks {
+		weeks = MaxCohortWeeks
+	}
+	return &RetentionCohort{Week: week, Retained: make([]int, weeks)}
+}
@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestClaimGuardianPolicyAccessConcurrent(t *testing.T) {
+	stores := map[string]func(t *testing.T) Store{
+		"memory":   func(t *testing.T) Store { return NewMemoryStore() },
+		"postgres": func(t *testing.T) Store { return newTestPostgres(t) },
+	}
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := open(t)
+			user := createTestUser(t, store, "maria@example.com")
+			guardian, err := store.CreateGuardian(user.ID, &Guardian{Name: "Cuidadora", Email: "ana@example.com"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Sem regras, nada a contar
+			if claimed, err := store.ClaimGuardianPolicyAccess(guardian.ID); err != nil || !claimed {
+				t.Fatalf("sem regras o acesso deveria passar: %v %v", claimed, err)
+			}
+
+			const maxAccesses = 3
+			if err := store.SaveGuardianAccessPolicy(&GuardianAccessPolicy{GuardianID: guardian.ID, MaxAccesses: maxAccesses}); err != nil {
+				t.Fatal(err)
+			}
+
+			// Aberturas simultâneas não passam do limite
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			claimed := 0
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					ok, err := store.ClaimGuardianPolicyAccess(guardian.ID)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					if ok {
+						mu.Lock()
+						claimed++
+						mu.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+			if claimed != maxAccesses {
+				t.Fatalf("acessos contados: %d (limite %d)", claimed, maxAccesses)
+			}
+
+			policy, err := store.GetGuardianAccessPolicy(guardian.ID)
+			if err != nil || policy.AccessCount != maxAccesses {
+				t.Fatalf("contagem gravada: %+v %v", policy, err)
+			}
+		})
+	}
+}
@@ -1,17 +1,26 @@
 package storage
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"famli/internal/ids"
+	"famli/internal/security"
 )
 
 var (
 	ErrNotFound      = errors.New("não encontrado")
 	ErrAlreadyExists = errors.New("já existe")
 	ErrInvalidData   = errors.New("dados inválidos")
+
+	// ErrVersionConflict indica que o item mudou desde a versão esperada
+	ErrVersionConflict = errors.New("versão desatualizada")
 )
 
 // MemoryStore implementa armazenamento em memória para o MVP
@@ -26,18 +35,60 @@ type MemoryStore struct {
 	progress            map[string]map[string]*GuideProgress // userID -> cardID -> progress
 	settings            map[string]*Settings
 	feedbacks           map[string]*Feedback                    // feedbackID -> feedback
+	feedbackReplies     map[string][]*FeedbackReply             // feedbackID -> conversa
 	analytics           []*AnalyticsEvent                       // Lista de eventos
+	completenessScores  map[string]map[time.Time]int            // userID -> dia -> nota de preparo
 	shareLinks          map[string]*ShareLink                   // linkID -> link
 	shareLinksByToken   map[string]string                       // token -> linkID
 	shareLinkAccesses   []*ShareLinkAccess                      // Lista de acessos
 	passwordResetTokens map[string]*PasswordResetToken          // tokenHash -> token
 	emergencyProtocols  map[string]*EmergencyProtocol           // userID -> protocol
 	idempotencyKeys     map[string]map[string]map[string]string // user_id -> resource_type -> key -> resource_id
+	loginHistory        map[string][]*LoginRecord               // userID -> logins
+	systemConfig        map[string]string                       // key -> value
+	webhooks            map[string]*Webhook                     // webhookID -> webhook
+	webhookDeliveries   map[string]*WebhookDelivery             // deliveryID -> entrega
+	pushSubscriptions   map[string]*PushSubscription            // endpoint -> inscrição
+	notifications       map[string]*Notification                // notificationID -> aviso
+	calendarFeeds       map[string]*CalendarFeed                // userID -> link ICS
+	captureTokens       map[string]*CaptureToken                // userID -> token da captura pelo navegador
+	itemSources         map[string]*ItemSource                  // itemID -> página de origem
+	emergencyCards      map[string]*EmergencyCard               // userID -> cartão de emergência
+	escalationChains    map[string]*EscalationChain             // userID -> lista de escalonamento
+	escalations         map[string]*EmergencyEscalation         // escalationID -> escalonamento
+	memorialConfigs     map[string]*MemorialConfig              // userID -> quem libera o memorial
+	memorialActivations map[string]*MemorialActivation          // activationID -> pedido de abertura do memorial
+	households          map[string]*Household                   // householdID -> família
+	householdMembers    map[string]map[string]*HouseholdMember  // householdID -> userID -> membro
+	pinAttempts         map[string]*PINAttempt                  // key -> falhas de PIN
+	categories          map[string]*Category                    // categoryID -> categoria
+	deviceSessions      map[string]*DeviceSession               // sessionID -> sessão
+	subscriptions       map[string]*Subscription                // userID -> assinatura
+	digestSentAt        map[string]time.Time                    // userID -> último resumo (ou inscrição)
+	assistantUsage      map[string]*AssistantUsage              // userID|dia -> uso do assistente
+	itemRelations       map[string]*ItemRelation                // relationID -> vínculo
+	itemViews           map[string]*ItemView                    // itemID|origem|sourceID -> recibo de leitura
+	itemOrder           map[string]*itemOrderEntry              // userID|itemID -> fixado e posição
+	expiryReminders     map[string]time.Time                    // itemID|validade|dias -> envio do lembrete
+	guardianAlerts      map[string]*GuardianAlert               // alertID -> aviso ao guardião
+	itemComments        map[string]*ItemComment                 // commentID -> comentário de guardião
+	conversationDrafts  map[string]*ConversationDraft           // userID -> item abandonado no WhatsApp
+	phoneLinks          map[string]*PhoneLink                   // linkID -> número de WhatsApp vinculado
+	inboundSenders      map[string]*InboundSender               // senderID -> remetente do gateway de email
+	apiKeys             map[string]*APIKey                      // keyID -> chave pessoal de API
+	guardianPolicies    map[string]*GuardianAccessPolicy        // guardianID -> regras de acesso
+	supportAccess       map[string]*SupportAccess               // userID -> autorização do suporte
+	retentionRuns       []*RetentionRun                         // Execuções da retenção, em ordem
+	statusIncidents     map[string]*StatusIncident              // incidentID -> nota da página de status
+	statusUptime        map[string]*StatusUptimeDay             // componente|dia -> verificações do dia
+	auditLog            []*AuditEntry                           // Eventos da atividade do painel, em ordem
+	auditSeq            int64                                   // Último ID de auditLog
+}
 
-	userSeq     int64
-	itemSeq     int64
-	guardianSeq int64
-	linkSeq     int64
+// itemOrderEntry é o item fixado/posição manual de um usuário
+type itemOrderEntry struct {
+	Pinned   bool
+	Position int
 }
 
 // NewMemoryStore cria uma nova instância do store
@@ -51,13 +102,51 @@ func NewMemoryStore() *MemoryStore {
 		progress:            make(map[string]map[string]*GuideProgress),
 		settings:            make(map[string]*Settings),
 		feedbacks:           make(map[string]*Feedback),
+		feedbackReplies:     make(map[string][]*FeedbackReply),
 		analytics:           make([]*AnalyticsEvent, 0),
+		completenessScores:  make(map[string]map[time.Time]int),
 		shareLinks:          make(map[string]*ShareLink),
 		shareLinksByToken:   make(map[string]string),
 		shareLinkAccesses:   make([]*ShareLinkAccess, 0),
 		passwordResetTokens: make(map[string]*PasswordResetToken),
 		emergencyProtocols:  make(map[string]*EmergencyProtocol),
 		idempotencyKeys:     make(map[string]map[string]map[string]string),
+		loginHistory:        make(map[string][]*LoginRecord),
+		systemConfig:        make(map[string]string),
+		webhooks:            make(map[string]*Webhook),
+		webhookDeliveries:   make(map[string]*WebhookDelivery),
+		pushSubscriptions:   make(map[string]*PushSubscription),
+		notifications:       make(map[string]*Notification),
+		calendarFeeds:       make(map[string]*CalendarFeed),
+		captureTokens:       make(map[string]*CaptureToken),
+		itemSources:         make(map[string]*ItemSource),
+		emergencyCards:      make(map[string]*EmergencyCard),
+		escalationChains:    make(map[string]*EscalationChain),
+		escalations:         make(map[string]*EmergencyEscalation),
+		memorialConfigs:     make(map[string]*MemorialConfig),
+		memorialActivations: make(map[string]*MemorialActivation),
+		households:          make(map[string]*Household),
+		householdMembers:    make(map[string]map[string]*HouseholdMember),
+		pinAttempts:         make(map[string]*PINAttempt),
+		categories:          make(map[string]*Category),
+		deviceSessions:      make(map[string]*DeviceSession),
+		subscriptions:       make(map[string]*Subscription),
+		digestSentAt:        make(map[string]time.Time),
+		assistantUsage:      make(map[string]*AssistantUsage),
+		itemRelations:       make(map[string]*ItemRelation),
+		itemViews:           make(map[string]*ItemView),
+		expiryReminders:     make(map[string]time.Time),
+		guardianAlerts:      make(map[string]*GuardianAlert),
+		itemComments:        make(map[string]*ItemComment),
+		conversationDrafts:  make(map[string]*ConversationDraft),
+		phoneLinks:          make(map[string]*PhoneLink),
+		inboundSenders:      make(map[string]*InboundSender),
+		apiKeys:             make(map[string]*APIKey),
+		guardianPolicies:    make(map[string]*GuardianAccessPolicy),
+		statusIncidents:     make(map[string]*StatusIncident),
+		statusUptime:        make(map[string]*StatusUptimeDay),
+		supportAccess:       make(map[string]*SupportAccess),
+		itemOrder:           make(map[string]*itemOrderEntry),
 	}
 }
 
@@ -75,12 +164,12 @@ func (s *MemoryStore) CreateUser(email, hashedPassword, name string) (*User, err
 		return nil, ErrAlreadyExists
 	}
 
-	s.userSeq++
 	user := &User{
-		ID:        fmt.Sprintf("usr_%d", s.userSeq),
+		ID:        ids.New(ids.User),
 		Email:     email,
 		Name:      name,
 		Password:  hashedPassword,
+		Plan:      PlanFree,
 		CreatedAt: time.Now(),
 	}
 
@@ -137,6 +226,48 @@ func (s *MemoryStore) UpdateUserLocale(userID, locale string) error {
 	return nil
 }
 
+// UpdateUserProfile corrige o nome e o email do usuário
+func (s *MemoryStore) UpdateUserProfile(userID, name, email string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.users[userID]
+	if !exists {
+		return ErrNotFound
+	}
+
+	normalized := strings.ToLower(strings.TrimSpace(email))
+	if normalized == "" {
+		return ErrInvalidData
+	}
+	if ownerID, taken := s.usersByEmail[normalized]; taken && ownerID != userID {
+		return ErrAlreadyExists
+	}
+
+	delete(s.usersByEmail, strings.ToLower(strings.TrimSpace(user.Email)))
+	s.usersByEmail[normalized] = userID
+	user.Name = name
+	user.Email = email
+	return nil
+}
+
+// AcceptLegalTerms registra as versões dos termos e da política aceitas
+func (s *MemoryStore) AcceptLegalTerms(userID, termsVersion, privacyVersion string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.users[userID]
+	if !exists {
+		return ErrNotFound
+	}
+
+	acceptedAt := at
+	user.TermsVersion = termsVersion
+	user.PrivacyVersion = privacyVersion
+	user.TermsAcceptedAt = &acceptedAt
+	return nil
+}
+
 // DeleteUser remove um usuário e todos os seus dados (LGPD: Direito ao esquecimento)
 func (s *MemoryStore) DeleteUser(userID string) error {
 	s.mu.Lock()
@@ -152,10 +283,111 @@ func (s *MemoryStore) DeleteUser(userID string) error {
 	delete(s.usersByEmail, normalized)
 
 	// Remover todos os dados relacionados (cascata)
+	for itemID := range s.items[userID] {
+		s.deleteRelationsLocked(itemID)
+		s.deleteItemViewsLocked(itemID)
+		s.deleteItemCommentsLocked(itemID, "")
+		s.deleteItemOrderLocked("", itemID)
+		s.deleteExpiryRemindersLocked(itemID)
+		delete(s.itemSources, itemID)
+	}
+	s.deleteItemOrderLocked(userID, "")
+	for guardianID := range s.guardians[userID] {
+		s.deleteRelationsLocked(guardianID)
+		delete(s.guardianPolicies, guardianID)
+	}
 	delete(s.items, userID)
 	delete(s.guardians, userID)
 	delete(s.progress, userID)
 	delete(s.settings, userID)
+	delete(s.loginHistory, userID)
+	delete(s.calendarFeeds, userID)
+	delete(s.captureTokens, userID)
+	delete(s.emergencyCards, userID)
+	delete(s.escalationChains, userID)
+	for id, escalation := range s.escalations {
+		if escalation.UserID == userID {
+			delete(s.escalations, id)
+		}
+	}
+	delete(s.memorialConfigs, userID)
+	for id, activation := range s.memorialActivations {
+		if activation.UserID == userID {
+			delete(s.memorialActivations, id)
+		}
+	}
+	delete(s.completenessScores, userID)
+	delete(s.subscriptions, userID)
+	delete(s.digestSentAt, userID)
+	delete(s.conversationDrafts, userID)
+	for id, link := range s.phoneLinks {
+		if link.UserID == userID {
+			delete(s.phoneLinks, id)
+		}
+	}
+	for id, sender := range s.inboundSenders {
+		if sender.UserID == userID {
+			delete(s.inboundSenders, id)
+		}
+	}
+	for id, key := range s.apiKeys {
+		if key.UserID == userID {
+			delete(s.apiKeys, id)
+		}
+	}
+	delete(s.supportAccess, userID)
+	for key, usage := range s.assistantUsage {
+		if usage.UserID == userID {
+			delete(s.assistantUsage, key)
+		}
+	}
+	for id, session := range s.deviceSessions {
+		if session.UserID == userID {
+			delete(s.deviceSessions, id)
+		}
+	}
+	for householdID, household := range s.households {
+		if household.OwnerID == userID {
+			s.deleteHouseholdLocked(householdID)
+		}
+	}
+	for _, members := range s.householdMembers {
+		delete(members, userID)
+	}
+	for _, userGuardians := range s.guardians {
+		for _, g := range userGuardians {
+			if g.AccountUserID == userID {
+				g.AccountUserID = ""
+				g.HasAccount = false
+			}
+		}
+	}
+	for id, hook := range s.webhooks {
+		if hook.UserID == userID {
+			delete(s.webhooks, id)
+		}
+	}
+	for id, delivery := range s.webhookDeliveries {
+		if delivery.UserID == userID {
+			delete(s.webhookDeliveries, id)
+		}
+	}
+	s.deleteGuardianAlertsLocked(userID, "")
+	for endpoint, sub := range s.pushSubscriptions {
+		if sub.UserID == userID {
+			delete(s.pushSubscriptions, endpoint)
+		}
+	}
+	for id, n := range s.notifications {
+		if n.UserID == userID {
+			delete(s.notifications, id)
+		}
+	}
+	for id, category := range s.categories {
+		if category.UserID == userID {
+			delete(s.categories, id)
+		}
+	}
 
 	// Remover o usuário
 	delete(s.users, userID)
@@ -192,14 +424,14 @@ func (s *MemoryStore) CreateOrUpdateSocialUser(provider AuthProvider, providerID
 	}
 
 	// Criar novo usuário
-	s.userSeq++
 	user := &User{
-		ID:         fmt.Sprintf("usr_%d", s.userSeq),
+		ID:         ids.New(ids.User),
 		Email:      email,
 		Name:       name,
 		Provider:   provider,
 		ProviderID: providerID,
 		AvatarURL:  avatarURL,
+		Plan:       PlanFree,
 		CreatedAt:  time.Now(),
 	}
 
@@ -243,1000 +475,4491 @@ func (s *MemoryStore) LinkSocialProvider(userID string, provider AuthProvider, p
 	return nil
 }
 
-// ExportUserData exporta todos os dados do usuário (LGPD: Portabilidade)
-func (s *MemoryStore) ExportUserData(userID string) (*UserDataExport, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+// ============ ACCOUNT SECURITY ============
+
+// RecordFailedLogin incrementa as falhas consecutivas e bloqueia a conta ao atingir o limite
+func (s *MemoryStore) RecordFailedLogin(userID string, maxAttempts int, lockDuration time.Duration) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
 	user, exists := s.users[userID]
 	if !exists {
 		return nil, ErrNotFound
 	}
 
-	// Criar cópia sem senha
-	userCopy := &User{
-		ID:        user.ID,
-		Email:     user.Email,
-		Name:      user.Name,
-		CreatedAt: user.CreatedAt,
+	user.FailedLoginAttempts++
+	if user.FailedLoginAttempts >= maxAttempts {
+		lockUntil := time.Now().Add(lockDuration)
+		user.LockedUntil = &lockUntil
 	}
 
-	// Coletar itens
-	items := make([]*BoxItem, 0)
-	for _, item := range s.items[userID] {
-		copyItem := *item
-		items = append(items, &copyItem)
+	return &User{
+		ID:                  user.ID,
+		FailedLoginAttempts: user.FailedLoginAttempts,
+		LockedUntil:         user.LockedUntil,
+	}, nil
+}
+
+// ResetFailedLogins zera as falhas consecutivas e remove o bloqueio
+func (s *MemoryStore) ResetFailedLogins(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.users[userID]
+	if !exists {
+		return ErrNotFound
 	}
 
-	// Coletar guardiões
-	guardians := make([]*Guardian, 0)
-	for _, g := range s.guardians[userID] {
-		copyG := *g
-		guardians = append(guardians, &copyG)
+	user.FailedLoginAttempts = 0
+	user.LockedUntil = nil
+	return nil
+}
+
+// ============ ASSINATURAS ============
+
+func (s *MemoryStore) GetSubscription(userID string) (*Subscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sub, ok := s.subscriptions[userID]
+	if !ok {
+		return nil, ErrNotFound
 	}
+	copySub := *sub
+	return &copySub, nil
+}
 
-	// Coletar progresso
-	progress := make([]*GuideProgress, 0)
-	for _, p := range s.progress[userID] {
-		copyP := *p
-		progress = append(progress, &copyP)
+func (s *MemoryStore) GetSubscriptionByCustomer(customerID string) (*Subscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, sub := range s.subscriptions {
+		if customerID != "" && sub.CustomerID == customerID {
+			copySub := *sub
+			return &copySub, nil
+		}
 	}
+	return nil, ErrNotFound
+}
 
-	// Coletar configurações
-	var settings *Settings
-	if s, exists := s.settings[userID]; exists {
-		copyS := *s
-		settings = &copyS
+func (s *MemoryStore) SaveSubscription(sub *Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[sub.UserID]
+	if !ok {
+		return ErrNotFound
 	}
 
-	return &UserDataExport{
-		User:       userCopy,
-		Items:      items,
-		Guardians:  guardians,
-		Progress:   progress,
-		Settings:   settings,
-		ExportedAt: time.Now(),
-	}, nil
+	copySub := *sub
+	copySub.UpdatedAt = time.Now()
+	s.subscriptions[sub.UserID] = &copySub
+	user.Plan = sub.Plan
+	return nil
 }
 
-// ============ BOX ITEMS ============
+// ============ SESSÕES POR DISPOSITIVO ============
 
-// GetBoxItems retorna os itens de um usuário (alias para compatibilidade)
-func (s *MemoryStore) GetBoxItems(userID string) ([]*BoxItem, error) {
-	items := s.ListBoxItems(userID)
-	return items, nil
+func (s *MemoryStore) CreateDeviceSession(session *DeviceSession) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expired := time.Now().Add(-DeviceSessionTTL)
+	for id, existing := range s.deviceSessions {
+		if existing.UserID == session.UserID && existing.LastSeenAt.Before(expired) {
+			delete(s.deviceSessions, id)
+		}
+	}
+
+	copySession := *session
+	s.deviceSessions[session.ID] = &copySession
+	return nil
 }
 
-func (s *MemoryStore) ListBoxItems(userID string) []*BoxItem {
+func (s *MemoryStore) GetDeviceSession(sessionID string) (*DeviceSession, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	userItems := s.items[userID]
-	result := make([]*BoxItem, 0, len(userItems))
-	for _, item := range userItems {
-		copyItem := *item
-		result = append(result, &copyItem)
+	session, ok := s.deviceSessions[sessionID]
+	if !ok || session.LastSeenAt.Before(time.Now().Add(-DeviceSessionTTL)) {
+		return nil, ErrNotFound
 	}
-	return result
+	copySession := *session
+	return &copySession, nil
 }
 
-func (s *MemoryStore) GetBoxItem(userID, itemID string) (*BoxItem, error) {
+func (s *MemoryStore) ListDeviceSessions(userID string) ([]*DeviceSession, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	userItems, ok := s.items[userID]
+	expired := time.Now().Add(-DeviceSessionTTL)
+	result := make([]*DeviceSession, 0)
+	for _, session := range s.deviceSessions {
+		if session.UserID == userID && !session.LastSeenAt.Before(expired) {
+			copySession := *session
+			result = append(result, &copySession)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].LastSeenAt.After(result[j].LastSeenAt) })
+	return result, nil
+}
+
+func (s *MemoryStore) TouchDeviceSession(sessionID, ipAddress string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.deviceSessions[sessionID]
 	if !ok {
-		return nil, ErrNotFound
+		return ErrNotFound
 	}
-	item, exists := userItems[itemID]
-	if !exists {
-		return nil, ErrNotFound
+	session.LastSeenAt = time.Now()
+	if ipAddress != "" {
+		session.IPAddress = ipAddress
 	}
-	copyItem := *item
-	return &copyItem, nil
+	return nil
 }
 
-func (s *MemoryStore) CreateBoxItem(userID string, item *BoxItem) (*BoxItem, error) {
-	s.itemSeq++
-	itemID := fmt.Sprintf("itm_%d", s.itemSeq)
-	return s.CreateBoxItemWithID(userID, item, itemID)
+func (s *MemoryStore) RenameDeviceSession(userID, sessionID, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.deviceSessions[sessionID]
+	if !ok || session.UserID != userID {
+		return ErrNotFound
+	}
+	session.Name = name
+	return nil
 }
 
-func (s *MemoryStore) CreateBoxItemWithID(userID string, item *BoxItem, itemID string) (*BoxItem, error) {
+func (s *MemoryStore) DeleteDeviceSession(userID, sessionID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	now := time.Now()
-	item.ID = itemID
-	item.UserID = userID
-	item.CreatedAt = now
-	item.UpdatedAt = now
-
-	if _, ok := s.items[userID]; !ok {
-		s.items[userID] = make(map[string]*BoxItem)
+	session, ok := s.deviceSessions[sessionID]
+	if !ok || session.UserID != userID {
+		return ErrNotFound
 	}
-	s.items[userID][item.ID] = item
-	copyItem := *item
-	return &copyItem, nil
+	delete(s.deviceSessions, sessionID)
+	return nil
 }
 
-func (s *MemoryStore) UpdateBoxItem(userID, itemID string, updates *BoxItem) (*BoxItem, error) {
+// SaveSupportAccess cria ou substitui a autorização do suporte
+func (s *MemoryStore) SaveSupportAccess(access *SupportAccess) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	userItems, ok := s.items[userID]
+	copyAccess := *access
+	s.supportAccess[access.UserID] = &copyAccess
+	return nil
+}
+
+// GetSupportAccess retorna a autorização do suporte do usuário
+func (s *MemoryStore) GetSupportAccess(userID string) (*SupportAccess, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	access, ok := s.supportAccess[userID]
 	if !ok {
 		return nil, ErrNotFound
 	}
-	item, exists := userItems[itemID]
-	if !exists {
-		return nil, ErrNotFound
-	}
+	copyAccess := *access
+	return &copyAccess, nil
+}
 
-	item.Title = updates.Title
-	item.Content = updates.Content
-	item.Type = updates.Type
-	item.Category = updates.Category
-	item.Recipient = updates.Recipient
-	item.IsImportant = updates.IsImportant
-	item.IsShared = updates.IsShared
-	item.GuardianIDs = updates.GuardianIDs
-	item.UpdatedAt = time.Now()
+// DeleteSupportAccess revoga a autorização do suporte
+func (s *MemoryStore) DeleteSupportAccess(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	copyItem := *item
-	return &copyItem, nil
+	delete(s.supportAccess, userID)
+	return nil
 }
 
-func (s *MemoryStore) DeleteBoxItem(userID, itemID string) error {
+// CreatePhoneLink vincula um número de WhatsApp à conta
+// O primeiro número da conta vira o principal.
+func (s *MemoryStore) CreatePhoneLink(link *PhoneLink) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	userItems, ok := s.items[userID]
-	if !ok {
-		return ErrNotFound
-	}
-	if _, exists := userItems[itemID]; !exists {
-		return ErrNotFound
+	hasPrimary := false
+	for _, existing := range s.phoneLinks {
+		if existing.Phone == link.Phone {
+			return ErrAlreadyExists
+		}
+		if existing.UserID == link.UserID && existing.Primary {
+			hasPrimary = true
+		}
 	}
-	delete(userItems, itemID)
+	link.Primary = !hasPrimary
+	copyLink := *link
+	s.phoneLinks[link.ID] = &copyLink
 	return nil
 }
 
-// ListBoxItemsPaginated lista itens com paginação (cursor-based)
-func (s *MemoryStore) ListBoxItemsPaginated(userID string, params *PaginationParams) (*PaginatedResult[*BoxItemSummary], error) {
-	params = NormalizePagination(params)
-
+// ListPhoneLinks lista os números vinculados à conta
+func (s *MemoryStore) ListPhoneLinks(userID string) ([]*PhoneLink, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	userItems := s.items[userID]
-
-	// Converter para slice e ordenar por ID desc
-	var allItems []*BoxItem
-	for _, item := range userItems {
-		copyItem := *item
-		allItems = append(allItems, &copyItem)
+	links := make([]*PhoneLink, 0)
+	for _, link := range s.phoneLinks {
+		if link.UserID == userID {
+			copyLink := *link
+			links = append(links, &copyLink)
+		}
 	}
+	sortPhoneLinks(links)
+	return links, nil
+}
 
-	// Ordenar por ID desc (simples)
-	for i := 0; i < len(allItems); i++ {
-		for j := i + 1; j < len(allItems); j++ {
-			if allItems[i].ID < allItems[j].ID {
-				allItems[i], allItems[j] = allItems[j], allItems[i]
-			}
+// GetPhoneLinkByPhone busca o vínculo de um número
+func (s *MemoryStore) GetPhoneLinkByPhone(phone string) (*PhoneLink, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, link := range s.phoneLinks {
+		if link.Phone == phone {
+			copyLink := *link
+			return &copyLink, nil
 		}
 	}
+	return nil, ErrNotFound
+}
 
-	// Aplicar cursor
+// SetPrimaryPhoneLink define o número principal da conta
+func (s *MemoryStore) SetPrimaryPhoneLink(userID, linkID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	target, ok := s.phoneLinks[linkID]
+	if !ok || target.UserID != userID {
+		return ErrNotFound
+	}
+	for _, link := range s.phoneLinks {
+		if link.UserID == userID {
+			link.Primary = link.ID == linkID
+		}
+	}
+	return nil
+}
+
+// DeletePhoneLink desvincula um número
+// Se era o principal, o número mais antigo que sobrou assume.
+func (s *MemoryStore) DeletePhoneLink(userID, linkID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	link, ok := s.phoneLinks[linkID]
+	if !ok || link.UserID != userID {
+		return ErrNotFound
+	}
+	delete(s.phoneLinks, linkID)
+	if !link.Primary {
+		return nil
+	}
+
+	var oldest *PhoneLink
+	for _, other := range s.phoneLinks {
+		if other.UserID == userID && (oldest == nil || other.CreatedAt.Before(oldest.CreatedAt)) {
+			oldest = other
+		}
+	}
+	if oldest != nil {
+		oldest.Primary = true
+	}
+	return nil
+}
+
+// sortPhoneLinks ordena os números: principal primeiro, depois os mais antigos
+func sortPhoneLinks(links []*PhoneLink) {
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].Primary != links[j].Primary {
+			return links[i].Primary
+		}
+		return links[i].CreatedAt.Before(links[j].CreatedAt)
+	})
+}
+
+// CreateInboundSender autoriza um email a guardar itens pelo gateway
+func (s *MemoryStore) CreateInboundSender(sender *InboundSender) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.inboundSenders {
+		if existing.Email == sender.Email {
+			return ErrAlreadyExists
+		}
+	}
+	copySender := *sender
+	s.inboundSenders[sender.ID] = &copySender
+	return nil
+}
+
+// ListInboundSenders lista os remetentes verificados da conta
+func (s *MemoryStore) ListInboundSenders(userID string) ([]*InboundSender, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	senders := make([]*InboundSender, 0)
+	for _, sender := range s.inboundSenders {
+		if sender.UserID == userID {
+			copySender := *sender
+			senders = append(senders, &copySender)
+		}
+	}
+	sort.Slice(senders, func(i, j int) bool {
+		return senders[i].CreatedAt.Before(senders[j].CreatedAt)
+	})
+	return senders, nil
+}
+
+// GetInboundSenderByEmail busca o remetente verificado de um email
+func (s *MemoryStore) GetInboundSenderByEmail(email string) (*InboundSender, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, sender := range s.inboundSenders {
+		if sender.Email == email {
+			copySender := *sender
+			return &copySender, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// DeleteInboundSender remove um remetente verificado
+func (s *MemoryStore) DeleteInboundSender(userID, senderID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sender, ok := s.inboundSenders[senderID]
+	if !ok || sender.UserID != userID {
+		return ErrNotFound
+	}
+	delete(s.inboundSenders, senderID)
+	return nil
+}
+
+// copyAPIKey copia a chave (as permissões não são compartilhadas)
+func copyAPIKey(key *APIKey) *APIKey {
+	copyKey := *key
+	copyKey.Scopes = append([]APIKeyScope(nil), key.Scopes...)
+	return &copyKey
+}
+
+// CreateAPIKey guarda uma chave pessoal de API
+func (s *MemoryStore) CreateAPIKey(key *APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.apiKeys {
+		if existing.KeyHash == key.KeyHash {
+			return ErrAlreadyExists
+		}
+	}
+	s.apiKeys[key.ID] = copyAPIKey(key)
+	return nil
+}
+
+// ListAPIKeys lista as chaves de API da conta
+func (s *MemoryStore) ListAPIKeys(userID string) ([]*APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]*APIKey, 0)
+	for _, key := range s.apiKeys {
+		if key.UserID == userID {
+			keys = append(keys, copyAPIKey(key))
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+	return keys, nil
+}
+
+// GetAPIKeyByHash busca a chave de API pelo hash
+func (s *MemoryStore) GetAPIKeyByHash(keyHash string) (*APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, key := range s.apiKeys {
+		if key.KeyHash == keyHash {
+			return copyAPIKey(key), nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// TouchAPIKey registra o último uso da chave
+func (s *MemoryStore) TouchAPIKey(keyID string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.apiKeys[keyID]
+	if !ok {
+		return ErrNotFound
+	}
+	key.LastUsedAt = &at
+	return nil
+}
+
+// DeleteAPIKey revoga uma chave de API
+func (s *MemoryStore) DeleteAPIKey(userID, keyID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.apiKeys[keyID]
+	if !ok || key.UserID != userID {
+		return ErrNotFound
+	}
+	delete(s.apiKeys, keyID)
+	return nil
+}
+
+// RecordLogin registra um login e informa se dispositivo/país são novos
+func (s *MemoryStore) RecordLogin(record *LoginRecord) (*LoginCheck, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history := s.loginHistory[record.UserID]
+	check := &LoginCheck{FirstLogin: len(history) == 0}
+
+	if len(history) > 0 {
+		knownDevice, knownCountry := false, false
+		for _, previous := range history {
+			if previous.DeviceHash == record.DeviceHash {
+				knownDevice = true
+			}
+			if previous.Country == record.Country {
+				knownCountry = true
+			}
+		}
+		check.NewDevice = record.DeviceHash != "" && !knownDevice
+		check.NewCountry = record.Country != "" && !knownCountry
+	}
+
+	if record.ID == "" {
+		record.ID = ids.New(ids.LoginAttempt)
+	}
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
+	}
+	s.loginHistory[record.UserID] = append(history, record)
+
+	return check, nil
+}
+
+// ExportUserData exporta todos os dados do usuário (LGPD: Portabilidade)
+func (s *MemoryStore) ExportUserData(userID string) (*UserDataExport, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, exists := s.users[userID]
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	// Criar cópia sem senha
+	userCopy := &User{
+		ID:        user.ID,
+		Email:     user.Email,
+		Name:      user.Name,
+		CreatedAt: user.CreatedAt,
+	}
+
+	// Coletar itens
+	items := make([]*BoxItem, 0)
+	for _, item := range s.items[userID] {
+		copyItem := *item
+		items = append(items, &copyItem)
+	}
+
+	// Coletar guardiões
+	guardians := make([]*Guardian, 0)
+	for _, g := range s.guardians[userID] {
+		copyG := *g
+		guardians = append(guardians, &copyG)
+	}
+
+	// Coletar progresso
+	progress := make([]*GuideProgress, 0)
+	for _, p := range s.progress[userID] {
+		copyP := *p
+		progress = append(progress, &copyP)
+	}
+
+	// Coletar configurações
+	var settings *Settings
+	if s, exists := s.settings[userID]; exists {
+		copyS := *s
+		settings = &copyS
+	}
+
+	return &UserDataExport{
+		User:       userCopy,
+		Items:      items,
+		Guardians:  guardians,
+		Progress:   progress,
+		Settings:   settings,
+		ExportedAt: time.Now(),
+	}, nil
+}
+
+// BackupUserData reúne a conta com os segredos para o backup administrativo
+func (s *MemoryStore) BackupUserData(userID string) (*UserBackup, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, exists := s.users[userID]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	userCopy := *user
+
+	backup := &UserBackup{
+		User:         &userCopy,
+		PasswordHash: user.Password,
+		Items:        make([]*BoxItem, 0),
+		Guardians:    make([]*GuardianBackup, 0),
+		ShareLinks:   make([]*ShareLinkBackup, 0),
+	}
+	for _, item := range s.items[userID] {
+		copyItem := *item
+		backup.Items = append(backup.Items, &copyItem)
+	}
+	for _, g := range s.guardians[userID] {
+		copyG := *g
+		backup.Guardians = append(backup.Guardians, &GuardianBackup{Guardian: &copyG, AccessPIN: g.AccessPIN, AccountUserID: g.AccountUserID})
+	}
+	for _, link := range s.shareLinks {
+		if link.UserID == userID {
+			copyLink := *link
+			backup.ShareLinks = append(backup.ShareLinks, &ShareLinkBackup{ShareLink: &copyLink, Token: link.Token, PIN: link.PIN})
+		}
+	}
+	return backup, nil
+}
+
+// RestoreUserData recria a conta do backup com os IDs originais
+func (s *MemoryStore) RestoreUserData(data *UserBackup) error {
+	if data == nil || data.User == nil || data.User.ID == "" {
+		return ErrInvalidData
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	normalized := strings.ToLower(strings.TrimSpace(data.User.Email))
+	if _, exists := s.users[data.User.ID]; exists {
+		return ErrAlreadyExists
+	}
+	if _, exists := s.usersByEmail[normalized]; exists {
+		return ErrAlreadyExists
+	}
+
+	user := *data.User
+	user.Password = data.PasswordHash
+	s.users[user.ID] = &user
+	s.usersByEmail[normalized] = user.ID
+	if user.ProviderID != "" {
+		s.usersByProvider[fmt.Sprintf("%s:%s", user.Provider, user.ProviderID)] = user.ID
+	}
+
+	s.guardians[user.ID] = make(map[string]*Guardian)
+	for _, backup := range data.Guardians {
+		g := *backup.Guardian
+		g.UserID = user.ID
+		g.AccessPIN = backup.AccessPIN
+		g.HasPIN = backup.AccessPIN != ""
+		if _, ok := s.users[backup.AccountUserID]; ok {
+			g.AccountUserID = backup.AccountUserID
+		}
+		g.HasAccount = g.AccountUserID != ""
+		s.guardians[user.ID][g.ID] = &g
+	}
+
+	s.items[user.ID] = make(map[string]*BoxItem)
+	for _, backup := range data.Items {
+		item := *backup
+		item.UserID = user.ID
+		if _, ok := s.households[item.HouseholdID]; !ok {
+			item.HouseholdID = "" // Famílias não fazem parte do backup
+		}
+		if item.Version == 0 {
+			item.Version = 1
+		}
+		s.items[user.ID][item.ID] = &item
+	}
+
+	for _, backup := range data.ShareLinks {
+		link := *backup.ShareLink
+		link.UserID = user.ID
+		link.Token = backup.Token
+		link.PIN = backup.PIN
+		s.shareLinks[link.ID] = &link
+		s.shareLinksByToken[link.Token] = link.ID
+	}
+	return nil
+}
+
+// ============ BOX ITEMS ============
+
+// GetBoxItems retorna os itens de um usuário (alias para compatibilidade)
+func (s *MemoryStore) GetBoxItems(userID string) ([]*BoxItem, error) {
+	items := s.ListBoxItems(userID)
+	return items, nil
+}
+
+func (s *MemoryStore) ListBoxItems(userID string) []*BoxItem {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	userItems := s.items[userID]
+	result := make([]*BoxItem, 0, len(userItems))
+	for _, item := range userItems {
+		copyItem := *item
+		result = append(result, &copyItem)
+	}
+	return result
+}
+
+func (s *MemoryStore) GetBoxItem(userID, itemID string) (*BoxItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	userItems, ok := s.items[userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	item, exists := userItems[itemID]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	copyItem := *item
+	return &copyItem, nil
+}
+
+func (s *MemoryStore) CreateBoxItem(userID string, item *BoxItem) (*BoxItem, error) {
+	return s.CreateBoxItemWithID(userID, item, ids.New(ids.Item))
+}
+
+func (s *MemoryStore) CreateBoxItemWithID(userID string, item *BoxItem, itemID string) (*BoxItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	item.ID = itemID
+	item.UserID = userID
+	item.CreatedAt = now
+	item.UpdatedAt = now
+	item.Version = 1
+
+	if _, ok := s.items[userID]; !ok {
+		s.items[userID] = make(map[string]*BoxItem)
+	}
+	s.items[userID][item.ID] = item
+	copyItem := *item
+	return &copyItem, nil
+}
+
+func (s *MemoryStore) UpdateBoxItem(userID, itemID string, updates *BoxItem) (*BoxItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userItems, ok := s.items[userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	item, exists := userItems[itemID]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	if updates.Version != 0 && updates.Version != item.Version {
+		return nil, ErrVersionConflict
+	}
+
+	item.Title = updates.Title
+	item.Content = updates.Content
+	item.Type = updates.Type
+	item.Category = updates.Category
+	item.Recipient = updates.Recipient
+	item.RecipientGuardianID = updates.RecipientGuardianID
+	item.Sealed = updates.Sealed
+	item.IsImportant = updates.IsImportant
+	item.IsShared = updates.IsShared
+	item.GuardianIDs = updates.GuardianIDs
+	item.DueDate = updates.DueDate
+	item.RenewalDate = updates.RenewalDate
+	item.HouseholdID = updates.HouseholdID
+	item.Tags = updates.Tags
+	item.Checklist = updates.Checklist
+	item.Schedule = updates.Schedule
+	item.Document = updates.Document
+	item.Pet = updates.Pet
+	item.FinancialAccount = updates.FinancialAccount
+	item.OnlineAccount = updates.OnlineAccount
+	item.ArchivedAt = updates.ArchivedAt
+	item.UpdatedAt = time.Now()
+	item.Version++
+
+	copyItem := *item
+	return &copyItem, nil
+}
+
+// SetChecklistEntryDone marca (ou desmarca) uma linha da lista do item
+func (s *MemoryStore) SetChecklistEntryDone(userID, itemID, entryID string, done bool) (*BoxItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[userID][itemID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	checklist, err := setChecklistDone(item.Checklist, entryID, done, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	item.Checklist = checklist
+	item.UpdatedAt = time.Now()
+	item.Version++
+
+	copyItem := *item
+	return &copyItem, nil
+}
+
+func (s *MemoryStore) DeleteBoxItem(userID, itemID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userItems, ok := s.items[userID]
+	if !ok {
+		return ErrNotFound
+	}
+	if _, exists := userItems[itemID]; !exists {
+		return ErrNotFound
+	}
+	delete(userItems, itemID)
+	s.deleteRelationsLocked(itemID)
+	s.deleteItemViewsLocked(itemID)
+	s.deleteItemCommentsLocked(itemID, "")
+	s.deleteItemOrderLocked("", itemID)
+	s.deleteExpiryRemindersLocked(itemID)
+	delete(s.itemSources, itemID)
+	return nil
+}
+
+// ListBoxItemsPaginated lista itens com paginação (cursor-based)
+func (s *MemoryStore) ListBoxItemsPaginated(userID string, params *PaginationParams) (*PaginatedResult[*BoxItemSummary], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	allItems := make([]*BoxItemSummary, 0, len(s.items[userID]))
+	for _, item := range s.items[userID] {
+		allItems = append(allItems, s.itemSummaryLocked(userID, item))
+	}
+	sortItemSummaries(allItems, BoxItemSortNewest)
+
+	return paginateItemSummaries(allItems, BoxItemSortNewest, params)
+}
+
+// CountBoxItems conta o total de itens de um usuário
+func (s *MemoryStore) CountBoxItems(userID string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.items[userID]), nil
+}
+
+// ============ RELAÇÕES ENTRE ITENS ============
+
+// CreateItemRelation grava um vínculo do item
+// Um "veja também" entre A e B é o mesmo vínculo que entre B e A.
+func (s *MemoryStore) CreateItemRelation(rel *ItemRelation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.itemRelations {
+		if existing.Kind != rel.Kind {
+			continue
+		}
+		same := existing.ItemID == rel.ItemID && existing.TargetID == rel.TargetID
+		mirrored := rel.Kind == RelationSeeAlso && existing.ItemID == rel.TargetID && existing.TargetID == rel.ItemID
+		if same || mirrored {
+			return ErrAlreadyExists
+		}
+	}
+
+	if rel.ID == "" {
+		rel.ID = ids.New(ids.Relation)
+	}
+	if rel.CreatedAt.IsZero() {
+		rel.CreatedAt = time.Now()
+	}
+	copyRel := *rel
+	s.itemRelations[rel.ID] = &copyRel
+	return nil
+}
+
+// ListItemRelations lista os vínculos do item (mais antigos primeiro)
+func (s *MemoryStore) ListItemRelations(itemID string) ([]*ItemRelation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*ItemRelation, 0)
+	for _, rel := range s.itemRelations {
+		if rel.ItemID == itemID || (rel.Kind == RelationSeeAlso && rel.TargetID == itemID) {
+			copyRel := *rel
+			result = append(result, &copyRel)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CreatedAt.Before(result[j].CreatedAt) })
+	return result, nil
+}
+
+// DeleteItemRelation remove um vínculo do item
+func (s *MemoryStore) DeleteItemRelation(itemID, relationID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rel, ok := s.itemRelations[relationID]
+	if !ok || (rel.ItemID != itemID && !(rel.Kind == RelationSeeAlso && rel.TargetID == itemID)) {
+		return ErrNotFound
+	}
+	delete(s.itemRelations, relationID)
+	return nil
+}
+
+// deleteRelationsLocked remove os vínculos de um item ou guardião removido
+// Requer s.mu (escrita)
+func (s *MemoryStore) deleteRelationsLocked(id string) {
+	for relID, rel := range s.itemRelations {
+		if rel.ItemID == id || rel.TargetID == id {
+			delete(s.itemRelations, relID)
+		}
+	}
+}
+
+// ============ RECIBOS DE LEITURA ============
+
+// RecordItemViews registra que a origem viu os itens
+func (s *MemoryStore) RecordItemViews(source ItemViewSource, sourceID string, itemIDs []string, at time.Time) (map[string]bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seenBefore := make(map[string]bool, len(itemIDs))
+	for _, itemID := range itemIDs {
+		key := itemID + "|" + string(source) + "|" + sourceID
+		view, ok := s.itemViews[key]
+		if !ok {
+			view = &ItemView{ItemID: itemID, Source: source, SourceID: sourceID, FirstViewedAt: at}
+			s.itemViews[key] = view
+		}
+		view.LastViewedAt = at
+		view.ViewCount++
+		seenBefore[itemID] = view.ViewCount > 1
+	}
+	return seenBefore, nil
+}
+
+// ListItemViews lista os recibos de leitura do item
+func (s *MemoryStore) ListItemViews(itemID string) ([]*ItemView, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*ItemView, 0)
+	for _, view := range s.itemViews {
+		if view.ItemID == itemID {
+			copyView := *view
+			result = append(result, &copyView)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].LastViewedAt.After(result[j].LastViewedAt) })
+	return result, nil
+}
+
+// deleteItemViewsLocked remove os recibos de um item removido
+// Requer s.mu (escrita)
+func (s *MemoryStore) deleteItemViewsLocked(itemID string) {
+	for key, view := range s.itemViews {
+		if view.ItemID == itemID {
+			delete(s.itemViews, key)
+		}
+	}
+}
+
+// ============ VALIDADE DOS DOCUMENTOS ============
+
+// ListExpiringDocuments lista os documentos vencidos ou que vencem até until
+// (pessoais e das famílias)
+func (s *MemoryStore) ListExpiringDocuments(filter *BoxItemFilter, until time.Time) ([]*BoxItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	households := make(map[string]bool, len(filter.HouseholdIDs))
+	for _, id := range filter.HouseholdIDs {
+		households[id] = true
+	}
+
+	result := make([]*BoxItem, 0)
+	for _, userItems := range s.items {
+		for _, item := range userItems {
+			if item.Type != ItemTypeDocument || item.Document == nil || item.Document.ExpiresOn == nil ||
+				item.Document.ExpiresOn.After(until) || !filter.Matches(item) {
+				continue
+			}
+			personal := filter.IncludePersonal && item.UserID == filter.UserID && item.HouseholdID == ""
+			if personal || (item.HouseholdID != "" && households[item.HouseholdID]) {
+				copyItem := *item
+				result = append(result, &copyItem)
+			}
+		}
+	}
+	return result, nil
+}
+
+// ListDocumentExpiries lista os documentos de todos os usuários que vencem
+// até until (sem os arquivados)
+func (s *MemoryStore) ListDocumentExpiries(until time.Time) ([]*DocumentExpiry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*DocumentExpiry, 0)
+	for _, userItems := range s.items {
+		for _, item := range userItems {
+			if item.Type != ItemTypeDocument || item.Document == nil || item.Document.ExpiresOn == nil ||
+				item.Document.ExpiresOn.After(until) || item.ArchivedAt != nil {
+				continue
+			}
+			result = append(result, &DocumentExpiry{ItemID: item.ID, UserID: item.UserID, ExpiresOn: *item.Document.ExpiresOn})
+		}
+	}
+	return result, nil
+}
+
+// ClaimExpiryReminder registra o lembrete de um documento
+// Cada antecedência é lembrada uma vez por validade: trocar a data gera
+// lembretes novos.
+func (s *MemoryStore) ClaimExpiryReminder(itemID string, expiresOn time.Time, daysBefore int, at time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := fmt.Sprintf("%s|%s|%d", itemID, expiresOn.Format("2006-01-02"), daysBefore)
+	if _, sent := s.expiryReminders[key]; sent {
+		return false, nil
+	}
+	s.expiryReminders[key] = at
+	return true, nil
+}
+
+// deleteExpiryRemindersLocked remove os lembretes de um item removido
+// Requer s.mu (escrita)
+func (s *MemoryStore) deleteExpiryRemindersLocked(itemID string) {
+	for key := range s.expiryReminders {
+		if strings.HasPrefix(key, itemID+"|") {
+			delete(s.expiryReminders, key)
+		}
+	}
+}
+
+// ============ CALENDÁRIO ============
+
+// ListDatedBoxItems lista os itens com vencimento ou renovação (sem conteúdo)
+func (s *MemoryStore) ListDatedBoxItems(userID string) ([]*BoxItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*BoxItem, 0)
+	for _, item := range s.items[userID] {
+		if !item.HasDates() || item.ArchivedAt != nil {
+			continue
+		}
+		copyItem := *item
+		copyItem.Content = ""
+		copyItem.Recipient = ""
+		result = append(result, &copyItem)
+	}
+	return result, nil
+}
+
+func (s *MemoryStore) SaveCalendarFeed(feed *CalendarFeed) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copyFeed := *feed
+	s.calendarFeeds[feed.UserID] = &copyFeed
+	return nil
+}
+
+func (s *MemoryStore) GetCalendarFeed(userID string) (*CalendarFeed, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	feed, ok := s.calendarFeeds[userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copyFeed := *feed
+	return &copyFeed, nil
+}
+
+func (s *MemoryStore) GetCalendarFeedByToken(tokenHash string) (*CalendarFeed, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, feed := range s.calendarFeeds {
+		if feed.TokenHash == tokenHash {
+			copyFeed := *feed
+			return &copyFeed, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) DeleteCalendarFeed(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.calendarFeeds[userID]; !ok {
+		return ErrNotFound
+	}
+	delete(s.calendarFeeds, userID)
+	return nil
+}
+
+func (s *MemoryStore) TouchCalendarFeed(userID string, accessedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	feed, ok := s.calendarFeeds[userID]
+	if !ok {
+		return ErrNotFound
+	}
+	feed.LastAccessedAt = &accessedAt
+	return nil
+}
+
+// ============ CAPTURA PELO NAVEGADOR ============
+
+func (s *MemoryStore) SaveCaptureToken(token *CaptureToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copyToken := *token
+	s.captureTokens[token.UserID] = &copyToken
+	return nil
+}
+
+func (s *MemoryStore) GetCaptureToken(userID string) (*CaptureToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	token, ok := s.captureTokens[userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copyToken := *token
+	return &copyToken, nil
+}
+
+func (s *MemoryStore) GetCaptureTokenByHash(tokenHash string) (*CaptureToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, token := range s.captureTokens {
+		if token.TokenHash == tokenHash {
+			copyToken := *token
+			return &copyToken, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) DeleteCaptureToken(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.captureTokens[userID]; !ok {
+		return ErrNotFound
+	}
+	delete(s.captureTokens, userID)
+	return nil
+}
+
+func (s *MemoryStore) TouchCaptureToken(userID string, usedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.captureTokens[userID]
+	if !ok {
+		return ErrNotFound
+	}
+	token.LastUsedAt = &usedAt
+	return nil
+}
+
+func (s *MemoryStore) SaveItemSource(source *ItemSource) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copySource := *source
+	s.itemSources[source.ItemID] = &copySource
+	return nil
+}
+
+func (s *MemoryStore) GetItemSource(itemID string) (*ItemSource, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	source, ok := s.itemSources[itemID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copySource := *source
+	return &copySource, nil
+}
+
+// ============ CAIXA PESSOAL + FAMÍLIAS ============
+
+// GetBoxItemByID busca um item sem filtrar pelo dono
+func (s *MemoryStore) GetBoxItemByID(itemID string) (*BoxItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, userItems := range s.items {
+		if item, ok := userItems[itemID]; ok {
+			copyItem := *item
+			return &copyItem, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// accessibleItemsLocked retorna os itens do filtro, na ordenação pedida
+// Requer s.mu (leitura)
+func (s *MemoryStore) accessibleItemsLocked(filter *BoxItemFilter) []*BoxItemSummary {
+	households := make(map[string]bool, len(filter.HouseholdIDs))
+	for _, id := range filter.HouseholdIDs {
+		households[id] = true
+	}
+
+	var result []*BoxItemSummary
+	for _, userItems := range s.items {
+		for _, item := range userItems {
+			personal := filter.IncludePersonal && item.UserID == filter.UserID && item.HouseholdID == ""
+			if (personal || (item.HouseholdID != "" && households[item.HouseholdID])) && filter.Matches(item) {
+				result = append(result, s.itemSummaryLocked(filter.UserID, item))
+			}
+		}
+	}
+	sortItemSummaries(result, filter.Sort)
+	return result
+}
+
+// ListScheduledRoutines lista as rotinas com agenda (pessoais e das famílias)
+func (s *MemoryStore) ListScheduledRoutines(filter *BoxItemFilter) ([]*BoxItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	households := make(map[string]bool, len(filter.HouseholdIDs))
+	for _, id := range filter.HouseholdIDs {
+		households[id] = true
+	}
+
+	result := make([]*BoxItem, 0)
+	for _, userItems := range s.items {
+		for _, item := range userItems {
+			if item.Type != ItemTypeRoutine || item.Schedule == nil || !filter.Matches(item) {
+				continue
+			}
+			personal := filter.IncludePersonal && item.UserID == filter.UserID && item.HouseholdID == ""
+			if personal || (item.HouseholdID != "" && households[item.HouseholdID]) {
+				copyItem := *item
+				result = append(result, &copyItem)
+			}
+		}
+	}
+	return result, nil
+}
+
+// itemSummaryLocked monta o resumo do item com a ordem de quem lista
+// Requer s.mu (leitura)
+func (s *MemoryStore) itemSummaryLocked(viewerID string, item *BoxItem) *BoxItemSummary {
+	summary := &BoxItemSummary{
+		ID:          item.ID,
+		Type:        item.Type,
+		Title:       item.Title,
+		Category:    item.Category,
+		IsImportant: item.IsImportant,
+		IsShared:    item.IsShared,
+		GuardianIDs: item.GuardianIDs,
+		CreatedAt:   item.CreatedAt,
+		UpdatedAt:   item.UpdatedAt,
+		Version:     item.Version,
+		DueDate:     item.DueDate,
+		RenewalDate: item.RenewalDate,
+		Tags:        item.Tags,
+		ArchivedAt:  item.ArchivedAt,
+		OwnerID:     item.UserID,
+		HouseholdID: item.HouseholdID,
+	}
+	if item.Document != nil {
+		summary.ExpiresOn = item.Document.ExpiresOn
+	}
+	if entry, ok := s.itemOrder[viewerID+"|"+item.ID]; ok {
+		summary.Pinned = entry.Pinned
+		summary.Position = entry.Position
+	}
+	return summary
+}
+
+// ListAccessibleBoxItemsPaginated lista itens pessoais e das famílias (cursor-based)
+func (s *MemoryStore) ListAccessibleBoxItemsPaginated(filter *BoxItemFilter, params *PaginationParams) (*PaginatedResult[*BoxItemSummary], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return paginateItemSummaries(s.accessibleItemsLocked(filter), filter.Sort, params)
+}
+
+// CountAccessibleBoxItems conta itens pessoais e das famílias
+func (s *MemoryStore) CountAccessibleBoxItems(filter *BoxItemFilter) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.accessibleItemsLocked(filter)), nil
+}
+
+// SetBoxItemPinned fixa (ou solta) o item na listagem do usuário
+func (s *MemoryStore) SetBoxItemPinned(userID, itemID string, pinned bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.itemOrderEntryLocked(userID, itemID).Pinned = pinned
+	return nil
+}
+
+// ReorderBoxItems define a posição manual dos itens pela ordem da lista
+func (s *MemoryStore) ReorderBoxItems(userID string, itemIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, itemID := range itemIDs {
+		s.itemOrderEntryLocked(userID, itemID).Position = i + 1
+	}
+	return nil
+}
+
+// itemOrderEntryLocked retorna (criando) a ordem do item para o usuário
+// Requer s.mu (escrita)
+func (s *MemoryStore) itemOrderEntryLocked(userID, itemID string) *itemOrderEntry {
+	key := userID + "|" + itemID
+	entry, ok := s.itemOrder[key]
+	if !ok {
+		entry = &itemOrderEntry{}
+		s.itemOrder[key] = entry
+	}
+	return entry
+}
+
+// deleteItemOrderLocked remove a ordem de um usuário ou de um item removido
+// (vazio = qualquer um)
+// Requer s.mu (escrita)
+func (s *MemoryStore) deleteItemOrderLocked(userID, itemID string) {
+	for key := range s.itemOrder {
+		keyUser, keyItem, _ := strings.Cut(key, "|")
+		if (userID == "" || keyUser == userID) && (itemID == "" || keyItem == itemID) {
+			delete(s.itemOrder, key)
+		}
+	}
+}
+
+// ============ FAMÍLIAS ============
+
+func (s *MemoryStore) CreateHousehold(household *Household, owner *HouseholdMember) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copyHousehold := *household
+	copyOwner := *owner
+	s.households[household.ID] = &copyHousehold
+	s.householdMembers[household.ID] = map[string]*HouseholdMember{owner.UserID: &copyOwner}
+	return nil
+}
+
+func (s *MemoryStore) GetHousehold(householdID string) (*Household, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	household, ok := s.households[householdID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copyHousehold := *household
+	return &copyHousehold, nil
+}
+
+func (s *MemoryStore) UpdateHousehold(household *Household) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.households[household.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	existing.Name = household.Name
+	existing.UpdatedAt = household.UpdatedAt
+	return nil
+}
+
+func (s *MemoryStore) DeleteHousehold(householdID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.households[householdID]; !ok {
+		return ErrNotFound
+	}
+	s.deleteHouseholdLocked(householdID)
+	return nil
+}
+
+// deleteHouseholdLocked remove a família e devolve os itens a quem os criou
+// Requer s.mu (escrita)
+func (s *MemoryStore) deleteHouseholdLocked(householdID string) {
+	for _, userItems := range s.items {
+		for _, item := range userItems {
+			if item.HouseholdID == householdID {
+				item.HouseholdID = ""
+			}
+		}
+	}
+	delete(s.householdMembers, householdID)
+	delete(s.households, householdID)
+}
+
+func (s *MemoryStore) ListHouseholdsForUser(userID string) ([]*Household, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*Household, 0)
+	for householdID, members := range s.householdMembers {
+		if _, ok := members[userID]; !ok {
+			continue
+		}
+		if household, ok := s.households[householdID]; ok {
+			copyHousehold := *household
+			result = append(result, &copyHousehold)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CreatedAt.Before(result[j].CreatedAt) })
+	return result, nil
+}
+
+func (s *MemoryStore) AddHouseholdMember(member *HouseholdMember) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	members, ok := s.householdMembers[member.HouseholdID]
+	if !ok {
+		return ErrNotFound
+	}
+	if _, exists := members[member.UserID]; exists {
+		return ErrAlreadyExists
+	}
+	copyMember := *member
+	members[member.UserID] = &copyMember
+	return nil
+}
+
+func (s *MemoryStore) GetHouseholdMember(householdID, userID string) (*HouseholdMember, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	member, ok := s.householdMembers[householdID][userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copyMember := *member
+	return &copyMember, nil
+}
+
+func (s *MemoryStore) ListHouseholdMembers(householdID string) ([]*HouseholdMember, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*HouseholdMember, 0)
+	for _, member := range s.householdMembers[householdID] {
+		copyMember := *member
+		if user, ok := s.users[member.UserID]; ok {
+			copyMember.Name = user.Name
+			copyMember.Email = user.Email
+		}
+		result = append(result, &copyMember)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CreatedAt.Before(result[j].CreatedAt) })
+	return result, nil
+}
+
+func (s *MemoryStore) UpdateHouseholdMember(member *HouseholdMember) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.householdMembers[member.HouseholdID][member.UserID]
+	if !ok {
+		return ErrNotFound
+	}
+	existing.Permission = member.Permission
+	existing.Status = member.Status
+	existing.JoinedAt = member.JoinedAt
+	return nil
+}
+
+func (s *MemoryStore) RemoveHouseholdMember(householdID, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	members := s.householdMembers[householdID]
+	if _, ok := members[userID]; !ok {
+		return ErrNotFound
+	}
+	delete(members, userID)
+
+	for _, item := range s.items[userID] {
+		if item.HouseholdID == householdID {
+			item.HouseholdID = ""
+		}
+	}
+	return nil
+}
+
+// ============ GUARDIANS ============
+
+// GetGuardians retorna os guardiões de um usuário (alias para compatibilidade)
+func (s *MemoryStore) GetGuardians(userID string) ([]*Guardian, error) {
+	guardians := s.ListGuardians(userID)
+	return guardians, nil
+}
+
+func (s *MemoryStore) ListGuardians(userID string) []*Guardian {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	userGuardians := s.guardians[userID]
+	result := make([]*Guardian, 0, len(userGuardians))
+	for _, guardian := range userGuardians {
+		copyGuardian := *guardian
+		result = append(result, &copyGuardian)
+	}
+	return result
+}
+
+func (s *MemoryStore) CreateGuardian(userID string, guardian *Guardian) (*Guardian, error) {
+	return s.CreateGuardianWithID(userID, guardian, ids.New(ids.Guardian))
+}
+
+func (s *MemoryStore) CreateGuardianWithID(userID string, guardian *Guardian, guardianID string) (*Guardian, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	guardian.ID = guardianID
+	guardian.UserID = userID
+	guardian.CreatedAt = now
+	guardian.UpdatedAt = now
+
+	if guardian.Role == "" {
+		guardian.Role = "viewer"
+	}
+
+	// Gerar access_token único
+	guardian.AccessToken = security.GenerateAccessToken()
+	if guardian.AccessType == "" {
+		guardian.AccessType = GuardianAccessNormal
+	}
+
+	if _, ok := s.guardians[userID]; !ok {
+		s.guardians[userID] = make(map[string]*Guardian)
+	}
+	s.guardians[userID][guardian.ID] = guardian
+	copyGuardian := *guardian
+	return &copyGuardian, nil
+}
+
+func (s *MemoryStore) UpdateGuardian(userID, guardianID string, updates *Guardian) (*Guardian, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userGuardians, ok := s.guardians[userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	guardian, exists := userGuardians[guardianID]
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	guardian.Name = updates.Name
+	guardian.Email = updates.Email
+	guardian.Phone = updates.Phone
+	guardian.Relationship = updates.Relationship
+	guardian.Notes = updates.Notes
+	guardian.NotifyChannel = updates.NotifyChannel
+	guardian.UpdatedAt = time.Now()
+
+	copyGuardian := *guardian
+	return &copyGuardian, nil
+}
+
+func (s *MemoryStore) DeleteGuardian(userID, guardianID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userGuardians, ok := s.guardians[userID]
+	if !ok {
+		return ErrNotFound
+	}
+	if _, exists := userGuardians[guardianID]; !exists {
+		return ErrNotFound
+	}
+	delete(userGuardians, guardianID)
+	s.deleteRelationsLocked(guardianID)
+	s.deleteGuardianAlertsLocked("", guardianID)
+	s.deleteItemCommentsLocked("", guardianID)
+	delete(s.guardianPolicies, guardianID)
+	for _, item := range s.items[userID] {
+		if item.RecipientGuardianID == guardianID {
+			item.RecipientGuardianID = "" // O texto do destinatário continua
+		}
+	}
+	return nil
+}
+
+// ListGuardiansPaginated lista guardiões com paginação
+func (s *MemoryStore) ListGuardiansPaginated(userID string, params *PaginationParams) (*PaginatedResult[*Guardian], error) {
+	params = NormalizePagination(params)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	userGuardians := s.guardians[userID]
+
+	// Converter para slice
+	var allGuardians []*Guardian
+	for _, g := range userGuardians {
+		copyG := *g
+		allGuardians = append(allGuardians, &copyG)
+	}
+
+	// Ordenar por ID desc
+	for i := 0; i < len(allGuardians); i++ {
+		for j := i + 1; j < len(allGuardians); j++ {
+			if allGuardians[i].ID < allGuardians[j].ID {
+				allGuardians[i], allGuardians[j] = allGuardians[j], allGuardians[i]
+			}
+		}
+	}
+
+	// Aplicar cursor
+	startIdx := 0
+	if params.Cursor != "" {
+		for i, g := range allGuardians {
+			if g.ID == params.Cursor {
+				startIdx = i + 1
+				break
+			}
+		}
+	}
+
+	// Paginar
+	endIdx := startIdx + params.Limit + 1
+	if endIdx > len(allGuardians) {
+		endIdx = len(allGuardians)
+	}
+
+	pagedGuardians := allGuardians[startIdx:endIdx]
+	hasMore := len(pagedGuardians) > params.Limit
+	if hasMore {
+		pagedGuardians = pagedGuardians[:params.Limit]
+	}
+
+	var nextCursor string
+	if hasMore && len(pagedGuardians) > 0 {
+		nextCursor = pagedGuardians[len(pagedGuardians)-1].ID
+	}
+
+	return &PaginatedResult[*Guardian]{
+		Items:      pagedGuardians,
+		NextCursor: nextCursor,
+		HasMore:    hasMore,
+	}, nil
+}
+
+// CountGuardians conta o total de guardiões de um usuário
+func (s *MemoryStore) CountGuardians(userID string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.guardians[userID]), nil
+}
+
+// GetGuardianByAccessToken busca um guardião pelo seu token de acesso
+func (s *MemoryStore) GetGuardianByAccessToken(token string) (*Guardian, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, userGuardians := range s.guardians {
+		for _, g := range userGuardians {
+			if g.AccessToken == token {
+				copyGuardian := *g
+				return &copyGuardian, nil
+			}
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// ListGuardiansByAccount lista os guardiões vinculados a uma conta (de todos os donos)
+func (s *MemoryStore) ListGuardiansByAccount(accountUserID string) ([]*Guardian, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*Guardian, 0)
+	for _, userGuardians := range s.guardians {
+		for _, g := range userGuardians {
+			if g.AccountUserID == accountUserID {
+				copyGuardian := *g
+				result = append(result, &copyGuardian)
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CreatedAt.After(result[j].CreatedAt) })
+	return result, nil
+}
+
+// LinkGuardianAccount vincula (ou desvincula, com "") a conta ao guardião
+func (s *MemoryStore) LinkGuardianAccount(guardianID, accountUserID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, userGuardians := range s.guardians {
+		if g, ok := userGuardians[guardianID]; ok {
+			g.AccountUserID = accountUserID
+			g.HasAccount = accountUserID != ""
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// RotateGuardianAccessToken gera um novo token de acesso para o guardião
+func (s *MemoryStore) RotateGuardianAccessToken(guardianID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, userGuardians := range s.guardians {
+		if g, ok := userGuardians[guardianID]; ok {
+			g.AccessToken = security.GenerateAccessToken()
+			g.UpdatedAt = time.Now()
+			return g.AccessToken, nil
+		}
+	}
+	return "", ErrNotFound
+}
+
+// SetGuardianAccessDisabled desativa (ou, com nil, reativa) o acesso do guardião
+func (s *MemoryStore) SetGuardianAccessDisabled(guardianID string, at *time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, userGuardians := range s.guardians {
+		if g, ok := userGuardians[guardianID]; ok {
+			if at != nil {
+				disabledAt := *at
+				at = &disabledAt
+			}
+			g.AccessDisabledAt = at
+			g.UpdatedAt = time.Now()
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// copyAccessPolicy copia as regras (inclusive as janelas)
+func copyAccessPolicy(policy *GuardianAccessPolicy) *GuardianAccessPolicy {
+	copyPolicy := *policy
+	copyPolicy.Windows = make([]AccessWindow, len(policy.Windows))
+	for i, window := range policy.Windows {
+		window.Weekdays = append([]time.Weekday(nil), window.Weekdays...)
+		copyPolicy.Windows[i] = window
+	}
+	return &copyPolicy
+}
+
+// GetGuardianAccessPolicy busca as regras de acesso do guardião
+func (s *MemoryStore) GetGuardianAccessPolicy(guardianID string) (*GuardianAccessPolicy, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	policy, ok := s.guardianPolicies[guardianID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyAccessPolicy(policy), nil
+}
+
+// SaveGuardianAccessPolicy cria ou substitui as regras de acesso do guardião
+func (s *MemoryStore) SaveGuardianAccessPolicy(policy *GuardianAccessPolicy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	policy.UpdatedAt = time.Now()
+	s.guardianPolicies[policy.GuardianID] = copyAccessPolicy(policy)
+	return nil
+}
+
+// DeleteGuardianAccessPolicy remove as regras de acesso do guardião
+func (s *MemoryStore) DeleteGuardianAccessPolicy(guardianID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.guardianPolicies[guardianID]; !ok {
+		return ErrNotFound
+	}
+	delete(s.guardianPolicies, guardianID)
+	return nil
+}
+
+// ClaimGuardianPolicyAccess conta um acesso ao conteúdo nas regras do
+// guardião, se o limite ainda permitir (sem regras, nada a contar)
+func (s *MemoryStore) ClaimGuardianPolicyAccess(guardianID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	policy, ok := s.guardianPolicies[guardianID]
+	if !ok {
+		return true, nil
+	}
+	if policy.MaxAccesses > 0 && policy.AccessCount >= policy.MaxAccesses {
+		return false, nil
+	}
+	policy.AccessCount++
+	return true, nil
+}
+
+// MarkGuardianPolicyBlocked registra o aviso de acesso barrado, se o último
+// foi antes de notifiedBefore
+func (s *MemoryStore) MarkGuardianPolicyBlocked(guardianID string, notifiedBefore time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	policy, ok := s.guardianPolicies[guardianID]
+	if !ok {
+		return false, ErrNotFound
+	}
+	if policy.BlockedNotifiedAt != nil && !policy.BlockedNotifiedAt.Before(notifiedBefore) {
+		return false, nil
+	}
+	now := time.Now()
+	policy.BlockedNotifiedAt = &now
+	return true, nil
+}
+
+// ListItemViewsBySource lista os recibos de leitura de uma origem
+func (s *MemoryStore) ListItemViewsBySource(source ItemViewSource, sourceID string) ([]*ItemView, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*ItemView, 0)
+	for _, view := range s.itemViews {
+		if view.Source == source && view.SourceID == sourceID {
+			copyView := *view
+			result = append(result, &copyView)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].LastViewedAt.After(result[j].LastViewedAt) })
+	return result, nil
+}
+
+// RequestGuardianDeletion marca o guardião para remoção
+// Pedidos repetidos mantêm a data do primeiro.
+func (s *MemoryStore) RequestGuardianDeletion(guardianID string, at time.Time) (*Guardian, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, userGuardians := range s.guardians {
+		if g, ok := userGuardians[guardianID]; ok {
+			if g.DeletionRequestedAt == nil {
+				requestedAt := at
+				g.DeletionRequestedAt = &requestedAt
+				g.UpdatedAt = at
+			}
+			copyGuardian := *g
+			return &copyGuardian, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// PurgeGuardiansPendingDeletion apaga os guardiões com remoção pedida antes
+// de requestedBefore, junto com os recibos de leitura e vínculos
+func (s *MemoryStore) PurgeGuardiansPendingDeletion(requestedBefore time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	purged := 0
+	for userID, userGuardians := range s.guardians {
+		for guardianID, g := range userGuardians {
+			if g.DeletionRequestedAt == nil || g.DeletionRequestedAt.After(requestedBefore) {
+				continue
+			}
+			delete(userGuardians, guardianID)
+			s.deleteRelationsLocked(guardianID)
+			s.deleteGuardianAlertsLocked("", guardianID)
+			s.deleteItemCommentsLocked("", guardianID)
+			delete(s.guardianPolicies, guardianID)
+			for key, view := range s.itemViews {
+				if view.Source == ItemViewGuardian && view.SourceID == guardianID {
+					delete(s.itemViews, key)
+				}
+			}
+			for _, item := range s.items[userID] {
+				if item.RecipientGuardianID == guardianID {
+					item.RecipientGuardianID = ""
+				}
+			}
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// ListSharedItems lista itens compartilhados de um usuário
+func (s *MemoryStore) ListSharedItems(userID string) []*BoxItem {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []*BoxItem
+	for _, item := range s.items[userID] {
+		if item.IsShared && item.ArchivedAt == nil {
+			copyItem := *item
+			result = append(result, &copyItem)
+		}
+	}
+	return result
+}
+
+// ListItemsForRecipient lista os itens do usuário endereçados ao guardião
+func (s *MemoryStore) ListItemsForRecipient(userID, guardianID string) ([]*BoxItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*BoxItem, 0)
+	for _, item := range s.items[userID] {
+		if item.RecipientGuardianID == guardianID {
+			copyItem := *item
+			result = append(result, &copyItem)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].UpdatedAt.After(result[j].UpdatedAt) })
+	return result, nil
+}
+
+// ============ AVISOS AOS GUARDIÕES ============
+
+// CreateGuardianAlert registra um novo aviso a um guardião
+func (s *MemoryStore) CreateGuardianAlert(alert *GuardianAlert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.guardianAlerts[alert.ID] = copyGuardianAlert(alert)
+	return nil
+}
+
+// UpdateGuardianAlert atualiza o resultado de uma tentativa de entrega
+func (s *MemoryStore) UpdateGuardianAlert(alert *GuardianAlert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.guardianAlerts[alert.ID]; !ok {
+		return ErrNotFound
+	}
+	s.guardianAlerts[alert.ID] = copyGuardianAlert(alert)
+	return nil
+}
+
+// ListGuardianAlerts lista os avisos mais recentes aos guardiões do usuário
+func (s *MemoryStore) ListGuardianAlerts(userID string, limit int) ([]*GuardianAlert, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	alerts := make([]*GuardianAlert, 0)
+	for _, alert := range s.guardianAlerts {
+		if alert.UserID == userID {
+			alerts = append(alerts, copyGuardianAlert(alert))
+		}
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].CreatedAt.After(alerts[j].CreatedAt)
+	})
+	if limit > 0 && len(alerts) > limit {
+		alerts = alerts[:limit]
+	}
+	return alerts, nil
+}
+
+// ListDueGuardianAlerts lista avisos pendentes cuja próxima tentativa já venceu
+func (s *MemoryStore) ListDueGuardianAlerts(now time.Time, limit int) ([]*GuardianAlert, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	due := make([]*GuardianAlert, 0)
+	for _, alert := range s.guardianAlerts {
+		if alert.Status != GuardianAlertPending || alert.NextAttemptAt == nil || alert.NextAttemptAt.After(now) {
+			continue
+		}
+		due = append(due, copyGuardianAlert(alert))
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].NextAttemptAt.Before(*due[j].NextAttemptAt)
+	})
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+// deleteGuardianAlertsLocked remove os avisos de um usuário ou de um guardião
+// removido (vazio = qualquer um)
+// Requer s.mu (escrita)
+func (s *MemoryStore) deleteGuardianAlertsLocked(userID, guardianID string) {
+	for id, alert := range s.guardianAlerts {
+		if (userID == "" || alert.UserID == userID) && (guardianID == "" || alert.GuardianID == guardianID) {
+			delete(s.guardianAlerts, id)
+		}
+	}
+}
+
+// copyGuardianAlert copia o aviso sem compartilhar o log de tentativas nem os canais
+func copyGuardianAlert(alert *GuardianAlert) *GuardianAlert {
+	copyAlert := *alert
+	copyAlert.Log = make([]GuardianAlertAttempt, len(alert.Log))
+	copy(copyAlert.Log, alert.Log)
+	if alert.Channels != nil {
+		copyAlert.Channels = append([]NotifyChannel(nil), alert.Channels...)
+	}
+	return &copyAlert
+}
+
+// ============ ITEM COMMENTS ============
+
+// CreateItemComment salva o comentário de um guardião em um item
+func (s *MemoryStore) CreateItemComment(comment *ItemComment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copyComment := *comment
+	s.itemComments[comment.ID] = &copyComment
+	return nil
+}
+
+// ListItemComments lista os comentários de um item, mais antigos primeiro
+func (s *MemoryStore) ListItemComments(itemID string) ([]*ItemComment, error) {
+	return s.listItemComments(func(c *ItemComment) bool { return c.ItemID == itemID }, false), nil
+}
+
+// ListItemCommentsByGuardian lista os comentários de um guardião, mais recentes primeiro
+func (s *MemoryStore) ListItemCommentsByGuardian(guardianID string) ([]*ItemComment, error) {
+	return s.listItemComments(func(c *ItemComment) bool { return c.GuardianID == guardianID }, true), nil
+}
+
+func (s *MemoryStore) listItemComments(match func(*ItemComment) bool, newestFirst bool) []*ItemComment {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	comments := make([]*ItemComment, 0)
+	for _, comment := range s.itemComments {
+		if match(comment) {
+			copyComment := *comment
+			comments = append(comments, &copyComment)
+		}
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		if newestFirst {
+			return comments[i].CreatedAt.After(comments[j].CreatedAt)
+		}
+		return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+	})
+	return comments
+}
+
+// DeleteItemComment remove um comentário do item
+func (s *MemoryStore) DeleteItemComment(itemID, commentID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	comment, ok := s.itemComments[commentID]
+	if !ok || comment.ItemID != itemID {
+		return ErrNotFound
+	}
+	delete(s.itemComments, commentID)
+	return nil
+}
+
+// deleteItemCommentsLocked remove os comentários de um item ou de um guardião
+// removido (vazio = qualquer um)
+// Requer s.mu (escrita)
+func (s *MemoryStore) deleteItemCommentsLocked(itemID, guardianID string) {
+	for id, comment := range s.itemComments {
+		if (itemID == "" || comment.ItemID == itemID) && (guardianID == "" || comment.GuardianID == guardianID) {
+			delete(s.itemComments, id)
+		}
+	}
+}
+
+// ============ IDEMPOTÊNCIA ============
+
+func (s *MemoryStore) RegisterIdempotencyKey(userID, key, resourceType, resourceID string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.idempotencyKeys[userID]; !ok {
+		s.idempotencyKeys[userID] = make(map[string]map[string]string)
+	}
+	if _, ok := s.idempotencyKeys[userID][resourceType]; !ok {
+		s.idempotencyKeys[userID][resourceType] = make(map[string]string)
+	}
+
+	if existingID, ok := s.idempotencyKeys[userID][resourceType][key]; ok {
+		return existingID, false, nil
+	}
+
+	s.idempotencyKeys[userID][resourceType][key] = resourceID
+	return "", true, nil
+}
+
+func (s *MemoryStore) DeleteIdempotencyKey(userID, key, resourceType string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.idempotencyKeys[userID]; !ok {
+		return nil
+	}
+	if _, ok := s.idempotencyKeys[userID][resourceType]; !ok {
+		return nil
+	}
+	delete(s.idempotencyKeys[userID][resourceType], key)
+	return nil
+}
+
+// ============ GUIDE PROGRESS ============
+
+func (s *MemoryStore) GetGuideProgress(userID string) map[string]*GuideProgress {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	userProgress := s.progress[userID]
+	result := make(map[string]*GuideProgress)
+	for cardID, p := range userProgress {
+		copyProgress := *p
+		result[cardID] = &copyProgress
+	}
+	return result
+}
+
+func (s *MemoryStore) UpdateGuideProgress(userID, cardID, status string) (*GuideProgress, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.progress[userID]; !ok {
+		s.progress[userID] = make(map[string]*GuideProgress)
+	}
+
+	progress := &GuideProgress{
+		UserID: userID,
+		CardID: cardID,
+		Status: status,
+	}
+	if status == "completed" {
+		progress.CompletedAt = time.Now()
+	}
+
+	s.progress[userID][cardID] = progress
+	return progress, nil
+}
+
+// ============ SETTINGS ============
+
+func (s *MemoryStore) GetSettings(userID string) *Settings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	settings, ok := s.settings[userID]
+	if !ok {
+		settings = &Settings{
+			UserID:               userID,
+			NotificationsEnabled: true,
+			Theme:                "light",
+			DigestFrequency:      DigestOff,
+		}
+		s.settings[userID] = settings
+	}
+	copySettings := *settings
+	copySettings.NotificationOptOuts = append([]NotificationCategory{}, settings.NotificationOptOuts...)
+	return &copySettings
+}
+
+func (s *MemoryStore) UpdateSettings(userID string, updates *Settings) *Settings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	updates.UserID = userID
+	updates.DigestFrequency = DigestOff
+	updates.MessagingOptOut = false
+	updates.MessagingConsentAt = nil
+	if current, ok := s.settings[userID]; ok {
+		updates.DigestFrequency = current.DigestFrequency
+		updates.MessagingOptOut = current.MessagingOptOut
+		updates.MessagingConsentAt = current.MessagingConsentAt
+	}
+	stored := *updates
+	stored.NotificationOptOuts = append([]NotificationCategory{}, updates.NotificationOptOuts...)
+	s.settings[userID] = &stored
+	copySettings := *updates
+	return &copySettings
+}
+
+// UpdateDigestFrequency liga, muda ou desliga o resumo pelo WhatsApp
+// since é a referência do próximo resumo (itens novos a partir dela).
+func (s *MemoryStore) UpdateDigestFrequency(userID string, frequency DigestFrequency, since time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	settings, ok := s.settings[userID]
+	if !ok {
+		settings = &Settings{UserID: userID, NotificationsEnabled: true, Theme: "light"}
+		s.settings[userID] = settings
+	}
+	settings.DigestFrequency = frequency
+	s.digestSentAt[userID] = since
+	return nil
+}
+
+// UpdateMessagingConsent registra o pedido de parar (ou voltar a receber)
+// mensagens pelo WhatsApp
+func (s *MemoryStore) UpdateMessagingConsent(userID string, optOut bool, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	settings, ok := s.settings[userID]
+	if !ok {
+		settings = &Settings{UserID: userID, NotificationsEnabled: true, Theme: "light", DigestFrequency: DigestOff}
+		s.settings[userID] = settings
+	}
+	settings.MessagingOptOut = optOut
+	settings.MessagingConsentAt = &at
+	return nil
+}
+
+// SaveConversationDraft guarda o item abandonado no meio da conversa
+func (s *MemoryStore) SaveConversationDraft(draft *ConversationDraft) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copyDraft := *draft
+	s.conversationDrafts[draft.UserID] = &copyDraft
+	return nil
+}
+
+// GetConversationDraft retorna o rascunho do usuário
+func (s *MemoryStore) GetConversationDraft(userID string) (*ConversationDraft, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	draft, ok := s.conversationDrafts[userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copyDraft := *draft
+	return &copyDraft, nil
+}
+
+// DeleteConversationDraft descarta o rascunho do usuário
+func (s *MemoryStore) DeleteConversationDraft(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.conversationDrafts, userID)
+	return nil
+}
+
+// ListDigestSubscriptions lista os usuários com resumo ligado
+// Quem pediu para não receber mensagens fica de fora.
+func (s *MemoryStore) ListDigestSubscriptions() ([]*DigestSubscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []*DigestSubscription
+	for userID, settings := range s.settings {
+		if settings.DigestFrequency.Period() == 0 || settings.MessagingOptOut {
+			continue
+		}
+		result = append(result, &DigestSubscription{
+			UserID:     userID,
+			Frequency:  settings.DigestFrequency,
+			LastSentAt: s.digestSentAt[userID],
+		})
+	}
+	return result, nil
+}
+
+// ClaimDigest registra o envio do resumo se lastSentAt ainda for o atual
+func (s *MemoryStore) ClaimDigest(userID string, lastSentAt, now time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.digestSentAt[userID].Equal(lastSentAt) {
+		return false, nil
+	}
+	s.digestSentAt[userID] = now
+	return true, nil
+}
+
+// ============ ADMIN / ESTATÍSTICAS ============
+
+// Stats representa estatísticas do sistema
+type Stats struct {
+	TotalUsers      int            `json:"total_users"`
+	TotalItems      int            `json:"total_items"`
+	ArchivedItems   int            `json:"archived_items"` // Incluídos em TotalItems
+	TotalGuardians  int            `json:"total_guardians"`
+	ItemsByType     map[string]int `json:"items_by_type"`
+	ItemsByCategory map[string]int `json:"items_by_category"`
+	RecentSignups   int            `json:"recent_signups"` // Últimos 7 dias
+}
+
+// GetStats retorna estatísticas gerais do sistema
+func (s *MemoryStore) GetStats() *Stats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := &Stats{
+		ItemsByType:     make(map[string]int),
+		ItemsByCategory: make(map[string]int),
+	}
+
+	// Contar usuários
+	stats.TotalUsers = len(s.users)
+
+	// Contar inscrições recentes (últimos 7 dias)
+	sevenDaysAgo := time.Now().AddDate(0, 0, -7)
+	for _, user := range s.users {
+		if user.CreatedAt.After(sevenDaysAgo) {
+			stats.RecentSignups++
+		}
+	}
+
+	// Contar itens e categorias
+	for _, userItems := range s.items {
+		for _, item := range userItems {
+			stats.TotalItems++
+			if item.ArchivedAt != nil {
+				stats.ArchivedItems++
+			}
+			stats.ItemsByType[string(item.Type)]++
+			if item.Category != "" {
+				stats.ItemsByCategory[item.Category]++
+			}
+		}
+	}
+
+	// Contar guardiões
+	for _, userGuardians := range s.guardians {
+		stats.TotalGuardians += len(userGuardians)
+	}
+
+	return stats
+}
+
+// GetUserUsage calcula o uso de armazenamento do usuário
+func (s *MemoryStore) GetUserUsage(userID string) (*UserUsage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.userUsage(userID), nil
+}
+
+// ListTopUsage lista os usuários que mais usam armazenamento
+func (s *MemoryStore) ListTopUsage(limit int) ([]*UserUsage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*UserUsage, 0, len(s.items))
+	for userID, userItems := range s.items {
+		if len(userItems) == 0 {
+			continue
+		}
+		usage := s.userUsage(userID)
+		if user, ok := s.users[userID]; ok {
+			usage.Email = user.Email
+			usage.Name = user.Name
+		}
+		result = append(result, usage)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ContentBytes != result[j].ContentBytes {
+			return result[i].ContentBytes > result[j].ContentBytes
+		}
+		if result[i].ItemCount != result[j].ItemCount {
+			return result[i].ItemCount > result[j].ItemCount
+		}
+		return result[i].UserID < result[j].UserID
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// GetAssistantUsage retorna o uso do assistente pelo usuário no dia
+func (s *MemoryStore) GetAssistantUsage(userID, day string) (*AssistantUsage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if usage, ok := s.assistantUsage[userID+"|"+day]; ok {
+		copyUsage := *usage
+		return &copyUsage, nil
+	}
+	return &AssistantUsage{UserID: userID, Day: day}, nil
+}
+
+// RecordAssistantUsage soma uma pergunta respondida (ou recusada) ao dia
+func (s *MemoryStore) RecordAssistantUsage(userID, day string, tokens int, rejected bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := userID + "|" + day
+	usage, ok := s.assistantUsage[key]
+	if !ok {
+		usage = &AssistantUsage{UserID: userID, Day: day}
+		s.assistantUsage[key] = usage
+	}
+	if rejected {
+		usage.Rejected++
+		return nil
+	}
+	usage.Requests++
+	usage.Tokens += tokens
+	return nil
+}
+
+// GetAssistantUsageStats soma o uso do assistente desde o dia informado
+func (s *MemoryStore) GetAssistantUsageStats(since string, topLimit int) (*AssistantUsageStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := &AssistantUsageStats{}
+	daily := make(map[string]*AssistantUsage)
+	users := make(map[string]*AssistantUsage)
+	for _, usage := range s.assistantUsage {
+		if usage.Day < since {
+			continue
+		}
+		stats.Requests += usage.Requests
+		stats.Tokens += usage.Tokens
+		stats.Rejected += usage.Rejected
+
+		day, ok := daily[usage.Day]
+		if !ok {
+			day = &AssistantUsage{Day: usage.Day}
+			daily[usage.Day] = day
+		}
+		user, ok := users[usage.UserID]
+		if !ok {
+			user = &AssistantUsage{UserID: usage.UserID}
+			users[usage.UserID] = user
+		}
+		for _, total := range []*AssistantUsage{day, user} {
+			total.Requests += usage.Requests
+			total.Tokens += usage.Tokens
+			total.Rejected += usage.Rejected
+		}
+	}
+
+	stats.Daily = make([]*AssistantUsage, 0, len(daily))
+	for _, day := range daily {
+		stats.Daily = append(stats.Daily, day)
+	}
+	sort.Slice(stats.Daily, func(i, j int) bool { return stats.Daily[i].Day < stats.Daily[j].Day })
+
+	stats.TopUsers = make([]*AssistantUsage, 0, len(users))
+	for _, user := range users {
+		if user.Requests > 0 {
+			stats.ActiveUsers++
+		}
+		stats.TopUsers = append(stats.TopUsers, user)
+	}
+	sort.Slice(stats.TopUsers, func(i, j int) bool {
+		if stats.TopUsers[i].Tokens != stats.TopUsers[j].Tokens {
+			return stats.TopUsers[i].Tokens > stats.TopUsers[j].Tokens
+		}
+		return stats.TopUsers[i].UserID < stats.TopUsers[j].UserID
+	})
+	if topLimit > 0 && len(stats.TopUsers) > topLimit {
+		stats.TopUsers = stats.TopUsers[:topLimit]
+	}
+	return stats, nil
+}
+
+// userUsage soma os itens do usuário (chamador segura o lock)
+func (s *MemoryStore) userUsage(userID string) *UserUsage {
+	usage := &UserUsage{UserID: userID}
+	for _, item := range s.items[userID] {
+		usage.ItemCount++
+		if item.ArchivedAt != nil {
+			usage.ArchivedCount++
+		}
+		usage.ContentBytes += item.ContentBytes()
+	}
+	return usage
+}
+
+// ListUsers retorna lista de todos os usuários (para admin)
+func (s *MemoryStore) ListUsers() []*User {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := make([]*User, 0, len(s.users))
+	for _, user := range s.users {
+		// Criar cópia sem a senha
+		copyUser := &User{
+			ID:                  user.ID,
+			Email:               user.Email,
+			Name:                user.Name,
+			CreatedAt:           user.CreatedAt,
+			FailedLoginAttempts: user.FailedLoginAttempts,
+			LockedUntil:         user.LockedUntil,
+			DisabledAt:          user.DisabledAt,
+			Role:                user.Role,
+			Plan:                user.Plan,
+			// Password NÃO incluído
+		}
+		users = append(users, copyUser)
+	}
+	return users
+}
+
+// SearchUsers busca usuários por email/nome e status, com paginação por cursor
+func (s *MemoryStore) SearchUsers(params *UserSearchParams) (*PaginatedResult[*User], error) {
+	if params == nil {
+		params = &UserSearchParams{}
+	}
+	NormalizePagination(&params.PaginationParams)
+
+	query := strings.ToLower(strings.TrimSpace(params.Query))
+	now := time.Now()
+
+	// Filtrar (ListUsers já retorna cópias sem senha)
+	var matched []*User
+	for _, user := range s.ListUsers() {
+		if query != "" && !strings.Contains(strings.ToLower(user.Email), query) &&
+			!strings.Contains(strings.ToLower(user.Name), query) {
+			continue
+		}
+		switch params.Status {
+		case UserStatusActive:
+			if user.IsDisabled() || user.IsLocked(now) {
+				continue
+			}
+		case UserStatusDisabled:
+			if !user.IsDisabled() {
+				continue
+			}
+		case UserStatusLocked:
+			if !user.IsLocked(now) {
+				continue
+			}
+		}
+		if params.Role != RoleNone && user.Role != params.Role {
+			continue
+		}
+		matched = append(matched, user)
+	}
+
+	// Ordenar do mais recente para o mais antigo
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].CreatedAt.Equal(matched[j].CreatedAt) {
+			return matched[i].ID > matched[j].ID
+		}
+		return matched[i].CreatedAt.After(matched[j].CreatedAt)
+	})
+
+	// Aplicar cursor
 	startIdx := 0
 	if params.Cursor != "" {
-		for i, item := range allItems {
-			if item.ID == params.Cursor {
+		for i, user := range matched {
+			if user.ID == params.Cursor {
 				startIdx = i + 1
 				break
 			}
 		}
 	}
 
-	// Paginar
-	endIdx := startIdx + params.Limit + 1
-	if endIdx > len(allItems) {
-		endIdx = len(allItems)
-	}
+	// Paginar
+	endIdx := startIdx + params.Limit + 1
+	if endIdx > len(matched) {
+		endIdx = len(matched)
+	}
+
+	paged := matched[startIdx:endIdx]
+	hasMore := len(paged) > params.Limit
+	if hasMore {
+		paged = paged[:params.Limit]
+	}
+
+	var nextCursor string
+	if hasMore && len(paged) > 0 {
+		nextCursor = paged[len(paged)-1].ID
+	}
+
+	return &PaginatedResult[*User]{
+		Items:      paged,
+		NextCursor: nextCursor,
+		HasMore:    hasMore,
+		Total:      len(matched),
+	}, nil
+}
+
+// SetUserDisabled desativa ou reativa uma conta (ação administrativa)
+func (s *MemoryStore) SetUserDisabled(userID string, disabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.users[userID]
+	if !exists {
+		return ErrNotFound
+	}
+
+	if !disabled {
+		user.DisabledAt = nil
+		return nil
+	}
+	now := time.Now()
+	user.DisabledAt = &now
+	return nil
+}
+
+// GetUserGrowth retorna os cadastros por dia de [from, to) e o total acumulado
+func (s *MemoryStore) GetUserGrowth(from, to time.Time) ([]*UserGrowthDay, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	before := 0
+	signups := make(map[time.Time]int)
+	for _, user := range s.users {
+		switch {
+		case user.CreatedAt.Before(from):
+			before++
+		case user.CreatedAt.Before(to):
+			signups[dayStart(user.CreatedAt)]++
+		}
+	}
+	return newUserGrowth(from, to, before, signups), nil
+}
+
+// GrantRole concede um papel administrativo ao usuário (substitui o anterior)
+func (s *MemoryStore) GrantRole(userID string, role Role) error {
+	if !role.IsAdmin() {
+		return ErrInvalidData
+	}
+	return s.setRole(userID, role)
+}
+
+// RevokeRole remove o papel administrativo do usuário
+func (s *MemoryStore) RevokeRole(userID string) error {
+	return s.setRole(userID, RoleNone)
+}
+
+// setRole atualiza o papel de um usuário
+func (s *MemoryStore) setRole(userID string, role Role) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.users[userID]
+	if !exists {
+		return ErrNotFound
+	}
+
+	user.Role = role
+	return nil
+}
+
+// ============ FEEDBACK ============
+
+// CreateFeedback salva um novo feedback
+func (s *MemoryStore) CreateFeedback(f *Feedback) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.feedbacks[f.ID] = f
+	return nil
+}
+
+// ListFeedbacks retorna todos os feedbacks
+func (s *MemoryStore) ListFeedbacks(status string, limit int) ([]*Feedback, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*Feedback, 0)
+	count := 0
+	for _, f := range s.feedbacks {
+		if status != "" && status != "all" && f.Status != status {
+			continue
+		}
+		result = append(result, f)
+		count++
+		if count >= limit {
+			break
+		}
+	}
+	return result, nil
+}
+
+// EachFeedback percorre os feedbacks criados em [from, to), mais antigos primeiro
+func (s *MemoryStore) EachFeedback(from, to time.Time, fn func(*Feedback) error) error {
+	s.mu.RLock()
+	feedbacks := make([]*Feedback, 0)
+	for _, f := range s.feedbacks {
+		if f.CreatedAt.Before(from) || !f.CreatedAt.Before(to) {
+			continue
+		}
+		copyFeedback := *f
+		copyFeedback.Replies = nil
+		feedbacks = append(feedbacks, &copyFeedback)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(feedbacks, func(i, j int) bool {
+		return feedbacks[i].CreatedAt.Before(feedbacks[j].CreatedAt)
+	})
+	for _, f := range feedbacks {
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateFeedbackStatus atualiza o status de um feedback
+func (s *MemoryStore) UpdateFeedbackStatus(id, status, adminNote string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f, ok := s.feedbacks[id]; ok {
+		f.Status = status
+		f.AdminNote = adminNote
+		f.UpdatedAt = time.Now()
+		return nil
+	}
+	return ErrNotFound
+}
+
+// GetFeedbackStats retorna estatísticas de feedbacks
+func (s *MemoryStore) GetFeedbackStats() (total, pending int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, f := range s.feedbacks {
+		total++
+		if f.Status == "pending" {
+			pending++
+		}
+	}
+	return
+}
+
+// GetFeedback retorna um feedback com a conversa
+func (s *MemoryStore) GetFeedback(id string) (*Feedback, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	f, ok := s.feedbacks[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return s.feedbackWithReplies(f), nil
+}
+
+// ListFeedbacksByUser retorna os feedbacks do usuário com a conversa
+func (s *MemoryStore) ListFeedbacksByUser(userID string, limit int) ([]*Feedback, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*Feedback, 0)
+	for _, f := range s.feedbacks {
+		if f.UserID == userID {
+			result = append(result, s.feedbackWithReplies(f))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// AddFeedbackReply adiciona uma mensagem à conversa do feedback
+func (s *MemoryStore) AddFeedbackReply(reply *FeedbackReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.feedbacks[reply.FeedbackID]
+	if !ok {
+		return ErrNotFound
+	}
+	copyReply := *reply
+	s.feedbackReplies[reply.FeedbackID] = append(s.feedbackReplies[reply.FeedbackID], &copyReply)
+	f.UpdatedAt = reply.CreatedAt
+	return nil
+}
+
+// feedbackWithReplies copia o feedback com a conversa (chamador segura o lock)
+func (s *MemoryStore) feedbackWithReplies(f *Feedback) *Feedback {
+	copyFeedback := *f
+	copyFeedback.Replies = make([]*FeedbackReply, 0, len(s.feedbackReplies[f.ID]))
+	for _, reply := range s.feedbackReplies[f.ID] {
+		copyReply := *reply
+		copyFeedback.Replies = append(copyFeedback.Replies, &copyReply)
+	}
+	return &copyFeedback
+}
+
+// ============ ANALYTICS ============
+
+// TrackEvent registra um evento de analytics
+func (s *MemoryStore) TrackEvent(e *AnalyticsEvent) error {
+	_, err := s.TrackEvents([]*AnalyticsEvent{e})
+	return err
+}
+
+// TrackEvents registra eventos em lote, ignorando client_event_id repetido
+func (s *MemoryStore) TrackEvents(events []*AnalyticsEvent) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[string]bool)
+	for _, e := range events {
+		if e.ClientEventID != "" {
+			seen[e.UserID+":"+e.ClientEventID] = false
+		}
+	}
+	if len(seen) > 0 {
+		for _, e := range s.analytics {
+			key := e.UserID + ":" + e.ClientEventID
+			if _, ok := seen[key]; ok {
+				seen[key] = true
+			}
+		}
+	}
+
+	tracked := 0
+	for _, e := range events {
+		if e.ClientEventID != "" {
+			key := e.UserID + ":" + e.ClientEventID
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+		// Limitar a 10000 eventos para não estourar memória
+		if len(s.analytics) > 10000 {
+			s.analytics = s.analytics[1000:] // Remover os 1000 mais antigos
+		}
+		s.analytics = append(s.analytics, e)
+		tracked++
+	}
+	return tracked, nil
+}
+
+// LinkAnonymousEvents atribui ao usuário os eventos anônimos do visitante
+func (s *MemoryStore) LinkAnonymousEvents(anonymousID, userID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	linked := 0
+	for _, e := range s.analytics {
+		if e.AnonymousID == anonymousID && e.UserID == "" {
+			e.UserID = userID
+			linked++
+		}
+	}
+	return linked, nil
+}
+
+// RollupAnalytics não faz nada: o resumo em memória já é calculado na hora
+func (s *MemoryStore) RollupAnalytics() (int, error) {
+	return 0, nil
+}
+
+// GetAnalyticsSummary retorna o resumo de analytics
+func (s *MemoryStore) GetAnalyticsSummary() *AnalyticsSummary {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	summary := &AnalyticsSummary{
+		EventsByType: make(map[string]int),
+	}
+	summary.TotalUsers = len(s.users)
+
+	for _, items := range s.items {
+		summary.TotalItems += len(items)
+	}
+	for _, guards := range s.guardians {
+		summary.TotalGuardians += len(guards)
+	}
+
+	today := time.Now().Truncate(24 * time.Hour)
+	weekAgo := today.AddDate(0, 0, -7)
+
+	activeUsers := make(map[string]bool)
+	for _, e := range s.analytics {
+		summary.EventsByType[string(e.EventType)]++
+		if e.CreatedAt.After(today) {
+			summary.EventsToday++
+			if e.UserID != "" {
+				activeUsers[e.UserID] = true
+			}
+		}
+	}
+	summary.ActiveToday = len(activeUsers)
+
+	for _, user := range s.users {
+		if user.CreatedAt.After(today) {
+			summary.NewUsersToday++
+		}
+		if user.CreatedAt.After(weekAgo) {
+			summary.NewUsersThisWeek++
+		}
+	}
+
+	summary.TotalFeedbacks, summary.PendingFeedbacks = s.GetFeedbackStats()
+
+	return summary
+}
+
+// GetRecentEvents retorna os eventos mais recentes
+func (s *MemoryStore) GetRecentEvents(limit int) ([]*AnalyticsEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.analytics) == 0 {
+		return []*AnalyticsEvent{}, nil
+	}
+
+	start := len(s.analytics) - limit
+	if start < 0 {
+		start = 0
+	}
+
+	result := make([]*AnalyticsEvent, 0, limit)
+	for i := len(s.analytics) - 1; i >= start; i-- {
+		result = append(result, s.analytics[i])
+	}
+
+	return result, nil
+}
+
+// GetDailyStats retorna estatísticas diárias
+func (s *MemoryStore) GetDailyStats(days int) ([]map[string]interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Simplificado para MemoryStore
+	stats := make([]map[string]interface{}, 0)
+	today := time.Now().Truncate(24 * time.Hour)
+
+	for i := days - 1; i >= 0; i-- {
+		day := today.AddDate(0, 0, -i)
+		dayStats := map[string]interface{}{
+			"date":   day.Format("2006-01-02"),
+			"events": 0,
+			"users":  0,
+		}
+
+		users := make(map[string]bool)
+		for _, e := range s.analytics {
+			eventDay := e.CreatedAt.Truncate(24 * time.Hour)
+			if eventDay.Equal(day) {
+				dayStats["events"] = dayStats["events"].(int) + 1
+				if e.UserID != "" {
+					users[e.UserID] = true
+				}
+			}
+		}
+		dayStats["users"] = len(users)
+		stats = append(stats, dayStats)
+	}
+
+	return stats, nil
+}
+
+// ListDailyStats retorna os eventos e usuários ativos por dia de [from, to)
+func (s *MemoryStore) ListDailyStats(from, to time.Time) ([]*DailyStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := make(map[time.Time]int)
+	active := make(map[time.Time]map[string]bool)
+	for _, e := range s.analytics {
+		if e.CreatedAt.Before(from) || !e.CreatedAt.Before(to) {
+			continue
+		}
+		day := dayStart(e.CreatedAt)
+		events[day]++
+		if e.UserID == "" {
+			continue
+		}
+		if active[day] == nil {
+			active[day] = make(map[string]bool)
+		}
+		active[day][e.UserID] = true
+	}
+
+	users := make(map[time.Time]int, len(active))
+	for day, ids := range active {
+		users[day] = len(ids)
+	}
+	return newDailyStats(from, to, events, users), nil
+}
+
+// GetFunnel calcula o funil de ativação dos usuários cadastrados em [from, to)
+func (s *MemoryStore) GetFunnel(from, to time.Time) (*AnalyticsFunnel, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	shared := make(map[string]bool)
+	for _, link := range s.shareLinks {
+		shared[link.UserID] = true
+	}
+
+	var registered, firstItem, guardian, sharedCount int
+	for _, user := range s.users {
+		if user.CreatedAt.Before(from) || !user.CreatedAt.Before(to) {
+			continue
+		}
+		registered++
+		if len(s.items[user.ID]) == 0 {
+			continue
+		}
+		firstItem++
+		if len(s.guardians[user.ID]) == 0 {
+			continue
+		}
+		guardian++
+		if shared[user.ID] {
+			sharedCount++
+		}
+	}
+
+	return newFunnel(from, to, registered, firstItem, guardian, sharedCount), nil
+}
+
+// GetRetentionCohorts calcula as coortes semanais dos cadastros em [from, to)
+func (s *MemoryStore) GetRetentionCohorts(from, to time.Time) ([]*RetentionCohort, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cohortOf := make(map[string]time.Time)
+	cohorts := make(map[time.Time]*RetentionCohort)
+	for _, user := range s.users {
+		if user.CreatedAt.Before(from) || !user.CreatedAt.Before(to) {
+			continue
+		}
+		week := weekStart(user.CreatedAt)
+		cohortOf[user.ID] = week
+		if cohorts[week] == nil {
+			cohorts[week] = newRetentionCohort(week, time.Now())
+		}
+		cohorts[week].Users++
+	}
+
+	active := make(map[string]map[int]bool) // userID -> semanas com atividade
+	for _, e := range s.analytics {
+		week, ok := cohortOf[e.UserID]
+		if !ok {
+			continue
+		}
+		n := int(weekStart(e.CreatedAt).Sub(week).Hours() / (24 * 7))
+		if n < 0 || n >= len(cohorts[week].Retained) {
+			continue
+		}
+		if active[e.UserID] == nil {
+			active[e.UserID] = make(map[int]bool)
+		}
+		if !active[e.UserID][n] {
+			active[e.UserID][n] = true
+			cohorts[week].Retained[n]++
+		}
+	}
+
+	result := make([]*RetentionCohort, 0, len(cohorts))
+	for _, cohort := range cohorts {
+		result = append(result, cohort)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Week.Before(result[j].Week)
+	})
+	return result, nil
+}
+
+// RecordCompletenessScore guarda a nota de preparo do usuário no dia
+func (s *MemoryStore) RecordCompletenessScore(userID string, day time.Time, score int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.completenessScores[userID] == nil {
+		s.completenessScores[userID] = make(map[time.Time]int)
+	}
+	s.completenessScores[userID][day.UTC().Truncate(24*time.Hour)] = score
+	return nil
+}
+
+// GetCompletenessTrend calcula a média diária das notas de preparo em [from, to)
+func (s *MemoryStore) GetCompletenessTrend(from, to time.Time) ([]*CompletenessDay, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	totals := make(map[time.Time]int)
+	byDay := make(map[time.Time]*CompletenessDay)
+	for _, days := range s.completenessScores {
+		for day, score := range days {
+			if day.Before(from) || !day.Before(to) {
+				continue
+			}
+			if byDay[day] == nil {
+				byDay[day] = &CompletenessDay{Day: day}
+			}
+			byDay[day].Users++
+			totals[day] += score
+			if score == 100 {
+				byDay[day].Complete++
+			}
+		}
+	}
+
+	result := make([]*CompletenessDay, 0, len(byDay))
+	for day, entry := range byDay {
+		entry.AverageScore = float64(totals[day]) / float64(entry.Users)
+		result = append(result, entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Day.Before(result[j].Day)
+	})
+	return result, nil
+}
+
+// ============ SYSTEM CONFIG ============
+
+// ListSystemConfig retorna as configurações cujas chaves começam com prefix
+func (s *MemoryStore) ListSystemConfig(prefix string) (map[string]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make(map[string]string)
+	for key, value := range s.systemConfig {
+		if strings.HasPrefix(key, prefix) {
+			result[key] = value
+		}
+	}
+	return result, nil
+}
+
+// SetSystemConfig cria ou atualiza uma configuração
+func (s *MemoryStore) SetSystemConfig(key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.systemConfig[key] = value
+	return nil
+}
+
+// DeleteSystemConfig remove uma configuração
+func (s *MemoryStore) DeleteSystemConfig(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.systemConfig[key]; !ok {
+		return ErrNotFound
+	}
+	delete(s.systemConfig, key)
+	return nil
+}
+
+// CheckHealth sempre responde: o storage em memória não tem conexão nem migrações
+func (s *MemoryStore) CheckHealth(ctx context.Context) (*MigrationSummary, error) {
+	return nil, nil
+}
+
+// CleanupExpiredRecords não faz nada: o storage em memória não tem partições
+// e os tokens vencidos saem em CleanupExpiredPasswordResetTokens
+func (s *MemoryStore) CleanupExpiredRecords() error {
+	return nil
+}
+
+// PurgeRetention remove os registros da classe mais antigos que days dias
+func (s *MemoryStore) PurgeRetention(class RetentionClass, days int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := time.Now().AddDate(0, 0, -days)
+	var purged int64
+
+	switch class {
+	case RetentionAnalytics:
+		kept := make([]*AnalyticsEvent, 0, len(s.analytics))
+		for _, e := range s.analytics {
+			if e.CreatedAt.After(cutoff) {
+				kept = append(kept, e)
+			}
+		}
+		purged = int64(len(s.analytics) - len(kept))
+		s.analytics = kept
+		for _, days := range s.completenessScores {
+			for day := range days {
+				if day.Before(cutoff.Truncate(24 * time.Hour)) {
+					delete(days, day)
+				}
+			}
+		}
+
+	case RetentionShareAccesses:
+		kept := make([]*ShareLinkAccess, 0, len(s.shareLinkAccesses))
+		for _, access := range s.shareLinkAccesses {
+			if access.AccessedAt.After(cutoff) {
+				kept = append(kept, access)
+			}
+		}
+		purged = int64(len(s.shareLinkAccesses) - len(kept))
+		s.shareLinkAccesses = kept
+
+	case RetentionLoginHistory:
+		for userID, history := range s.loginHistory {
+			kept := make([]*LoginRecord, 0, len(history))
+			for _, record := range history {
+				if record.CreatedAt.After(cutoff) {
+					kept = append(kept, record)
+				}
+			}
+			purged += int64(len(history) - len(kept))
+			s.loginHistory[userID] = kept
+		}
+
+	case RetentionWebhookDeliveries:
+		for id, delivery := range s.webhookDeliveries {
+			if delivery.Status != WebhookDeliveryPending && delivery.CreatedAt.Before(cutoff) {
+				delete(s.webhookDeliveries, id)
+				purged++
+			}
+		}
+
+	case RetentionGuardianAlerts:
+		for id, alert := range s.guardianAlerts {
+			if alert.Status != GuardianAlertPending && alert.CreatedAt.Before(cutoff) {
+				delete(s.guardianAlerts, id)
+				purged++
+			}
+		}
+
+	case RetentionAudit, RetentionDeletedAccountAudit:
+		// Contas excluídas não estão mais em s.users
+		kept := make([]*AuditEntry, 0, len(s.auditLog))
+		for _, entry := range s.auditLog {
+			_, exists := s.users[entry.UserID]
+			existing := entry.UserID == "" || exists
+			if entry.CreatedAt.Before(cutoff) && existing == (class == RetentionAudit) {
+				continue
+			}
+			kept = append(kept, entry)
+		}
+		purged = int64(len(s.auditLog) - len(kept))
+		s.auditLog = kept
+
+	default:
+		return 0, ErrInvalidData
+	}
+	return purged, nil
+}
+
+// CreateRetentionRun registra uma execução da retenção
+func (s *MemoryStore) CreateRetentionRun(run *RetentionRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if run.ID == "" {
+		run.ID = ids.New(ids.RetentionRun)
+	}
+	copyRun := *run
+	s.retentionRuns = append(s.retentionRuns, &copyRun)
+	return nil
+}
+
+// ListRetentionRuns lista as execuções da retenção, mais recentes primeiro
+func (s *MemoryStore) ListRetentionRuns(limit int) ([]*RetentionRun, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	runs := make([]*RetentionRun, 0, limit)
+	for i := len(s.retentionRuns) - 1; i >= 0 && len(runs) < limit; i-- {
+		copyRun := *s.retentionRuns[i]
+		runs = append(runs, &copyRun)
+	}
+	return runs, nil
+}
+
+// =============================================================================
+// PÁGINA DE STATUS
+// =============================================================================
+
+// copyIncident copia o incidente (inclusive a lista de componentes)
+func copyIncident(incident *StatusIncident) *StatusIncident {
+	copyInc := *incident
+	copyInc.Components = append([]string(nil), incident.Components...)
+	return &copyInc
+}
+
+// CreateStatusIncident publica um incidente
+func (s *MemoryStore) CreateStatusIncident(incident *StatusIncident) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if incident.ID == "" {
+		incident.ID = ids.New(ids.Incident)
+	}
+	now := time.Now()
+	if incident.CreatedAt.IsZero() {
+		incident.CreatedAt = now
+	}
+	incident.UpdatedAt = now
+	s.statusIncidents[incident.ID] = copyIncident(incident)
+	return nil
+}
+
+// GetStatusIncident busca um incidente
+func (s *MemoryStore) GetStatusIncident(id string) (*StatusIncident, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	incident, ok := s.statusIncidents[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyIncident(incident), nil
+}
+
+// UpdateStatusIncident atualiza um incidente
+func (s *MemoryStore) UpdateStatusIncident(incident *StatusIncident) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.statusIncidents[incident.ID]; !ok {
+		return ErrNotFound
+	}
+	incident.UpdatedAt = time.Now()
+	s.statusIncidents[incident.ID] = copyIncident(incident)
+	return nil
+}
+
+// DeleteStatusIncident remove um incidente
+func (s *MemoryStore) DeleteStatusIncident(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.statusIncidents[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.statusIncidents, id)
+	return nil
+}
+
+// ListStatusIncidents lista os incidentes abertos e os resolvidos desde since
+func (s *MemoryStore) ListStatusIncidents(since time.Time) ([]*StatusIncident, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	incidents := make([]*StatusIncident, 0)
+	for _, incident := range s.statusIncidents {
+		if incident.ResolvedAt != nil && incident.ResolvedAt.Before(since) {
+			continue
+		}
+		incidents = append(incidents, copyIncident(incident))
+	}
+	sort.Slice(incidents, func(i, j int) bool {
+		return incidents[i].CreatedAt.After(incidents[j].CreatedAt)
+	})
+	return incidents, nil
+}
+
+// RecordStatusCheck soma uma verificação da dependência ao dia
+func (s *MemoryStore) RecordStatusCheck(component string, day time.Time, ok bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	day = day.UTC().Truncate(24 * time.Hour)
+	key := component + "|" + day.Format("2006-01-02")
+	entry, exists := s.statusUptime[key]
+	if !exists {
+		entry = &StatusUptimeDay{Component: component, Day: day}
+		s.statusUptime[key] = entry
+	}
+	entry.Checks++
+	if !ok {
+		entry.Failures++
+	}
+	return nil
+}
+
+// ListStatusUptime lista os dias verificados desde since, em ordem
+func (s *MemoryStore) ListStatusUptime(since time.Time) ([]*StatusUptimeDay, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	since = since.UTC().Truncate(24 * time.Hour)
+	days := make([]*StatusUptimeDay, 0)
+	for _, entry := range s.statusUptime {
+		if entry.Day.Before(since) {
+			continue
+		}
+		copyDay := *entry
+		days = append(days, &copyDay)
+	}
+	sort.Slice(days, func(i, j int) bool {
+		if !days[i].Day.Equal(days[j].Day) {
+			return days[i].Day.Before(days[j].Day)
+		}
+		return days[i].Component < days[j].Component
+	})
+	return days, nil
+}
+
+// RecordAudit grava um evento de auditoria
+func (s *MemoryStore) RecordAudit(entry *AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	s.auditSeq++
+	entry.ID = auditActivityPrefix + strconv.FormatInt(s.auditSeq, 10)
+	copyEntry := *entry
+	s.auditLog = append(s.auditLog, &copyEntry)
+	return nil
+}
+
+// ListActivity lista a atividade do painel admin (auditoria + analytics)
+func (s *MemoryStore) ListActivity(params *ActivityParams) (*PaginatedResult[*ActivityEntry], error) {
+	if params == nil {
+		params = &ActivityParams{}
+	}
+	NormalizePagination(&params.PaginationParams)
+	actions, events := activitySources(params.Types)
+
+	inRange := func(t time.Time) bool {
+		return (params.From == nil || !t.Before(*params.From)) && (params.To == nil || t.Before(*params.To))
+	}
+
+	s.mu.RLock()
+	entries := make([]*ActivityEntry, 0)
+	for _, entry := range s.auditLog {
+		activity, ok := actions[entry.Action]
+		if !ok || !inRange(entry.CreatedAt) {
+			continue
+		}
+		entries = append(entries, &ActivityEntry{
+			ID:        entry.ID,
+			Type:      activity,
+			Source:    "audit",
+			UserID:    entry.UserID,
+			Details:   entry.Details,
+			CreatedAt: entry.CreatedAt,
+		})
+	}
+	for _, event := range s.analytics {
+		activity, ok := events[event.EventType]
+		if !ok || !inRange(event.CreatedAt) {
+			continue
+		}
+		var details map[string]interface{}
+		if len(event.Details) > 0 {
+			details = make(map[string]interface{}, len(event.Details))
+			for key, value := range event.Details {
+				details[key] = value
+			}
+		}
+		entries = append(entries, &ActivityEntry{
+			ID:        event.ID,
+			Type:      activity,
+			Source:    "analytics",
+			UserID:    event.UserID,
+			Details:   details,
+			CreatedAt: event.CreatedAt,
+		})
+	}
+	s.mu.RUnlock()
+
+	return paginateActivity(entries, params)
+}
+
+// ============================================================================
+// SHARE LINKS (Compartilhamento com Guardiões)
+// ============================================================================
+
+func (s *MemoryStore) CreateShareLink(link *ShareLink) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.shareLinks[link.ID] = link
+	s.shareLinksByToken[link.Token] = link.ID
+	return nil
+}
+
+func (s *MemoryStore) GetShareLinkByToken(token string) (*ShareLink, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	linkID, ok := s.shareLinksByToken[token]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	link, exists := s.shareLinks[linkID]
+	if !exists || !link.IsActive {
+		return nil, ErrNotFound
+	}
+
+	copyLink := *link
+	return &copyLink, nil
+}
+
+func (s *MemoryStore) GetShareLinksByUser(userID string) ([]*ShareLink, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var links []*ShareLink
+	for _, link := range s.shareLinks {
+		if link.UserID == userID {
+			copyLink := *link
+			links = append(links, &copyLink)
+		}
+	}
+	return links, nil
+}
+
+func (s *MemoryStore) UpdateShareLink(link *ShareLink) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.shareLinks[link.ID]
+	if !ok || existing.UserID != link.UserID {
+		return ErrNotFound
+	}
+
+	existing.Name = link.Name
+	existing.Categories = link.Categories
+	existing.ItemIDs = link.ItemIDs
+	existing.MessagesOnly = link.MessagesOnly
+	existing.PassphraseHint = link.PassphraseHint
+	existing.ExpiresAt = link.ExpiresAt
+	existing.MaxUses = link.MaxUses
+	existing.IsActive = link.IsActive
+	existing.UpdatedAt = time.Now()
+	return nil
+}
+
+func (s *MemoryStore) DeleteShareLink(userID, linkID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	link, ok := s.shareLinks[linkID]
+	if !ok || link.UserID != userID {
+		return ErrNotFound
+	}
+
+	delete(s.shareLinksByToken, link.Token)
+	delete(s.shareLinks, linkID)
+	return nil
+}
+
+func (s *MemoryStore) RecordShareLinkAccess(access *ShareLinkAccess) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.shareLinkAccesses = append(s.shareLinkAccesses, access)
+	return nil
+}
+
+// ListShareLinkAccesses lista os acessos a um link do usuário
+func (s *MemoryStore) ListShareLinkAccesses(userID, linkID string, limit int) ([]*ShareLinkAccess, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	pagedItems := allItems[startIdx:endIdx]
-	hasMore := len(pagedItems) > params.Limit
-	if hasMore {
-		pagedItems = pagedItems[:params.Limit]
+	link, ok := s.shareLinks[linkID]
+	if !ok || link.UserID != userID {
+		return nil, ErrNotFound
 	}
 
-	// Converter para BoxItemSummary
-	summaries := make([]*BoxItemSummary, len(pagedItems))
-	for i, item := range pagedItems {
-		summaries[i] = &BoxItemSummary{
-			ID:          item.ID,
-			Type:        item.Type,
-			Title:       item.Title,
-			Category:    item.Category,
-			IsImportant: item.IsImportant,
-			IsShared:    item.IsShared,
-			GuardianIDs: item.GuardianIDs,
-			UpdatedAt:   item.UpdatedAt,
+	result := make([]*ShareLinkAccess, 0)
+	for i := len(s.shareLinkAccesses) - 1; i >= 0 && len(result) < limit; i-- {
+		if access := s.shareLinkAccesses[i]; access.ShareLinkID == linkID {
+			copyAccess := *access
+			result = append(result, &copyAccess)
 		}
 	}
+	return result, nil
+}
 
-	var nextCursor string
-	if hasMore && len(summaries) > 0 {
-		nextCursor = summaries[len(summaries)-1].ID
+func (s *MemoryStore) IncrementShareLinkUsage(linkID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	link, ok := s.shareLinks[linkID]
+	if !ok {
+		return ErrNotFound
 	}
 
-	return &PaginatedResult[*BoxItemSummary]{
-		Items:      summaries,
-		NextCursor: nextCursor,
-		HasMore:    hasMore,
-	}, nil
+	link.UsageCount++
+	now := time.Now()
+	link.LastUsedAt = &now
+	return nil
 }
 
-// CountBoxItems conta o total de itens de um usuário
-func (s *MemoryStore) CountBoxItems(userID string) (int, error) {
+// ClaimShareLinkUsage conta um uso se o link ativo ainda tiver usos
+func (s *MemoryStore) ClaimShareLinkUsage(linkID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	link, ok := s.shareLinks[linkID]
+	if !ok {
+		return false, ErrNotFound
+	}
+	if !link.IsActive || (link.MaxUses > 0 && link.UsageCount >= link.MaxUses) {
+		return false, nil
+	}
+
+	link.UsageCount++
+	now := time.Now()
+	link.LastUsedAt = &now
+	return true, nil
+}
+
+// ============================================================================
+// PIN ATTEMPTS (Proteção contra força bruta)
+// ============================================================================
+
+func (s *MemoryStore) GetPINAttempt(key string) (*PINAttempt, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return len(s.items[userID]), nil
+
+	attempt, ok := s.pinAttempts[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copyAttempt := *attempt
+	return &copyAttempt, nil
 }
 
-// ============ GUARDIANS ============
+func (s *MemoryStore) RecordPINFailure(key string) (*PINAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-// GetGuardians retorna os guardiões de um usuário (alias para compatibilidade)
-func (s *MemoryStore) GetGuardians(userID string) ([]*Guardian, error) {
-	guardians := s.ListGuardians(userID)
-	return guardians, nil
+	attempt, ok := s.pinAttempts[key]
+	if !ok {
+		attempt = &PINAttempt{Key: key}
+		s.pinAttempts[key] = attempt
+	}
+	attempt.FailedAttempts++
+	attempt.LastFailedAt = time.Now()
+
+	copyAttempt := *attempt
+	return &copyAttempt, nil
 }
 
-func (s *MemoryStore) ListGuardians(userID string) []*Guardian {
+func (s *MemoryStore) ResetPINAttempts(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.pinAttempts, key)
+	return nil
+}
+
+func (s *MemoryStore) GetMemorialConfig(userID string) (*MemorialConfig, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	userGuardians := s.guardians[userID]
-	result := make([]*Guardian, 0, len(userGuardians))
-	for _, guardian := range userGuardians {
-		copyGuardian := *guardian
-		result = append(result, &copyGuardian)
+	config, ok := s.memorialConfigs[userID]
+	if !ok {
+		return nil, ErrNotFound
 	}
-	return result
+	copyConfig := *config
+	copyConfig.GuardianIDs = append([]string(nil), config.GuardianIDs...)
+	return &copyConfig, nil
 }
 
-func (s *MemoryStore) CreateGuardian(userID string, guardian *Guardian) (*Guardian, error) {
-	s.guardianSeq++
-	guardianID := fmt.Sprintf("grd_%d", s.guardianSeq)
-	return s.CreateGuardianWithID(userID, guardian, guardianID)
+func (s *MemoryStore) SaveMemorialConfig(config *MemorialConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copyConfig := *config
+	copyConfig.GuardianIDs = append([]string(nil), config.GuardianIDs...)
+	copyConfig.UpdatedAt = time.Now()
+	s.memorialConfigs[config.UserID] = &copyConfig
+	config.UpdatedAt = copyConfig.UpdatedAt
+	return nil
 }
 
-func (s *MemoryStore) CreateGuardianWithID(userID string, guardian *Guardian, guardianID string) (*Guardian, error) {
+func (s *MemoryStore) DeleteMemorialConfig(userID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	now := time.Now()
-	guardian.ID = guardianID
-	guardian.UserID = userID
-	guardian.CreatedAt = now
-	guardian.UpdatedAt = now
-
-	if guardian.Role == "" {
-		guardian.Role = "viewer"
+	if _, ok := s.memorialConfigs[userID]; !ok {
+		return ErrNotFound
 	}
+	delete(s.memorialConfigs, userID)
+	return nil
+}
 
-	// Gerar access_token único
-	guardian.AccessToken = fmt.Sprintf("gat_%d_%d", s.guardianSeq, now.UnixNano())
-	if guardian.AccessType == "" {
-		guardian.AccessType = GuardianAccessNormal
+// CreateMemorialActivation salva um novo pedido (um só pendente ou em
+// espera por usuário, como o índice único do Postgres)
+func (s *MemoryStore) CreateMemorialActivation(activation *MemorialActivation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.memorialActivations {
+		if existing.UserID == activation.UserID &&
+			(existing.Status == MemorialPending || existing.Status == MemorialWaiting) {
+			return ErrAlreadyExists
+		}
 	}
+	now := time.Now()
+	activation.CreatedAt = now
+	activation.UpdatedAt = now
+	s.memorialActivations[activation.ID] = copyMemorialActivation(activation)
+	return nil
+}
 
-	if _, ok := s.guardians[userID]; !ok {
-		s.guardians[userID] = make(map[string]*Guardian)
+// UpdateMemorialActivation salva o pedido se o estado salvo ainda for from
+// (o dono, as confirmações e o worker não se sobrescrevem)
+func (s *MemoryStore) UpdateMemorialActivation(activation *MemorialActivation, from MemorialActivationStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.memorialActivations[activation.ID]
+	if !ok {
+		return ErrNotFound
 	}
-	s.guardians[userID][guardian.ID] = guardian
-	copyGuardian := *guardian
-	return &copyGuardian, nil
+	if current.Status != from {
+		return ErrVersionConflict
+	}
+	activation.UpdatedAt = time.Now()
+	s.memorialActivations[activation.ID] = copyMemorialActivation(activation)
+	return nil
 }
 
-func (s *MemoryStore) UpdateGuardian(userID, guardianID string, updates *Guardian) (*Guardian, error) {
+func (s *MemoryStore) AddMemorialConfirmation(activationID string, confirmation MemorialConfirmation) (*MemorialActivation, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	userGuardians, ok := s.guardians[userID]
+	activation, ok := s.memorialActivations[activationID]
 	if !ok {
 		return nil, ErrNotFound
 	}
-	guardian, exists := userGuardians[guardianID]
-	if !exists {
+	if activation.Status != MemorialPending {
+		return nil, ErrVersionConflict
+	}
+	if !activation.ConfirmedBy(confirmation.GuardianID) {
+		confirmation.GuardianName = ""
+		activation.Confirmations = append(activation.Confirmations, confirmation)
+		activation.UpdatedAt = time.Now()
+	}
+	return copyMemorialActivation(activation), nil
+}
+
+func (s *MemoryStore) GetLatestMemorialActivation(userID string) (*MemorialActivation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var latest *MemorialActivation
+	for _, activation := range s.memorialActivations {
+		if activation.UserID == userID && (latest == nil || activation.CreatedAt.After(latest.CreatedAt)) {
+			latest = activation
+		}
+	}
+	if latest == nil {
 		return nil, ErrNotFound
 	}
+	return copyMemorialActivation(latest), nil
+}
 
-	guardian.Name = updates.Name
-	guardian.Email = updates.Email
-	guardian.Phone = updates.Phone
-	guardian.Relationship = updates.Relationship
-	guardian.Notes = updates.Notes
-	guardian.UpdatedAt = time.Now()
+// ListDueMemorialActivations lista pedidos com a espera vencida (waiting) ou
+// sem confirmações suficientes no prazo (pending)
+func (s *MemoryStore) ListDueMemorialActivations(now time.Time, limit int) ([]*MemorialActivation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	copyGuardian := *guardian
-	return &copyGuardian, nil
+	due := make([]*MemorialActivation, 0)
+	for _, activation := range s.memorialActivations {
+		waitingDue := activation.Status == MemorialWaiting && activation.UnlocksAt != nil && !activation.UnlocksAt.After(now)
+		pendingDue := activation.Status == MemorialPending && !activation.ExpiresAt.After(now)
+		if waitingDue || pendingDue {
+			due = append(due, copyMemorialActivation(activation))
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].CreatedAt.Before(due[j].CreatedAt)
+	})
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
 }
 
-func (s *MemoryStore) DeleteGuardian(userID, guardianID string) error {
+// copyMemorialActivation copia o pedido sem compartilhar as listas
+func copyMemorialActivation(activation *MemorialActivation) *MemorialActivation {
+	copyActivation := *activation
+	copyActivation.GuardianIDs = append([]string(nil), activation.GuardianIDs...)
+	copyActivation.Confirmations = append([]MemorialConfirmation(nil), activation.Confirmations...)
+	return &copyActivation
+}
+
+// ============================================================================
+// PASSWORD RESET (Recuperação de Senha)
+// ============================================================================
+
+func (s *MemoryStore) CreatePasswordResetToken(token *PasswordResetToken) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	userGuardians, ok := s.guardians[userID]
-	if !ok {
-		return ErrNotFound
-	}
-	if _, exists := userGuardians[guardianID]; !exists {
-		return ErrNotFound
+	// Invalidar tokens anteriores
+	for hash, t := range s.passwordResetTokens {
+		if t.UserID == token.UserID && t.UsedAt == nil {
+			now := time.Now()
+			t.UsedAt = &now
+			s.passwordResetTokens[hash] = t
+		}
 	}
-	delete(userGuardians, guardianID)
+
+	s.passwordResetTokens[token.Token] = token
 	return nil
 }
 
-// ListGuardiansPaginated lista guardiões com paginação
-func (s *MemoryStore) ListGuardiansPaginated(userID string, params *PaginationParams) (*PaginatedResult[*Guardian], error) {
-	params = NormalizePagination(params)
-
+func (s *MemoryStore) GetPasswordResetToken(tokenHash string) (*PasswordResetToken, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	userGuardians := s.guardians[userID]
+	token, ok := s.passwordResetTokens[tokenHash]
+	if !ok {
+		return nil, ErrNotFound
+	}
 
-	// Converter para slice
-	var allGuardians []*Guardian
-	for _, g := range userGuardians {
-		copyG := *g
-		allGuardians = append(allGuardians, &copyG)
+	if token.UsedAt != nil || token.ExpiresAt.Before(time.Now()) {
+		return nil, ErrNotFound
 	}
 
-	// Ordenar por ID desc
-	for i := 0; i < len(allGuardians); i++ {
-		for j := i + 1; j < len(allGuardians); j++ {
-			if allGuardians[i].ID < allGuardians[j].ID {
-				allGuardians[i], allGuardians[j] = allGuardians[j], allGuardians[i]
-			}
+	copyToken := *token
+	return &copyToken, nil
+}
+
+func (s *MemoryStore) MarkPasswordResetTokenUsed(tokenID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range s.passwordResetTokens {
+		if t.ID == tokenID {
+			now := time.Now()
+			t.UsedAt = &now
+			return nil
 		}
 	}
+	return ErrNotFound
+}
 
-	// Aplicar cursor
-	startIdx := 0
-	if params.Cursor != "" {
-		for i, g := range allGuardians {
-			if g.ID == params.Cursor {
-				startIdx = i + 1
-				break
-			}
+func (s *MemoryStore) CleanupExpiredPasswordResetTokens() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for hash, t := range s.passwordResetTokens {
+		if t.ExpiresAt.Before(time.Now()) || t.UsedAt != nil {
+			delete(s.passwordResetTokens, hash)
 		}
 	}
+	return nil
+}
 
-	// Paginar
-	endIdx := startIdx + params.Limit + 1
-	if endIdx > len(allGuardians) {
-		endIdx = len(allGuardians)
-	}
+// ============================================================================
+// EMERGENCY PROTOCOL (Protocolo de Emergência)
+// ============================================================================
 
-	pagedGuardians := allGuardians[startIdx:endIdx]
-	hasMore := len(pagedGuardians) > params.Limit
-	if hasMore {
-		pagedGuardians = pagedGuardians[:params.Limit]
-	}
+func (s *MemoryStore) GetEmergencyProtocol(userID string) (*EmergencyProtocol, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	var nextCursor string
-	if hasMore && len(pagedGuardians) > 0 {
-		nextCursor = pagedGuardians[len(pagedGuardians)-1].ID
+	protocol, ok := s.emergencyProtocols[userID]
+	if !ok {
+		return &EmergencyProtocol{UserID: userID, IsActive: false, NotifyGuardians: true}, nil
 	}
 
-	return &PaginatedResult[*Guardian]{
-		Items:      pagedGuardians,
-		NextCursor: nextCursor,
-		HasMore:    hasMore,
-	}, nil
+	copyProtocol := *protocol
+	return &copyProtocol, nil
+}
+
+func (s *MemoryStore) UpdateEmergencyProtocol(protocol *EmergencyProtocol) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.emergencyProtocols[protocol.UserID] = protocol
+	return nil
 }
 
-// CountGuardians conta o total de guardiões de um usuário
-func (s *MemoryStore) CountGuardians(userID string) (int, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return len(s.guardians[userID]), nil
+func (s *MemoryStore) SaveEmergencyCard(card *EmergencyCard) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.emergencyCards[card.UserID] = copyEmergencyCard(card)
+	return nil
 }
 
-// GetGuardianByAccessToken busca um guardião pelo seu token de acesso
-func (s *MemoryStore) GetGuardianByAccessToken(token string) (*Guardian, error) {
+func (s *MemoryStore) GetEmergencyCard(userID string) (*EmergencyCard, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	for _, userGuardians := range s.guardians {
-		for _, g := range userGuardians {
-			if g.AccessToken == token {
-				copyGuardian := *g
-				return &copyGuardian, nil
-			}
-		}
+	card, ok := s.emergencyCards[userID]
+	if !ok {
+		return nil, ErrNotFound
 	}
-	return nil, ErrNotFound
+	return copyEmergencyCard(card), nil
 }
 
-// ListSharedItems lista itens compartilhados de um usuário
-func (s *MemoryStore) ListSharedItems(userID string) []*BoxItem {
+func (s *MemoryStore) GetEmergencyCardByToken(tokenHash string) (*EmergencyCard, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	var result []*BoxItem
-	for _, item := range s.items[userID] {
-		if item.IsShared {
-			copyItem := *item
-			result = append(result, &copyItem)
+	for _, card := range s.emergencyCards {
+		if card.TokenHash == tokenHash {
+			return copyEmergencyCard(card), nil
 		}
 	}
-	return result
+	return nil, ErrNotFound
 }
 
-// ============ IDEMPOTÊNCIA ============
-
-func (s *MemoryStore) RegisterIdempotencyKey(userID, key, resourceType, resourceID string) (string, bool, error) {
+func (s *MemoryStore) DeleteEmergencyCard(userID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, ok := s.idempotencyKeys[userID]; !ok {
-		s.idempotencyKeys[userID] = make(map[string]map[string]string)
-	}
-	if _, ok := s.idempotencyKeys[userID][resourceType]; !ok {
-		s.idempotencyKeys[userID][resourceType] = make(map[string]string)
-	}
-
-	if existingID, ok := s.idempotencyKeys[userID][resourceType][key]; ok {
-		return existingID, false, nil
+	if _, ok := s.emergencyCards[userID]; !ok {
+		return ErrNotFound
 	}
-
-	s.idempotencyKeys[userID][resourceType][key] = resourceID
-	return "", true, nil
+	delete(s.emergencyCards, userID)
+	return nil
 }
 
-func (s *MemoryStore) DeleteIdempotencyKey(userID, key, resourceType string) error {
+func (s *MemoryStore) TouchEmergencyCard(userID string, accessedAt time.Time) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, ok := s.idempotencyKeys[userID]; !ok {
-		return nil
-	}
-	if _, ok := s.idempotencyKeys[userID][resourceType]; !ok {
-		return nil
+	card, ok := s.emergencyCards[userID]
+	if !ok {
+		return ErrNotFound
 	}
-	delete(s.idempotencyKeys[userID][resourceType], key)
+	card.LastAccessedAt = &accessedAt
 	return nil
 }
 
-// ============ GUIDE PROGRESS ============
+// copyEmergencyCard copia o cartão sem compartilhar as listas
+func copyEmergencyCard(card *EmergencyCard) *EmergencyCard {
+	copyCard := *card
+	copyCard.Contacts = append([]EmergencyContact(nil), card.Contacts...)
+	copyCard.Medications = append([]string(nil), card.Medications...)
+	return &copyCard
+}
 
-func (s *MemoryStore) GetGuideProgress(userID string) map[string]*GuideProgress {
+func (s *MemoryStore) GetEscalationChain(userID string) (*EscalationChain, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	userProgress := s.progress[userID]
-	result := make(map[string]*GuideProgress)
-	for cardID, p := range userProgress {
-		copyProgress := *p
-		result[cardID] = &copyProgress
+	chain, ok := s.escalationChains[userID]
+	if !ok {
+		return nil, ErrNotFound
 	}
-	return result
+	copyChain := *chain
+	copyChain.GuardianIDs = append([]string(nil), chain.GuardianIDs...)
+	return &copyChain, nil
 }
 
-func (s *MemoryStore) UpdateGuideProgress(userID, cardID, status string) (*GuideProgress, error) {
+func (s *MemoryStore) SaveEscalationChain(chain *EscalationChain) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, ok := s.progress[userID]; !ok {
-		s.progress[userID] = make(map[string]*GuideProgress)
-	}
-
-	progress := &GuideProgress{
-		UserID: userID,
-		CardID: cardID,
-		Status: status,
-	}
-	if status == "completed" {
-		progress.CompletedAt = time.Now()
-	}
-
-	s.progress[userID][cardID] = progress
-	return progress, nil
+	copyChain := *chain
+	copyChain.GuardianIDs = append([]string(nil), chain.GuardianIDs...)
+	copyChain.UpdatedAt = time.Now()
+	s.escalationChains[chain.UserID] = &copyChain
+	chain.UpdatedAt = copyChain.UpdatedAt
+	return nil
 }
 
-// ============ SETTINGS ============
-
-func (s *MemoryStore) GetSettings(userID string) *Settings {
+func (s *MemoryStore) DeleteEscalationChain(userID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	settings, ok := s.settings[userID]
-	if !ok {
-		settings = &Settings{
-			UserID:               userID,
-			NotificationsEnabled: true,
-			Theme:                "light",
-		}
-		s.settings[userID] = settings
+	if _, ok := s.escalationChains[userID]; !ok {
+		return ErrNotFound
 	}
-	copySettings := *settings
-	return &copySettings
+	delete(s.escalationChains, userID)
+	return nil
 }
 
-func (s *MemoryStore) UpdateSettings(userID string, updates *Settings) *Settings {
+func (s *MemoryStore) CreateEscalation(escalation *EmergencyEscalation) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	updates.UserID = userID
-	s.settings[userID] = updates
-	copySettings := *updates
-	return &copySettings
+	now := time.Now()
+	escalation.CreatedAt = now
+	escalation.UpdatedAt = now
+	s.escalations[escalation.ID] = copyEscalation(escalation)
+	return nil
 }
 
-// ============ ADMIN / ESTATÍSTICAS ============
+// UpdateEscalation salva o escalonamento se o estado salvo ainda for from
+// (a confirmação e o worker não se sobrescrevem)
+func (s *MemoryStore) UpdateEscalation(escalation *EmergencyEscalation, from EscalationStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-// Stats representa estatísticas do sistema
-type Stats struct {
-	TotalUsers      int            `json:"total_users"`
-	TotalItems      int            `json:"total_items"`
-	TotalGuardians  int            `json:"total_guardians"`
-	ItemsByType     map[string]int `json:"items_by_type"`
-	ItemsByCategory map[string]int `json:"items_by_category"`
-	RecentSignups   int            `json:"recent_signups"` // Últimos 7 dias
+	current, ok := s.escalations[escalation.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if current.Status != from {
+		return ErrVersionConflict
+	}
+	escalation.UpdatedAt = time.Now()
+	s.escalations[escalation.ID] = copyEscalation(escalation)
+	return nil
 }
 
-// GetStats retorna estatísticas gerais do sistema
-func (s *MemoryStore) GetStats() *Stats {
+func (s *MemoryStore) GetLatestEscalation(userID string) (*EmergencyEscalation, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	stats := &Stats{
-		ItemsByType:     make(map[string]int),
-		ItemsByCategory: make(map[string]int),
-	}
-
-	// Contar usuários
-	stats.TotalUsers = len(s.users)
-
-	// Contar inscrições recentes (últimos 7 dias)
-	sevenDaysAgo := time.Now().AddDate(0, 0, -7)
-	for _, user := range s.users {
-		if user.CreatedAt.After(sevenDaysAgo) {
-			stats.RecentSignups++
-		}
-	}
-
-	// Contar itens e categorias
-	for _, userItems := range s.items {
-		for _, item := range userItems {
-			stats.TotalItems++
-			stats.ItemsByType[string(item.Type)]++
-			if item.Category != "" {
-				stats.ItemsByCategory[item.Category]++
-			}
+	var latest *EmergencyEscalation
+	for _, escalation := range s.escalations {
+		if escalation.UserID == userID && (latest == nil || escalation.CreatedAt.After(latest.CreatedAt)) {
+			latest = escalation
 		}
 	}
-
-	// Contar guardiões
-	for _, userGuardians := range s.guardians {
-		stats.TotalGuardians += len(userGuardians)
+	if latest == nil {
+		return nil, ErrNotFound
 	}
-
-	return stats
+	return copyEscalation(latest), nil
 }
 
-// ListUsers retorna lista de todos os usuários (para admin)
-func (s *MemoryStore) ListUsers() []*User {
+func (s *MemoryStore) GetEscalationByAckToken(tokenHash string) (*EmergencyEscalation, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	users := make([]*User, 0, len(s.users))
-	for _, user := range s.users {
-		// Criar cópia sem a senha
-		copyUser := &User{
-			ID:        user.ID,
-			Email:     user.Email,
-			Name:      user.Name,
-			CreatedAt: user.CreatedAt,
-			// Password NÃO incluído
+	for _, escalation := range s.escalations {
+		if escalation.StepByAckToken(tokenHash) != nil {
+			return copyEscalation(escalation), nil
 		}
-		users = append(users, copyUser)
 	}
-	return users
-}
-
-// ============ FEEDBACK ============
-
-// CreateFeedback salva um novo feedback
-func (s *MemoryStore) CreateFeedback(f *Feedback) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.feedbacks[f.ID] = f
-	return nil
+	return nil, ErrNotFound
 }
 
-// ListFeedbacks retorna todos os feedbacks
-func (s *MemoryStore) ListFeedbacks(status string, limit int) ([]*Feedback, error) {
+// ListDueEscalations lista escalonamentos ativos cuja próxima chamada já venceu
+func (s *MemoryStore) ListDueEscalations(now time.Time, limit int) ([]*EmergencyEscalation, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	result := make([]*Feedback, 0)
-	count := 0
-	for _, f := range s.feedbacks {
-		if status != "" && status != "all" && f.Status != status {
+	due := make([]*EmergencyEscalation, 0)
+	for _, escalation := range s.escalations {
+		if escalation.Status != EscalationActive || escalation.NextStepAt == nil || escalation.NextStepAt.After(now) {
 			continue
 		}
-		result = append(result, f)
-		count++
-		if count >= limit {
-			break
-		}
+		due = append(due, copyEscalation(escalation))
 	}
-	return result, nil
-}
-
-// UpdateFeedbackStatus atualiza o status de um feedback
-func (s *MemoryStore) UpdateFeedbackStatus(id, status, adminNote string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	if f, ok := s.feedbacks[id]; ok {
-		f.Status = status
-		f.AdminNote = adminNote
-		f.UpdatedAt = time.Now()
-		return nil
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].NextStepAt.Before(*due[j].NextStepAt)
+	})
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
 	}
-	return ErrNotFound
+	return due, nil
 }
 
-// GetFeedbackStats retorna estatísticas de feedbacks
-func (s *MemoryStore) GetFeedbackStats() (total, pending int) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	for _, f := range s.feedbacks {
-		total++
-		if f.Status == "pending" {
-			pending++
-		}
-	}
-	return
+// copyEscalation copia o escalonamento sem compartilhar a lista
+func copyEscalation(escalation *EmergencyEscalation) *EmergencyEscalation {
+	copyEscalation := *escalation
+	copyEscalation.Steps = append([]EscalationStep(nil), escalation.Steps...)
+	return &copyEscalation
 }
 
-// ============ ANALYTICS ============
+// ============================================================================
+// WEBHOOKS (Integrações de saída)
+// ============================================================================
 
-// TrackEvent registra um evento de analytics
-func (s *MemoryStore) TrackEvent(e *AnalyticsEvent) error {
+// CreateWebhook salva um novo webhook
+func (s *MemoryStore) CreateWebhook(hook *Webhook) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	// Limitar a 10000 eventos para não estourar memória
-	if len(s.analytics) > 10000 {
-		s.analytics = s.analytics[1000:] // Remover os 1000 mais antigos
-	}
-	s.analytics = append(s.analytics, e)
+
+	copyHook := *hook
+	s.webhooks[hook.ID] = &copyHook
 	return nil
 }
 
-// GetAnalyticsSummary retorna o resumo de analytics
-func (s *MemoryStore) GetAnalyticsSummary() *AnalyticsSummary {
+// GetWebhook busca um webhook do usuário
+func (s *MemoryStore) GetWebhook(userID, webhookID string) (*Webhook, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	summary := &AnalyticsSummary{
-		EventsByType: make(map[string]int),
-	}
-	summary.TotalUsers = len(s.users)
-
-	for _, items := range s.items {
-		summary.TotalItems += len(items)
-	}
-	for _, guards := range s.guardians {
-		summary.TotalGuardians += len(guards)
-	}
-
-	today := time.Now().Truncate(24 * time.Hour)
-	weekAgo := today.AddDate(0, 0, -7)
-
-	activeUsers := make(map[string]bool)
-	for _, e := range s.analytics {
-		summary.EventsByType[string(e.EventType)]++
-		if e.CreatedAt.After(today) {
-			summary.EventsToday++
-			if e.UserID != "" {
-				activeUsers[e.UserID] = true
-			}
-		}
-	}
-	summary.ActiveToday = len(activeUsers)
-
-	for _, user := range s.users {
-		if user.CreatedAt.After(today) {
-			summary.NewUsersToday++
-		}
-		if user.CreatedAt.After(weekAgo) {
-			summary.NewUsersThisWeek++
-		}
-	}
-
-	summary.TotalFeedbacks, summary.PendingFeedbacks = s.GetFeedbackStats()
-
-	return summary
+	hook, ok := s.webhooks[webhookID]
+	if !ok || hook.UserID != userID {
+		return nil, ErrNotFound
+	}
+	copyHook := *hook
+	return &copyHook, nil
 }
 
-// GetRecentEvents retorna os eventos mais recentes
-func (s *MemoryStore) GetRecentEvents(limit int) ([]*AnalyticsEvent, error) {
+// ListWebhooks lista os webhooks do usuário (mais recentes primeiro)
+func (s *MemoryStore) ListWebhooks(userID string) ([]*Webhook, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	if len(s.analytics) == 0 {
-		return []*AnalyticsEvent{}, nil
+	hooks := make([]*Webhook, 0)
+	for _, hook := range s.webhooks {
+		if hook.UserID == userID {
+			copyHook := *hook
+			hooks = append(hooks, &copyHook)
+		}
 	}
+	sort.Slice(hooks, func(i, j int) bool {
+		return hooks[i].CreatedAt.After(hooks[j].CreatedAt)
+	})
+	return hooks, nil
+}
 
-	start := len(s.analytics) - limit
-	if start < 0 {
-		start = 0
+// ListWebhooksForEvent lista os webhooks ativos do usuário que assinam o evento
+func (s *MemoryStore) ListWebhooksForEvent(userID string, event WebhookEvent) ([]*Webhook, error) {
+	hooks, err := s.ListWebhooks(userID)
+	if err != nil {
+		return nil, err
 	}
 
-	result := make([]*AnalyticsEvent, 0, limit)
-	for i := len(s.analytics) - 1; i >= start; i-- {
-		result = append(result, s.analytics[i])
+	matched := make([]*Webhook, 0, len(hooks))
+	for _, hook := range hooks {
+		if hook.IsActive && hook.Subscribes(event) {
+			matched = append(matched, hook)
+		}
 	}
-
-	return result, nil
+	return matched, nil
 }
 
-// GetDailyStats retorna estatísticas diárias
-func (s *MemoryStore) GetDailyStats(days int) ([]map[string]interface{}, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	// Simplificado para MemoryStore
-	stats := make([]map[string]interface{}, 0)
-	today := time.Now().Truncate(24 * time.Hour)
-
-	for i := days - 1; i >= 0; i-- {
-		day := today.AddDate(0, 0, -i)
-		dayStats := map[string]interface{}{
-			"date":   day.Format("2006-01-02"),
-			"events": 0,
-			"users":  0,
-		}
+// UpdateWebhook atualiza URL, eventos, descrição, status e segredo
+func (s *MemoryStore) UpdateWebhook(hook *Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-		users := make(map[string]bool)
-		for _, e := range s.analytics {
-			eventDay := e.CreatedAt.Truncate(24 * time.Hour)
-			if eventDay.Equal(day) {
-				dayStats["events"] = dayStats["events"].(int) + 1
-				if e.UserID != "" {
-					users[e.UserID] = true
-				}
-			}
-		}
-		dayStats["users"] = len(users)
-		stats = append(stats, dayStats)
+	existing, ok := s.webhooks[hook.ID]
+	if !ok || existing.UserID != hook.UserID {
+		return ErrNotFound
 	}
 
-	return stats, nil
+	existing.URL = hook.URL
+	existing.Secret = hook.Secret
+	existing.Events = hook.Events
+	existing.Description = hook.Description
+	existing.IsActive = hook.IsActive
+	if hook.IsActive {
+		existing.FailureCount = hook.FailureCount
+	}
+	existing.UpdatedAt = time.Now()
+	return nil
 }
 
-// CleanupOldLogs limpa analytics antigos (no-op para MemoryStore, já que reinicia com o servidor)
-func (s *MemoryStore) CleanupOldLogs(retentionDays int) error {
+// DeleteWebhook remove um webhook e suas entregas
+func (s *MemoryStore) DeleteWebhook(userID, webhookID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if retentionDays < 7 {
-		retentionDays = 7
+	hook, ok := s.webhooks[webhookID]
+	if !ok || hook.UserID != userID {
+		return ErrNotFound
 	}
 
-	cutoff := time.Now().AddDate(0, 0, -retentionDays)
-	newAnalytics := make([]*AnalyticsEvent, 0)
-
-	for _, e := range s.analytics {
-		if e.CreatedAt.After(cutoff) {
-			newAnalytics = append(newAnalytics, e)
+	delete(s.webhooks, webhookID)
+	for id, delivery := range s.webhookDeliveries {
+		if delivery.WebhookID == webhookID {
+			delete(s.webhookDeliveries, id)
 		}
 	}
+	return nil
+}
 
-	s.analytics = newAnalytics
+// RecordWebhookResult atualiza falhas consecutivas e a data da última entrega
+func (s *MemoryStore) RecordWebhookResult(webhookID string, success bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	if len(s.shareLinkAccesses) > 0 {
-		newShareAccesses := make([]*ShareLinkAccess, 0)
-		for _, access := range s.shareLinkAccesses {
-			if access.AccessedAt.After(cutoff) {
-				newShareAccesses = append(newShareAccesses, access)
-			}
-		}
-		s.shareLinkAccesses = newShareAccesses
+	hook, ok := s.webhooks[webhookID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if success {
+		now := time.Now()
+		hook.FailureCount = 0
+		hook.LastDeliveryAt = &now
+	} else {
+		hook.FailureCount++
 	}
 	return nil
 }
 
-// ============================================================================
-// SHARE LINKS (Compartilhamento com Guardiões)
-// ============================================================================
+// CreateWebhookDelivery registra uma nova entrega
+func (s *MemoryStore) CreateWebhookDelivery(delivery *WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-func (s *MemoryStore) CreateShareLink(link *ShareLink) error {
+	copyDelivery := *delivery
+	s.webhookDeliveries[delivery.ID] = &copyDelivery
+	return nil
+}
+
+// UpdateWebhookDelivery atualiza o resultado de uma tentativa de entrega
+func (s *MemoryStore) UpdateWebhookDelivery(delivery *WebhookDelivery) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.shareLinks[link.ID] = link
-	s.shareLinksByToken[link.Token] = link.ID
+	if _, ok := s.webhookDeliveries[delivery.ID]; !ok {
+		return ErrNotFound
+	}
+	copyDelivery := *delivery
+	s.webhookDeliveries[delivery.ID] = &copyDelivery
 	return nil
 }
 
-func (s *MemoryStore) GetShareLinkByToken(token string) (*ShareLink, error) {
+// ListWebhookDeliveries lista as entregas mais recentes de um webhook
+func (s *MemoryStore) ListWebhookDeliveries(webhookID string, limit int) ([]*WebhookDelivery, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	linkID, ok := s.shareLinksByToken[token]
-	if !ok {
-		return nil, ErrNotFound
+	deliveries := make([]*WebhookDelivery, 0)
+	for _, delivery := range s.webhookDeliveries {
+		if delivery.WebhookID == webhookID {
+			copyDelivery := *delivery
+			deliveries = append(deliveries, &copyDelivery)
+		}
 	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+	})
+	if limit > 0 && len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
 
-	link, exists := s.shareLinks[linkID]
-	if !exists || !link.IsActive {
-		return nil, ErrNotFound
+// ListDueWebhookDeliveries lista entregas pendentes cuja próxima tentativa já venceu
+func (s *MemoryStore) ListDueWebhookDeliveries(now time.Time, limit int) ([]*WebhookDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	due := make([]*WebhookDelivery, 0)
+	for _, delivery := range s.webhookDeliveries {
+		if delivery.Status != WebhookDeliveryPending || delivery.NextAttemptAt == nil || delivery.NextAttemptAt.After(now) {
+			continue
+		}
+		copyDelivery := *delivery
+		due = append(due, &copyDelivery)
 	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].NextAttemptAt.Before(*due[j].NextAttemptAt)
+	})
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
 
-	copyLink := *link
-	return &copyLink, nil
+// ============================================================================
+// WEB PUSH (Inscrições por dispositivo)
+// ============================================================================
+
+// SavePushSubscription cria ou substitui a inscrição do endpoint
+func (s *MemoryStore) SavePushSubscription(sub *PushSubscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copySub := *sub
+	s.pushSubscriptions[sub.Endpoint] = &copySub
+	return nil
 }
 
-func (s *MemoryStore) GetShareLinksByUser(userID string) ([]*ShareLink, error) {
+// ListPushSubscriptions lista as inscrições do usuário (mais recentes primeiro)
+func (s *MemoryStore) ListPushSubscriptions(userID string) ([]*PushSubscription, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	var links []*ShareLink
-	for _, link := range s.shareLinks {
-		if link.UserID == userID {
-			copyLink := *link
-			links = append(links, &copyLink)
+	subs := make([]*PushSubscription, 0)
+	for _, sub := range s.pushSubscriptions {
+		if sub.UserID == userID {
+			copySub := *sub
+			subs = append(subs, &copySub)
 		}
 	}
-	return links, nil
+	sort.Slice(subs, func(i, j int) bool {
+		return subs[i].CreatedAt.After(subs[j].CreatedAt)
+	})
+	return subs, nil
 }
 
-func (s *MemoryStore) UpdateShareLink(link *ShareLink) error {
+// DeletePushSubscription remove a inscrição do endpoint (apenas do próprio usuário)
+func (s *MemoryStore) DeletePushSubscription(userID, endpoint string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	existing, ok := s.shareLinks[link.ID]
-	if !ok || existing.UserID != link.UserID {
+	sub, ok := s.pushSubscriptions[endpoint]
+	if !ok || sub.UserID != userID {
 		return ErrNotFound
 	}
-
-	existing.Name = link.Name
-	existing.Categories = link.Categories
-	existing.ExpiresAt = link.ExpiresAt
-	existing.MaxUses = link.MaxUses
-	existing.IsActive = link.IsActive
-	existing.UpdatedAt = time.Now()
+	delete(s.pushSubscriptions, endpoint)
 	return nil
 }
 
-func (s *MemoryStore) DeleteShareLink(userID, linkID string) error {
+// TouchPushSubscription registra o último envio com sucesso
+func (s *MemoryStore) TouchPushSubscription(id string, usedAt time.Time) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	link, ok := s.shareLinks[linkID]
-	if !ok || link.UserID != userID {
-		return ErrNotFound
+	for _, sub := range s.pushSubscriptions {
+		if sub.ID == id {
+			sub.LastUsedAt = &usedAt
+			return nil
+		}
 	}
-
-	delete(s.shareLinksByToken, link.Token)
-	delete(s.shareLinks, linkID)
-	return nil
+	return ErrNotFound
 }
 
-func (s *MemoryStore) RecordShareLinkAccess(access *ShareLinkAccess) error {
+// ============================================================================
+// CENTRAL DE NOTIFICAÇÕES
+// ============================================================================
+
+// CreateNotification salva um novo aviso
+func (s *MemoryStore) CreateNotification(n *Notification) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.shareLinkAccesses = append(s.shareLinkAccesses, access)
+	copyN := *n
+	copyN.Channels = append([]string{}, n.Channels...)
+	s.notifications[n.ID] = &copyN
 	return nil
 }
 
-func (s *MemoryStore) IncrementShareLinkUsage(linkID string) error {
+// UpdateNotificationChannels registra os canais externos em que o aviso foi entregue
+func (s *MemoryStore) UpdateNotificationChannels(notificationID string, channels []string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	link, ok := s.shareLinks[linkID]
+	n, ok := s.notifications[notificationID]
 	if !ok {
 		return ErrNotFound
 	}
-
-	link.UsageCount++
-	now := time.Now()
-	link.LastUsedAt = &now
+	n.Channels = append([]string{}, channels...)
 	return nil
 }
 
-// ============================================================================
-// PASSWORD RESET (Recuperação de Senha)
-// ============================================================================
+// ListNotifications lista os avisos do usuário (mais recentes primeiro, cursor = ID)
+func (s *MemoryStore) ListNotifications(userID string, unreadOnly bool, params *PaginationParams) (*PaginatedResult[*Notification], error) {
+	params = NormalizePagination(params)
 
-func (s *MemoryStore) CreatePasswordResetToken(token *PasswordResetToken) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	// Invalidar tokens anteriores
-	for hash, t := range s.passwordResetTokens {
-		if t.UserID == token.UserID && t.UsedAt == nil {
-			now := time.Now()
-			t.UsedAt = &now
-			s.passwordResetTokens[hash] = t
+	all := make([]*Notification, 0)
+	for _, n := range s.notifications {
+		if n.UserID != userID || (unreadOnly && n.ReadAt != nil) {
+			continue
 		}
+		copyN := *n
+		all = append(all, &copyN)
 	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].CreatedAt.Equal(all[j].CreatedAt) {
+			return all[i].ID > all[j].ID
+		}
+		return all[i].CreatedAt.After(all[j].CreatedAt)
+	})
 
-	s.passwordResetTokens[token.Token] = token
-	return nil
+	start := 0
+	if params.Cursor != "" {
+		for i, n := range all {
+			if n.ID == params.Cursor {
+				start = i + 1
+				break
+			}
+		}
+	}
+	page := all[start:]
+	hasMore := len(page) > params.Limit
+	if hasMore {
+		page = page[:params.Limit]
+	}
+
+	result := &PaginatedResult[*Notification]{Items: page, HasMore: hasMore}
+	if hasMore {
+		result.NextCursor = page[len(page)-1].ID
+	}
+	return result, nil
 }
 
-func (s *MemoryStore) GetPasswordResetToken(tokenHash string) (*PasswordResetToken, error) {
+// CountUnreadNotifications conta os avisos não lidos do usuário
+func (s *MemoryStore) CountUnreadNotifications(userID string) (int, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	token, ok := s.passwordResetTokens[tokenHash]
-	if !ok {
-		return nil, ErrNotFound
-	}
-
-	if token.UsedAt != nil || token.ExpiresAt.Before(time.Now()) {
-		return nil, ErrNotFound
+	count := 0
+	for _, n := range s.notifications {
+		if n.UserID == userID && n.ReadAt == nil {
+			count++
+		}
 	}
-
-	copyToken := *token
-	return &copyToken, nil
+	return count, nil
 }
 
-func (s *MemoryStore) MarkPasswordResetTokenUsed(tokenID string) error {
+// MarkNotificationRead marca um aviso do usuário como lido
+func (s *MemoryStore) MarkNotificationRead(userID, notificationID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	for _, t := range s.passwordResetTokens {
-		if t.ID == tokenID {
-			now := time.Now()
-			t.UsedAt = &now
-			return nil
-		}
+	n, ok := s.notifications[notificationID]
+	if !ok || n.UserID != userID {
+		return ErrNotFound
 	}
-	return ErrNotFound
+	if n.ReadAt == nil {
+		now := time.Now()
+		n.ReadAt = &now
+	}
+	return nil
 }
 
-func (s *MemoryStore) CleanupExpiredPasswordResetTokens() error {
+// MarkAllNotificationsRead marca todos os avisos do usuário como lidos
+func (s *MemoryStore) MarkAllNotificationsRead(userID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	for hash, t := range s.passwordResetTokens {
-		if t.ExpiresAt.Before(time.Now()) || t.UsedAt != nil {
-			delete(s.passwordResetTokens, hash)
+	now := time.Now()
+	for _, n := range s.notifications {
+		if n.UserID == userID && n.ReadAt == nil {
+			readAt := now
+			n.ReadAt = &readAt
 		}
 	}
 	return nil
 }
 
-// ============================================================================
-// EMERGENCY PROTOCOL (Protocolo de Emergência)
-// ============================================================================
+// ============ CATEGORIAS ============
 
-func (s *MemoryStore) GetEmergencyProtocol(userID string) (*EmergencyProtocol, error) {
+// ListCategories lista as categorias do usuário por posição
+func (s *MemoryStore) ListCategories(userID string) ([]*Category, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	protocol, ok := s.emergencyProtocols[userID]
-	if !ok {
-		return &EmergencyProtocol{UserID: userID, IsActive: false, NotifyGuardians: true}, nil
+	result := make([]*Category, 0)
+	for _, category := range s.categories {
+		if category.UserID == userID {
+			copyCategory := *category
+			result = append(result, &copyCategory)
+		}
 	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Position != result[j].Position {
+			return result[i].Position < result[j].Position
+		}
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
 
-	copyProtocol := *protocol
-	return &copyProtocol, nil
+// CreateCategory cria uma categoria (nomes únicos por usuário, sem diferenciar maiúsculas)
+func (s *MemoryStore) CreateCategory(category *Category) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.categoryNameTakenLocked(category.UserID, category.Name, "") {
+		return ErrAlreadyExists
+	}
+	copyCategory := *category
+	s.categories[category.ID] = &copyCategory
+	return nil
 }
 
-func (s *MemoryStore) UpdateEmergencyProtocol(protocol *EmergencyProtocol) error {
+// UpdateCategory altera nome, cor e ícone; renomear atualiza os itens do usuário
+func (s *MemoryStore) UpdateCategory(category *Category) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.emergencyProtocols[protocol.UserID] = protocol
+	existing, ok := s.categories[category.ID]
+	if !ok || existing.UserID != category.UserID {
+		return ErrNotFound
+	}
+	if s.categoryNameTakenLocked(category.UserID, category.Name, category.ID) {
+		return ErrAlreadyExists
+	}
+
+	if existing.Name != category.Name {
+		for _, item := range s.items[category.UserID] {
+			if item.Category == existing.Name {
+				item.Category = category.Name
+			}
+		}
+	}
+
+	existing.Name = category.Name
+	existing.Color = category.Color
+	existing.Icon = category.Icon
+	existing.UpdatedAt = category.UpdatedAt
+	return nil
+}
+
+// DeleteCategory remove a categoria; os itens do usuário ficam sem categoria
+func (s *MemoryStore) DeleteCategory(userID, categoryID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.categories[categoryID]
+	if !ok || existing.UserID != userID {
+		return ErrNotFound
+	}
+	for _, item := range s.items[userID] {
+		if item.Category == existing.Name {
+			item.Category = ""
+		}
+	}
+	delete(s.categories, categoryID)
+	return nil
+}
+
+// ReorderCategories define a posição das categorias pela ordem da lista
+func (s *MemoryStore) ReorderCategories(userID string, categoryIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for position, id := range categoryIDs {
+		if category, ok := s.categories[id]; ok && category.UserID == userID {
+			category.Position = position
+		}
+	}
 	return nil
 }
+
+func (s *MemoryStore) categoryNameTakenLocked(userID, name, exceptID string) bool {
+	for id, category := range s.categories {
+		if id != exceptID && category.UserID == userID && strings.EqualFold(category.Name, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,356 @@
+// =============================================================================
+// FAMLI - Migrações Versionadas
+// =============================================================================
+// Sistema de migrações do PostgreSQL baseado em arquivos numerados embutidos
+// no binário (internal/storage/migrations/*.sql).
+//
+// Convenção de nomes:
+//   NNNN_descricao.up.sql   - aplica a mudança
+//   NNNN_descricao.down.sql - desfaz a mudança (rollback)
+//
+// As versões aplicadas ficam registradas na tabela schema_migrations.
+// Cada migração roda em sua própria transação e um advisory lock impede
+// que duas instâncias migrem o banco ao mesmo tempo.
+//
+// Uso via CLI:
+//   go run main.go -migrate up
+//   go run main.go -migrate down -steps 1
+//   go run main.go -migrate status
+// =============================================================================
+
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID é a chave do advisory lock usado durante as migrações
+const migrationLockID = 727364001
+
+// migrationFilePattern valida nomes como 0001_initial.up.sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration representa uma migração versionada
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus descreve o estado de uma migração no banco
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// MigrationSummary resume as migrações para o health check
+type MigrationSummary struct {
+	Current int   `json:"current"`           // Última versão aplicada no banco
+	Latest  int   `json:"latest"`            // Última versão embutida no binário
+	Pending []int `json:"pending,omitempty"` // Versões do binário ainda não aplicadas
+}
+
+// Migrator aplica e reverte migrações em um banco PostgreSQL
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// NewMigrator cria um migrator com as migrações embutidas no binário
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// OpenMigrator conecta ao banco sem aplicar migrações automaticamente
+// Usado pela flag -migrate (o chamador deve chamar Close ao final)
+func OpenMigrator(databaseURL string) (*Migrator, error) {
+	db, err := openPostgres(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return migrator, nil
+}
+
+// Close fecha a conexão com o banco
+func (m *Migrator) Close() error {
+	return m.db.Close()
+}
+
+// loadMigrations lê e ordena os arquivos de migração
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar migrações: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("nome de migração inválido: %s", entry.Name())
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		content, err := fs.ReadFile(fsys, path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler migração %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("versão %d duplicada (%s, %s)", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migração %04d_%s sem arquivo .up.sql", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// =============================================================================
+// OPERAÇÕES
+// =============================================================================
+
+// Up aplica todas as migrações pendentes, em ordem crescente
+// Retorna a quantidade de migrações aplicadas
+func (m *Migrator) Up() (int, error) {
+	applied := 0
+	err := m.withLock(func(conn *sql.Conn) error {
+		done, err := m.appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(conn, migration, true); err != nil {
+				return err
+			}
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverte as últimas N migrações aplicadas, em ordem decrescente
+// Retorna a quantidade de migrações revertidas
+func (m *Migrator) Down(steps int) (int, error) {
+	if steps <= 0 {
+		steps = 1
+	}
+
+	reverted := 0
+	err := m.withLock(func(conn *sql.Conn) error {
+		done, err := m.appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && reverted < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migração %04d_%s não possui rollback (.down.sql)", migration.Version, migration.Name)
+			}
+			if err := m.apply(conn, migration, false); err != nil {
+				return err
+			}
+			reverted++
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status retorna o estado de todas as migrações conhecidas
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	var result []MigrationStatus
+	err := m.withLock(func(conn *sql.Conn) error {
+		done, err := m.appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+			if appliedAt, ok := done[migration.Version]; ok {
+				at := appliedAt
+				status.Applied = true
+				status.AppliedAt = &at
+			}
+			result = append(result, status)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// summarizeMigrations compara as versões aplicadas com as do binário
+// Não usa o advisory lock: o health check não pode esperar uma migração.
+func summarizeMigrations(ctx context.Context, db *sql.DB) (*MigrationSummary, error) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	summary := &MigrationSummary{}
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+		if version > summary.Current {
+			summary.Current = version
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, migration := range migrations {
+		summary.Latest = migration.Version
+		if !applied[migration.Version] {
+			summary.Pending = append(summary.Pending, migration.Version)
+		}
+	}
+	return summary, nil
+}
+
+// =============================================================================
+// FUNÇÕES AUXILIARES
+// =============================================================================
+
+// withLock executa fn em uma conexão dedicada protegida por advisory lock
+func (m *Migrator) withLock(fn func(conn *sql.Conn) error) error {
+	ctx := context.Background()
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao obter conexão: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("erro ao obter lock de migração: %w", err)
+	}
+	defer conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, migrationLockID)
+
+	if _, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`); err != nil {
+		return fmt.Errorf("erro ao criar schema_migrations: %w", err)
+	}
+
+	return fn(conn)
+}
+
+// appliedVersions retorna as versões já aplicadas e quando foram aplicadas
+func (m *Migrator) appliedVersions(conn *sql.Conn) (map[int]time.Time, error) {
+	rows, err := conn.QueryContext(context.Background(), `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	done := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		done[version] = appliedAt
+	}
+	return done, rows.Err()
+}
+
+// apply executa uma migração (up ou down) dentro de uma transação
+func (m *Migrator) apply(conn *sql.Conn, migration Migration, up bool) error {
+	ctx := context.Background()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	script, direction := migration.Up, "up"
+	if !up {
+		script, direction = migration.Down, "down"
+	}
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("erro na migração %04d_%s (%s): %w", migration.Version, migration.Name, direction, err)
+	}
+
+	if up {
+		_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, migration.Version, migration.Name)
+	} else {
+		_, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao registrar migração %04d: %w", migration.Version, err)
+	}
+
+	return tx.Commit()
+}
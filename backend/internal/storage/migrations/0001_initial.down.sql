@@ -0,0 +1,22 @@
+-- =============================================================================
+-- FAMLI - Migração 0001 (rollback): Schema inicial
+-- =============================================================================
+-- ATENÇÃO: remove TODAS as tabelas da aplicação. Use apenas em ambientes de
+-- desenvolvimento ou com backup recente.
+-- =============================================================================
+
+DROP TABLE IF EXISTS emergency_protocols;
+DROP TABLE IF EXISTS password_reset_tokens;
+DROP TABLE IF EXISTS share_link_accesses;
+DROP TABLE IF EXISTS share_links;
+DROP TABLE IF EXISTS analytics_events;
+DROP TABLE IF EXISTS feedbacks;
+DROP TABLE IF EXISTS deletion_tokens;
+DROP TABLE IF EXISTS audit_log;
+DROP TABLE IF EXISTS settings;
+DROP TABLE IF EXISTS guide_progress;
+DROP TABLE IF EXISTS guardians;
+DROP TABLE IF EXISTS box_items;
+DROP TABLE IF EXISTS idempotency_keys;
+DROP TABLE IF EXISTS system_config;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,293 @@
+-- =============================================================================
+-- FAMLI - Migração 0001: Schema inicial
+-- =============================================================================
+-- Consolida o schema que antes era aplicado pelo slice inline de migrate().
+-- Todos os comandos são idempotentes (IF NOT EXISTS), então bancos já
+-- existentes apenas registram esta versão em schema_migrations.
+-- =============================================================================
+
+-- Extensão UUID (ignora erro se não houver privilégio)
+DO $$
+BEGIN
+    CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+EXCEPTION
+    WHEN insufficient_privilege THEN
+        RAISE NOTICE 'uuid-ossp extension not available';
+END
+$$;
+
+-- Tabela users
+CREATE TABLE IF NOT EXISTS users (
+    id VARCHAR(50) PRIMARY KEY,
+    email VARCHAR(255) NOT NULL UNIQUE,
+    name VARCHAR(255),
+    password VARCHAR(255) NOT NULL,
+    terms_accepted BOOLEAN DEFAULT FALSE,
+    terms_accepted_at TIMESTAMP,
+    locale VARCHAR(10) DEFAULT 'pt-BR',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Migration: adicionar coluna locale se não existir
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'users' AND column_name = 'locale')
+    THEN
+        ALTER TABLE users ADD COLUMN locale VARCHAR(10) DEFAULT 'pt-BR';
+    END IF;
+END $$;
+
+-- Configuração do sistema (ex: salt de criptografia)
+CREATE TABLE IF NOT EXISTS system_config (
+    key VARCHAR(100) PRIMARY KEY,
+    value TEXT NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Idempotência (evita criação duplicada por retries)
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id VARCHAR(50) NOT NULL,
+    key VARCHAR(120) NOT NULL,
+    resource_type VARCHAR(50) NOT NULL,
+    resource_id VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, key, resource_type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_created ON idempotency_keys(created_at DESC);
+
+-- Tabela box_items (com limite de 10KB para content)
+CREATE TABLE IF NOT EXISTS box_items (
+    id VARCHAR(50) PRIMARY KEY,
+    user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL DEFAULT 'info',
+    title VARCHAR(512) NOT NULL,
+    content VARCHAR(10000),
+    category VARCHAR(50),
+    recipient VARCHAR(512),
+    is_important BOOLEAN DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Tabela guardians (notas limitadas a 1KB)
+CREATE TABLE IF NOT EXISTS guardians (
+    id VARCHAR(50) PRIMARY KEY,
+    user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(512) NOT NULL,
+    email VARCHAR(512),
+    phone VARCHAR(128),
+    relationship VARCHAR(255),
+    role VARCHAR(20) DEFAULT 'viewer',
+    notes VARCHAR(512),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Tabela guide_progress
+CREATE TABLE IF NOT EXISTS guide_progress (
+    user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    card_id VARCHAR(50) NOT NULL,
+    status VARCHAR(50) NOT NULL DEFAULT 'pending',
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, card_id)
+);
+
+-- Tabela settings
+CREATE TABLE IF NOT EXISTS settings (
+    user_id VARCHAR(50) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    emergency_protocol_enabled BOOLEAN DEFAULT FALSE,
+    notifications_enabled BOOLEAN DEFAULT TRUE,
+    theme VARCHAR(20) DEFAULT 'light',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- ===========================================================================
+-- ÍNDICES PARA PERFORMANCE
+-- ===========================================================================
+-- Colunas para Social Auth (Google, Apple)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS provider VARCHAR(50) DEFAULT 'email';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS provider_id VARCHAR(255);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_url TEXT;
+
+-- Índices de usuários
+CREATE INDEX IF NOT EXISTS idx_users_email ON users(LOWER(email));
+CREATE INDEX IF NOT EXISTS idx_users_created_at ON users(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_users_provider ON users(provider, provider_id) WHERE provider_id IS NOT NULL;
+
+-- Índices de box_items (performance em listagens e filtros)
+CREATE INDEX IF NOT EXISTS idx_box_items_user ON box_items(user_id);
+CREATE INDEX IF NOT EXISTS idx_box_items_user_type ON box_items(user_id, type);
+CREATE INDEX IF NOT EXISTS idx_box_items_user_created ON box_items(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_box_items_important ON box_items(user_id, is_important) WHERE is_important = TRUE;
+
+-- Índices de guardians
+CREATE INDEX IF NOT EXISTS idx_guardians_user ON guardians(user_id);
+
+-- Migrações: Compartilhamento integrado
+ALTER TABLE box_items ADD COLUMN IF NOT EXISTS is_shared BOOLEAN DEFAULT FALSE;
+ALTER TABLE box_items ADD COLUMN IF NOT EXISTS guardian_ids TEXT[];
+ALTER TABLE guardians ADD COLUMN IF NOT EXISTS access_token VARCHAR(100);
+ALTER TABLE guardians ADD COLUMN IF NOT EXISTS access_pin VARCHAR(255);
+ALTER TABLE guardians ADD COLUMN IF NOT EXISTS access_type VARCHAR(20) DEFAULT 'normal';
+CREATE INDEX IF NOT EXISTS idx_guardians_token ON guardians(access_token) WHERE access_token IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_box_items_shared ON box_items(user_id, is_shared) WHERE is_shared = TRUE;
+CREATE INDEX IF NOT EXISTS idx_box_items_guardian_ids ON box_items USING GIN (guardian_ids);
+CREATE INDEX IF NOT EXISTS idx_box_items_user_updated ON box_items(user_id, updated_at DESC);
+
+-- Índices de guide_progress (para verificar progresso rapidamente)
+CREATE INDEX IF NOT EXISTS idx_guide_progress_user ON guide_progress(user_id);
+
+-- ===========================================================================
+-- AUDITORIA E SEGURANÇA
+-- ===========================================================================
+-- Tabela de auditoria para rastrear ações sensíveis (LGPD)
+-- Removido user_agent para economizar espaço no banco
+CREATE TABLE IF NOT EXISTS audit_log (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(50),
+    action VARCHAR(100) NOT NULL,
+    resource_type VARCHAR(50),
+    resource_id VARCHAR(50),
+    ip_address VARCHAR(45),
+    details JSONB,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_user ON audit_log(user_id);
+CREATE INDEX IF NOT EXISTS idx_audit_action ON audit_log(action);
+CREATE INDEX IF NOT EXISTS idx_audit_created ON audit_log(created_at DESC);
+
+-- Tabela para tokens de exclusão (confirmação de exclusão de conta)
+CREATE TABLE IF NOT EXISTS deletion_tokens (
+    id VARCHAR(100) PRIMARY KEY,
+    user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_deletion_tokens_user ON deletion_tokens(user_id);
+CREATE INDEX IF NOT EXISTS idx_deletion_tokens_expires ON deletion_tokens(expires_at);
+
+-- ===========================================================================
+-- FEEDBACK
+-- ===========================================================================
+-- Feedbacks com limites de tamanho para economizar espaço
+CREATE TABLE IF NOT EXISTS feedbacks (
+    id VARCHAR(50) PRIMARY KEY,
+    user_id VARCHAR(50) REFERENCES users(id) ON DELETE SET NULL,
+    user_email VARCHAR(255),
+    type VARCHAR(50) NOT NULL DEFAULT 'suggestion',
+    message VARCHAR(2000) NOT NULL,
+    page VARCHAR(100),
+    status VARCHAR(50) NOT NULL DEFAULT 'pending',
+    admin_note VARCHAR(500),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_feedbacks_user ON feedbacks(user_id);
+CREATE INDEX IF NOT EXISTS idx_feedbacks_status ON feedbacks(status);
+CREATE INDEX IF NOT EXISTS idx_feedbacks_created ON feedbacks(created_at DESC);
+ALTER TABLE feedbacks ADD COLUMN IF NOT EXISTS user_agent VARCHAR(255);
+
+-- ===========================================================================
+-- ANALYTICS (com limpeza automática de eventos antigos)
+-- ===========================================================================
+CREATE TABLE IF NOT EXISTS analytics_events (
+    id VARCHAR(50) PRIMARY KEY,
+    user_id VARCHAR(50),
+    event_type VARCHAR(50) NOT NULL,
+    page VARCHAR(100),
+    details JSONB,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_analytics_user ON analytics_events(user_id);
+CREATE INDEX IF NOT EXISTS idx_analytics_type ON analytics_events(event_type);
+CREATE INDEX IF NOT EXISTS idx_analytics_created ON analytics_events(created_at DESC);
+
+-- Nota: Índice parcial com CURRENT_DATE não é permitido (não-IMMUTABLE)
+-- Consultas usam WHERE created_at >= date_trunc('day', CURRENT_TIMESTAMP) no runtime
+-- ===========================================================================
+-- COMPARTILHAMENTO E ACESSO (Links para Guardiões)
+-- ===========================================================================
+CREATE TABLE IF NOT EXISTS share_links (
+    id VARCHAR(50) PRIMARY KEY,
+    user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    guardian_id VARCHAR(50) REFERENCES guardians(id) ON DELETE SET NULL,
+    token VARCHAR(100) NOT NULL UNIQUE,
+    type VARCHAR(20) NOT NULL DEFAULT 'normal',
+    name VARCHAR(255) NOT NULL,
+    pin_hash VARCHAR(255),
+    categories TEXT[],
+    expires_at TIMESTAMP,
+    max_uses INT DEFAULT 0,
+    usage_count INT DEFAULT 0,
+    last_used_at TIMESTAMP,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_share_links_user ON share_links(user_id);
+CREATE INDEX IF NOT EXISTS idx_share_links_token ON share_links(token) WHERE is_active = TRUE;
+CREATE INDEX IF NOT EXISTS idx_share_links_guardian ON share_links(guardian_id);
+ALTER TABLE share_links ADD COLUMN IF NOT EXISTS guardian_ids TEXT[];
+
+-- Registro de acessos aos links
+CREATE TABLE IF NOT EXISTS share_link_accesses (
+    id VARCHAR(50) PRIMARY KEY,
+    share_link_id VARCHAR(50) NOT NULL REFERENCES share_links(id) ON DELETE CASCADE,
+    ip_address VARCHAR(45),
+    user_agent VARCHAR(500),
+    accessed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_share_accesses_link ON share_link_accesses(share_link_id);
+
+-- Ajustes de tamanho para campos curtos (com espaço para criptografia)
+ALTER TABLE box_items ALTER COLUMN title TYPE VARCHAR(512);
+ALTER TABLE box_items ALTER COLUMN recipient TYPE VARCHAR(512);
+ALTER TABLE guardians ALTER COLUMN name TYPE VARCHAR(512);
+ALTER TABLE guardians ALTER COLUMN email TYPE VARCHAR(512);
+ALTER TABLE guardians ALTER COLUMN phone TYPE VARCHAR(128);
+ALTER TABLE guardians ALTER COLUMN relationship TYPE VARCHAR(255);
+ALTER TABLE guardians ALTER COLUMN notes TYPE VARCHAR(512);
+ALTER TABLE share_links ALTER COLUMN name TYPE VARCHAR(255);
+ALTER TABLE feedbacks ALTER COLUMN page TYPE VARCHAR(255);
+
+-- ===========================================================================
+-- RECUPERAÇÃO DE SENHA
+-- ===========================================================================
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    id VARCHAR(50) PRIMARY KEY,
+    user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_reset_user ON password_reset_tokens(user_id);
+CREATE INDEX IF NOT EXISTS idx_password_reset_expires ON password_reset_tokens(expires_at);
+
+-- ===========================================================================
+-- PROTOCOLO DE EMERGÊNCIA
+-- ===========================================================================
+CREATE TABLE IF NOT EXISTS emergency_protocols (
+    user_id VARCHAR(50) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    is_active BOOLEAN DEFAULT FALSE,
+    activated_at TIMESTAMP,
+    activated_by VARCHAR(50),
+    deactivated_at TIMESTAMP,
+    reason VARCHAR(500),
+    notify_guardians BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
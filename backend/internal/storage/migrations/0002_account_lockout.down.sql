@@ -0,0 +1,7 @@
+-- =============================================================================
+-- FAMLI - Migração 0002 (rollback): Bloqueio de conta e histórico de login
+-- =============================================================================
+
+DROP TABLE IF EXISTS login_history;
+ALTER TABLE users DROP COLUMN IF EXISTS locked_until;
+ALTER TABLE users DROP COLUMN IF EXISTS failed_login_attempts;
@@ -0,0 +1,20 @@
+-- =============================================================================
+-- FAMLI - Migração 0002: Bloqueio de conta e histórico de login
+-- =============================================================================
+
+-- Tentativas de login falhas consecutivas e bloqueio temporário
+ALTER TABLE users ADD COLUMN IF NOT EXISTS failed_login_attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP;
+
+-- Histórico de logins (detecção de novo dispositivo/país)
+CREATE TABLE IF NOT EXISTS login_history (
+    id VARCHAR(50) PRIMARY KEY,
+    user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    ip_address VARCHAR(45),
+    country VARCHAR(2),
+    device_hash VARCHAR(64),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_login_history_user_device ON login_history(user_id, device_hash);
+CREATE INDEX IF NOT EXISTS idx_login_history_user_country ON login_history(user_id, country);
+CREATE INDEX IF NOT EXISTS idx_login_history_created ON login_history(created_at);
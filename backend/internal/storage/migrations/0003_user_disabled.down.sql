@@ -0,0 +1,6 @@
+-- =============================================================================
+-- FAMLI - Migração 0003 (rollback): Desativação de contas pelo admin
+-- =============================================================================
+
+DROP INDEX IF EXISTS idx_users_created_id;
+ALTER TABLE users DROP COLUMN IF EXISTS disabled_at;
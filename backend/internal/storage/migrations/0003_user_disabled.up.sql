@@ -0,0 +1,9 @@
+-- =============================================================================
+-- FAMLI - Migração 0003: Desativação de contas pelo admin
+-- =============================================================================
+
+-- Conta desativada por um administrador (NULL = ativa)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMP;
+
+-- Busca de usuários no painel admin (ordenação estável por data + id)
+CREATE INDEX IF NOT EXISTS idx_users_created_id ON users(created_at DESC, id DESC);
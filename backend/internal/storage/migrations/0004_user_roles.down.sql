@@ -0,0 +1,6 @@
+-- =============================================================================
+-- FAMLI - Migração 0004 (rollback): Papéis administrativos
+-- =============================================================================
+
+DROP INDEX IF EXISTS idx_users_role;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
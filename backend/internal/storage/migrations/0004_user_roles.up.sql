@@ -0,0 +1,10 @@
+-- =============================================================================
+-- FAMLI - Migração 0004: Papéis administrativos
+-- =============================================================================
+
+-- Papel do usuário no painel admin ('' = usuário comum)
+-- support: atendimento (contas e feedbacks)
+-- analyst: métricas e analytics (somente leitura)
+-- superadmin: acesso total, incluindo remoção de contas e gestão de papéis
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_users_role ON users(role) WHERE role <> '';
@@ -0,0 +1,6 @@
+-- =============================================================================
+-- FAMLI - Migração 0005 (rollback): Webhooks de saída
+-- =============================================================================
+
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
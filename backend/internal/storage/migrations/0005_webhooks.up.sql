@@ -0,0 +1,37 @@
+-- =============================================================================
+-- FAMLI - Migração 0005: Webhooks de saída
+-- =============================================================================
+
+-- Webhooks configurados pelo usuário
+CREATE TABLE IF NOT EXISTS webhooks (
+    id VARCHAR(50) PRIMARY KEY,
+    user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,              -- Segredo HMAC (criptografado)
+    events TEXT[] NOT NULL,
+    description VARCHAR(200),
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    failure_count INT NOT NULL DEFAULT 0, -- Falhas consecutivas
+    last_delivery_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_webhooks_user ON webhooks(user_id);
+
+-- Entregas (log + fila de retentativas)
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id VARCHAR(50) PRIMARY KEY,
+    webhook_id VARCHAR(50) NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    event VARCHAR(50) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    response_code INT,
+    error TEXT,
+    next_attempt_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1,5 @@
+-- =============================================================================
+-- FAMLI - Migração 0006 (rollback): Canal de notificação do guardião
+-- =============================================================================
+
+ALTER TABLE guardians DROP COLUMN IF EXISTS notify_channel;
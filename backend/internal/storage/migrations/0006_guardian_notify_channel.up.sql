@@ -0,0 +1,9 @@
+-- =============================================================================
+-- FAMLI - Migração 0006: Canal de notificação do guardião
+-- =============================================================================
+
+-- Canal preferido para avisos ao guardião ('' = automático)
+-- whatsapp: mensagem no WhatsApp (padrão quando há telefone)
+-- sms: SMS via Twilio (guardiões sem WhatsApp)
+-- email: apenas email
+ALTER TABLE guardians ADD COLUMN IF NOT EXISTS notify_channel VARCHAR(20) NOT NULL DEFAULT '';
@@ -0,0 +1,6 @@
+-- =============================================================================
+-- FAMLI - Migração 0007 (rollback): Notificações Web Push
+-- =============================================================================
+
+ALTER TABLE settings DROP COLUMN IF EXISTS notification_opt_outs;
+DROP TABLE IF EXISTS push_subscriptions;
@@ -0,0 +1,19 @@
+-- =============================================================================
+-- FAMLI - Migração 0007: Notificações Web Push
+-- =============================================================================
+
+-- Inscrições Web Push (uma por navegador/dispositivo)
+CREATE TABLE IF NOT EXISTS push_subscriptions (
+    id VARCHAR(50) PRIMARY KEY,
+    user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    endpoint TEXT NOT NULL UNIQUE,     -- URL do serviço de push
+    p256dh TEXT NOT NULL,              -- Chave pública do navegador
+    auth TEXT NOT NULL,                -- Segredo de autenticação (criptografado)
+    user_agent VARCHAR(300),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_push_subscriptions_user ON push_subscriptions(user_id);
+
+-- Categorias de notificação desligadas pelo usuário
+ALTER TABLE settings ADD COLUMN IF NOT EXISTS notification_opt_outs TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,5 @@
+-- =============================================================================
+-- FAMLI - Migração 0008 (rollback): Central de notificações
+-- =============================================================================
+
+DROP TABLE IF EXISTS notifications;
@@ -0,0 +1,18 @@
+-- =============================================================================
+-- FAMLI - Migração 0008: Central de notificações
+-- =============================================================================
+
+-- Avisos exibidos na central de notificações (inbox) do usuário
+CREATE TABLE IF NOT EXISTS notifications (
+    id VARCHAR(50) PRIMARY KEY,
+    user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    category VARCHAR(30) NOT NULL,
+    title VARCHAR(200) NOT NULL,
+    body TEXT NOT NULL,
+    url TEXT,
+    channels TEXT[] NOT NULL DEFAULT '{}', -- Canais externos em que foi entregue
+    read_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications(user_id) WHERE read_at IS NULL;
@@ -0,0 +1,8 @@
+-- =============================================================================
+-- FAMLI - Migração 0009 (rollback): Datas dos itens e calendário ICS
+-- =============================================================================
+
+DROP TABLE IF EXISTS calendar_feeds;
+DROP INDEX IF EXISTS idx_box_items_dates;
+ALTER TABLE box_items DROP COLUMN IF EXISTS renewal_date;
+ALTER TABLE box_items DROP COLUMN IF EXISTS due_date;
@@ -0,0 +1,17 @@
+-- =============================================================================
+-- FAMLI - Migração 0009: Datas dos itens e calendário ICS
+-- =============================================================================
+
+-- Vencimento e renovação (apenas o dia) dos itens da caixa
+ALTER TABLE box_items ADD COLUMN IF NOT EXISTS due_date DATE;
+ALTER TABLE box_items ADD COLUMN IF NOT EXISTS renewal_date DATE;
+CREATE INDEX IF NOT EXISTS idx_box_items_dates ON box_items(user_id)
+    WHERE due_date IS NOT NULL OR renewal_date IS NOT NULL;
+
+-- Link privado do calendário (um por usuário; apenas o hash do token)
+CREATE TABLE IF NOT EXISTS calendar_feeds (
+    user_id VARCHAR(50) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_accessed_at TIMESTAMP
+);
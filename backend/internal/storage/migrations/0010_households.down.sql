@@ -0,0 +1,8 @@
+-- =============================================================================
+-- FAMLI - Migração 0010 (rollback): Famílias (caixas compartilhadas)
+-- =============================================================================
+
+DROP INDEX IF EXISTS idx_box_items_household;
+ALTER TABLE box_items DROP COLUMN IF EXISTS household_id;
+DROP TABLE IF EXISTS household_members;
+DROP TABLE IF EXISTS households;
@@ -0,0 +1,30 @@
+-- =============================================================================
+-- FAMLI - Migração 0010: Famílias (caixas compartilhadas)
+-- =============================================================================
+
+CREATE TABLE IF NOT EXISTS households (
+    id VARCHAR(50) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    owner_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Membros e convites (pending até o convidado aceitar)
+CREATE TABLE IF NOT EXISTS household_members (
+    household_id VARCHAR(50) NOT NULL REFERENCES households(id) ON DELETE CASCADE,
+    user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    permission VARCHAR(20) NOT NULL DEFAULT 'view', -- view, edit, manage
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',  -- pending, active
+    invited_by VARCHAR(50),
+    joined_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (household_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_household_members_user ON household_members(user_id);
+
+-- Itens da caixa da família (NULL = caixa pessoal)
+-- Ao excluir a família, os itens voltam para a caixa pessoal de quem criou
+ALTER TABLE box_items ADD COLUMN IF NOT EXISTS household_id VARCHAR(50)
+    REFERENCES households(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_box_items_household ON box_items(household_id) WHERE household_id IS NOT NULL;
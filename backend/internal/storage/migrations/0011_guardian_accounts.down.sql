@@ -0,0 +1,6 @@
+-- =============================================================================
+-- FAMLI - Migração 0011 (rollback): Contas do portal do guardião
+-- =============================================================================
+
+DROP INDEX IF EXISTS idx_guardians_account;
+ALTER TABLE guardians DROP COLUMN IF EXISTS account_user_id;
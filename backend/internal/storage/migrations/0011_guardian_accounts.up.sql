@@ -0,0 +1,8 @@
+-- =============================================================================
+-- FAMLI - Migração 0011: Contas do portal do guardião
+-- =============================================================================
+
+-- Conta Famli vinculada ao guardião (com o token e o PIN do link de acesso)
+-- Uma mesma conta pode ser guardiã de vários donos.
+ALTER TABLE guardians ADD COLUMN IF NOT EXISTS account_user_id VARCHAR(50) REFERENCES users(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_guardians_account ON guardians(account_user_id) WHERE account_user_id IS NOT NULL;
@@ -0,0 +1,5 @@
+-- =============================================================================
+-- FAMLI - Migração 0012 (rollback): Tentativas de PIN
+-- =============================================================================
+
+DROP TABLE IF EXISTS pin_attempts;
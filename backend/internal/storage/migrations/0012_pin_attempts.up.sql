@@ -0,0 +1,12 @@
+-- =============================================================================
+-- FAMLI - Migração 0012: Tentativas de PIN
+-- =============================================================================
+
+-- Falhas consecutivas de PIN por link de acesso (proteção contra força bruta)
+-- key: "share:<linkID>" ou "guardian:<guardianID>"
+-- O bloqueio é calculado a partir de failed_attempts e last_failed_at.
+CREATE TABLE IF NOT EXISTS pin_attempts (
+    key VARCHAR(100) PRIMARY KEY,
+    failed_attempts INTEGER NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
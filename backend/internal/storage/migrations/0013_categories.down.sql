@@ -0,0 +1,6 @@
+-- =============================================================================
+-- FAMLI - Migração 0013 (rollback): Categorias definidas pelo usuário
+-- =============================================================================
+
+-- Os itens mantêm o nome da categoria em box_items.category
+DROP TABLE IF EXISTS categories;
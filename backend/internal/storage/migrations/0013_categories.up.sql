@@ -0,0 +1,37 @@
+-- =============================================================================
+-- FAMLI - Migração 0013: Categorias definidas pelo usuário
+-- =============================================================================
+
+-- Os itens continuam guardando o nome da categoria (box_items.category)
+CREATE TABLE IF NOT EXISTS categories (
+    id VARCHAR(50) PRIMARY KEY,
+    user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(40) NOT NULL,
+    color VARCHAR(7) NOT NULL DEFAULT '#9ca3af',
+    icon VARCHAR(16) NOT NULL DEFAULT '',
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_user_name ON categories(user_id, LOWER(name));
+
+-- Categorias fixas de antes (sanitizeCategory) para todos os usuários
+INSERT INTO categories (id, user_id, name, color, icon, position)
+SELECT 'cat_' || md5(u.id || ':' || d.name), u.id, d.name, d.color, d.icon, d.position
+FROM users u
+CROSS JOIN (VALUES
+    ('saúde', '#ef4444', '🏥', 0),
+    ('finanças', '#22c55e', '💰', 1),
+    ('família', '#f59e0b', '👨‍👩‍👧', 2),
+    ('documentos', '#3b82f6', '📄', 3),
+    ('memórias', '#ec4899', '💝', 4),
+    ('outros', '#9ca3af', '📁', 5)
+) AS d(name, color, icon, position)
+ON CONFLICT DO NOTHING;
+
+-- Outras categorias já usadas nos itens (ex.: criadas pelo WhatsApp)
+INSERT INTO categories (id, user_id, name, position)
+SELECT DISTINCT 'cat_' || md5(user_id || ':' || category), user_id, LEFT(category, 40), 100
+FROM box_items
+WHERE category IS NOT NULL AND category <> ''
+ON CONFLICT DO NOTHING;
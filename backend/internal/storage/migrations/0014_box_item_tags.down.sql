@@ -0,0 +1,6 @@
+-- =============================================================================
+-- FAMLI - Migração 0014 (rollback): Tags dos itens da caixa
+-- =============================================================================
+
+DROP INDEX IF EXISTS idx_box_items_tags;
+ALTER TABLE box_items DROP COLUMN IF EXISTS tags;
@@ -0,0 +1,9 @@
+-- =============================================================================
+-- FAMLI - Migração 0014: Tags dos itens da caixa
+-- =============================================================================
+
+-- Tags livres (minúsculas), além da categoria
+ALTER TABLE box_items ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+-- Filtro por tag (tags @> ARRAY[...])
+CREATE INDEX IF NOT EXISTS idx_box_items_tags ON box_items USING GIN (tags);
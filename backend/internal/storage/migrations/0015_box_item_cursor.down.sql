@@ -0,0 +1,7 @@
+-- =============================================================================
+-- FAMLI - Migração 0015 (rollback): Paginação estável dos itens da caixa
+-- =============================================================================
+
+DROP INDEX IF EXISTS idx_box_items_user_created;
+CREATE INDEX IF NOT EXISTS idx_box_items_user_created ON box_items(user_id, created_at DESC);
+ALTER TABLE box_items ALTER COLUMN created_at DROP NOT NULL;
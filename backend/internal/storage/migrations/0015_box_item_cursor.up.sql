@@ -0,0 +1,11 @@
+-- =============================================================================
+-- FAMLI - Migração 0015: Paginação estável dos itens da caixa
+-- =============================================================================
+
+-- O cursor da listagem usa (created_at, id): created_at não pode ser nulo
+UPDATE box_items SET created_at = COALESCE(updated_at, CURRENT_TIMESTAMP) WHERE created_at IS NULL;
+ALTER TABLE box_items ALTER COLUMN created_at SET NOT NULL;
+
+-- Mesma ordem do ORDER BY created_at DESC, id DESC
+DROP INDEX IF EXISTS idx_box_items_user_created;
+CREATE INDEX IF NOT EXISTS idx_box_items_user_created ON box_items(user_id, created_at DESC, id DESC);
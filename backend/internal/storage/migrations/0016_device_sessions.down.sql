@@ -0,0 +1,5 @@
+-- =============================================================================
+-- FAMLI - Migração 0016 (rollback): Sessões por dispositivo
+-- =============================================================================
+
+DROP TABLE IF EXISTS device_sessions;
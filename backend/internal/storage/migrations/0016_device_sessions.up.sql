@@ -0,0 +1,16 @@
+-- =============================================================================
+-- FAMLI - Migração 0016: Sessões por dispositivo
+-- =============================================================================
+
+-- Cada login cria uma sessão; o ID vai no JWT (claim "sid")
+-- Remover a sessão encerra o login naquele dispositivo
+CREATE TABLE IF NOT EXISTS device_sessions (
+    id VARCHAR(50) PRIMARY KEY,
+    user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip_address VARCHAR(45),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_device_sessions_user ON device_sessions(user_id, last_seen_at DESC);
@@ -0,0 +1,5 @@
+-- =============================================================================
+-- FAMLI - Migração 0017 (rollback): Tamanho do conteúdo dos itens (cotas)
+-- =============================================================================
+
+ALTER TABLE box_items DROP COLUMN IF EXISTS content_bytes;
@@ -0,0 +1,13 @@
+-- =============================================================================
+-- FAMLI - Migração 0017: Tamanho do conteúdo dos itens (cotas)
+-- =============================================================================
+
+-- Bytes de título + conteúdo + destinatário antes da criptografia
+-- Gravado pela aplicação em cada criação/edição
+ALTER TABLE box_items ADD COLUMN IF NOT EXISTS content_bytes BIGINT NOT NULL DEFAULT 0;
+
+-- Itens existentes: estimativa pelo tamanho gravado (criptografado, um pouco
+-- maior que o texto original) até a próxima edição
+UPDATE box_items
+SET content_bytes = OCTET_LENGTH(COALESCE(title, '')) + OCTET_LENGTH(COALESCE(content, '')) + OCTET_LENGTH(COALESCE(recipient, ''))
+WHERE content_bytes = 0;
@@ -0,0 +1,6 @@
+-- =============================================================================
+-- FAMLI - Migração 0018 (rollback): Planos e assinaturas (Stripe)
+-- =============================================================================
+
+DROP TABLE IF EXISTS subscriptions;
+ALTER TABLE users DROP COLUMN IF EXISTS plan;
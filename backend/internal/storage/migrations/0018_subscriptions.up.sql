@@ -0,0 +1,18 @@
+-- =============================================================================
+-- FAMLI - Migração 0018: Planos e assinaturas (Stripe)
+-- =============================================================================
+
+-- Plano atual do usuário (free ou premium), mantido pelos webhooks do Stripe
+ALTER TABLE users ADD COLUMN IF NOT EXISTS plan VARCHAR(20) NOT NULL DEFAULT 'free';
+
+-- Uma assinatura por usuário; o cliente do Stripe é criado no primeiro checkout
+CREATE TABLE IF NOT EXISTS subscriptions (
+    user_id VARCHAR(50) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    customer_id VARCHAR(100) NOT NULL UNIQUE,
+    subscription_id VARCHAR(100) NOT NULL DEFAULT '',
+    plan VARCHAR(20) NOT NULL DEFAULT 'free',
+    status VARCHAR(30) NOT NULL DEFAULT '',
+    current_period_end TIMESTAMP,
+    cancel_at_period_end BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,5 @@
+-- =============================================================================
+-- FAMLI - Migração 0019 (rollback): Conversa dos feedbacks
+-- =============================================================================
+
+DROP TABLE IF EXISTS feedback_replies;
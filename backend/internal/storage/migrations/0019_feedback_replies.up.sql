@@ -0,0 +1,14 @@
+-- =============================================================================
+-- FAMLI - Migração 0019: Conversa dos feedbacks
+-- =============================================================================
+
+-- Respostas da equipe e do usuário a um feedback, em ordem cronológica
+CREATE TABLE IF NOT EXISTS feedback_replies (
+    id VARCHAR(50) PRIMARY KEY,
+    feedback_id VARCHAR(50) NOT NULL REFERENCES feedbacks(id) ON DELETE CASCADE,
+    author_id VARCHAR(50) REFERENCES users(id) ON DELETE SET NULL,
+    from_admin BOOLEAN NOT NULL DEFAULT FALSE,
+    message VARCHAR(2000) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_feedback_replies_feedback ON feedback_replies(feedback_id, created_at);
@@ -0,0 +1,8 @@
+-- =============================================================================
+-- FAMLI - Migração 0020 (rollback): Lotes e enriquecimento dos eventos de analytics
+-- =============================================================================
+
+DROP INDEX IF EXISTS idx_analytics_client_event;
+ALTER TABLE analytics_events DROP COLUMN IF EXISTS device_class;
+ALTER TABLE analytics_events DROP COLUMN IF EXISTS country;
+ALTER TABLE analytics_events DROP COLUMN IF EXISTS client_event_id;
@@ -0,0 +1,11 @@
+-- =============================================================================
+-- FAMLI - Migração 0020: Lotes e enriquecimento dos eventos de analytics
+-- =============================================================================
+
+-- ID gerado pelo cliente (reenvio de lote não duplica), país e dispositivo
+ALTER TABLE analytics_events ADD COLUMN IF NOT EXISTS client_event_id VARCHAR(64);
+ALTER TABLE analytics_events ADD COLUMN IF NOT EXISTS country VARCHAR(2);
+ALTER TABLE analytics_events ADD COLUMN IF NOT EXISTS device_class VARCHAR(10);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_analytics_client_event
+    ON analytics_events(user_id, client_event_id) WHERE client_event_id IS NOT NULL;
@@ -0,0 +1,6 @@
+-- =============================================================================
+-- FAMLI - Migração 0021 (rollback): Eventos de visitantes anônimos
+-- =============================================================================
+
+DROP INDEX IF EXISTS idx_analytics_anonymous;
+ALTER TABLE analytics_events DROP COLUMN IF EXISTS anonymous_id;
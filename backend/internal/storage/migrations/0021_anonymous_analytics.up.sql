@@ -0,0 +1,10 @@
+-- =============================================================================
+-- FAMLI - Migração 0021: Eventos de visitantes anônimos
+-- =============================================================================
+
+-- Visitante sem login (cookie famli_anon). No cadastro os eventos anônimos
+-- do visitante recebem o user_id.
+ALTER TABLE analytics_events ADD COLUMN IF NOT EXISTS anonymous_id VARCHAR(50);
+
+CREATE INDEX IF NOT EXISTS idx_analytics_anonymous
+    ON analytics_events(anonymous_id) WHERE user_id IS NULL;
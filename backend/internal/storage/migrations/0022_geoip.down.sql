@@ -0,0 +1,8 @@
+-- =============================================================================
+-- FAMLI - Migração 0022 (rollback): Localização dos acessos a links (GeoIP)
+-- =============================================================================
+
+DROP INDEX IF EXISTS idx_share_accesses_link_time;
+ALTER TABLE share_link_accesses DROP COLUMN IF EXISTS city;
+ALTER TABLE share_link_accesses DROP COLUMN IF EXISTS country_name;
+ALTER TABLE share_link_accesses DROP COLUMN IF EXISTS country;
@@ -0,0 +1,10 @@
+-- =============================================================================
+-- FAMLI - Migração 0022: Localização dos acessos a links (GeoIP)
+-- =============================================================================
+
+-- País e cidade de quem acessou (nomes no idioma do dono do link)
+ALTER TABLE share_link_accesses ADD COLUMN IF NOT EXISTS country VARCHAR(2);
+ALTER TABLE share_link_accesses ADD COLUMN IF NOT EXISTS country_name VARCHAR(100);
+ALTER TABLE share_link_accesses ADD COLUMN IF NOT EXISTS city VARCHAR(100);
+
+CREATE INDEX IF NOT EXISTS idx_share_accesses_link_time ON share_link_accesses(share_link_id, accessed_at DESC);
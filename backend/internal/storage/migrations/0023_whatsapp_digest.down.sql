@@ -0,0 +1,7 @@
+-- =============================================================================
+-- FAMLI - Migração 0023 (rollback): Resumo da Caixa pelo WhatsApp
+-- =============================================================================
+
+DROP INDEX IF EXISTS idx_settings_digest;
+ALTER TABLE settings DROP COLUMN IF EXISTS digest_last_sent_at;
+ALTER TABLE settings DROP COLUMN IF EXISTS digest_frequency;
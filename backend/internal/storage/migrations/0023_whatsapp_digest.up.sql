@@ -0,0 +1,9 @@
+-- =============================================================================
+-- FAMLI - Migração 0023: Resumo da Caixa pelo WhatsApp
+-- =============================================================================
+
+-- Frequência do resumo (off, daily, weekly) e último envio (ou inscrição)
+ALTER TABLE settings ADD COLUMN IF NOT EXISTS digest_frequency VARCHAR(10) NOT NULL DEFAULT 'off';
+ALTER TABLE settings ADD COLUMN IF NOT EXISTS digest_last_sent_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_settings_digest ON settings(digest_frequency) WHERE digest_frequency <> 'off';
@@ -0,0 +1,5 @@
+-- =============================================================================
+-- FAMLI - Migração 0024 (rollback): Uso do assistente
+-- =============================================================================
+
+DROP TABLE IF EXISTS assistant_usage;
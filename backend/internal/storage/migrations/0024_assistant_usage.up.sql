@@ -0,0 +1,16 @@
+-- =============================================================================
+-- FAMLI - Migração 0024: Uso do assistente
+-- =============================================================================
+
+-- Consumo diário (UTC) do assistente por usuário: base do orçamento diário de
+-- tokens (ASSISTANT_DAILY_TOKENS) e das métricas do admin.
+CREATE TABLE IF NOT EXISTS assistant_usage (
+    user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    requests INTEGER NOT NULL DEFAULT 0,
+    tokens INTEGER NOT NULL DEFAULT 0,
+    rejected INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, day)
+);
+
+CREATE INDEX IF NOT EXISTS idx_assistant_usage_day ON assistant_usage(day);
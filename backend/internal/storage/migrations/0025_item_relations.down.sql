@@ -0,0 +1,5 @@
+-- =============================================================================
+-- FAMLI - Migração 0025 (rollback): Relações entre itens
+-- =============================================================================
+
+DROP TABLE IF EXISTS item_relations;
@@ -0,0 +1,25 @@
+-- =============================================================================
+-- FAMLI - Migração 0025: Relações entre itens
+-- =============================================================================
+
+-- Vínculos de um item da Caixa:
+-- - intended_for: item destinado a uma pessoa de confiança (target_guardian_id)
+-- - see_also: "veja também" entre dois itens (target_item_id, vale nos dois sentidos)
+-- Remover o item ou o guardião remove o vínculo.
+CREATE TABLE IF NOT EXISTS item_relations (
+    id VARCHAR(50) PRIMARY KEY,
+    item_id VARCHAR(50) NOT NULL REFERENCES box_items(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    target_item_id VARCHAR(50) REFERENCES box_items(id) ON DELETE CASCADE,
+    target_guardian_id VARCHAR(50) REFERENCES guardians(id) ON DELETE CASCADE,
+    created_by VARCHAR(50) REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (
+        (kind = 'intended_for' AND target_guardian_id IS NOT NULL AND target_item_id IS NULL) OR
+        (kind = 'see_also' AND target_item_id IS NOT NULL AND target_guardian_id IS NULL)
+    )
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_item_relations_unique
+    ON item_relations(item_id, kind, COALESCE(target_item_id, ''), COALESCE(target_guardian_id, ''));
+CREATE INDEX IF NOT EXISTS idx_item_relations_target_item ON item_relations(target_item_id);
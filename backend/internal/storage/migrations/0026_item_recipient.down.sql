@@ -0,0 +1,7 @@
+-- =============================================================================
+-- FAMLI - Migração 0026 (rollback): Destinatário do item como pessoa de confiança
+-- =============================================================================
+
+ALTER TABLE share_links DROP COLUMN IF EXISTS messages_only;
+DROP INDEX IF EXISTS idx_box_items_recipient_guardian;
+ALTER TABLE box_items DROP COLUMN IF EXISTS recipient_guardian_id;
@@ -0,0 +1,16 @@
+-- =============================================================================
+-- FAMLI - Migração 0026: Destinatário do item como pessoa de confiança
+-- =============================================================================
+
+-- O destinatário pode apontar para um guardião; o texto livre (recipient)
+-- continua valendo para quem não está cadastrado. Remover o guardião mantém
+-- o texto e solta a referência.
+ALTER TABLE box_items ADD COLUMN IF NOT EXISTS recipient_guardian_id VARCHAR(50)
+    REFERENCES guardians(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_box_items_recipient_guardian
+    ON box_items(recipient_guardian_id) WHERE recipient_guardian_id IS NOT NULL;
+
+-- Links memoriais podem mostrar apenas as mensagens endereçadas aos
+-- guardiões do link.
+ALTER TABLE share_links ADD COLUMN IF NOT EXISTS messages_only BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,5 @@
+-- =============================================================================
+-- FAMLI - Migração 0027 (rollback): Links compartilhados por seleção de itens
+-- =============================================================================
+
+ALTER TABLE share_links DROP COLUMN IF EXISTS item_ids;
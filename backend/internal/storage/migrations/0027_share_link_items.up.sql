@@ -0,0 +1,7 @@
+-- =============================================================================
+-- FAMLI - Migração 0027: Links compartilhados por seleção de itens
+-- =============================================================================
+
+-- Itens escolhidos para o link (vazio = todos os compartilhados, respeitando
+-- as categorias). Apenas itens com is_shared = TRUE aparecem.
+ALTER TABLE share_links ADD COLUMN IF NOT EXISTS item_ids TEXT[];
@@ -0,0 +1,5 @@
+-- =============================================================================
+-- FAMLI - Migração 0028 (rollback): Recibos de leitura dos itens
+-- =============================================================================
+
+DROP TABLE IF EXISTS item_views;
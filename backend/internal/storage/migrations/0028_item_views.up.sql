@@ -0,0 +1,15 @@
+-- =============================================================================
+-- FAMLI - Migração 0028: Recibos de leitura dos itens
+-- =============================================================================
+
+-- Uma linha por item e origem (link compartilhado ou guardião): quando foi
+-- visto pela primeira e pela última vez e quantas vezes.
+CREATE TABLE IF NOT EXISTS item_views (
+    item_id VARCHAR(50) NOT NULL REFERENCES box_items(id) ON DELETE CASCADE,
+    source VARCHAR(20) NOT NULL,
+    source_id VARCHAR(50) NOT NULL,
+    first_viewed_at TIMESTAMP NOT NULL,
+    last_viewed_at TIMESTAMP NOT NULL,
+    view_count INTEGER NOT NULL DEFAULT 1,
+    PRIMARY KEY (item_id, source, source_id)
+);
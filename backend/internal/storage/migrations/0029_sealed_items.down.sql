@@ -0,0 +1,6 @@
+-- =============================================================================
+-- FAMLI - Migração 0029 (rollback): Itens selados (cifrados no navegador)
+-- =============================================================================
+
+ALTER TABLE share_links DROP COLUMN IF EXISTS passphrase_hint;
+ALTER TABLE box_items DROP COLUMN IF EXISTS sealed_params;
@@ -0,0 +1,11 @@
+-- =============================================================================
+-- FAMLI - Migração 0029: Itens selados (cifrados no navegador)
+-- =============================================================================
+
+-- Itens "sealed" guardam em content o texto cifrado no navegador; aqui ficam
+-- os parâmetros de derivação da chave e da cifra (JSON, sem a frase secreta).
+ALTER TABLE box_items ADD COLUMN IF NOT EXISTS sealed_params JSONB;
+
+-- Dica para quem recebe o link sobre como obter a frase secreta
+-- (criptografada pela aplicação, como os demais campos sensíveis).
+ALTER TABLE share_links ADD COLUMN IF NOT EXISTS passphrase_hint TEXT;
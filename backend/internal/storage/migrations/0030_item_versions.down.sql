@@ -0,0 +1,5 @@
+-- =============================================================================
+-- FAMLI - Migração 0030 (rollback): Versão dos itens (ETag / If-Match)
+-- =============================================================================
+
+ALTER TABLE box_items DROP COLUMN IF EXISTS version;
@@ -0,0 +1,7 @@
+-- =============================================================================
+-- FAMLI - Migração 0030: Versão dos itens (ETag / If-Match)
+-- =============================================================================
+
+-- Aumenta a cada atualização; o PUT só grava se a versão enviada em
+-- If-Match for a atual, para que duas abas não se sobrescrevam.
+ALTER TABLE box_items ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,7 @@
+-- =============================================================================
+-- FAMLI - Migração 0031 (rollback): Remoção de dados pedida pelo guardião (LGPD)
+-- =============================================================================
+
+DROP INDEX IF EXISTS idx_item_views_source;
+DROP INDEX IF EXISTS idx_guardians_deletion_requested;
+ALTER TABLE guardians DROP COLUMN IF EXISTS deletion_requested_at;
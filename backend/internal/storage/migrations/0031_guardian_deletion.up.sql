@@ -0,0 +1,14 @@
+-- =============================================================================
+-- FAMLI - Migração 0031: Remoção de dados pedida pelo guardião (LGPD)
+-- =============================================================================
+
+-- O guardião (sem conta) pode pedir, pelo próprio link, a remoção dos seus
+-- dados. O registro fica marcado e é apagado após o prazo de carência
+-- (GUARDIAN_DELETION_GRACE_DAYS), junto com os recibos de leitura.
+ALTER TABLE guardians ADD COLUMN IF NOT EXISTS deletion_requested_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_guardians_deletion_requested
+    ON guardians(deletion_requested_at) WHERE deletion_requested_at IS NOT NULL;
+
+-- Exportação e remoção buscam os recibos pela origem
+CREATE INDEX IF NOT EXISTS idx_item_views_source ON item_views(source, source_id);
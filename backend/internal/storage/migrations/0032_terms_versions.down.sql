@@ -0,0 +1,6 @@
+-- =============================================================================
+-- FAMLI - Migração 0032 (rollback): Versão dos termos aceitos
+-- =============================================================================
+
+ALTER TABLE users DROP COLUMN IF EXISTS privacy_version;
+ALTER TABLE users DROP COLUMN IF EXISTS terms_version;
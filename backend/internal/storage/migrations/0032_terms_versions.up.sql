@@ -0,0 +1,10 @@
+-- =============================================================================
+-- FAMLI - Migração 0032: Versão dos termos aceitos
+-- =============================================================================
+
+-- terms_accepted era apenas um booleano. Agora guardamos qual versão dos
+-- Termos de Uso e da Política de Privacidade o usuário aceitou (e quando,
+-- em terms_accepted_at); ao publicar uma nova versão, o aceite é pedido de
+-- novo. Contas antigas ficam sem versão e aceitam no próximo acesso.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS terms_version VARCHAR(50);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS privacy_version VARCHAR(50);
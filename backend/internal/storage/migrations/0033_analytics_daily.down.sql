@@ -0,0 +1,7 @@
+-- =============================================================================
+-- FAMLI - Migração 0033 (rollback): Rollups diários de analytics
+-- =============================================================================
+
+DROP TABLE IF EXISTS analytics_daily_users;
+DROP TABLE IF EXISTS analytics_daily_events;
+DROP TABLE IF EXISTS analytics_daily;
@@ -0,0 +1,31 @@
+-- =============================================================================
+-- FAMLI - Migração 0033: Rollups diários de analytics
+-- =============================================================================
+
+-- O resumo do painel admin lia analytics_events inteira a cada acesso. Agora
+-- os dias fechados são consolidados por um worker (analytics.Rollup) e o
+-- resumo soma os rollups com os eventos de hoje.
+
+-- Uma linha por dia consolidado (também marca que o dia já foi processado).
+-- Dias mais antigos que LOG_RETENTION_DAYS são apagados com os eventos.
+CREATE TABLE IF NOT EXISTS analytics_daily (
+    day DATE PRIMARY KEY,
+    events INT NOT NULL DEFAULT 0,
+    active_users INT NOT NULL DEFAULT 0,
+    rolled_up_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Eventos por tipo em cada dia
+CREATE TABLE IF NOT EXISTS analytics_daily_events (
+    day DATE NOT NULL REFERENCES analytics_daily(day) ON DELETE CASCADE,
+    event_type VARCHAR(50) NOT NULL,
+    events INT NOT NULL,
+    PRIMARY KEY (day, event_type)
+);
+
+-- Usuários ativos em cada dia (para contar ativos distintos na semana)
+CREATE TABLE IF NOT EXISTS analytics_daily_users (
+    day DATE NOT NULL REFERENCES analytics_daily(day) ON DELETE CASCADE,
+    user_id VARCHAR(50) NOT NULL,
+    PRIMARY KEY (day, user_id)
+);
@@ -0,0 +1,68 @@
+-- =============================================================================
+-- FAMLI - Migração 0034 (rollback): Partições mensais de analytics_events e audit_log
+-- =============================================================================
+
+ALTER TABLE analytics_events RENAME TO analytics_events_partitioned;
+ALTER TABLE analytics_events_partitioned RENAME CONSTRAINT analytics_events_pkey TO analytics_events_partitioned_pkey;
+
+ALTER TABLE audit_log RENAME TO audit_log_partitioned;
+ALTER TABLE audit_log_partitioned RENAME CONSTRAINT audit_log_pkey TO audit_log_partitioned_pkey;
+ALTER SEQUENCE audit_log_id_seq RENAME TO audit_log_partitioned_id_seq;
+
+CREATE TABLE analytics_events (
+    id VARCHAR(50) PRIMARY KEY,
+    user_id VARCHAR(50),
+    event_type VARCHAR(50) NOT NULL,
+    page VARCHAR(100),
+    details JSONB,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    client_event_id VARCHAR(64),
+    country VARCHAR(2),
+    device_class VARCHAR(10),
+    anonymous_id VARCHAR(50)
+);
+
+CREATE TABLE audit_log (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(50),
+    action VARCHAR(100) NOT NULL,
+    resource_type VARCHAR(50),
+    resource_id VARCHAR(50),
+    ip_address VARCHAR(45),
+    details JSONB,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- O mesmo id pode aparecer em dois meses; fica a primeira ocorrência
+INSERT INTO analytics_events (id, user_id, anonymous_id, event_type, page, details, client_event_id, country, device_class, created_at)
+SELECT DISTINCT ON (id) id, user_id, anonymous_id, event_type, page, details, client_event_id, country, device_class, created_at
+FROM analytics_events_partitioned
+ORDER BY id, created_at;
+
+INSERT INTO audit_log (id, user_id, action, resource_type, resource_id, ip_address, details, created_at)
+SELECT id::int, user_id, action, resource_type, resource_id, ip_address, details, created_at
+FROM audit_log_partitioned;
+
+SELECT setval(pg_get_serial_sequence('audit_log', 'id'), COALESCE(MAX(id), 0) + 1, false) FROM audit_log;
+
+-- analytics_client_events já impedia duplicatas; a limpeza só garante o
+-- índice único de volta na tabela
+DELETE FROM analytics_events a USING analytics_events b
+WHERE a.client_event_id IS NOT NULL AND a.user_id = b.user_id
+  AND a.client_event_id = b.client_event_id AND a.id > b.id;
+
+DROP TABLE analytics_events_partitioned;
+DROP TABLE IF EXISTS analytics_client_events;
+DROP TABLE audit_log_partitioned;
+
+CREATE INDEX IF NOT EXISTS idx_analytics_user ON analytics_events(user_id);
+CREATE INDEX IF NOT EXISTS idx_analytics_type ON analytics_events(event_type);
+CREATE INDEX IF NOT EXISTS idx_analytics_created ON analytics_events(created_at DESC);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_analytics_client_event
+    ON analytics_events(user_id, client_event_id) WHERE client_event_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_analytics_anonymous
+    ON analytics_events(anonymous_id) WHERE user_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_audit_user ON audit_log(user_id);
+CREATE INDEX IF NOT EXISTS idx_audit_action ON audit_log(action);
+CREATE INDEX IF NOT EXISTS idx_audit_created ON audit_log(created_at DESC);
@@ -0,0 +1,125 @@
+-- =============================================================================
+-- FAMLI - Migração 0034: Partições mensais de analytics_events e audit_log
+-- =============================================================================
+
+-- As duas tabelas crescem sem parar entre as limpezas e o DELETE por data
+-- ficava cada vez mais lento. Agora são particionadas por mês (created_at):
+-- a limpeza (CleanupOldLogs) apaga meses inteiros com DROP TABLE e só faz
+-- DELETE no mês mais antigo que restou. As partições dos próximos meses são
+-- criadas ao iniciar o servidor e na limpeza diária (ver partitions.go).
+--
+-- Nomes das partições: analytics_events_y2025m01, audit_log_y2025m01...
+
+-- A chave primária e os índices únicos de uma tabela particionada precisam
+-- incluir created_at, que muda a cada reenvio sem occurred_at. Por isso a
+-- deduplicação por client_event_id sai para analytics_client_events (não
+-- particionada): o ID é reservado ali antes de gravar o evento.
+
+-- ---------------------------------------------------------------------------
+-- Tabelas antigas saem do caminho (nomes de PK e sequência são globais)
+-- ---------------------------------------------------------------------------
+ALTER TABLE analytics_events RENAME TO analytics_events_unpartitioned;
+ALTER TABLE analytics_events_unpartitioned RENAME CONSTRAINT analytics_events_pkey TO analytics_events_unpartitioned_pkey;
+
+ALTER TABLE audit_log RENAME TO audit_log_unpartitioned;
+ALTER TABLE audit_log_unpartitioned RENAME CONSTRAINT audit_log_pkey TO audit_log_unpartitioned_pkey;
+ALTER SEQUENCE audit_log_id_seq RENAME TO audit_log_unpartitioned_id_seq;
+
+-- ---------------------------------------------------------------------------
+-- Tabelas particionadas
+-- ---------------------------------------------------------------------------
+CREATE TABLE analytics_events (
+    id VARCHAR(50) NOT NULL,
+    user_id VARCHAR(50),
+    anonymous_id VARCHAR(50),
+    event_type VARCHAR(50) NOT NULL,
+    page VARCHAR(100),
+    details JSONB,
+    client_event_id VARCHAR(64),
+    country VARCHAR(2),
+    device_class VARCHAR(10),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id, created_at)
+) PARTITION BY RANGE (created_at);
+
+CREATE TABLE audit_log (
+    id BIGSERIAL,
+    user_id VARCHAR(50),
+    action VARCHAR(100) NOT NULL,
+    resource_type VARCHAR(50),
+    resource_id VARCHAR(50),
+    ip_address VARCHAR(45),
+    details JSONB,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id, created_at)
+) PARTITION BY RANGE (created_at);
+
+-- Um mês por partição, do evento mais antigo até dois meses à frente (ou
+-- até o mais recente, se algum registro estiver datado depois disso)
+DO $$
+DECLARE
+    parent TEXT;
+    month DATE;
+    last_month DATE;
+BEGIN
+    FOREACH parent IN ARRAY ARRAY['analytics_events', 'audit_log'] LOOP
+        EXECUTE format('SELECT date_trunc(''month'', COALESCE(MIN(created_at), LOCALTIMESTAMP)), '
+                || 'GREATEST(date_trunc(''month'', LOCALTIMESTAMP) + INTERVAL ''2 months'', date_trunc(''month'', MAX(created_at))) FROM %I',
+                parent || '_unpartitioned')
+            INTO month, last_month;
+        WHILE month <= last_month LOOP
+            EXECUTE format('CREATE TABLE IF NOT EXISTS %I PARTITION OF %I FOR VALUES FROM (%L) TO (%L)',
+                parent || '_' || to_char(month, '"y"YYYY"m"MM'), parent,
+                month, (month + INTERVAL '1 month')::date);
+            month := month + INTERVAL '1 month';
+        END LOOP;
+    END LOOP;
+END $$;
+
+-- ---------------------------------------------------------------------------
+-- Deduplicação do lote (um client_event_id por usuário)
+-- ---------------------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS analytics_client_events (
+    user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    client_event_id VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, client_event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_analytics_client_events_created ON analytics_client_events(created_at);
+
+INSERT INTO analytics_client_events (user_id, client_event_id, created_at)
+SELECT e.user_id, e.client_event_id, MIN(COALESCE(e.created_at, LOCALTIMESTAMP))
+FROM analytics_events_unpartitioned e
+JOIN users u ON u.id = e.user_id
+WHERE e.client_event_id IS NOT NULL
+GROUP BY e.user_id, e.client_event_id;
+
+-- ---------------------------------------------------------------------------
+-- Cópia dos dados (todos os registros: há partição para cada mês)
+-- ---------------------------------------------------------------------------
+INSERT INTO analytics_events (id, user_id, anonymous_id, event_type, page, details, client_event_id, country, device_class, created_at)
+SELECT id, user_id, anonymous_id, event_type, page, details, client_event_id, country, device_class, COALESCE(created_at, LOCALTIMESTAMP)
+FROM analytics_events_unpartitioned;
+
+INSERT INTO audit_log (id, user_id, action, resource_type, resource_id, ip_address, details, created_at)
+SELECT id, user_id, action, resource_type, resource_id, ip_address, details, COALESCE(created_at, LOCALTIMESTAMP)
+FROM audit_log_unpartitioned;
+
+SELECT setval(pg_get_serial_sequence('audit_log', 'id'), COALESCE(MAX(id), 0) + 1, false) FROM audit_log;
+
+DROP TABLE analytics_events_unpartitioned;
+DROP TABLE audit_log_unpartitioned;
+
+-- ---------------------------------------------------------------------------
+-- Índices (criados em cada partição)
+-- ---------------------------------------------------------------------------
+CREATE INDEX IF NOT EXISTS idx_analytics_user ON analytics_events(user_id);
+CREATE INDEX IF NOT EXISTS idx_analytics_type ON analytics_events(event_type);
+CREATE INDEX IF NOT EXISTS idx_analytics_created ON analytics_events(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_analytics_anonymous
+    ON analytics_events(anonymous_id) WHERE user_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_audit_user ON audit_log(user_id);
+CREATE INDEX IF NOT EXISTS idx_audit_action ON audit_log(action);
+CREATE INDEX IF NOT EXISTS idx_audit_created ON audit_log(created_at DESC);
@@ -0,0 +1,5 @@
+-- =============================================================================
+-- FAMLI - Migração 0035 (rollback): Desativar o acesso de um guardião
+-- =============================================================================
+
+ALTER TABLE guardians DROP COLUMN IF EXISTS access_disabled_at;
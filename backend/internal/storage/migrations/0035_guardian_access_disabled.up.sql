@@ -0,0 +1,7 @@
+-- =============================================================================
+-- FAMLI - Migração 0035: Desativar o acesso de um guardião
+-- =============================================================================
+
+-- O dono pode desativar o link e o portal de um guardião sem apagar o
+-- cadastro (ex: link vazou e a pessoa ainda vai receber um novo).
+ALTER TABLE guardians ADD COLUMN IF NOT EXISTS access_disabled_at TIMESTAMP;
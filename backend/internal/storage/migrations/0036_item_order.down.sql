@@ -0,0 +1,5 @@
+-- =============================================================================
+-- FAMLI - Migração 0036 (rollback): Itens fixados e ordem manual
+-- =============================================================================
+
+DROP TABLE IF EXISTS item_order;
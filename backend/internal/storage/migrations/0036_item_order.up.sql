@@ -0,0 +1,15 @@
+-- =============================================================================
+-- FAMLI - Migração 0036: Itens fixados e ordem manual
+-- =============================================================================
+
+-- Cada pessoa fixa e ordena os itens que vê (inclusive os da família) sem
+-- mudar a ordem dos outros membros. Item sem linha = não fixado, posição 0.
+CREATE TABLE IF NOT EXISTS item_order (
+    user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    item_id VARCHAR(50) NOT NULL REFERENCES box_items(id) ON DELETE CASCADE,
+    pinned BOOLEAN NOT NULL DEFAULT FALSE,
+    position INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, item_id)
+);
+CREATE INDEX IF NOT EXISTS idx_item_order_item ON item_order(item_id);
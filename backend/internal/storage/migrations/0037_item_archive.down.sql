@@ -0,0 +1,5 @@
+-- =============================================================================
+-- FAMLI - Migração 0037 (rollback): Itens arquivados
+-- =============================================================================
+
+ALTER TABLE box_items DROP COLUMN IF EXISTS archived_at;
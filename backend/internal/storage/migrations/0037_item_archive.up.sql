@@ -0,0 +1,8 @@
+-- =============================================================================
+-- FAMLI - Migração 0037: Itens arquivados
+-- =============================================================================
+
+-- Informações que ficaram velhas mas que a pessoa não quer apagar. Itens
+-- arquivados saem das listagens padrão, dos links compartilhados e do
+-- calendário, mas continuam contando na cota.
+ALTER TABLE box_items ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP;
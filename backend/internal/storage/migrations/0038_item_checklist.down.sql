@@ -0,0 +1,5 @@
+-- =============================================================================
+-- FAMLI - Migração 0038 (rollback): Lista marcável dentro dos itens
+-- =============================================================================
+
+ALTER TABLE box_items DROP COLUMN IF EXISTS checklist;
@@ -0,0 +1,8 @@
+-- =============================================================================
+-- FAMLI - Migração 0038: Lista marcável dentro dos itens
+-- =============================================================================
+
+-- [{"id": "chk_...", "text": "enc:...", "done": false, "done_at": null}, ...]
+-- na ordem definida pelo usuário. O texto de cada linha é criptografado como
+-- o título e o conteúdo do item.
+ALTER TABLE box_items ADD COLUMN IF NOT EXISTS checklist JSONB;
@@ -0,0 +1,5 @@
+-- =============================================================================
+-- FAMLI - Migração 0039 (rollback): Cartão de emergência
+-- =============================================================================
+
+DROP TABLE IF EXISTS emergency_cards;
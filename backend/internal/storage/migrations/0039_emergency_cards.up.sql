@@ -0,0 +1,15 @@
+-- =============================================================================
+-- FAMLI - Migração 0039: Cartão de emergência
+-- =============================================================================
+
+-- Um cartão por usuário, lido por socorristas pelo link público (apenas o
+-- hash do token). data é o JSON criptografado com nome, tipo sanguíneo,
+-- contatos e medicamentos.
+CREATE TABLE IF NOT EXISTS emergency_cards (
+    user_id VARCHAR(50) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    data TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_accessed_at TIMESTAMP
+);
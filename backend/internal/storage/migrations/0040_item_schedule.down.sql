@@ -0,0 +1,6 @@
+-- =============================================================================
+-- FAMLI - Migração 0040 (rollback): Agenda estruturada das rotinas
+-- =============================================================================
+
+DROP INDEX IF EXISTS idx_box_items_schedule;
+ALTER TABLE box_items DROP COLUMN IF EXISTS schedule;
@@ -0,0 +1,10 @@
+-- =============================================================================
+-- FAMLI - Migração 0040: Agenda estruturada das rotinas
+-- =============================================================================
+
+-- {"medication": "enc:...", "dose": "enc:...", "times": ["08:00", "20:00"],
+--  "weekdays": [1, 3, 5], "responsible_guardian_id": "grd_..."}
+-- Apenas em itens "routine"; remédio e dose são criptografados.
+ALTER TABLE box_items ADD COLUMN IF NOT EXISTS schedule JSONB;
+CREATE INDEX IF NOT EXISTS idx_box_items_schedule ON box_items(user_id)
+    WHERE schedule IS NOT NULL;
@@ -0,0 +1,7 @@
+-- =============================================================================
+-- FAMLI - Migração 0041 (rollback): Documentos com validade e lembretes de vencimento
+-- =============================================================================
+
+DROP TABLE IF EXISTS expiry_reminders;
+DROP INDEX IF EXISTS idx_box_items_document_expiry;
+ALTER TABLE box_items DROP COLUMN IF EXISTS document;
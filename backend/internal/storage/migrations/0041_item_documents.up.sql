@@ -0,0 +1,20 @@
+-- =============================================================================
+-- FAMLI - Migração 0041: Documentos com validade e lembretes de vencimento
+-- =============================================================================
+
+-- {"kind": "passport", "number": "enc:...", "expires_on": "2030-05-01"}
+-- Apenas em itens "document"; o número (já mascarado) é criptografado e a
+-- validade fica em AAAA-MM-DD, que se compara como texto.
+ALTER TABLE box_items ADD COLUMN IF NOT EXISTS document JSONB;
+CREATE INDEX IF NOT EXISTS idx_box_items_document_expiry ON box_items((document->>'expires_on'))
+    WHERE document IS NOT NULL;
+
+-- Lembretes enviados: um por validade e antecedência (trocar a data gera
+-- lembretes novos; a chave evita envios repetidos entre réplicas)
+CREATE TABLE IF NOT EXISTS expiry_reminders (
+    item_id VARCHAR(50) NOT NULL REFERENCES box_items(id) ON DELETE CASCADE,
+    expires_on DATE NOT NULL,
+    days_before INTEGER NOT NULL,
+    sent_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (item_id, expires_on, days_before)
+);
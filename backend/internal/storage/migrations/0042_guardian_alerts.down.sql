@@ -0,0 +1,5 @@
+-- =============================================================================
+-- FAMLI - Migração 0042 (rollback): Entregas dos avisos aos guardiões
+-- =============================================================================
+
+DROP TABLE IF EXISTS guardian_alerts;
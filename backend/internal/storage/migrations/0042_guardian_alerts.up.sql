@@ -0,0 +1,24 @@
+-- =============================================================================
+-- FAMLI - Migração 0042: Entregas dos avisos aos guardiões
+-- =============================================================================
+
+-- Cada aviso (ex: emergência acionada) gera uma linha por guardião, com as
+-- tentativas por canal e a próxima retentativa (backoff exponencial).
+
+CREATE TABLE IF NOT EXISTS guardian_alerts (
+    id VARCHAR(50) PRIMARY KEY,
+    user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    guardian_id VARCHAR(50) NOT NULL REFERENCES guardians(id) ON DELETE CASCADE,
+    event VARCHAR(50) NOT NULL,
+    message TEXT NOT NULL, -- Texto enviado (criptografado)
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    channel VARCHAR(20), -- Canal que entregou
+    error TEXT,
+    attempt_log JSONB NOT NULL DEFAULT '[]',
+    next_attempt_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_guardian_alerts_user ON guardian_alerts(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_guardian_alerts_due ON guardian_alerts(next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1,5 @@
+-- =============================================================================
+-- FAMLI - Migração 0043 (rollback): Acesso do suporte com consentimento do usuário
+-- =============================================================================
+
+DROP TABLE IF EXISTS support_access;
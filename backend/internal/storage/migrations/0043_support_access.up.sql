@@ -0,0 +1,11 @@
+-- =============================================================================
+-- FAMLI - Migração 0043: Acesso do suporte com consentimento do usuário
+-- =============================================================================
+
+-- Uma autorização por usuário (24h); revogar apaga a linha e encerra as
+-- sessões somente leitura do suporte na próxima requisição.
+CREATE TABLE IF NOT EXISTS support_access (
+    user_id VARCHAR(50) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    granted_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,5 @@
+-- =============================================================================
+-- FAMLI - Migração 0044 (rollback): Execuções da retenção de dados
+-- =============================================================================
+
+DROP TABLE IF EXISTS retention_runs;
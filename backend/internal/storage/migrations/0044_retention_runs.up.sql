@@ -0,0 +1,18 @@
+-- =============================================================================
+-- FAMLI - Migração 0044: Execuções da retenção de dados
+-- =============================================================================
+
+-- Cada execução do job de retenção registra, por classe de dados, o prazo
+-- aplicado e quantos registros foram removidos (GET /api/admin/retention).
+-- Os prazos ficam em system_config (chaves "retention:<classe>").
+CREATE TABLE IF NOT EXISTS retention_runs (
+    id VARCHAR(50) PRIMARY KEY,
+    class VARCHAR(50) NOT NULL,
+    days INTEGER NOT NULL,
+    purged BIGINT NOT NULL DEFAULT 0,
+    error TEXT,
+    started_at TIMESTAMP NOT NULL,
+    duration_ms BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_retention_runs_started ON retention_runs(started_at DESC);
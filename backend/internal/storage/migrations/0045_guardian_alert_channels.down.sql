@@ -0,0 +1,5 @@
+-- =============================================================================
+-- FAMLI - Migração 0045 (rollback): Canais escolhidos nos recados aos guardiões
+-- =============================================================================
+
+ALTER TABLE guardian_alerts DROP COLUMN IF EXISTS channels;
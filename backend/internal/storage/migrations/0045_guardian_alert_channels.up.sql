@@ -0,0 +1,9 @@
+-- =============================================================================
+-- FAMLI - Migração 0045: Canais escolhidos nos recados aos guardiões
+-- =============================================================================
+
+-- Recados do dono (POST /api/guardians/notify) podem restringir e ordenar os
+-- canais; vazio = preferência do guardião (notify_channel). As retentativas
+-- usam os mesmos canais.
+
+ALTER TABLE guardian_alerts ADD COLUMN IF NOT EXISTS channels JSONB NOT NULL DEFAULT '[]';
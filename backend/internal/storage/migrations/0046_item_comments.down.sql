@@ -0,0 +1,5 @@
+-- =============================================================================
+-- FAMLI - Migração 0046 (rollback): Comentários dos guardiões nos itens compartilhados
+-- =============================================================================
+
+DROP TABLE IF EXISTS item_comments;
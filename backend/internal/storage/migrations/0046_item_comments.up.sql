@@ -0,0 +1,18 @@
+-- =============================================================================
+-- FAMLI - Migração 0046: Comentários dos guardiões nos itens compartilhados
+-- =============================================================================
+
+-- Guardiões podem deixar perguntas curtas ("qual gaveta?") nos itens que veem
+-- pelo link de acesso; o dono lê e apaga na visualização do item. Os
+-- comentários somem com o item, o dono ou o guardião.
+
+CREATE TABLE IF NOT EXISTS item_comments (
+    id VARCHAR(50) PRIMARY KEY,
+    item_id VARCHAR(50) NOT NULL REFERENCES box_items(id) ON DELETE CASCADE,
+    user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    guardian_id VARCHAR(50) NOT NULL REFERENCES guardians(id) ON DELETE CASCADE,
+    body TEXT NOT NULL, -- Texto do comentário (criptografado)
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_item_comments_item ON item_comments(item_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_item_comments_guardian ON item_comments(guardian_id, created_at DESC);
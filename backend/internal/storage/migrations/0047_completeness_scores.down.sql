@@ -0,0 +1,5 @@
+-- =============================================================================
+-- FAMLI - Migração 0047 (rollback): Notas de preparo da caixa
+-- =============================================================================
+
+DROP TABLE IF EXISTS completeness_scores;
@@ -0,0 +1,16 @@
+-- =============================================================================
+-- FAMLI - Migração 0047: Notas de preparo da caixa
+-- =============================================================================
+
+-- GET /api/box/completeness guarda a nota do usuário (0 a 100) uma vez por
+-- dia (a última consulta do dia vale). O painel admin acompanha a média
+-- diária. Segue a retenção da classe analytics.
+
+CREATE TABLE IF NOT EXISTS completeness_scores (
+    user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    score SMALLINT NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, day)
+);
+CREATE INDEX IF NOT EXISTS idx_completeness_scores_day ON completeness_scores(day);
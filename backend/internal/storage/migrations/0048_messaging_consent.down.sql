@@ -0,0 +1,6 @@
+-- =============================================================================
+-- FAMLI - Migração 0048 (rollback): Consentimento para mensagens pelo WhatsApp
+-- =============================================================================
+
+ALTER TABLE settings DROP COLUMN IF EXISTS messaging_consent_at;
+ALTER TABLE settings DROP COLUMN IF EXISTS messaging_opt_out;
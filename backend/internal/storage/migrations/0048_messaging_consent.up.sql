@@ -0,0 +1,7 @@
+-- =============================================================================
+-- FAMLI - Migração 0048: Consentimento para mensagens pelo WhatsApp
+-- =============================================================================
+
+-- Usuário respondeu "STOP": avisos e resumos não saem até ele responder "START"
+ALTER TABLE settings ADD COLUMN IF NOT EXISTS messaging_opt_out BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE settings ADD COLUMN IF NOT EXISTS messaging_consent_at TIMESTAMP;
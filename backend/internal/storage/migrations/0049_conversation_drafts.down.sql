@@ -0,0 +1,5 @@
+-- =============================================================================
+-- FAMLI - Migração 0049 (rollback): Rascunhos das conversas pelo WhatsApp
+-- =============================================================================
+
+DROP TABLE IF EXISTS conversation_drafts;
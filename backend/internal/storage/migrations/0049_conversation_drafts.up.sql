@@ -0,0 +1,17 @@
+-- =============================================================================
+-- FAMLI - Migração 0049: Rascunhos das conversas pelo WhatsApp
+-- =============================================================================
+
+-- Item começado pelo WhatsApp cuja pergunta (categoria ou confirmação) ficou
+-- sem resposta. Um por usuário; "continuar" retoma de onde parou.
+CREATE TABLE IF NOT EXISTS conversation_drafts (
+    user_id VARCHAR(50) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    state VARCHAR(30) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    title TEXT NOT NULL,   -- Criptografado
+    content TEXT NOT NULL, -- Criptografado
+    category VARCHAR(50) NOT NULL DEFAULT '',
+    media_url TEXT NOT NULL DEFAULT '',
+    media_type VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
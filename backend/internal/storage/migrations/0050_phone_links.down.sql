@@ -0,0 +1,5 @@
+-- =============================================================================
+-- FAMLI - Migração 0050 (rollback): Vários números de WhatsApp por conta
+-- =============================================================================
+
+DROP TABLE IF EXISTS phone_links;
@@ -0,0 +1,17 @@
+-- =============================================================================
+-- FAMLI - Migração 0050: Vários números de WhatsApp por conta
+-- =============================================================================
+
+-- Números vinculados com o código enviado pelo próprio WhatsApp ("vincular").
+-- Cada número pertence a uma única conta; avisos e resumos vão ao principal.
+CREATE TABLE IF NOT EXISTS phone_links (
+    id VARCHAR(50) PRIMARY KEY,
+    user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    phone VARCHAR(32) NOT NULL UNIQUE,
+    label VARCHAR(100) NOT NULL DEFAULT '',
+    is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+    verified_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_phone_links_user ON phone_links(user_id, created_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_phone_links_primary ON phone_links(user_id) WHERE is_primary;
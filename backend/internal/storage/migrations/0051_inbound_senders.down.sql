@@ -0,0 +1,5 @@
+-- =============================================================================
+-- FAMLI - Migração 0051 (rollback): Remetentes do gateway de email
+-- =============================================================================
+
+DROP TABLE IF EXISTS inbound_senders;
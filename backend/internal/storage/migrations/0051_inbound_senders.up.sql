@@ -0,0 +1,15 @@
+-- =============================================================================
+-- FAMLI - Migração 0051: Remetentes do gateway de email
+-- =============================================================================
+
+-- Emails autorizados a guardar itens escrevendo para o endereço do gateway
+-- (INBOUND_EMAIL_ADDRESS). Vinculados com o código respondido por email
+-- ("vincular"); cada endereço pertence a uma única conta.
+CREATE TABLE IF NOT EXISTS inbound_senders (
+    id VARCHAR(50) PRIMARY KEY,
+    user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL UNIQUE,
+    verified_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_inbound_senders_user ON inbound_senders(user_id, created_at);
@@ -0,0 +1,6 @@
+-- =============================================================================
+-- FAMLI - Migração 0052 (rollback): Captura pelo navegador
+-- =============================================================================
+
+DROP TABLE IF EXISTS item_sources;
+DROP TABLE IF EXISTS capture_tokens;
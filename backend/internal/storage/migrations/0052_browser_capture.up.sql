@@ -0,0 +1,20 @@
+-- =============================================================================
+-- FAMLI - Migração 0052: Captura pelo navegador
+-- =============================================================================
+
+-- Token pessoal da captura (bookmarklet/extensão); um por usuário, apenas o hash
+CREATE TABLE IF NOT EXISTS capture_tokens (
+    user_id VARCHAR(50) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP
+);
+
+-- Página de origem dos itens capturados (URL e título criptografados)
+CREATE TABLE IF NOT EXISTS item_sources (
+    item_id VARCHAR(50) PRIMARY KEY REFERENCES box_items(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    page_title TEXT,
+    via VARCHAR(20) NOT NULL,
+    captured_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,6 @@
+-- =============================================================================
+-- FAMLI - Migração 0053 (rollback): Página de status
+-- =============================================================================
+
+DROP TABLE IF EXISTS status_uptime;
+DROP TABLE IF EXISTS status_incidents;
@@ -0,0 +1,28 @@
+-- =============================================================================
+-- FAMLI - Migração 0053: Página de status
+-- =============================================================================
+
+-- Notas de incidente publicadas pelos admins em GET /api/status
+CREATE TABLE IF NOT EXISTS status_incidents (
+    id VARCHAR(50) PRIMARY KEY,
+    title VARCHAR(200) NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    severity VARCHAR(20) NOT NULL,
+    state VARCHAR(20) NOT NULL,
+    components TEXT[] NOT NULL DEFAULT '{}',
+    created_by VARCHAR(50) REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_status_incidents_open ON status_incidents(resolved_at, created_at DESC);
+
+-- Disponibilidade diária das dependências (verificações do monitor de status)
+CREATE TABLE IF NOT EXISTS status_uptime (
+    component VARCHAR(30) NOT NULL,
+    day DATE NOT NULL,
+    checks INTEGER NOT NULL DEFAULT 0,
+    failures INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (component, day)
+);
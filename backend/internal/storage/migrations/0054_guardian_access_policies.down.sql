@@ -0,0 +1,5 @@
+-- =============================================================================
+-- FAMLI - Migração 0054 (rollback): Regras de acesso dos guardiões
+-- =============================================================================
+
+DROP TABLE IF EXISTS guardian_access_policies;
@@ -0,0 +1,15 @@
+-- =============================================================================
+-- FAMLI - Migração 0054: Regras de acesso dos guardiões
+-- =============================================================================
+
+-- Prazo, janelas de horário e limite de acessos de cada guardião
+CREATE TABLE IF NOT EXISTS guardian_access_policies (
+    guardian_id VARCHAR(50) PRIMARY KEY REFERENCES guardians(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP,
+    windows JSONB NOT NULL DEFAULT '[]',
+    timezone VARCHAR(64) NOT NULL DEFAULT 'America/Sao_Paulo',
+    max_accesses INTEGER NOT NULL DEFAULT 0,
+    access_count INTEGER NOT NULL DEFAULT 0,
+    blocked_notified_at TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
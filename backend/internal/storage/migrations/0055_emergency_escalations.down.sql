@@ -0,0 +1,6 @@
+-- =============================================================================
+-- FAMLI - Migração 0055 (rollback): Escalonamento de emergência
+-- =============================================================================
+
+DROP TABLE IF EXISTS emergency_escalations;
+DROP TABLE IF EXISTS emergency_escalation_chains;
@@ -0,0 +1,31 @@
+-- =============================================================================
+-- FAMLI - Migração 0055: Escalonamento de emergência
+-- =============================================================================
+
+-- Ordem em que as pessoas de confiança são chamadas numa emergência
+CREATE TABLE IF NOT EXISTS emergency_escalation_chains (
+    user_id VARCHAR(50) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    guardian_ids TEXT[] NOT NULL DEFAULT '{}',
+    ack_timeout_minutes INTEGER NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Escalonamentos em andamento e encerrados (steps guarda a cópia da lista,
+-- com o hash do token de confirmação de cada pessoa chamada)
+CREATE TABLE IF NOT EXISTS emergency_escalations (
+    id VARCHAR(50) PRIMARY KEY,
+    user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL,
+    steps JSONB NOT NULL DEFAULT '[]',
+    current_step INTEGER NOT NULL DEFAULT -1,
+    ack_timeout_minutes INTEGER NOT NULL,
+    next_step_at TIMESTAMP,
+    acknowledged_by VARCHAR(50),
+    acknowledged_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_emergency_escalations_user ON emergency_escalations(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_emergency_escalations_due ON emergency_escalations(next_step_at) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_emergency_escalations_steps ON emergency_escalations USING GIN (steps jsonb_path_ops);
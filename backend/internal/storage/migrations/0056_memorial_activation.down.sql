@@ -0,0 +1,6 @@
+-- =============================================================================
+-- FAMLI - Migração 0056 (rollback): Abertura do memorial com confirmação de várias pessoas
+-- =============================================================================
+
+DROP TABLE IF EXISTS memorial_activations;
+DROP TABLE IF EXISTS memorial_configs;
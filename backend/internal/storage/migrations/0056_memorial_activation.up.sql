@@ -0,0 +1,33 @@
+-- =============================================================================
+-- FAMLI - Migração 0056: Abertura do memorial com confirmação de várias pessoas
+-- =============================================================================
+
+-- Quem pode liberar os links memorial (N de M pessoas de confiança)
+CREATE TABLE IF NOT EXISTS memorial_configs (
+    user_id VARCHAR(50) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    guardian_ids TEXT[] NOT NULL DEFAULT '{}',
+    required_confirmations INTEGER NOT NULL,
+    delay_hours INTEGER NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Pedidos de abertura (confirmations guarda quem confirmou e quando)
+CREATE TABLE IF NOT EXISTS memorial_activations (
+    id VARCHAR(50) PRIMARY KEY,
+    user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL,
+    guardian_ids TEXT[] NOT NULL DEFAULT '{}',
+    required_confirmations INTEGER NOT NULL,
+    delay_hours INTEGER NOT NULL,
+    confirmations JSONB NOT NULL DEFAULT '[]',
+    expires_at TIMESTAMP NOT NULL,
+    unlocks_at TIMESTAMP,
+    unlocked_at TIMESTAMP,
+    aborted_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_memorial_activations_user ON memorial_activations(user_id, created_at DESC);
+-- Um só pedido em andamento por usuário
+CREATE UNIQUE INDEX IF NOT EXISTS idx_memorial_activations_open ON memorial_activations(user_id) WHERE status IN ('pending', 'waiting');
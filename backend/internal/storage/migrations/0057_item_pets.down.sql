@@ -0,0 +1,5 @@
+-- =============================================================================
+-- FAMLI - Migração 0057 (rollback): Perfil dos pets
+-- =============================================================================
+
+ALTER TABLE box_items DROP COLUMN IF EXISTS pet;
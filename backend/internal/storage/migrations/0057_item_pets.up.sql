@@ -0,0 +1,8 @@
+-- =============================================================================
+-- FAMLI - Migração 0057: Perfil dos pets
+-- =============================================================================
+
+-- {"name": "enc:...", "species": "dog", "vet_name": "enc:...", "vet_phone": "enc:...",
+--  "feeding_times": ["08:00", "18:00"], "food": "enc:...", "medication": "enc:..."}
+-- Apenas em itens "pet"; os textos são criptografados.
+ALTER TABLE box_items ADD COLUMN IF NOT EXISTS pet JSONB;
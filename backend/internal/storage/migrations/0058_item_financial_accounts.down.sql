@@ -0,0 +1,5 @@
+-- =============================================================================
+-- FAMLI - Migração 0058 (rollback): Inventário financeiro
+-- =============================================================================
+
+ALTER TABLE box_items DROP COLUMN IF EXISTS financial_account;
@@ -0,0 +1,9 @@
+-- =============================================================================
+-- FAMLI - Migração 0058: Inventário financeiro
+-- =============================================================================
+
+-- {"institution": "enc:...", "account_type": "checking", "number": "enc:...",
+--  "statements_location": "enc:...", "credentials_location": "enc:..."}
+-- Apenas em itens "financial_account"; o número já chega mascarado
+-- ("****1234") e os textos são criptografados.
+ALTER TABLE box_items ADD COLUMN IF NOT EXISTS financial_account JSONB;
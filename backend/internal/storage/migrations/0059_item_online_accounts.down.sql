@@ -0,0 +1,5 @@
+-- =============================================================================
+-- FAMLI - Migração 0059 (rollback): Legado digital (contas online)
+-- =============================================================================
+
+ALTER TABLE box_items DROP COLUMN IF EXISTS online_account;
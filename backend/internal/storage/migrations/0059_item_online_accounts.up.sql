@@ -0,0 +1,8 @@
+-- =============================================================================
+-- FAMLI - Migração 0059: Legado digital (contas online)
+-- =============================================================================
+
+-- {"service": "enc:...", "username_hint": "enc:...", "recovery_email_hint": "enc:...",
+--  "action": "memorialize"}
+-- Apenas em itens "online_account"; os textos são criptografados.
+ALTER TABLE box_items ADD COLUMN IF NOT EXISTS online_account JSONB;
@@ -0,0 +1,5 @@
+-- =============================================================================
+-- FAMLI - Migração 0060 (rollback): Chaves pessoais de API
+-- =============================================================================
+
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,17 @@
+-- =============================================================================
+-- FAMLI - Migração 0060: Chaves pessoais de API
+-- =============================================================================
+
+-- Chaves somente leitura para scripts do próprio usuário (ver auth/apikeys.go).
+-- Apenas o hash (SHA-256) da chave é guardado; prefix ajuda a reconhecê-la.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id VARCHAR(50) PRIMARY KEY,
+    user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(20) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_api_keys_user ON api_keys(user_id, created_at);
@@ -1,6 +1,12 @@
 package storage
 
-import "time"
+import (
+	"strings"
+	"time"
+	_ "time/tzdata" // Fusos das janelas de acesso dos guardiões, sem depender do sistema
+
+	"famli/internal/security"
+)
 
 // =============================================================================
 // PAGINAÇÃO
@@ -9,7 +15,7 @@ import "time"
 // PaginationParams define os parâmetros de paginação (cursor-based)
 // Cursor-based é mais eficiente que OFFSET para grandes datasets
 type PaginationParams struct {
-	Cursor string `json:"cursor,omitempty"` // ID do último item (para next page)
+	Cursor string `json:"cursor,omitempty"` // next_cursor da página anterior
 	Limit  int    `json:"limit"`            // Número de itens por página (max 50)
 }
 
@@ -65,6 +71,177 @@ type User struct {
 	AvatarURL  string       `json:"avatar_url,omitempty"`  // URL do avatar (Google/Apple)
 	Locale     string       `json:"locale,omitempty"`      // Idioma preferido (ex: "pt-BR", "en")
 	CreatedAt  time.Time    `json:"created_at"`
+
+	// Bloqueio de conta por tentativas de login falhas
+	FailedLoginAttempts int        `json:"failed_login_attempts,omitempty"`
+	LockedUntil         *time.Time `json:"locked_until,omitempty"`
+
+	// Desativação pelo admin (nil = conta ativa)
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+
+	// Papel administrativo (vazio = usuário comum)
+	Role Role `json:"role,omitempty"`
+
+	// Plano de assinatura (atualizado pelos webhooks do Stripe)
+	Plan Plan `json:"plan,omitempty"`
+
+	// Versões dos Termos de Uso e da Política de Privacidade aceitas por
+	// último (vazio = nunca aceitou com versão registrada)
+	TermsVersion    string     `json:"terms_version,omitempty"`
+	PrivacyVersion  string     `json:"privacy_version,omitempty"`
+	TermsAcceptedAt *time.Time `json:"terms_accepted_at,omitempty"`
+}
+
+// Plan é o plano de assinatura do usuário
+type Plan string
+
+const (
+	PlanFree    Plan = "free"    // Plano gratuito (padrão)
+	PlanPremium Plan = "premium" // Assinatura paga ativa
+)
+
+// IsPremium indica se o usuário tem assinatura paga ativa
+func (u *User) IsPremium() bool {
+	return u.Plan == PlanPremium
+}
+
+// Role define o papel administrativo de um usuário
+type Role string
+
+const (
+	RoleNone       Role = ""           // Usuário comum (sem acesso ao admin)
+	RoleSupport    Role = "support"    // Atendimento: contas e feedbacks
+	RoleAnalyst    Role = "analyst"    // Métricas e analytics (somente leitura)
+	RoleSuperadmin Role = "superadmin" // Acesso total
+)
+
+// AdminRoles lista todos os papéis com acesso ao painel admin
+var AdminRoles = []Role{RoleSupport, RoleAnalyst, RoleSuperadmin}
+
+// ParseRole valida um papel administrativo recebido como texto
+func ParseRole(value string) (Role, bool) {
+	for _, role := range AdminRoles {
+		if string(role) == value {
+			return role, true
+		}
+	}
+	return RoleNone, false
+}
+
+// IsAdmin indica se o papel dá acesso ao painel admin
+func (r Role) IsAdmin() bool {
+	_, ok := ParseRole(string(r))
+	return ok
+}
+
+// IsLocked indica se a conta está temporariamente bloqueada
+func (u *User) IsLocked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
+}
+
+// IsDisabled indica se a conta foi desativada por um administrador
+func (u *User) IsDisabled() bool {
+	return u.DisabledAt != nil
+}
+
+// Filtros de status para busca de usuários (admin)
+const (
+	UserStatusActive   = "active"
+	UserStatusDisabled = "disabled"
+	UserStatusLocked   = "locked"
+)
+
+// UserSearchParams define os filtros da busca de usuários no painel admin
+type UserSearchParams struct {
+	Query  string // Trecho do email ou nome (case-insensitive)
+	Status string // "", UserStatusActive, UserStatusDisabled ou UserStatusLocked
+	Role   Role   // Filtra por papel administrativo (vazio = todos)
+	PaginationParams
+}
+
+// LoginHistoryRetentionDays é por quanto tempo logins são lembrados
+// Mais longo que os logs comuns para não alertar sobre dispositivos já conhecidos
+const LoginHistoryRetentionDays = 180
+
+// LoginRecord registra um login bem-sucedido (detecção de novo dispositivo/país)
+type LoginRecord struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id"`
+	IPAddress  string    `json:"ip_address,omitempty"`
+	Country    string    `json:"country,omitempty"`     // Código ISO do país (ex: "BR"), vazio se desconhecido
+	DeviceHash string    `json:"device_hash,omitempty"` // Hash do User-Agent normalizado
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// DeviceSession é uma sessão de login em um dispositivo
+// O ID vai no JWT (claim "sid"); remover a sessão encerra o login no dispositivo.
+type DeviceSession struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"-"`
+	Name       string    `json:"name"`                 // "Chrome no macOS" ou nome dado pelo usuário
+	UserAgent  string    `json:"user_agent,omitempty"` // User-Agent do login
+	IPAddress  string    `json:"ip_address,omitempty"` // Último IP visto
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+// SupportAccess é a autorização do usuário para o suporte ver a conta
+// Enquanto vale, um administrador de suporte pode abrir uma sessão somente
+// leitura como o usuário; revogar (apagar) encerra essas sessões na hora.
+type SupportAccess struct {
+	UserID    string    `json:"-"`
+	GrantedAt time.Time `json:"granted_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ActiveAt indica se a autorização ainda vale no instante informado
+func (a *SupportAccess) ActiveAt(now time.Time) bool {
+	return a != nil && now.Before(a.ExpiresAt)
+}
+
+// APIKeyScope é uma permissão (somente leitura) de uma chave de API
+type APIKeyScope string
+
+const (
+	APIKeyItemsRead     APIKeyScope = "items:read"     // Itens da caixa
+	APIKeyGuardiansRead APIKeyScope = "guardians:read" // Pessoas de confiança
+)
+
+// APIKey é uma chave pessoal de API para scripts do próprio usuário
+// Só o hash da chave é guardado: a chave aparece uma única vez, na criação.
+type APIKey struct {
+	ID         string        `json:"id"`
+	UserID     string        `json:"-"`
+	Name       string        `json:"name"`
+	Prefix     string        `json:"prefix"` // Início da chave, para reconhecê-la na listagem
+	KeyHash    string        `json:"-"`      // SHA-256 da chave (security.HashToken)
+	Scopes     []APIKeyScope `json:"scopes"`
+	CreatedAt  time.Time     `json:"created_at"`
+	LastUsedAt *time.Time    `json:"last_used_at"`
+}
+
+// Subscription é a assinatura do usuário no Stripe
+// Mantida pelos webhooks; o plano do usuário (User.Plan) acompanha Plan.
+type Subscription struct {
+	UserID            string     `json:"-"`
+	CustomerID        string     `json:"-"`                            // Cliente no Stripe (cus_...)
+	SubscriptionID    string     `json:"-"`                            // Assinatura no Stripe (sub_...), vazio antes do checkout
+	Plan              Plan       `json:"plan"`                         // Plano concedido pela assinatura
+	Status            string     `json:"status,omitempty"`             // Status no Stripe (active, past_due, canceled...)
+	CurrentPeriodEnd  *time.Time `json:"current_period_end,omitempty"` // Fim do período pago
+	CancelAtPeriodEnd bool       `json:"cancel_at_period_end"`         // Cancelada, vale até o fim do período
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// DeviceSessionTTL é o tempo sem uso após o qual a sessão expira
+// (mesma duração do JWT, que é renovado enquanto houver uso)
+const DeviceSessionTTL = 7 * 24 * time.Hour
+
+// LoginCheck indica o que há de novo em um login em relação ao histórico
+type LoginCheck struct {
+	FirstLogin bool // Nenhum login anterior registrado
+	NewDevice  bool // Dispositivo nunca visto para este usuário
+	NewCountry bool // País nunca visto para este usuário (só se o país for conhecido)
 }
 
 // ItemType define os tipos de itens na Caixa Famli
@@ -77,6 +254,12 @@ const (
 	ItemTypeAccess   ItemType = "access"   // Instruções de acesso (não senhas!)
 	ItemTypeRoutine  ItemType = "routine"  // Rotina que não pode parar
 	ItemTypeLocation ItemType = "location" // Onde estão as coisas
+	ItemTypeSealed   ItemType = "sealed"   // Cifrado no navegador (nem o servidor lê)
+	ItemTypeDocument ItemType = "document" // Documento com validade (passaporte, apólice...)
+	ItemTypePet      ItemType = "pet"      // Perfil de um animal de estimação
+
+	ItemTypeFinancialAccount ItemType = "financial_account" // Conta bancária, investimento, cartão...
+	ItemTypeOnlineAccount    ItemType = "online_account"    // Conta online e o que fazer com ela
 )
 
 // BoxItem representa um item na Caixa Famli
@@ -94,6 +277,435 @@ type BoxItem struct {
 	GuardianIDs []string  `json:"guardian_ids,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Version começa em 1 e aumenta a cada atualização (ETag dos itens).
+	// Em UpdateBoxItem, o Version recebido é a versão esperada (0 = sem conferir).
+	Version int `json:"version,omitempty"`
+
+	// Datas importantes (apenas o dia, em UTC). Aparecem no calendário ICS.
+	DueDate     *time.Time `json:"due_date,omitempty"`     // Vencimento
+	RenewalDate *time.Time `json:"renewal_date,omitempty"` // Renovação
+
+	// HouseholdID coloca o item na caixa da família (vazio = caixa pessoal)
+	// UserID continua sendo quem criou o item.
+	HouseholdID string `json:"household_id,omitempty"`
+
+	// Tags livres (minúsculas), usadas nos filtros da listagem
+	Tags []string `json:"tags,omitempty"`
+
+	// Checklist é a lista marcável do item (ex: rotinas que não podem parar),
+	// na ordem definida pelo usuário. O texto de cada linha é criptografado.
+	Checklist []ChecklistEntry `json:"checklist,omitempty"`
+
+	// Schedule é a agenda estruturada das rotinas (type "routine"): remédio,
+	// dose, horários e responsável. nil = rotina em texto livre.
+	Schedule *RoutineSchedule `json:"schedule,omitempty"`
+
+	// Document são os dados dos documentos (type "document"): tipo, número
+	// mascarado e validade, usada nos lembretes de vencimento
+	Document *DocumentInfo `json:"document,omitempty"`
+
+	// Pet é o perfil do animal (type "pet"): espécie, veterinário, horários
+	// de alimentação e remédio
+	Pet *PetProfile `json:"pet,omitempty"`
+
+	// FinancialAccount é a conta financeira (type "financial_account"):
+	// instituição, tipo, número mascarado e onde estão extratos e acessos
+	FinancialAccount *FinancialAccount `json:"financial_account,omitempty"`
+
+	// OnlineAccount é a conta online (type "online_account"): serviço, dicas
+	// de login e o que fazer com ela (transformar em memorial, apagar ou
+	// transferir)
+	OnlineAccount *OnlineAccount `json:"online_account,omitempty"`
+
+	// ArchivedAt marca o item arquivado: sai das listagens padrão, dos links
+	// compartilhados e do calendário, mas não é apagado (nil = ativo)
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+
+	// RecipientGuardianID aponta o destinatário para uma pessoa de confiança
+	// de quem criou o item. Vazio = apenas o texto livre em Recipient.
+	RecipientGuardianID string `json:"recipient_guardian_id,omitempty"`
+
+	// Sealed traz os parâmetros dos itens selados (type "sealed"): Content é
+	// o texto cifrado no navegador com uma chave derivada de uma frase
+	// secreta que o servidor nunca recebe.
+	Sealed *SealedParams `json:"sealed,omitempty"`
+
+	// ContentHTML é o conteúdo (Markdown) renderizado, apenas com ?render=html.
+	// Nunca é preenchido para itens selados. Não é persistido.
+	ContentHTML string `json:"content_html,omitempty"`
+
+	// Relations são os guardiões e itens vinculados (GET de um item).
+	// Não é persistido.
+	Relations *ItemRelations `json:"relations,omitempty"`
+
+	// Source é a página de onde o item foi capturado (GET de um item).
+	// Guardado à parte, em item_sources.
+	Source *ItemSource `json:"source,omitempty"`
+
+	// ViewStatus indica, nas visualizações compartilhadas, se o item está
+	// sendo entregue agora ("delivered") ou já foi lido ("read").
+	// Não é persistido.
+	ViewStatus ItemViewStatus `json:"view_status,omitempty"`
+}
+
+// ItemViewSource identifica por onde um item foi visto
+type ItemViewSource string
+
+const (
+	ItemViewShareLink ItemViewSource = "share_link" // Link compartilhado (/compartilhado/{token})
+	ItemViewGuardian  ItemViewSource = "guardian"   // Link ou portal do guardião
+)
+
+// ItemViewStatus é o estado de leitura de um item para quem o vê
+type ItemViewStatus string
+
+const (
+	ItemDelivered ItemViewStatus = "delivered" // Primeira vez que a origem vê o item
+	ItemRead      ItemViewStatus = "read"      // Já visto antes pela mesma origem
+)
+
+// ItemView é o recibo de leitura de um item por uma origem
+type ItemView struct {
+	ItemID        string         `json:"item_id"`
+	Source        ItemViewSource `json:"source"`
+	SourceID      string         `json:"source_id"`             // ID do link ou do guardião
+	ViewerName    string         `json:"viewer_name,omitempty"` // Nome do link ou do guardião (não persistido)
+	FirstViewedAt time.Time      `json:"first_viewed_at"`
+	LastViewedAt  time.Time      `json:"last_viewed_at"`
+	ViewCount     int            `json:"view_count"`
+}
+
+// ItemComment é uma pergunta ou observação de um guardião sobre um item compartilhado
+type ItemComment struct {
+	ID           string    `json:"id"`
+	ItemID       string    `json:"item_id"`
+	UserID       string    `json:"-"` // Dono do item
+	GuardianID   string    `json:"guardian_id"`
+	GuardianName string    `json:"guardian_name,omitempty"` // Preenchido na listagem (não é salvo)
+	Body         string    `json:"body"`                    // Criptografado no banco
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// RelationKind é o tipo de vínculo de um item
+type RelationKind string
+
+const (
+	RelationIntendedFor RelationKind = "intended_for" // Item destinado a uma pessoa de confiança
+	RelationSeeAlso     RelationKind = "see_also"     // "Veja também" entre dois itens (nos dois sentidos)
+)
+
+// IsValid indica se o tipo de vínculo é conhecido
+func (k RelationKind) IsValid() bool {
+	return k == RelationIntendedFor || k == RelationSeeAlso
+}
+
+// ItemRelation é um vínculo de um item com um guardião ou com outro item
+type ItemRelation struct {
+	ID        string       `json:"id"`
+	ItemID    string       `json:"item_id"`
+	Kind      RelationKind `json:"kind"`
+	TargetID  string       `json:"target_id"` // Guardião (intended_for) ou item (see_also)
+	CreatedBy string       `json:"created_by,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// Counterpart retorna a outra ponta do vínculo, vista a partir do item
+// Um "veja também" aparece nos dois itens.
+func (r *ItemRelation) Counterpart(itemID string) string {
+	if r.Kind == RelationSeeAlso && r.TargetID == itemID {
+		return r.ItemID
+	}
+	return r.TargetID
+}
+
+// ItemRelations são as entidades vinculadas a um item, prontas para exibir
+type ItemRelations struct {
+	IntendedFor []*RelatedGuardian `json:"intended_for"`
+	SeeAlso     []*RelatedItem     `json:"see_also"`
+}
+
+// RelatedGuardian é uma pessoa de confiança a quem o item se destina
+type RelatedGuardian struct {
+	RelationID   string `json:"relation_id"`
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Relationship string `json:"relationship,omitempty"`
+}
+
+// RelatedItem é um item ligado por "veja também"
+type RelatedItem struct {
+	RelationID string   `json:"relation_id"`
+	ID         string   `json:"id"`
+	Title      string   `json:"title"`
+	Type       ItemType `json:"type"`
+	Category   string   `json:"category,omitempty"`
+}
+
+// SealedParams são os parâmetros para derivar a chave e decifrar um item
+// selado no navegador (valores binários em base64)
+type SealedParams struct {
+	KDF         string `json:"kdf"`                   // pbkdf2-sha256 ou argon2id
+	Salt        string `json:"salt"`                  // Sal da derivação
+	Iterations  int    `json:"iterations"`            // Iterações (PBKDF2) ou passes (Argon2id)
+	MemoryKiB   int    `json:"memory_kib,omitempty"`  // Apenas Argon2id
+	Parallelism int    `json:"parallelism,omitempty"` // Apenas Argon2id
+	Cipher      string `json:"cipher"`                // aes-256-gcm
+	Nonce       string `json:"nonce"`                 // IV do AES-GCM
+}
+
+// ChecklistEntry é uma linha da lista marcável de um item
+type ChecklistEntry struct {
+	ID     string     `json:"id"`
+	Text   string     `json:"text"`
+	Done   bool       `json:"done"`
+	DoneAt *time.Time `json:"done_at,omitempty"` // Quando foi marcada
+}
+
+// RoutineSchedule é a agenda de uma rotina (ex: remédio das 8h e das 20h)
+// Os horários são do relógio local de quem cuida (sem fuso horário).
+type RoutineSchedule struct {
+	Medication            string   `json:"medication,omitempty"`
+	Dose                  string   `json:"dose,omitempty"`
+	Times                 []string `json:"times"`                             // "HH:MM", em ordem
+	Weekdays              []int    `json:"weekdays,omitempty"`                // 0 = domingo; vazio = todos os dias
+	ResponsibleGuardianID string   `json:"responsible_guardian_id,omitempty"` // Pessoa de confiança de quem criou o item
+
+	// ResponsibleName é o nome do responsável nas visões dos guardiões (não é salvo)
+	ResponsibleName string `json:"responsible_name,omitempty"`
+}
+
+// RunsOn indica se a rotina acontece no dia da semana
+func (s *RoutineSchedule) RunsOn(day time.Weekday) bool {
+	if len(s.Weekdays) == 0 {
+		return true
+	}
+	for _, weekday := range s.Weekdays {
+		if weekday == int(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// DocumentInfo são os dados estruturados de um documento
+// O número nunca é guardado inteiro: apenas os 4 últimos caracteres.
+type DocumentInfo struct {
+	Kind      DocumentKind  `json:"kind"`
+	Number    string        `json:"number,omitempty"`     // Mascarado (ex: "****4321"); criptografado no banco
+	ExpiresOn *time.Time    `json:"expires_on,omitempty"` // Validade (apenas o dia, em UTC)
+	Legal     *LegalDetails `json:"legal,omitempty"`      // Apenas nos documentos jurídicos (Kind.IsLegal)
+}
+
+// LegalDetails são os dados de um documento jurídico (testamento, procuração...)
+// Os textos são criptografados no banco.
+type LegalDetails struct {
+	StoredAt        string     `json:"stored_at,omitempty"`        // Onde está o original (cartório, cofre...)
+	LawyerName      string     `json:"lawyer_name,omitempty"`      // Advogado(a) ou tabelião
+	LawyerContact   string     `json:"lawyer_contact,omitempty"`   // Telefone ou e-mail
+	ExecutorName    string     `json:"executor_name,omitempty"`    // Inventariante, procurador(a) ou responsável
+	ExecutorContact string     `json:"executor_contact,omitempty"` // Telefone ou e-mail
+	SignedOn        *time.Time `json:"signed_on,omitempty"`        // Data da assinatura (apenas o dia, em UTC)
+}
+
+// DocumentKind é o tipo do documento
+type DocumentKind string
+
+const (
+	DocumentPassport      DocumentKind = "passport"
+	DocumentIDCard        DocumentKind = "id_card"
+	DocumentDriverLicense DocumentKind = "driver_license"
+	DocumentInsurance     DocumentKind = "insurance"
+	DocumentVehicle       DocumentKind = "vehicle"
+	DocumentOther         DocumentKind = "other"
+
+	// Documentos jurídicos (com LegalDetails e checklist próprio)
+	DocumentWill             DocumentKind = "will"              // Testamento
+	DocumentPowerOfAttorney  DocumentKind = "power_of_attorney" // Procuração
+	DocumentLivingWill       DocumentKind = "living_will"       // Diretivas antecipadas de vontade
+	DocumentGuardianshipPlan DocumentKind = "guardianship"      // Indicação de tutor(a) dos filhos
+)
+
+// LegalDocumentKinds são os documentos jurídicos, na ordem do checklist
+var LegalDocumentKinds = []DocumentKind{DocumentWill, DocumentPowerOfAttorney, DocumentLivingWill, DocumentGuardianshipPlan}
+
+// IsLegal indica um documento jurídico
+func (k DocumentKind) IsLegal() bool {
+	for _, kind := range LegalDocumentKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// PetProfile é o perfil de um animal de estimação
+// Os textos são criptografados no banco.
+type PetProfile struct {
+	Name         string     `json:"name"`
+	Species      PetSpecies `json:"species"`
+	VetName      string     `json:"vet_name,omitempty"`      // Veterinário(a) ou clínica
+	VetPhone     string     `json:"vet_phone,omitempty"`     // Formato +55...
+	FeedingTimes []string   `json:"feeding_times,omitempty"` // "HH:MM", em ordem
+	Food         string     `json:"food,omitempty"`          // Ração e quantidade
+	Medication   string     `json:"medication,omitempty"`    // Remédio, dose e horário
+}
+
+// PetSpecies é a espécie do animal
+type PetSpecies string
+
+const (
+	PetDog     PetSpecies = "dog"
+	PetCat     PetSpecies = "cat"
+	PetBird    PetSpecies = "bird"
+	PetFish    PetSpecies = "fish"
+	PetRodent  PetSpecies = "rodent"
+	PetReptile PetSpecies = "reptile"
+	PetOther   PetSpecies = "other"
+)
+
+// FinancialAccount é uma conta no inventário financeiro
+// O número nunca é guardado inteiro ("****1234"); os textos são
+// criptografados no banco.
+type FinancialAccount struct {
+	Institution         string               `json:"institution"`
+	AccountType         FinancialAccountType `json:"account_type"`
+	Number              string               `json:"number,omitempty"`               // Mascarado: só os 4 últimos
+	StatementsLocation  string               `json:"statements_location,omitempty"`  // Onde chegam ou ficam os extratos
+	CredentialsLocation string               `json:"credentials_location,omitempty"` // Onde está a informação de acesso (nunca a senha)
+}
+
+// FinancialAccountType é o tipo da conta financeira
+type FinancialAccountType string
+
+const (
+	FinancialChecking   FinancialAccountType = "checking"    // Conta corrente
+	FinancialSavings    FinancialAccountType = "savings"     // Poupança
+	FinancialInvestment FinancialAccountType = "investment"  // Investimentos, corretora
+	FinancialRetirement FinancialAccountType = "retirement"  // Previdência
+	FinancialCreditCard FinancialAccountType = "credit_card" // Cartão de crédito
+	FinancialLoan       FinancialAccountType = "loan"        // Empréstimo, financiamento
+	FinancialOther      FinancialAccountType = "other"
+)
+
+// OnlineAccount é uma conta online no legado digital
+// Guarda só dicas (nunca a senha); os textos são criptografados no banco.
+type OnlineAccount struct {
+	Service           string              `json:"service"`
+	UsernameHint      string              `json:"username_hint,omitempty"`       // Ex: "maria.s... no Gmail pessoal"
+	RecoveryEmailHint string              `json:"recovery_email_hint,omitempty"` // Ex: "o email antigo do Hotmail"
+	Action            OnlineAccountAction `json:"action"`
+}
+
+// OnlineAccountAction é o que a família deve fazer com a conta
+type OnlineAccountAction string
+
+const (
+	OnlineAccountMemorialize OnlineAccountAction = "memorialize" // Transformar em memorial
+	OnlineAccountDelete      OnlineAccountAction = "delete"      // Encerrar e apagar
+	OnlineAccountTransfer    OnlineAccountAction = "transfer"    // Passar para alguém da família
+)
+
+// OnlineAccountActions são as ações, na ordem das seções do memorial
+var OnlineAccountActions = []OnlineAccountAction{OnlineAccountMemorialize, OnlineAccountDelete, OnlineAccountTransfer}
+
+// IsValid indica uma ação conhecida
+func (a OnlineAccountAction) IsValid() bool {
+	for _, action := range OnlineAccountActions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// ExpiryStatus é o selo de validade de um documento nas listagens
+type ExpiryStatus string
+
+const (
+	ExpiryExpired ExpiryStatus = "expired"  // Validade já passou
+	ExpirySoon    ExpiryStatus = "expiring" // Vence nos próximos 30 dias
+	ExpiryValid   ExpiryStatus = "valid"    // Ainda válido
+)
+
+// DocumentExpiry é um documento com validade, para os lembretes
+type DocumentExpiry struct {
+	ItemID    string
+	UserID    string
+	ExpiresOn time.Time
+}
+
+// IsSealed indica se o conteúdo do item é cifrado no navegador
+// O conteúdo de itens selados nunca é renderizado, buscado ou resumido.
+func (i *BoxItem) IsSealed() bool {
+	return i.Type == ItemTypeSealed
+}
+
+// HasDates indica se o item tem alguma data para o calendário
+func (i *BoxItem) HasDates() bool {
+	return i.DueDate != nil || i.RenewalDate != nil
+}
+
+// ContentBytes é o tamanho do texto do item (título, conteúdo, destinatário,
+// linhas da lista, remédio/dose da agenda, número e dados jurídicos do
+// documento, textos do perfil do pet, da conta financeira e da conta online)
+// Usado nas cotas; no banco é gravado antes da criptografia (content_bytes).
+func (i *BoxItem) ContentBytes() int64 {
+	size := len(i.Title) + len(i.Content) + len(i.Recipient)
+	for _, entry := range i.Checklist {
+		size += len(entry.Text)
+	}
+	if i.Schedule != nil {
+		size += len(i.Schedule.Medication) + len(i.Schedule.Dose)
+	}
+	if i.Document != nil {
+		size += len(i.Document.Number)
+		if legal := i.Document.Legal; legal != nil {
+			size += len(legal.StoredAt) + len(legal.LawyerName) + len(legal.LawyerContact) +
+				len(legal.ExecutorName) + len(legal.ExecutorContact)
+		}
+	}
+	if i.Pet != nil {
+		size += len(i.Pet.Name) + len(i.Pet.VetName) + len(i.Pet.VetPhone) + len(i.Pet.Food) + len(i.Pet.Medication)
+	}
+	if account := i.FinancialAccount; account != nil {
+		size += len(account.Institution) + len(account.Number) + len(account.StatementsLocation) + len(account.CredentialsLocation)
+	}
+	if account := i.OnlineAccount; account != nil {
+		size += len(account.Service) + len(account.UsernameHint) + len(account.RecoveryEmailHint)
+	}
+	return int64(size)
+}
+
+// UserUsage é o consumo de armazenamento de um usuário
+// Conta os itens criados por ele, inclusive os que estão em famílias.
+type UserUsage struct {
+	UserID        string `json:"user_id"`
+	Email         string `json:"email,omitempty"` // Apenas em ListTopUsage
+	Name          string `json:"name,omitempty"`  // Apenas em ListTopUsage
+	ItemCount     int    `json:"item_count"`
+	ArchivedCount int    `json:"archived_count"` // Incluídos em ItemCount (arquivados contam na cota)
+	ContentBytes  int64  `json:"content_bytes"`
+}
+
+// AssistantUsage é o consumo diário do assistente por um usuário
+// Day é a data em UTC (AAAA-MM-DD).
+type AssistantUsage struct {
+	UserID   string `json:"user_id,omitempty"`
+	Day      string `json:"day,omitempty"`
+	Requests int    `json:"requests"` // Perguntas respondidas
+	Tokens   int    `json:"tokens"`   // Tokens estimados (pergunta + resposta)
+	Rejected int    `json:"rejected"` // Perguntas recusadas pelo orçamento diário
+}
+
+// AssistantUsageStats resume o uso do assistente desde uma data (admin)
+type AssistantUsageStats struct {
+	Requests    int               `json:"requests"`
+	Tokens      int               `json:"tokens"`
+	Rejected    int               `json:"rejected"`
+	ActiveUsers int               `json:"active_users"`
+	Daily       []*AssistantUsage `json:"daily"`     // Totais por dia (sem UserID)
+	TopUsers    []*AssistantUsage `json:"top_users"` // Maiores consumidores no período (sem Day)
 }
 
 // BoxItemSummary é uma versão resumida do item para listagens
@@ -106,9 +718,116 @@ type BoxItemSummary struct {
 	IsImportant bool      `json:"is_important"`
 	IsShared    bool      `json:"is_shared"`
 	GuardianIDs []string  `json:"guardian_ids,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	Version     int       `json:"version"`
+
+	DueDate     *time.Time `json:"due_date,omitempty"`
+	RenewalDate *time.Time `json:"renewal_date,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
+
+	// Validade dos documentos e o selo calculado na listagem
+	ExpiresOn    *time.Time   `json:"expires_on,omitempty"`
+	ExpiryStatus ExpiryStatus `json:"expiry_status,omitempty"`
+
+	// Dono do item (preenchidos na listagem combinada pessoal + família)
+	OwnerID       string    `json:"owner_id,omitempty"`
+	HouseholdID   string    `json:"household_id,omitempty"`
+	Scope         ItemScope `json:"scope,omitempty"`
+	OwnerName     string    `json:"owner_name,omitempty"`     // Quem criou (itens da família)
+	HouseholdName string    `json:"household_name,omitempty"` // Nome da família
+	CanEdit       bool      `json:"can_edit"`
+
+	// Ordem de quem lista (cada pessoa fixa e ordena os itens que vê)
+	Pinned   bool `json:"pinned"`
+	Position int  `json:"position"` // Ordem manual (0 = nunca reordenado)
 }
 
+// ItemScope indica onde o item está guardado
+type ItemScope string
+
+const (
+	ItemScopePersonal  ItemScope = "personal"  // Caixa pessoal
+	ItemScopeHousehold ItemScope = "household" // Caixa da família
+)
+
+// BoxItemFilter define quais itens entram na listagem combinada
+type BoxItemFilter struct {
+	UserID          string   // Dono da caixa pessoal
+	IncludePersonal bool     // Itens pessoais (sem família)
+	HouseholdIDs    []string // Famílias das quais o usuário é membro ativo
+
+	// Filtros opcionais (zero = sem filtro)
+	Types        []ItemType // Qualquer um dos tipos
+	Category     string     // Sem diferenciar maiúsculas
+	Tags         []string   // Todas as tags
+	Important    *bool
+	Shared       *bool
+	UpdatedSince *time.Time
+
+	IncludeArchived bool // false = sem os itens arquivados
+
+	Sort BoxItemSort // Vazio = BoxItemSortNewest; itens fixados por UserID vêm sempre primeiro
+}
+
+// Matches indica se o item atende aos filtros opcionais
+// (o escopo pessoal/famílias é verificado por quem lista)
+func (f *BoxItemFilter) Matches(item *BoxItem) bool {
+	if len(f.Types) > 0 {
+		found := false
+		for _, t := range f.Types {
+			if item.Type == t {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.Category != "" && !strings.EqualFold(item.Category, f.Category) {
+		return false
+	}
+	for _, tag := range f.Tags {
+		found := false
+		for _, itemTag := range item.Tags {
+			if itemTag == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.Important != nil && item.IsImportant != *f.Important {
+		return false
+	}
+	if f.Shared != nil && item.IsShared != *f.Shared {
+		return false
+	}
+	if f.UpdatedSince != nil && item.UpdatedAt.Before(*f.UpdatedSince) {
+		return false
+	}
+	if !f.IncludeArchived && item.ArchivedAt != nil {
+		return false
+	}
+	return true
+}
+
+// BoxItemSort define a ordenação da listagem combinada
+type BoxItemSort string
+
+const (
+	BoxItemSortNewest  BoxItemSort = "newest"  // Criados mais recentemente primeiro (padrão)
+	BoxItemSortOldest  BoxItemSort = "oldest"  // Criados há mais tempo primeiro
+	BoxItemSortUpdated BoxItemSort = "updated" // Alterados mais recentemente primeiro
+	BoxItemSortDue     BoxItemSort = "due"     // Vencimento mais próximo primeiro (sem data no fim)
+	BoxItemSortManual  BoxItemSort = "manual"  // Ordem definida pelo usuário (não reordenados primeiro)
+	BoxItemSortTitle   BoxItemSort = "title"   // Título em ordem alfabética
+)
+
 // GuardianAccessType define os tipos de acesso do guardião
 type GuardianAccessType string
 
@@ -120,20 +839,246 @@ const (
 
 // Guardian representa uma pessoa de confiança
 type Guardian struct {
-	ID           string             `json:"id"`
-	UserID       string             `json:"user_id"`
-	Name         string             `json:"name"`
-	Email        string             `json:"email"`
-	Phone        string             `json:"phone,omitempty"`
-	Relationship string             `json:"relationship,omitempty"` // filho, neto, amigo, etc.
-	Role         string             `json:"role"`                   // viewer, coauthor (futuro)
-	Notes        string             `json:"notes,omitempty"`        // explicação do papel
-	AccessToken  string             `json:"access_token"`           // Token único para acesso (sempre retornado)
-	AccessPIN    string             `json:"-"`                      // PIN de proteção (hash) - não expor no JSON
-	HasPIN       bool               `json:"has_pin"`                // Indica se tem PIN configurado
-	AccessType   GuardianAccessType `json:"access_type,omitempty"`  // Tipo de acesso
-	CreatedAt    time.Time          `json:"created_at"`
-	UpdatedAt    time.Time          `json:"updated_at"`
+	ID            string             `json:"id"`
+	UserID        string             `json:"user_id"`
+	Name          string             `json:"name"`
+	Email         string             `json:"email"`
+	Phone         string             `json:"phone,omitempty"`
+	Relationship  string             `json:"relationship,omitempty"`   // filho, neto, amigo, etc.
+	Role          string             `json:"role"`                     // viewer, coauthor (futuro)
+	Notes         string             `json:"notes,omitempty"`          // explicação do papel
+	AccessToken   string             `json:"access_token"`             // Token único para acesso (sempre retornado)
+	AccessPIN     string             `json:"-"`                        // PIN de proteção (hash) - não expor no JSON
+	HasPIN        bool               `json:"has_pin"`                  // Indica se tem PIN configurado
+	AccessType    GuardianAccessType `json:"access_type,omitempty"`    // Tipo de acesso
+	NotifyChannel NotifyChannel      `json:"notify_channel,omitempty"` // Canal preferido para avisos ("" = automático)
+	AccountUserID string             `json:"-"`                        // Conta Famli vinculada (portal do guardião)
+	HasAccount    bool               `json:"has_account"`              // Indica se o guardião vinculou uma conta
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+
+	// DeletionRequestedAt é quando o próprio guardião pediu a remoção dos
+	// seus dados (LGPD); o registro é apagado após o prazo de carência
+	DeletionRequestedAt *time.Time `json:"deletion_requested_at,omitempty"`
+
+	// AccessDisabledAt é quando o dono desativou o acesso (link e portal);
+	// o cadastro continua salvo e o acesso pode ser reativado
+	AccessDisabledAt *time.Time `json:"access_disabled_at,omitempty"`
+}
+
+// NotifyChannel define por onde o guardião prefere receber avisos
+type NotifyChannel string
+
+const (
+	NotifyAuto     NotifyChannel = ""         // WhatsApp se houver telefone, senão email
+	NotifyWhatsApp NotifyChannel = "whatsapp" // Mensagem no WhatsApp
+	NotifySMS      NotifyChannel = "sms"      // SMS (guardiões sem WhatsApp)
+	NotifyEmail    NotifyChannel = "email"    // Apenas email
+)
+
+// ParseNotifyChannel valida um canal de notificação recebido como texto
+func ParseNotifyChannel(value string) (NotifyChannel, bool) {
+	switch channel := NotifyChannel(value); channel {
+	case NotifyAuto, NotifyWhatsApp, NotifySMS, NotifyEmail:
+		return channel, true
+	}
+	return "", false
+}
+
+// NotifyChannels retorna os canais a tentar, em ordem, para avisar o guardião
+// O canal preferido vem primeiro; os demais servem de fallback se ele falhar.
+// Canais sem o contato necessário (telefone ou email) são omitidos.
+func (g *Guardian) NotifyChannels() []NotifyChannel {
+	var order []NotifyChannel
+	switch g.NotifyChannel {
+	case NotifySMS:
+		order = []NotifyChannel{NotifySMS, NotifyEmail}
+	case NotifyEmail:
+		order = []NotifyChannel{NotifyEmail, NotifySMS}
+	default:
+		order = []NotifyChannel{NotifyWhatsApp, NotifySMS, NotifyEmail}
+	}
+	return g.reachable(order)
+}
+
+// AlertChannels retorna os canais a tentar para um aviso
+// preferred (escolhidos pelo dono, em ordem) substitui a preferência do
+// guardião; vazio = NotifyChannels.
+func (g *Guardian) AlertChannels(preferred []NotifyChannel) []NotifyChannel {
+	if len(preferred) == 0 {
+		return g.NotifyChannels()
+	}
+	return g.reachable(preferred)
+}
+
+// reachable omite os canais sem o contato necessário (telefone ou email)
+func (g *Guardian) reachable(order []NotifyChannel) []NotifyChannel {
+	channels := make([]NotifyChannel, 0, len(order))
+	for _, channel := range order {
+		if channel == NotifyEmail && g.Email == "" {
+			continue
+		}
+		if channel != NotifyEmail && g.Phone == "" {
+			continue
+		}
+		channels = append(channels, channel)
+	}
+	return channels
+}
+
+// DefaultAccessTimezone é o fuso das janelas de acesso sem fuso definido
+const DefaultAccessTimezone = "America/Sao_Paulo"
+
+// GuardianAccessPolicy são as regras de acesso de um guardião, definidas pelo
+// dono e conferidas no link de acesso e no portal
+type GuardianAccessPolicy struct {
+	GuardianID  string         `json:"-"`
+	ExpiresAt   *time.Time     `json:"expires_at,omitempty"`   // Depois disso o acesso não abre mais
+	Windows     []AccessWindow `json:"windows,omitempty"`      // Horários permitidos (vazio = qualquer horário)
+	Timezone    string         `json:"timezone"`               // Fuso das janelas (IANA)
+	MaxAccesses int            `json:"max_accesses,omitempty"` // Total de acessos ao conteúdo (0 = sem limite)
+	AccessCount int            `json:"access_count"`           // Acessos ao conteúdo desde a última contagem zerada
+	UpdatedAt   time.Time      `json:"updated_at"`
+
+	// BlockedNotifiedAt é o último aviso ao dono de um acesso barrado
+	// (no máximo um por dia, por guardião)
+	BlockedNotifiedAt *time.Time `json:"-"`
+}
+
+// AccessWindow é um horário permitido em alguns dias da semana
+// Com Start depois de End, a janela atravessa a meia-noite (ex: 22:00-06:00).
+type AccessWindow struct {
+	Weekdays []time.Weekday `json:"weekdays"` // 0 = domingo ... 6 = sábado
+	Start    string         `json:"start"`    // HH:MM
+	End      string         `json:"end"`      // HH:MM (exclusivo)
+}
+
+// AccessPolicyBlock é o motivo de uma regra barrar o acesso
+type AccessPolicyBlock string
+
+const (
+	AccessPolicyAllowed       AccessPolicyBlock = ""
+	AccessPolicyExpired       AccessPolicyBlock = "expired"        // Passou de ExpiresAt
+	AccessPolicyOutsideWindow AccessPolicyBlock = "outside_window" // Fora das janelas de horário
+	AccessPolicyLimitReached  AccessPolicyBlock = "limit_reached"  // MaxAccesses atingido
+)
+
+// Location retorna o fuso das janelas (o padrão se Timezone for inválido)
+func (p *GuardianAccessPolicy) Location() *time.Location {
+	name := p.Timezone
+	if name == "" {
+		name = DefaultAccessTimezone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		loc, _ = time.LoadLocation(DefaultAccessTimezone)
+	}
+	return loc
+}
+
+// Check confere as regras no instante now
+// O prazo vem primeiro, depois o limite de acessos e por fim o horário.
+func (p *GuardianAccessPolicy) Check(now time.Time) AccessPolicyBlock {
+	if p == nil {
+		return AccessPolicyAllowed
+	}
+	if p.ExpiresAt != nil && !now.Before(*p.ExpiresAt) {
+		return AccessPolicyExpired
+	}
+	if p.MaxAccesses > 0 && p.AccessCount >= p.MaxAccesses {
+		return AccessPolicyLimitReached
+	}
+	if len(p.Windows) == 0 {
+		return AccessPolicyAllowed
+	}
+
+	local := now.In(p.Location())
+	minute := local.Hour()*60 + local.Minute()
+	for _, window := range p.Windows {
+		if window.Contains(local.Weekday(), minute) {
+			return AccessPolicyAllowed
+		}
+	}
+	return AccessPolicyOutsideWindow
+}
+
+// Contains indica se o minuto do dia (0-1439), no dia da semana, está na
+// janela; depois da meia-noite vale o dia em que a janela começou
+func (w AccessWindow) Contains(day time.Weekday, minute int) bool {
+	start, okStart := ParseClock(w.Start)
+	end, okEnd := ParseClock(w.End)
+	if !okStart || !okEnd {
+		return false
+	}
+	runsOn := func(d time.Weekday) bool {
+		for _, weekday := range w.Weekdays {
+			if weekday == d {
+				return true
+			}
+		}
+		return false
+	}
+
+	if start < end {
+		return runsOn(day) && minute >= start && minute < end
+	}
+	// Atravessa a meia-noite
+	return (runsOn(day) && minute >= start) || (runsOn((day+6)%7) && minute < end)
+}
+
+// ParseClock converte "HH:MM" em minutos desde a meia-noite
+func ParseClock(value string) (int, bool) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// GuardianAlertEvent identifica o motivo de um aviso aos guardiões
+type GuardianAlertEvent string
+
+const (
+	GuardianAlertEmergency    GuardianAlertEvent = "emergency.activated"  // Primeiro acesso a um link de emergência
+	GuardianAlertOwnerMessage GuardianAlertEvent = "owner.message"        // Recado do dono (POST /api/guardians/notify)
+	GuardianAlertEscalation   GuardianAlertEvent = "emergency.escalation" // Chamada da lista de escalonamento (pede confirmação)
+	GuardianAlertMemorial     GuardianAlertEvent = "memorial.activation"  // Andamento do pedido de abertura do memorial
+)
+
+// GuardianAlertStatus define o estado da entrega de um aviso
+type GuardianAlertStatus string
+
+const (
+	GuardianAlertPending   GuardianAlertStatus = "pending"   // Aguardando (re)tentativa
+	GuardianAlertDelivered GuardianAlertStatus = "delivered" // Entregue por algum canal
+	GuardianAlertFailed    GuardianAlertStatus = "failed"    // Tentativas esgotadas (ou sem contato)
+)
+
+// GuardianAlertAttempt registra o envio do aviso por um canal
+type GuardianAlertAttempt struct {
+	Channel NotifyChannel `json:"channel"`
+	Success bool          `json:"success"`
+	Error   string        `json:"error,omitempty"`
+	At      time.Time     `json:"at"`
+}
+
+// GuardianAlert registra um aviso a um guardião e suas tentativas de entrega
+type GuardianAlert struct {
+	ID            string                 `json:"id"`
+	UserID        string                 `json:"-"`
+	GuardianID    string                 `json:"guardian_id"`
+	GuardianName  string                 `json:"guardian_name,omitempty"` // Preenchido na listagem (não é salvo)
+	Event         GuardianAlertEvent     `json:"event"`
+	Message       string                 `json:"-"`                  // Texto enviado (criptografado no banco)
+	Channels      []NotifyChannel        `json:"channels,omitempty"` // Canais escolhidos pelo dono (vazio = preferência do guardião)
+	Status        GuardianAlertStatus    `json:"status"`
+	Attempts      int                    `json:"attempts"`
+	Channel       NotifyChannel          `json:"channel,omitempty"` // Canal que entregou
+	Error         string                 `json:"error,omitempty"`   // Último erro
+	Log           []GuardianAlertAttempt `json:"log"`               // Tentativas por canal, em ordem
+	NextAttemptAt *time.Time             `json:"next_attempt_at,omitempty"`
+	CreatedAt     time.Time              `json:"created_at"`
+	DeliveredAt   *time.Time             `json:"delivered_at,omitempty"`
 }
 
 // GuideCard representa um card do Guia Famli
@@ -144,6 +1089,20 @@ type GuideCard struct {
 	Icon        string `json:"icon"`
 	Order       int    `json:"order"`
 	ItemType    string `json:"item_type,omitempty"` // tipo de item relacionado
+
+	// Templates são sugestões de itens para começar o passo
+	Templates []GuideItemTemplate `json:"templates,omitempty"`
+}
+
+// GuideItemTemplate é um item sugerido por um card do Guia (já traduzido)
+type GuideItemTemplate struct {
+	Type     ItemType `json:"type"`
+	Category string   `json:"category,omitempty"`
+	Title    string   `json:"title"`
+	Content  string   `json:"content,omitempty"`
+
+	// OnlineAccount pré-preenche a conta dos itens "online_account"
+	OnlineAccount *OnlineAccount `json:"online_account,omitempty"`
 }
 
 // GuideProgress armazena o progresso do usuário no Guia
@@ -160,6 +1119,109 @@ type Settings struct {
 	EmergencyProtocolEnabled bool   `json:"emergency_protocol_enabled"`
 	NotificationsEnabled     bool   `json:"notifications_enabled"`
 	Theme                    string `json:"theme"` // light, dark, auto
+
+	// NotificationOptOuts lista as categorias de notificação desligadas pelo usuário
+	NotificationOptOuts []NotificationCategory `json:"notification_opt_outs"`
+
+	// Language é o idioma do usuário (User.Locale), preenchido pelo handler.
+	// Não fica na tabela de settings.
+	Language string `json:"language,omitempty"`
+
+	// DigestFrequency é a frequência do resumo pelo WhatsApp (off, daily, weekly)
+	// Alterada apenas por UpdateDigestFrequency (UpdateSettings a preserva).
+	DigestFrequency DigestFrequency `json:"digest_frequency"`
+
+	// MessagingOptOut indica que o usuário pediu para não receber mensagens
+	// pelo WhatsApp (respondeu "STOP"). Avisos e resumos deixam de sair até
+	// ele responder "START" ou religar nas configurações.
+	// Alterado apenas por UpdateMessagingConsent (UpdateSettings o preserva).
+	MessagingOptOut bool `json:"messaging_opt_out"`
+
+	// MessagingConsentAt é quando o consentimento mudou pela última vez
+	MessagingConsentAt *time.Time `json:"messaging_consent_at,omitempty"`
+}
+
+// DigestFrequency define a frequência do resumo da Caixa pelo WhatsApp
+type DigestFrequency string
+
+const (
+	DigestOff    DigestFrequency = "off"    // Sem resumo (padrão)
+	DigestDaily  DigestFrequency = "daily"  // Todo dia
+	DigestWeekly DigestFrequency = "weekly" // Uma vez por semana
+)
+
+// IsValidDigestFrequency verifica se a frequência é conhecida
+func IsValidDigestFrequency(f DigestFrequency) bool {
+	return f == DigestOff || f == DigestDaily || f == DigestWeekly
+}
+
+// Period é o intervalo entre dois resumos (0 = desligado)
+func (f DigestFrequency) Period() time.Duration {
+	switch f {
+	case DigestDaily:
+		return 24 * time.Hour
+	case DigestWeekly:
+		return 7 * 24 * time.Hour
+	}
+	return 0
+}
+
+// PhoneLink é um número de WhatsApp vinculado à conta
+// Uma conta pode ter vários (ex: celular da família); avisos e resumos vão
+// para o principal. Cada número pertence a uma única conta.
+type PhoneLink struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"-"`
+	Phone      string    `json:"phone"`           // Formato E.164 (+5511999999999)
+	Label      string    `json:"label,omitempty"` // Apelido (ex: "Celular da Ana")
+	Primary    bool      `json:"primary"`
+	VerifiedAt time.Time `json:"verified_at"` // Quando o código de vinculação foi confirmado
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// InboundSender é um email autorizado a guardar itens pelo gateway de email
+// (ver internal/inbound). Cada endereço pertence a uma única conta.
+type InboundSender struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"-"`
+	Email      string    `json:"email"`       // Sempre em minúsculas
+	VerifiedAt time.Time `json:"verified_at"` // Quando o código de vinculação foi confirmado
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ConversationDraft é um item começado pelo WhatsApp e abandonado no meio
+// (pergunta sem resposta). Um por usuário; "continuar" retoma a conversa.
+type ConversationDraft struct {
+	UserID    string    `json:"user_id"`
+	State     string    `json:"state"` // Pergunta que ficou sem resposta (conversation.State)
+	Type      string    `json:"type"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	Category  string    `json:"category,omitempty"`
+	MediaURL  string    `json:"media_url,omitempty"`
+	MediaType string    `json:"media_type,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DigestSubscription é um usuário que recebe o resumo pelo WhatsApp
+type DigestSubscription struct {
+	UserID    string
+	Frequency DigestFrequency
+	// LastSentAt é o último envio (ou a inscrição, antes do primeiro resumo)
+	LastSentAt time.Time
+}
+
+// AllowsNotification indica se o usuário aceita notificações da categoria
+func (s *Settings) AllowsNotification(category NotificationCategory) bool {
+	if !s.NotificationsEnabled {
+		return false
+	}
+	for _, c := range s.NotificationOptOuts {
+		if c == category {
+			return false
+		}
+	}
+	return true
 }
 
 // UserDataExport representa todos os dados do usuário para exportação (LGPD)
@@ -172,6 +1234,32 @@ type UserDataExport struct {
 	ExportedAt time.Time        `json:"exported_at"`
 }
 
+// UserBackup são os dados de uma conta no backup administrativo
+// Diferente de UserDataExport, guarda os segredos que os modelos não expõem
+// no JSON (hash da senha, PINs, tokens), para que a conta, os guardiões e os
+// links funcionem após a restauração.
+type UserBackup struct {
+	User         *User              `json:"user"`
+	PasswordHash string             `json:"password_hash,omitempty"`
+	Items        []*BoxItem         `json:"items"`
+	Guardians    []*GuardianBackup  `json:"guardians"`
+	ShareLinks   []*ShareLinkBackup `json:"share_links"`
+}
+
+// GuardianBackup é um guardião com o hash do PIN e a conta vinculada
+type GuardianBackup struct {
+	*Guardian
+	AccessPIN     string `json:"access_pin,omitempty"`
+	AccountUserID string `json:"account_user_id,omitempty"`
+}
+
+// ShareLinkBackup é um link com o token e o hash do PIN
+type ShareLinkBackup struct {
+	*ShareLink
+	Token string `json:"token"`
+	PIN   string `json:"pin,omitempty"`
+}
+
 // =============================================================================
 // FEEDBACK
 // =============================================================================
@@ -199,6 +1287,19 @@ type Feedback struct {
 	AdminNote string       `json:"admin_note,omitempty"`
 	CreatedAt time.Time    `json:"created_at"`
 	UpdatedAt time.Time    `json:"updated_at"`
+
+	// Conversa entre o usuário e a equipe (carregada por GetFeedback e ListFeedbacksByUser)
+	Replies []*FeedbackReply `json:"replies,omitempty"`
+}
+
+// FeedbackReply é uma mensagem na conversa de um feedback
+type FeedbackReply struct {
+	ID         string    `json:"id"`
+	FeedbackID string    `json:"feedback_id"`
+	AuthorID   string    `json:"-"`          // Quem escreveu (vazio se a conta foi removida)
+	FromAdmin  bool      `json:"from_admin"` // Resposta da equipe Famli
+	Message    string    `json:"message"`
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 // =============================================================================
@@ -223,12 +1324,16 @@ const (
 
 // AnalyticsEvent representa um evento de analytics
 type AnalyticsEvent struct {
-	ID        string             `json:"id"`
-	UserID    string             `json:"user_id,omitempty"`
-	EventType AnalyticsEventType `json:"event_type"`
-	Page      string             `json:"page,omitempty"`
-	Details   map[string]string  `json:"details,omitempty"`
-	CreatedAt time.Time          `json:"created_at"`
+	ID            string             `json:"id"`
+	UserID        string             `json:"user_id,omitempty"`
+	EventType     AnalyticsEventType `json:"event_type"`
+	Page          string             `json:"page,omitempty"`
+	Details       map[string]string  `json:"details,omitempty"`
+	AnonymousID   string             `json:"anonymous_id,omitempty"`    // Visitante sem login (cookie famli_anon)
+	ClientEventID string             `json:"client_event_id,omitempty"` // ID gerado pelo cliente (deduplicação por usuário)
+	Country       string             `json:"country,omitempty"`         // Código ISO do país (headers do CDN)
+	DeviceClass   string             `json:"device_class,omitempty"`    // mobile, tablet, desktop ou bot
+	CreatedAt     time.Time          `json:"created_at"`
 }
 
 // AnalyticsSummary representa o resumo de analytics para o dashboard
@@ -254,6 +1359,65 @@ type AnalyticsSummary struct {
 	PendingFeedbacks int `json:"pending_feedbacks"`
 }
 
+// Etapas do funil de ativação (cada etapa inclui as anteriores)
+const (
+	FunnelRegistered = "registered" // Cadastro no período
+	FunnelFirstItem  = "first_item" // Guardou o primeiro item
+	FunnelGuardian   = "guardian"   // Cadastrou um guardião
+	FunnelShared     = "shared"     // Criou um link de compartilhamento
+)
+
+// MaxCohortWeeks limita as semanas acompanhadas em cada coorte
+const MaxCohortWeeks = 12
+
+// FunnelStep é uma etapa do funil de ativação
+type FunnelStep struct {
+	Step    string  `json:"step"`
+	Users   int     `json:"users"`
+	Percent float64 `json:"percent"` // Em relação aos cadastrados no período
+}
+
+// AnalyticsFunnel é o funil dos usuários cadastrados em [From, To)
+type AnalyticsFunnel struct {
+	From  time.Time    `json:"from"`
+	To    time.Time    `json:"to"`
+	Steps []FunnelStep `json:"steps"`
+}
+
+// RetentionCohort agrupa os usuários pela semana de cadastro
+//
+// Retained[n] é quantos usuários da coorte tiveram atividade (eventos de
+// analytics) n semanas após a semana de cadastro; a posição 0 é a própria
+// semana. Só entram semanas já iniciadas, até MaxCohortWeeks.
+type RetentionCohort struct {
+	Week     time.Time `json:"week"` // Segunda-feira (UTC) da semana de cadastro
+	Users    int       `json:"users"`
+	Retained []int     `json:"retained"`
+}
+
+// DailyStats são os eventos e os usuários ativos de um dia
+type DailyStats struct {
+	Day    time.Time `json:"day"`
+	Events int       `json:"events"`
+	Users  int       `json:"users"` // Usuários distintos com eventos no dia
+}
+
+// UserGrowthDay são os cadastros de um dia e o total acumulado de contas
+type UserGrowthDay struct {
+	Day        time.Time `json:"day"`
+	NewUsers   int       `json:"new_users"`
+	TotalUsers int       `json:"total_users"` // Contas existentes (não removidas) ao fim do dia
+}
+
+// CompletenessDay é a média das notas de preparo (GET /api/box/completeness)
+// registradas em um dia
+type CompletenessDay struct {
+	Day          time.Time `json:"day"`
+	Users        int       `json:"users"`
+	AverageScore float64   `json:"average_score"`
+	Complete     int       `json:"complete"` // Usuários com nota 100
+}
+
 // =============================================================================
 // COMPARTILHAMENTO E ACESSO
 // =============================================================================
@@ -265,6 +1429,7 @@ const (
 	ShareLinkNormal    ShareLinkType = "normal"    // Acesso normal (guardião pode ver)
 	ShareLinkEmergency ShareLinkType = "emergency" // Acesso de emergência (protocolo ativado)
 	ShareLinkMemorial  ShareLinkType = "memorial"  // Acesso memorial (pós-falecimento)
+	ShareLinkOneTime   ShareLinkType = "one_time"  // Envio avulso de um item por email (vale para um acesso)
 )
 
 // ShareLink representa um link de compartilhamento para guardiões
@@ -285,6 +1450,18 @@ type ShareLink struct {
 	IsActive    bool          `json:"is_active"`
 	CreatedAt   time.Time     `json:"created_at"`
 	UpdatedAt   time.Time     `json:"updated_at"`
+
+	// MessagesOnly restringe o link memorial aos itens endereçados aos
+	// guardiões do link (BoxItem.RecipientGuardianID)
+	MessagesOnly bool `json:"messages_only,omitempty"`
+
+	// ItemIDs restringe o link a itens escolhidos (vazio = todos). Vale junto
+	// com Categories e apenas para itens com is_shared.
+	ItemIDs []string `json:"item_ids,omitempty"`
+
+	// PassphraseHint orienta quem recebe o link sobre como obter a frase
+	// secreta dos itens selados (nunca a própria frase). Criptografado no banco.
+	PassphraseHint string `json:"passphrase_hint,omitempty"`
 }
 
 // ShareLinkAccess registra cada acesso a um link de compartilhamento
@@ -293,9 +1470,86 @@ type ShareLinkAccess struct {
 	ShareLinkID string    `json:"share_link_id"`
 	IPAddress   string    `json:"ip_address"`
 	UserAgent   string    `json:"user_agent"`
+	Country     string    `json:"country,omitempty"`      // Código ISO (GeoIP)
+	CountryName string    `json:"country_name,omitempty"` // No idioma do dono do link
+	City        string    `json:"city,omitempty"`         // No idioma do dono do link
 	AccessedAt  time.Time `json:"accessed_at"`
 }
 
+// PINAttempt registra falhas consecutivas de PIN em um link de acesso
+// Key identifica o alvo ("share:<linkID>" ou "guardian:<guardianID>").
+type PINAttempt struct {
+	Key            string    `json:"key"`
+	FailedAttempts int       `json:"failed_attempts"`
+	LastFailedAt   time.Time `json:"last_failed_at"`
+}
+
+// MemorialConfig define quem pode liberar os links memorial: N das pessoas
+// de confiança escolhidas precisam confirmar e, depois do prazo de espera
+// (em que o dono ainda pode cancelar), os links abrem
+type MemorialConfig struct {
+	UserID                string    `json:"-"`
+	GuardianIDs           []string  `json:"guardian_ids"`           // Pessoas que podem pedir e confirmar (M)
+	RequiredConfirmations int       `json:"required_confirmations"` // Confirmações necessárias (N)
+	DelayHours            int       `json:"delay_hours"`            // Espera entre a última confirmação e a liberação
+	UpdatedAt             time.Time `json:"updated_at"`
+}
+
+// MemorialActivationStatus define o estado de um pedido de abertura do memorial
+type MemorialActivationStatus string
+
+const (
+	MemorialPending  MemorialActivationStatus = "pending"  // Coletando confirmações
+	MemorialWaiting  MemorialActivationStatus = "waiting"  // Confirmado; aguardando o prazo de espera
+	MemorialUnlocked MemorialActivationStatus = "unlocked" // Links memorial liberados
+	MemorialAborted  MemorialActivationStatus = "aborted"  // Cancelado pelo dono
+	MemorialExpired  MemorialActivationStatus = "expired"  // Sem confirmações suficientes no prazo
+)
+
+// MemorialConfirmation é a confirmação de uma pessoa de confiança
+type MemorialConfirmation struct {
+	GuardianID   string    `json:"guardian_id"`
+	GuardianName string    `json:"guardian_name,omitempty"` // Preenchido na resposta (não é salvo)
+	ConfirmedAt  time.Time `json:"confirmed_at"`
+}
+
+// MemorialActivation é um pedido de abertura do memorial (a primeira
+// confirmação abre o pedido)
+type MemorialActivation struct {
+	ID                    string                   `json:"id"`
+	UserID                string                   `json:"-"`
+	Status                MemorialActivationStatus `json:"status"`
+	GuardianIDs           []string                 `json:"guardian_ids"` // Cópia da configuração no pedido
+	RequiredConfirmations int                      `json:"required_confirmations"`
+	DelayHours            int                      `json:"delay_hours"`
+	Confirmations         []MemorialConfirmation   `json:"confirmations"`
+	ExpiresAt             time.Time                `json:"expires_at"`            // Prazo para juntar as confirmações
+	UnlocksAt             *time.Time               `json:"unlocks_at,omitempty"`  // Fim da espera (waiting)
+	UnlockedAt            *time.Time               `json:"unlocked_at,omitempty"` // Quando os links abriram
+	AbortedAt             *time.Time               `json:"aborted_at,omitempty"`
+	CreatedAt             time.Time                `json:"created_at"`
+	UpdatedAt             time.Time                `json:"updated_at"`
+}
+
+// ConfirmedBy indica se a pessoa de confiança já confirmou o pedido
+func (a *MemorialActivation) ConfirmedBy(guardianID string) bool {
+	for _, confirmation := range a.Confirmations {
+		if confirmation.GuardianID == guardianID {
+			return true
+		}
+	}
+	return false
+}
+
+// Unlocked indica se os links memorial estão liberados em now (a espera
+// vencida vale mesmo antes do worker marcar unlocked)
+func (a *MemorialActivation) Unlocked(now time.Time) bool {
+	if a.Status == MemorialUnlocked {
+		return true
+	}
+	return a.Status == MemorialWaiting && a.UnlocksAt != nil && !a.UnlocksAt.After(now)
+}
+
 // PasswordResetToken representa um token de recuperação de senha
 type PasswordResetToken struct {
 	ID        string     `json:"id"`
@@ -306,6 +1560,57 @@ type PasswordResetToken struct {
 	CreatedAt time.Time  `json:"created_at"`
 }
 
+// CalendarFeed é o link privado do calendário ICS de um usuário
+// Apenas o hash do token é salvo; o link completo é mostrado uma única vez.
+type CalendarFeed struct {
+	UserID         string     `json:"-"`
+	TokenHash      string     `json:"-"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`
+}
+
+// CaptureToken é o token pessoal da captura pelo navegador (extensão ou
+// bookmarklet). Apenas o hash é salvo, como no CalendarFeed.
+type CaptureToken struct {
+	UserID     string     `json:"-"`
+	TokenHash  string     `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// ItemSource é a página de onde um item foi capturado
+type ItemSource struct {
+	ItemID     string    `json:"-"`
+	URL        string    `json:"url"`                  // Criptografado no banco
+	PageTitle  string    `json:"page_title,omitempty"` // Criptografado no banco
+	Via        string    `json:"via"`                  // Ex: "bookmarklet", "extension"
+	CapturedAt time.Time `json:"captured_at"`
+}
+
+// EmergencyCard é o cartão de emergência público de um usuário
+// Traz só os campos que o usuário preencheu, para socorristas lerem sem o
+// fluxo do guardião. Apenas o hash do token é salvo, como no CalendarFeed.
+type EmergencyCard struct {
+	UserID          string             `json:"-"`
+	TokenHash       string             `json:"-"`
+	Name            string             `json:"name,omitempty"`
+	BloodType       string             `json:"blood_type,omitempty"`
+	Contacts        []EmergencyContact `json:"contacts,omitempty"`
+	Medications     []string           `json:"medications,omitempty"`      // Medicamentos de uso contínuo/críticos
+	IncludeSchedule bool               `json:"include_schedule,omitempty"` // Mostra a agenda das rotinas pessoais
+	IncludeLegal    bool               `json:"include_legal,omitempty"`    // Mostra onde estão os documentos jurídicos
+	CreatedAt       time.Time          `json:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at"`
+	LastAccessedAt  *time.Time         `json:"last_accessed_at,omitempty"`
+}
+
+// EmergencyContact é um contato do cartão de emergência
+type EmergencyContact struct {
+	Name         string `json:"name"`
+	Relationship string `json:"relationship,omitempty"`
+	Phone        string `json:"phone"`
+}
+
 // EmergencyProtocol representa o estado do protocolo de emergência
 type EmergencyProtocol struct {
 	UserID          string     `json:"user_id"`
@@ -317,6 +1622,61 @@ type EmergencyProtocol struct {
 	NotifyGuardians bool       `json:"notify_guardians"` // Notificar outros guardiões
 }
 
+// EscalationChain é a ordem em que as pessoas de confiança são avisadas numa
+// emergência: a próxima só é chamada se a anterior não confirmar no prazo
+type EscalationChain struct {
+	UserID            string    `json:"-"`
+	GuardianIDs       []string  `json:"guardian_ids"`        // Em ordem de chamada
+	AckTimeoutMinutes int       `json:"ack_timeout_minutes"` // Prazo de cada pessoa para confirmar
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// EscalationStatus define o estado de um escalonamento
+type EscalationStatus string
+
+const (
+	EscalationActive       EscalationStatus = "active"       // Aguardando confirmação
+	EscalationAcknowledged EscalationStatus = "acknowledged" // Alguém confirmou
+	EscalationExhausted    EscalationStatus = "exhausted"    // A lista acabou sem confirmação
+	EscalationCancelled    EscalationStatus = "cancelled"    // Encerrado pelo dono
+)
+
+// EscalationStep é uma pessoa da lista num escalonamento
+type EscalationStep struct {
+	GuardianID   string     `json:"guardian_id"`
+	GuardianName string     `json:"guardian_name,omitempty"` // Preenchido na resposta (não é salvo)
+	AlertID      string     `json:"alert_id,omitempty"`      // Aviso enviado (GET /api/guardians/alerts)
+	NotifiedAt   *time.Time `json:"notified_at,omitempty"`
+	Skipped      bool       `json:"skipped,omitempty"` // Removida, desativada ou sem contato
+	AckTokenHash string     `json:"-"`                 // Hash do token do link de confirmação
+}
+
+// EmergencyEscalation é a caminhada pela lista de escalonamento depois que a
+// emergência foi acionada
+type EmergencyEscalation struct {
+	ID                string           `json:"id"`
+	UserID            string           `json:"-"`
+	Status            EscalationStatus `json:"status"`
+	Steps             []EscalationStep `json:"steps"`        // Cópia da lista no início
+	CurrentStep       int              `json:"current_step"` // Última pessoa chamada (-1 = nenhuma)
+	AckTimeoutMinutes int              `json:"ack_timeout_minutes"`
+	NextStepAt        *time.Time       `json:"next_step_at,omitempty"`    // Quando chamar a próxima (se ativo)
+	AcknowledgedBy    string           `json:"acknowledged_by,omitempty"` // ID do guardião que confirmou
+	AcknowledgedAt    *time.Time       `json:"acknowledged_at,omitempty"`
+	CreatedAt         time.Time        `json:"created_at"`
+	UpdatedAt         time.Time        `json:"updated_at"`
+}
+
+// StepByAckToken retorna a pessoa do token de confirmação (nil se nenhuma)
+func (e *EmergencyEscalation) StepByAckToken(tokenHash string) *EscalationStep {
+	for i := range e.Steps {
+		if e.Steps[i].AckTokenHash != "" && e.Steps[i].AckTokenHash == tokenHash {
+			return &e.Steps[i]
+		}
+	}
+	return nil
+}
+
 // SharedView representa a visualização compartilhada para um guardião
 type SharedView struct {
 	UserName     string        `json:"user_name"`
@@ -327,4 +1687,401 @@ type SharedView struct {
 	Message      string        `json:"message,omitempty"`   // Mensagem personalizada
 	LinkType     ShareLinkType `json:"link_type"`
 	AccessedAt   time.Time     `json:"accessed_at"`
+
+	// PassphraseHint é a dica do link para decifrar os itens selados
+	PassphraseHint string `json:"passphrase_hint,omitempty"`
+
+	// Search é a busca aplicada (?q=, ?category=); nil sem filtro
+	Search *ItemSearch `json:"search,omitempty"`
+
+	// Pets são os perfis dos pets entre os itens, para quem for cuidar deles
+	Pets []*SharedPet `json:"pets,omitempty"`
+
+	// DigitalLegacy são as contas online agrupadas pelo que fazer com elas
+	// (apenas em modo memorial)
+	DigitalLegacy []*DigitalLegacyGroup `json:"digital_legacy,omitempty"`
+}
+
+// SharedPet é um pet na seção própria das visões compartilhadas
+type SharedPet struct {
+	ItemID string `json:"item_id"`
+	*PetProfile
+}
+
+// DigitalLegacyGroup são as contas online com a mesma ação, no memorial
+type DigitalLegacyGroup struct {
+	Action   OnlineAccountAction    `json:"action"`
+	Accounts []*SharedOnlineAccount `json:"accounts"`
+}
+
+// SharedOnlineAccount é uma conta online na seção do legado digital
+type SharedOnlineAccount struct {
+	ItemID  string `json:"item_id"`
+	Title   string `json:"title"`
+	Content string `json:"content,omitempty"` // Instruções livres do item
+	*OnlineAccount
+}
+
+// ItemSearch resume a busca nos itens de um acesso compartilhado
+type ItemSearch struct {
+	Query    string `json:"q,omitempty"`
+	Category string `json:"category,omitempty"`
+	Total    int    `json:"total"` // Itens do acesso antes do filtro
+}
+
+// =============================================================================
+// WEBHOOKS (integrações de saída)
+// =============================================================================
+
+// WebhookEvent define os eventos enviados aos webhooks
+type WebhookEvent string
+
+const (
+	WebhookItemCreated        WebhookEvent = "item.created"        // Item criado na caixa
+	WebhookGuardianAdded      WebhookEvent = "guardian.added"      // Pessoa de confiança adicionada
+	WebhookEmergencyActivated WebhookEvent = "emergency.activated" // Primeiro acesso a um link de emergência
+	WebhookShareAccessed      WebhookEvent = "share.accessed"      // Link compartilhado acessado
+	WebhookPing               WebhookEvent = "ping"                // Teste manual (sempre entregue)
+)
+
+// WebhookEvents lista os eventos que o usuário pode assinar
+var WebhookEvents = []WebhookEvent{
+	WebhookItemCreated,
+	WebhookGuardianAdded,
+	WebhookEmergencyActivated,
+	WebhookShareAccessed,
+}
+
+// Webhook representa um endpoint externo configurado pelo usuário
+type Webhook struct {
+	ID             string         `json:"id"`
+	UserID         string         `json:"user_id"`
+	URL            string         `json:"url"`
+	Secret         string         `json:"-"` // Segredo HMAC (exibido apenas na criação)
+	Events         []WebhookEvent `json:"events"`
+	Description    string         `json:"description,omitempty"`
+	IsActive       bool           `json:"is_active"`
+	FailureCount   int            `json:"failure_count"` // Falhas consecutivas
+	LastDeliveryAt *time.Time     `json:"last_delivery_at,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+// Subscribes indica se o webhook deve receber o evento
+func (w *Webhook) Subscribes(event WebhookEvent) bool {
+	if event == WebhookPing {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveryStatus define o estado de uma entrega
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending WebhookDeliveryStatus = "pending" // Aguardando (re)tentativa
+	WebhookDeliverySuccess WebhookDeliveryStatus = "success" // Entregue (resposta 2xx)
+	WebhookDeliveryFailed  WebhookDeliveryStatus = "failed"  // Tentativas esgotadas
+)
+
+// WebhookDelivery registra o envio de um evento a um webhook
+type WebhookDelivery struct {
+	ID            string                `json:"id"`
+	WebhookID     string                `json:"webhook_id"`
+	UserID        string                `json:"-"`
+	Event         WebhookEvent          `json:"event"`
+	Payload       string                `json:"payload"` // Corpo JSON enviado
+	Status        WebhookDeliveryStatus `json:"status"`
+	Attempts      int                   `json:"attempts"`
+	ResponseCode  int                   `json:"response_code,omitempty"`
+	Error         string                `json:"error,omitempty"`
+	NextAttemptAt *time.Time            `json:"next_attempt_at,omitempty"`
+	CreatedAt     time.Time             `json:"created_at"`
+	DeliveredAt   *time.Time            `json:"delivered_at,omitempty"`
+}
+
+// =============================================================================
+// NOTIFICAÇÕES (Web Push)
+// =============================================================================
+
+// NotificationCategory agrupa notificações para permitir opt-out por tipo
+type NotificationCategory string
+
+const (
+	NotificationReminders      NotificationCategory = "reminders"       // Lembretes para manter a caixa em dia
+	NotificationGuardianAccess NotificationCategory = "guardian_access" // Alguém acessou informações compartilhadas
+	NotificationEmergency      NotificationCategory = "emergency"       // Eventos do protocolo de emergência
+	NotificationHousehold      NotificationCategory = "household"       // Convites e mudanças nas famílias
+)
+
+// NotificationCategories lista as categorias que o usuário pode desligar
+var NotificationCategories = []NotificationCategory{
+	NotificationReminders,
+	NotificationGuardianAccess,
+	NotificationEmergency,
+	NotificationHousehold,
+}
+
+// IsValidNotificationCategory verifica se a categoria existe
+func IsValidNotificationCategory(category NotificationCategory) bool {
+	for _, c := range NotificationCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// Notification é um aviso da central de notificações do usuário
+// Todo aviso fica na central; os canais externos (push, email, WhatsApp)
+// dependem da categoria e das preferências do usuário.
+type Notification struct {
+	ID        string               `json:"id"`
+	UserID    string               `json:"-"`
+	Category  NotificationCategory `json:"category"`
+	Title     string               `json:"title"`
+	Body      string               `json:"body"`
+	URL       string               `json:"url,omitempty"`
+	Channels  []string             `json:"channels"` // Canais externos em que foi entregue
+	ReadAt    *time.Time           `json:"read_at,omitempty"`
+	CreatedAt time.Time            `json:"created_at"`
+}
+
+// PushSubscription é a inscrição Web Push de um navegador/dispositivo
+// Endpoint é único: o mesmo navegador reinscrito substitui o registro anterior.
+type PushSubscription struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"-"`
+	Endpoint   string     `json:"-"`          // URL do serviço de push (contém token do dispositivo)
+	P256dh     string     `json:"-"`          // Chave pública do navegador (base64url)
+	Auth       string     `json:"-"`          // Segredo de autenticação (base64url)
+	UserAgent  string     `json:"user_agent"` // Identificação do dispositivo para o usuário
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// =============================================================================
+// FAMÍLIAS (caixas compartilhadas)
+// =============================================================================
+
+// Category é uma categoria da caixa definida pelo usuário
+// Os itens guardam o nome da categoria (renomear atualiza os itens).
+type Category struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"-"`
+	Name      string    `json:"name"`
+	Color     string    `json:"color"` // #RRGGBB
+	Icon      string    `json:"icon"`  // Emoji
+	Position  int       `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Household é uma família que compartilha uma caixa
+type Household struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	OwnerID   string    `json:"owner_id"` // Quem criou (não pode sair, apenas excluir)
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// HouseholdPermission define o que um membro pode fazer na caixa da família
+type HouseholdPermission string
+
+const (
+	HouseholdView   HouseholdPermission = "view"   // Apenas visualizar itens
+	HouseholdEdit   HouseholdPermission = "edit"   // Criar, editar e remover itens
+	HouseholdManage HouseholdPermission = "manage" // Editar itens e gerenciar membros
+)
+
+// IsValidHouseholdPermission verifica se a permissão existe
+func IsValidHouseholdPermission(p HouseholdPermission) bool {
+	return p == HouseholdView || p == HouseholdEdit || p == HouseholdManage
+}
+
+// HouseholdMemberStatus define o estado do convite
+type HouseholdMemberStatus string
+
+const (
+	HouseholdMemberPending HouseholdMemberStatus = "pending" // Convite ainda não aceito
+	HouseholdMemberActive  HouseholdMemberStatus = "active"
+)
+
+// HouseholdMember é a participação de um usuário em uma família
+type HouseholdMember struct {
+	HouseholdID string                `json:"household_id"`
+	UserID      string                `json:"user_id"`
+	Permission  HouseholdPermission   `json:"permission"`
+	Status      HouseholdMemberStatus `json:"status"`
+	InvitedBy   string                `json:"invited_by,omitempty"`
+	JoinedAt    *time.Time            `json:"joined_at,omitempty"`
+	CreatedAt   time.Time             `json:"created_at"`
+
+	// Preenchidos na listagem de membros
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+// IsActive indica se o membro já aceitou o convite
+func (m *HouseholdMember) IsActive() bool {
+	return m.Status == HouseholdMemberActive
+}
+
+// CanEdit indica se o membro pode criar/editar itens da família
+func (m *HouseholdMember) CanEdit() bool {
+	return m.IsActive() && (m.Permission == HouseholdEdit || m.Permission == HouseholdManage)
+}
+
+// CanManage indica se o membro pode convidar/remover membros
+func (m *HouseholdMember) CanManage() bool {
+	return m.IsActive() && m.Permission == HouseholdManage
+}
+
+// =============================================================================
+// RETENÇÃO DE DADOS
+// =============================================================================
+
+// RetentionClass é uma classe de dados com prazo de retenção próprio
+// Os prazos ficam em system_config (ver internal/retention).
+type RetentionClass string
+
+const (
+	RetentionAnalytics           RetentionClass = "analytics"             // analytics_events, analytics_daily e completeness_scores
+	RetentionAudit               RetentionClass = "audit"                 // audit_log de contas existentes (ou sem conta)
+	RetentionDeletedAccountAudit RetentionClass = "deleted_account_audit" // audit_log de contas já excluídas
+	RetentionShareAccesses       RetentionClass = "share_accesses"        // share_link_accesses
+	RetentionLoginHistory        RetentionClass = "login_history"         // login_history
+	RetentionWebhookDeliveries   RetentionClass = "webhook_deliveries"    // webhook_deliveries finalizadas
+	RetentionGuardianAlerts      RetentionClass = "guardian_alerts"       // guardian_alerts finalizados
+)
+
+// RetentionRun registra uma execução da retenção para uma classe
+type RetentionRun struct {
+	ID         string         `json:"id"`
+	Class      RetentionClass `json:"class"`
+	Days       int            `json:"days"`            // Prazo aplicado
+	Purged     int64          `json:"purged"`          // Registros removidos
+	Error      string         `json:"error,omitempty"` // Falha da execução (nada removido)
+	StartedAt  time.Time      `json:"started_at"`
+	DurationMS int64          `json:"duration_ms"`
+}
+
+// =============================================================================
+// PÁGINA DE STATUS
+// =============================================================================
+
+// IncidentSeverity é o impacto de um incidente na página de status
+type IncidentSeverity string
+
+const (
+	IncidentMinor       IncidentSeverity = "minor"       // Parte do sistema lenta ou instável
+	IncidentMajor       IncidentSeverity = "major"       // Sistema ou funcionalidade fora do ar
+	IncidentMaintenance IncidentSeverity = "maintenance" // Manutenção programada
+)
+
+// IncidentState é a fase de um incidente
+type IncidentState string
+
+const (
+	IncidentInvestigating IncidentState = "investigating"
+	IncidentIdentified    IncidentState = "identified"
+	IncidentMonitoring    IncidentState = "monitoring"
+	IncidentResolved      IncidentState = "resolved"
+)
+
+// StatusIncident é uma nota de incidente publicada pelos admins
+type StatusIncident struct {
+	ID         string           `json:"id"`
+	Title      string           `json:"title"`
+	Message    string           `json:"message"`
+	Severity   IncidentSeverity `json:"severity"`
+	State      IncidentState    `json:"state"`
+	Components []string         `json:"components,omitempty"` // Dependências afetadas (ex: email)
+	CreatedBy  string           `json:"-"`                    // Admin que abriu o incidente
+	CreatedAt  time.Time        `json:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+	ResolvedAt *time.Time       `json:"resolved_at,omitempty"`
+}
+
+// StatusUptimeDay são as verificações de uma dependência em um dia (UTC)
+type StatusUptimeDay struct {
+	Component string    `json:"component"`
+	Day       time.Time `json:"day"`
+	Checks    int       `json:"checks"`
+	Failures  int       `json:"failures"`
+}
+
+// =============================================================================
+// AUDITORIA E ATIVIDADE (painel admin)
+// =============================================================================
+
+// AuditEntry é um evento de auditoria gravado em audit_log
+// Só os eventos que alimentam a atividade do painel são gravados; os demais
+// ficam no log da aplicação (internal/security).
+type AuditEntry struct {
+	ID           string                 `json:"id"`
+	UserID       string                 `json:"user_id,omitempty"`
+	Action       string                 `json:"action"` // Tipo do evento (ex: REGISTER)
+	ResourceType string                 `json:"resource_type,omitempty"`
+	ResourceID   string                 `json:"resource_id,omitempty"`
+	IPAddress    string                 `json:"-"`
+	Details      map[string]interface{} `json:"details,omitempty"`
+	CreatedAt    time.Time              `json:"created_at"`
+}
+
+// ActivityType é um tipo de evento da atividade do painel admin
+type ActivityType string
+
+const (
+	ActivitySignup    ActivityType = "signup"    // Cadastro (auditoria)
+	ActivityDeletion  ActivityType = "deletion"  // Conta excluída pelo usuário ou pelo admin (auditoria)
+	ActivityEmergency ActivityType = "emergency" // Primeiro acesso a um link de emergência (auditoria)
+	ActivityFeedback  ActivityType = "feedback"  // Feedback enviado (auditoria)
+	ActivityExport    ActivityType = "export"    // Exportação dos dados (analytics)
+	ActivityGuide     ActivityType = "guide"     // Guia concluído (analytics)
+)
+
+// ActivityAuditActions liga os tipos da atividade às ações em audit_log
+var ActivityAuditActions = map[ActivityType]string{
+	ActivitySignup:    string(security.EventRegister),
+	ActivityDeletion:  string(security.EventAccountDeletion),
+	ActivityEmergency: string(security.EventEmergencyActivated),
+	ActivityFeedback:  string(security.EventFeedbackCreated),
+}
+
+// ActivityAnalyticsEvents liga os tipos da atividade aos eventos de analytics
+var ActivityAnalyticsEvents = map[ActivityType]AnalyticsEventType{
+	ActivityExport: EventExportData,
+	ActivityGuide:  EventCompleteGuide,
+}
+
+// IsValidActivityType verifica se o tipo de atividade existe
+func IsValidActivityType(t ActivityType) bool {
+	_, audit := ActivityAuditActions[t]
+	_, analytics := ActivityAnalyticsEvents[t]
+	return audit || analytics
+}
+
+// ActivityEntry é um evento da atividade (de audit_log ou de analytics_events)
+type ActivityEntry struct {
+	ID        string                 `json:"id"`
+	Type      ActivityType           `json:"type"`
+	Source    string                 `json:"source"` // "audit" ou "analytics"
+	UserID    string                 `json:"user_id,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// ActivityParams filtra a atividade do painel admin
+type ActivityParams struct {
+	Types []ActivityType // Vazio = todos
+	From  *time.Time     // Inclusivo
+	To    *time.Time     // Exclusivo
+	PaginationParams
 }
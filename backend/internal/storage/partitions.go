@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// =============================================================================
+// PARTIÇÕES MENSAIS (PostgreSQL)
+// =============================================================================
+// analytics_events e audit_log são particionadas por mês (created_at, ver a
+// migração 0034). As partições dos próximos meses são criadas com
+// antecedência e os meses que saíram do prazo de retenção (PurgeRetention)
+// são removidos inteiros (DROP TABLE), sem varrer a tabela com DELETE.
+
+// partitionedLogTables são as tabelas de log particionadas por mês
+var partitionedLogTables = []string{"analytics_events", "audit_log"}
+
+// logPartitionsAhead são os meses futuros que já ficam criados (um insert sem
+// partição falharia)
+const logPartitionsAhead = 2
+
+// logPartitionPattern reconhece os nomes das partições (ex: audit_log_y2025m01)
+var logPartitionPattern = regexp.MustCompile(`_y(\d{4})m(\d{2})$`)
+
+// logPartitionName retorna o nome da partição do mês
+func logPartitionName(table string, month time.Time) string {
+	return fmt.Sprintf("%s_y%04dm%02d", table, month.Year(), int(month.Month()))
+}
+
+// monthStart retorna o primeiro dia do mês de t (00:00, mesmo fuso)
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// logPartitionMonths retorna os meses cujas partições devem existir: o mês
+// atual e os próximos logPartitionsAhead
+func logPartitionMonths(now time.Time) []time.Time {
+	months := make([]time.Time, 0, logPartitionsAhead+1)
+	for i := 0; i <= logPartitionsAhead; i++ {
+		months = append(months, monthStart(now).AddDate(0, i, 0))
+	}
+	return months
+}
+
+// expiredLogPartitions filtra as partições da tabela cujo mês inteiro é mais
+// antigo que retentionDays. Nomes de outro formato (ou de outra tabela) e o
+// mês que cruza o limite ficam de fora.
+func expiredLogPartitions(table string, names []string, now time.Time, retentionDays int) []string {
+	cutoff := now.AddDate(0, 0, -retentionDays)
+
+	var expired []string
+	for _, name := range names {
+		match := logPartitionPattern.FindStringSubmatch(name)
+		if match == nil || name != table+match[0] {
+			continue
+		}
+		year, _ := strconv.Atoi(match[1])
+		month, _ := strconv.Atoi(match[2])
+		end := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, now.Location()).AddDate(0, 1, 0)
+		if !end.After(cutoff) {
+			expired = append(expired, name)
+		}
+	}
+	return expired
+}
+
+// isPartitioned indica se a tabela já é particionada (a migração 0034 pode
+// ainda não ter sido aplicada, ou ter sido revertida)
+func (s *PostgresStore) isPartitioned(table string) (bool, error) {
+	var kind string
+	err := s.db.QueryRow(`SELECT relkind FROM pg_class WHERE oid = to_regclass($1)`, table).Scan(&kind)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return kind == "p", nil
+}
+
+// dbNow retorna a hora local do banco, a mesma usada pelo DEFAULT de
+// created_at (TIMESTAMP sem fuso)
+func (s *PostgresStore) dbNow() (time.Time, error) {
+	var now time.Time
+	err := s.db.QueryRow(`SELECT LOCALTIMESTAMP`).Scan(&now)
+	return now, err
+}
+
+// ensureLogPartitions cria as partições do mês atual e dos próximos
+// logPartitionsAhead meses
+func (s *PostgresStore) ensureLogPartitions() error {
+	now, err := s.dbNow()
+	if err != nil {
+		return err
+	}
+
+	for _, table := range partitionedLogTables {
+		partitioned, err := s.isPartitioned(table)
+		if err != nil {
+			return err
+		}
+		if !partitioned {
+			continue
+		}
+
+		for _, month := range logPartitionMonths(now) {
+			_, err := s.db.Exec(fmt.Sprintf(
+				`CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s')`,
+				logPartitionName(table, month), table,
+				month.Format("2006-01-02"), month.AddDate(0, 1, 0).Format("2006-01-02"),
+			))
+			if err != nil {
+				return fmt.Errorf("erro ao criar partição de %s: %w", table, err)
+			}
+		}
+	}
+	return nil
+}
+
+// dropExpiredLogPartitions remove as partições da tabela cujo mês inteiro é
+// mais antigo que retentionDays e retorna quantos registros saíram com elas.
+// O mês que cruza o limite fica para o DELETE da retenção. Com onlyEmpty, só
+// saem partições já vazias (audit_log tem mais de um prazo por partição).
+func (s *PostgresStore) dropExpiredLogPartitions(table string, retentionDays int, onlyEmpty bool) (int64, error) {
+	now, err := s.dbNow()
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT c.relname FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = to_regclass($1)
+	`, table)
+	if err != nil {
+		return 0, err
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var purged int64
+	for _, name := range expiredLogPartitions(table, names, now, retentionDays) {
+		var count int64
+		if err := s.db.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM %s`, name)).Scan(&count); err != nil {
+			return purged, err
+		}
+		if onlyEmpty && count > 0 {
+			continue
+		}
+		if _, err := s.db.Exec(fmt.Sprintf(`DROP TABLE IF EXISTS %s`, name)); err != nil {
+			return purged, fmt.Errorf("erro ao remover partição %s: %w", name, err)
+		}
+		purged += count
+	}
+	return purged, nil
+}
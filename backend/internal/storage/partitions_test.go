@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestLogPartitionMonths(t *testing.T) {
+	now := time.Date(2025, 11, 20, 15, 30, 0, 0, time.UTC)
+
+	got := logPartitionMonths(now)
+	want := []time.Time{
+		time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("meses inesperados: %v", got)
+	}
+	if name := logPartitionName("audit_log", got[2]); name != "audit_log_y2026m01" {
+		t.Fatalf("nome inesperado: %s", name)
+	}
+}
+
+func TestExpiredLogPartitions(t *testing.T) {
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC) // 90 dias: limite em 17/03
+
+	names := []string{
+		"analytics_events_y2025m01",
+		"analytics_events_y2025m02",
+		"analytics_events_y2025m03", // Cruza o limite: fica para o DELETE
+		"analytics_events_y2025m06",
+		"audit_log_y2024m01",             // Outra tabela
+		"analytics_events_old_y2024m01",  // Outro formato
+		"analytics_events_unpartitioned", // Sem mês
+	}
+	got := expiredLogPartitions("analytics_events", names, now, 90)
+	want := []string{"analytics_events_y2025m01", "analytics_events_y2025m02"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("partições vencidas inesperadas: %v", got)
+	}
+
+	if got := expiredLogPartitions("analytics_events", names, now, 3650); len(got) != 0 {
+		t.Fatalf("nada deveria vencer em 10 anos: %v", got)
+	}
+}
+
+// partitionExists indica se a partição existe no schema do teste
+func partitionExists(t *testing.T, store *PostgresStore, name string) bool {
+	t.Helper()
+	var exists bool
+	if err := store.db.QueryRow(`SELECT to_regclass($1) IS NOT NULL`, name).Scan(&exists); err != nil {
+		t.Fatalf("erro ao consultar partição: %v", err)
+	}
+	return exists
+}
+
+// createOldPartition cria a partição de um mês passado
+func createOldPartition(t *testing.T, store *PostgresStore, table string, month time.Time) string {
+	t.Helper()
+	name := logPartitionName(table, month)
+	_, err := store.db.Exec(`CREATE TABLE ` + name + ` PARTITION OF ` + table +
+		` FOR VALUES FROM ('` + month.Format("2006-01-02") + `') TO ('` + month.AddDate(0, 1, 0).Format("2006-01-02") + `')`)
+	if err != nil {
+		t.Fatalf("erro ao criar partição %s: %v", name, err)
+	}
+	return name
+}
+
+func TestPostgresLogPartitionsAhead(t *testing.T) {
+	store := newTestPostgres(t)
+	now, err := store.dbNow()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	months := logPartitionMonths(now)
+	for _, table := range partitionedLogTables {
+		for _, month := range months {
+			if name := logPartitionName(table, month); !partitionExists(t, store, name) {
+				t.Fatalf("partição %s não criada", name)
+			}
+		}
+	}
+
+	// A limpeza diária recria as partições que faltam
+	last := logPartitionName("audit_log", months[len(months)-1])
+	if _, err := store.db.Exec(`DROP TABLE ` + last); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CleanupExpiredRecords(); err != nil {
+		t.Fatal(err)
+	}
+	if !partitionExists(t, store, last) {
+		t.Fatalf("partição %s não recriada pela limpeza", last)
+	}
+}
+
+func TestPostgresPurgeRetentionDropsPartitions(t *testing.T) {
+	store := newTestPostgres(t)
+	now, err := store.dbNow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := monthStart(now).AddDate(-2, 0, 0)
+
+	// analytics_events: o mês inteiro fora do prazo sai com DROP TABLE
+	oldEvents := createOldPartition(t, store, "analytics_events", old)
+	_, err = store.TrackEvents([]*AnalyticsEvent{
+		{ID: uuid.New().String(), EventType: EventPageView, CreatedAt: old.Add(time.Hour)},
+		{ID: uuid.New().String(), EventType: EventPageView, CreatedAt: old.Add(2 * time.Hour)},
+		{ID: uuid.New().String(), EventType: EventPageView, CreatedAt: now},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	purged, err := store.PurgeRetention(RetentionAnalytics, 365)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged < 2 || partitionExists(t, store, oldEvents) {
+		t.Fatalf("partição antiga deveria sair (purged=%d)", purged)
+	}
+	var remaining int
+	store.db.QueryRow(`SELECT COUNT(*) FROM analytics_events`).Scan(&remaining)
+	if remaining != 1 {
+		t.Fatalf("o evento do mês atual deveria ficar: %d", remaining)
+	}
+
+	// audit_log: as duas classes dividem a partição; só sai quando vazia
+	oldAudit := createOldPartition(t, store, "audit_log", old)
+	for _, userID := range []interface{}{nil, "usr_removido"} {
+		if _, err := store.db.Exec(`INSERT INTO audit_log (user_id, action, created_at) VALUES ($1, 'TEST', $2)`, userID, old.Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := store.PurgeRetention(RetentionAudit, 365); err != nil {
+		t.Fatal(err)
+	}
+	if !partitionExists(t, store, oldAudit) {
+		t.Fatal("a auditoria de conta excluída ainda está no prazo; a partição deveria ficar")
+	}
+	if _, err := store.PurgeRetention(RetentionDeletedAccountAudit, 365); err != nil {
+		t.Fatal(err)
+	}
+	if partitionExists(t, store, oldAudit) {
+		t.Fatal("a partição vazia deveria sair")
+	}
+}
+
+func TestPostgresTrackEventsDeduplicatesClientEvents(t *testing.T) {
+	store := newTestPostgres(t)
+	user := createTestUser(t, store, "maria@example.com")
+	now, err := store.dbNow()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event := func(clientEventID string, at time.Time) *AnalyticsEvent {
+		return &AnalyticsEvent{ID: uuid.New().String(), UserID: user.ID, EventType: EventPageView, ClientEventID: clientEventID, CreatedAt: at}
+	}
+
+	tracked, err := store.TrackEvents([]*AnalyticsEvent{event("c1", now), event("c2", now), event("c2", now)})
+	if err != nil || tracked != 2 {
+		t.Fatalf("lote deveria gravar 2 eventos: %d %v", tracked, err)
+	}
+
+	// Reenvio sem occurred_at: created_at muda, o evento continua duplicado
+	tracked, err = store.TrackEvents([]*AnalyticsEvent{event("c1", now.Add(time.Hour)), event("c3", now.Add(time.Hour))})
+	if err != nil || tracked != 1 {
+		t.Fatalf("reenvio deveria gravar só o novo: %d %v", tracked, err)
+	}
+}
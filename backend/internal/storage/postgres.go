@@ -17,15 +17,19 @@
 package storage
 
 import (
+	"context"
 	"crypto/rand"
 	"database/sql"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
+	"log"
+	"strconv"
 	"strings"
 	"time"
 
+	"famli/internal/ids"
 	"famli/internal/security"
 
 	"github.com/lib/pq"
@@ -40,20 +44,15 @@ type PostgresStore struct {
 
 // NewPostgresStore cria uma nova conexão com PostgreSQL
 // Inicializa também o encryptor para dados sensíveis
-func NewPostgresStore(databaseURL string) (*PostgresStore, error) {
-	db, err := sql.Open("postgres", databaseURL)
+//
+// Parâmetros:
+//   - databaseURL: conexão (DATABASE_URL)
+//   - encryptionKey: chave dos dados sensíveis (ENCRYPTION_KEY)
+//   - encryptionSalt: salt em base64 (ENCRYPTION_SALT); vazio = salt gravado no banco
+func NewPostgresStore(databaseURL, encryptionKey, encryptionSalt string) (*PostgresStore, error) {
+	db, err := openPostgres(databaseURL)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao conectar ao PostgreSQL: %w", err)
-	}
-
-	// Configurar pool de conexões para performance
-	db.SetMaxOpenConns(25)                 // Máximo de conexões abertas
-	db.SetMaxIdleConns(5)                  // Conexões ociosas no pool
-	db.SetConnMaxLifetime(5 * time.Minute) // Tempo de vida da conexão
-
-	// Testar conexão
-	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("erro ao pingar PostgreSQL: %w", err)
+		return nil, err
 	}
 
 	store := &PostgresStore{
@@ -64,18 +63,20 @@ func NewPostgresStore(databaseURL string) (*PostgresStore, error) {
 	if err := store.migrate(); err != nil {
 		return nil, fmt.Errorf("erro nas migrações: %w", err)
 	}
-
-	// Inicializar encryptor para dados sensíveis
-	encryptionKey := os.Getenv("ENCRYPTION_KEY")
-	if encryptionKey == "" {
-		encryptionKey = os.Getenv("JWT_SECRET") // Fallback para compatibilidade
+	if err := store.ensureLogPartitions(); err != nil {
+		return nil, err
+	}
+	backfilled, err := store.backfillGuardianAccessTokens()
+	if err != nil {
+		return nil, err
 	}
-	if encryptionKey == "" {
-		encryptionKey = "famli-dev-encryption-key-32chars!" // Apenas para desenvolvimento
-		// Aviso será logado pelo main.go
+	if backfilled > 0 {
+		log.Printf("[STORAGE] Tokens de acesso gerados para %d guardiões", backfilled)
 	}
 
-	salt, err := store.getOrCreateEncryptionSalt()
+	// Inicializar encryptor para dados sensíveis
+	// (o fallback para JWT_SECRET é aplicado pelo pacote config)
+	salt, err := store.getOrCreateEncryptionSalt(encryptionSalt)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao obter salt de criptografia: %w", err)
 	}
@@ -89,294 +90,35 @@ func NewPostgresStore(databaseURL string) (*PostgresStore, error) {
 	return store, nil
 }
 
-// migrate executa as migrações do banco
-func (s *PostgresStore) migrate() error {
-	migrations := []string{
-		// Extensão UUID (ignora erro se não houver privilégio)
-		`DO $$
-		BEGIN
-			CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
-		EXCEPTION
-			WHEN insufficient_privilege THEN
-				RAISE NOTICE 'uuid-ossp extension not available';
-		END
-		$$;`,
-
-		// Tabela users
-		`CREATE TABLE IF NOT EXISTS users (
-			id VARCHAR(50) PRIMARY KEY,
-			email VARCHAR(255) NOT NULL UNIQUE,
-			name VARCHAR(255),
-			password VARCHAR(255) NOT NULL,
-			terms_accepted BOOLEAN DEFAULT FALSE,
-			terms_accepted_at TIMESTAMP,
-			locale VARCHAR(10) DEFAULT 'pt-BR',
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)`,
-		// Migration: adicionar coluna locale se não existir
-		`DO $$ 
-		BEGIN 
-			IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
-				WHERE table_name = 'users' AND column_name = 'locale') 
-			THEN 
-				ALTER TABLE users ADD COLUMN locale VARCHAR(10) DEFAULT 'pt-BR';
-			END IF;
-		END $$`,
-
-		// Configuração do sistema (ex: salt de criptografia)
-		`CREATE TABLE IF NOT EXISTS system_config (
-			key VARCHAR(100) PRIMARY KEY,
-			value TEXT NOT NULL,
-			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)`,
-
-		// Idempotência (evita criação duplicada por retries)
-		`CREATE TABLE IF NOT EXISTS idempotency_keys (
-			user_id VARCHAR(50) NOT NULL,
-			key VARCHAR(120) NOT NULL,
-			resource_type VARCHAR(50) NOT NULL,
-			resource_id VARCHAR(50) NOT NULL,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			PRIMARY KEY (user_id, key, resource_type)
-		)`,
-		`CREATE INDEX IF NOT EXISTS idx_idempotency_created ON idempotency_keys(created_at DESC)`,
-
-		// Tabela box_items (com limite de 10KB para content)
-		`CREATE TABLE IF NOT EXISTS box_items (
-			id VARCHAR(50) PRIMARY KEY,
-			user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-			type VARCHAR(50) NOT NULL DEFAULT 'info',
-			title VARCHAR(512) NOT NULL,
-			content VARCHAR(10000),
-			category VARCHAR(50),
-			recipient VARCHAR(512),
-			is_important BOOLEAN DEFAULT FALSE,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)`,
-
-		// Tabela guardians (notas limitadas a 1KB)
-		`CREATE TABLE IF NOT EXISTS guardians (
-			id VARCHAR(50) PRIMARY KEY,
-			user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-			name VARCHAR(512) NOT NULL,
-			email VARCHAR(512),
-			phone VARCHAR(128),
-			relationship VARCHAR(255),
-			role VARCHAR(20) DEFAULT 'viewer',
-			notes VARCHAR(512),
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)`,
-
-		// Tabela guide_progress
-		`CREATE TABLE IF NOT EXISTS guide_progress (
-			user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-			card_id VARCHAR(50) NOT NULL,
-			status VARCHAR(50) NOT NULL DEFAULT 'pending',
-			completed_at TIMESTAMP,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			PRIMARY KEY (user_id, card_id)
-		)`,
-
-		// Tabela settings
-		`CREATE TABLE IF NOT EXISTS settings (
-			user_id VARCHAR(50) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
-			emergency_protocol_enabled BOOLEAN DEFAULT FALSE,
-			notifications_enabled BOOLEAN DEFAULT TRUE,
-			theme VARCHAR(20) DEFAULT 'light',
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)`,
-
-		// =======================================================================
-		// ÍNDICES PARA PERFORMANCE
-		// =======================================================================
-		// Colunas para Social Auth (Google, Apple)
-		`ALTER TABLE users ADD COLUMN IF NOT EXISTS provider VARCHAR(50) DEFAULT 'email'`,
-		`ALTER TABLE users ADD COLUMN IF NOT EXISTS provider_id VARCHAR(255)`,
-		`ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_url TEXT`,
-
-		// Índices de usuários
-		`CREATE INDEX IF NOT EXISTS idx_users_email ON users(LOWER(email))`,
-		`CREATE INDEX IF NOT EXISTS idx_users_created_at ON users(created_at DESC)`,
-		`CREATE INDEX IF NOT EXISTS idx_users_provider ON users(provider, provider_id) WHERE provider_id IS NOT NULL`,
-
-		// Índices de box_items (performance em listagens e filtros)
-		`CREATE INDEX IF NOT EXISTS idx_box_items_user ON box_items(user_id)`,
-		`CREATE INDEX IF NOT EXISTS idx_box_items_user_type ON box_items(user_id, type)`,
-		`CREATE INDEX IF NOT EXISTS idx_box_items_user_created ON box_items(user_id, created_at DESC)`,
-		`CREATE INDEX IF NOT EXISTS idx_box_items_important ON box_items(user_id, is_important) WHERE is_important = TRUE`,
-
-		// Índices de guardians
-		`CREATE INDEX IF NOT EXISTS idx_guardians_user ON guardians(user_id)`,
-
-		// Migrações: Compartilhamento integrado
-		`ALTER TABLE box_items ADD COLUMN IF NOT EXISTS is_shared BOOLEAN DEFAULT FALSE`,
-		`ALTER TABLE box_items ADD COLUMN IF NOT EXISTS guardian_ids TEXT[]`,
-		`ALTER TABLE guardians ADD COLUMN IF NOT EXISTS access_token VARCHAR(100)`,
-		`ALTER TABLE guardians ADD COLUMN IF NOT EXISTS access_pin VARCHAR(255)`,
-		`ALTER TABLE guardians ADD COLUMN IF NOT EXISTS access_type VARCHAR(20) DEFAULT 'normal'`,
-		`CREATE INDEX IF NOT EXISTS idx_guardians_token ON guardians(access_token) WHERE access_token IS NOT NULL`,
-		`CREATE INDEX IF NOT EXISTS idx_box_items_shared ON box_items(user_id, is_shared) WHERE is_shared = TRUE`,
-		`CREATE INDEX IF NOT EXISTS idx_box_items_guardian_ids ON box_items USING GIN (guardian_ids)`,
-		`CREATE INDEX IF NOT EXISTS idx_box_items_user_updated ON box_items(user_id, updated_at DESC)`,
-
-		// Índices de guide_progress (para verificar progresso rapidamente)
-		`CREATE INDEX IF NOT EXISTS idx_guide_progress_user ON guide_progress(user_id)`,
-
-		// =======================================================================
-		// AUDITORIA E SEGURANÇA
-		// =======================================================================
-		// Tabela de auditoria para rastrear ações sensíveis (LGPD)
-		// Removido user_agent para economizar espaço no banco
-		`CREATE TABLE IF NOT EXISTS audit_log (
-			id SERIAL PRIMARY KEY,
-			user_id VARCHAR(50),
-			action VARCHAR(100) NOT NULL,
-			resource_type VARCHAR(50),
-			resource_id VARCHAR(50),
-			ip_address VARCHAR(45),
-			details JSONB,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)`,
-		`CREATE INDEX IF NOT EXISTS idx_audit_user ON audit_log(user_id)`,
-		`CREATE INDEX IF NOT EXISTS idx_audit_action ON audit_log(action)`,
-		`CREATE INDEX IF NOT EXISTS idx_audit_created ON audit_log(created_at DESC)`,
-
-		// Tabela para tokens de exclusão (confirmação de exclusão de conta)
-		`CREATE TABLE IF NOT EXISTS deletion_tokens (
-			id VARCHAR(100) PRIMARY KEY,
-			user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-			expires_at TIMESTAMP NOT NULL,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)`,
-		`CREATE INDEX IF NOT EXISTS idx_deletion_tokens_user ON deletion_tokens(user_id)`,
-		`CREATE INDEX IF NOT EXISTS idx_deletion_tokens_expires ON deletion_tokens(expires_at)`,
-
-		// =======================================================================
-		// FEEDBACK
-		// =======================================================================
-		// Feedbacks com limites de tamanho para economizar espaço
-		`CREATE TABLE IF NOT EXISTS feedbacks (
-			id VARCHAR(50) PRIMARY KEY,
-			user_id VARCHAR(50) REFERENCES users(id) ON DELETE SET NULL,
-			user_email VARCHAR(255),
-			type VARCHAR(50) NOT NULL DEFAULT 'suggestion',
-			message VARCHAR(2000) NOT NULL,
-			page VARCHAR(100),
-			status VARCHAR(50) NOT NULL DEFAULT 'pending',
-			admin_note VARCHAR(500),
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)`,
-		`CREATE INDEX IF NOT EXISTS idx_feedbacks_user ON feedbacks(user_id)`,
-		`CREATE INDEX IF NOT EXISTS idx_feedbacks_status ON feedbacks(status)`,
-		`CREATE INDEX IF NOT EXISTS idx_feedbacks_created ON feedbacks(created_at DESC)`,
-		`ALTER TABLE feedbacks ADD COLUMN IF NOT EXISTS user_agent VARCHAR(255)`,
-
-		// =======================================================================
-		// ANALYTICS (com limpeza automática de eventos antigos)
-		// =======================================================================
-		`CREATE TABLE IF NOT EXISTS analytics_events (
-			id VARCHAR(50) PRIMARY KEY,
-			user_id VARCHAR(50),
-			event_type VARCHAR(50) NOT NULL,
-			page VARCHAR(100),
-			details JSONB,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)`,
-		`CREATE INDEX IF NOT EXISTS idx_analytics_user ON analytics_events(user_id)`,
-		`CREATE INDEX IF NOT EXISTS idx_analytics_type ON analytics_events(event_type)`,
-		`CREATE INDEX IF NOT EXISTS idx_analytics_created ON analytics_events(created_at DESC)`,
-		// Nota: Índice parcial com CURRENT_DATE não é permitido (não-IMMUTABLE)
-		// Consultas usam WHERE created_at >= date_trunc('day', CURRENT_TIMESTAMP) no runtime
-
-		// =======================================================================
-		// COMPARTILHAMENTO E ACESSO (Links para Guardiões)
-		// =======================================================================
-		`CREATE TABLE IF NOT EXISTS share_links (
-			id VARCHAR(50) PRIMARY KEY,
-			user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-			guardian_id VARCHAR(50) REFERENCES guardians(id) ON DELETE SET NULL,
-			token VARCHAR(100) NOT NULL UNIQUE,
-			type VARCHAR(20) NOT NULL DEFAULT 'normal',
-			name VARCHAR(255) NOT NULL,
-			pin_hash VARCHAR(255),
-			categories TEXT[],
-			expires_at TIMESTAMP,
-			max_uses INT DEFAULT 0,
-			usage_count INT DEFAULT 0,
-			last_used_at TIMESTAMP,
-			is_active BOOLEAN DEFAULT TRUE,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)`,
-		`CREATE INDEX IF NOT EXISTS idx_share_links_user ON share_links(user_id)`,
-		`CREATE INDEX IF NOT EXISTS idx_share_links_token ON share_links(token) WHERE is_active = TRUE`,
-		`CREATE INDEX IF NOT EXISTS idx_share_links_guardian ON share_links(guardian_id)`,
-		`ALTER TABLE share_links ADD COLUMN IF NOT EXISTS guardian_ids TEXT[]`,
-
-		// Registro de acessos aos links
-		`CREATE TABLE IF NOT EXISTS share_link_accesses (
-			id VARCHAR(50) PRIMARY KEY,
-			share_link_id VARCHAR(50) NOT NULL REFERENCES share_links(id) ON DELETE CASCADE,
-			ip_address VARCHAR(45),
-			user_agent VARCHAR(500),
-			accessed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)`,
-		`CREATE INDEX IF NOT EXISTS idx_share_accesses_link ON share_link_accesses(share_link_id)`,
-
-		// Ajustes de tamanho para campos curtos (com espaço para criptografia)
-		`ALTER TABLE box_items ALTER COLUMN title TYPE VARCHAR(512)`,
-		`ALTER TABLE box_items ALTER COLUMN recipient TYPE VARCHAR(512)`,
-		`ALTER TABLE guardians ALTER COLUMN name TYPE VARCHAR(512)`,
-		`ALTER TABLE guardians ALTER COLUMN email TYPE VARCHAR(512)`,
-		`ALTER TABLE guardians ALTER COLUMN phone TYPE VARCHAR(128)`,
-		`ALTER TABLE guardians ALTER COLUMN relationship TYPE VARCHAR(255)`,
-		`ALTER TABLE guardians ALTER COLUMN notes TYPE VARCHAR(512)`,
-		`ALTER TABLE share_links ALTER COLUMN name TYPE VARCHAR(255)`,
-		`ALTER TABLE feedbacks ALTER COLUMN page TYPE VARCHAR(255)`,
-
-		// =======================================================================
-		// RECUPERAÇÃO DE SENHA
-		// =======================================================================
-		`CREATE TABLE IF NOT EXISTS password_reset_tokens (
-			id VARCHAR(50) PRIMARY KEY,
-			user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-			token_hash VARCHAR(255) NOT NULL,
-			expires_at TIMESTAMP NOT NULL,
-			used_at TIMESTAMP,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)`,
-		`CREATE INDEX IF NOT EXISTS idx_password_reset_user ON password_reset_tokens(user_id)`,
-		`CREATE INDEX IF NOT EXISTS idx_password_reset_expires ON password_reset_tokens(expires_at)`,
-
-		// =======================================================================
-		// PROTOCOLO DE EMERGÊNCIA
-		// =======================================================================
-		`CREATE TABLE IF NOT EXISTS emergency_protocols (
-			user_id VARCHAR(50) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
-			is_active BOOLEAN DEFAULT FALSE,
-			activated_at TIMESTAMP,
-			activated_by VARCHAR(50),
-			deactivated_at TIMESTAMP,
-			reason VARCHAR(500),
-			notify_guardians BOOLEAN DEFAULT TRUE,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)`,
-	}
-
-	for _, migration := range migrations {
-		if _, err := s.db.Exec(migration); err != nil {
-			return fmt.Errorf("erro na migração: %w\nSQL: %s", err, migration)
-		}
+// openPostgres abre o pool de conexões e valida a conexão
+func openPostgres(databaseURL string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao conectar ao PostgreSQL: %w", err)
 	}
 
-	return nil
+	// Configurar pool de conexões para performance
+	db.SetMaxOpenConns(25)                 // Máximo de conexões abertas
+	db.SetMaxIdleConns(5)                  // Conexões ociosas no pool
+	db.SetConnMaxLifetime(5 * time.Minute) // Tempo de vida da conexão
+
+	// Testar conexão
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("erro ao pingar PostgreSQL: %w", err)
+	}
+
+	return db, nil
+}
+
+// migrate aplica as migrações versionadas pendentes (ver migrations.go)
+func (s *PostgresStore) migrate() error {
+	migrator, err := NewMigrator(s.db)
+	if err != nil {
+		return err
+	}
+	_, err = migrator.Up()
+	return err
 }
 
 // Close fecha a conexão com o banco
@@ -384,23 +126,23 @@ func (s *PostgresStore) Close() error {
 	return s.db.Close()
 }
 
-// CleanupOldLogs remove logs e analytics antigos para economizar espaço
-// Deve ser chamado periodicamente (ex: diariamente)
-func (s *PostgresStore) CleanupOldLogs(retentionDays int) error {
-	if retentionDays < 7 {
-		retentionDays = 7 // Mínimo de 7 dias
+// CheckHealth testa a conexão e compara as migrações aplicadas com as do binário
+func (s *PostgresStore) CheckHealth(ctx context.Context) (*MigrationSummary, error) {
+	if err := s.db.PingContext(ctx); err != nil {
+		return nil, fmt.Errorf("erro ao conectar ao PostgreSQL: %w", err)
 	}
+	return summarizeMigrations(ctx, s.db)
+}
 
-	queries := []string{
-		// Limpar audit_log antigos (manter últimos N dias)
-		fmt.Sprintf(`DELETE FROM audit_log WHERE created_at < NOW() - INTERVAL '%d days'`, retentionDays),
-
-		// Limpar analytics_events antigos
-		fmt.Sprintf(`DELETE FROM analytics_events WHERE created_at < NOW() - INTERVAL '%d days'`, retentionDays),
-
-		// Limpar acessos de links compartilhados antigos
-		fmt.Sprintf(`DELETE FROM share_link_accesses WHERE accessed_at < NOW() - INTERVAL '%d days'`, retentionDays),
+// CleanupExpiredRecords cria as partições dos próximos meses e remove tokens
+// vencidos. Deve ser chamado periodicamente (ex: diariamente); os logs saem
+// pela retenção por classe (PurgeRetention).
+func (s *PostgresStore) CleanupExpiredRecords() error {
+	if err := s.ensureLogPartitions(); err != nil {
+		return fmt.Errorf("cleanup error: %w", err)
+	}
 
+	queries := []string{
 		// Limpar deletion_tokens expirados
 		`DELETE FROM deletion_tokens WHERE expires_at < NOW()`,
 
@@ -417,6 +159,381 @@ func (s *PostgresStore) CleanupOldLogs(retentionDays int) error {
 	return nil
 }
 
+// auditOfExistingAccount filtra audit_log pelas contas que ainda existem (ou
+// registros sem conta); a negação separa a auditoria de contas excluídas
+const auditOfExistingAccount = `(user_id IS NULL OR EXISTS (SELECT 1 FROM users u WHERE u.id = audit_log.user_id))`
+
+// PurgeRetention remove os registros da classe mais antigos que days dias
+//
+// analytics_events perde os meses inteiros fora do prazo (DROP TABLE) e o
+// DELETE só alcança o mês que cruza o limite. Em audit_log as duas classes
+// dividem as partições: o DELETE vem primeiro e só saem meses já vazios.
+func (s *PostgresStore) PurgeRetention(class RetentionClass, days int) (int64, error) {
+	var purged int64
+	var queries []string
+	interval := fmt.Sprintf(`NOW() - INTERVAL '%d days'`, days)
+
+	switch class {
+	case RetentionAnalytics:
+		dropped, err := s.dropExpiredLogPartitions("analytics_events", days, false)
+		if err != nil {
+			return dropped, fmt.Errorf("retention error: %w", err)
+		}
+		purged = dropped
+		queries = []string{
+			`DELETE FROM analytics_events WHERE created_at < ` + interval,
+			`DELETE FROM analytics_client_events WHERE created_at < ` + interval,
+			// Rollups diários (eventos e usuários do dia vão em cascata)
+			fmt.Sprintf(`DELETE FROM analytics_daily WHERE day < CURRENT_DATE - %d`, days),
+			fmt.Sprintf(`DELETE FROM completeness_scores WHERE day < CURRENT_DATE - %d`, days),
+		}
+	case RetentionAudit:
+		queries = []string{`DELETE FROM audit_log WHERE created_at < ` + interval + ` AND ` + auditOfExistingAccount}
+	case RetentionDeletedAccountAudit:
+		queries = []string{`DELETE FROM audit_log WHERE created_at < ` + interval + ` AND NOT ` + auditOfExistingAccount}
+	case RetentionShareAccesses:
+		queries = []string{`DELETE FROM share_link_accesses WHERE accessed_at < ` + interval}
+	case RetentionLoginHistory:
+		queries = []string{`DELETE FROM login_history WHERE created_at < ` + interval}
+	case RetentionWebhookDeliveries:
+		queries = []string{`DELETE FROM webhook_deliveries WHERE status <> 'pending' AND created_at < ` + interval}
+	case RetentionGuardianAlerts:
+		queries = []string{`DELETE FROM guardian_alerts WHERE status <> 'pending' AND created_at < ` + interval}
+	default:
+		return 0, ErrInvalidData
+	}
+
+	for _, query := range queries {
+		result, err := s.db.Exec(query)
+		if err != nil {
+			return purged, fmt.Errorf("retention error: %w", err)
+		}
+		affected, _ := result.RowsAffected()
+		purged += affected
+	}
+
+	if class == RetentionAudit || class == RetentionDeletedAccountAudit {
+		if _, err := s.dropExpiredLogPartitions("audit_log", days, true); err != nil {
+			return purged, fmt.Errorf("retention error: %w", err)
+		}
+	}
+	return purged, nil
+}
+
+// CreateRetentionRun registra uma execução da retenção
+func (s *PostgresStore) CreateRetentionRun(run *RetentionRun) error {
+	if run.ID == "" {
+		run.ID = ids.New(ids.RetentionRun)
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO retention_runs (id, class, days, purged, error, started_at, duration_ms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, run.ID, run.Class, run.Days, run.Purged, nullString(run.Error), run.StartedAt, run.DurationMS)
+	return err
+}
+
+// ListRetentionRuns lista as execuções da retenção, mais recentes primeiro
+func (s *PostgresStore) ListRetentionRuns(limit int) ([]*RetentionRun, error) {
+	rows, err := s.db.Query(`
+		SELECT id, class, days, purged, error, started_at, duration_ms
+		FROM retention_runs
+		ORDER BY started_at DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := make([]*RetentionRun, 0)
+	for rows.Next() {
+		var run RetentionRun
+		var runError sql.NullString
+		if err := rows.Scan(&run.ID, &run.Class, &run.Days, &run.Purged, &runError, &run.StartedAt, &run.DurationMS); err != nil {
+			return nil, err
+		}
+		run.Error = runError.String
+		runs = append(runs, &run)
+	}
+	return runs, rows.Err()
+}
+
+// =============================================================================
+// PÁGINA DE STATUS
+// =============================================================================
+
+// statusIncidentColumns são as colunas lidas por scanStatusIncident
+const statusIncidentColumns = `id, title, message, severity, state, components, created_by, created_at, updated_at, resolved_at`
+
+// scanStatusIncident lê um incidente
+func scanStatusIncident(row interface{ Scan(...interface{}) error }) (*StatusIncident, error) {
+	var incident StatusIncident
+	var createdBy sql.NullString
+	var resolvedAt sql.NullTime
+	var components []string
+	if err := row.Scan(&incident.ID, &incident.Title, &incident.Message, &incident.Severity, &incident.State,
+		pq.Array(&components), &createdBy, &incident.CreatedAt, &incident.UpdatedAt, &resolvedAt); err != nil {
+		return nil, err
+	}
+	incident.Components = components
+	incident.CreatedBy = createdBy.String
+	if resolvedAt.Valid {
+		incident.ResolvedAt = &resolvedAt.Time
+	}
+	return &incident, nil
+}
+
+// CreateStatusIncident publica um incidente
+func (s *PostgresStore) CreateStatusIncident(incident *StatusIncident) error {
+	if incident.ID == "" {
+		incident.ID = ids.New(ids.Incident)
+	}
+	now := time.Now()
+	if incident.CreatedAt.IsZero() {
+		incident.CreatedAt = now
+	}
+	incident.UpdatedAt = now
+	_, err := s.db.Exec(`
+		INSERT INTO status_incidents (id, title, message, severity, state, components, created_by, created_at, updated_at, resolved_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, incident.ID, incident.Title, incident.Message, incident.Severity, incident.State, pq.Array(incident.Components),
+		nullString(incident.CreatedBy), incident.CreatedAt, incident.UpdatedAt, incident.ResolvedAt)
+	return err
+}
+
+// GetStatusIncident busca um incidente
+func (s *PostgresStore) GetStatusIncident(id string) (*StatusIncident, error) {
+	incident, err := scanStatusIncident(s.db.QueryRow(`SELECT `+statusIncidentColumns+` FROM status_incidents WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return incident, err
+}
+
+// UpdateStatusIncident atualiza um incidente
+func (s *PostgresStore) UpdateStatusIncident(incident *StatusIncident) error {
+	incident.UpdatedAt = time.Now()
+	result, err := s.db.Exec(`
+		UPDATE status_incidents
+		SET title = $2, message = $3, severity = $4, state = $5, components = $6, updated_at = $7, resolved_at = $8
+		WHERE id = $1
+	`, incident.ID, incident.Title, incident.Message, incident.Severity, incident.State, pq.Array(incident.Components),
+		incident.UpdatedAt, incident.ResolvedAt)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteStatusIncident remove um incidente
+func (s *PostgresStore) DeleteStatusIncident(id string) error {
+	result, err := s.db.Exec(`DELETE FROM status_incidents WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListStatusIncidents lista os incidentes abertos e os resolvidos desde since
+func (s *PostgresStore) ListStatusIncidents(since time.Time) ([]*StatusIncident, error) {
+	rows, err := s.db.Query(`
+		SELECT `+statusIncidentColumns+`
+		FROM status_incidents
+		WHERE resolved_at IS NULL OR resolved_at >= $1
+		ORDER BY created_at DESC
+	`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	incidents := make([]*StatusIncident, 0)
+	for rows.Next() {
+		incident, err := scanStatusIncident(rows)
+		if err != nil {
+			return nil, err
+		}
+		incidents = append(incidents, incident)
+	}
+	return incidents, rows.Err()
+}
+
+// RecordStatusCheck soma uma verificação da dependência ao dia
+func (s *PostgresStore) RecordStatusCheck(component string, day time.Time, ok bool) error {
+	failures := 0
+	if !ok {
+		failures = 1
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO status_uptime (component, day, checks, failures)
+		VALUES ($1, $2, 1, $3)
+		ON CONFLICT (component, day) DO UPDATE
+		SET checks = status_uptime.checks + 1, failures = status_uptime.failures + EXCLUDED.failures
+	`, component, day.UTC().Format("2006-01-02"), failures)
+	return err
+}
+
+// ListStatusUptime lista os dias verificados desde since, em ordem
+func (s *PostgresStore) ListStatusUptime(since time.Time) ([]*StatusUptimeDay, error) {
+	rows, err := s.db.Query(`
+		SELECT component, day, checks, failures
+		FROM status_uptime
+		WHERE day >= $1
+		ORDER BY day, component
+	`, since.UTC().Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := make([]*StatusUptimeDay, 0)
+	for rows.Next() {
+		var entry StatusUptimeDay
+		if err := rows.Scan(&entry.Component, &entry.Day, &entry.Checks, &entry.Failures); err != nil {
+			return nil, err
+		}
+		days = append(days, &entry)
+	}
+	return days, rows.Err()
+}
+
+// RecordAudit grava um evento de auditoria em audit_log
+func (s *PostgresStore) RecordAudit(entry *AuditEntry) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	var details []byte
+	if len(entry.Details) > 0 {
+		details, _ = json.Marshal(entry.Details)
+	}
+	var id int64
+	err := s.db.QueryRow(`
+		INSERT INTO audit_log (user_id, action, resource_type, resource_id, ip_address, details, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`, nullString(entry.UserID), entry.Action, nullString(entry.ResourceType), nullString(entry.ResourceID),
+		nullString(entry.IPAddress), details, entry.CreatedAt).Scan(&id)
+	if err != nil {
+		return err
+	}
+	entry.ID = auditActivityPrefix + strconv.FormatInt(id, 10)
+	return nil
+}
+
+// ListActivity lista a atividade do painel admin (audit_log + analytics_events)
+// Ordem: created_at DESC, id DESC. Total considera os filtros, não o cursor.
+func (s *PostgresStore) ListActivity(params *ActivityParams) (*PaginatedResult[*ActivityEntry], error) {
+	if params == nil {
+		params = &ActivityParams{}
+	}
+	NormalizePagination(&params.PaginationParams)
+	actions, events := activitySources(params.Types)
+
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	var conditions []string
+	if params.From != nil {
+		conditions = append(conditions, "created_at >= "+arg(*params.From))
+	}
+	if params.To != nil {
+		conditions = append(conditions, "created_at < "+arg(*params.To))
+	}
+
+	auditActions := make([]string, 0, len(actions))
+	for action := range actions {
+		auditActions = append(auditActions, action)
+	}
+	eventTypes := make([]string, 0, len(events))
+	for event := range events {
+		eventTypes = append(eventTypes, string(event))
+	}
+
+	// Mesmos filtros de data nas duas tabelas; o ID de audit_log ganha o
+	// prefixo para não colidir com os IDs de analytics
+	auditWhere := append([]string{"action = ANY(" + arg(pq.Array(auditActions)) + ")"}, conditions...)
+	eventsWhere := append([]string{"event_type = ANY(" + arg(pq.Array(eventTypes)) + ")"}, conditions...)
+	union := `
+		SELECT '` + auditActivityPrefix + `' || id::text AS id, 'audit' AS source, action AS kind, user_id, details, created_at
+		FROM audit_log WHERE ` + strings.Join(auditWhere, " AND ") + `
+		UNION ALL
+		SELECT id, 'analytics' AS source, event_type AS kind, user_id, details, created_at
+		FROM analytics_events WHERE ` + strings.Join(eventsWhere, " AND ")
+
+	var total int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM (`+union+`) activity`, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("erro ao contar atividade: %w", err)
+	}
+
+	where := ""
+	if params.Cursor != "" {
+		cursor, err := decodeActivityCursor(params.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		where = " WHERE (created_at, id) < (" + arg(cursor.CreatedAt) + ", " + arg(cursor.ID) + ")"
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, source, kind, user_id, details, created_at
+		FROM (`+union+`) activity`+where+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT `+arg(params.Limit+1), args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar atividade: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]*ActivityEntry, 0, params.Limit+1)
+	for rows.Next() {
+		var entry ActivityEntry
+		var kind string
+		var userID sql.NullString
+		var details []byte
+		if err := rows.Scan(&entry.ID, &entry.Source, &kind, &userID, &details, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("erro ao ler atividade: %w", err)
+		}
+		entry.UserID = userID.String
+		if entry.Source == "audit" {
+			entry.Type = actions[kind]
+		} else {
+			entry.Type = events[AnalyticsEventType(kind)]
+		}
+		if len(details) > 0 {
+			json.Unmarshal(details, &entry.Details)
+		}
+		entries = append(entries, &entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	hasMore := len(entries) > params.Limit
+	if hasMore {
+		entries = entries[:params.Limit]
+	}
+	var nextCursor string
+	if hasMore {
+		nextCursor = encodeActivityCursor(entries[len(entries)-1])
+	}
+
+	return &PaginatedResult[*ActivityEntry]{
+		Items:      entries,
+		NextCursor: nextCursor,
+		HasMore:    hasMore,
+		Total:      total,
+	}, nil
+}
+
 // ============================================================================
 // USERS
 // ============================================================================
@@ -427,7 +544,7 @@ func (s *PostgresStore) CreateUser(email, hashedPassword, name string) (*User, e
 		return nil, ErrInvalidData
 	}
 
-	id := fmt.Sprintf("usr_%d", time.Now().UnixNano())
+	id := ids.New(ids.User)
 	now := time.Now()
 
 	_, err := s.db.Exec(`
@@ -447,6 +564,7 @@ func (s *PostgresStore) CreateUser(email, hashedPassword, name string) (*User, e
 		Email:     email,
 		Name:      name,
 		Password:  hashedPassword,
+		Plan:      PlanFree,
 		CreatedAt: now,
 	}, nil
 }
@@ -455,11 +573,15 @@ func (s *PostgresStore) GetUserByEmail(email string) (*User, bool) {
 	normalized := strings.ToLower(strings.TrimSpace(email))
 
 	var user User
-	var locale sql.NullString
+	var locale, termsVersion, privacyVersion sql.NullString
+	var lockedUntil, disabledAt, termsAcceptedAt sql.NullTime
 	err := s.db.QueryRow(`
-		SELECT id, email, name, password, locale, created_at
+		SELECT id, email, name, password, locale, created_at, failed_login_attempts, locked_until, disabled_at, role, plan,
+			terms_version, privacy_version, terms_accepted_at
 		FROM users WHERE LOWER(email) = $1
-	`, normalized).Scan(&user.ID, &user.Email, &user.Name, &user.Password, &locale, &user.CreatedAt)
+	`, normalized).Scan(&user.ID, &user.Email, &user.Name, &user.Password, &locale, &user.CreatedAt,
+		&user.FailedLoginAttempts, &lockedUntil, &disabledAt, &user.Role, &user.Plan,
+		&termsVersion, &privacyVersion, &termsAcceptedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, false
@@ -473,18 +595,33 @@ func (s *PostgresStore) GetUserByEmail(email string) (*User, bool) {
 	if locale.Valid {
 		user.Locale = locale.String
 	}
+	if lockedUntil.Valid {
+		user.LockedUntil = &lockedUntil.Time
+	}
+	if disabledAt.Valid {
+		user.DisabledAt = &disabledAt.Time
+	}
+	user.TermsVersion = termsVersion.String
+	user.PrivacyVersion = privacyVersion.String
+	if termsAcceptedAt.Valid {
+		user.TermsAcceptedAt = &termsAcceptedAt.Time
+	}
 
 	return &user, true
 }
 
 func (s *PostgresStore) GetUserByID(id string) (*User, bool) {
 	var user User
-	var name, locale sql.NullString
+	var name, locale, termsVersion, privacyVersion sql.NullString
+	var lockedUntil, disabledAt, termsAcceptedAt sql.NullTime
 
 	err := s.db.QueryRow(`
-		SELECT id, email, name, password, locale, created_at
+		SELECT id, email, name, password, locale, created_at, failed_login_attempts, locked_until, disabled_at, role, plan,
+			terms_version, privacy_version, terms_accepted_at
 		FROM users WHERE id = $1
-	`, id).Scan(&user.ID, &user.Email, &name, &user.Password, &locale, &user.CreatedAt)
+	`, id).Scan(&user.ID, &user.Email, &name, &user.Password, &locale, &user.CreatedAt,
+		&user.FailedLoginAttempts, &lockedUntil, &disabledAt, &user.Role, &user.Plan,
+		&termsVersion, &privacyVersion, &termsAcceptedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, false
@@ -498,6 +635,17 @@ func (s *PostgresStore) GetUserByID(id string) (*User, bool) {
 	if locale.Valid {
 		user.Locale = locale.String
 	}
+	if lockedUntil.Valid {
+		user.LockedUntil = &lockedUntil.Time
+	}
+	if disabledAt.Valid {
+		user.DisabledAt = &disabledAt.Time
+	}
+	user.TermsVersion = termsVersion.String
+	user.PrivacyVersion = privacyVersion.String
+	if termsAcceptedAt.Valid {
+		user.TermsAcceptedAt = &termsAcceptedAt.Time
+	}
 	return &user, true
 }
 
@@ -533,6 +681,59 @@ func (s *PostgresStore) UpdateUserLocale(userID, locale string) error {
 	return nil
 }
 
+// UpdateUserProfile corrige o nome e o email do usuário
+func (s *PostgresStore) UpdateUserProfile(userID, name, email string) error {
+	normalized := strings.ToLower(strings.TrimSpace(email))
+	if normalized == "" {
+		return ErrInvalidData
+	}
+
+	// O índice único não ignora maiúsculas: conferir antes como no login
+	var taken bool
+	if err := s.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM users WHERE LOWER(email) = $1 AND id <> $2)`,
+		normalized, userID).Scan(&taken); err != nil {
+		return fmt.Errorf("erro ao verificar email: %w", err)
+	}
+	if taken {
+		return ErrAlreadyExists
+	}
+
+	result, err := s.db.Exec(`UPDATE users SET name = $1, email = $2, updated_at = $3 WHERE id = $4`,
+		name, email, time.Now(), userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate") || strings.Contains(err.Error(), "unique") {
+			return ErrAlreadyExists
+		}
+		return fmt.Errorf("erro ao atualizar perfil: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// AcceptLegalTerms registra as versões dos termos e da política aceitas
+func (s *PostgresStore) AcceptLegalTerms(userID, termsVersion, privacyVersion string, at time.Time) error {
+	result, err := s.db.Exec(`
+		UPDATE users
+		SET terms_accepted = TRUE, terms_accepted_at = $1, terms_version = $2, privacy_version = $3, updated_at = $1
+		WHERE id = $4
+	`, at, termsVersion, privacyVersion, userID)
+	if err != nil {
+		return fmt.Errorf("erro ao registrar aceite dos termos: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
 // DeleteUser remove um usuário e todos os seus dados (LGPD: Direito ao esquecimento)
 // Devido ao ON DELETE CASCADE, todos os dados relacionados são removidos automaticamente
 func (s *PostgresStore) DeleteUser(userID string) error {
@@ -594,7 +795,7 @@ func (s *PostgresStore) CreateOrUpdateSocialUser(provider AuthProvider, provider
 	}
 
 	// Criar novo usuário
-	id := fmt.Sprintf("usr_%d", now.UnixNano())
+	id := ids.New(ids.User)
 	_, err := s.db.Exec(`
 		INSERT INTO users (id, email, name, password, provider, provider_id, avatar_url, created_at, updated_at)
 		VALUES ($1, $2, $3, '', $4, $5, $6, $7, $8)
@@ -614,6 +815,7 @@ func (s *PostgresStore) CreateOrUpdateSocialUser(provider AuthProvider, provider
 		Provider:   provider,
 		ProviderID: providerID,
 		AvatarURL:  avatarURL,
+		Plan:       PlanFree,
 		CreatedAt:  now,
 	}, nil
 }
@@ -622,13 +824,14 @@ func (s *PostgresStore) CreateOrUpdateSocialUser(provider AuthProvider, provider
 func (s *PostgresStore) GetUserByProvider(provider AuthProvider, providerID string) (*User, bool) {
 	var user User
 	var name, avatarURL sql.NullString
+	var disabledAt sql.NullTime
 
 	err := s.db.QueryRow(`
-		SELECT id, email, name, password, provider, provider_id, avatar_url, created_at
+		SELECT id, email, name, password, provider, provider_id, avatar_url, created_at, disabled_at, role, plan
 		FROM users WHERE provider = $1 AND provider_id = $2
 	`, provider, providerID).Scan(
 		&user.ID, &user.Email, &name, &user.Password,
-		&user.Provider, &user.ProviderID, &avatarURL, &user.CreatedAt,
+		&user.Provider, &user.ProviderID, &avatarURL, &user.CreatedAt, &disabledAt, &user.Role, &user.Plan,
 	)
 
 	if err == sql.ErrNoRows {
@@ -640,6 +843,9 @@ func (s *PostgresStore) GetUserByProvider(provider AuthProvider, providerID stri
 
 	user.Name = name.String
 	user.AvatarURL = avatarURL.String
+	if disabledAt.Valid {
+		user.DisabledAt = &disabledAt.Time
+	}
 	return &user, true
 }
 
@@ -662,1426 +868,6351 @@ func (s *PostgresStore) LinkSocialProvider(userID string, provider AuthProvider,
 	return nil
 }
 
-// ExportUserData exporta todos os dados do usuário (LGPD: Portabilidade)
-func (s *PostgresStore) ExportUserData(userID string) (*UserDataExport, error) {
-	user, found := s.GetUserByID(userID)
-	if !found {
-		return nil, ErrNotFound
-	}
+// ============================================================================
+// ACCOUNT SECURITY (Bloqueio de conta e histórico de login)
+// ============================================================================
 
-	// Limpar senha do export
-	user.Password = ""
+// RecordFailedLogin incrementa as falhas consecutivas e bloqueia a conta ao atingir o limite
+// Retorna o usuário com o contador e o bloqueio atualizados
+func (s *PostgresStore) RecordFailedLogin(userID string, maxAttempts int, lockDuration time.Duration) (*User, error) {
+	lockUntil := time.Now().Add(lockDuration)
 
-	items := s.ListBoxItems(userID)
-	guardians := s.ListGuardians(userID)
-	progressMap := s.GetGuideProgress(userID)
-	settings := s.GetSettings(userID)
+	var attempts int
+	var lockedUntil sql.NullTime
+	err := s.db.QueryRow(`
+		UPDATE users SET
+			failed_login_attempts = failed_login_attempts + 1,
+			locked_until = CASE WHEN failed_login_attempts + 1 >= $2 THEN $3 ELSE locked_until END,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING failed_login_attempts, locked_until
+	`, userID, maxAttempts, lockUntil).Scan(&attempts, &lockedUntil)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao registrar falha de login: %w", err)
+	}
 
-	// Converter map de progresso para slice
-	progress := make([]*GuideProgress, 0, len(progressMap))
-	for _, p := range progressMap {
-		progress = append(progress, p)
+	user := &User{ID: userID, FailedLoginAttempts: attempts}
+	if lockedUntil.Valid {
+		user.LockedUntil = &lockedUntil.Time
 	}
+	return user, nil
+}
 
-	return &UserDataExport{
-		User:       user,
-		Items:      items,
-		Guardians:  guardians,
-		Progress:   progress,
-		Settings:   settings,
-		ExportedAt: time.Now(),
-	}, nil
+// ResetFailedLogins zera as falhas consecutivas e remove o bloqueio
+func (s *PostgresStore) ResetFailedLogins(userID string) error {
+	_, err := s.db.Exec(`
+		UPDATE users SET failed_login_attempts = 0, locked_until = NULL
+		WHERE id = $1 AND (failed_login_attempts > 0 OR locked_until IS NOT NULL)
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("erro ao resetar falhas de login: %w", err)
+	}
+	return nil
 }
 
-// ============================================================================
-// HELPERS DE CRIPTOGRAFIA
-// ============================================================================
+// RecordLogin registra um login e informa se dispositivo/país são novos
+func (s *PostgresStore) RecordLogin(record *LoginRecord) (*LoginCheck, error) {
+	var total, sameDevice, sameCountry int
+	err := s.db.QueryRow(`
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE device_hash = $2),
+			COUNT(*) FILTER (WHERE country = $3)
+		FROM login_history WHERE user_id = $1
+	`, record.UserID, record.DeviceHash, record.Country).Scan(&total, &sameDevice, &sameCountry)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao consultar histórico de login: %w", err)
+	}
 
-// encryptSensitive criptografa um valor se não estiver vazio
-// Em caso de erro, falha fechado para evitar persistir PII em texto puro.
-func (s *PostgresStore) encryptSensitive(value string) (string, error) {
-	if value == "" {
-		return "", nil
+	if record.ID == "" {
+		record.ID = ids.New(ids.LoginAttempt)
 	}
-	if s.encryptor == nil {
-		return "", fmt.Errorf("encryptor not configured")
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
 	}
-	encrypted, err := s.encryptor.Encrypt(value)
+
+	_, err = s.db.Exec(`
+		INSERT INTO login_history (id, user_id, ip_address, country, device_hash, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, record.ID, record.UserID, nullString(record.IPAddress), nullString(record.Country),
+		nullString(record.DeviceHash), record.CreatedAt)
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("erro ao registrar login: %w", err)
 	}
-	return "enc:" + encrypted, nil
+
+	return &LoginCheck{
+		FirstLogin: total == 0,
+		NewDevice:  total > 0 && record.DeviceHash != "" && sameDevice == 0,
+		NewCountry: total > 0 && record.Country != "" && sameCountry == 0,
+	}, nil
 }
 
-// decryptSensitive descriptografa um valor se estiver criptografado
-func (s *PostgresStore) decryptSensitive(value string) string {
-	if value == "" || s.encryptor == nil {
-		return value
-	}
+// =============================================================================
+// ASSINATURAS
+// =============================================================================
 
-	encryptedValue := value
-	if strings.HasPrefix(value, "enc:") {
-		encryptedValue = strings.TrimPrefix(value, "enc:")
-		decrypted, err := s.encryptor.Decrypt(encryptedValue)
-		if err != nil {
-			return ""
-		}
-		return decrypted
+// GetSubscription busca a assinatura do usuário
+func (s *PostgresStore) GetSubscription(userID string) (*Subscription, error) {
+	row := s.db.QueryRow(`
+		SELECT user_id, customer_id, subscription_id, plan, status, current_period_end, cancel_at_period_end, updated_at
+		FROM subscriptions WHERE user_id = $1
+	`, userID)
+
+	sub, err := scanSubscription(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
 	}
+	return sub, err
+}
 
-	if !isLikelyCiphertext(encryptedValue) {
-		return value
+// GetSubscriptionByCustomer busca a assinatura pelo cliente do Stripe
+func (s *PostgresStore) GetSubscriptionByCustomer(customerID string) (*Subscription, error) {
+	row := s.db.QueryRow(`
+		SELECT user_id, customer_id, subscription_id, plan, status, current_period_end, cancel_at_period_end, updated_at
+		FROM subscriptions WHERE customer_id = $1
+	`, customerID)
+
+	sub, err := scanSubscription(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
 	}
+	return sub, err
+}
 
-	decrypted, err := s.encryptor.Decrypt(encryptedValue)
+// SaveSubscription grava a assinatura e aplica o plano ao usuário (mesma transação)
+func (s *PostgresStore) SaveSubscription(sub *Subscription) error {
+	tx, err := s.db.Begin()
 	if err != nil {
-		return ""
+		return err
 	}
-	return decrypted
-}
+	defer tx.Rollback()
 
-func (s *PostgresStore) getOrCreateEncryptionSalt() ([]byte, error) {
-	envSalt := strings.TrimSpace(os.Getenv("ENCRYPTION_SALT"))
-	if envSalt != "" {
-		return decodeSalt(envSalt)
+	now := time.Now()
+	result, err := tx.Exec(`UPDATE users SET plan = $1, updated_at = $2 WHERE id = $3`, sub.Plan, now, sub.UserID)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar plano: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
 	}
 
-	var stored string
-	err := s.db.QueryRow(`SELECT value FROM system_config WHERE key = $1`, "encryption_salt").Scan(&stored)
-	if err != nil && err != sql.ErrNoRows {
-		return nil, err
+	_, err = tx.Exec(`
+		INSERT INTO subscriptions (user_id, customer_id, subscription_id, plan, status, current_period_end, cancel_at_period_end, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id) DO UPDATE SET
+			customer_id = $2, subscription_id = $3, plan = $4, status = $5,
+			current_period_end = $6, cancel_at_period_end = $7, updated_at = $8
+	`, sub.UserID, sub.CustomerID, sub.SubscriptionID, sub.Plan, sub.Status, sub.CurrentPeriodEnd,
+		sub.CancelAtPeriodEnd, now)
+	if isUniqueViolation(err) {
+		return ErrAlreadyExists
 	}
-	if stored == "" {
-		raw := make([]byte, 16)
-		if _, err := rand.Read(raw); err != nil {
-			return nil, err
-		}
-		stored = base64.StdEncoding.EncodeToString(raw)
-		_, err := s.db.Exec(`
-			INSERT INTO system_config (key, value, updated_at)
-			VALUES ($1, $2, $3)
-			ON CONFLICT (key) DO UPDATE SET value = $2, updated_at = $3
-		`, "encryption_salt", stored, time.Now())
-		if err != nil {
-			return nil, err
-		}
+	if err != nil {
+		return fmt.Errorf("erro ao salvar assinatura: %w", err)
 	}
 
-	return decodeSalt(stored)
+	return tx.Commit()
 }
 
-func decodeSalt(value string) ([]byte, error) {
-	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
-	if err != nil {
-		return nil, fmt.Errorf("invalid ENCRYPTION_SALT")
+// scanSubscription lê uma linha de subscriptions
+func scanSubscription(row interface{ Scan(...interface{}) error }) (*Subscription, error) {
+	var sub Subscription
+	var periodEnd sql.NullTime
+	if err := row.Scan(&sub.UserID, &sub.CustomerID, &sub.SubscriptionID, &sub.Plan, &sub.Status,
+		&periodEnd, &sub.CancelAtPeriodEnd, &sub.UpdatedAt); err != nil {
+		return nil, err
 	}
-	if len(decoded) < 16 {
-		return nil, fmt.Errorf("invalid ENCRYPTION_SALT length")
+	if periodEnd.Valid {
+		sub.CurrentPeriodEnd = &periodEnd.Time
 	}
-	return decoded, nil
+	return &sub, nil
 }
 
-func isLikelyCiphertext(value string) bool {
-	if len(value) < 24 {
-		return false
+// =============================================================================
+// SESSÕES POR DISPOSITIVO
+// =============================================================================
+
+// CreateDeviceSession registra a sessão e remove as sessões expiradas do usuário
+func (s *PostgresStore) CreateDeviceSession(session *DeviceSession) error {
+	if _, err := s.db.Exec(`
+		DELETE FROM device_sessions WHERE user_id = $1 AND last_seen_at < $2
+	`, session.UserID, time.Now().Add(-DeviceSessionTTL)); err != nil {
+		return fmt.Errorf("erro ao remover sessões expiradas: %w", err)
 	}
-	decoded, err := base64.StdEncoding.DecodeString(value)
+
+	_, err := s.db.Exec(`
+		INSERT INTO device_sessions (id, user_id, name, user_agent, ip_address, created_at, last_seen_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, session.ID, session.UserID, session.Name, session.UserAgent, nullString(session.IPAddress),
+		session.CreatedAt, session.LastSeenAt)
 	if err != nil {
-		return false
+		return fmt.Errorf("erro ao criar sessão: %w", err)
 	}
-	// nonce (12) + tag (16) + payload (>=0)
-	return len(decoded) >= 28
+	return nil
 }
 
-// ============================================================================
-// BOX ITEMS
-// ============================================================================
+// GetDeviceSession busca uma sessão ativa
+func (s *PostgresStore) GetDeviceSession(sessionID string) (*DeviceSession, error) {
+	row := s.db.QueryRow(`
+		SELECT id, user_id, name, user_agent, ip_address, created_at, last_seen_at
+		FROM device_sessions WHERE id = $1 AND last_seen_at >= $2
+	`, sessionID, time.Now().Add(-DeviceSessionTTL))
 
-// GetBoxItems retorna itens (compatibilidade - sem paginação)
-func (s *PostgresStore) GetBoxItems(userID string) ([]*BoxItem, error) {
-	return s.ListBoxItems(userID), nil
+	session, err := scanDeviceSession(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return session, err
 }
 
-// ListBoxItems lista todos os itens (CUIDADO: sem paginação, usar apenas para exportação)
-// Em caso de erro, retorna lista vazia (comportamento esperado para exportação)
-func (s *PostgresStore) ListBoxItems(userID string) []*BoxItem {
-	// Query com campos específicos (não usa SELECT *)
+// ListDeviceSessions lista as sessões ativas do usuário
+func (s *PostgresStore) ListDeviceSessions(userID string) ([]*DeviceSession, error) {
 	rows, err := s.db.Query(`
-		SELECT id, user_id, type, title, content, category, recipient, is_important, is_shared, guardian_ids, created_at, updated_at
-		FROM box_items 
-		WHERE user_id = $1
-		ORDER BY updated_at DESC
-		LIMIT 1000
-	`, userID)
+		SELECT id, user_id, name, user_agent, ip_address, created_at, last_seen_at
+		FROM device_sessions WHERE user_id = $1 AND last_seen_at >= $2
+		ORDER BY last_seen_at DESC
+	`, userID, time.Now().Add(-DeviceSessionTTL))
 	if err != nil {
-		return []*BoxItem{}
+		return nil, fmt.Errorf("erro ao listar sessões: %w", err)
 	}
 	defer rows.Close()
 
-	var items []*BoxItem
+	result := make([]*DeviceSession, 0)
 	for rows.Next() {
-		var item BoxItem
-		var title, content, category, recipient sql.NullString
-		var guardianIDs pq.StringArray
-		err := rows.Scan(
-			&item.ID, &item.UserID, &item.Type, &title,
-			&content, &category, &recipient,
-			&item.IsImportant, &item.IsShared, &guardianIDs, &item.CreatedAt, &item.UpdatedAt,
-		)
+		session, err := scanDeviceSession(rows)
 		if err != nil {
-			// Pular itens com erro de leitura
-			continue
+			return nil, err
 		}
-		// Descriptografar dados sensíveis
-		item.Title = s.decryptSensitive(title.String)
-		item.Content = s.decryptSensitive(content.String)
-		item.Category = category.String
-		item.Recipient = s.decryptSensitive(recipient.String)
-		item.GuardianIDs = guardianIDs
-		items = append(items, &item)
+		result = append(result, session)
 	}
-
-	return items
+	return result, rows.Err()
 }
 
-// ListBoxItemsPaginated lista itens com paginação (método preferido)
-// Usa cursor-based pagination para melhor performance
-func (s *PostgresStore) ListBoxItemsPaginated(userID string, params *PaginationParams) (*PaginatedResult[*BoxItemSummary], error) {
-	params = NormalizePagination(params)
-
-	// Query paginada - busca limit+1 para detectar hasMore
-	var rows *sql.Rows
-	var err error
-
-	if params.Cursor != "" {
-		// Buscar itens após o cursor (baseado no ID)
-		rows, err = s.db.Query(`
-			SELECT id, type, title, category, is_important, is_shared, guardian_ids, updated_at
-			FROM box_items 
-			WHERE user_id = $1 AND id < $2
-			ORDER BY id DESC
-			LIMIT $3
-		`, userID, params.Cursor, params.Limit+1)
-	} else {
-		// Primeira página
-		rows, err = s.db.Query(`
-			SELECT id, type, title, category, is_important, is_shared, guardian_ids, updated_at
-			FROM box_items 
-			WHERE user_id = $1
-			ORDER BY id DESC
-			LIMIT $2
-		`, userID, params.Limit+1)
-	}
+// TouchDeviceSession atualiza o último acesso e o IP da sessão
+func (s *PostgresStore) TouchDeviceSession(sessionID, ipAddress string) error {
+	_, err := s.db.Exec(`
+		UPDATE device_sessions SET last_seen_at = $1, ip_address = COALESCE($2, ip_address)
+		WHERE id = $3
+	`, time.Now(), nullString(ipAddress), sessionID)
+	return err
+}
 
+// RenameDeviceSession altera o nome de uma sessão do usuário
+func (s *PostgresStore) RenameDeviceSession(userID, sessionID, name string) error {
+	result, err := s.db.Exec(`
+		UPDATE device_sessions SET name = $1 WHERE id = $2 AND user_id = $3
+	`, name, sessionID, userID)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao listar itens paginados: %w", err)
+		return fmt.Errorf("erro ao renomear sessão: %w", err)
 	}
-	defer rows.Close()
-
-	var items []*BoxItemSummary
-	for rows.Next() {
-		var item BoxItemSummary
-		var title, category sql.NullString
-		var guardianIDs pq.StringArray
-		err := rows.Scan(
-			&item.ID, &item.Type, &title, &category,
-			&item.IsImportant, &item.IsShared, &guardianIDs, &item.UpdatedAt,
-		)
-		if err != nil {
-			// Pular itens com erro de leitura
-			continue
-		}
-		item.Title = s.decryptSensitive(title.String)
-		item.Category = category.String
-		item.GuardianIDs = guardianIDs
-		items = append(items, &item)
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrNotFound
 	}
+	return nil
+}
 
-	// Verificar se há mais páginas
-	hasMore := len(items) > params.Limit
-	if hasMore {
-		items = items[:params.Limit] // Remove o item extra
+// DeleteDeviceSession encerra uma sessão do usuário
+func (s *PostgresStore) DeleteDeviceSession(userID, sessionID string) error {
+	result, err := s.db.Exec(`
+		DELETE FROM device_sessions WHERE id = $1 AND user_id = $2
+	`, sessionID, userID)
+	if err != nil {
+		return fmt.Errorf("erro ao remover sessão: %w", err)
 	}
-
-	// Próximo cursor
-	var nextCursor string
-	if hasMore && len(items) > 0 {
-		nextCursor = items[len(items)-1].ID
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrNotFound
 	}
-
-	return &PaginatedResult[*BoxItemSummary]{
-		Items:      items,
-		NextCursor: nextCursor,
-		HasMore:    hasMore,
-	}, nil
+	return nil
 }
 
-// CountBoxItems conta o total de itens de um usuário
-func (s *PostgresStore) CountBoxItems(userID string) (int, error) {
-	var count int
-	err := s.db.QueryRow(`
-		SELECT COUNT(*) FROM box_items WHERE user_id = $1
-	`, userID).Scan(&count)
-	return count, err
+// SaveSupportAccess cria ou substitui a autorização do suporte
+func (s *PostgresStore) SaveSupportAccess(access *SupportAccess) error {
+	_, err := s.db.Exec(`
+		INSERT INTO support_access (user_id, granted_at, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET granted_at = EXCLUDED.granted_at, expires_at = EXCLUDED.expires_at
+	`, access.UserID, access.GrantedAt, access.ExpiresAt)
+	return err
 }
 
-// GetBoxItem busca um item específico por ID
-func (s *PostgresStore) GetBoxItem(userID, itemID string) (*BoxItem, error) {
-	var item BoxItem
-	var title, content, category, recipient sql.NullString
-	var guardianIDs pq.StringArray
-
-	// Query com campos específicos (não usa SELECT *)
+// GetSupportAccess retorna a autorização do suporte do usuário
+func (s *PostgresStore) GetSupportAccess(userID string) (*SupportAccess, error) {
+	access := SupportAccess{UserID: userID}
 	err := s.db.QueryRow(`
-		SELECT id, user_id, type, title, content, category, recipient, is_important, is_shared, guardian_ids, created_at, updated_at
-		FROM box_items 
-		WHERE user_id = $1 AND id = $2
-	`, userID, itemID).Scan(
-		&item.ID, &item.UserID, &item.Type, &title,
-		&content, &category, &recipient,
-		&item.IsImportant, &item.IsShared, &guardianIDs, &item.CreatedAt, &item.UpdatedAt,
-	)
-
+		SELECT granted_at, expires_at FROM support_access WHERE user_id = $1
+	`, userID).Scan(&access.GrantedAt, &access.ExpiresAt)
 	if err == sql.ErrNoRows {
 		return nil, ErrNotFound
 	}
 	if err != nil {
 		return nil, err
 	}
-
-	// Descriptografar dados sensíveis
-	item.Title = s.decryptSensitive(title.String)
-	item.Content = s.decryptSensitive(content.String)
-	item.Category = category.String
-	item.Recipient = s.decryptSensitive(recipient.String)
-	item.GuardianIDs = guardianIDs
-	return &item, nil
+	return &access, nil
 }
 
-// CreateBoxItem cria um novo item com dados criptografados
-func (s *PostgresStore) CreateBoxItem(userID string, item *BoxItem) (*BoxItem, error) {
-	id := fmt.Sprintf("itm_%d", time.Now().UnixNano())
-	return s.CreateBoxItemWithID(userID, item, id)
+// DeleteSupportAccess revoga a autorização do suporte
+func (s *PostgresStore) DeleteSupportAccess(userID string) error {
+	_, err := s.db.Exec(`DELETE FROM support_access WHERE user_id = $1`, userID)
+	return err
 }
 
-// CreateBoxItemWithID cria um novo item com ID pré-definido (idempotência).
-func (s *PostgresStore) CreateBoxItemWithID(userID string, item *BoxItem, itemID string) (*BoxItem, error) {
-	now := time.Now()
-
-	// Criptografar dados sensíveis antes de salvar
-	encTitle, err := s.encryptSensitive(item.Title)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao criptografar título: %w", err)
+// CreatePhoneLink vincula um número de WhatsApp à conta
+// O primeiro número da conta vira o principal.
+func (s *PostgresStore) CreatePhoneLink(link *PhoneLink) error {
+	err := s.db.QueryRow(`
+		INSERT INTO phone_links (id, user_id, phone, label, is_primary, verified_at, created_at)
+		VALUES ($1, $2, $3, $4, NOT EXISTS (SELECT 1 FROM phone_links WHERE user_id = $2 AND is_primary), $5, $6)
+		RETURNING is_primary
+	`, link.ID, link.UserID, link.Phone, link.Label, link.VerifiedAt, link.CreatedAt).Scan(&link.Primary)
+	if isUniqueViolation(err) {
+		return ErrAlreadyExists
 	}
-	encContent, err := s.encryptSensitive(item.Content)
+	return err
+}
+
+// ListPhoneLinks lista os números vinculados à conta
+func (s *PostgresStore) ListPhoneLinks(userID string) ([]*PhoneLink, error) {
+	rows, err := s.db.Query(`
+		SELECT id, user_id, phone, label, is_primary, verified_at, created_at
+		FROM phone_links WHERE user_id = $1
+		ORDER BY is_primary DESC, created_at
+	`, userID)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao criptografar conteúdo: %w", err)
+		return nil, err
 	}
-	encRecipient, err := s.encryptSensitive(item.Recipient)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao criptografar destinatário: %w", err)
+	defer rows.Close()
+
+	links := make([]*PhoneLink, 0)
+	for rows.Next() {
+		link, err := scanPhoneLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
 	}
+	return links, rows.Err()
+}
 
-	_, err = s.db.Exec(`
-		INSERT INTO box_items (id, user_id, type, title, content, category, recipient, is_important, is_shared, guardian_ids, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
-	`, itemID, userID, item.Type, encTitle, encContent, item.Category, encRecipient, item.IsImportant, item.IsShared, pq.Array(item.GuardianIDs), now, now)
+// GetPhoneLinkByPhone busca o vínculo de um número
+func (s *PostgresStore) GetPhoneLinkByPhone(phone string) (*PhoneLink, error) {
+	link, err := scanPhoneLink(s.db.QueryRow(`
+		SELECT id, user_id, phone, label, is_primary, verified_at, created_at
+		FROM phone_links WHERE phone = $1
+	`, phone))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return link, err
+}
 
+// SetPrimaryPhoneLink define o número principal da conta
+func (s *PostgresStore) SetPrimaryPhoneLink(userID, linkID string) error {
+	tx, err := s.db.Begin()
 	if err != nil {
-		return nil, err
+		return err
 	}
+	defer tx.Rollback()
 
-	item.ID = itemID
-	item.UserID = userID
-	item.CreatedAt = now
-	item.UpdatedAt = now
-	return item, nil
+	var exists bool
+	if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM phone_links WHERE id = $1 AND user_id = $2)`, linkID, userID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	// Em dois passos: o índice único só admite um principal por conta
+	if _, err := tx.Exec(`UPDATE phone_links SET is_primary = FALSE WHERE user_id = $1 AND is_primary`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE phone_links SET is_primary = TRUE WHERE id = $1`, linkID); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-// UpdateBoxItem atualiza um item existente com dados criptografados
-func (s *PostgresStore) UpdateBoxItem(userID, itemID string, updates *BoxItem) (*BoxItem, error) {
-	// Criptografar dados sensíveis antes de atualizar
-	encTitle, err := s.encryptSensitive(updates.Title)
+// DeletePhoneLink desvincula um número
+// Se era o principal, o número mais antigo que sobrou assume.
+func (s *PostgresStore) DeletePhoneLink(userID, linkID string) error {
+	tx, err := s.db.Begin()
 	if err != nil {
-		return nil, fmt.Errorf("erro ao criptografar título: %w", err)
+		return err
 	}
-	encContent, err := s.encryptSensitive(updates.Content)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao criptografar conteúdo: %w", err)
+	defer tx.Rollback()
+
+	var wasPrimary bool
+	err = tx.QueryRow(`DELETE FROM phone_links WHERE id = $1 AND user_id = $2 RETURNING is_primary`, linkID, userID).Scan(&wasPrimary)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
 	}
-	encRecipient, err := s.encryptSensitive(updates.Recipient)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao criptografar destinatário: %w", err)
+		return err
+	}
+	if wasPrimary {
+		if _, err := tx.Exec(`
+			UPDATE phone_links SET is_primary = TRUE
+			WHERE id = (SELECT id FROM phone_links WHERE user_id = $1 ORDER BY created_at LIMIT 1)
+		`, userID); err != nil {
+			return err
+		}
 	}
+	return tx.Commit()
+}
 
-	result, err := s.db.Exec(`
-		UPDATE box_items 
-		SET title = $1, content = $2, type = $3, category = $4, recipient = $5, is_important = $6, is_shared = $7, guardian_ids = $8, updated_at = $9
-		WHERE user_id = $10 AND id = $11
-	`, encTitle, encContent, updates.Type, updates.Category, encRecipient, updates.IsImportant, updates.IsShared, pq.Array(updates.GuardianIDs), time.Now(), userID, itemID)
+// scanPhoneLink lê um número vinculado de uma linha
+func scanPhoneLink(row interface{ Scan(...interface{}) error }) (*PhoneLink, error) {
+	var link PhoneLink
+	if err := row.Scan(&link.ID, &link.UserID, &link.Phone, &link.Label, &link.Primary, &link.VerifiedAt, &link.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// CreateInboundSender autoriza um email a guardar itens pelo gateway
+func (s *PostgresStore) CreateInboundSender(sender *InboundSender) error {
+	_, err := s.db.Exec(`
+		INSERT INTO inbound_senders (id, user_id, email, verified_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, sender.ID, sender.UserID, sender.Email, sender.VerifiedAt, sender.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrAlreadyExists
+	}
+	return err
+}
 
+// ListInboundSenders lista os remetentes verificados da conta
+func (s *PostgresStore) ListInboundSenders(userID string) ([]*InboundSender, error) {
+	rows, err := s.db.Query(`
+		SELECT id, user_id, email, verified_at, created_at
+		FROM inbound_senders WHERE user_id = $1
+		ORDER BY created_at
+	`, userID)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	rows, _ := result.RowsAffected()
-	if rows == 0 {
-		return nil, ErrNotFound
+	senders := make([]*InboundSender, 0)
+	for rows.Next() {
+		var sender InboundSender
+		if err := rows.Scan(&sender.ID, &sender.UserID, &sender.Email, &sender.VerifiedAt, &sender.CreatedAt); err != nil {
+			return nil, err
+		}
+		senders = append(senders, &sender)
 	}
-
-	return s.GetBoxItem(userID, itemID)
+	return senders, rows.Err()
 }
 
-func (s *PostgresStore) DeleteBoxItem(userID, itemID string) error {
-	result, err := s.db.Exec(`
-		DELETE FROM box_items WHERE user_id = $1 AND id = $2
-	`, userID, itemID)
+// GetInboundSenderByEmail busca o remetente verificado de um email
+func (s *PostgresStore) GetInboundSenderByEmail(email string) (*InboundSender, error) {
+	var sender InboundSender
+	err := s.db.QueryRow(`
+		SELECT id, user_id, email, verified_at, created_at
+		FROM inbound_senders WHERE email = $1
+	`, email).Scan(&sender.ID, &sender.UserID, &sender.Email, &sender.VerifiedAt, &sender.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sender, nil
+}
 
+// DeleteInboundSender remove um remetente verificado
+func (s *PostgresStore) DeleteInboundSender(userID, senderID string) error {
+	result, err := s.db.Exec(`DELETE FROM inbound_senders WHERE id = $1 AND user_id = $2`, senderID, userID)
 	if err != nil {
 		return err
 	}
-
-	rows, _ := result.RowsAffected()
-	if rows == 0 {
+	if n, _ := result.RowsAffected(); n == 0 {
 		return ErrNotFound
 	}
-
 	return nil
 }
 
-// ============================================================================
-// GUARDIANS
-// ============================================================================
+// CreateAPIKey guarda uma chave pessoal de API
+func (s *PostgresStore) CreateAPIKey(key *APIKey) error {
+	scopes := make([]string, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO api_keys (id, user_id, name, prefix, key_hash, scopes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, key.ID, key.UserID, key.Name, key.Prefix, key.KeyHash, pq.Array(scopes), key.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrAlreadyExists
+	}
+	return err
+}
 
-// GetGuardians retorna guardiões (compatibilidade)
-func (s *PostgresStore) GetGuardians(userID string) ([]*Guardian, error) {
-	return s.ListGuardians(userID), nil
+// scanAPIKey lê uma chave de API de uma linha
+func scanAPIKey(row interface{ Scan(...interface{}) error }) (*APIKey, error) {
+	var key APIKey
+	var scopes pq.StringArray
+	var lastUsedAt sql.NullTime
+	if err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.KeyHash, &scopes,
+		&key.CreatedAt, &lastUsedAt); err != nil {
+		return nil, err
+	}
+	key.Scopes = make([]APIKeyScope, 0, len(scopes))
+	for _, scope := range scopes {
+		key.Scopes = append(key.Scopes, APIKeyScope(scope))
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	return &key, nil
 }
 
-// ListGuardians lista todos os guardiões (CUIDADO: sem paginação)
-// Em caso de erro, retorna lista vazia
-func (s *PostgresStore) ListGuardians(userID string) []*Guardian {
+// ListAPIKeys lista as chaves de API da conta
+func (s *PostgresStore) ListAPIKeys(userID string) ([]*APIKey, error) {
 	rows, err := s.db.Query(`
-		SELECT id, user_id, name, email, phone, relationship, role, notes, access_token, access_pin, access_type, created_at, updated_at
-		FROM guardians 
-		WHERE user_id = $1
-		ORDER BY created_at DESC
-		LIMIT 100
+		SELECT id, user_id, name, prefix, key_hash, scopes, created_at, last_used_at
+		FROM api_keys WHERE user_id = $1
+		ORDER BY created_at
 	`, userID)
 	if err != nil {
-		return []*Guardian{}
+		return nil, err
 	}
 	defer rows.Close()
 
-	var guardians []*Guardian
+	keys := make([]*APIKey, 0)
 	for rows.Next() {
-		var g Guardian
-		var name, email, phone, relationship, notes, accessToken, accessPIN, accessType sql.NullString
-		err := rows.Scan(
-			&g.ID, &g.UserID, &name, &email, &phone,
-			&relationship, &g.Role, &notes, &accessToken, &accessPIN, &accessType,
-			&g.CreatedAt, &g.UpdatedAt,
-		)
+		key, err := scanAPIKey(rows)
 		if err != nil {
-			// Pular guardiões com erro de leitura
-			continue
+			return nil, err
 		}
-		// Descriptografar dados sensíveis (PII)
-		g.Name = s.decryptSensitive(name.String)
-		g.Email = s.decryptSensitive(email.String)
-		g.Phone = s.decryptSensitive(phone.String)
-		g.Relationship = relationship.String
-		g.Notes = s.decryptSensitive(notes.String)
-		g.AccessToken = accessToken.String
-		g.AccessPIN = accessPIN.String
-		g.HasPIN = accessPIN.String != ""
-		g.AccessType = GuardianAccessType(accessType.String)
-
-		s.ensureGuardianAccessToken(&g)
-
-		guardians = append(guardians, &g)
+		keys = append(keys, key)
 	}
-
-	return guardians
+	return keys, rows.Err()
 }
 
-// ListGuardiansPaginated lista guardiões com paginação
-func (s *PostgresStore) ListGuardiansPaginated(userID string, params *PaginationParams) (*PaginatedResult[*Guardian], error) {
-	params = NormalizePagination(params)
-
-	var rows *sql.Rows
-	var err error
-
-	if params.Cursor != "" {
-		rows, err = s.db.Query(`
-			SELECT id, user_id, name, email, phone, relationship, role, notes, access_token, access_type, created_at, updated_at
-			FROM guardians 
-			WHERE user_id = $1 AND id < $2
-			ORDER BY id DESC
-			LIMIT $3
-		`, userID, params.Cursor, params.Limit+1)
-	} else {
-		rows, err = s.db.Query(`
-			SELECT id, user_id, name, email, phone, relationship, role, notes, access_token, access_type, created_at, updated_at
-			FROM guardians 
-			WHERE user_id = $1
-			ORDER BY id DESC
-			LIMIT $2
-		`, userID, params.Limit+1)
+// GetAPIKeyByHash busca a chave de API pelo hash
+func (s *PostgresStore) GetAPIKeyByHash(keyHash string) (*APIKey, error) {
+	key, err := scanAPIKey(s.db.QueryRow(`
+		SELECT id, user_id, name, prefix, key_hash, scopes, created_at, last_used_at
+		FROM api_keys WHERE key_hash = $1
+	`, keyHash))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
 	}
+	return key, err
+}
 
+// TouchAPIKey registra o último uso da chave
+func (s *PostgresStore) TouchAPIKey(keyID string, at time.Time) error {
+	_, err := s.db.Exec(`UPDATE api_keys SET last_used_at = $1 WHERE id = $2`, at, keyID)
+	return err
+}
+
+// DeleteAPIKey revoga uma chave de API
+func (s *PostgresStore) DeleteAPIKey(userID, keyID string) error {
+	result, err := s.db.Exec(`DELETE FROM api_keys WHERE id = $1 AND user_id = $2`, keyID, userID)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao listar guardiões paginados: %w", err)
+		return err
 	}
-	defer rows.Close()
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
 
-	var guardians []*Guardian
-	for rows.Next() {
-		var g Guardian
-		var name, email, phone, relationship, notes, accessToken, accessType sql.NullString
-		err := rows.Scan(
-			&g.ID, &g.UserID, &name, &email, &phone,
-			&relationship, &g.Role, &notes, &accessToken, &accessType,
-			&g.CreatedAt, &g.UpdatedAt,
-		)
-		if err != nil {
-			continue
-		}
-		g.Name = s.decryptSensitive(name.String)
-		g.Email = s.decryptSensitive(email.String)
-		g.Phone = s.decryptSensitive(phone.String)
-		g.Relationship = relationship.String
-		g.Notes = s.decryptSensitive(notes.String)
-		g.AccessToken = accessToken.String
-		g.AccessType = GuardianAccessType(accessType.String)
-		s.ensureGuardianAccessToken(&g)
-		guardians = append(guardians, &g)
+// scanDeviceSession lê uma sessão de uma linha
+func scanDeviceSession(row interface{ Scan(...interface{}) error }) (*DeviceSession, error) {
+	var session DeviceSession
+	var ipAddress sql.NullString
+	if err := row.Scan(&session.ID, &session.UserID, &session.Name, &session.UserAgent, &ipAddress,
+		&session.CreatedAt, &session.LastSeenAt); err != nil {
+		return nil, err
 	}
+	session.IPAddress = ipAddress.String
+	return &session, nil
+}
 
-	hasMore := len(guardians) > params.Limit
-	if hasMore {
-		guardians = guardians[:params.Limit]
+// ExportUserData exporta todos os dados do usuário (LGPD: Portabilidade)
+func (s *PostgresStore) ExportUserData(userID string) (*UserDataExport, error) {
+	user, found := s.GetUserByID(userID)
+	if !found {
+		return nil, ErrNotFound
 	}
 
-	var nextCursor string
-	if hasMore && len(guardians) > 0 {
-		nextCursor = guardians[len(guardians)-1].ID
+	// Limpar senha do export
+	user.Password = ""
+
+	items := s.ListBoxItems(userID)
+	guardians := s.ListGuardians(userID)
+	progressMap := s.GetGuideProgress(userID)
+	settings := s.GetSettings(userID)
+
+	// Converter map de progresso para slice
+	progress := make([]*GuideProgress, 0, len(progressMap))
+	for _, p := range progressMap {
+		progress = append(progress, p)
 	}
 
-	return &PaginatedResult[*Guardian]{
-		Items:      guardians,
-		NextCursor: nextCursor,
-		HasMore:    hasMore,
+	return &UserDataExport{
+		User:       user,
+		Items:      items,
+		Guardians:  guardians,
+		Progress:   progress,
+		Settings:   settings,
+		ExportedAt: time.Now(),
 	}, nil
 }
 
-func (s *PostgresStore) ensureGuardianAccessToken(g *Guardian) {
-	if g == nil {
-		return
+// BackupUserData reúne a conta com os segredos para o backup administrativo
+func (s *PostgresStore) BackupUserData(userID string) (*UserBackup, error) {
+	user, found := s.GetUserByID(userID)
+	if !found {
+		return nil, ErrNotFound
 	}
-	if g.AccessToken == "" || isLegacyAccessToken(g.AccessToken) {
-		newToken := generateAccessToken()
-		g.AccessToken = newToken
-		// Atualizar no banco em background
-		go func(id, token string) {
-			s.db.Exec(`UPDATE guardians SET access_token = $1 WHERE id = $2`, token, id)
-		}(g.ID, newToken)
+
+	// Login social (não lido por GetUserByID)
+	var provider, providerID, avatarURL sql.NullString
+	if err := s.db.QueryRow(`
+		SELECT provider, provider_id, avatar_url FROM users WHERE id = $1
+	`, userID).Scan(&provider, &providerID, &avatarURL); err != nil {
+		return nil, err
 	}
-}
+	user.Provider = AuthProvider(provider.String)
+	user.ProviderID = providerID.String
+	user.AvatarURL = avatarURL.String
 
-func isLegacyAccessToken(token string) bool {
-	return strings.HasPrefix(token, "gat_")
-}
+	backup := &UserBackup{
+		User:         user,
+		PasswordHash: user.Password,
+		Items:        s.ListBoxItems(userID),
+		Guardians:    make([]*GuardianBackup, 0),
+		ShareLinks:   make([]*ShareLinkBackup, 0),
+	}
+	user.Password = ""
+	if backup.Items == nil {
+		backup.Items = make([]*BoxItem, 0)
+	}
+	for _, g := range s.ListGuardians(userID) {
+		backup.Guardians = append(backup.Guardians, &GuardianBackup{Guardian: g, AccessPIN: g.AccessPIN, AccountUserID: g.AccountUserID})
+	}
 
-// CountGuardians conta o total de guardiões de um usuário
+	links, err := s.GetShareLinksByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	pins := make(map[string]string)
+	rows, err := s.db.Query(`SELECT id, pin_hash FROM share_links WHERE user_id = $1 AND pin_hash IS NOT NULL`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, pinHash string
+		if err := rows.Scan(&id, &pinHash); err != nil {
+			return nil, err
+		}
+		pins[id] = pinHash
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		backup.ShareLinks = append(backup.ShareLinks, &ShareLinkBackup{ShareLink: link, Token: link.Token, PIN: pins[link.ID]})
+	}
+	return backup, nil
+}
+
+// RestoreUserData recria a conta do backup com os IDs originais
+// Tudo ou nada: a conta, os guardiões, os itens e os links entram na mesma
+// transação. Famílias e contas vinculadas que não existem mais são soltas.
+func (s *PostgresStore) RestoreUserData(data *UserBackup) error {
+	if data == nil || data.User == nil || data.User.ID == "" {
+		return ErrInvalidData
+	}
+	user := data.User
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM users WHERE id = $1 OR LOWER(email) = LOWER($2))
+	`, user.ID, user.Email).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return ErrAlreadyExists
+	}
+
+	provider := user.Provider
+	if provider == "" {
+		provider = "email"
+	}
+	plan := user.Plan
+	if plan == "" {
+		plan = PlanFree
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO users (id, email, name, password, locale, provider, provider_id, avatar_url, role, plan, disabled_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $12)
+	`, user.ID, user.Email, nullString(user.Name), data.PasswordHash, nullString(user.Locale), string(provider), nullString(user.ProviderID),
+		nullString(user.AvatarURL), string(user.Role), string(plan), user.DisabledAt, user.CreatedAt); err != nil {
+		if isUniqueViolation(err) {
+			return ErrAlreadyExists
+		}
+		return err
+	}
+
+	for _, backup := range data.Guardians {
+		g := backup.Guardian
+		encName, err := s.encryptSensitive(g.Name)
+		if err != nil {
+			return fmt.Errorf("erro ao criptografar nome: %w", err)
+		}
+		encEmail, err := s.encryptSensitive(g.Email)
+		if err != nil {
+			return fmt.Errorf("erro ao criptografar email: %w", err)
+		}
+		encPhone, err := s.encryptSensitive(g.Phone)
+		if err != nil {
+			return fmt.Errorf("erro ao criptografar telefone: %w", err)
+		}
+		encNotes, err := s.encryptSensitive(g.Notes)
+		if err != nil {
+			return fmt.Errorf("erro ao criptografar notas: %w", err)
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO guardians (id, user_id, name, email, phone, relationship, role, notes, access_token, access_pin, access_type, notify_channel, account_user_id, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, (SELECT id FROM users WHERE id = $13), $14, $15)
+		`, g.ID, user.ID, encName, encEmail, encPhone, g.Relationship, g.Role, encNotes, nullString(g.AccessToken), nullString(backup.AccessPIN),
+			string(g.AccessType), string(g.NotifyChannel), backup.AccountUserID, g.CreatedAt, g.UpdatedAt); err != nil {
+			return fmt.Errorf("guardião %s: %w", g.ID, err)
+		}
+	}
+
+	for _, item := range data.Items {
+		encTitle, err := s.encryptSensitive(item.Title)
+		if err != nil {
+			return fmt.Errorf("erro ao criptografar título: %w", err)
+		}
+		encContent, err := s.encryptSensitive(item.Content)
+		if err != nil {
+			return fmt.Errorf("erro ao criptografar conteúdo: %w", err)
+		}
+		encRecipient, err := s.encryptSensitive(item.Recipient)
+		if err != nil {
+			return fmt.Errorf("erro ao criptografar destinatário: %w", err)
+		}
+		encChecklist, err := s.checklistJSON(item.Checklist)
+		if err != nil {
+			return fmt.Errorf("erro ao criptografar lista: %w", err)
+		}
+		encSchedule, err := s.scheduleJSON(item.Schedule)
+		if err != nil {
+			return fmt.Errorf("erro ao criptografar agenda: %w", err)
+		}
+		encDocument, err := s.documentJSON(item.Document)
+		if err != nil {
+			return fmt.Errorf("erro ao criptografar documento: %w", err)
+		}
+		encPet, err := s.petJSON(item.Pet)
+		if err != nil {
+			return fmt.Errorf("erro ao criptografar perfil do pet: %w", err)
+		}
+		encAccount, err := s.financialAccountJSON(item.FinancialAccount)
+		if err != nil {
+			return fmt.Errorf("erro ao criptografar conta financeira: %w", err)
+		}
+		encOnline, err := s.onlineAccountJSON(item.OnlineAccount)
+		if err != nil {
+			return fmt.Errorf("erro ao criptografar conta online: %w", err)
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO box_items (id, user_id, type, title, content, category, recipient, is_important, is_shared, guardian_ids, created_at, updated_at, due_date, renewal_date, household_id, tags, content_bytes, recipient_guardian_id, sealed_params, checklist, schedule, document, pet, financial_account, online_account, archived_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, (SELECT id FROM households WHERE id = $15), $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26)
+		`, item.ID, user.ID, item.Type, encTitle, encContent, item.Category, encRecipient, item.IsImportant, item.IsShared, pq.Array(item.GuardianIDs),
+			item.CreatedAt, item.UpdatedAt, item.DueDate, item.RenewalDate, item.HouseholdID, pq.Array(itemTags(item.Tags)), item.ContentBytes(),
+			nullString(item.RecipientGuardianID), sealedParamsJSON(item.Sealed), encChecklist, encSchedule, encDocument, encPet, encAccount, encOnline, item.ArchivedAt); err != nil {
+			return fmt.Errorf("item %s: %w", item.ID, err)
+		}
+	}
+
+	for _, backup := range data.ShareLinks {
+		link := backup.ShareLink
+		encHint, err := s.encryptSensitive(link.PassphraseHint)
+		if err != nil {
+			return fmt.Errorf("erro ao criptografar dica da frase secreta: %w", err)
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO share_links (id, user_id, guardian_id, guardian_ids, token, type, name, pin_hash, categories, expires_at, max_uses, usage_count, last_used_at, is_active, created_at, updated_at, messages_only, item_ids, passphrase_hint)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+		`, link.ID, user.ID, nullString(link.GuardianID), pq.Array(link.GuardianIDs), backup.Token, link.Type, link.Name, nullString(backup.PIN),
+			pq.Array(link.Categories), link.ExpiresAt, link.MaxUses, link.UsageCount, link.LastUsedAt, link.IsActive, link.CreatedAt, link.UpdatedAt,
+			link.MessagesOnly, pq.Array(link.ItemIDs), nullString(encHint)); err != nil {
+			return fmt.Errorf("link %s: %w", link.ID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ============================================================================
+// HELPERS DE CRIPTOGRAFIA
+// ============================================================================
+
+// encryptSensitive criptografa um valor se não estiver vazio
+// Em caso de erro, falha fechado para evitar persistir PII em texto puro.
+func (s *PostgresStore) encryptSensitive(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if s.encryptor == nil {
+		return "", fmt.Errorf("encryptor not configured")
+	}
+	encrypted, err := s.encryptor.Encrypt(value)
+	if err != nil {
+		return "", err
+	}
+	return "enc:" + encrypted, nil
+}
+
+// decryptSensitive descriptografa um valor se estiver criptografado
+func (s *PostgresStore) decryptSensitive(value string) string {
+	if value == "" || s.encryptor == nil {
+		return value
+	}
+
+	encryptedValue := value
+	if strings.HasPrefix(value, "enc:") {
+		encryptedValue = strings.TrimPrefix(value, "enc:")
+		decrypted, err := s.encryptor.Decrypt(encryptedValue)
+		if err != nil {
+			return ""
+		}
+		return decrypted
+	}
+
+	if !isLikelyCiphertext(encryptedValue) {
+		return value
+	}
+
+	decrypted, err := s.encryptor.Decrypt(encryptedValue)
+	if err != nil {
+		return ""
+	}
+	return decrypted
+}
+
+func (s *PostgresStore) getOrCreateEncryptionSalt(envSalt string) ([]byte, error) {
+	envSalt = strings.TrimSpace(envSalt)
+	if envSalt != "" {
+		return decodeSalt(envSalt)
+	}
+
+	var stored string
+	err := s.db.QueryRow(`SELECT value FROM system_config WHERE key = $1`, "encryption_salt").Scan(&stored)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if stored == "" {
+		raw := make([]byte, 16)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+		stored = base64.StdEncoding.EncodeToString(raw)
+		_, err := s.db.Exec(`
+			INSERT INTO system_config (key, value, updated_at)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (key) DO UPDATE SET value = $2, updated_at = $3
+		`, "encryption_salt", stored, time.Now())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return decodeSalt(stored)
+}
+
+// ListSystemConfig retorna as configurações cujas chaves começam com prefix
+func (s *PostgresStore) ListSystemConfig(prefix string) (map[string]string, error) {
+	rows, err := s.db.Query(`SELECT key, value FROM system_config WHERE key LIKE $1`, escapeLike(prefix)+"%")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar configurações: %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("erro ao ler configuração: %w", err)
+		}
+		result[key] = value
+	}
+	return result, rows.Err()
+}
+
+// SetSystemConfig cria ou atualiza uma configuração
+func (s *PostgresStore) SetSystemConfig(key, value string) error {
+	_, err := s.db.Exec(`
+		INSERT INTO system_config (key, value, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO UPDATE SET value = $2, updated_at = $3
+	`, key, value, time.Now())
+	if err != nil {
+		return fmt.Errorf("erro ao salvar configuração: %w", err)
+	}
+	return nil
+}
+
+// DeleteSystemConfig remove uma configuração
+func (s *PostgresStore) DeleteSystemConfig(key string) error {
+	result, err := s.db.Exec(`DELETE FROM system_config WHERE key = $1`, key)
+	if err != nil {
+		return fmt.Errorf("erro ao remover configuração: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func decodeSalt(value string) ([]byte, error) {
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ENCRYPTION_SALT")
+	}
+	if len(decoded) < 16 {
+		return nil, fmt.Errorf("invalid ENCRYPTION_SALT length")
+	}
+	return decoded, nil
+}
+
+func isLikelyCiphertext(value string) bool {
+	if len(value) < 24 {
+		return false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return false
+	}
+	// nonce (12) + tag (16) + payload (>=0)
+	return len(decoded) >= 28
+}
+
+// ============================================================================
+// BOX ITEMS
+// ============================================================================
+
+// GetBoxItems retorna itens (compatibilidade - sem paginação)
+func (s *PostgresStore) GetBoxItems(userID string) ([]*BoxItem, error) {
+	return s.ListBoxItems(userID), nil
+}
+
+// ListBoxItems lista todos os itens (CUIDADO: sem paginação, usar apenas para exportação)
+// Em caso de erro, retorna lista vazia (comportamento esperado para exportação)
+func (s *PostgresStore) ListBoxItems(userID string) []*BoxItem {
+	// Query com campos específicos (não usa SELECT *)
+	rows, err := s.db.Query(`
+		SELECT id, user_id, type, title, content, category, recipient, is_important, is_shared, guardian_ids, created_at, updated_at, due_date, renewal_date, household_id, tags, recipient_guardian_id, sealed_params, checklist, schedule, document, pet, financial_account, online_account, version, archived_at
+		FROM box_items 
+		WHERE user_id = $1
+		ORDER BY updated_at DESC
+		LIMIT 1000
+	`, userID)
+	if err != nil {
+		return []*BoxItem{}
+	}
+	defer rows.Close()
+
+	var items []*BoxItem
+	for rows.Next() {
+		var item BoxItem
+		var title, content, category, recipient, householdID, recipientGuardianID, sealedParams, checklist, schedule, document, pet, financialAccount, onlineAccount sql.NullString
+		var guardianIDs, tags pq.StringArray
+		var dueDate, renewalDate, archivedAt sql.NullTime
+		err := rows.Scan(
+			&item.ID, &item.UserID, &item.Type, &title,
+			&content, &category, &recipient,
+			&item.IsImportant, &item.IsShared, &guardianIDs, &item.CreatedAt, &item.UpdatedAt,
+			&dueDate, &renewalDate, &householdID, &tags, &recipientGuardianID, &sealedParams, &checklist, &schedule, &document, &pet, &financialAccount, &onlineAccount, &item.Version, &archivedAt,
+		)
+		if err != nil {
+			// Pular itens com erro de leitura
+			continue
+		}
+		// Descriptografar dados sensíveis
+		item.Title = s.decryptSensitive(title.String)
+		item.Content = s.decryptSensitive(content.String)
+		item.Category = category.String
+		item.Recipient = s.decryptSensitive(recipient.String)
+		item.GuardianIDs = guardianIDs
+		item.Tags = tags
+		setItemDates(&item, dueDate, renewalDate)
+		if archivedAt.Valid {
+			item.ArchivedAt = &archivedAt.Time
+		}
+		item.HouseholdID = householdID.String
+		item.RecipientGuardianID = recipientGuardianID.String
+		item.Sealed = parseSealedParams(sealedParams)
+		item.Checklist = s.parseChecklist(checklist)
+		item.Schedule = s.parseSchedule(schedule)
+		item.Document = s.parseDocument(document)
+		item.Pet = s.parsePet(pet)
+		item.FinancialAccount = s.parseFinancialAccount(financialAccount)
+		item.OnlineAccount = s.parseOnlineAccount(onlineAccount)
+		items = append(items, &item)
+	}
+
+	return items
+}
+
+// ListBoxItemsPaginated lista itens com paginação (método preferido)
+// Usa cursor-based pagination para melhor performance
+func (s *PostgresStore) ListBoxItemsPaginated(userID string, params *PaginationParams) (*PaginatedResult[*BoxItemSummary], error) {
+	return s.listBoxItemSummaries(userID, `user_id = $1`, []interface{}{userID}, BoxItemSortNewest, params)
+}
+
+// accessibleItemsCondition monta o WHERE da listagem combinada (pessoal + famílias)
+func accessibleItemsCondition(filter *BoxItemFilter) (string, []interface{}) {
+	var condition string
+	var args []interface{}
+	if filter.IncludePersonal {
+		condition = `((user_id = $1 AND household_id IS NULL) OR household_id = ANY($2))`
+		args = []interface{}{filter.UserID, pq.Array(filter.HouseholdIDs)}
+	} else {
+		condition = `household_id = ANY($1)`
+		args = []interface{}{pq.Array(filter.HouseholdIDs)}
+	}
+
+	add := func(clause string, value interface{}) {
+		args = append(args, value)
+		condition += fmt.Sprintf(" AND "+clause, len(args))
+	}
+	if len(filter.Types) > 0 {
+		types := make([]string, len(filter.Types))
+		for i, t := range filter.Types {
+			types[i] = string(t)
+		}
+		add("type = ANY($%d)", pq.Array(types))
+	}
+	if filter.Category != "" {
+		add("LOWER(category) = LOWER($%d)", filter.Category)
+	}
+	if len(filter.Tags) > 0 {
+		add("tags @> $%d", pq.Array(filter.Tags))
+	}
+	if filter.Important != nil {
+		add("is_important = $%d", *filter.Important)
+	}
+	if filter.Shared != nil {
+		add("is_shared = $%d", *filter.Shared)
+	}
+	if filter.UpdatedSince != nil {
+		add("updated_at >= $%d", *filter.UpdatedSince)
+	}
+	if !filter.IncludeArchived {
+		condition += " AND archived_at IS NULL"
+	}
+	return condition, args
+}
+
+// boxItemsOrder retorna o ORDER BY e a condição do cursor (itens depois
+// da posição do cursor) para a ordenação pedida. Itens fixados vêm primeiro
+// em todas as ordenações.
+func boxItemsOrder(order BoxItemSort, cursor *itemCursor, args []interface{}) (string, string, []interface{}) {
+	var orderBy, column, op string
+	switch normalizeItemSort(order) {
+	case BoxItemSortOldest:
+		orderBy, column, op = "created_at ASC, id ASC", "created_at", ">"
+	case BoxItemSortUpdated:
+		orderBy, column, op = "updated_at DESC, id DESC", "updated_at", "<"
+	case BoxItemSortDue:
+		orderBy = "due_date ASC NULLS LAST, id ASC"
+	case BoxItemSortManual:
+		orderBy, column, op = "position ASC, created_at DESC, id DESC", "created_at", "<"
+	default:
+		orderBy, column, op = "created_at DESC, id DESC", "created_at", "<"
+	}
+	orderBy = "pinned DESC, " + orderBy
+	if cursor == nil {
+		return orderBy, "", args
+	}
+
+	var condition string
+	switch {
+	case column != "":
+		args = append(args, *cursor.Key, cursor.ID)
+		condition = fmt.Sprintf("(%s, id) %s ($%d, $%d)", column, op, len(args)-1, len(args))
+		if normalizeItemSort(order) == BoxItemSortManual {
+			args = append(args, cursor.Position)
+			condition = fmt.Sprintf("(position > $%[1]d OR (position = $%[1]d AND %[2]s))", len(args), condition)
+		}
+	case cursor.Key == nil:
+		// Vencimento: itens sem data ficam no fim
+		args = append(args, cursor.ID)
+		condition = fmt.Sprintf("(due_date IS NULL AND id > $%d)", len(args))
+	default:
+		args = append(args, *cursor.Key, cursor.ID)
+		condition = fmt.Sprintf("(due_date > $%[1]d OR (due_date = $%[1]d AND id > $%[2]d) OR due_date IS NULL)", len(args)-1, len(args))
+	}
+
+	// Fixados primeiro: depois de um fixado vêm os outros fixados e todos os não fixados
+	args = append(args, cursor.Pinned)
+	return orderBy, fmt.Sprintf("(pinned < $%[1]d OR (pinned = $%[1]d AND %[2]s))", len(args), condition), args
+}
+
+// ListAccessibleBoxItemsPaginated lista itens pessoais e das famílias (cursor-based)
+func (s *PostgresStore) ListAccessibleBoxItemsPaginated(filter *BoxItemFilter, params *PaginationParams) (*PaginatedResult[*BoxItemSummary], error) {
+	condition, args := accessibleItemsCondition(filter)
+	return s.listBoxItemSummaries(filter.UserID, condition, args, filter.Sort, params)
+}
+
+// listBoxItemSummaries executa a listagem paginada com o WHERE informado
+// Fixado e posição manual são os de viewerID (item_order).
+//
+// A ordenação "title" é feita aqui e não no banco, porque os títulos são
+// criptografados: carrega todos os itens do filtro e pagina em memória.
+func (s *PostgresStore) listBoxItemSummaries(viewerID, condition string, args []interface{}, order BoxItemSort, params *PaginationParams) (*PaginatedResult[*BoxItemSummary], error) {
+	params = NormalizePagination(params)
+	byTitle := normalizeItemSort(order) == BoxItemSortTitle
+
+	var cursor *itemCursor
+	if params.Cursor != "" {
+		var err error
+		if cursor, err = decodeItemCursor(params.Cursor, order); err != nil {
+			return nil, err
+		}
+	}
+
+	args = append(args, viewerID)
+	query := fmt.Sprintf(`
+		SELECT id, user_id, type, title, category, is_important, is_shared, guardian_ids, created_at, updated_at, due_date, renewal_date, household_id, tags, version, archived_at, pinned, position, document->>'expires_on'
+		FROM (
+			SELECT b.*, COALESCE(o.pinned, FALSE) AS pinned, COALESCE(o.position, 0) AS position
+			FROM box_items b
+			LEFT JOIN item_order o ON o.item_id = b.id AND o.user_id = $%d
+		) AS box_items
+		WHERE `, len(args)) + condition
+	if !byTitle {
+		orderBy, cursorCondition, orderArgs := boxItemsOrder(order, cursor, args)
+		args = orderArgs
+		if cursorCondition != "" {
+			query += " AND " + cursorCondition
+		}
+		// Busca limit+1 para detectar hasMore
+		args = append(args, params.Limit+1)
+		query += fmt.Sprintf(" ORDER BY %s LIMIT $%d", orderBy, len(args))
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar itens paginados: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*BoxItemSummary
+	for rows.Next() {
+		var item BoxItemSummary
+		var title, category, householdID, expiresOn sql.NullString
+		var guardianIDs, tags pq.StringArray
+		var dueDate, renewalDate, archivedAt sql.NullTime
+		err := rows.Scan(
+			&item.ID, &item.OwnerID, &item.Type, &title, &category,
+			&item.IsImportant, &item.IsShared, &guardianIDs, &item.CreatedAt, &item.UpdatedAt,
+			&dueDate, &renewalDate, &householdID, &tags, &item.Version, &archivedAt, &item.Pinned, &item.Position, &expiresOn,
+		)
+		if err != nil {
+			// Pular itens com erro de leitura
+			continue
+		}
+		item.Title = s.decryptSensitive(title.String)
+		item.Category = category.String
+		item.GuardianIDs = guardianIDs
+		item.Tags = tags
+		item.HouseholdID = householdID.String
+		if dueDate.Valid {
+			item.DueDate = &dueDate.Time
+		}
+		if renewalDate.Valid {
+			item.RenewalDate = &renewalDate.Time
+		}
+		if archivedAt.Valid {
+			item.ArchivedAt = &archivedAt.Time
+		}
+		if date, err := time.Parse("2006-01-02", expiresOn.String); err == nil {
+			item.ExpiresOn = &date
+		}
+		items = append(items, &item)
+	}
+
+	if byTitle {
+		sortItemSummaries(items, order)
+		return paginateItemSummaries(items, order, params)
+	}
+
+	hasMore := len(items) > params.Limit
+	if hasMore {
+		items = items[:params.Limit]
+	}
+
+	var nextCursor string
+	if hasMore && len(items) > 0 {
+		nextCursor = encodeItemCursor(order, items[len(items)-1])
+	}
+
+	return &PaginatedResult[*BoxItemSummary]{
+		Items:      items,
+		NextCursor: nextCursor,
+		HasMore:    hasMore,
+	}, nil
+}
+
+// CountAccessibleBoxItems conta itens pessoais e das famílias
+func (s *PostgresStore) CountAccessibleBoxItems(filter *BoxItemFilter) (int, error) {
+	condition, args := accessibleItemsCondition(filter)
+	var count int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM box_items WHERE `+condition, args...).Scan(&count)
+	return count, err
+}
+
+// ListScheduledRoutines lista as rotinas com agenda (pessoais e das famílias)
+func (s *PostgresStore) ListScheduledRoutines(filter *BoxItemFilter) ([]*BoxItem, error) {
+	condition, args := accessibleItemsCondition(filter)
+	rows, err := s.db.Query(`
+		SELECT id, user_id, type, title, content, category, recipient, is_important, is_shared, guardian_ids, created_at, updated_at, due_date, renewal_date, household_id, tags, recipient_guardian_id, sealed_params, checklist, schedule, document, pet, financial_account, online_account, version, archived_at
+		FROM box_items
+		WHERE `+condition+` AND type = 'routine' AND schedule IS NOT NULL
+		LIMIT 1000
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]*BoxItem, 0)
+	for rows.Next() {
+		item, err := s.scanBoxItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// ListExpiringDocuments lista os documentos vencidos ou que vencem até until
+// (pessoais e das famílias). A validade fica no JSON em AAAA-MM-DD, que se
+// compara como texto.
+func (s *PostgresStore) ListExpiringDocuments(filter *BoxItemFilter, until time.Time) ([]*BoxItem, error) {
+	condition, args := accessibleItemsCondition(filter)
+	args = append(args, until.Format("2006-01-02"))
+	rows, err := s.db.Query(fmt.Sprintf(`
+		SELECT id, user_id, type, title, content, category, recipient, is_important, is_shared, guardian_ids, created_at, updated_at, due_date, renewal_date, household_id, tags, recipient_guardian_id, sealed_params, checklist, schedule, document, pet, financial_account, online_account, version, archived_at
+		FROM box_items
+		WHERE `+condition+` AND type = 'document' AND document->>'expires_on' <= $%d
+		LIMIT 1000
+	`, len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]*BoxItem, 0)
+	for rows.Next() {
+		item, err := s.scanBoxItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// ListDocumentExpiries lista os documentos de todos os usuários que vencem
+// até until (sem os arquivados)
+func (s *PostgresStore) ListDocumentExpiries(until time.Time) ([]*DocumentExpiry, error) {
+	rows, err := s.db.Query(`
+		SELECT id, user_id, document->>'expires_on'
+		FROM box_items
+		WHERE type = 'document' AND archived_at IS NULL AND document->>'expires_on' <= $1
+	`, until.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*DocumentExpiry, 0)
+	for rows.Next() {
+		var expiry DocumentExpiry
+		var expiresOn string
+		if err := rows.Scan(&expiry.ItemID, &expiry.UserID, &expiresOn); err != nil {
+			return nil, err
+		}
+		if expiry.ExpiresOn, err = time.Parse("2006-01-02", expiresOn); err != nil {
+			continue
+		}
+		result = append(result, &expiry)
+	}
+	return result, rows.Err()
+}
+
+// ClaimExpiryReminder registra o lembrete de um documento
+// A chave primária garante um único envio por validade e antecedência, mesmo
+// com várias réplicas.
+func (s *PostgresStore) ClaimExpiryReminder(itemID string, expiresOn time.Time, daysBefore int, at time.Time) (bool, error) {
+	result, err := s.db.Exec(`
+		INSERT INTO expiry_reminders (item_id, expires_on, days_before, sent_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING
+	`, itemID, expiresOn.Format("2006-01-02"), daysBefore, at)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n == 1, err
+}
+
+// SetBoxItemPinned fixa (ou solta) o item na listagem do usuário
+func (s *PostgresStore) SetBoxItemPinned(userID, itemID string, pinned bool) error {
+	_, err := s.db.Exec(`
+		INSERT INTO item_order (user_id, item_id, pinned, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, item_id) DO UPDATE SET pinned = EXCLUDED.pinned, updated_at = EXCLUDED.updated_at
+	`, userID, itemID, pinned, time.Now())
+	return err
+}
+
+// ReorderBoxItems define a posição manual dos itens pela ordem da lista
+func (s *PostgresStore) ReorderBoxItems(userID string, itemIDs []string) error {
+	_, err := s.db.Exec(`
+		INSERT INTO item_order (user_id, item_id, position, updated_at)
+		SELECT $1, o.id, o.position, $3
+		FROM unnest($2::text[]) WITH ORDINALITY AS o(id, position)
+		ON CONFLICT (user_id, item_id) DO UPDATE SET position = EXCLUDED.position, updated_at = EXCLUDED.updated_at
+	`, userID, pq.Array(itemIDs), time.Now())
+	return err
+}
+
+// CountBoxItems conta o total de itens de um usuário
+func (s *PostgresStore) CountBoxItems(userID string) (int, error) {
+	var count int
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM box_items WHERE user_id = $1
+	`, userID).Scan(&count)
+	return count, err
+}
+
+// GetBoxItem busca um item específico por ID
+func (s *PostgresStore) GetBoxItem(userID, itemID string) (*BoxItem, error) {
+	// Query com campos específicos (não usa SELECT *)
+	return s.scanBoxItem(s.db.QueryRow(`
+		SELECT id, user_id, type, title, content, category, recipient, is_important, is_shared, guardian_ids, created_at, updated_at, due_date, renewal_date, household_id, tags, recipient_guardian_id, sealed_params, checklist, schedule, document, pet, financial_account, online_account, version, archived_at
+		FROM box_items 
+		WHERE user_id = $1 AND id = $2
+	`, userID, itemID))
+}
+
+// GetBoxItemByID busca um item sem filtrar pelo dono (o chamador verifica o acesso)
+func (s *PostgresStore) GetBoxItemByID(itemID string) (*BoxItem, error) {
+	return s.scanBoxItem(s.db.QueryRow(`
+		SELECT id, user_id, type, title, content, category, recipient, is_important, is_shared, guardian_ids, created_at, updated_at, due_date, renewal_date, household_id, tags, recipient_guardian_id, sealed_params, checklist, schedule, document, pet, financial_account, online_account, version, archived_at
+		FROM box_items 
+		WHERE id = $1
+	`, itemID))
+}
+
+// scanBoxItem lê um item completo e descriptografa os dados sensíveis
+// Aceita *sql.Row e *sql.Rows.
+func (s *PostgresStore) scanBoxItem(row interface{ Scan(...interface{}) error }) (*BoxItem, error) {
+	var item BoxItem
+	var title, content, category, recipient, householdID, recipientGuardianID, sealedParams, checklist, schedule, document, pet, financialAccount, onlineAccount sql.NullString
+	var guardianIDs, tags pq.StringArray
+	var dueDate, renewalDate, archivedAt sql.NullTime
+
+	err := row.Scan(
+		&item.ID, &item.UserID, &item.Type, &title,
+		&content, &category, &recipient,
+		&item.IsImportant, &item.IsShared, &guardianIDs, &item.CreatedAt, &item.UpdatedAt,
+		&dueDate, &renewalDate, &householdID, &tags, &recipientGuardianID, &sealedParams, &checklist, &schedule, &document, &pet, &financialAccount, &onlineAccount, &item.Version, &archivedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Descriptografar dados sensíveis
+	item.Title = s.decryptSensitive(title.String)
+	item.Content = s.decryptSensitive(content.String)
+	item.Category = category.String
+	item.Recipient = s.decryptSensitive(recipient.String)
+	item.GuardianIDs = guardianIDs
+	item.Tags = tags
+	setItemDates(&item, dueDate, renewalDate)
+	if archivedAt.Valid {
+		item.ArchivedAt = &archivedAt.Time
+	}
+	item.HouseholdID = householdID.String
+	item.RecipientGuardianID = recipientGuardianID.String
+	item.Sealed = parseSealedParams(sealedParams)
+	item.Checklist = s.parseChecklist(checklist)
+	item.Schedule = s.parseSchedule(schedule)
+	item.Document = s.parseDocument(document)
+	item.Pet = s.parsePet(pet)
+	item.FinancialAccount = s.parseFinancialAccount(financialAccount)
+	item.OnlineAccount = s.parseOnlineAccount(onlineAccount)
+	return &item, nil
+}
+
+// CreateBoxItem cria um novo item com dados criptografados
+func (s *PostgresStore) CreateBoxItem(userID string, item *BoxItem) (*BoxItem, error) {
+	id := ids.New(ids.Item)
+	return s.CreateBoxItemWithID(userID, item, id)
+}
+
+// CreateBoxItemWithID cria um novo item com ID pré-definido (idempotência).
+func (s *PostgresStore) CreateBoxItemWithID(userID string, item *BoxItem, itemID string) (*BoxItem, error) {
+	now := time.Now()
+
+	// Criptografar dados sensíveis antes de salvar
+	encTitle, err := s.encryptSensitive(item.Title)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criptografar título: %w", err)
+	}
+	encContent, err := s.encryptSensitive(item.Content)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criptografar conteúdo: %w", err)
+	}
+	encRecipient, err := s.encryptSensitive(item.Recipient)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criptografar destinatário: %w", err)
+	}
+	encChecklist, err := s.checklistJSON(item.Checklist)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criptografar lista: %w", err)
+	}
+	encSchedule, err := s.scheduleJSON(item.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criptografar agenda: %w", err)
+	}
+	encDocument, err := s.documentJSON(item.Document)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criptografar documento: %w", err)
+	}
+	encPet, err := s.petJSON(item.Pet)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criptografar perfil do pet: %w", err)
+	}
+	encAccount, err := s.financialAccountJSON(item.FinancialAccount)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criptografar conta financeira: %w", err)
+	}
+	encOnline, err := s.onlineAccountJSON(item.OnlineAccount)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criptografar conta online: %w", err)
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO box_items (id, user_id, type, title, content, category, recipient, is_important, is_shared, guardian_ids, created_at, updated_at, due_date, renewal_date, household_id, tags, content_bytes, recipient_guardian_id, sealed_params, checklist, schedule, document, pet, financial_account, online_account)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25)
+	`, itemID, userID, item.Type, encTitle, encContent, item.Category, encRecipient, item.IsImportant, item.IsShared, pq.Array(item.GuardianIDs), now, now,
+		item.DueDate, item.RenewalDate, nullString(item.HouseholdID), pq.Array(itemTags(item.Tags)), item.ContentBytes(), nullString(item.RecipientGuardianID),
+		sealedParamsJSON(item.Sealed), encChecklist, encSchedule, encDocument, encPet, encAccount, encOnline)
+
+	if err != nil {
+		return nil, err
+	}
+
+	item.ID = itemID
+	item.UserID = userID
+	item.CreatedAt = now
+	item.UpdatedAt = now
+	item.Version = 1
+	return item, nil
+}
+
+// UpdateBoxItem atualiza um item existente com dados criptografados
+// A versão é conferida e incrementada no mesmo UPDATE (sem janela entre
+// leitura e escrita).
+func (s *PostgresStore) UpdateBoxItem(userID, itemID string, updates *BoxItem) (*BoxItem, error) {
+	// Criptografar dados sensíveis antes de atualizar
+	encTitle, err := s.encryptSensitive(updates.Title)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criptografar título: %w", err)
+	}
+	encContent, err := s.encryptSensitive(updates.Content)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criptografar conteúdo: %w", err)
+	}
+	encRecipient, err := s.encryptSensitive(updates.Recipient)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criptografar destinatário: %w", err)
+	}
+	encChecklist, err := s.checklistJSON(updates.Checklist)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criptografar lista: %w", err)
+	}
+	encSchedule, err := s.scheduleJSON(updates.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criptografar agenda: %w", err)
+	}
+	encDocument, err := s.documentJSON(updates.Document)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criptografar documento: %w", err)
+	}
+	encPet, err := s.petJSON(updates.Pet)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criptografar perfil do pet: %w", err)
+	}
+	encAccount, err := s.financialAccountJSON(updates.FinancialAccount)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criptografar conta financeira: %w", err)
+	}
+	encOnline, err := s.onlineAccountJSON(updates.OnlineAccount)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criptografar conta online: %w", err)
+	}
+
+	result, err := s.db.Exec(`
+		UPDATE box_items 
+		SET title = $1, content = $2, type = $3, category = $4, recipient = $5, is_important = $6, is_shared = $7, guardian_ids = $8, updated_at = $9,
+			due_date = $10, renewal_date = $11, household_id = $12, tags = $13, content_bytes = $14, recipient_guardian_id = $15, sealed_params = $16,
+			archived_at = $20, checklist = $21, schedule = $22, document = $23, pet = $24, financial_account = $25, online_account = $26, version = version + 1
+		WHERE user_id = $17 AND id = $18 AND ($19 = 0 OR version = $19)
+	`, encTitle, encContent, updates.Type, updates.Category, encRecipient, updates.IsImportant, updates.IsShared, pq.Array(updates.GuardianIDs), time.Now(),
+		updates.DueDate, updates.RenewalDate, nullString(updates.HouseholdID), pq.Array(itemTags(updates.Tags)), updates.ContentBytes(),
+		nullString(updates.RecipientGuardianID), sealedParamsJSON(updates.Sealed), userID, itemID, updates.Version, updates.ArchivedAt, encChecklist, encSchedule, encDocument, encPet, encAccount, encOnline)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		// Nada atualizado: o item não existe ou está em outra versão
+		var exists bool
+		if err := s.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM box_items WHERE user_id = $1 AND id = $2)`, userID, itemID).Scan(&exists); err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, ErrVersionConflict
+		}
+		return nil, ErrNotFound
+	}
+
+	return s.GetBoxItem(userID, itemID)
+}
+
+// SetChecklistEntryDone marca (ou desmarca) uma linha da lista do item
+// O texto das linhas continua criptografado: só o estado muda.
+func (s *PostgresStore) SetChecklistEntryDone(userID, itemID, entryID string, done bool) (*BoxItem, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var raw sql.NullString
+	err = tx.QueryRow(`
+		SELECT checklist FROM box_items WHERE user_id = $1 AND id = $2 FOR UPDATE
+	`, userID, itemID).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var checklist []ChecklistEntry
+	if raw.Valid && raw.String != "" {
+		if err := json.Unmarshal([]byte(raw.String), &checklist); err != nil {
+			return nil, fmt.Errorf("erro ao ler lista: %w", err)
+		}
+	}
+	checklist, err = setChecklistDone(checklist, entryID, done, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(checklist)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE box_items SET checklist = $1, updated_at = $2, version = version + 1
+		WHERE user_id = $3 AND id = $4
+	`, string(data), time.Now(), userID, itemID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.GetBoxItem(userID, itemID)
+}
+
+// ListDatedBoxItems lista os itens com vencimento ou renovação (sem conteúdo)
+func (s *PostgresStore) ListDatedBoxItems(userID string) ([]*BoxItem, error) {
+	rows, err := s.db.Query(`
+		SELECT id, user_id, type, title, category, is_important, created_at, updated_at, due_date, renewal_date, household_id
+		FROM box_items
+		WHERE user_id = $1 AND (due_date IS NOT NULL OR renewal_date IS NOT NULL) AND archived_at IS NULL
+		ORDER BY COALESCE(due_date, renewal_date)
+		LIMIT 1000
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar itens com datas: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]*BoxItem, 0)
+	for rows.Next() {
+		var item BoxItem
+		var title, category, householdID sql.NullString
+		var dueDate, renewalDate sql.NullTime
+		err := rows.Scan(
+			&item.ID, &item.UserID, &item.Type, &title, &category, &item.IsImportant,
+			&item.CreatedAt, &item.UpdatedAt, &dueDate, &renewalDate, &householdID,
+		)
+		if err != nil {
+			continue
+		}
+		item.Title = s.decryptSensitive(title.String)
+		item.Category = category.String
+		setItemDates(&item, dueDate, renewalDate)
+		item.HouseholdID = householdID.String
+		items = append(items, &item)
+	}
+	return items, rows.Err()
+}
+
+func (s *PostgresStore) DeleteBoxItem(userID, itemID string) error {
+	result, err := s.db.Exec(`
+		DELETE FROM box_items WHERE user_id = $1 AND id = $2
+	`, userID, itemID)
+
+	if err != nil {
+		return err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// ============================================================================
+// RELAÇÕES ENTRE ITENS
+// ============================================================================
+
+// CreateItemRelation grava um vínculo do item
+// Um "veja também" entre A e B é o mesmo vínculo que entre B e A.
+func (s *PostgresStore) CreateItemRelation(rel *ItemRelation) error {
+	if rel.ID == "" {
+		rel.ID = ids.New(ids.Relation)
+	}
+	if rel.CreatedAt.IsZero() {
+		rel.CreatedAt = time.Now()
+	}
+
+	var targetItemID, targetGuardianID sql.NullString
+	if rel.Kind == RelationSeeAlso {
+		targetItemID = nullString(rel.TargetID)
+	} else {
+		targetGuardianID = nullString(rel.TargetID)
+	}
+
+	result, err := s.db.Exec(`
+		INSERT INTO item_relations (id, item_id, kind, target_item_id, target_guardian_id, created_by, created_at)
+		SELECT $1, $2, $3, $4, $5, $6, $7::timestamp
+		WHERE NOT EXISTS (
+			SELECT 1 FROM item_relations
+			WHERE $3 = 'see_also' AND kind = 'see_also' AND item_id = $4 AND target_item_id = $2
+		)
+		ON CONFLICT DO NOTHING
+	`, rel.ID, rel.ItemID, string(rel.Kind), targetItemID, targetGuardianID, nullString(rel.CreatedBy), rel.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrAlreadyExists
+	}
+	return nil
+}
+
+// ListItemRelations lista os vínculos do item (mais antigos primeiro)
+func (s *PostgresStore) ListItemRelations(itemID string) ([]*ItemRelation, error) {
+	rows, err := s.db.Query(`
+		SELECT id, item_id, kind, COALESCE(target_item_id, target_guardian_id), created_by, created_at
+		FROM item_relations
+		WHERE item_id = $1 OR (kind = 'see_also' AND target_item_id = $1)
+		ORDER BY created_at, id
+	`, itemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*ItemRelation, 0)
+	for rows.Next() {
+		var rel ItemRelation
+		var createdBy sql.NullString
+		if err := rows.Scan(&rel.ID, &rel.ItemID, &rel.Kind, &rel.TargetID, &createdBy, &rel.CreatedAt); err != nil {
+			return nil, err
+		}
+		rel.CreatedBy = createdBy.String
+		result = append(result, &rel)
+	}
+	return result, rows.Err()
+}
+
+// DeleteItemRelation remove um vínculo do item
+func (s *PostgresStore) DeleteItemRelation(itemID, relationID string) error {
+	result, err := s.db.Exec(`
+		DELETE FROM item_relations
+		WHERE id = $1 AND (item_id = $2 OR (kind = 'see_also' AND target_item_id = $2))
+	`, relationID, itemID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ============================================================================
+// RECIBOS DE LEITURA
+// ============================================================================
+
+// RecordItemViews registra que a origem viu os itens
+func (s *PostgresStore) RecordItemViews(source ItemViewSource, sourceID string, itemIDs []string, at time.Time) (map[string]bool, error) {
+	seenBefore := make(map[string]bool, len(itemIDs))
+	if len(itemIDs) == 0 {
+		return seenBefore, nil
+	}
+
+	rows, err := s.db.Query(`
+		INSERT INTO item_views (item_id, source, source_id, first_viewed_at, last_viewed_at, view_count)
+		SELECT b.id, $1, $2, $4::timestamp, $4::timestamp, 1
+		FROM box_items b WHERE b.id = ANY($3)
+		ON CONFLICT (item_id, source, source_id) DO UPDATE
+		SET last_viewed_at = EXCLUDED.last_viewed_at, view_count = item_views.view_count + 1
+		RETURNING item_id, view_count > 1
+	`, string(source), sourceID, pq.Array(itemIDs), at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var itemID string
+		var seen bool
+		if err := rows.Scan(&itemID, &seen); err != nil {
+			return nil, err
+		}
+		seenBefore[itemID] = seen
+	}
+	return seenBefore, rows.Err()
+}
+
+// ListItemViews lista os recibos de leitura do item
+func (s *PostgresStore) ListItemViews(itemID string) ([]*ItemView, error) {
+	rows, err := s.db.Query(`
+		SELECT item_id, source, source_id, first_viewed_at, last_viewed_at, view_count
+		FROM item_views
+		WHERE item_id = $1
+		ORDER BY last_viewed_at DESC
+	`, itemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*ItemView, 0)
+	for rows.Next() {
+		var view ItemView
+		if err := rows.Scan(&view.ItemID, &view.Source, &view.SourceID, &view.FirstViewedAt, &view.LastViewedAt, &view.ViewCount); err != nil {
+			return nil, err
+		}
+		result = append(result, &view)
+	}
+	return result, rows.Err()
+}
+
+// ============================================================================
+// GUARDIANS
+// ============================================================================
+
+// GetGuardians retorna guardiões (compatibilidade)
+func (s *PostgresStore) GetGuardians(userID string) ([]*Guardian, error) {
+	return s.ListGuardians(userID), nil
+}
+
+// ListGuardians lista todos os guardiões (CUIDADO: sem paginação)
+// Em caso de erro, retorna lista vazia
+func (s *PostgresStore) ListGuardians(userID string) []*Guardian {
+	rows, err := s.db.Query(`
+		SELECT id, user_id, name, email, phone, relationship, role, notes, access_token, access_pin, access_type, notify_channel, account_user_id, created_at, updated_at, deletion_requested_at, access_disabled_at
+		FROM guardians 
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT 100
+	`, userID)
+	if err != nil {
+		return []*Guardian{}
+	}
+	defer rows.Close()
+
+	var guardians []*Guardian
+	for rows.Next() {
+		var g Guardian
+		var name, email, phone, relationship, notes, accessToken, accessPIN, accessType, notifyChannel, accountUserID sql.NullString
+		var deletionRequestedAt, accessDisabledAt sql.NullTime
+		err := rows.Scan(
+			&g.ID, &g.UserID, &name, &email, &phone,
+			&relationship, &g.Role, &notes, &accessToken, &accessPIN, &accessType, &notifyChannel, &accountUserID,
+			&g.CreatedAt, &g.UpdatedAt, &deletionRequestedAt, &accessDisabledAt,
+		)
+		if err != nil {
+			// Pular guardiões com erro de leitura
+			continue
+		}
+		// Descriptografar dados sensíveis (PII)
+		g.Name = s.decryptSensitive(name.String)
+		g.Email = s.decryptSensitive(email.String)
+		g.Phone = s.decryptSensitive(phone.String)
+		g.Relationship = relationship.String
+		g.Notes = s.decryptSensitive(notes.String)
+		g.AccessToken = accessToken.String
+		g.AccessPIN = accessPIN.String
+		g.HasPIN = accessPIN.String != ""
+		g.AccessType = GuardianAccessType(accessType.String)
+		g.NotifyChannel = NotifyChannel(notifyChannel.String)
+		g.AccountUserID = accountUserID.String
+		g.HasAccount = accountUserID.String != ""
+		if deletionRequestedAt.Valid {
+			g.DeletionRequestedAt = &deletionRequestedAt.Time
+		}
+		if accessDisabledAt.Valid {
+			g.AccessDisabledAt = &accessDisabledAt.Time
+		}
+
+		guardians = append(guardians, &g)
+	}
+
+	return guardians
+}
+
+// ListGuardiansPaginated lista guardiões com paginação
+func (s *PostgresStore) ListGuardiansPaginated(userID string, params *PaginationParams) (*PaginatedResult[*Guardian], error) {
+	params = NormalizePagination(params)
+
+	var rows *sql.Rows
+	var err error
+
+	if params.Cursor != "" {
+		rows, err = s.db.Query(`
+			SELECT id, user_id, name, email, phone, relationship, role, notes, access_token, access_type, notify_channel, account_user_id, created_at, updated_at, deletion_requested_at, access_disabled_at
+			FROM guardians 
+			WHERE user_id = $1 AND id < $2
+			ORDER BY id DESC
+			LIMIT $3
+		`, userID, params.Cursor, params.Limit+1)
+	} else {
+		rows, err = s.db.Query(`
+			SELECT id, user_id, name, email, phone, relationship, role, notes, access_token, access_type, notify_channel, account_user_id, created_at, updated_at, deletion_requested_at, access_disabled_at
+			FROM guardians 
+			WHERE user_id = $1
+			ORDER BY id DESC
+			LIMIT $2
+		`, userID, params.Limit+1)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar guardiões paginados: %w", err)
+	}
+	defer rows.Close()
+
+	var guardians []*Guardian
+	for rows.Next() {
+		var g Guardian
+		var name, email, phone, relationship, notes, accessToken, accessType, notifyChannel, accountUserID sql.NullString
+		var deletionRequestedAt, accessDisabledAt sql.NullTime
+		err := rows.Scan(
+			&g.ID, &g.UserID, &name, &email, &phone,
+			&relationship, &g.Role, &notes, &accessToken, &accessType, &notifyChannel, &accountUserID,
+			&g.CreatedAt, &g.UpdatedAt, &deletionRequestedAt, &accessDisabledAt,
+		)
+		if err != nil {
+			continue
+		}
+		g.Name = s.decryptSensitive(name.String)
+		g.Email = s.decryptSensitive(email.String)
+		g.Phone = s.decryptSensitive(phone.String)
+		g.Relationship = relationship.String
+		g.Notes = s.decryptSensitive(notes.String)
+		g.AccessToken = accessToken.String
+		g.AccessType = GuardianAccessType(accessType.String)
+		g.NotifyChannel = NotifyChannel(notifyChannel.String)
+		g.AccountUserID = accountUserID.String
+		g.HasAccount = accountUserID.String != ""
+		if deletionRequestedAt.Valid {
+			g.DeletionRequestedAt = &deletionRequestedAt.Time
+		}
+		if accessDisabledAt.Valid {
+			g.AccessDisabledAt = &accessDisabledAt.Time
+		}
+		guardians = append(guardians, &g)
+	}
+
+	hasMore := len(guardians) > params.Limit
+	if hasMore {
+		guardians = guardians[:params.Limit]
+	}
+
+	var nextCursor string
+	if hasMore && len(guardians) > 0 {
+		nextCursor = guardians[len(guardians)-1].ID
+	}
+
+	return &PaginatedResult[*Guardian]{
+		Items:      guardians,
+		NextCursor: nextCursor,
+		HasMore:    hasMore,
+	}, nil
+}
+
+// backfillGuardianAccessTokens gera o token dos guardiões sem token ou com
+// o formato antigo ("gat_..."). Roda ao iniciar o servidor, depois das
+// migrações; os links antigos desses guardiões deixam de funcionar.
+func (s *PostgresStore) backfillGuardianAccessTokens() (int, error) {
+	rows, err := s.db.Query(`
+		SELECT id FROM guardians
+		WHERE access_token IS NULL OR access_token = '' OR access_token LIKE 'gat\_%'
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao buscar tokens de guardiões: %w", err)
+	}
+	var guardianIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		guardianIDs = append(guardianIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, id := range guardianIDs {
+		if _, err := s.RotateGuardianAccessToken(id); err != nil && !errors.Is(err, ErrNotFound) {
+			return 0, fmt.Errorf("erro ao gerar token do guardião %s: %w", id, err)
+		}
+	}
+	return len(guardianIDs), nil
+}
+
+// CountGuardians conta o total de guardiões de um usuário
 func (s *PostgresStore) CountGuardians(userID string) (int, error) {
 	var count int
 	err := s.db.QueryRow(`
-		SELECT COUNT(*) FROM guardians WHERE user_id = $1
-	`, userID).Scan(&count)
-	return count, err
+		SELECT COUNT(*) FROM guardians WHERE user_id = $1
+	`, userID).Scan(&count)
+	return count, err
+}
+
+// GetGuardianByAccessToken busca um guardião pelo seu token de acesso
+func (s *PostgresStore) GetGuardianByAccessToken(token string) (*Guardian, error) {
+	var g Guardian
+	var name, email, phone, relationship, notes, accessToken, accessPIN, accessType, notifyChannel, accountUserID sql.NullString
+	var deletionRequestedAt, accessDisabledAt sql.NullTime
+
+	err := s.db.QueryRow(`
+		SELECT id, user_id, name, email, phone, relationship, role, notes, access_token, access_pin, access_type, notify_channel, account_user_id, created_at, updated_at, deletion_requested_at, access_disabled_at
+		FROM guardians 
+		WHERE access_token = $1
+	`, token).Scan(
+		&g.ID, &g.UserID, &name, &email, &phone,
+		&relationship, &g.Role, &notes, &accessToken, &accessPIN, &accessType, &notifyChannel, &accountUserID,
+		&g.CreatedAt, &g.UpdatedAt, &deletionRequestedAt, &accessDisabledAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	g.Name = s.decryptSensitive(name.String)
+	g.Email = s.decryptSensitive(email.String)
+	g.Phone = s.decryptSensitive(phone.String)
+	g.Relationship = relationship.String
+	g.Notes = s.decryptSensitive(notes.String)
+	g.AccessToken = accessToken.String
+	g.AccessPIN = accessPIN.String
+	g.HasPIN = accessPIN.String != ""
+	g.AccessType = GuardianAccessType(accessType.String)
+	g.NotifyChannel = NotifyChannel(notifyChannel.String)
+	g.AccountUserID = accountUserID.String
+	g.HasAccount = accountUserID.String != ""
+	if deletionRequestedAt.Valid {
+		g.DeletionRequestedAt = &deletionRequestedAt.Time
+	}
+	if accessDisabledAt.Valid {
+		g.AccessDisabledAt = &accessDisabledAt.Time
+	}
+	return &g, nil
+}
+
+// ListGuardiansByAccount lista os guardiões vinculados a uma conta (de todos os donos)
+func (s *PostgresStore) ListGuardiansByAccount(accountUserID string) ([]*Guardian, error) {
+	rows, err := s.db.Query(`
+		SELECT id, user_id, name, email, phone, relationship, role, notes, access_token, access_pin, access_type, notify_channel, account_user_id, created_at, updated_at, deletion_requested_at, access_disabled_at
+		FROM guardians
+		WHERE account_user_id = $1
+		ORDER BY created_at DESC
+		LIMIT 100
+	`, accountUserID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar vínculos do guardião: %w", err)
+	}
+	defer rows.Close()
+
+	guardians := make([]*Guardian, 0)
+	for rows.Next() {
+		var g Guardian
+		var name, email, phone, relationship, notes, accessToken, accessPIN, accessType, notifyChannel, accountUserID sql.NullString
+		var deletionRequestedAt, accessDisabledAt sql.NullTime
+		err := rows.Scan(
+			&g.ID, &g.UserID, &name, &email, &phone,
+			&relationship, &g.Role, &notes, &accessToken, &accessPIN, &accessType, &notifyChannel, &accountUserID,
+			&g.CreatedAt, &g.UpdatedAt, &deletionRequestedAt, &accessDisabledAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		g.Name = s.decryptSensitive(name.String)
+		g.Email = s.decryptSensitive(email.String)
+		g.Phone = s.decryptSensitive(phone.String)
+		g.Relationship = relationship.String
+		g.Notes = s.decryptSensitive(notes.String)
+		g.AccessToken = accessToken.String
+		g.AccessPIN = accessPIN.String
+		g.HasPIN = accessPIN.String != ""
+		g.AccessType = GuardianAccessType(accessType.String)
+		g.NotifyChannel = NotifyChannel(notifyChannel.String)
+		g.AccountUserID = accountUserID.String
+		g.HasAccount = true
+		if deletionRequestedAt.Valid {
+			g.DeletionRequestedAt = &deletionRequestedAt.Time
+		}
+		if accessDisabledAt.Valid {
+			g.AccessDisabledAt = &accessDisabledAt.Time
+		}
+		guardians = append(guardians, &g)
+	}
+	return guardians, rows.Err()
+}
+
+// LinkGuardianAccount vincula (ou desvincula, com "") a conta ao guardião
+func (s *PostgresStore) LinkGuardianAccount(guardianID, accountUserID string) error {
+	result, err := s.db.Exec(`
+		UPDATE guardians SET account_user_id = $1 WHERE id = $2
+	`, nullString(accountUserID), guardianID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RotateGuardianAccessToken gera um novo token de acesso para o guardião
+// O link anterior deixa de funcionar imediatamente.
+func (s *PostgresStore) RotateGuardianAccessToken(guardianID string) (string, error) {
+	token := security.GenerateAccessToken()
+	result, err := s.db.Exec(`
+		UPDATE guardians SET access_token = $1, updated_at = $2 WHERE id = $3
+	`, token, time.Now(), guardianID)
+	if err != nil {
+		return "", err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return "", ErrNotFound
+	}
+	return token, nil
+}
+
+// SetGuardianAccessDisabled desativa (ou, com nil, reativa) o acesso do guardião
+func (s *PostgresStore) SetGuardianAccessDisabled(guardianID string, at *time.Time) error {
+	result, err := s.db.Exec(`
+		UPDATE guardians SET access_disabled_at = $1, updated_at = $2 WHERE id = $3
+	`, at, time.Now(), guardianID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetGuardianAccessPolicy busca as regras de acesso do guardião
+func (s *PostgresStore) GetGuardianAccessPolicy(guardianID string) (*GuardianAccessPolicy, error) {
+	policy := &GuardianAccessPolicy{GuardianID: guardianID}
+	var expiresAt, notifiedAt sql.NullTime
+	var windows []byte
+	err := s.db.QueryRow(`
+		SELECT expires_at, windows, timezone, max_accesses, access_count, blocked_notified_at, updated_at
+		FROM guardian_access_policies
+		WHERE guardian_id = $1
+	`, guardianID).Scan(&expiresAt, &windows, &policy.Timezone, &policy.MaxAccesses, &policy.AccessCount, &notifiedAt, &policy.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(windows) > 0 {
+		if err := json.Unmarshal(windows, &policy.Windows); err != nil {
+			return nil, fmt.Errorf("erro ao ler janelas de acesso: %w", err)
+		}
+	}
+	if expiresAt.Valid {
+		policy.ExpiresAt = &expiresAt.Time
+	}
+	if notifiedAt.Valid {
+		policy.BlockedNotifiedAt = &notifiedAt.Time
+	}
+	return policy, nil
+}
+
+// SaveGuardianAccessPolicy cria ou substitui as regras de acesso do guardião
+func (s *PostgresStore) SaveGuardianAccessPolicy(policy *GuardianAccessPolicy) error {
+	windows, err := json.Marshal(policy.Windows)
+	if err != nil {
+		return err
+	}
+	policy.UpdatedAt = time.Now()
+	_, err = s.db.Exec(`
+		INSERT INTO guardian_access_policies (guardian_id, expires_at, windows, timezone, max_accesses, access_count, blocked_notified_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (guardian_id) DO UPDATE
+		SET expires_at = EXCLUDED.expires_at, windows = EXCLUDED.windows, timezone = EXCLUDED.timezone,
+			max_accesses = EXCLUDED.max_accesses, access_count = EXCLUDED.access_count,
+			blocked_notified_at = EXCLUDED.blocked_notified_at, updated_at = EXCLUDED.updated_at
+	`, policy.GuardianID, policy.ExpiresAt, windows, policy.Timezone, policy.MaxAccesses, policy.AccessCount,
+		policy.BlockedNotifiedAt, policy.UpdatedAt)
+	return err
+}
+
+// DeleteGuardianAccessPolicy remove as regras de acesso do guardião
+func (s *PostgresStore) DeleteGuardianAccessPolicy(guardianID string) error {
+	result, err := s.db.Exec(`DELETE FROM guardian_access_policies WHERE guardian_id = $1`, guardianID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ClaimGuardianPolicyAccess conta um acesso ao conteúdo nas regras do
+// guardião, se o limite ainda permitir (um UPDATE condicional: aberturas
+// simultâneas não passam de max_accesses). Sem regras, nada a contar.
+func (s *PostgresStore) ClaimGuardianPolicyAccess(guardianID string) (bool, error) {
+	result, err := s.db.Exec(`
+		UPDATE guardian_access_policies SET access_count = access_count + 1
+		WHERE guardian_id = $1 AND (max_accesses = 0 OR access_count < max_accesses)
+	`, guardianID)
+	if err != nil {
+		return false, err
+	}
+	if rows, _ := result.RowsAffected(); rows > 0 {
+		return true, nil
+	}
+
+	var exists bool
+	err = s.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM guardian_access_policies WHERE guardian_id = $1)`, guardianID).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+	return !exists, nil
+}
+
+// MarkGuardianPolicyBlocked registra o aviso de acesso barrado, se o último
+// foi antes de notifiedBefore (um UPDATE condicional: só uma réplica avisa)
+func (s *PostgresStore) MarkGuardianPolicyBlocked(guardianID string, notifiedBefore time.Time) (bool, error) {
+	result, err := s.db.Exec(`
+		UPDATE guardian_access_policies SET blocked_notified_at = $2
+		WHERE guardian_id = $1 AND (blocked_notified_at IS NULL OR blocked_notified_at < $3)
+	`, guardianID, time.Now(), notifiedBefore)
+	if err != nil {
+		return false, err
+	}
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// ListItemViewsBySource lista os recibos de leitura de uma origem
+func (s *PostgresStore) ListItemViewsBySource(source ItemViewSource, sourceID string) ([]*ItemView, error) {
+	rows, err := s.db.Query(`
+		SELECT item_id, source, source_id, first_viewed_at, last_viewed_at, view_count
+		FROM item_views
+		WHERE source = $1 AND source_id = $2
+		ORDER BY last_viewed_at DESC
+	`, string(source), sourceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*ItemView, 0)
+	for rows.Next() {
+		var view ItemView
+		if err := rows.Scan(&view.ItemID, &view.Source, &view.SourceID, &view.FirstViewedAt, &view.LastViewedAt, &view.ViewCount); err != nil {
+			return nil, err
+		}
+		result = append(result, &view)
+	}
+	return result, rows.Err()
+}
+
+// RequestGuardianDeletion marca o guardião para remoção
+// Pedidos repetidos mantêm a data do primeiro.
+func (s *PostgresStore) RequestGuardianDeletion(guardianID string, at time.Time) (*Guardian, error) {
+	var userID string
+	var requestedAt time.Time
+	err := s.db.QueryRow(`
+		UPDATE guardians
+		SET deletion_requested_at = COALESCE(deletion_requested_at, $1), updated_at = $1
+		WHERE id = $2
+		RETURNING user_id, deletion_requested_at
+	`, at, guardianID).Scan(&userID, &requestedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Guardian{ID: guardianID, UserID: userID, DeletionRequestedAt: &requestedAt}, nil
+}
+
+// PurgeGuardiansPendingDeletion apaga os guardiões com remoção pedida antes
+// de requestedBefore, junto com os recibos de leitura e vínculos
+func (s *PostgresStore) PurgeGuardiansPendingDeletion(requestedBefore time.Time) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		DELETE FROM guardians
+		WHERE deletion_requested_at IS NOT NULL AND deletion_requested_at <= $1
+		RETURNING id
+	`, requestedBefore)
+	if err != nil {
+		return 0, err
+	}
+	var guardianIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		guardianIDs = append(guardianIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(guardianIDs) == 0 {
+		return 0, nil
+	}
+
+	if _, err := tx.Exec(`
+		DELETE FROM item_views WHERE source = $1 AND source_id = ANY($2)
+	`, string(ItemViewGuardian), pq.Array(guardianIDs)); err != nil {
+		return 0, err
+	}
+	// Vínculos (item_relations) e destinatários (box_items) saem pelas FKs
+	return len(guardianIDs), tx.Commit()
+}
+
+// ListSharedItems lista itens compartilhados de um usuário
+func (s *PostgresStore) ListSharedItems(userID string) []*BoxItem {
+	rows, err := s.db.Query(`
+		SELECT id, user_id, type, title, content, category, recipient, is_important, is_shared, guardian_ids, created_at, updated_at, due_date, renewal_date, household_id, tags, recipient_guardian_id, sealed_params, checklist, schedule, document, pet, financial_account, online_account
+		FROM box_items 
+		WHERE user_id = $1 AND is_shared = TRUE AND archived_at IS NULL
+		ORDER BY updated_at DESC
+		LIMIT 100
+	`, userID)
+	if err != nil {
+		return []*BoxItem{}
+	}
+	defer rows.Close()
+
+	var items []*BoxItem
+	for rows.Next() {
+		var item BoxItem
+		var title, content, category, recipient, householdID, recipientGuardianID, sealedParams, checklist, schedule, document, pet, financialAccount, onlineAccount sql.NullString
+		var guardianIDs, tags pq.StringArray
+		var dueDate, renewalDate sql.NullTime
+		err := rows.Scan(
+			&item.ID, &item.UserID, &item.Type, &title,
+			&content, &category, &recipient,
+			&item.IsImportant, &item.IsShared, &guardianIDs, &item.CreatedAt, &item.UpdatedAt,
+			&dueDate, &renewalDate, &householdID, &tags, &recipientGuardianID, &sealedParams, &checklist, &schedule, &document, &pet, &financialAccount, &onlineAccount,
+		)
+		if err != nil {
+			continue
+		}
+		item.Title = s.decryptSensitive(title.String)
+		item.Content = s.decryptSensitive(content.String)
+		item.Category = category.String
+		item.Recipient = s.decryptSensitive(recipient.String)
+		item.GuardianIDs = guardianIDs
+		item.Tags = tags
+		setItemDates(&item, dueDate, renewalDate)
+		item.HouseholdID = householdID.String
+		item.RecipientGuardianID = recipientGuardianID.String
+		item.Sealed = parseSealedParams(sealedParams)
+		item.Checklist = s.parseChecklist(checklist)
+		item.Schedule = s.parseSchedule(schedule)
+		item.Document = s.parseDocument(document)
+		item.Pet = s.parsePet(pet)
+		item.FinancialAccount = s.parseFinancialAccount(financialAccount)
+		item.OnlineAccount = s.parseOnlineAccount(onlineAccount)
+		items = append(items, &item)
+	}
+
+	return items
+}
+
+// ListItemsForRecipient lista os itens do usuário endereçados ao guardião
+func (s *PostgresStore) ListItemsForRecipient(userID, guardianID string) ([]*BoxItem, error) {
+	rows, err := s.db.Query(`
+		SELECT id, user_id, type, title, content, category, recipient, is_important, is_shared, guardian_ids, created_at, updated_at, due_date, renewal_date, household_id, tags, recipient_guardian_id, sealed_params, checklist, schedule, document, pet, financial_account, online_account
+		FROM box_items
+		WHERE user_id = $1 AND recipient_guardian_id = $2
+		ORDER BY updated_at DESC
+		LIMIT 1000
+	`, userID, guardianID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]*BoxItem, 0)
+	for rows.Next() {
+		var item BoxItem
+		var title, content, category, recipient, householdID, recipientGuardianID, sealedParams, checklist, schedule, document, pet, financialAccount, onlineAccount sql.NullString
+		var guardianIDs, tags pq.StringArray
+		var dueDate, renewalDate sql.NullTime
+		err := rows.Scan(
+			&item.ID, &item.UserID, &item.Type, &title,
+			&content, &category, &recipient,
+			&item.IsImportant, &item.IsShared, &guardianIDs, &item.CreatedAt, &item.UpdatedAt,
+			&dueDate, &renewalDate, &householdID, &tags, &recipientGuardianID, &sealedParams, &checklist, &schedule, &document, &pet, &financialAccount, &onlineAccount,
+		)
+		if err != nil {
+			return nil, err
+		}
+		item.Title = s.decryptSensitive(title.String)
+		item.Content = s.decryptSensitive(content.String)
+		item.Category = category.String
+		item.Recipient = s.decryptSensitive(recipient.String)
+		item.GuardianIDs = guardianIDs
+		item.Tags = tags
+		setItemDates(&item, dueDate, renewalDate)
+		item.HouseholdID = householdID.String
+		item.RecipientGuardianID = recipientGuardianID.String
+		item.Sealed = parseSealedParams(sealedParams)
+		item.Checklist = s.parseChecklist(checklist)
+		item.Schedule = s.parseSchedule(schedule)
+		item.Document = s.parseDocument(document)
+		item.Pet = s.parsePet(pet)
+		item.FinancialAccount = s.parseFinancialAccount(financialAccount)
+		item.OnlineAccount = s.parseOnlineAccount(onlineAccount)
+		items = append(items, &item)
+	}
+	return items, rows.Err()
+}
+
+// CreateGuardianAlert registra um novo aviso a um guardião (mensagem criptografada)
+func (s *PostgresStore) CreateGuardianAlert(alert *GuardianAlert) error {
+	message, err := s.encryptSensitive(alert.Message)
+	if err != nil {
+		return err
+	}
+	attemptLog, err := json.Marshal(alertLog(alert.Log))
+	if err != nil {
+		return err
+	}
+	channels, err := json.Marshal(alertChannels(alert.Channels))
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`
+		INSERT INTO guardian_alerts (id, user_id, guardian_id, event, message, channels, status, attempts, channel, error, attempt_log, next_attempt_at, created_at, delivered_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`, alert.ID, alert.UserID, alert.GuardianID, string(alert.Event), message, string(channels), string(alert.Status), alert.Attempts,
+		nullString(string(alert.Channel)), nullString(alert.Error), string(attemptLog), alert.NextAttemptAt, alert.CreatedAt, alert.DeliveredAt)
+	return err
+}
+
+// UpdateGuardianAlert atualiza o resultado de uma tentativa de entrega
+func (s *PostgresStore) UpdateGuardianAlert(alert *GuardianAlert) error {
+	attemptLog, err := json.Marshal(alertLog(alert.Log))
+	if err != nil {
+		return err
+	}
+	result, err := s.db.Exec(`
+		UPDATE guardian_alerts SET status = $1, attempts = $2, channel = $3, error = $4, attempt_log = $5,
+			next_attempt_at = $6, delivered_at = $7
+		WHERE id = $8
+	`, string(alert.Status), alert.Attempts, nullString(string(alert.Channel)), nullString(alert.Error), string(attemptLog),
+		alert.NextAttemptAt, alert.DeliveredAt, alert.ID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListGuardianAlerts lista os avisos mais recentes aos guardiões do usuário
+func (s *PostgresStore) ListGuardianAlerts(userID string, limit int) ([]*GuardianAlert, error) {
+	return s.queryGuardianAlerts(`
+		SELECT id, user_id, guardian_id, event, message, channels, status, attempts, channel, error, attempt_log, next_attempt_at, created_at, delivered_at
+		FROM guardian_alerts WHERE user_id = $1
+		ORDER BY created_at DESC LIMIT $2
+	`, userID, limit)
+}
+
+// ListDueGuardianAlerts lista avisos pendentes cuja próxima tentativa já venceu
+func (s *PostgresStore) ListDueGuardianAlerts(now time.Time, limit int) ([]*GuardianAlert, error) {
+	return s.queryGuardianAlerts(`
+		SELECT id, user_id, guardian_id, event, message, channels, status, attempts, channel, error, attempt_log, next_attempt_at, created_at, delivered_at
+		FROM guardian_alerts WHERE status = 'pending' AND next_attempt_at <= $1
+		ORDER BY next_attempt_at LIMIT $2
+	`, now, limit)
+}
+
+// queryGuardianAlerts executa uma consulta e lê os avisos retornados
+func (s *PostgresStore) queryGuardianAlerts(query string, args ...interface{}) ([]*GuardianAlert, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := make([]*GuardianAlert, 0)
+	for rows.Next() {
+		var a GuardianAlert
+		var event, status, message, channels, attemptLog string
+		var channel, errMsg sql.NullString
+		var nextAttemptAt, deliveredAt sql.NullTime
+
+		if err := rows.Scan(&a.ID, &a.UserID, &a.GuardianID, &event, &message, &channels, &status, &a.Attempts,
+			&channel, &errMsg, &attemptLog, &nextAttemptAt, &a.CreatedAt, &deliveredAt); err != nil {
+			return nil, err
+		}
+
+		a.Event = GuardianAlertEvent(event)
+		a.Message = s.decryptSensitive(message)
+		a.Status = GuardianAlertStatus(status)
+		a.Channel = NotifyChannel(channel.String)
+		a.Error = errMsg.String
+		if err := json.Unmarshal([]byte(channels), &a.Channels); err != nil {
+			return nil, err
+		}
+		if len(a.Channels) == 0 {
+			a.Channels = nil
+		}
+		a.Log = make([]GuardianAlertAttempt, 0)
+		if err := json.Unmarshal([]byte(attemptLog), &a.Log); err != nil {
+			return nil, err
+		}
+		if nextAttemptAt.Valid {
+			a.NextAttemptAt = &nextAttemptAt.Time
+		}
+		if deliveredAt.Valid {
+			a.DeliveredAt = &deliveredAt.Time
+		}
+		alerts = append(alerts, &a)
+	}
+	return alerts, rows.Err()
+}
+
+// alertLog garante um array JSON (e não null) no log de tentativas
+func alertLog(log []GuardianAlertAttempt) []GuardianAlertAttempt {
+	if log == nil {
+		return []GuardianAlertAttempt{}
+	}
+	return log
+}
+
+// alertChannels garante um array JSON (e não null) nos canais escolhidos
+func alertChannels(channels []NotifyChannel) []NotifyChannel {
+	if channels == nil {
+		return []NotifyChannel{}
+	}
+	return channels
+}
+
+// CreateItemComment salva o comentário de um guardião (texto criptografado)
+func (s *PostgresStore) CreateItemComment(comment *ItemComment) error {
+	body, err := s.encryptSensitive(comment.Body)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`
+		INSERT INTO item_comments (id, item_id, user_id, guardian_id, body, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, comment.ID, comment.ItemID, comment.UserID, comment.GuardianID, body, comment.CreatedAt)
+	return err
+}
+
+// ListItemComments lista os comentários de um item, mais antigos primeiro
+func (s *PostgresStore) ListItemComments(itemID string) ([]*ItemComment, error) {
+	return s.queryItemComments(`
+		SELECT id, item_id, user_id, guardian_id, body, created_at
+		FROM item_comments WHERE item_id = $1
+		ORDER BY created_at
+	`, itemID)
+}
+
+// ListItemCommentsByGuardian lista os comentários de um guardião, mais recentes primeiro
+func (s *PostgresStore) ListItemCommentsByGuardian(guardianID string) ([]*ItemComment, error) {
+	return s.queryItemComments(`
+		SELECT id, item_id, user_id, guardian_id, body, created_at
+		FROM item_comments WHERE guardian_id = $1
+		ORDER BY created_at DESC
+	`, guardianID)
+}
+
+// DeleteItemComment remove um comentário do item
+func (s *PostgresStore) DeleteItemComment(itemID, commentID string) error {
+	result, err := s.db.Exec(`DELETE FROM item_comments WHERE id = $1 AND item_id = $2`, commentID, itemID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// queryItemComments executa uma consulta e lê os comentários retornados
+func (s *PostgresStore) queryItemComments(query string, args ...interface{}) ([]*ItemComment, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := make([]*ItemComment, 0)
+	for rows.Next() {
+		var c ItemComment
+		var body string
+		if err := rows.Scan(&c.ID, &c.ItemID, &c.UserID, &c.GuardianID, &body, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		c.Body = s.decryptSensitive(body)
+		comments = append(comments, &c)
+	}
+	return comments, rows.Err()
+}
+
+// CreateGuardian cria um novo guardião com dados criptografados
+func (s *PostgresStore) CreateGuardian(userID string, guardian *Guardian) (*Guardian, error) {
+	id := ids.New(ids.Guardian)
+	return s.CreateGuardianWithID(userID, guardian, id)
+}
+
+func (s *PostgresStore) CreateGuardianWithID(userID string, guardian *Guardian, guardianID string) (*Guardian, error) {
+	now := time.Now()
+	role := guardian.Role
+	if role == "" {
+		role = "viewer"
+	}
+
+	// Gerar access_token único para o guardião
+	accessToken := security.GenerateAccessToken()
+	accessType := guardian.AccessType
+	if accessType == "" {
+		accessType = GuardianAccessNormal
+	}
+
+	// Criptografar dados sensíveis (PII)
+	encName, err := s.encryptSensitive(guardian.Name)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criptografar nome: %w", err)
+	}
+	encEmail, err := s.encryptSensitive(guardian.Email)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criptografar email: %w", err)
+	}
+	encPhone, err := s.encryptSensitive(guardian.Phone)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criptografar telefone: %w", err)
+	}
+	encNotes, err := s.encryptSensitive(guardian.Notes)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criptografar notas: %w", err)
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO guardians (id, user_id, name, email, phone, relationship, role, notes, access_token, access_pin, access_type, notify_channel, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`, guardianID, userID, encName, encEmail, encPhone, guardian.Relationship, role, encNotes, accessToken, guardian.AccessPIN, accessType, string(guardian.NotifyChannel), now, now)
+
+	if err != nil {
+		return nil, err
+	}
+
+	guardian.ID = guardianID
+	guardian.UserID = userID
+	guardian.Role = role
+	guardian.AccessToken = accessToken
+	guardian.HasPIN = guardian.AccessPIN != ""
+	guardian.AccessType = accessType
+	guardian.CreatedAt = now
+	guardian.UpdatedAt = now
+	return guardian, nil
+}
+
+// UpdateGuardian atualiza um guardião com dados criptografados
+func (s *PostgresStore) UpdateGuardian(userID, guardianID string, updates *Guardian) (*Guardian, error) {
+	// Criptografar dados sensíveis (PII)
+	encName, err := s.encryptSensitive(updates.Name)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criptografar nome: %w", err)
+	}
+	encEmail, err := s.encryptSensitive(updates.Email)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criptografar email: %w", err)
+	}
+	encPhone, err := s.encryptSensitive(updates.Phone)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criptografar telefone: %w", err)
+	}
+	encNotes, err := s.encryptSensitive(updates.Notes)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criptografar notas: %w", err)
+	}
+
+	var result sql.Result
+
+	// Se PIN foi fornecido, atualizar também
+	if updates.AccessPIN != "" {
+		result, err = s.db.Exec(`
+			UPDATE guardians 
+			SET name = $1, email = $2, phone = $3, relationship = $4, notes = $5, access_pin = $6, notify_channel = $7, updated_at = $8
+			WHERE user_id = $9 AND id = $10
+		`, encName, encEmail, encPhone, updates.Relationship, encNotes, updates.AccessPIN, string(updates.NotifyChannel), time.Now(), userID, guardianID)
+	} else {
+		result, err = s.db.Exec(`
+			UPDATE guardians 
+			SET name = $1, email = $2, phone = $3, relationship = $4, notes = $5, notify_channel = $6, updated_at = $7
+			WHERE user_id = $8 AND id = $9
+		`, encName, encEmail, encPhone, updates.Relationship, encNotes, string(updates.NotifyChannel), time.Now(), userID, guardianID)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return nil, ErrNotFound
+	}
+
+	// Buscar guardião atualizado
+	var g Guardian
+	var name, email, phone, relationship, notes, accessToken, accessPIN, accessType, notifyChannel, accountUserID sql.NullString
+	var deletionRequestedAt, accessDisabledAt sql.NullTime
+	err = s.db.QueryRow(`
+		SELECT id, user_id, name, email, phone, relationship, role, notes, access_token, access_pin, access_type, notify_channel, account_user_id, created_at, updated_at, deletion_requested_at, access_disabled_at
+		FROM guardians WHERE user_id = $1 AND id = $2
+	`, userID, guardianID).Scan(
+		&g.ID, &g.UserID, &name, &email, &phone,
+		&relationship, &g.Role, &notes, &accessToken, &accessPIN, &accessType, &notifyChannel, &accountUserID,
+		&g.CreatedAt, &g.UpdatedAt, &deletionRequestedAt, &accessDisabledAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	// Descriptografar dados sensíveis
+	g.Name = s.decryptSensitive(name.String)
+	g.Email = s.decryptSensitive(email.String)
+	g.Phone = s.decryptSensitive(phone.String)
+	g.Relationship = relationship.String
+	g.Notes = s.decryptSensitive(notes.String)
+	g.AccessToken = accessToken.String
+	g.AccessPIN = accessPIN.String
+	g.HasPIN = accessPIN.String != ""
+	g.AccessType = GuardianAccessType(accessType.String)
+	g.NotifyChannel = NotifyChannel(notifyChannel.String)
+	g.AccountUserID = accountUserID.String
+	g.HasAccount = accountUserID.String != ""
+	if deletionRequestedAt.Valid {
+		g.DeletionRequestedAt = &deletionRequestedAt.Time
+	}
+	if accessDisabledAt.Valid {
+		g.AccessDisabledAt = &accessDisabledAt.Time
+	}
+	return &g, nil
+}
+
+func (s *PostgresStore) DeleteGuardian(userID, guardianID string) error {
+	result, err := s.db.Exec(`
+		DELETE FROM guardians WHERE user_id = $1 AND id = $2
+	`, userID, guardianID)
+
+	if err != nil {
+		return err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// RegisterIdempotencyKey registra uma chave idempotente para um recurso.
+func (s *PostgresStore) RegisterIdempotencyKey(userID, key, resourceType, resourceID string) (string, bool, error) {
+	result, err := s.db.Exec(`
+		INSERT INTO idempotency_keys (user_id, key, resource_type, resource_id, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT DO NOTHING
+	`, userID, key, resourceType, resourceID, time.Now())
+	if err != nil {
+		return "", false, err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 1 {
+		return "", true, nil
+	}
+
+	var existingID string
+	err = s.db.QueryRow(`
+		SELECT resource_id FROM idempotency_keys
+		WHERE user_id = $1 AND key = $2 AND resource_type = $3
+	`, userID, key, resourceType).Scan(&existingID)
+	if err == sql.ErrNoRows {
+		return "", false, ErrNotFound
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return existingID, false, nil
+}
+
+// DeleteIdempotencyKey remove uma chave idempotente (ex.: após falha).
+func (s *PostgresStore) DeleteIdempotencyKey(userID, key, resourceType string) error {
+	_, err := s.db.Exec(`
+		DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND resource_type = $3
+	`, userID, key, resourceType)
+	return err
+}
+
+// ============================================================================
+// GUIDE PROGRESS
+// ============================================================================
+
+func (s *PostgresStore) GetGuideProgress(userID string) map[string]*GuideProgress {
+	rows, err := s.db.Query(`
+		SELECT user_id, card_id, status, completed_at
+		FROM guide_progress WHERE user_id = $1 LIMIT 50
+	`, userID)
+	if err != nil {
+		return map[string]*GuideProgress{}
+	}
+	defer rows.Close()
+
+	progress := make(map[string]*GuideProgress)
+	for rows.Next() {
+		var p GuideProgress
+		var completedAt sql.NullTime
+		err := rows.Scan(&p.UserID, &p.CardID, &p.Status, &completedAt)
+		if err != nil {
+			continue
+		}
+		if completedAt.Valid {
+			p.CompletedAt = completedAt.Time
+		}
+		progress[p.CardID] = &p
+	}
+
+	return progress
+}
+
+func (s *PostgresStore) UpdateGuideProgress(userID, cardID, status string) (*GuideProgress, error) {
+	now := time.Now()
+	var completedAt *time.Time
+	if status == "completed" {
+		completedAt = &now
+	}
+
+	// Upsert
+	_, err := s.db.Exec(`
+		INSERT INTO guide_progress (user_id, card_id, status, completed_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, card_id) 
+		DO UPDATE SET status = $3, completed_at = $4, updated_at = $6
+	`, userID, cardID, status, completedAt, now, now)
+
+	if err != nil {
+		return nil, err
+	}
+
+	progress := &GuideProgress{
+		UserID: userID,
+		CardID: cardID,
+		Status: status,
+	}
+	if completedAt != nil {
+		progress.CompletedAt = *completedAt
+	}
+
+	return progress, nil
+}
+
+// ============================================================================
+// SETTINGS
+// ============================================================================
+
+func (s *PostgresStore) GetSettings(userID string) *Settings {
+	var settings Settings
+	var optOuts []string
+	err := s.db.QueryRow(`
+		SELECT user_id, emergency_protocol_enabled, notifications_enabled, theme, notification_opt_outs, digest_frequency,
+		       messaging_opt_out, messaging_consent_at
+		FROM settings WHERE user_id = $1
+	`, userID).Scan(&settings.UserID, &settings.EmergencyProtocolEnabled, &settings.NotificationsEnabled, &settings.Theme,
+		pq.Array(&optOuts), &settings.DigestFrequency, &settings.MessagingOptOut, &settings.MessagingConsentAt)
+	for _, category := range optOuts {
+		settings.NotificationOptOuts = append(settings.NotificationOptOuts, NotificationCategory(category))
+	}
+
+	if err == sql.ErrNoRows {
+		// Criar configurações padrão
+		settings = Settings{
+			UserID:               userID,
+			NotificationsEnabled: true,
+			Theme:                "light",
+			DigestFrequency:      DigestOff,
+		}
+		s.db.Exec(`
+			INSERT INTO settings (user_id, notifications_enabled, theme)
+			VALUES ($1, $2, $3)
+		`, userID, true, "light")
+	}
+
+	return &settings
+}
+
+func (s *PostgresStore) UpdateSettings(userID string, updates *Settings) *Settings {
+	s.db.Exec(`
+		INSERT INTO settings (user_id, emergency_protocol_enabled, notifications_enabled, theme, notification_opt_outs)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) 
+		DO UPDATE SET emergency_protocol_enabled = $2, notifications_enabled = $3, theme = $4, notification_opt_outs = $5
+	`, userID, updates.EmergencyProtocolEnabled, updates.NotificationsEnabled, updates.Theme,
+		pq.Array(notificationCategoryStrings(updates.NotificationOptOuts)))
+
+	updates.UserID = userID
+	updates.DigestFrequency = DigestOff
+	updates.MessagingOptOut = false
+	updates.MessagingConsentAt = nil
+	s.db.QueryRow(`SELECT digest_frequency, messaging_opt_out, messaging_consent_at FROM settings WHERE user_id = $1`, userID).
+		Scan(&updates.DigestFrequency, &updates.MessagingOptOut, &updates.MessagingConsentAt)
+	return updates
+}
+
+// UpdateDigestFrequency liga, muda ou desliga o resumo pelo WhatsApp
+// since é a referência do próximo resumo (itens novos a partir dela).
+func (s *PostgresStore) UpdateDigestFrequency(userID string, frequency DigestFrequency, since time.Time) error {
+	_, err := s.db.Exec(`
+		INSERT INTO settings (user_id, notifications_enabled, theme, digest_frequency, digest_last_sent_at)
+		VALUES ($1, TRUE, 'light', $2, $3)
+		ON CONFLICT (user_id)
+		DO UPDATE SET digest_frequency = $2, digest_last_sent_at = $3
+	`, userID, frequency, since)
+	return err
+}
+
+// UpdateMessagingConsent registra o pedido de parar (ou voltar a receber)
+// mensagens pelo WhatsApp
+func (s *PostgresStore) UpdateMessagingConsent(userID string, optOut bool, at time.Time) error {
+	_, err := s.db.Exec(`
+		INSERT INTO settings (user_id, notifications_enabled, theme, messaging_opt_out, messaging_consent_at)
+		VALUES ($1, TRUE, 'light', $2, $3)
+		ON CONFLICT (user_id)
+		DO UPDATE SET messaging_opt_out = $2, messaging_consent_at = $3
+	`, userID, optOut, at)
+	return err
+}
+
+// SaveConversationDraft guarda o item abandonado no meio da conversa
+// O título e o conteúdo ficam criptografados, como nos itens da Caixa.
+func (s *PostgresStore) SaveConversationDraft(draft *ConversationDraft) error {
+	title, err := s.encryptSensitive(draft.Title)
+	if err != nil {
+		return err
+	}
+	content, err := s.encryptSensitive(draft.Content)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`
+		INSERT INTO conversation_drafts (user_id, state, type, title, content, category, media_url, media_type, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (user_id)
+		DO UPDATE SET state = $2, type = $3, title = $4, content = $5, category = $6, media_url = $7, media_type = $8, created_at = $9
+	`, draft.UserID, draft.State, draft.Type, title, content, draft.Category, draft.MediaURL, draft.MediaType, draft.CreatedAt)
+	return err
+}
+
+// GetConversationDraft retorna o rascunho do usuário
+func (s *PostgresStore) GetConversationDraft(userID string) (*ConversationDraft, error) {
+	var draft ConversationDraft
+	err := s.db.QueryRow(`
+		SELECT user_id, state, type, title, content, category, media_url, media_type, created_at
+		FROM conversation_drafts WHERE user_id = $1
+	`, userID).Scan(&draft.UserID, &draft.State, &draft.Type, &draft.Title, &draft.Content,
+		&draft.Category, &draft.MediaURL, &draft.MediaType, &draft.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	draft.Title = s.decryptSensitive(draft.Title)
+	draft.Content = s.decryptSensitive(draft.Content)
+	return &draft, nil
+}
+
+// DeleteConversationDraft descarta o rascunho do usuário
+func (s *PostgresStore) DeleteConversationDraft(userID string) error {
+	_, err := s.db.Exec(`DELETE FROM conversation_drafts WHERE user_id = $1`, userID)
+	return err
+}
+
+// ListDigestSubscriptions lista os usuários com resumo ligado
+// Quem pediu para não receber mensagens fica de fora.
+func (s *PostgresStore) ListDigestSubscriptions() ([]*DigestSubscription, error) {
+	rows, err := s.db.Query(`
+		SELECT user_id, digest_frequency, COALESCE(digest_last_sent_at, CURRENT_TIMESTAMP)
+		FROM settings WHERE digest_frequency <> 'off' AND NOT messaging_opt_out
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*DigestSubscription
+	for rows.Next() {
+		var sub DigestSubscription
+		if err := rows.Scan(&sub.UserID, &sub.Frequency, &sub.LastSentAt); err != nil {
+			return nil, err
+		}
+		result = append(result, &sub)
+	}
+	return result, rows.Err()
+}
+
+// ClaimDigest registra o envio do resumo se lastSentAt ainda for o atual
+// Com várias réplicas, apenas uma consegue marcar (e enviar) cada resumo.
+func (s *PostgresStore) ClaimDigest(userID string, lastSentAt, now time.Time) (bool, error) {
+	result, err := s.db.Exec(`
+		UPDATE settings SET digest_last_sent_at = $3
+		WHERE user_id = $1 AND digest_last_sent_at = $2
+	`, userID, lastSentAt, now)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n == 1, err
+}
+
+// ============================================================================
+// ADMIN / ESTATÍSTICAS
+// ============================================================================
+
+func (s *PostgresStore) GetStats() *Stats {
+	stats := &Stats{
+		ItemsByType:     make(map[string]int),
+		ItemsByCategory: make(map[string]int),
+	}
+
+	// Total de usuários
+	s.db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&stats.TotalUsers)
+
+	// Usuários recentes (7 dias)
+	s.db.QueryRow(`SELECT COUNT(*) FROM users WHERE created_at > NOW() - INTERVAL '7 days'`).Scan(&stats.RecentSignups)
+
+	// Total de itens
+	s.db.QueryRow(`SELECT COUNT(*), COUNT(archived_at) FROM box_items`).Scan(&stats.TotalItems, &stats.ArchivedItems)
+
+	// Total de guardiões
+	s.db.QueryRow(`SELECT COUNT(*) FROM guardians`).Scan(&stats.TotalGuardians)
+
+	// Itens por tipo (máximo 20 tipos diferentes)
+	rows, _ := s.db.Query(`SELECT type, COUNT(*) FROM box_items GROUP BY type LIMIT 20`)
+	if rows != nil {
+		defer rows.Close()
+		for rows.Next() {
+			var itemType string
+			var count int
+			rows.Scan(&itemType, &count)
+			stats.ItemsByType[itemType] = count
+		}
+	}
+
+	// Itens por categoria (máximo 20 categorias)
+	rows2, _ := s.db.Query(`SELECT category, COUNT(*) FROM box_items WHERE category != '' GROUP BY category LIMIT 20`)
+	if rows2 != nil {
+		defer rows2.Close()
+		for rows2.Next() {
+			var category string
+			var count int
+			rows2.Scan(&category, &count)
+			stats.ItemsByCategory[category] = count
+		}
+	}
+
+	return stats
+}
+
+// GetUserUsage calcula o uso de armazenamento do usuário
+// content_bytes é gravado antes da criptografia (BoxItem.ContentBytes).
+func (s *PostgresStore) GetUserUsage(userID string) (*UserUsage, error) {
+	usage := &UserUsage{UserID: userID}
+	err := s.db.QueryRow(`
+		SELECT COUNT(*), COUNT(archived_at), COALESCE(SUM(content_bytes), 0)
+		FROM box_items
+		WHERE user_id = $1
+	`, userID).Scan(&usage.ItemCount, &usage.ArchivedCount, &usage.ContentBytes)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao calcular uso: %w", err)
+	}
+	return usage, nil
+}
+
+// ListTopUsage lista os usuários que mais usam armazenamento
+func (s *PostgresStore) ListTopUsage(limit int) ([]*UserUsage, error) {
+	rows, err := s.db.Query(`
+		SELECT u.id, u.email, u.name, COUNT(*) AS item_count, COALESCE(SUM(b.content_bytes), 0) AS content_bytes
+		FROM box_items b
+		JOIN users u ON u.id = b.user_id
+		GROUP BY u.id, u.email, u.name
+		ORDER BY content_bytes DESC, item_count DESC, u.id
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar uso: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]*UserUsage, 0)
+	for rows.Next() {
+		usage := &UserUsage{}
+		if err := rows.Scan(&usage.UserID, &usage.Email, &usage.Name, &usage.ItemCount, &usage.ContentBytes); err != nil {
+			return nil, err
+		}
+		result = append(result, usage)
+	}
+	return result, rows.Err()
+}
+
+// GetAssistantUsage retorna o uso do assistente pelo usuário no dia
+func (s *PostgresStore) GetAssistantUsage(userID, day string) (*AssistantUsage, error) {
+	usage := &AssistantUsage{UserID: userID, Day: day}
+	err := s.db.QueryRow(`
+		SELECT requests, tokens, rejected FROM assistant_usage
+		WHERE user_id = $1 AND day = $2
+	`, userID, day).Scan(&usage.Requests, &usage.Tokens, &usage.Rejected)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("erro ao buscar uso do assistente: %w", err)
+	}
+	return usage, nil
+}
+
+// RecordAssistantUsage soma uma pergunta respondida (ou recusada) ao dia
+func (s *PostgresStore) RecordAssistantUsage(userID, day string, tokens int, rejected bool) error {
+	requests, rejections := 1, 0
+	if rejected {
+		requests, rejections, tokens = 0, 1, 0
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO assistant_usage (user_id, day, requests, tokens, rejected)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, day) DO UPDATE SET
+			requests = assistant_usage.requests + EXCLUDED.requests,
+			tokens = assistant_usage.tokens + EXCLUDED.tokens,
+			rejected = assistant_usage.rejected + EXCLUDED.rejected
+	`, userID, day, requests, tokens, rejections)
+	if err != nil {
+		return fmt.Errorf("erro ao registrar uso do assistente: %w", err)
+	}
+	return nil
+}
+
+// GetAssistantUsageStats soma o uso do assistente desde o dia informado
+func (s *PostgresStore) GetAssistantUsageStats(since string, topLimit int) (*AssistantUsageStats, error) {
+	stats := &AssistantUsageStats{Daily: []*AssistantUsage{}, TopUsers: []*AssistantUsage{}}
+	err := s.db.QueryRow(`
+		SELECT COALESCE(SUM(requests), 0), COALESCE(SUM(tokens), 0), COALESCE(SUM(rejected), 0),
+			COUNT(DISTINCT user_id) FILTER (WHERE requests > 0)
+		FROM assistant_usage WHERE day >= $1
+	`, since).Scan(&stats.Requests, &stats.Tokens, &stats.Rejected, &stats.ActiveUsers)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao resumir uso do assistente: %w", err)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT TO_CHAR(day, 'YYYY-MM-DD'), SUM(requests), SUM(tokens), SUM(rejected)
+		FROM assistant_usage WHERE day >= $1
+		GROUP BY day ORDER BY day
+	`, since)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao resumir uso do assistente: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		day := &AssistantUsage{}
+		if err := rows.Scan(&day.Day, &day.Requests, &day.Tokens, &day.Rejected); err != nil {
+			return nil, err
+		}
+		stats.Daily = append(stats.Daily, day)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	topRows, err := s.db.Query(`
+		SELECT user_id, SUM(requests), SUM(tokens), SUM(rejected)
+		FROM assistant_usage WHERE day >= $1
+		GROUP BY user_id ORDER BY SUM(tokens) DESC, user_id
+		LIMIT $2
+	`, since, topLimit)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao resumir uso do assistente: %w", err)
+	}
+	defer topRows.Close()
+	for topRows.Next() {
+		user := &AssistantUsage{}
+		if err := topRows.Scan(&user.UserID, &user.Requests, &user.Tokens, &user.Rejected); err != nil {
+			return nil, err
+		}
+		stats.TopUsers = append(stats.TopUsers, user)
+	}
+	return stats, topRows.Err()
+}
+
+func (s *PostgresStore) ListUsers() []*User {
+	rows, err := s.db.Query(`
+		SELECT id, email, name, created_at, failed_login_attempts, locked_until, disabled_at, role
+		FROM users ORDER BY created_at DESC LIMIT 500
+	`)
+	if err != nil {
+		return []*User{}
+	}
+	defer rows.Close()
+
+	var users []*User
+	for rows.Next() {
+		user, err := scanAdminUser(rows)
+		if err != nil {
+			continue
+		}
+		users = append(users, user)
+	}
+
+	return users
+}
+
+// SearchUsers busca usuários por email/nome e status, com paginação por cursor
+// Ordena do mais recente para o mais antigo (created_at, id)
+func (s *PostgresStore) SearchUsers(params *UserSearchParams) (*PaginatedResult[*User], error) {
+	if params == nil {
+		params = &UserSearchParams{}
+	}
+	NormalizePagination(&params.PaginationParams)
+
+	var conditions []string
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if query := strings.ToLower(strings.TrimSpace(params.Query)); query != "" {
+		pattern := arg("%" + escapeLike(query) + "%")
+		conditions = append(conditions, "(LOWER(email) LIKE "+pattern+" OR LOWER(COALESCE(name, '')) LIKE "+pattern+")")
+	}
+
+	switch params.Status {
+	case UserStatusActive:
+		conditions = append(conditions, "disabled_at IS NULL AND (locked_until IS NULL OR locked_until <= "+arg(time.Now())+")")
+	case UserStatusDisabled:
+		conditions = append(conditions, "disabled_at IS NOT NULL")
+	case UserStatusLocked:
+		conditions = append(conditions, "locked_until > "+arg(time.Now()))
+	}
+
+	if params.Role != RoleNone {
+		conditions = append(conditions, "role = "+arg(string(params.Role)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// Total considera apenas os filtros (não o cursor)
+	var total int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM users`+where, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("erro ao contar usuários: %w", err)
+	}
+
+	if params.Cursor != "" {
+		cursor := arg(params.Cursor)
+		conditions = append(conditions, "(created_at, id) < (SELECT created_at, id FROM users WHERE id = "+cursor+")")
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, email, name, created_at, failed_login_attempts, locked_until, disabled_at, role
+		FROM users`+where+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT `+arg(params.Limit+1), args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar usuários: %w", err)
+	}
+	defer rows.Close()
+
+	users := make([]*User, 0, params.Limit+1)
+	for rows.Next() {
+		user, err := scanAdminUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler usuário: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	hasMore := len(users) > params.Limit
+	if hasMore {
+		users = users[:params.Limit]
+	}
+
+	var nextCursor string
+	if hasMore && len(users) > 0 {
+		nextCursor = users[len(users)-1].ID
+	}
+
+	return &PaginatedResult[*User]{
+		Items:      users,
+		NextCursor: nextCursor,
+		HasMore:    hasMore,
+		Total:      total,
+	}, nil
+}
+
+// SetUserDisabled desativa ou reativa uma conta (ação administrativa)
+func (s *PostgresStore) SetUserDisabled(userID string, disabled bool) error {
+	var disabledAt interface{}
+	if disabled {
+		disabledAt = time.Now()
+	}
+
+	result, err := s.db.Exec(`UPDATE users SET disabled_at = $1, updated_at = $2 WHERE id = $3`,
+		disabledAt, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar status da conta: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// GetUserGrowth retorna os cadastros por dia de [from, to) e o total acumulado
+func (s *PostgresStore) GetUserGrowth(from, to time.Time) ([]*UserGrowthDay, error) {
+	var before int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM users WHERE created_at < $1`, from).Scan(&before); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT DATE(created_at) AS day, COUNT(*)
+		FROM users
+		WHERE created_at >= $1 AND created_at < $2
+		GROUP BY day
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	signups := make(map[time.Time]int)
+	for rows.Next() {
+		var day time.Time
+		var count int
+		if err := rows.Scan(&day, &count); err != nil {
+			return nil, err
+		}
+		signups[dayStart(day)] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return newUserGrowth(from, to, before, signups), nil
+}
+
+// GrantRole concede um papel administrativo ao usuário (substitui o anterior)
+func (s *PostgresStore) GrantRole(userID string, role Role) error {
+	if !role.IsAdmin() {
+		return ErrInvalidData
+	}
+	return s.setRole(userID, role)
+}
+
+// RevokeRole remove o papel administrativo do usuário
+func (s *PostgresStore) RevokeRole(userID string) error {
+	return s.setRole(userID, RoleNone)
+}
+
+// setRole atualiza a coluna role de um usuário
+func (s *PostgresStore) setRole(userID string, role Role) error {
+	result, err := s.db.Exec(`UPDATE users SET role = $1, updated_at = $2 WHERE id = $3`,
+		string(role), time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar papel: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// scanAdminUser lê um usuário das consultas administrativas (sem senha)
+func scanAdminUser(rows *sql.Rows) (*User, error) {
+	var user User
+	var name sql.NullString
+	var lockedUntil, disabledAt sql.NullTime
+	if err := rows.Scan(&user.ID, &user.Email, &name, &user.CreatedAt, &user.FailedLoginAttempts,
+		&lockedUntil, &disabledAt, &user.Role); err != nil {
+		return nil, err
+	}
+
+	user.Name = name.String
+	if lockedUntil.Valid {
+		user.LockedUntil = &lockedUntil.Time
+	}
+	if disabledAt.Valid {
+		user.DisabledAt = &disabledAt.Time
+	}
+	return &user, nil
+}
+
+// escapeLike escapa os curingas do LIKE (%, _ e \) em termos de busca
+func escapeLike(term string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(term)
+}
+
+// ============================================================================
+// FEEDBACK
+// ============================================================================
+
+// CreateFeedback salva um novo feedback
+func (s *PostgresStore) CreateFeedback(f *Feedback) error {
+	_, err := s.db.Exec(`
+		INSERT INTO feedbacks (id, user_id, user_email, type, message, page, user_agent, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, f.ID, f.UserID, f.UserEmail, f.Type, f.Message, f.Page, f.UserAgent, f.Status, f.CreatedAt, f.UpdatedAt)
+	return err
+}
+
+// ListFeedbacks retorna todos os feedbacks (para admin)
+func (s *PostgresStore) ListFeedbacks(status string, limit int) ([]*Feedback, error) {
+	var query string
+	var args []interface{}
+
+	if status != "" && status != "all" {
+		query = `
+			SELECT id, user_id, user_email, type, message, page, user_agent, status, admin_note, created_at, updated_at
+			FROM feedbacks WHERE status = $1 ORDER BY created_at DESC LIMIT $2
+		`
+		args = []interface{}{status, limit}
+	} else {
+		query = `
+			SELECT id, user_id, user_email, type, message, page, user_agent, status, admin_note, created_at, updated_at
+			FROM feedbacks ORDER BY created_at DESC LIMIT $1
+		`
+		args = []interface{}{limit}
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var feedbacks []*Feedback
+	for rows.Next() {
+		var f Feedback
+		var userID, userEmail, page, userAgent, adminNote sql.NullString
+		err := rows.Scan(&f.ID, &userID, &userEmail, &f.Type, &f.Message, &page, &userAgent, &f.Status, &adminNote, &f.CreatedAt, &f.UpdatedAt)
+		if err != nil {
+			continue
+		}
+		f.UserID = userID.String
+		f.UserEmail = userEmail.String
+		f.Page = page.String
+		f.UserAgent = userAgent.String
+		f.AdminNote = adminNote.String
+		feedbacks = append(feedbacks, &f)
+	}
+
+	return feedbacks, nil
+}
+
+// EachFeedback percorre os feedbacks criados em [from, to), mais antigos
+// primeiro, lendo as linhas conforme fn consome (sem carregar tudo)
+func (s *PostgresStore) EachFeedback(from, to time.Time, fn func(*Feedback) error) error {
+	rows, err := s.db.Query(`
+		SELECT id, user_id, user_email, type, message, page, user_agent, status, admin_note, created_at, updated_at
+		FROM feedbacks WHERE created_at >= $1 AND created_at < $2
+		ORDER BY created_at
+	`, from, to)
+	if err != nil {
+		return fmt.Errorf("erro ao listar feedbacks: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		f, err := scanFeedback(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// UpdateFeedbackStatus atualiza o status de um feedback
+func (s *PostgresStore) UpdateFeedbackStatus(id, status, adminNote string) error {
+	_, err := s.db.Exec(`
+		UPDATE feedbacks SET status = $1, admin_note = $2, updated_at = $3 WHERE id = $4
+	`, status, adminNote, time.Now(), id)
+	return err
+}
+
+// GetFeedbackStats retorna estatísticas de feedbacks
+func (s *PostgresStore) GetFeedbackStats() (total, pending int) {
+	s.db.QueryRow(`SELECT COUNT(*) FROM feedbacks`).Scan(&total)
+	s.db.QueryRow(`SELECT COUNT(*) FROM feedbacks WHERE status = 'pending'`).Scan(&pending)
+	return
+}
+
+// GetFeedback retorna um feedback com a conversa
+func (s *PostgresStore) GetFeedback(id string) (*Feedback, error) {
+	row := s.db.QueryRow(`
+		SELECT id, user_id, user_email, type, message, page, user_agent, status, admin_note, created_at, updated_at
+		FROM feedbacks WHERE id = $1
+	`, id)
+
+	f, err := scanFeedback(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	replies, err := s.listFeedbackReplies([]string{f.ID})
+	if err != nil {
+		return nil, err
+	}
+	f.Replies = replies[f.ID]
+	return f, nil
+}
+
+// ListFeedbacksByUser retorna os feedbacks do usuário com a conversa
+func (s *PostgresStore) ListFeedbacksByUser(userID string, limit int) ([]*Feedback, error) {
+	rows, err := s.db.Query(`
+		SELECT id, user_id, user_email, type, message, page, user_agent, status, admin_note, created_at, updated_at
+		FROM feedbacks WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar feedbacks: %w", err)
+	}
+	defer rows.Close()
+
+	feedbacks := make([]*Feedback, 0)
+	feedbackIDs := make([]string, 0)
+	for rows.Next() {
+		f, err := scanFeedback(rows)
+		if err != nil {
+			return nil, err
+		}
+		feedbacks = append(feedbacks, f)
+		feedbackIDs = append(feedbackIDs, f.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	replies, err := s.listFeedbackReplies(feedbackIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range feedbacks {
+		f.Replies = replies[f.ID]
+	}
+	return feedbacks, nil
+}
+
+// AddFeedbackReply adiciona uma mensagem à conversa do feedback
+func (s *PostgresStore) AddFeedbackReply(reply *FeedbackReply) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE feedbacks SET updated_at = $1 WHERE id = $2`, reply.CreatedAt, reply.FeedbackID)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar feedback: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO feedback_replies (id, feedback_id, author_id, from_admin, message, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, reply.ID, reply.FeedbackID, nullString(reply.AuthorID), reply.FromAdmin, reply.Message, reply.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("erro ao salvar resposta: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// listFeedbackReplies carrega as conversas dos feedbacks (feedbackID -> mensagens)
+func (s *PostgresStore) listFeedbackReplies(feedbackIDs []string) (map[string][]*FeedbackReply, error) {
+	replies := make(map[string][]*FeedbackReply, len(feedbackIDs))
+	if len(feedbackIDs) == 0 {
+		return replies, nil
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, feedback_id, author_id, from_admin, message, created_at
+		FROM feedback_replies WHERE feedback_id = ANY($1) ORDER BY created_at ASC
+	`, pq.Array(feedbackIDs))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar respostas: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var reply FeedbackReply
+		var authorID sql.NullString
+		if err := rows.Scan(&reply.ID, &reply.FeedbackID, &authorID, &reply.FromAdmin, &reply.Message, &reply.CreatedAt); err != nil {
+			return nil, err
+		}
+		reply.AuthorID = authorID.String
+		replies[reply.FeedbackID] = append(replies[reply.FeedbackID], &reply)
+	}
+	return replies, rows.Err()
+}
+
+// scanFeedback lê uma linha de feedbacks
+func scanFeedback(row interface{ Scan(...interface{}) error }) (*Feedback, error) {
+	var f Feedback
+	var userID, userEmail, page, userAgent, adminNote sql.NullString
+	if err := row.Scan(&f.ID, &userID, &userEmail, &f.Type, &f.Message, &page, &userAgent, &f.Status,
+		&adminNote, &f.CreatedAt, &f.UpdatedAt); err != nil {
+		return nil, err
+	}
+	f.UserID = userID.String
+	f.UserEmail = userEmail.String
+	f.Page = page.String
+	f.UserAgent = userAgent.String
+	f.AdminNote = adminNote.String
+	return &f, nil
+}
+
+// ============================================================================
+// ANALYTICS
+// ============================================================================
+
+// TrackEvent registra um evento de analytics
+func (s *PostgresStore) TrackEvent(e *AnalyticsEvent) error {
+	_, err := s.TrackEvents([]*AnalyticsEvent{e})
+	return err
+}
+
+// trackEventsChunk é o máximo de eventos por INSERT (10 parâmetros cada,
+// bem abaixo do limite de 65535 parâmetros do PostgreSQL)
+const trackEventsChunk = 500
+
+// TrackEvents registra eventos em lote (um INSERT por até trackEventsChunk
+// eventos, na mesma transação)
+//
+// client_event_id repetido do mesmo usuário é ignorado: o ID é reservado em
+// analytics_client_events antes do INSERT (ver claimClientEvents).
+func (s *PostgresStore) TrackEvents(events []*AnalyticsEvent) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	events, err = claimClientEvents(tx, events)
+	if err != nil {
+		return 0, err
+	}
+
+	tracked := 0
+	for start := 0; start < len(events); start += trackEventsChunk {
+		end := start + trackEventsChunk
+		if end > len(events) {
+			end = len(events)
+		}
+
+		var query strings.Builder
+		query.WriteString(`INSERT INTO analytics_events (id, user_id, anonymous_id, event_type, page, details, client_event_id, country, device_class, created_at) VALUES `)
+		args := make([]interface{}, 0, (end-start)*10)
+		for i, e := range events[start:end] {
+			if i > 0 {
+				query.WriteString(", ")
+			}
+			n := len(args)
+			fmt.Fprintf(&query, "($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+				n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9, n+10)
+			detailsJSON, _ := json.Marshal(e.Details)
+			args = append(args, e.ID, nullString(e.UserID), nullString(e.AnonymousID), e.EventType, e.Page, detailsJSON,
+				nullString(e.ClientEventID), nullString(e.Country), nullString(e.DeviceClass), e.CreatedAt)
+		}
+		query.WriteString(" ON CONFLICT DO NOTHING")
+
+		result, err := tx.Exec(query.String(), args...)
+		if err != nil {
+			return 0, fmt.Errorf("erro ao registrar eventos: %w", err)
+		}
+		if rows, _ := result.RowsAffected(); rows > 0 {
+			tracked += int(rows)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return tracked, nil
+}
+
+// claimClientEvents reserva os client_event_id do lote e retorna os eventos
+// a gravar: os sem ID do cliente e os com ID ainda não visto para o usuário.
+// A reserva fica fora de analytics_events porque o índice único de uma tabela
+// particionada incluiria created_at, que muda a cada reenvio.
+func claimClientEvents(tx *sql.Tx, events []*AnalyticsEvent) ([]*AnalyticsEvent, error) {
+	type clientKey struct{ userID, clientEventID string }
+
+	var pending []*AnalyticsEvent
+	requested := make(map[clientKey]bool)
+	for _, e := range events {
+		if e.ClientEventID == "" || e.UserID == "" {
+			continue
+		}
+		key := clientKey{e.UserID, e.ClientEventID}
+		if !requested[key] {
+			requested[key] = true
+			pending = append(pending, e)
+		}
+	}
+	if len(pending) == 0 {
+		return events, nil
+	}
+
+	claimed := make(map[clientKey]bool, len(pending))
+	for start := 0; start < len(pending); start += trackEventsChunk {
+		end := start + trackEventsChunk
+		if end > len(pending) {
+			end = len(pending)
+		}
+
+		var query strings.Builder
+		query.WriteString(`INSERT INTO analytics_client_events (user_id, client_event_id, created_at) VALUES `)
+		args := make([]interface{}, 0, (end-start)*3)
+		for i, e := range pending[start:end] {
+			if i > 0 {
+				query.WriteString(", ")
+			}
+			n := len(args)
+			fmt.Fprintf(&query, "($%d, $%d, $%d)", n+1, n+2, n+3)
+			args = append(args, e.UserID, e.ClientEventID, e.CreatedAt)
+		}
+		query.WriteString(" ON CONFLICT DO NOTHING RETURNING user_id, client_event_id")
+
+		rows, err := tx.Query(query.String(), args...)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao reservar eventos: %w", err)
+		}
+		for rows.Next() {
+			var key clientKey
+			if err := rows.Scan(&key.userID, &key.clientEventID); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			claimed[key] = true
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	result := make([]*AnalyticsEvent, 0, len(events))
+	for _, e := range events {
+		if e.ClientEventID != "" && e.UserID != "" {
+			key := clientKey{e.UserID, e.ClientEventID}
+			if !claimed[key] {
+				continue
+			}
+			delete(claimed, key) // Repetido no mesmo lote: só o primeiro
+		}
+		result = append(result, e)
+	}
+	return result, nil
+}
+
+// LinkAnonymousEvents atribui ao usuário os eventos anônimos do visitante
+func (s *PostgresStore) LinkAnonymousEvents(anonymousID, userID string) (int, error) {
+	result, err := s.db.Exec(`
+		UPDATE analytics_events SET user_id = $2
+		WHERE anonymous_id = $1 AND user_id IS NULL
+	`, anonymousID, userID)
+	if err != nil {
+		return 0, err
+	}
+	linked, _ := result.RowsAffected()
+	return int(linked), nil
+}
+
+// analyticsRollupDays são os dias fechados consolidados em analytics_daily
+// (a semana usada no resumo)
+const analyticsRollupDays = 7
+
+// GetAnalyticsSummary retorna o resumo de analytics
+// Com os rollups da última semana prontos, lê analytics_daily e apenas os
+// eventos de hoje; senão, calcula tudo em analytics_events.
+func (s *PostgresStore) GetAnalyticsSummary() *AnalyticsSummary {
+	var rolledUp int
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM analytics_daily
+		WHERE day >= CURRENT_DATE - 7 AND day < CURRENT_DATE
+	`).Scan(&rolledUp)
+	if err != nil || rolledUp < analyticsRollupDays {
+		return s.analyticsSummaryFromEvents()
+	}
+
+	summary := &AnalyticsSummary{
+		EventsByType: make(map[string]int),
+	}
+
+	err = s.db.QueryRow(`
+		WITH today AS (
+			SELECT user_id, event_type FROM analytics_events WHERE created_at >= CURRENT_DATE
+		)
+		SELECT
+			(SELECT COUNT(*) FROM users),
+			(SELECT COUNT(*) FROM users WHERE created_at >= CURRENT_DATE),
+			(SELECT COUNT(*) FROM users WHERE created_at >= CURRENT_DATE - INTERVAL '7 days'),
+			(SELECT COUNT(DISTINCT user_id) FROM today WHERE user_id IS NOT NULL),
+			(SELECT COUNT(*) FROM (
+				SELECT user_id FROM today WHERE user_id IS NOT NULL
+				UNION
+				SELECT user_id FROM analytics_daily_users WHERE day >= CURRENT_DATE - 7
+			) week),
+			(SELECT COUNT(*) FROM box_items),
+			(SELECT COUNT(*) FROM box_items WHERE created_at >= CURRENT_DATE),
+			(SELECT COUNT(*) FROM guardians),
+			(SELECT COUNT(*) FROM today)
+	`).Scan(
+		&summary.TotalUsers, &summary.NewUsersToday, &summary.NewUsersThisWeek,
+		&summary.ActiveToday, &summary.ActiveThisWeek,
+		&summary.TotalItems, &summary.ItemsCreatedToday, &summary.TotalGuardians,
+		&summary.EventsToday,
+	)
+	if err != nil {
+		return s.analyticsSummaryFromEvents()
+	}
+
+	// Eventos por tipo (últimos 7 dias + hoje, máximo 30 tipos)
+	rows, err := s.db.Query(`
+		SELECT event_type, SUM(events) AS count
+		FROM (
+			SELECT event_type, events FROM analytics_daily_events WHERE day >= CURRENT_DATE - 7
+			UNION ALL
+			SELECT event_type, COUNT(*) FROM analytics_events WHERE created_at >= CURRENT_DATE GROUP BY event_type
+		) t
+		GROUP BY event_type
+		ORDER BY count DESC
+		LIMIT 30
+	`)
+	if err == nil {
+		defer rows.Close()
+		for rows.Next() {
+			var eventType string
+			var count int
+			rows.Scan(&eventType, &count)
+			summary.EventsByType[eventType] = count
+		}
+	}
+
+	// Feedbacks
+	summary.TotalFeedbacks, summary.PendingFeedbacks = s.GetFeedbackStats()
+
+	return summary
+}
+
+// analyticsRerollDays são os últimos dias fechados consolidados de novo a cada
+// execução, para incluir eventos que chegam atrasados (filas do cliente,
+// occurred_at antigo, visitantes anônimos vinculados depois do cadastro)
+const analyticsRerollDays = 3
+
+// RollupAnalytics consolida em analytics_daily os dias fechados ainda não
+// processados (até analyticsRollupDays atrás) e recalcula os últimos
+// analyticsRerollDays. Retorna quantos dias foram consolidados pela primeira
+// vez. Várias réplicas podem rodar o worker: o dia é travado na transação.
+func (s *PostgresStore) RollupAnalytics() (int, error) {
+	rows, err := s.db.Query(`
+		SELECT d::date
+		FROM generate_series(CURRENT_DATE - $1::int, CURRENT_DATE - 1, INTERVAL '1 day') d
+		WHERE d >= CURRENT_DATE - $2::int
+		   OR NOT EXISTS (SELECT 1 FROM analytics_daily a WHERE a.day = d::date)
+		ORDER BY d
+	`, analyticsRollupDays, analyticsRerollDays)
+	if err != nil {
+		return 0, err
+	}
+	var days []string
+	for rows.Next() {
+		var day time.Time
+		if err := rows.Scan(&day); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		days = append(days, day.Format("2006-01-02"))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	rolledUp := 0
+	for _, day := range days {
+		created, err := s.rollupAnalyticsDay(day)
+		if err != nil {
+			return rolledUp, fmt.Errorf("rollup de %s: %w", day, err)
+		}
+		if created {
+			rolledUp++
+		}
+	}
+	return rolledUp, nil
+}
+
+// rollupAnalyticsDay consolida (ou recalcula) um dia (YYYY-MM-DD) numa transação;
+// true se o dia ainda não tinha sido consolidado
+func (s *PostgresStore) rollupAnalyticsDay(day string) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// A linha do dia funciona como trava: outra réplica espera o fim desta
+	// transação para recalcular o mesmo dia (xmax = 0: linha nova)
+	var created bool
+	err = tx.QueryRow(`
+		INSERT INTO analytics_daily (day) VALUES ($1::date)
+		ON CONFLICT (day) DO UPDATE SET rolled_up_at = CURRENT_TIMESTAMP
+		RETURNING (xmax = 0)
+	`, day).Scan(&created)
+	if err != nil {
+		return false, err
+	}
+
+	// O recálculo apaga e regrava o dia: tipos e usuários que sumiram dos
+	// eventos (ex.: removidos pela retenção) não ficam no rollup
+	queries := []string{
+		`DELETE FROM analytics_daily_events WHERE day = $1::date`,
+		`DELETE FROM analytics_daily_users WHERE day = $1::date`,
+		`INSERT INTO analytics_daily_events (day, event_type, events)
+		 SELECT $1::date, event_type, COUNT(*)
+		 FROM analytics_events
+		 WHERE created_at >= $1::date AND created_at < $1::date + 1
+		 GROUP BY event_type`,
+		`INSERT INTO analytics_daily_users (day, user_id)
+		 SELECT DISTINCT $1::date, user_id
+		 FROM analytics_events
+		 WHERE created_at >= $1::date AND created_at < $1::date + 1 AND user_id IS NOT NULL`,
+		`UPDATE analytics_daily SET
+			events = (SELECT COALESCE(SUM(events), 0) FROM analytics_daily_events WHERE day = $1::date),
+			active_users = (SELECT COUNT(*) FROM analytics_daily_users WHERE day = $1::date)
+		 WHERE day = $1::date`,
+	}
+	for _, query := range queries {
+		if _, err := tx.Exec(query, day); err != nil {
+			return false, err
+		}
+	}
+	return created, tx.Commit()
+}
+
+// analyticsSummaryFromEvents calcula o resumo direto de analytics_events
+// (uma consulta por número; usado enquanto os rollups não cobrem a semana)
+func (s *PostgresStore) analyticsSummaryFromEvents() *AnalyticsSummary {
+	summary := &AnalyticsSummary{
+		EventsByType: make(map[string]int),
+	}
+
+	// Total de usuários
+	s.db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&summary.TotalUsers)
+
+	// Usuários novos hoje
+	s.db.QueryRow(`SELECT COUNT(*) FROM users WHERE created_at >= CURRENT_DATE`).Scan(&summary.NewUsersToday)
+
+	// Usuários novos esta semana
+	s.db.QueryRow(`SELECT COUNT(*) FROM users WHERE created_at >= CURRENT_DATE - INTERVAL '7 days'`).Scan(&summary.NewUsersThisWeek)
+
+	// Usuários ativos hoje (com eventos)
+	s.db.QueryRow(`SELECT COUNT(DISTINCT user_id) FROM analytics_events WHERE created_at >= CURRENT_DATE AND user_id IS NOT NULL`).Scan(&summary.ActiveToday)
+
+	// Usuários ativos esta semana
+	s.db.QueryRow(`SELECT COUNT(DISTINCT user_id) FROM analytics_events WHERE created_at >= CURRENT_DATE - INTERVAL '7 days' AND user_id IS NOT NULL`).Scan(&summary.ActiveThisWeek)
+
+	// Total de itens
+	s.db.QueryRow(`SELECT COUNT(*) FROM box_items`).Scan(&summary.TotalItems)
+
+	// Itens criados hoje
+	s.db.QueryRow(`SELECT COUNT(*) FROM box_items WHERE created_at >= CURRENT_DATE`).Scan(&summary.ItemsCreatedToday)
+
+	// Total de guardiões
+	s.db.QueryRow(`SELECT COUNT(*) FROM guardians`).Scan(&summary.TotalGuardians)
+
+	// Eventos hoje
+	s.db.QueryRow(`SELECT COUNT(*) FROM analytics_events WHERE created_at >= CURRENT_DATE`).Scan(&summary.EventsToday)
+
+	// Eventos por tipo (últimos 7 dias, máximo 30 tipos)
+	rows, err := s.db.Query(`
+		SELECT event_type, COUNT(*) as count
+		FROM analytics_events
+		WHERE created_at >= CURRENT_DATE - INTERVAL '7 days'
+		GROUP BY event_type
+		ORDER BY count DESC
+		LIMIT 30
+	`)
+	if err == nil {
+		defer rows.Close()
+		for rows.Next() {
+			var eventType string
+			var count int
+			rows.Scan(&eventType, &count)
+			summary.EventsByType[eventType] = count
+		}
+	}
+
+	// Feedbacks
+	summary.TotalFeedbacks, summary.PendingFeedbacks = s.GetFeedbackStats()
+
+	return summary
+}
+
+// GetRecentEvents retorna os eventos mais recentes
+func (s *PostgresStore) GetRecentEvents(limit int) ([]*AnalyticsEvent, error) {
+	rows, err := s.db.Query(`
+		SELECT id, user_id, anonymous_id, event_type, page, details, client_event_id, country, device_class, created_at
+		FROM analytics_events
+		ORDER BY created_at DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*AnalyticsEvent
+	for rows.Next() {
+		var e AnalyticsEvent
+		var userID, anonymousID, page, clientEventID, country, deviceClass sql.NullString
+		var detailsJSON []byte
+		err := rows.Scan(&e.ID, &userID, &anonymousID, &e.EventType, &page, &detailsJSON, &clientEventID, &country, &deviceClass, &e.CreatedAt)
+		if err != nil {
+			continue
+		}
+		e.UserID = userID.String
+		e.AnonymousID = anonymousID.String
+		e.Page = page.String
+		e.ClientEventID = clientEventID.String
+		e.Country = country.String
+		e.DeviceClass = deviceClass.String
+		if len(detailsJSON) > 0 {
+			json.Unmarshal(detailsJSON, &e.Details)
+		}
+		events = append(events, &e)
+	}
+
+	return events, nil
+}
+
+// GetDailyStats retorna estatísticas diárias para gráficos
+func (s *PostgresStore) GetDailyStats(days int) ([]map[string]interface{}, error) {
+	rows, err := s.db.Query(`
+		SELECT 
+			DATE(created_at) as date,
+			COUNT(*) as events,
+			COUNT(DISTINCT user_id) as users
+		FROM analytics_events
+		WHERE created_at >= CURRENT_DATE - $1 * INTERVAL '1 day'
+		GROUP BY DATE(created_at)
+		ORDER BY date
+	`, days)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []map[string]interface{}
+	for rows.Next() {
+		var date time.Time
+		var events, users int
+		rows.Scan(&date, &events, &users)
+		stats = append(stats, map[string]interface{}{
+			"date":   date.Format("2006-01-02"),
+			"events": events,
+			"users":  users,
+		})
+	}
+
+	return stats, nil
+}
+
+// ListDailyStats retorna os eventos e usuários ativos por dia de [from, to)
+func (s *PostgresStore) ListDailyStats(from, to time.Time) ([]*DailyStats, error) {
+	rows, err := s.db.Query(`
+		SELECT DATE(created_at) AS day, COUNT(*), COUNT(DISTINCT user_id)
+		FROM analytics_events
+		WHERE created_at >= $1 AND created_at < $2
+		GROUP BY day
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make(map[time.Time]int)
+	users := make(map[time.Time]int)
+	for rows.Next() {
+		var day time.Time
+		var dayEvents, dayUsers int
+		if err := rows.Scan(&day, &dayEvents, &dayUsers); err != nil {
+			return nil, err
+		}
+		events[dayStart(day)] = dayEvents
+		users[dayStart(day)] = dayUsers
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return newDailyStats(from, to, events, users), nil
+}
+
+// GetFunnel calcula o funil de ativação dos usuários cadastrados em [from, to)
+func (s *PostgresStore) GetFunnel(from, to time.Time) (*AnalyticsFunnel, error) {
+	var registered, firstItem, guardian, shared int
+	err := s.db.QueryRow(`
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE has_item),
+			COUNT(*) FILTER (WHERE has_item AND has_guardian),
+			COUNT(*) FILTER (WHERE has_item AND has_guardian AND has_share)
+		FROM (
+			SELECT
+				EXISTS (SELECT 1 FROM box_items b WHERE b.user_id = u.id) AS has_item,
+				EXISTS (SELECT 1 FROM guardians g WHERE g.user_id = u.id) AS has_guardian,
+				EXISTS (SELECT 1 FROM share_links l WHERE l.user_id = u.id) AS has_share
+			FROM users u
+			WHERE u.created_at >= $1 AND u.created_at < $2
+		) funnel
+	`, from, to).Scan(&registered, &firstItem, &guardian, &shared)
+	if err != nil {
+		return nil, err
+	}
+
+	return newFunnel(from, to, registered, firstItem, guardian, shared), nil
+}
+
+// GetRetentionCohorts calcula as coortes semanais dos cadastros em [from, to)
+//
+// A atividade vem de analytics_events, então semanas mais antigas que a
+// retenção de eventos (classe analytics) aparecem zeradas.
+func (s *PostgresStore) GetRetentionCohorts(from, to time.Time) ([]*RetentionCohort, error) {
+	rows, err := s.db.Query(`
+		SELECT date_trunc('week', created_at) AS week, COUNT(*)
+		FROM users
+		WHERE created_at >= $1 AND created_at < $2
+		GROUP BY week
+		ORDER BY week
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	var cohorts []*RetentionCohort
+	byWeek := make(map[time.Time]*RetentionCohort)
+	for rows.Next() {
+		var week time.Time
+		var users int
+		if err := rows.Scan(&week, &users); err != nil {
+			return nil, err
+		}
+		cohort := newRetentionCohort(weekStart(week), now)
+		cohort.Users = users
+		cohorts = append(cohorts, cohort)
+		byWeek[cohort.Week] = cohort
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(cohorts) == 0 {
+		return []*RetentionCohort{}, nil
+	}
+
+	activity, err := s.db.Query(`
+		SELECT
+			date_trunc('week', u.created_at) AS cohort,
+			(date_trunc('week', e.created_at)::date - date_trunc('week', u.created_at)::date) / 7 AS offset_weeks,
+			COUNT(DISTINCT u.id)
+		FROM users u
+		JOIN analytics_events e ON e.user_id = u.id
+		WHERE u.created_at >= $1 AND u.created_at < $2
+		  AND e.created_at >= date_trunc('week', u.created_at)
+		  AND e.created_at < date_trunc('week', u.created_at) + $3 * INTERVAL '1 week'
+		GROUP BY cohort, offset_weeks
+	`, from, to, MaxCohortWeeks)
+	if err != nil {
+		return nil, err
+	}
+	defer activity.Close()
+
+	for activity.Next() {
+		var week time.Time
+		var offset, users int
+		if err := activity.Scan(&week, &offset, &users); err != nil {
+			return nil, err
+		}
+		cohort := byWeek[weekStart(week)]
+		if cohort != nil && offset >= 0 && offset < len(cohort.Retained) {
+			cohort.Retained[offset] = users
+		}
+	}
+
+	return cohorts, activity.Err()
+}
+
+// RecordCompletenessScore guarda a nota de preparo do usuário no dia
+func (s *PostgresStore) RecordCompletenessScore(userID string, day time.Time, score int) error {
+	_, err := s.db.Exec(`
+		INSERT INTO completeness_scores (user_id, day, score, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (user_id, day) DO UPDATE SET score = EXCLUDED.score, updated_at = NOW()
+	`, userID, day.UTC().Format("2006-01-02"), score)
+	return err
+}
+
+// GetCompletenessTrend calcula a média diária das notas de preparo em [from, to)
+func (s *PostgresStore) GetCompletenessTrend(from, to time.Time) ([]*CompletenessDay, error) {
+	rows, err := s.db.Query(`
+		SELECT day, COUNT(*), AVG(score), COUNT(*) FILTER (WHERE score = 100)
+		FROM completeness_scores
+		WHERE day >= $1 AND day < $2
+		GROUP BY day
+		ORDER BY day
+	`, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := make([]*CompletenessDay, 0)
+	for rows.Next() {
+		var d CompletenessDay
+		if err := rows.Scan(&d.Day, &d.Users, &d.AverageScore, &d.Complete); err != nil {
+			return nil, err
+		}
+		days = append(days, &d)
+	}
+	return days, rows.Err()
+}
+
+// ============================================================================
+// SHARE LINKS (Compartilhamento com Guardiões)
+// ============================================================================
+
+// CreateShareLink cria um novo link de compartilhamento
+func (s *PostgresStore) CreateShareLink(link *ShareLink) error {
+	encHint, err := s.encryptSensitive(link.PassphraseHint)
+	if err != nil {
+		return fmt.Errorf("erro ao criptografar dica da frase secreta: %w", err)
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO share_links (id, user_id, guardian_id, guardian_ids, token, type, name, pin_hash, categories, expires_at, max_uses, is_active, created_at, updated_at, messages_only, item_ids, passphrase_hint)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+	`, link.ID, link.UserID, nullString(link.GuardianID), pq.Array(link.GuardianIDs), link.Token, link.Type, link.Name,
+		nullString(link.PIN), pq.Array(link.Categories), link.ExpiresAt, link.MaxUses, link.IsActive, link.CreatedAt, link.UpdatedAt, link.MessagesOnly,
+		pq.Array(link.ItemIDs), nullString(encHint))
+	return err
+}
+
+// GetShareLinkByToken busca um link pelo token
+func (s *PostgresStore) GetShareLinkByToken(token string) (*ShareLink, error) {
+	var link ShareLink
+	var guardianID, pinHash, passphraseHint sql.NullString
+	var expiresAt, lastUsedAt sql.NullTime
+	var categories, guardianIDs, itemIDs pq.StringArray
+
+	err := s.db.QueryRow(`
+		SELECT id, user_id, guardian_id, guardian_ids, token, type, name, pin_hash, categories, expires_at, max_uses, usage_count, last_used_at, is_active, created_at, updated_at, messages_only, item_ids, passphrase_hint
+		FROM share_links
+		WHERE token = $1 AND is_active = TRUE
+	`, token).Scan(&link.ID, &link.UserID, &guardianID, &guardianIDs, &link.Token, &link.Type, &link.Name,
+		&pinHash, &categories, &expiresAt, &link.MaxUses, &link.UsageCount, &lastUsedAt, &link.IsActive, &link.CreatedAt, &link.UpdatedAt, &link.MessagesOnly,
+		&itemIDs, &passphraseHint)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	link.GuardianID = guardianID.String
+	link.GuardianIDs = guardianIDs
+	link.PIN = pinHash.String
+	link.Categories = categories
+	link.ItemIDs = itemIDs
+	link.PassphraseHint = s.decryptSensitive(passphraseHint.String)
+	if expiresAt.Valid {
+		link.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		link.LastUsedAt = &lastUsedAt.Time
+	}
+
+	return &link, nil
+}
+
+// GetShareLinksByUser lista todos os links de um usuário
+func (s *PostgresStore) GetShareLinksByUser(userID string) ([]*ShareLink, error) {
+	rows, err := s.db.Query(`
+		SELECT id, user_id, guardian_id, guardian_ids, token, type, name, categories, expires_at, max_uses, usage_count, last_used_at, is_active, created_at, updated_at, messages_only, item_ids, passphrase_hint
+		FROM share_links
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT 100
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []*ShareLink
+	for rows.Next() {
+		var link ShareLink
+		var guardianID, passphraseHint sql.NullString
+		var expiresAt, lastUsedAt sql.NullTime
+		var categories, guardianIDs, itemIDs pq.StringArray
+
+		err := rows.Scan(&link.ID, &link.UserID, &guardianID, &guardianIDs, &link.Token, &link.Type, &link.Name,
+			&categories, &expiresAt, &link.MaxUses, &link.UsageCount, &lastUsedAt, &link.IsActive, &link.CreatedAt, &link.UpdatedAt, &link.MessagesOnly,
+			&itemIDs, &passphraseHint)
+		if err != nil {
+			continue
+		}
+
+		link.GuardianID = guardianID.String
+		link.GuardianIDs = guardianIDs
+		link.Categories = categories
+		link.ItemIDs = itemIDs
+		link.PassphraseHint = s.decryptSensitive(passphraseHint.String)
+		if expiresAt.Valid {
+			link.ExpiresAt = &expiresAt.Time
+		}
+		if lastUsedAt.Valid {
+			link.LastUsedAt = &lastUsedAt.Time
+		}
+		links = append(links, &link)
+	}
+
+	return links, nil
+}
+
+// UpdateShareLink atualiza um link
+func (s *PostgresStore) UpdateShareLink(link *ShareLink) error {
+	encHint, err := s.encryptSensitive(link.PassphraseHint)
+	if err != nil {
+		return fmt.Errorf("erro ao criptografar dica da frase secreta: %w", err)
+	}
+
+	_, err = s.db.Exec(`
+		UPDATE share_links SET name = $1, categories = $2, expires_at = $3, max_uses = $4, is_active = $5, updated_at = $6,
+			item_ids = $7, messages_only = $8, passphrase_hint = $9
+		WHERE id = $10 AND user_id = $11
+	`, link.Name, pq.Array(link.Categories), link.ExpiresAt, link.MaxUses, link.IsActive, time.Now(),
+		pq.Array(link.ItemIDs), link.MessagesOnly, nullString(encHint), link.ID, link.UserID)
+	return err
+}
+
+// DeleteShareLink remove um link
+func (s *PostgresStore) DeleteShareLink(userID, linkID string) error {
+	result, err := s.db.Exec(`DELETE FROM share_links WHERE id = $1 AND user_id = $2`, linkID, userID)
+	if err != nil {
+		return err
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RecordShareLinkAccess registra um acesso a um link
+func (s *PostgresStore) RecordShareLinkAccess(access *ShareLinkAccess) error {
+	_, err := s.db.Exec(`
+		INSERT INTO share_link_accesses (id, share_link_id, ip_address, user_agent, country, country_name, city, accessed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, access.ID, access.ShareLinkID, access.IPAddress, access.UserAgent, nullString(access.Country),
+		nullString(access.CountryName), nullString(access.City), access.AccessedAt)
+	return err
+}
+
+// ListShareLinkAccesses lista os acessos a um link do usuário
+func (s *PostgresStore) ListShareLinkAccesses(userID, linkID string, limit int) ([]*ShareLinkAccess, error) {
+	var exists bool
+	err := s.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM share_links WHERE id = $1 AND user_id = $2)`, linkID, userID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, share_link_id, ip_address, user_agent, country, country_name, city, accessed_at
+		FROM share_link_accesses
+		WHERE share_link_id = $1
+		ORDER BY accessed_at DESC
+		LIMIT $2
+	`, linkID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accesses := make([]*ShareLinkAccess, 0)
+	for rows.Next() {
+		var access ShareLinkAccess
+		var ip, userAgent, country, countryName, city sql.NullString
+		if err := rows.Scan(&access.ID, &access.ShareLinkID, &ip, &userAgent, &country, &countryName, &city, &access.AccessedAt); err != nil {
+			return nil, err
+		}
+		access.IPAddress = ip.String
+		access.UserAgent = userAgent.String
+		access.Country = country.String
+		access.CountryName = countryName.String
+		access.City = city.String
+		accesses = append(accesses, &access)
+	}
+	return accesses, rows.Err()
+}
+
+// IncrementShareLinkUsage incrementa o contador de uso
+func (s *PostgresStore) IncrementShareLinkUsage(linkID string) error {
+	_, err := s.db.Exec(`
+		UPDATE share_links SET usage_count = usage_count + 1, last_used_at = $1 WHERE id = $2
+	`, time.Now(), linkID)
+	return err
+}
+
+// ClaimShareLinkUsage conta um uso se o link ativo ainda tiver usos
+// A condição fica no próprio UPDATE: dois acessos simultâneos ao último uso
+// não passam juntos.
+func (s *PostgresStore) ClaimShareLinkUsage(linkID string) (bool, error) {
+	result, err := s.db.Exec(`
+		UPDATE share_links SET usage_count = usage_count + 1, last_used_at = $1
+		WHERE id = $2 AND is_active = TRUE AND (max_uses = 0 OR usage_count < max_uses)
+	`, time.Now(), linkID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// ============================================================================
+// PIN ATTEMPTS (Proteção contra força bruta)
+// ============================================================================
+
+// GetPINAttempt retorna as falhas de PIN registradas para a chave
+func (s *PostgresStore) GetPINAttempt(key string) (*PINAttempt, error) {
+	var attempt PINAttempt
+	err := s.db.QueryRow(`
+		SELECT key, failed_attempts, last_failed_at FROM pin_attempts WHERE key = $1
+	`, key).Scan(&attempt.Key, &attempt.FailedAttempts, &attempt.LastFailedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// RecordPINFailure incrementa as falhas de PIN (upsert atômico)
+// Tentativas simultâneas não perdem incrementos.
+func (s *PostgresStore) RecordPINFailure(key string) (*PINAttempt, error) {
+	var attempt PINAttempt
+	err := s.db.QueryRow(`
+		INSERT INTO pin_attempts (key, failed_attempts, last_failed_at)
+		VALUES ($1, 1, $2)
+		ON CONFLICT (key) DO UPDATE
+		SET failed_attempts = pin_attempts.failed_attempts + 1, last_failed_at = EXCLUDED.last_failed_at
+		RETURNING key, failed_attempts, last_failed_at
+	`, key, time.Now()).Scan(&attempt.Key, &attempt.FailedAttempts, &attempt.LastFailedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// ResetPINAttempts remove as falhas de PIN da chave
+func (s *PostgresStore) ResetPINAttempts(key string) error {
+	_, err := s.db.Exec(`DELETE FROM pin_attempts WHERE key = $1`, key)
+	return err
+}
+
+// GetMemorialConfig busca quem pode liberar o memorial do usuário
+func (s *PostgresStore) GetMemorialConfig(userID string) (*MemorialConfig, error) {
+	config := &MemorialConfig{UserID: userID}
+	err := s.db.QueryRow(`
+		SELECT guardian_ids, required_confirmations, delay_hours, updated_at FROM memorial_configs WHERE user_id = $1
+	`, userID).Scan(pq.Array(&config.GuardianIDs), &config.RequiredConfirmations, &config.DelayHours, &config.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// SaveMemorialConfig cria ou substitui a configuração do memorial
+func (s *PostgresStore) SaveMemorialConfig(config *MemorialConfig) error {
+	config.UpdatedAt = time.Now()
+	_, err := s.db.Exec(`
+		INSERT INTO memorial_configs (user_id, guardian_ids, required_confirmations, delay_hours, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE
+		SET guardian_ids = EXCLUDED.guardian_ids, required_confirmations = EXCLUDED.required_confirmations,
+			delay_hours = EXCLUDED.delay_hours, updated_at = EXCLUDED.updated_at
+	`, config.UserID, pq.Array(config.GuardianIDs), config.RequiredConfirmations, config.DelayHours, config.UpdatedAt)
+	return err
+}
+
+// DeleteMemorialConfig remove a configuração do memorial
+func (s *PostgresStore) DeleteMemorialConfig(userID string) error {
+	result, err := s.db.Exec(`DELETE FROM memorial_configs WHERE user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+const memorialActivationColumns = `id, user_id, status, guardian_ids, required_confirmations, delay_hours, confirmations, expires_at, unlocks_at, unlocked_at, aborted_at, created_at, updated_at`
+
+// marshalMemorialConfirmations converte as confirmações para o JSONB (sem os nomes)
+func marshalMemorialConfirmations(confirmations []MemorialConfirmation) ([]byte, error) {
+	records := make([]MemorialConfirmation, len(confirmations))
+	for i, confirmation := range confirmations {
+		records[i] = confirmation
+		records[i].GuardianName = ""
+	}
+	return json.Marshal(records)
+}
+
+// CreateMemorialActivation salva um novo pedido de abertura do memorial
+// O índice único recusa um segundo pedido pendente ou em espera.
+func (s *PostgresStore) CreateMemorialActivation(activation *MemorialActivation) error {
+	confirmations, err := marshalMemorialConfirmations(activation.Confirmations)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	activation.CreatedAt = now
+	activation.UpdatedAt = now
+	_, err = s.db.Exec(`
+		INSERT INTO memorial_activations (`+memorialActivationColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`, activation.ID, activation.UserID, activation.Status, pq.Array(activation.GuardianIDs), activation.RequiredConfirmations,
+		activation.DelayHours, confirmations, activation.ExpiresAt, activation.UnlocksAt, activation.UnlockedAt,
+		activation.AbortedAt, activation.CreatedAt, activation.UpdatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate") || strings.Contains(err.Error(), "unique") {
+			return ErrAlreadyExists
+		}
+		return err
+	}
+	return nil
+}
+
+// UpdateMemorialActivation salva o pedido se o estado salvo ainda for from
+// (um UPDATE condicional: o dono, as confirmações e o worker não se sobrescrevem)
+func (s *PostgresStore) UpdateMemorialActivation(activation *MemorialActivation, from MemorialActivationStatus) error {
+	confirmations, err := marshalMemorialConfirmations(activation.Confirmations)
+	if err != nil {
+		return err
+	}
+	updatedAt := time.Now()
+	result, err := s.db.Exec(`
+		UPDATE memorial_activations
+		SET status = $3, confirmations = $4, unlocks_at = $5, unlocked_at = $6, aborted_at = $7, updated_at = $8
+		WHERE id = $1 AND status = $2
+	`, activation.ID, from, activation.Status, confirmations, activation.UnlocksAt, activation.UnlockedAt,
+		activation.AbortedAt, updatedAt)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		var exists bool
+		if err := s.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM memorial_activations WHERE id = $1)`, activation.ID).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return ErrNotFound
+		}
+		return ErrVersionConflict
+	}
+	activation.UpdatedAt = updatedAt
+	return nil
+}
+
+// AddMemorialConfirmation acrescenta a confirmação de forma atômica (duas
+// pessoas confirmando juntas não se perdem); repetida não duplica
+func (s *PostgresStore) AddMemorialConfirmation(activationID string, confirmation MemorialConfirmation) (*MemorialActivation, error) {
+	confirmation.GuardianName = ""
+	entry, err := json.Marshal([]MemorialConfirmation{confirmation})
+	if err != nil {
+		return nil, err
+	}
+	match, err := json.Marshal([]map[string]string{{"guardian_id": confirmation.GuardianID}})
+	if err != nil {
+		return nil, err
+	}
+
+	activation, err := scanMemorialActivation(s.db.QueryRow(`
+		UPDATE memorial_activations
+		SET confirmations = CASE WHEN confirmations @> $3::jsonb THEN confirmations ELSE confirmations || $2::jsonb END,
+			updated_at = $4
+		WHERE id = $1 AND status = 'pending'
+		RETURNING `+memorialActivationColumns, activationID, string(entry), string(match), time.Now()))
+	if err != ErrNotFound {
+		return activation, err
+	}
+
+	var exists bool
+	if err := s.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM memorial_activations WHERE id = $1)`, activationID).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return nil, ErrVersionConflict
+}
+
+// GetLatestMemorialActivation busca o pedido mais recente do usuário
+func (s *PostgresStore) GetLatestMemorialActivation(userID string) (*MemorialActivation, error) {
+	return scanMemorialActivation(s.db.QueryRow(`
+		SELECT `+memorialActivationColumns+` FROM memorial_activations
+		WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1
+	`, userID))
+}
+
+// ListDueMemorialActivations lista pedidos com a espera vencida (waiting) ou
+// sem confirmações suficientes no prazo (pending)
+func (s *PostgresStore) ListDueMemorialActivations(now time.Time, limit int) ([]*MemorialActivation, error) {
+	rows, err := s.db.Query(`
+		SELECT `+memorialActivationColumns+` FROM memorial_activations
+		WHERE (status = 'waiting' AND unlocks_at <= $1) OR (status = 'pending' AND expires_at <= $1)
+		ORDER BY created_at LIMIT $2
+	`, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	due := make([]*MemorialActivation, 0)
+	for rows.Next() {
+		activation, err := scanMemorialActivation(rows)
+		if err != nil {
+			return nil, err
+		}
+		due = append(due, activation)
+	}
+	return due, rows.Err()
+}
+
+// scanMemorialActivation lê um pedido de abertura (ErrNotFound se não houver linha)
+func scanMemorialActivation(row interface{ Scan(...interface{}) error }) (*MemorialActivation, error) {
+	var a MemorialActivation
+	var status string
+	var confirmations []byte
+	var unlocksAt, unlockedAt, abortedAt sql.NullTime
+	err := row.Scan(&a.ID, &a.UserID, &status, pq.Array(&a.GuardianIDs), &a.RequiredConfirmations, &a.DelayHours,
+		&confirmations, &a.ExpiresAt, &unlocksAt, &unlockedAt, &abortedAt, &a.CreatedAt, &a.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(confirmations, &a.Confirmations); err != nil {
+		return nil, fmt.Errorf("erro ao ler confirmações do memorial: %w", err)
+	}
+	a.Status = MemorialActivationStatus(status)
+	if unlocksAt.Valid {
+		a.UnlocksAt = &unlocksAt.Time
+	}
+	if unlockedAt.Valid {
+		a.UnlockedAt = &unlockedAt.Time
+	}
+	if abortedAt.Valid {
+		a.AbortedAt = &abortedAt.Time
+	}
+	return &a, nil
+}
+
+// ============================================================================
+// PASSWORD RESET (Recuperação de Senha)
+// ============================================================================
+
+// CreatePasswordResetToken cria um token de recuperação
+func (s *PostgresStore) CreatePasswordResetToken(token *PasswordResetToken) error {
+	// Invalidar tokens anteriores do usuário
+	s.db.Exec(`UPDATE password_reset_tokens SET used_at = $1 WHERE user_id = $2 AND used_at IS NULL`, time.Now(), token.UserID)
+
+	_, err := s.db.Exec(`
+		INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, token.ID, token.UserID, token.Token, token.ExpiresAt, token.CreatedAt)
+	return err
+}
+
+// GetPasswordResetToken busca um token válido
+func (s *PostgresStore) GetPasswordResetToken(tokenHash string) (*PasswordResetToken, error) {
+	var token PasswordResetToken
+	var usedAt sql.NullTime
+
+	err := s.db.QueryRow(`
+		SELECT id, user_id, token_hash, expires_at, used_at, created_at
+		FROM password_reset_tokens
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2
+	`, tokenHash, time.Now()).Scan(&token.ID, &token.UserID, &token.Token, &token.ExpiresAt, &usedAt, &token.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+
+	return &token, nil
+}
+
+// MarkPasswordResetTokenUsed marca um token como usado
+func (s *PostgresStore) MarkPasswordResetTokenUsed(tokenID string) error {
+	_, err := s.db.Exec(`UPDATE password_reset_tokens SET used_at = $1 WHERE id = $2`, time.Now(), tokenID)
+	return err
 }
 
-// GetGuardianByAccessToken busca um guardião pelo seu token de acesso
-func (s *PostgresStore) GetGuardianByAccessToken(token string) (*Guardian, error) {
-	var g Guardian
-	var name, email, phone, relationship, notes, accessToken, accessPIN, accessType sql.NullString
+// CleanupExpiredPasswordResetTokens remove tokens expirados
+func (s *PostgresStore) CleanupExpiredPasswordResetTokens() error {
+	_, err := s.db.Exec(`DELETE FROM password_reset_tokens WHERE expires_at < $1 OR used_at IS NOT NULL`, time.Now().Add(-24*time.Hour))
+	return err
+}
+
+// ============================================================================
+// EMERGENCY PROTOCOL (Protocolo de Emergência)
+// ============================================================================
+
+// GetEmergencyProtocol busca o protocolo de emergência de um usuário
+func (s *PostgresStore) GetEmergencyProtocol(userID string) (*EmergencyProtocol, error) {
+	var protocol EmergencyProtocol
+	var activatedAt, deactivatedAt sql.NullTime
+	var activatedBy, reason sql.NullString
 
 	err := s.db.QueryRow(`
-		SELECT id, user_id, name, email, phone, relationship, role, notes, access_token, access_pin, access_type, created_at, updated_at
-		FROM guardians 
-		WHERE access_token = $1
-	`, token).Scan(
-		&g.ID, &g.UserID, &name, &email, &phone,
-		&relationship, &g.Role, &notes, &accessToken, &accessPIN, &accessType,
-		&g.CreatedAt, &g.UpdatedAt,
-	)
+		SELECT user_id, is_active, activated_at, activated_by, deactivated_at, reason, notify_guardians
+		FROM emergency_protocols WHERE user_id = $1
+	`, userID).Scan(&protocol.UserID, &protocol.IsActive, &activatedAt, &activatedBy, &deactivatedAt, &reason, &protocol.NotifyGuardians)
 
 	if err == sql.ErrNoRows {
-		return nil, ErrNotFound
+		// Retornar protocolo padrão (não ativado)
+		return &EmergencyProtocol{UserID: userID, IsActive: false, NotifyGuardians: true}, nil
 	}
 	if err != nil {
 		return nil, err
 	}
 
-	g.Name = s.decryptSensitive(name.String)
-	g.Email = s.decryptSensitive(email.String)
-	g.Phone = s.decryptSensitive(phone.String)
-	g.Relationship = relationship.String
-	g.Notes = s.decryptSensitive(notes.String)
-	g.AccessToken = accessToken.String
-	g.AccessPIN = accessPIN.String
-	g.HasPIN = accessPIN.String != ""
-	g.AccessType = GuardianAccessType(accessType.String)
-	return &g, nil
+	if activatedAt.Valid {
+		protocol.ActivatedAt = &activatedAt.Time
+	}
+	if deactivatedAt.Valid {
+		protocol.DeactivatedAt = &deactivatedAt.Time
+	}
+	protocol.ActivatedBy = activatedBy.String
+	protocol.Reason = reason.String
+
+	return &protocol, nil
 }
 
-// ListSharedItems lista itens compartilhados de um usuário
-func (s *PostgresStore) ListSharedItems(userID string) []*BoxItem {
-	rows, err := s.db.Query(`
-		SELECT id, user_id, type, title, content, category, recipient, is_important, is_shared, guardian_ids, created_at, updated_at
-		FROM box_items 
-		WHERE user_id = $1 AND is_shared = TRUE
-		ORDER BY updated_at DESC
-		LIMIT 100
-	`, userID)
+// UpdateEmergencyProtocol atualiza o protocolo de emergência
+func (s *PostgresStore) UpdateEmergencyProtocol(protocol *EmergencyProtocol) error {
+	_, err := s.db.Exec(`
+		INSERT INTO emergency_protocols (user_id, is_active, activated_at, activated_by, deactivated_at, reason, notify_guardians, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id) DO UPDATE SET 
+			is_active = $2, activated_at = $3, activated_by = $4, deactivated_at = $5, reason = $6, notify_guardians = $7, updated_at = $8
+	`, protocol.UserID, protocol.IsActive, protocol.ActivatedAt, nullString(protocol.ActivatedBy),
+		protocol.DeactivatedAt, nullString(protocol.Reason), protocol.NotifyGuardians, time.Now())
+	return err
+}
+
+// emergencyCardData são os campos do cartão, salvos como um JSON criptografado
+type emergencyCardData struct {
+	Name        string             `json:"name,omitempty"`
+	BloodType   string             `json:"blood_type,omitempty"`
+	Contacts    []EmergencyContact `json:"contacts,omitempty"`
+	Medications []string           `json:"medications,omitempty"`
+
+	IncludeSchedule bool `json:"include_schedule,omitempty"`
+	IncludeLegal    bool `json:"include_legal,omitempty"`
+}
+
+// SaveEmergencyCard cria ou substitui o cartão de emergência do usuário
+func (s *PostgresStore) SaveEmergencyCard(card *EmergencyCard) error {
+	data, err := json.Marshal(emergencyCardData{
+		Name:        card.Name,
+		BloodType:   card.BloodType,
+		Contacts:    card.Contacts,
+		Medications: card.Medications,
+
+		IncludeSchedule: card.IncludeSchedule,
+		IncludeLegal:    card.IncludeLegal,
+	})
 	if err != nil {
-		return []*BoxItem{}
+		return err
 	}
-	defer rows.Close()
-
-	var items []*BoxItem
-	for rows.Next() {
-		var item BoxItem
-		var title, content, category, recipient sql.NullString
-		var guardianIDs pq.StringArray
-		err := rows.Scan(
-			&item.ID, &item.UserID, &item.Type, &title,
-			&content, &category, &recipient,
-			&item.IsImportant, &item.IsShared, &guardianIDs, &item.CreatedAt, &item.UpdatedAt,
-		)
-		if err != nil {
-			continue
-		}
-		item.Title = s.decryptSensitive(title.String)
-		item.Content = s.decryptSensitive(content.String)
-		item.Category = category.String
-		item.Recipient = s.decryptSensitive(recipient.String)
-		item.GuardianIDs = guardianIDs
-		items = append(items, &item)
+	encData, err := s.encryptSensitive(string(data))
+	if err != nil {
+		return fmt.Errorf("erro ao criptografar cartão de emergência: %w", err)
 	}
 
-	return items
+	_, err = s.db.Exec(`
+		INSERT INTO emergency_cards (user_id, token_hash, data, created_at, updated_at, last_accessed_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id) DO UPDATE SET
+			token_hash = EXCLUDED.token_hash,
+			data = EXCLUDED.data,
+			updated_at = EXCLUDED.updated_at,
+			last_accessed_at = EXCLUDED.last_accessed_at
+	`, card.UserID, card.TokenHash, encData, card.CreatedAt, card.UpdatedAt, card.LastAccessedAt)
+	return err
 }
 
-// CreateGuardian cria um novo guardião com dados criptografados
-func (s *PostgresStore) CreateGuardian(userID string, guardian *Guardian) (*Guardian, error) {
-	id := fmt.Sprintf("grd_%d", time.Now().UnixNano())
-	return s.CreateGuardianWithID(userID, guardian, id)
+// GetEmergencyCard busca o cartão de emergência do usuário
+func (s *PostgresStore) GetEmergencyCard(userID string) (*EmergencyCard, error) {
+	return s.scanEmergencyCard(s.db.QueryRow(`
+		SELECT user_id, token_hash, data, created_at, updated_at, last_accessed_at
+		FROM emergency_cards WHERE user_id = $1
+	`, userID))
 }
 
-func (s *PostgresStore) CreateGuardianWithID(userID string, guardian *Guardian, guardianID string) (*Guardian, error) {
-	now := time.Now()
-	role := guardian.Role
-	if role == "" {
-		role = "viewer"
+// GetEmergencyCardByToken busca o cartão de emergência pelo hash do token
+func (s *PostgresStore) GetEmergencyCardByToken(tokenHash string) (*EmergencyCard, error) {
+	return s.scanEmergencyCard(s.db.QueryRow(`
+		SELECT user_id, token_hash, data, created_at, updated_at, last_accessed_at
+		FROM emergency_cards WHERE token_hash = $1
+	`, tokenHash))
+}
+
+func (s *PostgresStore) scanEmergencyCard(row *sql.Row) (*EmergencyCard, error) {
+	var card EmergencyCard
+	var encData string
+	var lastAccessedAt sql.NullTime
+	err := row.Scan(&card.UserID, &card.TokenHash, &encData, &card.CreatedAt, &card.UpdatedAt, &lastAccessedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if lastAccessedAt.Valid {
+		card.LastAccessedAt = &lastAccessedAt.Time
 	}
 
-	// Gerar access_token único para o guardião
-	accessToken := generateAccessToken()
-	accessType := guardian.AccessType
-	if accessType == "" {
-		accessType = GuardianAccessNormal
+	var data emergencyCardData
+	if err := json.Unmarshal([]byte(s.decryptSensitive(encData)), &data); err == nil {
+		card.Name = data.Name
+		card.BloodType = data.BloodType
+		card.Contacts = data.Contacts
+		card.Medications = data.Medications
+		card.IncludeSchedule = data.IncludeSchedule
+		card.IncludeLegal = data.IncludeLegal
 	}
+	return &card, nil
+}
 
-	// Criptografar dados sensíveis (PII)
-	encName, err := s.encryptSensitive(guardian.Name)
+// DeleteEmergencyCard remove o cartão de emergência (e o link) do usuário
+func (s *PostgresStore) DeleteEmergencyCard(userID string) error {
+	result, err := s.db.Exec(`DELETE FROM emergency_cards WHERE user_id = $1`, userID)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao criptografar nome: %w", err)
+		return err
 	}
-	encEmail, err := s.encryptSensitive(guardian.Email)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao criptografar email: %w", err)
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
 	}
-	encPhone, err := s.encryptSensitive(guardian.Phone)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao criptografar telefone: %w", err)
+	return nil
+}
+
+// TouchEmergencyCard registra o último acesso ao cartão
+func (s *PostgresStore) TouchEmergencyCard(userID string, accessedAt time.Time) error {
+	_, err := s.db.Exec(`UPDATE emergency_cards SET last_accessed_at = $1 WHERE user_id = $2`, accessedAt, userID)
+	return err
+}
+
+// GetEscalationChain busca a lista de escalonamento do usuário
+func (s *PostgresStore) GetEscalationChain(userID string) (*EscalationChain, error) {
+	chain := &EscalationChain{UserID: userID}
+	err := s.db.QueryRow(`
+		SELECT guardian_ids, ack_timeout_minutes, updated_at FROM emergency_escalation_chains WHERE user_id = $1
+	`, userID).Scan(pq.Array(&chain.GuardianIDs), &chain.AckTimeoutMinutes, &chain.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
 	}
-	encNotes, err := s.encryptSensitive(guardian.Notes)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao criptografar notas: %w", err)
+		return nil, err
 	}
+	return chain, nil
+}
 
-	_, err = s.db.Exec(`
-		INSERT INTO guardians (id, user_id, name, email, phone, relationship, role, notes, access_token, access_pin, access_type, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
-	`, guardianID, userID, encName, encEmail, encPhone, guardian.Relationship, role, encNotes, accessToken, guardian.AccessPIN, accessType, now, now)
+// SaveEscalationChain cria ou substitui a lista de escalonamento
+func (s *PostgresStore) SaveEscalationChain(chain *EscalationChain) error {
+	chain.UpdatedAt = time.Now()
+	_, err := s.db.Exec(`
+		INSERT INTO emergency_escalation_chains (user_id, guardian_ids, ack_timeout_minutes, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE
+		SET guardian_ids = EXCLUDED.guardian_ids, ack_timeout_minutes = EXCLUDED.ack_timeout_minutes, updated_at = EXCLUDED.updated_at
+	`, chain.UserID, pq.Array(chain.GuardianIDs), chain.AckTimeoutMinutes, chain.UpdatedAt)
+	return err
+}
 
+// DeleteEscalationChain remove a lista de escalonamento
+func (s *PostgresStore) DeleteEscalationChain(userID string) error {
+	result, err := s.db.Exec(`DELETE FROM emergency_escalation_chains WHERE user_id = $1`, userID)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
 	}
+	return nil
+}
 
-	guardian.ID = guardianID
-	guardian.UserID = userID
-	guardian.Role = role
-	guardian.AccessToken = accessToken
-	guardian.HasPIN = guardian.AccessPIN != ""
-	guardian.AccessType = accessType
-	guardian.CreatedAt = now
-	guardian.UpdatedAt = now
-	return guardian, nil
+const escalationColumns = `id, user_id, status, steps, current_step, ack_timeout_minutes, next_step_at, acknowledged_by, acknowledged_at, created_at, updated_at`
+
+// escalationStepRecord é a pessoa da lista como fica no banco (com o hash do
+// token, que não sai na API)
+type escalationStepRecord struct {
+	EscalationStep
+	AckTokenHash string `json:"ack_token_hash,omitempty"`
 }
 
-// generateAccessToken gera um token único URL-safe de 20 caracteres
-func generateAccessToken() string {
-	b := make([]byte, 15)
-	rand.Read(b)
-	token := base64.URLEncoding.EncodeToString(b)
-	// Remover caracteres especiais e limitar a 20 chars
-	token = strings.ReplaceAll(token, "-", "")
-	token = strings.ReplaceAll(token, "_", "")
-	if len(token) > 20 {
-		token = token[:20]
+// marshalEscalationSteps converte as pessoas da lista para o JSONB
+func marshalEscalationSteps(steps []EscalationStep) ([]byte, error) {
+	records := make([]escalationStepRecord, len(steps))
+	for i, step := range steps {
+		records[i] = escalationStepRecord{EscalationStep: step, AckTokenHash: step.AckTokenHash}
+		records[i].GuardianName = ""
 	}
-	return token
+	return json.Marshal(records)
 }
 
-// UpdateGuardian atualiza um guardião com dados criptografados
-func (s *PostgresStore) UpdateGuardian(userID, guardianID string, updates *Guardian) (*Guardian, error) {
-	// Criptografar dados sensíveis (PII)
-	encName, err := s.encryptSensitive(updates.Name)
+// CreateEscalation salva um novo escalonamento
+func (s *PostgresStore) CreateEscalation(escalation *EmergencyEscalation) error {
+	steps, err := marshalEscalationSteps(escalation.Steps)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao criptografar nome: %w", err)
+		return err
 	}
-	encEmail, err := s.encryptSensitive(updates.Email)
+	now := time.Now()
+	escalation.CreatedAt = now
+	escalation.UpdatedAt = now
+	_, err = s.db.Exec(`
+		INSERT INTO emergency_escalations (`+escalationColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, escalation.ID, escalation.UserID, escalation.Status, steps, escalation.CurrentStep, escalation.AckTimeoutMinutes,
+		escalation.NextStepAt, nullString(escalation.AcknowledgedBy), escalation.AcknowledgedAt, escalation.CreatedAt, escalation.UpdatedAt)
+	return err
+}
+
+// UpdateEscalation salva o escalonamento se o estado salvo ainda for from
+// (um UPDATE condicional: a confirmação e o worker não se sobrescrevem)
+func (s *PostgresStore) UpdateEscalation(escalation *EmergencyEscalation, from EscalationStatus) error {
+	steps, err := marshalEscalationSteps(escalation.Steps)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao criptografar email: %w", err)
+		return err
 	}
-	encPhone, err := s.encryptSensitive(updates.Phone)
+	updatedAt := time.Now()
+	result, err := s.db.Exec(`
+		UPDATE emergency_escalations
+		SET status = $3, steps = $4, current_step = $5, next_step_at = $6, acknowledged_by = $7, acknowledged_at = $8, updated_at = $9
+		WHERE id = $1 AND status = $2
+	`, escalation.ID, from, escalation.Status, steps, escalation.CurrentStep, escalation.NextStepAt,
+		nullString(escalation.AcknowledgedBy), escalation.AcknowledgedAt, updatedAt)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao criptografar telefone: %w", err)
+		return err
 	}
-	encNotes, err := s.encryptSensitive(updates.Notes)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao criptografar notas: %w", err)
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		var exists bool
+		if err := s.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM emergency_escalations WHERE id = $1)`, escalation.ID).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return ErrNotFound
+		}
+		return ErrVersionConflict
 	}
+	escalation.UpdatedAt = updatedAt
+	return nil
+}
 
-	var result sql.Result
+// GetLatestEscalation busca o escalonamento mais recente do usuário
+func (s *PostgresStore) GetLatestEscalation(userID string) (*EmergencyEscalation, error) {
+	return scanEscalation(s.db.QueryRow(`
+		SELECT `+escalationColumns+` FROM emergency_escalations
+		WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1
+	`, userID))
+}
 
-	// Se PIN foi fornecido, atualizar também
-	if updates.AccessPIN != "" {
-		result, err = s.db.Exec(`
-			UPDATE guardians 
-			SET name = $1, email = $2, phone = $3, relationship = $4, notes = $5, access_pin = $6, updated_at = $7
-			WHERE user_id = $8 AND id = $9
-		`, encName, encEmail, encPhone, updates.Relationship, encNotes, updates.AccessPIN, time.Now(), userID, guardianID)
-	} else {
-		result, err = s.db.Exec(`
-			UPDATE guardians 
-			SET name = $1, email = $2, phone = $3, relationship = $4, notes = $5, updated_at = $6
-			WHERE user_id = $7 AND id = $8
-		`, encName, encEmail, encPhone, updates.Relationship, encNotes, time.Now(), userID, guardianID)
+// GetEscalationByAckToken busca o escalonamento pelo hash do token de confirmação
+func (s *PostgresStore) GetEscalationByAckToken(tokenHash string) (*EmergencyEscalation, error) {
+	match, err := json.Marshal([]map[string]string{{"ack_token_hash": tokenHash}})
+	if err != nil {
+		return nil, err
 	}
+	return scanEscalation(s.db.QueryRow(`
+		SELECT `+escalationColumns+` FROM emergency_escalations WHERE steps @> $1::jsonb
+	`, string(match)))
+}
 
+// ListDueEscalations lista escalonamentos ativos cuja próxima chamada já venceu
+func (s *PostgresStore) ListDueEscalations(now time.Time, limit int) ([]*EmergencyEscalation, error) {
+	rows, err := s.db.Query(`
+		SELECT `+escalationColumns+` FROM emergency_escalations
+		WHERE status = 'active' AND next_step_at <= $1
+		ORDER BY next_step_at LIMIT $2
+	`, now, limit)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	rows, _ := result.RowsAffected()
-	if rows == 0 {
-		return nil, ErrNotFound
+	due := make([]*EmergencyEscalation, 0)
+	for rows.Next() {
+		escalation, err := scanEscalation(rows)
+		if err != nil {
+			return nil, err
+		}
+		due = append(due, escalation)
 	}
+	return due, rows.Err()
+}
 
-	// Buscar guardião atualizado
-	var g Guardian
-	var name, email, phone, relationship, notes, accessToken, accessPIN, accessType sql.NullString
-	err = s.db.QueryRow(`
-		SELECT id, user_id, name, email, phone, relationship, role, notes, access_token, access_pin, access_type, created_at, updated_at
-		FROM guardians WHERE user_id = $1 AND id = $2
-	`, userID, guardianID).Scan(
-		&g.ID, &g.UserID, &name, &email, &phone,
-		&relationship, &g.Role, &notes, &accessToken, &accessPIN, &accessType,
-		&g.CreatedAt, &g.UpdatedAt,
-	)
+// scanEscalation lê um escalonamento (ErrNotFound se não houver linha)
+func scanEscalation(row interface{ Scan(...interface{}) error }) (*EmergencyEscalation, error) {
+	var e EmergencyEscalation
+	var status string
+	var steps []byte
+	var acknowledgedBy sql.NullString
+	var nextStepAt, acknowledgedAt sql.NullTime
+	err := row.Scan(&e.ID, &e.UserID, &status, &steps, &e.CurrentStep, &e.AckTimeoutMinutes, &nextStepAt,
+		&acknowledgedBy, &acknowledgedAt, &e.CreatedAt, &e.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
-	// Descriptografar dados sensíveis
-	g.Name = s.decryptSensitive(name.String)
-	g.Email = s.decryptSensitive(email.String)
-	g.Phone = s.decryptSensitive(phone.String)
-	g.Relationship = relationship.String
-	g.Notes = s.decryptSensitive(notes.String)
-	g.AccessToken = accessToken.String
-	g.AccessPIN = accessPIN.String
-	g.HasPIN = accessPIN.String != ""
-	g.AccessType = GuardianAccessType(accessType.String)
-	return &g, nil
+
+	var records []escalationStepRecord
+	if err := json.Unmarshal(steps, &records); err != nil {
+		return nil, fmt.Errorf("erro ao ler lista do escalonamento: %w", err)
+	}
+	e.Steps = make([]EscalationStep, len(records))
+	for i, record := range records {
+		e.Steps[i] = record.EscalationStep
+		e.Steps[i].AckTokenHash = record.AckTokenHash
+	}
+	e.Status = EscalationStatus(status)
+	e.AcknowledgedBy = acknowledgedBy.String
+	if nextStepAt.Valid {
+		e.NextStepAt = &nextStepAt.Time
+	}
+	if acknowledgedAt.Valid {
+		e.AcknowledgedAt = &acknowledgedAt.Time
+	}
+	return &e, nil
+}
+
+// ============================================================================
+// WEBHOOKS
+// ============================================================================
+
+const webhookColumns = `id, user_id, url, secret, events, description, is_active, failure_count, last_delivery_at, created_at, updated_at`
+
+// CreateWebhook salva um novo webhook (segredo criptografado)
+func (s *PostgresStore) CreateWebhook(hook *Webhook) error {
+	encSecret, err := s.encryptSensitive(hook.Secret)
+	if err != nil {
+		return fmt.Errorf("erro ao criptografar segredo: %w", err)
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO webhooks (id, user_id, url, secret, events, description, is_active, failure_count, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, hook.ID, hook.UserID, hook.URL, encSecret, pq.Array(webhookEventStrings(hook.Events)),
+		nullString(hook.Description), hook.IsActive, hook.FailureCount, hook.CreatedAt, hook.UpdatedAt)
+	return err
+}
+
+// GetWebhook busca um webhook do usuário
+func (s *PostgresStore) GetWebhook(userID, webhookID string) (*Webhook, error) {
+	row := s.db.QueryRow(`SELECT `+webhookColumns+` FROM webhooks WHERE id = $1 AND user_id = $2`, webhookID, userID)
+	hook, err := s.scanWebhook(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return hook, err
+}
+
+// ListWebhooks lista os webhooks do usuário (mais recentes primeiro)
+func (s *PostgresStore) ListWebhooks(userID string) ([]*Webhook, error) {
+	return s.queryWebhooks(`SELECT `+webhookColumns+` FROM webhooks WHERE user_id = $1 ORDER BY created_at DESC`, userID)
 }
 
-func (s *PostgresStore) DeleteGuardian(userID, guardianID string) error {
-	result, err := s.db.Exec(`
-		DELETE FROM guardians WHERE user_id = $1 AND id = $2
-	`, userID, guardianID)
+// ListWebhooksForEvent lista os webhooks ativos do usuário que assinam o evento
+func (s *PostgresStore) ListWebhooksForEvent(userID string, event WebhookEvent) ([]*Webhook, error) {
+	if event == WebhookPing {
+		return s.queryWebhooks(`SELECT `+webhookColumns+` FROM webhooks WHERE user_id = $1 AND is_active = TRUE`, userID)
+	}
+	return s.queryWebhooks(`
+		SELECT `+webhookColumns+` FROM webhooks
+		WHERE user_id = $1 AND is_active = TRUE AND $2 = ANY(events)
+	`, userID, string(event))
+}
 
+// UpdateWebhook atualiza URL, eventos, descrição, status e segredo
+func (s *PostgresStore) UpdateWebhook(hook *Webhook) error {
+	encSecret, err := s.encryptSensitive(hook.Secret)
 	if err != nil {
-		return err
+		return fmt.Errorf("erro ao criptografar segredo: %w", err)
 	}
 
-	rows, _ := result.RowsAffected()
-	if rows == 0 {
+	result, err := s.db.Exec(`
+		UPDATE webhooks SET url = $1, secret = $2, events = $3, description = $4, is_active = $5,
+			failure_count = CASE WHEN $5 THEN $6 ELSE failure_count END, updated_at = $7
+		WHERE id = $8 AND user_id = $9
+	`, hook.URL, encSecret, pq.Array(webhookEventStrings(hook.Events)), nullString(hook.Description),
+		hook.IsActive, hook.FailureCount, time.Now(), hook.ID, hook.UserID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
 		return ErrNotFound
 	}
-
 	return nil
 }
 
-// RegisterIdempotencyKey registra uma chave idempotente para um recurso.
-func (s *PostgresStore) RegisterIdempotencyKey(userID, key, resourceType, resourceID string) (string, bool, error) {
-	result, err := s.db.Exec(`
-		INSERT INTO idempotency_keys (user_id, key, resource_type, resource_id, created_at)
-		VALUES ($1, $2, $3, $4, $5)
-		ON CONFLICT DO NOTHING
-	`, userID, key, resourceType, resourceID, time.Now())
+// DeleteWebhook remove um webhook (entregas removidas em cascata)
+func (s *PostgresStore) DeleteWebhook(userID, webhookID string) error {
+	result, err := s.db.Exec(`DELETE FROM webhooks WHERE id = $1 AND user_id = $2`, webhookID, userID)
 	if err != nil {
-		return "", false, err
+		return err
 	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
 
-	rows, _ := result.RowsAffected()
-	if rows == 1 {
-		return "", true, nil
+// RecordWebhookResult atualiza falhas consecutivas e a data da última entrega
+func (s *PostgresStore) RecordWebhookResult(webhookID string, success bool) error {
+	if success {
+		_, err := s.db.Exec(`UPDATE webhooks SET failure_count = 0, last_delivery_at = $1 WHERE id = $2`, time.Now(), webhookID)
+		return err
 	}
+	_, err := s.db.Exec(`UPDATE webhooks SET failure_count = failure_count + 1 WHERE id = $1`, webhookID)
+	return err
+}
 
-	var existingID string
-	err = s.db.QueryRow(`
-		SELECT resource_id FROM idempotency_keys
-		WHERE user_id = $1 AND key = $2 AND resource_type = $3
-	`, userID, key, resourceType).Scan(&existingID)
-	if err == sql.ErrNoRows {
-		return "", false, ErrNotFound
+// CreateWebhookDelivery registra uma nova entrega
+func (s *PostgresStore) CreateWebhookDelivery(delivery *WebhookDelivery) error {
+	_, err := s.db.Exec(`
+		INSERT INTO webhook_deliveries (id, webhook_id, user_id, event, payload, status, attempts, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, delivery.ID, delivery.WebhookID, delivery.UserID, string(delivery.Event), delivery.Payload,
+		string(delivery.Status), delivery.Attempts, delivery.NextAttemptAt, delivery.CreatedAt)
+	return err
+}
+
+// UpdateWebhookDelivery atualiza o resultado de uma tentativa de entrega
+func (s *PostgresStore) UpdateWebhookDelivery(delivery *WebhookDelivery) error {
+	var responseCode sql.NullInt64
+	if delivery.ResponseCode > 0 {
+		responseCode = sql.NullInt64{Int64: int64(delivery.ResponseCode), Valid: true}
 	}
+
+	result, err := s.db.Exec(`
+		UPDATE webhook_deliveries SET status = $1, attempts = $2, response_code = $3, error = $4,
+			next_attempt_at = $5, delivered_at = $6
+		WHERE id = $7
+	`, string(delivery.Status), delivery.Attempts, responseCode, nullString(delivery.Error),
+		delivery.NextAttemptAt, delivery.DeliveredAt, delivery.ID)
 	if err != nil {
-		return "", false, err
+		return err
 	}
-	return existingID, false, nil
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
 }
 
-// DeleteIdempotencyKey remove uma chave idempotente (ex.: após falha).
-func (s *PostgresStore) DeleteIdempotencyKey(userID, key, resourceType string) error {
-	_, err := s.db.Exec(`
-		DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND resource_type = $3
-	`, userID, key, resourceType)
-	return err
+// ListWebhookDeliveries lista as entregas mais recentes de um webhook
+func (s *PostgresStore) ListWebhookDeliveries(webhookID string, limit int) ([]*WebhookDelivery, error) {
+	return s.queryWebhookDeliveries(`
+		SELECT id, webhook_id, user_id, event, payload, status, attempts, response_code, error, next_attempt_at, created_at, delivered_at
+		FROM webhook_deliveries WHERE webhook_id = $1
+		ORDER BY created_at DESC LIMIT $2
+	`, webhookID, limit)
 }
 
-// ============================================================================
-// GUIDE PROGRESS
-// ============================================================================
+// ListDueWebhookDeliveries lista entregas pendentes cuja próxima tentativa já venceu
+func (s *PostgresStore) ListDueWebhookDeliveries(now time.Time, limit int) ([]*WebhookDelivery, error) {
+	return s.queryWebhookDeliveries(`
+		SELECT id, webhook_id, user_id, event, payload, status, attempts, response_code, error, next_attempt_at, created_at, delivered_at
+		FROM webhook_deliveries WHERE status = 'pending' AND next_attempt_at <= $1
+		ORDER BY next_attempt_at LIMIT $2
+	`, now, limit)
+}
 
-func (s *PostgresStore) GetGuideProgress(userID string) map[string]*GuideProgress {
-	rows, err := s.db.Query(`
-		SELECT user_id, card_id, status, completed_at
-		FROM guide_progress WHERE user_id = $1 LIMIT 50
-	`, userID)
+// queryWebhooks executa uma consulta e lê os webhooks retornados
+func (s *PostgresStore) queryWebhooks(query string, args ...interface{}) ([]*Webhook, error) {
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
-		return map[string]*GuideProgress{}
+		return nil, err
 	}
 	defer rows.Close()
 
-	progress := make(map[string]*GuideProgress)
+	hooks := make([]*Webhook, 0)
 	for rows.Next() {
-		var p GuideProgress
-		var completedAt sql.NullTime
-		err := rows.Scan(&p.UserID, &p.CardID, &p.Status, &completedAt)
+		hook, err := s.scanWebhook(rows)
 		if err != nil {
-			continue
-		}
-		if completedAt.Valid {
-			p.CompletedAt = completedAt.Time
+			return nil, err
 		}
-		progress[p.CardID] = &p
+		hooks = append(hooks, hook)
 	}
-
-	return progress
+	return hooks, rows.Err()
 }
 
-func (s *PostgresStore) UpdateGuideProgress(userID, cardID, status string) (*GuideProgress, error) {
-	now := time.Now()
-	var completedAt *time.Time
-	if status == "completed" {
-		completedAt = &now
-	}
-
-	// Upsert
-	_, err := s.db.Exec(`
-		INSERT INTO guide_progress (user_id, card_id, status, completed_at, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		ON CONFLICT (user_id, card_id) 
-		DO UPDATE SET status = $3, completed_at = $4, updated_at = $6
-	`, userID, cardID, status, completedAt, now, now)
+// scanWebhook lê um webhook de uma linha (descriptografando o segredo)
+func (s *PostgresStore) scanWebhook(row interface{ Scan(...interface{}) error }) (*Webhook, error) {
+	var hook Webhook
+	var events []string
+	var description sql.NullString
+	var lastDeliveryAt sql.NullTime
+	var secret string
 
+	err := row.Scan(&hook.ID, &hook.UserID, &hook.URL, &secret, pq.Array(&events), &description,
+		&hook.IsActive, &hook.FailureCount, &lastDeliveryAt, &hook.CreatedAt, &hook.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
 
-	progress := &GuideProgress{
-		UserID: userID,
-		CardID: cardID,
-		Status: status,
+	hook.Secret = s.decryptSensitive(secret)
+	hook.Description = description.String
+	hook.Events = make([]WebhookEvent, 0, len(events))
+	for _, e := range events {
+		hook.Events = append(hook.Events, WebhookEvent(e))
 	}
-	if completedAt != nil {
-		progress.CompletedAt = *completedAt
+	if lastDeliveryAt.Valid {
+		hook.LastDeliveryAt = &lastDeliveryAt.Time
+	}
+	return &hook, nil
+}
+
+// queryWebhookDeliveries executa uma consulta e lê as entregas retornadas
+func (s *PostgresStore) queryWebhookDeliveries(query string, args ...interface{}) ([]*WebhookDelivery, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	return progress, nil
+	deliveries := make([]*WebhookDelivery, 0)
+	for rows.Next() {
+		var d WebhookDelivery
+		var event, status string
+		var responseCode sql.NullInt64
+		var errMsg sql.NullString
+		var nextAttemptAt, deliveredAt sql.NullTime
+
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.UserID, &event, &d.Payload, &status, &d.Attempts,
+			&responseCode, &errMsg, &nextAttemptAt, &d.CreatedAt, &deliveredAt); err != nil {
+			return nil, err
+		}
+
+		d.Event = WebhookEvent(event)
+		d.Status = WebhookDeliveryStatus(status)
+		d.ResponseCode = int(responseCode.Int64)
+		d.Error = errMsg.String
+		if nextAttemptAt.Valid {
+			d.NextAttemptAt = &nextAttemptAt.Time
+		}
+		if deliveredAt.Valid {
+			d.DeliveredAt = &deliveredAt.Time
+		}
+		deliveries = append(deliveries, &d)
+	}
+	return deliveries, rows.Err()
 }
 
 // ============================================================================
-// SETTINGS
+// WEB PUSH
 // ============================================================================
 
-func (s *PostgresStore) GetSettings(userID string) *Settings {
-	var settings Settings
-	err := s.db.QueryRow(`
-		SELECT user_id, emergency_protocol_enabled, notifications_enabled, theme
-		FROM settings WHERE user_id = $1
-	`, userID).Scan(&settings.UserID, &settings.EmergencyProtocolEnabled, &settings.NotificationsEnabled, &settings.Theme)
-
-	if err == sql.ErrNoRows {
-		// Criar configurações padrão
-		settings = Settings{
-			UserID:               userID,
-			NotificationsEnabled: true,
-			Theme:                "light",
-		}
-		s.db.Exec(`
-			INSERT INTO settings (user_id, notifications_enabled, theme)
-			VALUES ($1, $2, $3)
-		`, userID, true, "light")
+// SavePushSubscription cria ou substitui a inscrição do endpoint (auth criptografado)
+func (s *PostgresStore) SavePushSubscription(sub *PushSubscription) error {
+	encAuth, err := s.encryptSensitive(sub.Auth)
+	if err != nil {
+		return fmt.Errorf("erro ao criptografar inscrição: %w", err)
 	}
 
-	return &settings
+	_, err = s.db.Exec(`
+		INSERT INTO push_subscriptions (id, user_id, endpoint, p256dh, auth, user_agent, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (endpoint)
+		DO UPDATE SET user_id = $2, p256dh = $4, auth = $5, user_agent = $6, created_at = $7, last_used_at = NULL
+	`, sub.ID, sub.UserID, sub.Endpoint, sub.P256dh, encAuth, nullString(sub.UserAgent), sub.CreatedAt)
+	return err
 }
 
-func (s *PostgresStore) UpdateSettings(userID string, updates *Settings) *Settings {
-	s.db.Exec(`
-		INSERT INTO settings (user_id, emergency_protocol_enabled, notifications_enabled, theme)
-		VALUES ($1, $2, $3, $4)
-		ON CONFLICT (user_id) 
-		DO UPDATE SET emergency_protocol_enabled = $2, notifications_enabled = $3, theme = $4
-	`, userID, updates.EmergencyProtocolEnabled, updates.NotificationsEnabled, updates.Theme)
+// ListPushSubscriptions lista as inscrições do usuário (mais recentes primeiro)
+func (s *PostgresStore) ListPushSubscriptions(userID string) ([]*PushSubscription, error) {
+	rows, err := s.db.Query(`
+		SELECT id, user_id, endpoint, p256dh, auth, user_agent, created_at, last_used_at
+		FROM push_subscriptions WHERE user_id = $1 ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	updates.UserID = userID
-	return updates
-}
+	subs := make([]*PushSubscription, 0)
+	for rows.Next() {
+		var sub PushSubscription
+		var userAgent sql.NullString
+		var lastUsedAt sql.NullTime
 
-// ============================================================================
-// ADMIN / ESTATÍSTICAS
-// ============================================================================
+		if err := rows.Scan(&sub.ID, &sub.UserID, &sub.Endpoint, &sub.P256dh, &sub.Auth,
+			&userAgent, &sub.CreatedAt, &lastUsedAt); err != nil {
+			return nil, err
+		}
 
-func (s *PostgresStore) GetStats() *Stats {
-	stats := &Stats{
-		ItemsByType:     make(map[string]int),
-		ItemsByCategory: make(map[string]int),
+		sub.Auth = s.decryptSensitive(sub.Auth)
+		sub.UserAgent = userAgent.String
+		if lastUsedAt.Valid {
+			sub.LastUsedAt = &lastUsedAt.Time
+		}
+		subs = append(subs, &sub)
 	}
+	return subs, rows.Err()
+}
 
-	// Total de usuários
-	s.db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&stats.TotalUsers)
+// DeletePushSubscription remove a inscrição do endpoint (apenas do próprio usuário)
+func (s *PostgresStore) DeletePushSubscription(userID, endpoint string) error {
+	result, err := s.db.Exec(`DELETE FROM push_subscriptions WHERE endpoint = $1 AND user_id = $2`, endpoint, userID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
 
-	// Usuários recentes (7 dias)
-	s.db.QueryRow(`SELECT COUNT(*) FROM users WHERE created_at > NOW() - INTERVAL '7 days'`).Scan(&stats.RecentSignups)
+// TouchPushSubscription registra o último envio com sucesso
+func (s *PostgresStore) TouchPushSubscription(id string, usedAt time.Time) error {
+	_, err := s.db.Exec(`UPDATE push_subscriptions SET last_used_at = $1 WHERE id = $2`, usedAt, id)
+	return err
+}
 
-	// Total de itens
-	s.db.QueryRow(`SELECT COUNT(*) FROM box_items`).Scan(&stats.TotalItems)
+// ============================================================================
+// FAMÍLIAS (caixas compartilhadas)
+// ============================================================================
 
-	// Total de guardiões
-	s.db.QueryRow(`SELECT COUNT(*) FROM guardians`).Scan(&stats.TotalGuardians)
+// CreateHousehold cria a família e o membro dono na mesma instrução
+func (s *PostgresStore) CreateHousehold(household *Household, owner *HouseholdMember) error {
+	_, err := s.db.Exec(`
+		WITH created AS (
+			INSERT INTO households (id, name, owner_id, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id
+		)
+		INSERT INTO household_members (household_id, user_id, permission, status, invited_by, joined_at, created_at)
+		SELECT id, $6, $7, $8, NULL, $9, $10 FROM created
+	`, household.ID, household.Name, household.OwnerID, household.CreatedAt, household.UpdatedAt,
+		owner.UserID, owner.Permission, owner.Status, owner.JoinedAt, owner.CreatedAt)
+	return err
+}
 
-	// Itens por tipo (máximo 20 tipos diferentes)
-	rows, _ := s.db.Query(`SELECT type, COUNT(*) FROM box_items GROUP BY type LIMIT 20`)
-	if rows != nil {
-		defer rows.Close()
-		for rows.Next() {
-			var itemType string
-			var count int
-			rows.Scan(&itemType, &count)
-			stats.ItemsByType[itemType] = count
-		}
+// GetHousehold busca uma família por ID
+func (s *PostgresStore) GetHousehold(householdID string) (*Household, error) {
+	var h Household
+	err := s.db.QueryRow(`
+		SELECT id, name, owner_id, created_at, updated_at
+		FROM households WHERE id = $1
+	`, householdID).Scan(&h.ID, &h.Name, &h.OwnerID, &h.CreatedAt, &h.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
 	}
+	if err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
 
-	// Itens por categoria (máximo 20 categorias)
-	rows2, _ := s.db.Query(`SELECT category, COUNT(*) FROM box_items WHERE category != '' GROUP BY category LIMIT 20`)
-	if rows2 != nil {
-		defer rows2.Close()
-		for rows2.Next() {
-			var category string
-			var count int
-			rows2.Scan(&category, &count)
-			stats.ItemsByCategory[category] = count
-		}
+// UpdateHousehold altera o nome da família
+func (s *PostgresStore) UpdateHousehold(household *Household) error {
+	result, err := s.db.Exec(`
+		UPDATE households SET name = $1, updated_at = $2 WHERE id = $3
+	`, household.Name, household.UpdatedAt, household.ID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
 	}
+	return nil
+}
 
-	return stats
+// DeleteHousehold remove a família (itens voltam para quem criou via ON DELETE SET NULL)
+func (s *PostgresStore) DeleteHousehold(householdID string) error {
+	result, err := s.db.Exec(`DELETE FROM households WHERE id = $1`, householdID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
 }
 
-func (s *PostgresStore) ListUsers() []*User {
+// ListHouseholdsForUser lista as famílias do usuário (inclui convites pendentes)
+func (s *PostgresStore) ListHouseholdsForUser(userID string) ([]*Household, error) {
 	rows, err := s.db.Query(`
-		SELECT id, email, name, created_at FROM users ORDER BY created_at DESC LIMIT 500
-	`)
+		SELECT h.id, h.name, h.owner_id, h.created_at, h.updated_at
+		FROM households h
+		JOIN household_members m ON m.household_id = h.id
+		WHERE m.user_id = $1
+		ORDER BY h.created_at
+		LIMIT 50
+	`, userID)
 	if err != nil {
-		return []*User{}
+		return nil, err
 	}
 	defer rows.Close()
 
-	var users []*User
+	households := make([]*Household, 0)
 	for rows.Next() {
-		var user User
-		var name sql.NullString
-		rows.Scan(&user.ID, &user.Email, &name, &user.CreatedAt)
-		user.Name = name.String
-		users = append(users, &user)
+		var h Household
+		if err := rows.Scan(&h.ID, &h.Name, &h.OwnerID, &h.CreatedAt, &h.UpdatedAt); err != nil {
+			continue
+		}
+		households = append(households, &h)
 	}
-
-	return users
+	return households, rows.Err()
 }
 
-// ============================================================================
-// FEEDBACK
-// ============================================================================
-
-// CreateFeedback salva um novo feedback
-func (s *PostgresStore) CreateFeedback(f *Feedback) error {
-	_, err := s.db.Exec(`
-		INSERT INTO feedbacks (id, user_id, user_email, type, message, page, user_agent, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-	`, f.ID, f.UserID, f.UserEmail, f.Type, f.Message, f.Page, f.UserAgent, f.Status, f.CreatedAt, f.UpdatedAt)
-	return err
+// AddHouseholdMember adiciona um membro (convite)
+func (s *PostgresStore) AddHouseholdMember(member *HouseholdMember) error {
+	result, err := s.db.Exec(`
+		INSERT INTO household_members (household_id, user_id, permission, status, invited_by, joined_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (household_id, user_id) DO NOTHING
+	`, member.HouseholdID, member.UserID, member.Permission, member.Status,
+		nullString(member.InvitedBy), member.JoinedAt, member.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrAlreadyExists
+	}
+	return nil
 }
 
-// ListFeedbacks retorna todos os feedbacks (para admin)
-func (s *PostgresStore) ListFeedbacks(status string, limit int) ([]*Feedback, error) {
-	var query string
-	var args []interface{}
+const householdMemberColumns = `m.household_id, m.user_id, m.permission, m.status, m.invited_by, m.joined_at, m.created_at`
 
-	if status != "" && status != "all" {
-		query = `
-			SELECT id, user_id, user_email, type, message, page, user_agent, status, admin_note, created_at, updated_at
-			FROM feedbacks WHERE status = $1 ORDER BY created_at DESC LIMIT $2
-		`
-		args = []interface{}{status, limit}
-	} else {
-		query = `
-			SELECT id, user_id, user_email, type, message, page, user_agent, status, admin_note, created_at, updated_at
-			FROM feedbacks ORDER BY created_at DESC LIMIT $1
-		`
-		args = []interface{}{limit}
+// scanHouseholdMember lê um membro (extra recebe colunas adicionais)
+func scanHouseholdMember(scanner interface{ Scan(...interface{}) error }, extra ...interface{}) (*HouseholdMember, error) {
+	var m HouseholdMember
+	var invitedBy sql.NullString
+	var joinedAt sql.NullTime
+	dest := append([]interface{}{&m.HouseholdID, &m.UserID, &m.Permission, &m.Status, &invitedBy, &joinedAt, &m.CreatedAt}, extra...)
+	if err := scanner.Scan(dest...); err != nil {
+		return nil, err
+	}
+	m.InvitedBy = invitedBy.String
+	if joinedAt.Valid {
+		m.JoinedAt = &joinedAt.Time
 	}
+	return &m, nil
+}
 
-	rows, err := s.db.Query(query, args...)
+// GetHouseholdMember busca a participação do usuário na família
+func (s *PostgresStore) GetHouseholdMember(householdID, userID string) (*HouseholdMember, error) {
+	member, err := scanHouseholdMember(s.db.QueryRow(`
+		SELECT `+householdMemberColumns+`
+		FROM household_members m
+		WHERE m.household_id = $1 AND m.user_id = $2
+	`, householdID, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return member, err
+}
+
+// ListHouseholdMembers lista os membros com nome e email
+func (s *PostgresStore) ListHouseholdMembers(householdID string) ([]*HouseholdMember, error) {
+	rows, err := s.db.Query(`
+		SELECT `+householdMemberColumns+`, u.name, u.email
+		FROM household_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.household_id = $1
+		ORDER BY m.created_at
+	`, householdID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var feedbacks []*Feedback
+	members := make([]*HouseholdMember, 0)
 	for rows.Next() {
-		var f Feedback
-		var userID, userEmail, page, userAgent, adminNote sql.NullString
-		err := rows.Scan(&f.ID, &userID, &userEmail, &f.Type, &f.Message, &page, &userAgent, &f.Status, &adminNote, &f.CreatedAt, &f.UpdatedAt)
+		var name sql.NullString
+		var email string
+		member, err := scanHouseholdMember(rows, &name, &email)
 		if err != nil {
 			continue
 		}
-		f.UserID = userID.String
-		f.UserEmail = userEmail.String
-		f.Page = page.String
-		f.UserAgent = userAgent.String
-		f.AdminNote = adminNote.String
-		feedbacks = append(feedbacks, &f)
+		member.Name = name.String
+		member.Email = email
+		members = append(members, member)
 	}
-
-	return feedbacks, nil
+	return members, rows.Err()
 }
 
-// UpdateFeedbackStatus atualiza o status de um feedback
-func (s *PostgresStore) UpdateFeedbackStatus(id, status, adminNote string) error {
-	_, err := s.db.Exec(`
-		UPDATE feedbacks SET status = $1, admin_note = $2, updated_at = $3 WHERE id = $4
-	`, status, adminNote, time.Now(), id)
-	return err
+// UpdateHouseholdMember altera permissão e estado do convite
+func (s *PostgresStore) UpdateHouseholdMember(member *HouseholdMember) error {
+	result, err := s.db.Exec(`
+		UPDATE household_members SET permission = $1, status = $2, joined_at = $3
+		WHERE household_id = $4 AND user_id = $5
+	`, member.Permission, member.Status, member.JoinedAt, member.HouseholdID, member.UserID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
 }
 
-// GetFeedbackStats retorna estatísticas de feedbacks
-func (s *PostgresStore) GetFeedbackStats() (total, pending int) {
-	s.db.QueryRow(`SELECT COUNT(*) FROM feedbacks`).Scan(&total)
-	s.db.QueryRow(`SELECT COUNT(*) FROM feedbacks WHERE status = 'pending'`).Scan(&pending)
-	return
+// RemoveHouseholdMember remove o membro e devolve os itens dele para a caixa pessoal
+func (s *PostgresStore) RemoveHouseholdMember(householdID, userID string) error {
+	result, err := s.db.Exec(`
+		DELETE FROM household_members WHERE household_id = $1 AND user_id = $2
+	`, householdID, userID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+
+	_, err = s.db.Exec(`
+		UPDATE box_items SET household_id = NULL WHERE household_id = $1 AND user_id = $2
+	`, householdID, userID)
+	return err
 }
 
 // ============================================================================
-// ANALYTICS
+// CALENDÁRIO ICS
 // ============================================================================
 
-// TrackEvent registra um evento de analytics
-func (s *PostgresStore) TrackEvent(e *AnalyticsEvent) error {
-	detailsJSON, _ := json.Marshal(e.Details)
+// SaveCalendarFeed cria ou substitui o link do calendário do usuário
+func (s *PostgresStore) SaveCalendarFeed(feed *CalendarFeed) error {
 	_, err := s.db.Exec(`
-		INSERT INTO analytics_events (id, user_id, event_type, page, details, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
-	`, e.ID, e.UserID, e.EventType, e.Page, detailsJSON, e.CreatedAt)
+		INSERT INTO calendar_feeds (user_id, token_hash, created_at, last_accessed_at)
+		VALUES ($1, $2, $3, NULL)
+		ON CONFLICT (user_id) DO UPDATE SET
+			token_hash = EXCLUDED.token_hash,
+			created_at = EXCLUDED.created_at,
+			last_accessed_at = NULL
+	`, feed.UserID, feed.TokenHash, feed.CreatedAt)
 	return err
 }
 
-// GetAnalyticsSummary retorna o resumo de analytics
-func (s *PostgresStore) GetAnalyticsSummary() *AnalyticsSummary {
-	summary := &AnalyticsSummary{
-		EventsByType: make(map[string]int),
-	}
-
-	// Total de usuários
-	s.db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&summary.TotalUsers)
-
-	// Usuários novos hoje
-	s.db.QueryRow(`SELECT COUNT(*) FROM users WHERE created_at >= CURRENT_DATE`).Scan(&summary.NewUsersToday)
-
-	// Usuários novos esta semana
-	s.db.QueryRow(`SELECT COUNT(*) FROM users WHERE created_at >= CURRENT_DATE - INTERVAL '7 days'`).Scan(&summary.NewUsersThisWeek)
-
-	// Usuários ativos hoje (com eventos)
-	s.db.QueryRow(`SELECT COUNT(DISTINCT user_id) FROM analytics_events WHERE created_at >= CURRENT_DATE AND user_id IS NOT NULL`).Scan(&summary.ActiveToday)
+// GetCalendarFeed busca o link do calendário do usuário
+func (s *PostgresStore) GetCalendarFeed(userID string) (*CalendarFeed, error) {
+	return s.scanCalendarFeed(s.db.QueryRow(`
+		SELECT user_id, token_hash, created_at, last_accessed_at
+		FROM calendar_feeds WHERE user_id = $1
+	`, userID))
+}
 
-	// Usuários ativos esta semana
-	s.db.QueryRow(`SELECT COUNT(DISTINCT user_id) FROM analytics_events WHERE created_at >= CURRENT_DATE - INTERVAL '7 days' AND user_id IS NOT NULL`).Scan(&summary.ActiveThisWeek)
+// GetCalendarFeedByToken busca o link do calendário pelo hash do token
+func (s *PostgresStore) GetCalendarFeedByToken(tokenHash string) (*CalendarFeed, error) {
+	return s.scanCalendarFeed(s.db.QueryRow(`
+		SELECT user_id, token_hash, created_at, last_accessed_at
+		FROM calendar_feeds WHERE token_hash = $1
+	`, tokenHash))
+}
 
-	// Total de itens
-	s.db.QueryRow(`SELECT COUNT(*) FROM box_items`).Scan(&summary.TotalItems)
+func (s *PostgresStore) scanCalendarFeed(row *sql.Row) (*CalendarFeed, error) {
+	var feed CalendarFeed
+	var lastAccessedAt sql.NullTime
+	err := row.Scan(&feed.UserID, &feed.TokenHash, &feed.CreatedAt, &lastAccessedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if lastAccessedAt.Valid {
+		feed.LastAccessedAt = &lastAccessedAt.Time
+	}
+	return &feed, nil
+}
 
-	// Itens criados hoje
-	s.db.QueryRow(`SELECT COUNT(*) FROM box_items WHERE created_at >= CURRENT_DATE`).Scan(&summary.ItemsCreatedToday)
+// DeleteCalendarFeed revoga o link do calendário do usuário
+func (s *PostgresStore) DeleteCalendarFeed(userID string) error {
+	result, err := s.db.Exec(`DELETE FROM calendar_feeds WHERE user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
 
-	// Total de guardiões
-	s.db.QueryRow(`SELECT COUNT(*) FROM guardians`).Scan(&summary.TotalGuardians)
+// TouchCalendarFeed registra o último acesso ao calendário
+func (s *PostgresStore) TouchCalendarFeed(userID string, accessedAt time.Time) error {
+	_, err := s.db.Exec(`UPDATE calendar_feeds SET last_accessed_at = $1 WHERE user_id = $2`, accessedAt, userID)
+	return err
+}
 
-	// Eventos hoje
-	s.db.QueryRow(`SELECT COUNT(*) FROM analytics_events WHERE created_at >= CURRENT_DATE`).Scan(&summary.EventsToday)
+// ============================================================================
+// CAPTURA PELO NAVEGADOR
+// ============================================================================
 
-	// Eventos por tipo (últimos 7 dias, máximo 30 tipos)
-	rows, err := s.db.Query(`
-		SELECT event_type, COUNT(*) as count
-		FROM analytics_events
-		WHERE created_at >= CURRENT_DATE - INTERVAL '7 days'
-		GROUP BY event_type
-		ORDER BY count DESC
-		LIMIT 30
-	`)
-	if err == nil {
-		defer rows.Close()
-		for rows.Next() {
-			var eventType string
-			var count int
-			rows.Scan(&eventType, &count)
-			summary.EventsByType[eventType] = count
-		}
-	}
+// SaveCaptureToken cria ou substitui o token de captura do usuário
+func (s *PostgresStore) SaveCaptureToken(token *CaptureToken) error {
+	_, err := s.db.Exec(`
+		INSERT INTO capture_tokens (user_id, token_hash, created_at, last_used_at)
+		VALUES ($1, $2, $3, NULL)
+		ON CONFLICT (user_id) DO UPDATE SET
+			token_hash = EXCLUDED.token_hash,
+			created_at = EXCLUDED.created_at,
+			last_used_at = NULL
+	`, token.UserID, token.TokenHash, token.CreatedAt)
+	return err
+}
 
-	// Feedbacks
-	summary.TotalFeedbacks, summary.PendingFeedbacks = s.GetFeedbackStats()
+// GetCaptureToken busca o token de captura do usuário
+func (s *PostgresStore) GetCaptureToken(userID string) (*CaptureToken, error) {
+	return s.scanCaptureToken(s.db.QueryRow(`
+		SELECT user_id, token_hash, created_at, last_used_at
+		FROM capture_tokens WHERE user_id = $1
+	`, userID))
+}
 
-	return summary
+// GetCaptureTokenByHash busca o token de captura pelo hash
+func (s *PostgresStore) GetCaptureTokenByHash(tokenHash string) (*CaptureToken, error) {
+	return s.scanCaptureToken(s.db.QueryRow(`
+		SELECT user_id, token_hash, created_at, last_used_at
+		FROM capture_tokens WHERE token_hash = $1
+	`, tokenHash))
 }
 
-// GetRecentEvents retorna os eventos mais recentes
-func (s *PostgresStore) GetRecentEvents(limit int) ([]*AnalyticsEvent, error) {
-	rows, err := s.db.Query(`
-		SELECT id, user_id, event_type, page, details, created_at
-		FROM analytics_events
-		ORDER BY created_at DESC
-		LIMIT $1
-	`, limit)
+func (s *PostgresStore) scanCaptureToken(row *sql.Row) (*CaptureToken, error) {
+	var token CaptureToken
+	var lastUsedAt sql.NullTime
+	err := row.Scan(&token.UserID, &token.TokenHash, &token.CreatedAt, &lastUsedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+	return &token, nil
+}
 
-	var events []*AnalyticsEvent
-	for rows.Next() {
-		var e AnalyticsEvent
-		var userID, page sql.NullString
-		var detailsJSON []byte
-		err := rows.Scan(&e.ID, &userID, &e.EventType, &page, &detailsJSON, &e.CreatedAt)
-		if err != nil {
-			continue
-		}
-		e.UserID = userID.String
-		e.Page = page.String
-		if len(detailsJSON) > 0 {
-			json.Unmarshal(detailsJSON, &e.Details)
-		}
-		events = append(events, &e)
+// DeleteCaptureToken revoga o token de captura do usuário
+func (s *PostgresStore) DeleteCaptureToken(userID string) error {
+	result, err := s.db.Exec(`DELETE FROM capture_tokens WHERE user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
 	}
+	return nil
+}
 
-	return events, nil
+// TouchCaptureToken registra o último uso do token
+func (s *PostgresStore) TouchCaptureToken(userID string, usedAt time.Time) error {
+	_, err := s.db.Exec(`UPDATE capture_tokens SET last_used_at = $1 WHERE user_id = $2`, usedAt, userID)
+	return err
 }
 
-// GetDailyStats retorna estatísticas diárias para gráficos
-func (s *PostgresStore) GetDailyStats(days int) ([]map[string]interface{}, error) {
-	rows, err := s.db.Query(`
-		SELECT 
-			DATE(created_at) as date,
-			COUNT(*) as events,
-			COUNT(DISTINCT user_id) as users
-		FROM analytics_events
-		WHERE created_at >= CURRENT_DATE - $1 * INTERVAL '1 day'
-		GROUP BY DATE(created_at)
-		ORDER BY date
-	`, days)
+// SaveItemSource salva a página de origem de um item capturado
+func (s *PostgresStore) SaveItemSource(source *ItemSource) error {
+	encURL, err := s.encryptSensitive(source.URL)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("erro ao criptografar url: %w", err)
 	}
-	defer rows.Close()
-
-	var stats []map[string]interface{}
-	for rows.Next() {
-		var date time.Time
-		var events, users int
-		rows.Scan(&date, &events, &users)
-		stats = append(stats, map[string]interface{}{
-			"date":   date.Format("2006-01-02"),
-			"events": events,
-			"users":  users,
-		})
+	encTitle, err := s.encryptSensitive(source.PageTitle)
+	if err != nil {
+		return fmt.Errorf("erro ao criptografar título: %w", err)
 	}
+	_, err = s.db.Exec(`
+		INSERT INTO item_sources (item_id, url, page_title, via, captured_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (item_id) DO UPDATE SET
+			url = EXCLUDED.url,
+			page_title = EXCLUDED.page_title,
+			via = EXCLUDED.via,
+			captured_at = EXCLUDED.captured_at
+	`, source.ItemID, encURL, nullString(encTitle), source.Via, source.CapturedAt)
+	return err
+}
 
-	return stats, nil
+// GetItemSource busca a página de origem de um item
+func (s *PostgresStore) GetItemSource(itemID string) (*ItemSource, error) {
+	var source ItemSource
+	var title sql.NullString
+	err := s.db.QueryRow(`
+		SELECT item_id, url, page_title, via, captured_at
+		FROM item_sources WHERE item_id = $1
+	`, itemID).Scan(&source.ItemID, &source.URL, &title, &source.Via, &source.CapturedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	source.URL = s.decryptSensitive(source.URL)
+	source.PageTitle = s.decryptSensitive(title.String)
+	return &source, nil
 }
 
 // ============================================================================
-// SHARE LINKS (Compartilhamento com Guardiões)
+// CENTRAL DE NOTIFICAÇÕES
 // ============================================================================
 
-// CreateShareLink cria um novo link de compartilhamento
-func (s *PostgresStore) CreateShareLink(link *ShareLink) error {
+const notificationColumns = `id, user_id, category, title, body, url, channels, read_at, created_at`
+
+// CreateNotification salva um novo aviso
+func (s *PostgresStore) CreateNotification(n *Notification) error {
 	_, err := s.db.Exec(`
-		INSERT INTO share_links (id, user_id, guardian_id, guardian_ids, token, type, name, pin_hash, categories, expires_at, max_uses, is_active, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
-	`, link.ID, link.UserID, nullString(link.GuardianID), pq.Array(link.GuardianIDs), link.Token, link.Type, link.Name,
-		nullString(link.PIN), pq.Array(link.Categories), link.ExpiresAt, link.MaxUses, link.IsActive, link.CreatedAt, link.UpdatedAt)
+		INSERT INTO notifications (id, user_id, category, title, body, url, channels, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, n.ID, n.UserID, string(n.Category), n.Title, n.Body, nullString(n.URL), pq.Array(n.Channels), n.CreatedAt)
 	return err
 }
 
-// GetShareLinkByToken busca um link pelo token
-func (s *PostgresStore) GetShareLinkByToken(token string) (*ShareLink, error) {
-	var link ShareLink
-	var guardianID, pinHash sql.NullString
-	var expiresAt, lastUsedAt sql.NullTime
-	var categories, guardianIDs pq.StringArray
+// UpdateNotificationChannels registra os canais externos em que o aviso foi entregue
+func (s *PostgresStore) UpdateNotificationChannels(notificationID string, channels []string) error {
+	_, err := s.db.Exec(`UPDATE notifications SET channels = $1 WHERE id = $2`, pq.Array(channels), notificationID)
+	return err
+}
 
-	err := s.db.QueryRow(`
-		SELECT id, user_id, guardian_id, guardian_ids, token, type, name, pin_hash, categories, expires_at, max_uses, usage_count, last_used_at, is_active, created_at, updated_at
-		FROM share_links
-		WHERE token = $1 AND is_active = TRUE
-	`, token).Scan(&link.ID, &link.UserID, &guardianID, &guardianIDs, &link.Token, &link.Type, &link.Name,
-		&pinHash, &categories, &expiresAt, &link.MaxUses, &link.UsageCount, &lastUsedAt, &link.IsActive, &link.CreatedAt, &link.UpdatedAt)
+// ListNotifications lista os avisos do usuário (mais recentes primeiro, cursor = ID)
+func (s *PostgresStore) ListNotifications(userID string, unreadOnly bool, params *PaginationParams) (*PaginatedResult[*Notification], error) {
+	params = NormalizePagination(params)
 
-	if err == sql.ErrNoRows {
-		return nil, ErrNotFound
+	query := `SELECT ` + notificationColumns + ` FROM notifications WHERE user_id = $1`
+	args := []interface{}{userID}
+	if unreadOnly {
+		query += ` AND read_at IS NULL`
+	}
+	if params.Cursor != "" {
+		// Keyset por (created_at, id): IDs não são ordenados no tempo
+		args = append(args, params.Cursor)
+		query += fmt.Sprintf(` AND (created_at, id) < (SELECT created_at, id FROM notifications WHERE id = $%d AND user_id = $1)`, len(args))
 	}
+	args = append(args, params.Limit+1)
+	query += fmt.Sprintf(` ORDER BY created_at DESC, id DESC LIMIT $%d`, len(args))
+
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
+		return nil, fmt.Errorf("erro ao listar notificações: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]*Notification, 0)
+	for rows.Next() {
+		var n Notification
+		var category string
+		var link sql.NullString
+		var readAt sql.NullTime
+
+		if err := rows.Scan(&n.ID, &n.UserID, &category, &n.Title, &n.Body, &link,
+			pq.Array(&n.Channels), &readAt, &n.CreatedAt); err != nil {
+			return nil, err
+		}
+		n.Category = NotificationCategory(category)
+		n.URL = link.String
+		if readAt.Valid {
+			n.ReadAt = &readAt.Time
+		}
+		items = append(items, &n)
+	}
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	link.GuardianID = guardianID.String
-	link.GuardianIDs = guardianIDs
-	link.PIN = pinHash.String
-	link.Categories = categories
-	if expiresAt.Valid {
-		link.ExpiresAt = &expiresAt.Time
+	hasMore := len(items) > params.Limit
+	if hasMore {
+		items = items[:params.Limit]
+	}
+	result := &PaginatedResult[*Notification]{Items: items, HasMore: hasMore}
+	if hasMore {
+		result.NextCursor = items[len(items)-1].ID
+	}
+	return result, nil
+}
+
+// CountUnreadNotifications conta os avisos não lidos do usuário
+func (s *PostgresStore) CountUnreadNotifications(userID string) (int, error) {
+	var count int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`, userID).Scan(&count)
+	return count, err
+}
+
+// MarkNotificationRead marca um aviso do usuário como lido
+func (s *PostgresStore) MarkNotificationRead(userID, notificationID string) error {
+	result, err := s.db.Exec(`
+		UPDATE notifications SET read_at = COALESCE(read_at, $1)
+		WHERE id = $2 AND user_id = $3
+	`, time.Now(), notificationID, userID)
+	if err != nil {
+		return err
 	}
-	if lastUsedAt.Valid {
-		link.LastUsedAt = &lastUsedAt.Time
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
 	}
+	return nil
+}
 
-	return &link, nil
+// MarkAllNotificationsRead marca todos os avisos do usuário como lidos
+func (s *PostgresStore) MarkAllNotificationsRead(userID string) error {
+	_, err := s.db.Exec(`UPDATE notifications SET read_at = $1 WHERE user_id = $2 AND read_at IS NULL`, time.Now(), userID)
+	return err
 }
 
-// GetShareLinksByUser lista todos os links de um usuário
-func (s *PostgresStore) GetShareLinksByUser(userID string) ([]*ShareLink, error) {
+// ============================================================================
+// CATEGORIAS DA CAIXA
+// ============================================================================
+
+// ListCategories lista as categorias do usuário por posição
+func (s *PostgresStore) ListCategories(userID string) ([]*Category, error) {
 	rows, err := s.db.Query(`
-		SELECT id, user_id, guardian_id, guardian_ids, token, type, name, categories, expires_at, max_uses, usage_count, last_used_at, is_active, created_at, updated_at
-		FROM share_links
+		SELECT id, user_id, name, color, icon, position, created_at, updated_at
+		FROM categories
 		WHERE user_id = $1
-		ORDER BY created_at DESC
-		LIMIT 100
+		ORDER BY position, created_at
 	`, userID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var links []*ShareLink
+	categories := make([]*Category, 0)
 	for rows.Next() {
-		var link ShareLink
-		var guardianID sql.NullString
-		var expiresAt, lastUsedAt sql.NullTime
-		var categories, guardianIDs pq.StringArray
-
-		err := rows.Scan(&link.ID, &link.UserID, &guardianID, &guardianIDs, &link.Token, &link.Type, &link.Name,
-			&categories, &expiresAt, &link.MaxUses, &link.UsageCount, &lastUsedAt, &link.IsActive, &link.CreatedAt, &link.UpdatedAt)
-		if err != nil {
+		var c Category
+		if err := rows.Scan(&c.ID, &c.UserID, &c.Name, &c.Color, &c.Icon, &c.Position, &c.CreatedAt, &c.UpdatedAt); err != nil {
 			continue
 		}
-
-		link.GuardianID = guardianID.String
-		link.GuardianIDs = guardianIDs
-		link.Categories = categories
-		if expiresAt.Valid {
-			link.ExpiresAt = &expiresAt.Time
-		}
-		if lastUsedAt.Valid {
-			link.LastUsedAt = &lastUsedAt.Time
-		}
-		links = append(links, &link)
+		categories = append(categories, &c)
 	}
+	return categories, rows.Err()
+}
 
-	return links, nil
+// CreateCategory cria uma categoria (nomes únicos por usuário, sem diferenciar maiúsculas)
+func (s *PostgresStore) CreateCategory(category *Category) error {
+	result, err := s.db.Exec(`
+		INSERT INTO categories (id, user_id, name, color, icon, position, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT DO NOTHING
+	`, category.ID, category.UserID, category.Name, category.Color, category.Icon, category.Position,
+		category.CreatedAt, category.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrAlreadyExists
+	}
+	return nil
 }
 
-// UpdateShareLink atualiza um link
-func (s *PostgresStore) UpdateShareLink(link *ShareLink) error {
-	_, err := s.db.Exec(`
-		UPDATE share_links SET name = $1, categories = $2, expires_at = $3, max_uses = $4, is_active = $5, updated_at = $6
-		WHERE id = $7 AND user_id = $8
-	`, link.Name, pq.Array(link.Categories), link.ExpiresAt, link.MaxUses, link.IsActive, time.Now(), link.ID, link.UserID)
-	return err
+// UpdateCategory altera nome, cor e ícone; renomear atualiza os itens do usuário
+func (s *PostgresStore) UpdateCategory(category *Category) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var previousName string
+	err = tx.QueryRow(`
+		SELECT name FROM categories WHERE id = $1 AND user_id = $2 FOR UPDATE
+	`, category.ID, category.UserID).Scan(&previousName)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE categories SET name = $1, color = $2, icon = $3, updated_at = $4
+		WHERE id = $5 AND user_id = $6
+	`, category.Name, category.Color, category.Icon, category.UpdatedAt, category.ID, category.UserID)
+	if isUniqueViolation(err) {
+		return ErrAlreadyExists
+	}
+	if err != nil {
+		return err
+	}
+
+	if previousName != category.Name {
+		if _, err := tx.Exec(`
+			UPDATE box_items SET category = $1 WHERE user_id = $2 AND category = $3
+		`, category.Name, category.UserID, previousName); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
-// DeleteShareLink remove um link
-func (s *PostgresStore) DeleteShareLink(userID, linkID string) error {
-	result, err := s.db.Exec(`DELETE FROM share_links WHERE id = $1 AND user_id = $2`, linkID, userID)
+// DeleteCategory remove a categoria; os itens do usuário ficam sem categoria
+func (s *PostgresStore) DeleteCategory(userID, categoryID string) error {
+	tx, err := s.db.Begin()
 	if err != nil {
 		return err
 	}
-	rows, _ := result.RowsAffected()
-	if rows == 0 {
+	defer tx.Rollback()
+
+	var name string
+	err = tx.QueryRow(`
+		DELETE FROM categories WHERE id = $1 AND user_id = $2 RETURNING name
+	`, categoryID, userID).Scan(&name)
+	if err == sql.ErrNoRows {
 		return ErrNotFound
 	}
-	return nil
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE box_items SET category = '' WHERE user_id = $1 AND category = $2
+	`, userID, name); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-// RecordShareLinkAccess registra um acesso a um link
-func (s *PostgresStore) RecordShareLinkAccess(access *ShareLinkAccess) error {
+// ReorderCategories define a posição das categorias pela ordem da lista
+func (s *PostgresStore) ReorderCategories(userID string, categoryIDs []string) error {
 	_, err := s.db.Exec(`
-		INSERT INTO share_link_accesses (id, share_link_id, ip_address, user_agent, accessed_at)
-		VALUES ($1, $2, $3, $4, $5)
-	`, access.ID, access.ShareLinkID, access.IPAddress, access.UserAgent, access.AccessedAt)
+		UPDATE categories c SET position = o.position - 1, updated_at = $3
+		FROM unnest($2::text[]) WITH ORDINALITY AS o(id, position)
+		WHERE c.id = o.id AND c.user_id = $1
+	`, userID, pq.Array(categoryIDs), time.Now())
 	return err
 }
 
-// IncrementShareLinkUsage incrementa o contador de uso
-func (s *PostgresStore) IncrementShareLinkUsage(linkID string) error {
-	_, err := s.db.Exec(`
-		UPDATE share_links SET usage_count = usage_count + 1, last_used_at = $1 WHERE id = $2
-	`, time.Now(), linkID)
-	return err
+// isUniqueViolation indica violação de índice único (código 23505)
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
 }
 
-// ============================================================================
-// PASSWORD RESET (Recuperação de Senha)
-// ============================================================================
+// notificationCategoryStrings converte categorias para []string (TEXT[] no banco)
+func notificationCategoryStrings(categories []NotificationCategory) []string {
+	result := make([]string, len(categories))
+	for i, c := range categories {
+		result[i] = string(c)
+	}
+	return result
+}
 
-// CreatePasswordResetToken cria um token de recuperação
-func (s *PostgresStore) CreatePasswordResetToken(token *PasswordResetToken) error {
-	// Invalidar tokens anteriores do usuário
-	s.db.Exec(`UPDATE password_reset_tokens SET used_at = $1 WHERE user_id = $2 AND used_at IS NULL`, time.Now(), token.UserID)
+// webhookEventStrings converte eventos para []string (TEXT[] no banco)
+func webhookEventStrings(events []WebhookEvent) []string {
+	result := make([]string, len(events))
+	for i, e := range events {
+		result[i] = string(e)
+	}
+	return result
+}
 
-	_, err := s.db.Exec(`
-		INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at, created_at)
-		VALUES ($1, $2, $3, $4, $5)
-	`, token.ID, token.UserID, token.Token, token.ExpiresAt, token.CreatedAt)
-	return err
+// itemTags garante um array vazio (coluna NOT NULL) em vez de NULL
+func itemTags(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
 }
 
-// GetPasswordResetToken busca um token válido
-func (s *PostgresStore) GetPasswordResetToken(tokenHash string) (*PasswordResetToken, error) {
-	var token PasswordResetToken
-	var usedAt sql.NullTime
+// setItemDates preenche vencimento e renovação lidos do banco
+func setItemDates(item *BoxItem, dueDate, renewalDate sql.NullTime) {
+	if dueDate.Valid {
+		item.DueDate = &dueDate.Time
+	}
+	if renewalDate.Valid {
+		item.RenewalDate = &renewalDate.Time
+	}
+}
 
-	err := s.db.QueryRow(`
-		SELECT id, user_id, token_hash, expires_at, used_at, created_at
-		FROM password_reset_tokens
-		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2
-	`, tokenHash, time.Now()).Scan(&token.ID, &token.UserID, &token.Token, &token.ExpiresAt, &usedAt, &token.CreatedAt)
+// sealedParamsJSON serializa os parâmetros de um item selado (NULL se não houver)
+func sealedParamsJSON(params *SealedParams) sql.NullString {
+	if params == nil {
+		return sql.NullString{}
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: string(data), Valid: true}
+}
 
-	if err == sql.ErrNoRows {
-		return nil, ErrNotFound
+// checklistJSON serializa a lista do item com o texto das linhas
+// criptografado (NULL se não houver lista)
+func (s *PostgresStore) checklistJSON(checklist []ChecklistEntry) (sql.NullString, error) {
+	if len(checklist) == 0 {
+		return sql.NullString{}, nil
+	}
+	encrypted := make([]ChecklistEntry, len(checklist))
+	for i, entry := range checklist {
+		text, err := s.encryptSensitive(entry.Text)
+		if err != nil {
+			return sql.NullString{}, err
+		}
+		entry.Text = text
+		encrypted[i] = entry
 	}
+	data, err := json.Marshal(encrypted)
 	if err != nil {
-		return nil, err
+		return sql.NullString{}, err
 	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
 
-	if usedAt.Valid {
-		token.UsedAt = &usedAt.Time
+// parseChecklist lê a lista do item e descriptografa o texto das linhas
+func (s *PostgresStore) parseChecklist(data sql.NullString) []ChecklistEntry {
+	if !data.Valid || data.String == "" {
+		return nil
+	}
+	var checklist []ChecklistEntry
+	if err := json.Unmarshal([]byte(data.String), &checklist); err != nil {
+		return nil
 	}
+	for i := range checklist {
+		checklist[i].Text = s.decryptSensitive(checklist[i].Text)
+	}
+	return checklist
+}
 
-	return &token, nil
+// scheduleJSON serializa a agenda da rotina com o remédio e a dose
+// criptografados (o nome do responsável não é salvo)
+func (s *PostgresStore) scheduleJSON(schedule *RoutineSchedule) (sql.NullString, error) {
+	if schedule == nil {
+		return sql.NullString{}, nil
+	}
+	encrypted := *schedule
+	encrypted.ResponsibleName = ""
+	var err error
+	if encrypted.Medication, err = s.encryptSensitive(schedule.Medication); err != nil {
+		return sql.NullString{}, err
+	}
+	if encrypted.Dose, err = s.encryptSensitive(schedule.Dose); err != nil {
+		return sql.NullString{}, err
+	}
+	data, err := json.Marshal(encrypted)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
 }
 
-// MarkPasswordResetTokenUsed marca um token como usado
-func (s *PostgresStore) MarkPasswordResetTokenUsed(tokenID string) error {
-	_, err := s.db.Exec(`UPDATE password_reset_tokens SET used_at = $1 WHERE id = $2`, time.Now(), tokenID)
-	return err
+// parseSchedule lê a agenda da rotina e descriptografa o remédio e a dose
+func (s *PostgresStore) parseSchedule(data sql.NullString) *RoutineSchedule {
+	if !data.Valid || data.String == "" {
+		return nil
+	}
+	var schedule RoutineSchedule
+	if err := json.Unmarshal([]byte(data.String), &schedule); err != nil {
+		return nil
+	}
+	schedule.Medication = s.decryptSensitive(schedule.Medication)
+	schedule.Dose = s.decryptSensitive(schedule.Dose)
+	return &schedule
 }
 
-// CleanupExpiredPasswordResetTokens remove tokens expirados
-func (s *PostgresStore) CleanupExpiredPasswordResetTokens() error {
-	_, err := s.db.Exec(`DELETE FROM password_reset_tokens WHERE expires_at < $1 OR used_at IS NOT NULL`, time.Now().Add(-24*time.Hour))
-	return err
+// documentData é o documento como fica no banco: número criptografado e
+// validade em AAAA-MM-DD (comparável como texto nas consultas)
+type documentData struct {
+	Kind      DocumentKind `json:"kind"`
+	Number    string       `json:"number,omitempty"`
+	ExpiresOn string       `json:"expires_on,omitempty"`
+	Legal     *legalData   `json:"legal,omitempty"`
 }
 
-// ============================================================================
-// EMERGENCY PROTOCOL (Protocolo de Emergência)
-// ============================================================================
+// legalData são os dados jurídicos no banco: textos criptografados
+type legalData struct {
+	StoredAt        string `json:"stored_at,omitempty"`
+	LawyerName      string `json:"lawyer_name,omitempty"`
+	LawyerContact   string `json:"lawyer_contact,omitempty"`
+	ExecutorName    string `json:"executor_name,omitempty"`
+	ExecutorContact string `json:"executor_contact,omitempty"`
+	SignedOn        string `json:"signed_on,omitempty"`
+}
 
-// GetEmergencyProtocol busca o protocolo de emergência de um usuário
-func (s *PostgresStore) GetEmergencyProtocol(userID string) (*EmergencyProtocol, error) {
-	var protocol EmergencyProtocol
-	var activatedAt, deactivatedAt sql.NullTime
-	var activatedBy, reason sql.NullString
+// documentJSON serializa o documento com o número criptografado
+func (s *PostgresStore) documentJSON(document *DocumentInfo) (sql.NullString, error) {
+	if document == nil {
+		return sql.NullString{}, nil
+	}
+	data := documentData{Kind: document.Kind}
+	var err error
+	if data.Number, err = s.encryptSensitive(document.Number); err != nil {
+		return sql.NullString{}, err
+	}
+	if document.ExpiresOn != nil {
+		data.ExpiresOn = document.ExpiresOn.Format("2006-01-02")
+	}
+	if legal := document.Legal; legal != nil {
+		data.Legal = &legalData{}
+		fields := []struct {
+			dst *string
+			src string
+		}{
+			{&data.Legal.StoredAt, legal.StoredAt},
+			{&data.Legal.LawyerName, legal.LawyerName},
+			{&data.Legal.LawyerContact, legal.LawyerContact},
+			{&data.Legal.ExecutorName, legal.ExecutorName},
+			{&data.Legal.ExecutorContact, legal.ExecutorContact},
+		}
+		for _, field := range fields {
+			if *field.dst, err = s.encryptSensitive(field.src); err != nil {
+				return sql.NullString{}, err
+			}
+		}
+		if legal.SignedOn != nil {
+			data.Legal.SignedOn = legal.SignedOn.Format("2006-01-02")
+		}
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(encoded), Valid: true}, nil
+}
 
-	err := s.db.QueryRow(`
-		SELECT user_id, is_active, activated_at, activated_by, deactivated_at, reason, notify_guardians
-		FROM emergency_protocols WHERE user_id = $1
-	`, userID).Scan(&protocol.UserID, &protocol.IsActive, &activatedAt, &activatedBy, &deactivatedAt, &reason, &protocol.NotifyGuardians)
+// parseDocument lê o documento e descriptografa o número
+func (s *PostgresStore) parseDocument(data sql.NullString) *DocumentInfo {
+	if !data.Valid || data.String == "" {
+		return nil
+	}
+	var stored documentData
+	if err := json.Unmarshal([]byte(data.String), &stored); err != nil {
+		return nil
+	}
+	document := &DocumentInfo{Kind: stored.Kind, Number: s.decryptSensitive(stored.Number)}
+	if expiresOn, err := time.Parse("2006-01-02", stored.ExpiresOn); err == nil {
+		document.ExpiresOn = &expiresOn
+	}
+	if legal := stored.Legal; legal != nil {
+		document.Legal = &LegalDetails{
+			StoredAt:        s.decryptSensitive(legal.StoredAt),
+			LawyerName:      s.decryptSensitive(legal.LawyerName),
+			LawyerContact:   s.decryptSensitive(legal.LawyerContact),
+			ExecutorName:    s.decryptSensitive(legal.ExecutorName),
+			ExecutorContact: s.decryptSensitive(legal.ExecutorContact),
+		}
+		if signedOn, err := time.Parse("2006-01-02", legal.SignedOn); err == nil {
+			document.Legal.SignedOn = &signedOn
+		}
+	}
+	return document
+}
 
-	if err == sql.ErrNoRows {
-		// Retornar protocolo padrão (não ativado)
-		return &EmergencyProtocol{UserID: userID, IsActive: false, NotifyGuardians: true}, nil
+// petData é o perfil do pet como fica no banco: textos criptografados
+type petData struct {
+	Name         string     `json:"name,omitempty"`
+	Species      PetSpecies `json:"species"`
+	VetName      string     `json:"vet_name,omitempty"`
+	VetPhone     string     `json:"vet_phone,omitempty"`
+	FeedingTimes []string   `json:"feeding_times,omitempty"`
+	Food         string     `json:"food,omitempty"`
+	Medication   string     `json:"medication,omitempty"`
+}
+
+// petJSON serializa o perfil do pet com os textos criptografados
+func (s *PostgresStore) petJSON(pet *PetProfile) (sql.NullString, error) {
+	if pet == nil {
+		return sql.NullString{}, nil
+	}
+	data := petData{Species: pet.Species, FeedingTimes: pet.FeedingTimes}
+	var err error
+	if data.Name, err = s.encryptSensitive(pet.Name); err != nil {
+		return sql.NullString{}, err
+	}
+	if data.VetName, err = s.encryptSensitive(pet.VetName); err != nil {
+		return sql.NullString{}, err
+	}
+	if data.VetPhone, err = s.encryptSensitive(pet.VetPhone); err != nil {
+		return sql.NullString{}, err
 	}
+	if data.Food, err = s.encryptSensitive(pet.Food); err != nil {
+		return sql.NullString{}, err
+	}
+	if data.Medication, err = s.encryptSensitive(pet.Medication); err != nil {
+		return sql.NullString{}, err
+	}
+	encoded, err := json.Marshal(data)
 	if err != nil {
-		return nil, err
+		return sql.NullString{}, err
 	}
+	return sql.NullString{String: string(encoded), Valid: true}, nil
+}
 
-	if activatedAt.Valid {
-		protocol.ActivatedAt = &activatedAt.Time
+// parsePet lê o perfil do pet e descriptografa os textos
+func (s *PostgresStore) parsePet(data sql.NullString) *PetProfile {
+	if !data.Valid || data.String == "" {
+		return nil
 	}
-	if deactivatedAt.Valid {
-		protocol.DeactivatedAt = &deactivatedAt.Time
+	var stored petData
+	if err := json.Unmarshal([]byte(data.String), &stored); err != nil {
+		return nil
 	}
-	protocol.ActivatedBy = activatedBy.String
-	protocol.Reason = reason.String
+	return &PetProfile{
+		Name:         s.decryptSensitive(stored.Name),
+		Species:      stored.Species,
+		VetName:      s.decryptSensitive(stored.VetName),
+		VetPhone:     s.decryptSensitive(stored.VetPhone),
+		FeedingTimes: stored.FeedingTimes,
+		Food:         s.decryptSensitive(stored.Food),
+		Medication:   s.decryptSensitive(stored.Medication),
+	}
+}
 
-	return &protocol, nil
+// financialAccountData é a conta financeira como fica no banco: textos criptografados
+type financialAccountData struct {
+	Institution         string               `json:"institution,omitempty"`
+	AccountType         FinancialAccountType `json:"account_type"`
+	Number              string               `json:"number,omitempty"`
+	StatementsLocation  string               `json:"statements_location,omitempty"`
+	CredentialsLocation string               `json:"credentials_location,omitempty"`
 }
 
-// UpdateEmergencyProtocol atualiza o protocolo de emergência
-func (s *PostgresStore) UpdateEmergencyProtocol(protocol *EmergencyProtocol) error {
-	_, err := s.db.Exec(`
-		INSERT INTO emergency_protocols (user_id, is_active, activated_at, activated_by, deactivated_at, reason, notify_guardians, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		ON CONFLICT (user_id) DO UPDATE SET 
-			is_active = $2, activated_at = $3, activated_by = $4, deactivated_at = $5, reason = $6, notify_guardians = $7, updated_at = $8
-	`, protocol.UserID, protocol.IsActive, protocol.ActivatedAt, nullString(protocol.ActivatedBy),
-		protocol.DeactivatedAt, nullString(protocol.Reason), protocol.NotifyGuardians, time.Now())
-	return err
+// financialAccountJSON serializa a conta financeira com os textos criptografados
+func (s *PostgresStore) financialAccountJSON(account *FinancialAccount) (sql.NullString, error) {
+	if account == nil {
+		return sql.NullString{}, nil
+	}
+	data := financialAccountData{AccountType: account.AccountType}
+	var err error
+	if data.Institution, err = s.encryptSensitive(account.Institution); err != nil {
+		return sql.NullString{}, err
+	}
+	if data.Number, err = s.encryptSensitive(account.Number); err != nil {
+		return sql.NullString{}, err
+	}
+	if data.StatementsLocation, err = s.encryptSensitive(account.StatementsLocation); err != nil {
+		return sql.NullString{}, err
+	}
+	if data.CredentialsLocation, err = s.encryptSensitive(account.CredentialsLocation); err != nil {
+		return sql.NullString{}, err
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(encoded), Valid: true}, nil
+}
+
+// parseFinancialAccount lê a conta financeira e descriptografa os textos
+func (s *PostgresStore) parseFinancialAccount(data sql.NullString) *FinancialAccount {
+	if !data.Valid || data.String == "" {
+		return nil
+	}
+	var stored financialAccountData
+	if err := json.Unmarshal([]byte(data.String), &stored); err != nil {
+		return nil
+	}
+	return &FinancialAccount{
+		Institution:         s.decryptSensitive(stored.Institution),
+		AccountType:         stored.AccountType,
+		Number:              s.decryptSensitive(stored.Number),
+		StatementsLocation:  s.decryptSensitive(stored.StatementsLocation),
+		CredentialsLocation: s.decryptSensitive(stored.CredentialsLocation),
+	}
+}
+
+// onlineAccountData é a conta online como fica no banco: textos criptografados
+type onlineAccountData struct {
+	Service           string              `json:"service,omitempty"`
+	UsernameHint      string              `json:"username_hint,omitempty"`
+	RecoveryEmailHint string              `json:"recovery_email_hint,omitempty"`
+	Action            OnlineAccountAction `json:"action"`
+}
+
+// onlineAccountJSON serializa a conta online com os textos criptografados
+func (s *PostgresStore) onlineAccountJSON(account *OnlineAccount) (sql.NullString, error) {
+	if account == nil {
+		return sql.NullString{}, nil
+	}
+	data := onlineAccountData{Action: account.Action}
+	var err error
+	if data.Service, err = s.encryptSensitive(account.Service); err != nil {
+		return sql.NullString{}, err
+	}
+	if data.UsernameHint, err = s.encryptSensitive(account.UsernameHint); err != nil {
+		return sql.NullString{}, err
+	}
+	if data.RecoveryEmailHint, err = s.encryptSensitive(account.RecoveryEmailHint); err != nil {
+		return sql.NullString{}, err
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(encoded), Valid: true}, nil
+}
+
+// parseOnlineAccount lê a conta online e descriptografa os textos
+func (s *PostgresStore) parseOnlineAccount(data sql.NullString) *OnlineAccount {
+	if !data.Valid || data.String == "" {
+		return nil
+	}
+	var stored onlineAccountData
+	if err := json.Unmarshal([]byte(data.String), &stored); err != nil {
+		return nil
+	}
+	return &OnlineAccount{
+		Service:           s.decryptSensitive(stored.Service),
+		UsernameHint:      s.decryptSensitive(stored.UsernameHint),
+		RecoveryEmailHint: s.decryptSensitive(stored.RecoveryEmailHint),
+		Action:            stored.Action,
+	}
+}
+
+// parseSealedParams lê os parâmetros de um item selado do banco
+func parseSealedParams(data sql.NullString) *SealedParams {
+	if !data.Valid || data.String == "" {
+		return nil
+	}
+	var params SealedParams
+	if err := json.Unmarshal([]byte(data.String), &params); err != nil {
+		return nil
+	}
+	return &params
 }
 
 // nullString retorna sql.NullString para strings vazias
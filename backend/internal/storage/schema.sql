@@ -5,6 +5,10 @@
 -- Execute este script para criar todas as tabelas necessárias.
 --
 -- Uso: psql -d famli -f schema.sql
+--
+-- Nota: a fonte de verdade do schema são as migrações versionadas em
+-- internal/storage/migrations/ (aplicadas automaticamente ao iniciar o
+-- servidor ou via `go run main.go -migrate up`).
 -- =============================================================================
 
 -- Extensão para UUID
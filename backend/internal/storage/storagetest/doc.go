@@ -0,0 +1,21 @@
+// =============================================================================
+// FAMLI - Mocks de Storage para Testes
+// =============================================================================
+// Mocks gerados a partir das interfaces de storage/store.go, para testar
+// handlers sem montar um MemoryStore inteiro. Cada método chama o campo
+// <Método>Func correspondente; métodos não configurados entram em pânico,
+// deixando claro o que o teste precisa fornecer.
+//
+//	store := &storagetest.Store{}
+//	store.GetUserByIDFunc = func(id string) (*storage.User, bool) {
+//		return &storage.User{ID: id, Name: "Maria"}, true
+//	}
+//	handler := settings.NewHandler(store)
+//
+// Para um único domínio, use o mock da interface (ex: storagetest.ShareStore).
+// Depois de mudar as interfaces, regenere com `go generate`.
+// =============================================================================
+
+package storagetest
+
+//go:generate go run ./gen
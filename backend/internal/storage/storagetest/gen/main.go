@@ -0,0 +1,271 @@
+// =============================================================================
+// FAMLI - Gerador dos Mocks de Storage
+// =============================================================================
+// Lê as interfaces de storage/store.go e gera, para cada uma, um mock com um
+// campo <Método>Func por método. A composição Store vira uma struct que
+// embute todos os mocks e satisfaz storage.Store.
+//
+// Uso (a partir de internal/storage/storagetest):
+//
+//	go generate
+// =============================================================================
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// storagePkg é o pacote das interfaces (tipos exportados são qualificados com ele)
+const storagePkg = "storage"
+
+// compositeName é a interface composta pelas demais
+const compositeName = "Store"
+
+func main() {
+	input := flag.String("in", "../store.go", "arquivo com as interfaces")
+	output := flag.String("out", "mocks_gen.go", "arquivo gerado")
+	flag.Parse()
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, *input, nil, parser.ParseComments)
+	if err != nil {
+		log.Fatalf("erro ao ler %s: %v", *input, err)
+	}
+
+	g := &generator{fset: fset}
+	var composite *ast.InterfaceType
+	var interfaces []*ast.TypeSpec
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			iface, ok := ts.Type.(*ast.InterfaceType)
+			if !ok {
+				continue
+			}
+			if ts.Name.Name == compositeName {
+				composite = iface
+				continue
+			}
+			interfaces = append(interfaces, ts)
+		}
+	}
+	if composite == nil {
+		log.Fatalf("interface %s não encontrada em %s", compositeName, *input)
+	}
+
+	for _, ts := range interfaces {
+		g.mock(ts.Name.Name, ts.Type.(*ast.InterfaceType))
+	}
+	g.composite(composite)
+
+	var out bytes.Buffer
+	out.WriteString("// Code generated by storagetest/gen; DO NOT EDIT.\n\n")
+	out.WriteString("package storagetest\n\nimport (\n")
+	if len(g.stdImports) > 0 {
+		for _, pkg := range sortedKeys(g.stdImports) {
+			fmt.Fprintf(&out, "\t%q\n", pkg)
+		}
+		out.WriteString("\n")
+	}
+	out.WriteString("\t\"famli/internal/storage\"\n)\n")
+	out.Write(g.buf.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		log.Fatalf("código gerado inválido: %v", err)
+	}
+	if err := os.WriteFile(*output, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// generator acumula o código gerado
+type generator struct {
+	fset *token.FileSet
+	buf  bytes.Buffer
+	// stdImports são os pacotes da biblioteca padrão usados nas assinaturas
+	// (ex: time, context)
+	stdImports map[string]bool
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// mock gera a struct e os métodos de uma interface
+func (g *generator) mock(name string, iface *ast.InterfaceType) {
+	type method struct {
+		name   string
+		params []string // "nome tipo"
+		args   []string // nomes, para repassar
+		res    string   // retornos, já formatados
+		hasRes bool
+	}
+
+	var methods []method
+	for _, field := range iface.Methods.List {
+		fn, ok := field.Type.(*ast.FuncType)
+		if !ok {
+			log.Fatalf("%s: interfaces embutidas só são aceitas em %s", name, compositeName)
+		}
+		m := method{name: field.Names[0].Name, hasRes: fn.Results != nil && len(fn.Results.List) > 0}
+		for i, p := range fn.Params.List {
+			typ := g.expr(p.Type)
+			names := p.Names
+			if len(names) == 0 {
+				names = []*ast.Ident{ast.NewIdent(fmt.Sprintf("p%d", i))}
+			}
+			for _, n := range names {
+				m.params = append(m.params, n.Name+" "+typ)
+				arg := n.Name
+				if _, variadic := p.Type.(*ast.Ellipsis); variadic {
+					arg += "..."
+				}
+				m.args = append(m.args, arg)
+			}
+		}
+		m.res = g.results(fn.Results)
+		methods = append(methods, m)
+	}
+
+	g.printf("\n// %s é o mock de storage.%s\n", name, name)
+	g.printf("// Métodos sem a função correspondente entram em pânico.\n")
+	g.printf("type %s struct {\n", name)
+	for _, m := range methods {
+		g.printf("\t%sFunc func(%s)%s\n", m.name, strings.Join(m.params, ", "), m.res)
+	}
+	g.printf("}\n\nvar _ storage.%s = (*%s)(nil)\n", name, name)
+
+	for _, m := range methods {
+		g.printf("\nfunc (m *%s) %s(%s)%s {\n", name, m.name, strings.Join(m.params, ", "), m.res)
+		g.printf("\tif m.%sFunc == nil {\n\t\tpanic(%q)\n\t}\n", m.name, "storagetest: "+name+"."+m.name+" não configurado")
+		call := fmt.Sprintf("m.%sFunc(%s)", m.name, strings.Join(m.args, ", "))
+		if m.hasRes {
+			g.printf("\treturn %s\n}\n", call)
+		} else {
+			g.printf("\t%s\n}\n", call)
+		}
+	}
+}
+
+// composite gera a struct que embute todos os mocks
+func (g *generator) composite(iface *ast.InterfaceType) {
+	g.printf("\n// %s embute os mocks de todos os domínios e satisfaz storage.%s\n", compositeName, compositeName)
+	g.printf("type %s struct {\n", compositeName)
+	for _, field := range iface.Methods.List {
+		ident, ok := field.Type.(*ast.Ident)
+		if !ok || len(field.Names) > 0 {
+			log.Fatalf("%s deve apenas embutir as outras interfaces", compositeName)
+		}
+		g.printf("\t%s\n", ident.Name)
+	}
+	g.printf("}\n\nvar _ storage.%s = (*%s)(nil)\n", compositeName, compositeName)
+}
+
+// results formata a lista de retornos (com nomes, se houver)
+func (g *generator) results(list *ast.FieldList) string {
+	if list == nil || len(list.List) == 0 {
+		return ""
+	}
+	var parts []string
+	named := false
+	for _, r := range list.List {
+		typ := g.expr(r.Type)
+		if len(r.Names) == 0 {
+			parts = append(parts, typ)
+			continue
+		}
+		named = true
+		for _, n := range r.Names {
+			parts = append(parts, n.Name+" "+typ)
+		}
+	}
+	if len(parts) == 1 && !named {
+		return " " + parts[0]
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// expr imprime um tipo qualificando os identificadores do pacote storage
+func (g *generator) expr(e ast.Expr) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, g.fset, g.qualify(e)); err != nil {
+		log.Fatal(err)
+	}
+	return buf.String()
+}
+
+func (g *generator) qualify(e ast.Expr) ast.Expr {
+	switch t := e.(type) {
+	case *ast.Ident:
+		if ast.IsExported(t.Name) {
+			return &ast.SelectorExpr{X: ast.NewIdent(storagePkg), Sel: ast.NewIdent(t.Name)}
+		}
+		return t
+	case *ast.SelectorExpr:
+		if pkg, ok := t.X.(*ast.Ident); ok {
+			if g.stdImports == nil {
+				g.stdImports = make(map[string]bool)
+			}
+			g.stdImports[pkg.Name] = true
+		}
+		return t
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: g.qualify(t.X)}
+	case *ast.ArrayType:
+		return &ast.ArrayType{Len: t.Len, Elt: g.qualify(t.Elt)}
+	case *ast.MapType:
+		return &ast.MapType{Key: g.qualify(t.Key), Value: g.qualify(t.Value)}
+	case *ast.Ellipsis:
+		return &ast.Ellipsis{Elt: g.qualify(t.Elt)}
+	case *ast.IndexExpr:
+		return &ast.IndexExpr{X: g.qualify(t.X), Index: g.qualify(t.Index)}
+	case *ast.IndexListExpr:
+		indices := make([]ast.Expr, len(t.Indices))
+		for i, idx := range t.Indices {
+			indices[i] = g.qualify(idx)
+		}
+		return &ast.IndexListExpr{X: g.qualify(t.X), Indices: indices}
+	case *ast.FuncType:
+		return &ast.FuncType{Params: g.qualifyFields(t.Params), Results: g.qualifyFields(t.Results)}
+	}
+	return e
+}
+
+// qualifyFields qualifica os tipos dos parâmetros ou resultados de uma função
+func (g *generator) qualifyFields(fields *ast.FieldList) *ast.FieldList {
+	if fields == nil {
+		return nil
+	}
+	list := make([]*ast.Field, len(fields.List))
+	for i, field := range fields.List {
+		list[i] = &ast.Field{Names: field.Names, Type: g.qualify(field.Type)}
+	}
+	return &ast.FieldList{List: list}
+}
+
+// sortedKeys retorna as chaves em ordem alfabética
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,2144 @@
+// Code generated by storagetest/gen; DO NOT EDIT.
+
+package storagetest
+
+import (
+	"context"
+	"time"
+
+	"famli/internal/storage"
+)
+
+// UserStore é o mock de storage.UserStore
+// Métodos sem a função correspondente entram em pânico.
+type UserStore struct {
+	CreateUserFunc               func(email string, hashedPassword string, name string) (*storage.User, error)
+	GetUserByEmailFunc           func(email string) (*storage.User, bool)
+	GetUserByIDFunc              func(id string) (*storage.User, bool)
+	UpdateUserPasswordFunc       func(userID string, hashedPassword string) error
+	UpdateUserLocaleFunc         func(userID string, locale string) error
+	UpdateUserProfileFunc        func(userID string, name string, email string) error
+	DeleteUserFunc               func(userID string) error
+	AcceptLegalTermsFunc         func(userID string, termsVersion string, privacyVersion string, at time.Time) error
+	CreateOrUpdateSocialUserFunc func(provider storage.AuthProvider, providerID string, email string, name string, avatarURL string) (*storage.User, error)
+	GetUserByProviderFunc        func(provider storage.AuthProvider, providerID string) (*storage.User, bool)
+	LinkSocialProviderFunc       func(userID string, provider storage.AuthProvider, providerID string) error
+	RecordFailedLoginFunc        func(userID string, maxAttempts int, lockDuration time.Duration) (*storage.User, error)
+	ResetFailedLoginsFunc        func(userID string) error
+	RecordLoginFunc              func(record *storage.LoginRecord) (*storage.LoginCheck, error)
+	CreateDeviceSessionFunc      func(session *storage.DeviceSession) error
+	GetDeviceSessionFunc         func(sessionID string) (*storage.DeviceSession, error)
+	ListDeviceSessionsFunc       func(userID string) ([]*storage.DeviceSession, error)
+	TouchDeviceSessionFunc       func(sessionID string, ipAddress string) error
+	RenameDeviceSessionFunc      func(userID string, sessionID string, name string) error
+	DeleteDeviceSessionFunc      func(userID string, sessionID string) error
+	SaveSupportAccessFunc        func(access *storage.SupportAccess) error
+	GetSupportAccessFunc         func(userID string) (*storage.SupportAccess, error)
+	DeleteSupportAccessFunc      func(userID string) error
+	CreateAPIKeyFunc             func(key *storage.APIKey) error
+	ListAPIKeysFunc              func(userID string) ([]*storage.APIKey, error)
+	GetAPIKeyByHashFunc          func(keyHash string) (*storage.APIKey, error)
+	TouchAPIKeyFunc              func(keyID string, at time.Time) error
+	DeleteAPIKeyFunc             func(userID string, keyID string) error
+	CreatePhoneLinkFunc          func(link *storage.PhoneLink) error
+	ListPhoneLinksFunc           func(userID string) ([]*storage.PhoneLink, error)
+	GetPhoneLinkByPhoneFunc      func(phone string) (*storage.PhoneLink, error)
+	SetPrimaryPhoneLinkFunc      func(userID string, linkID string) error
+	DeletePhoneLinkFunc          func(userID string, linkID string) error
+	CreateInboundSenderFunc      func(sender *storage.InboundSender) error
+	ListInboundSendersFunc       func(userID string) ([]*storage.InboundSender, error)
+	GetInboundSenderByEmailFunc  func(email string) (*storage.InboundSender, error)
+	DeleteInboundSenderFunc      func(userID string, senderID string) error
+}
+
+var _ storage.UserStore = (*UserStore)(nil)
+
+func (m *UserStore) CreateUser(email string, hashedPassword string, name string) (*storage.User, error) {
+	if m.CreateUserFunc == nil {
+		panic("storagetest: UserStore.CreateUser não configurado")
+	}
+	return m.CreateUserFunc(email, hashedPassword, name)
+}
+
+func (m *UserStore) GetUserByEmail(email string) (*storage.User, bool) {
+	if m.GetUserByEmailFunc == nil {
+		panic("storagetest: UserStore.GetUserByEmail não configurado")
+	}
+	return m.GetUserByEmailFunc(email)
+}
+
+func (m *UserStore) GetUserByID(id string) (*storage.User, bool) {
+	if m.GetUserByIDFunc == nil {
+		panic("storagetest: UserStore.GetUserByID não configurado")
+	}
+	return m.GetUserByIDFunc(id)
+}
+
+func (m *UserStore) UpdateUserPassword(userID string, hashedPassword string) error {
+	if m.UpdateUserPasswordFunc == nil {
+		panic("storagetest: UserStore.UpdateUserPassword não configurado")
+	}
+	return m.UpdateUserPasswordFunc(userID, hashedPassword)
+}
+
+func (m *UserStore) UpdateUserLocale(userID string, locale string) error {
+	if m.UpdateUserLocaleFunc == nil {
+		panic("storagetest: UserStore.UpdateUserLocale não configurado")
+	}
+	return m.UpdateUserLocaleFunc(userID, locale)
+}
+
+func (m *UserStore) UpdateUserProfile(userID string, name string, email string) error {
+	if m.UpdateUserProfileFunc == nil {
+		panic("storagetest: UserStore.UpdateUserProfile não configurado")
+	}
+	return m.UpdateUserProfileFunc(userID, name, email)
+}
+
+func (m *UserStore) DeleteUser(userID string) error {
+	if m.DeleteUserFunc == nil {
+		panic("storagetest: UserStore.DeleteUser não configurado")
+	}
+	return m.DeleteUserFunc(userID)
+}
+
+func (m *UserStore) AcceptLegalTerms(userID string, termsVersion string, privacyVersion string, at time.Time) error {
+	if m.AcceptLegalTermsFunc == nil {
+		panic("storagetest: UserStore.AcceptLegalTerms não configurado")
+	}
+	return m.AcceptLegalTermsFunc(userID, termsVersion, privacyVersion, at)
+}
+
+func (m *UserStore) CreateOrUpdateSocialUser(provider storage.AuthProvider, providerID string, email string, name string, avatarURL string) (*storage.User, error) {
+	if m.CreateOrUpdateSocialUserFunc == nil {
+		panic("storagetest: UserStore.CreateOrUpdateSocialUser não configurado")
+	}
+	return m.CreateOrUpdateSocialUserFunc(provider, providerID, email, name, avatarURL)
+}
+
+func (m *UserStore) GetUserByProvider(provider storage.AuthProvider, providerID string) (*storage.User, bool) {
+	if m.GetUserByProviderFunc == nil {
+		panic("storagetest: UserStore.GetUserByProvider não configurado")
+	}
+	return m.GetUserByProviderFunc(provider, providerID)
+}
+
+func (m *UserStore) LinkSocialProvider(userID string, provider storage.AuthProvider, providerID string) error {
+	if m.LinkSocialProviderFunc == nil {
+		panic("storagetest: UserStore.LinkSocialProvider não configurado")
+	}
+	return m.LinkSocialProviderFunc(userID, provider, providerID)
+}
+
+func (m *UserStore) RecordFailedLogin(userID string, maxAttempts int, lockDuration time.Duration) (*storage.User, error) {
+	if m.RecordFailedLoginFunc == nil {
+		panic("storagetest: UserStore.RecordFailedLogin não configurado")
+	}
+	return m.RecordFailedLoginFunc(userID, maxAttempts, lockDuration)
+}
+
+func (m *UserStore) ResetFailedLogins(userID string) error {
+	if m.ResetFailedLoginsFunc == nil {
+		panic("storagetest: UserStore.ResetFailedLogins não configurado")
+	}
+	return m.ResetFailedLoginsFunc(userID)
+}
+
+func (m *UserStore) RecordLogin(record *storage.LoginRecord) (*storage.LoginCheck, error) {
+	if m.RecordLoginFunc == nil {
+		panic("storagetest: UserStore.RecordLogin não configurado")
+	}
+	return m.RecordLoginFunc(record)
+}
+
+func (m *UserStore) CreateDeviceSession(session *storage.DeviceSession) error {
+	if m.CreateDeviceSessionFunc == nil {
+		panic("storagetest: UserStore.CreateDeviceSession não configurado")
+	}
+	return m.CreateDeviceSessionFunc(session)
+}
+
+func (m *UserStore) GetDeviceSession(sessionID string) (*storage.DeviceSession, error) {
+	if m.GetDeviceSessionFunc == nil {
+		panic("storagetest: UserStore.GetDeviceSession não configurado")
+	}
+	return m.GetDeviceSessionFunc(sessionID)
+}
+
+func (m *UserStore) ListDeviceSessions(userID string) ([]*storage.DeviceSession, error) {
+	if m.ListDeviceSessionsFunc == nil {
+		panic("storagetest: UserStore.ListDeviceSessions não configurado")
+	}
+	return m.ListDeviceSessionsFunc(userID)
+}
+
+func (m *UserStore) TouchDeviceSession(sessionID string, ipAddress string) error {
+	if m.TouchDeviceSessionFunc == nil {
+		panic("storagetest: UserStore.TouchDeviceSession não configurado")
+	}
+	return m.TouchDeviceSessionFunc(sessionID, ipAddress)
+}
+
+func (m *UserStore) RenameDeviceSession(userID string, sessionID string, name string) error {
+	if m.RenameDeviceSessionFunc == nil {
+		panic("storagetest: UserStore.RenameDeviceSession não configurado")
+	}
+	return m.RenameDeviceSessionFunc(userID, sessionID, name)
+}
+
+func (m *UserStore) DeleteDeviceSession(userID string, sessionID string) error {
+	if m.DeleteDeviceSessionFunc == nil {
+		panic("storagetest: UserStore.DeleteDeviceSession não configurado")
+	}
+	return m.DeleteDeviceSessionFunc(userID, sessionID)
+}
+
+func (m *UserStore) SaveSupportAccess(access *storage.SupportAccess) error {
+	if m.SaveSupportAccessFunc == nil {
+		panic("storagetest: UserStore.SaveSupportAccess não configurado")
+	}
+	return m.SaveSupportAccessFunc(access)
+}
+
+func (m *UserStore) GetSupportAccess(userID string) (*storage.SupportAccess, error) {
+	if m.GetSupportAccessFunc == nil {
+		panic("storagetest: UserStore.GetSupportAccess não configurado")
+	}
+	return m.GetSupportAccessFunc(userID)
+}
+
+func (m *UserStore) DeleteSupportAccess(userID string) error {
+	if m.DeleteSupportAccessFunc == nil {
+		panic("storagetest: UserStore.DeleteSupportAccess não configurado")
+	}
+	return m.DeleteSupportAccessFunc(userID)
+}
+
+func (m *UserStore) CreateAPIKey(key *storage.APIKey) error {
+	if m.CreateAPIKeyFunc == nil {
+		panic("storagetest: UserStore.CreateAPIKey não configurado")
+	}
+	return m.CreateAPIKeyFunc(key)
+}
+
+func (m *UserStore) ListAPIKeys(userID string) ([]*storage.APIKey, error) {
+	if m.ListAPIKeysFunc == nil {
+		panic("storagetest: UserStore.ListAPIKeys não configurado")
+	}
+	return m.ListAPIKeysFunc(userID)
+}
+
+func (m *UserStore) GetAPIKeyByHash(keyHash string) (*storage.APIKey, error) {
+	if m.GetAPIKeyByHashFunc == nil {
+		panic("storagetest: UserStore.GetAPIKeyByHash não configurado")
+	}
+	return m.GetAPIKeyByHashFunc(keyHash)
+}
+
+func (m *UserStore) TouchAPIKey(keyID string, at time.Time) error {
+	if m.TouchAPIKeyFunc == nil {
+		panic("storagetest: UserStore.TouchAPIKey não configurado")
+	}
+	return m.TouchAPIKeyFunc(keyID, at)
+}
+
+func (m *UserStore) DeleteAPIKey(userID string, keyID string) error {
+	if m.DeleteAPIKeyFunc == nil {
+		panic("storagetest: UserStore.DeleteAPIKey não configurado")
+	}
+	return m.DeleteAPIKeyFunc(userID, keyID)
+}
+
+func (m *UserStore) CreatePhoneLink(link *storage.PhoneLink) error {
+	if m.CreatePhoneLinkFunc == nil {
+		panic("storagetest: UserStore.CreatePhoneLink não configurado")
+	}
+	return m.CreatePhoneLinkFunc(link)
+}
+
+func (m *UserStore) ListPhoneLinks(userID string) ([]*storage.PhoneLink, error) {
+	if m.ListPhoneLinksFunc == nil {
+		panic("storagetest: UserStore.ListPhoneLinks não configurado")
+	}
+	return m.ListPhoneLinksFunc(userID)
+}
+
+func (m *UserStore) GetPhoneLinkByPhone(phone string) (*storage.PhoneLink, error) {
+	if m.GetPhoneLinkByPhoneFunc == nil {
+		panic("storagetest: UserStore.GetPhoneLinkByPhone não configurado")
+	}
+	return m.GetPhoneLinkByPhoneFunc(phone)
+}
+
+func (m *UserStore) SetPrimaryPhoneLink(userID string, linkID string) error {
+	if m.SetPrimaryPhoneLinkFunc == nil {
+		panic("storagetest: UserStore.SetPrimaryPhoneLink não configurado")
+	}
+	return m.SetPrimaryPhoneLinkFunc(userID, linkID)
+}
+
+func (m *UserStore) DeletePhoneLink(userID string, linkID string) error {
+	if m.DeletePhoneLinkFunc == nil {
+		panic("storagetest: UserStore.DeletePhoneLink não configurado")
+	}
+	return m.DeletePhoneLinkFunc(userID, linkID)
+}
+
+func (m *UserStore) CreateInboundSender(sender *storage.InboundSender) error {
+	if m.CreateInboundSenderFunc == nil {
+		panic("storagetest: UserStore.CreateInboundSender não configurado")
+	}
+	return m.CreateInboundSenderFunc(sender)
+}
+
+func (m *UserStore) ListInboundSenders(userID string) ([]*storage.InboundSender, error) {
+	if m.ListInboundSendersFunc == nil {
+		panic("storagetest: UserStore.ListInboundSenders não configurado")
+	}
+	return m.ListInboundSendersFunc(userID)
+}
+
+func (m *UserStore) GetInboundSenderByEmail(email string) (*storage.InboundSender, error) {
+	if m.GetInboundSenderByEmailFunc == nil {
+		panic("storagetest: UserStore.GetInboundSenderByEmail não configurado")
+	}
+	return m.GetInboundSenderByEmailFunc(email)
+}
+
+func (m *UserStore) DeleteInboundSender(userID string, senderID string) error {
+	if m.DeleteInboundSenderFunc == nil {
+		panic("storagetest: UserStore.DeleteInboundSender não configurado")
+	}
+	return m.DeleteInboundSenderFunc(userID, senderID)
+}
+
+// SubscriptionStore é o mock de storage.SubscriptionStore
+// Métodos sem a função correspondente entram em pânico.
+type SubscriptionStore struct {
+	GetSubscriptionFunc           func(userID string) (*storage.Subscription, error)
+	GetSubscriptionByCustomerFunc func(customerID string) (*storage.Subscription, error)
+	SaveSubscriptionFunc          func(sub *storage.Subscription) error
+}
+
+var _ storage.SubscriptionStore = (*SubscriptionStore)(nil)
+
+func (m *SubscriptionStore) GetSubscription(userID string) (*storage.Subscription, error) {
+	if m.GetSubscriptionFunc == nil {
+		panic("storagetest: SubscriptionStore.GetSubscription não configurado")
+	}
+	return m.GetSubscriptionFunc(userID)
+}
+
+func (m *SubscriptionStore) GetSubscriptionByCustomer(customerID string) (*storage.Subscription, error) {
+	if m.GetSubscriptionByCustomerFunc == nil {
+		panic("storagetest: SubscriptionStore.GetSubscriptionByCustomer não configurado")
+	}
+	return m.GetSubscriptionByCustomerFunc(customerID)
+}
+
+func (m *SubscriptionStore) SaveSubscription(sub *storage.Subscription) error {
+	if m.SaveSubscriptionFunc == nil {
+		panic("storagetest: SubscriptionStore.SaveSubscription não configurado")
+	}
+	return m.SaveSubscriptionFunc(sub)
+}
+
+// BoxStore é o mock de storage.BoxStore
+// Métodos sem a função correspondente entram em pânico.
+type BoxStore struct {
+	GetBoxItemsFunc                     func(userID string) ([]*storage.BoxItem, error)
+	ListBoxItemsFunc                    func(userID string) []*storage.BoxItem
+	GetBoxItemFunc                      func(userID string, itemID string) (*storage.BoxItem, error)
+	CreateBoxItemFunc                   func(userID string, item *storage.BoxItem) (*storage.BoxItem, error)
+	CreateBoxItemWithIDFunc             func(userID string, item *storage.BoxItem, itemID string) (*storage.BoxItem, error)
+	UpdateBoxItemFunc                   func(userID string, itemID string, updates *storage.BoxItem) (*storage.BoxItem, error)
+	DeleteBoxItemFunc                   func(userID string, itemID string) error
+	SetChecklistEntryDoneFunc           func(userID string, itemID string, entryID string, done bool) (*storage.BoxItem, error)
+	ListBoxItemsPaginatedFunc           func(userID string, params *storage.PaginationParams) (*storage.PaginatedResult[*storage.BoxItemSummary], error)
+	CountBoxItemsFunc                   func(userID string) (int, error)
+	GetBoxItemByIDFunc                  func(itemID string) (*storage.BoxItem, error)
+	ListAccessibleBoxItemsPaginatedFunc func(filter *storage.BoxItemFilter, params *storage.PaginationParams) (*storage.PaginatedResult[*storage.BoxItemSummary], error)
+	CountAccessibleBoxItemsFunc         func(filter *storage.BoxItemFilter) (int, error)
+	SetBoxItemPinnedFunc                func(userID string, itemID string, pinned bool) error
+	ReorderBoxItemsFunc                 func(userID string, itemIDs []string) error
+	CreateItemRelationFunc              func(rel *storage.ItemRelation) error
+	ListItemRelationsFunc               func(itemID string) ([]*storage.ItemRelation, error)
+	DeleteItemRelationFunc              func(itemID string, relationID string) error
+	RecordItemViewsFunc                 func(source storage.ItemViewSource, sourceID string, itemIDs []string, at time.Time) (map[string]bool, error)
+	ListItemViewsFunc                   func(itemID string) ([]*storage.ItemView, error)
+	ListScheduledRoutinesFunc           func(filter *storage.BoxItemFilter) ([]*storage.BoxItem, error)
+	ListExpiringDocumentsFunc           func(filter *storage.BoxItemFilter, until time.Time) ([]*storage.BoxItem, error)
+	ListDocumentExpiriesFunc            func(until time.Time) ([]*storage.DocumentExpiry, error)
+	ClaimExpiryReminderFunc             func(itemID string, expiresOn time.Time, daysBefore int, at time.Time) (bool, error)
+	ListDatedBoxItemsFunc               func(userID string) ([]*storage.BoxItem, error)
+	SaveCalendarFeedFunc                func(feed *storage.CalendarFeed) error
+	GetCalendarFeedFunc                 func(userID string) (*storage.CalendarFeed, error)
+	GetCalendarFeedByTokenFunc          func(tokenHash string) (*storage.CalendarFeed, error)
+	DeleteCalendarFeedFunc              func(userID string) error
+	TouchCalendarFeedFunc               func(userID string, accessedAt time.Time) error
+	SaveCaptureTokenFunc                func(token *storage.CaptureToken) error
+	GetCaptureTokenFunc                 func(userID string) (*storage.CaptureToken, error)
+	GetCaptureTokenByHashFunc           func(tokenHash string) (*storage.CaptureToken, error)
+	DeleteCaptureTokenFunc              func(userID string) error
+	TouchCaptureTokenFunc               func(userID string, usedAt time.Time) error
+	SaveItemSourceFunc                  func(source *storage.ItemSource) error
+	GetItemSourceFunc                   func(itemID string) (*storage.ItemSource, error)
+}
+
+var _ storage.BoxStore = (*BoxStore)(nil)
+
+func (m *BoxStore) GetBoxItems(userID string) ([]*storage.BoxItem, error) {
+	if m.GetBoxItemsFunc == nil {
+		panic("storagetest: BoxStore.GetBoxItems não configurado")
+	}
+	return m.GetBoxItemsFunc(userID)
+}
+
+func (m *BoxStore) ListBoxItems(userID string) []*storage.BoxItem {
+	if m.ListBoxItemsFunc == nil {
+		panic("storagetest: BoxStore.ListBoxItems não configurado")
+	}
+	return m.ListBoxItemsFunc(userID)
+}
+
+func (m *BoxStore) GetBoxItem(userID string, itemID string) (*storage.BoxItem, error) {
+	if m.GetBoxItemFunc == nil {
+		panic("storagetest: BoxStore.GetBoxItem não configurado")
+	}
+	return m.GetBoxItemFunc(userID, itemID)
+}
+
+func (m *BoxStore) CreateBoxItem(userID string, item *storage.BoxItem) (*storage.BoxItem, error) {
+	if m.CreateBoxItemFunc == nil {
+		panic("storagetest: BoxStore.CreateBoxItem não configurado")
+	}
+	return m.CreateBoxItemFunc(userID, item)
+}
+
+func (m *BoxStore) CreateBoxItemWithID(userID string, item *storage.BoxItem, itemID string) (*storage.BoxItem, error) {
+	if m.CreateBoxItemWithIDFunc == nil {
+		panic("storagetest: BoxStore.CreateBoxItemWithID não configurado")
+	}
+	return m.CreateBoxItemWithIDFunc(userID, item, itemID)
+}
+
+func (m *BoxStore) UpdateBoxItem(userID string, itemID string, updates *storage.BoxItem) (*storage.BoxItem, error) {
+	if m.UpdateBoxItemFunc == nil {
+		panic("storagetest: BoxStore.UpdateBoxItem não configurado")
+	}
+	return m.UpdateBoxItemFunc(userID, itemID, updates)
+}
+
+func (m *BoxStore) DeleteBoxItem(userID string, itemID string) error {
+	if m.DeleteBoxItemFunc == nil {
+		panic("storagetest: BoxStore.DeleteBoxItem não configurado")
+	}
+	return m.DeleteBoxItemFunc(userID, itemID)
+}
+
+func (m *BoxStore) SetChecklistEntryDone(userID string, itemID string, entryID string, done bool) (*storage.BoxItem, error) {
+	if m.SetChecklistEntryDoneFunc == nil {
+		panic("storagetest: BoxStore.SetChecklistEntryDone não configurado")
+	}
+	return m.SetChecklistEntryDoneFunc(userID, itemID, entryID, done)
+}
+
+func (m *BoxStore) ListBoxItemsPaginated(userID string, params *storage.PaginationParams) (*storage.PaginatedResult[*storage.BoxItemSummary], error) {
+	if m.ListBoxItemsPaginatedFunc == nil {
+		panic("storagetest: BoxStore.ListBoxItemsPaginated não configurado")
+	}
+	return m.ListBoxItemsPaginatedFunc(userID, params)
+}
+
+func (m *BoxStore) CountBoxItems(userID string) (int, error) {
+	if m.CountBoxItemsFunc == nil {
+		panic("storagetest: BoxStore.CountBoxItems não configurado")
+	}
+	return m.CountBoxItemsFunc(userID)
+}
+
+func (m *BoxStore) GetBoxItemByID(itemID string) (*storage.BoxItem, error) {
+	if m.GetBoxItemByIDFunc == nil {
+		panic("storagetest: BoxStore.GetBoxItemByID não configurado")
+	}
+	return m.GetBoxItemByIDFunc(itemID)
+}
+
+func (m *BoxStore) ListAccessibleBoxItemsPaginated(filter *storage.BoxItemFilter, params *storage.PaginationParams) (*storage.PaginatedResult[*storage.BoxItemSummary], error) {
+	if m.ListAccessibleBoxItemsPaginatedFunc == nil {
+		panic("storagetest: BoxStore.ListAccessibleBoxItemsPaginated não configurado")
+	}
+	return m.ListAccessibleBoxItemsPaginatedFunc(filter, params)
+}
+
+func (m *BoxStore) CountAccessibleBoxItems(filter *storage.BoxItemFilter) (int, error) {
+	if m.CountAccessibleBoxItemsFunc == nil {
+		panic("storagetest: BoxStore.CountAccessibleBoxItems não configurado")
+	}
+	return m.CountAccessibleBoxItemsFunc(filter)
+}
+
+func (m *BoxStore) SetBoxItemPinned(userID string, itemID string, pinned bool) error {
+	if m.SetBoxItemPinnedFunc == nil {
+		panic("storagetest: BoxStore.SetBoxItemPinned não configurado")
+	}
+	return m.SetBoxItemPinnedFunc(userID, itemID, pinned)
+}
+
+func (m *BoxStore) ReorderBoxItems(userID string, itemIDs []string) error {
+	if m.ReorderBoxItemsFunc == nil {
+		panic("storagetest: BoxStore.ReorderBoxItems não configurado")
+	}
+	return m.ReorderBoxItemsFunc(userID, itemIDs)
+}
+
+func (m *BoxStore) CreateItemRelation(rel *storage.ItemRelation) error {
+	if m.CreateItemRelationFunc == nil {
+		panic("storagetest: BoxStore.CreateItemRelation não configurado")
+	}
+	return m.CreateItemRelationFunc(rel)
+}
+
+func (m *BoxStore) ListItemRelations(itemID string) ([]*storage.ItemRelation, error) {
+	if m.ListItemRelationsFunc == nil {
+		panic("storagetest: BoxStore.ListItemRelations não configurado")
+	}
+	return m.ListItemRelationsFunc(itemID)
+}
+
+func (m *BoxStore) DeleteItemRelation(itemID string, relationID string) error {
+	if m.DeleteItemRelationFunc == nil {
+		panic("storagetest: BoxStore.DeleteItemRelation não configurado")
+	}
+	return m.DeleteItemRelationFunc(itemID, relationID)
+}
+
+func (m *BoxStore) RecordItemViews(source storage.ItemViewSource, sourceID string, itemIDs []string, at time.Time) (map[string]bool, error) {
+	if m.RecordItemViewsFunc == nil {
+		panic("storagetest: BoxStore.RecordItemViews não configurado")
+	}
+	return m.RecordItemViewsFunc(source, sourceID, itemIDs, at)
+}
+
+func (m *BoxStore) ListItemViews(itemID string) ([]*storage.ItemView, error) {
+	if m.ListItemViewsFunc == nil {
+		panic("storagetest: BoxStore.ListItemViews não configurado")
+	}
+	return m.ListItemViewsFunc(itemID)
+}
+
+func (m *BoxStore) ListScheduledRoutines(filter *storage.BoxItemFilter) ([]*storage.BoxItem, error) {
+	if m.ListScheduledRoutinesFunc == nil {
+		panic("storagetest: BoxStore.ListScheduledRoutines não configurado")
+	}
+	return m.ListScheduledRoutinesFunc(filter)
+}
+
+func (m *BoxStore) ListExpiringDocuments(filter *storage.BoxItemFilter, until time.Time) ([]*storage.BoxItem, error) {
+	if m.ListExpiringDocumentsFunc == nil {
+		panic("storagetest: BoxStore.ListExpiringDocuments não configurado")
+	}
+	return m.ListExpiringDocumentsFunc(filter, until)
+}
+
+func (m *BoxStore) ListDocumentExpiries(until time.Time) ([]*storage.DocumentExpiry, error) {
+	if m.ListDocumentExpiriesFunc == nil {
+		panic("storagetest: BoxStore.ListDocumentExpiries não configurado")
+	}
+	return m.ListDocumentExpiriesFunc(until)
+}
+
+func (m *BoxStore) ClaimExpiryReminder(itemID string, expiresOn time.Time, daysBefore int, at time.Time) (bool, error) {
+	if m.ClaimExpiryReminderFunc == nil {
+		panic("storagetest: BoxStore.ClaimExpiryReminder não configurado")
+	}
+	return m.ClaimExpiryReminderFunc(itemID, expiresOn, daysBefore, at)
+}
+
+func (m *BoxStore) ListDatedBoxItems(userID string) ([]*storage.BoxItem, error) {
+	if m.ListDatedBoxItemsFunc == nil {
+		panic("storagetest: BoxStore.ListDatedBoxItems não configurado")
+	}
+	return m.ListDatedBoxItemsFunc(userID)
+}
+
+func (m *BoxStore) SaveCalendarFeed(feed *storage.CalendarFeed) error {
+	if m.SaveCalendarFeedFunc == nil {
+		panic("storagetest: BoxStore.SaveCalendarFeed não configurado")
+	}
+	return m.SaveCalendarFeedFunc(feed)
+}
+
+func (m *BoxStore) GetCalendarFeed(userID string) (*storage.CalendarFeed, error) {
+	if m.GetCalendarFeedFunc == nil {
+		panic("storagetest: BoxStore.GetCalendarFeed não configurado")
+	}
+	return m.GetCalendarFeedFunc(userID)
+}
+
+func (m *BoxStore) GetCalendarFeedByToken(tokenHash string) (*storage.CalendarFeed, error) {
+	if m.GetCalendarFeedByTokenFunc == nil {
+		panic("storagetest: BoxStore.GetCalendarFeedByToken não configurado")
+	}
+	return m.GetCalendarFeedByTokenFunc(tokenHash)
+}
+
+func (m *BoxStore) DeleteCalendarFeed(userID string) error {
+	if m.DeleteCalendarFeedFunc == nil {
+		panic("storagetest: BoxStore.DeleteCalendarFeed não configurado")
+	}
+	return m.DeleteCalendarFeedFunc(userID)
+}
+
+func (m *BoxStore) TouchCalendarFeed(userID string, accessedAt time.Time) error {
+	if m.TouchCalendarFeedFunc == nil {
+		panic("storagetest: BoxStore.TouchCalendarFeed não configurado")
+	}
+	return m.TouchCalendarFeedFunc(userID, accessedAt)
+}
+
+func (m *BoxStore) SaveCaptureToken(token *storage.CaptureToken) error {
+	if m.SaveCaptureTokenFunc == nil {
+		panic("storagetest: BoxStore.SaveCaptureToken não configurado")
+	}
+	return m.SaveCaptureTokenFunc(token)
+}
+
+func (m *BoxStore) GetCaptureToken(userID string) (*storage.CaptureToken, error) {
+	if m.GetCaptureTokenFunc == nil {
+		panic("storagetest: BoxStore.GetCaptureToken não configurado")
+	}
+	return m.GetCaptureTokenFunc(userID)
+}
+
+func (m *BoxStore) GetCaptureTokenByHash(tokenHash string) (*storage.CaptureToken, error) {
+	if m.GetCaptureTokenByHashFunc == nil {
+		panic("storagetest: BoxStore.GetCaptureTokenByHash não configurado")
+	}
+	return m.GetCaptureTokenByHashFunc(tokenHash)
+}
+
+func (m *BoxStore) DeleteCaptureToken(userID string) error {
+	if m.DeleteCaptureTokenFunc == nil {
+		panic("storagetest: BoxStore.DeleteCaptureToken não configurado")
+	}
+	return m.DeleteCaptureTokenFunc(userID)
+}
+
+func (m *BoxStore) TouchCaptureToken(userID string, usedAt time.Time) error {
+	if m.TouchCaptureTokenFunc == nil {
+		panic("storagetest: BoxStore.TouchCaptureToken não configurado")
+	}
+	return m.TouchCaptureTokenFunc(userID, usedAt)
+}
+
+func (m *BoxStore) SaveItemSource(source *storage.ItemSource) error {
+	if m.SaveItemSourceFunc == nil {
+		panic("storagetest: BoxStore.SaveItemSource não configurado")
+	}
+	return m.SaveItemSourceFunc(source)
+}
+
+func (m *BoxStore) GetItemSource(itemID string) (*storage.ItemSource, error) {
+	if m.GetItemSourceFunc == nil {
+		panic("storagetest: BoxStore.GetItemSource não configurado")
+	}
+	return m.GetItemSourceFunc(itemID)
+}
+
+// HouseholdStore é o mock de storage.HouseholdStore
+// Métodos sem a função correspondente entram em pânico.
+type HouseholdStore struct {
+	CreateHouseholdFunc       func(household *storage.Household, owner *storage.HouseholdMember) error
+	GetHouseholdFunc          func(householdID string) (*storage.Household, error)
+	UpdateHouseholdFunc       func(household *storage.Household) error
+	DeleteHouseholdFunc       func(householdID string) error
+	ListHouseholdsForUserFunc func(userID string) ([]*storage.Household, error)
+	AddHouseholdMemberFunc    func(member *storage.HouseholdMember) error
+	GetHouseholdMemberFunc    func(householdID string, userID string) (*storage.HouseholdMember, error)
+	ListHouseholdMembersFunc  func(householdID string) ([]*storage.HouseholdMember, error)
+	UpdateHouseholdMemberFunc func(member *storage.HouseholdMember) error
+	RemoveHouseholdMemberFunc func(householdID string, userID string) error
+}
+
+var _ storage.HouseholdStore = (*HouseholdStore)(nil)
+
+func (m *HouseholdStore) CreateHousehold(household *storage.Household, owner *storage.HouseholdMember) error {
+	if m.CreateHouseholdFunc == nil {
+		panic("storagetest: HouseholdStore.CreateHousehold não configurado")
+	}
+	return m.CreateHouseholdFunc(household, owner)
+}
+
+func (m *HouseholdStore) GetHousehold(householdID string) (*storage.Household, error) {
+	if m.GetHouseholdFunc == nil {
+		panic("storagetest: HouseholdStore.GetHousehold não configurado")
+	}
+	return m.GetHouseholdFunc(householdID)
+}
+
+func (m *HouseholdStore) UpdateHousehold(household *storage.Household) error {
+	if m.UpdateHouseholdFunc == nil {
+		panic("storagetest: HouseholdStore.UpdateHousehold não configurado")
+	}
+	return m.UpdateHouseholdFunc(household)
+}
+
+func (m *HouseholdStore) DeleteHousehold(householdID string) error {
+	if m.DeleteHouseholdFunc == nil {
+		panic("storagetest: HouseholdStore.DeleteHousehold não configurado")
+	}
+	return m.DeleteHouseholdFunc(householdID)
+}
+
+func (m *HouseholdStore) ListHouseholdsForUser(userID string) ([]*storage.Household, error) {
+	if m.ListHouseholdsForUserFunc == nil {
+		panic("storagetest: HouseholdStore.ListHouseholdsForUser não configurado")
+	}
+	return m.ListHouseholdsForUserFunc(userID)
+}
+
+func (m *HouseholdStore) AddHouseholdMember(member *storage.HouseholdMember) error {
+	if m.AddHouseholdMemberFunc == nil {
+		panic("storagetest: HouseholdStore.AddHouseholdMember não configurado")
+	}
+	return m.AddHouseholdMemberFunc(member)
+}
+
+func (m *HouseholdStore) GetHouseholdMember(householdID string, userID string) (*storage.HouseholdMember, error) {
+	if m.GetHouseholdMemberFunc == nil {
+		panic("storagetest: HouseholdStore.GetHouseholdMember não configurado")
+	}
+	return m.GetHouseholdMemberFunc(householdID, userID)
+}
+
+func (m *HouseholdStore) ListHouseholdMembers(householdID string) ([]*storage.HouseholdMember, error) {
+	if m.ListHouseholdMembersFunc == nil {
+		panic("storagetest: HouseholdStore.ListHouseholdMembers não configurado")
+	}
+	return m.ListHouseholdMembersFunc(householdID)
+}
+
+func (m *HouseholdStore) UpdateHouseholdMember(member *storage.HouseholdMember) error {
+	if m.UpdateHouseholdMemberFunc == nil {
+		panic("storagetest: HouseholdStore.UpdateHouseholdMember não configurado")
+	}
+	return m.UpdateHouseholdMemberFunc(member)
+}
+
+func (m *HouseholdStore) RemoveHouseholdMember(householdID string, userID string) error {
+	if m.RemoveHouseholdMemberFunc == nil {
+		panic("storagetest: HouseholdStore.RemoveHouseholdMember não configurado")
+	}
+	return m.RemoveHouseholdMemberFunc(householdID, userID)
+}
+
+// CategoryStore é o mock de storage.CategoryStore
+// Métodos sem a função correspondente entram em pânico.
+type CategoryStore struct {
+	ListCategoriesFunc    func(userID string) ([]*storage.Category, error)
+	CreateCategoryFunc    func(category *storage.Category) error
+	UpdateCategoryFunc    func(category *storage.Category) error
+	DeleteCategoryFunc    func(userID string, categoryID string) error
+	ReorderCategoriesFunc func(userID string, categoryIDs []string) error
+}
+
+var _ storage.CategoryStore = (*CategoryStore)(nil)
+
+func (m *CategoryStore) ListCategories(userID string) ([]*storage.Category, error) {
+	if m.ListCategoriesFunc == nil {
+		panic("storagetest: CategoryStore.ListCategories não configurado")
+	}
+	return m.ListCategoriesFunc(userID)
+}
+
+func (m *CategoryStore) CreateCategory(category *storage.Category) error {
+	if m.CreateCategoryFunc == nil {
+		panic("storagetest: CategoryStore.CreateCategory não configurado")
+	}
+	return m.CreateCategoryFunc(category)
+}
+
+func (m *CategoryStore) UpdateCategory(category *storage.Category) error {
+	if m.UpdateCategoryFunc == nil {
+		panic("storagetest: CategoryStore.UpdateCategory não configurado")
+	}
+	return m.UpdateCategoryFunc(category)
+}
+
+func (m *CategoryStore) DeleteCategory(userID string, categoryID string) error {
+	if m.DeleteCategoryFunc == nil {
+		panic("storagetest: CategoryStore.DeleteCategory não configurado")
+	}
+	return m.DeleteCategoryFunc(userID, categoryID)
+}
+
+func (m *CategoryStore) ReorderCategories(userID string, categoryIDs []string) error {
+	if m.ReorderCategoriesFunc == nil {
+		panic("storagetest: CategoryStore.ReorderCategories não configurado")
+	}
+	return m.ReorderCategoriesFunc(userID, categoryIDs)
+}
+
+// GuardianStore é o mock de storage.GuardianStore
+// Métodos sem a função correspondente entram em pânico.
+type GuardianStore struct {
+	GetGuardiansFunc                  func(userID string) ([]*storage.Guardian, error)
+	ListGuardiansFunc                 func(userID string) []*storage.Guardian
+	CreateGuardianFunc                func(userID string, guardian *storage.Guardian) (*storage.Guardian, error)
+	CreateGuardianWithIDFunc          func(userID string, guardian *storage.Guardian, guardianID string) (*storage.Guardian, error)
+	UpdateGuardianFunc                func(userID string, guardianID string, updates *storage.Guardian) (*storage.Guardian, error)
+	DeleteGuardianFunc                func(userID string, guardianID string) error
+	ListGuardiansPaginatedFunc        func(userID string, params *storage.PaginationParams) (*storage.PaginatedResult[*storage.Guardian], error)
+	CountGuardiansFunc                func(userID string) (int, error)
+	GetGuardianByAccessTokenFunc      func(token string) (*storage.Guardian, error)
+	ListSharedItemsFunc               func(userID string) []*storage.BoxItem
+	ListItemsForRecipientFunc         func(userID string, guardianID string) ([]*storage.BoxItem, error)
+	ListGuardiansByAccountFunc        func(accountUserID string) ([]*storage.Guardian, error)
+	LinkGuardianAccountFunc           func(guardianID string, accountUserID string) error
+	RotateGuardianAccessTokenFunc     func(guardianID string) (string, error)
+	SetGuardianAccessDisabledFunc     func(guardianID string, at *time.Time) error
+	GetGuardianAccessPolicyFunc       func(guardianID string) (*storage.GuardianAccessPolicy, error)
+	SaveGuardianAccessPolicyFunc      func(policy *storage.GuardianAccessPolicy) error
+	DeleteGuardianAccessPolicyFunc    func(guardianID string) error
+	ClaimGuardianPolicyAccessFunc     func(guardianID string) (bool, error)
+	MarkGuardianPolicyBlockedFunc     func(guardianID string, notifiedBefore time.Time) (bool, error)
+	ListItemViewsBySourceFunc         func(source storage.ItemViewSource, sourceID string) ([]*storage.ItemView, error)
+	RequestGuardianDeletionFunc       func(guardianID string, at time.Time) (*storage.Guardian, error)
+	PurgeGuardiansPendingDeletionFunc func(requestedBefore time.Time) (int, error)
+	CreateGuardianAlertFunc           func(alert *storage.GuardianAlert) error
+	UpdateGuardianAlertFunc           func(alert *storage.GuardianAlert) error
+	ListGuardianAlertsFunc            func(userID string, limit int) ([]*storage.GuardianAlert, error)
+	ListDueGuardianAlertsFunc         func(now time.Time, limit int) ([]*storage.GuardianAlert, error)
+	CreateItemCommentFunc             func(comment *storage.ItemComment) error
+	ListItemCommentsFunc              func(itemID string) ([]*storage.ItemComment, error)
+	ListItemCommentsByGuardianFunc    func(guardianID string) ([]*storage.ItemComment, error)
+	DeleteItemCommentFunc             func(itemID string, commentID string) error
+}
+
+var _ storage.GuardianStore = (*GuardianStore)(nil)
+
+func (m *GuardianStore) GetGuardians(userID string) ([]*storage.Guardian, error) {
+	if m.GetGuardiansFunc == nil {
+		panic("storagetest: GuardianStore.GetGuardians não configurado")
+	}
+	return m.GetGuardiansFunc(userID)
+}
+
+func (m *GuardianStore) ListGuardians(userID string) []*storage.Guardian {
+	if m.ListGuardiansFunc == nil {
+		panic("storagetest: GuardianStore.ListGuardians não configurado")
+	}
+	return m.ListGuardiansFunc(userID)
+}
+
+func (m *GuardianStore) CreateGuardian(userID string, guardian *storage.Guardian) (*storage.Guardian, error) {
+	if m.CreateGuardianFunc == nil {
+		panic("storagetest: GuardianStore.CreateGuardian não configurado")
+	}
+	return m.CreateGuardianFunc(userID, guardian)
+}
+
+func (m *GuardianStore) CreateGuardianWithID(userID string, guardian *storage.Guardian, guardianID string) (*storage.Guardian, error) {
+	if m.CreateGuardianWithIDFunc == nil {
+		panic("storagetest: GuardianStore.CreateGuardianWithID não configurado")
+	}
+	return m.CreateGuardianWithIDFunc(userID, guardian, guardianID)
+}
+
+func (m *GuardianStore) UpdateGuardian(userID string, guardianID string, updates *storage.Guardian) (*storage.Guardian, error) {
+	if m.UpdateGuardianFunc == nil {
+		panic("storagetest: GuardianStore.UpdateGuardian não configurado")
+	}
+	return m.UpdateGuardianFunc(userID, guardianID, updates)
+}
+
+func (m *GuardianStore) DeleteGuardian(userID string, guardianID string) error {
+	if m.DeleteGuardianFunc == nil {
+		panic("storagetest: GuardianStore.DeleteGuardian não configurado")
+	}
+	return m.DeleteGuardianFunc(userID, guardianID)
+}
+
+func (m *GuardianStore) ListGuardiansPaginated(userID string, params *storage.PaginationParams) (*storage.PaginatedResult[*storage.Guardian], error) {
+	if m.ListGuardiansPaginatedFunc == nil {
+		panic("storagetest: GuardianStore.ListGuardiansPaginated não configurado")
+	}
+	return m.ListGuardiansPaginatedFunc(userID, params)
+}
+
+func (m *GuardianStore) CountGuardians(userID string) (int, error) {
+	if m.CountGuardiansFunc == nil {
+		panic("storagetest: GuardianStore.CountGuardians não configurado")
+	}
+	return m.CountGuardiansFunc(userID)
+}
+
+func (m *GuardianStore) GetGuardianByAccessToken(token string) (*storage.Guardian, error) {
+	if m.GetGuardianByAccessTokenFunc == nil {
+		panic("storagetest: GuardianStore.GetGuardianByAccessToken não configurado")
+	}
+	return m.GetGuardianByAccessTokenFunc(token)
+}
+
+func (m *GuardianStore) ListSharedItems(userID string) []*storage.BoxItem {
+	if m.ListSharedItemsFunc == nil {
+		panic("storagetest: GuardianStore.ListSharedItems não configurado")
+	}
+	return m.ListSharedItemsFunc(userID)
+}
+
+func (m *GuardianStore) ListItemsForRecipient(userID string, guardianID string) ([]*storage.BoxItem, error) {
+	if m.ListItemsForRecipientFunc == nil {
+		panic("storagetest: GuardianStore.ListItemsForRecipient não configurado")
+	}
+	return m.ListItemsForRecipientFunc(userID, guardianID)
+}
+
+func (m *GuardianStore) ListGuardiansByAccount(accountUserID string) ([]*storage.Guardian, error) {
+	if m.ListGuardiansByAccountFunc == nil {
+		panic("storagetest: GuardianStore.ListGuardiansByAccount não configurado")
+	}
+	return m.ListGuardiansByAccountFunc(accountUserID)
+}
+
+func (m *GuardianStore) LinkGuardianAccount(guardianID string, accountUserID string) error {
+	if m.LinkGuardianAccountFunc == nil {
+		panic("storagetest: GuardianStore.LinkGuardianAccount não configurado")
+	}
+	return m.LinkGuardianAccountFunc(guardianID, accountUserID)
+}
+
+func (m *GuardianStore) RotateGuardianAccessToken(guardianID string) (string, error) {
+	if m.RotateGuardianAccessTokenFunc == nil {
+		panic("storagetest: GuardianStore.RotateGuardianAccessToken não configurado")
+	}
+	return m.RotateGuardianAccessTokenFunc(guardianID)
+}
+
+func (m *GuardianStore) SetGuardianAccessDisabled(guardianID string, at *time.Time) error {
+	if m.SetGuardianAccessDisabledFunc == nil {
+		panic("storagetest: GuardianStore.SetGuardianAccessDisabled não configurado")
+	}
+	return m.SetGuardianAccessDisabledFunc(guardianID, at)
+}
+
+func (m *GuardianStore) GetGuardianAccessPolicy(guardianID string) (*storage.GuardianAccessPolicy, error) {
+	if m.GetGuardianAccessPolicyFunc == nil {
+		panic("storagetest: GuardianStore.GetGuardianAccessPolicy não configurado")
+	}
+	return m.GetGuardianAccessPolicyFunc(guardianID)
+}
+
+func (m *GuardianStore) SaveGuardianAccessPolicy(policy *storage.GuardianAccessPolicy) error {
+	if m.SaveGuardianAccessPolicyFunc == nil {
+		panic("storagetest: GuardianStore.SaveGuardianAccessPolicy não configurado")
+	}
+	return m.SaveGuardianAccessPolicyFunc(policy)
+}
+
+func (m *GuardianStore) DeleteGuardianAccessPolicy(guardianID string) error {
+	if m.DeleteGuardianAccessPolicyFunc == nil {
+		panic("storagetest: GuardianStore.DeleteGuardianAccessPolicy não configurado")
+	}
+	return m.DeleteGuardianAccessPolicyFunc(guardianID)
+}
+
+func (m *GuardianStore) ClaimGuardianPolicyAccess(guardianID string) (bool, error) {
+	if m.ClaimGuardianPolicyAccessFunc == nil {
+		panic("storagetest: GuardianStore.ClaimGuardianPolicyAccess não configurado")
+	}
+	return m.ClaimGuardianPolicyAccessFunc(guardianID)
+}
+
+func (m *GuardianStore) MarkGuardianPolicyBlocked(guardianID string, notifiedBefore time.Time) (bool, error) {
+	if m.MarkGuardianPolicyBlockedFunc == nil {
+		panic("storagetest: GuardianStore.MarkGuardianPolicyBlocked não configurado")
+	}
+	return m.MarkGuardianPolicyBlockedFunc(guardianID, notifiedBefore)
+}
+
+func (m *GuardianStore) ListItemViewsBySource(source storage.ItemViewSource, sourceID string) ([]*storage.ItemView, error) {
+	if m.ListItemViewsBySourceFunc == nil {
+		panic("storagetest: GuardianStore.ListItemViewsBySource não configurado")
+	}
+	return m.ListItemViewsBySourceFunc(source, sourceID)
+}
+
+func (m *GuardianStore) RequestGuardianDeletion(guardianID string, at time.Time) (*storage.Guardian, error) {
+	if m.RequestGuardianDeletionFunc == nil {
+		panic("storagetest: GuardianStore.RequestGuardianDeletion não configurado")
+	}
+	return m.RequestGuardianDeletionFunc(guardianID, at)
+}
+
+func (m *GuardianStore) PurgeGuardiansPendingDeletion(requestedBefore time.Time) (int, error) {
+	if m.PurgeGuardiansPendingDeletionFunc == nil {
+		panic("storagetest: GuardianStore.PurgeGuardiansPendingDeletion não configurado")
+	}
+	return m.PurgeGuardiansPendingDeletionFunc(requestedBefore)
+}
+
+func (m *GuardianStore) CreateGuardianAlert(alert *storage.GuardianAlert) error {
+	if m.CreateGuardianAlertFunc == nil {
+		panic("storagetest: GuardianStore.CreateGuardianAlert não configurado")
+	}
+	return m.CreateGuardianAlertFunc(alert)
+}
+
+func (m *GuardianStore) UpdateGuardianAlert(alert *storage.GuardianAlert) error {
+	if m.UpdateGuardianAlertFunc == nil {
+		panic("storagetest: GuardianStore.UpdateGuardianAlert não configurado")
+	}
+	return m.UpdateGuardianAlertFunc(alert)
+}
+
+func (m *GuardianStore) ListGuardianAlerts(userID string, limit int) ([]*storage.GuardianAlert, error) {
+	if m.ListGuardianAlertsFunc == nil {
+		panic("storagetest: GuardianStore.ListGuardianAlerts não configurado")
+	}
+	return m.ListGuardianAlertsFunc(userID, limit)
+}
+
+func (m *GuardianStore) ListDueGuardianAlerts(now time.Time, limit int) ([]*storage.GuardianAlert, error) {
+	if m.ListDueGuardianAlertsFunc == nil {
+		panic("storagetest: GuardianStore.ListDueGuardianAlerts não configurado")
+	}
+	return m.ListDueGuardianAlertsFunc(now, limit)
+}
+
+func (m *GuardianStore) CreateItemComment(comment *storage.ItemComment) error {
+	if m.CreateItemCommentFunc == nil {
+		panic("storagetest: GuardianStore.CreateItemComment não configurado")
+	}
+	return m.CreateItemCommentFunc(comment)
+}
+
+func (m *GuardianStore) ListItemComments(itemID string) ([]*storage.ItemComment, error) {
+	if m.ListItemCommentsFunc == nil {
+		panic("storagetest: GuardianStore.ListItemComments não configurado")
+	}
+	return m.ListItemCommentsFunc(itemID)
+}
+
+func (m *GuardianStore) ListItemCommentsByGuardian(guardianID string) ([]*storage.ItemComment, error) {
+	if m.ListItemCommentsByGuardianFunc == nil {
+		panic("storagetest: GuardianStore.ListItemCommentsByGuardian não configurado")
+	}
+	return m.ListItemCommentsByGuardianFunc(guardianID)
+}
+
+func (m *GuardianStore) DeleteItemComment(itemID string, commentID string) error {
+	if m.DeleteItemCommentFunc == nil {
+		panic("storagetest: GuardianStore.DeleteItemComment não configurado")
+	}
+	return m.DeleteItemCommentFunc(itemID, commentID)
+}
+
+// GuideStore é o mock de storage.GuideStore
+// Métodos sem a função correspondente entram em pânico.
+type GuideStore struct {
+	GetGuideProgressFunc    func(userID string) map[string]*storage.GuideProgress
+	UpdateGuideProgressFunc func(userID string, cardID string, status string) (*storage.GuideProgress, error)
+}
+
+var _ storage.GuideStore = (*GuideStore)(nil)
+
+func (m *GuideStore) GetGuideProgress(userID string) map[string]*storage.GuideProgress {
+	if m.GetGuideProgressFunc == nil {
+		panic("storagetest: GuideStore.GetGuideProgress não configurado")
+	}
+	return m.GetGuideProgressFunc(userID)
+}
+
+func (m *GuideStore) UpdateGuideProgress(userID string, cardID string, status string) (*storage.GuideProgress, error) {
+	if m.UpdateGuideProgressFunc == nil {
+		panic("storagetest: GuideStore.UpdateGuideProgress não configurado")
+	}
+	return m.UpdateGuideProgressFunc(userID, cardID, status)
+}
+
+// SettingsStore é o mock de storage.SettingsStore
+// Métodos sem a função correspondente entram em pânico.
+type SettingsStore struct {
+	GetSettingsFunc             func(userID string) *storage.Settings
+	UpdateSettingsFunc          func(userID string, updates *storage.Settings) *storage.Settings
+	UpdateDigestFrequencyFunc   func(userID string, frequency storage.DigestFrequency, since time.Time) error
+	ListDigestSubscriptionsFunc func() ([]*storage.DigestSubscription, error)
+	ClaimDigestFunc             func(userID string, lastSentAt time.Time, now time.Time) (bool, error)
+	UpdateMessagingConsentFunc  func(userID string, optOut bool, at time.Time) error
+	SaveConversationDraftFunc   func(draft *storage.ConversationDraft) error
+	GetConversationDraftFunc    func(userID string) (*storage.ConversationDraft, error)
+	DeleteConversationDraftFunc func(userID string) error
+}
+
+var _ storage.SettingsStore = (*SettingsStore)(nil)
+
+func (m *SettingsStore) GetSettings(userID string) *storage.Settings {
+	if m.GetSettingsFunc == nil {
+		panic("storagetest: SettingsStore.GetSettings não configurado")
+	}
+	return m.GetSettingsFunc(userID)
+}
+
+func (m *SettingsStore) UpdateSettings(userID string, updates *storage.Settings) *storage.Settings {
+	if m.UpdateSettingsFunc == nil {
+		panic("storagetest: SettingsStore.UpdateSettings não configurado")
+	}
+	return m.UpdateSettingsFunc(userID, updates)
+}
+
+func (m *SettingsStore) UpdateDigestFrequency(userID string, frequency storage.DigestFrequency, since time.Time) error {
+	if m.UpdateDigestFrequencyFunc == nil {
+		panic("storagetest: SettingsStore.UpdateDigestFrequency não configurado")
+	}
+	return m.UpdateDigestFrequencyFunc(userID, frequency, since)
+}
+
+func (m *SettingsStore) ListDigestSubscriptions() ([]*storage.DigestSubscription, error) {
+	if m.ListDigestSubscriptionsFunc == nil {
+		panic("storagetest: SettingsStore.ListDigestSubscriptions não configurado")
+	}
+	return m.ListDigestSubscriptionsFunc()
+}
+
+func (m *SettingsStore) ClaimDigest(userID string, lastSentAt time.Time, now time.Time) (bool, error) {
+	if m.ClaimDigestFunc == nil {
+		panic("storagetest: SettingsStore.ClaimDigest não configurado")
+	}
+	return m.ClaimDigestFunc(userID, lastSentAt, now)
+}
+
+func (m *SettingsStore) UpdateMessagingConsent(userID string, optOut bool, at time.Time) error {
+	if m.UpdateMessagingConsentFunc == nil {
+		panic("storagetest: SettingsStore.UpdateMessagingConsent não configurado")
+	}
+	return m.UpdateMessagingConsentFunc(userID, optOut, at)
+}
+
+func (m *SettingsStore) SaveConversationDraft(draft *storage.ConversationDraft) error {
+	if m.SaveConversationDraftFunc == nil {
+		panic("storagetest: SettingsStore.SaveConversationDraft não configurado")
+	}
+	return m.SaveConversationDraftFunc(draft)
+}
+
+func (m *SettingsStore) GetConversationDraft(userID string) (*storage.ConversationDraft, error) {
+	if m.GetConversationDraftFunc == nil {
+		panic("storagetest: SettingsStore.GetConversationDraft não configurado")
+	}
+	return m.GetConversationDraftFunc(userID)
+}
+
+func (m *SettingsStore) DeleteConversationDraft(userID string) error {
+	if m.DeleteConversationDraftFunc == nil {
+		panic("storagetest: SettingsStore.DeleteConversationDraft não configurado")
+	}
+	return m.DeleteConversationDraftFunc(userID)
+}
+
+// AdminStore é o mock de storage.AdminStore
+// Métodos sem a função correspondente entram em pânico.
+type AdminStore struct {
+	GetStatsFunc        func() *storage.Stats
+	ListUsersFunc       func() []*storage.User
+	SearchUsersFunc     func(params *storage.UserSearchParams) (*storage.PaginatedResult[*storage.User], error)
+	SetUserDisabledFunc func(userID string, disabled bool) error
+	GetUserGrowthFunc   func(from time.Time, to time.Time) ([]*storage.UserGrowthDay, error)
+	GrantRoleFunc       func(userID string, role storage.Role) error
+	RevokeRoleFunc      func(userID string) error
+	ExportUserDataFunc  func(userID string) (*storage.UserDataExport, error)
+	BackupUserDataFunc  func(userID string) (*storage.UserBackup, error)
+	RestoreUserDataFunc func(data *storage.UserBackup) error
+}
+
+var _ storage.AdminStore = (*AdminStore)(nil)
+
+func (m *AdminStore) GetStats() *storage.Stats {
+	if m.GetStatsFunc == nil {
+		panic("storagetest: AdminStore.GetStats não configurado")
+	}
+	return m.GetStatsFunc()
+}
+
+func (m *AdminStore) ListUsers() []*storage.User {
+	if m.ListUsersFunc == nil {
+		panic("storagetest: AdminStore.ListUsers não configurado")
+	}
+	return m.ListUsersFunc()
+}
+
+func (m *AdminStore) SearchUsers(params *storage.UserSearchParams) (*storage.PaginatedResult[*storage.User], error) {
+	if m.SearchUsersFunc == nil {
+		panic("storagetest: AdminStore.SearchUsers não configurado")
+	}
+	return m.SearchUsersFunc(params)
+}
+
+func (m *AdminStore) SetUserDisabled(userID string, disabled bool) error {
+	if m.SetUserDisabledFunc == nil {
+		panic("storagetest: AdminStore.SetUserDisabled não configurado")
+	}
+	return m.SetUserDisabledFunc(userID, disabled)
+}
+
+func (m *AdminStore) GetUserGrowth(from time.Time, to time.Time) ([]*storage.UserGrowthDay, error) {
+	if m.GetUserGrowthFunc == nil {
+		panic("storagetest: AdminStore.GetUserGrowth não configurado")
+	}
+	return m.GetUserGrowthFunc(from, to)
+}
+
+func (m *AdminStore) GrantRole(userID string, role storage.Role) error {
+	if m.GrantRoleFunc == nil {
+		panic("storagetest: AdminStore.GrantRole não configurado")
+	}
+	return m.GrantRoleFunc(userID, role)
+}
+
+func (m *AdminStore) RevokeRole(userID string) error {
+	if m.RevokeRoleFunc == nil {
+		panic("storagetest: AdminStore.RevokeRole não configurado")
+	}
+	return m.RevokeRoleFunc(userID)
+}
+
+func (m *AdminStore) ExportUserData(userID string) (*storage.UserDataExport, error) {
+	if m.ExportUserDataFunc == nil {
+		panic("storagetest: AdminStore.ExportUserData não configurado")
+	}
+	return m.ExportUserDataFunc(userID)
+}
+
+func (m *AdminStore) BackupUserData(userID string) (*storage.UserBackup, error) {
+	if m.BackupUserDataFunc == nil {
+		panic("storagetest: AdminStore.BackupUserData não configurado")
+	}
+	return m.BackupUserDataFunc(userID)
+}
+
+func (m *AdminStore) RestoreUserData(data *storage.UserBackup) error {
+	if m.RestoreUserDataFunc == nil {
+		panic("storagetest: AdminStore.RestoreUserData não configurado")
+	}
+	return m.RestoreUserDataFunc(data)
+}
+
+// UsageStore é o mock de storage.UsageStore
+// Métodos sem a função correspondente entram em pânico.
+type UsageStore struct {
+	GetUserUsageFunc           func(userID string) (*storage.UserUsage, error)
+	ListTopUsageFunc           func(limit int) ([]*storage.UserUsage, error)
+	GetAssistantUsageFunc      func(userID string, day string) (*storage.AssistantUsage, error)
+	RecordAssistantUsageFunc   func(userID string, day string, tokens int, rejected bool) error
+	GetAssistantUsageStatsFunc func(since string, topLimit int) (*storage.AssistantUsageStats, error)
+}
+
+var _ storage.UsageStore = (*UsageStore)(nil)
+
+func (m *UsageStore) GetUserUsage(userID string) (*storage.UserUsage, error) {
+	if m.GetUserUsageFunc == nil {
+		panic("storagetest: UsageStore.GetUserUsage não configurado")
+	}
+	return m.GetUserUsageFunc(userID)
+}
+
+func (m *UsageStore) ListTopUsage(limit int) ([]*storage.UserUsage, error) {
+	if m.ListTopUsageFunc == nil {
+		panic("storagetest: UsageStore.ListTopUsage não configurado")
+	}
+	return m.ListTopUsageFunc(limit)
+}
+
+func (m *UsageStore) GetAssistantUsage(userID string, day string) (*storage.AssistantUsage, error) {
+	if m.GetAssistantUsageFunc == nil {
+		panic("storagetest: UsageStore.GetAssistantUsage não configurado")
+	}
+	return m.GetAssistantUsageFunc(userID, day)
+}
+
+func (m *UsageStore) RecordAssistantUsage(userID string, day string, tokens int, rejected bool) error {
+	if m.RecordAssistantUsageFunc == nil {
+		panic("storagetest: UsageStore.RecordAssistantUsage não configurado")
+	}
+	return m.RecordAssistantUsageFunc(userID, day, tokens, rejected)
+}
+
+func (m *UsageStore) GetAssistantUsageStats(since string, topLimit int) (*storage.AssistantUsageStats, error) {
+	if m.GetAssistantUsageStatsFunc == nil {
+		panic("storagetest: UsageStore.GetAssistantUsageStats não configurado")
+	}
+	return m.GetAssistantUsageStatsFunc(since, topLimit)
+}
+
+// FeedbackStore é o mock de storage.FeedbackStore
+// Métodos sem a função correspondente entram em pânico.
+type FeedbackStore struct {
+	CreateFeedbackFunc       func(f *storage.Feedback) error
+	ListFeedbacksFunc        func(status string, limit int) ([]*storage.Feedback, error)
+	EachFeedbackFunc         func(from time.Time, to time.Time, fn func(*storage.Feedback) error) error
+	UpdateFeedbackStatusFunc func(id string, status string, adminNote string) error
+	GetFeedbackStatsFunc     func() (total int, pending int)
+	GetFeedbackFunc          func(id string) (*storage.Feedback, error)
+	ListFeedbacksByUserFunc  func(userID string, limit int) ([]*storage.Feedback, error)
+	AddFeedbackReplyFunc     func(reply *storage.FeedbackReply) error
+}
+
+var _ storage.FeedbackStore = (*FeedbackStore)(nil)
+
+func (m *FeedbackStore) CreateFeedback(f *storage.Feedback) error {
+	if m.CreateFeedbackFunc == nil {
+		panic("storagetest: FeedbackStore.CreateFeedback não configurado")
+	}
+	return m.CreateFeedbackFunc(f)
+}
+
+func (m *FeedbackStore) ListFeedbacks(status string, limit int) ([]*storage.Feedback, error) {
+	if m.ListFeedbacksFunc == nil {
+		panic("storagetest: FeedbackStore.ListFeedbacks não configurado")
+	}
+	return m.ListFeedbacksFunc(status, limit)
+}
+
+func (m *FeedbackStore) EachFeedback(from time.Time, to time.Time, fn func(*storage.Feedback) error) error {
+	if m.EachFeedbackFunc == nil {
+		panic("storagetest: FeedbackStore.EachFeedback não configurado")
+	}
+	return m.EachFeedbackFunc(from, to, fn)
+}
+
+func (m *FeedbackStore) UpdateFeedbackStatus(id string, status string, adminNote string) error {
+	if m.UpdateFeedbackStatusFunc == nil {
+		panic("storagetest: FeedbackStore.UpdateFeedbackStatus não configurado")
+	}
+	return m.UpdateFeedbackStatusFunc(id, status, adminNote)
+}
+
+func (m *FeedbackStore) GetFeedbackStats() (total int, pending int) {
+	if m.GetFeedbackStatsFunc == nil {
+		panic("storagetest: FeedbackStore.GetFeedbackStats não configurado")
+	}
+	return m.GetFeedbackStatsFunc()
+}
+
+func (m *FeedbackStore) GetFeedback(id string) (*storage.Feedback, error) {
+	if m.GetFeedbackFunc == nil {
+		panic("storagetest: FeedbackStore.GetFeedback não configurado")
+	}
+	return m.GetFeedbackFunc(id)
+}
+
+func (m *FeedbackStore) ListFeedbacksByUser(userID string, limit int) ([]*storage.Feedback, error) {
+	if m.ListFeedbacksByUserFunc == nil {
+		panic("storagetest: FeedbackStore.ListFeedbacksByUser não configurado")
+	}
+	return m.ListFeedbacksByUserFunc(userID, limit)
+}
+
+func (m *FeedbackStore) AddFeedbackReply(reply *storage.FeedbackReply) error {
+	if m.AddFeedbackReplyFunc == nil {
+		panic("storagetest: FeedbackStore.AddFeedbackReply não configurado")
+	}
+	return m.AddFeedbackReplyFunc(reply)
+}
+
+// AnalyticsStore é o mock de storage.AnalyticsStore
+// Métodos sem a função correspondente entram em pânico.
+type AnalyticsStore struct {
+	TrackEventFunc              func(e *storage.AnalyticsEvent) error
+	TrackEventsFunc             func(events []*storage.AnalyticsEvent) (int, error)
+	LinkAnonymousEventsFunc     func(anonymousID string, userID string) (int, error)
+	GetAnalyticsSummaryFunc     func() *storage.AnalyticsSummary
+	RollupAnalyticsFunc         func() (int, error)
+	GetRecentEventsFunc         func(limit int) ([]*storage.AnalyticsEvent, error)
+	GetDailyStatsFunc           func(days int) ([]map[string]interface{}, error)
+	ListDailyStatsFunc          func(from time.Time, to time.Time) ([]*storage.DailyStats, error)
+	GetFunnelFunc               func(from time.Time, to time.Time) (*storage.AnalyticsFunnel, error)
+	GetRetentionCohortsFunc     func(from time.Time, to time.Time) ([]*storage.RetentionCohort, error)
+	RecordCompletenessScoreFunc func(userID string, day time.Time, score int) error
+	GetCompletenessTrendFunc    func(from time.Time, to time.Time) ([]*storage.CompletenessDay, error)
+}
+
+var _ storage.AnalyticsStore = (*AnalyticsStore)(nil)
+
+func (m *AnalyticsStore) TrackEvent(e *storage.AnalyticsEvent) error {
+	if m.TrackEventFunc == nil {
+		panic("storagetest: AnalyticsStore.TrackEvent não configurado")
+	}
+	return m.TrackEventFunc(e)
+}
+
+func (m *AnalyticsStore) TrackEvents(events []*storage.AnalyticsEvent) (int, error) {
+	if m.TrackEventsFunc == nil {
+		panic("storagetest: AnalyticsStore.TrackEvents não configurado")
+	}
+	return m.TrackEventsFunc(events)
+}
+
+func (m *AnalyticsStore) LinkAnonymousEvents(anonymousID string, userID string) (int, error) {
+	if m.LinkAnonymousEventsFunc == nil {
+		panic("storagetest: AnalyticsStore.LinkAnonymousEvents não configurado")
+	}
+	return m.LinkAnonymousEventsFunc(anonymousID, userID)
+}
+
+func (m *AnalyticsStore) GetAnalyticsSummary() *storage.AnalyticsSummary {
+	if m.GetAnalyticsSummaryFunc == nil {
+		panic("storagetest: AnalyticsStore.GetAnalyticsSummary não configurado")
+	}
+	return m.GetAnalyticsSummaryFunc()
+}
+
+func (m *AnalyticsStore) RollupAnalytics() (int, error) {
+	if m.RollupAnalyticsFunc == nil {
+		panic("storagetest: AnalyticsStore.RollupAnalytics não configurado")
+	}
+	return m.RollupAnalyticsFunc()
+}
+
+func (m *AnalyticsStore) GetRecentEvents(limit int) ([]*storage.AnalyticsEvent, error) {
+	if m.GetRecentEventsFunc == nil {
+		panic("storagetest: AnalyticsStore.GetRecentEvents não configurado")
+	}
+	return m.GetRecentEventsFunc(limit)
+}
+
+func (m *AnalyticsStore) GetDailyStats(days int) ([]map[string]interface{}, error) {
+	if m.GetDailyStatsFunc == nil {
+		panic("storagetest: AnalyticsStore.GetDailyStats não configurado")
+	}
+	return m.GetDailyStatsFunc(days)
+}
+
+func (m *AnalyticsStore) ListDailyStats(from time.Time, to time.Time) ([]*storage.DailyStats, error) {
+	if m.ListDailyStatsFunc == nil {
+		panic("storagetest: AnalyticsStore.ListDailyStats não configurado")
+	}
+	return m.ListDailyStatsFunc(from, to)
+}
+
+func (m *AnalyticsStore) GetFunnel(from time.Time, to time.Time) (*storage.AnalyticsFunnel, error) {
+	if m.GetFunnelFunc == nil {
+		panic("storagetest: AnalyticsStore.GetFunnel não configurado")
+	}
+	return m.GetFunnelFunc(from, to)
+}
+
+func (m *AnalyticsStore) GetRetentionCohorts(from time.Time, to time.Time) ([]*storage.RetentionCohort, error) {
+	if m.GetRetentionCohortsFunc == nil {
+		panic("storagetest: AnalyticsStore.GetRetentionCohorts não configurado")
+	}
+	return m.GetRetentionCohortsFunc(from, to)
+}
+
+func (m *AnalyticsStore) RecordCompletenessScore(userID string, day time.Time, score int) error {
+	if m.RecordCompletenessScoreFunc == nil {
+		panic("storagetest: AnalyticsStore.RecordCompletenessScore não configurado")
+	}
+	return m.RecordCompletenessScoreFunc(userID, day, score)
+}
+
+func (m *AnalyticsStore) GetCompletenessTrend(from time.Time, to time.Time) ([]*storage.CompletenessDay, error) {
+	if m.GetCompletenessTrendFunc == nil {
+		panic("storagetest: AnalyticsStore.GetCompletenessTrend não configurado")
+	}
+	return m.GetCompletenessTrendFunc(from, to)
+}
+
+// ShareStore é o mock de storage.ShareStore
+// Métodos sem a função correspondente entram em pânico.
+type ShareStore struct {
+	CreateShareLinkFunc             func(link *storage.ShareLink) error
+	GetShareLinkByTokenFunc         func(token string) (*storage.ShareLink, error)
+	GetShareLinksByUserFunc         func(userID string) ([]*storage.ShareLink, error)
+	UpdateShareLinkFunc             func(link *storage.ShareLink) error
+	DeleteShareLinkFunc             func(userID string, linkID string) error
+	RecordShareLinkAccessFunc       func(access *storage.ShareLinkAccess) error
+	ListShareLinkAccessesFunc       func(userID string, linkID string, limit int) ([]*storage.ShareLinkAccess, error)
+	IncrementShareLinkUsageFunc     func(linkID string) error
+	ClaimShareLinkUsageFunc         func(linkID string) (bool, error)
+	GetPINAttemptFunc               func(key string) (*storage.PINAttempt, error)
+	RecordPINFailureFunc            func(key string) (*storage.PINAttempt, error)
+	ResetPINAttemptsFunc            func(key string) error
+	GetMemorialConfigFunc           func(userID string) (*storage.MemorialConfig, error)
+	SaveMemorialConfigFunc          func(config *storage.MemorialConfig) error
+	DeleteMemorialConfigFunc        func(userID string) error
+	CreateMemorialActivationFunc    func(activation *storage.MemorialActivation) error
+	UpdateMemorialActivationFunc    func(activation *storage.MemorialActivation, from storage.MemorialActivationStatus) error
+	AddMemorialConfirmationFunc     func(activationID string, confirmation storage.MemorialConfirmation) (*storage.MemorialActivation, error)
+	GetLatestMemorialActivationFunc func(userID string) (*storage.MemorialActivation, error)
+	ListDueMemorialActivationsFunc  func(now time.Time, limit int) ([]*storage.MemorialActivation, error)
+}
+
+var _ storage.ShareStore = (*ShareStore)(nil)
+
+func (m *ShareStore) CreateShareLink(link *storage.ShareLink) error {
+	if m.CreateShareLinkFunc == nil {
+		panic("storagetest: ShareStore.CreateShareLink não configurado")
+	}
+	return m.CreateShareLinkFunc(link)
+}
+
+func (m *ShareStore) GetShareLinkByToken(token string) (*storage.ShareLink, error) {
+	if m.GetShareLinkByTokenFunc == nil {
+		panic("storagetest: ShareStore.GetShareLinkByToken não configurado")
+	}
+	return m.GetShareLinkByTokenFunc(token)
+}
+
+func (m *ShareStore) GetShareLinksByUser(userID string) ([]*storage.ShareLink, error) {
+	if m.GetShareLinksByUserFunc == nil {
+		panic("storagetest: ShareStore.GetShareLinksByUser não configurado")
+	}
+	return m.GetShareLinksByUserFunc(userID)
+}
+
+func (m *ShareStore) UpdateShareLink(link *storage.ShareLink) error {
+	if m.UpdateShareLinkFunc == nil {
+		panic("storagetest: ShareStore.UpdateShareLink não configurado")
+	}
+	return m.UpdateShareLinkFunc(link)
+}
+
+func (m *ShareStore) DeleteShareLink(userID string, linkID string) error {
+	if m.DeleteShareLinkFunc == nil {
+		panic("storagetest: ShareStore.DeleteShareLink não configurado")
+	}
+	return m.DeleteShareLinkFunc(userID, linkID)
+}
+
+func (m *ShareStore) RecordShareLinkAccess(access *storage.ShareLinkAccess) error {
+	if m.RecordShareLinkAccessFunc == nil {
+		panic("storagetest: ShareStore.RecordShareLinkAccess não configurado")
+	}
+	return m.RecordShareLinkAccessFunc(access)
+}
+
+func (m *ShareStore) ListShareLinkAccesses(userID string, linkID string, limit int) ([]*storage.ShareLinkAccess, error) {
+	if m.ListShareLinkAccessesFunc == nil {
+		panic("storagetest: ShareStore.ListShareLinkAccesses não configurado")
+	}
+	return m.ListShareLinkAccessesFunc(userID, linkID, limit)
+}
+
+func (m *ShareStore) IncrementShareLinkUsage(linkID string) error {
+	if m.IncrementShareLinkUsageFunc == nil {
+		panic("storagetest: ShareStore.IncrementShareLinkUsage não configurado")
+	}
+	return m.IncrementShareLinkUsageFunc(linkID)
+}
+
+func (m *ShareStore) ClaimShareLinkUsage(linkID string) (bool, error) {
+	if m.ClaimShareLinkUsageFunc == nil {
+		panic("storagetest: ShareStore.ClaimShareLinkUsage não configurado")
+	}
+	return m.ClaimShareLinkUsageFunc(linkID)
+}
+
+func (m *ShareStore) GetPINAttempt(key string) (*storage.PINAttempt, error) {
+	if m.GetPINAttemptFunc == nil {
+		panic("storagetest: ShareStore.GetPINAttempt não configurado")
+	}
+	return m.GetPINAttemptFunc(key)
+}
+
+func (m *ShareStore) RecordPINFailure(key string) (*storage.PINAttempt, error) {
+	if m.RecordPINFailureFunc == nil {
+		panic("storagetest: ShareStore.RecordPINFailure não configurado")
+	}
+	return m.RecordPINFailureFunc(key)
+}
+
+func (m *ShareStore) ResetPINAttempts(key string) error {
+	if m.ResetPINAttemptsFunc == nil {
+		panic("storagetest: ShareStore.ResetPINAttempts não configurado")
+	}
+	return m.ResetPINAttemptsFunc(key)
+}
+
+func (m *ShareStore) GetMemorialConfig(userID string) (*storage.MemorialConfig, error) {
+	if m.GetMemorialConfigFunc == nil {
+		panic("storagetest: ShareStore.GetMemorialConfig não configurado")
+	}
+	return m.GetMemorialConfigFunc(userID)
+}
+
+func (m *ShareStore) SaveMemorialConfig(config *storage.MemorialConfig) error {
+	if m.SaveMemorialConfigFunc == nil {
+		panic("storagetest: ShareStore.SaveMemorialConfig não configurado")
+	}
+	return m.SaveMemorialConfigFunc(config)
+}
+
+func (m *ShareStore) DeleteMemorialConfig(userID string) error {
+	if m.DeleteMemorialConfigFunc == nil {
+		panic("storagetest: ShareStore.DeleteMemorialConfig não configurado")
+	}
+	return m.DeleteMemorialConfigFunc(userID)
+}
+
+func (m *ShareStore) CreateMemorialActivation(activation *storage.MemorialActivation) error {
+	if m.CreateMemorialActivationFunc == nil {
+		panic("storagetest: ShareStore.CreateMemorialActivation não configurado")
+	}
+	return m.CreateMemorialActivationFunc(activation)
+}
+
+func (m *ShareStore) UpdateMemorialActivation(activation *storage.MemorialActivation, from storage.MemorialActivationStatus) error {
+	if m.UpdateMemorialActivationFunc == nil {
+		panic("storagetest: ShareStore.UpdateMemorialActivation não configurado")
+	}
+	return m.UpdateMemorialActivationFunc(activation, from)
+}
+
+func (m *ShareStore) AddMemorialConfirmation(activationID string, confirmation storage.MemorialConfirmation) (*storage.MemorialActivation, error) {
+	if m.AddMemorialConfirmationFunc == nil {
+		panic("storagetest: ShareStore.AddMemorialConfirmation não configurado")
+	}
+	return m.AddMemorialConfirmationFunc(activationID, confirmation)
+}
+
+func (m *ShareStore) GetLatestMemorialActivation(userID string) (*storage.MemorialActivation, error) {
+	if m.GetLatestMemorialActivationFunc == nil {
+		panic("storagetest: ShareStore.GetLatestMemorialActivation não configurado")
+	}
+	return m.GetLatestMemorialActivationFunc(userID)
+}
+
+func (m *ShareStore) ListDueMemorialActivations(now time.Time, limit int) ([]*storage.MemorialActivation, error) {
+	if m.ListDueMemorialActivationsFunc == nil {
+		panic("storagetest: ShareStore.ListDueMemorialActivations não configurado")
+	}
+	return m.ListDueMemorialActivationsFunc(now, limit)
+}
+
+// PasswordResetStore é o mock de storage.PasswordResetStore
+// Métodos sem a função correspondente entram em pânico.
+type PasswordResetStore struct {
+	CreatePasswordResetTokenFunc          func(token *storage.PasswordResetToken) error
+	GetPasswordResetTokenFunc             func(tokenHash string) (*storage.PasswordResetToken, error)
+	MarkPasswordResetTokenUsedFunc        func(tokenID string) error
+	CleanupExpiredPasswordResetTokensFunc func() error
+}
+
+var _ storage.PasswordResetStore = (*PasswordResetStore)(nil)
+
+func (m *PasswordResetStore) CreatePasswordResetToken(token *storage.PasswordResetToken) error {
+	if m.CreatePasswordResetTokenFunc == nil {
+		panic("storagetest: PasswordResetStore.CreatePasswordResetToken não configurado")
+	}
+	return m.CreatePasswordResetTokenFunc(token)
+}
+
+func (m *PasswordResetStore) GetPasswordResetToken(tokenHash string) (*storage.PasswordResetToken, error) {
+	if m.GetPasswordResetTokenFunc == nil {
+		panic("storagetest: PasswordResetStore.GetPasswordResetToken não configurado")
+	}
+	return m.GetPasswordResetTokenFunc(tokenHash)
+}
+
+func (m *PasswordResetStore) MarkPasswordResetTokenUsed(tokenID string) error {
+	if m.MarkPasswordResetTokenUsedFunc == nil {
+		panic("storagetest: PasswordResetStore.MarkPasswordResetTokenUsed não configurado")
+	}
+	return m.MarkPasswordResetTokenUsedFunc(tokenID)
+}
+
+func (m *PasswordResetStore) CleanupExpiredPasswordResetTokens() error {
+	if m.CleanupExpiredPasswordResetTokensFunc == nil {
+		panic("storagetest: PasswordResetStore.CleanupExpiredPasswordResetTokens não configurado")
+	}
+	return m.CleanupExpiredPasswordResetTokensFunc()
+}
+
+// EmergencyStore é o mock de storage.EmergencyStore
+// Métodos sem a função correspondente entram em pânico.
+type EmergencyStore struct {
+	GetEmergencyProtocolFunc    func(userID string) (*storage.EmergencyProtocol, error)
+	UpdateEmergencyProtocolFunc func(protocol *storage.EmergencyProtocol) error
+	SaveEmergencyCardFunc       func(card *storage.EmergencyCard) error
+	GetEmergencyCardFunc        func(userID string) (*storage.EmergencyCard, error)
+	GetEmergencyCardByTokenFunc func(tokenHash string) (*storage.EmergencyCard, error)
+	DeleteEmergencyCardFunc     func(userID string) error
+	TouchEmergencyCardFunc      func(userID string, accessedAt time.Time) error
+	GetEscalationChainFunc      func(userID string) (*storage.EscalationChain, error)
+	SaveEscalationChainFunc     func(chain *storage.EscalationChain) error
+	DeleteEscalationChainFunc   func(userID string) error
+	CreateEscalationFunc        func(escalation *storage.EmergencyEscalation) error
+	UpdateEscalationFunc        func(escalation *storage.EmergencyEscalation, from storage.EscalationStatus) error
+	GetLatestEscalationFunc     func(userID string) (*storage.EmergencyEscalation, error)
+	GetEscalationByAckTokenFunc func(tokenHash string) (*storage.EmergencyEscalation, error)
+	ListDueEscalationsFunc      func(now time.Time, limit int) ([]*storage.EmergencyEscalation, error)
+}
+
+var _ storage.EmergencyStore = (*EmergencyStore)(nil)
+
+func (m *EmergencyStore) GetEmergencyProtocol(userID string) (*storage.EmergencyProtocol, error) {
+	if m.GetEmergencyProtocolFunc == nil {
+		panic("storagetest: EmergencyStore.GetEmergencyProtocol não configurado")
+	}
+	return m.GetEmergencyProtocolFunc(userID)
+}
+
+func (m *EmergencyStore) UpdateEmergencyProtocol(protocol *storage.EmergencyProtocol) error {
+	if m.UpdateEmergencyProtocolFunc == nil {
+		panic("storagetest: EmergencyStore.UpdateEmergencyProtocol não configurado")
+	}
+	return m.UpdateEmergencyProtocolFunc(protocol)
+}
+
+func (m *EmergencyStore) SaveEmergencyCard(card *storage.EmergencyCard) error {
+	if m.SaveEmergencyCardFunc == nil {
+		panic("storagetest: EmergencyStore.SaveEmergencyCard não configurado")
+	}
+	return m.SaveEmergencyCardFunc(card)
+}
+
+func (m *EmergencyStore) GetEmergencyCard(userID string) (*storage.EmergencyCard, error) {
+	if m.GetEmergencyCardFunc == nil {
+		panic("storagetest: EmergencyStore.GetEmergencyCard não configurado")
+	}
+	return m.GetEmergencyCardFunc(userID)
+}
+
+func (m *EmergencyStore) GetEmergencyCardByToken(tokenHash string) (*storage.EmergencyCard, error) {
+	if m.GetEmergencyCardByTokenFunc == nil {
+		panic("storagetest: EmergencyStore.GetEmergencyCardByToken não configurado")
+	}
+	return m.GetEmergencyCardByTokenFunc(tokenHash)
+}
+
+func (m *EmergencyStore) DeleteEmergencyCard(userID string) error {
+	if m.DeleteEmergencyCardFunc == nil {
+		panic("storagetest: EmergencyStore.DeleteEmergencyCard não configurado")
+	}
+	return m.DeleteEmergencyCardFunc(userID)
+}
+
+func (m *EmergencyStore) TouchEmergencyCard(userID string, accessedAt time.Time) error {
+	if m.TouchEmergencyCardFunc == nil {
+		panic("storagetest: EmergencyStore.TouchEmergencyCard não configurado")
+	}
+	return m.TouchEmergencyCardFunc(userID, accessedAt)
+}
+
+func (m *EmergencyStore) GetEscalationChain(userID string) (*storage.EscalationChain, error) {
+	if m.GetEscalationChainFunc == nil {
+		panic("storagetest: EmergencyStore.GetEscalationChain não configurado")
+	}
+	return m.GetEscalationChainFunc(userID)
+}
+
+func (m *EmergencyStore) SaveEscalationChain(chain *storage.EscalationChain) error {
+	if m.SaveEscalationChainFunc == nil {
+		panic("storagetest: EmergencyStore.SaveEscalationChain não configurado")
+	}
+	return m.SaveEscalationChainFunc(chain)
+}
+
+func (m *EmergencyStore) DeleteEscalationChain(userID string) error {
+	if m.DeleteEscalationChainFunc == nil {
+		panic("storagetest: EmergencyStore.DeleteEscalationChain não configurado")
+	}
+	return m.DeleteEscalationChainFunc(userID)
+}
+
+func (m *EmergencyStore) CreateEscalation(escalation *storage.EmergencyEscalation) error {
+	if m.CreateEscalationFunc == nil {
+		panic("storagetest: EmergencyStore.CreateEscalation não configurado")
+	}
+	return m.CreateEscalationFunc(escalation)
+}
+
+func (m *EmergencyStore) UpdateEscalation(escalation *storage.EmergencyEscalation, from storage.EscalationStatus) error {
+	if m.UpdateEscalationFunc == nil {
+		panic("storagetest: EmergencyStore.UpdateEscalation não configurado")
+	}
+	return m.UpdateEscalationFunc(escalation, from)
+}
+
+func (m *EmergencyStore) GetLatestEscalation(userID string) (*storage.EmergencyEscalation, error) {
+	if m.GetLatestEscalationFunc == nil {
+		panic("storagetest: EmergencyStore.GetLatestEscalation não configurado")
+	}
+	return m.GetLatestEscalationFunc(userID)
+}
+
+func (m *EmergencyStore) GetEscalationByAckToken(tokenHash string) (*storage.EmergencyEscalation, error) {
+	if m.GetEscalationByAckTokenFunc == nil {
+		panic("storagetest: EmergencyStore.GetEscalationByAckToken não configurado")
+	}
+	return m.GetEscalationByAckTokenFunc(tokenHash)
+}
+
+func (m *EmergencyStore) ListDueEscalations(now time.Time, limit int) ([]*storage.EmergencyEscalation, error) {
+	if m.ListDueEscalationsFunc == nil {
+		panic("storagetest: EmergencyStore.ListDueEscalations não configurado")
+	}
+	return m.ListDueEscalationsFunc(now, limit)
+}
+
+// WebhookStore é o mock de storage.WebhookStore
+// Métodos sem a função correspondente entram em pânico.
+type WebhookStore struct {
+	CreateWebhookFunc            func(hook *storage.Webhook) error
+	GetWebhookFunc               func(userID string, webhookID string) (*storage.Webhook, error)
+	ListWebhooksFunc             func(userID string) ([]*storage.Webhook, error)
+	ListWebhooksForEventFunc     func(userID string, event storage.WebhookEvent) ([]*storage.Webhook, error)
+	UpdateWebhookFunc            func(hook *storage.Webhook) error
+	DeleteWebhookFunc            func(userID string, webhookID string) error
+	RecordWebhookResultFunc      func(webhookID string, success bool) error
+	CreateWebhookDeliveryFunc    func(delivery *storage.WebhookDelivery) error
+	UpdateWebhookDeliveryFunc    func(delivery *storage.WebhookDelivery) error
+	ListWebhookDeliveriesFunc    func(webhookID string, limit int) ([]*storage.WebhookDelivery, error)
+	ListDueWebhookDeliveriesFunc func(now time.Time, limit int) ([]*storage.WebhookDelivery, error)
+}
+
+var _ storage.WebhookStore = (*WebhookStore)(nil)
+
+func (m *WebhookStore) CreateWebhook(hook *storage.Webhook) error {
+	if m.CreateWebhookFunc == nil {
+		panic("storagetest: WebhookStore.CreateWebhook não configurado")
+	}
+	return m.CreateWebhookFunc(hook)
+}
+
+func (m *WebhookStore) GetWebhook(userID string, webhookID string) (*storage.Webhook, error) {
+	if m.GetWebhookFunc == nil {
+		panic("storagetest: WebhookStore.GetWebhook não configurado")
+	}
+	return m.GetWebhookFunc(userID, webhookID)
+}
+
+func (m *WebhookStore) ListWebhooks(userID string) ([]*storage.Webhook, error) {
+	if m.ListWebhooksFunc == nil {
+		panic("storagetest: WebhookStore.ListWebhooks não configurado")
+	}
+	return m.ListWebhooksFunc(userID)
+}
+
+func (m *WebhookStore) ListWebhooksForEvent(userID string, event storage.WebhookEvent) ([]*storage.Webhook, error) {
+	if m.ListWebhooksForEventFunc == nil {
+		panic("storagetest: WebhookStore.ListWebhooksForEvent não configurado")
+	}
+	return m.ListWebhooksForEventFunc(userID, event)
+}
+
+func (m *WebhookStore) UpdateWebhook(hook *storage.Webhook) error {
+	if m.UpdateWebhookFunc == nil {
+		panic("storagetest: WebhookStore.UpdateWebhook não configurado")
+	}
+	return m.UpdateWebhookFunc(hook)
+}
+
+func (m *WebhookStore) DeleteWebhook(userID string, webhookID string) error {
+	if m.DeleteWebhookFunc == nil {
+		panic("storagetest: WebhookStore.DeleteWebhook não configurado")
+	}
+	return m.DeleteWebhookFunc(userID, webhookID)
+}
+
+func (m *WebhookStore) RecordWebhookResult(webhookID string, success bool) error {
+	if m.RecordWebhookResultFunc == nil {
+		panic("storagetest: WebhookStore.RecordWebhookResult não configurado")
+	}
+	return m.RecordWebhookResultFunc(webhookID, success)
+}
+
+func (m *WebhookStore) CreateWebhookDelivery(delivery *storage.WebhookDelivery) error {
+	if m.CreateWebhookDeliveryFunc == nil {
+		panic("storagetest: WebhookStore.CreateWebhookDelivery não configurado")
+	}
+	return m.CreateWebhookDeliveryFunc(delivery)
+}
+
+func (m *WebhookStore) UpdateWebhookDelivery(delivery *storage.WebhookDelivery) error {
+	if m.UpdateWebhookDeliveryFunc == nil {
+		panic("storagetest: WebhookStore.UpdateWebhookDelivery não configurado")
+	}
+	return m.UpdateWebhookDeliveryFunc(delivery)
+}
+
+func (m *WebhookStore) ListWebhookDeliveries(webhookID string, limit int) ([]*storage.WebhookDelivery, error) {
+	if m.ListWebhookDeliveriesFunc == nil {
+		panic("storagetest: WebhookStore.ListWebhookDeliveries não configurado")
+	}
+	return m.ListWebhookDeliveriesFunc(webhookID, limit)
+}
+
+func (m *WebhookStore) ListDueWebhookDeliveries(now time.Time, limit int) ([]*storage.WebhookDelivery, error) {
+	if m.ListDueWebhookDeliveriesFunc == nil {
+		panic("storagetest: WebhookStore.ListDueWebhookDeliveries não configurado")
+	}
+	return m.ListDueWebhookDeliveriesFunc(now, limit)
+}
+
+// NotificationStore é o mock de storage.NotificationStore
+// Métodos sem a função correspondente entram em pânico.
+type NotificationStore struct {
+	SavePushSubscriptionFunc       func(sub *storage.PushSubscription) error
+	ListPushSubscriptionsFunc      func(userID string) ([]*storage.PushSubscription, error)
+	DeletePushSubscriptionFunc     func(userID string, endpoint string) error
+	TouchPushSubscriptionFunc      func(id string, usedAt time.Time) error
+	CreateNotificationFunc         func(n *storage.Notification) error
+	UpdateNotificationChannelsFunc func(notificationID string, channels []string) error
+	ListNotificationsFunc          func(userID string, unreadOnly bool, params *storage.PaginationParams) (*storage.PaginatedResult[*storage.Notification], error)
+	CountUnreadNotificationsFunc   func(userID string) (int, error)
+	MarkNotificationReadFunc       func(userID string, notificationID string) error
+	MarkAllNotificationsReadFunc   func(userID string) error
+}
+
+var _ storage.NotificationStore = (*NotificationStore)(nil)
+
+func (m *NotificationStore) SavePushSubscription(sub *storage.PushSubscription) error {
+	if m.SavePushSubscriptionFunc == nil {
+		panic("storagetest: NotificationStore.SavePushSubscription não configurado")
+	}
+	return m.SavePushSubscriptionFunc(sub)
+}
+
+func (m *NotificationStore) ListPushSubscriptions(userID string) ([]*storage.PushSubscription, error) {
+	if m.ListPushSubscriptionsFunc == nil {
+		panic("storagetest: NotificationStore.ListPushSubscriptions não configurado")
+	}
+	return m.ListPushSubscriptionsFunc(userID)
+}
+
+func (m *NotificationStore) DeletePushSubscription(userID string, endpoint string) error {
+	if m.DeletePushSubscriptionFunc == nil {
+		panic("storagetest: NotificationStore.DeletePushSubscription não configurado")
+	}
+	return m.DeletePushSubscriptionFunc(userID, endpoint)
+}
+
+func (m *NotificationStore) TouchPushSubscription(id string, usedAt time.Time) error {
+	if m.TouchPushSubscriptionFunc == nil {
+		panic("storagetest: NotificationStore.TouchPushSubscription não configurado")
+	}
+	return m.TouchPushSubscriptionFunc(id, usedAt)
+}
+
+func (m *NotificationStore) CreateNotification(n *storage.Notification) error {
+	if m.CreateNotificationFunc == nil {
+		panic("storagetest: NotificationStore.CreateNotification não configurado")
+	}
+	return m.CreateNotificationFunc(n)
+}
+
+func (m *NotificationStore) UpdateNotificationChannels(notificationID string, channels []string) error {
+	if m.UpdateNotificationChannelsFunc == nil {
+		panic("storagetest: NotificationStore.UpdateNotificationChannels não configurado")
+	}
+	return m.UpdateNotificationChannelsFunc(notificationID, channels)
+}
+
+func (m *NotificationStore) ListNotifications(userID string, unreadOnly bool, params *storage.PaginationParams) (*storage.PaginatedResult[*storage.Notification], error) {
+	if m.ListNotificationsFunc == nil {
+		panic("storagetest: NotificationStore.ListNotifications não configurado")
+	}
+	return m.ListNotificationsFunc(userID, unreadOnly, params)
+}
+
+func (m *NotificationStore) CountUnreadNotifications(userID string) (int, error) {
+	if m.CountUnreadNotificationsFunc == nil {
+		panic("storagetest: NotificationStore.CountUnreadNotifications não configurado")
+	}
+	return m.CountUnreadNotificationsFunc(userID)
+}
+
+func (m *NotificationStore) MarkNotificationRead(userID string, notificationID string) error {
+	if m.MarkNotificationReadFunc == nil {
+		panic("storagetest: NotificationStore.MarkNotificationRead não configurado")
+	}
+	return m.MarkNotificationReadFunc(userID, notificationID)
+}
+
+func (m *NotificationStore) MarkAllNotificationsRead(userID string) error {
+	if m.MarkAllNotificationsReadFunc == nil {
+		panic("storagetest: NotificationStore.MarkAllNotificationsRead não configurado")
+	}
+	return m.MarkAllNotificationsReadFunc(userID)
+}
+
+// SystemStore é o mock de storage.SystemStore
+// Métodos sem a função correspondente entram em pânico.
+type SystemStore struct {
+	ListSystemConfigFunc       func(prefix string) (map[string]string, error)
+	SetSystemConfigFunc        func(key string, value string) error
+	DeleteSystemConfigFunc     func(key string) error
+	CleanupExpiredRecordsFunc  func() error
+	PurgeRetentionFunc         func(class storage.RetentionClass, days int) (int64, error)
+	CreateRetentionRunFunc     func(run *storage.RetentionRun) error
+	ListRetentionRunsFunc      func(limit int) ([]*storage.RetentionRun, error)
+	CreateStatusIncidentFunc   func(incident *storage.StatusIncident) error
+	GetStatusIncidentFunc      func(id string) (*storage.StatusIncident, error)
+	UpdateStatusIncidentFunc   func(incident *storage.StatusIncident) error
+	DeleteStatusIncidentFunc   func(id string) error
+	ListStatusIncidentsFunc    func(since time.Time) ([]*storage.StatusIncident, error)
+	RecordStatusCheckFunc      func(component string, day time.Time, ok bool) error
+	ListStatusUptimeFunc       func(since time.Time) ([]*storage.StatusUptimeDay, error)
+	RecordAuditFunc            func(entry *storage.AuditEntry) error
+	ListActivityFunc           func(params *storage.ActivityParams) (*storage.PaginatedResult[*storage.ActivityEntry], error)
+	CheckHealthFunc            func(ctx context.Context) (*storage.MigrationSummary, error)
+	RegisterIdempotencyKeyFunc func(userID string, key string, resourceType string, resourceID string) (existingID string, inserted bool, err error)
+	DeleteIdempotencyKeyFunc   func(userID string, key string, resourceType string) error
+}
+
+var _ storage.SystemStore = (*SystemStore)(nil)
+
+func (m *SystemStore) ListSystemConfig(prefix string) (map[string]string, error) {
+	if m.ListSystemConfigFunc == nil {
+		panic("storagetest: SystemStore.ListSystemConfig não configurado")
+	}
+	return m.ListSystemConfigFunc(prefix)
+}
+
+func (m *SystemStore) SetSystemConfig(key string, value string) error {
+	if m.SetSystemConfigFunc == nil {
+		panic("storagetest: SystemStore.SetSystemConfig não configurado")
+	}
+	return m.SetSystemConfigFunc(key, value)
+}
+
+func (m *SystemStore) DeleteSystemConfig(key string) error {
+	if m.DeleteSystemConfigFunc == nil {
+		panic("storagetest: SystemStore.DeleteSystemConfig não configurado")
+	}
+	return m.DeleteSystemConfigFunc(key)
+}
+
+func (m *SystemStore) CleanupExpiredRecords() error {
+	if m.CleanupExpiredRecordsFunc == nil {
+		panic("storagetest: SystemStore.CleanupExpiredRecords não configurado")
+	}
+	return m.CleanupExpiredRecordsFunc()
+}
+
+func (m *SystemStore) PurgeRetention(class storage.RetentionClass, days int) (int64, error) {
+	if m.PurgeRetentionFunc == nil {
+		panic("storagetest: SystemStore.PurgeRetention não configurado")
+	}
+	return m.PurgeRetentionFunc(class, days)
+}
+
+func (m *SystemStore) CreateRetentionRun(run *storage.RetentionRun) error {
+	if m.CreateRetentionRunFunc == nil {
+		panic("storagetest: SystemStore.CreateRetentionRun não configurado")
+	}
+	return m.CreateRetentionRunFunc(run)
+}
+
+func (m *SystemStore) ListRetentionRuns(limit int) ([]*storage.RetentionRun, error) {
+	if m.ListRetentionRunsFunc == nil {
+		panic("storagetest: SystemStore.ListRetentionRuns não configurado")
+	}
+	return m.ListRetentionRunsFunc(limit)
+}
+
+func (m *SystemStore) CreateStatusIncident(incident *storage.StatusIncident) error {
+	if m.CreateStatusIncidentFunc == nil {
+		panic("storagetest: SystemStore.CreateStatusIncident não configurado")
+	}
+	return m.CreateStatusIncidentFunc(incident)
+}
+
+func (m *SystemStore) GetStatusIncident(id string) (*storage.StatusIncident, error) {
+	if m.GetStatusIncidentFunc == nil {
+		panic("storagetest: SystemStore.GetStatusIncident não configurado")
+	}
+	return m.GetStatusIncidentFunc(id)
+}
+
+func (m *SystemStore) UpdateStatusIncident(incident *storage.StatusIncident) error {
+	if m.UpdateStatusIncidentFunc == nil {
+		panic("storagetest: SystemStore.UpdateStatusIncident não configurado")
+	}
+	return m.UpdateStatusIncidentFunc(incident)
+}
+
+func (m *SystemStore) DeleteStatusIncident(id string) error {
+	if m.DeleteStatusIncidentFunc == nil {
+		panic("storagetest: SystemStore.DeleteStatusIncident não configurado")
+	}
+	return m.DeleteStatusIncidentFunc(id)
+}
+
+func (m *SystemStore) ListStatusIncidents(since time.Time) ([]*storage.StatusIncident, error) {
+	if m.ListStatusIncidentsFunc == nil {
+		panic("storagetest: SystemStore.ListStatusIncidents não configurado")
+	}
+	return m.ListStatusIncidentsFunc(since)
+}
+
+func (m *SystemStore) RecordStatusCheck(component string, day time.Time, ok bool) error {
+	if m.RecordStatusCheckFunc == nil {
+		panic("storagetest: SystemStore.RecordStatusCheck não configurado")
+	}
+	return m.RecordStatusCheckFunc(component, day, ok)
+}
+
+func (m *SystemStore) ListStatusUptime(since time.Time) ([]*storage.StatusUptimeDay, error) {
+	if m.ListStatusUptimeFunc == nil {
+		panic("storagetest: SystemStore.ListStatusUptime não configurado")
+	}
+	return m.ListStatusUptimeFunc(since)
+}
+
+func (m *SystemStore) RecordAudit(entry *storage.AuditEntry) error {
+	if m.RecordAuditFunc == nil {
+		panic("storagetest: SystemStore.RecordAudit não configurado")
+	}
+	return m.RecordAuditFunc(entry)
+}
+
+func (m *SystemStore) ListActivity(params *storage.ActivityParams) (*storage.PaginatedResult[*storage.ActivityEntry], error) {
+	if m.ListActivityFunc == nil {
+		panic("storagetest: SystemStore.ListActivity não configurado")
+	}
+	return m.ListActivityFunc(params)
+}
+
+func (m *SystemStore) CheckHealth(ctx context.Context) (*storage.MigrationSummary, error) {
+	if m.CheckHealthFunc == nil {
+		panic("storagetest: SystemStore.CheckHealth não configurado")
+	}
+	return m.CheckHealthFunc(ctx)
+}
+
+func (m *SystemStore) RegisterIdempotencyKey(userID string, key string, resourceType string, resourceID string) (existingID string, inserted bool, err error) {
+	if m.RegisterIdempotencyKeyFunc == nil {
+		panic("storagetest: SystemStore.RegisterIdempotencyKey não configurado")
+	}
+	return m.RegisterIdempotencyKeyFunc(userID, key, resourceType, resourceID)
+}
+
+func (m *SystemStore) DeleteIdempotencyKey(userID string, key string, resourceType string) error {
+	if m.DeleteIdempotencyKeyFunc == nil {
+		panic("storagetest: SystemStore.DeleteIdempotencyKey não configurado")
+	}
+	return m.DeleteIdempotencyKeyFunc(userID, key, resourceType)
+}
+
+// Store embute os mocks de todos os domínios e satisfaz storage.Store
+type Store struct {
+	UserStore
+	SubscriptionStore
+	BoxStore
+	HouseholdStore
+	CategoryStore
+	GuardianStore
+	GuideStore
+	SettingsStore
+	AdminStore
+	UsageStore
+	FeedbackStore
+	AnalyticsStore
+	ShareStore
+	PasswordResetStore
+	EmergencyStore
+	WebhookStore
+	NotificationStore
+	SystemStore
+}
+
+var _ storage.Store = (*Store)(nil)
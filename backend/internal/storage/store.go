@@ -4,38 +4,196 @@
 // Define a interface comum para todos os backends de armazenamento.
 // Isso permite trocar entre MemoryStore (desenvolvimento) e PostgresStore
 // (produção) sem modificar o código dos handlers.
+//
+// Store é a composição de interfaces menores, uma por domínio. Pacotes que
+// usam apenas um domínio podem depender só da interface dele, e os testes
+// podem usar os mocks de storagetest no lugar do MemoryStore.
+//
+// Ao adicionar métodos, rode `go generate ./internal/storage/storagetest`.
 // =============================================================================
 
 package storage
 
+import (
+	"context"
+	"time"
+)
+
 // Store define a interface para armazenamento de dados
 type Store interface {
+	UserStore
+	SubscriptionStore
+	BoxStore
+	HouseholdStore
+	CategoryStore
+	GuardianStore
+	GuideStore
+	SettingsStore
+	AdminStore
+	UsageStore
+	FeedbackStore
+	AnalyticsStore
+	ShareStore
+	PasswordResetStore
+	EmergencyStore
+	WebhookStore
+	NotificationStore
+	SystemStore
+}
+
+// UserStore guarda as contas, o login social e as sessões
+type UserStore interface {
 	// Users
 	CreateUser(email, hashedPassword, name string) (*User, error)
 	GetUserByEmail(email string) (*User, bool)
 	GetUserByID(id string) (*User, bool)
 	UpdateUserPassword(userID, hashedPassword string) error
-	UpdateUserLocale(userID, locale string) error // Atualiza idioma preferido
-	DeleteUser(userID string) error               // LGPD: Direito ao esquecimento
+	UpdateUserLocale(userID, locale string) error       // Atualiza idioma preferido
+	UpdateUserProfile(userID, name, email string) error // LGPD: Correção (ErrAlreadyExists se o email for de outra conta)
+	DeleteUser(userID string) error                     // LGPD: Direito ao esquecimento
+
+	// Termos de Uso e Política de Privacidade (versão aceita)
+	AcceptLegalTerms(userID, termsVersion, privacyVersion string, at time.Time) error // ErrNotFound se não existir
 
 	// Social Auth (Google, Apple)
 	CreateOrUpdateSocialUser(provider AuthProvider, providerID, email, name, avatarURL string) (*User, error)
 	GetUserByProvider(provider AuthProvider, providerID string) (*User, bool)
 	LinkSocialProvider(userID string, provider AuthProvider, providerID string) error
 
+	// Account Security (bloqueio de conta e histórico de login)
+	RecordFailedLogin(userID string, maxAttempts int, lockDuration time.Duration) (*User, error)
+	ResetFailedLogins(userID string) error
+	RecordLogin(record *LoginRecord) (*LoginCheck, error)
+
+	// Sessões por dispositivo (expiram após DeviceSessionTTL sem uso)
+	CreateDeviceSession(session *DeviceSession) error           // Remove as sessões expiradas do usuário
+	GetDeviceSession(sessionID string) (*DeviceSession, error)  // ErrNotFound se removida ou expirada
+	ListDeviceSessions(userID string) ([]*DeviceSession, error) // Usadas mais recentemente primeiro
+	TouchDeviceSession(sessionID, ipAddress string) error       // Atualiza último acesso e IP
+	RenameDeviceSession(userID, sessionID, name string) error   // ErrNotFound se não for do usuário
+	DeleteDeviceSession(userID, sessionID string) error         // ErrNotFound se não for do usuário
+
+	// Acesso do suporte (consentimento para a sessão somente leitura do suporte)
+	SaveSupportAccess(access *SupportAccess) error          // Cria ou substitui a autorização do usuário
+	GetSupportAccess(userID string) (*SupportAccess, error) // ErrNotFound se não houver (pode estar vencida)
+	DeleteSupportAccess(userID string) error                // Revoga (sem erro se não houver)
+
+	// Chaves pessoais de API (apenas o hash da chave é guardado)
+	CreateAPIKey(key *APIKey) error
+	ListAPIKeys(userID string) ([]*APIKey, error)    // Mais antigas primeiro
+	GetAPIKeyByHash(keyHash string) (*APIKey, error) // ErrNotFound se não existir
+	TouchAPIKey(keyID string, at time.Time) error    // Registra o último uso
+	DeleteAPIKey(userID, keyID string) error         // ErrNotFound se não for do usuário
+
+	// Números de WhatsApp vinculados (o primeiro vira o principal)
+	CreatePhoneLink(link *PhoneLink) error                // ErrAlreadyExists se o número já estiver vinculado
+	ListPhoneLinks(userID string) ([]*PhoneLink, error)   // Principal primeiro, depois os mais antigos
+	GetPhoneLinkByPhone(phone string) (*PhoneLink, error) // ErrNotFound se não estiver vinculado
+	SetPrimaryPhoneLink(userID, linkID string) error      // ErrNotFound se não for do usuário
+	DeletePhoneLink(userID, linkID string) error          // ErrNotFound se não for do usuário; promove outro a principal
+
+	// Remetentes verificados do gateway de email
+	CreateInboundSender(sender *InboundSender) error              // ErrAlreadyExists se o email já estiver vinculado
+	ListInboundSenders(userID string) ([]*InboundSender, error)   // Mais antigos primeiro
+	GetInboundSenderByEmail(email string) (*InboundSender, error) // ErrNotFound se não estiver vinculado
+	DeleteInboundSender(userID, senderID string) error            // ErrNotFound se não for do usuário
+}
+
+// SubscriptionStore guarda as assinaturas pagas
+type SubscriptionStore interface {
+	// Assinaturas (Stripe)
+	GetSubscription(userID string) (*Subscription, error)               // ErrNotFound se nunca assinou
+	GetSubscriptionByCustomer(customerID string) (*Subscription, error) // ErrNotFound se desconhecido
+	SaveSubscription(sub *Subscription) error                           // Cria ou atualiza e aplica o plano ao usuário
+}
+
+// BoxStore guarda os itens da Caixa Famli e o que depende deles
+type BoxStore interface {
 	// Box Items (métodos legacy para compatibilidade)
 	GetBoxItems(userID string) ([]*BoxItem, error)
 	ListBoxItems(userID string) []*BoxItem
 	GetBoxItem(userID, itemID string) (*BoxItem, error)
 	CreateBoxItem(userID string, item *BoxItem) (*BoxItem, error)
 	CreateBoxItemWithID(userID string, item *BoxItem, itemID string) (*BoxItem, error)
-	UpdateBoxItem(userID, itemID string, updates *BoxItem) (*BoxItem, error)
+	UpdateBoxItem(userID, itemID string, updates *BoxItem) (*BoxItem, error) // ErrVersionConflict se updates.Version (≠ 0) não for a atual
 	DeleteBoxItem(userID, itemID string) error
+	SetChecklistEntryDone(userID, itemID, entryID string, done bool) (*BoxItem, error) // ErrNotFound se o item ou a linha não existir; incrementa a versão
 
 	// Box Items (métodos paginados - preferir estes)
 	ListBoxItemsPaginated(userID string, params *PaginationParams) (*PaginatedResult[*BoxItemSummary], error)
 	CountBoxItems(userID string) (int, error)
 
+	// Box Items (caixa pessoal + caixas das famílias)
+	GetBoxItemByID(itemID string) (*BoxItem, error) // Sem filtro de dono: o chamador verifica o acesso
+	ListAccessibleBoxItemsPaginated(filter *BoxItemFilter, params *PaginationParams) (*PaginatedResult[*BoxItemSummary], error)
+	CountAccessibleBoxItems(filter *BoxItemFilter) (int, error)
+
+	// Itens fixados e ordem manual (por usuário: cada membro tem a sua)
+	SetBoxItemPinned(userID, itemID string, pinned bool) error
+	ReorderBoxItems(userID string, itemIDs []string) error // Posição = índice na lista + 1
+
+	// Relações entre itens (destinatário e "veja também")
+	CreateItemRelation(rel *ItemRelation) error               // ErrAlreadyExists se o vínculo já existir
+	ListItemRelations(itemID string) ([]*ItemRelation, error) // Inclui os "veja também" que apontam para o item
+	DeleteItemRelation(itemID, relationID string) error       // ErrNotFound se o vínculo não envolver o item
+
+	// Recibos de leitura (itens vistos por links e guardiões)
+	RecordItemViews(source ItemViewSource, sourceID string, itemIDs []string, at time.Time) (map[string]bool, error) // true = item já visto antes pela origem
+	ListItemViews(itemID string) ([]*ItemView, error)                                                                // Mais recentes primeiro
+
+	// Agenda das rotinas (itens "routine" com Schedule, sem os arquivados)
+	ListScheduledRoutines(filter *BoxItemFilter) ([]*BoxItem, error)
+
+	// Validade dos documentos (itens "document" com Document.ExpiresOn, sem os arquivados)
+	ListExpiringDocuments(filter *BoxItemFilter, until time.Time) ([]*BoxItem, error)                   // Vencidos ou vencendo até until
+	ListDocumentExpiries(until time.Time) ([]*DocumentExpiry, error)                                    // De todos os usuários (lembretes)
+	ClaimExpiryReminder(itemID string, expiresOn time.Time, daysBefore int, at time.Time) (bool, error) // false = lembrete já enviado
+
+	// Calendário (itens com vencimento/renovação e link ICS privado)
+	ListDatedBoxItems(userID string) ([]*BoxItem, error) // Sem conteúdo, apenas metadados e datas
+	SaveCalendarFeed(feed *CalendarFeed) error           // Cria ou substitui o link do usuário
+	GetCalendarFeed(userID string) (*CalendarFeed, error)
+	GetCalendarFeedByToken(tokenHash string) (*CalendarFeed, error)
+	DeleteCalendarFeed(userID string) error
+	TouchCalendarFeed(userID string, accessedAt time.Time) error
+
+	// Captura pelo navegador (token pessoal e origem dos itens capturados)
+	SaveCaptureToken(token *CaptureToken) error // Cria ou substitui o token do usuário
+	GetCaptureToken(userID string) (*CaptureToken, error)
+	GetCaptureTokenByHash(tokenHash string) (*CaptureToken, error)
+	DeleteCaptureToken(userID string) error
+	TouchCaptureToken(userID string, usedAt time.Time) error
+	SaveItemSource(source *ItemSource) error
+	GetItemSource(itemID string) (*ItemSource, error) // ErrNotFound se o item não foi capturado
+}
+
+// HouseholdStore guarda as famílias e seus membros
+type HouseholdStore interface {
+	// Famílias (caixas compartilhadas)
+	CreateHousehold(household *Household, owner *HouseholdMember) error
+	GetHousehold(householdID string) (*Household, error)
+	UpdateHousehold(household *Household) error
+	DeleteHousehold(householdID string) error                  // Itens voltam para a caixa pessoal de quem criou
+	ListHouseholdsForUser(userID string) ([]*Household, error) // Inclui convites pendentes
+	AddHouseholdMember(member *HouseholdMember) error          // ErrAlreadyExists se já for membro
+	GetHouseholdMember(householdID, userID string) (*HouseholdMember, error)
+	ListHouseholdMembers(householdID string) ([]*HouseholdMember, error)
+	UpdateHouseholdMember(member *HouseholdMember) error
+	RemoveHouseholdMember(householdID, userID string) error // Itens do membro voltam para a caixa pessoal dele
+}
+
+// CategoryStore guarda as categorias da caixa
+type CategoryStore interface {
+	// Categorias da caixa (definidas pelo usuário)
+	ListCategories(userID string) ([]*Category, error)           // Ordenadas por posição
+	CreateCategory(category *Category) error                     // ErrAlreadyExists se o nome já existir
+	UpdateCategory(category *Category) error                     // Renomear atualiza os itens do usuário
+	DeleteCategory(userID, categoryID string) error              // Itens do usuário ficam sem categoria
+	ReorderCategories(userID string, categoryIDs []string) error // Posição = índice na lista
+}
+
+// GuardianStore guarda os guardiões e o acesso deles aos itens
+type GuardianStore interface {
 	// Guardians (métodos legacy para compatibilidade)
 	GetGuardians(userID string) ([]*Guardian, error)
 	ListGuardians(userID string) []*Guardian
@@ -50,35 +208,133 @@ type Store interface {
 
 	// Guardian Access (acesso via token do guardião)
 	GetGuardianByAccessToken(token string) (*Guardian, error)
-	ListSharedItems(userID string) []*BoxItem // Lista itens com is_shared = true
+	ListSharedItems(userID string) []*BoxItem                            // Lista itens com is_shared = true
+	ListItemsForRecipient(userID, guardianID string) ([]*BoxItem, error) // Itens endereçados ao guardião, mais recentes primeiro
+
+	// Guardian Accounts (portal do guardião com login)
+	ListGuardiansByAccount(accountUserID string) ([]*Guardian, error) // Vínculos da conta, de todos os donos
+	LinkGuardianAccount(guardianID, accountUserID string) error       // "" desvincula; ErrNotFound se não existir
+	RotateGuardianAccessToken(guardianID string) (string, error)      // Invalida o link atual; ErrNotFound se não existir
+	SetGuardianAccessDisabled(guardianID string, at *time.Time) error // nil reativa o acesso; ErrNotFound se não existir
+
+	// Guardian Access Policies (prazo, horários e limite de acessos)
+	GetGuardianAccessPolicy(guardianID string) (*GuardianAccessPolicy, error)            // ErrNotFound sem regras
+	SaveGuardianAccessPolicy(policy *GuardianAccessPolicy) error                         // Cria ou substitui (inclusive AccessCount)
+	DeleteGuardianAccessPolicy(guardianID string) error                                  // ErrNotFound sem regras
+	ClaimGuardianPolicyAccess(guardianID string) (bool, error)                           // Conta um acesso; false se o limite já foi atingido (sem regras: true)
+	MarkGuardianPolicyBlocked(guardianID string, notifiedBefore time.Time) (bool, error) // true se o último aviso foi antes de notifiedBefore (e registra agora)
+
+	// Guardian Privacy (LGPD para guardiões sem conta)
+	ListItemViewsBySource(source ItemViewSource, sourceID string) ([]*ItemView, error) // Recibos de leitura da origem, mais recentes primeiro
+	RequestGuardianDeletion(guardianID string, at time.Time) (*Guardian, error)        // Marca para remoção (mantém a primeira data); ErrNotFound se não existir
+	PurgeGuardiansPendingDeletion(requestedBefore time.Time) (int, error)              // Apaga os guardiões marcados antes da data e seus recibos
+
+	// Guardian Alerts (entregas dos avisos aos guardiões)
+	CreateGuardianAlert(alert *GuardianAlert) error
+	UpdateGuardianAlert(alert *GuardianAlert) error // ErrNotFound se não existir
+	ListGuardianAlerts(userID string, limit int) ([]*GuardianAlert, error)
+	ListDueGuardianAlerts(now time.Time, limit int) ([]*GuardianAlert, error)
+
+	// Item Comments (perguntas dos guardiões nos itens compartilhados)
+	CreateItemComment(comment *ItemComment) error
+	ListItemComments(itemID string) ([]*ItemComment, error)               // Mais antigos primeiro
+	ListItemCommentsByGuardian(guardianID string) ([]*ItemComment, error) // Do guardião, mais recentes primeiro
+	DeleteItemComment(itemID, commentID string) error                     // ErrNotFound se não for do item
+}
 
-	// Guide Progress
+// GuideStore guarda o progresso no Guia Famli
+type GuideStore interface {
 	GetGuideProgress(userID string) map[string]*GuideProgress
 	UpdateGuideProgress(userID, cardID, status string) (*GuideProgress, error)
+}
 
+// SettingsStore guarda as preferências do usuário
+type SettingsStore interface {
 	// Settings
 	GetSettings(userID string) *Settings
 	UpdateSettings(userID string, updates *Settings) *Settings
 
+	// Resumo pelo WhatsApp
+	UpdateDigestFrequency(userID string, frequency DigestFrequency, since time.Time) error
+	ListDigestSubscriptions() ([]*DigestSubscription, error)
+	ClaimDigest(userID string, lastSentAt, now time.Time) (bool, error) // Marca o envio se ninguém marcou antes (várias réplicas)
+
+	// Consentimento para mensagens pelo WhatsApp (STOP/START)
+	UpdateMessagingConsent(userID string, optOut bool, at time.Time) error
+
+	// Rascunhos das conversas pelo WhatsApp (um por usuário)
+	SaveConversationDraft(draft *ConversationDraft) error           // Substitui o rascunho anterior
+	GetConversationDraft(userID string) (*ConversationDraft, error) // ErrNotFound se não houver
+	DeleteConversationDraft(userID string) error
+}
+
+// AdminStore reúne as operações do painel administrativo sobre as contas
+type AdminStore interface {
 	// Admin
 	GetStats() *Stats
 	ListUsers() []*User
+	SearchUsers(params *UserSearchParams) (*PaginatedResult[*User], error)
+	SetUserDisabled(userID string, disabled bool) error
+	GetUserGrowth(from, to time.Time) ([]*UserGrowthDay, error) // Um registro por dia de [from, to), inclusive os vazios
+
+	// Papéis administrativos
+	GrantRole(userID string, role Role) error
+	RevokeRole(userID string) error
 
 	// User Data Export (LGPD: Portabilidade)
 	ExportUserData(userID string) (*UserDataExport, error)
 
+	// Backup administrativo (recuperação de desastres, com os IDs originais)
+	BackupUserData(userID string) (*UserBackup, error) // Inclui hashes de senha e PIN; ErrNotFound se não existir
+	RestoreUserData(data *UserBackup) error            // ErrAlreadyExists se o ID ou email da conta já existir
+}
+
+// UsageStore guarda o consumo de armazenamento e do assistente (cotas)
+type UsageStore interface {
+	// Uso de armazenamento (cotas)
+	GetUserUsage(userID string) (*UserUsage, error) // Itens criados pelo usuário e bytes de texto
+	ListTopUsage(limit int) ([]*UserUsage, error)   // Maiores consumidores (bytes, depois itens)
+
+	// Uso do assistente (orçamento diário de tokens)
+	GetAssistantUsage(userID, day string) (*AssistantUsage, error)                   // Zerado se não houver uso no dia
+	RecordAssistantUsage(userID, day string, tokens int, rejected bool) error        // Soma uma pergunta (ou recusa) ao dia
+	GetAssistantUsageStats(since string, topLimit int) (*AssistantUsageStats, error) // Totais desde o dia (admin)
+}
+
+// FeedbackStore guarda os feedbacks e as respostas da equipe
+type FeedbackStore interface {
 	// Feedback
 	CreateFeedback(f *Feedback) error
 	ListFeedbacks(status string, limit int) ([]*Feedback, error)
+	EachFeedback(from, to time.Time, fn func(*Feedback) error) error // Criados em [from, to), mais antigos primeiro, sem a conversa; para no primeiro erro de fn
 	UpdateFeedbackStatus(id, status, adminNote string) error
 	GetFeedbackStats() (total, pending int)
+	GetFeedback(id string) (*Feedback, error)                          // Com a conversa; ErrNotFound se não existir
+	ListFeedbacksByUser(userID string, limit int) ([]*Feedback, error) // Mais recentes primeiro, com a conversa
+	AddFeedbackReply(reply *FeedbackReply) error                       // ErrNotFound se o feedback não existir
+}
 
+// AnalyticsStore guarda os eventos de uso e gera os relatórios
+type AnalyticsStore interface {
 	// Analytics
 	TrackEvent(e *AnalyticsEvent) error
+	TrackEvents(events []*AnalyticsEvent) (int, error)           // Ignora client_event_id repetido do mesmo usuário; retorna quantos gravou
+	LinkAnonymousEvents(anonymousID, userID string) (int, error) // Atribui ao usuário os eventos anônimos do visitante
 	GetAnalyticsSummary() *AnalyticsSummary
+	RollupAnalytics() (int, error) // Consolida os dias fechados em analytics_daily (recalcula os últimos); retorna quantos dias novos
 	GetRecentEvents(limit int) ([]*AnalyticsEvent, error)
 	GetDailyStats(days int) ([]map[string]interface{}, error)
+	ListDailyStats(from, to time.Time) ([]*DailyStats, error)           // Um registro por dia de [from, to), inclusive os vazios
+	GetFunnel(from, to time.Time) (*AnalyticsFunnel, error)             // Usuários cadastrados em [from, to)
+	GetRetentionCohorts(from, to time.Time) ([]*RetentionCohort, error) // Coortes semanais dos cadastros em [from, to)
 
+	// Notas de preparo da caixa (uma por usuário e dia; a última do dia vale)
+	RecordCompletenessScore(userID string, day time.Time, score int) error
+	GetCompletenessTrend(from, to time.Time) ([]*CompletenessDay, error) // Média diária das notas em [from, to)
+}
+
+// ShareStore guarda os links compartilhados e a proteção dos PINs
+type ShareStore interface {
 	// Share Links (Compartilhamento com Guardiões)
 	CreateShareLink(link *ShareLink) error
 	GetShareLinkByToken(token string) (*ShareLink, error)
@@ -86,20 +342,123 @@ type Store interface {
 	UpdateShareLink(link *ShareLink) error
 	DeleteShareLink(userID, linkID string) error
 	RecordShareLinkAccess(access *ShareLinkAccess) error
+	ListShareLinkAccesses(userID, linkID string, limit int) ([]*ShareLinkAccess, error) // Mais recentes primeiro; ErrNotFound se o link não for do usuário
 	IncrementShareLinkUsage(linkID string) error
+	ClaimShareLinkUsage(linkID string) (bool, error) // Conta um uso só se o link ativo ainda tiver usos; false se esgotado
 
+	// PIN Attempts (proteção contra força bruta nos links com PIN)
+	GetPINAttempt(key string) (*PINAttempt, error)    // ErrNotFound se não houver falhas
+	RecordPINFailure(key string) (*PINAttempt, error) // Incrementa as falhas de forma atômica
+	ResetPINAttempts(key string) error
+
+	// Abertura do memorial (N de M pessoas de confiança confirmam)
+	GetMemorialConfig(userID string) (*MemorialConfig, error)                                                    // ErrNotFound sem configuração
+	SaveMemorialConfig(config *MemorialConfig) error                                                             // Cria ou substitui
+	DeleteMemorialConfig(userID string) error                                                                    // ErrNotFound sem configuração
+	CreateMemorialActivation(activation *MemorialActivation) error                                               // ErrAlreadyExists se já houver pedido pendente ou em espera
+	UpdateMemorialActivation(activation *MemorialActivation, from MemorialActivationStatus) error                // ErrNotFound; ErrVersionConflict se o estado salvo não for from
+	AddMemorialConfirmation(activationID string, confirmation MemorialConfirmation) (*MemorialActivation, error) // Atômico; repetida não duplica; ErrVersionConflict se não estiver pendente
+	GetLatestMemorialActivation(userID string) (*MemorialActivation, error)                                      // Mais recente; ErrNotFound se nunca houve
+	ListDueMemorialActivations(now time.Time, limit int) ([]*MemorialActivation, error)                          // Espera vencida (waiting) ou prazo vencido (pending)
+}
+
+// PasswordResetStore guarda os tokens de recuperação de senha
+type PasswordResetStore interface {
 	// Password Reset (Recuperação de Senha)
 	CreatePasswordResetToken(token *PasswordResetToken) error
 	GetPasswordResetToken(tokenHash string) (*PasswordResetToken, error)
 	MarkPasswordResetTokenUsed(tokenID string) error
 	CleanupExpiredPasswordResetTokens() error
+}
 
+// EmergencyStore guarda o protocolo de emergência
+type EmergencyStore interface {
 	// Emergency Protocol (Protocolo de Emergência)
 	GetEmergencyProtocol(userID string) (*EmergencyProtocol, error)
 	UpdateEmergencyProtocol(protocol *EmergencyProtocol) error
 
-	// Maintenance
-	CleanupOldLogs(retentionDays int) error
+	// Cartão de emergência (link público com os dados mínimos)
+	SaveEmergencyCard(card *EmergencyCard) error // Cria ou substitui o cartão do usuário
+	GetEmergencyCard(userID string) (*EmergencyCard, error)
+	GetEmergencyCardByToken(tokenHash string) (*EmergencyCard, error)
+	DeleteEmergencyCard(userID string) error
+	TouchEmergencyCard(userID string, accessedAt time.Time) error
+
+	// Escalonamento (pessoas de confiança chamadas em ordem até alguém confirmar)
+	GetEscalationChain(userID string) (*EscalationChain, error) // ErrNotFound sem lista
+	SaveEscalationChain(chain *EscalationChain) error           // Cria ou substitui
+	DeleteEscalationChain(userID string) error                  // ErrNotFound sem lista
+	CreateEscalation(escalation *EmergencyEscalation) error
+	UpdateEscalation(escalation *EmergencyEscalation, from EscalationStatus) error // ErrNotFound; ErrVersionConflict se o estado salvo não for from
+	GetLatestEscalation(userID string) (*EmergencyEscalation, error)               // Mais recente; ErrNotFound se nunca houve
+	GetEscalationByAckToken(tokenHash string) (*EmergencyEscalation, error)        // ErrNotFound
+	ListDueEscalations(now time.Time, limit int) ([]*EmergencyEscalation, error)   // Ativos com NextStepAt vencido
+}
+
+// WebhookStore guarda os webhooks e as entregas
+type WebhookStore interface {
+	// Webhooks (integrações de saída)
+	CreateWebhook(hook *Webhook) error
+	GetWebhook(userID, webhookID string) (*Webhook, error)
+	ListWebhooks(userID string) ([]*Webhook, error)
+	ListWebhooksForEvent(userID string, event WebhookEvent) ([]*Webhook, error) // Apenas ativos
+	UpdateWebhook(hook *Webhook) error
+	DeleteWebhook(userID, webhookID string) error
+	RecordWebhookResult(webhookID string, success bool) error // Atualiza falhas consecutivas
+	CreateWebhookDelivery(delivery *WebhookDelivery) error
+	UpdateWebhookDelivery(delivery *WebhookDelivery) error
+	ListWebhookDeliveries(webhookID string, limit int) ([]*WebhookDelivery, error)
+	ListDueWebhookDeliveries(now time.Time, limit int) ([]*WebhookDelivery, error)
+}
+
+// NotificationStore guarda os avisos e as inscrições Web Push
+type NotificationStore interface {
+	// Web Push (inscrições por dispositivo)
+	SavePushSubscription(sub *PushSubscription) error // Cria ou substitui (mesmo endpoint)
+	ListPushSubscriptions(userID string) ([]*PushSubscription, error)
+	DeletePushSubscription(userID, endpoint string) error
+	TouchPushSubscription(id string, usedAt time.Time) error // Atualiza último envio com sucesso
+
+	// Central de notificações
+	CreateNotification(n *Notification) error
+	UpdateNotificationChannels(notificationID string, channels []string) error
+	ListNotifications(userID string, unreadOnly bool, params *PaginationParams) (*PaginatedResult[*Notification], error)
+	CountUnreadNotifications(userID string) (int, error)
+	MarkNotificationRead(userID, notificationID string) error
+	MarkAllNotificationsRead(userID string) error
+}
+
+// SystemStore reúne a configuração do sistema, a manutenção e a idempotência
+type SystemStore interface {
+	// Configuração do sistema (system_config: chave/valor)
+	ListSystemConfig(prefix string) (map[string]string, error)
+	SetSystemConfig(key, value string) error
+	DeleteSystemConfig(key string) error // ErrNotFound se a chave não existir
+
+	// Maintenance: cria as partições dos logs e remove tokens vencidos
+	CleanupExpiredRecords() error
+
+	// Retenção por classe de dados (internal/retention)
+	PurgeRetention(class RetentionClass, days int) (int64, error) // Remove o que passou de days dias; retorna quantos
+	CreateRetentionRun(run *RetentionRun) error
+	ListRetentionRuns(limit int) ([]*RetentionRun, error) // Mais recentes primeiro
+
+	// Página de status: incidentes publicados pelos admins e disponibilidade diária
+	CreateStatusIncident(incident *StatusIncident) error
+	GetStatusIncident(id string) (*StatusIncident, error)
+	UpdateStatusIncident(incident *StatusIncident) error              // ErrNotFound se não existir
+	DeleteStatusIncident(id string) error                             // ErrNotFound se não existir
+	ListStatusIncidents(since time.Time) ([]*StatusIncident, error)   // Abertos + resolvidos desde since, mais recentes primeiro
+	RecordStatusCheck(component string, day time.Time, ok bool) error // Soma uma verificação ao dia (UTC)
+	ListStatusUptime(since time.Time) ([]*StatusUptimeDay, error)     // Dias desde since, em ordem
+
+	// Auditoria (audit_log) e atividade do painel admin
+	RecordAudit(entry *AuditEntry) error
+	ListActivity(params *ActivityParams) (*PaginatedResult[*ActivityEntry], error) // audit_log + analytics_events, mais recentes primeiro
+
+	// Health check: testa a conexão e resume as migrações
+	// (nil no storage em memória, que não tem migrações)
+	CheckHealth(ctx context.Context) (*MigrationSummary, error)
 
 	// Idempotência
 	RegisterIdempotencyKey(userID, key, resourceType, resourceID string) (existingID string, inserted bool, err error)
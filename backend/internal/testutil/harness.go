@@ -0,0 +1,286 @@
+// =============================================================================
+// FAMLI - Harness de Testes de Integração
+// =============================================================================
+// Sobe o router completo (internal/server) com MemoryStore num
+// httptest.Server, para testar rotas, middlewares e handlers juntos.
+//
+//	h := testutil.New(t, nil)
+//	maria := h.Register("maria@example.com", "Maria")
+//	maria.Post("/api/box/items", map[string]string{"title": "Banco", "type": "info"}).
+//		Expect(http.StatusCreated)
+//
+// Cada Client tem seus cookies (sessão) e um IP próprio (X-Forwarded-For),
+// para que os limites de login e cadastro por IP não interfiram entre testes.
+// =============================================================================
+
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"famli/internal/config"
+	"famli/internal/server"
+	"famli/internal/storage"
+)
+
+// DefaultPassword é a senha usada por Register (atende à política de senhas)
+const DefaultPassword = "Famli-Teste-2024!"
+
+// Harness é um servidor Famli completo para testes
+type Harness struct {
+	t      testing.TB
+	Server *httptest.Server
+	App    *server.Server // Aplicação montada (ex: App.MountFrontend nos testes do SPA)
+	Store  *storage.MemoryStore
+	Config *config.Config
+	nextIP atomic.Uint32
+}
+
+// New sobe o servidor com a configuração de desenvolvimento
+// env sobrescreve variáveis (ex: {"QUOTA_MAX_ITEMS": "2"}); o ambiente real
+// do processo não é lido. O servidor é encerrado no fim do teste.
+func New(t testing.TB, env map[string]string) *Harness {
+	t.Helper()
+
+	cfg, err := config.LoadFrom(func(key string) string { return env[key] })
+	if err != nil {
+		t.Fatalf("testutil: configuração inválida: %v", err)
+	}
+
+	store := storage.NewMemoryStore()
+	srv := server.New(cfg, store, "Memory")
+	ts := httptest.NewServer(srv.Router)
+	t.Cleanup(ts.Close)
+
+	return &Harness{t: t, Server: ts, App: srv, Store: store, Config: cfg}
+}
+
+// URL monta o endereço completo de um caminho (ex: "/api/health")
+func (h *Harness) URL(path string) string {
+	return h.Server.URL + path
+}
+
+// =============================================================================
+// CLIENTES
+// =============================================================================
+
+// Client faz requisições com sessão (cookies) e IP próprios
+type Client struct {
+	h      *Harness
+	http   *http.Client
+	ip     string
+	header http.Header
+
+	// User é a conta logada (nil para visitantes)
+	User *storage.User
+}
+
+// NewClient cria um visitante sem sessão
+func (h *Harness) NewClient() *Client {
+	jar, _ := cookiejar.New(nil)
+	n := h.nextIP.Add(1)
+	return &Client{
+		h:      h,
+		http:   &http.Client{Jar: jar},
+		ip:     fmt.Sprintf("10.%d.%d.%d", (n>>16)&0xff, (n>>8)&0xff, n&0xff),
+		header: make(http.Header),
+	}
+}
+
+// Register cria a conta com DefaultPassword e retorna o cliente já logado
+func (h *Harness) Register(email, name string) *Client {
+	h.t.Helper()
+	c := h.NewClient()
+	c.Post("/api/auth/register", map[string]interface{}{
+		"email":        email,
+		"password":     DefaultPassword,
+		"name":         name,
+		"accept_terms": true,
+	}).Expect(http.StatusCreated)
+	c.loadUser(email)
+	return c
+}
+
+// Login autentica o cliente e retorna a resposta (sem verificar o status)
+func (c *Client) Login(email, password string) *Response {
+	c.h.t.Helper()
+	resp := c.Post("/api/auth/login", map[string]string{"email": email, "password": password})
+	if resp.Status == http.StatusOK {
+		c.loadUser(email)
+	}
+	return resp
+}
+
+// loadUser guarda a conta logada, lida direto do storage
+func (c *Client) loadUser(email string) {
+	c.h.t.Helper()
+	user, ok := c.h.Store.GetUserByEmail(email)
+	if !ok {
+		c.h.t.Fatalf("testutil: conta %s não encontrada após o login", email)
+	}
+	c.User = user
+}
+
+// SetHeader define um header enviado em todas as requisições do cliente
+func (c *Client) SetHeader(key, value string) {
+	c.header.Set(key, value)
+}
+
+// WithHeader retorna uma cópia do cliente (mesma sessão) que envia também
+// o header key, ex: c.WithHeader("If-Match", etag).Put(...)
+func (c *Client) WithHeader(key, value string) *Client {
+	copied := *c
+	copied.header = c.header.Clone()
+	copied.header.Set(key, value)
+	return &copied
+}
+
+// Get faz GET
+func (c *Client) Get(path string) *Response { return c.Do(http.MethodGet, path, nil) }
+
+// Post faz POST com corpo JSON (nil = sem corpo)
+func (c *Client) Post(path string, body interface{}) *Response {
+	return c.Do(http.MethodPost, path, body)
+}
+
+// Put faz PUT com corpo JSON
+func (c *Client) Put(path string, body interface{}) *Response {
+	return c.Do(http.MethodPut, path, body)
+}
+
+// Patch faz PATCH com corpo JSON
+func (c *Client) Patch(path string, body interface{}) *Response {
+	return c.Do(http.MethodPatch, path, body)
+}
+
+// Delete faz DELETE
+func (c *Client) Delete(path string) *Response { return c.Do(http.MethodDelete, path, nil) }
+
+// Do envia a requisição; falhas de rede interrompem o teste
+func (c *Client) Do(method, path string, body interface{}) *Response {
+	c.h.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			c.h.t.Fatalf("testutil: corpo inválido para %s %s: %v", method, path, err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	contentType := ""
+	if body != nil {
+		contentType = "application/json"
+	}
+	return c.send(method, path, reader, contentType)
+}
+
+// Upload envia um arquivo por multipart (campo field), com a sessão do cliente
+func (c *Client) Upload(path, field, filename string, data []byte) *Response {
+	c.h.t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile(field, filename)
+	if err != nil {
+		c.h.t.Fatalf("testutil: multipart inválido para %s: %v", path, err)
+	}
+	part.Write(data)
+	form.Close()
+
+	return c.send(http.MethodPost, path, &body, form.FormDataContentType())
+}
+
+// send envia a requisição com os headers e o IP do cliente
+func (c *Client) send(method, path string, reader io.Reader, contentType string) *Response {
+	c.h.t.Helper()
+
+	req, err := http.NewRequest(method, c.h.URL(path), reader)
+	if err != nil {
+		c.h.t.Fatalf("testutil: requisição inválida %s %s: %v", method, path, err)
+	}
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("X-Forwarded-For", c.ip)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		c.h.t.Fatalf("testutil: %s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.h.t.Fatalf("testutil: erro ao ler resposta de %s %s: %v", method, path, err)
+	}
+
+	return &Response{t: c.h.t, Request: method + " " + path, Status: resp.StatusCode, Header: resp.Header, Body: data}
+}
+
+// =============================================================================
+// RESPOSTAS
+// =============================================================================
+
+// Response é uma resposta já lida
+type Response struct {
+	t       testing.TB
+	Request string // "MÉTODO caminho", para as mensagens de erro
+	Status  int
+	Header  http.Header
+	Body    []byte
+}
+
+// Expect interrompe o teste se o status for diferente do esperado
+func (r *Response) Expect(status int) *Response {
+	r.t.Helper()
+	if r.Status != status {
+		r.t.Fatalf("%s: status %d, esperado %d\n%s", r.Request, r.Status, status, r.Body)
+	}
+	return r
+}
+
+// ExpectError interrompe o teste se o status ou o código do erro
+// (campo "code" da resposta padrão de erro) forem diferentes dos esperados
+func (r *Response) ExpectError(status int, code string) *Response {
+	r.t.Helper()
+	r.Expect(status)
+	if got := r.String("code"); got != code {
+		r.t.Fatalf("%s: código %q, esperado %q\n%s", r.Request, got, code, r.Body)
+	}
+	return r
+}
+
+// JSON decodifica o corpo em v
+func (r *Response) JSON(v interface{}) {
+	r.t.Helper()
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		r.t.Fatalf("%s: corpo não é JSON válido: %v\n%s", r.Request, err, r.Body)
+	}
+}
+
+// Map decodifica o corpo como objeto JSON
+func (r *Response) Map() map[string]interface{} {
+	r.t.Helper()
+	var m map[string]interface{}
+	r.JSON(&m)
+	return m
+}
+
+// String retorna o campo de texto key do objeto JSON ("" se ausente)
+func (r *Response) String(key string) string {
+	r.t.Helper()
+	value, _ := r.Map()[key].(string)
+	return value
+}
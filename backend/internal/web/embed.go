@@ -0,0 +1,31 @@
+//go:build embed
+
+// =============================================================================
+// FAMLI - Frontend Embutido no Binário
+// =============================================================================
+// Com -tags embed, o frontend buildado vai dentro do binário e o STATIC_DIR é
+// ignorado. O build precisa copiar frontend/dist para internal/web/dist antes
+// (go:embed não lê fora do módulo); use make backend-build-embed.
+// =============================================================================
+
+package web
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed all:dist
+var distFS embed.FS
+
+// Embedded retorna o frontend embutido no binário
+func Embedded() (fs.FS, bool) {
+	dist, err := fs.Sub(distFS, "dist")
+	if err != nil {
+		return nil, false
+	}
+	if _, err := fs.Stat(dist, "index.html"); err != nil {
+		return nil, false
+	}
+	return dist, true
+}
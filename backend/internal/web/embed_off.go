@@ -0,0 +1,11 @@
+//go:build !embed
+
+package web
+
+import "io/fs"
+
+// Embedded retorna o frontend embutido no binário
+// Sem -tags embed não há frontend embutido: o SPA é lido do STATIC_DIR.
+func Embedded() (fs.FS, bool) {
+	return nil, false
+}
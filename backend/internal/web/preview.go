@@ -0,0 +1,73 @@
+package web
+
+import (
+	"html"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"famli/internal/i18n"
+)
+
+// =============================================================================
+// PRÉVIA DE LINKS
+// =============================================================================
+// Apps de mensagem (WhatsApp, Telegram...) leem as meta tags Open Graph da
+// página para montar a prévia do link. Nas páginas dos links compartilhados o
+// index.html sai com título e descrição próprios, sem dados do conteúdo.
+
+// Meta são os textos da prévia de uma página
+type Meta struct {
+	Title       string
+	Description string
+	URL         string // Endereço público da página (og:url)
+}
+
+// Meta tags trocadas na prévia (name/property e o atributo content)
+var (
+	titleTag        = regexp.MustCompile(`<title>[^<]*</title>`)
+	metaTitle       = metaTag("og:title", "og:image:alt", "twitter:title", "twitter:image:alt")
+	metaDesc        = metaTag("description", "og:description", "twitter:description")
+	metaURL         = metaTag("og:url", "twitter:url")
+	metaRobots      = metaTag("robots")
+	metaContentAttr = regexp.MustCompile(`content="[^"]*"`)
+)
+
+// metaTag reconhece as meta tags com um dos nomes (name= ou property=)
+func metaTag(names ...string) *regexp.Regexp {
+	for i, name := range names {
+		names[i] = regexp.QuoteMeta(name)
+	}
+	return regexp.MustCompile(`<meta (?:name|property)="(?:` + strings.Join(names, "|") + `)" content="[^"]*"\s*/?>`)
+}
+
+// ServeMeta responde com o index.html no idioma do visitante, com os textos
+// da prévia no título, na descrição e nas tags Open Graph e Twitter. A página
+// varia por link: não vai para o cache nem para os buscadores.
+func (s *SPA) ServeMeta(w http.ResponseWriter, r *http.Request, meta Meta) {
+	body := s.page(i18n.GetPreferredLanguage(r)).body
+	body = titleTag.ReplaceAllLiteral(body, []byte("<title>"+html.EscapeString(meta.Title)+"</title>"))
+	body = replaceContent(body, metaTitle, meta.Title)
+	body = replaceContent(body, metaDesc, meta.Description)
+	body = replaceContent(body, metaRobots, "noindex, nofollow")
+	if meta.URL != "" {
+		body = replaceContent(body, metaURL, meta.URL)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", cacheNever)
+	w.Header().Set("X-Robots-Tag", "noindex, nofollow")
+	w.Header().Add("Vary", "Accept-Language")
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		w.Write(body)
+	}
+}
+
+// replaceContent troca o atributo content das meta tags reconhecidas por re
+func replaceContent(body []byte, re *regexp.Regexp, value string) []byte {
+	content := []byte(`content="` + html.EscapeString(value) + `"`)
+	return re.ReplaceAllFunc(body, func(tag []byte) []byte {
+		return metaContentAttr.ReplaceAllLiteral(tag, content)
+	})
+}
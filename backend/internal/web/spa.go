@@ -0,0 +1,227 @@
+// =============================================================================
+// FAMLI - Frontend (SPA)
+// =============================================================================
+// Serve o frontend buildado (frontend/dist), lido do disco (STATIC_DIR) ou
+// embutido no binário (build com -tags embed, ver embed.go):
+//
+// - Rotas de página (sem extensão) e arquivos inexistentes recebem o
+//   index.html com as meta tags no idioma do visitante. Cada idioma é montado
+//   uma única vez e fica em memória, com ETag; o navegador revalida a cada
+//   acesso (no-cache) e recebe 304 se nada mudou
+// - Arquivos com hash no nome (/assets/*, workbox-*.js) são imutáveis e ficam
+//   em cache por um ano; service worker, manifest e version.json nunca
+// - Arquivos pré-comprimidos no build (.br, .gz) são servidos quando o
+//   navegador aceita; os demais são comprimidos na hora pelo middleware de
+//   compressão do servidor
+// =============================================================================
+
+package web
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"famli/internal/i18n"
+)
+
+// Cache-Control de cada tipo de arquivo
+const (
+	cacheImmutable  = "public, max-age=31536000, immutable"
+	cacheDefault    = "public, max-age=86400"
+	cacheRevalidate = "no-cache"
+	cacheNever      = "no-store, no-cache, must-revalidate"
+)
+
+// hashedName reconhece nomes gerados com hash do conteúdo (ex: index-BXk3a9Qz.js)
+var hashedName = regexp.MustCompile(`[-.][A-Za-z0-9_]{8,}\.(js|css|woff2?|png|jpe?g|svg|webp)$`)
+
+// neverCached são arquivos que precisam chegar sempre atualizados
+var neverCached = map[string]bool{
+	"/sw.js":                true,
+	"/service-worker.js":    true,
+	"/push-sw.js":           true,
+	"/manifest.webmanifest": true,
+	"/version.json":         true,
+}
+
+// precompressed são as codificações geradas no build, em ordem de preferência
+var precompressed = []struct {
+	encoding  string
+	extension string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// SPA serve os arquivos do frontend
+type SPA struct {
+	fsys       fs.FS
+	index      []byte
+	modTime    time.Time
+	fileServer http.Handler
+
+	mu    sync.Mutex
+	pages map[string]*page // idioma → index.html localizado
+}
+
+// page é o index.html já localizado
+type page struct {
+	body []byte
+	etag string
+}
+
+// NewSPA serve o frontend do diretório dir (modo de desenvolvimento)
+func NewSPA(dir string) (*SPA, error) {
+	return NewSPAFS(os.DirFS(dir))
+}
+
+// NewSPAFS serve o frontend de fsys, que deve ter o index.html na raiz
+func NewSPAFS(fsys fs.FS) (*SPA, error) {
+	index, err := fs.ReadFile(fsys, "index.html")
+	if err != nil {
+		return nil, err
+	}
+	// Arquivos embutidos não têm data: usa o início do processo
+	modTime := time.Now()
+	if info, err := fs.Stat(fsys, "index.html"); err == nil && !info.ModTime().IsZero() {
+		modTime = info.ModTime()
+	}
+
+	return &SPA{
+		fsys:       fsys,
+		index:      index,
+		modTime:    modTime,
+		fileServer: http.FileServer(http.FS(fsys)),
+		pages:      make(map[string]*page),
+	}, nil
+}
+
+// ServeHTTP implementa http.Handler
+func (s *SPA) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	urlPath := path.Clean("/" + r.URL.Path)
+
+	// Se termina em / ou não tem extensão, é uma rota de página SPA
+	isPageRoute := urlPath == "/" ||
+		(!strings.Contains(path.Base(urlPath), ".") &&
+			!strings.HasPrefix(urlPath, "/assets/") &&
+			!strings.HasPrefix(urlPath, "/icons/"))
+
+	// Caminho dentro do fs.FS (sem a barra inicial)
+	filePath := strings.TrimPrefix(urlPath, "/")
+	if isPageRoute || !isFile(s.fsys, filePath) || urlPath == "/index.html" {
+		s.serveIndex(w, r)
+		return
+	}
+
+	w.Header().Set("Cache-Control", cacheControl(urlPath))
+	if s.servePrecompressed(w, r, urlPath, filePath) {
+		return
+	}
+	s.fileServer.ServeHTTP(w, r)
+}
+
+// serveIndex responde com o index.html no idioma do visitante
+func (s *SPA) serveIndex(w http.ResponseWriter, r *http.Request) {
+	p := s.page(i18n.GetPreferredLanguage(r))
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", cacheRevalidate)
+	w.Header().Add("Vary", "Accept-Language")
+	w.Header().Set("ETag", p.etag)
+	http.ServeContent(w, r, "index.html", s.modTime, bytes.NewReader(p.body))
+}
+
+// page retorna o index.html do idioma, montando na primeira vez
+func (s *SPA) page(lang string) *page {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p, ok := s.pages[lang]; ok {
+		return p
+	}
+	body := []byte(i18n.InjectMetaTags(string(s.index), lang))
+	sum := sha256.Sum256(body)
+	p := &page{body: body, etag: `"` + hex.EncodeToString(sum[:8]) + `"`}
+	s.pages[lang] = p
+	return p
+}
+
+// servePrecompressed serve a versão .br ou .gz gerada no build, se existir
+// e o navegador aceitar. Retorna false para servir o arquivo original.
+func (s *SPA) servePrecompressed(w http.ResponseWriter, r *http.Request, urlPath, filePath string) bool {
+	contentType := mime.TypeByExtension(path.Ext(urlPath))
+	if contentType == "" {
+		return false
+	}
+
+	accepted := r.Header.Get("Accept-Encoding")
+	for _, pc := range precompressed {
+		if !acceptsEncoding(accepted, pc.encoding) {
+			continue
+		}
+		f, err := s.fsys.Open(filePath + pc.extension)
+		if err != nil {
+			continue
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil || info.IsDir() {
+			continue
+		}
+		// os.File e os arquivos do embed.FS implementam Seek
+		content, ok := f.(io.ReadSeeker)
+		if !ok {
+			continue
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Encoding", pc.encoding)
+		w.Header().Add("Vary", "Accept-Encoding")
+		http.ServeContent(w, r, urlPath, info.ModTime(), content)
+		return true
+	}
+	return false
+}
+
+// cacheControl define o Cache-Control de um arquivo estático
+func cacheControl(urlPath string) string {
+	switch {
+	case neverCached[urlPath]:
+		return cacheNever
+	case strings.HasPrefix(urlPath, "/assets/") || hashedName.MatchString(urlPath):
+		return cacheImmutable
+	default:
+		return cacheDefault
+	}
+}
+
+// acceptsEncoding verifica se o Accept-Encoding inclui a codificação
+// (ignora as que vierem com q=0)
+func acceptsEncoding(header, encoding string) bool {
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), encoding) {
+			continue
+		}
+		q := strings.ReplaceAll(params, " ", "")
+		return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+	}
+	return false
+}
+
+// isFile verifica se o caminho é um arquivo existente em fsys
+func isFile(fsys fs.FS, name string) bool {
+	info, err := fs.Stat(fsys, name)
+	return err == nil && !info.IsDir()
+}
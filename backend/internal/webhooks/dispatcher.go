@@ -0,0 +1,410 @@
+// =============================================================================
+// FAMLI - Webhooks de Saída
+// =============================================================================
+// Envia eventos da conta para URLs configuradas pelo usuário.
+//
+// Eventos:
+// - item.created: item criado na caixa
+// - guardian.added: pessoa de confiança adicionada
+// - emergency.activated: primeiro acesso a um link de emergência
+// - share.accessed: link compartilhado acessado
+// - ping: teste manual (POST /api/webhooks/{id}/test)
+//
+// Cada entrega é um POST JSON com os headers:
+// - X-Famli-Event: nome do evento
+// - X-Famli-Delivery: ID da entrega (use para deduplicar)
+// - X-Famli-Signature: t=<unix>,v1=<hex(HMAC-SHA256(segredo, "<t>.<corpo>"))>
+//
+// Entrega "pelo menos uma vez": respostas fora de 2xx (ou erro de rede) são
+// retentadas com backoff exponencial (1min, 5min, 30min, 2h, 12h).
+// Os payloads não incluem conteúdo dos itens, apenas identificadores e metadados.
+//
+// Proteção contra SSRF: apenas HTTPS e endereços públicos (em desenvolvimento,
+// HTTP e localhost são aceitos para testes).
+// =============================================================================
+
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"famli/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// retryBackoff define a espera antes de cada nova tentativa
+var retryBackoff = []time.Duration{
+	1 * time.Minute,
+	5 * time.Minute,
+	30 * time.Minute,
+	2 * time.Hour,
+	12 * time.Hour,
+}
+
+const (
+	// maxAttempts é o total de tentativas por entrega (1 imediata + retentativas)
+	maxAttempts = 6
+
+	// maxConsecutiveFailures desativa o webhook após muitas falhas seguidas
+	maxConsecutiveFailures = 50
+
+	// pollInterval é a frequência com que o worker procura retentativas vencidas
+	pollInterval = 30 * time.Second
+
+	// pollBatchSize limita quantas retentativas são processadas por ciclo
+	pollBatchSize = 50
+
+	// requestTimeout limita o tempo de cada tentativa de entrega
+	requestTimeout = 10 * time.Second
+
+	// maxErrorLength limita o tamanho do erro gravado no log de entregas
+	maxErrorLength = 300
+)
+
+// ErrInvalidURL indica uma URL de webhook não permitida
+var ErrInvalidURL = errors.New("invalid webhook url")
+
+// errBlockedAddress indica tentativa de conexão a um endereço interno
+var errBlockedAddress = errors.New("endereço de destino não permitido")
+
+// Dispatcher cria e entrega os eventos de webhook
+type Dispatcher struct {
+	store         storage.WebhookStore
+	client        *http.Client
+	allowInsecure bool // Aceita HTTP e endereços locais (apenas desenvolvimento)
+}
+
+// NewDispatcher cria um dispatcher sobre o store
+func NewDispatcher(store storage.WebhookStore, allowInsecure bool) *Dispatcher {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	if !allowInsecure {
+		dialer.Control = blockPrivateAddresses
+	}
+
+	return &Dispatcher{
+		store:         store,
+		allowInsecure: allowInsecure,
+		client: &http.Client{
+			Timeout: requestTimeout,
+			Transport: &http.Transport{
+				Proxy:               nil,
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: 5 * time.Second,
+				MaxIdleConns:        20,
+				IdleConnTimeout:     60 * time.Second,
+			},
+			// Não seguir redirecionamentos (evita desvio para endereços internos)
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// ValidateURL verifica se a URL pode ser usada como destino de webhook
+func (d *Dispatcher) ValidateURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" || parsed.User != nil {
+		return ErrInvalidURL
+	}
+	if len(raw) > 500 {
+		return ErrInvalidURL
+	}
+
+	switch parsed.Scheme {
+	case "https":
+	case "http":
+		if !d.allowInsecure {
+			return ErrInvalidURL
+		}
+	default:
+		return ErrInvalidURL
+	}
+
+	if d.allowInsecure {
+		return nil
+	}
+
+	// IP literal: validar já no cadastro (hostnames são validados na conexão)
+	if ip := net.ParseIP(parsed.Hostname()); ip != nil && !isPublicIP(ip) {
+		return ErrInvalidURL
+	}
+	if parsed.Hostname() == "localhost" {
+		return ErrInvalidURL
+	}
+	return nil
+}
+
+// Emit enfileira o evento para todos os webhooks ativos do usuário que o assinam
+// Não bloqueia a requisição: a busca e a entrega acontecem em background.
+func (d *Dispatcher) Emit(userID string, event storage.WebhookEvent, data interface{}) {
+	go func() {
+		hooks, err := d.store.ListWebhooksForEvent(userID, event)
+		if err != nil {
+			log.Printf("[Webhooks] Erro ao listar webhooks: %v", err)
+			return
+		}
+		for _, hook := range hooks {
+			if _, err := d.Send(hook, event, data); err != nil {
+				log.Printf("[Webhooks] Erro ao enfileirar %s: %v", event, err)
+			}
+		}
+	}()
+}
+
+// Send registra uma entrega para o webhook e faz a primeira tentativa em background
+func (d *Dispatcher) Send(hook *storage.Webhook, event storage.WebhookEvent, data interface{}) (*storage.WebhookDelivery, error) {
+	now := time.Now()
+	deliveryID := "whd_" + uuid.New().String()
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"id":         deliveryID,
+		"event":      event,
+		"created_at": now.UTC(),
+		"data":       data,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Se o processo cair antes da primeira tentativa, o worker assume em retryBackoff[0]
+	nextAttempt := now.Add(retryBackoff[0])
+	delivery := &storage.WebhookDelivery{
+		ID:            deliveryID,
+		WebhookID:     hook.ID,
+		UserID:        hook.UserID,
+		Event:         event,
+		Payload:       string(payload),
+		Status:        storage.WebhookDeliveryPending,
+		NextAttemptAt: &nextAttempt,
+		CreatedAt:     now,
+	}
+	if err := d.store.CreateWebhookDelivery(delivery); err != nil {
+		return nil, err
+	}
+
+	// Cópia para a tentativa em background (o chamador pode serializar a original)
+	pending := *delivery
+	go d.attempt(hook, &pending)
+	return delivery, nil
+}
+
+// Start inicia o worker de retentativas (encerra quando ctx é cancelado)
+func (d *Dispatcher) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				d.retryDue()
+			}
+		}
+	}()
+}
+
+// retryDue processa as entregas pendentes cuja próxima tentativa já venceu
+func (d *Dispatcher) retryDue() {
+	due, err := d.store.ListDueWebhookDeliveries(time.Now(), pollBatchSize)
+	if err != nil {
+		log.Printf("[Webhooks] Erro ao buscar retentativas: %v", err)
+		return
+	}
+
+	for _, delivery := range due {
+		hook, err := d.store.GetWebhook(delivery.UserID, delivery.WebhookID)
+		if err != nil || !hook.IsActive {
+			// Webhook removido ou desativado: encerrar a entrega
+			delivery.Status = storage.WebhookDeliveryFailed
+			delivery.NextAttemptAt = nil
+			delivery.Error = "webhook removido ou desativado"
+			d.store.UpdateWebhookDelivery(delivery)
+			continue
+		}
+		d.attempt(hook, delivery)
+	}
+}
+
+// attempt faz uma tentativa de entrega e agenda a próxima em caso de falha
+func (d *Dispatcher) attempt(hook *storage.Webhook, delivery *storage.WebhookDelivery) {
+	statusCode, err := d.post(hook, delivery)
+	now := time.Now()
+
+	delivery.Attempts++
+	delivery.ResponseCode = statusCode
+	success := err == nil
+
+	if success {
+		delivery.Status = storage.WebhookDeliverySuccess
+		delivery.Error = ""
+		delivery.NextAttemptAt = nil
+		delivery.DeliveredAt = &now
+	} else {
+		delivery.Error = truncate(err.Error(), maxErrorLength)
+		if delivery.Attempts >= maxAttempts {
+			delivery.Status = storage.WebhookDeliveryFailed
+			delivery.NextAttemptAt = nil
+		} else {
+			next := now.Add(retryBackoff[delivery.Attempts-1])
+			delivery.NextAttemptAt = &next
+		}
+	}
+
+	if err := d.store.UpdateWebhookDelivery(delivery); err != nil {
+		log.Printf("[Webhooks] Erro ao atualizar entrega %s: %v", delivery.ID, err)
+	}
+	if err := d.store.RecordWebhookResult(hook.ID, success); err != nil {
+		log.Printf("[Webhooks] Erro ao registrar resultado do webhook %s: %v", hook.ID, err)
+	}
+
+	// Endpoint abandonado: desativar para parar de gerar entregas
+	if !success && hook.FailureCount+1 >= maxConsecutiveFailures {
+		hook.IsActive = false
+		if err := d.store.UpdateWebhook(hook); err == nil {
+			log.Printf("[Webhooks] Webhook %s desativado após %d falhas seguidas", hook.ID, maxConsecutiveFailures)
+		}
+	}
+}
+
+// post envia o payload assinado e retorna o status HTTP recebido
+func (d *Dispatcher) post(hook *storage.Webhook, delivery *storage.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Famli-Webhooks/1.0")
+	req.Header.Set("X-Famli-Event", string(delivery.Event))
+	req.Header.Set("X-Famli-Delivery", delivery.ID)
+	req.Header.Set("X-Famli-Signature", Sign(hook.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("resposta HTTP %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// =============================================================================
+// ASSINATURA
+// =============================================================================
+
+// Sign gera o header X-Famli-Signature para o corpo enviado
+//
+// Para verificar no receptor: recalcule HMAC-SHA256(segredo, "<t>.<corpo>"),
+// compare com v1 em tempo constante e rejeite timestamps muito antigos.
+func Sign(secret string, timestamp int64, body []byte) string {
+	ts := strconv.FormatInt(timestamp, 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// GenerateSecret cria um novo segredo de assinatura
+func GenerateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// =============================================================================
+// PROTEÇÃO CONTRA SSRF
+// =============================================================================
+
+// blockPrivateAddresses impede conexões a endereços internos (após resolução DNS)
+func blockPrivateAddresses(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicIP(ip) {
+		return errBlockedAddress
+	}
+	return nil
+}
+
+// isPublicIP verifica se o IP é roteável publicamente
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	// 100.64.0.0/10 (CGNAT) também é usado por redes internas de provedores
+	if ip4 := ip.To4(); ip4 != nil && ip4[0] == 100 && ip4[1]&0xC0 == 64 {
+		return false
+	}
+	return true
+}
+
+// truncate limita o tamanho de uma string
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max]
+}
+
+// =============================================================================
+// INSTÂNCIA GLOBAL
+// =============================================================================
+
+var (
+	defaultDispatcher   *Dispatcher
+	defaultDispatcherMu sync.RWMutex
+)
+
+// Init define o dispatcher global usado por Emit
+func Init(store storage.WebhookStore, allowInsecure bool) *Dispatcher {
+	dispatcher := NewDispatcher(store, allowInsecure)
+	defaultDispatcherMu.Lock()
+	defaultDispatcher = dispatcher
+	defaultDispatcherMu.Unlock()
+	return dispatcher
+}
+
+// Emit envia o evento pelo dispatcher global (não faz nada sem Init)
+func Emit(userID string, event storage.WebhookEvent, data interface{}) {
+	defaultDispatcherMu.RLock()
+	dispatcher := defaultDispatcher
+	defaultDispatcherMu.RUnlock()
+
+	if dispatcher == nil || userID == "" {
+		return
+	}
+	dispatcher.Emit(userID, event, data)
+}
@@ -0,0 +1,337 @@
+// =============================================================================
+// FAMLI - Handler de Webhooks
+// =============================================================================
+// Endpoints (usuário autenticado):
+// - GET    /api/webhooks                 - lista webhooks e eventos disponíveis
+// - POST   /api/webhooks                 - cria webhook (segredo retornado uma vez)
+// - PUT    /api/webhooks/{id}            - altera URL, eventos, status ou rotaciona o segredo
+// - DELETE /api/webhooks/{id}            - remove webhook
+// - GET    /api/webhooks/{id}/deliveries - últimas entregas (log)
+// - POST   /api/webhooks/{id}/test       - envia um evento "ping"
+// =============================================================================
+
+package webhooks
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/i18n"
+	"famli/internal/security"
+	"famli/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxWebhooksPerUser limita quantos webhooks cada usuário pode cadastrar
+	maxWebhooksPerUser = 10
+
+	// deliveriesPageSize é quantas entregas o log retorna
+	deliveriesPageSize = 50
+)
+
+// Handler gerencia os webhooks do usuário
+type Handler struct {
+	store       storage.WebhookStore
+	dispatcher  *Dispatcher
+	auditLogger *security.AuditLogger
+}
+
+// NewHandler cria uma nova instância do handler de webhooks
+func NewHandler(store storage.WebhookStore, dispatcher *Dispatcher) *Handler {
+	return &Handler{
+		store:       store,
+		dispatcher:  dispatcher,
+		auditLogger: security.GetAuditLogger(),
+	}
+}
+
+// webhookPayload é o corpo de POST e PUT /api/webhooks
+type webhookPayload struct {
+	URL          string                 `json:"url"`
+	Events       []storage.WebhookEvent `json:"events"`
+	Description  string                 `json:"description"`
+	IsActive     *bool                  `json:"is_active"`
+	RotateSecret bool                   `json:"rotate_secret"`
+}
+
+// List retorna os webhooks do usuário
+//
+// Endpoint: GET /api/webhooks
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	hooks, err := h.store.ListWebhooks(auth.GetUserID(r))
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "webhooks.list_error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"webhooks": hooks,
+		"events":   storage.WebhookEvents,
+	})
+}
+
+// Create cadastra um novo webhook
+//
+// Endpoint: POST /api/webhooks
+//
+// Body: {"url": "https://...", "events": ["item.created"], "description": "Zapier"}
+// Resposta: {"webhook": {...}, "secret": "whsec_..."} (o segredo não é exibido novamente)
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+
+	var payload webhookPayload
+	if err := security.DecodeJSONStrict(r.Body, &payload); err != nil {
+		apierror.WriteDecodeError(w, r, err, "webhooks.invalid_data")
+		return
+	}
+	if errKey := h.validate(&payload); errKey != "" {
+		apierror.Write(w, r, http.StatusBadRequest, errKey)
+		return
+	}
+
+	existing, err := h.store.ListWebhooks(userID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "webhooks.save_error")
+		return
+	}
+	if len(existing) >= maxWebhooksPerUser {
+		apierror.Write(w, r, http.StatusBadRequest, "webhooks.limit_reached")
+		return
+	}
+
+	secret, err := GenerateSecret()
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "webhooks.save_error")
+		return
+	}
+
+	now := time.Now()
+	hook := &storage.Webhook{
+		ID:          "whk_" + uuid.New().String(),
+		UserID:      userID,
+		URL:         payload.URL,
+		Secret:      secret,
+		Events:      payload.Events,
+		Description: payload.Description,
+		IsActive:    true,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := h.store.CreateWebhook(hook); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "webhooks.save_error")
+		return
+	}
+
+	h.logChange(r, hook, "create")
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"webhook": hook,
+		"secret":  secret,
+	})
+}
+
+// Update altera um webhook existente
+//
+// Endpoint: PUT /api/webhooks/{id}
+//
+// Body: {"url": "...", "events": [...], "description": "...", "is_active": true, "rotate_secret": false}
+// Com rotate_secret=true, a resposta inclui o novo segredo.
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	hook, ok := h.findWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	var payload webhookPayload
+	if err := security.DecodeJSONStrict(r.Body, &payload); err != nil {
+		apierror.WriteDecodeError(w, r, err, "webhooks.invalid_data")
+		return
+	}
+	if errKey := h.validate(&payload); errKey != "" {
+		apierror.Write(w, r, http.StatusBadRequest, errKey)
+		return
+	}
+
+	hook.URL = payload.URL
+	hook.Events = payload.Events
+	hook.Description = payload.Description
+	if payload.IsActive != nil {
+		if *payload.IsActive && !hook.IsActive {
+			// Reativação manual zera o contador de falhas
+			hook.FailureCount = 0
+		}
+		hook.IsActive = *payload.IsActive
+	}
+
+	response := map[string]interface{}{"webhook": hook}
+	if payload.RotateSecret {
+		secret, err := GenerateSecret()
+		if err != nil {
+			apierror.Write(w, r, http.StatusInternalServerError, "webhooks.save_error")
+			return
+		}
+		hook.Secret = secret
+		response["secret"] = secret
+	}
+
+	if err := h.store.UpdateWebhook(hook); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "webhooks.save_error")
+		return
+	}
+
+	h.logChange(r, hook, "update")
+	writeJSON(w, http.StatusOK, response)
+}
+
+// Delete remove um webhook e seu log de entregas
+//
+// Endpoint: DELETE /api/webhooks/{id}
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	hook, ok := h.findWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.store.DeleteWebhook(hook.UserID, hook.ID); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "webhooks.save_error")
+		return
+	}
+
+	h.logChange(r, hook, "delete")
+	writeJSON(w, http.StatusOK, map[string]string{"message": i18n.Tr(r, "webhooks.deleted")})
+}
+
+// Deliveries retorna as últimas entregas de um webhook
+//
+// Endpoint: GET /api/webhooks/{id}/deliveries
+func (h *Handler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	hook, ok := h.findWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.store.ListWebhookDeliveries(hook.ID, deliveriesPageSize)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "webhooks.list_error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"deliveries": deliveries})
+}
+
+// Test envia um evento "ping" para o webhook
+//
+// Endpoint: POST /api/webhooks/{id}/test
+//
+// A entrega é assíncrona: consulte /deliveries para ver o resultado.
+func (h *Handler) Test(w http.ResponseWriter, r *http.Request) {
+	hook, ok := h.findWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.dispatcher.Send(hook, storage.WebhookPing, map[string]string{"webhook_id": hook.ID})
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "webhooks.test_error")
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{"delivery": delivery})
+}
+
+// =============================================================================
+// FUNÇÕES AUXILIARES
+// =============================================================================
+
+// findWebhook carrega o webhook da URL (escrevendo 404 se não pertencer ao usuário)
+func (h *Handler) findWebhook(w http.ResponseWriter, r *http.Request) (*storage.Webhook, bool) {
+	hook, err := h.store.GetWebhook(auth.GetUserID(r), chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.WriteStorage(w, r, err, apierror.StorageKeys{NotFound: "webhooks.not_found", Internal: "webhooks.list_error"})
+		return nil, false
+	}
+	return hook, true
+}
+
+// validate normaliza o payload e retorna a chave de tradução do erro (vazia se válido)
+func (h *Handler) validate(payload *webhookPayload) string {
+	payload.URL = strings.TrimSpace(payload.URL)
+	payload.Description = strings.TrimSpace(payload.Description)
+
+	if err := h.dispatcher.ValidateURL(payload.URL); err != nil {
+		return "webhooks.invalid_url"
+	}
+	if len(payload.Description) > 200 {
+		return "webhooks.invalid_data"
+	}
+	if len(payload.Events) == 0 {
+		return "webhooks.invalid_events"
+	}
+
+	seen := make(map[storage.WebhookEvent]bool, len(payload.Events))
+	events := make([]storage.WebhookEvent, 0, len(payload.Events))
+	for _, event := range payload.Events {
+		if !isSubscribable(event) {
+			return "webhooks.invalid_events"
+		}
+		if !seen[event] {
+			seen[event] = true
+			events = append(events, event)
+		}
+	}
+	payload.Events = events
+	return ""
+}
+
+// isSubscribable verifica se o evento pode ser assinado
+func isSubscribable(event storage.WebhookEvent) bool {
+	for _, e := range storage.WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// logChange registra a alteração no audit log (sem URL completa nem segredo)
+func (h *Handler) logChange(r *http.Request, hook *storage.Webhook, action string) {
+	h.auditLogger.Log(security.AuditEvent{
+		Type:     security.EventWebhookChanged,
+		Severity: security.SeverityInfo,
+		UserID:   hook.UserID,
+		ClientIP: security.GetClientIP(r),
+		Resource: "webhook:" + hook.ID,
+		Action:   action,
+		Result:   "success",
+		Details: map[string]interface{}{
+			"host":      urlHost(hook.URL),
+			"events":    hook.Events,
+			"is_active": hook.IsActive,
+		},
+	})
+}
+
+// urlHost retorna apenas o host da URL (caminhos podem conter tokens)
+func urlHost(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return parsed.Host
+}
+
+// writeJSON escreve resposta JSON
+func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
+	security.SetJSONHeaders(w)
+	w.WriteHeader(status)
+	if payload != nil {
+		json.NewEncoder(w).Encode(payload)
+	}
+}
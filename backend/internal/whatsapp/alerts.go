@@ -0,0 +1,283 @@
+// =============================================================================
+// FAMLI - Avisos aos Guardiões
+// =============================================================================
+// Alertas importantes (ex: o primeiro acesso a um link de emergência) avisam
+// todas as pessoas de confiança com acesso ativo; recados do dono (POST
+// /api/guardians/notify) vão só aos guardiões escolhidos. Cada guardião
+// recebe um registro de entrega (storage.GuardianAlert) com o resultado de
+// cada canal:
+//
+//	canal preferido → demais canais (WhatsApp → SMS → email)
+//
+// Se nenhum canal entregar, o aviso é retentado com backoff exponencial
+// (1min, 5min, 30min, 2h, 12h) pelo worker iniciado em StartAlerts. O dono
+// acompanha as entregas em GET /api/guardians/alerts.
+// =============================================================================
+
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"famli/internal/i18n"
+	"famli/internal/ids"
+	"famli/internal/storage"
+)
+
+// alertBackoff define a espera antes de cada nova tentativa
+var alertBackoff = []time.Duration{
+	1 * time.Minute,
+	5 * time.Minute,
+	30 * time.Minute,
+	2 * time.Hour,
+	12 * time.Hour,
+}
+
+const (
+	// maxAlertAttempts é o total de tentativas por aviso (1 imediata + retentativas)
+	maxAlertAttempts = 6
+
+	// alertPollInterval é a frequência com que o worker procura retentativas vencidas
+	alertPollInterval = 30 * time.Second
+
+	// alertBatchSize limita quantas retentativas são processadas por ciclo
+	alertBatchSize = 50
+
+	// maxAlertErrorLength limita o tamanho dos erros gravados
+	maxAlertErrorLength = 300
+)
+
+// errNoContact indica um guardião sem telefone nem email
+var errNoContact = errors.New("guardião sem telefone ou email")
+
+// NotifyEmergency avisa os guardiões de que a emergência foi acionada: em
+// ordem, se o dono tiver uma lista de escalonamento (ver escalation.go), ou
+// todos de uma vez
+// Não bloqueia: os envios acontecem em background.
+func (s *Service) NotifyEmergency(userID string) {
+	go func() {
+		owner, ok := s.store.GetUserByID(userID)
+		if !ok {
+			return
+		}
+		if s.startEscalation(owner) {
+			return
+		}
+		locale := i18n.UserLocale(owner.Locale, nil)
+		message := i18n.Format(locale, "guardian.emergency_alert_message", i18n.Vars{"owner": owner.Name})
+		if err := s.NotifyGuardians(userID, storage.GuardianAlertEmergency, message); err != nil {
+			log.Printf("[WhatsApp] Erro ao avisar os guardiões de %s: %v", userID, err)
+		}
+	}()
+}
+
+// NotifyGuardians notifica os guardiões de um usuário
+// Usado para alertas importantes (ex: protocolo de emergência)
+//
+// Cada guardião é avisado pelo canal preferido (notify_channel). Se o envio
+// falhar, tenta os demais canais disponíveis: WhatsApp → SMS → email. Sem
+// sucesso em nenhum, o aviso fica pendente para o worker de retentativas.
+func (s *Service) NotifyGuardians(userID string, event storage.GuardianAlertEvent, message string) error {
+	guardians, err := s.store.GetGuardians(userID)
+	if err != nil {
+		return err
+	}
+	locale := s.ownerLocale(userID)
+
+	for _, guardian := range guardians {
+		if guardian.AccessDisabledAt != nil {
+			continue
+		}
+
+		alert := newAlert(userID, guardian.ID, event, message, nil)
+		if err := s.store.CreateGuardianAlert(alert); err != nil {
+			log.Printf("[WhatsApp] Erro ao registrar aviso ao guardião %s: %v", guardian.ID, err)
+			continue
+		}
+		s.attemptAlert(guardian, alert, locale)
+	}
+
+	return nil
+}
+
+// MessageGuardian registra um recado do dono ao guardião e tenta a entrega em
+// background. channels restringe e ordena os canais (vazio = preferência do
+// guardião); as retentativas usam os mesmos canais.
+func (s *Service) MessageGuardian(userID string, guardian *storage.Guardian, message string, channels []storage.NotifyChannel) (*storage.GuardianAlert, error) {
+	return s.deliverInBackground(guardian, newAlert(userID, guardian.ID, storage.GuardianAlertOwnerMessage, message, channels))
+}
+
+// AlertGuardian registra um aviso do sistema ao guardião (ex: andamento da
+// abertura do memorial) e tenta a entrega em background, nos canais de
+// preferência do guardião
+func (s *Service) AlertGuardian(userID string, guardian *storage.Guardian, event storage.GuardianAlertEvent, message string) error {
+	_, err := s.deliverInBackground(guardian, newAlert(userID, guardian.ID, event, message, nil))
+	return err
+}
+
+// deliverInBackground registra o aviso e faz a primeira tentativa sem
+// bloquear (as retentativas ficam com o worker)
+func (s *Service) deliverInBackground(guardian *storage.Guardian, alert *storage.GuardianAlert) (*storage.GuardianAlert, error) {
+	if err := s.store.CreateGuardianAlert(alert); err != nil {
+		return nil, err
+	}
+
+	// O worker atualiza uma cópia: o aviso retornado fica como foi registrado
+	attempt := *alert
+	attempt.Log = []storage.GuardianAlertAttempt{}
+	go s.attemptAlert(guardian, &attempt, s.ownerLocale(alert.UserID))
+	return alert, nil
+}
+
+// newAlert monta um aviso pendente
+// Se o processo cair antes da primeira tentativa, o worker assume em alertBackoff[0].
+func newAlert(userID, guardianID string, event storage.GuardianAlertEvent, message string, channels []storage.NotifyChannel) *storage.GuardianAlert {
+	now := time.Now()
+	nextAttempt := now.Add(alertBackoff[0])
+	return &storage.GuardianAlert{
+		ID:            ids.New(ids.GuardianAlert),
+		UserID:        userID,
+		GuardianID:    guardianID,
+		Event:         event,
+		Message:       message,
+		Channels:      channels,
+		Status:        storage.GuardianAlertPending,
+		Log:           []storage.GuardianAlertAttempt{},
+		NextAttemptAt: &nextAttempt,
+		CreatedAt:     now,
+	}
+}
+
+// StartAlerts inicia o worker de retentativas dos avisos e de escalonamento
+// (encerra quando ctx é cancelado)
+func (s *Service) StartAlerts(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(alertPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.retryDueAlerts()
+				s.advanceDueEscalations()
+			}
+		}
+	}()
+}
+
+// retryDueAlerts processa os avisos pendentes cuja próxima tentativa já venceu
+func (s *Service) retryDueAlerts() {
+	due, err := s.store.ListDueGuardianAlerts(time.Now(), alertBatchSize)
+	if err != nil {
+		log.Printf("[WhatsApp] Erro ao buscar retentativas de avisos: %v", err)
+		return
+	}
+
+	for _, alert := range due {
+		guardian := s.findGuardian(alert.UserID, alert.GuardianID)
+		if guardian == nil || guardian.AccessDisabledAt != nil {
+			// Guardião removido ou desativado: encerrar o aviso
+			alert.Status = storage.GuardianAlertFailed
+			alert.NextAttemptAt = nil
+			alert.Error = "guardião removido ou com acesso desativado"
+			s.store.UpdateGuardianAlert(alert)
+			continue
+		}
+		s.attemptAlert(guardian, alert, s.ownerLocale(alert.UserID))
+	}
+}
+
+// attemptAlert faz uma tentativa (todos os canais) e agenda a próxima em caso de falha
+func (s *Service) attemptAlert(guardian *storage.Guardian, alert *storage.GuardianAlert, locale string) {
+	alert.Attempts++
+	lastErr := errNoContact
+
+	for _, channel := range guardian.AlertChannels(alert.Channels) {
+		err := s.sendAlert(guardian, channel, alert.Message, locale)
+		at := time.Now()
+		entry := storage.GuardianAlertAttempt{Channel: channel, Success: err == nil, At: at}
+		if err != nil {
+			log.Printf("[WhatsApp] Falha ao notificar guardião %s via %s: %v", guardian.ID, channel, err)
+			entry.Error = truncateError(err)
+			alert.Log = append(alert.Log, entry)
+			lastErr = err
+			continue
+		}
+
+		alert.Log = append(alert.Log, entry)
+		alert.Status = storage.GuardianAlertDelivered
+		alert.Channel = channel
+		alert.Error = ""
+		alert.NextAttemptAt = nil
+		alert.DeliveredAt = &at
+		break
+	}
+
+	if alert.Status != storage.GuardianAlertDelivered {
+		alert.Error = truncateError(lastErr)
+		if alert.Attempts >= maxAlertAttempts || errors.Is(lastErr, errNoContact) {
+			alert.Status = storage.GuardianAlertFailed
+			alert.NextAttemptAt = nil
+			log.Printf("[WhatsApp] Não foi possível notificar o guardião %s", guardian.ID)
+		} else {
+			next := time.Now().Add(alertBackoff[alert.Attempts-1])
+			alert.NextAttemptAt = &next
+		}
+	}
+
+	if err := s.store.UpdateGuardianAlert(alert); err != nil {
+		log.Printf("[WhatsApp] Erro ao atualizar aviso %s: %v", alert.ID, err)
+	}
+}
+
+// sendAlert envia o aviso ao guardião por um canal
+func (s *Service) sendAlert(guardian *storage.Guardian, channel storage.NotifyChannel, message, locale string) error {
+	switch channel {
+	case storage.NotifyWhatsApp:
+		if s.client == nil {
+			return errNotConfigured
+		}
+		return s.client.SendMessage(guardian.Phone, message)
+	case storage.NotifySMS:
+		return s.SendSMS(guardian.Phone, message)
+	case storage.NotifyEmail:
+		return s.mailer.SendNotice(guardian.Email, guardian.Name, message, locale)
+	}
+	return errNoContact
+}
+
+// findGuardian busca o guardião do usuário (nil se não existir mais)
+func (s *Service) findGuardian(userID, guardianID string) *storage.Guardian {
+	guardians, err := s.store.GetGuardians(userID)
+	if err != nil {
+		return nil
+	}
+	for _, guardian := range guardians {
+		if guardian.ID == guardianID {
+			return guardian
+		}
+	}
+	return nil
+}
+
+// ownerLocale é o idioma dos emails aos guardiões (o do dono da caixa)
+// Guardiões não têm idioma salvo.
+func (s *Service) ownerLocale(userID string) string {
+	if owner, ok := s.store.GetUserByID(userID); ok {
+		return i18n.UserLocale(owner.Locale, nil)
+	}
+	return i18n.DefaultLocale
+}
+
+// truncateError limita o tamanho do erro gravado no aviso
+func truncateError(err error) string {
+	message := err.Error()
+	if len(message) > maxAlertErrorLength {
+		return message[:maxAlertErrorLength]
+	}
+	return message
+}
@@ -0,0 +1,66 @@
+// =============================================================================
+// FAMLI - Canal WhatsApp
+// =============================================================================
+// Adapta o cliente Twilio às interfaces conversation.Channel e
+// conversation.InteractiveChannel (botões e listas, ver interactive.go).
+// Sem credenciais configuradas, os envios são apenas registrados em log.
+// =============================================================================
+
+package whatsapp
+
+import (
+	"errors"
+	"log"
+
+	"famli/internal/conversation"
+)
+
+// channel implementa conversation.InteractiveChannel sobre o cliente Twilio
+type channel struct {
+	// client é nil quando a integração não está configurada
+	client *TwilioClient
+}
+
+// Name retorna o nome do canal exibido ao usuário
+func (c *channel) Name() string {
+	return "WhatsApp"
+}
+
+// Send envia uma mensagem de texto para um número
+func (c *channel) Send(to, body string) error {
+	if c.client == nil {
+		log.Printf("[WhatsApp] Cliente não configurado, mensagem não enviada")
+		return nil
+	}
+	return c.client.SendMessage(to, body)
+}
+
+// SendPrompt envia uma pergunta com botões ou lista (conversation.InteractiveChannel)
+// Sem cliente configurado retorna erro, e o motor responde com o texto numerado.
+func (c *channel) SendPrompt(to string, prompt conversation.Prompt) error {
+	if c.client == nil {
+		return errNotConfigured
+	}
+
+	options := make([]InteractiveOption, len(prompt.Options))
+	for i, opt := range prompt.Options {
+		options[i] = InteractiveOption{ID: opt.ID, Title: opt.Title}
+	}
+
+	return c.client.SendInteractive(to, InteractiveMessage{
+		Body:     prompt.Body,
+		Options:  options,
+		List:     prompt.Style == conversation.PromptList,
+		Button:   prompt.Button,
+		Fallback: prompt.Text,
+		Language: prompt.Locale,
+	})
+}
+
+// FetchMedia baixa uma mídia recebida pelo webhook
+func (c *channel) FetchMedia(mediaURL string) ([]byte, string, error) {
+	if c.client == nil {
+		return nil, "", errors.New("cliente WhatsApp não configurado")
+	}
+	return c.client.FetchMedia(mediaURL)
+}
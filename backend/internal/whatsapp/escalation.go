@@ -0,0 +1,173 @@
+// =============================================================================
+// FAMLI - Escalonamento de Emergência
+// =============================================================================
+// Com uma lista de escalonamento (PUT /api/emergency-escalation), a emergência
+// não avisa todos os guardiões de uma vez: a primeira pessoa da lista recebe
+// o aviso com um link de confirmação e tem ack_timeout_minutes para
+// confirmar. Sem confirmação, o worker chama a próxima, e assim por diante:
+//
+//	1ª pessoa → (prazo) → 2ª pessoa → (prazo) → ... → fim da lista (exhausted)
+//
+// Pessoas removidas, desativadas ou sem nenhum contato são puladas na hora.
+// A confirmação (GET/POST /api/emergency-ack/{token}, ver emergency/
+// escalation.go) encerra a caminhada; ao fim da lista sem confirmação o dono
+// é avisado. Sem lista (ou se ninguém da lista existir mais), vale o aviso a
+// todos os guardiões.
+// =============================================================================
+
+package whatsapp
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"famli/internal/i18n"
+	"famli/internal/ids"
+	"famli/internal/notifications"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+// escalationBatchSize limita quantos escalonamentos vencidos são processados por ciclo
+const escalationBatchSize = 50
+
+// ackPath é o caminho do link de confirmação enviado a cada pessoa da lista
+const ackPath = "/api/emergency-ack/"
+
+// startEscalation inicia a caminhada pela lista de escalonamento do dono
+// Retorna false se não houver lista (ou ninguém dela puder ser chamado): aí
+// todos os guardiões são avisados.
+func (s *Service) startEscalation(owner *storage.User) bool {
+	chain, err := s.store.GetEscalationChain(owner.ID)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("[WhatsApp] Erro ao buscar lista de escalonamento de %s: %v", owner.ID, err)
+		}
+		return false
+	}
+
+	// Um escalonamento em andamento continua (o link de emergência só avisa uma vez)
+	if latest, err := s.store.GetLatestEscalation(owner.ID); err == nil && latest.Status == storage.EscalationActive {
+		return true
+	}
+
+	escalation := &storage.EmergencyEscalation{
+		ID:                ids.New(ids.Escalation),
+		UserID:            owner.ID,
+		Status:            storage.EscalationActive,
+		CurrentStep:       -1,
+		AckTimeoutMinutes: chain.AckTimeoutMinutes,
+	}
+	for _, guardianID := range chain.GuardianIDs {
+		if guardian := s.findGuardian(owner.ID, guardianID); guardian != nil && guardian.AccessDisabledAt == nil {
+			escalation.Steps = append(escalation.Steps, storage.EscalationStep{GuardianID: guardianID})
+		}
+	}
+	if len(escalation.Steps) == 0 {
+		return false
+	}
+
+	if err := s.store.CreateEscalation(escalation); err != nil {
+		log.Printf("[WhatsApp] Erro ao iniciar escalonamento de %s: %v", owner.ID, err)
+		return false
+	}
+	s.advanceEscalation(escalation, owner)
+	return true
+}
+
+// advanceDueEscalations chama a próxima pessoa dos escalonamentos sem
+// confirmação no prazo
+func (s *Service) advanceDueEscalations() {
+	due, err := s.store.ListDueEscalations(time.Now(), escalationBatchSize)
+	if err != nil {
+		log.Printf("[WhatsApp] Erro ao buscar escalonamentos vencidos: %v", err)
+		return
+	}
+
+	for _, escalation := range due {
+		owner, ok := s.store.GetUserByID(escalation.UserID)
+		if !ok {
+			continue
+		}
+		s.advanceEscalation(escalation, owner)
+	}
+}
+
+// advanceEscalation chama a próxima pessoa da lista que puder ser avisada
+// O escalonamento é salvo antes de cada envio: se alguém confirmou nesse
+// meio-tempo, o UPDATE condicional falha e ninguém mais é chamado.
+func (s *Service) advanceEscalation(escalation *storage.EmergencyEscalation, owner *storage.User) {
+	locale := i18n.UserLocale(owner.Locale, nil)
+
+	for next := escalation.CurrentStep + 1; ; next++ {
+		escalation.CurrentStep = next
+		if next >= len(escalation.Steps) {
+			s.exhaustEscalation(escalation)
+			return
+		}
+
+		step := &escalation.Steps[next]
+		guardian := s.findGuardian(owner.ID, step.GuardianID)
+		if guardian == nil || guardian.AccessDisabledAt != nil {
+			step.Skipped = true
+			continue
+		}
+
+		token := security.GenerateShareToken()
+		message := i18n.Format(locale, "guardian.emergency_escalation_message", i18n.Vars{
+			"owner":   owner.Name,
+			"link":    s.ackURL(token),
+			"minutes": escalation.AckTimeoutMinutes,
+		})
+		alert := newAlert(owner.ID, guardian.ID, storage.GuardianAlertEscalation, message, nil)
+
+		now := time.Now()
+		nextStepAt := now.Add(time.Duration(escalation.AckTimeoutMinutes) * time.Minute)
+		step.AckTokenHash = security.HashToken(token)
+		step.AlertID = alert.ID
+		step.NotifiedAt = &now
+		escalation.NextStepAt = &nextStepAt
+		if err := s.store.UpdateEscalation(escalation, storage.EscalationActive); err != nil {
+			if !errors.Is(err, storage.ErrVersionConflict) {
+				log.Printf("[WhatsApp] Erro ao atualizar escalonamento %s: %v", escalation.ID, err)
+			}
+			return
+		}
+
+		if err := s.store.CreateGuardianAlert(alert); err != nil {
+			log.Printf("[WhatsApp] Erro ao registrar aviso ao guardião %s: %v", guardian.ID, err)
+			return
+		}
+		s.attemptAlert(guardian, alert, locale)
+
+		// Sem nenhum contato, não adianta esperar o prazo
+		if alert.Status != storage.GuardianAlertFailed {
+			return
+		}
+		step.Skipped = true
+	}
+}
+
+// exhaustEscalation encerra a caminhada sem confirmação e avisa o dono
+func (s *Service) exhaustEscalation(escalation *storage.EmergencyEscalation) {
+	escalation.Status = storage.EscalationExhausted
+	escalation.NextStepAt = nil
+	if err := s.store.UpdateEscalation(escalation, storage.EscalationActive); err != nil {
+		if !errors.Is(err, storage.ErrVersionConflict) {
+			log.Printf("[WhatsApp] Erro ao encerrar escalonamento %s: %v", escalation.ID, err)
+		}
+		return
+	}
+	notifications.Notify(escalation.UserID, storage.NotificationEmergency, "notify.emergency_escalation_exhausted")
+}
+
+// ackURL monta o link de confirmação do aviso
+func (s *Service) ackURL(token string) string {
+	appURL := ""
+	if s.config != nil {
+		appURL = strings.TrimRight(s.config.AppURL, "/")
+	}
+	return appURL + ackPath + token
+}
@@ -7,6 +7,7 @@
 // - POST /api/whatsapp/webhook  - Recebe mensagens do Twilio
 // - GET  /api/whatsapp/webhook  - Validação do webhook (Twilio verification)
 // - POST /api/whatsapp/link     - Vincula número WhatsApp a uma conta Famli
+// - /api/whatsapp/links          - Vários números por conta (ver links.go)
 // - GET  /api/whatsapp/status   - Verifica status da integração
 //
 // Fluxo do Webhook:
@@ -21,11 +22,15 @@ package whatsapp
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"time"
 
+	"famli/internal/apierror"
 	"famli/internal/auth"
+	"famli/internal/i18n"
+	"famli/internal/storage"
 )
 
 // =============================================================================
@@ -89,7 +94,7 @@ func (h *Handler) Webhook(w http.ResponseWriter, r *http.Request) {
 	msg, err := ParseWebhookRequest(r)
 	if err != nil {
 		log.Printf("[WhatsApp] Erro ao parsear webhook: %v", err)
-		h.writeErrorTwiML(w, "Desculpe, não consegui entender sua mensagem.")
+		h.writeErrorTwiML(w, i18n.Tr(r, "whatsapp.not_understood"))
 		return
 	}
 
@@ -107,7 +112,13 @@ func (h *Handler) Webhook(w http.ResponseWriter, r *http.Request) {
 	response, err := h.service.ProcessMessage(msg)
 	if err != nil {
 		log.Printf("[WhatsApp] Erro ao processar mensagem: %v", err)
-		response = "Desculpe, tive um problema ao processar sua mensagem. Tente novamente."
+		response = i18n.T(h.service.Locale(msg.From), "whatsapp.process_error")
+	}
+
+	// Resposta já enviada pela API (pergunta com botões ou lista)
+	if response == "" {
+		h.writeEmptyTwiML(w)
+		return
 	}
 
 	// Enviar resposta como TwiML
@@ -128,90 +139,61 @@ func (h *Handler) WebhookVerify(w http.ResponseWriter, r *http.Request) {
 // VINCULAÇÃO DE CONTA
 // =============================================================================
 
-// LinkPayload é o payload para vincular um número WhatsApp
-type LinkPayload struct {
-	// Code é o código de 6 dígitos gerado pelo usuário no WhatsApp
-	Code string `json:"code"`
-
-	// PhoneNumber é o número de telefone a ser vinculado
-	PhoneNumber string `json:"phone_number"`
-}
-
 // Link vincula um número WhatsApp a uma conta Famli
 //
 // O usuário:
 // 1. Digita "vincular" no WhatsApp e recebe um código
-// 2. Acessa famli.me/configuracoes
+// 2. Acessa famli.me/perfil
 // 3. Digita o código para vincular
 //
+// Mantido por compatibilidade: o mesmo que POST /api/whatsapp/links (ver
+// links.go), com a resposta no formato antigo.
+//
 // Endpoint: POST /api/whatsapp/link
 // Autenticação: Requer JWT (usuário logado)
 // Body: { "code": "123456", "phone_number": "+5511999999999" }
 func (h *Handler) Link(w http.ResponseWriter, r *http.Request) {
-	// Obter ID do usuário do contexto (requer autenticação)
-	userID := auth.GetUserID(r)
-	if userID == "" {
-		writeJSONError(w, http.StatusUnauthorized, "Faça login para vincular seu WhatsApp")
-		return
-	}
-
-	// Parsear payload
-	var payload LinkPayload
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		writeJSONError(w, http.StatusBadRequest, "Dados inválidos")
+	var req LinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, i18n.Tr(r, "whatsapp.invalid_data"))
 		return
 	}
 
-	// Validar campos
-	if payload.PhoneNumber == "" {
-		writeJSONError(w, http.StatusBadRequest, "Número de telefone é obrigatório")
+	link, ok := h.linkPhone(w, r, &req)
+	if !ok {
 		return
 	}
 
-	// TODO: Validar código (implementar sistema de códigos com expiração)
-	// Por enquanto, aceitamos qualquer código para testes
-
-	// Vincular número ao usuário
-	h.service.LinkPhoneToUser(payload.PhoneNumber, userID)
-
-	// Enviar mensagem de confirmação no WhatsApp
-	go func() {
-		msg := "✅ *WhatsApp vinculado com sucesso!*\n\n" +
-			"Agora você pode me enviar:\n" +
-			"• Textos para guardar\n" +
-			"• Fotos e memórias\n" +
-			"• Áudios e documentos\n\n" +
-			"_Experimente: me envie algo para guardar!_ 💚"
-
-		if err := h.service.SendMessage(payload.PhoneNumber, msg); err != nil {
-			log.Printf("[WhatsApp] Erro ao enviar confirmação de vinculação: %v", err)
-		}
-	}()
-
-	// Responder sucesso
 	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
-		"message": "WhatsApp vinculado com sucesso!",
+		"message": i18n.Tr(r, "whatsapp.linked"),
+		"link":    link,
 	})
 }
 
-// Unlink desvincula o WhatsApp de uma conta Famli
+// Unlink desvincula todos os números WhatsApp da conta
+// Para desvincular só um número, use DELETE /api/whatsapp/links/{linkID}.
 //
 // Endpoint: DELETE /api/whatsapp/link
 // Autenticação: Requer JWT
 func (h *Handler) Unlink(w http.ResponseWriter, r *http.Request) {
 	userID := auth.GetUserID(r)
-	if userID == "" {
-		writeJSONError(w, http.StatusUnauthorized, "Faça login")
+
+	links, err := h.service.PhoneLinks(userID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "whatsapp.link_error")
 		return
 	}
-
-	// TODO: Implementar desvinculação
-	// Por enquanto, apenas retornamos sucesso
+	for _, link := range links {
+		if err := h.service.UnlinkPhone(userID, link.ID); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			apierror.Write(w, r, http.StatusInternalServerError, "whatsapp.link_error")
+			return
+		}
+	}
 
 	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
-		"message": "WhatsApp desvinculado",
+		"message": i18n.Tr(r, "whatsapp.unlinked"),
 	})
 }
 
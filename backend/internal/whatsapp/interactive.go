@@ -0,0 +1,269 @@
+// =============================================================================
+// FAMLI - Mensagens interativas do WhatsApp (botões e listas)
+// =============================================================================
+// O WhatsApp exibe botões de resposta rápida e listas de opções a partir de
+// modelos da Content API do Twilio. Cada formato (rótulos, botões, idioma) é
+// criado uma vez e reaproveitado; o texto da pergunta vai como variável {{1}}.
+//
+// Todo modelo inclui um "twilio/text" ({{2}}) com as opções numeradas, que o
+// Twilio entrega em canais sem suporte a mensagens interativas.
+//
+// Limites do WhatsApp:
+// - Botões: até 3, com até 20 caracteres
+// - Lista: até 10 itens, com até 24 caracteres; botão com até 20
+//
+// Documentação:
+// - https://www.twilio.com/docs/content/twilio-quick-reply
+// - https://www.twilio.com/docs/content/twiliolist-picker
+// =============================================================================
+
+package whatsapp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// contentAPIURL é o endpoint de criação de modelos da Content API
+const contentAPIURL = "https://content.twilio.com/v1/Content"
+
+// Limites das mensagens interativas do WhatsApp
+const (
+	maxQuickReplies    = 3
+	maxQuickReplyTitle = 20
+	maxListItems       = 10
+	maxListItemTitle   = 24
+	maxListButtonTitle = 20
+	maxInteractiveBody = 1024
+)
+
+// errInteractiveLimits indica opções fora dos limites do WhatsApp
+var errInteractiveLimits = errors.New("opções fora dos limites do WhatsApp")
+
+// InteractiveOption é um botão ou item de lista
+type InteractiveOption struct {
+	// ID é devolvido no webhook (ButtonPayload ou ListId) quando escolhido
+	ID string
+
+	// Title é o rótulo exibido
+	Title string
+}
+
+// InteractiveMessage é uma mensagem com botões de resposta rápida ou lista
+type InteractiveMessage struct {
+	// Body é o texto da pergunta
+	Body string
+
+	// Options são os botões (List = false) ou itens da lista (List = true)
+	Options []InteractiveOption
+
+	// List exibe as opções em uma lista; Button é o rótulo que abre a lista
+	List   bool
+	Button string
+
+	// Fallback é o texto enviado a canais sem suporte (opções numeradas)
+	Fallback string
+
+	// Language é o idioma do modelo (ex: "pt-BR", "en")
+	Language string
+}
+
+// validate confere os limites do WhatsApp antes de criar o modelo
+func (m *InteractiveMessage) validate() error {
+	if len(m.Options) == 0 || utf8.RuneCountInString(m.Body) > maxInteractiveBody {
+		return errInteractiveLimits
+	}
+
+	maxOptions, maxTitle := maxQuickReplies, maxQuickReplyTitle
+	if m.List {
+		maxOptions, maxTitle = maxListItems, maxListItemTitle
+		if m.Button == "" || utf8.RuneCountInString(m.Button) > maxListButtonTitle {
+			return errInteractiveLimits
+		}
+	}
+	if len(m.Options) > maxOptions {
+		return errInteractiveLimits
+	}
+	for _, opt := range m.Options {
+		if opt.ID == "" || opt.Title == "" || utf8.RuneCountInString(opt.Title) > maxTitle {
+			return errInteractiveLimits
+		}
+	}
+	return nil
+}
+
+// contentTypes monta os tipos do modelo (texto nas variáveis {{1}} e {{2}})
+func (m *InteractiveMessage) contentTypes() map[string]interface{} {
+	types := map[string]interface{}{
+		"twilio/text": map[string]string{"body": "{{2}}"},
+	}
+
+	if m.List {
+		items := make([]map[string]string, len(m.Options))
+		for i, opt := range m.Options {
+			items[i] = map[string]string{"id": opt.ID, "item": opt.Title}
+		}
+		types["twilio/list-picker"] = map[string]interface{}{
+			"body":   "{{1}}",
+			"button": m.Button,
+			"items":  items,
+		}
+		return types
+	}
+
+	actions := make([]map[string]string, len(m.Options))
+	for i, opt := range m.Options {
+		actions[i] = map[string]string{"id": opt.ID, "title": opt.Title}
+	}
+	types["twilio/quick-reply"] = map[string]interface{}{
+		"body":    "{{1}}",
+		"actions": actions,
+	}
+	return types
+}
+
+// SendInteractive envia uma mensagem com botões ou lista de opções
+//
+// Parâmetros:
+//   - to: número de destino (formato: +5511999999999)
+//   - msg: pergunta, opções e texto alternativo
+//
+// Retorna:
+//   - error: erro se as opções excederem os limites ou o envio falhar
+//     (quem chama deve responder com o texto numerado)
+func (c *TwilioClient) SendInteractive(to string, msg InteractiveMessage) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+
+	contentSid, err := c.contentSid(&msg)
+	if err != nil {
+		return err
+	}
+
+	if !strings.HasPrefix(to, "whatsapp:") {
+		to = "whatsapp:" + to
+	}
+
+	variables, err := json.Marshal(map[string]string{"1": msg.Body, "2": msg.Fallback})
+	if err != nil {
+		return fmt.Errorf("erro ao montar variáveis: %w", err)
+	}
+
+	apiURL := fmt.Sprintf(
+		"https://api.twilio.com/2010-04-01/Accounts/%s/Messages.json",
+		c.accountSid,
+	)
+
+	data := url.Values{}
+	data.Set("To", to)
+	data.Set("From", c.fromNumber)
+	data.Set("ContentSid", contentSid)
+	data.Set("ContentVariables", string(variables))
+
+	req, err := http.NewRequest("POST", apiURL, strings.NewReader(data.Encode()))
+	if err != nil {
+		return fmt.Errorf("erro ao criar requisição: %w", err)
+	}
+	req.SetBasicAuth(c.accountSid, c.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("erro ao enviar mensagem: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		_, _ = io.ReadAll(resp.Body)
+		log.Printf("[Twilio] Erro na API (interativa): status=%d", resp.StatusCode)
+		return fmt.Errorf("erro da API Twilio: status %d", resp.StatusCode)
+	}
+
+	log.Printf("[Twilio] Mensagem interativa enviada para %s", maskPhone(to))
+	return nil
+}
+
+// contentSid retorna o modelo da mensagem, criando-o na primeira vez
+func (c *TwilioClient) contentSid(msg *InteractiveMessage) (string, error) {
+	types := msg.contentTypes()
+
+	definition, err := json.Marshal(map[string]interface{}{
+		"language": msg.Language,
+		"types":    types,
+	})
+	if err != nil {
+		return "", fmt.Errorf("erro ao montar modelo: %w", err)
+	}
+	sum := sha256.Sum256(definition)
+	key := hex.EncodeToString(sum[:])
+
+	c.contentMu.Lock()
+	defer c.contentMu.Unlock()
+
+	if sid, ok := c.contentSids[key]; ok {
+		return sid, nil
+	}
+
+	sid, err := c.createContent("famli_"+key[:16], msg.Language, types)
+	if err != nil {
+		return "", err
+	}
+	c.contentSids[key] = sid
+	return sid, nil
+}
+
+// createContent cria um modelo na Content API e retorna o SID
+func (c *TwilioClient) createContent(name, language string, types map[string]interface{}) (string, error) {
+	if language == "" {
+		language = "pt_BR"
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"friendly_name": name,
+		"language":      strings.ReplaceAll(language, "-", "_"),
+		"variables":     map[string]string{"1": "Famli", "2": "Famli"},
+		"types":         types,
+	})
+	if err != nil {
+		return "", fmt.Errorf("erro ao montar modelo: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", contentAPIURL, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("erro ao criar requisição: %w", err)
+	}
+	req.SetBasicAuth(c.accountSid, c.authToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("erro ao criar modelo: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		_, _ = io.ReadAll(resp.Body)
+		log.Printf("[Twilio] Erro na Content API: status=%d", resp.StatusCode)
+		return "", fmt.Errorf("erro da Content API: status %d", resp.StatusCode)
+	}
+
+	var created struct {
+		Sid string `json:"sid"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil || created.Sid == "" {
+		return "", fmt.Errorf("resposta inválida da Content API")
+	}
+
+	log.Printf("[Twilio] Modelo interativo criado: %s", created.Sid)
+	return created.Sid, nil
+}
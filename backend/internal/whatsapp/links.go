@@ -0,0 +1,265 @@
+// =============================================================================
+// FAMLI - Números de WhatsApp Vinculados
+// =============================================================================
+// Famílias dividem aparelhos: uma conta pode ter vários números vinculados
+// (até maxPhoneLinks), e qualquer um deles conversa com a mesma Caixa. Os
+// avisos e resumos vão só para o número principal.
+//
+// Para vincular, a pessoa envia "vincular" pelo WhatsApp, recebe um código
+// (ver conversation/link.go) e o digita no perfil. Um número pertence a uma
+// única conta: se já estiver vinculado a outra, a resposta é 409.
+//
+// Endpoints:
+// - GET    /api/whatsapp/links                  - Lista os números da conta
+// - POST   /api/whatsapp/links                  - Vincula um número (código)
+// - POST   /api/whatsapp/links/{linkID}/primary - Define o número principal
+// - DELETE /api/whatsapp/links/{linkID}         - Desvincula um número
+// =============================================================================
+
+package whatsapp
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"famli/internal/apierror"
+	"famli/internal/auth"
+	"famli/internal/i18n"
+	"famli/internal/ids"
+	"famli/internal/security"
+	"famli/internal/storage"
+)
+
+const (
+	// maxPhoneLinks limita os números vinculados a uma conta
+	maxPhoneLinks = 5
+
+	// maxLinkLabelLength limita o apelido do número
+	maxLinkLabelLength = 60
+)
+
+var (
+	// errInvalidLinkCode indica código inexistente, vencido ou de outro número
+	errInvalidLinkCode = errors.New("código de vinculação inválido")
+
+	// errLinkedHere indica que o número já está vinculado a esta conta
+	errLinkedHere = errors.New("número já vinculado a esta conta")
+
+	// errTooManyLinks indica que a conta atingiu maxPhoneLinks
+	errTooManyLinks = errors.New("limite de números vinculados atingido")
+)
+
+// =============================================================================
+// SERVIÇO
+// =============================================================================
+
+// PhoneLinks lista os números vinculados ao usuário (principal primeiro)
+func (s *Service) PhoneLinks(userID string) ([]*storage.PhoneLink, error) {
+	return s.store.ListPhoneLinks(userID)
+}
+
+// LinkPhone confirma o código pedido pelo WhatsApp e vincula o número
+// phone (opcional) precisa ser o mesmo número que pediu o código.
+func (s *Service) LinkPhone(userID, code, phone, label string) (*storage.PhoneLink, error) {
+	address, ok := s.engine.RedeemLinkCode(strings.TrimSpace(code))
+	if !ok || (phone != "" && !samePhone(phone, address)) {
+		return nil, errInvalidLinkCode
+	}
+
+	if existing, err := s.store.GetPhoneLinkByPhone(address); err == nil {
+		if existing.UserID == userID {
+			return existing, errLinkedHere
+		}
+		return nil, storage.ErrAlreadyExists
+	}
+
+	links, err := s.store.ListPhoneLinks(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(links) >= maxPhoneLinks {
+		return nil, errTooManyLinks
+	}
+
+	now := time.Now().UTC()
+	link := &storage.PhoneLink{
+		ID:         ids.New(ids.PhoneLink),
+		UserID:     userID,
+		Phone:      address,
+		Label:      label,
+		VerifiedAt: now,
+		CreatedAt:  now,
+	}
+	if err := s.store.CreatePhoneLink(link); err != nil {
+		return nil, err
+	}
+	s.engine.LinkUser(address, userID)
+
+	log.Printf("[WhatsApp] Número %s vinculado ao usuário %s", maskPhone(address), userID)
+	return link, nil
+}
+
+// UnlinkPhone desvincula um número do usuário
+func (s *Service) UnlinkPhone(userID, linkID string) error {
+	links, err := s.store.ListPhoneLinks(userID)
+	if err != nil {
+		return err
+	}
+	for _, link := range links {
+		if link.ID != linkID {
+			continue
+		}
+		if err := s.store.DeletePhoneLink(userID, linkID); err != nil {
+			return err
+		}
+		s.engine.LinkUser(link.Phone, "")
+		log.Printf("[WhatsApp] Número %s desvinculado do usuário %s", maskPhone(link.Phone), userID)
+		return nil
+	}
+	return storage.ErrNotFound
+}
+
+// SetPrimaryPhone define o número que recebe avisos e resumos
+func (s *Service) SetPrimaryPhone(userID, linkID string) error {
+	return s.store.SetPrimaryPhoneLink(userID, linkID)
+}
+
+// samePhone compara dois números ignorando prefixo, espaços e pontuação
+func samePhone(a, b string) bool {
+	digits := func(phone string) string {
+		var out strings.Builder
+		for _, r := range cleanPhoneNumber(phone) {
+			if r >= '0' && r <= '9' {
+				out.WriteRune(r)
+			}
+		}
+		return out.String()
+	}
+	return digits(a) != "" && digits(a) == digits(b)
+}
+
+// =============================================================================
+// ENDPOINTS
+// =============================================================================
+
+// LinkRequest é o corpo de POST /api/whatsapp/links
+type LinkRequest struct {
+	Code  string `json:"code"`
+	Label string `json:"label"`
+
+	// PhoneNumber é opcional: se informado, precisa ser o número do código
+	PhoneNumber string `json:"phone_number"`
+}
+
+// ListLinks lista os números vinculados à conta
+//
+// Endpoint: GET /api/whatsapp/links
+func (h *Handler) ListLinks(w http.ResponseWriter, r *http.Request) {
+	links, err := h.service.PhoneLinks(auth.GetUserID(r))
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "whatsapp.link_error")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"links":     links,
+		"max_links": maxPhoneLinks,
+	})
+}
+
+// CreateLink vincula mais um número à conta
+//
+// Endpoint: POST /api/whatsapp/links
+// Body: {"code": "123456", "label": "Celular da Ana"}
+func (h *Handler) CreateLink(w http.ResponseWriter, r *http.Request) {
+	var req LinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "whatsapp.invalid_data")
+		return
+	}
+
+	link, ok := h.linkPhone(w, r, &req)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusCreated, link)
+}
+
+// linkPhone valida o pedido, vincula o número e envia a confirmação pelo
+// WhatsApp; em caso de erro, já responde
+func (h *Handler) linkPhone(w http.ResponseWriter, r *http.Request, req *LinkRequest) (*storage.PhoneLink, bool) {
+	userID := auth.GetUserID(r)
+	if strings.TrimSpace(req.Code) == "" {
+		apierror.Write(w, r, http.StatusBadRequest, "whatsapp.code_required")
+		return nil, false
+	}
+	label := security.SanitizeText(strings.TrimSpace(req.Label), maxLinkLabelLength)
+
+	link, err := h.service.LinkPhone(userID, req.Code, req.PhoneNumber, label)
+	switch {
+	case errors.Is(err, errInvalidLinkCode):
+		apierror.Write(w, r, http.StatusBadRequest, "whatsapp.invalid_code")
+		return nil, false
+	case errors.Is(err, errLinkedHere):
+		apierror.Write(w, r, http.StatusConflict, "whatsapp.phone_already_linked")
+		return nil, false
+	case errors.Is(err, storage.ErrAlreadyExists):
+		apierror.Write(w, r, http.StatusConflict, "whatsapp.phone_linked_elsewhere")
+		return nil, false
+	case errors.Is(err, errTooManyLinks):
+		apierror.Write(w, r, http.StatusBadRequest, "whatsapp.too_many_links")
+		return nil, false
+	case err != nil:
+		apierror.Write(w, r, http.StatusInternalServerError, "whatsapp.link_error")
+		return nil, false
+	}
+
+	// Confirmação no WhatsApp (no idioma do usuário), exceto para quem
+	// pediu para não receber mensagens
+	if !h.service.OptedOut(userID) {
+		msg := i18n.Tr(r, "whatsapp.linked_message")
+		go func() {
+			if err := h.service.SendMessage(link.Phone, msg); err != nil {
+				log.Printf("[WhatsApp] Erro ao enviar confirmação de vinculação: %v", err)
+			}
+		}()
+	}
+	return link, true
+}
+
+// SetPrimaryLink define o número que recebe avisos e resumos
+//
+// Endpoint: POST /api/whatsapp/links/{linkID}/primary
+func (h *Handler) SetPrimaryLink(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+	if err := h.service.SetPrimaryPhone(userID, chi.URLParam(r, "linkID")); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			apierror.Write(w, r, http.StatusNotFound, "whatsapp.link_not_found")
+			return
+		}
+		apierror.Write(w, r, http.StatusInternalServerError, "whatsapp.link_error")
+		return
+	}
+	h.ListLinks(w, r)
+}
+
+// DeleteLink desvincula um número da conta
+//
+// Endpoint: DELETE /api/whatsapp/links/{linkID}
+func (h *Handler) DeleteLink(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserID(r)
+	if err := h.service.UnlinkPhone(userID, chi.URLParam(r, "linkID")); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			apierror.Write(w, r, http.StatusNotFound, "whatsapp.link_not_found")
+			return
+		}
+		apierror.Write(w, r, http.StatusInternalServerError, "whatsapp.link_error")
+		return
+	}
+	h.ListLinks(w, r)
+}
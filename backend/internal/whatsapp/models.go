@@ -6,8 +6,8 @@
 //
 // Funcionalidades:
 // - Receber mensagens via webhook (Twilio)
-// - Processar texto, imagens e áudios
-// - Salvar conteúdo na Caixa Famli
+// - Encaminhar ao motor de conversas (internal/conversation), que processa
+//   texto, imagens e áudios e salva o conteúdo na Caixa Famli
 // - Enviar respostas e confirmações
 // - Notificar guardiões quando necessário
 // =============================================================================
@@ -77,10 +77,24 @@ type IncomingMessage struct {
 	// ProfileName é o nome do perfil do WhatsApp do remetente
 	ProfileName string `json:"profile_name,omitempty"`
 
+	// ButtonPayload é o ID do botão de resposta rápida tocado (se houver)
+	ButtonPayload string `json:"button_payload,omitempty"`
+
+	// ListID é o ID do item escolhido em uma lista de opções (se houver)
+	ListID string `json:"list_id,omitempty"`
+
 	// ReceivedAt é quando a mensagem foi recebida pelo nosso sistema
 	ReceivedAt time.Time `json:"received_at"`
 }
 
+// SelectedOption retorna o ID da opção escolhida em botão ou lista
+func (m *IncomingMessage) SelectedOption() string {
+	if m.ButtonPayload != "" {
+		return m.ButtonPayload
+	}
+	return m.ListID
+}
+
 // GetMessageType determina o tipo de mensagem baseado no conteúdo
 // Retorna o tipo apropriado para processamento
 func (m *IncomingMessage) GetMessageType() MessageType {
@@ -121,54 +135,6 @@ type OutgoingMessage struct {
 	MediaUrl string `json:"media_url,omitempty"`
 }
 
-// =============================================================================
-// SESSÃO DO USUÁRIO
-// =============================================================================
-
-// UserSession armazena o estado da conversa com um usuário
-// Permite manter contexto entre mensagens
-type UserSession struct {
-	// PhoneNumber é o número do usuário (chave)
-	PhoneNumber string `json:"phone_number"`
-
-	// UserID é o ID do usuário no Famli (se vinculado)
-	UserID string `json:"user_id,omitempty"`
-
-	// State é o estado atual da conversa
-	// Valores: "idle", "awaiting_title", "awaiting_category", "awaiting_confirmation"
-	State string `json:"state"`
-
-	// PendingItem armazena dados temporários de um item sendo criado
-	PendingItem *PendingBoxItem `json:"pending_item,omitempty"`
-
-	// LastMessageAt é quando a última mensagem foi recebida
-	LastMessageAt time.Time `json:"last_message_at"`
-
-	// CreatedAt é quando a sessão foi criada
-	CreatedAt time.Time `json:"created_at"`
-}
-
-// PendingBoxItem armazena dados de um item que está sendo criado via WhatsApp
-type PendingBoxItem struct {
-	// Content é o conteúdo principal (texto, URL da imagem, etc.)
-	Content string `json:"content"`
-
-	// Type é o tipo do item (info, memory, note, etc.)
-	Type string `json:"type"`
-
-	// Title é o título do item (pode ser gerado automaticamente)
-	Title string `json:"title"`
-
-	// Category é a categoria (saúde, finanças, família, etc.)
-	Category string `json:"category"`
-
-	// MediaUrl é a URL da mídia se houver
-	MediaUrl string `json:"media_url,omitempty"`
-
-	// MediaType é o tipo da mídia
-	MediaType string `json:"media_type,omitempty"`
-}
-
 // =============================================================================
 // CONFIGURAÇÃO
 // =============================================================================
@@ -185,40 +151,25 @@ type Config struct {
 	// Formato: whatsapp:+14155238886 (sandbox) ou seu número verificado
 	TwilioPhoneNumber string
 
+	// TwilioSMSNumber é o número usado para SMS (ex: +5511999999999)
+	// Opcional: se vazio, usa TwilioPhoneNumber sem o prefixo whatsapp:
+	TwilioSMSNumber string
+
 	// WebhookBaseURL é a URL base para webhooks (ex: https://famli.me)
 	WebhookBaseURL string
 
+	// AppURL é o endereço público do frontend, citado nas respostas
+	// (ex: https://famli.me → "famli.me/minha-caixa")
+	AppURL string
+
+	// SessionTimeout é o prazo de uma conversa sem mensagens; perguntas
+	// sem resposta viram rascunho (0 = conversation.DefaultSessionTimeout)
+	SessionTimeout time.Duration
+
 	// Enabled indica se a integração está ativa
 	Enabled bool
 }
 
-// =============================================================================
-// COMANDOS RECONHECIDOS
-// =============================================================================
-
-// Command representa um comando que o usuário pode enviar
-type Command string
-
-const (
-	// CommandHelp mostra a ajuda
-	CommandHelp Command = "ajuda"
-
-	// CommandSave inicia o processo de salvar algo
-	CommandSave Command = "guardar"
-
-	// CommandList lista os últimos itens salvos
-	CommandList Command = "listar"
-
-	// CommandCancel cancela a operação atual
-	CommandCancel Command = "cancelar"
-
-	// CommandStatus mostra o status da conta
-	CommandStatus Command = "status"
-
-	// CommandLink vincula o número a uma conta Famli
-	CommandLink Command = "vincular"
-)
-
 // =============================================================================
 // FUNÇÕES AUXILIARES
 // =============================================================================
@@ -13,11 +13,13 @@
 // Documentação:
 // - https://www.twilio.com/docs/whatsapp
 // - https://www.twilio.com/docs/whatsapp/sandbox
+// - https://www.twilio.com/docs/content (botões e listas)
 // =============================================================================
 
 package whatsapp
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -25,6 +27,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 )
 
 // =============================================================================
@@ -45,8 +48,19 @@ type TwilioClient struct {
 	// Formato: whatsapp:+14155238886 (sandbox) ou whatsapp:+5511999999999
 	fromNumber string
 
+	// smsFromNumber é o número usado para SMS (sem prefixo whatsapp:)
+	// Se vazio, usa o mesmo número do WhatsApp
+	smsFromNumber string
+
 	// httpClient é o cliente HTTP para fazer requisições
 	httpClient *http.Client
+
+	// contentSids guarda os modelos interativos já criados (ver interactive.go)
+	// Chave: hash da definição do modelo. Valor: Content SID (HX...)
+	contentSids map[string]string
+
+	// contentMu protege contentSids
+	contentMu sync.Mutex
 }
 
 // NewTwilioClient cria uma nova instância do cliente Twilio
@@ -60,10 +74,11 @@ type TwilioClient struct {
 //   - *TwilioClient: cliente configurado
 func NewTwilioClient(accountSid, authToken, fromNumber string) *TwilioClient {
 	return &TwilioClient{
-		accountSid: accountSid,
-		authToken:  authToken,
-		fromNumber: fromNumber,
-		httpClient: &http.Client{},
+		accountSid:  accountSid,
+		authToken:   authToken,
+		fromNumber:  fromNumber,
+		httpClient:  &http.Client{},
+		contentSids: make(map[string]string),
 	}
 }
 
@@ -174,6 +189,132 @@ func (c *TwilioClient) SendMessageWithMedia(to, body, mediaURL string) error {
 	return nil
 }
 
+// SetSMSNumber define o número remetente dos SMS (formato: +5511999999999)
+func (c *TwilioClient) SetSMSNumber(number string) {
+	c.smsFromNumber = strings.TrimPrefix(number, "whatsapp:")
+}
+
+// SendSMS envia um SMS comum, para destinatários sem WhatsApp
+//
+// Parâmetros:
+//   - to: número de destino (formato: +5511999999999)
+//   - body: texto da mensagem
+//
+// Retorna:
+//   - error: erro se houver falha no envio
+func (c *TwilioClient) SendSMS(to, body string) error {
+	to = strings.TrimPrefix(to, "whatsapp:")
+
+	from := c.smsFromNumber
+	if from == "" {
+		from = strings.TrimPrefix(c.fromNumber, "whatsapp:")
+	}
+
+	apiURL := fmt.Sprintf(
+		"https://api.twilio.com/2010-04-01/Accounts/%s/Messages.json",
+		c.accountSid,
+	)
+
+	data := url.Values{}
+	data.Set("To", to)
+	data.Set("From", from)
+	data.Set("Body", body)
+
+	req, err := http.NewRequest("POST", apiURL, strings.NewReader(data.Encode()))
+	if err != nil {
+		return fmt.Errorf("erro ao criar requisição: %w", err)
+	}
+	req.SetBasicAuth(c.accountSid, c.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("erro ao enviar SMS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		_, _ = io.ReadAll(resp.Body)
+		log.Printf("[Twilio] Erro na API (SMS): status=%d", resp.StatusCode)
+		return fmt.Errorf("erro da API Twilio: status %d", resp.StatusCode)
+	}
+
+	log.Printf("[Twilio] SMS enviado para %s", maskPhone(to))
+	return nil
+}
+
+// =============================================================================
+// DOWNLOAD DE MÍDIA
+// =============================================================================
+
+// maxMediaSize limita o tamanho das mídias baixadas (16 MB, limite do WhatsApp)
+const maxMediaSize = 16 << 20
+
+// FetchMedia baixa uma mídia recebida (MediaUrl0 do webhook)
+// As URLs de mídia do Twilio exigem autenticação da conta.
+//
+// Retorna:
+//   - []byte: conteúdo da mídia
+//   - string: tipo MIME informado pelo Twilio
+//   - error: erro se houver falha no download
+func (c *TwilioClient) FetchMedia(mediaURL string) ([]byte, string, error) {
+	if !strings.HasPrefix(mediaURL, "https://api.twilio.com/") {
+		return nil, "", fmt.Errorf("URL de mídia inválida")
+	}
+
+	req, err := http.NewRequest("GET", mediaURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("erro ao criar requisição: %w", err)
+	}
+	req.SetBasicAuth(c.accountSid, c.authToken)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("erro ao baixar mídia: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, "", fmt.Errorf("erro da API Twilio: status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxMediaSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("erro ao ler mídia: %w", err)
+	}
+	if len(data) > maxMediaSize {
+		return nil, "", fmt.Errorf("mídia excede %d bytes", maxMediaSize)
+	}
+
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+// Ping confere se a API do Twilio responde e aceita as credenciais
+// (consulta a própria conta; não envia mensagens)
+func (c *TwilioClient) Ping(ctx context.Context) error {
+	apiURL := fmt.Sprintf("https://api.twilio.com/2010-04-01/Accounts/%s.json", c.accountSid)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return fmt.Errorf("erro ao criar requisição: %w", err)
+	}
+	req.SetBasicAuth(c.accountSid, c.authToken)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("erro ao acessar a API Twilio: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("credenciais recusadas pela API Twilio: status %d", resp.StatusCode)
+	case resp.StatusCode >= 400:
+		return fmt.Errorf("erro da API Twilio: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
 // =============================================================================
 // VALIDAÇÃO DE WEBHOOK
 // =============================================================================
@@ -213,6 +354,7 @@ func (c *TwilioClient) ValidateWebhookSignature(signature, webhookURL string, pa
 //   - Body: conteúdo da mensagem
 //   - NumMedia: quantidade de arquivos anexados
 //   - MediaUrl0, MediaContentType0: dados da primeira mídia
+//   - ButtonPayload / ListId: opção escolhida em botão ou lista
 //
 // Parâmetros:
 //   - r: requisição HTTP do webhook
@@ -239,6 +381,10 @@ func ParseWebhookRequest(r *http.Request) (*IncomingMessage, error) {
 		Body:        r.FormValue("Body"),
 		NumMedia:    numMedia,
 		ProfileName: r.FormValue("ProfileName"),
+
+		// Respostas a botões e listas (mensagens interativas)
+		ButtonPayload: r.FormValue("ButtonPayload"),
+		ListID:        r.FormValue("ListId"),
 	}
 
 	// Se tem mídia, pegar a primeira
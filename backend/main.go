@@ -15,89 +15,103 @@
 // - Criptografia de dados sensíveis (A02)
 // - Auditoria de eventos (A09)
 //
-// Variáveis de ambiente:
+// Variáveis de ambiente (lidas e validadas pelo pacote config):
 // - PORT: porta do servidor (padrão: 8080)
-// - STATIC_DIR: diretório do frontend buildado
+// - STATIC_DIR: diretório do frontend buildado (ignorado no build -tags embed)
 // - JWT_SECRET: segredo para tokens JWT (mínimo 32 caracteres em produção)
 // - ENCRYPTION_KEY: chave para criptografar dados sensíveis
 // - ENV: ambiente (development, production)
 // - TWILIO_*: configurações do WhatsApp
+// - REDIS_URL: Redis para rate limiting distribuído (opcional)
+// - VAPID_*: chaves das notificações Web Push
+// A lista completa está em env.example.
+//
+// Flags:
+// - -migrate up|down|status: gerencia as migrações do banco e encerra
+// - -steps N: quantidade de migrações revertidas com -migrate down
+// - -vapid-keys: gera um par de chaves VAPID para Web Push e encerra
+// - -restore arquivo: restaura um backup (BACKUP_ENCRYPTION_KEY) e encerra
+// - -dry-run: com -restore, apenas verifica o arquivo, sem gravar
 // =============================================================================
 
 package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
-	"strconv"
-	"strings"
 	"syscall"
 	"time"
 
-	"github.com/go-chi/chi/v5"
-	chimiddleware "github.com/go-chi/chi/v5/middleware"
-	"github.com/go-chi/cors"
-
-	"famli/internal/admin"
-	"famli/internal/analytics"
-	"famli/internal/auth"
-	"famli/internal/box"
-	"famli/internal/feedback"
-	"famli/internal/guardian"
-	"famli/internal/guide"
-	"famli/internal/i18n"
-	"famli/internal/oauth"
+	"famli/internal/backup"
+	"famli/internal/config"
+	"famli/internal/notifications"
 	"famli/internal/security"
-	"famli/internal/settings"
-	"famli/internal/share"
+	"famli/internal/server"
 	"famli/internal/storage"
-	"famli/internal/whatsapp"
+	"famli/internal/web"
 )
 
 func main() {
 	// =========================================================================
-	// CONFIGURAÇÃO
+	// FLAGS DE LINHA DE COMANDO
 	// =========================================================================
 
-	// Ambiente
-	env := getenv("ENV", "development")
-	isDev := env == "development"
-
-	// Variáveis de ambiente com valores padrão para desenvolvimento
-	port := getenv("PORT", "8080")
-	staticDir := getenv("STATIC_DIR", filepath.Join("..", "frontend", "dist"))
-	jwtSecret := getenv("JWT_SECRET", "famli-dev-secret-change-in-production")
-	encryptionKey := getenv("ENCRYPTION_KEY", "famli-encryption-key-change-in-prod")
-
-	// Validar segredo JWT em produção
-	if !isDev && len(jwtSecret) < 32 {
-		log.Fatal("❌ JWT_SECRET deve ter pelo menos 32 caracteres em produção")
+	migrateCmd := flag.String("migrate", "", "executa migrações do banco e encerra: up, down ou status")
+	migrateSteps := flag.Int("steps", 1, "quantidade de migrações revertidas com -migrate down")
+	vapidKeys := flag.Bool("vapid-keys", false, "gera um par de chaves VAPID para Web Push e encerra")
+	restoreFile := flag.String("restore", "", "restaura um arquivo de backup no banco e encerra")
+	dryRun := flag.Bool("dry-run", false, "com -restore, apenas verifica o arquivo sem gravar")
+	flag.Parse()
+
+	// Configuração lida uma única vez (validada abaixo, após os comandos)
+	cfg, cfgErr := config.Load()
+
+	if *migrateCmd != "" {
+		runMigrateCommand(cfg.DatabaseURL, *migrateCmd, *migrateSteps)
+		return
 	}
 
-	// Configuração do WhatsApp/Twilio
-	whatsappConfig := &whatsapp.Config{
-		TwilioAccountSid:  getenv("TWILIO_ACCOUNT_SID", ""),
-		TwilioAuthToken:   getenv("TWILIO_AUTH_TOKEN", ""),
-		TwilioPhoneNumber: getenv("TWILIO_PHONE_NUMBER", ""),
-		WebhookBaseURL:    getenv("WEBHOOK_BASE_URL", "http://localhost:8080"),
-		Enabled:           getenv("TWILIO_ACCOUNT_SID", "") != "",
+	if *restoreFile != "" {
+		runRestoreCommand(cfg, *restoreFile, *dryRun)
+		return
 	}
 
-	// Configuração do OAuth (Google, Apple)
-	oauthConfig := &oauth.Config{
-		GoogleClientID:  getenv("GOOGLE_CLIENT_ID", ""),
-		AppleClientID:   getenv("APPLE_CLIENT_ID", ""),
-		AppleTeamID:     getenv("APPLE_TEAM_ID", ""),
-		AppleKeyID:      getenv("APPLE_KEY_ID", ""),
-		ApplePrivateKey: getenv("APPLE_PRIVATE_KEY", ""),
+	if *vapidKeys {
+		keys, privateKey, err := notifications.GenerateVAPIDKeys()
+		if err != nil {
+			log.Fatalf("❌ Erro ao gerar chaves VAPID: %v", err)
+		}
+		fmt.Printf("VAPID_PUBLIC_KEY=%s\nVAPID_PRIVATE_KEY=%s\n", keys.PublicKey, privateKey)
+		return
+	}
+
+	// =========================================================================
+	// CONFIGURAÇÃO
+	// =========================================================================
+
+	// Fora de desenvolvimento, configuração inválida interrompe a inicialização
+	if cfgErr != nil {
+		if !cfg.IsDevelopment() {
+			log.Fatalf("❌ %v", cfgErr)
+		}
+		log.Printf("⚠️  %v", cfgErr)
+	}
+	for _, warning := range cfg.Warnings() {
+		log.Printf("⚠️  %s", warning)
 	}
 
+	// Ambiente
+	env := cfg.Env
+
+	port := cfg.Port
+	staticDir := cfg.StaticDir
+
 	// =========================================================================
 	// LOG DE INICIALIZAÇÃO
 	// =========================================================================
@@ -106,16 +120,16 @@ func main() {
 	log.Printf("🏠 Famli - Ambiente: %s", env)
 	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 
-	if whatsappConfig.Enabled {
+	if cfg.WhatsAppEnabled() {
 		log.Println("📱 WhatsApp: habilitado")
 	} else {
 		log.Println("📱 WhatsApp: desabilitado")
 	}
 
-	if oauthConfig.GoogleClientID != "" {
+	if cfg.OAuth.GoogleClientID != "" {
 		log.Println("🔐 Google OAuth: habilitado")
 	}
-	if oauthConfig.AppleClientID != "" {
+	if cfg.OAuth.AppleClientID != "" {
 		log.Println("🍎 Apple Sign In: habilitado")
 	}
 
@@ -123,9 +137,13 @@ func main() {
 	// VERIFICAÇÃO DO FRONTEND
 	// =========================================================================
 
-	indexPath := filepath.Join(staticDir, "index.html")
+	// Build com -tags embed: o frontend vem dentro do binário e STATIC_DIR
+	// é ignorado. Sem a tag, o frontend é lido do disco (desenvolvimento).
+	embeddedFrontend, frontendEmbedded := web.Embedded()
 	frontendBuilt := true
-	if _, err := os.Stat(indexPath); os.IsNotExist(err) {
+	if frontendEmbedded {
+		log.Println("📦 Frontend: embutido no binário")
+	} else if _, err := os.Stat(filepath.Join(staticDir, "index.html")); os.IsNotExist(err) {
 		frontendBuilt = false
 		log.Printf("⚠️  Frontend não encontrado em %s", staticDir)
 	}
@@ -135,13 +153,12 @@ func main() {
 	// =========================================================================
 
 	// Verificar se há DATABASE_URL para usar PostgreSQL
-	databaseURL := getenv("DATABASE_URL", "")
 	var store storage.Store
 	var storageType string
 
-	if databaseURL != "" {
+	if cfg.DatabaseURL != "" {
 		// Usar PostgreSQL em produção
-		pgStore, err := storage.NewPostgresStore(databaseURL)
+		pgStore, err := storage.NewPostgresStore(cfg.DatabaseURL, cfg.EncryptionKey, cfg.EncryptionSalt)
 		if err != nil {
 			log.Fatalf("❌ Erro ao conectar ao PostgreSQL: %v", err)
 		}
@@ -156,34 +173,44 @@ func main() {
 		log.Println("💾 Storage: Memória (dados serão perdidos ao reiniciar)")
 	}
 
-	// Limpeza automática de logs antigos (economizar espaço)
-	retentionDays := getenvInt("LOG_RETENTION_DAYS", 30)
-	cleanupIntervalHours := getenvInt("LOG_CLEANUP_INTERVAL_HOURS", 24)
-	if err := store.CleanupOldLogs(retentionDays); err != nil {
-		log.Printf("⚠️  Erro na limpeza de logs: %v", err)
-	} else {
-		log.Printf("🧹 Logs antigos (>%d dias): limpos", retentionDays)
+	// Limpeza automática de tokens vencidos (os logs saem pelo job de
+	// retenção por classe, iniciado com o servidor em internal/retention)
+	cleanupIntervalHours := cfg.LogCleanupIntervalHours
+	if err := store.CleanupExpiredRecords(); err != nil {
+		log.Printf("⚠️  Erro na limpeza de registros vencidos: %v", err)
 	}
 	if err := store.CleanupExpiredPasswordResetTokens(); err != nil {
 		log.Printf("⚠️  Erro na limpeza de tokens de senha: %v", err)
 	}
+	purgeGuardians := func() {
+		// Guardiões que pediram a remoção dos dados (LGPD), após a carência
+		before := time.Now().AddDate(0, 0, -cfg.Share.GuardianDeletionGraceDays)
+		purged, err := store.PurgeGuardiansPendingDeletion(before)
+		if err != nil {
+			log.Printf("⚠️  Erro na remoção de guardiões: %v", err)
+		} else if purged > 0 {
+			log.Printf("🧹 Guardiões com remoção pedida (>%d dias): %d apagados", cfg.Share.GuardianDeletionGraceDays, purged)
+		}
+	}
+	purgeGuardians()
 	if cleanupIntervalHours > 0 {
 		go func() {
 			ticker := time.NewTicker(time.Duration(cleanupIntervalHours) * time.Hour)
 			defer ticker.Stop()
 			for range ticker.C {
-				if err := store.CleanupOldLogs(retentionDays); err != nil {
-					log.Printf("⚠️  Erro na limpeza periódica de logs: %v", err)
+				if err := store.CleanupExpiredRecords(); err != nil {
+					log.Printf("⚠️  Erro na limpeza periódica de registros vencidos: %v", err)
 				}
 				if err := store.CleanupExpiredPasswordResetTokens(); err != nil {
 					log.Printf("⚠️  Erro na limpeza periódica de tokens de senha: %v", err)
 				}
+				purgeGuardians()
 			}
 		}()
 	}
 
 	// Encryptor para dados sensíveis
-	encryptor, err := security.NewEncryptor(encryptionKey)
+	encryptor, err := security.NewEncryptor(cfg.EncryptionKey)
 	if err != nil {
 		log.Printf("⚠️  Criptografia não configurada: %v", err)
 	} else {
@@ -191,290 +218,47 @@ func main() {
 		_ = encryptor // TODO: Usar encryptor no box handler para dados sensíveis
 	}
 
-	// Handlers organizados por domínio
-	authHandler := auth.NewHandler(store, jwtSecret)
-	boxHandler := box.NewHandler(store)
-	guardianHandler := guardian.NewHandler(store)
-	guideHandler := guide.NewHandler(store)
-	settingsHandler := settings.NewHandler(store)
-	adminHandler := admin.NewHandler(store, storageType)
-	feedbackHandler := feedback.NewHandler(store)
-	analyticsHandler := analytics.NewHandler(store)
-	oauthHandler := oauth.NewHandler(store, jwtSecret, oauthConfig)
-	shareHandler := share.NewHandler(store)
-
-	// Serviço e handler do WhatsApp
-	whatsappService := whatsapp.NewService(store, whatsappConfig)
-	whatsappHandler := whatsapp.NewHandler(whatsappService, whatsappConfig)
-
-	// Rate limiters
-	apiLimiter := security.NewRateLimiter(security.APIRateLimit)
-	webhookLimiter := security.NewRateLimiter(security.WebhookRateLimit)
-
-	// =========================================================================
-	// CONFIGURAÇÃO DO ROUTER
-	// =========================================================================
-
-	r := chi.NewRouter()
-
-	// ─────────────────────────────────────────────────────────────────────────
-	// MIDDLEWARES GLOBAIS
-	// ─────────────────────────────────────────────────────────────────────────
-
-	// Request ID para rastreamento
-	r.Use(chimiddleware.RequestID)
-
-	// IP real do cliente (quando atrás de proxy)
-	r.Use(chimiddleware.RealIP)
-
-	// Logger de requisições (com redação de tokens)
-	r.Use(security.RedactingLogger())
-
-	// Recuperar de panics
-	r.Use(chimiddleware.Recoverer)
-
-	// Headers de segurança (OWASP A05)
-	var headersConfig security.SecurityHeadersConfig
-	if isDev {
-		headersConfig = security.DevelopmentSecurityHeadersConfig()
-	} else {
-		headersConfig = security.DefaultSecurityHeadersConfig()
+	// Backend de rate limiting (Redis para múltiplas réplicas, memória por padrão)
+	// Precisa ser configurado antes dos handlers, que criam seus próprios limiters
+	if err := security.ConfigureRateLimitBackend(cfg.RedisURL); err != nil {
+		log.Printf("⚠️  Rate limit distribuído indisponível, usando memória: %v", err)
 	}
-	r.Use(security.HeadersMiddleware(headersConfig))
-
-	// CORS - Cross-Origin Resource Sharing
-	allowedOrigins := []string{"http://localhost:5173", "http://localhost:8080"}
-	if !isDev {
-		allowedOrigins = append(allowedOrigins, "https://famli.me", "https://www.famli.me")
+	log.Printf("🚦 Rate limit: %s", security.RateLimitBackendName())
+
+	// Geolocalização por IP (acessos a links compartilhados e auditoria)
+	if cfg.GeoIPDBPath != "" {
+		if geo, err := security.OpenGeoIP(cfg.GeoIPDBPath); err != nil {
+			log.Printf("⚠️  GeoIP indisponível: %v", err)
+		} else {
+			security.SetGeoIP(geo)
+			log.Println("🌎 GeoIP: habilitado")
+		}
 	}
 
-	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   allowedOrigins,
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "Accept-Language"},
-		AllowCredentials: true,
-		MaxAge:           300,
-	}))
-
-	// =========================================================================
-	// ROTAS DA API
-	// =========================================================================
-
-	r.Route("/api", func(api chi.Router) {
-		// Rate limiting para API (OWASP A04)
-		api.Use(apiLimiter.Middleware(security.GetClientIP))
-
-		// ─────────────────────────────────────────────────────────────────────
-		// ROTAS PÚBLICAS (sem autenticação)
-		// ─────────────────────────────────────────────────────────────────────
-
-		// Health check público (para load balancers)
-		api.Get("/health", adminHandler.PublicHealth)
-
-		// Autenticação (rate limit adicional no handler)
-		api.Post("/auth/register", authHandler.Register)
-		api.Post("/auth/login", authHandler.Login)
-
-		// Recuperação de senha
-		api.Post("/auth/forgot-password", authHandler.ForgotPassword)
-		api.Post("/auth/reset-password", authHandler.ResetPassword)
-
-		// OAuth - Login Social (Google, Apple)
-		api.Post("/auth/oauth/google", oauthHandler.Google)
-		api.Post("/auth/oauth/apple", oauthHandler.Apple)
-		api.Get("/auth/oauth/status", oauthHandler.Status)
-
-		// Webhook do WhatsApp (chamado pelo Twilio)
-		api.Group(func(wh chi.Router) {
-			wh.Use(webhookLimiter.Middleware(security.GetClientIP))
-			wh.Get("/whatsapp/webhook", whatsappHandler.WebhookVerify)
-			wh.Post("/whatsapp/webhook", whatsappHandler.Webhook)
-		})
-
-		// Status da integração WhatsApp
-		api.Get("/whatsapp/status", whatsappHandler.Status)
-
-		// ─────────────────────────────────────────────────────────────────────
-		// ROTAS PROTEGIDAS (requerem autenticação JWT)
-		// ─────────────────────────────────────────────────────────────────────
-
-		api.Group(func(pr chi.Router) {
-			// Middleware de autenticação JWT
-			pr.Use(auth.JWTMiddleware(jwtSecret))
-			// CSRF - validar origem para requisições mutantes
-			pr.Use(security.CSRFMiddleware(allowedOrigins, isDev))
-
-			// Autenticação
-			pr.Get("/auth/me", authHandler.Me)
-			pr.Post("/auth/logout", authHandler.Logout)
-
-			// LGPD - Direitos do Titular
-			pr.Delete("/auth/account", authHandler.DeleteAccount) // Direito ao esquecimento
-			pr.Get("/auth/export", authHandler.ExportData)        // Direito à portabilidade
-
-			// Caixa Famli
-			pr.Get("/box/items", boxHandler.List)
-			pr.Post("/box/items", boxHandler.Create)
-			pr.Put("/box/items/{itemID}", boxHandler.Update)
-			pr.Delete("/box/items/{itemID}", boxHandler.Delete)
-
-			// Guardiões
-			pr.Get("/guardians", guardianHandler.List)
-			pr.Post("/guardians", guardianHandler.Create)
-			pr.Put("/guardians/{guardianID}", guardianHandler.Update)
-			pr.Delete("/guardians/{guardianID}", guardianHandler.Delete)
-
-			// Guia Famli
-			pr.Get("/guide/cards", guideHandler.ListCards)
-			pr.Get("/guide/progress", guideHandler.GetProgress)
-			pr.Post("/guide/progress/{cardID}", guideHandler.MarkCardProgress)
-
-			// Configurações
-			pr.Get("/settings", settingsHandler.Get)
-			pr.Put("/settings", settingsHandler.Update)
-
-			// Assistente
-			pr.Post("/assistant", boxHandler.Assistant)
-
-			// WhatsApp (vincular/desvincular)
-			pr.Post("/whatsapp/link", whatsappHandler.Link)
-			pr.Delete("/whatsapp/link", whatsappHandler.Unlink)
-
-			// Feedback - Usuários podem enviar feedback
-			pr.Post("/feedback", feedbackHandler.Create)
-
-			// Analytics - Rastreamento de eventos
-			pr.Post("/analytics/track", analyticsHandler.Track)
-
-			// Share - Gerenciar links de compartilhamento
-			pr.Post("/share/links", shareHandler.CreateLink)
-			pr.Get("/share/links", shareHandler.ListLinks)
-			pr.Delete("/share/links/{id}", shareHandler.DeleteLink)
-		})
-
-		// ─────────────────────────────────────────────────────────────────────
-		// ROTAS PÚBLICAS DE COMPARTILHAMENTO (não requerem autenticação)
-		// ─────────────────────────────────────────────────────────────────────
-
-		api.Route("/shared", func(sr chi.Router) {
-			// Rate limit para prevenir brute force em PINs
-			sr.Use(apiLimiter.Middleware(security.GetClientIP))
-
-			// Acessar conteúdo compartilhado
-			sr.Get("/{token}", shareHandler.AccessShared)
-			// Verificar PIN e acessar
-			sr.Post("/{token}/verify", shareHandler.VerifyPIN)
-		})
-
-		// ─────────────────────────────────────────────────────────────────────
-		// ROTA DE ACESSO DO GUARDIÃO (nova arquitetura integrada)
-		// ─────────────────────────────────────────────────────────────────────
-		api.Route("/guardian-access", func(sr chi.Router) {
-			sr.Use(apiLimiter.Middleware(security.GetClientIP))
-			sr.Get("/{token}", shareHandler.AccessGuardianView)
-			sr.Post("/{token}/verify", shareHandler.VerifyGuardianPIN)
-		})
-
-		// ─────────────────────────────────────────────────────────────────────
-		// ROTAS ADMINISTRATIVAS (requerem autenticação JWT + permissão admin)
-		// ─────────────────────────────────────────────────────────────────────
-
-		api.Route("/admin", func(ar chi.Router) {
-			// Autenticação JWT obrigatória
-			ar.Use(auth.JWTMiddleware(jwtSecret))
-			// CSRF - validar origem para requisições mutantes
-			ar.Use(security.CSRFMiddleware(allowedOrigins, isDev))
-			// Verificação de permissão admin
-			ar.Use(adminHandler.AdminOnly)
-
-			// Dashboard com métricas
-			ar.Get("/dashboard", adminHandler.Dashboard)
-			// Health check detalhado
-			ar.Get("/health", adminHandler.Health)
-			// Lista de usuários
-			ar.Get("/users", adminHandler.Users)
-			// Atividade recente
-			ar.Get("/activity", adminHandler.Activity)
-
-			// Feedbacks - Gerenciamento de feedbacks dos usuários
-			ar.Get("/feedbacks", feedbackHandler.List)
-			ar.Get("/feedbacks/stats", feedbackHandler.GetStats)
-			ar.Patch("/feedbacks/{id}", feedbackHandler.Update)
-
-			// Analytics - Métricas de uso da aplicação
-			ar.Get("/analytics/summary", analyticsHandler.GetSummary)
-			ar.Get("/analytics/events", analyticsHandler.GetRecentEvents)
-			ar.Get("/analytics/daily", analyticsHandler.GetDailyStats)
-		})
-	})
+	// Serviços, handlers e rotas da API
+	srv := server.New(cfg, store, storageType)
+	srv.Start(context.Background())
+	r := srv.Router
 
 	// =========================================================================
 	// SERVIR FRONTEND (SPA)
 	// =========================================================================
 
 	if frontendBuilt {
-		fileServer := http.FileServer(http.Dir(staticDir))
-		indexPath := filepath.Join(staticDir, "index.html")
-
-		// Ler o index.html uma vez
-		indexHTML, err := os.ReadFile(indexPath)
+		// index.html localizado em cache por idioma, assets com cache longo,
+		// versões pré-comprimidas (ver internal/web) e prévia nas páginas dos
+		// links compartilhados
+		var spa *web.SPA
+		var err error
+		if frontendEmbedded {
+			spa, err = web.NewSPAFS(embeddedFrontend)
+		} else {
+			spa, err = web.NewSPA(staticDir)
+		}
 		if err != nil {
-			log.Printf("⚠️  Erro ao ler index.html: %v", err)
+			log.Fatalf("❌ Erro ao ler index.html: %v", err)
 		}
-
-		r.Handle("/*", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-			urlPath := req.URL.Path
-			filePath := filepath.Join(staticDir, urlPath)
-
-			setStaticCacheHeaders := func(path string, isHTML bool) {
-				switch {
-				case isHTML:
-					w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate")
-				case path == "/sw.js" || path == "/service-worker.js" || strings.HasSuffix(path, "manifest.webmanifest") || path == "/version.json":
-					w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate")
-				case strings.HasPrefix(path, "/assets/"):
-					w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
-				default:
-					w.Header().Set("Cache-Control", "public, max-age=86400")
-				}
-			}
-
-			// Verificar se é uma rota de página (não um arquivo estático)
-			// Se termina em / ou não tem extensão, é uma rota de página SPA
-			isPageRoute := urlPath == "/" ||
-				(!strings.Contains(filepath.Base(urlPath), ".") &&
-					!strings.HasPrefix(urlPath, "/assets/") &&
-					!strings.HasPrefix(urlPath, "/icons/"))
-
-			// Se é uma rota de página, servir index.html com meta tags localizadas
-			if isPageRoute {
-				// Detectar idioma preferido
-				lang := i18n.GetPreferredLanguage(req)
-
-				// Injetar meta tags no idioma correto
-				localizedHTML := i18n.InjectMetaTags(string(indexHTML), lang)
-
-				w.Header().Set("Content-Type", "text/html; charset=utf-8")
-				setStaticCacheHeaders(urlPath, true)
-				io.WriteString(w, localizedHTML)
-				return
-			}
-
-			// Para arquivos estáticos, verificar se existe
-			if _, err := os.Stat(filePath); os.IsNotExist(err) {
-				// Arquivo não existe, servir index.html (SPA fallback)
-				lang := i18n.GetPreferredLanguage(req)
-				localizedHTML := i18n.InjectMetaTags(string(indexHTML), lang)
-				w.Header().Set("Content-Type", "text/html; charset=utf-8")
-				setStaticCacheHeaders(urlPath, true)
-				io.WriteString(w, localizedHTML)
-				return
-			}
-
-			// Servir arquivo estático
-			setStaticCacheHeaders(urlPath, false)
-			fileServer.ServeHTTP(w, req)
-		}))
+		srv.MountFrontend(spa)
 	} else {
 		r.Handle("/*", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Content-Type", "text/html; charset=utf-8")
@@ -511,6 +295,8 @@ func main() {
 		if err := server.Shutdown(ctx); err != nil {
 			log.Printf("⚠️  Erro ao encerrar servidor: %v", err)
 		}
+		// Gravar os eventos de analytics que ficaram na fila
+		srv.Stop(ctx)
 		close(shutdownDone)
 	}()
 
@@ -524,20 +310,85 @@ func main() {
 // FUNÇÕES AUXILIARES
 // =============================================================================
 
-func getenv(key, fallback string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// runMigrateCommand executa a flag -migrate (up, down, status) e encerra
+func runMigrateCommand(databaseURL, command string, steps int) {
+	if databaseURL == "" {
+		log.Fatal("❌ DATABASE_URL é obrigatório para -migrate")
+	}
+
+	migrator, err := storage.OpenMigrator(databaseURL)
+	if err != nil {
+		log.Fatalf("❌ Erro ao conectar ao PostgreSQL: %v", err)
+	}
+	defer migrator.Close()
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up()
+		if err != nil {
+			log.Fatalf("❌ Erro nas migrações: %v", err)
+		}
+		log.Printf("✅ Migrações aplicadas: %d", applied)
+
+	case "down":
+		reverted, err := migrator.Down(steps)
+		if err != nil {
+			log.Fatalf("❌ Erro ao reverter migrações: %v", err)
+		}
+		log.Printf("↩️  Migrações revertidas: %d", reverted)
+
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatalf("❌ Erro ao consultar migrações: %v", err)
+		}
+		for _, st := range statuses {
+			if st.Applied {
+				fmt.Printf("[x] %04d_%s (%s)\n", st.Version, st.Name, st.AppliedAt.Format(time.RFC3339))
+			} else {
+				fmt.Printf("[ ] %04d_%s\n", st.Version, st.Name)
+			}
+		}
+
+	default:
+		log.Fatalf("❌ Comando de migração inválido: %s (use up, down ou status)", command)
 	}
-	return fallback
 }
 
-func getenvInt(key string, fallback int) int {
-	if value := os.Getenv(key); value != "" {
-		if parsed, err := strconv.Atoi(value); err == nil {
-			return parsed
+// runRestoreCommand executa a flag -restore e encerra
+// O arquivo inteiro é verificado antes de qualquer gravação.
+func runRestoreCommand(cfg *config.Config, file string, dryRun bool) {
+	if cfg.Backup.EncryptionKey == "" {
+		log.Fatal("❌ BACKUP_ENCRYPTION_KEY é obrigatório para -restore")
+	}
+
+	var store storage.Store
+	if !dryRun {
+		if cfg.DatabaseURL == "" {
+			log.Fatal("❌ DATABASE_URL é obrigatório para -restore (use -dry-run para apenas verificar)")
 		}
+		pgStore, err := storage.NewPostgresStore(cfg.DatabaseURL, cfg.EncryptionKey, cfg.EncryptionSalt)
+		if err != nil {
+			log.Fatalf("❌ Erro ao conectar ao PostgreSQL: %v", err)
+		}
+		store = pgStore
+	}
+
+	report, err := backup.Restore(store, file, cfg.Backup.EncryptionKey, dryRun)
+	if err != nil {
+		if report != nil {
+			log.Printf("↩️  Contas restauradas antes do erro: %d (já existentes: %d)", report.Restored, report.Skipped)
+		}
+		log.Fatalf("❌ Erro ao restaurar backup: %v", err)
+	}
+
+	log.Printf("✅ Backup válido, gerado em %s: %d contas, %d itens, %d guardiões, %d links",
+		report.CreatedAt.Format(time.RFC3339), report.Stats.Users, report.Stats.Items, report.Stats.Guardians, report.Stats.ShareLinks)
+	if dryRun {
+		log.Println("🔍 -dry-run: nada foi gravado")
+		return
 	}
-	return fallback
+	log.Printf("✅ Contas restauradas: %d (já existentes, puladas: %d)", report.Restored, report.Skipped)
 }
 
 // =============================================================================
@@ -0,0 +1,38 @@
+// =============================================================================
+// FAMLI - Service Worker de Notificações Push
+// =============================================================================
+// Registrado com escopo próprio (/push/) apenas para receber Web Push.
+// Não faz cache: o service worker do PWA (sw.js) continua independente.
+// =============================================================================
+
+self.addEventListener('push', (event) => {
+  let data = {}
+  try {
+    data = event.data ? event.data.json() : {}
+  } catch (e) {
+    data = { title: 'Famli', body: event.data ? event.data.text() : '' }
+  }
+
+  event.waitUntil(
+    self.registration.showNotification(data.title || 'Famli', {
+      body: data.body || '',
+      tag: data.tag,
+      icon: '/icons/icon-192x192.png',
+      badge: '/icons/icon-72x72.png',
+      data: { url: data.url || '/' }
+    })
+  )
+})
+
+self.addEventListener('notificationclick', (event) => {
+  event.notification.close()
+  const url = new URL(event.notification.data?.url || '/', self.location.origin).href
+
+  event.waitUntil(
+    self.clients.matchAll({ type: 'window', includeUncontrolled: true }).then((windows) => {
+      const existing = windows.find((w) => w.url.startsWith(self.location.origin))
+      if (existing) return existing.focus()
+      return self.clients.openWindow(url)
+    })
+  )
+})
@@ -19,6 +19,7 @@ const routePaths = {
     dashboard: '/minha-caixa',
     admin: '/administracao',
     profile: '/perfil',
+    guardianPortal: '/compartilhados-comigo',
     terms: '/termos',
     privacy: '/privacidade'
   },
@@ -28,6 +29,7 @@ const routePaths = {
     dashboard: '/my-box',
     admin: '/admin',
     profile: '/profile',
+    guardianPortal: '/shared-with-me',
     terms: '/terms',
     privacy: '/privacy'
   }
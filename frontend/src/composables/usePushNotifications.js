@@ -0,0 +1,137 @@
+// =============================================================================
+// FAMLI - usePushNotifications Composable
+// =============================================================================
+// Inscreve/cancela este navegador para receber notificações Web Push.
+//
+// Fluxo:
+// 1. GET /api/notifications/vapid-key (chave pública do servidor)
+// 2. Registra /push-sw.js (escopo /push/) e pede permissão ao usuário
+// 3. Envia a inscrição para POST /api/notifications/subscribe
+//
+// Uso:
+// const { supported, subscribed, refresh, subscribe, unsubscribe } = usePushNotifications()
+// =============================================================================
+
+import { ref } from 'vue'
+
+const SW_URL = '/push-sw.js'
+const SW_SCOPE = '/push/'
+
+/**
+ * Converte a chave base64url do servidor para o formato do PushManager
+ */
+function urlBase64ToUint8Array(base64String) {
+  const padding = '='.repeat((4 - (base64String.length % 4)) % 4)
+  const base64 = (base64String + padding).replace(/-/g, '+').replace(/_/g, '/')
+  const raw = atob(base64)
+  return Uint8Array.from([...raw].map((c) => c.charCodeAt(0)))
+}
+
+/**
+ * Aguarda o worker ativar (navigator.serviceWorker.ready não serve: a página
+ * não está no escopo /push/)
+ */
+function waitUntilActive(registration) {
+  if (registration.active) return Promise.resolve()
+  const worker = registration.installing || registration.waiting
+  return new Promise((resolve) => {
+    worker.addEventListener('statechange', () => {
+      if (worker.state === 'activated') resolve()
+    })
+  })
+}
+
+export function usePushNotifications() {
+  const supported = ref(
+    typeof window !== 'undefined' &&
+      'serviceWorker' in navigator &&
+      'PushManager' in window &&
+      'Notification' in window
+  )
+  const available = ref(false)
+  const subscribed = ref(false)
+  const categories = ref([])
+  const busy = ref(false)
+
+  async function getRegistration() {
+    return navigator.serviceWorker.getRegistration(SW_SCOPE)
+  }
+
+  /**
+   * Carrega a configuração do servidor e o estado deste navegador
+   */
+  async function refresh() {
+    try {
+      const res = await fetch('/api/notifications/vapid-key', { credentials: 'include' })
+      if (!res.ok) return
+      const data = await res.json()
+      available.value = !!data.enabled
+      categories.value = data.categories || []
+
+      if (!supported.value) return
+      const registration = await getRegistration()
+      const subscription = registration && (await registration.pushManager.getSubscription())
+      subscribed.value = !!subscription
+    } catch (e) {
+      // Sem notificações push
+    }
+  }
+
+  async function subscribe() {
+    if (!supported.value || busy.value) return false
+    busy.value = true
+    try {
+      const keyRes = await fetch('/api/notifications/vapid-key', { credentials: 'include' })
+      const { enabled, public_key: publicKey } = await keyRes.json()
+      if (!enabled || !publicKey) return false
+
+      const permission = await Notification.requestPermission()
+      if (permission !== 'granted') return false
+
+      const registration = await navigator.serviceWorker.register(SW_URL, { scope: SW_SCOPE })
+      await waitUntilActive(registration)
+      const subscription = await registration.pushManager.subscribe({
+        userVisibleOnly: true,
+        applicationServerKey: urlBase64ToUint8Array(publicKey)
+      })
+
+      const res = await fetch('/api/notifications/subscribe', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        credentials: 'include',
+        body: JSON.stringify(subscription.toJSON())
+      })
+      subscribed.value = res.ok
+      return res.ok
+    } catch (e) {
+      return false
+    } finally {
+      busy.value = false
+    }
+  }
+
+  async function unsubscribe() {
+    if (!supported.value || busy.value) return
+    busy.value = true
+    try {
+      const registration = await getRegistration()
+      const subscription = registration && (await registration.pushManager.getSubscription())
+      if (subscription) {
+        await fetch('/api/notifications/subscribe', {
+          method: 'DELETE',
+          headers: { 'Content-Type': 'application/json' },
+          credentials: 'include',
+          body: JSON.stringify({ endpoint: subscription.endpoint })
+        })
+        await subscription.unsubscribe()
+      }
+      subscribed.value = false
+    } catch (e) {
+      // Mantém o estado atual
+    } finally {
+      busy.value = false
+    }
+  }
+
+  return { supported, available, subscribed, categories, busy, refresh, subscribe, unsubscribe }
+}
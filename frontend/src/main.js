@@ -16,6 +16,7 @@ import TermsPage from './pages/TermsPage.vue'
 import PrivacyPolicyPage from './pages/PrivacyPolicyPage.vue'
 import SharedPage from './pages/SharedPage.vue'
 import ResetPasswordPage from './pages/ResetPasswordPage.vue'
+import GuardianPortalPage from './pages/GuardianPortalPage.vue'
 
 // Rotas com aliases para suportar múltiplos idiomas
 // URL principal em pt-BR, aliases em en
@@ -64,6 +65,14 @@ const routes = [
     name: 'privacy', 
     component: PrivacyPolicyPage 
   },
+  // Portal do guardião (caixas compartilhadas com a conta)
+  { 
+    path: '/compartilhados-comigo', 
+    alias: ['/shared-with-me'],
+    name: 'guardian-portal', 
+    component: GuardianPortalPage, 
+    meta: { requiresAuth: true } 
+  },
   // Página pública de compartilhamento (para guardiões)
   { 
     path: '/compartilhado/:token', 
@@ -110,7 +119,10 @@ if (import.meta.env.PROD && enableSW) {
   })
 } else if (import.meta.env.PROD && 'serviceWorker' in navigator) {
   navigator.serviceWorker.getRegistrations().then((regs) => {
-    regs.forEach((reg) => reg.unregister())
+    // Mantém o worker de notificações push (escopo próprio, sem cache)
+    regs
+      .filter((reg) => !reg.active?.scriptURL.endsWith('/push-sw.js'))
+      .forEach((reg) => reg.unregister())
   })
   if ('caches' in window) {
     caches.keys().then((keys) => Promise.all(keys.map((key) => caches.delete(key))))
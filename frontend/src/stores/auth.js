@@ -103,6 +103,10 @@ export const useAuthStore = defineStore('auth', () => {
   const error = ref('')
   const sessionCheckInProgress = ref(false)
   const lastSessionCheck = ref(0)
+  // Versões dos termos/política pendentes de aceite (null = nada pendente)
+  const legalPending = ref(null)
+  // Sessão do suporte: o admin vê a conta do usuário (somente leitura)
+  const supportSession = ref(false)
 
   const isAuthenticated = computed(() => !!user.value)
 
@@ -132,7 +136,9 @@ export const useAuthStore = defineStore('auth', () => {
       if (res.ok) {
         const data = await res.json()
         user.value = data.user
+        supportSession.value = !!data.support_session
         console.debug('[Auth] Sessão válida para:', data.user?.email)
+        checkLegal()
         return true
       }
 
@@ -174,7 +180,8 @@ export const useAuthStore = defineStore('auth', () => {
       const res = await fetchWithRetry('/api/auth/register', {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
-        body: JSON.stringify({ email, password, name })
+        // O formulário só envia com a caixa dos termos marcada
+        body: JSON.stringify({ email, password, name, accept_terms: true })
       })
 
       const data = await res.json()
@@ -213,6 +220,7 @@ export const useAuthStore = defineStore('auth', () => {
       }
 
       user.value = data.user
+      checkLegal()
       return true
     } catch (e) {
       error.value = translateError(null, 'auth.errors.connectionError')
@@ -232,9 +240,60 @@ export const useAuthStore = defineStore('auth', () => {
       // Ignorar erro de logout
     }
     user.value = null
+    legalPending.value = null
     lastSessionCheck.value = 0
   }
 
+  // Encerrar a sessão do suporte e voltar à conta do admin
+  async function endSupportSession() {
+    try {
+      await fetch('/api/auth/impersonation/end', {
+        method: 'POST',
+        credentials: 'include'
+      })
+    } catch (e) {
+      // Sem rede: o cookie do suporte expira sozinho (até 1h)
+    }
+    supportSession.value = false
+    lastSessionCheck.value = 0
+  }
+
+  // Verificar se há nova versão dos termos/política para aceitar
+  async function checkLegal() {
+    try {
+      const res = await fetch('/api/legal/status', { credentials: 'include' })
+      if (res.ok) {
+        const data = await res.json()
+        legalPending.value = data.pending ? data.current : null
+      }
+    } catch (e) {
+      // Sem rede: o backend responde 428 na próxima requisição
+    }
+  }
+
+  // Aceitar as versões vigentes dos termos e da política
+  async function acceptLegal() {
+    if (!legalPending.value) return true
+    try {
+      const res = await fetch('/api/legal/accept', {
+        method: 'POST',
+        credentials: 'include',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(legalPending.value)
+      })
+      if (res.status === 409) {
+        // Nova versão publicada enquanto o usuário lia
+        await checkLegal()
+        return false
+      }
+      if (!res.ok) return false
+      legalPending.value = null
+      return true
+    } catch (e) {
+      return false
+    }
+  }
+
   // Login via Google OAuth
   async function loginWithGoogle(idToken) {
     loading.value = true
@@ -255,6 +314,7 @@ export const useAuthStore = defineStore('auth', () => {
       }
 
       user.value = data.user
+      checkLegal()
       return true
     } catch (e) {
       error.value = translateError(null, 'auth.errors.connectionError')
@@ -284,6 +344,7 @@ export const useAuthStore = defineStore('auth', () => {
       }
 
       user.value = data.user
+      checkLegal()
       return true
     } catch (e) {
       error.value = translateError(null, 'auth.errors.connectionError')
@@ -304,6 +365,18 @@ export const useAuthStore = defineStore('auth', () => {
       credentials: 'include'
     })
 
+    // Termos ou política atualizados: pedir o aceite
+    if (res.status === 428) {
+      try {
+        const data = await res.clone().json()
+        if (data.code === 'LEGAL_ACCEPTANCE_REQUIRED') {
+          legalPending.value = data.details
+        }
+      } catch (e) {
+        // Ignorar corpo inválido
+      }
+    }
+
     // Se for erro de sessão, tratar
     if (res.status === 401) {
       try {
@@ -324,10 +397,15 @@ export const useAuthStore = defineStore('auth', () => {
     loading,
     error,
     isAuthenticated,
+    legalPending,
+    supportSession,
     checkSession,
+    checkLegal,
+    acceptLegal,
     register,
     login,
     logout,
+    endSupportSession,
     loginWithGoogle,
     loginWithApple,
     clearError,
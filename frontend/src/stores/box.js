@@ -156,18 +156,22 @@ export const useBoxStore = defineStore('box', () => {
 
   // Estado dos guardiões
   const guardians = ref([])
+
+  // Categorias definidas pelo usuário
+  const categories = ref([])
   
   // Estado geral
   const loading = ref(false)
   const loadingMore = ref(false)
   const error = ref('')
+  const conflictItem = ref(null) // Versão atual após um 412 no updateItem
 
   // Tamanho da página
   const PAGE_SIZE = 20
 
   // Contagem por tipo
   const counts = computed(() => {
-    const infos = items.value.filter(i => i.type === 'info' || i.type === 'location' || i.type === 'access' || i.type === 'routine').length
+    const infos = items.value.filter(i => i.type === 'info' || i.type === 'location' || i.type === 'access' || i.type === 'routine' || i.type === 'document').length
     const memories = items.value.filter(i => i.type === 'memory' || i.type === 'note').length
     const people = guardians.value.length
     return { infos, memories, people, total: infos + memories + people }
@@ -191,7 +195,9 @@ export const useBoxStore = defineStore('box', () => {
       content: g.relationship ? `${g.relationship} • ${g.email}` : g.email
     }))
 
+    // Itens fixados primeiro (como na ordenação da API)
     return [...itemEntries, ...guardianEntries].sort((a, b) => {
+      if (!!a.pinned !== !!b.pinned) return a.pinned ? -1 : 1
       const da = new Date(a.updated_at || a.created_at || 0).getTime()
       const db = new Date(b.updated_at || b.created_at || 0).getTime()
       return db - da
@@ -205,7 +211,8 @@ export const useBoxStore = defineStore('box', () => {
       note: '📝',
       access: '🔑',
       routine: '🔄',
-      location: '📍'
+      location: '📍',
+      document: '🪪'
     }
     return icons[type] || '📄'
   }
@@ -217,7 +224,8 @@ export const useBoxStore = defineStore('box', () => {
       note: 'Nota pessoal',
       access: 'Instruções de acesso',
       routine: 'Rotina',
-      location: 'Localização'
+      location: 'Localização',
+      document: 'Documento'
     }
     return labels[type] || 'Item'
   }
@@ -276,9 +284,21 @@ export const useBoxStore = defineStore('box', () => {
     }
   }
 
+  async function fetchCategories() {
+    try {
+      const res = await fetchWithRetry('/api/box/categories')
+      if (res.ok) {
+        const data = await res.json()
+        categories.value = data.categories || []
+      }
+    } catch (e) {
+      error.value = translateError('network error')
+    }
+  }
+
   async function fetchAll() {
     loading.value = true
-    await Promise.all([fetchItems(), fetchGuardians()])
+    await Promise.all([fetchItems(), fetchGuardians(), fetchCategories()])
     loading.value = false
   }
 
@@ -338,11 +358,15 @@ export const useBoxStore = defineStore('box', () => {
     return null
   }
 
-  async function updateItem(id, payload) {
+  // version: versão do item que foi editada (If-Match). Se o item mudou em
+  // outro lugar, a resposta é 412: conflictItem recebe a versão atual para a
+  // tela mostrar o aviso; salvar de novo com conflictItem.version sobrescreve.
+  async function updateItem(id, payload, version) {
+    conflictItem.value = null
     try {
       const res = await fetchWithRetry(`/api/box/items/${id}`, {
         method: 'PUT',
-        headers: { 'Content-Type': 'application/json' },
+        headers: { 'Content-Type': 'application/json', 'If-Match': version ? `"${version}"` : '*' },
         body: JSON.stringify(payload)
       })
       if (res.ok) {
@@ -351,6 +375,10 @@ export const useBoxStore = defineStore('box', () => {
         if (idx !== -1) items.value[idx] = updated
         error.value = ''
         return updated
+      } else if (res.status === 412) {
+        const data = await res.json().catch(() => null)
+        conflictItem.value = data?.details?.item || null
+        error.value = i18n.global.t('apiErrors.version_conflict')
       } else {
         const errorText = await readErrorMessage(res, 'server error')
         error.value = translateError(errorText)
@@ -361,6 +389,29 @@ export const useBoxStore = defineStore('box', () => {
     return null
   }
 
+  // Arquivado sai da listagem padrão (não é apagado)
+  async function archiveItem(id, version) {
+    try {
+      const res = await fetchWithRetry(`/api/box/items/${id}`, {
+        method: 'PATCH',
+        headers: { 'Content-Type': 'application/json', 'If-Match': version ? `"${version}"` : '*' },
+        body: JSON.stringify({ archived: true })
+      })
+      if (res.ok) {
+        items.value = items.value.filter(i => i.id !== id)
+        itemsTotal.value = Math.max(0, itemsTotal.value - 1)
+        error.value = ''
+        return true
+      } else {
+        const errorText = await readErrorMessage(res, 'server error')
+        error.value = translateError(errorText)
+      }
+    } catch (e) {
+      error.value = translateError('network error')
+    }
+    return false
+  }
+
   async function deleteItem(id) {
     try {
       const res = await fetchWithRetry(`/api/box/items/${id}`, {
@@ -438,13 +489,77 @@ export const useBoxStore = defineStore('box', () => {
     return false
   }
 
+  // Gera um novo link de acesso (o link antigo para de funcionar)
+  async function rotateGuardianToken(id) {
+    try {
+      const res = await fetchWithRetry(`/api/guardians/${id}/rotate-token`, {
+        method: 'POST'
+      })
+      if (res.ok) {
+        const guardian = await res.json()
+        guardians.value = guardians.value.map(g => (g.id === id ? { ...g, ...guardian } : g))
+        error.value = ''
+        return guardian
+      } else {
+        const errorText = await readErrorMessage(res, 'server error')
+        error.value = translateError(errorText)
+      }
+    } catch (e) {
+      error.value = translateError('network error')
+    }
+    return null
+  }
+
+  // Desativa ou reativa o acesso (link e portal) mantendo o cadastro
+  async function setGuardianAccess(id, enabled) {
+    try {
+      const action = enabled ? 'enable-access' : 'disable-access'
+      const res = await fetchWithRetry(`/api/guardians/${id}/${action}`, {
+        method: 'POST'
+      })
+      if (res.ok) {
+        const guardian = await res.json()
+        guardians.value = guardians.value.map(g => (g.id === id ? guardian : g))
+        error.value = ''
+        return guardian
+      } else {
+        const errorText = await readErrorMessage(res, 'server error')
+        error.value = translateError(errorText)
+      }
+    } catch (e) {
+      error.value = translateError('network error')
+    }
+    return null
+  }
+
+  async function setItemPinned(id, pinned) {
+    try {
+      const res = await fetchWithRetry(`/api/box/items/${id}/pin`, {
+        method: pinned ? 'PUT' : 'DELETE'
+      })
+      if (res.ok) {
+        items.value = items.value.map(i => (i.id === id ? { ...i, pinned } : i))
+        error.value = ''
+        return true
+      } else {
+        const errorText = await readErrorMessage(res, 'server error')
+        error.value = translateError(errorText)
+      }
+    } catch (e) {
+      error.value = translateError('network error')
+    }
+    return false
+  }
+
   return {
     // Estado
     items,
     guardians,
+    categories,
     loading,
     loadingMore,
     error,
+    conflictItem,
     itemsHasMore,
     itemsTotal,
     
@@ -455,13 +570,18 @@ export const useBoxStore = defineStore('box', () => {
     // Ações
     fetchItems,
     fetchGuardians,
+    fetchCategories,
     fetchAll,
     refresh,
     loadMoreItems,
     createItem,
     updateItem,
     deleteItem,
+    archiveItem,
+    setItemPinned,
     createGuardian,
-    deleteGuardian
+    deleteGuardian,
+    rotateGuardianToken,
+    setGuardianAccess
   }
 })
@@ -7,12 +7,16 @@
 // - Vue 3 com Single File Components
 // - PWA com Service Worker (auto update)
 // - Proxy para API em desenvolvimento
+// - Versões .br e .gz dos assets (servidas pelo backend, ver internal/web)
 // =============================================================================
 
 import { defineConfig } from 'vite'
 import vue from '@vitejs/plugin-vue'
 import { VitePWA } from 'vite-plugin-pwa'
 import { execSync } from 'node:child_process'
+import { readdirSync, readFileSync, writeFileSync } from 'node:fs'
+import { join, resolve } from 'node:path'
+import { brotliCompressSync, gzipSync, constants as zlib } from 'node:zlib'
 
 function buildVersion() {
   const buildTime = new Date().toISOString()
@@ -26,6 +30,41 @@ function buildVersion() {
   return { build, commit, buildTime }
 }
 
+// Gera arquivo.br e arquivo.gz ao lado de cada asset de texto, para o backend
+// servir sem comprimir a cada requisição
+const COMPRESSIBLE = /\.(js|css|svg|json|webmanifest)$/
+function precompressAssets(dir) {
+  for (const entry of readdirSync(dir, { withFileTypes: true })) {
+    const file = join(dir, entry.name)
+    if (entry.isDirectory()) {
+      precompressAssets(file)
+      continue
+    }
+    if (!COMPRESSIBLE.test(entry.name)) continue
+
+    const source = readFileSync(file)
+    if (source.length < 1024) continue
+    writeFileSync(file + '.br', brotliCompressSync(source, {
+      params: { [zlib.BROTLI_PARAM_QUALITY]: zlib.BROTLI_MAX_QUALITY }
+    }))
+    writeFileSync(file + '.gz', gzipSync(source, { level: 9 }))
+  }
+}
+
+function precompressPlugin() {
+  let assetsDir = ''
+  return {
+    name: 'precompress-assets',
+    apply: 'build',
+    configResolved(config) {
+      assetsDir = resolve(config.root, config.build.outDir, config.build.assetsDir)
+    },
+    closeBundle() {
+      precompressAssets(assetsDir)
+    }
+  }
+}
+
 export default defineConfig({
   plugins: [
     vue(),
@@ -41,6 +80,7 @@ export default defineConfig({
         })
       }
     },
+    precompressPlugin(),
     VitePWA({
       // =======================================================================
       // CONFIGURAÇÃO DO SERVICE WORKER
@@ -24,9 +24,9 @@ fi
 
 # Verificar se há admins configurados
 if [ -n "$ADMIN_EMAILS" ]; then
-    echo "   Admins: configurados"
+    echo "   Superadmin inicial: configurado"
 else
-    echo "   ⚠️  ADMIN_EMAILS não configurado"
+    echo "   ⚠️  ADMIN_EMAILS não configurado (necessário apenas para o primeiro superadmin)"
 fi
 
 exec "$ROOT_DIR/server"